@@ -22,13 +22,39 @@ func main() {
 	flag.IntVar(&config.Port, "port", config.Port, "Port to listen on")
 	flag.IntVar(&config.MaxConcurrentJobs, "max-concurrent", config.MaxConcurrentJobs, "Maximum concurrent crawl jobs")
 	flag.StringVar(&config.APIKey, "api-key", config.APIKey, "API key for authentication (optional)")
+	flag.StringVar(&config.APIKeysFile, "api-keys-file", config.APIKeysFile, "JSON file of named API keys with optional quotas")
+	flag.StringVar(&config.UsageFile, "usage-file", config.UsageFile, "File that persists per-key usage across restarts")
+	flag.StringVar(&config.AuditLogFile, "audit-log", config.AuditLogFile, "JSON Lines file recording every operation that changes server state (served by /api/v1/audit)")
+	flag.Int64Var(&config.AuditMaxSize, "audit-max-size", config.AuditMaxSize, "Rotate the audit log once it reaches this many bytes (0 = never)")
+	flag.IntVar(&config.AuditMaxFiles, "audit-max-files", config.AuditMaxFiles, "Rotated audit logs to keep")
+
+	flag.IntVar(&config.RateLimit, "rate-limit", config.RateLimit, "Requests per minute per API key, or per client IP without authentication (0 = unlimited)")
+	flag.IntVar(&config.RateBurst, "rate-burst", config.RateBurst, "Requests a client may make at once before -rate-limit applies (0 = the rate limit)")
+	flag.IntVar(&config.AuthFailureLimit, "auth-failure-limit", config.AuthFailureLimit, "Failed authentications per minute after which a client IP is refused (0 = unlimited)")
 
 	var corsOrigins string
 	flag.StringVar(&corsOrigins, "cors-origins", "", "Comma-separated list of allowed CORS origins")
 
+	flag.Int64Var(&config.MaxBodySize, "max-body-size", config.MaxBodySize, "Maximum request body size in bytes (0 = unlimited)")
 	flag.IntVar(&config.ReadTimeout, "read-timeout", config.ReadTimeout, "Read timeout in seconds")
 	flag.IntVar(&config.WriteTimeout, "write-timeout", config.WriteTimeout, "Write timeout in seconds")
 	flag.IntVar(&config.IdleTimeout, "idle-timeout", config.IdleTimeout, "Idle timeout in seconds")
+	flag.IntVar(&config.RetentionDays, "retention-days", config.RetentionDays, "Remove finished jobs after N days (0 = keep forever)")
+	flag.BoolVar(&config.RetentionPruneFiles, "retention-prune-files", config.RetentionPruneFiles, "Also delete output and state files of removed jobs")
+	flag.DurationVar(&config.HostDelay, "host-delay", config.HostDelay, "Minimum time between requests to the same host across all jobs (0 = per-job delays only)")
+	flag.BoolVar(&config.Polite, "polite", config.Polite, "Polite mode: always obey robots.txt, raise delays to -polite-min-delay, cap requests in flight per host, require a user agent with a URL or email, and refuse requests that ignore robots or rotate user agents")
+	flag.DurationVar(&config.PoliteMinDelay, "polite-min-delay", config.PoliteMinDelay, "Shortest delay between requests in polite mode")
+	flag.IntVar(&config.PoliteHostConcurrency, "polite-host-concurrency", config.PoliteHostConcurrency, "Requests in flight to one host across all jobs in polite mode (0 = unlimited)")
+	flag.StringVar(&config.PoliteUserAgent, "polite-user-agent", config.PoliteUserAgent, "User agent of jobs that set none in polite mode; must contain a URL or email address (default: the crawler's own)")
+	flag.StringVar(&config.PresetsDir, "presets-dir", config.PresetsDir, "Directory of the presets served by /api/v1/presets (default: the desktop app's presets)")
+	flag.StringVar(&config.SchedulesFile, "schedules-file", config.SchedulesFile, "File of the schedules served by /api/v1/schedules (default: the desktop app's schedules)")
+	flag.BoolVar(&config.RunSchedules, "run-schedules", config.RunSchedules, "Run the enabled schedules when due")
+
+	flag.BoolVar(&config.AllowCommands, "allow-commands", config.AllowCommands, "Let jobs run a post-save command (postSaveCommand) or browser binary (browserPath) on this server; anyone who can create jobs can then run any command")
+
+	flag.StringVar(&config.OutputOwner, "chown", config.OutputOwner, "Owner of all job output as 'uid[:gid]' (Unix only, requires running as root); requests cannot set one")
+
+	flag.BoolVar(&config.Debug, "debug", config.Debug, "Serve pprof on /debug/pprof/ and runtime statistics on /api/v1/debug/runtime (admin keys only, or local clients without authentication)")
 
 	flag.Parse()
 
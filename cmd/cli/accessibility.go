@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runAccessibility handles the "accessibility" subcommand, checking a crawl's
+// saved pages for accessibility issues and writing accessibility_report.html
+// and accessibility_report.json
+func runAccessibility(args []string) {
+	fs := flag.NewFlagSet("accessibility", flag.ExitOnError)
+	top := fs.Int("top", 10, "Number of pages with the most issues to list")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s accessibility [options] <output-dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	report, err := crawler.GenerateAccessibilityReport(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Audited %d pages, found %d issues on %d pages\n", report.Pages, report.TotalIssues, report.PagesAffected)
+	for _, issueType := range crawler.A11yIssueTypes {
+		if n := report.IssueCounts[issueType]; n > 0 {
+			fmt.Printf("  %-16s %d\n", issueType, n)
+		}
+	}
+	for i, page := range report.PageDetails {
+		if i >= *top || page.Issues == 0 {
+			break
+		}
+		if i == 0 {
+			fmt.Println("Pages with the most issues:")
+		}
+		fmt.Printf("  %4d  %s\n", page.Issues, page.URL)
+	}
+	fmt.Printf("Report written to %s and %s\n", report.HTMLPath, report.JSONPath)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runAlerts handles the "alerts" subcommand, printing the alert rule
+// matches recorded by a crawl
+func runAlerts(args []string) {
+	fs := flag.NewFlagSet("alerts", flag.ExitOnError)
+	rule := fs.String("rule", "", "Only list alerts of the rule with this name")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s alerts [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads the %s written by crawls run with -alerts\n", crawler.AlertsFile)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	alerts, err := crawler.LoadAlerts(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	alerts = crawler.FilterAlerts(alerts, *rule)
+
+	for _, alert := range alerts {
+		fmt.Printf("%s  %s  (%d matches)\n", alert.URL, alert.Rule, alert.Count)
+		for _, m := range alert.Matches {
+			fmt.Printf("    %q  ...%s...\n", m.Text, m.Context)
+		}
+	}
+	fmt.Printf("%d alerts\n", len(alerts))
+}
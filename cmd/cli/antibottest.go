@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runAntiBotTest handles the "antibot-test" subcommand, probing a page in
+// browser mode for the fingerprint signals that give the crawler away
+func runAntiBotTest(args []string) {
+	fs := flag.NewFlagSet("antibot-test", flag.ExitOnError)
+	var antiBot crawler.AntiBotConfig
+	url := fs.String("url", "", "Fingerprinting test page to probe (default a bundled page served on a local port)")
+	headless := fs.Bool("headless", true, "Run the browser in headless mode")
+	userAgent := fs.String("user-agent", "", "Custom User-Agent header (defaults to WebScraper/1.0)")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.StringVar(&antiBot.Profile, "antibot-profile", "", "Anti-bot profile to test: "+strings.Join(crawler.AntiBotProfiles(), ", "))
+	fs.BoolVar(&antiBot.HideWebdriver, "hide-webdriver", false, "Hide navigator.webdriver flag")
+	fs.BoolVar(&antiBot.SpoofPlugins, "spoof-plugins", false, "Inject realistic browser plugins")
+	fs.BoolVar(&antiBot.SpoofLanguages, "spoof-languages", false, "Set realistic navigator.languages")
+	fs.BoolVar(&antiBot.SpoofWebGL, "spoof-webgl", false, "Override WebGL vendor/renderer")
+	fs.BoolVar(&antiBot.RandomViewport, "random-viewport", false, "Use random viewport sizes")
+	fs.BoolVar(&antiBot.RotateUserAgent, "rotate-ua", false, "Use a user agent of the built-in pool")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s antibot-test [flags]\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Opens a page in browser mode with the anti-bot settings and reports which fingerprint signals leak\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := crawler.RunAntiBotTest(ctx, crawler.AntiBotTestOptions{
+		URL:       *url,
+		AntiBot:   antiBot,
+		Headless:  *headless,
+		UserAgent: *userAgent,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, s := range result.Signals {
+			if s.Leaks {
+				fmt.Printf("LEAK  %-10s %s\n      fix: %s\n", s.Name, s.Value, s.Fix)
+			} else {
+				fmt.Printf("OK    %-10s %s\n", s.Name, s.Value)
+			}
+		}
+		fmt.Printf("%d of %d signals leak with profile %s\n", result.Leaks, len(result.Signals), result.Profile)
+	}
+	if result.Leaks > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runCat handles the "cat" subcommand, printing a stored output file and
+// transparently decompressing .gz and .zst files
+func runCat(args []string) {
+	fs := flag.NewFlagSet("cat", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s cat <file>\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "The uncompressed name may be given for compressed files (e.g. page.html for page.html.zst)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rc, _, err := crawler.OpenOutputFile(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(os.Stdout, rc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runControl handles the "control" (or "ctl") subcommand, sending a command
+// to a crawl started with -control-socket
+func runControl(args []string) {
+	fs := flag.NewFlagSet("control", flag.ExitOnError)
+	socket := fs.String("socket", "", "Control socket path or localhost:port of the running crawl (its -control-socket)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s control|ctl -socket <path|localhost:port> <command> [args...]\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Commands: %s\n", strings.Join(crawler.ControlCommands, ", "))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *socket == "" || fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	reply, err := crawler.SendControlCommand(*socket, strings.Join(fs.Args(), " "))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(reply)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"scraper/internal/api"
+	"scraper/internal/crawler"
+)
+
+// applyDefinition loads a job definition and uses its settings for every
+// flag that was not given explicitly on the command line
+func applyDefinition(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	req, err := api.ParseJobDefinition(data)
+	if err != nil {
+		return err
+	}
+	return applyRequest(req, "definition")
+}
+
+// applyRequest uses the settings of a crawl request for every flag that was
+// not given explicitly on the command line. source names the request's
+// origin in errors.
+func applyRequest(req *api.CrawlRequest, source string) error {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for name, value := range definitionFlags(req) {
+		if explicit[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s in %s: %v", name, source, err)
+		}
+	}
+	return nil
+}
+
+// definitionFlags maps the settings of a crawl request to CLI flag values.
+// Unset fields are omitted so the flag defaults apply.
+func definitionFlags(req *api.CrawlRequest) map[string]string {
+	values := make(map[string]string)
+	setString := func(name, v string) {
+		if v != "" {
+			values[name] = v
+		}
+	}
+	setInt := func(name string, v int) {
+		if v != 0 {
+			values[name] = strconv.Itoa(v)
+		}
+	}
+	setFloat := func(name string, v float64) {
+		if v != 0 {
+			values[name] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	setBool := func(name string, v bool) {
+		if v {
+			values[name] = "true"
+		}
+	}
+
+	setString("url", req.URL)
+	setString("recipe", req.Recipe)
+	setInt("depth", req.MaxDepth)
+	setString("depth-mode", req.DepthMode)
+	setInt("max-pages", req.MaxPages)
+	setString("max-runtime", req.MaxRuntime)
+	setInt("max-consecutive-errors", req.MaxConsecutiveErrors)
+	setString("url-timeout", req.URLTimeout)
+	setString("stop-pattern", req.StopPattern)
+	setBool("concurrent", req.Concurrent)
+	setInt("workers", req.Workers)
+	setString("delay", req.Delay)
+	setString("output", req.OutputDir)
+	setString("state", req.StateFile)
+	setBool("state-merge", req.StateMerge)
+	setInt("frontier-limit", req.FrontierLimit)
+	setString("prefix-filter", req.PrefixFilterURL)
+	setString("exclude-extensions", strings.Join(req.ExcludeExtensions, ","))
+	setString("allowed-hosts", strings.Join(req.AllowedHosts, ","))
+	setString("denied-hosts", strings.Join(req.DeniedHosts, ","))
+	setBool("external-links", req.ExternalLinks)
+	setBool("report", req.Report)
+	setString("link-selectors", strings.Join(req.LinkSelectors, ","))
+	setBool("verbose", req.Verbose)
+	setString("user-agent", req.UserAgent)
+	setString("ua-pool", req.UserAgentPool)
+	if len(req.UserAgents) > 0 {
+		// -user-agents also accepts the list inline as a JSON array
+		if data, err := json.Marshal(req.UserAgents); err == nil {
+			values["user-agents"] = string(data)
+		}
+	}
+	setBool("sticky-ua", req.StickyUserAgent)
+	setBool("warm-up", req.WarmUp)
+	setInt("warm-up-pages", req.WarmUpPages)
+	setString("referer-policy", req.RefererPolicy)
+	setBool("ignore-robots", req.IgnoreRobots)
+	setInt("min-content", req.MinContentLength)
+	setInt("max-content", req.MaxContentLength)
+	setInt("min-words", req.MinWords)
+	setInt("max-words", req.MaxWords)
+	setFloat("max-link-density", req.MaxLinkDensity)
+	if req.MaxHTMLSize != 0 {
+		values["max-html-size"] = strconv.FormatInt(req.MaxHTMLSize, 10)
+	}
+	setBool("no-extract", req.DisableContentExtraction || req.DisableReadability)
+	setString("compress", req.CompressOutput)
+	setString("metrics-interval", req.MetricsInterval)
+	setString("fetch-mode", req.FetchMode)
+	if req.Headless != nil {
+		values["headless"] = strconv.FormatBool(*req.Headless)
+	}
+	setBool("wait-login", req.WaitForLogin)
+	setString("page-load-wait", req.PageLoadWait)
+	setString("har", req.HARMode)
+	setString("cassette", req.Cassette)
+	setString("notify", req.Notify)
+	setString("results-db", req.ResultsDB)
+	setBool("discover-apis", req.DiscoverAPIs)
+	setBool("keep-cookie-banners", req.KeepCookieBanners)
+	setBool("lazy-load-scroll", req.LazyLoadScroll)
+	setInt("scroll-step", req.ScrollStep)
+	setString("scroll-delay", req.ScrollDelay)
+	setString("shadow-dom", req.ShadowDOM)
+	setInt("browser-recycle", req.BrowserRecycle)
+	setString("browser-path", req.BrowserPath)
+	setInt("browser-max-memory", req.BrowserMaxMemory)
+	setInt("browser-max-tabs", req.BrowserMaxTabs)
+	setInt("browser-max-processes", req.BrowserMaxProcesses)
+	setString("iframes", req.Iframes)
+	setString("iframe-hosts", strings.Join(req.IframeHosts, ","))
+	setString("provenance", req.Provenance)
+	setBool("cas", req.ContentAddressable)
+	if len(req.PageScripts) > 0 {
+		// -page-scripts also accepts the scripts inline as a JSON array
+		if data, err := json.Marshal(req.PageScripts); err == nil {
+			values["page-scripts"] = string(data)
+		}
+	}
+	if len(req.ContentFilters) > 0 {
+		// -content-filters also accepts the filters inline as a JSON array
+		if data, err := json.Marshal(req.ContentFilters); err == nil {
+			values["content-filters"] = string(data)
+		}
+	}
+	if len(req.FollowOnly) > 0 {
+		if data, err := json.Marshal(req.FollowOnly); err == nil {
+			values["follow-only"] = string(data)
+		}
+	}
+	if len(req.Alerts) > 0 {
+		if data, err := json.Marshal(req.Alerts); err == nil {
+			values["alerts"] = string(data)
+		}
+	}
+	setString("plugins-dir", req.PluginsDir)
+	if len(req.EventSinks) > 0 {
+		if data, err := json.Marshal(req.EventSinks); err == nil {
+			values["event-sinks"] = string(data)
+		}
+	}
+	if req.IndexInterval != nil {
+		values["index-interval"] = strconv.Itoa(*req.IndexInterval)
+	}
+	if req.NormalizeURLs != nil {
+		values["normalize-urls"] = strconv.FormatBool(*req.NormalizeURLs)
+	}
+	setBool("lowercase-paths", req.LowercasePaths)
+
+	if p := req.Pagination; p != nil {
+		setBool("enable-pagination", p.Enable)
+		setString("pagination-selector", p.Selector)
+		setInt("max-pagination-clicks", p.MaxClicks)
+		setString("pagination-wait", p.WaitAfterClick)
+		setString("pagination-wait-selector", p.WaitSelector)
+		values["pagination-stop-duplicate"] = strconv.FormatBool(p.StopOnDuplicate)
+		setBool("pagination-harvest-urls", p.HarvestURLs)
+	}
+
+	if ab := req.AntiBot; ab != nil {
+		setString("antibot-profile", ab.Profile)
+		setBool("hide-webdriver", ab.HideWebdriver)
+		setBool("spoof-plugins", ab.SpoofPlugins)
+		setBool("spoof-languages", ab.SpoofLanguages)
+		setBool("spoof-webgl", ab.SpoofWebGL)
+		setBool("canvas-noise", ab.AddCanvasNoise)
+		setBool("natural-mouse", ab.NaturalMouseMovement)
+		setBool("typing-delays", ab.RandomTypingDelays)
+		setBool("natural-scroll", ab.NaturalScrolling)
+		setBool("action-delays", ab.RandomActionDelays)
+		setBool("click-offset", ab.RandomClickOffset)
+		setBool("rotate-ua", ab.RotateUserAgent)
+		setBool("random-viewport", ab.RandomViewport)
+		setBool("match-timezone", ab.MatchTimezone)
+		setString("timezone", ab.Timezone)
+	}
+
+	if g := req.Geo; g != nil {
+		setString("region", g.Region)
+		setString("accept-language", g.AcceptLanguage)
+		setString("locale", g.Locale)
+		setString("geo-timezone", g.Timezone)
+		setString("geolocation", g.Geolocation)
+		setString("proxy", g.Proxy)
+		setBool("proxy-dns", g.ProxyDNS)
+	}
+
+	if p := req.Permissions; p != nil {
+		setString("file-mode", p.FileMode)
+		setString("dir-mode", p.DirMode)
+		setString("chown", p.Owner)
+	}
+
+	if t := req.Tracing; t != nil {
+		setString("otlp-endpoint", t.Endpoint)
+		setFloat("trace-sample-rate", t.SampleRate)
+	}
+
+	if e := req.Embeddings; e != nil {
+		setString("embeddings-endpoint", e.Endpoint)
+		setString("embeddings-model", e.Model)
+		setString("embeddings-key", e.APIKey)
+		setInt("embeddings-dimensions", e.Dimensions)
+		setString("embeddings-store", e.Store)
+	}
+
+	if e := req.Enrichment; e != nil {
+		setString("enrich-endpoint", e.Endpoint)
+		setString("enrich-key", e.APIKey)
+		setInt("enrich-concurrency", e.Concurrency)
+		setString("enrich-timeout", e.Timeout)
+	}
+
+	setString("post-save-command", req.PostSaveCommand)
+	setInt("post-save-concurrency", req.PostSaveConcurrency)
+	setString("post-save-timeout", req.PostSaveTimeout)
+	setString("post-save-on-failure", req.PostSaveOnFailure)
+	setBool("wayback-fallback", req.WaybackFallback)
+	setBool("wayback-save", req.WaybackSave)
+	setString("wayback-save-key", req.WaybackSaveKey)
+	setString("wayback-save-interval", req.WaybackSaveInterval)
+
+	if f := req.Faults; f != nil {
+		setString("fault-latency", f.Latency)
+		setFloat("fault-5xx-rate", f.ErrorRate)
+		setFloat("fault-truncate-rate", f.TruncateRate)
+		setFloat("fault-reset-rate", f.ResetRate)
+		if f.Seed != 0 {
+			values["fault-seed"] = strconv.FormatInt(f.Seed, 10)
+		}
+	}
+
+	return values
+}
+
+// saveDefinition writes the crawl configuration as a job definition file
+func saveDefinition(path string, config *crawler.Config) error {
+	def := api.NewJobDefinition(api.RequestFromConfig(config), "")
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
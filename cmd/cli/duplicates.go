@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runDuplicates handles the "duplicates" subcommand, clustering a crawl's
+// saved pages by near-duplicate content and writing duplicates_report.html
+// and duplicates_report.json
+func runDuplicates(args []string) {
+	fs := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	threshold := fs.Int("threshold", crawler.DefaultDuplicateThreshold, fmt.Sprintf("Maximum differing SimHash bits for near-duplicates (1-%d)", crawler.MaxDuplicateThreshold))
+	top := fs.Int("top", 10, "Number of largest clusters to list")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s duplicates [options] <output-dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	report, err := crawler.GenerateDuplicatesReport(fs.Arg(0), crawler.DuplicateOptions{Threshold: *threshold})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Compared %d pages, found %d duplicate pages in %d clusters\n", report.Pages, report.DuplicatePages, len(report.Clusters))
+	if report.SkippedPages > 0 {
+		fmt.Printf("Skipped %d pages with fewer than %d words\n", report.SkippedPages, crawler.MinDuplicateWords)
+	}
+	for i, c := range report.Clusters {
+		if i >= *top {
+			break
+		}
+		fmt.Printf("  %4d  %s", c.Size, c.Representative)
+		if len(c.VaryingParams) > 0 {
+			fmt.Printf("  (varying: %s)", strings.Join(c.VaryingParams, ", "))
+		}
+		fmt.Println()
+	}
+	if len(report.ParamCounts) > 0 {
+		params := make([]string, 0, len(report.ParamCounts))
+		for p := range report.ParamCounts {
+			params = append(params, p)
+		}
+		sort.Slice(params, func(i, j int) bool { return report.ParamCounts[params[i]] > report.ParamCounts[params[j]] })
+		fmt.Println("Query parameters behind duplicates:")
+		for _, p := range params {
+			fmt.Printf("  %-20s %d pages\n", p, report.ParamCounts[p])
+		}
+	}
+	fmt.Printf("Report written to %s and %s\n", report.HTMLPath, report.JSONPath)
+}
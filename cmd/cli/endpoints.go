@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runEndpoints handles the "endpoints" subcommand, printing the XHR/fetch
+// endpoints discovered by a browser mode crawl
+func runEndpoints(args []string) {
+	fs := flag.NewFlagSet("endpoints", flag.ExitOnError)
+	jsonOnly := fs.Bool("json", false, "Only list endpoints that responded with JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s endpoints [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads the %s written by crawls run with -discover-apis\n", crawler.APIEndpointsFile)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	endpoints, err := crawler.LoadAPIEndpoints(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	endpoints = crawler.FilterAPIEndpoints(endpoints, *jsonOnly)
+
+	for _, ep := range endpoints {
+		contentType := ep.ContentType
+		if contentType == "" {
+			contentType = "-"
+		}
+		fmt.Printf("%-6s %s  %s  (%d calls, %d pages)\n", ep.Method, ep.URL, contentType, ep.Calls, ep.Pages)
+		if len(ep.QueryParams) > 0 {
+			fmt.Printf("       params: %s\n", strings.Join(ep.QueryParams, ", "))
+		}
+	}
+	fmt.Printf("%d endpoints\n", len(endpoints))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runErrors handles the "errors" subcommand, printing the errored URLs of a
+// crawl with their error class and the page each was found on
+func runErrors(args []string) {
+	fs := flag.NewFlagSet("errors", flag.ExitOnError)
+	class := fs.String("class", "", "Only list errors of this class: "+strings.Join(crawler.ErrorClasses, ", "))
+	asJSON := fs.Bool("json", false, "Print the errors as JSON")
+	urlsOnly := fs.Bool("urls-only", false, "Print only the URLs, e.g. for the retry control command")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s errors [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads the %s written at the end of every crawl. Errors of a running crawl are listed and retried\n", crawler.URLInventoryJSONL)
+		fmt.Fprintf(fs.Output(), "with its control socket: %s control -socket <addr> errors|retry <url...|class|all>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *class != "" && !crawler.ValidErrorClass(*class) {
+		fmt.Printf("Error: invalid class %q (must be one of %s)\n", *class, strings.Join(crawler.ErrorClasses, ", "))
+		os.Exit(1)
+	}
+
+	records, err := crawler.LoadURLInventory(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	errs := crawler.URLErrors(records, *class)
+
+	switch {
+	case *asJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(errs); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case *urlsOnly:
+		for _, e := range errs {
+			fmt.Println(e.URL)
+		}
+	default:
+		for _, e := range errs {
+			referrer := e.Referrer
+			if referrer == "" {
+				referrer = "start URL"
+			}
+			fmt.Printf("%-12s %s  %s  (from %s)\n", e.Class, e.URL, e.Reason, referrer)
+		}
+		counts := crawler.CountErrorClasses(errs)
+		var parts []string
+		for _, c := range crawler.ErrorClasses {
+			if counts[c] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", counts[c], c))
+			}
+		}
+		fmt.Printf("%d errors", len(errs))
+		if len(parts) > 0 {
+			fmt.Printf(": %s", strings.Join(parts, ", "))
+		}
+		fmt.Println()
+	}
+}
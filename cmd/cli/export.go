@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runExport handles the "export" subcommand, stitching a finished crawl into a book
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "html", "Export format: 'html' for a single file with a table of contents, 'epub' for an e-book")
+	order := fs.String("order", "path", "Chapter order: 'path' (URL hierarchy) or 'crawl' (navigation/discovery order)")
+	title := fs.String("title", "", "Book title (defaults to the output directory name)")
+	output := fs.String("o", "", "Output file (defaults to _book.<format> inside the crawl directory)")
+	embedImages := fs.Bool("embed-images", true, "Download and embed images so the book works offline")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export [flags] <output-dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	result, err := crawler.ExportBook(fs.Arg(0), crawler.ExportOptions{
+		Format:      crawler.ExportFormat(*format),
+		Order:       crawler.ExportOrder(*order),
+		Title:       *title,
+		OutputFile:  *output,
+		EmbedImages: *embedImages,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Exported %d chapters (%d images) to %s (%s)\n",
+		result.Chapters, result.Images, result.Path, crawler.FormatBytes(result.Size))
+}
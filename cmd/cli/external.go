@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runExternalLinks handles the "external-links" subcommand, printing the
+// out-of-scope links recorded by a crawl
+func runExternalLinks(args []string) {
+	fs := flag.NewFlagSet("external-links", flag.ExitOnError)
+	host := fs.String("host", "", "Only list links to this host, *.example.com matching its subdomains")
+	urlsOnly := fs.Bool("urls-only", false, "Print each distinct target URL once, e.g. as seeds for a follow-up crawl")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s external-links [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads the %s written by crawls run with -external-links\n", crawler.ExternalLinksFile)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	links, err := crawler.LoadExternalLinks(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	links = crawler.FilterExternalLinks(links, *host)
+
+	if *urlsOnly {
+		seen := make(map[string]bool)
+		for _, link := range links {
+			if !seen[link.URL] {
+				seen[link.URL] = true
+				fmt.Println(link.URL)
+			}
+		}
+		return
+	}
+
+	for _, link := range links {
+		text := link.Text
+		if text == "" {
+			text = "-"
+		}
+		fmt.Printf("%s  %q  (on %s, %s)\n", link.URL, text, link.Referrer, link.Reason)
+	}
+	fmt.Printf("%d external links\n", len(links))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runFetch handles the "fetch" subcommand, printing the extracted content
+// of the start page as Markdown, text or JSON without crawling
+func runFetch(config crawler.Config, format string) {
+	if format != "markdown" && format != "text" && format != "json" {
+		fmt.Printf("Error: invalid -fetch-format %q (expected markdown, text or json)\n", format)
+		os.Exit(1)
+	}
+
+	ctx, cancel := crawler.SetupSignalHandler()
+	defer cancel()
+
+	page, err := crawler.FetchPage(ctx, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(page)
+	case "text":
+		fmt.Println(page.Text)
+	default:
+		if page.Title != "" && !strings.HasPrefix(page.Markdown, "# ") {
+			fmt.Printf("# %s\n\n", page.Title)
+		}
+		fmt.Print(page.Markdown)
+	}
+}
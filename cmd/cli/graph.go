@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runGraph handles the "graph" subcommand, printing the crawl map of a
+// finished crawl as JSON or Graphviz DOT
+func runGraph(args []string) {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "Output format: dot (Graphviz, e.g. | dot -Tsvg > map.svg) or json")
+	maxNodes := fs.Int("max-nodes", crawler.DefaultGraphNodes, fmt.Sprintf("URLs on the map, in discovery order (at most %d)", crawler.MaxGraphNodes))
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s graph [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Draws the discovered URLs, linked to the page each was first found on, from the %s written at the end of every crawl\n", crawler.URLInventoryJSONL)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "dot" && *format != "json" {
+		fmt.Printf("Error: invalid format %q (must be dot or json)\n", *format)
+		os.Exit(1)
+	}
+	if *maxNodes < 0 {
+		fmt.Printf("Error: -max-nodes cannot be negative, got: %d\n", *maxNodes)
+		os.Exit(1)
+	}
+
+	records, err := crawler.LoadURLInventory(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	graph := crawler.BuildCrawlGraph(records, *maxNodes)
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(graph)
+	} else {
+		err = crawler.WriteCrawlGraphDOT(os.Stdout, graph)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
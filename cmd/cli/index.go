@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"scraper/internal/crawler"
+)
+
+// runIndex handles the "index" subcommand, regenerating a crawl's
+// _index.html and listing the pages it captured
+func runIndex(args []string) {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the pages as a JSON array (url, title, path, excerpt, ...)")
+	limit := fs.Int("limit", 0, "List at most this many pages (0 = all)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s index [options] <output-dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	pages, err := crawler.BuildIndex(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	total := len(pages)
+	if *limit > 0 && len(pages) > *limit {
+		pages = pages[:*limit]
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(pages)
+		return
+	}
+
+	for _, p := range pages {
+		title := p.Title
+		if title == "" {
+			title = "-"
+		}
+		fmt.Printf("%s  %q  %s\n", p.URL, title, p.Filename)
+	}
+	fmt.Printf("%d pages indexed in %s\n", total, filepath.Join(fs.Arg(0), crawler.BrowseIndexFile))
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -13,33 +14,187 @@ import (
 )
 
 func main() {
+	// "plan" and "fetch" take the crawl flags, so they are handled after
+	// they are parsed
+	planOnly := false
+	fetchOnly := false
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "plan":
+			planOnly = true
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		case "fetch":
+			fetchOnly = true
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "site":
+			runSite(os.Args[2:])
+			return
+		case "cat":
+			runCat(os.Args[2:])
+			return
+		case "urls":
+			runURLs(os.Args[2:])
+			return
+		case "graph":
+			runGraph(os.Args[2:])
+			return
+		case "errors":
+			runErrors(os.Args[2:])
+			return
+		case "redirects":
+			runRedirects(os.Args[2:])
+			return
+		case "seo":
+			runSEO(os.Args[2:])
+			return
+		case "accessibility":
+			runAccessibility(os.Args[2:])
+			return
+		case "duplicates":
+			runDuplicates(os.Args[2:])
+			return
+		case "endpoints":
+			runEndpoints(os.Args[2:])
+			return
+		case "external-links":
+			runExternalLinks(os.Args[2:])
+			return
+		case "alerts":
+			runAlerts(os.Args[2:])
+			return
+		case "manifest":
+			runManifest(os.Args[2:])
+			return
+		case "index":
+			runIndex(os.Args[2:])
+			return
+		case "reprocess":
+			runReprocess(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "selftest":
+			runSelfTest(os.Args[2:])
+			return
+		case "antibot-test":
+			runAntiBotTest(os.Args[2:])
+			return
+		case "control", "ctl":
+			runControl(os.Args[2:])
+			return
+		case "secrets":
+			runSecrets(os.Args[2:])
+			return
+		case "presets":
+			runPresets(os.Args[2:])
+			return
+		case "schedule":
+			runSchedule(os.Args[2:])
+			return
+		case "recipes":
+			runRecipes(os.Args[2:])
+			return
+		case "plugins":
+			runPlugins(os.Args[2:])
+			return
+		}
+	}
+
 	var config crawler.Config
 	var excludeExtensions string
+	var allowedHosts, deniedHosts string
+	var iframeHosts string
 	var linkSelectors string
 	var fetchMode string
 	var paginationWait string
 	var pageLoadWait string
+	var scrollDelay string
+	var pageScripts string
+	var userAgents string
+	var contentFilters string
+	var followOnly string
+	var alerts string
+	var polite bool
+	var eventSinks string
+	var metricsInterval string
+	var definitionFile string
+	var saveDefinitionFile string
+	var presetName, savePresetName, presetsDir string
+	var recipeName string
+	var recrawlScope, recrawlPattern string
+	var controlSocket string
 
 	flag.StringVar(&config.URL, "url", "", "Starting URL to scrape")
 	flag.BoolVar(&config.Concurrent, "concurrent", false, "Run in concurrent mode")
+	flag.IntVar(&config.Workers, "workers", crawler.DefaultWorkers, "Number of simultaneous requests in concurrent mode")
 	flag.DurationVar(&config.Delay, "delay", time.Second, "Delay between fetches")
 	flag.IntVar(&config.MaxDepth, "depth", 10, "Maximum crawl depth")
+	flag.StringVar(&config.DepthMode, "depth-mode", crawler.DepthModeLinks, "What -depth counts: 'links' (link hops from the start URL) or 'path' (URL path segments beyond the start URL or -prefix-filter)")
+	flag.IntVar(&config.MaxPages, "max-pages", 0, "Stop after this many pages have been saved (0 = unlimited)")
+	flag.DurationVar(&config.MaxRuntime, "max-runtime", 0, "Stop after the crawl has run this long, e.g. '30m' (0 = unlimited)")
+	flag.IntVar(&config.MaxConsecutiveErrors, "max-consecutive-errors", 0, "Stop after this many fetches failed in a row (0 = never)")
+	flag.DurationVar(&config.URLTimeout, "url-timeout", 0, "Give up on a URL not fetched, parsed and saved within this long, e.g. '45s' (0 = none)")
+	flag.StringVar(&config.StopPattern, "stop-pattern", "", "Stop once every URL matching this regex found so far has been fetched")
 	flag.StringVar(&config.OutputDir, "output", "", "Output directory (defaults to URL-based name)")
 	flag.StringVar(&config.StateFile, "state", "", "State file for resume functionality (defaults to folder name)")
+	flag.BoolVar(&config.StateMerge, "state-merge", false, "Resume a state file saved for another start URL by merging it: URLs of its http/https or www variant are rewritten to the new start URL, which is queued unless visited")
+	flag.IntVar(&config.FrontierLimit, "frontier-limit", 0, "Queued URLs kept in memory; the rest of the queue spills to disk next to the state file (0 = unlimited)")
 	flag.StringVar(&config.PrefixFilterURL, "prefix-filter", "", "URL prefix to filter by (if not specified, no prefix filtering is applied)")
 	flag.StringVar(&excludeExtensions, "exclude-extensions", "", "Comma-separated list of asset extensions to exclude (e.g., js,css,png)")
+	flag.StringVar(&allowedHosts, "allowed-hosts", "", "Comma-separated hosts links may lead to, *.example.com matching subdomains (default: any host)")
+	flag.StringVar(&deniedHosts, "denied-hosts", "", "Comma-separated hosts links are never followed to, e.g. *.cdn.com,tracker.net (checked before -allowed-hosts)")
+	flag.BoolVar(&config.ExternalLinks, "external-links", false, "Record links left out by -prefix-filter or the host lists to external_links.jsonl (target, anchor text, referrer) without fetching them")
 	flag.StringVar(&linkSelectors, "link-selectors", "", "Comma-separated list of CSS selectors to filter links (e.g., 'a.internal,.nav-link')")
 	flag.BoolVar(&config.Verbose, "verbose", false, "Enable verbose debug output")
 	flag.StringVar(&config.UserAgent, "user-agent", "", "Custom User-Agent header (defaults to WebScraper/1.0)")
 	flag.BoolVar(&config.IgnoreRobots, "ignore-robots", false, "Ignore robots.txt rules")
+	flag.BoolVar(&polite, "polite", false, "Polite mode: always obey robots.txt, raise -delay to at least 1s, send at most 2 requests at once to each host, and require a user agent with a URL or email (refuses -ignore-robots and rotating user agents)")
 	flag.IntVar(&config.MinContentLength, "min-content", 100, "Minimum text content length (characters) for a page to be saved")
+	flag.IntVar(&config.MaxContentLength, "max-content", 0, "Maximum text content length (characters) for a page to be saved (0 = no limit)")
+	flag.IntVar(&config.MinWords, "min-words", 0, "Minimum number of words for a page to be saved (0 = no limit)")
+	flag.IntVar(&config.MaxWords, "max-words", 0, "Maximum number of words for a page to be saved (0 = no limit)")
+	flag.Float64Var(&config.MaxLinkDensity, "max-link-density", 0, "Skip pages whose share of words inside links is higher, e.g. 0.5 for tag and archive pages (0-1, 0 = no limit); their links are still followed")
+	flag.Int64Var(&config.MaxHTMLSize, "max-html-size", crawler.DefaultMaxHTMLSize, "Skip pages whose HTML is larger than this many bytes")
 	flag.BoolVar(&config.ShowProgress, "progress", true, "Show progress bar and statistics")
+	flag.StringVar(&config.ProgressFormat, "progress-format", crawler.ProgressFormatText, "Progress output: 'text' for people, or 'ndjson' for one JSON record per interval on stdout and the final summary as the last record")
+	flag.BoolVar(&config.ProgressPanel, "progress-panel", false, "Show a multi-line live panel with each worker's current URL, recent errors and rates (falls back to the progress line when stdout is not a terminal)")
+	flag.StringVar(&config.Notify, "notify", crawler.NotifyOff, "Announce the end or failure of the crawl: 'off', 'desktop' (a desktop notification, or the terminal bell where no notifier is found) or 'bell' (terminal bell)")
 	flag.StringVar(&config.MetricsFile, "metrics-json", "", "Output final metrics to JSON file")
+	flag.BoolVar(&config.Report, "report", false, "Write report.html to the output directory when the crawl ends: headline stats, throughput and status code charts, content types and errors with referrers")
+	flag.StringVar(&metricsInterval, "metrics-interval", "5s", "Time between samples written to metrics-timeseries.json/.csv in the output directory")
+	flag.IntVar(&config.IndexInterval, "index-interval", crawler.DefaultIndexInterval, "Rewrite _index.html every N saved pages during the crawl (0 = only at completion)")
 	flag.BoolVar(&config.DisableContentExtraction, "no-extract", false, "Disable content extraction via trafilatura (extracts main article content by default)")
+	flag.StringVar(&config.CompressOutput, "compress", crawler.CompressionNone, "Compress stored HTML files: 'none', 'gzip' (.gz) or 'zstd' (.zst)")
 	flag.StringVar(&fetchMode, "fetch-mode", "http", "Fetch mode: 'http' for standard HTTP client, 'browser' for real browser via chromedp")
 	flag.BoolVar(&config.Headless, "headless", true, "Run browser in headless mode (only applies when fetch-mode=browser)")
 	flag.BoolVar(&config.WaitForLogin, "wait-login", false, "Wait for manual login before crawling (only applies when fetch-mode=browser and headless=false)")
 	flag.StringVar(&pageLoadWait, "page-load-wait", "500ms", "Time to wait after page load for dynamic content (only applies when fetch-mode=browser)")
+	flag.StringVar(&config.HARMode, "har", crawler.HARModeOff, "Record network activity as HAR: 'off', 'page' (_har/<page>.har) or 'crawl' (crawl.har) (requires fetch-mode=browser)")
+	flag.StringVar(&pageScripts, "page-scripts", "", "JSON file (or inline JSON array) of {\"pattern\", \"script\"} objects: JavaScript run after load on pages whose URL matches the regex (requires fetch-mode=browser)")
+	flag.BoolVar(&config.DiscoverAPIs, "discover-apis", false, "Log XHR/fetch endpoints called by pages to api_endpoints.jsonl (requires fetch-mode=browser)")
+	flag.StringVar(&contentFilters, "content-filters", "", "JSON file (or inline JSON array) of {\"pattern\", \"keep\", \"remove\"} objects: on pages whose URL matches the regex, strip elements matching the remove selector and keep only those matching the keep selector before saving")
+	flag.StringVar(&followOnly, "follow-only", "", "JSON file (or inline JSON array) of {\"pattern\", \"title\"} objects: pages whose URL matches the pattern regex and whose title matches the title regex are traversed for links but never saved (e.g. pagination index pages, category listings)")
+	flag.StringVar(&alerts, "alerts", "", "JSON file (or inline JSON array) of {\"name\", \"pattern\", \"keywords\", \"target\"} objects: saved pages matching the pattern regex or any keyword (ignoring case) in their text, or their HTML with target html, are logged, recorded in alerts.jsonl and sent as content_alert events")
+	flag.StringVar(&config.PluginsDir, "plugins-dir", "", "Directory of JavaScript plugins (*.js) defining filterURL(url), transform(page) and/or extract(page) hooks: leave links out, change the saved HTML and add fields to .meta.json (see 'plugins')")
+	flag.BoolVar(&config.KeepCookieBanners, "keep-cookie-banners", false, "Don't dismiss cookie consent banners before capture (only applies when fetch-mode=browser)")
+	flag.BoolVar(&config.LazyLoadScroll, "lazy-load-scroll", false, "Scroll through each page before capture so lazy-loaded images and content are saved (requires fetch-mode=browser)")
+	flag.IntVar(&config.ScrollStep, "scroll-step", 0, "Pixels per -lazy-load-scroll step (0 = one viewport)")
+	flag.StringVar(&scrollDelay, "scroll-delay", "", "Pause after each -lazy-load-scroll step (e.g., 500ms; default 250ms)")
+	flag.StringVar(&config.Iframes, "iframes", crawler.IframesOff, "Capture the documents of same-origin iframes: 'off', 'link' (saved as pages of their own) or 'inline' (replacing the iframe in the saved page)")
+	flag.StringVar(&iframeHosts, "iframe-hosts", "", "Comma-separated cross-origin hosts whose iframes -iframes captures too, *.example.com matching subdomains (requires fetch-mode=browser)")
+	flag.StringVar(&config.Provenance, "provenance", crawler.ProvenanceOff, "Record the source URL, fetch time and crawler version in saved pages: 'off', 'comment' (an HTML comment) or 'banner' (the comment and a visible banner)")
+	flag.BoolVar(&config.ContentAddressable, "cas", false, "Content-addressable storage: store each distinct file once in _blobs/ under its SHA-256, with small per-URL pointer files")
+	flag.IntVar(&config.BrowserRecycle, "browser-recycle", 0, "Relaunch the browser after this many pages to reclaim leaked memory, keeping cookies (0 = never; requires fetch-mode=browser)")
+	flag.StringVar(&config.BrowserPath, "browser-path", "", "Chrome binary to run, e.g. a downloaded Chrome for Testing (default: a system-installed Chrome found on PATH)")
+	flag.IntVar(&config.BrowserMaxMemory, "browser-max-memory", 0, "Relaunch the browser, keeping cookies, when its processes use more than this many MB (0 = unlimited; Linux only)")
+	flag.IntVar(&config.BrowserMaxTabs, "browser-max-tabs", 0, "Tabs open at once; fetches wait for a free one (0 = unlimited)")
+	flag.IntVar(&config.BrowserMaxProcesses, "browser-max-processes", 0, "Chrome processes, renderers included: caps renderers at this minus 3 and relaunches the browser beyond it (0 = unlimited, otherwise at least 4)")
+	flag.StringVar(&config.ShadowDOM, "shadow-dom", crawler.ShadowDOMOff, "Save the shadow roots of web components: 'off', 'declarative' (<template shadowrootmode> inside their hosts) or 'flatten' (inlined into their hosts, best for extraction) (requires fetch-mode=browser)")
 
 	// Pagination flags (only apply when fetch-mode=browser)
 	flag.BoolVar(&config.Pagination.Enable, "enable-pagination", false, "Enable click-based pagination (requires fetch-mode=browser)")
@@ -48,8 +203,10 @@ func main() {
 	flag.StringVar(&paginationWait, "pagination-wait", "2s", "Time to wait after each pagination click (e.g., 2s, 500ms)")
 	flag.StringVar(&config.Pagination.WaitSelector, "pagination-wait-selector", "", "CSS selector to wait for after pagination click")
 	flag.BoolVar(&config.Pagination.StopOnDuplicate, "pagination-stop-duplicate", true, "Stop pagination if duplicate content is detected")
+	flag.BoolVar(&config.Pagination.HarvestURLs, "pagination-harvest-urls", false, "Queue the URLs pagination clicks navigate to (e.g. via history.pushState) as regular pages instead of saving them as pagination pages")
 
 	// Anti-bot bypass flags (only apply when fetch-mode=browser and headless=false)
+	flag.StringVar(&config.AntiBot.Profile, "antibot-profile", "", "Anti-bot profile setting a coherent set of the options below: "+strings.Join(crawler.AntiBotProfiles(), ", ")+"; options given individually are added to it")
 	flag.BoolVar(&config.AntiBot.HideWebdriver, "hide-webdriver", false, "Hide navigator.webdriver flag")
 	flag.BoolVar(&config.AntiBot.SpoofPlugins, "spoof-plugins", false, "Inject realistic browser plugins")
 	flag.BoolVar(&config.AntiBot.SpoofLanguages, "spoof-languages", false, "Set realistic navigator.languages")
@@ -60,16 +217,130 @@ func main() {
 	flag.BoolVar(&config.AntiBot.NaturalScrolling, "natural-scroll", false, "Use momentum-based scrolling")
 	flag.BoolVar(&config.AntiBot.RandomActionDelays, "action-delays", false, "Add jittered action delays")
 	flag.BoolVar(&config.AntiBot.RandomClickOffset, "click-offset", false, "Randomize click positions")
-	flag.BoolVar(&config.AntiBot.RotateUserAgent, "rotate-ua", false, "Rotate through user agents")
+	flag.BoolVar(&config.AntiBot.RotateUserAgent, "rotate-ua", false, "Rotate through user agents (both fetch modes)")
+	flag.StringVar(&config.UserAgentPool, "ua-pool", "", "Built-in user agents -rotate-ua cycles through: "+strings.Join(crawler.UserAgentPools(), ", ")+" (default desktop)")
+	flag.StringVar(&userAgents, "user-agents", "", "File with one user agent per line (or inline JSON array) for -rotate-ua to cycle through instead of -ua-pool")
+	flag.BoolVar(&config.StickyUserAgent, "sticky-ua", false, "Keep the user agent first picked for a host for all of its pages (with -rotate-ua)")
+	flag.BoolVar(&config.WarmUp, "warm-up", false, "Visit each host's homepage before its deeper URLs, keeping its cookies, for sites that block deep links without a session")
+	flag.IntVar(&config.WarmUpPages, "warm-up-pages", 0, fmt.Sprintf("Pages linked from the homepage also visited during -warm-up (0-%d)", crawler.MaxWarmUpPages))
+	flag.StringVar(&config.RefererPolicy, "referer-policy", "", "Referer sent with requests: "+strings.Join(crawler.RefererPolicies(), ", ")+" (default strict with -warm-up, none without)")
 	flag.BoolVar(&config.AntiBot.RandomViewport, "random-viewport", false, "Use random viewport sizes")
 	flag.BoolVar(&config.AntiBot.MatchTimezone, "match-timezone", false, "Enable timezone override")
 	flag.StringVar(&config.AntiBot.Timezone, "timezone", "", "Timezone to use (e.g., America/New_York)")
 
+	// Region emulation flags (locale, geo-timezone and geolocation require fetch-mode=browser)
+	flag.StringVar(&config.Geo.Region, "region", "", "Region preset filling the other region flags: "+strings.Join(crawler.GeoRegionCodes(), ", "))
+	flag.StringVar(&config.Geo.AcceptLanguage, "accept-language", "", "Accept-Language header to send (e.g., 'de-DE,de;q=0.9')")
+	flag.StringVar(&config.Geo.Locale, "locale", "", "Browser locale to emulate (e.g., de-DE)")
+	flag.StringVar(&config.Geo.Timezone, "geo-timezone", "", "Timezone to emulate in every tab (e.g., Europe/Berlin)")
+	flag.StringVar(&config.Geo.Geolocation, "geolocation", "", "Geolocation to emulate as 'latitude,longitude' (e.g., 52.52,13.405)")
+	flag.StringVar(&config.Geo.Proxy, "proxy", "", "Proxy URL (http, https, socks5 or socks5h), with user:pass@ credentials if needed (not for SOCKS in browser mode); {region} is replaced by the region code")
+	flag.BoolVar(&config.Geo.ProxyDNS, "proxy-dns", false, "Resolve host names only through the proxy: in browser mode every DNS lookup besides the proxy's own is blocked, so none leak to the local resolver")
+
+	// Output permission flags, for output on shared volumes
+	flag.StringVar(&config.Permissions.FileMode, "file-mode", "", "Octal mode of written files (e.g., 0664), applied regardless of the umask")
+	flag.StringVar(&config.Permissions.DirMode, "dir-mode", "", "Octal mode of created directories (e.g., 2775), applied regardless of the umask")
+	flag.StringVar(&config.Permissions.Owner, "chown", "", "Owner of written files as 'uid[:gid]' (Unix only, requires running as root)")
+
+	// Re-crawl flags, for refreshing part of a previous crawl into its output directory
+	flag.StringVar(&recrawlScope, "recrawl", "", "Fetch again only URLs of the previous crawl in the output directory: failed, changed (rewrite pages whose HTML changed) or pattern")
+	flag.StringVar(&recrawlPattern, "recrawl-pattern", "", "URL regex selecting the URLs to re-crawl with -recrawl pattern")
+
+	// Record-and-replay flags, for iterating on extraction without refetching
+	flag.StringVar(&config.Cassette, "cassette", crawler.CassetteOff, "Fetch cassette: 'off', 'record' (save every response) or 'replay' (answer every fetch from the cassette, without network access)")
+	flag.StringVar(&config.CassetteFile, "cassette-file", "", "Cassette file (default: <output>/"+crawler.CassetteFile+"); replay into a new -output to keep the recorded crawl")
+
+	// Decision trace, for finding out why expected pages were not captured
+	flag.StringVar(&config.TraceDecisions, "trace-decisions", "", "Write every URL considered and the rule that accepted or rejected it (robots, prefix, extension, content-type, dedup, ...) to this JSON Lines file")
+
+	// Results database, for querying the crawl with SQL
+	flag.StringVar(&config.ResultsDB, "results-db", "", "Write pages, links, errors, redirects and metrics samples to this SQLite database during the crawl, relative to -output unless absolute (e.g. "+crawler.ResultsDBFile+")")
+
+	// Event sinks, for external monitoring
+	flag.StringVar(&config.Tracing.Endpoint, "otlp-endpoint", "", "Export OpenTelemetry spans of each URL's robots check, fetch, parse, extraction and save to this OTLP/HTTP collector (e.g. http://localhost:4318)")
+	flag.Float64Var(&config.Tracing.SampleRate, "trace-sample-rate", 0, "Share of URLs traced with -otlp-endpoint, from 0 to 1 (0 = every URL)")
+	flag.StringVar(&eventSinks, "event-sinks", "", "JSON file (or inline JSON array) of {\"type\", \"path\", \"url\", \"topic\", \"events\", \"payload\", \"mappings\"} objects: also send crawl events to a file (events.ndjson), a webhook, a NATS subject or a Kafka topic through a REST Proxy; list page_saved in events to publish each saved page (payload pointer or text); type elasticsearch indexes saved pages in an Elasticsearch/OpenSearch index (topic)")
+
+	// Embeddings of saved pages, for semantic search
+	flag.StringVar(&config.Embeddings.Endpoint, "embeddings-endpoint", "", "Send the text of each saved page to this OpenAI-compatible embeddings API (e.g. https://api.openai.com/v1 or http://localhost:11434/v1) and store the vectors")
+	flag.StringVar(&config.Embeddings.Model, "embeddings-model", "", "Embedding model for -embeddings-endpoint (e.g. text-embedding-3-small)")
+	flag.StringVar(&config.Embeddings.APIKey, "embeddings-key", "", "API key for -embeddings-endpoint, or secret://name (default $"+crawler.EmbeddingsAPIKeyEnv+")")
+	flag.IntVar(&config.Embeddings.Dimensions, "embeddings-dimensions", 0, "Vector size, for models that can shorten their vectors (0 = the model's)")
+	flag.StringVar(&config.Embeddings.Store, "embeddings-store", crawler.EmbeddingsStoreFile, "Where vectors go: file (<page>"+crawler.EmbeddingFileExt+" next to each page) or results-db (the embeddings table of -results-db)")
+
+	// Enrichment of saved pages by an HTTP endpoint, e.g. an LLM summarizing them
+	flag.StringVar(&config.Enrichment.Endpoint, "enrich-endpoint", "", "POST the extracted content of each saved page ({\"url\", \"jobId\", \"metadata\", \"text\", \"html\"}) to this URL and add the JSON object it answers with (summary, tags, ...) to the page's .meta.json under \"enrichment\"")
+	flag.StringVar(&config.Enrichment.APIKey, "enrich-key", "", "Bearer token for -enrich-endpoint, or secret://name (default $"+crawler.EnrichmentAPIKeyEnv+")")
+	flag.IntVar(&config.Enrichment.Concurrency, "enrich-concurrency", crawler.DefaultEnrichmentConcurrency, "Enrichment requests in flight at once")
+	flag.DurationVar(&config.Enrichment.Timeout, "enrich-timeout", crawler.DefaultEnrichmentTimeout, "Timeout of each enrichment request")
+
+	// Shell command run on each saved page, e.g. a converter of its own
+	flag.StringVar(&config.PostSaveCommand, "post-save-command", "", "Shell command run for each saved page, with the saved file as $1 (not on Windows) and $SCRAPER_FILE, its metadata JSON on stdin, and $SCRAPER_META_FILE, $SCRAPER_URL, $SCRAPER_JOB_ID and $SCRAPER_OUTPUT_DIR set")
+	flag.IntVar(&config.PostSaveConcurrency, "post-save-concurrency", crawler.DefaultPostSaveConcurrency, "Post-save commands running at once")
+	flag.DurationVar(&config.PostSaveTimeout, "post-save-timeout", crawler.DefaultPostSaveTimeout, "Kill a post-save command running longer than this")
+	flag.StringVar(&config.PostSaveOnFailure, "post-save-on-failure", crawler.PostSaveFailureWarn, "After a post-save command fails: warn (log it and go on), disable (stop running it) or stop (end the crawl with stop reason post-save-failed)")
+
+	flag.BoolVar(&config.WaybackFallback, "wayback-fallback", false, "Save the latest Wayback Machine snapshot of pages answering 404 or 410 or timing out, marked with archived_source in their .meta.json")
+	flag.BoolVar(&config.WaybackSave, "wayback-save", false, "Submit the URL of each saved page to the Wayback Machine's Save Page Now, rate-limited")
+	flag.StringVar(&config.WaybackSaveKey, "wayback-save-key", "", "archive.org API key for -wayback-save as accesskey:secret, or secret://name (default $"+crawler.WaybackSaveKeyEnv+"; anonymous without one)")
+	flag.DurationVar(&config.WaybackSaveInterval, "wayback-save-interval", crawler.DefaultWaybackSaveInterval, "Time between Save Page Now submissions")
+
+	// Fault injection flags, for exercising error paths in development and CI; hidden from -help
+	flag.DurationVar(&config.Faults.Latency, "fault-latency", 0, "Latency added to every fetch")
+	flag.Float64Var(&config.Faults.ErrorRate, "fault-5xx-rate", 0, "Share of fetches answered with a random 5xx (0-1)")
+	flag.Float64Var(&config.Faults.TruncateRate, "fault-truncate-rate", 0, "Share of fetches whose body is cut in half (0-1)")
+	flag.Float64Var(&config.Faults.ResetRate, "fault-reset-rate", 0, "Share of fetches failed with a connection reset (0-1)")
+	flag.Int64Var(&config.Faults.Seed, "fault-seed", 0, "Seed selecting which fetches fail")
+	flag.Usage = usage
+
 	// URL normalization flags
 	normalizeURLs := flag.Bool("normalize-urls", true, "Enable URL normalization for better duplicate detection")
 	lowercasePaths := flag.Bool("lowercase-paths", false, "Lowercase URL paths during normalization (use with caution)")
+
+	// Job definitions
+	flag.StringVar(&definitionFile, "definition", "", "Load crawl settings from a job definition JSON file (explicit flags take precedence)")
+	flag.StringVar(&saveDefinitionFile, "save-definition", "", "Write the crawl settings to a job definition JSON file and exit without crawling")
+
+	// Presets
+	flag.StringVar(&presetName, "preset", "", "Load crawl settings from a saved preset (explicit flags and -definition take precedence)")
+	flag.StringVar(&savePresetName, "save-preset", "", "Save the crawl settings as a preset with this name and exit without crawling")
+	flag.StringVar(&presetsDir, "presets-dir", "", "Directory of the presets (default: the desktop app's presets, see the presets subcommand)")
+
+	// Recipes
+	flag.StringVar(&recipeName, "recipe", "", "Apply a site recipe, e.g. discourse-forum or mediawiki, to the settings not given otherwise (see the recipes subcommand)")
+
+	// Runtime control
+	flag.StringVar(&controlSocket, "control-socket", "", "Accept status, metrics, pause, resume, verbose and stop commands on this Unix socket path or localhost:port (see the control subcommand)")
+
+	// Single page fetch
+	fetchFormat := flag.String("fetch-format", "markdown", "Output of the fetch subcommand: markdown, text or json")
 	flag.Parse()
 
+	if definitionFile != "" {
+		if err := applyDefinition(definitionFile); err != nil {
+			fmt.Printf("Error: failed to load definition: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if presetName != "" {
+		if err := applyPreset(presetsDir, presetName); err != nil {
+			fmt.Printf("Error: failed to load preset: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// A definition or preset may name a recipe too
+	if recipeName != "" {
+		if err := applyRecipe(recipeName); err != nil {
+			fmt.Printf("Error: failed to load recipe: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// A plan previews the links of the start page unless -depth says otherwise
+	if planOnly && !isFlagSet("depth") {
+		config.MaxDepth = 1
+	}
+
 	// Set URL normalization options
 	config.NormalizeURLs = *normalizeURLs
 	config.LowercasePaths = *lowercasePaths
@@ -86,6 +357,86 @@ func main() {
 		config.PageLoadWait = waitDuration
 	}
 
+	// Parse lazy-load scroll delay (browser mode)
+	if scrollDelay != "" {
+		d, err := time.ParseDuration(scrollDelay)
+		if err != nil {
+			fmt.Printf("Error: invalid -scroll-delay: %v\n", err)
+			os.Exit(1)
+		}
+		config.ScrollDelay = d
+	}
+
+	// Load page scripts (browser mode)
+	if pageScripts != "" {
+		scripts, err := crawler.ParsePageScripts(pageScripts)
+		if err != nil {
+			fmt.Printf("Error: invalid -page-scripts: %v\n", err)
+			os.Exit(1)
+		}
+		config.PageScripts = scripts
+	}
+
+	// Load the user agents to rotate through
+	if userAgents != "" {
+		agents, err := crawler.ParseUserAgents(userAgents)
+		if err != nil {
+			fmt.Printf("Error: invalid -user-agents: %v\n", err)
+			os.Exit(1)
+		}
+		config.UserAgents = agents
+	}
+
+	// Load content filters
+	if contentFilters != "" {
+		filters, err := crawler.ParseContentFilters(contentFilters)
+		if err != nil {
+			fmt.Printf("Error: invalid -content-filters: %v\n", err)
+			os.Exit(1)
+		}
+		config.ContentFilters = filters
+	}
+
+	// Load event sinks
+	if eventSinks != "" {
+		sinks, err := crawler.ParseEventSinks(eventSinks)
+		if err != nil {
+			fmt.Printf("Error: invalid -event-sinks: %v\n", err)
+			os.Exit(1)
+		}
+		config.EventSinks = sinks
+	}
+
+	// Load follow-only rules
+	if followOnly != "" {
+		rules, err := crawler.ParseFollowOnlyRules(followOnly)
+		if err != nil {
+			fmt.Printf("Error: invalid -follow-only: %v\n", err)
+			os.Exit(1)
+		}
+		config.FollowOnly = rules
+	}
+
+	// Load alert rules
+	if alerts != "" {
+		rules, err := crawler.ParseAlertRules(alerts)
+		if err != nil {
+			fmt.Printf("Error: invalid -alerts: %v\n", err)
+			os.Exit(1)
+		}
+		config.Alerts = rules
+	}
+
+	// Parse metrics sampling interval
+	if metricsInterval != "" {
+		d, err := time.ParseDuration(metricsInterval)
+		if err != nil {
+			fmt.Printf("Error: invalid -metrics-interval: %v\n", err)
+			os.Exit(1)
+		}
+		config.MetricsInterval = d
+	}
+
 	// Parse pagination wait duration
 	if config.Pagination.Enable {
 		waitDuration, err := time.ParseDuration(paginationWait)
@@ -103,6 +454,17 @@ func main() {
 		}
 	}
 
+	// Parse host lists
+	if iframeHosts != "" {
+		config.IframeHosts = splitHosts(iframeHosts)
+	}
+	if allowedHosts != "" {
+		config.AllowedHosts = splitHosts(allowedHosts)
+	}
+	if deniedHosts != "" {
+		config.DeniedHosts = splitHosts(deniedHosts)
+	}
+
 	// Parse link selectors
 	if linkSelectors != "" {
 		config.LinkSelectors = strings.Split(linkSelectors, ",")
@@ -111,6 +473,11 @@ func main() {
 		}
 	}
 
+	if polite {
+		policy := crawler.DefaultPolitePolicy()
+		config.Polite = &policy
+	}
+
 	// Validate configuration
 	if err := crawler.ValidateConfig(&config); err != nil {
 		fmt.Printf("Error: %v\n", err)
@@ -118,6 +485,33 @@ func main() {
 		os.Exit(1)
 	}
 
+	if saveDefinitionFile != "" {
+		if err := saveDefinition(saveDefinitionFile, &config); err != nil {
+			log.Fatal("Failed to save definition:", err)
+		}
+		fmt.Printf("Saved job definition to %s\n", saveDefinitionFile)
+		return
+	}
+
+	if savePresetName != "" {
+		path, err := savePreset(presetsDir, savePresetName, &config)
+		if err != nil {
+			log.Fatal("Failed to save preset:", err)
+		}
+		fmt.Printf("Saved preset %s to %s\n", savePresetName, path)
+		return
+	}
+
+	if planOnly {
+		runPlan(config)
+		return
+	}
+
+	if fetchOnly {
+		runFetch(config, *fetchFormat)
+		return
+	}
+
 	// Set default output directory
 	if err := crawler.SetDefaultOutputDir(&config); err != nil {
 		log.Fatal("Invalid URL:", err)
@@ -126,6 +520,16 @@ func main() {
 	// Set default state file
 	crawler.SetDefaultStateFile(&config)
 
+	// Select the URLs to re-crawl from the previous crawl's URL inventory
+	if recrawlScope != "" {
+		n, err := crawler.PrepareRecrawl(&config, recrawlScope, recrawlPattern)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(messageOutput(&config), "Re-crawling %d %s URLs into %s\n", n, recrawlScope, config.OutputDir)
+	}
+
 	// Set up signal handling for graceful shutdown
 	ctx, cancel := crawler.SetupSignalHandler()
 	defer cancel()
@@ -136,6 +540,16 @@ func main() {
 	}
 	defer c.Close()
 
+	// SIGUSR1 dumps the status, SIGUSR2 toggles pause/resume
+	crawler.HandleControlSignals(ctx, c)
+	if controlSocket != "" {
+		go func() {
+			if err := c.ServeControl(ctx, controlSocket); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}()
+	}
+
 	// If wait-login is enabled, set up a goroutine to wait for Enter key
 	if config.WaitForLogin && config.FetchMode == crawler.FetchModeBrowser && !config.Headless {
 		go func() {
@@ -144,7 +558,7 @@ func main() {
 
 			// Check if the crawler is waiting for login
 			for c.IsWaitingForLogin() {
-				fmt.Println("\nBrowser opened. Complete login, then press ENTER to start crawling...")
+				fmt.Fprintln(messageOutput(&config), "\nBrowser opened. Complete login, then press ENTER to start crawling...")
 				reader := bufio.NewReader(os.Stdin)
 				_, _ = reader.ReadString('\n')
 				c.ConfirmLogin()
@@ -157,3 +571,56 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// isFlagSet reports whether a flag was given on the command line or by a
+// definition file
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// messageOutput returns where messages for the user go during a crawl:
+// stdout, or stderr when stdout carries the NDJSON progress stream
+func messageOutput(config *crawler.Config) io.Writer {
+	if config.ProgressFormat == crawler.ProgressFormatNDJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// splitHosts splits a comma-separated host list, dropping empty entries
+func splitHosts(list string) []string {
+	var hosts []string
+	for _, host := range strings.Split(list, ",") {
+		if host = strings.TrimSpace(strings.ToLower(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// isHiddenFlag reports whether a flag is left out of -help. The fault
+// injection flags are for development and CI, not regular crawls.
+func isHiddenFlag(name string) bool {
+	return strings.HasPrefix(name, "fault-")
+}
+
+// usage prints the default usage message without the hidden flags
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if !isHiddenFlag(f.Name) {
+			visible.Var(f.Value, f.Name, f.Usage)
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	visible.PrintDefaults()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"scraper/internal/crawler"
+)
+
+// runManifest handles the "manifest" subcommand, printing the pages.ndjson
+// of an output directory and, with -follow, the pages saved after it like
+// tail -f, while the crawl is still running
+func runManifest(args []string) {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	offset := fs.Int64("offset", 0, "Byte offset in the manifest to start from")
+	follow := fs.Bool("follow", false, "Keep printing pages as they are saved, until interrupted")
+	interval := fs.Duration("interval", time.Second, "How often -follow checks for new pages")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s manifest [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Prints the %s a crawl appends each saved page to, one JSON object per line\n", crawler.ManifestFile)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *interval <= 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	next := *offset
+	for {
+		data, n, err := crawler.ReadManifestLines(fs.Arg(0), next)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		next = n
+		if !*follow {
+			return
+		}
+		time.Sleep(*interval)
+	}
+}
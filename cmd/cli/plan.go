@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runPlan handles the "plan" subcommand, printing which links of the start
+// page (and of the pages above -depth) a crawl would queue and which it
+// would filter out, without crawling
+func runPlan(config crawler.Config) {
+	ctx, cancel := crawler.SetupSignalHandler()
+	defer cancel()
+
+	plan, err := crawler.Plan(ctx, config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, p := range plan.Pages {
+		if p.Error != "" {
+			fmt.Printf("FETCHED   %d  %s: %s\n", p.Depth, p.URL, p.Error)
+		} else {
+			fmt.Printf("FETCHED   %d  %s (%d new links)\n", p.Depth, p.URL, p.Links)
+		}
+	}
+	for _, l := range plan.Links {
+		if l.Queued {
+			fmt.Printf("QUEUE     %d  %s\n", l.Depth, l.URL)
+		} else {
+			fmt.Printf("%-9s %d  %s\n", strings.ToUpper(l.Reason), l.Depth, l.URL)
+		}
+	}
+
+	reasons := make([]string, 0, len(plan.Filtered))
+	for reason, n := range plan.Filtered {
+		reasons = append(reasons, fmt.Sprintf("%s %d", reason, n))
+	}
+	sort.Strings(reasons)
+	fmt.Printf("%d pages fetched, %d links: %d queued, %d filtered", len(plan.Pages), len(plan.Links), plan.Queued, len(plan.Links)-plan.Queued)
+	if len(reasons) > 0 {
+		fmt.Printf(" (%s)", strings.Join(reasons, ", "))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runPlugins handles the "plugins" subcommand, loading the plugins of a
+// directory and listing the hooks each defines
+func runPlugins(args []string) {
+	fs := flag.NewFlagSet("plugins", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s plugins <plugins-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Loads the %s plugins used with -plugins-dir, failing on the first that does not compile.\n", crawler.PluginExt)
+		fmt.Fprintf(fs.Output(), "Hooks: %s(url) returns false to leave a link out, %s(page) returns the HTML to save, %s(page) returns fields for .meta.json;\n",
+			crawler.PluginHookFilterURL, crawler.PluginHookTransform, crawler.PluginHookExtract)
+		fmt.Fprintf(fs.Output(), "page has url, html, title and text. log(...) writes to the crawl log.\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	plugins, err := crawler.ListPlugins(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	for _, p := range plugins {
+		fmt.Printf("%-24s %s\n", p.Name, strings.Join(p.Hooks, ", "))
+	}
+	fmt.Printf("%d plugins\n", len(plugins))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"scraper/internal/api"
+	"scraper/internal/crawler"
+)
+
+// openPresets returns the preset store in dir, or the desktop app's presets
+// when dir is empty
+func openPresets(dir string) (*api.PresetStore, error) {
+	if dir == "" {
+		var err error
+		if dir, err = api.DefaultPresetsDir(); err != nil {
+			return nil, err
+		}
+	}
+	return api.NewPresetStore(dir), nil
+}
+
+// applyPreset loads a preset and uses its settings for every flag that was
+// not given explicitly on the command line or by a definition file
+func applyPreset(dir, name string) error {
+	store, err := openPresets(dir)
+	if err != nil {
+		return err
+	}
+	p, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	return applyRequest(&p.Request, "preset")
+}
+
+// savePreset saves the crawl configuration as a preset and returns the file
+// it was written to
+func savePreset(dir, name string, config *crawler.Config) (string, error) {
+	store, err := openPresets(dir)
+	if err != nil {
+		return "", err
+	}
+	if err := store.Save(api.NewPreset(name, api.RequestFromConfig(config))); err != nil {
+		return "", err
+	}
+	return filepath.Join(store.Dir(), name+".json"), nil
+}
+
+// runPresets handles the "presets" subcommand, managing the presets shared
+// with the desktop app, the API and the MCP server
+func runPresets(args []string) {
+	fs := flag.NewFlagSet("presets", flag.ExitOnError)
+	dir := fs.String("dir", "", "Presets directory (default: the desktop app's presets)")
+	output := fs.String("o", "", "Output file for export (default: stdout)")
+	overwrite := fs.Bool("overwrite", false, "Replace presets with the same name on import (default: skip them)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s presets [options] list | show <name> | delete <name> | export [name...] | import <file>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Crawl with a preset with -preset <name>; save one with -save-preset <name>.\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	// Options may also follow the action, as in "export -o presets.json"
+	action := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	names := fs.Args()
+
+	switch action {
+	case "list":
+		if len(names) != 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+	case "show", "delete", "import":
+		if len(names) != 1 {
+			fs.Usage()
+			os.Exit(1)
+		}
+	case "export":
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	store, err := openPresets(*dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "list":
+		presets, err := store.List()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, p := range presets {
+			fmt.Printf("%-30s %s  %s\n", p.Name, p.CreatedAt.Format("2006-01-02 15:04"), p.URL)
+		}
+	case "show":
+		p, err := store.Get(names[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		printPresetJSON(p)
+	case "delete":
+		if err := store.Delete(names[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted preset %s\n", names[0])
+	case "export":
+		bundle, err := store.Export(names)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if *output == "" {
+			printPresetJSON(bundle)
+			return
+		}
+		data, _ := json.MarshalIndent(bundle, "", "  ")
+		if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported %d presets to %s\n", len(bundle.Presets), *output)
+	case "import":
+		data, err := os.ReadFile(names[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		result, err := store.Import(data, *overwrite)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %d presets into %s", len(result.Imported), store.Dir())
+		if result.Upgraded > 0 {
+			fmt.Printf(" (%d upgraded from an older version)", result.Upgraded)
+		}
+		fmt.Println()
+		for _, name := range result.Skipped {
+			fmt.Printf("Skipped %s: a preset with that name exists (use -overwrite to replace it)\n", name)
+		}
+	}
+}
+
+// printPresetJSON writes a preset or bundle to stdout as indented JSON
+func printPresetJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
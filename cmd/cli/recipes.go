@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/api"
+)
+
+// applyRecipe uses the settings of a recipe for every flag that was not
+// given explicitly, by a definition file or by a preset
+func applyRecipe(name string) error {
+	recipe, err := api.GetRecipe(name)
+	if err != nil {
+		return err
+	}
+	return applyRequest(&recipe.Request, "recipe")
+}
+
+// runRecipes handles the "recipes" subcommand, listing the built-in and
+// user recipes usable with -recipe
+func runRecipes(args []string) {
+	fs := flag.NewFlagSet("recipes", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s recipes [list | show <name>]\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Crawl with a recipe with -recipe <name>. User recipes are the *.json files in %s (default: %s)\n", api.RecipesDirEnv, strings.Join(api.RecipeDirs(), string(os.PathListSeparator)))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	action := "list"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+	switch {
+	case action == "list" && fs.NArg() <= 1:
+		recipes, err := api.Recipes()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, r := range recipes {
+			source := r.Source
+			if source != api.BuiltinRecipeSource {
+				source = "user"
+			}
+			fmt.Printf("%-20s %-8s %s\n", r.Name, source, r.Description)
+		}
+	case action == "show" && fs.NArg() == 2:
+		recipe, err := api.GetRecipe(fs.Arg(1))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		printPresetJSON(recipe)
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+}
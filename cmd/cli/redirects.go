@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runRedirects handles the "redirects" subcommand, printing the redirect
+// mapping of a crawl along with the chains it abandoned
+func runRedirects(args []string) {
+	fs := flag.NewFlagSet("redirects", flag.ExitOnError)
+	failuresOnly := fs.Bool("failures", false, "Only list redirect loops and overly long chains")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s redirects [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads the %s written at the end of every crawl\n", crawler.RedirectsFile)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	m, err := crawler.LoadRedirectMap(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*failuresOnly {
+		for _, r := range m.Redirects {
+			fmt.Printf("%d  %s -> %s\n", r.Status, r.From, r.To)
+		}
+	}
+	for _, f := range m.Failures {
+		fmt.Printf("%s: %s\n", f.Reason, f.URL)
+		for _, r := range f.Chain {
+			fmt.Printf("    %d  %s -> %s\n", r.Status, r.From, r.To)
+		}
+	}
+	if !*failuresOnly {
+		fmt.Printf("%d redirects, %d failed chains, %d permanent aliases\n", len(m.Redirects), len(m.Failures), len(m.Aliases))
+	}
+}
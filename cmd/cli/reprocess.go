@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runReprocess handles the "reprocess" subcommand, extracting the content
+// of a crawl's saved pages again with the current settings and regenerating
+// its _index.html, without fetching anything
+func runReprocess(args []string) {
+	fs := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	noExtract := fs.Bool("no-extract", false, "Remove the extracted .content.html files instead of extracting them again")
+	contentFilters := fs.String("content-filters", "", "JSON file (or inline JSON array) of {\"pattern\", \"keep\", \"remove\"} objects applied to pages before extraction")
+	pluginsDir := fs.String("plugins-dir", "", "Directory of JavaScript plugins whose extract(page) hooks fill the \"plugins\" field of .meta.json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s reprocess [options] <output-dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	opts := crawler.ReprocessOptions{DisableContentExtraction: *noExtract, PluginsDir: *pluginsDir}
+	if *contentFilters != "" {
+		filters, err := crawler.ParseContentFilters(*contentFilters)
+		if err != nil {
+			fmt.Printf("Error: invalid -content-filters: %v\n", err)
+			os.Exit(1)
+		}
+		opts.ContentFilters = filters
+	}
+
+	result, err := crawler.Reprocess(fs.Arg(0), opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Reprocessed %d pages: %d with extracted content, %d changed\n", result.Pages, result.Extracted, result.Changed)
+	if result.Failed > 0 {
+		fmt.Printf("Failed to reprocess %d pages (see the warnings above)\n", result.Failed)
+	}
+	fmt.Printf("Index written to %s\n", result.IndexFile)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"scraper/internal/api"
+)
+
+// runSchedule handles the "schedule" subcommand, managing the schedules
+// shared with the desktop app, the API and the MCP server, and running them
+func runSchedule(args []string) {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	file := fs.String("file", "", "Schedules file (default: the desktop app's schedules)")
+	dir := fs.String("presets-dir", "", "Presets directory (default: the desktop app's presets)")
+	url := fs.String("url", "", "For add: start URL, overriding the preset's")
+	limit := fs.Int("limit", 20, "For history: maximum runs to list (0 = all)")
+	maxJobs := fs.Int("max-jobs", 2, "For daemon: maximum schedules running at once")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s schedule [options] list | add <preset> <spec> | remove <id> | enable <id> | disable <id> | run <id> | history [id] | daemon\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Specs: %s, in local time.\n", api.ScheduleSpecHelp)
+		fmt.Fprintf(fs.Output(), "Schedules run when due while the desktop app, the daemon or a server started with -run-schedules is up.\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	// Options may also follow the action, as in "add -url ... docs nightly"
+	action := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+	rest := fs.Args()
+
+	wantArgs := map[string]func(int) bool{
+		"list":    func(n int) bool { return n == 0 },
+		"add":     func(n int) bool { return n >= 2 },
+		"remove":  func(n int) bool { return n == 1 },
+		"enable":  func(n int) bool { return n == 1 },
+		"disable": func(n int) bool { return n == 1 },
+		"run":     func(n int) bool { return n == 1 },
+		"history": func(n int) bool { return n <= 1 },
+		"daemon":  func(n int) bool { return n == 0 },
+	}
+	if check, ok := wantArgs[action]; !ok || !check(len(rest)) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	presets, err := openPresets(*dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	path := *file
+	if path == "" {
+		if path, err = api.DefaultSchedulesFile(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	store := api.NewScheduleStore(path)
+
+	switch action {
+	case "list":
+		schedules, err := store.List()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, s := range schedules {
+			next := s.NextRun.Local().Format("2006-01-02 15:04")
+			if !s.Enabled {
+				next = "disabled"
+			}
+			last := "never run"
+			if s.LastRun != nil {
+				last = fmt.Sprintf("last %s %s", s.LastRun.StartedAt.Local().Format("2006-01-02 15:04"), s.LastRun.Status)
+			}
+			target := s.Preset
+			if s.URL != "" {
+				target += " " + s.URL
+			}
+			fmt.Printf("%s  %-20s next %-16s  %-28s  %s\n", s.ID, s.Spec, next, last, target)
+		}
+	case "add":
+		scheduler := api.NewScheduler(store, presets, nil)
+		s, err := scheduler.Add(rest[0], *url, strings.Join(rest[1:], " "))
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added schedule %s: %s %s, next run %s\n", s.ID, s.Preset, s.Spec, s.NextRun.Local().Format("2006-01-02 15:04"))
+	case "remove":
+		if err := store.Remove(rest[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed schedule %s\n", rest[0])
+	case "enable", "disable":
+		s, err := store.SetEnabled(rest[0], action == "enable")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Schedule %s %sd\n", s.ID, action)
+	case "history":
+		id := ""
+		if len(rest) == 1 {
+			id = rest[0]
+		}
+		runs, err := store.History(id, *limit)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, r := range runs {
+			printScheduleRun(r)
+		}
+	case "run", "daemon":
+		jm := api.NewJobManager(*maxJobs)
+		defer jm.Shutdown()
+		scheduler := api.NewScheduler(store, presets, jm.ScheduleRunner(api.AnonymousKey))
+		ended := make(chan api.ScheduleRun, 1)
+		scheduler.OnRunEnd(func(r api.ScheduleRun) {
+			printScheduleRun(r)
+			if action == "run" {
+				ended <- r
+			}
+		})
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+		if action == "run" {
+			run, err := scheduler.RunNow(rest[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Running %s (%s)...\n", run.ScheduleID, run.Preset)
+			select {
+			case r := <-ended:
+				if r.Status != api.ScheduleRunCompleted {
+					os.Exit(1)
+				}
+			case <-sigChan:
+				scheduler.Stop()
+				os.Exit(1)
+			}
+			return
+		}
+
+		scheduler.Start()
+		fmt.Printf("Running the schedules in %s when due (Ctrl+C to stop)\n", path)
+		<-sigChan
+		fmt.Println("Stopping...")
+		scheduler.Stop()
+	}
+}
+
+// printScheduleRun prints a run of the schedule history on one line
+func printScheduleRun(r api.ScheduleRun) {
+	duration := ""
+	if r.EndedAt != nil {
+		duration = r.EndedAt.Sub(r.StartedAt).Round(time.Second).String()
+	}
+	fmt.Printf("%s  %s  %-9s %-8s %-8s %s  %d saved, %d errors", r.StartedAt.Local().Format("2006-01-02 15:04"), r.ScheduleID, r.Status, r.Trigger, duration, r.Preset, r.PagesSaved, r.Errors)
+	if r.Error != "" {
+		fmt.Printf("  (%s)", r.Error)
+	} else if r.StopReason != "" {
+		fmt.Printf("  (%s)", r.StopReason)
+	}
+	if r.OutputDir != "" {
+		fmt.Printf("  -> %s", r.OutputDir)
+	}
+	fmt.Println()
+}
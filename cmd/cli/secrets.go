@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runSecrets handles the "secrets" subcommand, managing the encrypted store
+// that secret://name references in proxies and page scripts resolve against
+func runSecrets(args []string) {
+	fs := flag.NewFlagSet("secrets", flag.ExitOnError)
+	value := fs.String("value", "", "Value for set (default: read from stdin, so it stays out of the shell history)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s secrets [options] list | set <name> | delete <name>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "The store is encrypted with the master key in %s and kept in %s (default: secrets.enc in the user config dir)\n", crawler.SecretsKeyEnv, crawler.SecretsFileEnv)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	action := fs.Arg(0)
+	if (action == "list" && fs.NArg() != 1) || (action != "list" && fs.NArg() != 2) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	store, err := crawler.OpenDefaultSecretStore()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "list":
+		for _, name := range store.Names() {
+			fmt.Println(name)
+		}
+		return
+	case "set":
+		secret := *value
+		if secret == "" {
+			data, err := io.ReadAll(bufio.NewReader(os.Stdin))
+			if err != nil {
+				fmt.Printf("Error: failed to read value: %v\n", err)
+				os.Exit(1)
+			}
+			secret = strings.TrimRight(string(data), "\r\n")
+		}
+		if err := store.Set(fs.Arg(1), secret); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "delete":
+		if !store.Delete(fs.Arg(1)) {
+			fmt.Printf("Error: unknown secret %q\n", fs.Arg(1))
+			os.Exit(1)
+		}
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if err := store.Save(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved %s (%d secrets)\n", store.Path(), len(store.Names()))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// runSelfTest handles the "selftest" subcommand, crawling synthetic sites
+// served on a local port to validate the installation
+func runSelfTest(args []string) {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	only := fs.String("only", "", "Comma-separated checks to run (default all: "+strings.Join(crawler.SelfTestNames(), ", ")+")")
+	keep := fs.Bool("keep", false, "Keep the crawl output of the checks")
+	verbose := fs.Bool("v", false, "Show the crawler log")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s selftest [flags]\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Crawls synthetic sites served on a local port to verify the crawler works\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var names []string
+	if *only != "" {
+		for _, name := range strings.Split(*only, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	dir, err := os.MkdirTemp("", "scraper-selftest-")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	// os.Exit skips deferred calls
+	exit := func(code int) {
+		if *keep {
+			fmt.Printf("Output kept in %s\n", dir)
+		} else {
+			os.RemoveAll(dir)
+		}
+		os.Exit(code)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	failed := 0
+	results, err := crawler.RunSelfTest(ctx, dir, names, func(r crawler.SelfTestResult) {
+		if r.Passed {
+			fmt.Printf("PASS  %-12s %5.2fs  %s\n", r.Name, r.Seconds, r.Description)
+		} else {
+			failed++
+			fmt.Printf("FAIL  %-12s %5.2fs  %s\n      %s\n", r.Name, r.Seconds, r.Description, r.Error)
+		}
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("%d of %d checks passed\n", len(results)-failed, len(results))
+	if failed > 0 {
+		exit(1)
+	}
+	exit(0)
+}
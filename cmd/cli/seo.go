@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runSEO handles the "seo" subcommand, auditing a crawl's saved pages and
+// writing seo_report.html and seo_report.json
+func runSEO(args []string) {
+	fs := flag.NewFlagSet("seo", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s seo <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Broken internal links are checked when the crawl wrote %s\n", crawler.URLInventoryJSONL)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	report, err := crawler.GenerateSEOReport(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Audited %d pages, found %d issues\n", report.Pages, len(report.Issues))
+	for _, issueType := range crawler.SEOIssueTypes {
+		if n := report.IssueCounts[issueType]; n > 0 {
+			fmt.Printf("  %-24s %d\n", issueType, n)
+		}
+	}
+	if !report.BrokenLinkCheck {
+		fmt.Printf("Broken links not checked: no %s in the output directory\n", crawler.URLInventoryJSONL)
+	}
+	fmt.Printf("Report written to %s and %s\n", report.HTMLPath, report.JSONPath)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runServe handles the "serve" subcommand, serving an output directory over
+// HTTP for clicking through the results in a browser
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8081", "Address to listen on")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s serve [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Directories show their %s when present, otherwise a listing\n", crawler.BrowseIndexFile)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	dir := fs.Arg(0)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Printf("Error: %s is not a directory\n", dir)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Serving %s on http://%s/\n", dir, *addr)
+	if err := http.ListenAndServe(*addr, crawler.NewOutputBrowser(dir)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runSite handles the "site" subcommand, generating a browsable static mirror of a crawl
+func runSite(args []string) {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	title := fs.String("title", "", "Site title (defaults to the output directory name)")
+	output := fs.String("o", "", "Site directory (defaults to _site inside the crawl directory)")
+	embedImages := fs.Bool("embed-images", false, "Download images into the site instead of linking to the original host")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s site [flags] <output-dir>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	result, err := crawler.GenerateSite(fs.Arg(0), crawler.SiteOptions{
+		Title:       *title,
+		SiteDir:     *output,
+		EmbedImages: *embedImages,
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Generated site with %d pages (%d images) in %s\n", result.Pages, result.Images, result.Path)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"scraper/internal/crawler"
+)
+
+// runURLs handles the "urls" subcommand, printing the URL inventory of a
+// crawl, optionally filtered by outcome
+func runURLs(args []string) {
+	fs := flag.NewFlagSet("urls", flag.ExitOnError)
+	format := fs.String("format", "csv", "Output format: csv or jsonl")
+	status := fs.String("status", "", "Only list URLs with this outcome: saved, skipped, error, blocked or queued")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s urls [flags] <output-dir>\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reads the %s written at the end of every crawl\n", crawler.URLInventoryJSONL)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "csv" && *format != "jsonl" {
+		fmt.Printf("Error: invalid format %q (must be csv or jsonl)\n", *format)
+		os.Exit(1)
+	}
+	if *status != "" && !crawler.ValidURLStatus(*status) {
+		fmt.Printf("Error: invalid status %q\n", *status)
+		os.Exit(1)
+	}
+
+	records, err := crawler.LoadURLInventory(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	records = crawler.FilterURLRecords(records, *status)
+
+	if *format == "jsonl" {
+		err = crawler.WriteURLInventoryJSONL(os.Stdout, records)
+	} else {
+		err = crawler.WriteURLInventoryCSV(os.Stdout, records)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
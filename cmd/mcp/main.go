@@ -9,6 +9,12 @@
 //
 //	-max-jobs int
 //	      Maximum concurrent crawl jobs (default 5)
+//	-pprof string
+//	      Serve pprof on this local address (e.g. localhost:6060)
+//	-polite
+//	      Enforce polite mode on every crawl (see -polite-* for its limits)
+//	-audit-log string
+//	      Record tool calls that change server state in this JSON Lines file
 //
 // Configuration in Claude Code (~/.claude/mcp.json):
 //
@@ -25,20 +31,84 @@ package main
 import (
 	"flag"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"scraper/internal/api"
+	"scraper/internal/crawler"
 	"scraper/internal/mcp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 func main() {
 	// Parse command-line flags
 	maxJobs := flag.Int("max-jobs", 5, "Maximum concurrent crawl jobs")
+	retentionDays := flag.Int("retention-days", 0, "Remove finished jobs after N days (0 = keep forever)")
+	pruneFiles := flag.Bool("retention-prune-files", false, "Also delete output and state files of removed jobs")
+	hostDelay := flag.Duration("host-delay", 0, "Minimum time between requests to the same host across all jobs (0 = per-job delays only)")
+	polite := flag.Bool("polite", false, "Polite mode: always obey robots.txt, raise delays to -polite-min-delay, cap requests in flight per host, require a user agent with a URL or email, and refuse crawls that ignore robots or rotate user agents")
+	politeMinDelay := flag.Duration("polite-min-delay", crawler.DefaultPoliteMinDelay, "Shortest delay between requests in polite mode")
+	politeHostConcurrency := flag.Int("polite-host-concurrency", crawler.DefaultPoliteHostConcurrency, "Requests in flight to one host across all jobs in polite mode (0 = unlimited)")
+	politeUserAgent := flag.String("polite-user-agent", "", "User agent of crawls that set none in polite mode; must contain a URL or email address (default: the crawler's own)")
+	presetsDir := flag.String("presets-dir", "", "Directory of the presets managed by scraper_presets (default: the desktop app's presets)")
+	schedulesFile := flag.String("schedules-file", "", "File of the schedules managed by scraper_schedules (default: the desktop app's schedules)")
+	runSchedules := flag.Bool("run-schedules", false, "Run the enabled schedules when due while the server is up")
+	auditLog := flag.String("audit-log", "", "JSON Lines file recording every tool call that changes server state (read with scraper_audit)")
+	auditMaxSize := flag.Int64("audit-max-size", api.DefaultAuditMaxSize, "Rotate the audit log once it reaches this many bytes (0 = never)")
+	auditMaxFiles := flag.Int("audit-max-files", api.DefaultAuditMaxFiles, "Rotated audit logs to keep")
+	outputOwner := flag.String("chown", "", "Owner of all job output as 'uid[:gid]' (Unix only, requires running as root); tool calls cannot set one")
+	pprofAddr := flag.String("pprof", "", "Serve pprof on /debug/pprof/ at this local address (e.g. localhost:6060)")
 	flag.Parse()
 
+	if *retentionDays < 0 {
+		log.Fatalf("retention-days cannot be negative")
+	}
+	if *hostDelay < 0 {
+		log.Fatalf("host-delay cannot be negative")
+	}
+	var politePolicy *crawler.PolitePolicy
+	if *polite {
+		politePolicy = &crawler.PolitePolicy{MinDelay: *politeMinDelay, HostConcurrency: *politeHostConcurrency, UserAgent: *politeUserAgent}
+		if err := politePolicy.Validate(); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if err := (crawler.OutputPermissions{Owner: *outputOwner}).Validate(); err != nil {
+		log.Fatalf("invalid -chown: %v", err)
+	}
+	if *pprofAddr != "" {
+		servePprof(*pprofAddr)
+	}
+
 	// Create and start the MCP server
 	server := mcp.NewServer(*maxJobs)
+	server.SetRetention(api.RetentionPolicy{
+		MaxAge:     time.Duration(*retentionDays) * 24 * time.Hour,
+		PruneFiles: *pruneFiles,
+	})
+	server.SetHostDelay(*hostDelay)
+	server.SetPolitePolicy(politePolicy)
+	server.SetOutputOwner(*outputOwner)
+	server.SetPresetsDir(*presetsDir)
+	server.SetSchedulesFile(*schedulesFile)
+	if *runSchedules {
+		if err := server.RunSchedules(); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+	if *auditLog != "" {
+		audit, err := api.OpenAuditLog(*auditLog, *auditMaxSize, *auditMaxFiles)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		server.SetAuditLog(audit)
+	}
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -55,3 +125,25 @@ func main() {
 		log.Fatalf("MCP server error: %v", err)
 	}
 }
+
+// servePprof serves the profiles of the server on addr, which must be a
+// loopback address since the profiles are not authenticated
+func servePprof(addr string) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		log.Fatalf("invalid pprof address: %v", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		log.Fatalf("pprof address must be on localhost, got %s", addr)
+	}
+
+	api.EnableContentionProfiling()
+	r := chi.NewRouter()
+	r.Mount("/debug", middleware.Profiler())
+	go func() {
+		log.Printf("Serving pprof on http://%s/debug/pprof/", addr)
+		if err := http.ListenAndServe(addr, r); err != nil {
+			log.Printf("pprof server error: %v", err)
+		}
+	}()
+}
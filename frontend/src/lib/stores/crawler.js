@@ -7,6 +7,7 @@ function createCrawlerStore() {
         progress: null,
         logs: [],
         error: null,
+        stopReason: null, // Why the last crawl ended
     });
 
     return {
@@ -18,12 +19,14 @@ function createCrawlerStore() {
             logs: [...state.logs.slice(-499), log] // Keep last 500 logs
         })),
         setError: (error) => update(state => ({ ...state, error })),
+        setStopReason: (stopReason) => update(state => ({ ...state, stopReason })),
         clearLogs: () => update(state => ({ ...state, logs: [] })),
         reset: () => set({
             status: 'stopped',
             progress: null,
             logs: [],
             error: null,
+            stopReason: null,
         }),
     };
 }
@@ -35,22 +38,65 @@ export const crawlerStore = createCrawlerStore();
 const defaultConfig = {
     url: '',
     concurrent: false,
+    workers: 10,
     delay: '1s',
     maxDepth: 10,
+    depthMode: 'links',
+    maxPages: 0,
+    maxRuntime: '', // e.g. '30m'; empty for no limit
+    maxConsecutiveErrors: 0,
+    urlTimeout: '', // e.g. '45s'; empty for no deadline
+    stopPattern: '',
     outputDir: '',
     stateFile: '',
+    stateMerge: false,
+    frontierLimit: 0,
     prefixFilter: '',
+    allowedHosts: '',
+    deniedHosts: '',
+    externalLinks: false,
+    report: false,
     excludeExtensions: 'js,css,png,jpg,gif,svg,ico,woff,woff2,ttf,eot',
     linkSelectors: 'a[href]',
     verbose: false,
     userAgent: '',
     ignoreRobots: false,
+    polite: false, // Enforce polite mode: robots.txt, delay floor, per-host concurrency, contactable user agent
     minContent: 100,
+    maxContent: 0,
+    minWords: 0,
+    maxWords: 0,
+    maxLinkDensity: 0,
+    maxHtmlSize: 10485760,
     disableContentExtraction: false,
+    compressOutput: 'none',
+    indexInterval: 50,
+    metricsInterval: '5s',
     fetchMode: 'http',
     headless: true,
     waitForLogin: false,
     pageLoadWait: '500ms',
+    harMode: 'off',
+    discoverApis: false,
+    keepCookieBanners: false, // Cookie banners are dismissed unless set
+    lazyLoadScroll: false,
+    scrollStep: 0, // Pixels per lazy-load scroll step, 0 = one viewport
+    scrollDelay: '250ms',
+    shadowDom: 'off', // 'off', 'declarative' or 'flatten'
+    browserRecycle: 0, // Relaunch the browser every N pages, 0 = never
+    browserPath: '', // Chrome binary; empty for a system-installed Chrome
+    browserMaxMemory: 0, // MB before the browser is relaunched, 0 = unlimited
+    browserMaxTabs: 0, // Tabs open at once, 0 = unlimited
+    browserMaxProcesses: 0, // Chrome processes, 0 = unlimited
+    iframes: 'off', // 'off', 'link' or 'inline'
+    iframeHosts: '', // Comma-separated cross-origin hosts, browser mode only
+    provenance: 'off', // 'off', 'comment' or 'banner'
+    contentAddressable: false, // Store each distinct file once in _blobs/
+    pageScripts: [], // [{ pattern, script }] run after load on matching pages
+    contentFilters: [], // [{ pattern, keep, remove }] applied to saved pages
+    followOnly: [], // [{ pattern, title }] pages traversed for links but not saved
+    alerts: [], // [{ name, pattern, keywords, target }] matches recorded in alerts.jsonl
+    pluginsDir: '', // Directory of JavaScript plugins with filterURL, transform and extract hooks
     // Pagination settings (browser mode only)
     enablePagination: false,
     paginationSelector: '',
@@ -58,7 +104,9 @@ const defaultConfig = {
     paginationWait: '2s',
     paginationWaitSelector: '',
     paginationStopOnDuplicate: true,
+    paginationHarvestUrls: false,
     // Anti-bot settings (visible only in non-headless browser mode)
+    antiBotProfile: '', // off, low, standard or aggressive; the checkboxes add to it
     hideWebdriver: false,
     spoofPlugins: false,
     spoofLanguages: false,
@@ -70,12 +118,68 @@ const defaultConfig = {
     randomActionDelays: false,
     randomClickOffset: false,
     rotateUserAgent: false,
+    userAgentPool: '', // desktop (default), mobile or all
+    userAgents: '', // One per line, replaces the pool
+    stickyUserAgent: false,
+    warmUp: false,
+    warmUpPages: 0, // Pages linked from the homepage also visited, 0-5
+    refererPolicy: '', // none, origin, strict or full; strict with warm-up, none without
     randomViewport: false,
     matchTimezone: false,
     timezone: '',
+    // Region emulation settings (locale, timezone and geolocation browser mode only)
+    region: '',
+    acceptLanguage: '',
+    locale: '',
+    geoTimezone: '',
+    geolocation: '',
+    proxy: '',
+    proxyDns: false,
+    // Output permission settings, for output on shared volumes
+    fileMode: '',
+    dirMode: '',
+    owner: '',
     // URL normalization settings
     normalizeUrls: true,
     lowercasePaths: false,
+    notify: 'off', // Announce the end of the crawl: 'off', 'desktop' or 'bell'
+    // Record-and-replay of fetches
+    cassette: 'off',
+    cassetteFile: '',
+    traceDecisions: '',
+    resultsDb: '',
+    eventSinks: [], // [{ type, path, url, topic, events, payload, mappings }] where crawl events are also sent
+    // OpenTelemetry export of per-URL spans
+    otlpEndpoint: '',
+    traceSampleRate: 0,
+    // Embeddings of saved pages through an OpenAI-compatible API
+    embeddingsEndpoint: '',
+    embeddingsModel: '',
+    embeddingsApiKey: '',
+    embeddingsDimensions: 0,
+    embeddingsStore: 'file',
+    // Enrichment of saved pages by an HTTP endpoint
+    enrichmentEndpoint: '',
+    enrichmentApiKey: '',
+    enrichmentConcurrency: 2,
+    enrichmentTimeout: '60s',
+    // Shell command run on each saved page
+    postSaveCommand: '',
+    postSaveConcurrency: 2,
+    postSaveTimeout: '60s',
+    postSaveOnFailure: 'warn',
+    // Save Wayback Machine snapshots of dead pages
+    waybackFallback: false,
+    // Submit saved pages to the Wayback Machine's Save Page Now
+    waybackSave: false,
+    waybackSaveKey: '',
+    waybackSaveInterval: '10s',
+    // Fault injection, for development
+    faultLatency: '',
+    faultErrorRate: 0,
+    faultTruncateRate: 0,
+    faultResetRate: 0,
+    faultSeed: 0,
 };
 
 function createConfigStore() {
@@ -91,16 +195,16 @@ function createConfigStore() {
 
         /**
          * Get the current config object (useful for saving presets)
-         * Excludes outputDir and stateFile as per spec
+         * Excludes outputDir, stateFile and stateMerge as per spec
          */
         getPresetConfig: () => {
-            const { outputDir, stateFile, ...presetFields } = currentValue;
+            const { outputDir, stateFile, stateMerge, ...presetFields } = currentValue;
             return presetFields;
         },
 
         /**
          * Apply a loaded preset config to the form
-         * Preserves outputDir and stateFile from current config
+         * Preserves outputDir, stateFile and stateMerge from current config
          * @param {object} preset - The preset config to apply
          */
         applyPreset: (preset) => {
@@ -109,6 +213,7 @@ function createConfigStore() {
                 ...preset,               // Apply preset values
                 outputDir: current.outputDir,    // Preserve job-specific paths
                 stateFile: current.stateFile,
+                stateMerge: current.stateMerge,
             }));
         },
 
@@ -89,6 +89,42 @@ function createPresetsStore() {
             }
         },
 
+        /**
+         * Export all presets to a bundle file chosen in a save dialog
+         * @returns {{success: boolean, path?: string, error?: string}}
+         */
+        async exportPresets() {
+            try {
+                if (window.go && window.go.app && window.go.app.App) {
+                    const path = await window.go.app.App.ExportPresets();
+                    return { success: true, path };
+                }
+                return { success: false, error: 'Backend not available' };
+            } catch (err) {
+                return { success: false, error: err.message || err || 'Failed to export presets' };
+            }
+        },
+
+        /**
+         * Import presets from a bundle or preset file chosen in an open dialog.
+         * Presets from older versions are upgraded.
+         * @param {boolean} overwrite - Replace presets with the same name
+         */
+        async importPresets(overwrite) {
+            try {
+                if (window.go && window.go.app && window.go.app.App) {
+                    const result = await window.go.app.App.ImportPresets(overwrite);
+                    if (result) {
+                        await this.loadPresets();
+                    }
+                    return { success: true, result };
+                }
+                return { success: false, error: 'Backend not available' };
+            } catch (err) {
+                return { success: false, error: err.message || err || 'Failed to import presets' };
+            }
+        },
+
         /**
          * Clear any error state
          */
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Defaults of the audit log
+const (
+	DefaultAuditMaxSize  = 10 << 20 // Bytes before the log is rotated
+	DefaultAuditMaxFiles = 5        // Rotated files kept next to the log
+)
+
+// auditBodyLimit bounds the part of a request body kept for its summary
+const auditBodyLimit = 64 << 10
+
+// AuditEntry is one recorded operation of the audit log
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Key      string    `json:"key"`                // API key name (anonymous without auth, mcp for MCP tools)
+	ClientIP string    `json:"clientIp,omitempty"` // Remote address of API requests
+	Action   string    `json:"action"`             // Method and route ("POST /api/v1/crawl/{jobId}/pause") or MCP tool
+	Path     string    `json:"path,omitempty"`     // Path of API requests
+	JobID    string    `json:"jobId,omitempty"`
+	Status   int       `json:"status,omitempty"` // HTTP status of API requests
+	Error    string    `json:"error,omitempty"`  // Error of failed MCP tool calls
+	Summary  string    `json:"summary,omitempty"`
+}
+
+// AuditFilter narrows the entries read from an audit log
+type AuditFilter struct {
+	Key   string
+	JobID string
+	Since time.Time
+	Limit int // Most recent entries to return (0 = all)
+}
+
+// AuditLog is an append-only JSON Lines file of operations that change
+// server state. Once the file reaches maxSize it is renamed to path.1
+// (shifting older files to path.2 and so on) and a new one is started;
+// only maxFiles rotated files are kept.
+type AuditLog struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// OpenAuditLog opens or creates the audit log at path
+func OpenAuditLog(path string, maxSize int64, maxFiles int) (*AuditLog, error) {
+	if maxSize < 0 || maxFiles < 0 {
+		return nil, fmt.Errorf("audit log size and file count cannot be negative")
+	}
+	a := &AuditLog{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Path returns the file the log is written to
+func (a *AuditLog) Path() string {
+	return a.path
+}
+
+func (a *AuditLog) open() error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	a.file = f
+	a.size = info.Size()
+	return nil
+}
+
+// Record appends an entry, rotating the log first when it would grow past
+// its maximum size
+func (a *AuditLog) Record(entry AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		if err := a.open(); err != nil {
+			return err
+		}
+	}
+	if a.maxSize > 0 && a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// rotate moves the current file to path.1, shifting older rotated files up
+// and dropping those past maxFiles, and starts a new file
+func (a *AuditLog) rotate() error {
+	a.file.Close()
+	a.file = nil
+	os.Remove(a.rotatedPath(a.maxFiles))
+	for i := a.maxFiles - 1; i >= 1; i-- {
+		os.Rename(a.rotatedPath(i), a.rotatedPath(i+1))
+	}
+	if a.maxFiles > 0 {
+		if err := os.Rename(a.path, a.rotatedPath(1)); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	} else if err := os.Remove(a.path); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	return a.open()
+}
+
+func (a *AuditLog) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", a.path, n)
+}
+
+// Entries returns the entries matching filter from the log and its rotated
+// files, most recent first, with the number of matches before the limit
+func (a *AuditLog) Entries(filter AuditFilter) ([]AuditEntry, int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var entries []AuditEntry
+	for i := a.maxFiles; i >= 0; i-- {
+		path := a.path
+		if i > 0 {
+			path = a.rotatedPath(i)
+		}
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e AuditEntry
+			if json.Unmarshal(scanner.Bytes(), &e) != nil {
+				continue
+			}
+			if filter.Key != "" && e.Key != filter.Key {
+				continue
+			}
+			if filter.JobID != "" && e.JobID != filter.JobID {
+				continue
+			}
+			if !filter.Since.IsZero() && e.Time.Before(filter.Since) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	total := len(entries)
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, total, nil
+}
+
+// Close closes the file; a later Record reopens it
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// SummarizeArguments describes the arguments of an operation without their
+// values, which may hold credentials, except for the target url (stripped
+// of any user info): "url=https://example.com/ fields=delay,maxPages"
+func SummarizeArguments(args map[string]interface{}) string {
+	var parts []string
+	if raw, ok := args["url"].(string); ok && raw != "" {
+		if u, err := url.Parse(raw); err == nil {
+			u.User = nil
+			raw = u.String()
+		}
+		parts = append(parts, "url="+raw)
+	}
+	var fields []string
+	for name := range args {
+		if name != "url" {
+			fields = append(fields, name)
+		}
+	}
+	if len(fields) > 0 {
+		sort.Strings(fields)
+		parts = append(parts, "fields="+strings.Join(fields, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// summarizeBody summarizes a JSON object body with SummarizeArguments, and
+// other bodies by their size
+func summarizeBody(body []byte, truncated bool) string {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return ""
+	}
+	var args map[string]interface{}
+	if truncated || json.Unmarshal(body, &args) != nil {
+		if truncated {
+			return fmt.Sprintf("body of more than %d bytes", len(body))
+		}
+		return fmt.Sprintf("body of %d bytes", len(body))
+	}
+	return SummarizeArguments(args)
+}
+
+// auditBody passes a request body through while keeping its first
+// auditBodyLimit bytes
+type auditBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := auditBodyLimit - b.buf.Len(); n > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+	} else {
+		b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// auditResponseWriter captures the status and the start of the body of a
+// response, where created jobs report their ID
+type auditResponseWriter struct {
+	responseWriter
+	head bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(p []byte) (int, error) {
+	if room := 1024 - w.head.Len(); room > 0 {
+		w.head.Write(p[:min(len(p), room)])
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Audit middleware records every request that may change server state
+// (anything but GET, HEAD and OPTIONS) in audit, with the API key, client IP,
+// route, job, response status and a summary of the body. Must run after
+// APIKeyAuth.
+func Audit(audit *AuditLog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body *auditBody
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &auditBody{ReadCloser: r.Body}
+				r.Body = body
+			}
+			wrapped := &auditResponseWriter{responseWriter: responseWriter{ResponseWriter: w, statusCode: http.StatusOK}}
+			next.ServeHTTP(wrapped, r)
+
+			entry := AuditEntry{
+				Key:      "anonymous",
+				ClientIP: clientIP(r),
+				Action:   r.Method + " " + r.URL.Path,
+				Path:     r.URL.Path,
+				Status:   wrapped.statusCode,
+			}
+			if key, ok := APIKeyFromContext(r.Context()); ok {
+				entry.Key = key.Name
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					entry.Action = r.Method + " " + strings.TrimSuffix(pattern, "/")
+				}
+				entry.JobID = rctx.URLParam("jobId")
+			}
+			if entry.JobID == "" && wrapped.statusCode < 300 {
+				var created struct {
+					JobID string `json:"jobId"`
+				}
+				if json.Unmarshal(wrapped.head.Bytes(), &created) == nil {
+					entry.JobID = created.JobID
+				}
+			}
+			if body != nil {
+				entry.Summary = summarizeBody(body.buf.Bytes(), body.truncated)
+			}
+			if err := audit.Record(entry); err != nil {
+				log.Printf("Audit: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"scraper/internal/crawler"
+)
+
+// BrowseCrawl handles GET /api/v1/crawl/{jobId}/browse/*
+// It serves the job's output directory for clicking through results in a
+// browser, with _index.html as the default document.
+func (h *Handlers) BrowseCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	outputDir, err := h.JobManager.JobOutputDir(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Relative links in the index resolve against /browse/, not the job
+	if !strings.HasSuffix(r.URL.Path, "/browse/") && chi.URLParam(r, "*") == "" {
+		http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	// Saved pages are third-party HTML served from the API's own origin:
+	// sandbox them so their scripts cannot call the API from its origin
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	browser := crawler.NewOutputBrowser(outputDir)
+	browser.OnError = func(w http.ResponseWriter, status int, message string) {
+		writeError(w, APIError{Code: status, Message: message})
+	}
+	u := *r.URL
+	u.Path = "/" + chi.URLParam(r, "*")
+	br := r.Clone(r.Context())
+	br.URL = &u
+	browser.ServeHTTP(w, br)
+}
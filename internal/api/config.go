@@ -1,9 +1,13 @@
 package api
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"scraper/internal/crawler"
 )
 
 // ServerConfig holds all configuration for the API server
@@ -20,6 +24,43 @@ type ServerConfig struct {
 	// APIKey is the optional API key for authentication (empty = no auth)
 	APIKey string
 
+	// APIKeysFile is a JSON file of named API keys with optional quotas
+	APIKeysFile string
+
+	// APIKeys are named API keys, loaded from APIKeysFile when set
+	APIKeys []APIKeyConfig
+
+	// UsageFile persists per-key usage across restarts (empty = in memory only)
+	UsageFile string
+
+	// AuditLogFile records every request that changes server state (job
+	// creation, control and deletion, config patches, presets, secrets) as
+	// JSON Lines, served to admin keys by /api/v1/audit (empty = no audit log)
+	AuditLogFile string
+
+	// AuditMaxSize rotates the audit log once it reaches this many bytes
+	// (default: 10 MiB, 0 = never)
+	AuditMaxSize int64
+
+	// AuditMaxFiles is how many rotated audit logs are kept (default: 5)
+	AuditMaxFiles int
+
+	// MaxBodySize limits request bodies in bytes (default: 1 MiB, 0 = unlimited)
+	MaxBodySize int64
+
+	// RateLimit is the requests per minute allowed to each API key, or to
+	// each client IP without authentication (0 = unlimited). Keys may set
+	// their own in the API keys file.
+	RateLimit int
+
+	// RateBurst is how many requests a client may make at once before
+	// RateLimit applies (0 = RateLimit)
+	RateBurst int
+
+	// AuthFailureLimit is the failed authentications per minute after which
+	// a client IP is refused (default: 10, 0 = unlimited)
+	AuthFailureLimit int
+
 	// CORSOrigins is a list of allowed CORS origins (empty = no CORS)
 	CORSOrigins []string
 
@@ -31,6 +72,61 @@ type ServerConfig struct {
 
 	// IdleTimeout is the maximum amount of time to wait for the next request (seconds)
 	IdleTimeout int
+
+	// RetentionDays removes finished jobs this many days after they end (0 = keep forever)
+	RetentionDays int
+
+	// RetentionPruneFiles also deletes the output directory and state file of removed jobs
+	RetentionPruneFiles bool
+
+	// HostDelay is the minimum time between two requests to the same host
+	// across all jobs (0 = each job only keeps its own delay)
+	HostDelay time.Duration
+
+	// Polite enforces polite mode on every job: robots.txt is always obeyed,
+	// delays are raised to PoliteMinDelay, requests in flight to each host
+	// are capped at PoliteHostConcurrency across all jobs, and jobs must send
+	// a user agent with a URL or email address. Requests that ignore robots
+	// or rotate user agents are refused.
+	Polite bool
+
+	// PoliteMinDelay is the shortest delay between requests in polite mode
+	// (default: 1s)
+	PoliteMinDelay time.Duration
+
+	// PoliteHostConcurrency caps the requests in flight to one host in
+	// polite mode (default: 2, 0 = unlimited)
+	PoliteHostConcurrency int
+
+	// PoliteUserAgent is sent by jobs that set no user agent in polite mode
+	// (default: the crawler's own, which links to the project)
+	PoliteUserAgent string
+
+	// PresetsDir holds the presets served under /api/v1/presets (default:
+	// the presets directory of the desktop app in the user config dir)
+	PresetsDir string
+
+	// SchedulesFile holds the schedules served under /api/v1/schedules
+	// (default: the schedules file of the desktop app in the user config dir)
+	SchedulesFile string
+
+	// RunSchedules runs the enabled schedules of SchedulesFile when due.
+	// Without it schedules are managed and run by hand only.
+	RunSchedules bool
+
+	// AllowCommands lets jobs run a post-save command or a browser binary of
+	// their choice (browserPath) on the server. Off by default: anyone who
+	// can create jobs could run any command.
+	AllowCommands bool
+
+	// OutputOwner ("uid[:gid]") is the owner all job output is chowned to;
+	// the server must run as root. Requests cannot pick an owner themselves.
+	OutputOwner string
+
+	// Debug serves pprof under /debug/pprof/ and runtime statistics under
+	// /api/v1/debug/runtime, to admin keys or, without authentication, to
+	// local clients only
+	Debug bool
 }
 
 // DefaultServerConfig returns a ServerConfig with sensible defaults
@@ -39,11 +135,31 @@ func DefaultServerConfig() *ServerConfig {
 		Host:              "0.0.0.0",
 		Port:              8080,
 		MaxConcurrentJobs: 5,
+		MaxBodySize:       DefaultMaxBodySize,
+		AuthFailureLimit:  DefaultAuthFailureLimit,
+		AuditMaxSize:      DefaultAuditMaxSize,
+		AuditMaxFiles:     DefaultAuditMaxFiles,
 		APIKey:            "",
 		CORSOrigins:       nil,
 		ReadTimeout:       30,
 		WriteTimeout:      60,
 		IdleTimeout:       120,
+
+		PoliteMinDelay:        crawler.DefaultPoliteMinDelay,
+		PoliteHostConcurrency: crawler.DefaultPoliteHostConcurrency,
+	}
+}
+
+// PolitePolicy returns the policy enforced on jobs, or nil when polite mode
+// is off
+func (c *ServerConfig) PolitePolicy() *crawler.PolitePolicy {
+	if !c.Polite {
+		return nil
+	}
+	return &crawler.PolitePolicy{
+		MinDelay:        c.PoliteMinDelay,
+		HostConcurrency: c.PoliteHostConcurrency,
+		UserAgent:       c.PoliteUserAgent,
 	}
 }
 
@@ -75,6 +191,54 @@ func (c *ServerConfig) LoadFromEnv() {
 		c.APIKey = apiKey
 	}
 
+	if keysFile := os.Getenv("API_KEYS_FILE"); keysFile != "" {
+		c.APIKeysFile = keysFile
+	}
+
+	if usageFile := os.Getenv("API_USAGE_FILE"); usageFile != "" {
+		c.UsageFile = usageFile
+	}
+
+	if auditLog := os.Getenv("API_AUDIT_LOG"); auditLog != "" {
+		c.AuditLogFile = auditLog
+	}
+
+	if maxSize := os.Getenv("API_AUDIT_MAX_SIZE"); maxSize != "" {
+		if n, err := strconv.ParseInt(maxSize, 10, 64); err == nil && n >= 0 {
+			c.AuditMaxSize = n
+		}
+	}
+
+	if maxFiles := os.Getenv("API_AUDIT_MAX_FILES"); maxFiles != "" {
+		if n, err := strconv.Atoi(maxFiles); err == nil && n >= 0 {
+			c.AuditMaxFiles = n
+		}
+	}
+
+	if maxBody := os.Getenv("API_MAX_BODY_SIZE"); maxBody != "" {
+		if n, err := strconv.ParseInt(maxBody, 10, 64); err == nil && n >= 0 {
+			c.MaxBodySize = n
+		}
+	}
+
+	if rateLimit := os.Getenv("API_RATE_LIMIT"); rateLimit != "" {
+		if n, err := strconv.Atoi(rateLimit); err == nil && n >= 0 {
+			c.RateLimit = n
+		}
+	}
+
+	if rateBurst := os.Getenv("API_RATE_BURST"); rateBurst != "" {
+		if n, err := strconv.Atoi(rateBurst); err == nil && n >= 0 {
+			c.RateBurst = n
+		}
+	}
+
+	if authFailures := os.Getenv("API_AUTH_FAILURE_LIMIT"); authFailures != "" {
+		if n, err := strconv.Atoi(authFailures); err == nil && n >= 0 {
+			c.AuthFailureLimit = n
+		}
+	}
+
 	if origins := os.Getenv("API_CORS_ORIGINS"); origins != "" {
 		c.CORSOrigins = strings.Split(origins, ",")
 		for i, origin := range c.CORSOrigins {
@@ -99,6 +263,76 @@ func (c *ServerConfig) LoadFromEnv() {
 			c.IdleTimeout = t
 		}
 	}
+
+	if retentionDays := os.Getenv("API_RETENTION_DAYS"); retentionDays != "" {
+		if d, err := strconv.Atoi(retentionDays); err == nil && d >= 0 {
+			c.RetentionDays = d
+		}
+	}
+
+	if pruneFiles := os.Getenv("API_RETENTION_PRUNE_FILES"); pruneFiles != "" {
+		if b, err := strconv.ParseBool(pruneFiles); err == nil {
+			c.RetentionPruneFiles = b
+		}
+	}
+
+	if hostDelay := os.Getenv("API_HOST_DELAY"); hostDelay != "" {
+		if d, err := time.ParseDuration(hostDelay); err == nil && d >= 0 {
+			c.HostDelay = d
+		}
+	}
+
+	if polite := os.Getenv("API_POLITE"); polite != "" {
+		if b, err := strconv.ParseBool(polite); err == nil {
+			c.Polite = b
+		}
+	}
+
+	if minDelay := os.Getenv("API_POLITE_MIN_DELAY"); minDelay != "" {
+		if d, err := time.ParseDuration(minDelay); err == nil && d >= 0 {
+			c.PoliteMinDelay = d
+		}
+	}
+
+	if hostConcurrency := os.Getenv("API_POLITE_HOST_CONCURRENCY"); hostConcurrency != "" {
+		if n, err := strconv.Atoi(hostConcurrency); err == nil && n >= 0 {
+			c.PoliteHostConcurrency = n
+		}
+	}
+
+	if userAgent := os.Getenv("API_POLITE_USER_AGENT"); userAgent != "" {
+		c.PoliteUserAgent = userAgent
+	}
+
+	if presetsDir := os.Getenv("API_PRESETS_DIR"); presetsDir != "" {
+		c.PresetsDir = presetsDir
+	}
+
+	if schedulesFile := os.Getenv("API_SCHEDULES_FILE"); schedulesFile != "" {
+		c.SchedulesFile = schedulesFile
+	}
+
+	if runSchedules := os.Getenv("API_RUN_SCHEDULES"); runSchedules != "" {
+		if b, err := strconv.ParseBool(runSchedules); err == nil {
+			c.RunSchedules = b
+		}
+	}
+
+	if allow := os.Getenv("API_ALLOW_COMMANDS"); allow != "" {
+		if b, err := strconv.ParseBool(allow); err == nil {
+			c.AllowCommands = b
+		}
+	}
+
+	if owner := os.Getenv("API_CHOWN"); owner != "" {
+		c.OutputOwner = owner
+	}
+
+	if debug := os.Getenv("API_DEBUG"); debug != "" {
+		if b, err := strconv.ParseBool(debug); err == nil {
+			c.Debug = b
+		}
+	}
 }
 
 // Validate checks that the configuration is valid
@@ -111,12 +345,96 @@ func (c *ServerConfig) Validate() error {
 		return APIError{Code: 500, Message: "invalid max concurrent jobs", Details: "must be at least 1"}
 	}
 
+	if c.RetentionDays < 0 {
+		return APIError{Code: 500, Message: "invalid retention days", Details: "cannot be negative"}
+	}
+
+	if c.MaxBodySize < 0 {
+		return APIError{Code: 500, Message: "invalid max body size", Details: "cannot be negative"}
+	}
+
+	if c.AuditMaxSize < 0 || c.AuditMaxFiles < 0 {
+		return APIError{Code: 500, Message: "invalid audit log rotation", Details: "cannot be negative"}
+	}
+
+	if c.RateLimit < 0 || c.RateBurst < 0 {
+		return APIError{Code: 500, Message: "invalid rate limit", Details: "cannot be negative"}
+	}
+
+	if c.AuthFailureLimit < 0 {
+		return APIError{Code: 500, Message: "invalid auth failure limit", Details: "cannot be negative"}
+	}
+
+	if c.HostDelay < 0 {
+		return APIError{Code: 500, Message: "invalid host delay", Details: "cannot be negative"}
+	}
+
+	if policy := c.PolitePolicy(); policy != nil {
+		if err := policy.Validate(); err != nil {
+			return APIError{Code: 500, Message: "invalid polite mode", Details: err.Error()}
+		}
+	}
+
+	if err := (crawler.OutputPermissions{Owner: c.OutputOwner}).Validate(); err != nil {
+		return APIError{Code: 500, Message: "invalid output owner", Details: err.Error()}
+	}
+
+	names := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, k := range c.Keys() {
+		if k.Name == "" || k.Key == "" {
+			return APIError{Code: 500, Message: "invalid API key", Details: "every API key needs a name and a key"}
+		}
+		if names[k.Name] || keys[k.Key] {
+			return APIError{Code: 500, Message: "invalid API key", Details: fmt.Sprintf("duplicate API key %q", k.Name)}
+		}
+		if k.RateLimit < 0 {
+			return APIError{Code: 500, Message: "invalid API key", Details: fmt.Sprintf("rate limit of API key %q cannot be negative", k.Name)}
+		}
+		if k.Quota.DailyBytes < 0 || k.Quota.DailyPages < 0 || k.Quota.MonthlyBytes < 0 || k.Quota.MonthlyPages < 0 {
+			return APIError{Code: 500, Message: "invalid API key", Details: fmt.Sprintf("quota of API key %q cannot be negative", k.Name)}
+		}
+		names[k.Name] = true
+		keys[k.Key] = true
+	}
+
 	return nil
 }
 
 // HasAuth returns true if API key authentication is enabled
 func (c *ServerConfig) HasAuth() bool {
-	return c.APIKey != ""
+	return c.APIKey != "" || len(c.APIKeys) > 0
+}
+
+// Keys returns every accepted API key: APIKey as the unlimited admin key
+// "default", followed by the named APIKeys
+func (c *ServerConfig) Keys() []APIKeyConfig {
+	var keys []APIKeyConfig
+	if c.APIKey != "" {
+		keys = append(keys, APIKeyConfig{Name: "default", Key: c.APIKey, Admin: true})
+	}
+	return append(keys, c.APIKeys...)
+}
+
+// RetentionPolicy returns the job retention policy described by the config
+func (c *ServerConfig) RetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		MaxAge:     time.Duration(c.RetentionDays) * 24 * time.Hour,
+		PruneFiles: c.RetentionPruneFiles,
+	}
+}
+
+// HasRateLimit returns true if the server or any API key limits its rate
+func (c *ServerConfig) HasRateLimit() bool {
+	if c.RateLimit > 0 {
+		return true
+	}
+	for _, k := range c.APIKeys {
+		if k.RateLimit > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 // HasCORS returns true if CORS is configured
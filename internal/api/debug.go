@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jobLabel is the pprof label carrying the job ID on the goroutines a job
+// starts, and on those they start in turn
+const jobLabel = "job"
+
+// Sampling of the mutex and block profiles while diagnostics are enabled:
+// one in mutexProfileFraction contended locks, and blocking events about
+// once per blockProfileRate spent blocked
+const (
+	mutexProfileFraction = 10
+	blockProfileRate     = int(time.Millisecond)
+)
+
+// goroutineGroup matches the "<count> @ <pcs>" line starting each group of
+// identical stacks in the text goroutine profile
+var goroutineGroup = regexp.MustCompile(`^(\d+) @`)
+
+// Runtime reports the process's goroutines and heap, with the goroutines of
+// each job counted through the job's pprof label
+func (m *JobManager) Runtime() RuntimeResponse {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	total, byJob := goroutinesByLabel(jobLabel)
+	resp := RuntimeResponse{
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: total,
+		Unowned:    total,
+		Heap: HeapStats{
+			Alloc:      mem.HeapAlloc,
+			InUse:      mem.HeapInuse,
+			Idle:       mem.HeapIdle,
+			Released:   mem.HeapReleased,
+			Sys:        mem.Sys,
+			Objects:    mem.HeapObjects,
+			NextGC:     mem.NextGC,
+			NumGC:      mem.NumGC,
+			PauseTotal: time.Duration(mem.PauseTotalNs).String(),
+		},
+		Jobs: []JobRuntime{},
+	}
+	if mem.LastGC > 0 {
+		last := time.Unix(0, int64(mem.LastGC))
+		resp.Heap.LastGC = &last
+	}
+
+	m.mu.RLock()
+	for id, n := range byJob {
+		status := JobStatus("removed")
+		if job, ok := m.jobs[id]; ok {
+			status = job.GetStatus()
+		}
+		resp.Jobs = append(resp.Jobs, JobRuntime{ID: id, Status: status, Goroutines: n})
+		resp.Unowned -= n
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(resp.Jobs, func(i, j int) bool {
+		if resp.Jobs[i].Goroutines != resp.Jobs[j].Goroutines {
+			return resp.Jobs[i].Goroutines > resp.Jobs[j].Goroutines
+		}
+		return resp.Jobs[i].ID < resp.Jobs[j].ID
+	})
+	return resp
+}
+
+// EnableContentionProfiling starts sampling lock contention and blocking,
+// which the mutex and block profiles of /debug/pprof/ are empty without
+func EnableContentionProfiling() {
+	runtime.SetMutexProfileFraction(mutexProfileFraction)
+	runtime.SetBlockProfileRate(blockProfileRate)
+}
+
+// goroutinesByLabel counts all goroutines and, by value, those carrying the
+// pprof label key. Labels are only in the text form of the goroutine
+// profile, where each group of identical stacks is followed by
+// "# labels: {...}" when its goroutines have labels.
+func goroutinesByLabel(key string) (int, map[string]int) {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+
+	total := 0
+	counts := make(map[string]int)
+	group := 0
+	scanner := bufio.NewScanner(&buf)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := goroutineGroup.FindStringSubmatch(line); m != nil {
+			group, _ = strconv.Atoi(m[1])
+			total += group
+			continue
+		}
+		if labels, ok := strings.CutPrefix(line, "# labels: "); ok {
+			var values map[string]string
+			if json.Unmarshal([]byte(labels), &values) == nil && values[key] != "" {
+				counts[values[key]] += group
+			}
+		}
+	}
+	return total, counts
+}
+
+// DebugAccess middleware guards the diagnostics endpoints: they need an
+// admin key when the server has authentication, and a client on the same
+// machine when it has none
+func DebugAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := APIKeyFromContext(r.Context()); ok {
+			if !key.Admin {
+				writeError(w, APIError{Code: 403, Message: "diagnostics require an admin key"})
+				return
+			}
+		} else if !isLoopback(r.RemoteAddr) {
+			writeError(w, APIError{Code: 403, Message: "diagnostics are only served to local clients", Details: "set an API key to reach them remotely"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback reports whether a request's remote address is on this machine
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
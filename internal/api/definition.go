@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"scraper/internal/crawler"
+)
+
+// JobDefinitionVersion is the current job definition format version
+const JobDefinitionVersion = 1
+
+// JobDefinition is a portable description of a crawl job that can be shared
+// between environments and used to start an identical job elsewhere
+type JobDefinition struct {
+	Version     int          `json:"version"`
+	ExportedAt  time.Time    `json:"exportedAt"`
+	SourceJobID string       `json:"sourceJobId,omitempty"`
+	Request     CrawlRequest `json:"request"`
+}
+
+// NewJobDefinition wraps a crawl request in a definition. Settings tied to
+// the exporting environment (output directory, state file, cassette file,
+// decision trace file, the retention keep flag and re-crawl settings) are
+// left out.
+func NewJobDefinition(req CrawlRequest, sourceJobID string) JobDefinition {
+	return JobDefinition{
+		Version:     JobDefinitionVersion,
+		ExportedAt:  time.Now(),
+		SourceJobID: sourceJobID,
+		Request:     portableRequest(req),
+	}
+}
+
+// portableRequest clears the settings of req tied to the environment it
+// was made in, for definitions and presets
+func portableRequest(req CrawlRequest) CrawlRequest {
+	req.OutputDir = ""
+	req.StateFile = ""
+	req.StateMerge = false
+	req.CassetteFile = ""
+	req.TraceDecisions = ""
+	req.Keep = false
+	req.RecrawlURLs = nil
+	req.RecrawlScope = ""
+	req.ParentJobID = ""
+	return req
+}
+
+// ParseJobDefinition decodes a job definition and returns its crawl request.
+// A bare CrawlRequest object is accepted as well, so hand-written configs
+// can be imported directly.
+func ParseJobDefinition(data []byte) (*CrawlRequest, error) {
+	var probe struct {
+		Version int             `json:"version"`
+		Request json.RawMessage `json:"request"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, APIError{Code: 400, Message: "invalid JSON", Details: err.Error()}
+	}
+
+	if probe.Version > JobDefinitionVersion {
+		return nil, APIError{
+			Code:    400,
+			Message: "unsupported definition version",
+			Details: fmt.Sprintf("version %d is newer than supported version %d", probe.Version, JobDefinitionVersion),
+		}
+	}
+
+	var req CrawlRequest
+	if len(probe.Request) > 0 {
+		var def JobDefinition
+		if err := unmarshalStrict(data, &def); err != nil {
+			return nil, APIError{Code: 400, Message: "invalid definition", Details: err.Error()}
+		}
+		req = def.Request
+	} else if err := unmarshalStrict(data, &req); err != nil {
+		return nil, APIError{Code: 400, Message: "invalid definition", Details: err.Error()}
+	}
+
+	if err := ValidateCrawlRequest(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// RequestFromConfig converts a crawler config into the equivalent crawl
+// request, so crawls configured outside the API can be saved as definitions
+func RequestFromConfig(cfg *crawler.Config) CrawlRequest {
+	headless := cfg.Headless
+	indexInterval := cfg.IndexInterval
+	normalizeURLs := cfg.NormalizeURLs
+
+	req := CrawlRequest{
+		URL:                      cfg.URL,
+		MaxDepth:                 cfg.MaxDepth,
+		DepthMode:                cfg.DepthMode,
+		MaxPages:                 cfg.MaxPages,
+		MaxConsecutiveErrors:     cfg.MaxConsecutiveErrors,
+		StopPattern:              cfg.StopPattern,
+		Concurrent:               cfg.Concurrent,
+		Workers:                  cfg.Workers,
+		Delay:                    cfg.Delay.String(),
+		OutputDir:                cfg.OutputDir,
+		StateFile:                cfg.StateFile,
+		StateMerge:               cfg.StateMerge,
+		FrontierLimit:            cfg.FrontierLimit,
+		PrefixFilterURL:          cfg.PrefixFilterURL,
+		ExcludeExtensions:        cfg.ExcludeExtensions,
+		AllowedHosts:             cfg.AllowedHosts,
+		DeniedHosts:              cfg.DeniedHosts,
+		ExternalLinks:            cfg.ExternalLinks,
+		Report:                   cfg.Report,
+		LinkSelectors:            cfg.LinkSelectors,
+		Verbose:                  cfg.Verbose,
+		UserAgent:                cfg.UserAgent,
+		UserAgentPool:            cfg.UserAgentPool,
+		UserAgents:               cfg.UserAgents,
+		StickyUserAgent:          cfg.StickyUserAgent,
+		WarmUp:                   cfg.WarmUp,
+		WarmUpPages:              cfg.WarmUpPages,
+		RefererPolicy:            cfg.RefererPolicy,
+		IgnoreRobots:             cfg.IgnoreRobots,
+		MinContentLength:         cfg.MinContentLength,
+		MaxContentLength:         cfg.MaxContentLength,
+		MinWords:                 cfg.MinWords,
+		MaxWords:                 cfg.MaxWords,
+		MaxLinkDensity:           cfg.MaxLinkDensity,
+		MaxHTMLSize:              cfg.MaxHTMLSize,
+		DisableContentExtraction: cfg.DisableContentExtraction,
+		CompressOutput:           cfg.CompressOutput,
+		FetchMode:                string(cfg.FetchMode),
+		Headless:                 &headless,
+		WaitForLogin:             cfg.WaitForLogin,
+		HARMode:                  cfg.HARMode,
+		Cassette:                 cfg.Cassette,
+		CassetteFile:             cfg.CassetteFile,
+		Notify:                   cfg.Notify,
+		TraceDecisions:           cfg.TraceDecisions,
+		ResultsDB:                cfg.ResultsDB,
+		EventSinks:               cfg.EventSinks,
+		DiscoverAPIs:             cfg.DiscoverAPIs,
+		PageScripts:              cfg.PageScripts,
+		KeepCookieBanners:        cfg.KeepCookieBanners,
+		LazyLoadScroll:           cfg.LazyLoadScroll,
+		ScrollStep:               cfg.ScrollStep,
+		ShadowDOM:                cfg.ShadowDOM,
+		BrowserRecycle:           cfg.BrowserRecycle,
+		BrowserPath:              cfg.BrowserPath,
+		BrowserMaxMemory:         cfg.BrowserMaxMemory,
+		BrowserMaxTabs:           cfg.BrowserMaxTabs,
+		BrowserMaxProcesses:      cfg.BrowserMaxProcesses,
+		Iframes:                  cfg.Iframes,
+		IframeHosts:              cfg.IframeHosts,
+		Provenance:               cfg.Provenance,
+		ContentAddressable:       cfg.ContentAddressable,
+		ContentFilters:           cfg.ContentFilters,
+		FollowOnly:               cfg.FollowOnly,
+		Alerts:                   cfg.Alerts,
+		PluginsDir:               cfg.PluginsDir,
+		RecrawlURLs:              cfg.RecrawlURLs,
+		RecrawlScope:             cfg.RecrawlScope,
+		IndexInterval:            &indexInterval,
+		NormalizeURLs:            &normalizeURLs,
+		LowercasePaths:           cfg.LowercasePaths,
+	}
+	if cfg.MetricsInterval > 0 {
+		req.MetricsInterval = cfg.MetricsInterval.String()
+	}
+	if cfg.MaxRuntime > 0 {
+		req.MaxRuntime = cfg.MaxRuntime.String()
+	}
+	if cfg.URLTimeout > 0 {
+		req.URLTimeout = cfg.URLTimeout.String()
+	}
+	if cfg.PageLoadWait > 0 {
+		req.PageLoadWait = cfg.PageLoadWait.String()
+	}
+	if cfg.ScrollDelay > 0 {
+		req.ScrollDelay = cfg.ScrollDelay.String()
+	}
+	if cfg.Pagination.Enable {
+		req.Pagination = &PaginationConfig{
+			Enable:          true,
+			Selector:        cfg.Pagination.Selector,
+			MaxClicks:       cfg.Pagination.MaxClicks,
+			WaitAfterClick:  cfg.Pagination.WaitAfterClick.String(),
+			WaitSelector:    cfg.Pagination.WaitSelector,
+			StopOnDuplicate: cfg.Pagination.StopOnDuplicate,
+			HarvestURLs:     cfg.Pagination.HarvestURLs,
+		}
+	}
+	if cfg.AntiBot != (crawler.AntiBotConfig{}) {
+		antiBot := AntiBotConfig(cfg.AntiBot)
+		req.AntiBot = &antiBot
+	}
+	if cfg.Geo != (crawler.GeoConfig{}) {
+		geo := GeoConfig(cfg.Geo)
+		req.Geo = &geo
+	}
+	if cfg.Permissions != (crawler.OutputPermissions{}) {
+		permissions := OutputPermissions(cfg.Permissions)
+		req.Permissions = &permissions
+	}
+	if cfg.Tracing.Enabled() {
+		req.Tracing = &TracingConfig{Endpoint: cfg.Tracing.Endpoint, SampleRate: cfg.Tracing.SampleRate}
+	}
+	if cfg.Embeddings.Enabled() {
+		embeddings := EmbeddingsConfig(cfg.Embeddings)
+		req.Embeddings = &embeddings
+	}
+	if e := cfg.Enrichment; e.Enabled() {
+		req.Enrichment = &EnrichmentConfig{Endpoint: e.Endpoint, APIKey: e.APIKey, Concurrency: e.Concurrency}
+		if e.Timeout > 0 {
+			req.Enrichment.Timeout = e.Timeout.String()
+		}
+	}
+	req.PostSaveCommand = cfg.PostSaveCommand
+	req.PostSaveConcurrency = cfg.PostSaveConcurrency
+	if cfg.PostSaveTimeout > 0 {
+		req.PostSaveTimeout = cfg.PostSaveTimeout.String()
+	}
+	req.PostSaveOnFailure = cfg.PostSaveOnFailure
+	req.WaybackFallback = cfg.WaybackFallback
+	req.WaybackSave = cfg.WaybackSave
+	req.WaybackSaveKey = cfg.WaybackSaveKey
+	if cfg.WaybackSaveInterval > 0 {
+		req.WaybackSaveInterval = cfg.WaybackSaveInterval.String()
+	}
+	if cfg.Faults != (crawler.FaultConfig{}) {
+		req.Faults = &FaultConfig{
+			ErrorRate:    cfg.Faults.ErrorRate,
+			TruncateRate: cfg.Faults.TruncateRate,
+			ResetRate:    cfg.Faults.ResetRate,
+			Seed:         cfg.Faults.Seed,
+		}
+		if cfg.Faults.Latency > 0 {
+			req.Faults.Latency = cfg.Faults.Latency.String()
+		}
+	}
+	return req
+}
+
+// ExportJobDefinition returns the definition of an existing job, reflecting
+// any runtime changes made to its settings
+func (m *JobManager) ExportJobDefinition(jobID string) (JobDefinition, error) {
+	m.mu.RLock()
+	job, exists := m.jobs[jobID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return JobDefinition{}, APIError{Code: 404, Message: "job not found"}
+	}
+
+	job.mu.Lock()
+	req := *job.Config
+	job.mu.Unlock()
+
+	return NewJobDefinition(req, job.ID), nil
+}
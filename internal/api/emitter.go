@@ -1,36 +1,52 @@
 package api
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"scraper/internal/crawler"
 )
 
+// SSEReplayBufferSize is the number of recent events kept for clients that
+// connect late or reconnect
+const SSEReplayBufferSize = 500
+
 // SSEEmitter implements crawler.EventEmitter and broadcasts events to SSE clients
 type SSEEmitter struct {
-	mu      sync.RWMutex
-	clients map[chan SSEEvent]struct{}
-	closed  bool
+	mu           sync.RWMutex
+	clients      map[chan SSEEvent]SSEClientInfo
+	closed       bool
+	lastClientID int
+	nextID       uint64
+	history      []SSEEvent // Most recent events, oldest first, bounded by SSEReplayBufferSize
 }
 
 // NewSSEEmitter creates a new SSE event emitter
 func NewSSEEmitter() *SSEEmitter {
 	return &SSEEmitter{
-		clients: make(map[chan SSEEvent]struct{}),
+		clients: make(map[chan SSEEvent]SSEClientInfo),
 	}
 }
 
 // Emit implements crawler.EventEmitter interface
 // It broadcasts the crawler event to all connected SSE clients
 func (e *SSEEmitter) Emit(event crawler.CrawlerEvent) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
 	if e.closed {
 		return
 	}
 
+	e.nextID++
 	sseEvent := FromCrawlerEvent(event)
+	sseEvent.ID = e.nextID
+
+	e.history = append(e.history, sseEvent)
+	if len(e.history) > SSEReplayBufferSize {
+		e.history = e.history[len(e.history)-SSEReplayBufferSize:]
+	}
 
 	// Non-blocking broadcast to all clients
 	for clientChan := range e.clients {
@@ -47,19 +63,38 @@ func (e *SSEEmitter) Emit(event crawler.CrawlerEvent) {
 // Subscribe creates a new client channel for receiving events
 // Returns the channel and a cleanup function
 func (e *SSEEmitter) Subscribe() (<-chan SSEEvent, func()) {
+	ch, _, unsubscribe := e.SubscribeSince(nil, SSEClientInfo{})
+	return ch, unsubscribe
+}
+
+// SubscribeSince subscribes like Subscribe and also returns the buffered
+// events with an ID greater than *since. A nil since skips the replay.
+// Replay and subscription happen atomically, so no event is missed or
+// delivered twice. On a closed emitter the replay still includes the
+// terminal events, followed by a closed channel. info describes the client
+// for ClientInfo; its ID and ConnectedAt are assigned here.
+func (e *SSEEmitter) SubscribeSince(since *uint64, info SSEClientInfo) (<-chan SSEEvent, []SSEEvent, func()) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	var replay []SSEEvent
+	if since != nil {
+		replay = e.eventsSince(*since)
+	}
+
 	if e.closed {
 		// Return a closed channel if emitter is closed
 		ch := make(chan SSEEvent)
 		close(ch)
-		return ch, func() {}
+		return ch, replay, func() {}
 	}
 
 	// Buffer size of 100 to prevent blocking during bursts
 	clientChan := make(chan SSEEvent, 100)
-	e.clients[clientChan] = struct{}{}
+	e.lastClientID++
+	info.ID = e.lastClientID
+	info.ConnectedAt = time.Now()
+	e.clients[clientChan] = info
 
 	// Return unsubscribe function
 	unsubscribe := func() {
@@ -71,7 +106,28 @@ func (e *SSEEmitter) Subscribe() (<-chan SSEEvent, func()) {
 		}
 	}
 
-	return clientChan, unsubscribe
+	return clientChan, replay, unsubscribe
+}
+
+// EventsSince returns the buffered events with an ID greater than since
+func (e *SSEEmitter) EventsSince(since uint64) []SSEEvent {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.eventsSince(since)
+}
+
+// eventsSince returns a copy of the buffered events after since. Caller must hold e.mu.
+func (e *SSEEmitter) eventsSince(since uint64) []SSEEvent {
+	start := len(e.history)
+	for i, ev := range e.history {
+		if ev.ID > since {
+			start = i
+			break
+		}
+	}
+	events := make([]SSEEvent, len(e.history)-start)
+	copy(events, e.history[start:])
+	return events
 }
 
 // ClientCount returns the number of connected clients
@@ -81,6 +137,21 @@ func (e *SSEEmitter) ClientCount() int {
 	return len(e.clients)
 }
 
+// ClientInfo returns the connected clients, oldest first
+func (e *SSEEmitter) ClientInfo() []SSEClientInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	clients := make([]SSEClientInfo, 0, len(e.clients))
+	for _, info := range e.clients {
+		clients = append(clients, info)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
+}
+
 // Close closes all client channels and prevents new subscriptions
 func (e *SSEEmitter) Close() {
 	e.mu.Lock()
@@ -96,7 +167,7 @@ func (e *SSEEmitter) Close() {
 	for clientChan := range e.clients {
 		close(clientChan)
 	}
-	e.clients = make(map[chan SSEEvent]struct{})
+	e.clients = make(map[chan SSEEvent]SSEClientInfo)
 }
 
 // IsClosed returns whether the emitter has been closed
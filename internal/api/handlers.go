@@ -1,12 +1,22 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
-	"sort"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"scraper/internal/crawler"
+
 	"github.com/go-chi/chi/v5"
 )
 
@@ -15,6 +25,8 @@ type Handlers struct {
 	JobManager *JobManager
 	StartTime  time.Time
 	Version    string
+	Presets    *PresetStore // Presets served under /api/v1/presets; nil disables them
+	Scheduler  *Scheduler   // Schedules served under /api/v1/schedules; nil disables them
 }
 
 // NewHandlers creates a new Handlers instance
@@ -48,6 +60,40 @@ func writeError(w http.ResponseWriter, err error) {
 	})
 }
 
+// readBody reads a request body, reporting bodies over the MaxBodySize limit as 413
+func readBody(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, APIError{
+				Code:    413,
+				Message: "request body too large",
+				Details: fmt.Sprintf("request bodies are limited to %d bytes", tooLarge.Limit),
+			}
+		}
+		return nil, APIError{Code: 400, Message: "failed to read request body"}
+	}
+	return body, nil
+}
+
+// decodeBody strictly decodes a JSON request body into v; unknown fields are
+// rejected. An empty body leaves v untouched when optional is set.
+func decodeBody(r *http.Request, v interface{}, optional bool) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	if optional && len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err := unmarshalStrict(body, v); err != nil {
+		return APIError{Code: 400, Message: "invalid JSON", Details: err.Error()}
+	}
+	return nil
+}
+
 // HealthCheck handles GET /health
 func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	uptime := time.Since(h.StartTime)
@@ -57,176 +103,1465 @@ func (h *Handlers) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		Version:    h.Version,
 		Uptime:     formatUptime(uptime),
 		ActiveJobs: h.JobManager.ActiveJobCount(),
+		Polite:     h.JobManager.PolitePolicy(),
 	})
 }
 
-// CreateCrawl handles POST /api/v1/crawl
-func (h *Handlers) CreateCrawl(w http.ResponseWriter, r *http.Request) {
-	// Parse request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		writeError(w, APIError{Code: 400, Message: "failed to read request body"})
+// GetUsage handles GET /api/v1/usage
+// Non-admin API keys see their own usage; admin keys (and servers without
+// authentication) see every key, optionally narrowed with ?key=<name>.
+func (h *Handlers) GetUsage(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("key")
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		if name != "" && name != key.Name {
+			writeError(w, APIError{Code: 403, Message: "usage of other API keys requires an admin key"})
+			return
+		}
+		name = key.Name
+	}
+
+	writeJSON(w, http.StatusOK, UsageResponse{Keys: h.JobManager.Usage(name, time.Now())})
+}
+
+// GetAudit handles GET /api/v1/audit
+// Lists audited operations, most recent first, narrowed with ?key=<name>,
+// ?jobId=<id>, ?since=<RFC 3339 time> and ?limit=<n> (default 100). Only
+// admin keys (and servers without authentication) may read it.
+func (h *Handlers) GetAudit(w http.ResponseWriter, r *http.Request) {
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		writeError(w, APIError{Code: 403, Message: "the audit log requires an admin key"})
+		return
+	}
+	audit := h.JobManager.AuditLog()
+	if audit == nil {
+		writeError(w, APIError{Code: 404, Message: "audit log not enabled", Details: "start the server with --audit-log"})
 		return
 	}
-	defer r.Body.Close()
 
-	var req CrawlRequest
-	if err := json.Unmarshal(body, &req); err != nil {
-		writeError(w, APIError{Code: 400, Message: "invalid JSON", Details: err.Error()})
+	q := r.URL.Query()
+	filter := AuditFilter{Key: q.Get("key"), JobID: q.Get("jobId"), Limit: 100}
+	if v := q.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid since", Details: err.Error()})
+			return
+		}
+		filter.Since = since
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, APIError{Code: 400, Message: "invalid limit", Details: "must be a non-negative number"})
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, total, err := audit.Entries(filter)
+	if err != nil {
+		writeError(w, APIError{Code: 500, Message: "failed to read audit log", Details: err.Error()})
 		return
 	}
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+	writeJSON(w, http.StatusOK, AuditResponse{Total: total, Entries: entries})
+}
+
+// GetRuntime handles GET /api/v1/debug/runtime
+// Reports goroutines and heap statistics, with the goroutines of each job.
+func (h *Handlers) GetRuntime(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.JobManager.Runtime())
+}
 
-	// Validate URL is provided
-	if req.URL == "" {
-		writeError(w, APIError{Code: 400, Message: "url is required"})
+// RunSelfTest handles POST /api/v1/selftest
+// Crawls synthetic sites served on a local port and reports each check.
+func (h *Handlers) RunSelfTest(w http.ResponseWriter, r *http.Request) {
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		writeError(w, APIError{Code: 403, Message: "the self-test requires an admin key"})
 		return
 	}
 
-	// Create the job
-	job, err := h.JobManager.CreateJob(&req)
-	if err != nil {
+	var req SelfTestRequest
+	if err := decodeBody(r, &req, true); err != nil {
 		writeError(w, err)
 		return
 	}
+	for _, name := range req.Checks {
+		if !slices.Contains(crawler.SelfTestNames(), name) {
+			writeError(w, APIError{
+				Code:    400,
+				Message: "unknown self-test check",
+				Details: fmt.Sprintf("%q is not one of %s", name, strings.Join(crawler.SelfTestNames(), ", ")),
+			})
+			return
+		}
+	}
 
-	// Start the job
-	if err := h.JobManager.StartJob(job.ID); err != nil {
-		writeError(w, err)
+	dir, err := os.MkdirTemp("", "scraper-selftest-")
+	if err != nil {
+		writeError(w, APIError{Code: 500, Message: "failed to create self-test directory", Details: err.Error()})
 		return
 	}
+	defer os.RemoveAll(dir)
 
-	writeJSON(w, http.StatusCreated, CrawlResponse{
-		JobID:     job.ID,
-		Status:    job.GetStatus(),
-		CreatedAt: job.CreatedAt,
-	})
+	results, err := crawler.RunSelfTest(r.Context(), dir, req.Checks, nil)
+	if err != nil {
+		writeError(w, APIError{Code: 500, Message: "self-test interrupted", Details: err.Error()})
+		return
+	}
+	resp := SelfTestResponse{Passed: true, Results: results}
+	for _, result := range results {
+		resp.Passed = resp.Passed && result.Passed
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
-// ListCrawls handles GET /api/v1/crawl
-func (h *Handlers) ListCrawls(w http.ResponseWriter, r *http.Request) {
-	jobs := h.JobManager.ListJobs()
-
-	// Sort by creation time (newest first)
-	sort.Slice(jobs, func(i, j int) bool {
-		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
-	})
+// RunAntiBotTest handles POST /api/v1/antibot-test
+// Probes a page in browser mode and reports which fingerprint signals leak.
+func (h *Handlers) RunAntiBotTest(w http.ResponseWriter, r *http.Request) {
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		writeError(w, APIError{Code: 403, Message: "the anti-bot test requires an admin key"})
+		return
+	}
 
-	summaries := make([]JobSummary, len(jobs))
-	for i, job := range jobs {
-		summaries[i] = job.ToSummary()
+	var req AntiBotTestRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+	opts := crawler.AntiBotTestOptions{URL: req.URL, Headless: true, UserAgent: req.UserAgent}
+	if req.AntiBot != nil {
+		opts.AntiBot = crawler.AntiBotConfig(*req.AntiBot)
+	}
+	if req.Headless != nil {
+		opts.Headless = *req.Headless
+	}
+	if err := opts.Validate(); err != nil {
+		writeError(w, APIError{Code: 400, Message: "invalid anti-bot test", Details: err.Error()})
+		return
 	}
 
-	writeJSON(w, http.StatusOK, summaries)
+	result, err := crawler.RunAntiBotTest(r.Context(), opts)
+	if err != nil {
+		writeError(w, APIError{Code: 502, Message: "anti-bot test failed", Details: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
 }
 
-// GetCrawl handles GET /api/v1/crawl/{jobId}
-func (h *Handlers) GetCrawl(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
-
-	job, err := h.JobManager.GetJob(jobID)
+// ListPresets handles GET /api/v1/presets
+func (h *Handlers) ListPresets(w http.ResponseWriter, r *http.Request) {
+	store, err := h.presetStore()
 	if err != nil {
 		writeError(w, err)
 		return
 	}
-
-	writeJSON(w, http.StatusOK, job.ToDetails())
+	presets, err := store.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PresetsResponse{Presets: presets})
 }
 
-// DeleteCrawl handles DELETE /api/v1/crawl/{jobId}
-func (h *Handlers) DeleteCrawl(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
-
-	// First try to stop if running
-	job, err := h.JobManager.GetJob(jobID)
+// GetPreset handles GET /api/v1/presets/{name}
+// Presets saved by older versions are returned upgraded.
+func (h *Handlers) GetPreset(w http.ResponseWriter, r *http.Request) {
+	store, err := h.presetStore()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	p, err := store.Get(chi.URLParam(r, "name"))
 	if err != nil {
 		writeError(w, err)
 		return
 	}
+	writeJSON(w, http.StatusOK, p)
+}
 
-	status := job.GetStatus()
-	if status == JobStatusRunning || status == JobStatusPaused || status == JobStatusWaitingForLogin {
-		// Stop the job first
-		if err := h.JobManager.StopJob(jobID); err != nil {
-			writeError(w, err)
+// SavePreset handles PUT /api/v1/presets/{name}
+// The body is a CrawlRequest; the url may be left out.
+func (h *Handlers) SavePreset(w http.ResponseWriter, r *http.Request) {
+	store, err := h.presetStore()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req CrawlRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+	p := NewPreset(chi.URLParam(r, "name"), req)
+	key, ok := APIKeyFromContext(r.Context())
+	if ok {
+		p.Owner = key.Name
+	}
+	if existing, err := store.Get(p.Name); err == nil {
+		if !CanChangePreset(key, ok, existing) {
+			writeError(w, presetForbidden(existing))
 			return
 		}
-		// Give it a moment to stop
-		time.Sleep(100 * time.Millisecond)
+		p.Owner = existing.Owner
 	}
-
-	// Now delete
-	if err := h.JobManager.DeleteJob(jobID); err != nil {
+	if err := store.Save(p); err != nil {
+		writeError(w, err)
+		return
+	}
+	saved, err := store.Get(p.Name)
+	if err != nil {
 		writeError(w, err)
 		return
 	}
+	writeJSON(w, http.StatusOK, saved)
+}
 
+// DeletePreset handles DELETE /api/v1/presets/{name}
+func (h *Handlers) DeletePreset(w http.ResponseWriter, r *http.Request) {
+	store, err := h.presetStore()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	key, ok := APIKeyFromContext(r.Context())
+	if existing, err := store.Get(chi.URLParam(r, "name")); err == nil && !CanChangePreset(key, ok, existing) {
+		writeError(w, presetForbidden(existing))
+		return
+	}
+	if err := store.Delete(chi.URLParam(r, "name")); err != nil {
+		writeError(w, err)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// PauseCrawl handles POST /api/v1/crawl/{jobId}/pause
-func (h *Handlers) PauseCrawl(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
+// ExportPresets handles GET /api/v1/presets/export
+// Bundles the presets given with ?name= (repeatable), or all of them, into
+// one file.
+func (h *Handlers) ExportPresets(w http.ResponseWriter, r *http.Request) {
+	store, err := h.presetStore()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	bundle, err := store.Export(r.URL.Query()["name"])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="presets.json"`)
+	writeJSON(w, http.StatusOK, bundle)
+}
 
-	if err := h.JobManager.PauseJob(jobID); err != nil {
+// ImportPresets handles POST /api/v1/presets/import
+// The body is a preset bundle or a single preset of any supported version;
+// existing presets are only replaced with ?overwrite=true.
+func (h *Handlers) ImportPresets(w http.ResponseWriter, r *http.Request) {
+	store, err := h.presetStore()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	overwrite, _ := strconv.ParseBool(r.URL.Query().Get("overwrite"))
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	owner := ""
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		owner = key.Name
+	}
+	result, err := store.ImportFor(owner, body, overwrite)
+	if err != nil {
 		writeError(w, err)
 		return
 	}
+	writeJSON(w, http.StatusOK, result)
+}
 
-	job, _ := h.JobManager.GetJob(jobID)
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"jobId":  jobID,
-		"status": job.GetStatus(),
-	})
+// presetForbidden is the error of a change to p refused by CanChangePreset
+func presetForbidden(p *Preset) error {
+	return APIError{Code: 403, Message: "preset belongs to another API key", Details: fmt.Sprintf("preset %s can only be changed by API key %q or an admin key", p.Name, p.Owner)}
 }
 
-// ResumeCrawl handles POST /api/v1/crawl/{jobId}/resume
-func (h *Handlers) ResumeCrawl(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
+// presetStore returns the store of the preset endpoints
+func (h *Handlers) presetStore() (*PresetStore, error) {
+	if h.Presets == nil {
+		return nil, APIError{Code: 503, Message: "presets not configured", Details: "the server has no presets directory"}
+	}
+	return h.Presets, nil
+}
 
-	if err := h.JobManager.ResumeJob(jobID); err != nil {
+// ListSchedules handles GET /api/v1/schedules
+func (h *Handlers) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	scheduler, err := h.scheduler()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	schedules, err := scheduler.Store().List()
+	if err != nil {
 		writeError(w, err)
 		return
 	}
+	key, ok := APIKeyFromContext(r.Context())
+	visible := schedules[:0]
+	for i := range schedules {
+		if CanAccessSchedule(key, ok, &schedules[i]) {
+			visible = append(visible, schedules[i])
+		}
+	}
+	writeJSON(w, http.StatusOK, SchedulesResponse{Running: scheduler.Running(), Schedules: visible})
+}
 
-	job, _ := h.JobManager.GetJob(jobID)
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"jobId":  jobID,
-		"status": job.GetStatus(),
-	})
+// CreateSchedule handles POST /api/v1/schedules
+// The body is a ScheduleRequest; the preset must exist.
+func (h *Handlers) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduler, err := h.scheduler()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var req ScheduleRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+	owner := ""
+	if key, ok := APIKeyFromContext(r.Context()); ok {
+		owner = key.Name
+	}
+	sched, err := scheduler.AddFor(owner, req.Preset, req.URL, req.Spec)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sched)
 }
 
-// ConfirmLogin handles POST /api/v1/crawl/{jobId}/confirm-login
-func (h *Handlers) ConfirmLogin(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
+// GetSchedule handles GET /api/v1/schedules/{scheduleId}
+func (h *Handlers) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduler, err := h.scheduler()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sched, err := accessSchedule(r, scheduler)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sched)
+}
 
-	if err := h.JobManager.ConfirmLogin(jobID); err != nil {
+// DeleteSchedule handles DELETE /api/v1/schedules/{scheduleId}
+func (h *Handlers) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduler, err := h.scheduler()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, err := accessSchedule(r, scheduler); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := scheduler.Store().Remove(chi.URLParam(r, "scheduleId")); err != nil {
 		writeError(w, err)
 		return
 	}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"jobId":   jobID,
-		"message": "login confirmed",
-	})
+// EnableSchedule handles POST /api/v1/schedules/{scheduleId}/enable
+func (h *Handlers) EnableSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setScheduleEnabled(w, r, true)
 }
 
-// GetMetrics handles GET /api/v1/crawl/{jobId}/metrics
-func (h *Handlers) GetMetrics(w http.ResponseWriter, r *http.Request) {
-	jobID := chi.URLParam(r, "jobId")
+// DisableSchedule handles POST /api/v1/schedules/{scheduleId}/disable
+func (h *Handlers) DisableSchedule(w http.ResponseWriter, r *http.Request) {
+	h.setScheduleEnabled(w, r, false)
+}
 
-	job, err := h.JobManager.GetJob(jobID)
+func (h *Handlers) setScheduleEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	scheduler, err := h.scheduler()
 	if err != nil {
 		writeError(w, err)
 		return
 	}
+	if _, err := accessSchedule(r, scheduler); err != nil {
+		writeError(w, err)
+		return
+	}
+	sched, err := scheduler.Store().SetEnabled(chi.URLParam(r, "scheduleId"), enabled)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sched)
+}
 
-	metrics := job.GetMetrics()
-	if metrics == nil {
-		writeError(w, APIError{Code: 404, Message: "metrics not available"})
+// RunSchedule handles POST /api/v1/schedules/{scheduleId}/run
+// The run starts in the background; it is returned while running, with
+// the job it started in the schedule history.
+func (h *Handlers) RunSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduler, err := h.scheduler()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, err := accessSchedule(r, scheduler); err != nil {
+		writeError(w, err)
 		return
 	}
+	run, err := scheduler.RunNow(chi.URLParam(r, "scheduleId"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, run)
+}
 
-	writeJSON(w, http.StatusOK, metrics)
+// GetScheduleHistory handles GET /api/v1/schedules/history
+// Query params: schedule=<id> (default: all schedules), limit=<n> (default 50)
+func (h *Handlers) GetScheduleHistory(w http.ResponseWriter, r *http.Request) {
+	scheduler, err := h.scheduler()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, APIError{Code: 400, Message: "invalid limit", Details: "must be a non-negative number"})
+			return
+		}
+		limit = n
+	}
+	// Non-admin keys only see the runs of their own schedules
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok || key.Admin {
+		runs, err := scheduler.Store().History(r.URL.Query().Get("schedule"), limit)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, ScheduleHistoryResponse{Runs: runs})
+		return
+	}
+	all, err := scheduler.Store().History(r.URL.Query().Get("schedule"), 0)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	runs := []ScheduleRun{}
+	for _, run := range all {
+		if limit > 0 && len(runs) == limit {
+			break
+		}
+		if run.Owner == key.Name {
+			runs = append(runs, run)
+		}
+	}
+	writeJSON(w, http.StatusOK, ScheduleHistoryResponse{Runs: runs})
+}
+
+// accessSchedule returns the schedule of the request's scheduleId, as not
+// found when it belongs to another API key (see CanAccessSchedule)
+func accessSchedule(r *http.Request, scheduler *Scheduler) (*Schedule, error) {
+	id := chi.URLParam(r, "scheduleId")
+	sched, err := scheduler.Store().Get(id)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := APIKeyFromContext(r.Context())
+	if !CanAccessSchedule(key, ok, sched) {
+		return nil, APIError{Code: 404, Message: "schedule not found", Details: id}
+	}
+	return sched, nil
+}
+
+func (h *Handlers) scheduler() (*Scheduler, error) {
+	if h.Scheduler == nil {
+		return nil, APIError{Code: 503, Message: "schedules not configured", Details: "the server has no schedules file or presets directory"}
+	}
+	return h.Scheduler, nil
+}
+
+// ListRecipes handles GET /api/v1/recipes
+// Returns the built-in recipes and those in SCRAPER_RECIPES_DIR.
+func (h *Handlers) ListRecipes(w http.ResponseWriter, r *http.Request) {
+	recipes, err := Recipes()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, RecipesResponse{Recipes: recipes})
+}
+
+// GetRecipe handles GET /api/v1/recipes/{name}
+func (h *Handlers) GetRecipe(w http.ResponseWriter, r *http.Request) {
+	recipe, err := GetRecipe(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, recipe)
+}
+
+// ListPlugins handles GET /api/v1/plugins
+// Query params: dir (plugins directory on the server, as in pluginsDir).
+// Returns 400 when a plugin does not compile.
+func (h *Handlers) ListPlugins(w http.ResponseWriter, r *http.Request) {
+	dir := r.URL.Query().Get("dir")
+	if dir == "" {
+		writeError(w, APIError{Code: 400, Message: "dir is required"})
+		return
+	}
+	if key, ok := APIKeyFromContext(r.Context()); ok {
+		var err error
+		if dir, err = NamespacePath(key, "dir", dir); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	plugins, err := crawler.ListPlugins(dir)
+	if err != nil {
+		writeError(w, APIError{Code: 400, Message: "invalid plugins", Details: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, PluginsResponse{Dir: dir, Plugins: plugins})
+}
+
+// ListSecrets handles GET /api/v1/secrets
+// Returns the names of the stored secrets, never their values.
+func (h *Handlers) ListSecrets(w http.ResponseWriter, r *http.Request) {
+	store, err := openSecretStore(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SecretsResponse{Names: store.Names()})
+}
+
+// SetSecret handles PUT /api/v1/secrets/{name}
+func (h *Handlers) SetSecret(w http.ResponseWriter, r *http.Request) {
+	var req SetSecretRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+	store, err := openSecretStore(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := store.Set(chi.URLParam(r, "name"), req.Value); err != nil {
+		writeError(w, APIError{Code: 400, Message: "invalid secret", Details: err.Error()})
+		return
+	}
+	if err := store.Save(); err != nil {
+		writeError(w, APIError{Code: 500, Message: "failed to save secrets", Details: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, SecretsResponse{Names: store.Names()})
+}
+
+// DeleteSecret handles DELETE /api/v1/secrets/{name}
+func (h *Handlers) DeleteSecret(w http.ResponseWriter, r *http.Request) {
+	store, err := openSecretStore(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	name := chi.URLParam(r, "name")
+	if !store.Delete(name) {
+		writeError(w, APIError{Code: 404, Message: "secret not found", Details: name})
+		return
+	}
+	if err := store.Save(); err != nil {
+		writeError(w, APIError{Code: 500, Message: "failed to save secrets", Details: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, SecretsResponse{Names: store.Names()})
+}
+
+// openSecretStore opens the secrets store for a request, which requires an
+// admin key when the server has authentication
+func openSecretStore(r *http.Request) (*crawler.SecretStore, error) {
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		return nil, APIError{Code: 403, Message: "managing secrets requires an admin key"}
+	}
+	store, err := crawler.OpenDefaultSecretStore()
+	if errors.Is(err, crawler.ErrSecretsKeyMissing) {
+		return nil, APIError{Code: 503, Message: "secrets store not configured", Details: err.Error()}
+	}
+	if err != nil {
+		return nil, APIError{Code: 500, Message: "failed to open secrets store", Details: err.Error()}
+	}
+	return store, nil
+}
+
+// oneShotOwner returns the API key a plan or fetch request is charged to,
+// after confining the request's paths to the key's namespace like those of a
+// job (see NamespaceRequest)
+func oneShotOwner(r *http.Request, req *CrawlRequest) (string, error) {
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok {
+		return AnonymousKey, nil
+	}
+	if err := NamespaceRequest(key, req); err != nil {
+		return "", err
+	}
+	return key.Name, nil
+}
+
+// PlanCrawl handles POST /api/v1/plan
+// The body is a CrawlRequest; the start page is fetched and its links are
+// reported as queued or filtered out, without creating a job.
+func (h *Handlers) PlanCrawl(w http.ResponseWriter, r *http.Request) {
+	var req CrawlRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := ValidateCrawlRequest(&req); err != nil {
+		writeError(w, err)
+		return
+	}
+	owner, err := oneShotOwner(r, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	plan, err := h.JobManager.PlanCrawlFor(r.Context(), owner, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// FetchPage handles POST /api/v1/fetch
+// The body is a CrawlRequest; its start page is fetched with the request's
+// fetch settings and returned as extracted text and Markdown, without
+// creating a job.
+func (h *Handlers) FetchPage(w http.ResponseWriter, r *http.Request) {
+	var req CrawlRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := ValidateCrawlRequest(&req); err != nil {
+		writeError(w, err)
+		return
+	}
+	owner, err := oneShotOwner(r, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	page, err := h.JobManager.FetchPageFor(r.Context(), owner, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// CreateCrawl handles POST /api/v1/crawl
+func (h *Handlers) CreateCrawl(w http.ResponseWriter, r *http.Request) {
+	// Parse request body
+	var req CrawlRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Validate URL is provided and the request is within limits
+	if err := ValidateCrawlRequest(&req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.createAndStart(w, r, &req)
+}
+
+// ImportCrawl handles POST /api/v1/crawl/import
+// The body is a job definition as returned by GET /api/v1/crawl/{jobId}/definition
+// (or a bare CrawlRequest); a new job is created from it and started.
+func (h *Handlers) ImportCrawl(w http.ResponseWriter, r *http.Request) {
+	body, err := readBody(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	req, err := ParseJobDefinition(body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.createAndStart(w, r, req)
+}
+
+// RecrawlCrawl handles POST /api/v1/crawl/{jobId}/recrawl
+// It starts a child job that fetches again the failed, changed or
+// pattern-matching URLs of a finished job into its output directory.
+func (h *Handlers) RecrawlCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var recrawl RecrawlRequest
+	if err := decodeBody(r, &recrawl, false); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	req, err := h.JobManager.RecrawlJobRequest(jobID, recrawl)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h.createAndStart(w, r, req)
+}
+
+// createAndStart creates a job from req charged to the request's API key,
+// starts it and writes the 201 response
+func (h *Handlers) createAndStart(w http.ResponseWriter, r *http.Request, req *CrawlRequest) {
+	owner := AnonymousKey
+	if key, ok := APIKeyFromContext(r.Context()); ok {
+		owner = key.Name
+		if err := NamespaceRequest(key, req); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	// Create the job
+	job, err := h.JobManager.CreateJobFor(owner, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Start the job
+	if err := h.JobManager.StartJob(job.ID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, CrawlResponse{
+		JobID:     job.ID,
+		Status:    job.GetStatus(),
+		CreatedAt: job.CreatedAt,
+		Warnings:  job.GetWarnings(),
+	})
+}
+
+// ListCrawls handles GET /api/v1/crawl
+// Query params: status, tag, url_contains, sort (created|url|status),
+// order (asc|desc), limit, offset. The total number of matching jobs is
+// returned in the X-Total-Count header.
+func (h *Handlers) ListCrawls(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := JobListOptions{
+		Status:      JobStatus(q.Get("status")),
+		Tag:         q.Get("tag"),
+		URLContains: q.Get("url_contains"),
+		Sort:        q.Get("sort"),
+		Order:       q.Get("order"),
+	}
+	for name, dst := range map[string]*int{"limit": &opts.Limit, "offset": &opts.Offset} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				writeError(w, APIError{Code: 400, Message: "invalid " + name, Details: err.Error()})
+				return
+			}
+			*dst = n
+		}
+	}
+	// Non-admin keys only see their own jobs
+	if key, ok := APIKeyFromContext(r.Context()); ok && !key.Admin {
+		opts.Owner = key.Name
+	}
+
+	jobs, total, err := h.JobManager.QueryJobs(opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	summaries := make([]JobSummary, len(jobs))
+	for i, job := range jobs {
+		summaries[i] = job.ToSummary()
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// GetCrawl handles GET /api/v1/crawl/{jobId}
+func (h *Handlers) GetCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	job, err := h.JobManager.GetJob(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job.ToDetails())
+}
+
+// DeleteCrawl handles DELETE /api/v1/crawl/{jobId}
+func (h *Handlers) DeleteCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	// First try to stop if running
+	job, err := h.JobManager.GetJob(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	status := job.GetStatus()
+	if status == JobStatusRunning || status == JobStatusPaused || status == JobStatusWaitingForLogin {
+		// Stop the job first
+		if err := h.JobManager.StopJob(jobID); err != nil {
+			writeError(w, err)
+			return
+		}
+		// Give it a moment to stop
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Now delete
+	if err := h.JobManager.DeleteJob(jobID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PauseCrawl handles POST /api/v1/crawl/{jobId}/pause
+func (h *Handlers) PauseCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	if err := h.JobManager.PauseJob(jobID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	job, _ := h.JobManager.GetJob(jobID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobId":  jobID,
+		"status": job.GetStatus(),
+	})
+}
+
+// ResumeCrawl handles POST /api/v1/crawl/{jobId}/resume
+func (h *Handlers) ResumeCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	if err := h.JobManager.ResumeJob(jobID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	job, _ := h.JobManager.GetJob(jobID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobId":  jobID,
+		"status": job.GetStatus(),
+	})
+}
+
+// SetWorkers handles POST /api/v1/crawl/{jobId}/workers
+func (h *Handlers) SetWorkers(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req WorkersRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.JobManager.SetJobWorkers(jobID, req.Workers); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, WorkersResponse{
+		JobID:   jobID,
+		Workers: req.Workers,
+	})
+}
+
+// SetKeep handles POST /api/v1/crawl/{jobId}/keep
+func (h *Handlers) SetKeep(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req KeepRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.JobManager.SetJobKeep(jobID, req.Keep); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, KeepResponse{
+		JobID: jobID,
+		Keep:  req.Keep,
+	})
+}
+
+// UpdateConfig handles PATCH /api/v1/crawl/{jobId}/config
+func (h *Handlers) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req ConfigPatchRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	current, err := h.JobManager.UpdateJobConfig(jobID, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ConfigPatchResponse{
+		JobID:  jobID,
+		Config: current,
+	})
+}
+
+// ConfirmLogin handles POST /api/v1/crawl/{jobId}/confirm-login
+func (h *Handlers) ConfirmLogin(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	if err := h.JobManager.ConfirmLogin(jobID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobId":   jobID,
+		"message": "login confirmed",
+	})
+}
+
+// GetMetrics handles GET /api/v1/crawl/{jobId}/metrics
+func (h *Handlers) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	job, err := h.JobManager.GetJob(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	metrics := job.GetMetrics()
+	if metrics == nil {
+		writeError(w, APIError{Code: 404, Message: "metrics not available"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, metrics)
+}
+
+// ListSSEClients handles GET /api/v1/crawl/{jobId}/clients
+func (h *Handlers) ListSSEClients(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	job, err := h.JobManager.GetJob(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	clients := job.Emitter.ClientInfo()
+	writeJSON(w, http.StatusOK, SSEClientsResponse{
+		JobID:   jobID,
+		Count:   len(clients),
+		Clients: clients,
+	})
+}
+
+// GetMetricsTimeSeries handles GET /api/v1/crawl/{jobId}/metrics/timeseries
+// Pass ?format=csv for a CSV download instead of JSON.
+func (h *Handlers) GetMetricsTimeSeries(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	job, err := h.JobManager.GetJob(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeError(w, APIError{Code: 400, Message: "invalid format", Details: "format must be json or csv"})
+		return
+	}
+
+	series := job.GetMetricsTimeSeries()
+	if series == nil {
+		writeError(w, APIError{Code: 404, Message: "metrics not available"})
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+crawler.MetricsTimeSeriesCSV+"\"")
+		w.WriteHeader(http.StatusOK)
+		crawler.WriteMetricsCSV(w, job.Crawler.MetricsTimeSeries())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, series)
+}
+
+// GetURLInventory handles GET /api/v1/crawl/{jobId}/urls
+// Query params: format (json|csv|jsonl), status, limit, offset.
+func (h *Handlers) GetURLInventory(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "jsonl" {
+		writeError(w, APIError{Code: 400, Message: "invalid format", Details: "format must be json, csv or jsonl"})
+		return
+	}
+
+	query := URLInventoryQuery{Status: q.Get("status")}
+	for name, dst := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				writeError(w, APIError{Code: 400, Message: "invalid " + name, Details: err.Error()})
+				return
+			}
+			*dst = n
+		}
+	}
+
+	resp, records, err := h.JobManager.QueryURLInventory(jobID, query)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+crawler.URLInventoryCSV+"\"")
+		w.WriteHeader(http.StatusOK)
+		crawler.WriteURLInventoryCSV(w, records)
+	case "jsonl":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+crawler.URLInventoryJSONL+"\"")
+		w.WriteHeader(http.StatusOK)
+		crawler.WriteURLInventoryJSONL(w, records)
+	default:
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// GetURLErrors handles GET /api/v1/crawl/{jobId}/errors
+// Query params: class (error class), limit.
+func (h *Handlers) GetURLErrors(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid limit", Details: err.Error()})
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.JobManager.GetJobErrors(jobID, q.Get("class"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RetryURLs handles POST /api/v1/crawl/{jobId}/retry
+func (h *Handlers) RetryURLs(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req RetryRequest
+	if err := decodeBody(r, &req, false); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp, err := h.JobManager.RetryJobErrors(jobID, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetCrawlGraph handles GET /api/v1/crawl/{jobId}/graph
+// Query params: since (version of the last response, for changes only),
+// maxNodes (default 2000, at most 20000), format (json or dot).
+func (h *Handlers) GetCrawlGraph(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "dot" {
+		writeError(w, APIError{Code: 400, Message: "invalid format", Details: "format must be json or dot"})
+		return
+	}
+
+	var since uint64
+	if v := q.Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid since", Details: err.Error()})
+			return
+		}
+		since = n
+	}
+	maxNodes := 0
+	if v := q.Get("maxNodes"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid maxNodes", Details: err.Error()})
+			return
+		}
+		maxNodes = n
+	}
+
+	resp, err := h.JobManager.GetJobGraph(jobID, since, maxNodes)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.WriteHeader(http.StatusOK)
+		crawler.WriteCrawlGraphDOT(w, resp.CrawlGraph)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetRedirects handles GET /api/v1/crawl/{jobId}/redirects
+func (h *Handlers) GetRedirects(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	resp, err := h.JobManager.GetJobRedirects(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetAPIEndpoints handles GET /api/v1/crawl/{jobId}/endpoints
+// Query params: format (json|jsonl), json (true to keep only JSON responses), limit.
+func (h *Handlers) GetAPIEndpoints(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "jsonl" {
+		writeError(w, APIError{Code: 400, Message: "invalid format", Details: "format must be json or jsonl"})
+		return
+	}
+
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid limit", Details: err.Error()})
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.JobManager.GetJobAPIEndpoints(jobID, q.Get("json") == "true", limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format == "jsonl" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+crawler.APIEndpointsFile+"\"")
+		w.WriteHeader(http.StatusOK)
+		crawler.WriteAPIEndpointsJSONL(w, resp.Endpoints)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetExternalLinks handles GET /api/v1/crawl/{jobId}/external-links
+// Query params: format (json|jsonl), host (exact or *.domain), limit.
+func (h *Handlers) GetExternalLinks(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "jsonl" {
+		writeError(w, APIError{Code: 400, Message: "invalid format", Details: "format must be json or jsonl"})
+		return
+	}
+
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid limit", Details: err.Error()})
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.JobManager.GetJobExternalLinks(jobID, q.Get("host"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format == "jsonl" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+crawler.ExternalLinksFile+"\"")
+		w.WriteHeader(http.StatusOK)
+		crawler.WriteExternalLinksJSONL(w, resp.Links)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetAlerts handles GET /api/v1/crawl/{jobId}/alerts
+// Query params: rule (alert rule name), limit.
+func (h *Handlers) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid limit", Details: err.Error()})
+			return
+		}
+		limit = n
+	}
+
+	resp, err := h.JobManager.GetJobAlerts(jobID, q.Get("rule"), limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ExportCrawl handles POST /api/v1/crawl/{jobId}/export
+func (h *Handlers) ExportCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req ExportRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	embedImages := true
+	if req.EmbedImages != nil {
+		embedImages = *req.EmbedImages
+	}
+
+	result, err := h.JobManager.ExportJob(jobID, crawler.ExportOptions{
+		Format:      crawler.ExportFormat(req.Format),
+		Order:       crawler.ExportOrder(req.Order),
+		Title:       req.Title,
+		EmbedImages: embedImages,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GenerateSite handles POST /api/v1/crawl/{jobId}/site
+func (h *Handlers) GenerateSite(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req SiteRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result, err := h.JobManager.GenerateJobSite(jobID, crawler.SiteOptions{
+		Title:       req.Title,
+		EmbedImages: req.EmbedImages,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// AuditSEO handles POST /api/v1/crawl/{jobId}/seo
+func (h *Handlers) AuditSEO(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	report, err := h.JobManager.AuditJobSEO(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// AuditAccessibility handles POST /api/v1/crawl/{jobId}/accessibility
+func (h *Handlers) AuditAccessibility(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	report, err := h.JobManager.AuditJobAccessibility(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// FindDuplicates handles POST /api/v1/crawl/{jobId}/duplicates
+func (h *Handlers) FindDuplicates(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req DuplicatesRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	report, err := h.JobManager.FindJobDuplicates(jobID, crawler.DuplicateOptions{Threshold: req.Threshold})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// ReprocessCrawl handles POST /api/v1/crawl/{jobId}/reprocess
+func (h *Handlers) ReprocessCrawl(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req ReprocessRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+	if key, ok := APIKeyFromContext(r.Context()); ok && req.PluginsDir != nil {
+		dir, err := NamespacePath(key, "pluginsDir", *req.PluginsDir)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		req.PluginsDir = &dir
+	}
+
+	result, err := h.JobManager.ReprocessJob(jobID, req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GenerateIndex handles POST /api/v1/crawl/{jobId}/index
+func (h *Handlers) GenerateIndex(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	var req IndexRequest
+	if err := decodeBody(r, &req, true); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp, err := h.JobManager.GenerateJobIndex(jobID, req.Limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetFile handles GET /api/v1/crawl/{jobId}/files/*
+// Compressed output files are decompressed on the fly, so clients always
+// receive plain HTML regardless of the job's compression setting.
+func (h *Handlers) GetFile(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	rc, name, err := h.JobManager.OpenJobFile(jobID, chi.URLParam(r, "*"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	// Saved files are third-party content served from the API's own origin,
+	// sandboxed like those of BrowseCrawl
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, rc)
+}
+
+// GetDefinition handles GET /api/v1/crawl/{jobId}/definition
+func (h *Handlers) GetDefinition(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+
+	def, err := h.JobManager.ExportJobDefinition(jobID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="job-`+jobID+`.json"`)
+	writeJSON(w, http.StatusOK, def)
 }
 
 // formatUptime formats duration as a human-readable string
@@ -0,0 +1,363 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// URLInventoryQuery selects part of a job's URL inventory
+type URLInventoryQuery struct {
+	Status string // Only URLs with this outcome (saved, skipped, error, blocked, queued)
+	Limit  int    // Maximum URLs to return (0 = all)
+	Offset int    // URLs to skip before the first returned one
+}
+
+// GetURLInventory returns every URL the job has encountered. The live
+// inventory is used while the crawler exists; otherwise the urls.jsonl
+// written to the output directory is read.
+func (j *CrawlJob) GetURLInventory() ([]crawler.URLRecord, error) {
+	if j.Crawler != nil {
+		return j.Crawler.URLInventory(), nil
+	}
+
+	j.mu.Lock()
+	outputDir := j.OutputDir
+	j.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, APIError{Code: 404, Message: "URL inventory not available"}
+	}
+	records, err := crawler.LoadURLInventory(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 404, Message: "URL inventory not available", Details: err.Error()}
+	}
+	return records, nil
+}
+
+// QueryURLInventory returns the job's URL records matching q, along with
+// the per-status counts of the whole inventory
+func (m *JobManager) QueryURLInventory(jobID string, q URLInventoryQuery) (*URLInventoryResponse, []crawler.URLRecord, error) {
+	if q.Status != "" && !crawler.ValidURLStatus(q.Status) {
+		return nil, nil, APIError{Code: 400, Message: "invalid status", Details: "status must be saved, skipped, error, blocked or queued"}
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return nil, nil, APIError{Code: 400, Message: "limit and offset must not be negative"}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	all, err := job.GetURLInventory()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := crawler.FilterURLRecords(all, q.Status)
+	total := len(records)
+	if q.Offset >= len(records) {
+		records = records[:0]
+	} else {
+		records = records[q.Offset:]
+	}
+	if q.Limit > 0 && len(records) > q.Limit {
+		records = records[:q.Limit]
+	}
+
+	resp := &URLInventoryResponse{
+		JobID:  job.ID,
+		Total:  total,
+		Offset: q.Offset,
+		Counts: crawler.CountURLStatuses(all),
+		URLs:   make([]URLRecord, 0, len(records)),
+	}
+	for _, r := range records {
+		resp.URLs = append(resp.URLs, URLRecord{
+			URL:         r.URL,
+			Status:      r.Status,
+			Depth:       r.Depth,
+			Referrer:    r.Referrer,
+			ContentType: r.ContentType,
+			Size:        r.Size,
+			HTTPStatus:  r.HTTPStatus,
+			Reason:      r.Reason,
+			AnchorText:  r.AnchorText,
+			Heading:     r.Heading,
+			Rel:         r.Rel,
+		})
+	}
+	return resp, records, nil
+}
+
+// GetJobGraph returns the crawl map of the job: the first maxNodes URLs it
+// discovered, each linked to the page it was found on. While the crawler
+// exists only the nodes changed after version since are returned; otherwise
+// the whole map is built from urls.jsonl.
+func (m *JobManager) GetJobGraph(jobID string, since uint64, maxNodes int) (*CrawlGraphResponse, error) {
+	if maxNodes < 0 {
+		return nil, APIError{Code: 400, Message: "maxNodes must not be negative"}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if c := job.Crawler; c != nil {
+		return &CrawlGraphResponse{JobID: job.ID, CrawlGraph: c.CrawlGraph(since, maxNodes)}, nil
+	}
+	records, err := job.GetURLInventory()
+	if err != nil {
+		return nil, APIError{Code: 404, Message: "crawl map not available", Details: err.Error()}
+	}
+	return &CrawlGraphResponse{JobID: job.ID, CrawlGraph: crawler.BuildCrawlGraph(records, maxNodes)}, nil
+}
+
+// GetJobErrors returns the job's errored URLs with their error class and
+// referrer, optionally only those of class, and the count of each class
+func (m *JobManager) GetJobErrors(jobID, class string, limit int) (*URLErrorsResponse, error) {
+	if limit < 0 {
+		return nil, APIError{Code: 400, Message: "limit must not be negative"}
+	}
+	if class != "" && !crawler.ValidErrorClass(class) {
+		return nil, APIError{Code: 400, Message: "invalid class", Details: "class must be one of " + strings.Join(crawler.ErrorClasses, ", ")}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := job.GetURLInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	errs := crawler.URLErrors(all, "")
+	resp := &URLErrorsResponse{JobID: job.ID, Counts: crawler.CountErrorClasses(errs), Errors: crawler.URLErrors(all, class)}
+	resp.Total = len(resp.Errors)
+	if limit > 0 && len(resp.Errors) > limit {
+		resp.Errors = resp.Errors[:limit]
+	}
+	return resp, nil
+}
+
+// RetryJobErrors queues errored URLs of an active job again without
+// restarting it, e.g. after the login they needed was confirmed
+func (m *JobManager) RetryJobErrors(jobID string, req *RetryRequest) (*RetryResponse, error) {
+	if req.All == (len(req.URLs) > 0) {
+		return nil, APIError{Code: 400, Message: "either urls or all is required"}
+	}
+	if req.Class != "" && !crawler.ValidErrorClass(req.Class) {
+		return nil, APIError{Code: 400, Message: "invalid class", Details: "class must be one of " + strings.Join(crawler.ErrorClasses, ", ")}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	status := job.GetStatus()
+	if status != JobStatusRunning && status != JobStatusPaused && status != JobStatusWaitingForLogin {
+		return nil, APIError{Code: 400, Message: "job is not active", Details: "re-crawl the failed URLs of a finished job instead"}
+	}
+	if job.Crawler == nil {
+		return nil, APIError{Code: 400, Message: "crawler not initialized"}
+	}
+
+	var queued int
+	if req.All {
+		queued, err = job.Crawler.RetryErrors(req.Class)
+	} else {
+		queued, err = job.Crawler.RetryURLs(req.URLs)
+	}
+	if errors.Is(err, crawler.ErrNotRetryable) {
+		return nil, APIError{Code: 409, Message: "no errored URLs to retry", Details: "the URLs did not error or are already queued again"}
+	} else if err != nil {
+		return nil, APIError{Code: 400, Message: "retry failed", Details: err.Error()}
+	}
+	return &RetryResponse{JobID: job.ID, Queued: queued}, nil
+}
+
+// GetRedirects returns the redirect mapping of the job, from the live
+// crawler while it exists or from redirects.json otherwise
+func (j *CrawlJob) GetRedirects() (*crawler.RedirectMap, error) {
+	if j.Crawler != nil {
+		return j.Crawler.Redirects(), nil
+	}
+
+	j.mu.Lock()
+	outputDir := j.OutputDir
+	j.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, APIError{Code: 404, Message: "redirects not available"}
+	}
+	m, err := crawler.LoadRedirectMap(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 404, Message: "redirects not available", Details: err.Error()}
+	}
+	return m, nil
+}
+
+// GetJobRedirects returns every redirect the job followed, the chains it
+// abandoned as loops or too long, and the permanent redirect aliases
+func (m *JobManager) GetJobRedirects(jobID string) (*RedirectsResponse, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	rm, err := job.GetRedirects()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RedirectsResponse{
+		JobID:     job.ID,
+		Redirects: rm.Redirects,
+		Failures:  rm.Failures,
+		Aliases:   rm.Aliases,
+	}
+	if resp.Redirects == nil {
+		resp.Redirects = []crawler.Redirect{}
+	}
+	if resp.Failures == nil {
+		resp.Failures = []crawler.RedirectFailure{}
+	}
+	if resp.Aliases == nil {
+		resp.Aliases = map[string]string{}
+	}
+	return resp, nil
+}
+
+// GetAPIEndpoints returns the XHR/fetch endpoints discovered by the job,
+// from the live crawler while it exists or from api_endpoints.jsonl otherwise
+func (j *CrawlJob) GetAPIEndpoints() ([]crawler.APIEndpoint, error) {
+	if j.Crawler != nil {
+		return j.Crawler.APIEndpoints(), nil
+	}
+
+	j.mu.Lock()
+	outputDir := j.OutputDir
+	j.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, APIError{Code: 404, Message: "API endpoints not available"}
+	}
+	endpoints, err := crawler.LoadAPIEndpoints(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 404, Message: "API endpoints not available", Details: err.Error()}
+	}
+	return endpoints, nil
+}
+
+// GetJobAPIEndpoints returns the deduplicated XHR/fetch endpoints the job's
+// pages called, optionally only those responding with JSON
+func (m *JobManager) GetJobAPIEndpoints(jobID string, jsonOnly bool, limit int) (*APIEndpointsResponse, error) {
+	if limit < 0 {
+		return nil, APIError{Code: 400, Message: "limit must not be negative"}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := job.GetAPIEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := crawler.FilterAPIEndpoints(all, jsonOnly)
+	resp := &APIEndpointsResponse{JobID: job.ID, Total: len(endpoints), Endpoints: endpoints}
+	if limit > 0 && len(resp.Endpoints) > limit {
+		resp.Endpoints = resp.Endpoints[:limit]
+	}
+	if resp.Endpoints == nil {
+		resp.Endpoints = []crawler.APIEndpoint{}
+	}
+	return resp, nil
+}
+
+// GetExternalLinks returns the out-of-scope links recorded by the job, from
+// the live crawler while it exists or from external_links.jsonl otherwise
+func (j *CrawlJob) GetExternalLinks() ([]crawler.ExternalLink, error) {
+	if j.Crawler != nil {
+		return j.Crawler.ExternalLinks(), nil
+	}
+
+	j.mu.Lock()
+	outputDir := j.OutputDir
+	j.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, APIError{Code: 404, Message: "external links not available"}
+	}
+	links, err := crawler.LoadExternalLinks(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 404, Message: "external links not available", Details: err.Error()}
+	}
+	return links, nil
+}
+
+// GetJobAlerts returns the alert rule matches of the job's saved pages,
+// optionally only those of the named rule. alerts.jsonl is written as the
+// crawl goes, so it is read while the job runs too.
+func (m *JobManager) GetJobAlerts(jobID, rule string, limit int) (*AlertsResponse, error) {
+	if limit < 0 {
+		return nil, APIError{Code: 400, Message: "limit must not be negative"}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.mu.Lock()
+	outputDir := job.OutputDir
+	job.mu.Unlock()
+	if outputDir == "" {
+		return nil, APIError{Code: 404, Message: "alerts not available"}
+	}
+	all, err := crawler.LoadAlerts(outputDir)
+	if os.IsNotExist(err) {
+		all = []crawler.Alert{}
+	} else if err != nil {
+		return nil, APIError{Code: 500, Message: "failed to read alerts", Details: err.Error()}
+	}
+
+	alerts := crawler.FilterAlerts(all, rule)
+	resp := &AlertsResponse{JobID: job.ID, Total: len(alerts), Alerts: alerts}
+	if limit > 0 && len(resp.Alerts) > limit {
+		resp.Alerts = resp.Alerts[:limit]
+	}
+	return resp, nil
+}
+
+// GetJobExternalLinks returns the links the job's pages made to URLs outside
+// its scope, optionally only those to host (an exact name or *.domain)
+func (m *JobManager) GetJobExternalLinks(jobID, host string, limit int) (*ExternalLinksResponse, error) {
+	if limit < 0 {
+		return nil, APIError{Code: 400, Message: "limit must not be negative"}
+	}
+	if host != "" && !crawler.ValidHostPattern(host) {
+		return nil, APIError{Code: 400, Message: "invalid host", Details: "expected a host name like example.com or *.example.com"}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	all, err := job.GetExternalLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	links := crawler.FilterExternalLinks(all, host)
+	resp := &ExternalLinksResponse{JobID: job.ID, Total: len(links), Links: links}
+	if limit > 0 && len(resp.Links) > limit {
+		resp.Links = resp.Links[:limit]
+	}
+	if resp.Links == nil {
+		resp.Links = []crawler.ExternalLink{}
+	}
+	return resp, nil
+}
@@ -2,7 +2,15 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,13 +25,28 @@ type CrawlJob struct {
 	Crawler     *crawler.Crawler
 	Emitter     *SSEEmitter
 	Config      *CrawlRequest
+	OutputDir   string // resolved output directory, set when the job starts
+	StateFile   string // resolved state file, set when the job starts
 	Status      JobStatus
 	CreatedAt   time.Time
 	StartedAt   *time.Time
 	CompletedAt *time.Time
 	Error       error
+	StopReason  string // Why the crawl ended, set when it completes
+	Owner       string // API key name the job's fetches are charged to
+	Warnings    []string // Set when the job starts, e.g. scope overlaps with other active jobs
 	cancel      context.CancelFunc
 	mu          sync.Mutex
+
+	chargedBytes int64 // Bytes and pages already charged to Owner
+	chargedPages int64
+}
+
+// GetWarnings returns the warnings raised when the job started (thread-safe)
+func (j *CrawlJob) GetWarnings() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Warnings
 }
 
 // GetStatus returns the current job status (thread-safe)
@@ -40,6 +63,20 @@ func (j *CrawlJob) SetStatus(status JobStatus) {
 	j.Status = status
 }
 
+// GetOutputDir returns the resolved output directory (thread-safe)
+func (j *CrawlJob) GetOutputDir() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.OutputDir
+}
+
+// GetOwner returns the API key name the job is charged to (thread-safe)
+func (j *CrawlJob) GetOwner() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Owner
+}
+
 // GetMetrics returns current metrics snapshot
 func (j *CrawlJob) GetMetrics() *MetricsSnapshot {
 	if j.Crawler == nil {
@@ -69,13 +106,57 @@ func (j *CrawlJob) GetMetrics() *MetricsSnapshot {
 		RobotsBlocked:   snapshot.RobotsBlocked,
 		DepthLimitHits:  snapshot.DepthLimitHits,
 		ContentFiltered: snapshot.ContentFiltered,
+		FollowOnly:      snapshot.FollowOnly,
+		StopReason:      snapshot.StopReason,
+		Redirected:      snapshot.Redirected,
+		RedirectErrors:  snapshot.RedirectErrors,
+		TimedOut:        snapshot.TimedOut,
+		BrowserRecycles: snapshot.BrowserRecycles,
+		BrowserCrashes:  snapshot.BrowserCrashes,
 		PagesPerSecond:  snapshot.PagesPerSecond,
 		QueueSize:       snapshot.QueueSize,
+		HeapAlloc:       snapshot.HeapAlloc,
+		PeakHeapAlloc:   snapshot.PeakHeapAlloc,
+		SysMemory:       snapshot.SysMemory,
+		NumGC:           snapshot.NumGC,
 		ElapsedTime:     crawler.FormatDuration(elapsed),
 		Percentage:      percentage,
+		Depths:          snapshot.Depths,
+		Content:         snapshot.Content,
+		Active:          snapshot.Active,
+		RecentErrors:    snapshot.RecentErrors,
 	}
 }
 
+// GetMetricsTimeSeries returns the metrics samples recorded so far
+func (j *CrawlJob) GetMetricsTimeSeries() *MetricsTimeSeriesResponse {
+	if j.Crawler == nil {
+		return nil
+	}
+
+	series := j.Crawler.MetricsTimeSeries()
+	resp := &MetricsTimeSeriesResponse{
+		JobID:           j.ID,
+		IntervalSeconds: series.Interval,
+		Samples:         make([]MetricsSample, 0, len(series.Samples)),
+	}
+	for _, s := range series.Samples {
+		resp.Samples = append(resp.Samples, MetricsSample{
+			Timestamp:       s.Timestamp,
+			ElapsedSeconds:  s.Elapsed,
+			URLsProcessed:   s.URLsProcessed,
+			URLsSaved:       s.URLsSaved,
+			URLsSkipped:     s.URLsSkipped,
+			URLsErrored:     s.URLsErrored,
+			QueueSize:       s.QueueSize,
+			BytesDownloaded: s.BytesDownloaded,
+			PagesPerSecond:  s.PagesPerSecond,
+			HeapAlloc:       s.HeapAlloc,
+		})
+	}
+	return resp
+}
+
 // ToSummary converts job to a summary view
 func (j *CrawlJob) ToSummary() JobSummary {
 	j.mu.Lock()
@@ -86,7 +167,19 @@ func (j *CrawlJob) ToSummary() JobSummary {
 		URL:       j.Config.URL,
 		Status:    j.Status,
 		CreatedAt: j.CreatedAt,
+		Tags:      j.Config.Tags,
+		Keep:      j.Config.Keep,
+	}
+}
+
+// HasTag reports whether the job carries tag, ignoring case
+func (j *CrawlJob) HasTag(tag string) bool {
+	for _, t := range j.Config.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
 	}
+	return false
 }
 
 // ToDetails converts job to a detailed view
@@ -95,8 +188,10 @@ func (j *CrawlJob) ToDetails() JobDetails {
 	defer j.mu.Unlock()
 
 	waitingForLogin := false
+	workers := 0
 	if j.Crawler != nil {
 		waitingForLogin = j.Crawler.IsWaitingForLogin()
+		workers = j.Crawler.Workers()
 	}
 
 	details := JobDetails{
@@ -106,8 +201,15 @@ func (j *CrawlJob) ToDetails() JobDetails {
 		CreatedAt:       j.CreatedAt,
 		StartedAt:       j.StartedAt,
 		CompletedAt:     j.CompletedAt,
+		StopReason:      j.StopReason,
 		Config:          j.Config,
 		WaitingForLogin: waitingForLogin,
+		Workers:         workers,
+		SSEClients:      j.Emitter.ClientCount(),
+		Warnings:        j.Warnings,
+	}
+	if j.Error != nil {
+		details.Error = j.Error.Error()
 	}
 
 	// Add metrics if crawler exists
@@ -123,6 +225,17 @@ func (j *CrawlJob) ToDetails() JobDetails {
 type JobManager struct {
 	jobs          map[string]*CrawlJob
 	maxConcurrent int
+	retention     RetentionPolicy
+	stopRetention func()
+	usage         *UsageTracker
+	stopUsage     func()
+	hostLimiter   *crawler.HostLimiter // Shared by all jobs' crawlers, nil without a host delay or polite mode
+	hostDelay     time.Duration
+	polite        *crawler.PolitePolicy   // Enforced on every job, nil when polite mode is off
+	audit         *AuditLog               // Operations that change server state, nil without an audit log
+	allowCommands bool                    // Jobs may run a post-save command
+	outputOwner   string                  // "uid[:gid]" all job output is chowned to, "" = unchanged
+	apiKeys       map[string]APIKeyConfig // By name, for the runs of schedules created with a key
 	mu            sync.RWMutex
 }
 
@@ -131,11 +244,216 @@ func NewJobManager(maxConcurrent int) *JobManager {
 	return &JobManager{
 		jobs:          make(map[string]*CrawlJob),
 		maxConcurrent: maxConcurrent,
+		usage:         NewUsageTracker(),
+		allowCommands: true,
+	}
+}
+
+// SetAllowCommands lets jobs started from now on run a post-save command or
+// a browser binary of their choice, or refuses those that set one. Both run
+// with the rights of the process, so servers open to others refuse them.
+func (m *JobManager) SetAllowCommands(allow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.allowCommands = allow
+}
+
+// checkCommands refuses requests with a post-save command or a browser
+// binary when commands are not allowed
+func (m *JobManager) checkCommands(req *CrawlRequest) error {
+	m.mu.RLock()
+	allow := m.allowCommands
+	m.mu.RUnlock()
+	if req.PostSaveCommand != "" && !allow {
+		return APIError{Code: 403, Message: "post-save commands are not allowed on this server", Details: "start the API server with -allow-commands to run them"}
+	}
+	if req.BrowserPath != "" && !allow {
+		return APIError{Code: 403, Message: "browserPath is not allowed on this server", Details: "start the API server with -allow-commands to run a browser binary of the job's choice"}
+	}
+	return nil
+}
+
+// SetAPIKeys gives the manager the API keys of the server, so runs of
+// schedules created with a key can be confined and charged like the key's
+// own jobs
+func (m *JobManager) SetAPIKeys(keys []APIKeyConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKeys = make(map[string]APIKeyConfig, len(keys))
+	for _, key := range keys {
+		m.apiKeys[key.Name] = key
+	}
+}
+
+// APIKey returns the API key name set with SetAPIKeys
+func (m *JobManager) APIKey(name string) (APIKeyConfig, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.apiKeys[name]
+	return key, ok
+}
+
+// SetOutputOwner chowns the output of jobs started from now on to owner
+// ("uid[:gid]", "" = leave it to the server user). The owner is a setting of
+// the server: requests that set permissions.owner are refused, since the
+// server runs as root to chown and a job could otherwise hand its files to
+// any user.
+func (m *JobManager) SetOutputOwner(owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.outputOwner = owner
+}
+
+// checkOwner refuses requests that pick the owner of their output
+func checkOwner(req *CrawlRequest) error {
+	if req.Permissions != nil && req.Permissions.Owner != "" {
+		return APIError{Code: 403, Message: "permissions.owner is not allowed in requests", Details: "the owner of job output is set for the whole server with -chown"}
+	}
+	return nil
+}
+
+// SetHostDelay spaces out requests to each host across all jobs started
+// from now on (0 = each job only keeps its own delay)
+func (m *JobManager) SetHostDelay(delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hostDelay = delay
+	m.resetHostLimiter()
+}
+
+// SetPolitePolicy enforces policy on every job started from now on (nil
+// turns polite mode off). Its host concurrency cap is shared by all jobs.
+func (m *JobManager) SetPolitePolicy(policy *crawler.PolitePolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.polite = policy
+	m.resetHostLimiter()
+}
+
+// SetAuditLog records operations that change server state in audit (nil
+// turns the audit log off)
+func (m *JobManager) SetAuditLog(audit *AuditLog) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.audit = audit
+}
+
+// AuditLog returns the audit log, or nil when operations are not audited
+func (m *JobManager) AuditLog() *AuditLog {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.audit
+}
+
+// PolitePolicy returns the policy enforced on jobs, or nil when polite mode
+// is off
+func (m *JobManager) PolitePolicy() *crawler.PolitePolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.polite
+}
+
+// resetHostLimiter replaces the limiter shared by new jobs after the host
+// delay or polite policy changed. m.mu must be held.
+func (m *JobManager) resetHostLimiter() {
+	m.hostLimiter = nil
+	concurrency := 0
+	if m.polite != nil {
+		concurrency = m.polite.HostConcurrency
+	}
+	if m.hostDelay > 0 || concurrency > 0 {
+		m.hostLimiter = crawler.NewHostLimiter(m.hostDelay)
+		if concurrency > 0 {
+			m.hostLimiter.SetHostConcurrency(concurrency)
+		}
+	}
+}
+
+// checkPolite refuses requests polite mode doesn't allow, before a job is
+// created for them
+func (m *JobManager) checkPolite(req *CrawlRequest) error {
+	if m.PolitePolicy() == nil {
+		return nil
+	}
+	config, err := buildConfig(req)
+	if err != nil {
+		return err
+	}
+	return m.applyPolite(config)
+}
+
+// applyPolite makes config of a plan or one-shot fetch share the host limits
+// of the jobs and enforces polite mode on it, refusing settings it doesn't
+// allow
+func (m *JobManager) applyPolite(config *crawler.Config) error {
+	m.mu.RLock()
+	config.HostLimiter = m.hostLimiter
+	config.Polite = m.polite
+	m.mu.RUnlock()
+	if config.Polite == nil {
+		return nil
+	}
+	if _, err := config.Polite.Enforce(config); err != nil {
+		return APIError{Code: 400, Message: "refused by polite mode", Details: err.Error()}
+	}
+	return nil
+}
+
+// overlapWarnings describes the active jobs other than job whose scope
+// overlaps the scope of config, as they may fetch the same pages
+func (m *JobManager) overlapWarnings(job *CrawlJob, config *crawler.Config) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []string
+	for id, other := range m.jobs {
+		if other == job {
+			continue
+		}
+		switch other.GetStatus() {
+		case JobStatusRunning, JobStatusPaused, JobStatusWaitingForLogin:
+		default:
+			continue
+		}
+		scope := crawler.Config{URL: other.Config.URL, PrefixFilterURL: other.Config.PrefixFilterURL}
+		if crawler.ScopesOverlap(*config, scope) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var warnings []string
+	for _, id := range ids {
+		warning := fmt.Sprintf("scope overlaps with active job %s (%s); both may fetch the same pages", id, m.jobs[id].Config.URL)
+		if m.hostDelay <= 0 {
+			warning += " at their combined rate"
+		}
+		warnings = append(warnings, warning)
 	}
+	return warnings
 }
 
 // CreateJob creates a new crawl job from the request
 func (m *JobManager) CreateJob(req *CrawlRequest) (*CrawlJob, error) {
+	return m.CreateJobFor(AnonymousKey, req)
+}
+
+// CreateJobFor creates a new crawl job charged to the named API key,
+// refusing it when the key has used up its quota
+func (m *JobManager) CreateJobFor(owner string, req *CrawlRequest) (*CrawlJob, error) {
+	if err := m.usage.CheckQuota(owner, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkOwner(req); err != nil {
+		return nil, err
+	}
+	if err := m.checkPolite(req); err != nil {
+		return nil, err
+	}
+	if err := m.checkCommands(req); err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -154,6 +472,8 @@ func (m *JobManager) CreateJob(req *CrawlRequest) (*CrawlJob, error) {
 		}
 	}
 
+	req.Tags = normalizeTags(req.Tags)
+
 	// Generate job ID
 	jobID := uuid.New().String()[:8]
 
@@ -166,6 +486,7 @@ func (m *JobManager) CreateJob(req *CrawlRequest) (*CrawlJob, error) {
 		Config:    req,
 		Status:    JobStatusPending,
 		CreatedAt: time.Now(),
+		Owner:     owner,
 	}
 
 	m.jobs[jobID] = job
@@ -194,6 +515,30 @@ func (m *JobManager) StartJob(jobID string) error {
 		job.mu.Unlock()
 		return err
 	}
+	job.mu.Unlock()
+
+	// Other jobs are checked without holding this job's lock
+	warnings := m.overlapWarnings(job, crawlerConfig)
+	m.mu.RLock()
+	crawlerConfig.HostLimiter = m.hostLimiter
+	crawlerConfig.Polite = m.polite
+	crawlerConfig.Permissions.Owner = m.outputOwner
+	m.mu.RUnlock()
+	if crawlerConfig.Polite != nil {
+		notes, err := crawlerConfig.Polite.Enforce(crawlerConfig)
+		if err != nil {
+			return APIError{Code: 400, Message: "refused by polite mode", Details: err.Error()}
+		}
+		warnings = append(warnings, notes...)
+	}
+	crawlerConfig.Tracing.JobID = job.ID
+	crawlerConfig.JobID = job.ID
+
+	job.mu.Lock()
+	if job.Status != JobStatusPending {
+		job.mu.Unlock()
+		return APIError{Code: 400, Message: "job already started"}
+	}
 
 	// Create context for this job
 	ctx, cancel := context.WithCancel(context.Background())
@@ -207,14 +552,23 @@ func (m *JobManager) StartJob(jobID string) error {
 		return APIError{Code: 500, Message: "failed to create crawler", Details: err.Error()}
 	}
 
+	for _, warning := range warnings {
+		log.Printf("Job %s: %s", job.ID, warning)
+		crawler.EmitLog(job.Emitter, "warn", warning)
+	}
+
 	job.Crawler = c
+	job.Warnings = warnings
+	job.OutputDir = crawlerConfig.OutputDir
+	job.StateFile = crawlerConfig.StateFile
 	job.Status = JobStatusRunning
 	now := time.Now()
 	job.StartedAt = &now
 	job.mu.Unlock()
 
-	// Start crawling in background
-	go func() {
+	// Start crawling in background. The job label follows every goroutine
+	// the crawl starts, so the runtime statistics can count them per job.
+	go pprof.Do(context.Background(), pprof.Labels(jobLabel, job.ID), func(context.Context) {
 		err := c.Start()
 
 		job.mu.Lock()
@@ -228,15 +582,315 @@ func (m *JobManager) StartJob(jobID string) error {
 		} else {
 			job.Status = JobStatusCompleted
 		}
+		job.StopReason = c.StopReason()
 		job.mu.Unlock()
 
+		// Charge the final fetches to the job's API key
+		m.chargeJob(job, now)
+
 		// Close the emitter to signal completion to SSE clients
 		job.Emitter.Close()
-	}()
+	})
 
 	return nil
 }
 
+// ExportJob stitches a job's crawled pages into a single book
+func (m *JobManager) ExportJob(jobID string, opts crawler.ExportOptions) (*crawler.ExportResult, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+
+	if err := crawler.ValidateExportOptions(&opts); err != nil {
+		return nil, APIError{Code: 400, Message: "invalid export options", Details: err.Error()}
+	}
+
+	result, err := crawler.ExportBook(outputDir, opts)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "export failed", Details: err.Error()}
+	}
+	return result, nil
+}
+
+// GenerateJobSite builds a static site mirror from a job's crawled pages
+func (m *JobManager) GenerateJobSite(jobID string, opts crawler.SiteOptions) (*crawler.SiteResult, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+
+	result, err := crawler.GenerateSite(outputDir, opts)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "site generation failed", Details: err.Error()}
+	}
+	return result, nil
+}
+
+// AuditJobSEO runs an SEO audit over a job's saved pages and writes
+// seo_report.html and seo_report.json to its output directory
+func (m *JobManager) AuditJobSEO(jobID string) (*crawler.SEOReport, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+
+	report, err := crawler.GenerateSEOReport(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "SEO audit failed", Details: err.Error()}
+	}
+	return report, nil
+}
+
+// AuditJobAccessibility checks a job's saved pages for basic accessibility
+// issues and writes accessibility_report.html and accessibility_report.json
+func (m *JobManager) AuditJobAccessibility(jobID string) (*crawler.AccessibilityReport, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+
+	report, err := crawler.GenerateAccessibilityReport(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "accessibility audit failed", Details: err.Error()}
+	}
+	return report, nil
+}
+
+// FindJobDuplicates clusters a job's saved pages by near-duplicate content
+// and writes duplicates_report.html and duplicates_report.json
+func (m *JobManager) FindJobDuplicates(jobID string, opts crawler.DuplicateOptions) (*crawler.DuplicatesReport, error) {
+	if opts.Threshold < 0 || opts.Threshold > crawler.MaxDuplicateThreshold {
+		return nil, APIError{Code: 400, Message: "invalid threshold", Details: fmt.Sprintf("threshold must be between 1 and %d", crawler.MaxDuplicateThreshold)}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+
+	report, err := crawler.GenerateDuplicatesReport(outputDir, opts)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "duplicate detection failed", Details: err.Error()}
+	}
+	return report, nil
+}
+
+// GenerateJobIndex rewrites the job's _index.html and lists the pages it
+// indexes, at most limit of them (0 = all). A live crawl writes it from the
+// pages saved so far.
+func (m *JobManager) GenerateJobIndex(jobID string, limit int) (*IndexResponse, error) {
+	if limit < 0 {
+		return nil, APIError{Code: 400, Message: "limit must not be negative"}
+	}
+
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+
+	var pages []crawler.PageEntry
+	if job.Crawler != nil {
+		pages, err = job.Crawler.WriteIndex()
+	} else {
+		pages, err = crawler.BuildIndex(outputDir)
+	}
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "index generation failed", Details: err.Error()}
+	}
+
+	resp := &IndexResponse{
+		JobID:     job.ID,
+		IndexFile: filepath.Join(outputDir, crawler.BrowseIndexFile),
+		Total:     len(pages),
+		Pages:     pages,
+	}
+	if limit > 0 && len(resp.Pages) > limit {
+		resp.Pages = resp.Pages[:limit]
+	}
+	return resp, nil
+}
+
+// OpenJobFile opens a file from a job's output directory, decompressing
+// .gz and .zst files on the fly. relPath may use the uncompressed name.
+// The returned name is the resolved uncompressed path.
+func (m *JobManager) OpenJobFile(jobID, relPath string) (io.ReadCloser, string, error) {
+	outputDir, err := m.JobOutputDir(jobID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Reject absolute paths and anything escaping the output directory
+	cleaned := filepath.Clean(filepath.FromSlash(relPath))
+	if relPath == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return nil, "", APIError{Code: 400, Message: "invalid file path", Details: relPath}
+	}
+
+	rc, name, err := crawler.OpenOutputFile(filepath.Join(outputDir, cleaned))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", APIError{Code: 404, Message: "file not found", Details: relPath}
+		}
+		return nil, "", APIError{Code: 422, Message: "failed to open file", Details: err.Error()}
+	}
+	return rc, name, nil
+}
+
+// RecrawlJobRequest builds the request of a job that fetches again the URLs
+// of a finished job selected by scope, writing into the same output directory
+func (m *JobManager) RecrawlJobRequest(jobID string, recrawl RecrawlRequest) (*CrawlRequest, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job.mu.Lock()
+	status, outputDir := job.Status, job.OutputDir
+	req := *job.Config
+	job.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+	if isActiveStatus(status) {
+		return nil, APIError{Code: 409, Message: "job is still running"}
+	}
+	for _, other := range m.ListJobs() {
+		if other.ID != jobID && isActiveStatus(other.GetStatus()) && filepath.Clean(other.GetOutputDir()) == filepath.Clean(outputDir) {
+			return nil, APIError{Code: 409, Message: "output directory is in use", Details: "job " + other.ID + " is writing to it"}
+		}
+	}
+
+	records, err := crawler.LoadURLInventory(outputDir)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "failed to load URL inventory", Details: err.Error()}
+	}
+	urls, err := crawler.SelectRecrawlURLs(records, recrawl.Scope, recrawl.Pattern)
+	if err != nil {
+		return nil, APIError{Code: 400, Message: "invalid re-crawl request", Details: err.Error()}
+	}
+	if len(urls) == 0 {
+		return nil, APIError{Code: 400, Message: "no URLs match the re-crawl scope"}
+	}
+
+	req.OutputDir = outputDir
+	req.StateFile = crawler.RecrawlStateFile(outputDir)
+	req.RecrawlURLs = urls
+	req.RecrawlScope = recrawl.Scope
+	req.ParentJobID = jobID
+	req.Keep = false
+	return &req, nil
+}
+
+// ReprocessJob extracts the content of a finished job's saved pages again,
+// with the settings of req falling back to the job's, and regenerates its
+// _index.html
+func (m *JobManager) ReprocessJob(jobID string, req ReprocessRequest) (*crawler.ReprocessResult, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job.mu.Lock()
+	status, outputDir := job.Status, job.OutputDir
+	config := *job.Config
+	job.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, APIError{Code: 400, Message: "job has no output yet"}
+	}
+	if isActiveStatus(status) {
+		return nil, APIError{Code: 409, Message: "job is still running"}
+	}
+	for _, other := range m.ListJobs() {
+		if other.ID != jobID && isActiveStatus(other.GetStatus()) && filepath.Clean(other.GetOutputDir()) == filepath.Clean(outputDir) {
+			return nil, APIError{Code: 409, Message: "output directory is in use", Details: "job " + other.ID + " is writing to it"}
+		}
+	}
+	if len(req.ContentFilters) > MaxRequestListItems {
+		return nil, APIError{
+			Code:    400,
+			Message: "too many list entries",
+			Details: fmt.Sprintf("contentFilters has %d entries, the limit is %d", len(req.ContentFilters), MaxRequestListItems),
+		}
+	}
+
+	opts := crawler.ReprocessOptions{
+		DisableContentExtraction: config.DisableContentExtraction || config.DisableReadability,
+		ContentFilters:           config.ContentFilters,
+		PluginsDir:               config.PluginsDir,
+	}
+	if req.DisableContentExtraction != nil {
+		opts.DisableContentExtraction = *req.DisableContentExtraction
+	}
+	if req.ContentFilters != nil {
+		opts.ContentFilters = req.ContentFilters
+	}
+	if req.PluginsDir != nil {
+		opts.PluginsDir = *req.PluginsDir
+	}
+
+	result, err := crawler.Reprocess(outputDir, opts)
+	if err != nil {
+		return nil, APIError{Code: 422, Message: "reprocessing failed", Details: err.Error()}
+	}
+	return result, nil
+}
+
+// isActiveStatus reports whether a job in status may still write to its
+// output directory
+func isActiveStatus(status JobStatus) bool {
+	switch status {
+	case JobStatusPending, JobStatusRunning, JobStatusPaused, JobStatusWaitingForLogin:
+		return true
+	}
+	return false
+}
+
+// JobOutputDir returns the output directory of a job
+func (m *JobManager) JobOutputDir(jobID string) (string, error) {
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return "", err
+	}
+
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		return "", APIError{Code: 400, Message: "job has no output yet"}
+	}
+	return outputDir, nil
+}
+
 // GetJob returns a job by ID
 func (m *JobManager) GetJob(jobID string) (*CrawlJob, error) {
 	m.mu.RLock()
@@ -263,6 +917,137 @@ func (m *JobManager) ListJobs() []*CrawlJob {
 	return jobs
 }
 
+// Job list sort fields
+const (
+	JobSortCreated = "created"
+	JobSortURL     = "url"
+	JobSortStatus  = "status"
+)
+
+// JobListOptions filters, sorts and paginates a job listing
+type JobListOptions struct {
+	Status      JobStatus // Only jobs in this state
+	Tag         string    // Only jobs carrying this tag (case-insensitive)
+	URLContains string    // Only jobs whose URL contains this substring (case-insensitive)
+	Sort        string    // "created" (default), "url" or "status"
+	Order       string    // "desc" (default for created) or "asc" (default otherwise)
+	Limit       int       // Maximum jobs to return (0 = all)
+	Offset      int       // Matching jobs to skip
+	Owner       string    // Only jobs of this API key ("" = every key)
+}
+
+// QueryJobs returns one page of jobs matching opts along with the total
+// number of matching jobs
+func (m *JobManager) QueryJobs(opts JobListOptions) ([]*CrawlJob, int, error) {
+	if opts.Status != "" && !validJobStatus(opts.Status) {
+		return nil, 0, APIError{Code: 400, Message: "invalid status filter", Details: string(opts.Status)}
+	}
+	if opts.Sort == "" {
+		opts.Sort = JobSortCreated
+	}
+	if opts.Sort != JobSortCreated && opts.Sort != JobSortURL && opts.Sort != JobSortStatus {
+		return nil, 0, APIError{Code: 400, Message: "sort must be one of: created, url, status"}
+	}
+	if opts.Order == "" {
+		opts.Order = "asc"
+		if opts.Sort == JobSortCreated {
+			opts.Order = "desc"
+		}
+	}
+	if opts.Order != "asc" && opts.Order != "desc" {
+		return nil, 0, APIError{Code: 400, Message: "order must be asc or desc"}
+	}
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return nil, 0, APIError{Code: 400, Message: "limit and offset cannot be negative"}
+	}
+
+	urlContains := strings.ToLower(opts.URLContains)
+	var matched []*CrawlJob
+	for _, job := range m.ListJobs() {
+		if opts.Status != "" && job.GetStatus() != opts.Status {
+			continue
+		}
+		if opts.Tag != "" && !job.HasTag(opts.Tag) {
+			continue
+		}
+		if opts.Owner != "" && job.GetOwner() != opts.Owner {
+			continue
+		}
+		if urlContains != "" && !strings.Contains(strings.ToLower(job.Config.URL), urlContains) {
+			continue
+		}
+		matched = append(matched, job)
+	}
+
+	// Ties fall back to creation time and ID so pages are stable
+	less := func(a, b *CrawlJob) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}
+	switch opts.Sort {
+	case JobSortURL:
+		byCreated := less
+		less = func(a, b *CrawlJob) bool {
+			if a.Config.URL != b.Config.URL {
+				return a.Config.URL < b.Config.URL
+			}
+			return byCreated(a, b)
+		}
+	case JobSortStatus:
+		byCreated := less
+		less = func(a, b *CrawlJob) bool {
+			if sa, sb := a.GetStatus(), b.GetStatus(); sa != sb {
+				return sa < sb
+			}
+			return byCreated(a, b)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if opts.Order == "desc" {
+			return less(matched[j], matched[i])
+		}
+		return less(matched[i], matched[j])
+	})
+
+	total := len(matched)
+	if opts.Offset >= total {
+		return []*CrawlJob{}, total, nil
+	}
+	matched = matched[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(matched) {
+		matched = matched[:opts.Limit]
+	}
+	return matched, total, nil
+}
+
+// validJobStatus reports whether s is a known job status
+func validJobStatus(s JobStatus) bool {
+	switch s {
+	case JobStatusPending, JobStatusRunning, JobStatusPaused, JobStatusCompleted,
+		JobStatusStopped, JobStatusError, JobStatusWaitingForLogin:
+		return true
+	}
+	return false
+}
+
+// normalizeTags trims tags and drops empty and duplicate (case-insensitive) entries
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, t)
+	}
+	return out
+}
+
 // StopJob stops a running job
 func (m *JobManager) StopJob(jobID string) error {
 	m.mu.RLock()
@@ -363,6 +1148,60 @@ func (m *JobManager) ConfirmLogin(jobID string) error {
 	return nil
 }
 
+// SetJobWorkers changes the number of concurrent workers of an active job
+func (m *JobManager) SetJobWorkers(jobID string, workers int) error {
+	_, err := m.UpdateJobConfig(jobID, &ConfigPatchRequest{Workers: &workers})
+	return err
+}
+
+// UpdateJobConfig applies runtime-tunable settings to an active job without restarting it
+func (m *JobManager) UpdateJobConfig(jobID string, req *ConfigPatchRequest) (crawler.RuntimeConfig, error) {
+	m.mu.RLock()
+	job, exists := m.jobs[jobID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return crawler.RuntimeConfig{}, APIError{Code: 404, Message: "job not found"}
+	}
+
+	status := job.GetStatus()
+	if status != JobStatusRunning && status != JobStatusPaused && status != JobStatusWaitingForLogin {
+		return crawler.RuntimeConfig{}, APIError{Code: 400, Message: "job is not active"}
+	}
+
+	if job.Crawler == nil {
+		return crawler.RuntimeConfig{}, APIError{Code: 400, Message: "crawler not initialized"}
+	}
+
+	update := crawler.ConfigUpdate{
+		Workers:  req.Workers,
+		MaxPages: req.MaxPages,
+		Verbose:  req.Verbose,
+	}
+	if req.Delay != nil {
+		d, err := time.ParseDuration(*req.Delay)
+		if err != nil {
+			return crawler.RuntimeConfig{}, APIError{Code: 400, Message: "invalid delay format", Details: err.Error()}
+		}
+		update.Delay = &d
+	}
+
+	current, err := job.Crawler.UpdateConfig(update)
+	if err != nil {
+		return crawler.RuntimeConfig{}, APIError{Code: 400, Message: "invalid configuration", Details: err.Error()}
+	}
+
+	// Keep the stored request in sync so job details reflect the live settings
+	job.mu.Lock()
+	job.Config.Delay = current.Delay
+	job.Config.Workers = current.Workers
+	job.Config.MaxPages = current.MaxPages
+	job.Config.Verbose = current.Verbose
+	job.mu.Unlock()
+
+	return current, nil
+}
+
 // DeleteJob removes a job (must be stopped or completed)
 func (m *JobManager) DeleteJob(jobID string) error {
 	m.mu.Lock()
@@ -400,11 +1239,70 @@ func (m *JobManager) ActiveJobCount() int {
 	return count
 }
 
+// CheckpointJobs pauses every active crawl and saves its state, URL
+// inventory and redirects, so re-submitting the same request after a restart
+// resumes where it stopped. Jobs are checkpointed in parallel until ctx ends;
+// checkpointed jobs are left paused. It returns their IDs.
+func (m *JobManager) CheckpointJobs(ctx context.Context) []string {
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		checkpointed []string
+	)
+
+	for _, job := range m.ListJobs() {
+		job.mu.Lock()
+		c, status, stateFile := job.Crawler, job.Status, job.StateFile
+		job.mu.Unlock()
+		if c == nil || !isActiveStatus(status) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(job *CrawlJob) {
+			defer wg.Done()
+			if err := c.Checkpoint(ctx); err != nil {
+				log.Printf("Job %s not checkpointed: %v", job.ID, err)
+				return
+			}
+
+			state := c.GetState()
+			log.Printf("Checkpointed job %s (%d URLs processed, %d queued) to %s", job.ID, state.Processed, len(state.Queue), stateFile)
+			job.SetStatus(JobStatusPaused)
+
+			mu.Lock()
+			checkpointed = append(checkpointed, job.ID)
+			mu.Unlock()
+		}(job)
+	}
+	wg.Wait()
+
+	sort.Strings(checkpointed)
+	return checkpointed
+}
+
 // Shutdown stops all jobs and cleans up
 func (m *JobManager) Shutdown() {
+	m.chargeUsage(time.Now())
+	if err := m.usage.Save(); err != nil {
+		log.Printf("Usage: %v", err)
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.stopRetention != nil {
+		m.stopRetention()
+		m.stopRetention = nil
+	}
+	if m.stopUsage != nil {
+		m.stopUsage()
+		m.stopUsage = nil
+	}
+	if m.audit != nil {
+		m.audit.Close()
+	}
+
 	for _, job := range m.jobs {
 		job.mu.Lock()
 		if job.Crawler != nil {
@@ -419,8 +1317,119 @@ func (m *JobManager) Shutdown() {
 	}
 }
 
-// translateConfig converts API CrawlRequest to crawler.Config
+// PlanCrawl previews the links a crawl of req would queue and filter out,
+// without crawling (see crawler.Plan). The depth defaults to 1: the links of
+// the start page. Requests the server would refuse for a job are refused.
+func (m *JobManager) PlanCrawl(ctx context.Context, req *CrawlRequest) (*crawler.CrawlPlan, error) {
+	return m.PlanCrawlFor(ctx, AnonymousKey, req)
+}
+
+// PlanCrawlFor previews a crawl like PlanCrawl, charging the fetched pages to
+// the named API key and refusing it when the key has used up its quota
+func (m *JobManager) PlanCrawlFor(ctx context.Context, owner string, req *CrawlRequest) (*crawler.CrawlPlan, error) {
+	if err := m.usage.CheckQuota(owner, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := m.checkCommands(req); err != nil {
+		return nil, err
+	}
+	planReq := *req
+	if planReq.MaxDepth == 0 {
+		planReq.MaxDepth = 1
+	}
+	config, err := buildConfig(&planReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.applyPolite(config); err != nil {
+		return nil, err
+	}
+	plan, err := crawler.Plan(ctx, *config)
+	if err != nil {
+		return nil, APIError{Code: 400, Message: "invalid configuration", Details: err.Error()}
+	}
+	m.usage.Charge(owner, plan.Bytes, int64(len(plan.Pages)), time.Now())
+	return plan, nil
+}
+
+// FetchPageTimeout bounds a synchronous page fetch, well within the
+// server's default write timeout
+const FetchPageTimeout = 30 * time.Second
+
+// FetchPage fetches the start URL of req and returns its extracted content
+// as text and Markdown along with its links, without creating a job (see
+// crawler.FetchPage). A fetch still running after FetchPageTimeout is
+// abandoned with a 504. Requests the server would refuse for a job are
+// refused.
+func (m *JobManager) FetchPage(ctx context.Context, req *CrawlRequest) (*crawler.FetchedPage, error) {
+	return m.FetchPageFor(ctx, AnonymousKey, req)
+}
+
+// FetchPageFor fetches a page like FetchPage, charging it to the named API
+// key and refusing it when the key has used up its quota
+func (m *JobManager) FetchPageFor(ctx context.Context, owner string, req *CrawlRequest) (*crawler.FetchedPage, error) {
+	if err := m.usage.CheckQuota(owner, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := m.checkCommands(req); err != nil {
+		return nil, err
+	}
+	config, err := buildConfig(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.applyPolite(config); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, FetchPageTimeout)
+	defer cancel()
+
+	type fetchResult struct {
+		page *crawler.FetchedPage
+		err  error
+	}
+	done := make(chan fetchResult, 1)
+	go func() {
+		page, err := crawler.FetchPage(ctx, *config)
+		done <- fetchResult{page, err}
+	}()
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return nil, APIError{Code: 502, Message: "fetch failed", Details: result.err.Error()}
+		}
+		m.usage.Charge(owner, result.page.Bytes, 1, time.Now())
+		return result.page, nil
+	case <-ctx.Done():
+		return nil, APIError{Code: 504, Message: "fetch timed out", Details: fmt.Sprintf("no response within %s", FetchPageTimeout)}
+	}
+}
+
+// translateConfig converts API CrawlRequest to crawler.Config and creates
+// its output directory
 func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
+	config, err := buildConfig(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure output directory exists
+	if err := crawler.EnsureOutputDir(config); err != nil {
+		return nil, APIError{Code: 500, Message: "failed to create output directory", Details: err.Error()}
+	}
+
+	return config, nil
+}
+
+// buildConfig converts req like translateConfig without creating the
+// output directory, for previews and one-shot fetches that write nothing
+func buildConfig(req *CrawlRequest) (*crawler.Config, error) {
+	// Settings of a recipe apply where the request leaves them empty
+	req, err := ApplyRecipe(req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Parse delay duration
 	delay := time.Second // default
 	if req.Delay != "" {
@@ -453,6 +1462,36 @@ func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
 		headless = *req.Headless
 	}
 
+	// Parse metrics sampling interval
+	var metricsInterval time.Duration
+	if req.MetricsInterval != "" {
+		d, err := time.ParseDuration(req.MetricsInterval)
+		if err != nil {
+			return nil, APIError{Code: 400, Message: "invalid metricsInterval format", Details: err.Error()}
+		}
+		metricsInterval = d
+	}
+
+	// Parse run time limit
+	var maxRuntime time.Duration
+	if req.MaxRuntime != "" {
+		d, err := time.ParseDuration(req.MaxRuntime)
+		if err != nil {
+			return nil, APIError{Code: 400, Message: "invalid maxRuntime format", Details: err.Error()}
+		}
+		maxRuntime = d
+	}
+
+	// Parse per-URL deadline
+	var urlTimeout time.Duration
+	if req.URLTimeout != "" {
+		d, err := time.ParseDuration(req.URLTimeout)
+		if err != nil {
+			return nil, APIError{Code: 400, Message: "invalid urlTimeout format", Details: err.Error()}
+		}
+		urlTimeout = d
+	}
+
 	// Parse page load wait duration
 	var pageLoadWait time.Duration
 	if req.PageLoadWait != "" {
@@ -463,10 +1502,21 @@ func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
 		pageLoadWait = waitDuration
 	}
 
+	// Parse lazy-load scroll delay
+	var scrollDelay time.Duration
+	if req.ScrollDelay != "" {
+		d, err := time.ParseDuration(req.ScrollDelay)
+		if err != nil {
+			return nil, APIError{Code: 400, Message: "invalid scrollDelay format", Details: err.Error()}
+		}
+		scrollDelay = d
+	}
+
 	// Build anti-bot config
 	var antiBotConfig crawler.AntiBotConfig
 	if req.AntiBot != nil {
 		antiBotConfig = crawler.AntiBotConfig{
+			Profile:              req.AntiBot.Profile,
 			HideWebdriver:        req.AntiBot.HideWebdriver,
 			SpoofPlugins:         req.AntiBot.SpoofPlugins,
 			SpoofLanguages:       req.AntiBot.SpoofLanguages,
@@ -484,6 +1534,93 @@ func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
 		}
 	}
 
+	var paginationConfig crawler.PaginationConfig
+	if req.Pagination != nil {
+		paginationConfig = crawler.PaginationConfig{
+			Enable:          req.Pagination.Enable,
+			Selector:        req.Pagination.Selector,
+			MaxClicks:       req.Pagination.MaxClicks,
+			WaitSelector:    req.Pagination.WaitSelector,
+			StopOnDuplicate: req.Pagination.StopOnDuplicate,
+			HarvestURLs:     req.Pagination.HarvestURLs,
+		}
+		if req.Pagination.WaitAfterClick != "" {
+			d, err := time.ParseDuration(req.Pagination.WaitAfterClick)
+			if err != nil {
+				return nil, APIError{Code: 400, Message: "invalid pagination waitAfterClick format", Details: err.Error()}
+			}
+			paginationConfig.WaitAfterClick = d
+		}
+	}
+
+	var geoConfig crawler.GeoConfig
+	if req.Geo != nil {
+		geoConfig = crawler.GeoConfig(*req.Geo)
+	}
+
+	var permissions crawler.OutputPermissions
+	if req.Permissions != nil {
+		permissions = crawler.OutputPermissions(*req.Permissions)
+	}
+
+	var faults crawler.FaultConfig
+	if req.Faults != nil {
+		faults = crawler.FaultConfig{
+			ErrorRate:    req.Faults.ErrorRate,
+			TruncateRate: req.Faults.TruncateRate,
+			ResetRate:    req.Faults.ResetRate,
+			Seed:         req.Faults.Seed,
+		}
+		if req.Faults.Latency != "" {
+			d, err := time.ParseDuration(req.Faults.Latency)
+			if err != nil {
+				return nil, APIError{Code: 400, Message: "invalid fault latency format", Details: err.Error()}
+			}
+			faults.Latency = d
+		}
+	}
+
+	var tracing crawler.TracingConfig
+	if req.Tracing != nil {
+		tracing = crawler.TracingConfig{Endpoint: req.Tracing.Endpoint, SampleRate: req.Tracing.SampleRate}
+	}
+	var embeddings crawler.EmbeddingsConfig
+	if req.Embeddings != nil {
+		embeddings = crawler.EmbeddingsConfig(*req.Embeddings)
+	}
+	var enrichment crawler.EnrichmentConfig
+	if e := req.Enrichment; e != nil {
+		enrichment = crawler.EnrichmentConfig{Endpoint: e.Endpoint, APIKey: e.APIKey, Concurrency: e.Concurrency}
+		if e.Timeout != "" {
+			d, err := time.ParseDuration(e.Timeout)
+			if err != nil {
+				return nil, APIError{Code: 400, Message: "invalid enrichment timeout format", Details: err.Error()}
+			}
+			enrichment.Timeout = d
+		}
+	}
+	var postSaveTimeout time.Duration
+	if req.PostSaveTimeout != "" {
+		d, err := time.ParseDuration(req.PostSaveTimeout)
+		if err != nil {
+			return nil, APIError{Code: 400, Message: "invalid postSaveTimeout format", Details: err.Error()}
+		}
+		postSaveTimeout = d
+	}
+	var waybackSaveInterval time.Duration
+	if req.WaybackSaveInterval != "" {
+		d, err := time.ParseDuration(req.WaybackSaveInterval)
+		if err != nil {
+			return nil, APIError{Code: 400, Message: "invalid waybackSaveInterval format", Details: err.Error()}
+		}
+		waybackSaveInterval = d
+	}
+
+	indexInterval := crawler.DefaultIndexInterval
+	if req.IndexInterval != nil {
+		indexInterval = *req.IndexInterval
+	}
+
 	// URL normalization settings (default to true if not specified)
 	normalizeURLs := true
 	if req.NormalizeURLs != nil {
@@ -493,24 +1630,95 @@ func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
 	config := &crawler.Config{
 		URL:                req.URL,
 		Concurrent:         req.Concurrent,
+		Workers:            req.Workers,
 		Delay:              delay,
 		MaxDepth:           maxDepth,
+		DepthMode:          req.DepthMode,
+		MaxPages:           req.MaxPages,
+		MaxRuntime:         maxRuntime,
+		MaxConsecutiveErrors: req.MaxConsecutiveErrors,
+		URLTimeout:         urlTimeout,
+		StopPattern:        req.StopPattern,
 		OutputDir:          req.OutputDir,
 		StateFile:          req.StateFile,
+		StateMerge:         req.StateMerge,
+		FrontierLimit:      req.FrontierLimit,
 		PrefixFilterURL:    req.PrefixFilterURL,
 		ExcludeExtensions:  req.ExcludeExtensions,
+		AllowedHosts:       req.AllowedHosts,
+		DeniedHosts:        req.DeniedHosts,
+		ExternalLinks:      req.ExternalLinks,
+		Report:             req.Report,
 		LinkSelectors:      req.LinkSelectors,
 		Verbose:            req.Verbose,
 		UserAgent:          req.UserAgent,
+		UserAgentPool:      req.UserAgentPool,
+		UserAgents:         req.UserAgents,
+		StickyUserAgent:    req.StickyUserAgent,
+		WarmUp:             req.WarmUp,
+		WarmUpPages:        req.WarmUpPages,
+		RefererPolicy:      req.RefererPolicy,
 		IgnoreRobots:       req.IgnoreRobots,
 		MinContentLength:   minContent,
+		MaxContentLength:   req.MaxContentLength,
+		MinWords:           req.MinWords,
+		MaxWords:           req.MaxWords,
+		MaxLinkDensity:     req.MaxLinkDensity,
+		MaxHTMLSize:        req.MaxHTMLSize,
 		ShowProgress:       false, // API doesn't need console progress
 		DisableContentExtraction: req.DisableContentExtraction || req.DisableReadability,
+		CompressOutput:     req.CompressOutput,
+		MetricsInterval:    metricsInterval,
 		FetchMode:          fetchMode,
 		Headless:           headless,
 		WaitForLogin:       req.WaitForLogin,
 		PageLoadWait:       pageLoadWait,
+		HARMode:            req.HARMode,
+		DiscoverAPIs:       req.DiscoverAPIs,
+		PageScripts:        req.PageScripts,
+		KeepCookieBanners:  req.KeepCookieBanners,
+		LazyLoadScroll:     req.LazyLoadScroll,
+		ScrollStep:         req.ScrollStep,
+		ScrollDelay:        scrollDelay,
+		ShadowDOM:          req.ShadowDOM,
+		BrowserRecycle:     req.BrowserRecycle,
+		BrowserPath:        req.BrowserPath,
+		BrowserMaxMemory:   req.BrowserMaxMemory,
+		BrowserMaxTabs:     req.BrowserMaxTabs,
+		BrowserMaxProcesses: req.BrowserMaxProcesses,
+		Iframes:            req.Iframes,
+		IframeHosts:        req.IframeHosts,
+		Provenance:         req.Provenance,
+		ContentAddressable: req.ContentAddressable,
+		ContentFilters:     req.ContentFilters,
+		FollowOnly:         req.FollowOnly,
+		Alerts:             req.Alerts,
+		PluginsDir:         req.PluginsDir,
+		IndexInterval:      indexInterval,
 		AntiBot:            antiBotConfig,
+		Geo:                geoConfig,
+		Pagination:         paginationConfig,
+		Permissions:        permissions,
+		RecrawlURLs:        req.RecrawlURLs,
+		RecrawlScope:       req.RecrawlScope,
+		Faults:             faults,
+		Cassette:           req.Cassette,
+		CassetteFile:       req.CassetteFile,
+		Notify:             req.Notify,
+		TraceDecisions:     req.TraceDecisions,
+		ResultsDB:          req.ResultsDB,
+		EventSinks:         req.EventSinks,
+		Tracing:            tracing,
+		Embeddings:         embeddings,
+		Enrichment:         enrichment,
+		PostSaveCommand:     req.PostSaveCommand,
+		PostSaveConcurrency: req.PostSaveConcurrency,
+		PostSaveTimeout:     postSaveTimeout,
+		PostSaveOnFailure:   req.PostSaveOnFailure,
+		WaybackFallback:     req.WaybackFallback,
+		WaybackSave:         req.WaybackSave,
+		WaybackSaveKey:      req.WaybackSaveKey,
+		WaybackSaveInterval: waybackSaveInterval,
 		NormalizeURLs:      normalizeURLs,
 		LowercasePaths:     req.LowercasePaths,
 	}
@@ -519,6 +1727,12 @@ func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
 	if err := crawler.ValidateConfig(config); err != nil {
 		return nil, APIError{Code: 400, Message: "invalid configuration", Details: err.Error()}
 	}
+	// Fail before the job starts when a referenced secret can't be resolved,
+	// without telling clients which secrets exist
+	if _, err := crawler.ResolveSecrets(*config); err != nil {
+		log.Printf("Secrets: %v", err)
+		return nil, APIError{Code: 400, Message: "invalid configuration", Details: "a secret reference can't be resolved"}
+	}
 
 	// Set default output directory
 	if err := crawler.SetDefaultOutputDir(config); err != nil {
@@ -528,10 +1742,5 @@ func translateConfig(req *CrawlRequest) (*crawler.Config, error) {
 	// Set default state file
 	crawler.SetDefaultStateFile(config)
 
-	// Ensure output directory exists
-	if err := crawler.EnsureOutputDir(config); err != nil {
-		return nil, APIError{Code: 500, Message: "failed to create output directory", Details: err.Error()}
-	}
-
 	return config, nil
 }
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxBodySize is the default limit of request bodies (1 MiB)
+const DefaultMaxBodySize = 1 << 20
+
+// Limits of client-supplied crawl requests
+const (
+	MaxURLLength        = 2048  // Longest accepted URL
+	MaxRequestURLs      = 10000 // Most URLs in a request's URL lists (recrawlUrls)
+	MaxRequestListItems = 100   // Most entries in other lists (tags, selectors, scripts, ...)
+)
+
+// unmarshalStrict is json.Unmarshal that rejects unknown fields and data
+// after the JSON value
+func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// ValidateCrawlRequest bounds the size of a client-supplied crawl request so
+// a malformed or malicious client cannot start absurd jobs
+func ValidateCrawlRequest(req *CrawlRequest) error {
+	if req.URL == "" {
+		return APIError{Code: 400, Message: "url is required"}
+	}
+	if len(req.URL) > MaxURLLength {
+		return APIError{Code: 400, Message: "url too long", Details: fmt.Sprintf("urls are limited to %d characters", MaxURLLength)}
+	}
+
+	if len(req.RecrawlURLs) > MaxRequestURLs {
+		return APIError{
+			Code:    400,
+			Message: "too many URLs",
+			Details: fmt.Sprintf("recrawlUrls has %d URLs, the limit is %d", len(req.RecrawlURLs), MaxRequestURLs),
+		}
+	}
+	for _, u := range req.RecrawlURLs {
+		if len(u) > MaxURLLength {
+			return APIError{Code: 400, Message: "url too long", Details: fmt.Sprintf("recrawlUrls entries are limited to %d characters", MaxURLLength)}
+		}
+	}
+
+	lists := []struct {
+		name string
+		n    int
+	}{
+		{"excludeExtensions", len(req.ExcludeExtensions)},
+		{"allowedHosts", len(req.AllowedHosts)},
+		{"deniedHosts", len(req.DeniedHosts)},
+		{"iframeHosts", len(req.IframeHosts)},
+		{"linkSelectors", len(req.LinkSelectors)},
+		{"pageScripts", len(req.PageScripts)},
+		{"contentFilters", len(req.ContentFilters)},
+		{"followOnly", len(req.FollowOnly)},
+		{"alerts", len(req.Alerts)},
+		{"eventSinks", len(req.EventSinks)},
+		{"userAgents", len(req.UserAgents)},
+		{"tags", len(req.Tags)},
+	}
+	for _, l := range lists {
+		if l.n > MaxRequestListItems {
+			return APIError{
+				Code:    400,
+				Message: "too many list entries",
+				Details: fmt.Sprintf("%s has %d entries, the limit is %d", l.name, l.n, MaxRequestListItems),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"scraper/internal/crawler"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ManifestPollInterval is how often a followed manifest is checked for
+// newly saved pages
+const ManifestPollInterval = 500 * time.Millisecond
+
+// ReadJobManifest returns the complete lines of the job's pages.ndjson from
+// byte offset on and the offset after them. done reports whether the job
+// had finished before they were read, so no pages follow them.
+func (m *JobManager) ReadJobManifest(jobID string, offset int64) (data []byte, next int64, done bool, err error) {
+	if offset < 0 {
+		return nil, 0, false, APIError{Code: 400, Message: "offset must not be negative"}
+	}
+	job, err := m.GetJob(jobID)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	switch job.GetStatus() {
+	case JobStatusCompleted, JobStatusStopped, JobStatusError:
+		done = true
+	}
+	outputDir := job.GetOutputDir()
+	if outputDir == "" {
+		// Not started yet
+		return nil, offset, done, nil
+	}
+	data, next, err = crawler.ReadManifestLines(outputDir, offset)
+	if err != nil {
+		return nil, 0, false, APIError{Code: 422, Message: "failed to read page manifest", Details: err.Error()}
+	}
+	return data, next, done, nil
+}
+
+// GetManifest handles GET /api/v1/crawl/{jobId}/manifest
+// Query params: offset (byte offset to read from, default 0), follow (true to
+// keep the response open and stream pages as they are saved until the job
+// ends). Without follow, X-Manifest-Offset is the offset to read on from;
+// with it, that is offset plus the bytes received.
+func (h *Handlers) GetManifest(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobId")
+	q := r.URL.Query()
+
+	var offset int64
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, APIError{Code: 400, Message: "invalid offset", Details: err.Error()})
+			return
+		}
+		offset = n
+	}
+
+	data, next, done, err := h.JobManager.ReadJobManifest(jobID, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if q.Get("follow") != "true" {
+		w.Header().Set("X-Manifest-Offset", strconv.FormatInt(next, 10))
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
+	w.WriteHeader(http.StatusOK)
+	stream := newSSEStream(w)
+	poll := time.NewTicker(ManifestPollInterval)
+	defer poll.Stop()
+	for {
+		if err := stream.Send(func() error {
+			_, err := w.Write(data)
+			return err
+		}); err != nil {
+			return
+		}
+		if done {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		}
+		if data, next, done, err = h.JobManager.ReadJobManifest(jobID, next); err != nil {
+			return
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"runtime/debug"
 	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // Logger middleware logs HTTP requests
@@ -48,6 +51,12 @@ func (rw *responseWriter) Flush() {
 	}
 }
 
+// Unwrap lets http.ResponseController reach the connection, for SSE write
+// deadlines
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Recovery middleware recovers from panics
 func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -65,8 +74,23 @@ func Recovery(next http.Handler) http.Handler {
 	})
 }
 
-// APIKeyAuth middleware validates API key authentication
-func APIKeyAuth(apiKey string) func(http.Handler) http.Handler {
+// apiKeyContextKey is the request context key of the authenticated APIKeyConfig
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext returns the API key a request authenticated with
+func APIKeyFromContext(ctx context.Context) (APIKeyConfig, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(APIKeyConfig)
+	return key, ok
+}
+
+// APIKeyAuth middleware validates API key authentication against keys and
+// records the matching key in the request context
+func APIKeyAuth(keys []APIKeyConfig) func(http.Handler) http.Handler {
+	byKey := make(map[string]APIKeyConfig, len(keys))
+	for _, k := range keys {
+		byKey[k.Key] = k
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip auth for health endpoint
@@ -98,7 +122,8 @@ func APIKeyAuth(apiKey string) func(http.Handler) http.Handler {
 			}
 
 			// Validate API key
-			if parts[1] != apiKey {
+			key, ok := byKey[parts[1]]
+			if !ok {
 				writeJSON(w, http.StatusUnauthorized, APIError{
 					Code:    401,
 					Message: "invalid API key",
@@ -106,6 +131,35 @@ func APIKeyAuth(apiKey string) func(http.Handler) http.Handler {
 				return
 			}
 
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+		})
+	}
+}
+
+// JobAccess middleware answers requests for a job owned by another API key
+// with 404, as if the job did not exist, unless the key is an admin key.
+// Requests for unknown jobs pass through to the handler.
+func JobAccess(jm *JobManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := APIKeyFromContext(r.Context())
+			if job, err := jm.GetJob(chi.URLParam(r, "jobId")); err == nil && !CanAccessJob(key, ok, job) {
+				writeError(w, APIError{Code: 404, Message: "job not found"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBodySize middleware limits request bodies to limit bytes; reading past
+// it fails with *http.MaxBytesError
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -137,8 +191,9 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 			}
 
 			// Set other CORS headers
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-RateLimit-Limit, X-RateLimit-Remaining, Retry-After")
 			w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 
 			// Handle preflight requests
@@ -0,0 +1,496 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PresetVersion is the current preset format version. Version 1 presets
+// are the files the desktop app saved before presets were versioned: the
+// flat settings of its form, without a version field. Version 2 keeps the
+// settings as a CrawlRequest, like job definitions, and adds bundles of
+// several presets for sharing.
+const PresetVersion = 2
+
+// MaxPresetNameLength is the longest accepted preset name
+const MaxPresetNameLength = 50
+
+// PresetNamePattern matches valid preset names, which are also file names
+var PresetNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// Preset is a named set of crawl settings, e.g. tuned for one site, that
+// can be shared between the desktop app, the CLI, the API and MCP. As in
+// job definitions, settings tied to an environment (output directory,
+// state file, ...) are left out.
+type Preset struct {
+	Version   int          `json:"version,omitempty"` // Set in preset files; a bundle has one for all its presets
+	Name      string       `json:"name"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Request   CrawlRequest `json:"request"`
+	Owner     string       `json:"owner,omitempty"` // API key that saved the preset; other non-admin keys can't change it
+}
+
+// PresetBundle is a set of presets exported to a single file
+type PresetBundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Presets    []Preset  `json:"presets"`
+}
+
+// PresetSummary describes a stored preset in listings
+type PresetSummary struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// PresetImportResult reports the outcome of importing presets
+type PresetImportResult struct {
+	Imported []string `json:"imported"`
+	Skipped  []string `json:"skipped,omitempty"`  // Already existing and not overwritten
+	Upgraded int      `json:"upgraded,omitempty"` // Converted from an older preset version
+}
+
+// NewPreset creates a preset of the current version from req
+func NewPreset(name string, req CrawlRequest) Preset {
+	return Preset{
+		Version:   PresetVersion,
+		Name:      name,
+		CreatedAt: time.Now(),
+		Request:   portableRequest(req),
+	}
+}
+
+// ValidatePresetName checks that name can be used for a preset
+func ValidatePresetName(name string) error {
+	var details string
+	switch {
+	case name == "":
+		details = "preset name cannot be empty"
+	case len(name) > MaxPresetNameLength:
+		details = fmt.Sprintf("preset name too long (max %d characters)", MaxPresetNameLength)
+	case !PresetNamePattern.MatchString(name):
+		details = "preset name can only contain letters, numbers, dashes, and underscores"
+	default:
+		return nil
+	}
+	return APIError{Code: 400, Message: "invalid preset name", Details: details}
+}
+
+// validatePreset checks the name and settings of p
+func validatePreset(p *Preset) error {
+	if err := ValidatePresetName(p.Name); err != nil {
+		return err
+	}
+	// A preset may leave the start URL to the crawl using it
+	check := p.Request
+	if check.URL == "" {
+		check.URL = "https://example.com/"
+	}
+	return ValidateCrawlRequest(&check)
+}
+
+// ParsePresets decodes a preset file or bundle, upgrading presets of older
+// versions, and returns the presets with the number that were upgraded
+func ParsePresets(data []byte) ([]Preset, int, error) {
+	var probe struct {
+		Version int             `json:"version"`
+		Presets json.RawMessage `json:"presets"`
+		Request json.RawMessage `json:"request"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, 0, APIError{Code: 400, Message: "invalid JSON", Details: err.Error()}
+	}
+	if probe.Version > PresetVersion {
+		return nil, 0, APIError{
+			Code:    400,
+			Message: "unsupported preset version",
+			Details: fmt.Sprintf("version %d is newer than supported version %d", probe.Version, PresetVersion),
+		}
+	}
+
+	version := probe.Version
+	if version == 0 {
+		// Unversioned files are version 1, unless written by hand in the
+		// current layout
+		version = 1
+		if len(probe.Request) > 0 {
+			version = PresetVersion
+		}
+	}
+
+	raws := []json.RawMessage{data}
+	if len(probe.Presets) > 0 {
+		var bundle struct {
+			Version    int               `json:"version"`
+			ExportedAt time.Time         `json:"exportedAt"`
+			Presets    []json.RawMessage `json:"presets"`
+		}
+		if err := unmarshalStrict(data, &bundle); err != nil {
+			return nil, 0, APIError{Code: 400, Message: "invalid preset bundle", Details: err.Error()}
+		}
+		raws = bundle.Presets
+	}
+
+	presets := make([]Preset, 0, len(raws))
+	for i, raw := range raws {
+		p, err := decodePreset(raw, version)
+		if err != nil {
+			var apiErr APIError
+			if !errors.As(err, &apiErr) {
+				apiErr = APIError{Code: 400, Message: "invalid preset", Details: err.Error()}
+			}
+			if len(raws) > 1 {
+				apiErr.Details = fmt.Sprintf("preset %d: %s", i+1, apiErr.Details)
+			}
+			return nil, 0, apiErr
+		}
+		presets = append(presets, p)
+	}
+	upgraded := 0
+	if version < PresetVersion {
+		upgraded = len(presets)
+	}
+	return presets, upgraded, nil
+}
+
+// decodePreset upgrades a preset of the given version to the current one
+// and decodes it
+func decodePreset(raw json.RawMessage, version int) (Preset, error) {
+	for v := version; v < PresetVersion; v++ {
+		var err error
+		if raw, err = presetMigrations[v](raw); err != nil {
+			return Preset{}, err
+		}
+	}
+
+	// Settings that no longer exist are dropped from upgraded presets
+	// rather than failing them
+	decode := unmarshalStrict
+	if version < PresetVersion {
+		decode = json.Unmarshal
+	}
+	var p Preset
+	if err := decode(raw, &p); err != nil {
+		return Preset{}, err
+	}
+	p.Version = PresetVersion
+	p.Request = portableRequest(p.Request)
+	if err := validatePreset(&p); err != nil {
+		return Preset{}, err
+	}
+	return p, nil
+}
+
+// presetMigrations upgrade a preset from the version of their key to the
+// next one
+var presetMigrations = map[int]func(json.RawMessage) (json.RawMessage, error){
+	1: migratePresetV1,
+}
+
+// presetV1Nested maps the flat settings of version 1 presets to the request
+// object and field holding them in version 2
+var presetV1Nested = map[string][2]string{
+	"enablePagination":          {"pagination", "enable"},
+	"paginationSelector":        {"pagination", "selector"},
+	"maxPaginationClicks":       {"pagination", "maxClicks"},
+	"paginationWait":            {"pagination", "waitAfterClick"},
+	"paginationWaitSelector":    {"pagination", "waitSelector"},
+	"paginationStopOnDuplicate": {"pagination", "stopOnDuplicate"},
+	"hideWebdriver":             {"antiBot", "hideWebdriver"},
+	"spoofPlugins":              {"antiBot", "spoofPlugins"},
+	"spoofLanguages":            {"antiBot", "spoofLanguages"},
+	"spoofWebGL":                {"antiBot", "spoofWebGL"},
+	"addCanvasNoise":            {"antiBot", "addCanvasNoise"},
+	"naturalMouseMovement":      {"antiBot", "naturalMouseMovement"},
+	"randomTypingDelays":        {"antiBot", "randomTypingDelays"},
+	"naturalScrolling":          {"antiBot", "naturalScrolling"},
+	"randomActionDelays":        {"antiBot", "randomActionDelays"},
+	"randomClickOffset":         {"antiBot", "randomClickOffset"},
+	"rotateUserAgent":           {"antiBot", "rotateUserAgent"},
+	"randomViewport":            {"antiBot", "randomViewport"},
+	"matchTimezone":             {"antiBot", "matchTimezone"},
+	"timezone":                  {"antiBot", "timezone"},
+	"region":                    {"geo", "region"},
+	"acceptLanguage":            {"geo", "acceptLanguage"},
+	"locale":                    {"geo", "locale"},
+	"geoTimezone":               {"geo", "timezone"},
+	"geolocation":               {"geo", "geolocation"},
+	"proxy":                     {"geo", "proxy"},
+	"fileMode":                  {"permissions", "fileMode"},
+	"dirMode":                   {"permissions", "dirMode"},
+	"owner":                     {"permissions", "owner"},
+}
+
+// presetV1Lists are the comma-separated settings of version 1 presets,
+// which are arrays in version 2
+var presetV1Lists = map[string]bool{
+	"allowedHosts":      true,
+	"deniedHosts":       true,
+	"excludeExtensions": true,
+	"linkSelectors":     true,
+}
+
+// migratePresetV1 converts the flat form settings of a version 1 preset to
+// a version 2 preset with a crawl request
+func migratePresetV1(raw json.RawMessage) (json.RawMessage, error) {
+	var flat map[string]any
+	if err := json.Unmarshal(raw, &flat); err != nil {
+		return nil, err
+	}
+
+	preset := map[string]any{"name": flat["name"], "createdAt": flat["createdAt"]}
+	delete(flat, "name")
+	delete(flat, "createdAt")
+	delete(flat, "version")
+
+	request := make(map[string]any)
+	groups := make(map[string]map[string]any)
+	for key, value := range flat {
+		if nested, ok := presetV1Nested[key]; ok {
+			// Like the desktop app, leave out groups of default settings
+			if value == nil || value == "" || value == false || value == float64(0) {
+				continue
+			}
+			if groups[nested[0]] == nil {
+				groups[nested[0]] = make(map[string]any)
+			}
+			groups[nested[0]][nested[1]] = value
+			continue
+		}
+		if presetV1Lists[key] {
+			s, _ := value.(string)
+			var list []string
+			for _, part := range strings.Split(s, ",") {
+				if part = strings.TrimSpace(part); part != "" {
+					list = append(list, part)
+				}
+			}
+			if len(list) > 0 {
+				request[key] = list
+			}
+			continue
+		}
+		request[key] = value
+	}
+	// Pagination settings only applied with pagination enabled
+	if groups["pagination"] != nil && groups["pagination"]["enable"] != true {
+		delete(groups, "pagination")
+	}
+	for name, group := range groups {
+		request[name] = group
+	}
+	preset["request"] = request
+	return json.Marshal(preset)
+}
+
+// PresetStore keeps presets as one JSON file per preset in a directory.
+// The desktop app, the CLI, the API server and the MCP server use the same
+// directory by default, so presets saved by one are available to the
+// others on the same machine.
+type PresetStore struct {
+	dir string
+}
+
+// DefaultPresetsDir returns the presets directory in the user config dir
+func DefaultPresetsDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, "scraper", "presets"), nil
+}
+
+// NewPresetStore creates a store for the presets in dir
+func NewPresetStore(dir string) *PresetStore {
+	return &PresetStore{dir: dir}
+}
+
+// Dir returns the directory the presets are kept in
+func (s *PresetStore) Dir() string {
+	return s.dir
+}
+
+// path returns the file of the preset name
+func (s *PresetStore) path(name string) (string, error) {
+	if err := ValidatePresetName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, name+".json"), nil
+}
+
+// List returns the stored presets, newest first. Files that can't be read
+// as presets are skipped.
+func (s *PresetStore) List() ([]PresetSummary, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return []PresetSummary{}, nil
+	}
+	if err != nil {
+		return nil, APIError{Code: 500, Message: "failed to read presets", Details: err.Error()}
+	}
+
+	presets := make([]PresetSummary, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		p, err := s.Get(name)
+		if err != nil {
+			continue
+		}
+		presets = append(presets, PresetSummary{Name: name, CreatedAt: p.CreatedAt, URL: p.Request.URL})
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].CreatedAt.After(presets[j].CreatedAt)
+	})
+	return presets, nil
+}
+
+// Get loads the preset name, upgrading it if it was saved by an older
+// version
+func (s *PresetStore) Get(name string) (*Preset, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, APIError{Code: 404, Message: "preset not found", Details: name}
+	}
+	if err != nil {
+		return nil, APIError{Code: 500, Message: "failed to read preset", Details: err.Error()}
+	}
+
+	presets, _, err := ParsePresets(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(presets) != 1 {
+		return nil, APIError{Code: 400, Message: "invalid preset", Details: fmt.Sprintf("%s holds %d presets", path, len(presets))}
+	}
+	p := presets[0]
+	// The file name is the preset's name, also for files copied in by hand
+	p.Name = name
+	return &p, nil
+}
+
+// Save validates p and writes it in the current version, replacing a
+// preset of the same name
+func (s *PresetStore) Save(p Preset) error {
+	p.Version = PresetVersion
+	p.Request = portableRequest(p.Request)
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+	if err := validatePreset(&p); err != nil {
+		return err
+	}
+	path, err := s.path(p.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return APIError{Code: 500, Message: "failed to save preset", Details: err.Error()}
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return APIError{Code: 500, Message: "failed to save preset", Details: err.Error()}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return APIError{Code: 500, Message: "failed to save preset", Details: err.Error()}
+	}
+	return nil
+}
+
+// Delete removes the preset name
+func (s *PresetStore) Delete(name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return APIError{Code: 404, Message: "preset not found", Details: name}
+		}
+		return APIError{Code: 500, Message: "failed to delete preset", Details: err.Error()}
+	}
+	return nil
+}
+
+// Export bundles the presets names, or every stored preset when names is
+// empty, sorted by name
+func (s *PresetStore) Export(names []string) (*PresetBundle, error) {
+	if len(names) == 0 {
+		list, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range list {
+			names = append(names, p.Name)
+		}
+	}
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+
+	bundle := &PresetBundle{Version: PresetVersion, ExportedAt: time.Now(), Presets: make([]Preset, 0, len(names))}
+	for _, name := range names {
+		p, err := s.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		p.Version = 0
+		bundle.Presets = append(bundle.Presets, *p)
+	}
+	return bundle, nil
+}
+
+// Import saves the presets of a preset file or bundle of any supported
+// version. Presets whose name is taken are skipped unless overwrite is set.
+func (s *PresetStore) Import(data []byte, overwrite bool) (*PresetImportResult, error) {
+	return s.ImportFor("", data, overwrite)
+}
+
+// ImportFor imports presets like Import on behalf of the named non-admin
+// API key: they are saved as the key's, and presets of the same name saved
+// by others are skipped even with overwrite. An empty owner imports them as
+// Import does.
+func (s *PresetStore) ImportFor(owner string, data []byte, overwrite bool) (*PresetImportResult, error) {
+	presets, upgraded, err := ParsePresets(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PresetImportResult{Imported: []string{}, Upgraded: upgraded}
+	for _, p := range presets {
+		if owner != "" {
+			p.Owner = owner
+			if existing, err := s.Get(p.Name); err == nil && existing.Owner != owner {
+				result.Skipped = append(result.Skipped, p.Name)
+				continue
+			}
+		}
+		if !overwrite {
+			if path, err := s.path(p.Name); err == nil {
+				if _, err := os.Stat(path); err == nil {
+					result.Skipped = append(result.Skipped, p.Name)
+					continue
+				}
+			}
+		}
+		if err := s.Save(p); err != nil {
+			return result, err
+		}
+		result.Imported = append(result.Imported, p.Name)
+	}
+	return result, nil
+}
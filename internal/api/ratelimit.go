@@ -0,0 +1,225 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultAuthFailureLimit is how many failed authentications a client IP
+// may make per minute before it is refused
+const DefaultAuthFailureLimit = 10
+
+// maxRateBuckets bounds the buckets a RateLimiter keeps before it drops the
+// ones that have refilled
+const maxRateBuckets = 10000
+
+// tokenBucket holds the tokens of one client; it refills continuously at
+// perMinute tokens a minute up to burst
+type tokenBucket struct {
+	tokens    float64
+	perMinute int
+	burst     int
+	updated   time.Time
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Minutes()
+	b.tokens = math.Min(float64(b.burst), b.tokens+elapsed*float64(b.perMinute))
+	b.updated = now
+}
+
+// full reports whether the bucket has refilled completely, so dropping it
+// changes nothing
+func (b *tokenBucket) full(now time.Time) bool {
+	elapsed := now.Sub(b.updated).Minutes()
+	return b.tokens+elapsed*float64(b.perMinute) >= float64(b.burst)
+}
+
+// RateDecision is the outcome of taking a token from a bucket
+type RateDecision struct {
+	Allowed    bool
+	Limit      int           // Requests per minute of the bucket
+	Remaining  int           // Whole tokens left
+	RetryAfter time.Duration // Until the next token, when refused
+}
+
+// RateLimiter is a set of token buckets keyed by client, each allowing
+// perMinute requests a minute with bursts of up to burst requests. Buckets
+// may have their own rate, as API keys with a rate limit of their own do.
+type RateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	burst     int
+	buckets   map[string]*tokenBucket
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a limiter allowing perMinute requests a minute per
+// client, in bursts of up to burst requests (0 = perMinute)
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	return &RateLimiter{
+		perMinute: perMinute,
+		burst:     burst,
+		buckets:   make(map[string]*tokenBucket),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of key, refilled at the limiter's rate
+func (l *RateLimiter) Allow(key string) RateDecision {
+	return l.AllowN(key, l.perMinute)
+}
+
+// AllowN takes a token from the bucket of key, refilled at perMinute tokens
+// a minute instead of the limiter's rate
+func (l *RateLimiter) AllowN(key string, perMinute int) RateDecision {
+	return l.take(key, perMinute, true)
+}
+
+// Check reports whether the bucket of key has a token left without taking it
+func (l *RateLimiter) Check(key string) RateDecision {
+	return l.take(key, l.perMinute, false)
+}
+
+func (l *RateLimiter) take(key string, perMinute int, consume bool) RateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	burst := l.burst
+	if burst <= 0 {
+		burst = perMinute
+	}
+	b, ok := l.buckets[key]
+	if !ok || b.perMinute != perMinute || b.burst != burst {
+		if !consume {
+			return RateDecision{Allowed: true, Limit: perMinute, Remaining: burst}
+		}
+		l.prune(now)
+		b = &tokenBucket{tokens: float64(burst), perMinute: perMinute, burst: burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	decision := RateDecision{Limit: perMinute}
+	if b.tokens < 1 {
+		missing := (1 - b.tokens) / float64(perMinute)
+		decision.RetryAfter = time.Duration(math.Ceil(missing * float64(time.Minute)))
+		return decision
+	}
+	if consume {
+		b.tokens--
+	}
+	decision.Allowed = true
+	decision.Remaining = int(b.tokens)
+	return decision
+}
+
+// prune drops the buckets that have refilled once there are too many
+func (l *RateLimiter) prune(now time.Time) {
+	if len(l.buckets) < maxRateBuckets {
+		return
+	}
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP returns the IP address of a request's remote address. Forwarding
+// headers are ignored since clients can set them to anything.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// setRateHeaders describes a decision in the X-RateLimit-* headers, with
+// Retry-After when the request was refused
+func setRateHeaders(w http.ResponseWriter, d RateDecision) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	if !d.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.RetryAfter.Seconds()))))
+	}
+}
+
+// RateLimit middleware limits the requests of each API key, or of each
+// client IP when the request is not authenticated, answering those over the
+// limit with 429. Keys with a rate limit of their own use it instead of the
+// limiter's. Must run after APIKeyAuth; /health is not limited.
+func RateLimit(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var d RateDecision
+			if key, ok := APIKeyFromContext(r.Context()); ok {
+				perMinute := limiter.perMinute
+				if key.RateLimit > 0 {
+					perMinute = key.RateLimit
+				}
+				if perMinute <= 0 {
+					next.ServeHTTP(w, r)
+					return
+				}
+				d = limiter.AllowN("key:"+key.Name, perMinute)
+			} else {
+				if limiter.perMinute <= 0 {
+					next.ServeHTTP(w, r)
+					return
+				}
+				d = limiter.Allow("ip:" + clientIP(r))
+			}
+
+			setRateHeaders(w, d)
+			if !d.Allowed {
+				writeError(w, APIError{
+					Code:    429,
+					Message: "rate limit exceeded",
+					Details: "retry after " + d.RetryAfter.Round(time.Second).String(),
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthFailureLimit middleware counts the requests of each client IP that
+// fail authentication and refuses the IP with 429, before checking its key,
+// once it has used up its failures, so API keys can't be guessed at speed.
+// Must run before APIKeyAuth.
+func AuthFailureLimit(limiter *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+			if d := limiter.Check(ip); !d.Allowed {
+				setRateHeaders(w, d)
+				writeError(w, APIError{
+					Code:    429,
+					Message: "too many failed authentication attempts",
+					Details: "retry after " + d.RetryAfter.Round(time.Second).String(),
+				})
+				return
+			}
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+			if wrapped.statusCode == http.StatusUnauthorized {
+				limiter.Allow(ip)
+			}
+		})
+	}
+}
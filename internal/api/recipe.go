@@ -0,0 +1,303 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// RecipesDirEnv names the environment variable holding the directories of
+// user recipes, separated like PATH. It defaults to the recipes directory
+// in the user config dir.
+const RecipesDirEnv = "SCRAPER_RECIPES_DIR"
+
+// BuiltinRecipeSource is the Source of the recipes compiled into the scraper
+const BuiltinRecipeSource = "builtin"
+
+// Recipe is a named set of crawl settings for a kind of site, such as a
+// forum or wiki software: link selectors, follow-only rules, content
+// filters, pagination and anti-bot options. A crawl request naming a recipe
+// gets the recipe's settings for every setting it leaves empty.
+type Recipe struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Sites       []string     `json:"sites,omitempty"`  // Software or sites the recipe is made for, for listings
+	Request     CrawlRequest `json:"request"`          // Settings applied; no URL and no environment-specific fields
+	Source      string       `json:"source,omitempty"` // "builtin" or the file the recipe was loaded from
+}
+
+// builtinRecipes are available in every installation. Link selectors are
+// chained :not() filters because the CLI splits -link-selectors at commas.
+var builtinRecipes = []Recipe{
+	{
+		Name:        "discourse-forum",
+		Description: "Discourse forums: saves topics; category, tag and listing pages are followed but not saved; user profiles, search, login and raw/print views are skipped",
+		Sites:       []string{"Discourse"},
+		Request: CrawlRequest{
+			LinkSelectors: []string{`a[href]:not([href*="/u/"]):not([href*="/search"]):not([href*="/login"]):not([href*="/signup"]):not([href*="/raw/"]):not([href*="print=true"])`},
+			FollowOnly: []crawler.FollowOnlyRule{
+				{Pattern: `/(c|tag|tags)/`},
+				{Pattern: `/(latest|top|new|categories)([/?]|$)`},
+			},
+			ContentFilters: []crawler.ContentFilter{
+				{Pattern: `/t/`, Keep: "#main-outlet", Remove: ".crawler-nav, .crawler-linkback-list, .topic-map, .post-links-container, #suggested-topics"},
+			},
+		},
+	},
+	{
+		Name:        "mediawiki",
+		Description: "MediaWiki sites such as Wikipedia or Fandom: saves articles; category pages are followed but not saved; special, talk and user pages, edit and history links and links to missing pages are skipped",
+		Sites:       []string{"MediaWiki", "Wikipedia", "Fandom"},
+		Request: CrawlRequest{
+			LinkSelectors: []string{`a[href]:not(.new):not(.external):not([href*="Special:"]):not([href*="Talk:"]):not([href*="_talk:"]):not([href*="User:"]):not([href*="action="]):not([href*="oldid="]):not([href*="diff="]):not([href*="printable="])`},
+			FollowOnly: []crawler.FollowOnlyRule{
+				{Pattern: `Category:`},
+			},
+			ContentFilters: []crawler.ContentFilter{
+				{Keep: "#content, main", Remove: ".mw-editsection, #toc, .toc, .navbox, .mw-jump-link, .printfooter"},
+			},
+		},
+	},
+	{
+		Name:        "wordpress-blog",
+		Description: "WordPress blogs: saves posts and pages; category, tag, author, date and page-number archives are followed but not saved; feeds, comment replies, share links and admin pages are skipped",
+		Sites:       []string{"WordPress"},
+		Request: CrawlRequest{
+			LinkSelectors: []string{`a[href]:not([href*="/wp-admin"]):not([href*="/wp-login.php"]):not([href*="/wp-json"]):not([href*="/feed"]):not([href*="replytocom="]):not([href*="share="])`},
+			FollowOnly: []crawler.FollowOnlyRule{
+				{Pattern: `/(category|tag|author)/`},
+				{Pattern: `/page/[0-9]+/?$`},
+				{Pattern: `/[0-9]{4}/([0-9]{2}/)?$`},
+			},
+			ContentFilters: []crawler.ContentFilter{
+				{Remove: "#comments, .comments-area, .sharedaddy, .jp-relatedposts, .related-posts, .post-navigation, .widget-area"},
+			},
+		},
+	},
+	{
+		Name:        "docusaurus-docs",
+		Description: "Docusaurus documentation sites: keeps the article of each page without the table of contents, edit links and previous/next navigation; blog tag pages are followed but not saved",
+		Sites:       []string{"Docusaurus"},
+		Request: CrawlRequest{
+			FollowOnly: []crawler.FollowOnlyRule{
+				{Pattern: `/tags/`},
+			},
+			ContentFilters: []crawler.ContentFilter{
+				{Keep: "article", Remove: ".theme-doc-toc-mobile, .theme-doc-footer, .pagination-nav, .theme-edit-this-page"},
+			},
+		},
+	},
+	{
+		Name:        "medium-blog",
+		Description: "Medium publications and profiles: renders pages in a browser with anti-bot measures and a slower pace, keeps the article, and skips sign-in, membership and tag listing links",
+		Sites:       []string{"Medium"},
+		Request: CrawlRequest{
+			FetchMode:     "browser",
+			Delay:         "3s",
+			PageLoadWait:  "3s",
+			LinkSelectors: []string{`a[href]:not([href*="/m/signin"]):not([href*="/membership"]):not([href*="/plans"]):not([href*="/tag/"]):not([href*="source="])`},
+			ContentFilters: []crawler.ContentFilter{
+				{Keep: "article"},
+			},
+			AntiBot: &AntiBotConfig{
+				HideWebdriver:      true,
+				SpoofPlugins:       true,
+				SpoofLanguages:     true,
+				NaturalScrolling:   true,
+				RandomActionDelays: true,
+			},
+		},
+	},
+}
+
+// RecipeDirs returns the directories user recipes are loaded from: those
+// in SCRAPER_RECIPES_DIR, or the recipes directory in the user config dir
+func RecipeDirs() []string {
+	if dirs := os.Getenv(RecipesDirEnv); dirs != "" {
+		return filepath.SplitList(dirs)
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(configDir, "scraper", "recipes")}
+}
+
+// LoadRecipes returns the built-in recipes and those in the *.json files
+// of dirs, sorted by name. A recipe in a later directory replaces one of
+// the same name, so user recipes can override built-in ones. Missing
+// directories are skipped; invalid recipe files are an error.
+func LoadRecipes(dirs ...string) ([]Recipe, error) {
+	byName := make(map[string]Recipe)
+	for _, r := range builtinRecipes {
+		r.Source = BuiltinRecipeSource
+		byName[r.Name] = r
+	}
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, APIError{Code: 500, Message: "failed to read recipes", Details: err.Error()}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			r, err := loadRecipeFile(path)
+			if err != nil {
+				return nil, err
+			}
+			byName[r.Name] = r
+		}
+	}
+
+	recipes := make([]Recipe, 0, len(byName))
+	for _, r := range byName {
+		recipes = append(recipes, r)
+	}
+	sort.Slice(recipes, func(i, j int) bool {
+		return recipes[i].Name < recipes[j].Name
+	})
+	return recipes, nil
+}
+
+// loadRecipeFile reads and validates a user recipe. The name defaults to
+// the file name.
+func loadRecipeFile(path string) (Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Recipe{}, APIError{Code: 500, Message: "failed to read recipe", Details: err.Error()}
+	}
+	var r Recipe
+	if err := unmarshalStrict(data, &r); err != nil {
+		return Recipe{}, APIError{Code: 400, Message: "invalid recipe", Details: fmt.Sprintf("%s: %v", path, err)}
+	}
+	if r.Name == "" {
+		r.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	if err := validateRecipe(&r); err != nil {
+		return Recipe{}, APIError{Code: 400, Message: "invalid recipe", Details: fmt.Sprintf("%s: %v", path, err)}
+	}
+	r.Request = portableRequest(r.Request)
+	r.Source = path
+	return r, nil
+}
+
+// validateRecipe checks the name and settings of r
+func validateRecipe(r *Recipe) error {
+	if !PresetNamePattern.MatchString(r.Name) || len(r.Name) > MaxPresetNameLength {
+		return fmt.Errorf("name %q must be at most %d letters, numbers, dashes and underscores", r.Name, MaxPresetNameLength)
+	}
+	if r.Request.URL != "" {
+		return fmt.Errorf("a recipe can't set the url")
+	}
+	if r.Request.Recipe != "" {
+		return fmt.Errorf("a recipe can't refer to another recipe")
+	}
+	check := r.Request
+	check.URL = "https://example.com/"
+	if err := ValidateCrawlRequest(&check); err != nil {
+		return err
+	}
+	_, err := buildConfig(&check)
+	return err
+}
+
+// Recipes returns the built-in recipes and those in RecipeDirs
+func Recipes() ([]Recipe, error) {
+	return LoadRecipes(RecipeDirs()...)
+}
+
+// GetRecipe returns the recipe name from Recipes
+func GetRecipe(name string) (*Recipe, error) {
+	recipes, err := Recipes()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(recipes))
+	for i := range recipes {
+		if recipes[i].Name == name {
+			return &recipes[i], nil
+		}
+		names = append(names, recipes[i].Name)
+	}
+	return nil, APIError{Code: 404, Message: "recipe not found", Details: fmt.Sprintf("%q is not one of: %s", name, strings.Join(names, ", "))}
+}
+
+// ApplyRecipe returns req with the settings of its recipe filled in where
+// req leaves them empty, or req itself when it names no recipe. Lists and
+// scalar settings of req replace the recipe's; the fields of nested
+// objects such as antiBot are merged one by one.
+func ApplyRecipe(req *CrawlRequest) (*CrawlRequest, error) {
+	if req.Recipe == "" {
+		return req, nil
+	}
+	recipe, err := GetRecipe(req.Recipe)
+	if err != nil {
+		if apiErr, ok := err.(APIError); ok && apiErr.Code == 404 {
+			apiErr.Code = 400
+			return nil, apiErr
+		}
+		return nil, err
+	}
+	merged, err := MergeRequest(recipe.Request, *req)
+	if err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+// MergeRequest returns base with the settings that override sets
+// replacing base's. Settings left at their zero value in override keep
+// base's value, so a recipe option can't be switched off by a request.
+func MergeRequest(base, override CrawlRequest) (CrawlRequest, error) {
+	var baseMap, overrideMap map[string]any
+	if err := roundTrip(base, &baseMap); err != nil {
+		return CrawlRequest{}, err
+	}
+	if err := roundTrip(override, &overrideMap); err != nil {
+		return CrawlRequest{}, err
+	}
+	mergeMaps(baseMap, overrideMap)
+
+	var merged CrawlRequest
+	if err := roundTrip(baseMap, &merged); err != nil {
+		return CrawlRequest{}, err
+	}
+	return merged, nil
+}
+
+// roundTrip converts v to out through JSON
+func roundTrip(v, out any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return APIError{Code: 500, Message: "failed to merge settings", Details: err.Error()}
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return APIError{Code: 500, Message: "failed to merge settings", Details: err.Error()}
+	}
+	return nil
+}
+
+// mergeMaps copies the entries of src into dst, merging nested objects
+func mergeMaps(dst, src map[string]any) {
+	for key, value := range src {
+		if value == "" {
+			// The start URL is the only field marshaled when empty
+			continue
+		}
+		if srcObj, ok := value.(map[string]any); ok {
+			if dstObj, ok := dst[key].(map[string]any); ok {
+				mergeMaps(dstObj, srcObj)
+				continue
+			}
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RetentionSweepInterval is how often finished jobs are checked for expiry
+const RetentionSweepInterval = time.Hour
+
+// RetentionPolicy controls automatic removal of finished jobs
+type RetentionPolicy struct {
+	MaxAge     time.Duration // Remove finished jobs this long after they end (0 = keep forever)
+	PruneFiles bool          // Also delete the output directory and state file of removed jobs
+}
+
+// Enabled reports whether expired jobs are removed
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0
+}
+
+// SetRetention applies a retention policy and starts (or stops) the
+// background sweep that enforces it
+func (m *JobManager) SetRetention(policy RetentionPolicy) {
+	m.mu.Lock()
+	m.retention = policy
+	stop := m.stopRetention
+	m.stopRetention = nil
+	if policy.Enabled() {
+		done := make(chan struct{})
+		m.stopRetention = func() { close(done) }
+		go m.runRetention(done)
+	}
+	m.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+}
+
+// Retention returns the active retention policy
+func (m *JobManager) Retention() RetentionPolicy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.retention
+}
+
+// runRetention prunes expired jobs every RetentionSweepInterval until done is closed
+func (m *JobManager) runRetention(done <-chan struct{}) {
+	ticker := time.NewTicker(RetentionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if removed := m.PruneExpiredJobs(time.Now()); len(removed) > 0 {
+				log.Printf("Retention: removed %d expired job(s): %s", len(removed), strings.Join(removed, ", "))
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// PruneExpiredJobs removes finished jobs that ended more than the retention
+// MaxAge before now, skipping jobs marked keep. When PruneFiles is set their
+// output directory and state file are deleted too, unless a remaining job
+// still uses the same directory. It returns the IDs of the removed jobs.
+func (m *JobManager) PruneExpiredJobs(now time.Time) []string {
+	m.mu.Lock()
+	policy := m.retention
+	if !policy.Enabled() {
+		m.mu.Unlock()
+		return nil
+	}
+
+	var expired []*CrawlJob
+	for id, job := range m.jobs {
+		if job.expired(now, policy.MaxAge) {
+			expired = append(expired, job)
+			delete(m.jobs, id)
+		}
+	}
+
+	// Directories of the remaining jobs must survive even if an expired job
+	// crawled into the same place
+	inUse := make(map[string]bool)
+	for _, job := range m.jobs {
+		job.mu.Lock()
+		if job.OutputDir != "" {
+			inUse[filepath.Clean(job.OutputDir)] = true
+		}
+		job.mu.Unlock()
+	}
+	m.mu.Unlock()
+
+	removed := make([]string, 0, len(expired))
+	for _, job := range expired {
+		job.Emitter.Close()
+		removed = append(removed, job.ID)
+		if policy.PruneFiles {
+			job.pruneFiles(inUse)
+		}
+	}
+	return removed
+}
+
+// SetJobKeep marks a job as exempt from (or subject to) retention cleanup
+func (m *JobManager) SetJobKeep(jobID string, keep bool) error {
+	m.mu.RLock()
+	job, exists := m.jobs[jobID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return APIError{Code: 404, Message: "job not found"}
+	}
+
+	job.mu.Lock()
+	job.Config.Keep = keep
+	job.mu.Unlock()
+	return nil
+}
+
+// expired reports whether a finished job ended more than maxAge before now
+func (j *CrawlJob) expired(now time.Time, maxAge time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.Config.Keep {
+		return false
+	}
+	switch j.Status {
+	case JobStatusCompleted, JobStatusStopped, JobStatusError:
+	default:
+		return false
+	}
+
+	finished := j.CreatedAt
+	if j.CompletedAt != nil {
+		finished = *j.CompletedAt
+	}
+	return now.Sub(finished) > maxAge
+}
+
+// pruneFiles deletes the job's output directory and state file, skipping
+// directories listed in inUse
+func (j *CrawlJob) pruneFiles(inUse map[string]bool) {
+	j.mu.Lock()
+	outputDir, stateFile := j.OutputDir, j.StateFile
+	j.mu.Unlock()
+
+	if outputDir != "" {
+		dir := filepath.Clean(outputDir)
+		if inUse[dir] || dir == "." || dir == string(filepath.Separator) {
+			return
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Retention: failed to remove %s: %v", dir, err)
+		}
+	}
+	if stateFile != "" {
+		if err := os.Remove(stateFile); err != nil && !os.IsNotExist(err) {
+			log.Printf("Retention: failed to remove %s: %v", stateFile, err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // NewRouter creates and configures the HTTP router with all routes
@@ -19,32 +20,145 @@ func NewRouter(handlers *Handlers, config *ServerConfig) http.Handler {
 		r.Use(CORS(config.CORSOrigins))
 	}
 
-	// API key authentication (if configured)
+	// Request body size limit (if configured)
+	if config.MaxBodySize > 0 {
+		r.Use(MaxBodySize(config.MaxBodySize))
+	}
+
+	// API key authentication (if configured), refusing clients that keep
+	// failing it
 	if config.HasAuth() {
-		r.Use(APIKeyAuth(config.APIKey))
+		if config.AuthFailureLimit > 0 {
+			r.Use(AuthFailureLimit(NewRateLimiter(config.AuthFailureLimit, 0)))
+		}
+		r.Use(APIKeyAuth(config.Keys()))
+	}
+
+	// Per-key or per-IP rate limiting (if configured)
+	if config.HasRateLimit() {
+		r.Use(RateLimit(NewRateLimiter(config.RateLimit, config.RateBurst)))
+	}
+
+	// Audit log of operations that change server state (if configured)
+	if audit := handlers.JobManager.AuditLog(); audit != nil {
+		r.Use(Audit(audit))
 	}
 
 	// Health check (always accessible)
 	r.Get("/health", handlers.HealthCheck)
 
+	// Profiling of a long-running server (admin keys or local clients)
+	if config.Debug {
+		r.With(DebugAccess).Mount("/debug", middleware.Profiler())
+	}
+
 	// API v1 routes
 	r.Route("/api/v1", func(r chi.Router) {
 		// Crawl job endpoints
 		r.Route("/crawl", func(r chi.Router) {
 			r.Post("/", handlers.CreateCrawl)        // Create new crawl
 			r.Get("/", handlers.ListCrawls)          // List all crawls
+			r.Post("/import", handlers.ImportCrawl)  // Create and start a job from a definition
 
 			// Job-specific endpoints
 			r.Route("/{jobId}", func(r chi.Router) {
+				r.Use(JobAccess(handlers.JobManager)) // Jobs of other API keys are not found
 				r.Get("/", handlers.GetCrawl)              // Get job details
 				r.Delete("/", handlers.DeleteCrawl)        // Stop and remove job
 				r.Post("/pause", handlers.PauseCrawl)      // Pause job
 				r.Post("/resume", handlers.ResumeCrawl)    // Resume job
+				r.Post("/workers", handlers.SetWorkers)    // Change concurrent worker count
+				r.Patch("/config", handlers.UpdateConfig)  // Adjust runtime settings of a live job
+				r.Post("/keep", handlers.SetKeep)          // Exempt job from retention cleanup
 				r.Post("/confirm-login", handlers.ConfirmLogin) // Confirm manual login
 				r.Get("/metrics", handlers.GetMetrics)     // Get metrics
+				r.Get("/metrics/timeseries", handlers.GetMetricsTimeSeries) // Metrics samples over time (JSON or CSV)
 				r.Get("/events", handlers.StreamEvents)    // SSE event stream
+				r.Get("/clients", handlers.ListSSEClients) // Clients connected to the event stream
+				r.Get("/urls", handlers.GetURLInventory)   // Every encountered URL and its outcome (JSON, CSV or JSONL)
+				r.Get("/errors", handlers.GetURLErrors)    // Errored URLs with error class and referrer
+				r.Post("/retry", handlers.RetryURLs)       // Queue errored URLs of the running job again
+				r.Get("/graph", handlers.GetCrawlGraph)    // Crawl map: discovered URLs linked to their referrers, or changes since a version
+				r.Get("/redirects", handlers.GetRedirects) // Redirect mapping, loops and permanent aliases
+				r.Get("/endpoints", handlers.GetAPIEndpoints) // XHR/fetch endpoints found in browser mode
+				r.Get("/external-links", handlers.GetExternalLinks) // Out-of-scope links recorded with externalLinks
+				r.Get("/alerts", handlers.GetAlerts)       // Alert rule matches recorded in alerts.jsonl
+				r.Get("/manifest", handlers.GetManifest)   // Saved pages as NDJSON, optionally followed while the crawl runs
+				r.Get("/definition", handlers.GetDefinition) // Export the job's config as a definition
+				r.Post("/export", handlers.ExportCrawl)    // Export pages as a book
+				r.Post("/site", handlers.GenerateSite)     // Generate static site mirror
+				r.Post("/seo", handlers.AuditSEO)          // SEO audit of saved pages (seo_report.html/.json)
+				r.Post("/accessibility", handlers.AuditAccessibility) // Accessibility audit of saved pages
+				r.Post("/duplicates", handlers.FindDuplicates)        // Near-duplicate content clusters
+				r.Post("/index", handlers.GenerateIndex)              // Regenerate _index.html and list the indexed pages
+				r.Post("/reprocess", handlers.ReprocessCrawl)         // Extract saved pages again and regenerate _index.html
+				r.Post("/recrawl", handlers.RecrawlCrawl)   // Re-crawl failed, changed or matching URLs in a child job
+				r.Get("/files/*", handlers.GetFile)        // Download a stored file (decompressed)
+				r.Get("/browse", handlers.BrowseCrawl)     // Redirects to /browse/
+				r.Get("/browse/*", handlers.BrowseCrawl)   // Browse the output directory (_index.html as default document)
 			})
 		})
+
+		// Pages and bytes fetched per API key, with quotas
+		r.Get("/usage", handlers.GetUsage)
+
+		// Recorded operations that changed server state (admin keys only)
+		r.Get("/audit", handlers.GetAudit)
+
+		// Presets shared with the desktop app, CLI and MCP server
+		r.Route("/presets", func(r chi.Router) {
+			r.Get("/", handlers.ListPresets)
+			r.Get("/export", handlers.ExportPresets)   // Bundle presets into one file
+			r.Post("/import", handlers.ImportPresets)  // Import a bundle or preset, upgrading older versions
+			r.Get("/{name}", handlers.GetPreset)
+			r.Put("/{name}", handlers.SavePreset)
+			r.Delete("/{name}", handlers.DeletePreset)
+		})
+
+		// Schedules running presets at set times, shared like presets
+		r.Route("/schedules", func(r chi.Router) {
+			r.Get("/", handlers.ListSchedules)
+			r.Post("/", handlers.CreateSchedule)
+			r.Get("/history", handlers.GetScheduleHistory) // Runs of all or one schedule, newest first
+			r.Get("/{scheduleId}", handlers.GetSchedule)
+			r.Delete("/{scheduleId}", handlers.DeleteSchedule)
+			r.Post("/{scheduleId}/enable", handlers.EnableSchedule)
+			r.Post("/{scheduleId}/disable", handlers.DisableSchedule)
+			r.Post("/{scheduleId}/run", handlers.RunSchedule) // Start a run now, also when disabled
+		})
+
+		// Site recipes that crawl requests can name in "recipe"
+		r.Route("/recipes", func(r chi.Router) {
+			r.Get("/", handlers.ListRecipes)
+			r.Get("/{name}", handlers.GetRecipe)
+		})
+
+		// Check the JavaScript plugins of a directory named in "pluginsDir"
+		r.Get("/plugins", handlers.ListPlugins)
+
+		// Encrypted secrets referenced as secret://name (admin keys only)
+		r.Route("/secrets", func(r chi.Router) {
+			r.Get("/", handlers.ListSecrets)
+			r.Put("/{name}", handlers.SetSecret)
+			r.Delete("/{name}", handlers.DeleteSecret)
+		})
+
+		// Crawl synthetic sites to validate the installation
+		r.Post("/selftest", handlers.RunSelfTest)
+
+		// Report the fingerprint signals a browser crawl leaks
+		r.Post("/antibot-test", handlers.RunAntiBotTest)
+
+		// Preview the links a crawl would queue or filter out
+		r.Post("/plan", handlers.PlanCrawl)
+
+		// Fetch and extract a single page
+		r.Post("/fetch", handlers.FetchPage)
+
+		// Goroutines and heap of the server, per job
+		if config.Debug {
+			r.With(DebugAccess).Get("/debug/runtime", handlers.GetRuntime)
+		}
 	})
 
 	// 404 handler
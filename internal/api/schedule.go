@@ -0,0 +1,802 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleFileVersion is the current version of the schedules file
+const ScheduleFileVersion = 1
+
+// ScheduleHistoryLimit is the number of runs kept in the schedule history
+const ScheduleHistoryLimit = 200
+
+// ScheduleCheckInterval is how often a running scheduler looks for due
+// schedules
+const ScheduleCheckInterval = 30 * time.Second
+
+// MinScheduleInterval is the shortest interval of "every" schedules
+const MinScheduleInterval = 5 * time.Minute
+
+// ScheduleTag is added to the tags of the jobs started by schedules
+const ScheduleTag = "scheduled"
+
+// scheduleLockStale is how old a schedules lock file must be before it is
+// taken to be left behind by a process that died holding it
+const scheduleLockStale = 30 * time.Second
+
+// Schedule run triggers
+const (
+	ScheduleTriggerSchedule = "schedule" // Started because the schedule was due
+	ScheduleTriggerManual   = "manual"   // Started with run now
+)
+
+// Schedule run statuses
+const (
+	ScheduleRunRunning   = "running"
+	ScheduleRunCompleted = "completed"
+	ScheduleRunError     = "error"
+	ScheduleRunSkipped   = "skipped" // Due while the previous run was still going
+)
+
+// ScheduleSpecHelp lists the accepted schedule specs
+const ScheduleSpecHelp = `"hourly", "nightly" (daily 02:00), "daily HH:MM", "weekly <day> HH:MM" or "every <duration>"`
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ScheduleSpec is a parsed schedule spec. Times of day are in the local
+// time zone of the process running the schedule.
+type ScheduleSpec struct {
+	every   time.Duration // Fixed interval; zero for calendar specs
+	hourly  bool
+	weekly  bool
+	weekday time.Weekday
+	hour    int
+	minute  int
+}
+
+// ParseSchedule parses a schedule spec, one of ScheduleSpecHelp
+func ParseSchedule(spec string) (ScheduleSpec, error) {
+	fields := strings.Fields(strings.ToLower(spec))
+	invalid := func(details string) (ScheduleSpec, error) {
+		if details == "" {
+			details = fmt.Sprintf("%q is not a schedule (use %s)", spec, ScheduleSpecHelp)
+		}
+		return ScheduleSpec{}, APIError{Code: 400, Message: "invalid schedule", Details: details}
+	}
+	if len(fields) == 0 {
+		return invalid("")
+	}
+
+	var s ScheduleSpec
+	switch fields[0] {
+	case "hourly":
+		if len(fields) != 1 {
+			return invalid("")
+		}
+		s.hourly = true
+	case "nightly":
+		if len(fields) != 1 {
+			return invalid("")
+		}
+		s.hour = 2
+	case "daily":
+		if len(fields) != 2 {
+			return invalid("")
+		}
+		hour, minute, ok := parseClock(fields[1])
+		if !ok {
+			return invalid(fmt.Sprintf("invalid time of day %q (use HH:MM)", fields[1]))
+		}
+		s.hour, s.minute = hour, minute
+	case "weekly":
+		if len(fields) != 3 {
+			return invalid("")
+		}
+		day, ok := weekdays[fields[1][:min(3, len(fields[1]))]]
+		if !ok {
+			return invalid(fmt.Sprintf("invalid day %q", fields[1]))
+		}
+		hour, minute, ok := parseClock(fields[2])
+		if !ok {
+			return invalid(fmt.Sprintf("invalid time of day %q (use HH:MM)", fields[2]))
+		}
+		s.weekly, s.weekday, s.hour, s.minute = true, day, hour, minute
+	case "every":
+		if len(fields) != 2 {
+			return invalid("")
+		}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil {
+			return invalid(fmt.Sprintf("invalid interval %q", fields[1]))
+		}
+		if d < MinScheduleInterval {
+			return invalid(fmt.Sprintf("interval must be at least %v", MinScheduleInterval))
+		}
+		s.every = d
+	default:
+		return invalid("")
+	}
+	return s, nil
+}
+
+// parseClock parses a HH:MM time of day
+func parseClock(s string) (hour, minute int, ok bool) {
+	h, m, found := strings.Cut(s, ":")
+	if !found {
+		return 0, 0, false
+	}
+	hour, err1 := strconv.Atoi(h)
+	minute, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// Next returns the first time the schedule is due after t
+func (s ScheduleSpec) Next(t time.Time) time.Time {
+	t = t.Local()
+	switch {
+	case s.every > 0:
+		return t.Add(s.every).Truncate(time.Minute)
+	case s.hourly:
+		return t.Truncate(time.Hour).Add(time.Hour)
+	}
+	next := time.Date(t.Year(), t.Month(), t.Day(), s.hour, s.minute, 0, 0, time.Local)
+	if s.weekly {
+		next = next.AddDate(0, 0, (int(s.weekday)-int(next.Weekday())+7)%7)
+	}
+	for !next.After(t) {
+		if s.weekly {
+			next = next.AddDate(0, 0, 7)
+		} else {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
+
+// Schedule runs a preset at set times, e.g. nightly
+type Schedule struct {
+	ID        string       `json:"id"`
+	Preset    string       `json:"preset"`
+	URL       string       `json:"url,omitempty"` // Start URL, overriding the preset's
+	Spec      string       `json:"spec"`
+	Enabled   bool         `json:"enabled"`
+	CreatedAt time.Time    `json:"createdAt"`
+	NextRun   time.Time    `json:"nextRun"`
+	LastRun   *ScheduleRun `json:"lastRun,omitempty"`
+	Owner     string       `json:"owner,omitempty"` // API key that created the schedule; its runs are jobs of that key
+}
+
+// ScheduleRun is one run of a schedule in the schedule history
+type ScheduleRun struct {
+	ID         string     `json:"id"`
+	ScheduleID string     `json:"scheduleId"`
+	Preset     string     `json:"preset"`
+	URL        string     `json:"url,omitempty"`
+	Trigger    string     `json:"trigger"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"startedAt"`
+	EndedAt    *time.Time `json:"endedAt,omitempty"`
+	JobID      string     `json:"jobId,omitempty"`
+	OutputDir  string     `json:"outputDir,omitempty"`
+	PagesSaved int64      `json:"pagesSaved"`
+	Errors     int64      `json:"errors"`
+	StopReason string     `json:"stopReason,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Owner      string     `json:"owner,omitempty"` // API key of the schedule, which the run's job is charged to
+}
+
+// scheduleFile is the layout of the schedules file
+type scheduleFile struct {
+	Version   int           `json:"version"`
+	Schedules []Schedule    `json:"schedules"`
+	History   []ScheduleRun `json:"history"` // Oldest first
+}
+
+// schedule returns the schedule id in f
+func (f *scheduleFile) schedule(id string) (*Schedule, error) {
+	for i := range f.Schedules {
+		if f.Schedules[i].ID == id {
+			return &f.Schedules[i], nil
+		}
+	}
+	return nil, APIError{Code: 404, Message: "schedule not found", Details: id}
+}
+
+// ScheduleStore keeps schedules and their run history in one JSON file.
+// Like presets, the desktop app, the CLI, the API server and the MCP
+// server use the same file by default; a lock file next to it keeps
+// processes sharing it from starting the same due run twice.
+type ScheduleStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultSchedulesFile returns the schedules file in the user config dir
+func DefaultSchedulesFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, "scraper", "schedules.json"), nil
+}
+
+// NewScheduleStore creates a store for the schedules in the file path
+func NewScheduleStore(path string) *ScheduleStore {
+	return &ScheduleStore{path: path}
+}
+
+// Path returns the file the schedules are kept in
+func (s *ScheduleStore) Path() string {
+	return s.path
+}
+
+// update loads the schedules file, passes it to fn and, when write is set
+// and fn succeeds, saves it, all while holding the lock file
+func (s *ScheduleStore) update(write bool, fn func(*scheduleFile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if write {
+		unlock, err := s.lock()
+		if err != nil {
+			return APIError{Code: 500, Message: "failed to lock schedules", Details: err.Error()}
+		}
+		defer unlock()
+	}
+
+	f := scheduleFile{Version: ScheduleFileVersion}
+	data, err := os.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return APIError{Code: 500, Message: "failed to read schedules", Details: err.Error()}
+	default:
+		if err := json.Unmarshal(data, &f); err != nil {
+			return APIError{Code: 500, Message: "invalid schedules file", Details: fmt.Sprintf("%s: %v", s.path, err)}
+		}
+		if f.Version > ScheduleFileVersion {
+			return APIError{Code: 500, Message: "unsupported schedules file version", Details: fmt.Sprintf("version %d is newer than supported version %d", f.Version, ScheduleFileVersion)}
+		}
+	}
+
+	if err := fn(&f); err != nil || !write {
+		return err
+	}
+
+	f.Version = ScheduleFileVersion
+	if len(f.History) > ScheduleHistoryLimit {
+		f.History = f.History[len(f.History)-ScheduleHistoryLimit:]
+	}
+	data, err = json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return APIError{Code: 500, Message: "failed to save schedules", Details: err.Error()}
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return APIError{Code: 500, Message: "failed to save schedules", Details: err.Error()}
+	}
+	// Written to a temporary file first so readers never see half a file
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return APIError{Code: 500, Message: "failed to save schedules", Details: err.Error()}
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return APIError{Code: 500, Message: "failed to save schedules", Details: err.Error()}
+	}
+	return nil
+}
+
+// lock takes the lock file of the schedules file, waiting for another
+// process holding it, and returns the function releasing it
+func (s *ScheduleStore) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return nil, err
+	}
+	path := s.path + ".lock"
+	deadline := time.Now().Add(scheduleLockStale)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > scheduleLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another process", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// List returns the schedules, in the order they were added
+func (s *ScheduleStore) List() ([]Schedule, error) {
+	var schedules []Schedule
+	err := s.update(false, func(f *scheduleFile) error {
+		schedules = f.Schedules
+		return nil
+	})
+	if schedules == nil {
+		schedules = []Schedule{}
+	}
+	return schedules, err
+}
+
+// Get returns the schedule id
+func (s *ScheduleStore) Get(id string) (*Schedule, error) {
+	var sched *Schedule
+	err := s.update(false, func(f *scheduleFile) error {
+		var err error
+		sched, err = f.schedule(id)
+		return err
+	})
+	return sched, err
+}
+
+// Add stores a new schedule, setting its ID, creation time and next run
+func (s *ScheduleStore) Add(sched Schedule) (*Schedule, error) {
+	spec, err := ParseSchedule(sched.Spec)
+	if err != nil {
+		return nil, err
+	}
+	sched.ID = uuid.New().String()[:8]
+	sched.CreatedAt = time.Now()
+	sched.NextRun = spec.Next(sched.CreatedAt)
+	sched.LastRun = nil
+	err = s.update(true, func(f *scheduleFile) error {
+		f.Schedules = append(f.Schedules, sched)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sched, nil
+}
+
+// Remove deletes the schedule id. Its runs stay in the history.
+func (s *ScheduleStore) Remove(id string) error {
+	return s.update(true, func(f *scheduleFile) error {
+		if _, err := f.schedule(id); err != nil {
+			return err
+		}
+		kept := f.Schedules[:0]
+		for _, sched := range f.Schedules {
+			if sched.ID != id {
+				kept = append(kept, sched)
+			}
+		}
+		f.Schedules = kept
+		return nil
+	})
+}
+
+// SetEnabled enables or disables the schedule id. Enabling it moves its
+// next run to the first time it is due from now, so runs missed while it
+// was disabled are not caught up.
+func (s *ScheduleStore) SetEnabled(id string, enabled bool) (*Schedule, error) {
+	var updated Schedule
+	err := s.update(true, func(f *scheduleFile) error {
+		sched, err := f.schedule(id)
+		if err != nil {
+			return err
+		}
+		if enabled && !sched.Enabled {
+			spec, err := ParseSchedule(sched.Spec)
+			if err != nil {
+				return err
+			}
+			sched.NextRun = spec.Next(time.Now())
+		}
+		sched.Enabled = enabled
+		updated = *sched
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// History returns the runs of the schedule id, or of all schedules when id
+// is empty, newest first. A limit above zero caps the number returned.
+func (s *ScheduleStore) History(id string, limit int) ([]ScheduleRun, error) {
+	runs := []ScheduleRun{}
+	err := s.update(false, func(f *scheduleFile) error {
+		for i := len(f.History) - 1; i >= 0; i-- {
+			if id != "" && f.History[i].ScheduleID != id {
+				continue
+			}
+			runs = append(runs, f.History[i])
+			if limit > 0 && len(runs) == limit {
+				break
+			}
+		}
+		return nil
+	})
+	return runs, err
+}
+
+// SaveRun adds run to the history, or replaces the run with its ID, and
+// sets it as the last run of its schedule
+func (s *ScheduleStore) SaveRun(run ScheduleRun) error {
+	return s.update(true, func(f *scheduleFile) error {
+		replaced := false
+		for i := range f.History {
+			if f.History[i].ID == run.ID {
+				f.History[i] = run
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			f.History = append(f.History, run)
+		}
+		if sched, err := f.schedule(run.ScheduleID); err == nil {
+			last := run
+			sched.LastRun = &last
+		}
+		return nil
+	})
+}
+
+// ClaimDue returns the enabled schedules due at now and moves their next
+// run to the first time they are due after now. A schedule missed while
+// nothing was running it is claimed once, not once per missed run.
+func (s *ScheduleStore) ClaimDue(now time.Time) ([]Schedule, error) {
+	var due []Schedule
+	err := s.update(true, func(f *scheduleFile) error {
+		for i := range f.Schedules {
+			sched := &f.Schedules[i]
+			if !sched.Enabled || sched.NextRun.After(now) {
+				continue
+			}
+			spec, err := ParseSchedule(sched.Spec)
+			if err != nil {
+				log.Printf("Schedules: skipping %s: %v", sched.ID, err)
+				continue
+			}
+			sched.NextRun = spec.Next(now)
+			due = append(due, *sched)
+		}
+		return nil
+	})
+	return due, err
+}
+
+// ScheduleRunner runs the crawl of a scheduled run, returning once it ends.
+// It fills in the job, output directory and counts of run; the scheduler
+// sets its status from the returned error. It should stop the crawl when
+// ctx is canceled.
+type ScheduleRunner func(ctx context.Context, req *CrawlRequest, run *ScheduleRun) error
+
+// Scheduler starts the runs of the schedules in a ScheduleStore, with the
+// crawl settings of the presets in a PresetStore. Runs can be started by
+// hand without starting the scheduler; Start also runs schedules when due.
+type Scheduler struct {
+	store   *ScheduleStore
+	presets *PresetStore
+	runner  ScheduleRunner
+
+	mu     sync.Mutex
+	active map[string]bool // Schedules with a run in progress in this process
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   func()
+	wg     sync.WaitGroup
+	onRun  func(ScheduleRun) // Called when a run ends
+}
+
+// NewScheduler creates a scheduler running the schedules of store with
+// runner
+func NewScheduler(store *ScheduleStore, presets *PresetStore, runner ScheduleRunner) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:   store,
+		presets: presets,
+		runner:  runner,
+		active:  make(map[string]bool),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Store returns the store of the scheduler's schedules
+func (s *Scheduler) Store() *ScheduleStore {
+	return s.store
+}
+
+// OnRunEnd sets a function called with every run when it ends, e.g. to
+// notify the user
+func (s *Scheduler) OnRunEnd(fn func(ScheduleRun)) {
+	s.mu.Lock()
+	s.onRun = fn
+	s.mu.Unlock()
+}
+
+// Start runs due schedules every ScheduleCheckInterval until Stop
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	done := make(chan struct{})
+	s.stop = func() { close(done) }
+	go s.loop(done)
+}
+
+// Running reports whether the scheduler runs schedules when due
+func (s *Scheduler) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stop != nil
+}
+
+// Stop stops running schedules, stops the runs in progress and waits for
+// them to end
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	stop := s.stop
+	s.stop = nil
+	s.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop checks for due schedules until done is closed
+func (s *Scheduler) loop(done <-chan struct{}) {
+	ticker := time.NewTicker(ScheduleCheckInterval)
+	defer ticker.Stop()
+
+	s.RunDue(time.Now())
+	for {
+		select {
+		case <-ticker.C:
+			s.RunDue(time.Now())
+		case <-done:
+			return
+		}
+	}
+}
+
+// RunDue starts the runs of the schedules due at now and returns them.
+// A schedule whose previous run is still going gets a skipped run.
+func (s *Scheduler) RunDue(now time.Time) []ScheduleRun {
+	due, err := s.store.ClaimDue(now)
+	if err != nil {
+		log.Printf("Schedules: %v", err)
+		return nil
+	}
+	runs := make([]ScheduleRun, 0, len(due))
+	for _, sched := range due {
+		run, err := s.start(sched, ScheduleTriggerSchedule)
+		if err != nil {
+			log.Printf("Schedules: %s: %v", sched.ID, err)
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs
+}
+
+// RunNow starts a run of the schedule id, also when it is disabled, and
+// returns it while it is running
+func (s *Scheduler) RunNow(id string) (*ScheduleRun, error) {
+	sched, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.start(*sched, ScheduleTriggerManual)
+}
+
+// Add validates and stores a new enabled schedule of the preset
+func (s *Scheduler) Add(preset, url, spec string) (*Schedule, error) {
+	return s.AddFor("", preset, url, spec)
+}
+
+// AddFor adds a schedule like Add on behalf of the named API key, whose
+// jobs its runs are
+func (s *Scheduler) AddFor(owner, preset, url, spec string) (*Schedule, error) {
+	p, err := s.presets.Get(preset)
+	if err != nil {
+		return nil, err
+	}
+	if url == "" && p.Request.URL == "" {
+		return nil, APIError{Code: 400, Message: "url is required", Details: fmt.Sprintf("preset %s has no start URL", preset)}
+	}
+	if url != "" {
+		check := p.Request
+		check.URL = url
+		if err := ValidateCrawlRequest(&check); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.Add(Schedule{Preset: preset, URL: url, Spec: spec, Enabled: true, Owner: owner})
+}
+
+// Active reports whether a run of the schedule id is in progress in this
+// process
+func (s *Scheduler) Active(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active[id]
+}
+
+// start records a run of sched and starts it in the background. When a
+// run of sched is already in progress, a due run is recorded as skipped
+// and a manual one refused.
+func (s *Scheduler) start(sched Schedule, trigger string) (*ScheduleRun, error) {
+	run := ScheduleRun{
+		ID:         uuid.New().String()[:8],
+		ScheduleID: sched.ID,
+		Preset:     sched.Preset,
+		URL:        sched.URL,
+		Trigger:    trigger,
+		Status:     ScheduleRunRunning,
+		StartedAt:  time.Now(),
+		Owner:      sched.Owner,
+	}
+
+	s.mu.Lock()
+	busy := s.active[sched.ID]
+	if !busy {
+		s.active[sched.ID] = true
+	}
+	s.mu.Unlock()
+	if busy && trigger == ScheduleTriggerManual {
+		return nil, APIError{Code: 409, Message: "schedule is already running", Details: sched.ID}
+	}
+	if busy {
+		run.Status = ScheduleRunSkipped
+		run.Error = "the previous run was still going"
+		run.EndedAt = &run.StartedAt
+		return &run, s.store.SaveRun(run)
+	}
+
+	req, err := s.request(sched)
+	if err == nil {
+		err = s.store.SaveRun(run)
+	}
+	if err != nil {
+		s.mu.Lock()
+		delete(s.active, sched.ID)
+		s.mu.Unlock()
+		s.finish(run, err)
+		return &run, err
+	}
+
+	// The runner fills in its own copy, published to the history when it
+	// ends; the caller gets the run as it started
+	s.wg.Add(1)
+	go func(run ScheduleRun) {
+		defer s.wg.Done()
+		err := s.runner(s.ctx, req, &run)
+		s.mu.Lock()
+		delete(s.active, sched.ID)
+		s.mu.Unlock()
+		s.finish(run, err)
+	}(run)
+	return &run, nil
+}
+
+// request builds the crawl request of sched from its preset
+func (s *Scheduler) request(sched Schedule) (*CrawlRequest, error) {
+	p, err := s.presets.Get(sched.Preset)
+	if err != nil {
+		return nil, err
+	}
+	req := p.Request
+	if sched.URL != "" {
+		req.URL = sched.URL
+	}
+	if req.URL == "" {
+		return nil, APIError{Code: 400, Message: "url is required", Details: fmt.Sprintf("preset %s has no start URL", sched.Preset)}
+	}
+	req.Tags = append(append([]string{}, req.Tags...), ScheduleTag, "schedule:"+sched.ID)
+	if err := ValidateCrawlRequest(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// finish records the end of run, failed with err if not nil
+func (s *Scheduler) finish(run ScheduleRun, err error) {
+	now := time.Now()
+	run.EndedAt = &now
+	run.Status = ScheduleRunCompleted
+	if err != nil {
+		run.Status = ScheduleRunError
+		run.Error = err.Error()
+		var apiErr APIError
+		if errors.As(err, &apiErr) && apiErr.Details != "" {
+			run.Error = apiErr.Message + ": " + apiErr.Details
+		}
+	}
+	if err := s.store.SaveRun(run); err != nil {
+		log.Printf("Schedules: failed to record run %s: %v", run.ID, err)
+	}
+	log.Printf("Schedules: run %s of %s (%s) %s", run.ID, run.ScheduleID, run.Preset, run.Status)
+
+	s.mu.Lock()
+	onRun := s.onRun
+	s.mu.Unlock()
+	if onRun != nil {
+		onRun(run)
+	}
+}
+
+// ScheduleRunner returns a runner starting scheduled runs as jobs of the
+// manager, polling them until they end. Runs of schedules created with an
+// API key are jobs of that key, confined and charged like its own; the
+// others are charged to owner.
+func (m *JobManager) ScheduleRunner(owner string) ScheduleRunner {
+	return func(ctx context.Context, req *CrawlRequest, run *ScheduleRun) error {
+		jobOwner := owner
+		if run.Owner != "" {
+			key, ok := m.APIKey(run.Owner)
+			if !ok {
+				return APIError{Code: 403, Message: "API key of the schedule is not known to this server", Details: run.Owner}
+			}
+			if err := NamespaceRequest(key, req); err != nil {
+				return err
+			}
+			jobOwner = key.Name
+		}
+		job, err := m.CreateJobFor(jobOwner, req)
+		if err != nil {
+			return err
+		}
+		run.JobID = job.ID
+		if err := m.StartJob(job.ID); err != nil {
+			return err
+		}
+		run.OutputDir = job.GetOutputDir()
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for isActiveStatus(job.GetStatus()) {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				m.StopJob(job.ID)
+				ctx = context.Background()
+			}
+		}
+
+		if metrics := job.GetMetrics(); metrics != nil {
+			run.PagesSaved = metrics.URLsSaved
+			run.Errors = metrics.URLsErrored
+		}
+		job.mu.Lock()
+		run.StopReason = job.StopReason
+		jobErr := job.Error
+		job.mu.Unlock()
+		return jobErr
+	}
+}
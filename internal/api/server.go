@@ -4,24 +4,75 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// ShutdownCheckpointTimeout bounds how long Shutdown waits for running
+// crawls to finish their current pages and save their state
+const ShutdownCheckpointTimeout = 20 * time.Second
+
 // Server represents the API server
 type Server struct {
 	httpServer *http.Server
 	jobManager *JobManager
+	scheduler  *Scheduler
 	config     *ServerConfig
 }
 
 // NewServer creates a new API server
 func NewServer(config *ServerConfig) (*Server, error) {
+	if config.APIKeysFile != "" {
+		keys, err := LoadAPIKeys(config.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		config.APIKeys = keys
+	}
+
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
 	jobManager := NewJobManager(config.MaxConcurrentJobs)
+	jobManager.SetRetention(config.RetentionPolicy())
+	jobManager.SetHostDelay(config.HostDelay)
+	jobManager.SetPolitePolicy(config.PolitePolicy())
+	jobManager.SetAllowCommands(config.AllowCommands)
+	jobManager.SetOutputOwner(config.OutputOwner)
+	jobManager.SetAPIKeys(config.Keys())
+	if err := jobManager.SetUsageTracking(config.Keys(), config.UsageFile); err != nil {
+		jobManager.Shutdown()
+		return nil, err
+	}
+	if config.AuditLogFile != "" {
+		audit, err := OpenAuditLog(config.AuditLogFile, config.AuditMaxSize, config.AuditMaxFiles)
+		if err != nil {
+			jobManager.Shutdown()
+			return nil, err
+		}
+		jobManager.SetAuditLog(audit)
+	}
 	handlers := NewHandlers(jobManager, "1.0.0")
+	if config.PresetsDir == "" {
+		if dir, err := DefaultPresetsDir(); err == nil {
+			config.PresetsDir = dir
+		}
+	}
+	if config.PresetsDir != "" {
+		handlers.Presets = NewPresetStore(config.PresetsDir)
+	}
+	if config.SchedulesFile == "" {
+		if path, err := DefaultSchedulesFile(); err == nil {
+			config.SchedulesFile = path
+		}
+	}
+	if config.SchedulesFile != "" && handlers.Presets != nil {
+		handlers.Scheduler = NewScheduler(NewScheduleStore(config.SchedulesFile), handlers.Presets, jobManager.ScheduleRunner(AnonymousKey))
+	}
+	if config.Debug {
+		EnableContentionProfiling()
+	}
 	router := NewRouter(handlers, config)
 
 	httpServer := &http.Server{
@@ -35,6 +86,7 @@ func NewServer(config *ServerConfig) (*Server, error) {
 	return &Server{
 		httpServer: httpServer,
 		jobManager: jobManager,
+		scheduler:  handlers.Scheduler,
 		config:     config,
 	}, nil
 }
@@ -43,12 +95,60 @@ func NewServer(config *ServerConfig) (*Server, error) {
 func (s *Server) Start() error {
 	log.Printf("API server starting on %s", s.config.Address())
 	if s.config.HasAuth() {
-		log.Printf("API key authentication enabled")
+		log.Printf("API key authentication enabled (%d key(s))", len(s.config.Keys()))
+	}
+	if s.config.UsageFile != "" {
+		log.Printf("Usage is persisted to %s", s.config.UsageFile)
+	}
+	if s.config.AuditLogFile != "" {
+		log.Printf("Operations are audited to %s", s.config.AuditLogFile)
 	}
 	if s.config.HasCORS() {
 		log.Printf("CORS enabled for origins: %v", s.config.CORSOrigins)
 	}
+	if s.config.RateLimit > 0 {
+		burst := s.config.RateBurst
+		if burst == 0 {
+			burst = s.config.RateLimit
+		}
+		log.Printf("Rate limit: %d request(s) per minute per client (burst %d)", s.config.RateLimit, burst)
+	} else if s.config.HasRateLimit() {
+		log.Printf("Rate limits set for some API keys only")
+	}
+	if s.config.HasAuth() && s.config.AuthFailureLimit > 0 {
+		log.Printf("Client IPs are refused after %d failed authentication(s) per minute", s.config.AuthFailureLimit)
+	}
 	log.Printf("Max concurrent jobs: %d", s.config.MaxConcurrentJobs)
+	if s.config.PresetsDir != "" {
+		log.Printf("Presets are kept in %s", s.config.PresetsDir)
+	}
+	if s.scheduler != nil {
+		if s.config.RunSchedules {
+			s.scheduler.Start()
+			log.Printf("Running the schedules in %s", s.config.SchedulesFile)
+		} else {
+			log.Printf("Schedules are kept in %s (run them when due with -run-schedules)", s.config.SchedulesFile)
+		}
+	}
+	if s.config.Debug {
+		if s.config.HasAuth() {
+			log.Printf("Diagnostics enabled on /debug/pprof/ and /api/v1/debug/runtime for admin keys")
+		} else {
+			log.Printf("Diagnostics enabled on /debug/pprof/ and /api/v1/debug/runtime for local clients")
+		}
+	}
+	if s.config.HostDelay > 0 {
+		log.Printf("Requests to each host are at least %v apart across all jobs", s.config.HostDelay)
+	}
+	if policy := s.config.PolitePolicy(); policy != nil {
+		log.Printf("Polite mode: %s", policy)
+	}
+	if s.config.AllowCommands {
+		log.Printf("Jobs may run post-save commands and browser binaries on this server")
+	}
+	if s.config.RetentionDays > 0 {
+		log.Printf("Finished jobs are removed after %d day(s) (prune files: %v)", s.config.RetentionDays, s.config.RetentionPruneFiles)
+	}
 
 	err := s.httpServer.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -61,8 +161,20 @@ func (s *Server) Start() error {
 func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down API server...")
 
+	// Checkpoint running crawls so a restarted server can resume them,
+	// leaving the rest of ctx for outstanding requests
+	checkpointCtx, cancel := context.WithTimeout(ctx, ShutdownCheckpointTimeout)
+	checkpointed := s.jobManager.CheckpointJobs(checkpointCtx)
+	cancel()
+	if len(checkpointed) > 0 {
+		log.Printf("Checkpointed %d job(s): %s", len(checkpointed), strings.Join(checkpointed, ", "))
+	}
+
 	// Stop all active jobs
 	s.jobManager.Shutdown()
+	if s.scheduler != nil {
+		s.scheduler.Stop()
+	}
 
 	// Shutdown HTTP server
 	return s.httpServer.Shutdown(ctx)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -12,6 +13,11 @@ import (
 // SSEHeartbeatInterval is how often to send heartbeat comments
 const SSEHeartbeatInterval = 15 * time.Second
 
+// SSEWriteTimeout bounds each write to an SSE client. A client that stops
+// reading is dropped once a write blocks this long, instead of holding its
+// subscription until the TCP connection times out.
+const SSEWriteTimeout = 10 * time.Second
+
 // StreamEvents handles GET /api/v1/crawl/{jobId}/events (SSE)
 func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "jobId")
@@ -23,12 +29,19 @@ func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if client supports SSE
-	flusher, ok := w.(http.Flusher)
-	if !ok {
+	if _, ok := w.(http.Flusher); !ok {
 		writeError(w, APIError{Code: 500, Message: "streaming not supported"})
 		return
 	}
 
+	// Clients resuming a stream send Last-Event-ID; ?since= lets new clients
+	// request the buffered history (since=0 replays everything retained)
+	since, err := parseSinceID(r)
+	if err != nil {
+		writeError(w, APIError{Code: 400, Message: "invalid since", Details: err.Error()})
+		return
+	}
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -36,40 +49,66 @@ func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
 
 	// Subscribe to events
-	eventChan, unsubscribe := job.Emitter.Subscribe()
+	eventChan, replay, unsubscribe := job.Emitter.SubscribeSince(since, SSEClientInfo{
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	})
 	defer unsubscribe()
 
+	stream := newSSEStream(w)
+
 	// Create heartbeat ticker
 	heartbeat := time.NewTicker(SSEHeartbeatInterval)
 	defer heartbeat.Stop()
 
-	// Send initial connection event
-	sendSSEEvent(w, "connected", map[string]interface{}{
-		"jobId":  jobID,
-		"status": job.GetStatus(),
+	// Send initial connection event, then replay missed events before
+	// streaming live ones
+	err = stream.Send(func() error {
+		if err := sendSSEEvent(w, "connected", map[string]interface{}{
+			"jobId":  jobID,
+			"status": job.GetStatus(),
+		}); err != nil {
+			return err
+		}
+		for _, event := range replay {
+			if err := writeSSEEvent(w, event); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
-	flusher.Flush()
+	if err != nil {
+		return
+	}
 
-	// Stream events
+	// Stream events. Any write error means the client is gone, so return
+	// and let the deferred unsubscribe release it right away.
 	for {
 		select {
 		case event, ok := <-eventChan:
 			if !ok {
 				// Channel closed, job completed
-				sendSSEEvent(w, "disconnected", map[string]string{
-					"reason": "job completed",
+				stream.Send(func() error {
+					return sendSSEEvent(w, "disconnected", map[string]string{
+						"reason": "job completed",
+					})
 				})
-				flusher.Flush()
 				return
 			}
 
-			sendSSEEvent(w, event.Type, event)
-			flusher.Flush()
+			if err := stream.Send(func() error { return writeSSEEvent(w, event) }); err != nil {
+				return
+			}
 
 		case <-heartbeat.C:
 			// Send heartbeat comment to keep connection alive
-			fmt.Fprintf(w, ": heartbeat %d\n\n", time.Now().Unix())
-			flusher.Flush()
+			err := stream.Send(func() error {
+				_, err := fmt.Fprintf(w, ": heartbeat %d\n\n", time.Now().Unix())
+				return err
+			})
+			if err != nil {
+				return
+			}
 
 		case <-r.Context().Done():
 			// Client disconnected
@@ -78,17 +117,70 @@ func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// sendSSEEvent writes a single SSE event to the response writer
-func sendSSEEvent(w http.ResponseWriter, eventType string, data interface{}) {
+// sseStream writes to an SSE response with a per-write deadline
+type sseStream struct {
+	rc *http.ResponseController
+}
+
+// newSSEStream wraps an SSE response writer
+func newSSEStream(w http.ResponseWriter) *sseStream {
+	return &sseStream{rc: http.NewResponseController(w)}
+}
+
+// Send runs write under a fresh write deadline and flushes the result.
+// The deadline also replaces the server's WriteTimeout, which would
+// otherwise cut long-lived streams off.
+func (s *sseStream) Send(write func() error) error {
+	// Not every ResponseWriter supports deadlines (e.g. test recorders)
+	s.rc.SetWriteDeadline(time.Now().Add(SSEWriteTimeout))
+	if err := write(); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// parseSinceID reads the replay position from the Last-Event-ID header or the
+// since query parameter. It returns nil when neither is present.
+func parseSinceID(r *http.Request) (*uint64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("since")
+	}
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("event ID must be a non-negative integer, got: %s", raw)
+	}
+	return &id, nil
+}
+
+// writeSSEEvent writes a crawler event with its ID so clients can resume from it
+func writeSSEEvent(w http.ResponseWriter, event SSEEvent) error {
+	if event.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	return sendSSEEvent(w, event.Type, event)
+}
+
+// sendSSEEvent writes a single SSE event to the response writer.
+// It returns the write error, which means the client has gone away.
+func sendSSEEvent(w http.ResponseWriter, eventType string, data interface{}) error {
 	// Event type
-	fmt.Fprintf(w, "event: %s\n", eventType)
+	if _, err := fmt.Fprintf(w, "event: %s\n", eventType); err != nil {
+		return err
+	}
 
 	// Data (JSON encoded)
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		jsonData = []byte(`{"error": "failed to encode event data"}`)
 	}
-	fmt.Fprintf(w, "data: %s\n\n", jsonData)
+	_, err = fmt.Fprintf(w, "data: %s\n\n", jsonData)
+	return err
 }
 
 // SSEClient is used for testing SSE connections
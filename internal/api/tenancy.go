@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"scraper/internal/crawler"
+)
+
+// KeyNamespace returns the directory holding the default output directories
+// of the jobs of a non-admin API key
+func KeyNamespace(key string) string {
+	return filepath.Join(crawler.DefaultOutputRoot, "keys", crawler.SanitizeDirName(key))
+}
+
+// NamespaceRequest confines every path of a job created with a non-admin
+// API key to the key's namespace: the default output directory is created
+// there, and explicit paths must be relative and are resolved inside it.
+// Paths the crawler resolves against the output directory (resultsDb and
+// file event sinks) are left relative. Paths already inside the namespace,
+// such as those a re-crawl inherits from its parent, are kept. Secret
+// references, and endpoints that would get the server's own API keys, are
+// refused (see checkSecretRefs and checkServerKeys). Admin keys are not
+// confined.
+func NamespaceRequest(key APIKeyConfig, req *CrawlRequest) error {
+	if key.Admin {
+		return nil
+	}
+	if err := checkSecretRefs(key, req); err != nil {
+		return err
+	}
+	if err := checkServerKeys(key, req); err != nil {
+		return err
+	}
+	namespace := KeyNamespace(key.Name)
+
+	if req.OutputDir == "" {
+		config := crawler.Config{URL: req.URL}
+		if err := crawler.SetDefaultOutputDir(&config); err != nil {
+			return APIError{Code: 400, Message: "invalid URL", Details: err.Error()}
+		}
+		req.OutputDir = filepath.Join(namespace, filepath.Base(config.OutputDir))
+	}
+	for _, path := range []struct {
+		name     string
+		value    *string
+		inOutput bool // Resolved against the output directory by the crawler
+	}{
+		{"outputDir", &req.OutputDir, false},
+		{"stateFile", &req.StateFile, false},
+		{"cassetteFile", &req.CassetteFile, false},
+		{"traceDecisions", &req.TraceDecisions, false},
+		{"pluginsDir", &req.PluginsDir, false},
+		{"resultsDb", &req.ResultsDB, true},
+	} {
+		resolved, err := namespacePath(key, path.name, *path.value, path.inOutput)
+		if err != nil {
+			return err
+		}
+		*path.value = resolved
+	}
+	for i, sink := range req.EventSinks {
+		if sink.Type != crawler.EventSinkFile {
+			continue
+		}
+		if _, err := namespacePath(key, fmt.Sprintf("eventSinks[%d].path", i), sink.Path, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NamespacePath confines path, given in the request parameter name by a
+// non-admin API key, to the key's namespace like NamespaceRequest does, and
+// returns the path to use. Admin keys are not confined.
+func NamespacePath(key APIKeyConfig, name, path string) (string, error) {
+	if key.Admin {
+		return path, nil
+	}
+	return namespacePath(key, name, path, false)
+}
+
+// namespacePath returns path confined to the namespace of key: kept when
+// empty or already inside it, refused unless relative and resolved inside
+// it otherwise. Paths inOutput the crawler resolves against the output
+// directory are only checked to stay relative.
+func namespacePath(key APIKeyConfig, name, path string, inOutput bool) (string, error) {
+	namespace := KeyNamespace(key.Name)
+	if path == "" || withinDir(namespace, path) {
+		return path, nil
+	}
+	if !filepath.IsLocal(path) {
+		return "", APIError{
+			Code:    403,
+			Message: fmt.Sprintf("%s must be a relative path", name),
+			Details: fmt.Sprintf("paths of API key %q are resolved inside %s", key.Name, namespace),
+		}
+	}
+	if inOutput {
+		return path, nil
+	}
+	return filepath.Join(namespace, path), nil
+}
+
+// checkSecretRefs refuses the secret references in a request of a non-admin
+// API key. Only a proxy that is a reference and nothing else is allowed:
+// every other field sends its value to a destination the request chooses
+// (an embeddings or enrichment endpoint, a page script), which would hand
+// out any stored secret.
+func checkSecretRefs(key APIKeyConfig, req *CrawlRequest) error {
+	check := *req
+	if check.Geo != nil && crawler.IsSecretRef(check.Geo.Proxy) {
+		geo := *check.Geo
+		geo.Proxy = ""
+		check.Geo = &geo
+	}
+	data, err := json.Marshal(check)
+	if err != nil {
+		return APIError{Code: 400, Message: "invalid request", Details: err.Error()}
+	}
+	if strings.Contains(string(data), crawler.SecretRefPrefix) {
+		return APIError{
+			Code:    403,
+			Message: "secret references are not allowed for this API key",
+			Details: fmt.Sprintf("API key %q may only refer to a secret as the whole geo.proxy", key.Name),
+		}
+	}
+	return nil
+}
+
+// checkServerKeys refuses the endpoints of a request of a non-admin API key
+// that come without their own API key: the crawler would fall back to the
+// server's key from the environment and send it to an endpoint the request
+// chooses
+func checkServerKeys(key APIKeyConfig, req *CrawlRequest) error {
+	if e := req.Embeddings; e != nil && e.Endpoint != "" && e.APIKey == "" {
+		return serverKeyError(key, "embeddings", crawler.EmbeddingsAPIKeyEnv)
+	}
+	if e := req.Enrichment; e != nil && e.Endpoint != "" && e.APIKey == "" {
+		return serverKeyError(key, "enrichment", crawler.EnrichmentAPIKeyEnv)
+	}
+	return nil
+}
+
+// serverKeyError is the error of an endpoint field that would get the
+// server's API key from env
+func serverKeyError(key APIKeyConfig, field, env string) error {
+	return APIError{
+		Code:    403,
+		Message: fmt.Sprintf("%s.apiKey is required for this API key", field),
+		Details: fmt.Sprintf("API key %q can't use the server's $%s", key.Name, env),
+	}
+}
+
+// CanAccessJob reports whether a request authenticated with key (ok false
+// when the server has no authentication) may see and manage job: admin keys
+// reach every job, other keys only their own
+func CanAccessJob(key APIKeyConfig, ok bool, job *CrawlJob) bool {
+	return !ok || key.Admin || job.GetOwner() == key.Name
+}
+
+// CanAccessSchedule reports whether a request authenticated with key (ok
+// false when the server has no authentication) may see and manage sched:
+// admin keys reach every schedule, other keys only those they created
+func CanAccessSchedule(key APIKeyConfig, ok bool, sched *Schedule) bool {
+	return !ok || key.Admin || sched.Owner == key.Name
+}
+
+// CanChangePreset reports whether a request authenticated with key (ok false
+// when the server has no authentication) may replace or delete p: presets
+// are shared for reading, but other keys than admin ones may only change
+// those they saved
+func CanChangePreset(key APIKeyConfig, ok bool, p *Preset) bool {
+	return !ok || key.Admin || p.Owner == key.Name
+}
+
+// withinDir reports whether path is dir or inside it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -22,29 +22,107 @@ const (
 // CrawlRequest represents the request body for starting a new crawl
 type CrawlRequest struct {
 	URL                string            `json:"url"`
+	Recipe             string            `json:"recipe,omitempty"` // Recipe whose settings fill those left empty (see GET /api/v1/recipes)
 	MaxDepth           int               `json:"maxDepth,omitempty"`
+	DepthMode          string            `json:"depthMode,omitempty"` // "links" (default) or "path"
+	MaxPages           int               `json:"maxPages,omitempty"` // Stop after N saved pages (0 = unlimited)
+	MaxRuntime         string            `json:"maxRuntime,omitempty"` // Stop after crawling this long, e.g. "30m" (unlimited when empty)
+	MaxConsecutiveErrors int             `json:"maxConsecutiveErrors,omitempty"` // Stop after N fetches failed in a row (0 = never)
+	URLTimeout         string            `json:"urlTimeout,omitempty"` // Give up on a URL not fetched, parsed and saved within this long, e.g. "45s" (none when empty)
+	StopPattern        string            `json:"stopPattern,omitempty"` // Stop once every discovered URL matching this regex was fetched
 	Concurrent         bool              `json:"concurrent,omitempty"`
+	Workers            int               `json:"workers,omitempty"` // Simultaneous requests in concurrent mode (default 10)
 	Delay              string            `json:"delay,omitempty"`
 	OutputDir          string            `json:"outputDir,omitempty"`
 	StateFile          string            `json:"stateFile,omitempty"`
+	StateMerge         bool              `json:"stateMerge,omitempty"` // Merge a state file saved for another start URL (http/https, www variant) instead of only warning
+	FrontierLimit      int               `json:"frontierLimit,omitempty"` // Queued URLs kept in memory, the rest spilled to disk (0 = unlimited)
 	PrefixFilterURL    string            `json:"prefixFilter,omitempty"`
 	ExcludeExtensions  []string          `json:"excludeExtensions,omitempty"`
+	AllowedHosts       []string          `json:"allowedHosts,omitempty"` // Only follow links to these hosts ("*.example.com" matches subdomains)
+	DeniedHosts        []string          `json:"deniedHosts,omitempty"`  // Never follow links to these hosts
+	ExternalLinks      bool              `json:"externalLinks,omitempty"` // Record out-of-scope links to external_links.jsonl without fetching them
+	Report             bool              `json:"report,omitempty"`        // Write report.html with stats, charts and errors when the crawl ends
 	LinkSelectors      []string          `json:"linkSelectors,omitempty"`
 	Verbose            bool              `json:"verbose,omitempty"`
 	UserAgent          string            `json:"userAgent,omitempty"`
+	UserAgentPool      string            `json:"userAgentPool,omitempty"`   // Built-in set antiBot.rotateUserAgent cycles through: desktop (default), mobile or all
+	UserAgents         []string          `json:"userAgents,omitempty"`      // Own user agents to cycle through instead of a built-in set
+	StickyUserAgent    bool              `json:"stickyUserAgent,omitempty"` // Keep one user agent per host
+	WarmUp             bool              `json:"warmUp,omitempty"`          // Visit each host's homepage before its deeper URLs, keeping cookies
+	WarmUpPages        int               `json:"warmUpPages,omitempty"`     // Pages linked from the homepage also visited during warm-up (0-5)
+	RefererPolicy      string            `json:"refererPolicy,omitempty"`   // none, origin, strict or full (default: strict with warmUp, none without)
 	IgnoreRobots       bool              `json:"ignoreRobots,omitempty"`
 	MinContentLength   int               `json:"minContent,omitempty"`
+	MaxContentLength   int               `json:"maxContent,omitempty"`     // Skip pages with more text characters (0 = no limit)
+	MinWords           int               `json:"minWords,omitempty"`       // Skip pages with fewer words (0 = no limit)
+	MaxWords           int               `json:"maxWords,omitempty"`       // Skip pages with more words (0 = no limit)
+	MaxLinkDensity     float64           `json:"maxLinkDensity,omitempty"` // Skip pages whose share of words inside links is higher, 0-1 (0 = no limit)
+	MaxHTMLSize        int64             `json:"maxHtmlSize,omitempty"` // Skip pages larger than this many bytes (default 10 MiB)
 	DisableContentExtraction bool       `json:"disableContentExtraction,omitempty"`
 	DisableReadability       bool       `json:"disableReadability,omitempty"` // Deprecated: use DisableContentExtraction
+	CompressOutput     string            `json:"compressOutput,omitempty"` // "none" (default), "gzip" or "zstd"
+	MetricsInterval    string            `json:"metricsInterval,omitempty"` // Time between metrics time series samples (default "5s")
 	FetchMode          string            `json:"fetchMode,omitempty"`
 	Headless           *bool             `json:"headless,omitempty"`
 	WaitForLogin       bool              `json:"waitForLogin,omitempty"`
 	PageLoadWait       string            `json:"pageLoadWait,omitempty"`
+	HARMode            string            `json:"harMode,omitempty"` // "off" (default), "page" or "crawl"; browser mode only
+	DiscoverAPIs       bool              `json:"discoverApis,omitempty"` // Log XHR/fetch endpoints to api_endpoints.jsonl; browser mode only
+	PageScripts        []crawler.PageScript `json:"pageScripts,omitempty"` // JavaScript run after load on matching pages; browser mode only
+	KeepCookieBanners  bool              `json:"keepCookieBanners,omitempty"` // Don't dismiss cookie consent banners; browser mode only
+	LazyLoadScroll     bool              `json:"lazyLoadScroll,omitempty"` // Scroll through pages before capture to load lazy images; browser mode only
+	ScrollStep         int               `json:"scrollStep,omitempty"`     // Pixels per lazy-load scroll step (0 = one viewport)
+	ScrollDelay        string            `json:"scrollDelay,omitempty"`    // Pause after each lazy-load scroll step (default "250ms")
+	ShadowDOM          string            `json:"shadowDom,omitempty"`      // "off" (default), "declarative" or "flatten"; browser mode only
+	BrowserRecycle     int               `json:"browserRecycle,omitempty"` // Relaunch the browser every N pages, keeping cookies (0 = never); browser mode only
+	BrowserPath        string            `json:"browserPath,omitempty"`    // Chrome binary to run (default: a system-installed Chrome), refused by API servers not started with -allow-commands
+	BrowserMaxMemory   int               `json:"browserMaxMemory,omitempty"` // Relaunch the browser beyond this many MB (0 = unlimited)
+	BrowserMaxTabs     int               `json:"browserMaxTabs,omitempty"` // Tabs open at once (0 = unlimited)
+	BrowserMaxProcesses int              `json:"browserMaxProcesses,omitempty"` // Chrome processes, renderers included (0 = unlimited)
+	Iframes            string            `json:"iframes,omitempty"`        // "off" (default), "link" or "inline"
+	IframeHosts        []string          `json:"iframeHosts,omitempty"`    // Cross-origin hosts whose iframes are captured too; browser mode only
+	Provenance         string            `json:"provenance,omitempty"`     // "off" (default), "comment" or "banner" recording source, fetch time, version and job in saved pages
+	ContentAddressable bool              `json:"contentAddressable,omitempty"` // Store each distinct file once under its hash, with per-URL pointer files
+	ContentFilters     []crawler.ContentFilter `json:"contentFilters,omitempty"` // Elements stripped or kept on matching pages before saving
+	FollowOnly         []crawler.FollowOnlyRule `json:"followOnly,omitempty"` // Pages traversed for links but never saved, by URL or title pattern
+	Alerts             []crawler.AlertRule      `json:"alerts,omitempty"`     // Patterns or keywords watched for in saved pages, matches in alerts.jsonl
+	PluginsDir         string                   `json:"pluginsDir,omitempty"` // Directory on the server of JavaScript plugins filtering URLs, transforming and extracting from pages
+	IndexInterval      *int              `json:"indexInterval,omitempty"` // Rewrite _index.html every N saved pages (0 = only at completion)
 	Pagination         *PaginationConfig `json:"pagination,omitempty"`
 	AntiBot            *AntiBotConfig    `json:"antiBot,omitempty"`
+	Geo                *GeoConfig        `json:"geo,omitempty"` // Region/language emulation and proxy
+	Permissions        *OutputPermissions `json:"permissions,omitempty"` // Modes and owner of written files
+	Faults             *FaultConfig       `json:"faults,omitempty"` // Artificial failures injected into fetches, for development
+	Cassette           string             `json:"cassette,omitempty"`     // "off" (default), "record" (save every response) or "replay" (fetch only from the cassette)
+	CassetteFile       string             `json:"cassetteFile,omitempty"` // Cassette path (default cassette.jsonl in outputDir)
+	Notify             string             `json:"notify,omitempty"`       // Announce the end or failure of the crawl on the server's desktop: "off" (default), "desktop" or "bell"
+	TraceDecisions     string             `json:"traceDecisions,omitempty"` // JSON Lines file recording every URL considered and the rule that accepted or rejected it
+	ResultsDB          string             `json:"resultsDb,omitempty"`      // SQLite database of pages, links, errors, redirects and metrics samples (relative to outputDir)
+	EventSinks         []crawler.EventSink `json:"eventSinks,omitempty"` // Files, webhooks, NATS subjects, Kafka topics and Elasticsearch indexes the crawl events are also sent to
+	Tracing            *TracingConfig      `json:"tracing,omitempty"`    // OpenTelemetry spans of each URL's stages, exported over OTLP
+	Embeddings         *EmbeddingsConfig   `json:"embeddings,omitempty"` // Vectors of each saved page's text from an OpenAI-compatible embeddings endpoint
+	Enrichment         *EnrichmentConfig   `json:"enrichment,omitempty"` // Summary, tags, ... of each saved page from an HTTP endpoint, added to its .meta.json
+	// Shell command run for each saved page, refused by API servers not started with -allow-commands
+	PostSaveCommand     string `json:"postSaveCommand,omitempty"`     // Gets the saved file as $1 and $SCRAPER_FILE and its metadata JSON on stdin
+	PostSaveConcurrency int    `json:"postSaveConcurrency,omitempty"` // Commands running at once (default 2)
+	PostSaveTimeout     string `json:"postSaveTimeout,omitempty"`     // A command is killed after this long, e.g. "2m" (default 60s)
+	PostSaveOnFailure   string `json:"postSaveOnFailure,omitempty"`   // After a failed command: "warn" (default), "disable" or "stop"
+	WaybackFallback     bool   `json:"waybackFallback,omitempty"`     // Save the latest Wayback Machine snapshot of pages answering 404 or 410 or timing out
+	WaybackSave         bool   `json:"waybackSave,omitempty"`         // Submit the URL of each saved page to the Wayback Machine's Save Page Now
+	WaybackSaveKey      string `json:"waybackSaveKey,omitempty"`      // archive.org accesskey:secret or secret://name (default $SCRAPER_WAYBACK_KEY)
+	WaybackSaveInterval string `json:"waybackSaveInterval,omitempty"` // Time between submissions, e.g. "30s" (default 10s)
+	// Re-crawl settings, set on jobs spawned by POST /crawl/{jobId}/recrawl
+	RecrawlURLs  []string `json:"recrawlUrls,omitempty"`  // Fetch only these URLs into outputDir, without following links
+	RecrawlScope string   `json:"recrawlScope,omitempty"` // "failed", "changed" (unchanged pages are kept) or "pattern"
+	ParentJobID  string   `json:"parentJobId,omitempty"`  // Job whose output is re-crawled
 	// URL normalization settings
 	NormalizeURLs  *bool `json:"normalizeUrls,omitempty"`
 	LowercasePaths bool  `json:"lowercasePaths,omitempty"`
+	// Tags are free-form labels for organizing and filtering jobs
+	Tags []string `json:"tags,omitempty"`
+	// Keep exempts the job from the server's retention cleanup
+	Keep bool `json:"keep,omitempty"`
 }
 
 // PaginationConfig holds click-based pagination settings
@@ -55,10 +133,13 @@ type PaginationConfig struct {
 	WaitAfterClick  string `json:"waitAfterClick,omitempty"`
 	WaitSelector    string `json:"waitSelector,omitempty"`
 	StopOnDuplicate bool   `json:"stopOnDuplicate,omitempty"`
+	HarvestURLs     bool   `json:"harvestUrls,omitempty"` // Queue URLs clicks navigate to as regular pages
 }
 
 // AntiBotConfig mirrors crawler.AntiBotConfig for API requests
 type AntiBotConfig struct {
+	Profile string `json:"profile,omitempty"` // off, low, standard or aggressive; the options below add to it
+
 	// Browser Fingerprint Modifications
 	HideWebdriver  bool `json:"hideWebdriver,omitempty"`
 	SpoofPlugins   bool `json:"spoofPlugins,omitempty"`
@@ -80,11 +161,137 @@ type AntiBotConfig struct {
 	Timezone        string `json:"timezone,omitempty"`
 }
 
+// GeoConfig mirrors crawler.GeoConfig for API requests
+type GeoConfig struct {
+	Region         string `json:"region,omitempty"`         // Region preset (e.g. "de"), fills the fields left empty
+	AcceptLanguage string `json:"acceptLanguage,omitempty"` // Accept-Language header
+	Locale         string `json:"locale,omitempty"`         // Browser locale; browser mode only
+	Timezone       string `json:"timezone,omitempty"`       // IANA timezone; browser mode only
+	Geolocation    string `json:"geolocation,omitempty"`    // "latitude,longitude"; browser mode only
+	Proxy          string `json:"proxy,omitempty"`          // Proxy URL; {region} is replaced by the region code
+	ProxyDNS       bool   `json:"proxyDns,omitempty"`       // Resolve host names only through the proxy
+}
+
+// OutputPermissions mirrors crawler.OutputPermissions for API requests
+type OutputPermissions struct {
+	FileMode string `json:"fileMode,omitempty"` // Octal file mode (e.g. "0664")
+	DirMode  string `json:"dirMode,omitempty"`  // Octal directory mode (e.g. "2775")
+	Owner    string `json:"owner,omitempty"`    // "uid[:gid]"; Unix only, server must run as root
+}
+
+// TracingConfig mirrors crawler.TracingConfig for API requests
+type TracingConfig struct {
+	Endpoint   string  `json:"endpoint,omitempty"`   // OTLP/HTTP collector, e.g. http://localhost:4318
+	SampleRate float64 `json:"sampleRate,omitempty"` // Share of URLs traced, from 0 to 1 (0 = every URL)
+}
+
+// EmbeddingsConfig mirrors crawler.EmbeddingsConfig for API requests
+type EmbeddingsConfig struct {
+	Endpoint   string `json:"endpoint,omitempty"`   // API base URL, e.g. https://api.openai.com/v1
+	Model      string `json:"model,omitempty"`      // e.g. text-embedding-3-small
+	APIKey     string `json:"apiKey,omitempty"`     // Bearer token or secret://name (default: $SCRAPER_EMBEDDINGS_API_KEY, without authentication or for admin keys)
+	Dimensions int    `json:"dimensions,omitempty"` // Vector size, for models that can shorten their vectors (0 = the model's)
+	Store      string `json:"store,omitempty"`      // "file" (default) or "results-db"
+}
+
+// EnrichmentConfig mirrors crawler.EnrichmentConfig for API requests
+type EnrichmentConfig struct {
+	Endpoint    string `json:"endpoint,omitempty"`    // URL the extracted content of each saved page is POSTed to
+	APIKey      string `json:"apiKey,omitempty"`      // Bearer token or secret://name (default: $SCRAPER_ENRICHMENT_API_KEY, without authentication or for admin keys)
+	Concurrency int    `json:"concurrency,omitempty"` // Requests in flight at once (default 2)
+	Timeout     string `json:"timeout,omitempty"`     // Per request (e.g. "2m", default "60s")
+}
+
+// FaultConfig mirrors crawler.FaultConfig for API requests
+type FaultConfig struct {
+	Latency      string  `json:"latency,omitempty"`      // Added to every fetch (e.g. "200ms")
+	ErrorRate    float64 `json:"errorRate,omitempty"`    // Share of fetches answered with a random 5xx (0-1)
+	TruncateRate float64 `json:"truncateRate,omitempty"` // Share of fetches whose body is cut in half (0-1)
+	ResetRate    float64 `json:"resetRate,omitempty"`    // Share of fetches failed with a connection reset (0-1)
+	Seed         int64   `json:"seed,omitempty"`         // Selects which fetches fail
+}
+
+// ExportRequest represents the request body for exporting a job as a book
+type ExportRequest struct {
+	Format      string `json:"format,omitempty"`      // "html" (default) or "epub"
+	Order       string `json:"order,omitempty"`       // "path" (default) or "crawl"
+	Title       string `json:"title,omitempty"`
+	EmbedImages *bool  `json:"embedImages,omitempty"` // defaults to true
+}
+
+// SiteRequest represents the request body for generating a static site from a job
+type SiteRequest struct {
+	Title       string `json:"title,omitempty"`
+	EmbedImages bool   `json:"embedImages,omitempty"`
+}
+
+// DuplicatesRequest represents the request body for a near-duplicate content report
+type DuplicatesRequest struct {
+	Threshold int `json:"threshold,omitempty"` // Max SimHash bit difference (default 3, max 10)
+}
+
+// IndexRequest represents the request body for regenerating a job's index
+type IndexRequest struct {
+	Limit int `json:"limit,omitempty"` // Return at most this many pages (0 = all)
+}
+
+// RecrawlRequest represents the request body for re-crawling part of a job
+type RecrawlRequest struct {
+	Scope   string `json:"scope"`             // "failed", "changed" or "pattern"
+	Pattern string `json:"pattern,omitempty"` // URL regex, required for the "pattern" scope
+}
+
+// ReprocessRequest represents the request body for extracting a job's saved
+// pages again. Omitted settings are taken from the job's config.
+type ReprocessRequest struct {
+	DisableContentExtraction *bool                   `json:"disableContentExtraction,omitempty"`
+	ContentFilters           []crawler.ContentFilter `json:"contentFilters,omitempty"`
+	PluginsDir               *string                 `json:"pluginsDir,omitempty"`
+}
+
+// WorkersRequest represents a request to change the worker count of a running job
+type WorkersRequest struct {
+	Workers int `json:"workers"`
+}
+
+// KeepRequest is the body of POST /api/v1/crawl/{jobId}/keep
+type KeepRequest struct {
+	Keep bool `json:"keep"`
+}
+
+// KeepResponse reports the keep flag after a change
+type KeepResponse struct {
+	JobID string `json:"jobId"`
+	Keep  bool   `json:"keep"`
+}
+
+// WorkersResponse reports the worker count after a change
+type WorkersResponse struct {
+	JobID   string `json:"jobId"`
+	Workers int    `json:"workers"`
+}
+
+// ConfigPatchRequest holds runtime-tunable settings for a running job.
+// Omitted fields are left unchanged.
+type ConfigPatchRequest struct {
+	Delay    *string `json:"delay,omitempty"`
+	Workers  *int    `json:"workers,omitempty"`
+	MaxPages *int    `json:"maxPages,omitempty"`
+	Verbose  *bool   `json:"verbose,omitempty"`
+}
+
+// ConfigPatchResponse reports the runtime settings after a change
+type ConfigPatchResponse struct {
+	JobID  string                `json:"jobId"`
+	Config crawler.RuntimeConfig `json:"config"`
+}
+
 // CrawlResponse is returned when a crawl job is created
 type CrawlResponse struct {
 	JobID     string    `json:"jobId"`
 	Status    JobStatus `json:"status"`
 	CreatedAt time.Time `json:"createdAt"`
+	Warnings  []string  `json:"warnings,omitempty"` // e.g. scope overlaps with other active jobs
 }
 
 // JobSummary provides a brief overview of a job (for listing)
@@ -93,6 +300,8 @@ type JobSummary struct {
 	URL       string    `json:"url"`
 	Status    JobStatus `json:"status"`
 	CreatedAt time.Time `json:"createdAt"`
+	Tags      []string  `json:"tags,omitempty"`
+	Keep      bool      `json:"keep,omitempty"`
 }
 
 // JobDetails provides full information about a job
@@ -103,9 +312,104 @@ type JobDetails struct {
 	CreatedAt       time.Time        `json:"createdAt"`
 	StartedAt       *time.Time       `json:"startedAt,omitempty"`
 	CompletedAt     *time.Time       `json:"completedAt,omitempty"`
+	StopReason      string           `json:"stopReason,omitempty"` // Why the crawl ended: queue-empty, max-pages, max-runtime, consecutive-errors, targets-fetched, post-save-failed or stopped
 	Config          *CrawlRequest    `json:"config,omitempty"`
 	Metrics         *MetricsSnapshot `json:"metrics,omitempty"`
 	WaitingForLogin bool             `json:"waitingForLogin,omitempty"`
+	Workers         int              `json:"workers,omitempty"`
+	SSEClients      int              `json:"sseClients"` // Clients currently streaming this job's events
+	Warnings        []string         `json:"warnings,omitempty"` // Raised when the job started, e.g. scope overlaps with other active jobs
+	Error           string           `json:"error,omitempty"`
+}
+
+// UsageResponse is the response of GET /api/v1/usage
+type UsageResponse struct {
+	Keys []KeyUsage `json:"keys"`
+}
+
+// AuditResponse is the response of GET /api/v1/audit
+type AuditResponse struct {
+	Total   int          `json:"total"` // Matching entries before the limit
+	Entries []AuditEntry `json:"entries"`
+}
+
+// RuntimeResponse is the response of GET /api/v1/debug/runtime
+type RuntimeResponse struct {
+	GoVersion  string       `json:"goVersion"`
+	NumCPU     int          `json:"numCpu"`
+	GOMAXPROCS int          `json:"gomaxprocs"`
+	Goroutines int          `json:"goroutines"`      // All goroutines of the process
+	Unowned    int          `json:"unownedGoroutines"` // Goroutines not started by a job (server, SSE clients, ...)
+	Heap       HeapStats    `json:"heap"`
+	Jobs       []JobRuntime `json:"jobs"` // Jobs with goroutines, most first
+}
+
+// HeapStats is the memory part of RuntimeResponse, in bytes
+type HeapStats struct {
+	Alloc      uint64     `json:"alloc"`    // Live heap objects
+	InUse      uint64     `json:"inUse"`    // Heap spans in use
+	Idle       uint64     `json:"idle"`     // Heap spans not in use, including those returned to the OS
+	Released   uint64     `json:"released"` // Returned to the OS
+	Sys        uint64     `json:"sys"`      // Obtained from the OS, all runtime memory
+	Objects    uint64     `json:"objects"`  // Live heap objects count
+	NextGC     uint64     `json:"nextGc"`   // Heap size the next collection starts at
+	NumGC      uint32     `json:"numGc"`
+	PauseTotal string     `json:"pauseTotal"` // Time spent in stop-the-world GC pauses
+	LastGC     *time.Time `json:"lastGc,omitempty"`
+}
+
+// JobRuntime counts the goroutines a job started. Goroutines of a job that
+// has ended point to a leak.
+type JobRuntime struct {
+	ID         string    `json:"id"`
+	Status     JobStatus `json:"status"`
+	Goroutines int       `json:"goroutines"`
+}
+
+// SelfTestRequest is the optional body of POST /api/v1/selftest
+type SelfTestRequest struct {
+	Checks []string `json:"checks,omitempty"` // Checks to run; all when empty
+}
+
+// SelfTestResponse is the response of POST /api/v1/selftest
+type SelfTestResponse struct {
+	Passed  bool                     `json:"passed"`
+	Results []crawler.SelfTestResult `json:"results"`
+}
+
+// AntiBotTestRequest is the optional body of POST /api/v1/antibot-test
+type AntiBotTestRequest struct {
+	URL       string         `json:"url,omitempty"`       // Page to probe; a bundled local page when empty
+	AntiBot   *AntiBotConfig `json:"antiBot,omitempty"`   // Profile and options under test
+	Headless  *bool          `json:"headless,omitempty"`  // Default true
+	UserAgent string         `json:"userAgent,omitempty"` // User agent when not rotating
+}
+
+// RecipesResponse is the response of GET /api/v1/recipes
+type RecipesResponse struct {
+	Recipes []Recipe `json:"recipes"` // Sorted by name
+}
+
+// PluginsResponse is the response of GET /api/v1/plugins
+type PluginsResponse struct {
+	Dir     string               `json:"dir"`
+	Plugins []crawler.PluginInfo `json:"plugins"` // In the order their hooks run
+}
+
+// PresetsResponse is the response of GET /api/v1/presets
+type PresetsResponse struct {
+	Presets []PresetSummary `json:"presets"` // Newest first
+}
+
+// SecretsResponse is the response of the /api/v1/secrets endpoints. Values
+// are never returned.
+type SecretsResponse struct {
+	Names []string `json:"names"`
+}
+
+// SetSecretRequest is the body of PUT /api/v1/secrets/{name}
+type SetSecretRequest struct {
+	Value string `json:"value"`
 }
 
 // MetricsSnapshot represents a point-in-time snapshot of crawl metrics
@@ -118,11 +422,136 @@ type MetricsSnapshot struct {
 	RobotsBlocked   int64   `json:"robotsBlocked"`
 	DepthLimitHits  int64   `json:"depthLimitHits"`
 	ContentFiltered int64   `json:"contentFiltered"`
+	FollowOnly      int64   `json:"followOnly"`
+	StopReason      string  `json:"stopReason,omitempty"`
+	Redirected      int64   `json:"redirected"`
+	RedirectErrors  int64   `json:"redirectErrors"`
+	TimedOut        int64   `json:"timedOut"`
+	BrowserRecycles int64   `json:"browserRecycles"`
+	BrowserCrashes  int64   `json:"browserCrashes"`
 	PagesPerSecond  float64 `json:"pagesPerSecond"`
 	QueueSize       int     `json:"queueSize"`
+	HeapAlloc       int64   `json:"heapAlloc"`
+	PeakHeapAlloc   int64   `json:"peakHeapAlloc"`
+	SysMemory       int64   `json:"sysMemory"`
+	NumGC           int64   `json:"numGC"`
 	ElapsedTime     string  `json:"elapsedTime,omitempty"`
 	Percentage      float64 `json:"percentage,omitempty"`
 	CurrentURL      string  `json:"currentUrl,omitempty"`
+	Depths          []crawler.DepthStats `json:"depths,omitempty"` // Discovered, saved and errored URLs per depth
+	Content         crawler.ContentStats `json:"content"`          // Status codes, content types, charsets, languages and largest pages
+	Active          []crawler.ActiveFetch `json:"active,omitempty"`       // URLs being processed by workers, oldest first
+	RecentErrors    []crawler.RecentError `json:"recentErrors,omitempty"` // Latest failed URLs, oldest first
+}
+
+// MetricsSample is one point of a job's metrics time series
+type MetricsSample struct {
+	Timestamp       time.Time `json:"timestamp"`
+	ElapsedSeconds  float64   `json:"elapsedSeconds"`
+	URLsProcessed   int64     `json:"urlsProcessed"`
+	URLsSaved       int64     `json:"urlsSaved"`
+	URLsSkipped     int64     `json:"urlsSkipped"`
+	URLsErrored     int64     `json:"urlsErrored"`
+	QueueSize       int       `json:"queueSize"`
+	BytesDownloaded int64     `json:"bytesDownloaded"`
+	PagesPerSecond  float64   `json:"pagesPerSecond"` // Throughput since the previous sample
+	HeapAlloc       int64     `json:"heapAlloc"`
+}
+
+// MetricsTimeSeriesResponse is the response for GET /api/v1/crawl/{jobId}/metrics/timeseries
+type MetricsTimeSeriesResponse struct {
+	JobID           string          `json:"jobId"`
+	IntervalSeconds float64         `json:"intervalSeconds"`
+	Samples         []MetricsSample `json:"samples"`
+}
+
+// URLRecord is one URL of a job's inventory
+type URLRecord struct {
+	URL         string `json:"url"`
+	Status      string `json:"status"` // saved, skipped, error, blocked or queued
+	Depth       int    `json:"depth"`
+	Referrer    string `json:"referrer,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+	HTTPStatus  int    `json:"httpStatus,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	AnchorText  string `json:"anchorText,omitempty"` // Text of the link on the referrer
+	Heading     string `json:"heading,omitempty"`    // Nearest heading before that link
+	Rel         string `json:"rel,omitempty"`        // rel attribute of that link
+}
+
+// URLInventoryResponse is the response for GET /api/v1/crawl/{jobId}/urls
+type URLInventoryResponse struct {
+	JobID  string         `json:"jobId"`
+	Total  int            `json:"total"` // Matching URLs before limit and offset
+	Offset int            `json:"offset"`
+	Counts map[string]int `json:"counts"` // URLs per status across the whole inventory
+	URLs   []URLRecord    `json:"urls"`
+}
+
+// URLErrorsResponse is the response for GET /api/v1/crawl/{jobId}/errors
+type URLErrorsResponse struct {
+	JobID  string             `json:"jobId"`
+	Total  int                `json:"total"`  // Matching errored URLs before limit
+	Counts map[string]int     `json:"counts"` // Errored URLs per error class
+	Errors []crawler.URLError `json:"errors"`
+}
+
+// RetryRequest is the body of POST /api/v1/crawl/{jobId}/retry: the errored
+// URLs to queue again, or all of them (of class, when set)
+type RetryRequest struct {
+	URLs  []string `json:"urls,omitempty"`
+	All   bool     `json:"all,omitempty"`
+	Class string   `json:"class,omitempty"` // With all: only errors of this class
+}
+
+// RetryResponse reports how many errored URLs were queued again
+type RetryResponse struct {
+	JobID  string `json:"jobId"`
+	Queued int    `json:"queued"`
+}
+
+// CrawlGraphResponse is the response for GET /api/v1/crawl/{jobId}/graph
+type CrawlGraphResponse struct {
+	JobID string `json:"jobId"`
+	crawler.CrawlGraph
+}
+
+// RedirectsResponse is the response for GET /api/v1/crawl/{jobId}/redirects
+type RedirectsResponse struct {
+	JobID     string                    `json:"jobId"`
+	Redirects []crawler.Redirect        `json:"redirects"` // Every hop followed: from, to, status
+	Failures  []crawler.RedirectFailure `json:"failures"`  // Redirect loops and chains longer than the limit
+	Aliases   map[string]string         `json:"aliases"`   // Permanently redirected URL -> final URL
+}
+
+// APIEndpointsResponse is the response for GET /api/v1/crawl/{jobId}/endpoints
+type APIEndpointsResponse struct {
+	JobID     string                `json:"jobId"`
+	Total     int                   `json:"total"` // Matching endpoints before limit
+	Endpoints []crawler.APIEndpoint `json:"endpoints"`
+}
+
+// ExternalLinksResponse is the response for GET /api/v1/crawl/{jobId}/external-links
+type ExternalLinksResponse struct {
+	JobID string                 `json:"jobId"`
+	Total int                    `json:"total"` // Matching links before limit
+	Links []crawler.ExternalLink `json:"links"`
+}
+
+// AlertsResponse is the response for GET /api/v1/crawl/{jobId}/alerts
+type AlertsResponse struct {
+	JobID  string          `json:"jobId"`
+	Total  int             `json:"total"` // Matching alerts before limit
+	Alerts []crawler.Alert `json:"alerts"`
+}
+
+// IndexResponse is the response for POST /api/v1/crawl/{jobId}/index
+type IndexResponse struct {
+	JobID     string              `json:"jobId"`
+	IndexFile string              `json:"indexFile"` // Path of the regenerated _index.html
+	Total     int                 `json:"total"`     // Indexed pages before limit
+	Pages     []crawler.PageEntry `json:"pages"`     // Sorted by URL
 }
 
 // APIError represents a standardized error response
@@ -142,11 +571,27 @@ func (e APIError) Error() string {
 
 // SSEEvent represents a Server-Sent Event
 type SSEEvent struct {
+	ID        uint64      `json:"id,omitempty"` // Sequence number, used for replay via Last-Event-ID or ?since=
 	Type      string      `json:"type"`
 	Timestamp time.Time   `json:"timestamp"`
 	Data      interface{} `json:"data,omitempty"`
 }
 
+// SSEClientInfo describes a client connected to a job's event stream
+type SSEClientInfo struct {
+	ID          int       `json:"id"`
+	RemoteAddr  string    `json:"remoteAddr,omitempty"`
+	UserAgent   string    `json:"userAgent,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+// SSEClientsResponse is the response for GET /api/v1/crawl/{jobId}/clients
+type SSEClientsResponse struct {
+	JobID   string          `json:"jobId"`
+	Count   int             `json:"count"`
+	Clients []SSEClientInfo `json:"clients"`
+}
+
 // FromCrawlerEvent converts a crawler event to an SSE event
 func FromCrawlerEvent(event crawler.CrawlerEvent) SSEEvent {
 	return SSEEvent{
@@ -162,4 +607,23 @@ type HealthResponse struct {
 	Version   string `json:"version"`
 	Uptime    string `json:"uptime"`
 	ActiveJobs int   `json:"activeJobs"`
+	Polite     *crawler.PolitePolicy `json:"polite,omitempty"` // Guardrails enforced on every job, when polite mode is on
+}
+
+// ScheduleRequest is the body of POST /api/v1/schedules
+type ScheduleRequest struct {
+	Preset string `json:"preset"`
+	URL    string `json:"url,omitempty"` // Start URL, overriding the preset's
+	Spec   string `json:"spec"`          // e.g. "nightly", "daily 03:30", "weekly mon 06:00", "every 6h"
+}
+
+// SchedulesResponse is the response of GET /api/v1/schedules
+type SchedulesResponse struct {
+	Running   bool       `json:"running"` // Whether this server runs the schedules when due
+	Schedules []Schedule `json:"schedules"`
+}
+
+// ScheduleHistoryResponse is the response of GET /api/v1/schedules/history
+type ScheduleHistoryResponse struct {
+	Runs []ScheduleRun `json:"runs"` // Newest first
 }
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageSweepInterval is how often the pages and bytes fetched by running jobs
+// are charged to their API keys and quotas are enforced
+const UsageSweepInterval = 5 * time.Second
+
+// AnonymousKey is the usage key of jobs created without an API key
+const AnonymousKey = "anonymous"
+
+// Quota limits what the jobs of one API key may fetch (0 = unlimited).
+// Days and months are calendar periods in UTC.
+type Quota struct {
+	DailyBytes   int64 `json:"dailyBytes,omitempty"`
+	DailyPages   int64 `json:"dailyPages,omitempty"`
+	MonthlyBytes int64 `json:"monthlyBytes,omitempty"`
+	MonthlyPages int64 `json:"monthlyPages,omitempty"`
+}
+
+// IsZero reports whether the quota sets no limit
+func (q Quota) IsZero() bool {
+	return q == Quota{}
+}
+
+// Exceeded returns the limit usage has reached, or "" when it is within quota
+func (q Quota) Exceeded(u KeyUsage) string {
+	switch {
+	case q.DailyBytes > 0 && u.DayBytes >= q.DailyBytes:
+		return "daily bytes"
+	case q.DailyPages > 0 && u.DayPages >= q.DailyPages:
+		return "daily pages"
+	case q.MonthlyBytes > 0 && u.MonthBytes >= q.MonthlyBytes:
+		return "monthly bytes"
+	case q.MonthlyPages > 0 && u.MonthPages >= q.MonthlyPages:
+		return "monthly pages"
+	}
+	return ""
+}
+
+// APIKeyConfig is one API key of a shared deployment
+type APIKeyConfig struct {
+	Name  string `json:"name"`            // Shown in usage reports instead of the key
+	Key   string `json:"key"`             // Bearer token
+	Admin bool   `json:"admin,omitempty"` // May see the usage of every key
+	Quota Quota  `json:"quota"`
+
+	// RateLimit overrides the server's requests per minute for this key
+	// (0 = the server's)
+	RateLimit int `json:"rateLimit,omitempty"`
+}
+
+// LoadAPIKeys reads API keys from a JSON file holding an array of APIKeyConfig
+func LoadAPIKeys(path string) ([]APIKeyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+
+	var keys []APIKeyConfig
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// KeyUsage is what the jobs of one API key fetched
+type KeyUsage struct {
+	Key        string `json:"key"` // API key name
+	Day        string `json:"day"` // Current day (UTC, 2006-01-02)
+	DayBytes   int64  `json:"dayBytes"`
+	DayPages   int64  `json:"dayPages"`
+	Month      string `json:"month"` // Current month (UTC, 2006-01)
+	MonthBytes int64  `json:"monthBytes"`
+	MonthPages int64  `json:"monthPages"`
+	TotalBytes int64  `json:"totalBytes"`
+	TotalPages int64  `json:"totalPages"`
+	Quota      *Quota `json:"quota,omitempty"`
+	Exceeded   string `json:"exceeded,omitempty"` // Limit reached, if any
+	ActiveJobs int    `json:"activeJobs"`
+}
+
+// roll starts new day and month periods when now lies past the recorded ones
+func (u *KeyUsage) roll(now time.Time) {
+	now = now.UTC()
+	if day := now.Format("2006-01-02"); u.Day != day {
+		u.Day, u.DayBytes, u.DayPages = day, 0, 0
+	}
+	if month := now.Format("2006-01"); u.Month != month {
+		u.Month, u.MonthBytes, u.MonthPages = month, 0, 0
+	}
+}
+
+// UsageTracker accumulates the pages and bytes fetched by each API key's jobs
+type UsageTracker struct {
+	usage  map[string]*KeyUsage
+	quotas map[string]Quota
+	file   string // Usage is persisted here when set
+	dirty  bool
+	mu     sync.Mutex
+}
+
+// NewUsageTracker creates an empty tracker without quotas
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		usage:  make(map[string]*KeyUsage),
+		quotas: make(map[string]Quota),
+	}
+}
+
+// SetQuotas replaces the quotas with those of keys
+func (t *UsageTracker) SetQuotas(keys []APIKeyConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.quotas = make(map[string]Quota)
+	for _, k := range keys {
+		t.quotas[k.Name] = k.Quota
+		if _, ok := t.usage[k.Name]; !ok {
+			t.usage[k.Name] = &KeyUsage{Key: k.Name}
+		}
+	}
+}
+
+// Load reads the usage persisted by an earlier run and keeps saving to path.
+// A missing file starts from zero.
+func (t *UsageTracker) Load(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.file = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read usage file: %w", err)
+	}
+
+	var saved []KeyUsage
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("invalid usage file %s: %w", path, err)
+	}
+	for _, u := range saved {
+		u.Quota, u.Exceeded, u.ActiveJobs = nil, "", 0
+		t.usage[u.Key] = &u
+	}
+	return nil
+}
+
+// Save writes the usage to the tracker's file when it changed since the last save
+func (t *UsageTracker) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file == "" || !t.dirty {
+		return nil
+	}
+
+	saved := make([]KeyUsage, 0, len(t.usage))
+	for _, u := range t.usage {
+		saved = append(saved, *u)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Key < saved[j].Key })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage file: %w", err)
+	}
+	t.dirty = false
+	return nil
+}
+
+// Charge adds fetched bytes and pages to the usage of key
+func (t *UsageTracker) Charge(key string, bytes, pages int64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u, ok := t.usage[key]
+	if !ok {
+		u = &KeyUsage{Key: key}
+		t.usage[key] = u
+	}
+	u.roll(now)
+	u.DayBytes += bytes
+	u.DayPages += pages
+	u.MonthBytes += bytes
+	u.MonthPages += pages
+	u.TotalBytes += bytes
+	u.TotalPages += pages
+	t.dirty = true
+}
+
+// Usage returns the usage of key in the periods containing now
+func (t *UsageTracker) Usage(key string, now time.Time) KeyUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshot(key, now)
+}
+
+// All returns the usage of every key that has a quota or fetched anything, sorted by key
+func (t *UsageTracker) All(now time.Time) []KeyUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	all := make([]KeyUsage, 0, len(t.usage))
+	for key := range t.usage {
+		all = append(all, t.snapshot(key, now))
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Key < all[j].Key })
+	return all
+}
+
+// snapshot copies the usage of key and attaches its quota; t.mu must be held
+func (t *UsageTracker) snapshot(key string, now time.Time) KeyUsage {
+	u := KeyUsage{Key: key}
+	if recorded, ok := t.usage[key]; ok {
+		u = *recorded
+	}
+	u.roll(now)
+	if q := t.quotas[key]; !q.IsZero() {
+		u.Quota = &q
+		u.Exceeded = q.Exceeded(u)
+	}
+	return u
+}
+
+// CheckQuota returns a 429 error when key has used up its quota
+func (t *UsageTracker) CheckQuota(key string, now time.Time) error {
+	if limit := t.Usage(key, now).Exceeded; limit != "" {
+		return APIError{
+			Code:    429,
+			Message: "quota exceeded",
+			Details: fmt.Sprintf("%s quota of API key %q is used up", limit, key),
+		}
+	}
+	return nil
+}
+
+// SetUsageTracking applies the quotas of keys, restores usage persisted in
+// file (when set) and starts the background sweep that charges running jobs
+// and stops those of keys over quota
+func (m *JobManager) SetUsageTracking(keys []APIKeyConfig, file string) error {
+	m.usage.SetQuotas(keys)
+	if file != "" {
+		if err := m.usage.Load(file); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	stop := m.stopUsage
+	done := make(chan struct{})
+	m.stopUsage = func() { close(done) }
+	m.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+	go m.runUsage(done)
+	return nil
+}
+
+// runUsage charges running jobs and enforces quotas every UsageSweepInterval until done is closed
+func (m *JobManager) runUsage(done <-chan struct{}) {
+	ticker := time.NewTicker(UsageSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.EnforceQuotas(time.Now())
+			if err := m.usage.Save(); err != nil {
+				log.Printf("Usage: %v", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// chargeUsage charges every job's fetches since the last charge to its API key
+func (m *JobManager) chargeUsage(now time.Time) {
+	for _, job := range m.ListJobs() {
+		m.chargeJob(job, now)
+	}
+}
+
+// chargeJob charges the pages and bytes a job fetched since its last charge to its API key
+func (m *JobManager) chargeJob(job *CrawlJob, now time.Time) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.Crawler == nil {
+		return
+	}
+	metrics := job.Crawler.GetMetrics()
+	if metrics == nil {
+		return
+	}
+
+	snapshot := metrics.GetSnapshot()
+	bytes := snapshot.BytesDownloaded - job.chargedBytes
+	pages := snapshot.URLsProcessed - job.chargedPages
+	if bytes <= 0 && pages <= 0 {
+		return
+	}
+	job.chargedBytes, job.chargedPages = snapshot.BytesDownloaded, snapshot.URLsProcessed
+	m.usage.Charge(job.Owner, bytes, pages, now)
+}
+
+// EnforceQuotas charges running jobs and stops the active jobs of keys that
+// have used up their quota. It returns the IDs of the stopped jobs.
+func (m *JobManager) EnforceQuotas(now time.Time) []string {
+	m.chargeUsage(now)
+
+	var stopped []string
+	for _, job := range m.ListJobs() {
+		owner := job.GetOwner()
+		if !isActiveStatus(job.GetStatus()) {
+			continue
+		}
+		limit := m.usage.Usage(owner, now).Exceeded
+		if limit == "" {
+			continue
+		}
+		if err := m.StopJob(job.ID); err != nil {
+			continue
+		}
+
+		job.mu.Lock()
+		job.Error = fmt.Errorf("stopped: %s quota of API key %q is used up", limit, owner)
+		job.mu.Unlock()
+		log.Printf("Usage: stopped job %s, %s quota of API key %q is used up", job.ID, limit, owner)
+		stopped = append(stopped, job.ID)
+	}
+	return stopped
+}
+
+// Usage returns the up-to-date usage of key, or of every key when key is
+// empty, including the number of active jobs of each key
+func (m *JobManager) Usage(key string, now time.Time) []KeyUsage {
+	m.chargeUsage(now)
+
+	var usage []KeyUsage
+	if key != "" {
+		usage = []KeyUsage{m.usage.Usage(key, now)}
+	} else {
+		usage = m.usage.All(now)
+	}
+
+	active := make(map[string]int)
+	for _, job := range m.ListJobs() {
+		if isActiveStatus(job.GetStatus()) {
+			active[job.GetOwner()]++
+		}
+	}
+	for i := range usage {
+		usage[i].ActiveJobs = active[usage[i].Key]
+	}
+	return usage
+}
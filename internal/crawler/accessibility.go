@@ -0,0 +1,317 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Accessibility report files written to the output directory
+const (
+	AccessibilityReportHTML = "accessibility_report.html"
+	AccessibilityReportJSON = "accessibility_report.json"
+)
+
+// Accessibility issue types
+const (
+	A11yIssueMissingAlt   = "missing_alt"
+	A11yIssueEmptyLink    = "empty_link"
+	A11yIssueEmptyButton  = "empty_button"
+	A11yIssueMissingLang  = "missing_lang"
+	A11yIssueHeadingOrder = "heading_order"
+)
+
+// A11yIssueTypes lists the accessibility issue types in report order
+var A11yIssueTypes = []string{
+	A11yIssueMissingAlt,
+	A11yIssueEmptyLink,
+	A11yIssueEmptyButton,
+	A11yIssueMissingLang,
+	A11yIssueHeadingOrder,
+}
+
+// a11yIssueLabels are the column headings used for each issue type in the HTML report
+var a11yIssueLabels = map[string]string{
+	A11yIssueMissingAlt:   "Images without alt",
+	A11yIssueEmptyLink:    "Empty links",
+	A11yIssueEmptyButton:  "Empty buttons",
+	A11yIssueMissingLang:  "Missing lang",
+	A11yIssueHeadingOrder: "Skipped headings",
+}
+
+// MaxA11yExamples caps the offending snippets kept per page
+const MaxA11yExamples = 5
+
+// A11yPage holds the accessibility issue counts of one saved page
+type A11yPage struct {
+	URL      string         `json:"url"`
+	Issues   int            `json:"issues"`
+	Counts   map[string]int `json:"counts"`
+	Examples []string       `json:"examples,omitempty"` // Up to MaxA11yExamples offending snippets
+}
+
+// AccessibilityReport aggregates accessibility issues across a crawl
+type AccessibilityReport struct {
+	GeneratedAt   time.Time      `json:"generated_at"`
+	Pages         int            `json:"pages"`
+	PagesAffected int            `json:"pages_affected"`
+	TotalIssues   int            `json:"total_issues"`
+	IssueCounts   map[string]int `json:"issue_counts"`
+	PageDetails   []A11yPage     `json:"page_details"` // Pages with the most issues first
+	HTMLPath      string         `json:"html_path,omitempty"`
+	JSONPath      string         `json:"json_path,omitempty"`
+}
+
+// AuditAccessibility checks the saved pages of a crawl for basic
+// accessibility issues: images without alt text, links and buttons without
+// an accessible name, a missing lang attribute and skipped heading levels
+func AuditAccessibility(outputDir string) (*AccessibilityReport, error) {
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+
+	report := &AccessibilityReport{
+		GeneratedAt: time.Now(),
+		IssueCounts: make(map[string]int),
+		PageDetails: []A11yPage{},
+	}
+	err := forEachSavedPage(outputDir, func(pageURL string, doc *goquery.Document) {
+		page := auditA11yPage(pageURL, doc)
+		report.Pages++
+		report.TotalIssues += page.Issues
+		for t, n := range page.Counts {
+			report.IssueCounts[t] += n
+		}
+		if page.Issues > 0 {
+			report.PagesAffected++
+		}
+		report.PageDetails = append(report.PageDetails, page)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if report.Pages == 0 {
+		return nil, fmt.Errorf("no saved pages found in %s", outputDir)
+	}
+
+	sort.Slice(report.PageDetails, func(i, j int) bool {
+		a, b := report.PageDetails[i], report.PageDetails[j]
+		if a.Issues != b.Issues {
+			return a.Issues > b.Issues
+		}
+		return a.URL < b.URL
+	})
+	return report, nil
+}
+
+// GenerateAccessibilityReport audits a crawl and writes
+// accessibility_report.html and accessibility_report.json to its output directory
+func GenerateAccessibilityReport(outputDir string) (*AccessibilityReport, error) {
+	report, err := AuditAccessibility(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	report.HTMLPath = filepath.Join(outputDir, AccessibilityReportHTML)
+	report.JSONPath = filepath.Join(outputDir, AccessibilityReportJSON)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal accessibility report: %v", err)
+	}
+	if err := os.WriteFile(report.JSONPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write accessibility report: %v", err)
+	}
+
+	html, err := renderAccessibilityReport(report, filepath.Base(outputDir))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(report.HTMLPath, html, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write accessibility report: %v", err)
+	}
+	return report, nil
+}
+
+// auditA11yPage counts the accessibility issues of one page
+func auditA11yPage(pageURL string, doc *goquery.Document) A11yPage {
+	page := A11yPage{URL: pageURL, Counts: make(map[string]int)}
+	add := func(issueType, example string) {
+		page.Counts[issueType]++
+		page.Issues++
+		if example != "" && len(page.Examples) < MaxA11yExamples {
+			page.Examples = append(page.Examples, issueType+": "+example)
+		}
+	}
+
+	if strings.TrimSpace(doc.Find("html").AttrOr("lang", "")) == "" {
+		add(A11yIssueMissingLang, "")
+	}
+
+	// alt="" is valid for decorative images, so only a missing attribute counts
+	doc.Find("img").Each(func(_ int, s *goquery.Selection) {
+		if _, ok := s.Attr("alt"); !ok && !a11yHidden(s) {
+			add(A11yIssueMissingAlt, a11ySnippet(s))
+		}
+	})
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		if !a11yHidden(s) && a11yAccessibleName(s) == "" {
+			add(A11yIssueEmptyLink, a11ySnippet(s))
+		}
+	})
+
+	doc.Find("button, input[type=button], input[type=submit], input[type=reset]").Each(func(_ int, s *goquery.Selection) {
+		if a11yHidden(s) {
+			return
+		}
+		name := a11yAccessibleName(s)
+		if goquery.NodeName(s) == "input" {
+			name += strings.TrimSpace(s.AttrOr("value", ""))
+			// Submit and reset inputs get a default label from the browser
+			if t := strings.ToLower(s.AttrOr("type", "")); t == "submit" || t == "reset" {
+				return
+			}
+		}
+		if name == "" {
+			add(A11yIssueEmptyButton, a11ySnippet(s))
+		}
+	})
+
+	_, skips := headingStructure(doc)
+	for _, skip := range skips {
+		add(A11yIssueHeadingOrder, skip)
+	}
+	return page
+}
+
+// a11yAccessibleName approximates the accessible name of an element from
+// its text, ARIA labelling attributes, title and the alt text of images inside it
+func a11yAccessibleName(s *goquery.Selection) string {
+	for _, attr := range []string{"aria-label", "aria-labelledby", "title"} {
+		if v := strings.TrimSpace(s.AttrOr(attr, "")); v != "" {
+			return v
+		}
+	}
+	if text := collapseSpace(s.Text()); text != "" {
+		return text
+	}
+	name := ""
+	s.Find("img[alt], svg title").EachWithBreak(func(_ int, img *goquery.Selection) bool {
+		name = strings.TrimSpace(img.AttrOr("alt", img.Text()))
+		return name == ""
+	})
+	return name
+}
+
+// a11yHidden reports whether an element is hidden from assistive technology
+func a11yHidden(s *goquery.Selection) bool {
+	return s.AttrOr("aria-hidden", "") == "true" || s.Is("[hidden]") || s.Closest("[aria-hidden=true]").Length() > 0
+}
+
+// a11ySnippet returns a short HTML excerpt identifying an element
+func a11ySnippet(s *goquery.Selection) string {
+	html, err := goquery.OuterHtml(s)
+	if err != nil {
+		return goquery.NodeName(s)
+	}
+	html = collapseSpace(html)
+	if len(html) > 120 {
+		html = html[:117] + "..."
+	}
+	return html
+}
+
+// renderAccessibilityReport renders the report as a standalone HTML page
+func renderAccessibilityReport(report *AccessibilityReport, title string) ([]byte, error) {
+	tmpl, err := template.New("a11y").Parse(accessibilityReportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	type column struct {
+		Type  string
+		Label string
+		Count int
+	}
+	columns := make([]column, len(A11yIssueTypes))
+	for i, t := range A11yIssueTypes {
+		columns[i] = column{Type: t, Label: a11yIssueLabels[t], Count: report.IssueCounts[t]}
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Title   string
+		Report  *AccessibilityReport
+		Columns []column
+	}{title, report, columns})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render accessibility report: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const accessibilityReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Accessibility Report - {{.Title}}</title>
+    <style>
+        :root { --bg: #ffffff; --bg-alt: #f8f9fa; --text: #212529; --muted: #6c757d; --border: #dee2e6; --accent: #0d6efd; --bad: #dc3545; --good: #198754; }
+        @media (prefers-color-scheme: dark) {
+            :root { --bg: #1a1a2e; --bg-alt: #16213e; --text: #f8f9fa; --muted: #9ca3af; --border: #374151; --accent: #60a5fa; --bad: #f87171; --good: #34d399; }
+        }
+        body { margin: 0 auto; max-width: 80rem; padding: 2rem; background: var(--bg); color: var(--text); font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; line-height: 1.5; }
+        .muted { color: var(--muted); font-size: 0.9rem; }
+        .summary { display: grid; grid-template-columns: repeat(auto-fill, minmax(11rem, 1fr)); gap: 0.75rem; margin: 1.5rem 0; }
+        .summary div { padding: 0.75rem; border: 1px solid var(--border); border-radius: 6px; background: var(--bg-alt); }
+        .summary strong { display: block; font-size: 1.5rem; }
+        .summary .bad strong { color: var(--bad); }
+        .summary .good strong { color: var(--good); }
+        table { width: 100%; border-collapse: collapse; font-size: 0.9rem; }
+        th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid var(--border); vertical-align: top; }
+        td.url { word-break: break-all; }
+        td.num { text-align: right; font-family: monospace; }
+        th { background: var(--bg-alt); }
+        details { font-size: 0.8rem; color: var(--muted); }
+        code { word-break: break-all; }
+        a { color: var(--accent); }
+    </style>
+</head>
+<body>
+    <h1>Accessibility Report: {{.Title}}</h1>
+    <p class="muted">{{.Report.Pages}} pages audited &middot; {{.Report.PagesAffected}} with issues &middot; {{.Report.TotalIssues}} issues &middot; generated {{.Report.GeneratedAt.Format "Jan 2, 2006 15:04"}}</p>
+
+    <div class="summary">
+        {{- range .Columns}}
+        <div class="{{if .Count}}bad{{else}}good{{end}}"><strong>{{.Count}}</strong>{{.Label}}</div>
+        {{- end}}
+    </div>
+
+    <table>
+        <tr><th>Page</th>{{range .Columns}}<th>{{.Label}}</th>{{end}}<th>Total</th></tr>
+        {{- $columns := .Columns}}
+        {{- range .Report.PageDetails}}
+        {{- if .Issues}}
+        {{- $counts := .Counts}}
+        <tr>
+            <td class="url"><a href="{{.URL}}">{{.URL}}</a>
+                {{- if .Examples}}<details><summary>Examples</summary>{{range .Examples}}<div><code>{{.}}</code></div>{{end}}</details>{{end}}
+            </td>
+            {{- range $columns}}<td class="num">{{index $counts .Type}}</td>{{end}}
+            <td class="num">{{.Issues}}</td>
+        </tr>
+        {{- end}}
+        {{- end}}
+    </table>
+</body>
+</html>
+`
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestAuditA11yPage(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want map[string]int
+	}{
+		{
+			name: "accessible page",
+			html: `<html lang="en"><body><h1>T</h1><h2>S</h2>
+				<img src="a.png" alt="Logo"><img src="spacer.gif" alt="">
+				<a href="/a">Docs</a><a href="/b" aria-label="Home"><svg></svg></a><a href="/c"><img src="c.png" alt="Contact"></a>
+				<button>Go</button><input type="submit"><input type="button" value="Send">
+				<div aria-hidden="true"><img src="deco.png"><a href="/x"></a></div></body></html>`,
+			want: map[string]int{},
+		},
+		{
+			name: "inaccessible page",
+			html: `<html><body><h1>T</h1><h3>Skip</h3><h2>Ok</h2><h4>Skip again</h4>
+				<img src="a.png"><img src="b.png">
+				<a href="/a"></a><a href="/b"><img src="icon.png"></a>
+				<button><span class="icon"></span></button><input type="button"></body></html>`,
+			want: map[string]int{
+				A11yIssueMissingLang:  1,
+				A11yIssueMissingAlt:   3,
+				A11yIssueEmptyLink:    2,
+				A11yIssueEmptyButton:  2,
+				A11yIssueHeadingOrder: 2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			page := auditA11yPage("https://example.com/", doc)
+			total := 0
+			for _, issueType := range A11yIssueTypes {
+				if page.Counts[issueType] != tt.want[issueType] {
+					t.Errorf("%s = %d, want %d", issueType, page.Counts[issueType], tt.want[issueType])
+				}
+				total += tt.want[issueType]
+			}
+			if page.Issues != total {
+				t.Errorf("Issues = %d, want %d", page.Issues, total)
+			}
+			if len(page.Examples) > MaxA11yExamples {
+				t.Errorf("%d examples kept, want at most %d", len(page.Examples), MaxA11yExamples)
+			}
+		})
+	}
+}
+
+func TestGenerateAccessibilityReport(t *testing.T) {
+	dir := t.TempDir()
+	writeSEOFixture(t, dir, "good", "https://example.com/good", `<html lang="en"><body><h1>Fine</h1></body></html>`)
+	writeSEOFixture(t, dir, "bad", "https://example.com/bad", `<html><body><img src="x.png"><a href="/"></a></body></html>`)
+
+	report, err := GenerateAccessibilityReport(dir)
+	if err != nil {
+		t.Fatalf("GenerateAccessibilityReport() error = %v", err)
+	}
+	if report.Pages != 2 || report.PagesAffected != 1 || report.TotalIssues != 3 {
+		t.Errorf("pages = %d, affected = %d, issues = %d", report.Pages, report.PagesAffected, report.TotalIssues)
+	}
+	if report.PageDetails[0].URL != "https://example.com/bad" {
+		t.Errorf("page with most issues should come first, got %s", report.PageDetails[0].URL)
+	}
+
+	html, err := os.ReadFile(filepath.Join(dir, AccessibilityReportHTML))
+	if err != nil {
+		t.Fatalf("HTML report not written: %v", err)
+	}
+	if !strings.Contains(string(html), "https://example.com/bad") || strings.Contains(string(html), `href="https://example.com/good"`) {
+		t.Error("HTML report should list only pages with issues")
+	}
+	if _, err := os.Stat(filepath.Join(dir, AccessibilityReportJSON)); err != nil {
+		t.Errorf("JSON report not written: %v", err)
+	}
+
+	if _, err := AuditAccessibility(t.TempDir()); err == nil {
+		t.Error("AuditAccessibility() on an empty directory should fail")
+	}
+}
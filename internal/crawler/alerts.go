@@ -0,0 +1,264 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// AlertsFile records the alert rule matches of saved pages
+const AlertsFile = "alerts.jsonl"
+
+// What an alert rule is matched against
+const (
+	AlertTargetText = "text" // Plain text of the extracted content, or of the page without it
+	AlertTargetHTML = "html" // Stored HTML, including markup, scripts and attributes
+)
+
+const (
+	alertMaxMatches = 10 // Matches recorded per alert
+	alertContext    = 40 // Characters of context on each side of a match
+)
+
+// AlertRule watches saved pages for a regular expression or any of a list
+// of keywords, e.g. leaked credentials, brand mentions or error strings.
+// A rule with both fires when either matches.
+type AlertRule struct {
+	Name     string   `json:"name,omitempty"`     // Shown in alerts (default "rule N")
+	Pattern  string   `json:"pattern,omitempty"`  // Regular expression
+	Keywords []string `json:"keywords,omitempty"` // Matched literally, ignoring case
+	Target   string   `json:"target,omitempty"`   // "text" (default) or "html"
+}
+
+// compiledAlertRule is an AlertRule with its pattern and keywords compiled
+// into one expression
+type compiledAlertRule struct {
+	AlertRule
+	re *regexp.Regexp
+}
+
+// compileAlertRules compiles rules, naming the unnamed ones after their
+// position
+func compileAlertRules(rules []AlertRule) ([]compiledAlertRule, error) {
+	compiled := make([]compiledAlertRule, 0, len(rules))
+	for i, r := range rules {
+		var parts []string
+		if r.Pattern != "" {
+			if _, err := regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("alert rule %d has an invalid pattern: %v", i+1, err)
+			}
+			parts = append(parts, "(?:"+r.Pattern+")")
+		}
+		var keywords []string
+		for _, k := range r.Keywords {
+			if k = strings.TrimSpace(k); k != "" {
+				keywords = append(keywords, regexp.QuoteMeta(k))
+			}
+		}
+		if len(keywords) > 0 {
+			parts = append(parts, "(?i:"+strings.Join(keywords, "|")+")")
+		}
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("alert rule %d has neither pattern nor keywords", i+1)
+		}
+		switch r.Target {
+		case "", AlertTargetText, AlertTargetHTML:
+		default:
+			return nil, fmt.Errorf("alert rule %d has an invalid target %q (want text or html)", i+1, r.Target)
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		compiled = append(compiled, compiledAlertRule{AlertRule: r, re: regexp.MustCompile(strings.Join(parts, "|"))})
+	}
+	return compiled, nil
+}
+
+// ParseAlertRules reads alert rules given inline as a JSON array or as the
+// path of a JSON file holding one
+func ParseAlertRules(value string) ([]AlertRule, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	var rules []AlertRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("alert rules must be a JSON array of {\"name\", \"pattern\", \"keywords\", \"target\"} objects: %v", err)
+	}
+	if _, err := compileAlertRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Alert is a saved page matching an alert rule, written to alerts.jsonl and
+// sent as a content_alert event
+type Alert struct {
+	Time    time.Time    `json:"time"`
+	URL     string       `json:"url"`
+	Rule    string       `json:"rule"`
+	Count   int          `json:"count"`   // Matches in the page
+	Matches []AlertMatch `json:"matches"` // The first ones
+	JobID   string       `json:"jobId,omitempty"`
+}
+
+// AlertMatch is a matching piece of a page with the text around it
+type AlertMatch struct {
+	Text    string `json:"text"`
+	Context string `json:"context"`
+}
+
+// matchAlert returns the alert of rule for content, or nil when it doesn't
+// match
+func matchAlert(rule compiledAlertRule, content string) *Alert {
+	locs := rule.re.FindAllStringIndex(content, -1)
+	if len(locs) == 0 {
+		return nil
+	}
+	alert := &Alert{Rule: rule.Name, Count: len(locs)}
+	for _, loc := range locs {
+		if len(alert.Matches) == alertMaxMatches {
+			break
+		}
+		start, end := loc[0]-alertContext, loc[1]+alertContext
+		if start < 0 {
+			start = 0
+		}
+		if end > len(content) {
+			end = len(content)
+		}
+		for start > 0 && !utf8.RuneStart(content[start]) {
+			start--
+		}
+		for end < len(content) && !utf8.RuneStart(content[end]) {
+			end++
+		}
+		alert.Matches = append(alert.Matches, AlertMatch{
+			Text:    content[loc[0]:loc[1]],
+			Context: collapseSpace(content[start:end]),
+		})
+	}
+	return alert
+}
+
+// alertLog appends alerts to alerts.jsonl, a line at a time so the file can
+// be followed while the crawl runs
+type alertLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openAlertLog creates alerts.jsonl, or appends to it when a crawl is
+// resumed
+func openAlertLog(outputDir string, resume bool, perms outputPerms) (*alertLog, error) {
+	path := filepath.Join(outputDir, AlertsFile)
+	var f *os.File
+	var err error
+	if resume {
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			err = perms.applyFile(path)
+		}
+	} else {
+		f, err = perms.create(path)
+	}
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, fmt.Errorf("failed to open %s: %v", AlertsFile, err)
+	}
+	return &alertLog{file: f}, nil
+}
+
+func (l *alertLog) write(alert Alert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.file.Write(append(data, '\n'))
+	return err
+}
+
+func (l *alertLog) Close() error {
+	return l.file.Close()
+}
+
+// checkAlerts matches a saved page against the alert rules, recording and
+// announcing each rule that matches. text is only called when a rule
+// matches text.
+func (c *Crawler) checkAlerts(rawURL string, text func() string, html []byte) {
+	for _, rule := range c.alertRules {
+		content := ""
+		if rule.Target == AlertTargetHTML {
+			content = string(html)
+		} else {
+			content = text()
+		}
+		alert := matchAlert(rule, content)
+		if alert == nil {
+			continue
+		}
+		alert.Time = time.Now()
+		alert.URL = rawURL
+		alert.JobID = c.config.JobID
+		c.log.Warn("Alert %q: %d matches on %s", alert.Rule, alert.Count, rawURL)
+		if c.alerts != nil {
+			if err := c.alerts.write(*alert); err != nil {
+				c.log.Warn("Failed to write %s: %v", AlertsFile, err)
+			}
+		}
+		if c.emitter != nil {
+			c.emitter.Emit(CrawlerEvent{Type: EventContentAlert, Timestamp: alert.Time, Data: *alert})
+		}
+	}
+}
+
+// LoadAlerts reads the alerts.jsonl of a crawl
+func LoadAlerts(outputDir string) ([]Alert, error) {
+	f, err := os.Open(filepath.Join(outputDir, AlertsFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	alerts := []Alert{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var alert Alert
+		if err := json.Unmarshal(scanner.Bytes(), &alert); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", AlertsFile, err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, scanner.Err()
+}
+
+// FilterAlerts returns the alerts of the named rule ("" keeps all)
+func FilterAlerts(alerts []Alert, rule string) []Alert {
+	if rule == "" {
+		return alerts
+	}
+	out := []Alert{}
+	for _, alert := range alerts {
+		if alert.Rule == rule {
+			out = append(out, alert)
+		}
+	}
+	return out
+}
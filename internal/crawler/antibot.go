@@ -0,0 +1,77 @@
+package crawler
+
+// Anti-bot profiles for AntiBotConfig.Profile, each a coherent set of
+// fingerprint and behavior options. Options set individually are added to
+// the profile's.
+const (
+	AntiBotProfileOff        = "off"        // No options besides those set individually (default)
+	AntiBotProfileLow        = "low"        // Hide the automation markers, at no cost in speed
+	AntiBotProfileStandard   = "standard"   // Low plus a consistent GPU and screen, and human-paced actions
+	AntiBotProfileAggressive = "aggressive" // Standard plus canvas noise, human input and user agent rotation
+)
+
+// antiBotProfiles holds the options each profile turns on
+var antiBotProfiles = map[string]AntiBotConfig{
+	AntiBotProfileOff: {},
+	AntiBotProfileLow: {
+		HideWebdriver:  true,
+		SpoofPlugins:   true,
+		SpoofLanguages: true,
+	},
+	AntiBotProfileStandard: {
+		HideWebdriver:      true,
+		SpoofPlugins:       true,
+		SpoofLanguages:     true,
+		SpoofWebGL:         true,
+		NaturalScrolling:   true,
+		RandomActionDelays: true,
+		RandomViewport:     true,
+	},
+	AntiBotProfileAggressive: {
+		HideWebdriver:        true,
+		SpoofPlugins:         true,
+		SpoofLanguages:       true,
+		SpoofWebGL:           true,
+		AddCanvasNoise:       true,
+		NaturalMouseMovement: true,
+		RandomTypingDelays:   true,
+		NaturalScrolling:     true,
+		RandomActionDelays:   true,
+		RandomClickOffset:    true,
+		RotateUserAgent:      true,
+		RandomViewport:       true,
+	},
+}
+
+// AntiBotProfiles returns the profile names, from least to most evasive
+func AntiBotProfiles() []string {
+	return []string{AntiBotProfileOff, AntiBotProfileLow, AntiBotProfileStandard, AntiBotProfileAggressive}
+}
+
+// ValidAntiBotProfile reports whether profile names a profile ("" is off)
+func ValidAntiBotProfile(profile string) bool {
+	if profile == "" {
+		return true
+	}
+	_, ok := antiBotProfiles[profile]
+	return ok
+}
+
+// Effective returns the options in effect: those of the profile plus the
+// ones set individually
+func (a AntiBotConfig) Effective() AntiBotConfig {
+	p := antiBotProfiles[a.Profile]
+	a.HideWebdriver = a.HideWebdriver || p.HideWebdriver
+	a.SpoofPlugins = a.SpoofPlugins || p.SpoofPlugins
+	a.SpoofLanguages = a.SpoofLanguages || p.SpoofLanguages
+	a.SpoofWebGL = a.SpoofWebGL || p.SpoofWebGL
+	a.AddCanvasNoise = a.AddCanvasNoise || p.AddCanvasNoise
+	a.NaturalMouseMovement = a.NaturalMouseMovement || p.NaturalMouseMovement
+	a.RandomTypingDelays = a.RandomTypingDelays || p.RandomTypingDelays
+	a.NaturalScrolling = a.NaturalScrolling || p.NaturalScrolling
+	a.RandomActionDelays = a.RandomActionDelays || p.RandomActionDelays
+	a.RandomClickOffset = a.RandomClickOffset || p.RandomClickOffset
+	a.RotateUserAgent = a.RotateUserAgent || p.RotateUserAgent
+	a.RandomViewport = a.RandomViewport || p.RandomViewport
+	return a
+}
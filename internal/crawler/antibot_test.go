@@ -284,3 +284,40 @@ func containsString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestAntiBotProfiles(t *testing.T) {
+	if got := (AntiBotConfig{}).Effective(); got != (AntiBotConfig{}) {
+		t.Errorf("no profile turned on %+v", got)
+	}
+
+	low := AntiBotConfig{Profile: AntiBotProfileLow}.Effective()
+	if !low.HideWebdriver || low.RandomActionDelays {
+		t.Errorf("low profile = %+v", low)
+	}
+
+	// Individual options add to the profile
+	custom := AntiBotConfig{Profile: AntiBotProfileLow, RandomActionDelays: true, Timezone: "Europe/Berlin"}.Effective()
+	if !custom.HideWebdriver || !custom.RandomActionDelays || custom.Timezone != "Europe/Berlin" {
+		t.Errorf("low profile with overrides = %+v", custom)
+	}
+
+	// Each profile includes the options of the one below it
+	names := AntiBotProfiles()
+	for i := 1; i < len(names); i++ {
+		lower := AntiBotConfig{Profile: names[i-1]}.Effective()
+		lower.Profile = names[i]
+		if lower.Effective() != (AntiBotConfig{Profile: names[i]}).Effective() {
+			t.Errorf("profile %s lacks options of %s", names[i], names[i-1])
+		}
+	}
+
+	for _, profile := range append(names, "") {
+		if !ValidAntiBotProfile(profile) {
+			t.Errorf("ValidAntiBotProfile(%q) = false", profile)
+		}
+	}
+	config := Config{URL: "https://example.com", MaxDepth: 1, AntiBot: AntiBotConfig{Profile: "stealthy"}}
+	if err := ValidateConfig(&config); err == nil {
+		t.Error("accepted an unknown profile")
+	}
+}
@@ -0,0 +1,237 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Signals reported by RunAntiBotTest, in report order
+const (
+	AntiBotSignalWebdriver = "webdriver"  // navigator.webdriver
+	AntiBotSignalUserAgent = "user-agent" // navigator.userAgent
+	AntiBotSignalPlugins   = "plugins"    // navigator.plugins.length
+	AntiBotSignalLanguages = "languages"  // navigator.languages
+	AntiBotSignalWebGL     = "webgl"      // Unmasked WebGL vendor and renderer
+	AntiBotSignalChrome    = "chrome"     // window.chrome, missing in headless Chrome
+	AntiBotSignalWindow    = "window"     // Outer window size, 0x0 in headless Chrome
+)
+
+// antiBotProbeID is the id of the element the probe writes its findings to
+const antiBotProbeID = "scraper-antibot-probe"
+
+// antiBotProbeScript reads the fingerprint signals of the page it runs on
+// and appends them to the document as JSON, so they come back with the HTML
+var antiBotProbeScript = `
+const probe = {
+  webdriver: String(navigator.webdriver),
+  userAgent: navigator.userAgent,
+  plugins: navigator.plugins ? navigator.plugins.length : 0,
+  languages: (navigator.languages || []).join(','),
+  chrome: typeof window.chrome !== 'undefined',
+  outerWidth: window.outerWidth,
+  outerHeight: window.outerHeight,
+  webglVendor: '',
+  webglRenderer: '',
+};
+try {
+  const gl = document.createElement('canvas').getContext('webgl');
+  const info = gl && gl.getExtension('WEBGL_debug_renderer_info');
+  if (info) {
+    probe.webglVendor = String(gl.getParameter(info.UNMASKED_VENDOR_WEBGL));
+    probe.webglRenderer = String(gl.getParameter(info.UNMASKED_RENDERER_WEBGL));
+  }
+} catch (e) {}
+const out = document.createElement('script');
+out.type = 'application/json';
+out.id = '` + antiBotProbeID + `';
+out.textContent = JSON.stringify(probe);
+document.body.appendChild(out);
+`
+
+// antiBotTestPage is the bundled page probed when no URL is given
+const antiBotTestPage = `<!DOCTYPE html>
+<html lang="en"><head><title>Anti-bot self-test</title></head>
+<body><p>This page is probed for the fingerprint signals of the browser that loads it.</p></body></html>`
+
+// automatedUserAgent matches user agents that announce automation
+var automatedUserAgent = regexp.MustCompile(`(?i)headless|bot\b|crawler|spider|scraper`)
+
+// emulatedRenderer matches the software WebGL renderers of headless Chrome
+var emulatedRenderer = regexp.MustCompile(`(?i)swiftshader|llvmpipe|software`)
+
+// AntiBotTestOptions configure RunAntiBotTest
+type AntiBotTestOptions struct {
+	URL       string        // Page to probe ("" = a bundled page served on a local port)
+	AntiBot   AntiBotConfig // Profile and options under test
+	Headless  bool          // Run the browser without a window
+	UserAgent string        // User agent when not rotating (default DefaultUserAgent)
+}
+
+// Validate checks the profile and the URL to probe
+func (o AntiBotTestOptions) Validate() error {
+	if !ValidAntiBotProfile(o.AntiBot.Profile) {
+		return fmt.Errorf("invalid anti-bot profile %q (valid: %s)", o.AntiBot.Profile, strings.Join(AntiBotProfiles(), ", "))
+	}
+	if o.URL != "" {
+		u, err := url.Parse(o.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("URL to probe must be an http or https URL, got: %s", o.URL)
+		}
+	}
+	return nil
+}
+
+// AntiBotSignal is one fingerprint signal as the probed page saw it
+type AntiBotSignal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Leaks bool   `json:"leaks"`         // Whether the value gives away an automated browser
+	Fix   string `json:"fix,omitempty"` // Setting that hides it, when it leaks
+}
+
+// AntiBotTestResult is the report of RunAntiBotTest
+type AntiBotTestResult struct {
+	URL      string          `json:"url,omitempty"` // Empty for the bundled page
+	Profile  string          `json:"profile"`
+	Headless bool            `json:"headless"`
+	Signals  []AntiBotSignal `json:"signals"`
+	Leaks    int             `json:"leaks"` // Signals that leak
+}
+
+// antiBotProbe is what antiBotProbeScript reports
+type antiBotProbe struct {
+	Webdriver     string `json:"webdriver"`
+	UserAgent     string `json:"userAgent"`
+	Plugins       int    `json:"plugins"`
+	Languages     string `json:"languages"`
+	Chrome        bool   `json:"chrome"`
+	OuterWidth    int    `json:"outerWidth"`
+	OuterHeight   int    `json:"outerHeight"`
+	WebGLVendor   string `json:"webglVendor"`
+	WebGLRenderer string `json:"webglRenderer"`
+}
+
+// RunAntiBotTest opens a page in browser mode with the anti-bot settings of
+// opts and reports which fingerprint signals give the browser away, to tune
+// the settings before a real crawl
+func RunAntiBotTest(ctx context.Context, opts AntiBotTestOptions) (*AntiBotTestResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	target := opts.URL
+	if target == "" {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, antiBotTestPage)
+		}))
+		defer server.Close()
+		target = server.URL + "/"
+	}
+
+	antiBot := opts.AntiBot.Effective()
+	userAgent := opts.UserAgent
+	if pool := newUserAgentPool(Config{AntiBot: antiBot}); pool != nil {
+		userAgent = pool.pick(target)
+	}
+	fetcher, err := NewBrowserFetcherWithAntiBot(opts.Headless, userAgent, antiBot)
+	if err != nil {
+		return nil, err
+	}
+	defer fetcher.Close()
+	if err := fetcher.SetPageScripts([]PageScript{{Script: antiBotProbeScript}}); err != nil {
+		return nil, err
+	}
+
+	type fetched struct {
+		result *FetchResult
+		err    error
+	}
+	done := make(chan fetched, 1)
+	go func() {
+		result, err := fetcher.Fetch(target, userAgent)
+		done <- fetched{result, err}
+	}()
+	var f fetched
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case f = <-done:
+	}
+	if f.err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", target, f.err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(f.result.Body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", target, err)
+	}
+	var probe antiBotProbe
+	if err := json.Unmarshal([]byte(doc.Find("#"+antiBotProbeID).Text()), &probe); err != nil {
+		if len(f.result.PageScriptErrors) > 0 {
+			return nil, fmt.Errorf("probe failed on %s: %s", target, f.result.PageScriptErrors[0])
+		}
+		return nil, fmt.Errorf("probe found no results on %s: %v", target, err)
+	}
+
+	profile := opts.AntiBot.Profile
+	if profile == "" {
+		profile = AntiBotProfileOff
+	}
+	result := &AntiBotTestResult{
+		URL:      opts.URL,
+		Profile:  profile,
+		Headless: opts.Headless,
+		Signals:  evaluateAntiBotProbe(probe),
+	}
+	for _, s := range result.Signals {
+		if s.Leaks {
+			result.Leaks++
+		}
+	}
+	return result, nil
+}
+
+// evaluateAntiBotProbe turns the probe's findings into signals, naming the
+// setting that hides each one that leaks
+func evaluateAntiBotProbe(p antiBotProbe) []AntiBotSignal {
+	webgl := strings.TrimSpace(p.WebGLVendor + " " + p.WebGLRenderer)
+	if webgl == "" {
+		webgl = "unavailable"
+	}
+	languages := p.Languages
+	if languages == "" {
+		languages = "none"
+	}
+	signal := func(name, value string, leaks bool, fix string) AntiBotSignal {
+		s := AntiBotSignal{Name: name, Value: value, Leaks: leaks}
+		if leaks {
+			s.Fix = fix
+		}
+		return s
+	}
+	return []AntiBotSignal{
+		signal(AntiBotSignalWebdriver, p.Webdriver, p.Webdriver == "true",
+			"hideWebdriver (-hide-webdriver) or the low profile"),
+		signal(AntiBotSignalUserAgent, p.UserAgent, automatedUserAgent.MatchString(p.UserAgent),
+			"a browser userAgent (-user-agent) or rotateUserAgent (-rotate-ua)"),
+		signal(AntiBotSignalPlugins, strconv.Itoa(p.Plugins), p.Plugins == 0,
+			"spoofPlugins (-spoof-plugins) or the low profile"),
+		signal(AntiBotSignalLanguages, languages, p.Languages == "",
+			"spoofLanguages (-spoof-languages) or the low profile"),
+		signal(AntiBotSignalWebGL, webgl, p.WebGLRenderer == "" || emulatedRenderer.MatchString(webgl),
+			"spoofWebGL (-spoof-webgl) or the standard profile"),
+		signal(AntiBotSignalChrome, strconv.FormatBool(p.Chrome), !p.Chrome,
+			"run with a window (headless off)"),
+		signal(AntiBotSignalWindow, fmt.Sprintf("%dx%d", p.OuterWidth, p.OuterHeight), p.OuterWidth == 0 || p.OuterHeight == 0,
+			"run with a window (headless off)"),
+	}
+}
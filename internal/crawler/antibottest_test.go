@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEvaluateAntiBotProbe(t *testing.T) {
+	headless := antiBotProbe{
+		Webdriver:     "true",
+		UserAgent:     "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/131.0.0.0 Safari/537.36",
+		WebGLVendor:   "Google Inc. (Google)",
+		WebGLRenderer: "ANGLE (Google, Vulkan 1.3.0 (SwiftShader Device (Subzero)), SwiftShader driver)",
+	}
+	leaks := make(map[string]AntiBotSignal)
+	for _, s := range evaluateAntiBotProbe(headless) {
+		if s.Leaks {
+			leaks[s.Name] = s
+		}
+	}
+	for _, name := range []string{AntiBotSignalWebdriver, AntiBotSignalUserAgent, AntiBotSignalPlugins, AntiBotSignalLanguages, AntiBotSignalWebGL, AntiBotSignalChrome, AntiBotSignalWindow} {
+		if s, ok := leaks[name]; !ok || s.Fix == "" {
+			t.Errorf("headless probe: signal %s not reported as leaking with a fix: %+v", name, s)
+		}
+	}
+
+	spoofed := antiBotProbe{
+		Webdriver:     "false",
+		UserAgent:     chromeUserAgents[0],
+		Plugins:       3,
+		Languages:     "en-US,en",
+		Chrome:        true,
+		OuterWidth:    1920,
+		OuterHeight:   1080,
+		WebGLVendor:   "Intel Inc.",
+		WebGLRenderer: "Intel Iris OpenGL Engine",
+	}
+	for _, s := range evaluateAntiBotProbe(spoofed) {
+		if s.Leaks || s.Fix != "" {
+			t.Errorf("spoofed probe: signal %s leaks: %+v", s.Name, s)
+		}
+	}
+}
+
+func TestRunAntiBotTestInvalidProfile(t *testing.T) {
+	_, err := RunAntiBotTest(context.Background(), AntiBotTestOptions{AntiBot: AntiBotConfig{Profile: "paranoid"}})
+	if err == nil {
+		t.Error("accepted an unknown profile")
+	}
+}
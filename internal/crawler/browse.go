@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BrowseIndexFile is served instead of a listing for directories that have one
+const BrowseIndexFile = "_index.html"
+
+// OutputEntry is a file or directory in an output directory listing
+type OutputEntry struct {
+	Name string `json:"name"`
+	Dir  bool   `json:"dir"`
+	Size int64  `json:"size"` // 0 for directories
+	Href string `json:"-"`    // Link relative to the listed directory
+}
+
+// OutputBrowser serves an output directory over HTTP for clicking through
+// results: directories show their _index.html when present, otherwise a
+// listing, and compressed files are decompressed on the fly
+type OutputBrowser struct {
+	dir string
+	// OnError writes error responses (default: plain text via http.Error)
+	OnError func(w http.ResponseWriter, status int, message string)
+}
+
+// NewOutputBrowser creates a browser for the output directory
+func NewOutputBrowser(dir string) *OutputBrowser {
+	return &OutputBrowser{
+		dir: dir,
+		OnError: func(w http.ResponseWriter, status int, message string) {
+			http.Error(w, message, status)
+		},
+	}
+}
+
+// resolveOutputPath resolves a slash-separated path inside dir, rejecting
+// absolute paths and anything escaping it
+func resolveOutputPath(dir, relPath string) (string, bool) {
+	cleaned := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(relPath, "/")))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(dir, cleaned), true
+}
+
+// ListOutputDir lists a directory inside an output directory, directories
+// first, each group sorted by name
+func ListOutputDir(dir, relPath string) ([]OutputEntry, error) {
+	full, ok := resolveOutputPath(dir, relPath)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: relPath, Err: os.ErrInvalid}
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, err
+	}
+	listing := make([]OutputEntry, 0, len(entries))
+	for _, dirs := range []bool{true, false} {
+		for _, e := range entries {
+			if e.IsDir() != dirs {
+				continue
+			}
+			entry := OutputEntry{Name: e.Name(), Dir: e.IsDir()}
+			// Pointers of content-addressable crawls are listed as the file
+			// they stand in for, which serveFile resolves to the blob
+			var blob string
+			if name, ok := strings.CutSuffix(e.Name(), BlobPointerExt); ok && !e.IsDir() {
+				if resolved, err := readBlobPointer(filepath.Join(full, e.Name())); err == nil {
+					entry.Name, blob = name, resolved
+				}
+			}
+			// The "./" prefix keeps names containing a colon from reading as a scheme
+			entry.Href = "./" + (&url.URL{Path: entry.Name}).EscapedPath()
+			if entry.Dir {
+				entry.Href += "/"
+			} else if blob != "" {
+				if info, err := os.Stat(blob); err == nil {
+					entry.Size = info.Size()
+				}
+			} else if info, err := e.Info(); err == nil {
+				entry.Size = info.Size()
+			}
+			listing = append(listing, entry)
+		}
+	}
+	return listing, nil
+}
+
+// ServeHTTP serves the file or directory at the request path
+func (b *OutputBrowser) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	full, ok := resolveOutputPath(b.dir, r.URL.Path)
+	if !ok {
+		b.OnError(w, http.StatusBadRequest, "invalid file path")
+		return
+	}
+
+	info, err := os.Stat(full)
+	if err != nil || !info.IsDir() {
+		b.serveFile(w, full)
+		return
+	}
+
+	// Relative links in the index and listing resolve against the directory.
+	// The redirect is relative too, so it works under a stripped prefix.
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		w.Header().Set("Location", path.Base(r.URL.Path)+"/")
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+	if _, err := os.Stat(filepath.Join(full, BrowseIndexFile)); err == nil {
+		b.serveFile(w, filepath.Join(full, BrowseIndexFile))
+		return
+	}
+
+	listing, err := ListOutputDir(b.dir, r.URL.Path)
+	if err != nil {
+		b.OnError(w, http.StatusUnprocessableEntity, "failed to read directory")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	outputListingTemplate.Execute(w, struct {
+		Title   string
+		Parent  bool
+		Entries []OutputEntry
+	}{
+		Title:   "Index of /" + strings.Trim(r.URL.Path, "/"),
+		Parent:  strings.Trim(r.URL.Path, "/") != "",
+		Entries: listing,
+	})
+}
+
+// serveFile sends a stored file, decompressed, with the content type of its name
+func (b *OutputBrowser) serveFile(w http.ResponseWriter, full string) {
+	rc, name, err := OpenOutputFile(full)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			b.OnError(w, http.StatusNotFound, "file not found")
+			return
+		}
+		b.OnError(w, http.StatusUnprocessableEntity, "failed to open file")
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, rc)
+}
+
+var outputListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; }
+td { padding: 0.2rem 1.5rem 0.2rem 0; }
+td.size { text-align: right; color: #666; }
+a { color: #0366d6; text-decoration: none; }
+a:hover { text-decoration: underline; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+{{if .Parent}}<tr><td><a href="../">../</a></td><td></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.Href}}">{{.Name}}{{if .Dir}}/{{end}}</a></td><td class="size">{{if not .Dir}}{{.Size}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
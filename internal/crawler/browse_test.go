@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "zeta"), 0755)
+	os.MkdirAll(filepath.Join(dir, "alpha"), 0755)
+	os.WriteFile(filepath.Join(dir, "beta.html"), []byte("12345"), 0644)
+
+	entries, err := ListOutputDir(dir, "/")
+	if err != nil {
+		t.Fatalf("ListOutputDir() error = %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "alpha,zeta,beta.html" {
+		t.Errorf("ListOutputDir() order = %s, want directories first", got)
+	}
+	if entries[2].Size != 5 || entries[2].Href != "./beta.html" || entries[0].Href != "./alpha/" {
+		t.Errorf("ListOutputDir() entries = %+v", entries)
+	}
+
+	if _, err := ListOutputDir(dir, "../"); err == nil {
+		t.Error("ListOutputDir() outside the output directory should fail")
+	}
+}
+
+func TestOutputBrowser(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, BrowseIndexFile), []byte("<h1>index</h1>"), 0644)
+	if _, err := writeOutputFile(filepath.Join(dir, "docs", "intro.html"), []byte("<p>intro</p>"), CompressionZstd); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/browse", NewOutputBrowser(dir)))
+	defer server.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/browse/", http.StatusOK, "<h1>index</h1>"},
+		{"/browse/docs", http.StatusOK, `<a href="./intro.html.zst">intro.html.zst</a>`}, // Redirected to docs/
+		{"/browse/docs/intro.html", http.StatusOK, "<p>intro</p>"},
+		{"/browse/docs/intro.html.zst", http.StatusOK, "<p>intro</p>"},
+		{"/browse/docs/missing.html", http.StatusNotFound, "file not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %q, want containing %q", body, tt.wantBody)
+			}
+		})
+	}
+}
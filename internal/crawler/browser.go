@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chromedp/cdproto/emulation"
@@ -16,17 +18,27 @@ import (
 
 // BrowserFetcher implements Fetcher using a real browser via chromedp
 type BrowserFetcher struct {
-	allocCtx     context.Context
-	allocCancel  context.CancelFunc
-	browserCtx   context.Context
-	cancelFunc   context.CancelFunc
+	mu           sync.Mutex
+	browser      *browserInstance // Running browser new tabs are opened in
+	allocOpts    []chromedp.ExecAllocatorOption
+	closed       bool
+	recycleEvery int                            // Relaunch the browser after this many pages, 0 = never
+	onRestart    func(reason string, err error) // Called when the browser is relaunched
+	limits       BrowserLimits
+	tabSlots     chan struct{} // Tabs open under MaxTabs, nil = unlimited
+	done         chan struct{} // Closed with the fetcher
 	headless     bool
 	userAgent    string
 	antiBot      AntiBotConfig
-	userAgents   []string
-	uaIndex      int
-	uaMu         sync.Mutex
 	pageLoadWait time.Duration
+	geo          GeoConfig // Resolved region emulation applied to every tab
+	captureHAR   bool      // Record the network activity of every page load
+	pageScripts  []compiledPageScript
+	referer      func(rawURL string) string // Referer of each navigation, nil = none
+	lazyLoad     *lazyLoadScroll            // Scroll through pages before capture, nil = don't
+	shadowDOM    string                     // Shadow DOM capture mode, "" = light DOM only
+	// Dismiss cookie consent banners before capture
+	dismissCookieBanners bool
 }
 
 // NewBrowserFetcher creates a new browser-based fetcher
@@ -46,6 +58,20 @@ func NewBrowserFetcherWithAntiBot(headless bool, userAgent string, antiBot AntiB
 
 // NewBrowserFetcherWithPageLoadWait creates a new browser-based fetcher with configurable page load wait
 func NewBrowserFetcherWithPageLoadWait(headless bool, userAgent string, antiBot AntiBotConfig, pageLoadWait time.Duration) (*BrowserFetcher, error) {
+	return NewBrowserFetcherWithGeo(headless, userAgent, antiBot, pageLoadWait, GeoConfig{})
+}
+
+// NewBrowserFetcherWithGeo creates a new browser-based fetcher that emulates
+// the language, locale, timezone and geolocation of geo and uses its proxy
+func NewBrowserFetcherWithGeo(headless bool, userAgent string, antiBot AntiBotConfig, pageLoadWait time.Duration, geo GeoConfig) (*BrowserFetcher, error) {
+	return NewBrowserFetcherWithLimits(headless, userAgent, antiBot, pageLoadWait, geo, BrowserLimits{})
+}
+
+// NewBrowserFetcherWithLimits creates a new browser-based fetcher running
+// the Chrome binary of limits, relaunched when it exceeds their memory or
+// process count, with at most their number of tabs open at once
+func NewBrowserFetcherWithLimits(headless bool, userAgent string, antiBot AntiBotConfig, pageLoadWait time.Duration, geo GeoConfig, limits BrowserLimits) (*BrowserFetcher, error) {
+	geo = geo.Resolved()
 	if userAgent == "" {
 		userAgent = DefaultUserAgent
 	}
@@ -62,6 +88,8 @@ func NewBrowserFetcherWithPageLoadWait(headless bool, userAgent string, antiBot
 		chromedp.Flag("disable-dev-shm-usage", true),
 		chromedp.UserAgent(userAgent),
 	)
+	opts = append(opts, geoAllocatorOptions(geo)...)
+	opts = append(opts, limits.allocatorOptions()...)
 
 	// Anti-bot: Hide automation indicators
 	if antiBot.HideWebdriver {
@@ -79,66 +107,102 @@ func NewBrowserFetcherWithPageLoadWait(headless bool, userAgent string, antiBot
 		)
 	}
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	browserCtx, cancelFunc := chromedp.NewContext(allocCtx)
-
-	// Start browser
-	if err := chromedp.Run(browserCtx); err != nil {
-		allocCancel()
-		return nil, fmt.Errorf("failed to start browser: %w", err)
+	f := &BrowserFetcher{
+		allocOpts:    opts,
+		headless:     headless,
+		userAgent:    userAgent,
+		antiBot:      antiBot,
+		pageLoadWait: pageLoadWait,
+		geo:          geo,
+		limits:       limits,
+		done:         make(chan struct{}),
 	}
-
-	// Anti-bot: Set timezone if configured
-	if antiBot.MatchTimezone && antiBot.Timezone != "" {
-		if err := chromedp.Run(browserCtx,
-			chromedp.ActionFunc(func(ctx context.Context) error {
-				return emulation.SetTimezoneOverride(antiBot.Timezone).Do(ctx)
-			}),
-		); err != nil {
-			// Log but don't fail - timezone override is non-critical
-			fmt.Printf("Warning: failed to set timezone override: %v\n", err)
+	if limits.MaxTabs > 0 {
+		f.tabSlots = make(chan struct{}, limits.MaxTabs)
+	}
+	browser, err := f.launch()
+	if err != nil {
+		return nil, err
+	}
+	f.browser = browser
+	if limits.monitored() {
+		if _, _, err := processTree(os.Getpid()); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		} else {
+			go f.monitorLimits()
 		}
 	}
+	return f, nil
+}
 
-	// Set up user agent rotation if enabled
-	var userAgentPool []string
-	if antiBot.RotateUserAgent {
-		userAgentPool = GetChromeUserAgents()
-	}
+// SetHARCapture enables or disables recording each page load as a HARCapture
+// in FetchResult.HAR, used for HAR files and API discovery. It must be
+// called before the first fetch.
+func (f *BrowserFetcher) SetHARCapture(enabled bool) {
+	f.captureHAR = enabled
+}
 
-	return &BrowserFetcher{
-		allocCtx:     allocCtx,
-		allocCancel:  allocCancel,
-		browserCtx:   browserCtx,
-		cancelFunc:   cancelFunc,
-		headless:     headless,
-		userAgent:    userAgent,
-		antiBot:      antiBot,
-		userAgents:   userAgentPool,
-		uaIndex:      0,
-		pageLoadWait: pageLoadWait,
-	}, nil
+// SetReferer navigates to each URL with the Referer referer returns, when
+// not empty. Cookies are kept across tabs of the browser anyway.
+func (f *BrowserFetcher) SetReferer(referer func(rawURL string) string) {
+	f.referer = referer
 }
 
-// GetNextUserAgent returns the next user agent in rotation (thread-safe)
-func (f *BrowserFetcher) GetNextUserAgent() string {
-	if len(f.userAgents) == 0 {
-		return f.userAgent
+// navigateAction loads rawURL in the tab, with its Referer if any
+func (f *BrowserFetcher) navigateAction(rawURL string) chromedp.Action {
+	if f.referer == nil {
+		return chromedp.Navigate(rawURL)
 	}
+	referer := f.referer(rawURL)
+	if referer == "" {
+		return chromedp.Navigate(rawURL)
+	}
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, errorText, _, err := page.Navigate(rawURL).WithReferrer(referer).Do(ctx)
+		if err != nil {
+			return err
+		}
+		if errorText != "" {
+			return fmt.Errorf("page load error %s", errorText)
+		}
+		return nil
+	})
+}
 
-	f.uaMu.Lock()
-	defer f.uaMu.Unlock()
-
-	ua := f.userAgents[f.uaIndex]
-	f.uaIndex = (f.uaIndex + 1) % len(f.userAgents)
-	return ua
+// userAgentActions override the User-Agent of one tab when the crawler
+// rotates it; the one given at startup needs no override
+func (f *BrowserFetcher) userAgentActions(userAgent string) []chromedp.Action {
+	if userAgent == "" || userAgent == f.userAgent {
+		return nil
+	}
+	return []chromedp.Action{emulation.SetUserAgentOverride(userAgent)}
 }
 
 // Fetch retrieves a URL using the browser
 func (f *BrowserFetcher) Fetch(rawURL string, userAgent string) (*FetchResult, error) {
+	return f.FetchContext(context.Background(), rawURL, userAgent)
+}
+
+// FetchContext is Fetch closing the tab when ctx ends
+func (f *BrowserFetcher) FetchContext(ctx context.Context, rawURL string, userAgent string) (*FetchResult, error) {
+	browser, closeTab, err := f.openTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var crashed atomic.Bool
+	result, err := f.fetchTab(ctx, browser, &crashed, rawURL, userAgent)
+	closeTab()
+	return result, f.crashCheck(browser, &crashed, err)
+}
+
+// fetchTab loads rawURL in a new tab of browser, recording in crashed
+// whether the tab crashed
+func (f *BrowserFetcher) fetchTab(ctx context.Context, browser *browserInstance, crashed *atomic.Bool, rawURL string, userAgent string) (*FetchResult, error) {
 	// Create a new tab context for this request
-	tabCtx, cancel := chromedp.NewContext(f.browserCtx)
+	tabCtx, cancel := chromedp.NewContext(browser.ctx)
 	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
 
 	// Set timeout for the page load
 	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, HTTPTimeout)
@@ -148,25 +212,43 @@ func (f *BrowserFetcher) Fetch(rawURL string, userAgent string) (*FetchResult, e
 	var finalURL string
 	var statusCode int
 	var contentType string
+	var redirects []Redirect
+	var har *harRecorder
+	if f.captureHAR {
+		har = newHARRecorder(rawURL)
+	}
 
-	// Set up response listener to capture status code and content type
+	// Set up response listener to capture status code, content type and
+	// the redirect hops of the document request
 	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
-		if resp, ok := ev.(*network.EventResponseReceived); ok {
-			if resp.Type == network.ResourceTypeDocument {
-				statusCode = int(resp.Response.Status)
-				contentType = resp.Response.MimeType
+		if har != nil {
+			har.handle(ev)
+		}
+		watchCrash(ev, crashed, cancel)
+		switch e := ev.(type) {
+		case *network.EventResponseReceived:
+			if e.Type == network.ResourceTypeDocument {
+				statusCode = int(e.Response.Status)
+				contentType = e.Response.MimeType
+			}
+		case *network.EventRequestWillBeSent:
+			if e.Type == network.ResourceTypeDocument && e.RedirectResponse != nil {
+				redirects = append(redirects, Redirect{
+					From:   e.RedirectResponse.URL,
+					To:     e.Request.URL,
+					Status: int(e.RedirectResponse.Status),
+				})
 			}
 		}
 	})
 
-	// Build actions - user agent is set at browser startup via allocator options
-	// The userAgent parameter is ignored here as it's configured when creating the fetcher
-	_ = userAgent
-
 	// Inject anti-bot scripts before navigation
 	scripts := BuildInjectionScripts(f.antiBot)
 
-	var actions []chromedp.Action
+	// Region emulation, proxy logins and a rotated user agent apply to this
+	// tab only
+	actions := append(proxyAuthActions(tabCtx, f.geo), geoActions(f.geo)...)
+	actions = append(actions, f.userAgentActions(userAgent)...)
 
 	// First, inject scripts to run on new documents
 	if len(scripts) > 0 {
@@ -187,22 +269,37 @@ func (f *BrowserFetcher) Fetch(rawURL string, userAgent string) (*FetchResult, e
 	}
 
 	// Core navigation actions
+	var scriptErrs []string
+	var cookieBanner string
 	actions = append(actions,
 		network.Enable(),
-		chromedp.Navigate(rawURL),
+		f.navigateAction(rawURL),
 		chromedp.WaitReady("body", chromedp.ByQuery),
 		chromedp.Sleep(f.pageLoadWait), // Configurable delay for dynamic content
+	)
+	actions = append(actions, f.cookieBannerActions(&cookieBanner)...)
+	actions = append(actions, f.lazyLoadActions()...)
+	actions = append(actions, f.pageScriptActions(rawURL, &scriptErrs)...)
+	actions = append(actions,
 		chromedp.Location(&finalURL),
-		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+		f.captureHTMLAction(&html),
 	)
 
 	err := chromedp.Run(tabCtx, actions...)
 	if err != nil {
+		if strings.Contains(err.Error(), "net::ERR_TOO_MANY_REDIRECTS") {
+			return &FetchResult{Redirects: redirects, HAR: har.capture()}, browserRedirectError(redirects)
+		}
+		// Keep the network activity of failed loads, it is what HAR capture is for
+		var partial *FetchResult
+		if har != nil {
+			partial = &FetchResult{HAR: har.capture()}
+		}
 		// Check if it's a navigation error that might still have some content
 		if strings.Contains(err.Error(), "net::ERR_") {
-			return nil, fmt.Errorf("navigation failed: %w", err)
+			return partial, fmt.Errorf("navigation failed: %w", err)
 		}
-		return nil, fmt.Errorf("browser fetch failed: %w", err)
+		return partial, fmt.Errorf("browser fetch failed: %w", err)
 	}
 
 	// Default status code if not captured
@@ -216,30 +313,52 @@ func (f *BrowserFetcher) Fetch(rawURL string, userAgent string) (*FetchResult, e
 	}
 
 	return &FetchResult{
-		Body:        []byte(html),
-		StatusCode:  statusCode,
-		ContentType: contentType,
-		FinalURL:    finalURL,
+		Body:             []byte(html),
+		StatusCode:       statusCode,
+		ContentType:      contentType,
+		FinalURL:         finalURL,
+		Redirects:        redirects,
+		HAR:              har.capture(),
+		PageScriptErrors: scriptErrs,
+		CookieBanner:     cookieBanner,
 	}, nil
 }
 
+// browserRedirectError classifies a chain Chrome gave up on as a loop when
+// it revisits a URL, otherwise as too long
+func browserRedirectError(hops []Redirect) error {
+	seen := make(map[string]bool)
+	for _, hop := range hops {
+		if seen[hop.To] {
+			return fmt.Errorf("%w: %s", ErrRedirectLoop, hop.To)
+		}
+		seen[hop.From] = true
+	}
+	return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, len(hops))
+}
+
 // NavigateForLogin opens a URL in the browser and returns a cancel function to close the tab.
 // This is used for manual login - the tab stays open until the cancel function is called.
 // Session data (cookies, etc.) will persist in the browser context for subsequent fetches.
 func (f *BrowserFetcher) NavigateForLogin(rawURL string) (context.CancelFunc, error) {
 	// Create a new tab context for login
-	tabCtx, cancel := chromedp.NewContext(f.browserCtx)
+	f.mu.Lock()
+	browserCtx := f.browser.ctx
+	f.mu.Unlock()
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
 
 	// Set timeout for the page load (but not for the overall login wait)
 	loadCtx, loadCancel := context.WithTimeout(tabCtx, HTTPTimeout)
 	defer loadCancel()
 
-	// Navigate to the URL
-	err := chromedp.Run(loadCtx,
+	// Navigate to the URL with the same proxy and region emulation as the crawl
+	actions := append(proxyAuthActions(tabCtx, f.geo), geoActions(f.geo)...)
+	actions = append(actions,
 		network.Enable(),
 		chromedp.Navigate(rawURL),
 		chromedp.WaitReady("body", chromedp.ByQuery),
 	)
+	err := chromedp.Run(loadCtx, actions...)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("failed to navigate for login: %w", err)
@@ -251,15 +370,22 @@ func (f *BrowserFetcher) NavigateForLogin(rawURL string) (context.CancelFunc, er
 
 // Close releases browser resources
 func (f *BrowserFetcher) Close() error {
-	f.cancelFunc()
-	f.allocCancel()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		close(f.done)
+	}
+	f.closed = true
+	f.browser.close()
 	return nil
 }
 
 // PageCallback is called for each page fetched during pagination
-// It receives the page content, page number (1-indexed), and a virtual URL with page parameter
+// It receives the page content, page number (1-indexed), a virtual URL with page parameter
+// and the hash of the page content. The content is nil for pages captured before an
+// interruption, which are clicked through but not fetched again.
 // Return an error to stop pagination early
-type PageCallback func(result *FetchResult, pageNumber int, virtualURL string) error
+type PageCallback func(result *FetchResult, pageNumber int, virtualURL string, contentHash string) error
 
 // PaginatedFetchResult contains the result of a paginated fetch operation
 type PaginatedFetchResult struct {
@@ -269,14 +395,38 @@ type PaginatedFetchResult struct {
 }
 
 // FetchWithPagination fetches a URL and handles click-based pagination
-// It calls the callback for each page of content (including the initial page)
-func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, config PaginationConfig, callback PageCallback) (*PaginatedFetchResult, error) {
+// It calls the callback for each page of content (including the initial page).
+// captured holds the content hashes of the pages an interrupted pagination of
+// the URL got through: clicks cannot jump to a page, so these are clicked
+// through again, but without human-like delays and without fetching their
+// content, as long as their content is unchanged.
+func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, config PaginationConfig, captured []string, callback PageCallback) (*PaginatedFetchResult, error) {
+	browser, closeTab, err := f.openTab(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var crashed atomic.Bool
+	result, err := f.paginate(browser, &crashed, rawURL, userAgent, config, captured, callback)
+	closeTab()
+	if err == nil && !f.crashed(browser, &crashed) {
+		return result, nil
+	}
+	if err == nil {
+		// The crash ended the pagination with a page error
+		err = result.LastError
+	}
+	return result, f.crashCheck(browser, &crashed, err)
+}
+
+// paginate runs FetchWithPagination in a tab of browser, recording in
+// crashed whether the tab crashed
+func (f *BrowserFetcher) paginate(browser *browserInstance, crashed *atomic.Bool, rawURL string, userAgent string, config PaginationConfig, captured []string, callback PageCallback) (*PaginatedFetchResult, error) {
 	result := &PaginatedFetchResult{
 		TotalPages: 0,
 	}
 
 	// Create a persistent tab context for the entire pagination session
-	tabCtx, cancel := chromedp.NewContext(f.browserCtx)
+	tabCtx, cancel := chromedp.NewContext(browser.ctx)
 	defer cancel()
 
 	// Set timeout for the entire pagination operation
@@ -287,9 +437,17 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 
 	var statusCode int
 	var contentType string
+	var har *harRecorder
+	if f.captureHAR {
+		har = newHARRecorder(rawURL)
+	}
 
 	// Set up response listener
 	chromedp.ListenTarget(tabCtx, func(ev interface{}) {
+		if har != nil {
+			har.handle(ev)
+		}
+		watchCrash(ev, crashed, cancel)
 		if resp, ok := ev.(*network.EventResponseReceived); ok {
 			if resp.Type == network.ResourceTypeDocument {
 				statusCode = int(resp.Response.Status)
@@ -300,7 +458,8 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 
 	// Build initial navigation actions with anti-bot scripts
 	scripts := BuildInjectionScripts(f.antiBot)
-	var actions []chromedp.Action
+	actions := append(proxyAuthActions(tabCtx, f.geo), geoActions(f.geo)...)
+	actions = append(actions, f.userAgentActions(userAgent)...)
 
 	if len(scripts) > 0 {
 		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
@@ -319,13 +478,18 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 		actions = append(actions, chromedp.Sleep(RandomActionDelay()))
 	}
 
-	// Navigate to the initial URL
+	// Navigate to the initial URL, then dismiss its cookie banner and run its
+	// page scripts before paginating
+	var scriptErrs []string
+	var cookieBanner string
 	actions = append(actions,
 		network.Enable(),
-		chromedp.Navigate(rawURL),
+		f.navigateAction(rawURL),
 		chromedp.WaitReady("body", chromedp.ByQuery),
 		chromedp.Sleep(f.pageLoadWait),
 	)
+	actions = append(actions, f.cookieBannerActions(&cookieBanner)...)
+	actions = append(actions, f.pageScriptActions(rawURL, &scriptErrs)...)
 
 	if err := chromedp.Run(tabCtx, actions...); err != nil {
 		return result, fmt.Errorf("initial navigation failed: %w", err)
@@ -333,12 +497,7 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 
 	// Initialize pagination state
 	paginationState := NewPaginationState(config, f.antiBot)
-
-	// Process the initial page (page 1)
-	initialResult, err := f.fetchCurrentPage(tabCtx, rawURL, statusCode, contentType)
-	if err != nil {
-		return result, fmt.Errorf("failed to fetch initial page: %w", err)
-	}
+	paginationState.Replay = captured
 
 	// Get initial content hash
 	initialHash, err := getContentHash(tabCtx)
@@ -348,10 +507,24 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 	paginationState.SeenHashes[initialHash] = true
 	paginationState.ContentHash = initialHash
 
+	// Process the initial page (page 1), unless it was captured before
+	var initialResult *FetchResult
+	if paginationState.Captured(1, initialHash) {
+		har.capture() // Drop the requests of a page that is not fetched again
+	} else {
+		initialResult, err = f.fetchCurrentPage(tabCtx, rawURL, statusCode, contentType)
+		if err != nil {
+			return result, fmt.Errorf("failed to fetch initial page: %w", err)
+		}
+		initialResult.HAR = har.capture()
+		initialResult.PageScriptErrors = scriptErrs
+		initialResult.CookieBanner = cookieBanner
+	}
+
 	result.TotalPages = 1
 
 	// Call callback for initial page
-	if err := callback(initialResult, 1, rawURL); err != nil {
+	if err := callback(initialResult, 1, rawURL, initialHash); err != nil {
 		return result, err
 	}
 
@@ -365,8 +538,12 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 		default:
 		}
 
-		// Attempt to click pagination
-		clickResult, err := ClickPagination(tabCtx, config.Selector, config, paginationState.Behavior)
+		// Attempt to click pagination, straight through pages captured before
+		behavior := paginationState.Behavior
+		if paginationState.Replaying() {
+			behavior = NewHumanBehavior(AntiBotConfig{})
+		}
+		clickResult, err := ClickPagination(tabCtx, config.Selector, config, behavior)
 		if err != nil {
 			result.LastError = err
 			result.ExhaustedReason = fmt.Sprintf("click error: %v", err)
@@ -394,6 +571,21 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 			paginationState.ClickCount++
 		}
 
+		pageNumber := result.TotalPages + 1
+
+		// Generate virtual URL with page parameter
+		virtualURL := fmt.Sprintf("%s?_page=%d", rawURL, pageNumber)
+
+		if paginationState.Captured(pageNumber, clickResult.ContentHash) {
+			har.capture()
+			result.TotalPages++
+			if err := callback(nil, pageNumber, virtualURL, clickResult.ContentHash); err != nil {
+				result.LastError = err
+				return result, nil
+			}
+			continue
+		}
+
 		// Fetch the new page content
 		pageResult, err := f.fetchCurrentPage(tabCtx, rawURL, statusCode, contentType)
 		if err != nil {
@@ -403,13 +595,15 @@ func (f *BrowserFetcher) FetchWithPagination(rawURL string, userAgent string, co
 		}
 
 		result.TotalPages++
-		pageNumber := result.TotalPages
 
-		// Generate virtual URL with page parameter
-		virtualURL := fmt.Sprintf("%s?_page=%d", rawURL, pageNumber)
+		// Requests made since the previous page, e.g. the XHR behind "Load more"
+		if har != nil {
+			har.SetTitle(virtualURL)
+			pageResult.HAR = har.capture()
+		}
 
 		// Call callback for this page
-		if err := callback(pageResult, pageNumber, virtualURL); err != nil {
+		if err := callback(pageResult, pageNumber, virtualURL, clickResult.ContentHash); err != nil {
 			result.LastError = err
 			return result, nil
 		}
@@ -424,10 +618,12 @@ func (f *BrowserFetcher) fetchCurrentPage(ctx context.Context, originalURL strin
 	var html string
 	var finalURL string
 
-	err := chromedp.Run(ctx,
+	// Lazy content of the page, and of what a click added to it, loads first
+	actions := append(f.lazyLoadActions(),
 		chromedp.Location(&finalURL),
-		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+		f.captureHTMLAction(&html),
 	)
+	err := chromedp.Run(ctx, actions...)
 	if err != nil {
 		return nil, err
 	}
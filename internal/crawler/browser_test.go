@@ -2,9 +2,56 @@ package crawler
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
+func TestHTTPFetcherMaxBodySize(t *testing.T) {
+	page := strings.Repeat("x", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flushing first forces a chunked response without Content-Length
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		limit   int64
+		path    string
+		wantErr bool
+	}{
+		{"unlimited", 0, "/", false},
+		{"within limit", 100, "/", false},
+		{"declared length over limit", 50, "/", true},
+		{"streamed body over limit", 50, "/chunked", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewHTTPFetcherWithMaxBodySize(tt.limit)
+			result, err := fetcher.Fetch(server.URL+tt.path, "")
+			if tt.wantErr {
+				if !errors.Is(err, ErrBodyTooLarge) {
+					t.Errorf("Fetch() error = %v, want ErrBodyTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if string(result.Body) != page {
+				t.Errorf("Fetch() body length = %d, want %d", len(result.Body), len(page))
+			}
+		})
+	}
+}
+
 func TestHTTPFetcher(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping network test in short mode")
@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserLimits caps the resources of the browser in browser mode
+type BrowserLimits struct {
+	Path         string // Chrome binary to run, "" = a system-installed Chrome found on PATH
+	MaxMemory    int    // MB of resident memory of all browser processes before it is relaunched, 0 = unlimited
+	MaxTabs      int    // Tabs open at once, 0 = unlimited
+	MaxProcesses int    // Browser processes, renderers included, 0 = unlimited
+}
+
+// BrowserLimits returns the browser resource limits of the configuration
+func (c Config) BrowserLimits() BrowserLimits {
+	return BrowserLimits{
+		Path:         c.BrowserPath,
+		MaxMemory:    c.BrowserMaxMemory,
+		MaxTabs:      c.BrowserMaxTabs,
+		MaxProcesses: c.BrowserMaxProcesses,
+	}
+}
+
+// BrowserBaseProcesses is how many processes Chrome runs besides its
+// renderers: the browser, GPU and utility processes
+const BrowserBaseProcesses = 3
+
+// browserMonitorInterval is how often the browser's memory and processes
+// are checked against MaxMemory and MaxProcesses
+const browserMonitorInterval = 5 * time.Second
+
+// errProcessStatsUnsupported is returned by processTree where the memory
+// and processes of the browser can't be read
+var errProcessStatsUnsupported = errors.New("browser memory and process limits are only supported on Linux")
+
+// monitored reports whether the browser's processes need to be watched
+func (l BrowserLimits) monitored() bool {
+	return l.MaxMemory > 0 || l.MaxProcesses > 0
+}
+
+// allocatorOptions returns the Chrome options enforcing the limits: the
+// binary, and a renderer limit leaving room for the other processes
+func (l BrowserLimits) allocatorOptions() []chromedp.ExecAllocatorOption {
+	var opts []chromedp.ExecAllocatorOption
+	if l.Path != "" {
+		opts = append(opts, chromedp.ExecPath(l.Path))
+	}
+	if l.MaxProcesses > 0 {
+		opts = append(opts, chromedp.Flag("renderer-process-limit", max(1, l.MaxProcesses-BrowserBaseProcesses)))
+	}
+	return opts
+}
+
+// acquireTab waits for a free tab under MaxTabs, returning a function to
+// call when the tab is closed
+func (f *BrowserFetcher) acquireTab(ctx context.Context) (func(), error) {
+	if f.tabSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case f.tabSlots <- struct{}{}:
+		return func() { <-f.tabSlots }, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// monitorLimits checks the browser against MaxMemory and MaxProcesses until
+// the fetcher is closed
+func (f *BrowserFetcher) monitorLimits() {
+	ticker := time.NewTicker(browserMonitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+			f.checkLimits()
+		}
+	}
+}
+
+// checkLimits relaunches the browser when its processes use more memory, or
+// are more, than the limits allow. A browser that hasn't loaded a page yet
+// is left alone, as relaunching it would not help.
+func (f *BrowserFetcher) checkLimits() {
+	f.mu.Lock()
+	b := f.browser
+	pages := b.pages
+	f.mu.Unlock()
+	if pages == 0 || b.ctx.Err() != nil {
+		return
+	}
+	c := chromedp.FromContext(b.ctx)
+	if c == nil || c.Browser == nil || c.Browser.Process() == nil {
+		return
+	}
+	processes, rss, err := processTree(c.Browser.Process().Pid)
+	if err != nil {
+		return
+	}
+
+	var over error
+	switch {
+	case f.limits.MaxMemory > 0 && rss > int64(f.limits.MaxMemory)<<20:
+		over = fmt.Errorf("browser uses %s, over its %d MB limit", FormatBytes(rss), f.limits.MaxMemory)
+	case f.limits.MaxProcesses > 0 && processes > f.limits.MaxProcesses:
+		over = fmt.Errorf("browser runs %d processes, over its limit of %d", processes, f.limits.MaxProcesses)
+	}
+	if over != nil {
+		f.relaunch(b, BrowserRestartLimit, over)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build linux
+
+package crawler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processTree returns the number of processes in the tree rooted at pid
+// and their total resident memory in bytes, read from /proc
+func processTree(pid int) (int, int64, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, 0, err
+	}
+	children := make(map[int][]int)
+	for _, e := range entries {
+		child, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue // Exited meanwhile
+		}
+		// The command name in parentheses may hold spaces; the state and
+		// parent PID follow it
+		end := bytes.LastIndexByte(stat, ')')
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(string(stat[end+1:]))
+		if len(fields) < 2 {
+			continue
+		}
+		if parent, err := strconv.Atoi(fields[1]); err == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	var count int
+	var rss int64
+	pageSize := int64(os.Getpagesize())
+	for pending := []int{pid}; len(pending) > 0; {
+		p := pending[0]
+		pending = append(pending[1:], children[p]...)
+		statm, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(p), "statm"))
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(statm))
+		if len(fields) < 2 {
+			continue
+		}
+		resident, _ := strconv.ParseInt(fields[1], 10, 64)
+		count++
+		rss += resident * pageSize
+	}
+	return count, rss, nil
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package crawler
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestProcessTree(t *testing.T) {
+	alone, rss, err := processTree(os.Getpid())
+	if err != nil {
+		t.Fatalf("processTree() error = %v", err)
+	}
+	if alone < 1 || rss <= 0 {
+		t.Fatalf("processTree() = %d processes, %d bytes", alone, rss)
+	}
+
+	child := exec.Command("sleep", "10")
+	if err := child.Start(); err != nil {
+		t.Skipf("can't start a child process: %v", err)
+	}
+	defer func() {
+		child.Process.Kill()
+		child.Wait()
+	}()
+	withChild, _, err := processTree(os.Getpid())
+	if err != nil {
+		t.Fatalf("processTree() error = %v", err)
+	}
+	if withChild != alone+1 {
+		t.Errorf("processTree() with a child = %d processes, want %d", withChild, alone+1)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package crawler
+
+// processTree can't read the processes of the browser on this platform
+func processTree(pid int) (int, int64, error) {
+	return 0, 0, errProcessStatsUnsupported
+}
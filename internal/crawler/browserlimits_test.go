@@ -0,0 +1,39 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAcquireTabWaitsForFreeTab(t *testing.T) {
+	f := &BrowserFetcher{tabSlots: make(chan struct{}, 1)}
+	release, err := f.acquireTab(context.Background())
+	if err != nil {
+		t.Fatalf("acquireTab() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, ErrURLTimeout)
+	defer cancel()
+	if _, err := f.acquireTab(ctx); !errors.Is(err, ErrURLTimeout) {
+		t.Errorf("acquireTab() with every tab taken = %v, want the context's cause", err)
+	}
+
+	release()
+	if _, err := f.acquireTab(context.Background()); err != nil {
+		t.Errorf("acquireTab() after a release error = %v", err)
+	}
+}
+
+func TestBrowserLimitsValidation(t *testing.T) {
+	for _, tc := range []struct {
+		processes int
+		valid     bool
+	}{{0, true}, {BrowserBaseProcesses, false}, {BrowserBaseProcesses + 1, true}, {-1, false}} {
+		config := Config{URL: "https://example.com", MaxDepth: 1, BrowserMaxProcesses: tc.processes}
+		if err := ValidateConfig(&config); (err == nil) != tc.valid {
+			t.Errorf("BrowserMaxProcesses %d: ValidateConfig() error = %v, want valid %v", tc.processes, err, tc.valid)
+		}
+	}
+}
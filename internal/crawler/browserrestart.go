@@ -0,0 +1,278 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrBrowserCrashed is returned by browser fetches whose tab or browser
+// crashed. The browser has been relaunched by the time it is returned, so
+// the URL can be fetched again.
+var ErrBrowserCrashed = errors.New("browser crashed")
+
+// Why a browser was relaunched
+const (
+	BrowserRestartRecycle = "recycle" // After BrowserRecycle pages
+	BrowserRestartCrash   = "crash"   // A tab or the browser crashed
+	BrowserRestartLimit   = "limit"   // The browser exceeded MaxMemory or MaxProcesses
+)
+
+// maxCrashRequeues is how many times a URL whose fetch crashed the browser
+// is queued again before it is recorded as an error
+const maxCrashRequeues = 1
+
+// browserInstance is one launch of the browser. Tabs keep the instance
+// they were opened in: a relaunched browser replaces it for new tabs, and it
+// is closed once its last tab is.
+type browserInstance struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	allocCancel context.CancelFunc
+	tabs        int  // Open tabs, guarded by BrowserFetcher.mu
+	pages       int  // Pages loaded, guarded by BrowserFetcher.mu
+	retired     bool // Replaced by a newer instance
+}
+
+func (b *browserInstance) close() {
+	b.cancel()
+	b.allocCancel()
+}
+
+// SetRecycle relaunches the browser after every pages page loads, keeping
+// its cookies, to reclaim the memory a long-running Chrome leaks (0 = never)
+func (f *BrowserFetcher) SetRecycle(pages int) {
+	f.recycleEvery = pages
+}
+
+// SetRestartHandler calls handler whenever the browser is relaunched, with
+// BrowserRestartRecycle, BrowserRestartCrash or BrowserRestartLimit and the
+// error that caused a crash or limit restart
+func (f *BrowserFetcher) SetRestartHandler(handler func(reason string, err error)) {
+	f.onRestart = handler
+}
+
+// browserRestarted counts a relaunch of the browser in the metrics
+func (c *Crawler) browserRestarted(reason string, err error) {
+	switch reason {
+	case BrowserRestartCrash:
+		c.log.Warn("Relaunched the browser after a crash: %v", err)
+		c.metrics.IncrementBrowserCrashes()
+	case BrowserRestartLimit:
+		c.log.Warn("Relaunched the browser: %v", err)
+		c.metrics.IncrementBrowserRecycles()
+	default:
+		c.log.Info("Relaunched the browser to reclaim memory")
+		c.metrics.IncrementBrowserRecycles()
+	}
+}
+
+// requeueCrashed queues a URL whose fetch crashed the browser again, at the
+// front of the queue, unless it did so maxCrashRequeues times already.
+// Reports whether it was queued.
+func (c *Crawler) requeueCrashed(rawURL string, depth int, err error) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.crashRequeues[rawURL] >= maxCrashRequeues {
+		return false
+	}
+	if c.crashRequeues == nil {
+		c.crashRequeues = make(map[string]int)
+	}
+	c.crashRequeues[rawURL]++
+	c.log.Warn("Queuing %s again: %v", rawURL, err)
+
+	delete(c.state.Visited, rawURL)
+	c.state.Queue = append([]URLInfo{{URL: rawURL, Depth: depth}}, c.state.Queue...)
+	c.state.Queued[rawURL] = true
+	c.targetQueued(rawURL)
+	return true
+}
+
+// launch starts a browser with the fetcher's options
+func (f *BrowserFetcher) launch() (*browserInstance, error) {
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), f.allocOpts...)
+	browserCtx, cancelFunc := chromedp.NewContext(allocCtx)
+
+	// Start browser
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelFunc()
+		allocCancel()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	// Anti-bot: Set timezone if configured
+	if f.antiBot.MatchTimezone && f.antiBot.Timezone != "" {
+		if err := chromedp.Run(browserCtx,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				return emulation.SetTimezoneOverride(f.antiBot.Timezone).Do(ctx)
+			}),
+		); err != nil {
+			// Log but don't fail - timezone override is non-critical
+			fmt.Printf("Warning: failed to set timezone override: %v\n", err)
+		}
+	}
+
+	return &browserInstance{ctx: browserCtx, cancel: cancelFunc, allocCancel: allocCancel}, nil
+}
+
+// openTab returns the running browser to open a tab in, and a function to
+// call once the tab is closed. It waits for a free tab under MaxTabs until
+// ctx ends.
+func (f *BrowserFetcher) openTab(ctx context.Context) (*browserInstance, func(), error) {
+	releaseSlot, err := f.acquireTab(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	f.mu.Lock()
+	b := f.browser
+	b.tabs++
+	f.mu.Unlock()
+
+	return b, func() {
+		releaseSlot()
+		f.mu.Lock()
+		b.tabs--
+		b.pages++
+		closeRetired := b.retired && b.tabs == 0
+		recycle := !b.retired && f.recycleEvery > 0 && b.pages >= f.recycleEvery
+		f.mu.Unlock()
+
+		if closeRetired {
+			b.close()
+		}
+		if recycle {
+			f.relaunch(b, BrowserRestartRecycle, nil)
+		}
+	}, nil
+}
+
+// relaunch replaces the browser instance b with a new one, unless another
+// tab did so already. Cookies are carried over when b still responds.
+func (f *BrowserFetcher) relaunch(b *browserInstance, reason string, cause error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.browser != b || f.closed {
+		return
+	}
+
+	cookies, cookieErr := browserCookies(b.ctx)
+	next, err := f.launch()
+	if err != nil {
+		// Keep the old instance; if it is gone, fetches fail until a later
+		// crash restart succeeds
+		fmt.Printf("Warning: failed to relaunch browser: %v\n", err)
+		return
+	}
+	if cookieErr == nil && len(cookies) > 0 {
+		if err := setBrowserCookies(next.ctx, cookies); err != nil {
+			fmt.Printf("Warning: failed to restore cookies after browser relaunch: %v\n", err)
+		}
+	}
+
+	f.browser = next
+	b.retired = true
+	if b.tabs == 0 {
+		b.close()
+	}
+	if f.onRestart != nil {
+		f.onRestart(reason, cause)
+	}
+}
+
+// crashCheck reports the crash of a tab, or of the browser it ran in, as an
+// ErrBrowserCrashed error after relaunching the browser. Other errors are
+// returned as they are.
+func (f *BrowserFetcher) crashCheck(b *browserInstance, tabCrashed *atomic.Bool, err error) error {
+	if !f.crashed(b, tabCrashed) {
+		return err
+	}
+	what := "tab"
+	if b.ctx.Err() != nil {
+		what = "browser"
+	}
+	if err == nil {
+		err = errors.New("target crashed")
+	}
+	crashErr := fmt.Errorf("%w (%s): %v", ErrBrowserCrashed, what, err)
+	f.relaunch(b, BrowserRestartCrash, crashErr)
+	return crashErr
+}
+
+// crashed reports whether a tab of b crashed, or b itself while the
+// fetcher is open
+func (f *BrowserFetcher) crashed(b *browserInstance, tabCrashed *atomic.Bool) bool {
+	if tabCrashed.Load() {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return !f.closed && b.ctx.Err() != nil
+}
+
+// watchCrash records in crashed when the tab's renderer crashes, and closes
+// the tab, which would otherwise wait for a page load that never comes
+func watchCrash(ev interface{}, crashed *atomic.Bool, closeTab context.CancelFunc) {
+	if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+		crashed.Store(true)
+		go closeTab()
+	}
+}
+
+// browserCookies returns every cookie of the browser as parameters to set
+// them again
+func browserCookies(browserCtx context.Context) ([]*network.CookieParam, error) {
+	if browserCtx.Err() != nil {
+		return nil, browserCtx.Err()
+	}
+	ctx, cancel := context.WithTimeout(browserCtx, 5*time.Second)
+	defer cancel()
+
+	var params []*network.CookieParam
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		cookies, err := storage.GetCookies().Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser))
+		if err != nil {
+			return err
+		}
+		for _, c := range cookies {
+			p := &network.CookieParam{
+				Name:         c.Name,
+				Value:        c.Value,
+				Domain:       c.Domain,
+				Path:         c.Path,
+				Secure:       c.Secure,
+				HTTPOnly:     c.HTTPOnly,
+				SameSite:     c.SameSite,
+				Priority:     c.Priority,
+				SourceScheme: c.SourceScheme,
+				SourcePort:   c.SourcePort,
+				PartitionKey: c.PartitionKey,
+			}
+			if !c.Session {
+				expires := cdp.TimeSinceEpoch(time.Unix(0, int64(c.Expires*float64(time.Second))))
+				p.Expires = &expires
+			}
+			params = append(params, p)
+		}
+		return nil
+	}))
+	return params, err
+}
+
+// setBrowserCookies sets cookies in a browser
+func setBrowserCookies(browserCtx context.Context, cookies []*network.CookieParam) error {
+	ctx, cancel := context.WithTimeout(browserCtx, 5*time.Second)
+	defer cancel()
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		return storage.SetCookies(cookies).Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Browser))
+	}))
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// crashingFetcher fails fetches of its URLs with ErrBrowserCrashed, as
+// many times as given for each, before passing them to the inner fetcher
+type crashingFetcher struct {
+	Fetcher
+	mu      sync.Mutex
+	crashes map[string]int
+}
+
+func (f *crashingFetcher) Fetch(url string, userAgent string) (*FetchResult, error) {
+	f.mu.Lock()
+	crash := f.crashes[url] > 0
+	if crash {
+		f.crashes[url]--
+	}
+	f.mu.Unlock()
+	if crash {
+		return nil, fmt.Errorf("%w (tab): target crashed", ErrBrowserCrashed)
+	}
+	return f.Fetcher.Fetch(url, userAgent)
+}
+
+func (f *crashingFetcher) Unwrap() Fetcher { return f.Fetcher }
+
+func TestCrashedURLsRequeued(t *testing.T) {
+	site := NewTestSite(TestSiteOptions{Pages: 6})
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         5,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+	}
+	c, err := NewCrawler(config, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	once, always := site.PageURL(1), site.PageURL(2)
+	c.fetcher = &crashingFetcher{Fetcher: c.fetcher, crashes: map[string]int{once: 1, always: 100}}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	status := make(map[string]URLRecord)
+	for _, rec := range c.URLInventory() {
+		status[rec.URL] = rec
+	}
+	if rec := status[once]; rec.Status != URLStatusSaved {
+		t.Errorf("%s crashed once and should be saved on its second try, got %s (%q)", once, rec.Status, rec.Reason)
+	}
+	if rec := status[always]; rec.Status != URLStatusError {
+		t.Errorf("%s keeps crashing and should be given up as an error, got %s", always, rec.Status)
+	}
+	if got := len(c.state.Visited); got != site.Pages() {
+		t.Errorf("visited %d pages, want %d", got, site.Pages())
+	}
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobDir is the directory of a content-addressable output directory that
+// holds each distinct stored file once, under the SHA-256 of its content
+const BlobDir = "_blobs"
+
+// BlobPointerExt is appended to the name of a stored file for the pointer
+// file standing in for it in a content-addressable crawl. The pointer holds
+// the path of the blob, relative to the pointer's directory.
+const BlobPointerExt = ".blob"
+
+// blobPath returns the output-relative, slash-separated path of the blob
+// holding data: <BlobDir>/<first 2 hex digits>/<sha256>.html, plus the
+// compression extension
+func blobPath(data []byte, method string) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	return BlobDir + "/" + hash[:2] + "/" + hash + ".html" + compressionExt(method)
+}
+
+// storeOutputFile writes a stored page file at path, compressed as
+// configured. In content-addressable mode the data goes to its blob, written
+// only when no earlier page had the same content, and path gets a pointer to
+// it. It returns the path written and the output-relative blob path ("" when
+// the file is written as is).
+func (c *Crawler) storeOutputFile(path string, data []byte) (string, string, error) {
+	method := c.config.CompressOutput
+	if !c.config.ContentAddressable {
+		written, err := writeOutputFile(path, data, method)
+		return written, "", err
+	}
+
+	blob := blobPath(data, method)
+	blobFile := filepath.Join(c.config.OutputDir, filepath.FromSlash(blob))
+	if _, err := os.Stat(blobFile); os.IsNotExist(err) {
+		if err := c.writeBlob(blobFile, data, method); err != nil {
+			return "", "", err
+		}
+	} else if err != nil {
+		return "", "", err
+	} else {
+		c.log.Debug("Reusing blob %s for %s", blob, path)
+	}
+
+	written := path + compressionExt(method)
+	// A file left by an earlier crawl without content addressing would be
+	// found before the pointer
+	if err := os.Remove(written); err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+	target, err := filepath.Rel(filepath.Dir(written), blobFile)
+	if err != nil {
+		return "", "", err
+	}
+	written += BlobPointerExt
+	return written, blob, os.WriteFile(written, []byte(filepath.ToSlash(target)+"\n"), 0644)
+}
+
+// writeBlob writes a new blob through a temporary file, so a worker storing
+// the same content at the same time never sees it half written
+func (c *Crawler) writeBlob(blobFile string, data []byte, method string) error {
+	if err := c.perms.mkdirAll(filepath.Dir(blobFile)); err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(blobFile), err)
+	}
+	compressed, err := compressData(method, data)
+	if err != nil {
+		return fmt.Errorf("failed to compress %s: %v", blobFile, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(blobFile), ".blob-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(compressed); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), blobFile); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return c.perms.applyFile(blobFile)
+}
+
+// readBlobPointer returns the blob a pointer file refers to. Pointers only
+// ever refer to files in a BlobDir, so a tampered one can't reach elsewhere.
+func readBlobPointer(pointer string) (string, error) {
+	data, err := os.ReadFile(pointer)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(filepath.Dir(pointer), filepath.FromSlash(strings.TrimSpace(string(data))))
+	if filepath.Base(filepath.Dir(filepath.Dir(target))) != BlobDir {
+		return "", fmt.Errorf("blob pointer %s does not refer to a blob", pointer)
+	}
+	if _, err := os.Stat(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
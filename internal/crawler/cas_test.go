@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentAddressableCrawl(t *testing.T) {
+	mirror := `<html><body><h1>Mirror</h1><p>` + strings.Repeat("The same page under two URLs. ", 10) + `</p></body></html>`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><h1>Home</h1><p>Links to the mirrors.</p><a href="/a">A</a><a href="/b">B</a></body></html>`)
+		case "/a", "/b":
+			fmt.Fprint(w, mirror)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:                server.URL + "/",
+		MaxDepth:           1,
+		OutputDir:          outputDir,
+		StateFile:          filepath.Join(outputDir, "state.json"),
+		MinContentLength:   10,
+		CompressOutput:     CompressionZstd,
+		ContentAddressable: true,
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	for _, name := range []string{"a.html", "b.html"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name+".zst"+BlobPointerExt)); err != nil {
+			t.Errorf("pointer of %s not written: %v", name, err)
+		}
+		body, err := ReadOutputFile(filepath.Join(outputDir, name))
+		if err != nil || string(body) != mirror {
+			t.Errorf("ReadOutputFile(%s) = %q, %v; want the fetched page", name, body, err)
+		}
+	}
+
+	blobs, _ := filepath.Glob(filepath.Join(outputDir, BlobDir, "*", "*.html.zst"))
+	// Home, its content, and one blob each for the raw and extracted mirror
+	if len(blobs) != 4 {
+		t.Errorf("%d blobs written, want 4: %v", len(blobs), blobs)
+	}
+
+	pages, err := BuildIndex(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hrefs := make(map[string]string)
+	for _, p := range pages {
+		if p.Blob == "" || p.ContentBlob == "" {
+			t.Errorf("index entry of %s misses its blobs: %+v", p.URL, p)
+		}
+		hrefs[p.Filename] = p.Href()
+	}
+	if hrefs[filepath.FromSlash("a.html.zst")] == "" || hrefs[filepath.FromSlash("a.html.zst")] != hrefs[filepath.FromSlash("b.html.zst")] {
+		t.Errorf("identical pages should link the same blob: %v", hrefs)
+	}
+
+	listing, err := ListOutputDir(outputDir, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[string]bool)
+	for _, e := range listing {
+		listed[e.Name] = true
+	}
+	if !listed["a.html.zst"] || listed["a.html.zst"+BlobPointerExt] {
+		t.Errorf("listing should show pointers as the files they stand in for: %v", listing)
+	}
+}
+
+func TestReadBlobPointer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.html"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pointer := filepath.Join(dir, "page.html"+BlobPointerExt)
+	if err := os.WriteFile(pointer, []byte("secret.html\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBlobPointer(pointer); err == nil {
+		t.Error("a pointer outside the blob directory should be rejected")
+	}
+	if _, err := ResolveOutputFile(filepath.Join(dir, "page.html")); err == nil {
+		t.Error("ResolveOutputFile should not follow a pointer outside the blob directory")
+	}
+}
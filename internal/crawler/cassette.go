@@ -0,0 +1,324 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cassette modes
+const (
+	CassetteOff    = "off"    // Fetch from the network only
+	CassetteRecord = "record" // Fetch from the network and record every response
+	CassetteReplay = "replay" // Answer every fetch from a recorded cassette, without network access
+)
+
+// CassetteFile is the default cassette name in the output directory
+const CassetteFile = "cassette.jsonl"
+
+// ErrNotInCassette is returned when replaying a URL the cassette has no response for
+var ErrNotInCassette = errors.New("not in cassette")
+
+// ValidCassetteMode reports whether mode is a known cassette mode ("" is off)
+func ValidCassetteMode(mode string) bool {
+	switch mode {
+	case "", CassetteOff, CassetteRecord, CassetteReplay:
+		return true
+	}
+	return false
+}
+
+// CassetteEntry is one recorded response. Bodies are base64 in the JSON.
+type CassetteEntry struct {
+	URL         string     `json:"url"`
+	Time        time.Time  `json:"time"`
+	StatusCode  int        `json:"statusCode,omitempty"`
+	ContentType string     `json:"contentType,omitempty"`
+	FinalURL    string     `json:"finalUrl,omitempty"`
+	Redirects   []Redirect `json:"redirects,omitempty"`
+	Body        []byte     `json:"body,omitempty"`
+	Error       string     `json:"error,omitempty"` // Fetch error; replayed as the same error
+}
+
+// replayedErrors are the sentinel errors the crawler tells apart, restored
+// from the error text of replayed entries
+var replayedErrors = []error{ErrBodyTooLarge, ErrRedirectLoop, ErrTooManyRedirects}
+
+// err returns the recorded fetch error
+func (e *CassetteEntry) err() error {
+	if e.Error == "" {
+		return nil
+	}
+	for _, sentinel := range replayedErrors {
+		if strings.Contains(e.Error, sentinel.Error()) {
+			return fmt.Errorf("%s (replayed): %w", e.URL, sentinel)
+		}
+	}
+	return fmt.Errorf("%s (replayed)", e.Error)
+}
+
+// Cassette is a file of recorded responses, appended to in record mode and
+// read into memory in replay mode. Later recordings of a URL win.
+type Cassette struct {
+	path    string
+	mode    string
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]*CassetteEntry
+}
+
+// OpenCassette opens the cassette at path. Replay mode reads it and fails if
+// it does not exist; record mode creates or appends to it on the first recording.
+func OpenCassette(path, mode string) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, entries: make(map[string]*CassetteEntry)}
+	if mode != CassetteReplay {
+		return c, nil
+	}
+
+	entries, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		c.entries[entries[i].URL] = &entries[i]
+	}
+	// A redirected response also answers its final URL, which the crawler
+	// requests directly once it knows the redirect as permanent
+	for _, e := range c.entries {
+		if e.FinalURL == "" || e.FinalURL == e.URL || e.Error != "" {
+			continue
+		}
+		if _, ok := c.entries[e.FinalURL]; !ok {
+			direct := *e
+			direct.URL, direct.Redirects = e.FinalURL, nil
+			c.entries[e.FinalURL] = &direct
+		}
+	}
+	return c, nil
+}
+
+// LoadCassette reads every entry of a cassette file in recording order
+func LoadCassette(path string) ([]CassetteEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer f.Close()
+
+	var entries []CassetteEntry
+	reader := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			var e CassetteEntry
+			if jerr := json.Unmarshal(data, &e); jerr != nil {
+				// A crash can leave the last line half written
+				if err == io.EOF {
+					break
+				}
+				return nil, fmt.Errorf("invalid cassette entry on line %d: %w", line, jerr)
+			}
+			entries = append(entries, e)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+// Path returns the cassette file
+func (c *Cassette) Path() string {
+	return c.path
+}
+
+// Len returns the number of URLs available for replay (0 in record mode)
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Lookup returns the recorded response of rawURL
+func (c *Cassette) Lookup(rawURL string) (*CassetteEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[rawURL]
+	return e, ok
+}
+
+// Record appends an entry to the cassette file
+func (c *Cassette) Record(e CassetteEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+			return err
+		}
+		c.file, err = os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open cassette: %w", err)
+		}
+	}
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to record to cassette: %w", err)
+	}
+	return nil
+}
+
+// Close closes the cassette file
+func (c *Cassette) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// CassetteFetcher records the responses of a wrapped Fetcher, or replays
+// them from the cassette when there is no wrapped fetcher
+type CassetteFetcher struct {
+	Fetcher  // nil in replay mode
+	cassette *Cassette
+}
+
+// NewCassetteFetcher records the fetches of fetcher to cassette, or replays
+// from cassette when fetcher is nil
+func NewCassetteFetcher(fetcher Fetcher, cassette *Cassette) *CassetteFetcher {
+	return &CassetteFetcher{Fetcher: fetcher, cassette: cassette}
+}
+
+// Unwrap returns the wrapped fetcher
+func (f *CassetteFetcher) Unwrap() Fetcher {
+	return f.Fetcher
+}
+
+// Fetch replays the recorded response of url, or fetches and records it
+func (f *CassetteFetcher) Fetch(url string, userAgent string) (*FetchResult, error) {
+	if f.Fetcher == nil {
+		e, ok := f.cassette.Lookup(url)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", url, ErrNotInCassette)
+		}
+		result := &FetchResult{
+			Body:        e.Body,
+			StatusCode:  e.StatusCode,
+			ContentType: e.ContentType,
+			FinalURL:    e.FinalURL,
+			Redirects:   e.Redirects,
+		}
+		return result, e.err()
+	}
+
+	result, err := f.Fetcher.Fetch(url, userAgent)
+	e := CassetteEntry{URL: url, Time: time.Now()}
+	if result != nil {
+		e.StatusCode = result.StatusCode
+		e.ContentType = result.ContentType
+		e.FinalURL = result.FinalURL
+		e.Redirects = result.Redirects
+		e.Body = result.Body
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if rerr := f.cassette.Record(e); rerr != nil {
+		return result, errors.Join(err, rerr)
+	}
+	return result, err
+}
+
+// Close closes the cassette and the wrapped fetcher
+func (f *CassetteFetcher) Close() error {
+	err := f.cassette.Close()
+	if f.Fetcher != nil {
+		err = errors.Join(f.Fetcher.Close(), err)
+	}
+	return err
+}
+
+// Transport returns a RoundTripper for GET requests that records the
+// responses of next, or replays them in replay mode. The crawler sends
+// robots.txt requests through it.
+func (c *Cassette) Transport(next http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, next: next}
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	next     http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rawURL := req.URL.String()
+	if t.cassette.mode == CassetteReplay {
+		e, ok := t.cassette.Lookup(rawURL)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", rawURL, ErrNotInCassette)
+		}
+		if err := e.err(); err != nil {
+			return nil, err
+		}
+		header := make(http.Header)
+		if e.ContentType != "" {
+			header.Set("Content-Type", e.ContentType)
+		}
+		if e.FinalURL != "" && e.FinalURL != rawURL {
+			header.Set("Location", e.FinalURL)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+			StatusCode:    e.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(e.Body)),
+			ContentLength: int64(len(e.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	e := CassetteEntry{URL: rawURL, Time: time.Now()}
+	if err != nil {
+		e.Error = err.Error()
+		t.cassette.Record(e)
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	e.StatusCode = resp.StatusCode
+	e.ContentType = resp.Header.Get("Content-Type")
+	e.FinalURL = rawURL
+	if location := resp.Header.Get("Location"); location != "" {
+		e.FinalURL = location
+	}
+	e.Body = body
+	t.cassette.Record(e)
+	return resp, nil
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCassetteRecordReplay(t *testing.T) {
+	site := NewTestSite(TestSiteOptions{Pages: 15, Redirects: 2, Disallowed: 1})
+	recordDir := t.TempDir()
+	config := selfTestConfig(site, recordDir)
+	config.Cassette = CassetteRecord
+	recorded, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("record crawl failed: %v", err)
+	}
+	site.Close()
+
+	cassettePath := filepath.Join(recordDir, CassetteFile)
+	entries, err := LoadCassette(cassettePath)
+	if err != nil {
+		t.Fatalf("LoadCassette() error = %v", err)
+	}
+	// Every page, fetched once, plus robots.txt
+	if len(entries) != site.Pages()+1 {
+		t.Errorf("cassette has %d entries, want %d", len(entries), site.Pages()+1)
+	}
+
+	// Replay into a new directory with the server gone
+	replayDir := t.TempDir()
+	config = selfTestConfig(site, replayDir)
+	config.Cassette = CassetteReplay
+	config.CassetteFile = cassettePath
+	config.Delay = 0
+	replayed, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("replay crawl failed: %v", err)
+	}
+
+	want, got := recorded.GetMetrics().GetSnapshot(), replayed.GetMetrics().GetSnapshot()
+	if got.URLsSaved != want.URLsSaved || got.URLsErrored != 0 || got.RobotsBlocked != want.RobotsBlocked {
+		t.Errorf("replay saved %d, errored %d, blocked %d; recording saved %d, blocked %d",
+			got.URLsSaved, got.URLsErrored, got.RobotsBlocked, want.URLsSaved, want.RobotsBlocked)
+	}
+	if err := expectSaved(site, replayDir, site.PagesWithin(site.Pages())); err != nil {
+		t.Error(err)
+	}
+
+	// Replaying again into the same directory starts over instead of finding the crawl done
+	again, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("second replay failed: %v", err)
+	}
+	if saved := again.GetMetrics().GetSnapshot().URLsSaved; saved != want.URLsSaved {
+		t.Errorf("second replay saved %d pages, want %d", saved, want.URLsSaved)
+	}
+}
+
+func TestCassetteReplayErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CassetteFile)
+	if _, err := OpenCassette(path, CassetteReplay); err == nil {
+		t.Error("expected an error replaying a missing cassette")
+	}
+
+	c, _ := OpenCassette(path, CassetteRecord)
+	c.Record(CassetteEntry{URL: "https://example.com/big", Error: "fetch failed: " + ErrBodyTooLarge.Error()})
+	c.Close()
+	// A half-written last line, as a crash leaves it, is ignored
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"url":"https://example.com/cut","body":"PGh0`)
+	f.Close()
+
+	c, err := OpenCassette(path, CassetteReplay)
+	if err != nil {
+		t.Fatalf("OpenCassette() error = %v", err)
+	}
+	fetcher := NewCassetteFetcher(nil, c)
+	if _, err := fetcher.Fetch("https://example.com/big", ""); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("expected replayed ErrBodyTooLarge, got %v", err)
+	}
+	if _, err := fetcher.Fetch("https://example.com/other", ""); !errors.Is(err, ErrNotInCassette) {
+		t.Errorf("expected ErrNotInCassette, got %v", err)
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected 1 replayable URL, got %d", c.Len())
+	}
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrCrawlFinished is returned by Checkpoint when the crawl loop has already
+// ended; the crawl saves its final state itself
+var ErrCrawlFinished = errors.New("crawl already finished")
+
+// checkpointPollInterval is how often Checkpoint checks whether the crawl loop has paused
+const checkpointPollInterval = 20 * time.Millisecond
+
+// loopState reports whether the crawl loop is blocked waiting for Resume
+// and whether it has ended
+func (c *Crawler) loopState() (parked, done bool) {
+	c.pauseMu.Lock()
+	defer c.pauseMu.Unlock()
+	return c.parked, c.loopDone
+}
+
+// Checkpoint pauses the crawl, waits until the pages in flight are done and
+// saves the state file, URL inventory and redirects, so a later crawl with the
+// same state file continues from exactly this point. The crawler stays paused. It
+// fails when the crawl loop does not pause before ctx ends, e.g. while a
+// slow page is still being fetched or the crawl is waiting for login.
+func (c *Crawler) Checkpoint(ctx context.Context) error {
+	if !c.IsPaused() {
+		c.Pause()
+	}
+
+	ticker := time.NewTicker(checkpointPollInterval)
+	defer ticker.Stop()
+	for {
+		parked, done := c.loopState()
+		if done {
+			return ErrCrawlFinished
+		}
+		if parked {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("crawl did not pause in time: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	// Concurrent crawls still finish the pages their workers picked up. The
+	// count is polled rather than waited on, as a Resume after a timeout
+	// starts new workers.
+	for c.activeWorkers.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("workers did not finish in time: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	if err := c.saveState(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	if err := c.writeURLInventory(); err != nil {
+		return err
+	}
+	return c.writeRedirects()
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint(t *testing.T) {
+	const pages = 15
+	body := strings.Repeat("Some meaningful content for the page. ", 10)
+
+	for _, concurrent := range []bool{false, true} {
+		t.Run(fmt.Sprintf("concurrent=%v", concurrent), func(t *testing.T) {
+			var mu sync.Mutex
+			fetched := make(map[string]int)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			})
+			// A chain of pages, each linking to the next
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				fetched[r.URL.Path]++
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/p"))
+				next := ""
+				if n+1 < pages {
+					next = fmt.Sprintf(`<a href="/p%d">next</a>`, n+1)
+				}
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprintf(w, `<html><body><p>%s %s</p>%s</body></html>`, r.URL.Path, body, next)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			outputDir := t.TempDir()
+			config := Config{
+				URL:              server.URL + "/p0",
+				MaxDepth:         pages,
+				Concurrent:       concurrent,
+				Workers:          2,
+				OutputDir:        outputDir,
+				StateFile:        filepath.Join(outputDir, "state.json"),
+				MinContentLength: 10,
+			}
+			fetchCount := func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(fetched)
+			}
+
+			c, err := NewCrawler(config, context.Background())
+			if err != nil {
+				t.Fatalf("NewCrawler() error = %v", err)
+			}
+			done := make(chan error, 1)
+			go func() { done <- c.Start() }()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for fetchCount() < 3 {
+				if time.Now().After(deadline) {
+					t.Fatal("crawl made no progress")
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := c.Checkpoint(ctx); err != nil {
+				t.Fatalf("Checkpoint() error = %v", err)
+			}
+			if !c.IsPaused() {
+				t.Error("expected crawler to stay paused after checkpoint")
+			}
+
+			checkpointed := fetchCount()
+			time.Sleep(50 * time.Millisecond)
+			if fetchCount() != checkpointed {
+				t.Fatalf("crawl kept fetching after checkpoint: %d -> %d", checkpointed, fetchCount())
+			}
+
+			state, err := LoadState(config.StateFile, config.URL)
+			if err != nil {
+				t.Fatalf("LoadState() error = %v", err)
+			}
+			if len(state.Visited) != checkpointed || len(state.Queue) == 0 {
+				t.Fatalf("expected %d visited URLs and a queue, got %d visited, %d queued", checkpointed, len(state.Visited), len(state.Queue))
+			}
+			if _, err := LoadURLInventory(outputDir); err != nil {
+				t.Errorf("expected URL inventory to be written: %v", err)
+			}
+
+			c.Stop()
+			<-done
+			c.Close()
+
+			// A new crawl with the same state file picks up where the checkpoint left off
+			c, err = NewCrawler(config, context.Background())
+			if err != nil {
+				t.Fatalf("NewCrawler() error = %v", err)
+			}
+			defer c.Close()
+			if err := c.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(fetched) != pages {
+				t.Errorf("expected all %d pages fetched, got %d", pages, len(fetched))
+			}
+			for path, n := range fetched {
+				if n != 1 {
+					t.Errorf("%s fetched %d times, want 1", path, n)
+				}
+			}
+		})
+	}
+}
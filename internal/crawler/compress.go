@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Output compression methods for stored HTML files
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+var (
+	zstdEncoderOnce sync.Once
+	zstdEncoder     *zstd.Encoder
+	zstdEncoderErr  error
+)
+
+// ValidCompression reports whether method is a supported output compression
+// method. An empty method is treated as CompressionNone.
+func ValidCompression(method string) bool {
+	switch method {
+	case "", CompressionNone, CompressionGzip, CompressionZstd:
+		return true
+	}
+	return false
+}
+
+// compressionExt returns the file extension appended to compressed files
+func compressionExt(method string) string {
+	switch method {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// compressData compresses data with the given method
+func compressData(method string, data []byte) ([]byte, error) {
+	switch method {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		// A single encoder is safe for concurrent EncodeAll calls
+		zstdEncoderOnce.Do(func() {
+			zstdEncoder, zstdEncoderErr = zstd.NewWriter(nil)
+		})
+		if zstdEncoderErr != nil {
+			return nil, zstdEncoderErr
+		}
+		return zstdEncoder.EncodeAll(data, nil), nil
+	}
+	return data, nil
+}
+
+// writeOutputFile writes data to path, compressing it when compression is
+// enabled. It returns the path actually written, which carries the
+// compression extension.
+func writeOutputFile(path string, data []byte, method string) (string, error) {
+	compressed, err := compressData(method, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress %s: %v", path, err)
+	}
+	path += compressionExt(method)
+	return path, os.WriteFile(path, compressed, 0644)
+}
+
+// ResolveOutputFile returns the on-disk location of a stored output file.
+// If path does not exist, its .zst and .gz variants are tried, so callers can
+// use the uncompressed name regardless of how the crawl was configured.
+// Pointer files of content-addressable crawls resolve to their blob.
+func ResolveOutputFile(path string) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	for _, ext := range []string{".zst", ".gz"} {
+		if _, err := os.Stat(path + ext); err == nil {
+			return path + ext, nil
+		}
+	}
+	for _, ext := range []string{"", ".zst", ".gz"} {
+		if blob, err := readBlobPointer(path + ext + BlobPointerExt); err == nil {
+			return blob, nil
+		}
+	}
+	return "", &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+}
+
+// OpenOutputFile opens a stored output file and decompresses it on the fly
+// based on its extension. The returned name has the compression extension
+// stripped.
+func OpenOutputFile(path string) (io.ReadCloser, string, error) {
+	resolved, err := ResolveOutputFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case strings.HasSuffix(resolved, ".zst"):
+		zr, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			f.Close()
+			return nil, "", err
+		}
+		return &decompressReader{Reader: zr, closeFn: func() error {
+			zr.Close()
+			return f.Close()
+		}}, strings.TrimSuffix(resolved, ".zst"), nil
+	case strings.HasSuffix(resolved, ".gz"):
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, "", err
+		}
+		return &decompressReader{Reader: zr, closeFn: func() error {
+			zr.Close()
+			return f.Close()
+		}}, strings.TrimSuffix(resolved, ".gz"), nil
+	}
+	return f, resolved, nil
+}
+
+// ReadOutputFile reads a stored output file, decompressing it if needed
+func ReadOutputFile(path string) ([]byte, error) {
+	rc, _, err := OpenOutputFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// decompressReader closes both the decompressor and the underlying file
+type decompressReader struct {
+	io.Reader
+	closeFn func() error
+}
+
+func (d *decompressReader) Close() error {
+	return d.closeFn()
+}
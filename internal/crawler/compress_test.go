@@ -0,0 +1,111 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFileRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("<p>compressible content</p>", 100))
+
+	tests := []struct {
+		method  string
+		wantExt string
+	}{
+		{CompressionNone, ""},
+		{"", ""},
+		{CompressionGzip, ".gz"},
+		{CompressionZstd, ".zst"},
+	}
+
+	for _, tt := range tests {
+		t.Run("method="+tt.method, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "page.html")
+
+			written, err := writeOutputFile(path, data, tt.method)
+			if err != nil {
+				t.Fatalf("writeOutputFile() error = %v", err)
+			}
+			if written != path+tt.wantExt {
+				t.Errorf("written path = %q, want %q", written, path+tt.wantExt)
+			}
+
+			if tt.wantExt != "" {
+				raw, _ := os.ReadFile(written)
+				if len(raw) >= len(data) {
+					t.Errorf("compressed size %d not smaller than %d", len(raw), len(data))
+				}
+			}
+
+			// Reading by the uncompressed name must find and decompress the file
+			got, err := ReadOutputFile(path)
+			if err != nil {
+				t.Fatalf("ReadOutputFile() error = %v", err)
+			}
+			if string(got) != string(data) {
+				t.Error("ReadOutputFile() returned different content")
+			}
+
+			rc, name, err := OpenOutputFile(written)
+			if err != nil {
+				t.Fatalf("OpenOutputFile() error = %v", err)
+			}
+			rc.Close()
+			if name != path {
+				t.Errorf("OpenOutputFile() name = %q, want %q", name, path)
+			}
+		})
+	}
+}
+
+func TestReadOutputFileMissing(t *testing.T) {
+	_, err := ReadOutputFile(filepath.Join(t.TempDir(), "missing.html"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestSaveContentCompressed(t *testing.T) {
+	outputDir := t.TempDir()
+	c := &Crawler{
+		config: Config{OutputDir: outputDir, CompressOutput: CompressionZstd},
+		log:    &Logger{},
+		index:  NewIndexBuilder(outputDir, 0),
+	}
+
+	body := `<html><head><title>Article</title></head><body><article><h1>Compressed</h1><p>` +
+		strings.Repeat("This article body is long enough to be extracted as the main content. ", 10) +
+		`</p></article></body></html>`
+	page, err := NewPageDocument("https://example.com/article", []byte(body))
+	if err != nil {
+		t.Fatalf("NewPageDocument() error = %v", err)
+	}
+	if err := c.saveContent("https://example.com/article", page); err != nil {
+		t.Fatalf("saveContent() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "article.html.zst")); err != nil {
+		t.Errorf("compressed HTML file missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "article.html")); !os.IsNotExist(err) {
+		t.Error("uncompressed HTML file should not be written")
+	}
+
+	entry, err := loadPageEntry(outputDir, filepath.Join(outputDir, "article.meta.json"))
+	if err != nil {
+		t.Fatalf("loadPageEntry() error = %v", err)
+	}
+	if entry.Filename != "article.html.zst" {
+		t.Errorf("Filename = %q, want article.html.zst", entry.Filename)
+	}
+	if entry.HasContent {
+		if entry.ContentFile != "article.content.html.zst" {
+			t.Errorf("ContentFile = %q, want article.content.html.zst", entry.ContentFile)
+		}
+		if !strings.Contains(entry.Excerpt, "article body") {
+			t.Errorf("Excerpt not read from compressed content file: %q", entry.Excerpt)
+		}
+	}
+}
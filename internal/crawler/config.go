@@ -2,8 +2,8 @@ package crawler
 
 import (
 	"fmt"
+	"math"
 	"net/url"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -13,6 +13,9 @@ import (
 // MaxDirNameLength is the maximum length for directory names to avoid filesystem issues
 const MaxDirNameLength = 100
 
+// DefaultOutputRoot is the directory default output directories are created in
+const DefaultOutputRoot = "backup"
+
 // FetchMode determines how pages are fetched
 type FetchMode string
 
@@ -26,6 +29,8 @@ const (
 // AntiBotConfig holds anti-bot bypass configuration options
 // These options are only effective when using browser mode with headless disabled
 type AntiBotConfig struct {
+	Profile string `json:"profile"` // Named set of the options below: off, low, standard or aggressive; see Effective
+
 	// Browser Fingerprint Modifications
 	HideWebdriver  bool `json:"hideWebdriver"`  // Removes navigator.webdriver flag
 	SpoofPlugins   bool `json:"spoofPlugins"`   // Injects realistic navigator.plugins
@@ -41,7 +46,7 @@ type AntiBotConfig struct {
 	RandomClickOffset    bool `json:"randomClickOffset"`    // Small offset from element center
 
 	// Browser Properties
-	RotateUserAgent bool   `json:"rotateUserAgent"` // Cycle through UA strings
+	RotateUserAgent bool   `json:"rotateUserAgent"` // Cycle through UA strings (both fetch modes; see Config.UserAgentPool)
 	RandomViewport  bool   `json:"randomViewport"`  // Use common screen resolutions
 	MatchTimezone   bool   `json:"matchTimezone"`   // Enable timezone override
 	Timezone        string `json:"timezone"`        // Explicit timezone (e.g., America/New_York)
@@ -56,32 +61,108 @@ type PaginationConfig struct {
 	WaitAfterClick  time.Duration `json:"waitAfterClick"`  // Time to wait after each click (default: 2s)
 	WaitSelector    string        `json:"waitSelector"`    // Optional: wait for this element to appear after click
 	StopOnDuplicate bool          `json:"stopOnDuplicate"` // Stop if same content is seen twice
+	HarvestURLs     bool          `json:"harvestUrls"`     // Queue the URLs clicks navigate to (e.g. via history.pushState) as regular pages instead of saving them as pagination pages
 }
 
 // Config holds all configuration options for the crawler
 type Config struct {
 	URL                string
 	Concurrent         bool
+	Workers            int // Simultaneous requests in concurrent mode (0 = DefaultWorkers)
 	Delay              time.Duration
 	MaxDepth           int
+	DepthMode          string // What MaxDepth counts: "links" (link hops, default) or "path" (path segments beyond the start URL or prefix filter)
+	MaxPages           int // Stop after N pages have been saved in this run (0 = unlimited)
+	MaxRuntime         time.Duration // Stop after this run has crawled this long (0 = unlimited)
+	MaxConsecutiveErrors int // Stop after N fetches failed in a row (0 = never)
+	URLTimeout         time.Duration // Hard deadline for fetching, parsing and saving one URL, after which it is given up as an error (0 = none)
+	StopPattern        string // Stop once every discovered URL matching this regex was fetched ("" = never)
 	OutputDir          string
 	StateFile          string
+	StateMerge         bool // Resume a state file saved for another start URL by merging it: URLs of its http/https or www variant are rewritten to the new one
+	FrontierLimit      int  // Queued URLs kept in memory; the rest of the queue spills to disk next to the state file (0 = unlimited)
 	PrefixFilterURL    string
+	AllowedHosts       []string // Only queue links to these hosts; "*.example.com" matches its subdomains (empty = any host)
+	DeniedHosts        []string // Never queue links to these hosts, matched like AllowedHosts and checked first
+	ExternalLinks      bool     // Record links left out by the host or prefix filter to external_links.jsonl, without fetching them
+	Report             bool     // Write report.html, a shareable summary with charts and errors, when the crawl ends
 	ExcludeExtensions  []string
 	LinkSelectors      []string
 	Verbose            bool
 	UserAgent          string
+	UserAgentPool      string   // Built-in set AntiBot.RotateUserAgent cycles through: desktop (default), mobile or all
+	UserAgents         []string // Own user agents AntiBot.RotateUserAgent cycles through instead of a built-in set
+	StickyUserAgent    bool     // Keep the user agent first picked for a host for all of its pages
+	WarmUp             bool     // Visit each host's homepage before its first deeper URL, keeping the session's cookies
+	WarmUpPages        int      // Internal pages linked from the homepage also visited during warm-up (0 to MaxWarmUpPages)
+	RefererPolicy      string   // Referer sent with requests: none, origin, strict or full ("" = strict with WarmUp, none without)
 	IgnoreRobots       bool
 	MinContentLength   int
+	MaxContentLength   int     // Skip pages with more visible text than this many characters (0 = no limit)
+	MinWords           int     // Skip pages with fewer visible words (0 = no limit)
+	MaxWords           int     // Skip pages with more visible words (0 = no limit)
+	MaxLinkDensity     float64 // Skip pages whose share of words inside links is higher, from 0 to 1 (0 = no limit)
+	MaxHTMLSize        int64 // Largest page body in bytes that will be parsed (0 = DefaultMaxHTMLSize)
 	ShowProgress       bool
+	ProgressPanel      bool   // Show a multi-line live panel instead of the progress line when stdout is a terminal
+	ProgressFormat     string // Progress output: "text" (default) or "ndjson" records on stdout
+	Notify             string // Announce the end or failure of the crawl: "off" (default), "desktop" (bell where no notifier is found) or "bell"
 	MetricsFile        string
+	MetricsInterval    time.Duration // Time between metrics time series samples (0 = DefaultMetricsInterval)
 	DisableContentExtraction bool
+	CompressOutput     string // Compress stored HTML files: "none", "gzip" or "zstd"
 	FetchMode          FetchMode
 	Headless           bool
 	WaitForLogin       bool
 	AntiBot            AntiBotConfig
+	Geo                GeoConfig // Accept-Language, locale/timezone/geolocation emulation and proxy
 	Pagination         PaginationConfig
 	PageLoadWait       time.Duration // Time to wait after page load for dynamic content (browser mode only)
+	HARMode            string        // Record network activity as HAR: "off", "page" or "crawl" (browser mode only)
+	DiscoverAPIs       bool          // Log XHR/fetch endpoints to api_endpoints.jsonl (browser mode only)
+	PageScripts        []PageScript  // JavaScript run after load on pages matching each pattern (browser mode only)
+	KeepCookieBanners  bool          // Don't dismiss cookie consent banners before capture (browser mode only)
+	LazyLoadScroll     bool          // Scroll through each page before capture to load lazy images and content (browser mode only)
+	ScrollStep         int           // Pixels per lazy-load scroll step (0 = one viewport height)
+	ScrollDelay        time.Duration // Pause after each lazy-load scroll step (0 = DefaultScrollDelay)
+	ShadowDOM          string        // Save web component shadow roots: "off", "declarative" or "flatten" (browser mode only)
+	BrowserRecycle     int           // Relaunch the browser after this many pages to reclaim leaked memory, keeping cookies (0 = never; browser mode only)
+	BrowserPath        string        // Chrome binary to run, e.g. a downloaded Chrome for Testing ("" = a system-installed Chrome found on PATH)
+	BrowserMaxMemory   int           // Relaunch the browser when its processes use more than this many MB of memory (0 = unlimited; Linux only)
+	BrowserMaxTabs     int           // Tabs open at once; fetches wait for a free one (0 = unlimited)
+	BrowserMaxProcesses int          // Chrome processes, renderers included; caps renderers and relaunches the browser beyond it (0 = unlimited; relaunch on Linux only)
+	Provenance         string        // Record source URL, fetch time, crawler version and job in saved pages: "off", "comment" or "banner"
+	ContentAddressable bool          // Store each distinct file once in _blobs/ under its hash, with per-URL pointer files
+	Iframes            string        // Capture iframe documents: "off", "link" (saved as pages of their own) or "inline" (into the page)
+	IframeHosts        []string      // Cross-origin hosts whose iframes are captured too, matched like AllowedHosts (browser mode only)
+	ContentFilters     []ContentFilter // Elements stripped or kept on pages matching each pattern before saving and extraction
+	FollowOnly         []FollowOnlyRule // Pages whose URL or title matches are traversed for links but never saved
+	Alerts             []AlertRule      // Patterns or keywords watched for in saved pages, matches recorded in alerts.jsonl
+	PluginsDir         string           // Directory of JavaScript plugins filtering URLs, transforming and extracting from pages ("" = none)
+	Permissions        OutputPermissions // Modes and owner of written files and directories
+	IndexInterval      int           // Rewrite _index.html every N saved pages (0 = only at completion)
+	RecrawlURLs        []string      // Fetch only these URLs of a previous crawl into OutputDir, without following links
+	RecrawlScope       string        // Scope the re-crawl URLs were selected with; "changed" keeps unchanged pages as they are
+	Faults             FaultConfig   // Artificial latency and failures injected into fetches, for development
+	HostLimiter        *HostLimiter  // Spaces out requests to each host across the crawlers sharing it (nil = none)
+	Polite             *PolitePolicy // Guardrails enforced on the crawl: robots.txt, delay floor, per-host concurrency, contactable user agent (nil = none)
+	JobID              string        // API or MCP job the crawl runs as, recorded in provenance blocks ("" = none)
+	Cassette           string        // Record responses to a cassette or replay them from it: "off", "record" or "replay"
+	CassetteFile       string        // Cassette path (default cassette.jsonl in OutputDir)
+	TraceDecisions     string        // JSON Lines file recording every URL considered and the rule that accepted or rejected it ("" = off)
+	ResultsDB          string        // SQLite database of pages, links, errors, redirects and metrics samples, relative to OutputDir unless absolute ("" = off)
+	Embeddings         EmbeddingsConfig // Vectors of each saved page's text from an OpenAI-compatible embeddings endpoint
+	Enrichment         EnrichmentConfig // Summary, tags, ... of each saved page from an HTTP endpoint, added to its .meta.json
+	PostSaveCommand     string        // Shell command run for each saved page, with the file as $1 and $SCRAPER_FILE and its metadata JSON on stdin ("" = none)
+	PostSaveConcurrency int           // Post-save commands running at once (0 = DefaultPostSaveConcurrency)
+	PostSaveTimeout     time.Duration // A post-save command is killed after this long (0 = DefaultPostSaveTimeout)
+	PostSaveOnFailure   string        // After a failed post-save command: "warn" (default), "disable" or "stop"
+	WaybackFallback     bool          // Save the latest Wayback Machine snapshot of pages answering 404 or 410 or timing out, marked archived_source in .meta.json
+	WaybackSave         bool          // Submit the URL of each saved page to the Wayback Machine's Save Page Now
+	WaybackSaveKey      string        // archive.org API key as accesskey:secret, or secret://name (default: $SCRAPER_WAYBACK_KEY; anonymous without one)
+	WaybackSaveInterval time.Duration // Time between submissions (0 = DefaultWaybackSaveInterval)
+	EventSinks         []EventSink   // Files, webhooks, NATS subjects, Kafka topics and Elasticsearch indexes the crawl events are also sent to
+	Tracing            TracingConfig // OpenTelemetry spans of each URL's robots check, fetch, parse, extraction and save, exported over OTLP
 	// URL normalization options for better duplicate detection
 	NormalizeURLs  bool // Enable URL normalization (default: true)
 	LowercasePaths bool // Lowercase URL paths during normalization (default: false)
@@ -107,10 +188,79 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("URL must have a host")
 	}
 
+	if err := validateHostLists(config, parsedURL.Hostname()); err != nil {
+		return err
+	}
+
+	if err := ValidateEventSinks(config.EventSinks); err != nil {
+		return err
+	}
+	if err := validateTracing(config.Tracing); err != nil {
+		return err
+	}
+	if err := validateEmbeddings(config.Embeddings, config.ResultsDB); err != nil {
+		return err
+	}
+	if err := validateEnrichment(config.Enrichment); err != nil {
+		return err
+	}
+	if err := validatePostSave(config); err != nil {
+		return err
+	}
+	if err := validateWaybackSave(config); err != nil {
+		return err
+	}
+	if !ValidAntiBotProfile(config.AntiBot.Profile) {
+		return fmt.Errorf("anti-bot profile must be one of: %s, got: %s", strings.Join(AntiBotProfiles(), ", "), config.AntiBot.Profile)
+	}
+	if err := validateUserAgents(config); err != nil {
+		return err
+	}
+	if err := validateWarmUp(config); err != nil {
+		return err
+	}
+
 	// Validate MaxDepth
 	if config.MaxDepth <= 0 {
 		return fmt.Errorf("depth must be greater than 0, got: %d", config.MaxDepth)
 	}
+	if !ValidDepthMode(config.DepthMode) {
+		return fmt.Errorf("depth mode must be %s or %s, got: %s", DepthModeLinks, DepthModePath, config.DepthMode)
+	}
+
+	// Validate MaxPages
+	if config.MaxPages < 0 {
+		return fmt.Errorf("max-pages cannot be negative, got: %d", config.MaxPages)
+	}
+	if config.FrontierLimit < 0 {
+		return fmt.Errorf("frontier-limit cannot be negative, got: %d", config.FrontierLimit)
+	}
+
+	// Validate the other stop conditions
+	if config.MaxRuntime < 0 {
+		return fmt.Errorf("max-runtime cannot be negative, got: %v", config.MaxRuntime)
+	}
+	if config.BrowserRecycle < 0 {
+		return fmt.Errorf("browser-recycle cannot be negative, got: %d", config.BrowserRecycle)
+	}
+	if config.BrowserMaxMemory < 0 {
+		return fmt.Errorf("browser-max-memory cannot be negative, got: %d", config.BrowserMaxMemory)
+	}
+	if config.BrowserMaxTabs < 0 {
+		return fmt.Errorf("browser-max-tabs cannot be negative, got: %d", config.BrowserMaxTabs)
+	}
+	if config.BrowserMaxProcesses < 0 || (config.BrowserMaxProcesses > 0 && config.BrowserMaxProcesses <= BrowserBaseProcesses) {
+		return fmt.Errorf("browser-max-processes must be 0 (unlimited) or more than %d, Chrome's processes besides renderers, got: %d", BrowserBaseProcesses, config.BrowserMaxProcesses)
+	}
+	if config.URLTimeout < 0 {
+		return fmt.Errorf("url-timeout cannot be negative, got: %v", config.URLTimeout)
+	}
+	if config.MaxConsecutiveErrors < 0 {
+		return fmt.Errorf("max-consecutive-errors cannot be negative, got: %d", config.MaxConsecutiveErrors)
+	}
+	if _, err := compileStopPattern(config.StopPattern); err != nil {
+		return fmt.Errorf("invalid stop pattern: %v", err)
+	}
 
 	// Validate Delay (duration can't be negative from flag parsing, but check anyway)
 	if config.Delay < 0 {
@@ -122,11 +272,155 @@ func ValidateConfig(config *Config) error {
 		return fmt.Errorf("min-content cannot be negative, got: %d", config.MinContentLength)
 	}
 
+	// Validate the page filters
+	if config.MaxContentLength < 0 {
+		return fmt.Errorf("max-content cannot be negative, got: %d", config.MaxContentLength)
+	}
+	if config.MaxContentLength > 0 && config.MaxContentLength <= config.MinContentLength {
+		return fmt.Errorf("max-content must be greater than min-content, got: %d <= %d", config.MaxContentLength, config.MinContentLength)
+	}
+	if config.MinWords < 0 || config.MaxWords < 0 {
+		return fmt.Errorf("word limits cannot be negative, got: min-words %d, max-words %d", config.MinWords, config.MaxWords)
+	}
+	if config.MaxWords > 0 && config.MaxWords < config.MinWords {
+		return fmt.Errorf("max-words must not be less than min-words, got: %d < %d", config.MaxWords, config.MinWords)
+	}
+	if config.MaxLinkDensity < 0 || config.MaxLinkDensity > 1 || math.IsNaN(config.MaxLinkDensity) {
+		return fmt.Errorf("max-link-density must be between 0 and 1, got: %v", config.MaxLinkDensity)
+	}
+
+	// Validate Workers
+	if config.Workers < 0 || config.Workers > MaxWorkers {
+		return fmt.Errorf("workers must be between 1 and %d, got: %d", MaxWorkers, config.Workers)
+	}
+
+	// Validate MaxHTMLSize
+	if config.MaxHTMLSize < 0 {
+		return fmt.Errorf("max-html-size cannot be negative, got: %d", config.MaxHTMLSize)
+	}
+
+	// Validate MetricsInterval
+	if config.MetricsInterval < 0 {
+		return fmt.Errorf("metrics-interval cannot be negative, got: %v", config.MetricsInterval)
+	}
+
+	// Validate IndexInterval
+	if config.IndexInterval < 0 {
+		return fmt.Errorf("index-interval cannot be negative, got: %d", config.IndexInterval)
+	}
+
+	if !ValidNotify(config.Notify) {
+		return fmt.Errorf("notify must be one of: %s, %s, %s, got: %s", NotifyOff, NotifyDesktop, NotifyBell, config.Notify)
+	}
+	if !ValidProgressFormat(config.ProgressFormat) {
+		return fmt.Errorf("progress-format must be one of: %s, %s, got: %s", ProgressFormatText, ProgressFormatNDJSON, config.ProgressFormat)
+	}
+
+	// Validate CompressOutput
+	if !ValidCompression(config.CompressOutput) {
+		return fmt.Errorf("compress must be one of: %s, %s, %s, got: %s", CompressionNone, CompressionGzip, CompressionZstd, config.CompressOutput)
+	}
+
 	// Validate FetchMode
 	if config.FetchMode != "" && config.FetchMode != FetchModeHTTP && config.FetchMode != FetchModeBrowser {
 		return fmt.Errorf("fetch-mode must be 'http' or 'browser', got: %s", config.FetchMode)
 	}
 
+	// Validate HARMode
+	if !ValidHARMode(config.HARMode) {
+		return fmt.Errorf("har must be one of: %s, %s, %s, got: %s", HARModeOff, HARModePage, HARModeCrawl, config.HARMode)
+	}
+	if config.HARMode != "" && config.HARMode != HARModeOff && config.FetchMode != FetchModeBrowser {
+		return fmt.Errorf("HAR capture requires browser fetch mode")
+	}
+	if config.DiscoverAPIs && config.FetchMode != FetchModeBrowser {
+		return fmt.Errorf("API discovery requires browser fetch mode")
+	}
+
+	if err := validateLazyLoad(config); err != nil {
+		return err
+	}
+	if err := validateShadowDOM(config); err != nil {
+		return err
+	}
+	if err := validateIframes(config); err != nil {
+		return err
+	}
+	if err := validateProvenance(config); err != nil {
+		return err
+	}
+
+	// Validate PageScripts
+	if len(config.PageScripts) > 0 {
+		if config.FetchMode != FetchModeBrowser {
+			return fmt.Errorf("page scripts require browser fetch mode")
+		}
+		if _, err := compilePageScripts(config.PageScripts); err != nil {
+			return err
+		}
+	}
+
+	// Validate ContentFilters
+	if _, err := compileContentFilters(config.ContentFilters); err != nil {
+		return err
+	}
+
+	// Validate FollowOnly
+	if _, err := compileFollowOnlyRules(config.FollowOnly); err != nil {
+		return err
+	}
+
+	// Validate Alerts
+	if _, err := compileAlertRules(config.Alerts); err != nil {
+		return err
+	}
+
+	// Validate PluginsDir: every plugin must compile
+	if config.PluginsDir != "" {
+		if _, err := loadPlugins(config.PluginsDir, nil); err != nil {
+			return err
+		}
+	}
+
+	// Validate polite mode: the policy itself, and settings it refuses
+	if config.Polite != nil {
+		if err := config.Polite.Validate(); err != nil {
+			return err
+		}
+		check := *config
+		if _, err := config.Polite.Enforce(&check); err != nil {
+			return err
+		}
+	}
+
+	// Validate cassette
+	if !ValidCassetteMode(config.Cassette) {
+		return fmt.Errorf("cassette must be one of: %s, %s, %s, got: %s", CassetteOff, CassetteRecord, CassetteReplay, config.Cassette)
+	}
+	if config.Cassette == CassetteReplay && config.Pagination.Enable {
+		return fmt.Errorf("click-based pagination cannot be replayed from a cassette")
+	}
+
+	// Validate fault injection
+	if err := config.Faults.Validate(); err != nil {
+		return err
+	}
+
+	// Validate re-crawl
+	if config.RecrawlScope != "" && !ValidRecrawlScope(config.RecrawlScope) {
+		return fmt.Errorf("re-crawl scope must be failed, changed or pattern, got: %s", config.RecrawlScope)
+	}
+
+	// Validate Permissions
+	if _, err := config.Permissions.parse(); err != nil {
+		return err
+	}
+
+	// Validate GeoConfig
+	if err := validateGeo(config.Geo, config.FetchMode); err != nil {
+		return err
+	}
+
 	// Validate PaginationConfig
 	if config.Pagination.Enable {
 		// Pagination requires browser mode
@@ -144,6 +438,8 @@ func ValidateConfig(config *Config) error {
 		if config.Pagination.WaitAfterClick <= 0 {
 			config.Pagination.WaitAfterClick = 2 * time.Second
 		}
+	} else if config.Pagination.HarvestURLs {
+		return fmt.Errorf("pagination URL harvesting requires pagination to be enabled")
 	}
 
 	// Validate PrefixFilterURL if provided
@@ -211,7 +507,7 @@ func SetDefaultOutputDir(config *Config) error {
 
 	// Sanitize directory name by removing/replacing invalid characters
 	dirName = SanitizeDirName(dirName)
-	config.OutputDir = filepath.Join("backup", dirName)
+	config.OutputDir = filepath.Join(DefaultOutputRoot, dirName)
 
 	return nil
 }
@@ -229,7 +525,12 @@ func SetDefaultStateFile(config *Config) {
 	config.StateFile = filepath.Join(config.OutputDir, folderName+"_state.json")
 }
 
-// EnsureOutputDir creates the output directory if it doesn't exist
+// EnsureOutputDir creates the output directory if it doesn't exist, with
+// the configured directory mode and owner
 func EnsureOutputDir(config *Config) error {
-	return os.MkdirAll(config.OutputDir, 0755)
+	perms, err := config.Permissions.parse()
+	if err != nil {
+		return err
+	}
+	return perms.mkdirAll(config.OutputDir)
 }
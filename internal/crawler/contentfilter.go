@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// ContentFilter cleans pages whose URL matches Pattern before they are
+// checked for content, saved and extracted: elements matching Remove are
+// deleted, then the body is reduced to the elements matching Keep. Links
+// are still discovered on the unfiltered page.
+type ContentFilter struct {
+	Pattern string `json:"pattern"`          // Regular expression matched against the page URL; empty matches every page
+	Keep    string `json:"keep,omitempty"`   // CSS selector of the containers to keep (e.g. "main article")
+	Remove  string `json:"remove,omitempty"` // CSS selector of the elements to strip (e.g. ".ads, .related-posts, .share")
+}
+
+// compiledContentFilter is a ContentFilter with its pattern and selectors compiled
+type compiledContentFilter struct {
+	ContentFilter
+	re     *regexp.Regexp
+	keep   goquery.Matcher
+	remove goquery.Matcher
+}
+
+// compileContentFilters compiles the patterns and selectors of filters, keeping their order
+func compileContentFilters(filters []ContentFilter) ([]compiledContentFilter, error) {
+	compiled := make([]compiledContentFilter, 0, len(filters))
+	for i, f := range filters {
+		if strings.TrimSpace(f.Keep) == "" && strings.TrimSpace(f.Remove) == "" {
+			return nil, fmt.Errorf("content filter %d has neither keep nor remove", i+1)
+		}
+		cf := compiledContentFilter{ContentFilter: f}
+		var err error
+		if cf.re, err = regexp.Compile(f.Pattern); err != nil {
+			return nil, fmt.Errorf("content filter %d has an invalid pattern: %v", i+1, err)
+		}
+		if strings.TrimSpace(f.Keep) != "" {
+			if cf.keep, err = cascadia.Compile(f.Keep); err != nil {
+				return nil, fmt.Errorf("content filter %d has an invalid keep selector: %v", i+1, err)
+			}
+		}
+		if strings.TrimSpace(f.Remove) != "" {
+			if cf.remove, err = cascadia.Compile(f.Remove); err != nil {
+				return nil, fmt.Errorf("content filter %d has an invalid remove selector: %v", i+1, err)
+			}
+		}
+		compiled = append(compiled, cf)
+	}
+	return compiled, nil
+}
+
+// ParseContentFilters reads content filters given inline as a JSON array or
+// as the path of a JSON file holding one
+func ParseContentFilters(value string) ([]ContentFilter, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	var filters []ContentFilter
+	if err := json.Unmarshal(data, &filters); err != nil {
+		return nil, fmt.Errorf("content filters must be a JSON array of {\"pattern\", \"keep\", \"remove\"} objects: %v", err)
+	}
+	if _, err := compileContentFilters(filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// filterContent returns page with the matching content filters applied, as
+// a new document so the shared one stays intact for link discovery. Without
+// a matching filter page itself is returned.
+func (c *Crawler) filterContent(page *PageDocument) *PageDocument {
+	var matching []compiledContentFilter
+	for _, f := range c.filters {
+		if f.re.MatchString(page.URL) {
+			matching = append(matching, f)
+		}
+	}
+	if len(matching) == 0 {
+		return page
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.Body))
+	if err != nil {
+		c.log.Debug("Not filtering %s: %v", page.URL, err)
+		return page
+	}
+	for _, f := range matching {
+		if f.remove != nil {
+			doc.FindMatcher(f.remove).Remove()
+		}
+		if f.keep != nil && !keepOnly(doc, f.keep) {
+			c.log.Debug("Content filter %q: nothing on %s matches %q, keeping the whole body", f.Pattern, page.URL, f.Keep)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc.Nodes[0]); err != nil {
+		c.log.Debug("Not filtering %s: %v", page.URL, err)
+		return page
+	}
+	return &PageDocument{URL: page.URL, Body: buf.Bytes(), Doc: doc, UserAgent: page.UserAgent}
+}
+
+// keepOnly replaces the children of <body> with the outermost elements
+// matching keep, in document order. It reports false, leaving the document
+// unchanged, when no element matches.
+func keepOnly(doc *goquery.Document, keep goquery.Matcher) bool {
+	body := doc.Find("body").First()
+	kept := body.FindMatcher(keep)
+	// Nested matches come along with their outermost match
+	kept = kept.NotSelection(kept.FindMatcher(keep))
+	if kept.Length() == 0 {
+		return false
+	}
+	kept.Remove()
+	body.Contents().Remove()
+	body.AppendSelection(kept)
+	return true
+}
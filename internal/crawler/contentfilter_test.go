@@ -0,0 +1,168 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseContentFilters(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "filters.json")
+	os.WriteFile(file, []byte(`[{"pattern": "/blog/", "keep": "main article", "remove": ".share"}]`), 0644)
+
+	tests := []struct {
+		name     string
+		value    string
+		want     int
+		errorMsg string
+	}{
+		{"inline", `[{"pattern": "", "remove": ".ads, .related-posts"}, {"pattern": "/docs/", "keep": "main"}]`, 2, ""},
+		{"file", file, 1, ""},
+		{"malformed JSON", `[{"pattern": ".*"`, 0, "must be a JSON array"},
+		{"invalid pattern", `[{"pattern": "(", "keep": "main"}]`, 0, "content filter 1 has an invalid pattern"},
+		{"invalid keep", `[{"keep": "main["}]`, 0, "content filter 1 has an invalid keep selector"},
+		{"invalid remove", `[{"keep": "main"}, {"remove": ">>"}]`, 0, "content filter 2 has an invalid remove selector"},
+		{"no selectors", `[{"pattern": ".*", "keep": " "}]`, 0, "content filter 1 has neither keep nor remove"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := ParseContentFilters(tt.value)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("ParseContentFilters() error = %v, want containing %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseContentFilters() error = %v", err)
+			}
+			if len(filters) != tt.want {
+				t.Errorf("ParseContentFilters() returned %d filters, want %d", len(filters), tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterContent(t *testing.T) {
+	const body = `<html><head><title>Post</title></head><body>
+<nav><a href="/other">Other</a></nav>
+<main><article><h1>Post</h1><p>Text</p><div class="share">Share</div><article>Nested</article></article>
+<aside class="related-posts">Related</aside><article><p>Second</p></article></main>
+<footer>Footer</footer></body></html>`
+
+	tests := []struct {
+		name    string
+		filters []ContentFilter
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "remove",
+			filters: []ContentFilter{{Remove: ".share, .related-posts"}},
+			want:    []string{"<nav>", "Text", "<footer>"},
+			notWant: []string{"Share", "Related"},
+		},
+		{
+			name:    "keep",
+			filters: []ContentFilter{{Keep: "main article"}},
+			want:    []string{"<title>Post</title>", "Text", "Share", "Nested", "Second"},
+			notWant: []string{"<nav>", "Related", "<footer>", "<main>"},
+		},
+		{
+			name:    "remove then keep",
+			filters: []ContentFilter{{Pattern: `/blog/`, Remove: ".share"}, {Pattern: `/blog/`, Keep: "main"}},
+			want:    []string{"Text", "Related"},
+			notWant: []string{"Share", "<nav>", "<footer>"},
+		},
+		{
+			name:    "keep without match",
+			filters: []ContentFilter{{Keep: "#missing"}},
+			want:    []string{"<nav>", "Text", "<footer>"},
+		},
+		{
+			name:    "pattern does not match",
+			filters: []ContentFilter{{Pattern: `/docs/`, Remove: "nav"}},
+			want:    []string{"<nav>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := compileContentFilters(tt.filters)
+			if err != nil {
+				t.Fatalf("compileContentFilters() error = %v", err)
+			}
+			c := &Crawler{log: &Logger{}, filters: filters}
+			page, _ := NewPageDocument("https://example.com/blog/post", []byte(body))
+
+			filtered := c.filterContent(page)
+			got := string(filtered.Body)
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("filtered page lacks %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("filtered page still has %q:\n%s", s, got)
+				}
+			}
+			if strings.Count(got, "Nested") > 1 {
+				t.Errorf("nested match kept twice:\n%s", got)
+			}
+			if page.Doc.Find("nav").Length() != 1 || string(page.Body) != body {
+				t.Error("filtering changed the shared page")
+			}
+		})
+	}
+}
+
+func TestContentFiltersCrawl(t *testing.T) {
+	text := strings.Repeat("Readable article text. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><nav><a href="/next">Next</a></nav><div class="ads">Buy now</div><main><p>%s</p></main></body></html>`, text)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		ContentFilters:   []ContentFilter{{Pattern: `/$`, Keep: "main", Remove: ".ads"}},
+	}
+	c, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	// Links in stripped parts of the page are still followed
+	if saved := c.GetMetrics().GetSnapshot().URLsSaved; saved != 2 {
+		t.Errorf("saved %d pages, want 2", saved)
+	}
+	root, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if err != nil {
+		t.Fatalf("root page not saved: %v", err)
+	}
+	if strings.Contains(string(root), "Buy now") || strings.Contains(string(root), "<nav>") || !strings.Contains(string(root), "Readable") {
+		t.Errorf("root page not filtered:\n%s", root)
+	}
+	next, err := os.ReadFile(filepath.Join(outputDir, "next.html"))
+	if err != nil {
+		t.Fatalf("second page not saved: %v", err)
+	}
+	if !strings.Contains(string(next), "Buy now") {
+		t.Error("filter applied to a page its pattern does not match")
+	}
+}
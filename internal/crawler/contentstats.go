@@ -0,0 +1,196 @@
+package crawler
+
+import (
+	"fmt"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LargestPagesKept is how many of the largest responses ContentStats lists
+const LargestPagesKept = 10
+
+// contentSniffLength bounds how much of an HTML body is searched for the
+// charset declaration and the lang attribute
+const contentSniffLength = 4096
+
+// unknownValue is counted for responses without a content type, and for
+// HTML pages that declare no charset or language
+const unknownValue = "unknown"
+
+var (
+	metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.-]+)`)
+	htmlLangPattern    = regexp.MustCompile(`(?i)<html[^>]*\slang\s*=\s*["']?\s*([a-z0-9_-]+)`)
+)
+
+// ContentStats describes what a crawl fetched: every response is counted
+// by status code and content type, HTML pages by charset and language, and
+// the largest bodies are listed
+type ContentStats struct {
+	StatusCodes  map[string]int64 `json:"statuses,omitempty"`  // Responses per HTTP status code
+	ContentTypes map[string]int64 `json:"types,omitempty"`     // Responses per media type, without parameters
+	Charsets     map[string]int64 `json:"charsets,omitempty"`  // HTML pages per declared charset
+	Languages    map[string]int64 `json:"languages,omitempty"` // HTML pages per lang attribute
+	LargestPages []PageSize       `json:"largest,omitempty"`   // Largest bodies, largest first
+}
+
+// PageSize is the body size of one fetched URL
+type PageSize struct {
+	URL   string `json:"url"`
+	Bytes int64  `json:"bytes"`
+}
+
+// record counts one response
+func (s *ContentStats) record(rawURL string, result *FetchResult) {
+	if s.StatusCodes == nil {
+		s.StatusCodes = make(map[string]int64)
+		s.ContentTypes = make(map[string]int64)
+		s.Charsets = make(map[string]int64)
+		s.Languages = make(map[string]int64)
+	}
+	s.StatusCodes[strconv.Itoa(result.StatusCode)]++
+
+	mediaType, params, err := mime.ParseMediaType(result.ContentType)
+	if err != nil || mediaType == "" {
+		mediaType = unknownValue
+	}
+	s.ContentTypes[mediaType]++
+
+	if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		head := result.Body
+		if len(head) > contentSniffLength {
+			head = head[:contentSniffLength]
+		}
+		charset := strings.ToLower(params["charset"])
+		if charset == "" {
+			if m := metaCharsetPattern.FindSubmatch(head); m != nil {
+				charset = strings.ToLower(string(m[1]))
+			}
+		}
+		if charset == "" {
+			charset = unknownValue
+		}
+		s.Charsets[charset]++
+
+		lang := unknownValue
+		if m := htmlLangPattern.FindSubmatch(head); m != nil {
+			lang = strings.ToLower(string(m[1]))
+		}
+		s.Languages[lang]++
+	}
+
+	s.addPage(PageSize{URL: rawURL, Bytes: int64(len(result.Body))})
+}
+
+// addPage keeps page if it is among the largest seen so far
+func (s *ContentStats) addPage(page PageSize) {
+	if page.Bytes == 0 {
+		return
+	}
+	if len(s.LargestPages) == LargestPagesKept && page.Bytes <= s.LargestPages[len(s.LargestPages)-1].Bytes {
+		return
+	}
+	i := sort.Search(len(s.LargestPages), func(i int) bool {
+		return s.LargestPages[i].Bytes < page.Bytes
+	})
+	s.LargestPages = append(s.LargestPages, PageSize{})
+	copy(s.LargestPages[i+1:], s.LargestPages[i:])
+	s.LargestPages[i] = page
+	if len(s.LargestPages) > LargestPagesKept {
+		s.LargestPages = s.LargestPages[:LargestPagesKept]
+	}
+}
+
+// copy returns a deep copy of s
+func (s ContentStats) copy() ContentStats {
+	return ContentStats{
+		StatusCodes:  copyCounts(s.StatusCodes),
+		ContentTypes: copyCounts(s.ContentTypes),
+		Charsets:     copyCounts(s.Charsets),
+		Languages:    copyCounts(s.Languages),
+		LargestPages: append([]PageSize(nil), s.LargestPages...),
+	}
+}
+
+// copyCounts returns a copy of counts, nil when it is empty
+func copyCounts(counts map[string]int64) map[string]int64 {
+	if len(counts) == 0 {
+		return nil
+	}
+	out := make(map[string]int64, len(counts))
+	for k, v := range counts {
+		out[k] = v
+	}
+	return out
+}
+
+// RecordResponse counts a fetched response in the content distributions.
+// Failed fetches without a status code are not counted.
+func (m *CrawlerMetrics) RecordResponse(rawURL string, result *FetchResult) {
+	if m == nil || result == nil || result.StatusCode == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Content.record(rawURL, result)
+}
+
+// FormatContentStats renders the distributions of stats as tables, one per
+// non-empty distribution, followed by the largest pages
+func FormatContentStats(stats ContentStats) string {
+	var sb strings.Builder
+	sections := []struct {
+		title  string
+		counts map[string]int64
+	}{
+		{"Status Codes:", stats.StatusCodes},
+		{"Content Types:", stats.ContentTypes},
+		{"Charsets:", stats.Charsets},
+		{"Languages:", stats.Languages},
+	}
+	for _, section := range sections {
+		if len(section.counts) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(section.title + "\n")
+		sb.WriteString(FormatDistribution(section.counts))
+	}
+	if len(stats.LargestPages) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("Largest Pages:\n")
+		for _, p := range stats.LargestPages {
+			fmt.Fprintf(&sb, "  %10s  %s\n", FormatBytes(p.Bytes), p.URL)
+		}
+	}
+	return sb.String()
+}
+
+// FormatDistribution renders counts as lines of value, count and share,
+// most frequent first
+func FormatDistribution(counts map[string]int64) string {
+	keys := make([]string, 0, len(counts))
+	var total int64
+	for k, v := range counts {
+		keys = append(keys, k)
+		total += v
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	var sb strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "  %-30s %8d  %5.1f%%\n", k, counts[k], float64(counts[k])*100/float64(total))
+	}
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentStats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		text := strings.Repeat("Page text. ", 10)
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+			fmt.Fprintf(w, `<html lang="en"><body><p>%s</p><a href="/de">DE</a><a href="/latin">Latin</a><a href="/data.json">Data</a><a href="/missing">Missing</a></body></html>`, text)
+		case "/de":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<!DOCTYPE html><html class="x" lang="de-DE"><head><meta charset="utf-8"></head><body><p>%s%s</p></body></html>`, text, text)
+		case "/latin":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><meta http-equiv="Content-Type" content="text/html; charset=ISO-8859-1"></head><body><p>%s</p></body></html>`, text)
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"text": "data"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+	}
+	c, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	stats := c.GetMetrics().GetSnapshot().Content
+
+	checkCounts := func(name string, got, want map[string]int64) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("%s = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+	checkCounts("status codes", stats.StatusCodes, map[string]int64{"200": 4, "404": 1})
+	checkCounts("content types", stats.ContentTypes, map[string]int64{"text/html": 3, "application/json": 1, "text/plain": 1})
+	checkCounts("charsets", stats.Charsets, map[string]int64{"utf-8": 2, "iso-8859-1": 1})
+	checkCounts("languages", stats.Languages, map[string]int64{"en": 1, "de-de": 1, "unknown": 1})
+
+	if len(stats.LargestPages) != 5 {
+		t.Fatalf("largest pages = %+v, want all 5 responses", stats.LargestPages)
+	}
+	if stats.LargestPages[0].URL != server.URL+"/de" {
+		t.Errorf("largest page = %s, want /de", stats.LargestPages[0].URL)
+	}
+	for i := 1; i < len(stats.LargestPages); i++ {
+		if stats.LargestPages[i].Bytes > stats.LargestPages[i-1].Bytes {
+			t.Errorf("largest pages not sorted: %+v", stats.LargestPages)
+		}
+	}
+
+	summary := FormatContentStats(stats)
+	for _, want := range []string{"Status Codes:", "Content Types:", "Charsets:", "Languages:", "Largest Pages:", "  200 "} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+}
+
+func TestContentStatsKeepsLargestPages(t *testing.T) {
+	var stats ContentStats
+	for i := 1; i <= 25; i++ {
+		stats.addPage(PageSize{URL: fmt.Sprintf("/%d", i), Bytes: int64((i * 7) % 25)})
+	}
+	if len(stats.LargestPages) != LargestPagesKept {
+		t.Fatalf("kept %d pages, want %d", len(stats.LargestPages), LargestPagesKept)
+	}
+	for i, p := range stats.LargestPages {
+		if want := int64(24 - i); p.Bytes != want {
+			t.Errorf("page %d has %d bytes, want %d", i, p.Bytes, want)
+		}
+	}
+}
+
+func TestFormatDistribution(t *testing.T) {
+	got := FormatDistribution(map[string]int64{"b": 1, "a": 1, "c": 2})
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines:\n%s", len(lines), got)
+	}
+	for i, prefix := range []string{"  c ", "  a ", "  b "} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want it to start with %q", i, lines[i], prefix)
+		}
+	}
+	if !strings.HasSuffix(lines[0], "50.0%") {
+		t.Errorf("share of c = %q, want 50.0%%", lines[0])
+	}
+}
@@ -0,0 +1,272 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StatusFile is the file in the output directory a status dump is written to
+const StatusFile = "status.json"
+
+// ControlCommands lists the commands accepted on a control socket
+var ControlCommands = []string{"status", "metrics", "pause", "resume", "toggle", "verbose", "quiet", "errors [class]", "retry <url...|class|all>", "stop"}
+
+// CrawlStatus is a point-in-time summary of a running crawl
+type CrawlStatus struct {
+	State   string         `json:"state"` // running, paused or stopping
+	URL     string         `json:"url"`
+	Config  RuntimeConfig  `json:"config"`
+	Metrics CrawlerMetrics `json:"metrics"`
+}
+
+// Status returns a summary of the crawl as it is now
+func (c *Crawler) Status() CrawlStatus {
+	state := "running"
+	switch {
+	case c.isShuttingDown():
+		state = "stopping"
+	case c.IsPaused():
+		state = "paused"
+	}
+	return CrawlStatus{
+		State:   state,
+		URL:     c.config.URL,
+		Config:  c.RuntimeConfig(),
+		Metrics: c.metrics.GetSnapshot(),
+	}
+}
+
+// WriteStatus prints a human-readable status summary to w
+func (c *Crawler) WriteStatus(w io.Writer) {
+	status := c.Status()
+	m := &status.Metrics
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "=== Crawl Status ===")
+	fmt.Fprintf(w, "State:            %s\n", status.State)
+	fmt.Fprintf(w, "Elapsed:          %s\n", FormatDuration(time.Since(m.StartTime)))
+	fmt.Fprintf(w, "URLs Processed:   %d\n", m.URLsProcessed)
+	fmt.Fprintf(w, "URLs Saved:       %d\n", m.URLsSaved)
+	fmt.Fprintf(w, "URLs Skipped:     %d\n", m.URLsSkipped)
+	fmt.Fprintf(w, "Errors:           %d\n", m.URLsErrored)
+	fmt.Fprintf(w, "Queue:            %d\n", m.QueueSize)
+	fmt.Fprintf(w, "Speed:            %.2f pages/second\n", m.PagesPerSecond)
+	fmt.Fprintf(w, "Heap:             %s\n", FormatBytes(m.HeapAlloc))
+	fmt.Fprintf(w, "Delay:            %s\n", status.Config.Delay)
+	if status.Config.Workers > 0 {
+		fmt.Fprintf(w, "Workers:          %d\n", status.Config.Workers)
+	}
+	fmt.Fprintf(w, "Verbose:          %v\n", status.Config.Verbose)
+	if len(m.Depths) > 0 {
+		fmt.Fprintln(w, "URLs per Depth:")
+		fmt.Fprint(w, FormatDepthHistogram(m.Depths))
+	}
+	if stats := FormatContentStats(m.Content); stats != "" {
+		fmt.Fprint(w, stats)
+	}
+}
+
+// WriteStatusFile writes the crawl status as JSON to status.json in the
+// output directory and returns the file path
+func (c *Crawler) WriteStatusFile() (string, error) {
+	status := c.Status()
+	data, err := json.MarshalIndent(&status, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal status: %v", err)
+	}
+	path := filepath.Join(c.config.OutputDir, StatusFile)
+	if err := c.perms.writeFile(path, data); err != nil {
+		return "", fmt.Errorf("failed to write status: %v", err)
+	}
+	return path, nil
+}
+
+// TogglePause pauses a running crawl or resumes a paused one and reports
+// whether it is now paused
+func (c *Crawler) TogglePause() bool {
+	if c.IsPaused() {
+		c.Resume()
+		c.log.Info("Crawl resumed")
+		return false
+	}
+	c.Pause()
+	c.log.Info("Crawl paused")
+	return true
+}
+
+// SetVerbose switches verbose logging of a running crawl on or off
+func (c *Crawler) SetVerbose(verbose bool) {
+	c.UpdateConfig(ConfigUpdate{Verbose: &verbose})
+}
+
+// ControlCommand runs one control command on the crawl and returns the reply.
+// Only errors and retry take arguments, separated by spaces.
+func (c *Crawler) ControlCommand(command string) (string, error) {
+	name, args, _ := strings.Cut(strings.TrimSpace(command), " ")
+	switch strings.ToLower(name) {
+	case "status":
+		status := c.Status()
+		data, err := json.Marshal(&status)
+		return string(data), err
+	case "metrics":
+		snapshot := c.metrics.GetSnapshot()
+		data, err := json.Marshal(&snapshot)
+		return string(data), err
+	case "pause":
+		c.Pause()
+		return "paused", nil
+	case "resume":
+		c.Resume()
+		return "running", nil
+	case "toggle":
+		if c.TogglePause() {
+			return "paused", nil
+		}
+		return "running", nil
+	case "verbose":
+		c.SetVerbose(true)
+		return "verbose on", nil
+	case "quiet":
+		c.SetVerbose(false)
+		return "verbose off", nil
+	case "errors":
+		errs, err := c.CrawlErrors(strings.TrimSpace(args))
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(errs)
+		return string(data), err
+	case "retry":
+		n, err := c.retryCommand(strings.Fields(args))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("queued %d URLs again", n), nil
+	case "stop":
+		c.Stop()
+		return "stopping", nil
+	}
+	return "", fmt.Errorf("unknown command %q, expected one of: %s", command, strings.Join(ControlCommands, ", "))
+}
+
+// retryCommand queues errored URLs again for the retry command: all of them,
+// those of an error class, or the URLs listed
+func (c *Crawler) retryCommand(args []string) (int, error) {
+	switch {
+	case len(args) == 0:
+		return 0, fmt.Errorf("retry needs URLs, an error class or all")
+	case len(args) == 1 && args[0] == "all":
+		return c.RetryErrors("")
+	case len(args) == 1 && ValidErrorClass(args[0]):
+		return c.RetryErrors(args[0])
+	}
+	return c.RetryURLs(args)
+}
+
+// ControlAddress splits a control socket address into its network and
+// address: a host:port on the loopback interface is a TCP port ("localhost"
+// or no host meaning 127.0.0.1), anything else the path of a Unix socket
+func ControlAddress(addr string) (network, address string, err error) {
+	if addr == "" {
+		return "", "", fmt.Errorf("empty control address")
+	}
+	host, port, splitErr := net.SplitHostPort(addr)
+	if splitErr != nil || strings.ContainsAny(addr, `/\`) {
+		return "unix", addr, nil
+	}
+	switch host {
+	case "", "localhost":
+		host = "127.0.0.1"
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return "", "", fmt.Errorf("control port must be on localhost, got: %s", addr)
+	}
+	return "tcp", net.JoinHostPort(host, port), nil
+}
+
+// ServeControl accepts control commands on addr (see ControlAddress) until
+// ctx is done. Each line received is a command, answered with one line: the
+// reply, or the error prefixed with "error: ".
+func (c *Crawler) ServeControl(ctx context.Context, addr string) error {
+	network, address, err := ControlAddress(addr)
+	if err != nil {
+		return err
+	}
+	if network == "unix" {
+		os.Remove(address) // Left behind by a crawl that did not exit cleanly
+		defer os.Remove(address)
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("control socket: %w", err)
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("control socket: %w", err)
+		}
+		go c.serveControlConn(conn)
+	}
+}
+
+// serveControlConn answers the commands sent on one control connection
+func (c *Crawler) serveControlConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		reply, err := c.ControlCommand(scanner.Text())
+		if err != nil {
+			reply = "error: " + err.Error()
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// SendControlCommand sends command to the control socket at addr and
+// returns the reply
+func SendControlCommand(addr, command string) (string, error) {
+	network, address, err := ControlAddress(addr)
+	if err != nil {
+		return "", err
+	}
+	conn, err := net.DialTimeout(network, address, 5*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	reply = strings.TrimSuffix(reply, "\n")
+	if msg, ok := strings.CutPrefix(reply, "error: "); ok {
+		return "", errors.New(msg)
+	}
+	return reply, nil
+}
@@ -0,0 +1,198 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newControlTestCrawler(t *testing.T) *Crawler {
+	t.Helper()
+	c, err := NewCrawler(Config{
+		URL:       "https://example.com",
+		MaxDepth:  1,
+		Delay:     time.Second,
+		OutputDir: t.TempDir(),
+	}, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestControlCommand(t *testing.T) {
+	c := newControlTestCrawler(t)
+
+	steps := []struct {
+		command string
+		reply   string
+		paused  bool
+		verbose bool
+	}{
+		{"pause", "paused", true, false},
+		{"resume", "running", false, false},
+		{"toggle", "paused", true, false},
+		{" Toggle\n", "running", false, false},
+		{"verbose", "verbose on", false, true},
+		{"quiet", "verbose off", false, false},
+	}
+	for _, s := range steps {
+		reply, err := c.ControlCommand(s.command)
+		if err != nil {
+			t.Fatalf("ControlCommand(%q) error = %v", s.command, err)
+		}
+		if reply != s.reply || c.IsPaused() != s.paused || c.log.IsVerbose() != s.verbose {
+			t.Errorf("ControlCommand(%q) = %q, paused %v, verbose %v; want %q, %v, %v",
+				s.command, reply, c.IsPaused(), c.log.IsVerbose(), s.reply, s.paused, s.verbose)
+		}
+	}
+
+	reply, err := c.ControlCommand("status")
+	if err != nil {
+		t.Fatalf("ControlCommand(status) error = %v", err)
+	}
+	var status CrawlStatus
+	if err := json.Unmarshal([]byte(reply), &status); err != nil {
+		t.Fatalf("status reply is not JSON: %v", err)
+	}
+	if status.State != "running" || status.URL != "https://example.com" || status.Config.Delay != "1s" {
+		t.Errorf("status: state %q, url %q, delay %q", status.State, status.URL, status.Config.Delay)
+	}
+
+	reply, err = c.ControlCommand("metrics")
+	if err != nil {
+		t.Fatalf("ControlCommand(metrics) error = %v", err)
+	}
+	var metrics map[string]any
+	if err := json.Unmarshal([]byte(reply), &metrics); err != nil || metrics["urls_processed"] == nil {
+		t.Errorf("metrics reply = %s, %v", reply, err)
+	}
+
+	if reply, err := c.ControlCommand("errors"); err != nil || reply != "[]" {
+		t.Errorf("ControlCommand(errors) = %q, %v; want []", reply, err)
+	}
+	if _, err := c.ControlCommand("errors sideways"); err == nil {
+		t.Error("ControlCommand(errors sideways) should reject the unknown class")
+	}
+	if _, err := c.ControlCommand("retry"); err == nil {
+		t.Error("ControlCommand(retry) without arguments should fail")
+	}
+
+	if _, err := c.ControlCommand("explode"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("ControlCommand(explode) error = %v, want unknown command", err)
+	}
+
+	if reply, _ := c.ControlCommand("stop"); reply != "stopping" || c.Status().State != "stopping" {
+		t.Errorf("after stop: reply %q, state %q", reply, c.Status().State)
+	}
+}
+
+func TestWriteStatusFile(t *testing.T) {
+	c := newControlTestCrawler(t)
+	c.Pause()
+
+	path, err := c.WriteStatusFile()
+	if err != nil {
+		t.Fatalf("WriteStatusFile() error = %v", err)
+	}
+	if path != filepath.Join(c.config.OutputDir, StatusFile) {
+		t.Errorf("WriteStatusFile() path = %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading status: %v", err)
+	}
+	var status CrawlStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatalf("status file is not JSON: %v", err)
+	}
+	if status.State != "paused" {
+		t.Errorf("state = %q, want paused", status.State)
+	}
+
+	var summary strings.Builder
+	c.WriteStatus(&summary)
+	if !strings.Contains(summary.String(), "State:            paused") {
+		t.Errorf("WriteStatus() = %q", summary.String())
+	}
+}
+
+func TestControlAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		network string
+		address string
+		wantErr bool
+	}{
+		{"/tmp/scraper.sock", "unix", "/tmp/scraper.sock", false},
+		{"scraper.sock", "unix", "scraper.sock", false},
+		{"localhost:7070", "tcp", "127.0.0.1:7070", false},
+		{":7070", "tcp", "127.0.0.1:7070", false},
+		{"[::1]:7070", "tcp", "[::1]:7070", false},
+		{"0.0.0.0:7070", "", "", true},
+		{"example.com:7070", "", "", true},
+		{"", "", "", true},
+	}
+
+	for _, tt := range tests {
+		network, address, err := ControlAddress(tt.addr)
+		if (err != nil) != tt.wantErr || network != tt.network || address != tt.address {
+			t.Errorf("ControlAddress(%q) = %q, %q, %v; want %q, %q, error %v",
+				tt.addr, network, address, err, tt.network, tt.address, tt.wantErr)
+		}
+	}
+}
+
+func TestServeControl(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	t.Run("unix", func(t *testing.T) {
+		testServeControl(t, filepath.Join(t.TempDir(), "control.sock"))
+	})
+	t.Run("tcp", func(t *testing.T) {
+		testServeControl(t, fmt.Sprintf("localhost:%d", port))
+	})
+}
+
+func testServeControl(t *testing.T, socket string) {
+	c := newControlTestCrawler(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- c.ServeControl(ctx, socket) }()
+
+	var reply string
+	var err error
+	for range 50 {
+		if reply, err = SendControlCommand(socket, "pause"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil || reply != "paused" || !c.IsPaused() {
+		t.Fatalf("SendControlCommand(pause) = %q, %v; paused %v", reply, err, c.IsPaused())
+	}
+	if _, err := SendControlCommand(socket, "explode"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("SendControlCommand(explode) error = %v, want unknown command", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("ServeControl() error = %v", err)
+	}
+	if _, err := SendControlCommand(socket, "status"); err == nil {
+		t.Error("control socket still answers after the crawl ended")
+	}
+}
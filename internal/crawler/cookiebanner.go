@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// cookieBannerSettle is how long to wait after clicking a consent button
+// for the banner to close and the page to reflow
+const cookieBannerSettle = 300 * time.Millisecond
+
+// cookieBannerAcceptSelectors are the accept buttons of common consent
+// managers, tried in order
+var cookieBannerAcceptSelectors = []string{
+	"#onetrust-accept-btn-handler",                           // OneTrust
+	"#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll", // Cookiebot
+	"#CybotCookiebotDialogBodyButtonAccept",                  // Cookiebot (legacy)
+	`button[data-testid="uc-accept-all-button"]`,             // Usercentrics
+	"#didomi-notice-agree-button",                            // Didomi
+	`.qc-cmp2-summary-buttons button[mode="primary"]`,        // Quantcast Choice
+	"#truste-consent-button",                                 // TrustArc
+	".osano-cm-accept-all",                                   // Osano
+	".cmplz-accept",                                          // Complianz
+	".cky-btn-accept",                                        // CookieYes
+	"#BorlabsCookieBox ._brlbs-btn-accept-all",               // Borlabs Cookie
+	".iubenda-cs-accept-btn",                                 // iubenda
+	".klaro .cm-btn-accept-all",                              // Klaro
+	".fc-cta-consent",                                        // Google Funding Choices
+	"#cookie_action_close_header",                            // CookieLawInfo
+	"#cn-accept-cookie",                                      // Cookie Notice
+	".moove-gdpr-infobar-allow-all",                          // GDPR Cookie Compliance
+	`[data-cookiebanner="accept_button"]`,                    // Meta
+	".cc-window .cc-allow",                                   // Osano cookieconsent
+	".cc-window .cc-dismiss",                                 // Osano cookieconsent (notice only)
+}
+
+// cookieBannerContainers are the consent manager elements removed after a
+// banner is dismissed, so leftovers do not end up in the extracted text
+var cookieBannerContainers = []string{
+	"#onetrust-consent-sdk",
+	"#CybotCookiebotDialog",
+	"#CybotCookiebotDialogBodyUnderlay",
+	"#usercentrics-root",
+	"#didomi-host",
+	".qc-cmp2-container",
+	"#truste-consent-track",
+	".osano-cm-window",
+	"#cmplz-cookiebanner-container",
+	".cky-consent-container",
+	"#BorlabsCookieBox",
+	"#iubenda-cs-banner",
+	".klaro",
+	".fc-consent-root",
+	"#cookie-law-info-bar",
+	"#cookie-notice",
+	"#moove_gdpr_cookie_info_bar",
+	`[id^="sp_message_container"]`,
+	".cc-window",
+}
+
+// cookieBannerButtonTexts are accept button labels, lowercased, clicked when
+// no known consent manager is found. Only buttons inside an element whose id,
+// class or label mentions cookies or consent are considered.
+var cookieBannerButtonTexts = []string{
+	// English
+	"accept", "accept all", "accept all cookies", "accept cookies", "allow all",
+	"allow all cookies", "allow cookies", "i accept", "i agree", "agree",
+	"agree and close", "got it", "ok", "okay",
+	// German
+	"akzeptieren", "alle akzeptieren", "alle cookies akzeptieren", "alle zulassen",
+	"zustimmen", "einverstanden", "ich stimme zu",
+	// French
+	"accepter", "tout accepter", "accepter et fermer", "j'accepte",
+	"autoriser tous les cookies",
+	// Spanish, Italian, Portuguese
+	"aceptar", "aceptar todo", "aceptar todas", "aceptar cookies",
+	"accetta", "accetta tutti", "accetto",
+	"aceitar", "aceitar todos", "aceito",
+	// Dutch, Japanese
+	"accepteren", "alles accepteren", "akkoord", "同意する",
+}
+
+// cookieBannerScript clicks the first visible known accept button, or an
+// accept-labelled button inside a consent element, then removes consent
+// manager containers. It searches open shadow roots too and evaluates to a
+// description of what it did, empty when no banner was found.
+var cookieBannerScript = fmt.Sprintf(`(() => {
+  const selectors = %s, containers = %s, texts = %s;
+  const consent = /cookie|consent|gdpr|privacy|cmp/i;
+  const visible = el => {
+    const r = el.getBoundingClientRect(), s = getComputedStyle(el);
+    return r.width > 0 && r.height > 0 && s.visibility !== 'hidden' && s.display !== 'none';
+  };
+  const roots = [document];
+  for (const el of document.querySelectorAll('*')) if (el.shadowRoot) roots.push(el.shadowRoot);
+
+  let dismissed = '';
+  search: for (const root of roots) {
+    for (const sel of selectors) {
+      const el = root.querySelector(sel);
+      if (el && visible(el)) { el.click(); dismissed = 'clicked ' + sel; break search; }
+    }
+  }
+  if (!dismissed) {
+    search: for (const root of roots) {
+      for (const el of root.querySelectorAll('button, a, [role="button"], input[type="button"], input[type="submit"]')) {
+        const text = (el.innerText || el.value || '').trim().toLowerCase().replace(/\s+/g, ' ');
+        if (!texts.includes(text) || !visible(el)) continue;
+        for (let p = el.parentElement || el.getRootNode().host, i = 0; p && i < 10; p = p.parentElement || p.getRootNode().host, i++) {
+          if (consent.test(p.id + ' ' + p.getAttribute('class') + ' ' + p.getAttribute('aria-label'))) {
+            el.click(); dismissed = 'clicked "' + text + '"'; break search;
+          }
+        }
+      }
+    }
+  }
+  for (const sel of containers) {
+    for (const el of document.querySelectorAll(sel)) {
+      el.remove();
+      if (!dismissed) dismissed = 'removed ' + sel;
+    }
+  }
+  if (dismissed) {
+    // Consent managers lock scrolling while the banner is open
+    document.documentElement.style.overflow = '';
+    if (document.body) document.body.style.overflow = '';
+  }
+  return dismissed;
+})()`, jsonList(cookieBannerAcceptSelectors), jsonList(cookieBannerContainers), jsonList(cookieBannerButtonTexts))
+
+// jsonList encodes a string list as a JavaScript array literal
+func jsonList(values []string) string {
+	b, _ := json.Marshal(values)
+	return string(b)
+}
+
+// SetCookieBannerDismissal enables or disables dismissing cookie consent
+// banners before capture. It must be called before the first fetch.
+func (f *BrowserFetcher) SetCookieBannerDismissal(enabled bool) {
+	f.dismissCookieBanners = enabled
+}
+
+// cookieBannerActions dismisses a cookie banner when enabled, storing what
+// was done in dismissed. A failing script does not fail the fetch.
+func (f *BrowserFetcher) cookieBannerActions(dismissed *string) []chromedp.Action {
+	if !f.dismissCookieBanners {
+		return nil
+	}
+	return []chromedp.Action{chromedp.ActionFunc(func(ctx context.Context) error {
+		if err := chromedp.Evaluate(cookieBannerScript, dismissed).Do(ctx); err != nil || *dismissed == "" {
+			return nil
+		}
+		return chromedp.Sleep(cookieBannerSettle).Do(ctx)
+	})}
+}
+
+// logCookieBanner reports a dismissed cookie banner
+func (c *Crawler) logCookieBanner(rawURL string, result *FetchResult) {
+	if result != nil && result.CookieBanner != "" {
+		c.log.Debug("Dismissed cookie banner on %s (%s)", rawURL, result.CookieBanner)
+	}
+}
@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/cascadia"
+)
+
+func TestCookieBannerSelectors(t *testing.T) {
+	for _, list := range [][]string{cookieBannerAcceptSelectors, cookieBannerContainers} {
+		for _, sel := range list {
+			if _, err := cascadia.Compile(sel); err != nil {
+				t.Errorf("invalid selector %q: %v", sel, err)
+			}
+		}
+	}
+}
+
+func TestCookieBannerButtonTexts(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, text := range cookieBannerButtonTexts {
+		// The script compares against the trimmed, lowercased label
+		if text != strings.ToLower(strings.Join(strings.Fields(text), " ")) {
+			t.Errorf("button text %q is not normalized", text)
+		}
+		if seen[text] {
+			t.Errorf("duplicate button text %q", text)
+		}
+		seen[text] = true
+	}
+}
+
+func TestCookieBannerActions(t *testing.T) {
+	var dismissed string
+	f := &BrowserFetcher{}
+	if actions := f.cookieBannerActions(&dismissed); len(actions) != 0 {
+		t.Errorf("cookieBannerActions() returned %d actions while disabled, want 0", len(actions))
+	}
+	f.SetCookieBannerDismissal(true)
+	if actions := f.cookieBannerActions(&dismissed); len(actions) != 1 {
+		t.Errorf("cookieBannerActions() returned %d actions, want 1", len(actions))
+	}
+}
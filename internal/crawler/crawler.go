@@ -2,12 +2,14 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -22,8 +24,11 @@ const (
 	// HTTPTimeout is the timeout for HTTP requests
 	HTTPTimeout = 30 * time.Second
 
-	// MaxConcurrentRequests is the maximum number of simultaneous requests in concurrent mode
-	MaxConcurrentRequests = 10
+	// DefaultWorkers is the number of simultaneous requests in concurrent mode when none is configured
+	DefaultWorkers = 10
+
+	// MaxWorkers is the upper bound accepted for the worker count
+	MaxWorkers = 100
 
 	// StateSaveInterval is how often state is saved (every N processed URLs)
 	StateSaveInterval = 10
@@ -37,19 +42,43 @@ const (
 	// DefaultUserAgent is the default User-Agent header sent with requests
 	DefaultUserAgent = "Mozilla/5.0 (compatible; WebScraper/1.0; +https://github.com/user/scraper)"
 
+	// RobotsPrefetchWorkers is how many robots.txt files of newly discovered hosts are fetched at once in the background
+	RobotsPrefetchWorkers = 4
+
 	// MaxRedirects is the maximum number of redirects to follow per request
 	MaxRedirects = 10
+
+	// DefaultMaxHTMLSize is the largest page body that will be parsed (10 MiB)
+	DefaultMaxHTMLSize = 10 << 20
+
+	// DefaultIndexInterval is how often _index.html is rewritten during a crawl (every N saved pages)
+	DefaultIndexInterval = 50
 )
 
 // Logger provides leveled logging for the crawler
 type Logger struct {
 	verbose bool
 	emitter EventEmitter
+	mu      sync.RWMutex
+}
+
+// SetVerbose enables or disables debug output
+func (l *Logger) SetVerbose(verbose bool) {
+	l.mu.Lock()
+	l.verbose = verbose
+	l.mu.Unlock()
+}
+
+// IsVerbose reports whether debug output is enabled
+func (l *Logger) IsVerbose() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.verbose
 }
 
 // Debug logs a message only if verbose mode is enabled
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.verbose {
+	if l.IsVerbose() {
 		msg := fmt.Sprintf(format, args...)
 		log.Printf("[DEBUG] %s", msg)
 		EmitLog(l.emitter, "debug", msg)
@@ -81,25 +110,84 @@ func (l *Logger) Error(format string, args ...interface{}) {
 type Crawler struct {
 	config       Config
 	state        *CrawlerState
+	frontier     *frontier // Queue spilled to disk (nil without FrontierLimit)
 	fetcher      Fetcher
 	robotsClient *http.Client // Separate client for robots.txt (always HTTP)
 	mu           sync.RWMutex
+	configMu     sync.RWMutex // Guards settings that can change during a crawl
 	wg           sync.WaitGroup
-	semaphore    chan struct{}
+	workers      *workerLimiter
 	log          *Logger
 	robotsCache  map[string]*robotstxt.RobotsData
 	robotsMu     sync.RWMutex
+	robotsFetch  map[string]chan struct{} // robots.txt fetches under way, closed when done
+	robotsSeen   map[string]bool          // Hosts whose robots.txt was prefetched
+	robotsSlots  chan struct{}            // Limits prefetches to RobotsPrefetchWorkers
 	metrics      *CrawlerMetrics
 	ctx          context.Context
 	cancel       context.CancelFunc
 	emitter      EventEmitter
 	paused       bool
+	parked       bool // Crawl loop is blocked in checkPaused
+	loopDone     bool // Crawl loop has returned
 	pauseMu      sync.Mutex
 	pauseCond    *sync.Cond
 	loginDone    chan struct{}
 	loginWaiting bool
 	loginMu      sync.Mutex
 	normalizer   *URLNormalizer // URL normalizer for deduplication
+	index        *IndexBuilder  // Incrementally updated _index.html
+	recorder     *metricsRecorder
+	inventory    *urlInventory    // Every URL encountered, written to urls.csv/urls.jsonl
+	redirects    *redirectLog     // Redirect hops and failed chains, written to redirects.json
+	har          *harCollector    // Page captures written to crawl.har in HARModeCrawl
+	endpoints    *apiEndpointLog  // XHR/fetch endpoints, written to api_endpoints.jsonl
+	external     *externalLinkLog // Out-of-scope links, written to external_links.jsonl
+	perms        outputPerms      // Mode and owner of written files and directories
+	trace        *decisionTrace   // Decisions written to Config.TraceDecisions, nil when off
+	results      *resultsDB       // Config.ResultsDB, nil when off
+	manifest     *pageManifest    // Saved pages appended to pages.ndjson, nil outside Start
+	panel        *progressPanel   // Multi-line progress display, nil when off or not on a terminal
+	sinks        *eventSinks      // Config.EventSinks, nil without any
+	embeddings   *embedder        // Config.Embeddings, nil when off
+	enrichment   *enricher        // Config.Enrichment, nil when off
+	postSave     *postSaver       // Config.PostSaveCommand, nil without one
+	wayback      *waybackClient   // Config.WaybackFallback, nil when off
+	waybackSave  *waybackSaver    // Config.WaybackSave, nil when off
+	archived     sync.Map         // URL -> archivedSource of pages fetched from the Wayback Machine
+	tracer       *crawlTracer     // OpenTelemetry spans, nil when tracing is off
+	userAgents   *userAgentPool   // User agent rotation, nil when off
+	warmUps      *warmUpHosts     // Hosts visited through their homepage first, nil without Config.WarmUp
+
+	// Content filters cleaning saved pages, by URL pattern
+	filters []compiledContentFilter
+
+	// Rules for navigation hubs whose links are followed but which are never saved
+	followOnly []compiledFollowOnlyRule
+
+	// Plugins of Config.PluginsDir, nil without it
+	plugins *pluginSet
+
+	// Alert rules saved pages are matched against, and alerts.jsonl while
+	// the crawl runs
+	alertRules []compiledAlertRule
+	alerts     *alertLog
+
+	// Stop conditions besides MaxPages; see stopCondition
+	deadline          time.Time      // End of MaxRuntime, zero without a limit
+	consecutiveErrors atomic.Int64   // Fetches failed in a row
+	stopPattern       *regexp.Regexp // Compiled StopPattern
+	pendingTargets    atomic.Int64   // URLs matching stopPattern queued or being fetched
+	fetchedTargets    atomic.Int64   // URLs matching stopPattern fetched
+
+	// Workers of a concurrent crawl still processing a URL
+	activeWorkers atomic.Int64
+
+	// URLs processed under URLTimeout (nil without a timeout)
+	watchdog *watchdog
+
+	// Times each URL was queued again after crashing the browser, guarded by mu
+	crashRequeues map[string]int
 }
 
 // NewCrawler creates a new Crawler instance with the given configuration
@@ -109,6 +197,17 @@ func NewCrawler(config Config, ctx context.Context) (*Crawler, error) {
 
 // NewCrawlerWithEmitter creates a new Crawler instance with event emission capability
 func NewCrawlerWithEmitter(config Config, ctx context.Context, emitter EventEmitter) (*Crawler, error) {
+	// Polite mode refuses some settings and bounds others before anything
+	// else looks at them
+	var politeNotes []string
+	if config.Polite != nil {
+		notes, err := config.Polite.Enforce(&config)
+		if err != nil {
+			return nil, err
+		}
+		politeNotes = notes
+	}
+
 	// Set default user agent if not provided
 	userAgent := config.UserAgent
 	if userAgent == "" {
@@ -120,26 +219,162 @@ func NewCrawlerWithEmitter(config Config, ctx context.Context, emitter EventEmit
 		config.FetchMode = FetchModeHTTP
 	}
 
+	if config.Workers == 0 {
+		config.Workers = DefaultWorkers
+	}
+	if config.MaxHTMLSize == 0 {
+		config.MaxHTMLSize = DefaultMaxHTMLSize
+	}
+	if config.MetricsInterval == 0 {
+		config.MetricsInterval = DefaultMetricsInterval
+	}
+
+	perms, err := config.Permissions.parse()
+	if err != nil {
+		return nil, err
+	}
+	filters, err := compileContentFilters(config.ContentFilters)
+	if err != nil {
+		return nil, err
+	}
+	followOnly, err := compileFollowOnlyRules(config.FollowOnly)
+	if err != nil {
+		return nil, err
+	}
+	alertRules, err := compileAlertRules(config.Alerts)
+	if err != nil {
+		return nil, err
+	}
+	stopPattern, err := compileStopPattern(config.StopPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stop pattern: %v", err)
+	}
+	// The fetchers get the secret values; the crawler keeps the references
+	// so state files and job definitions never hold the plaintext
+	fetchConfig, err := ResolveSecrets(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGeo(fetchConfig.Geo, config.FetchMode); err != nil {
+		return nil, err
+	}
+
 	// Create a child context so we can cancel it independently
 	crawlerCtx, cancel := context.WithCancel(ctx)
 
 	// Create the appropriate fetcher based on config
 	var fetcher Fetcher
-	var err error
+
+	// Events go to the sinks as well as to the caller's emitter
+	var sinks *eventSinks
+	if len(config.EventSinks) > 0 {
+		sinks = &eventSinks{next: emitter}
+		emitter = sinks
+	}
 
 	logger := &Logger{verbose: config.Verbose, emitter: emitter}
+	for _, note := range politeNotes {
+		logger.Warn("%s", note)
+	}
 
-	switch config.FetchMode {
-	case FetchModeBrowser:
+	var plugins *pluginSet
+	if config.PluginsDir != "" {
+		if plugins, err = newPluginSet(config.PluginsDir, logger); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	var cassette *Cassette
+	if config.Cassette == CassetteRecord || config.Cassette == CassetteReplay {
+		cassettePath := config.CassetteFile
+		if cassettePath == "" {
+			cassettePath = filepath.Join(config.OutputDir, CassetteFile)
+		}
+		cassette, err = OpenCassette(cassettePath, config.Cassette)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	switch {
+	case config.Cassette == CassetteReplay:
+		logger.Info("Replaying %d recorded responses from %s without network access", cassette.Len(), cassette.Path())
+		fetcher = NewCassetteFetcher(nil, cassette)
+	case config.FetchMode == FetchModeBrowser:
 		logger.Info("Using browser-based fetching (headless=%v)", config.Headless)
-		fetcher, err = NewBrowserFetcherWithPageLoadWait(config.Headless, userAgent, config.AntiBot, config.PageLoadWait)
+		var browserFetcher *BrowserFetcher
+		browserFetcher, err = NewBrowserFetcherWithLimits(config.Headless, userAgent, config.AntiBot.Effective(), config.PageLoadWait, fetchConfig.Geo, config.BrowserLimits())
 		if err != nil {
 			cancel()
 			return nil, fmt.Errorf("failed to create browser fetcher: %w", err)
 		}
+		if config.HARMode == HARModePage || config.HARMode == HARModeCrawl {
+			logger.Info("Recording network activity as HAR (%s)", config.HARMode)
+			browserFetcher.SetHARCapture(true)
+		}
+		if config.DiscoverAPIs {
+			logger.Info("Logging XHR/fetch endpoints to %s", APIEndpointsFile)
+			browserFetcher.SetHARCapture(true)
+		}
+		if len(fetchConfig.PageScripts) > 0 {
+			if err := browserFetcher.SetPageScripts(fetchConfig.PageScripts); err != nil {
+				browserFetcher.Close()
+				cancel()
+				return nil, fmt.Errorf("failed to create browser fetcher: %w", err)
+			}
+			logger.Info("Running %d page script(s) on matching pages", len(config.PageScripts))
+		}
+		browserFetcher.SetCookieBannerDismissal(!config.KeepCookieBanners)
+		if config.BrowserPath != "" {
+			logger.Info("Using the browser at %s", config.BrowserPath)
+		}
+		if config.BrowserMaxMemory > 0 || config.BrowserMaxTabs > 0 || config.BrowserMaxProcesses > 0 {
+			logger.Info("Limiting the browser to %d MB, %d tabs and %d processes (0 = unlimited)", config.BrowserMaxMemory, config.BrowserMaxTabs, config.BrowserMaxProcesses)
+		}
+		if config.BrowserRecycle > 0 {
+			logger.Info("Relaunching the browser every %d pages", config.BrowserRecycle)
+			browserFetcher.SetRecycle(config.BrowserRecycle)
+		}
+		if config.LazyLoadScroll {
+			logger.Info("Scrolling through pages before capture to load lazy content")
+			browserFetcher.SetLazyLoadScroll(config.ScrollStep, config.ScrollDelay)
+		}
+		if config.ShadowDOM != "" && config.ShadowDOM != ShadowDOMOff {
+			logger.Info("Serializing shadow DOM content into saved pages (%s)", config.ShadowDOM)
+			browserFetcher.SetShadowDOM(config.ShadowDOM)
+		}
+		fetcher = browserFetcher
 	default:
 		logger.Info("Using HTTP-based fetching")
-		fetcher = NewHTTPFetcher()
+		httpFetcher := NewHTTPFetcherWithMaxBodySize(config.MaxHTMLSize)
+		if err := httpFetcher.SetGeo(fetchConfig.Geo); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create HTTP fetcher: %w", err)
+		}
+		fetcher = httpFetcher
+	}
+	if config.Cassette == CassetteRecord {
+		logger.Info("Recording responses to %s", cassette.Path())
+		fetcher = NewCassetteFetcher(fetcher, cassette)
+	}
+	if config.HostLimiter != nil && config.Cassette != CassetteReplay {
+		if config.HostLimiter.Interval() > 0 {
+			logger.Info("Sharing a limit of one request per %v to each host with other crawls", config.HostLimiter.Interval())
+		}
+		if n := config.HostLimiter.HostConcurrency(); n > 0 {
+			logger.Info("Limiting requests in flight to each host to %d", n)
+		}
+		fetcher = NewHostLimitedFetcher(crawlerCtx, fetcher, config.HostLimiter)
+	}
+	if config.Faults.Enabled() {
+		logger.Warn("Injecting faults: latency %v, 5xx rate %v, truncate rate %v, reset rate %v, seed %d",
+			config.Faults.Latency, config.Faults.ErrorRate, config.Faults.TruncateRate, config.Faults.ResetRate, config.Faults.Seed)
+		fetcher = NewFaultFetcher(fetcher, config.Faults)
+	}
+	if geo := fetchConfig.Geo.Resolved(); geo != (GeoConfig{}) {
+		logger.Info("Emulating region %q (language %q, proxy %v)", geo.Region, geo.AcceptLanguage, geo.Proxy != "")
 	}
 
 	// Configure HTTP transport for robots.txt fetching (always HTTP)
@@ -148,16 +383,25 @@ func NewCrawlerWithEmitter(config Config, ctx context.Context, emitter EventEmit
 		MaxIdleConnsPerHost: 5,
 		IdleConnTimeout:     30 * time.Second,
 	}
+	// robots.txt is requested through the same proxy as pages, since it can vary by region
+	if proxy, err := fetchConfig.Geo.Resolved().ProxyURL(); err == nil && proxy != nil {
+		robotsTransport.Proxy = http.ProxyURL(proxy)
+	}
+	// robots.txt is recorded and replayed along with the pages
+	var robotsRoundTripper http.RoundTripper = robotsTransport
+	if cassette != nil {
+		robotsRoundTripper = cassette.Transport(robotsTransport)
+	}
 
 	c := &Crawler{
 		config:  config,
 		fetcher: fetcher,
 		robotsClient: &http.Client{
 			Timeout:   HTTPTimeout,
-			Transport: robotsTransport,
+			Transport: robotsRoundTripper,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= MaxRedirects {
-					return fmt.Errorf("stopped after %d redirects", MaxRedirects)
+				if err := checkRedirect(req, via); err != nil {
+					return err
 				}
 				req.Header.Set("User-Agent", userAgent)
 				return nil
@@ -165,10 +409,52 @@ func NewCrawlerWithEmitter(config Config, ctx context.Context, emitter EventEmit
 		},
 		log:         logger,
 		robotsCache: make(map[string]*robotstxt.RobotsData),
+		robotsFetch: make(map[string]chan struct{}),
+		robotsSeen:  make(map[string]bool),
+		robotsSlots: make(chan struct{}, RobotsPrefetchWorkers),
 		metrics:     NewCrawlerMetrics(),
+		perms:       perms,
+		filters:     filters,
+		followOnly:  followOnly,
+		plugins:     plugins,
+		alertRules:  alertRules,
+		stopPattern: stopPattern,
+		recorder:    newMetricsRecorder(config.MetricsInterval),
+		inventory:   newURLInventory(),
+		redirects:   newRedirectLog(),
+		har:         &harCollector{},
+		endpoints:   newAPIEndpointLog(),
+		external:    newExternalLinkLog(),
 		ctx:         crawlerCtx,
 		cancel:      cancel,
 		emitter:     emitter,
+		sinks:       sinks,
+		userAgents:  newUserAgentPool(config),
+	}
+	if config.Embeddings.Enabled() {
+		c.embeddings = newEmbedder(fetchConfig.Embeddings, logger)
+	}
+	if config.URLTimeout > 0 {
+		c.watchdog = newWatchdog(config.URLTimeout)
+	}
+	if config.Enrichment.Enabled() {
+		c.enrichment = newEnricher(fetchConfig.Enrichment, perms, logger)
+	}
+	if config.PostSaveCommand != "" {
+		c.postSave = newPostSaver(&config, logger)
+	}
+	if config.WaybackFallback {
+		c.wayback = newWaybackClient(config.MaxHTMLSize, waybackRequestGap)
+	}
+	if config.WaybackSave {
+		c.waybackSave = newWaybackSaver(fetchConfig.WaybackSaveKey, config.WaybackSaveInterval, logger)
+	}
+	if config.WarmUp {
+		c.warmUps = &warmUpHosts{hosts: make(map[string]*hostWarmUp)}
+	}
+	c.setUpSession()
+	if bf, ok := asBrowserFetcher(fetcher); ok {
+		bf.SetRestartHandler(c.browserRestarted)
 	}
 
 	c.pauseCond = sync.NewCond(&c.pauseMu)
@@ -180,7 +466,7 @@ func NewCrawlerWithEmitter(config Config, ctx context.Context, emitter EventEmit
 	}
 
 	if config.Concurrent {
-		c.semaphore = make(chan struct{}, MaxConcurrentRequests)
+		c.workers = newWorkerLimiter(c.config.Workers)
 	}
 
 	return c, nil
@@ -222,6 +508,20 @@ func (c *Crawler) Resume() {
 	EmitStateChange(c.emitter, EventCrawlResumed)
 }
 
+// Workers returns the current number of concurrent workers (0 in sequential mode)
+func (c *Crawler) Workers() int {
+	if c.workers == nil {
+		return 0
+	}
+	return c.workers.Limit()
+}
+
+// SetWorkers changes the number of concurrent workers of a running crawl
+func (c *Crawler) SetWorkers(n int) error {
+	_, err := c.UpdateConfig(ConfigUpdate{Workers: &n})
+	return err
+}
+
 // IsWaitingForLogin returns whether the crawler is waiting for manual login
 func (c *Crawler) IsWaitingForLogin() bool {
 	c.loginMu.Lock()
@@ -259,8 +559,10 @@ func (c *Crawler) Close() error {
 func (c *Crawler) checkPaused() {
 	c.pauseMu.Lock()
 	for c.paused && !c.isShuttingDown() {
+		c.parked = true
 		c.pauseCond.Wait()
 	}
+	c.parked = false
 	c.pauseMu.Unlock()
 }
 
@@ -299,7 +601,9 @@ func (c *Crawler) fetchRobots(rawURL string) (*http.Response, error) {
 	return c.robotsClient.Do(req)
 }
 
-// getRobots fetches and caches robots.txt for a given host
+// getRobots returns the robots.txt of a host, fetching and caching it on
+// first use. A fetch already under way, such as a prefetch, is waited for
+// rather than repeated.
 func (c *Crawler) getRobots(host string, scheme string) *robotstxt.RobotsData {
 	// Check cache first
 	c.robotsMu.RLock()
@@ -310,50 +614,95 @@ func (c *Crawler) getRobots(host string, scheme string) *robotstxt.RobotsData {
 		return robots
 	}
 
-	// Fetch robots.txt
+	c.robotsMu.Lock()
+	if robots, exists := c.robotsCache[host]; exists {
+		c.robotsMu.Unlock()
+		return robots
+	}
+	if done, pending := c.robotsFetch[host]; pending {
+		c.robotsMu.Unlock()
+		<-done
+		c.robotsMu.RLock()
+		defer c.robotsMu.RUnlock()
+		return c.robotsCache[host]
+	}
+	done := make(chan struct{})
+	c.robotsFetch[host] = done
+	c.robotsMu.Unlock()
+
+	// A failed fetch caches nil to avoid repeated failed fetches
+	robots = c.loadRobots(host, scheme)
+	c.robotsMu.Lock()
+	c.robotsCache[host] = robots
+	delete(c.robotsFetch, host)
+	c.robotsMu.Unlock()
+	close(done)
+	return robots
+}
+
+// loadRobots fetches and parses the robots.txt of a host, returning nil when
+// it is missing or unreadable
+func (c *Crawler) loadRobots(host string, scheme string) *robotstxt.RobotsData {
 	robotsURL := fmt.Sprintf("%s://%s/robots.txt", scheme, host)
 	resp, err := c.fetchRobots(robotsURL)
 	if err != nil {
 		c.log.Debug("Failed to fetch robots.txt for %s: %v", host, err)
-		// Cache nil to avoid repeated failed fetches
-		c.robotsMu.Lock()
-		c.robotsCache[host] = nil
-		c.robotsMu.Unlock()
 		return nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		c.log.Debug("robots.txt returned %d for %s", resp.StatusCode, host)
-		c.robotsMu.Lock()
-		c.robotsCache[host] = nil
-		c.robotsMu.Unlock()
 		return nil
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		c.log.Debug("Failed to read robots.txt for %s: %v", host, err)
-		c.robotsMu.Lock()
-		c.robotsCache[host] = nil
-		c.robotsMu.Unlock()
 		return nil
 	}
 
-	robots, err = robotstxt.FromBytes(body)
+	robots, err := robotstxt.FromBytes(body)
 	if err != nil {
 		c.log.Debug("Failed to parse robots.txt for %s: %v", host, err)
-		c.robotsMu.Lock()
-		c.robotsCache[host] = nil
-		c.robotsMu.Unlock()
 		return nil
 	}
 
 	c.log.Debug("Loaded robots.txt for %s", host)
+	return robots
+}
+
+// prefetchRobots loads the robots.txt of a queued URL's host in the
+// background, at most RobotsPrefetchWorkers at a time, so the worker that
+// fetches the URL later finds it cached instead of waiting for it
+func (c *Crawler) prefetchRobots(rawURL string) {
+	if c.config.IgnoreRobots {
+		return
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return
+	}
+
 	c.robotsMu.Lock()
-	c.robotsCache[host] = robots
+	_, cached := c.robotsCache[parsed.Host]
+	_, pending := c.robotsFetch[parsed.Host]
+	scheduled := c.robotsSeen[parsed.Host]
+	c.robotsSeen[parsed.Host] = true
 	c.robotsMu.Unlock()
-	return robots
+	if cached || pending || scheduled {
+		return
+	}
+
+	go func() {
+		select {
+		case c.robotsSlots <- struct{}{}:
+		case <-c.ctx.Done():
+			return
+		}
+		defer func() { <-c.robotsSlots }()
+		c.getRobots(parsed.Host, parsed.Scheme)
+	}()
 }
 
 // isAllowedByRobots checks if a URL is allowed by robots.txt
@@ -389,29 +738,190 @@ func (c *Crawler) isAllowedByRobots(rawURL string) bool {
 }
 
 // Start begins the crawling process
-func (c *Crawler) Start() error {
+func (c *Crawler) Start() (err error) {
+	defer func() { c.notifyFinished(err) }()
+
 	// Load state
 	state, err := LoadState(c.config.StateFile, c.config.URL)
 	if err != nil {
 		return fmt.Errorf("failed to load state: %v", err)
 	}
+	// A finished re-crawl or replay leaves its URLs visited, so the next one starts afresh
+	if (len(c.config.RecrawlURLs) > 0 || c.config.Cassette == CassetteReplay) && len(state.Queue) == 0 && len(state.Spilled) == 0 {
+		state = NewCrawlerState(c.config.URL)
+	}
 	c.state = state
+	c.reconcileStartURL()
 
 	if err := EnsureOutputDir(&c.config); err != nil {
 		return fmt.Errorf("failed to create output directory: %v", err)
 	}
 
-	if len(c.state.Queue) == 0 {
+	if c.config.TraceDecisions != "" {
+		trace, err := openDecisionTrace(c.config.TraceDecisions, len(c.state.Visited) > 0, c.perms)
+		if err != nil {
+			return err
+		}
+		c.trace = trace
+		defer func() {
+			c.trace = nil
+			if err := trace.Close(); err != nil {
+				c.log.Warn("Failed to write decision trace: %v", err)
+			}
+		}()
+	}
+
+	if c.config.ResultsDB != "" {
+		results, err := openResultsDB(resultsDBPath(c.config), len(c.state.Visited) > 0, c.perms, c.log)
+		if err != nil {
+			return err
+		}
+		c.results = results
+		c.recorder.onSample = results.addSample
+		defer func() {
+			c.results = nil
+			c.recorder.onSample = nil
+			if err := results.Close(); err != nil {
+				c.log.Warn("Failed to write results database: %v", err)
+			}
+		}()
+	}
+
+	// Started after the results database so the queued pages are stored
+	// before it closes
+	if c.embeddings != nil {
+		c.embeddings.start(c.storeEmbedding)
+		c.log.Info("Generating embeddings with %s", c.config.Embeddings.Model)
+		defer c.embeddings.close()
+	}
+	if c.enrichment != nil {
+		c.enrichment.start()
+		c.log.Info("Enriching pages with %s", c.config.Enrichment.Endpoint)
+		defer c.enrichment.close()
+	}
+	if c.postSave != nil {
+		c.postSave.start()
+		c.log.Info("Running post-save command: %s", c.config.PostSaveCommand)
+		defer c.postSave.close()
+	}
+	if c.waybackSave != nil {
+		c.waybackSave.start(c.ctx)
+		c.log.Info("Submitting saved pages to the Wayback Machine, one every %s", c.waybackSave.client.gap)
+		defer c.waybackSave.close()
+	}
+	if c.plugins != nil {
+		c.log.Info("Plugins: %s", strings.Join(c.plugins.names(), ", "))
+		defer c.plugins.summary()
+	}
+
+	// Re-crawls write into the output directory of an earlier crawl, so its
+	// manifest is kept like that of a resumed one
+	manifest, err := openPageManifest(c.config.OutputDir, len(c.state.Visited) > 0 || len(c.config.RecrawlURLs) > 0, c.perms)
+	if err != nil {
+		return err
+	}
+	c.manifest = manifest
+	defer func() {
+		c.manifest = nil
+		if err := manifest.Close(); err != nil {
+			c.log.Warn("Failed to write page manifest: %v", err)
+		}
+	}()
+
+	if len(c.alertRules) > 0 {
+		alerts, err := openAlertLog(c.config.OutputDir, len(c.state.Visited) > 0, c.perms)
+		if err != nil {
+			return err
+		}
+		c.alerts = alerts
+		defer func() {
+			c.alerts = nil
+			alerts.Close()
+		}()
+	}
+
+	if c.sinks != nil {
+		if err := c.sinks.open(c.config, len(c.state.Visited) > 0, c.perms, c.log); err != nil {
+			return err
+		}
+		defer c.sinks.close()
+	}
+
+	if c.config.Tracing.Enabled() {
+		tracer, err := newCrawlTracer(c.config)
+		if err != nil {
+			return err
+		}
+		c.tracer = tracer
+		c.log.Info("Exporting traces to %s", c.config.Tracing.Endpoint)
+		defer func() {
+			if err := tracer.shutdown(); err != nil {
+				c.log.Warn("Failed to export traces: %v", err)
+			}
+		}()
+	}
+
+	// Seed the index with pages saved by a previous run so incremental
+	// updates never drop them
+	c.index = NewIndexBuilder(c.config.OutputDir, c.config.IndexInterval)
+	c.index.perms = c.perms
+	if err := c.index.Load(); err != nil {
+		c.log.Warn("Failed to load existing pages into index: %v", err)
+	}
+
+	// Carry over the inventory of an interrupted run being resumed, or of
+	// the crawl being partially re-crawled
+	if len(c.state.Visited) > 0 || len(c.config.RecrawlURLs) > 0 {
+		if records, err := LoadURLInventory(c.config.OutputDir); err == nil {
+			c.inventory.Load(records)
+		}
+		if c.config.DiscoverAPIs {
+			if endpoints, err := LoadAPIEndpoints(c.config.OutputDir); err == nil {
+				c.endpoints.Load(endpoints)
+			}
+		}
+		if c.config.ExternalLinks {
+			if links, err := LoadExternalLinks(c.config.OutputDir); err == nil {
+				c.external.Load(links)
+			}
+		}
+	}
+
+	// Permanent redirects learned by earlier crawls become aliases, so
+	// their final URLs are requested directly
+	c.loadRedirects()
+
+	if len(c.config.RecrawlURLs) > 0 {
+		if len(c.state.Queue) == 0 && len(c.state.Spilled) == 0 {
+			c.queueRecrawl()
+		}
+	} else if len(c.state.Queue) == 0 && len(c.state.Spilled) == 0 {
 		// Normalize the initial URL for consistent deduplication
-		initialURL := c.normalizeURL(c.config.URL)
+		initialURL := c.resolveAlias(c.normalizeURL(c.config.URL))
 		c.state.Queue = append(c.state.Queue, URLInfo{URL: initialURL, Depth: 0})
 		c.state.URLDepths[initialURL] = 0
 		c.state.Queued[initialURL] = true
+		c.traceDecision(Decision{Stage: DecisionStageDiscover, URL: initialURL, Accepted: true, Rule: DecisionStart})
+	}
+	for _, info := range c.state.Queue {
+		c.inventory.Discover(info.URL, info.Depth, "")
+		c.metrics.IncrementDiscoveredAt(info.Depth)
+		c.targetQueued(info.URL)
+	}
+	if err := c.openFrontier(); err != nil {
+		return fmt.Errorf("failed to load spilled queue: %v", err)
+	}
+	defer c.closeFrontier()
+	// Targets fetched before a resume count towards StopPattern
+	for u := range c.state.Visited {
+		if c.isStopTarget(u) {
+			c.fetchedTargets.Add(1)
+		}
 	}
 
 	// Handle login wait for non-headless browser mode
 	if c.config.WaitForLogin && c.config.FetchMode == FetchModeBrowser && !c.config.Headless {
-		if bf, ok := c.fetcher.(*BrowserFetcher); ok {
+		if bf, ok := asBrowserFetcher(c.fetcher); ok {
 			c.log.Info("Opening browser for login at: %s", c.config.URL)
 
 			// Navigate browser to initial URL
@@ -444,61 +954,117 @@ func (c *Crawler) Start() error {
 		}
 	}
 
-	c.log.Info("Starting crawler with %d URLs in queue", len(c.state.Queue))
+	c.log.Info("Starting crawler with %d URLs in queue", c.queueSize())
 	c.log.Debug("Max depth set to: %d", c.config.MaxDepth)
 
 	EmitStateChange(c.emitter, EventCrawlStarted)
 
+	stopRecording := c.startMetricsRecorder()
+	stopWatchdog := c.startWatchdog()
+
+	if c.config.ShowProgress && c.config.ProgressPanel && c.config.ProgressFormat != ProgressFormatNDJSON && stdoutIsTerminal() {
+		c.panel = newProgressPanel()
+	}
+
+	// The run time limit starts with the crawl, after any login wait
+	if c.config.MaxRuntime > 0 {
+		c.deadline = time.Now().Add(c.config.MaxRuntime)
+	}
+
+	var reason string
 	if c.config.Concurrent {
-		c.crawlConcurrent()
+		reason = c.crawlConcurrent()
 	} else {
-		c.crawlSequential()
+		reason = c.crawlSequential()
+	}
+	if c.panel != nil {
+		c.panel.close()
+		c.panel = nil
+	}
+	c.metrics.SetStopReason(reason)
+	c.log.Info("Crawl ended: %s", reason)
+	c.pauseMu.Lock()
+	c.loopDone = true
+	c.pauseMu.Unlock()
+
+	stopWatchdog()
+	stopRecording()
+	if err := c.writeMetricsTimeSeries(); err != nil {
+		c.log.Warn("Failed to write metrics time series: %v", err)
+	}
+	if err := c.writeURLInventory(); err != nil {
+		c.log.Warn("Failed to write URL inventory: %v", err)
+	}
+	if err := c.writeRedirects(); err != nil {
+		c.log.Warn("Failed to write redirects: %v", err)
+	}
+	if err := c.writeCrawlHAR(); err != nil {
+		c.log.Warn("Failed to write HAR: %v", err)
+	}
+	if err := c.writeAPIEndpoints(); err != nil {
+		c.log.Warn("Failed to write API endpoints: %v", err)
+	}
+	if err := c.writeExternalLinks(); err != nil {
+		c.log.Warn("Failed to write external links: %v", err)
 	}
 
 	// Display final summary if progress is enabled
 	if c.config.ShowProgress {
-		c.metrics.DisplayFinalSummary()
+		c.displayFinalSummary()
 	}
 
 	// Write metrics to JSON if requested
 	if c.config.MetricsFile != "" {
-		if err := c.metrics.WriteJSON(c.config.MetricsFile); err != nil {
+		if err := c.metrics.WriteJSON(c.config.MetricsFile); err == nil {
+			err = c.perms.applyFile(c.config.MetricsFile)
+		}
+		if err != nil {
 			c.log.Error("Failed to write metrics: %v", err)
 		} else {
 			c.log.Info("Metrics written to %s", c.config.MetricsFile)
 		}
 	}
 
-	EmitStateChange(c.emitter, EventCrawlCompleted)
+	if err := c.writeCrawlReport(); err != nil {
+		c.log.Warn("Failed to write crawl report: %v", err)
+	} else if c.config.Report {
+		c.log.Info("Crawl report written to %s", filepath.Join(c.config.OutputDir, CrawlReportFile))
+	}
+
+	EmitCompleted(c.emitter, reason)
 
-	// Generate index page
-	if err := GenerateIndex(c.config.OutputDir); err != nil {
+	// Finalize index page
+	if err := c.index.Write(); err != nil {
 		c.log.Warn("Failed to generate index: %v", err)
 	} else {
 		c.log.Info("Generated index page at %s", filepath.Join(c.config.OutputDir, "_index.html"))
 	}
 
-	return SaveState(c.state, c.config.StateFile)
+	return c.saveState()
 }
 
-func (c *Crawler) crawlSequential() {
-	for len(c.state.Queue) > 0 {
+// crawlSequential processes the queue one URL at a time and returns why it stopped
+func (c *Crawler) crawlSequential() string {
+	for c.balanceFrontier() {
 		// Check for pause
 		c.checkPaused()
 
 		// Check for shutdown signal
 		if c.isShuttingDown() {
 			c.log.Info("Shutdown signal received, stopping crawl...")
-			return
+			return StopStopped
+		}
+
+		if reason := c.stopCondition(); reason != "" {
+			return reason
 		}
 
-		currentURLInfo := c.state.Queue[0]
-		c.state.Queue = c.state.Queue[1:]
+		currentURLInfo := c.nextQueued()
 
 		// Update metrics queue size
-		c.metrics.SetQueueSize(len(c.state.Queue))
+		c.metrics.SetQueueSize(c.queueSize())
 
-		c.log.Debug("Queue length: %d, Processing: %s (depth %d)", len(c.state.Queue), currentURLInfo.URL, currentURLInfo.Depth)
+		c.log.Debug("Queue length: %d, Processing: %s (depth %d)", c.queueSize(), currentURLInfo.URL, currentURLInfo.Depth)
 
 		// Remove from queued map
 		delete(c.state.Queued, currentURLInfo.URL)
@@ -506,6 +1072,9 @@ func (c *Crawler) crawlSequential() {
 		if c.state.Visited[currentURLInfo.URL] {
 			c.log.Debug("Skipping already visited: %s", currentURLInfo.URL)
 			c.metrics.IncrementSkipped()
+			c.inventory.Settle(currentURLInfo.URL, URLStatusSkipped, "already visited")
+			c.traceOutcome(currentURLInfo.URL, currentURLInfo.Depth, URLStatusSkipped, "already visited")
+			c.targetDone(currentURLInfo.URL, false)
 			continue
 		}
 
@@ -513,6 +1082,8 @@ func (c *Crawler) crawlSequential() {
 		if currentURLInfo.Depth > c.config.MaxDepth {
 			c.log.Debug("Skipping due to depth limit (%d > %d): %s", currentURLInfo.Depth, c.config.MaxDepth, currentURLInfo.URL)
 			c.metrics.IncrementDepthLimitHits()
+			c.recordURL(currentURLInfo.URL, currentURLInfo.Depth, URLStatusSkipped, "depth limit", nil)
+			c.targetDone(currentURLInfo.URL, false)
 			continue
 		}
 
@@ -525,28 +1096,31 @@ func (c *Crawler) crawlSequential() {
 			}()
 			c.processURL(currentURLInfo.URL, currentURLInfo.Depth)
 		}()
+		c.targetDone(currentURLInfo.URL, true)
 
 		// Emit progress event and display if enabled
 		if c.config.ShowProgress && c.metrics.ShouldDisplay() {
-			c.metrics.DisplayProgress(c.config.Verbose)
+			c.displayProgress(currentURLInfo.URL)
 			EmitProgress(c.emitter, c.metrics, currentURLInfo.URL)
 		}
 
-		time.Sleep(c.config.Delay)
+		time.Sleep(c.delay())
 
 		// Save state periodically
-		if c.state.Processed%StateSaveInterval == 0 {
-			c.log.Debug("Saving state at %d processed URLs", c.state.Processed)
-			if err := SaveState(c.state, c.config.StateFile); err != nil {
+		if processed := c.processedCount(); processed%StateSaveInterval == 0 {
+			c.log.Debug("Saving state at %d processed URLs", processed)
+			if err := c.saveState(); err != nil {
 				c.log.Warn("Failed to save state: %v", err)
 			}
 		}
 	}
 	c.log.Debug("Crawling completed. Queue is now empty.")
+	return StopQueueEmpty
 }
 
-func (c *Crawler) crawlConcurrent() {
-	var activeGoroutines atomic.Int64
+// crawlConcurrent processes the queue with a pool of workers and returns why it stopped
+func (c *Crawler) crawlConcurrent() string {
+	reason := StopQueueEmpty
 
 	for {
 		// Check for pause
@@ -555,18 +1129,23 @@ func (c *Crawler) crawlConcurrent() {
 		// Check for shutdown signal
 		if c.isShuttingDown() {
 			c.log.Info("Shutdown signal received, waiting for active goroutines to finish...")
+			reason = StopStopped
+			break
+		}
+
+		if r := c.stopCondition(); r != "" {
+			reason = r
 			break
 		}
 
 		// Check if we have URLs to process
-		if len(c.state.Queue) > 0 {
-			currentURLInfo := c.state.Queue[0]
-			c.state.Queue = c.state.Queue[1:]
+		if c.balanceFrontier() {
+			currentURLInfo := c.nextQueued()
 
 			// Update metrics queue size
-			c.metrics.SetQueueSize(len(c.state.Queue))
+			c.metrics.SetQueueSize(c.queueSize())
 
-			c.log.Debug("Concurrent - Queue length: %d, Processing: %s (depth %d)", len(c.state.Queue), currentURLInfo.URL, currentURLInfo.Depth)
+			c.log.Debug("Concurrent - Queue length: %d, Processing: %s (depth %d)", c.queueSize(), currentURLInfo.URL, currentURLInfo.Depth)
 
 			// Remove from queued map with proper locking
 			c.mu.Lock()
@@ -577,6 +1156,9 @@ func (c *Crawler) crawlConcurrent() {
 			if visited {
 				c.log.Debug("Concurrent - Skipping already visited: %s", currentURLInfo.URL)
 				c.metrics.IncrementSkipped()
+				c.inventory.Settle(currentURLInfo.URL, URLStatusSkipped, "already visited")
+				c.traceOutcome(currentURLInfo.URL, currentURLInfo.Depth, URLStatusSkipped, "already visited")
+				c.targetDone(currentURLInfo.URL, false)
 				continue
 			}
 
@@ -584,17 +1166,19 @@ func (c *Crawler) crawlConcurrent() {
 			if currentURLInfo.Depth > c.config.MaxDepth {
 				c.log.Debug("Concurrent - Skipping due to depth limit (%d > %d): %s", currentURLInfo.Depth, c.config.MaxDepth, currentURLInfo.URL)
 				c.metrics.IncrementDepthLimitHits()
+				c.recordURL(currentURLInfo.URL, currentURLInfo.Depth, URLStatusSkipped, "depth limit", nil)
+				c.targetDone(currentURLInfo.URL, false)
 				continue
 			}
 
 			c.wg.Add(1)
-			activeGoroutines.Add(1)
-			c.semaphore <- struct{}{} // Acquire semaphore
+			c.activeWorkers.Add(1)
+			c.workers.Acquire()
 
 			go func(urlInfo URLInfo) {
 				defer c.wg.Done()
-				defer func() { <-c.semaphore }()            // Release semaphore
-				defer func() { activeGoroutines.Add(-1) }() // Decrement counter atomically
+				defer c.workers.Release()
+				defer func() { c.activeWorkers.Add(-1) }() // Decrement counter atomically
 				defer func() {
 					if r := recover(); r != nil {
 						c.log.Error("Recovered from panic while processing %s: %v", urlInfo.URL, r)
@@ -603,26 +1187,27 @@ func (c *Crawler) crawlConcurrent() {
 				}()
 
 				c.processURL(urlInfo.URL, urlInfo.Depth)
-				time.Sleep(c.config.Delay)
+				c.targetDone(urlInfo.URL, true)
+				time.Sleep(c.delay())
 			}(currentURLInfo)
 
 			// Emit progress event and display if enabled
 			if c.config.ShowProgress && c.metrics.ShouldDisplay() {
-				c.metrics.DisplayProgress(c.config.Verbose)
+				c.displayProgress(currentURLInfo.URL)
 				EmitProgress(c.emitter, c.metrics, currentURLInfo.URL)
 			}
 
 			// Save state periodically
-			if c.state.Processed%StateSaveInterval == 0 {
-				c.log.Debug("Concurrent - Waiting for goroutines before saving state at %d processed URLs", c.state.Processed)
+			if processed := c.processedCount(); processed%StateSaveInterval == 0 {
+				c.log.Debug("Concurrent - Waiting for goroutines before saving state at %d processed URLs", processed)
 				c.wg.Wait()
-				if err := SaveState(c.state, c.config.StateFile); err != nil {
+				if err := c.saveState(); err != nil {
 					c.log.Warn("Failed to save state: %v", err)
 				}
 			}
 		} else {
 			// Queue is empty, check if we have active goroutines that might add more URLs
-			currentActive := activeGoroutines.Load()
+			currentActive := c.activeWorkers.Load()
 
 			if currentActive == 0 {
 				// No more goroutines running and queue is empty - we're done
@@ -639,6 +1224,14 @@ func (c *Crawler) crawlConcurrent() {
 	c.log.Debug("Concurrent - Main loop finished, waiting for remaining goroutines")
 	c.wg.Wait()
 	c.log.Debug("Concurrent - All goroutines finished, crawling completed")
+	return reason
+}
+
+// processedCount returns how many URLs workers have started processing
+func (c *Crawler) processedCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state.Processed
 }
 
 func (c *Crawler) processURL(rawURL string, currentDepth int) {
@@ -656,40 +1249,89 @@ func (c *Crawler) processURL(rawURL string, currentDepth int) {
 	}
 	c.state.Visited[rawURL] = true
 	c.state.Processed++
+	processed := c.state.Processed
 	c.mu.Unlock()
 
 	c.metrics.IncrementProcessed()
-	c.log.Info("[%d] Processing: %s", c.state.Processed, rawURL)
+	c.metrics.StartFetch(rawURL)
+	defer c.metrics.EndFetch(rawURL)
+	c.tracer.startURL(rawURL, currentDepth)
+	defer c.tracer.endURL(rawURL)
+	c.log.Info("[%d] Processing: %s", processed, rawURL)
 
 	// Check robots.txt before fetching
-	if !c.isAllowedByRobots(rawURL) {
+	robotsSpan := c.tracer.stage(rawURL, SpanRobots)
+	allowed := c.isAllowedByRobots(rawURL)
+	robotsSpan.End()
+	if !allowed {
 		c.log.Debug("Blocked by robots.txt: %s", rawURL)
 		c.metrics.IncrementRobotsBlocked()
+		c.recordURL(rawURL, currentDepth, URLStatusBlocked, "robots.txt", nil)
 		return
 	}
 
 	// Get user agent for fetcher
-	userAgent := c.config.UserAgent
-	if userAgent == "" {
-		userAgent = DefaultUserAgent
-	}
+	userAgent := c.userAgentFor(rawURL)
+	c.warmUp(rawURL, userAgent)
 
-	// Check if pagination is enabled and we're using browser mode
-	if c.config.Pagination.Enable && c.config.FetchMode == FetchModeBrowser {
+	// Check if pagination is enabled and we're using browser mode; URLs
+	// harvested from a pagination are regular pages
+	if c.config.Pagination.Enable && c.config.FetchMode == FetchModeBrowser && !c.isHarvested(rawURL) {
 		c.processURLWithPagination(rawURL, currentDepth, userAgent)
 		return
 	}
 
-	result, err := c.fetcher.Fetch(rawURL, userAgent)
+	// Fetching, parsing and saving run under URLTimeout
+	ctx, done := c.urlContext(rawURL)
+	defer done()
+
+	fetchSpan := c.tracer.stage(rawURL, SpanFetch)
+	result, err := fetchContext(ctx, c.fetcher, rawURL, userAgent)
+	endFetchSpan(fetchSpan, result, err)
+	if c.urlTimedOut(ctx, rawURL, currentDepth, "fetch", result) {
+		return
+	}
+	if errors.Is(err, ErrBrowserCrashed) && c.requeueCrashed(rawURL, currentDepth, err) {
+		return
+	}
+	c.saveHAR(rawURL, result)
+	c.recordAPIEndpoints(rawURL, result)
+	c.logCookieBanner(rawURL, result)
+	c.logPageScriptErrors(rawURL, result)
+	if finalURL, duplicate := c.trackRedirects(rawURL, result, err); duplicate {
+		c.log.Debug("Skipping %s: redirects to already visited %s", rawURL, finalURL)
+		c.metrics.IncrementSkipped()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, "redirects to already visited "+finalURL, result)
+		return
+	}
+	if isRedirectError(err) {
+		c.log.Warn("Giving up on %s: %v", rawURL, err)
+		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, err.Error(), result)
+		return
+	}
+	if errors.Is(err, ErrBodyTooLarge) {
+		c.log.Warn("Skipping %s: %v", rawURL, err)
+		c.metrics.IncrementContentFiltered()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, "body too large", result)
+		return
+	}
+	// Dead pages may still be saved from the Wayback Machine
+	if archived := c.waybackFallback(ctx, rawURL, result, err); archived != nil {
+		result, err = archived, nil
+		defer c.archived.Delete(rawURL)
+	}
 	if err != nil {
 		c.log.Error("Error fetching %s: %v", rawURL, err)
 		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, err.Error(), result)
 		return
 	}
 
 	if result.StatusCode != http.StatusOK {
 		c.log.Debug("HTTP %d for %s", result.StatusCode, rawURL)
 		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, fmt.Sprintf("HTTP %d", result.StatusCode), result)
 		return
 	}
 
@@ -697,76 +1339,213 @@ func (c *Crawler) processURL(rawURL string, currentDepth int) {
 	if c.shouldExcludeByContentType(result.ContentType) {
 		c.log.Debug("Skipping %s: excluded content type %s", rawURL, result.ContentType)
 		c.metrics.IncrementContentFiltered()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, "excluded content type", result)
 		return
 	}
 
 	body := result.Body
 
+	if c.exceedsMaxHTMLSize(body) {
+		c.log.Warn("Skipping %s: page is %s, larger than the %s parse limit", rawURL, FormatBytes(int64(len(body))), FormatBytes(c.config.MaxHTMLSize))
+		c.metrics.IncrementContentFiltered()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, "larger than parse limit", result)
+		return
+	}
+
+	// Parse once and share the document with every later stage
+	parseSpan := c.tracer.stage(rawURL, SpanParse)
+	page, err := NewPageDocument(rawURL, body)
+	parseSpan.End()
+	if err != nil {
+		c.log.Error("Error parsing HTML for %s: %v", rawURL, err)
+		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, "parse error: "+err.Error(), result)
+		return
+	}
+	page.UserAgent = userAgent
+	page = c.captureIframes(page, currentDepth, userAgent)
+	if c.urlTimedOut(ctx, rawURL, currentDepth, "parse", result) {
+		return
+	}
+
+	// Navigation hubs are traversed for their links but never saved
+	if reason := c.followOnlyReason(page); reason != "" {
+		c.log.Debug("Not saving %s: %s", rawURL, reason)
+		c.metrics.IncrementFollowOnly()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, reason, result)
+		c.queueLinks(page, currentDepth)
+		return
+	}
+
+	// Content filters and plugins clean the saved copy; links come from the
+	// whole page
+	content := c.transformContent(c.filterContent(page))
+
 	// Check if page has meaningful content
-	if !c.hasContent(string(body)) {
+	if !c.hasContent(content) {
 		c.log.Debug("Skipping %s: no meaningful content", rawURL)
 		c.metrics.IncrementContentFiltered()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, "no meaningful content", result)
+		return
+	}
+
+	// Hub pages kept out of the saved corpus still lead to their links
+	if reason := c.pageFilterReason(content); reason != "" {
+		c.log.Debug("Skipping %s: %s", rawURL, reason)
+		c.metrics.IncrementContentFiltered()
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, reason, result)
+		c.queueLinks(page, currentDepth)
+		return
+	}
+
+	if c.config.RecrawlScope == RecrawlChanged && c.unchangedOnDisk(rawURL, content.Body) {
+		c.log.Debug("Skipping %s: unchanged since the last crawl", rawURL)
+		c.metrics.IncrementSkipped()
+		c.recordURL(rawURL, currentDepth, URLStatusSaved, "unchanged", result)
+		return
+	}
+
+	if c.urlTimedOut(ctx, rawURL, currentDepth, "filters", result) {
 		return
 	}
 
 	// Save the content
-	if err := c.saveContent(rawURL, body); err != nil {
+	if err := c.saveContent(rawURL, content); err != nil {
 		c.log.Error("Error saving content for %s: %v", rawURL, err)
 		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, "save error: "+err.Error(), result)
 		return
 	}
 
 	c.metrics.IncrementSaved(int64(len(body)))
+	c.recordURL(rawURL, currentDepth, URLStatusSaved, "", result)
 
-	// Extract and queue new URLs - wrap in error handling
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				c.log.Error("Panic extracting URLs from %s: %v", rawURL, r)
-			}
-		}()
-		c.extractAndQueueURLs(rawURL, string(body), currentDepth)
+	c.queueLinks(page, currentDepth)
+}
+
+// queueLinks extracts and queues the links of page, logging a panic in link
+// extraction instead of propagating it
+func (c *Crawler) queueLinks(page *PageDocument, currentDepth int) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Error("Panic extracting URLs from %s: %v", page.URL, r)
+		}
 	}()
+	c.extractAndQueueURLs(page, currentDepth)
 }
 
 // processURLWithPagination handles URL processing with click-based pagination
 func (c *Crawler) processURLWithPagination(rawURL string, currentDepth int, userAgent string) {
-	browserFetcher, ok := c.fetcher.(*BrowserFetcher)
+	browserFetcher, ok := asBrowserFetcher(c.fetcher)
 	if !ok {
 		c.log.Error("Pagination enabled but fetcher is not a BrowserFetcher")
 		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, "pagination requires browser fetcher", nil)
 		return
 	}
 
 	c.log.Debug("Using pagination for %s (selector: %s)", rawURL, c.config.Pagination.Selector)
 
+	// Pages captured before an interruption are clicked through, not saved again
+	c.mu.Lock()
+	captured := c.state.Pagination[rawURL]
+	c.state.Pagination[rawURL] = append([]string{}, captured...)
+	c.mu.Unlock()
+	if len(captured) > 0 {
+		c.log.Info("Resuming pagination of %s after %d captured pages", rawURL, len(captured))
+	}
+	maxClicks := c.config.Pagination.MaxClicks
+	c.metrics.SetPaginationClicks(rawURL, 0, maxClicks)
+
 	// Page callback processes each paginated page
-	pageCallback := func(result *FetchResult, pageNumber int, virtualURL string) error {
+	savedPages := 0
+	pageCallback := func(result *FetchResult, pageNumber int, virtualURL string, contentHash string) error {
+		if c.isShuttingDown() {
+			return errPaginationInterrupted
+		}
+		c.metrics.SetPaginationClicks(rawURL, pageNumber-1, maxClicks)
+		if result == nil {
+			c.log.Debug("Page %d of %s was captured before, clicking through", pageNumber, rawURL)
+			return nil
+		}
+		defer c.recordPaginationPage(rawURL, pageNumber, contentHash)
+
+		c.saveHAR(virtualURL, result)
+		c.recordAPIEndpoints(virtualURL, result)
+		c.logCookieBanner(virtualURL, result)
+		c.logPageScriptErrors(virtualURL, result)
+
+		if c.harvestPageURL(rawURL, currentDepth, pageNumber, result) {
+			return nil
+		}
+
 		// Check if content type should be excluded
 		if c.shouldExcludeByContentType(result.ContentType) {
 			c.log.Debug("Skipping page %d of %s: excluded content type %s", pageNumber, rawURL, result.ContentType)
 			c.metrics.IncrementContentFiltered()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusSkipped, "excluded content type", result)
 			return nil
 		}
 
 		body := result.Body
 
+		if c.exceedsMaxHTMLSize(body) {
+			c.log.Warn("Skipping page %d of %s: page is %s, larger than the %s parse limit", pageNumber, rawURL, FormatBytes(int64(len(body))), FormatBytes(c.config.MaxHTMLSize))
+			c.metrics.IncrementContentFiltered()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusSkipped, "larger than parse limit", result)
+			return nil
+		}
+
+		parseSpan := c.tracer.stage(rawURL, SpanParse)
+		page, err := NewPageDocument(rawURL, body)
+		parseSpan.End()
+		if err != nil {
+			c.log.Error("Error parsing HTML for page %d of %s: %v", pageNumber, rawURL, err)
+			c.metrics.IncrementErrored()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusError, "parse error: "+err.Error(), result)
+			return nil
+		}
+		page.UserAgent = userAgent
+		page = c.captureIframes(page, currentDepth, userAgent)
+
+		if reason := c.followOnlyReason(page); reason != "" {
+			c.log.Debug("Not saving page %d of %s: %s", pageNumber, rawURL, reason)
+			c.metrics.IncrementFollowOnly()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusSkipped, reason, result)
+			c.queueLinks(page, currentDepth)
+			return nil
+		}
+
+		content := c.transformContent(c.filterContent(page))
+
 		// Check if page has meaningful content
-		if !c.hasContent(string(body)) {
+		if !c.hasContent(content) {
 			c.log.Debug("Skipping page %d of %s: no meaningful content", pageNumber, rawURL)
 			c.metrics.IncrementContentFiltered()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusSkipped, "no meaningful content", result)
+			return nil
+		}
+
+		if reason := c.pageFilterReason(content); reason != "" {
+			c.log.Debug("Skipping page %d of %s: %s", pageNumber, rawURL, reason)
+			c.metrics.IncrementContentFiltered()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusSkipped, reason, result)
+			c.queueLinks(page, currentDepth)
 			return nil
 		}
 
 		// Save the content using the virtual URL for unique filenames
-		if err := c.saveContent(virtualURL, body); err != nil {
+		if err := c.saveContent(virtualURL, content); err != nil {
 			c.log.Error("Error saving content for page %d of %s: %v", pageNumber, rawURL, err)
 			c.metrics.IncrementErrored()
+			c.recordPage(rawURL, virtualURL, currentDepth, URLStatusError, "save error: "+err.Error(), result)
 			return nil // Don't stop pagination on save error
 		}
 
 		c.metrics.IncrementSaved(int64(len(body)))
-		c.log.Info("[%d] Saved page %d: %s", c.state.Processed, pageNumber, virtualURL)
+		c.recordPage(rawURL, virtualURL, currentDepth, URLStatusSaved, "", result)
+		savedPages++
+		c.log.Info("[%d] Saved page %d: %s", c.processedCount(), pageNumber, virtualURL)
 
 		// Extract and queue new URLs at the same depth (pagination doesn't increase depth)
 		func() {
@@ -775,38 +1554,85 @@ func (c *Crawler) processURLWithPagination(rawURL string, currentDepth int, user
 					c.log.Error("Panic extracting URLs from page %d of %s: %v", pageNumber, rawURL, r)
 				}
 			}()
-			c.extractAndQueueURLs(rawURL, string(body), currentDepth)
+			c.extractAndQueueURLs(page, currentDepth)
 		}()
 
 		return nil
 	}
 
 	// Execute paginated fetch
-	paginationResult, err := browserFetcher.FetchWithPagination(rawURL, userAgent, c.config.Pagination, pageCallback)
+	paginationResult, err := browserFetcher.FetchWithPagination(rawURL, userAgent, c.config.Pagination, captured, pageCallback)
+	if errors.Is(err, errPaginationInterrupted) || (err == nil && errors.Is(paginationResult.LastError, errPaginationInterrupted)) {
+		// The URL stays in the state's pagination progress, so a resumed
+		// crawl queues it again and carries on from the last captured page
+		c.log.Info("Pagination of %s interrupted after %d pages", rawURL, paginationResult.TotalPages)
+		return
+	}
+	if errors.Is(err, ErrBrowserCrashed) && c.requeueCrashed(rawURL, currentDepth, err) {
+		// The pagination progress is kept to carry on after the captured pages
+		return
+	}
+	c.mu.Lock()
+	delete(c.state.Pagination, rawURL)
+	c.mu.Unlock()
 	if err != nil {
 		c.log.Error("Error during pagination for %s: %v", rawURL, err)
 		c.metrics.IncrementErrored()
+		c.recordURL(rawURL, currentDepth, URLStatusError, err.Error(), nil)
 		return
 	}
 
 	c.log.Debug("Pagination completed for %s: %d pages fetched, reason: %s",
 		rawURL, paginationResult.TotalPages, paginationResult.ExhaustedReason)
 
+	// The paginated URL itself is saved through its pages
+	if savedPages > 0 {
+		c.recordURL(rawURL, currentDepth, URLStatusSaved, fmt.Sprintf("%d pages saved", savedPages), nil)
+	} else if len(captured) > 0 {
+		c.recordURL(rawURL, currentDepth, URLStatusSaved, fmt.Sprintf("%d pages captured before resuming", len(captured)), nil)
+	} else {
+		c.recordURL(rawURL, currentDepth, URLStatusSkipped, "no pages saved", nil)
+	}
+
 	if paginationResult.LastError != nil {
 		c.log.Warn("Pagination had errors for %s: %v", rawURL, paginationResult.LastError)
 	}
 }
 
-func (c *Crawler) extractAndQueueURLs(baseURL, html string, currentDepth int) {
+// errPaginationInterrupted stops a pagination when the crawl shuts down
+var errPaginationInterrupted = errors.New("pagination interrupted")
+
+// recordPaginationPage records the content hash of page pageNumber of
+// rawURL in the crawl state. Sequential crawls also save the state every
+// StateSaveInterval pages, so a crash loses few of a long pagination's pages.
+func (c *Crawler) recordPaginationPage(rawURL string, pageNumber int, contentHash string) {
+	c.mu.Lock()
+	hashes := c.state.Pagination[rawURL]
+	if pageNumber > 0 && len(hashes) >= pageNumber {
+		hashes = hashes[:pageNumber-1]
+	}
+	c.state.Pagination[rawURL] = append(hashes, contentHash)
+	c.mu.Unlock()
+
+	if !c.config.Concurrent && pageNumber%StateSaveInterval == 0 {
+		if err := c.saveState(); err != nil {
+			c.log.Warn("Failed to save state: %v", err)
+		}
+	}
+}
+
+func (c *Crawler) extractAndQueueURLs(page *PageDocument, currentDepth int) {
+	baseURL := page.URL
+	doc := page.Doc
+
 	defer func() {
 		if r := recover(); r != nil {
 			c.log.Error("Panic in extractAndQueueURLs for %s: %v", baseURL, r)
 		}
 	}()
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
-	if err != nil {
-		c.log.Error("Error parsing HTML for %s: %v", baseURL, err)
+	// Re-crawls only refresh the selected URLs
+	if len(c.config.RecrawlURLs) > 0 {
 		return
 	}
 
@@ -823,6 +1649,9 @@ func (c *Crawler) extractAndQueueURLs(baseURL, html string, currentDepth int) {
 		selectors = []string{"a[href]"}
 	}
 
+	// Headings give every link the context of the section it is in
+	headings := linkHeadings(page.Root())
+
 	// Process each selector
 	for _, selector := range selectors {
 		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
@@ -839,35 +1668,52 @@ func (c *Crawler) extractAndQueueURLs(baseURL, html string, currentDepth int) {
 
 			absoluteURL, err := base.Parse(href)
 			if err != nil {
-				// Skip malformed URLs silently
+				// Skip malformed URLs
+				c.traceLink(href, baseURL, currentDepth, LinkFilterInvalid, err.Error())
 				return
 			}
 
 			urlStr := absoluteURL.String()
+			link := linkContext(s, headings)
 
-			if c.isValidURL(urlStr) {
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							c.log.Error("Panic queuing URL %s: %v", urlStr, r)
-						}
-					}()
-
-					// Normalize URL for deduplication
-					normalizedURL := c.normalizeURL(urlStr)
-
-					c.mu.Lock()
-					defer c.mu.Unlock()
-
-					if !c.state.Visited[normalizedURL] && !c.state.Queued[normalizedURL] {
-						// Add URL with incremented depth (store normalized URL)
-						newDepth := currentDepth + 1
-						c.state.Queue = append(c.state.Queue, URLInfo{URL: normalizedURL, Depth: newDepth})
-						c.state.URLDepths[normalizedURL] = newDepth
-						c.state.Queued[normalizedURL] = true
+			if reason := c.linkFilterReason(urlStr); reason != "" {
+				c.traceLink(urlStr, baseURL, currentDepth, reason, "")
+				c.recordExternalLink(urlStr, baseURL, reason, link)
+				return
+			}
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						c.log.Error("Panic queuing URL %s: %v", urlStr, r)
 					}
 				}()
-			}
+
+				// Normalize URL for deduplication, requesting known
+				// permanent redirects at their final URL
+				normalizedURL := c.resolveAlias(c.normalizeURL(urlStr))
+
+				c.mu.Lock()
+				defer c.mu.Unlock()
+
+				switch {
+				case c.state.Visited[normalizedURL]:
+					c.traceLink(normalizedURL, baseURL, currentDepth, DecisionDedup, "already visited")
+				case c.isQueued(normalizedURL):
+					c.traceLink(normalizedURL, baseURL, currentDepth, DecisionDedup, "already queued")
+				default:
+					// Add URL one level deeper, in link hops or path segments (store normalized URL)
+					newDepth := c.linkDepth(normalizedURL, currentDepth)
+					c.state.Queue = append(c.state.Queue, URLInfo{URL: normalizedURL, Depth: newDepth})
+					c.state.URLDepths[normalizedURL] = newDepth
+					c.state.Queued[normalizedURL] = true
+					c.inventory.DiscoverLink(normalizedURL, newDepth, baseURL, link)
+					c.metrics.IncrementDiscoveredAt(newDepth)
+					c.targetQueued(normalizedURL)
+					c.traceLink(normalizedURL, baseURL, currentDepth, DecisionQueued, "")
+					c.prefetchRobots(normalizedURL)
+				}
+			}()
 		})
 	}
 }
@@ -2,10 +2,18 @@ package crawler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -451,14 +459,32 @@ func TestHasContent(t *testing.T) {
 				config: Config{},
 				log:    &Logger{verbose: false},
 			}
-			result := c.hasContent(tt.html)
+			page, err := NewPageDocument("https://example.com", []byte(tt.html))
+			if err != nil {
+				t.Fatalf("NewPageDocument() error = %v", err)
+			}
+			result := c.hasContent(page)
 			if result != tt.expected {
 				t.Errorf("hasContent() = %v, want %v", result, tt.expected)
 			}
+			// The shared document must be left intact for link extraction
+			if page.Doc.Find("script, style").Length() != strings.Count(tt.html, "<script>")+strings.Count(tt.html, "<style>") {
+				t.Error("hasContent() modified the document")
+			}
 		})
 	}
 }
 
+func TestExceedsMaxHTMLSize(t *testing.T) {
+	c := &Crawler{config: Config{MaxHTMLSize: 10}}
+	if c.exceedsMaxHTMLSize([]byte("0123456789")) {
+		t.Error("body at the limit should be accepted")
+	}
+	if !c.exceedsMaxHTMLSize([]byte("0123456789a")) {
+		t.Error("body over the limit should be rejected")
+	}
+}
+
 func TestStatePersistence(t *testing.T) {
 	// Create a temporary directory for test files
 	tmpDir, err := os.MkdirTemp("", "crawler_test")
@@ -587,6 +613,196 @@ func TestStateBackwardCompatibility(t *testing.T) {
 	if !state.Queued["https://example.com/page2"] {
 		t.Error("Queued should contain page2 from queue")
 	}
+	if state.SchemaVersion != StateSchemaVersion {
+		t.Errorf("SchemaVersion = %d, want %d", state.SchemaVersion, StateSchemaVersion)
+	}
+	backup, err := os.ReadFile(StateBackupPath(stateFile, 1))
+	if err != nil {
+		t.Fatalf("migrated state should be backed up: %v", err)
+	}
+	if string(backup) != oldStateJSON {
+		t.Error("backup should hold the state file as it was")
+	}
+}
+
+func TestStateSchemaVersion(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	state := NewCrawlerState("https://example.com")
+	state.Visited["https://example.com/"] = true
+	if err := SaveState(state, stateFile); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"schema_version": 3`) {
+		t.Errorf("saved state should carry its schema version: %s", data)
+	}
+	if _, err := LoadState(stateFile, "https://example.com"); err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if _, err := os.Stat(StateBackupPath(stateFile, StateSchemaVersion)); !os.IsNotExist(err) {
+		t.Error("current state files should not be backed up")
+	}
+
+	future := `{"schema_version": 99, "visited": {"https://example.com/": true}}`
+	if err := os.WriteFile(stateFile, []byte(future), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadState(stateFile, "https://example.com")
+	if !errors.Is(err, ErrStateTooNew) {
+		t.Fatalf("LoadState() error = %v, want ErrStateTooNew", err)
+	}
+	if !strings.Contains(err.Error(), "schema 99") {
+		t.Errorf("error should name the version: %v", err)
+	}
+	if backup, err := os.ReadFile(StateBackupPath(stateFile, 99)); err != nil || string(backup) != future {
+		t.Errorf("refused state should be backed up, got %q, %v", backup, err)
+	}
+	if data, _ := os.ReadFile(stateFile); string(data) != future {
+		t.Error("refused state file should be left untouched")
+	}
+}
+
+func TestStateRequeuesInterruptedPagination(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	state := NewCrawlerState("https://example.com")
+	state.Visited["https://example.com/list"] = true
+	state.Visited["https://example.com/done"] = true
+	state.URLDepths["https://example.com/list"] = 2
+	state.Queue = []URLInfo{{URL: "https://example.com/next", Depth: 1}}
+	state.Queued["https://example.com/next"] = true
+	state.Pagination["https://example.com/list"] = []string{"h1", "h2", "h3"}
+	if err := SaveState(state, stateFile); err != nil {
+		t.Fatalf("SaveState() failed: %v", err)
+	}
+
+	loaded, err := LoadState(stateFile, "https://example.com")
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	want := []URLInfo{{URL: "https://example.com/list", Depth: 2}, {URL: "https://example.com/next", Depth: 1}}
+	if !reflect.DeepEqual(loaded.Queue, want) {
+		t.Errorf("Queue = %+v, want %+v", loaded.Queue, want)
+	}
+	if loaded.Visited["https://example.com/list"] || !loaded.Queued["https://example.com/list"] {
+		t.Error("interrupted pagination should be queued again, not visited")
+	}
+	if !loaded.Visited["https://example.com/done"] {
+		t.Error("finished URLs should stay visited")
+	}
+	if got := loaded.Pagination["https://example.com/list"]; len(got) != 3 {
+		t.Errorf("captured pages = %v, want 3 hashes", got)
+	}
+}
+
+func TestCompareStartURLs(t *testing.T) {
+	tests := []struct {
+		saved, current, want string
+	}{
+		{"", "https://example.com", StartURLSame},
+		{"https://example.com/docs", "https://example.com/docs/", StartURLSame},
+		{"https://Example.com:443/docs", "https://example.com/docs", StartURLSame},
+		{"http://example.com/docs", "https://example.com/docs/", StartURLVariant},
+		{"https://www.example.com", "https://example.com/", StartURLVariant},
+		{"https://example.com/docs", "https://example.com/blog", StartURLOther},
+		{"https://example.com", "https://example.org", StartURLOther},
+		{"https://example.com/?lang=en", "https://example.com/?lang=de", StartURLOther},
+	}
+	for _, tt := range tests {
+		if got := CompareStartURLs(tt.saved, tt.current); got != tt.want {
+			t.Errorf("CompareStartURLs(%q, %q) = %q, want %q", tt.saved, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestMergeStartURL(t *testing.T) {
+	state := NewCrawlerState("http://example.com/")
+	state.Visited["http://example.com/"] = true
+	state.Visited["http://example.com/a"] = true
+	state.Visited["https://example.com/b"] = true
+	state.Visited["https://other.org/x"] = true
+	state.URLDepths["http://example.com/a"] = 1
+	state.URLDepths["https://example.com/a"] = 3
+	state.Queue = []URLInfo{{URL: "http://example.com/c", Depth: 2}, {URL: "https://example.com/a", Depth: 3}}
+	state.Queued["http://example.com/c"] = true
+	state.Queued["https://example.com/a"] = true
+	state.Aliases["http://example.com/old"] = "http://example.com/new"
+
+	if n := state.MergeStartURL("https://example.com/"); n != 2 {
+		t.Errorf("rewritten = %d, want 2", n)
+	}
+	if state.BaseURL != "https://example.com/" {
+		t.Errorf("BaseURL = %q", state.BaseURL)
+	}
+	for _, u := range []string{"https://example.com/", "https://example.com/a", "https://example.com/b", "https://other.org/x"} {
+		if !state.Visited[u] {
+			t.Errorf("%s should be visited", u)
+		}
+	}
+	if state.Visited["http://example.com/a"] {
+		t.Error("old form of a URL should be gone")
+	}
+	want := []URLInfo{{URL: "https://example.com/c", Depth: 2}}
+	if !reflect.DeepEqual(state.Queue, want) {
+		t.Errorf("Queue = %+v, want %+v (visited URLs dropped)", state.Queue, want)
+	}
+	if state.Queued["https://example.com/a"] || !state.Queued["https://example.com/c"] {
+		t.Errorf("Queued = %v", state.Queued)
+	}
+	if state.URLDepths["https://example.com/a"] != 1 {
+		t.Errorf("merged depth = %d, want the smaller 1", state.URLDepths["https://example.com/a"])
+	}
+	if state.Aliases["https://example.com/old"] != "https://example.com/new" {
+		t.Errorf("Aliases = %v", state.Aliases)
+	}
+}
+
+func TestReconcileStartURL(t *testing.T) {
+	newCrawler := func(merge bool) *Crawler {
+		c, err := NewCrawler(Config{URL: "https://example.com/blog", StateMerge: merge, MaxDepth: 10}, context.Background())
+		if err != nil {
+			t.Fatalf("NewCrawler() error = %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		c.state = NewCrawlerState("https://example.com/docs")
+		c.state.Visited["https://example.com/docs"] = true
+		c.state.Queue = []URLInfo{{URL: "https://example.com/docs/next", Depth: 1}}
+		c.state.Queued["https://example.com/docs/next"] = true
+		return c
+	}
+
+	// Without merging another site's state is resumed as it is
+	c := newCrawler(false)
+	c.reconcileStartURL()
+	if c.state.BaseURL != "https://example.com/docs" || len(c.state.Queue) != 1 {
+		t.Errorf("state should be left alone, got %+v", c.state)
+	}
+
+	// Merging queues the new start URL in front of the resumed queue
+	c = newCrawler(true)
+	c.reconcileStartURL()
+	if c.state.BaseURL != "https://example.com/blog" {
+		t.Errorf("BaseURL = %q", c.state.BaseURL)
+	}
+	if len(c.state.Queue) != 2 || c.state.Queue[0].URL != "https://example.com/blog" || !c.state.Visited["https://example.com/docs"] {
+		t.Errorf("expected the start URL queued and the visited set kept, got %+v", c.state)
+	}
+}
+
+func TestRecordPaginationPage(t *testing.T) {
+	c := &Crawler{config: Config{Concurrent: true}, state: NewCrawlerState("https://example.com")}
+	c.state.Pagination["https://example.com/list"] = []string{"h1", "h2", "h3"}
+
+	// A page that changed since the interruption replaces it and the pages after it
+	c.recordPaginationPage("https://example.com/list", 2, "new2")
+	c.recordPaginationPage("https://example.com/list", 3, "new3")
+	c.recordPaginationPage("https://example.com/list", 4, "new4")
+	want := []string{"h1", "new2", "new3", "new4"}
+	if got := c.state.Pagination["https://example.com/list"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("captured pages = %v, want %v", got, want)
+	}
 }
 
 func TestValidateConfig(t *testing.T) {
@@ -632,6 +848,177 @@ func TestValidateConfig(t *testing.T) {
 			expectError: true,
 			errorMsg:    "URL must have a host",
 		},
+		{
+			name: "negative max pages",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				MaxPages: -1,
+			},
+			expectError: true,
+			errorMsg:    "max-pages cannot be negative",
+		},
+		{
+			name: "too many workers",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				Workers:  MaxWorkers + 1,
+			},
+			expectError: true,
+			errorMsg:    "workers must be between 1 and",
+		},
+		{
+			name: "negative index interval",
+			config: Config{
+				URL:           "https://example.com",
+				MaxDepth:      10,
+				IndexInterval: -1,
+			},
+			expectError: true,
+			errorMsg:    "index-interval cannot be negative",
+		},
+		{
+			name: "unknown progress format",
+			config: Config{
+				URL:            "https://example.com",
+				MaxDepth:       10,
+				ProgressFormat: "xml",
+			},
+			expectError: true,
+			errorMsg:    "progress-format must be one of",
+		},
+		{
+			name: "unknown compression",
+			config: Config{
+				URL:            "https://example.com",
+				MaxDepth:       10,
+				CompressOutput: "brotli",
+			},
+			expectError: true,
+			errorMsg:    "compress must be one of",
+		},
+		{
+			name: "unknown HAR mode",
+			config: Config{
+				URL:       "https://example.com",
+				MaxDepth:  10,
+				FetchMode: FetchModeBrowser,
+				HARMode:   "all",
+			},
+			expectError: true,
+			errorMsg:    "har must be one of",
+		},
+		{
+			name: "HAR capture in HTTP mode",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				HARMode:  HARModePage,
+			},
+			expectError: true,
+			errorMsg:    "HAR capture requires browser fetch mode",
+		},
+		{
+			name: "HAR capture off in HTTP mode",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				HARMode:  HARModeOff,
+			},
+			expectError: false,
+		},
+		{
+			name: "API discovery in HTTP mode",
+			config: Config{
+				URL:          "https://example.com",
+				MaxDepth:     10,
+				DiscoverAPIs: true,
+			},
+			expectError: true,
+			errorMsg:    "API discovery requires browser fetch mode",
+		},
+		{
+			name: "geolocation in HTTP mode",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				Geo:      GeoConfig{Region: "us", Geolocation: "40.7,-74.0"},
+			},
+			expectError: true,
+			errorMsg:    "locale, timezone and geolocation emulation require browser fetch mode",
+		},
+		{
+			name: "invalid output file mode",
+			config: Config{
+				URL:         "https://example.com",
+				MaxDepth:    10,
+				Permissions: OutputPermissions{FileMode: "0868"},
+			},
+			expectError: true,
+			errorMsg:    "invalid file mode",
+		},
+		{
+			name: "trace sample rate above 1",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				Tracing:  TracingConfig{Endpoint: "http://localhost:4318", SampleRate: 1.5},
+			},
+			expectError: true,
+			errorMsg:    "trace sample rate must be between 0 and 1",
+		},
+		{
+			name: "OTLP endpoint without scheme",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				Tracing:  TracingConfig{Endpoint: "localhost:4318"},
+			},
+			expectError: true,
+			errorMsg:    "OTLP endpoint must be an http or https URL",
+		},
+		{
+			name: "invalid re-crawl scope",
+			config: Config{
+				URL:          "https://example.com",
+				MaxDepth:     10,
+				RecrawlScope: "all",
+			},
+			expectError: true,
+			errorMsg:    "re-crawl scope must be",
+		},
+		{
+			name: "page scripts in HTTP mode",
+			config: Config{
+				URL:         "https://example.com",
+				MaxDepth:    10,
+				PageScripts: []PageScript{{Pattern: ".*", Script: "1"}},
+			},
+			expectError: true,
+			errorMsg:    "page scripts require browser fetch mode",
+		},
+		{
+			name: "invalid page script pattern",
+			config: Config{
+				URL:         "https://example.com",
+				MaxDepth:    10,
+				FetchMode:   FetchModeBrowser,
+				PageScripts: []PageScript{{Pattern: "(", Script: "1"}},
+			},
+			expectError: true,
+			errorMsg:    "invalid pattern",
+		},
+		{
+			name: "negative metrics interval",
+			config: Config{
+				URL:             "https://example.com",
+				MaxDepth:        10,
+				MetricsInterval: -time.Second,
+			},
+			expectError: true,
+			errorMsg:    "metrics-interval cannot be negative",
+		},
 		{
 			name: "zero depth",
 			config: Config{
@@ -734,6 +1121,120 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "max-content not above min-content",
+			config: Config{
+				URL:              "https://example.com",
+				MaxDepth:         10,
+				MinContentLength: 500,
+				MaxContentLength: 500,
+			},
+			expectError: true,
+			errorMsg:    "max-content must be greater than min-content",
+		},
+		{
+			name: "max-words below min-words",
+			config: Config{
+				URL:      "https://example.com",
+				MaxDepth: 10,
+				MinWords: 200,
+				MaxWords: 100,
+			},
+			expectError: true,
+			errorMsg:    "max-words must not be less than min-words",
+		},
+		{
+			name: "link density above 1",
+			config: Config{
+				URL:            "https://example.com",
+				MaxDepth:       10,
+				MaxLinkDensity: 1.5,
+			},
+			expectError: true,
+			errorMsg:    "max-link-density must be between 0 and 1",
+		},
+		{
+			name: "page filters are valid",
+			config: Config{
+				URL:              "https://example.com",
+				MaxDepth:         10,
+				MaxContentLength: 100000,
+				MinWords:         50,
+				MaxWords:         5000,
+				MaxLinkDensity:   0.5,
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid depth mode",
+			config: Config{
+				URL:       "https://example.com",
+				MaxDepth:  10,
+				DepthMode: "segments",
+			},
+			expectError: true,
+			errorMsg:    "depth mode must be links or path",
+		},
+		{
+			name: "negative max runtime",
+			config: Config{
+				URL:        "https://example.com",
+				MaxDepth:   10,
+				MaxRuntime: -time.Second,
+			},
+			expectError: true,
+			errorMsg:    "max-runtime cannot be negative",
+		},
+		{
+			name: "negative max consecutive errors",
+			config: Config{
+				URL:                  "https://example.com",
+				MaxDepth:             10,
+				MaxConsecutiveErrors: -1,
+			},
+			expectError: true,
+			errorMsg:    "max-consecutive-errors cannot be negative",
+		},
+		{
+			name: "invalid stop pattern",
+			config: Config{
+				URL:         "https://example.com",
+				MaxDepth:    10,
+				StopPattern: "(",
+			},
+			expectError: true,
+			errorMsg:    "invalid stop pattern",
+		},
+		{
+			name: "host lists",
+			config: Config{
+				URL:          "https://docs.example.com",
+				MaxDepth:     10,
+				AllowedHosts: []string{"*.example.com"},
+				DeniedHosts:  []string{"cdn.example.com"},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid host pattern",
+			config: Config{
+				URL:         "https://example.com",
+				MaxDepth:    10,
+				DeniedHosts: []string{"https://cdn.example.com/"},
+			},
+			expectError: true,
+			errorMsg:    "invalid denied host",
+		},
+		{
+			name: "start host not allowed",
+			config: Config{
+				URL:          "https://example.com",
+				MaxDepth:     10,
+				AllowedHosts: []string{"docs.example.com"},
+			},
+			expectError: true,
+			errorMsg:    "not among the allowed hosts",
+		},
 	}
 
 	for _, tt := range tests {
@@ -861,6 +1362,12 @@ func TestCrawlerMetrics(t *testing.T) {
 	if snapshot.URLsProcessed != 2 {
 		t.Errorf("snapshot.URLsProcessed = %d, want 2", snapshot.URLsProcessed)
 	}
+	if snapshot.HeapAlloc <= 0 || snapshot.SysMemory <= 0 {
+		t.Errorf("snapshot memory stats not sampled: heap=%d sys=%d", snapshot.HeapAlloc, snapshot.SysMemory)
+	}
+	if snapshot.PeakHeapAlloc < snapshot.HeapAlloc {
+		t.Errorf("PeakHeapAlloc = %d, want >= HeapAlloc %d", snapshot.PeakHeapAlloc, snapshot.HeapAlloc)
+	}
 
 	// Test Finalize
 	m.Finalize()
@@ -872,6 +1379,55 @@ func TestCrawlerMetrics(t *testing.T) {
 	}
 }
 
+func TestMetricsSnapshot(t *testing.T) {
+	m := NewCrawlerMetrics()
+	// Every exported field set, so one GetSnapshot leaves out shows up
+	v := reflect.ValueOf(m).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i); {
+		case !v.Type().Field(i).IsExported():
+		case f.CanInt():
+			f.SetInt(int64(i + 1))
+		case f.CanFloat():
+			f.SetFloat(float64(i + 1))
+		case f.Kind() == reflect.String:
+			f.SetString("set")
+		}
+	}
+	m.EndTime = m.StartTime.Add(time.Minute)
+	m.IncrementDiscoveredAt(1)
+	m.RecordResponse("https://example.com/", &FetchResult{StatusCode: 200, ContentType: "text/html"})
+	m.StartFetch("https://example.com/a")
+	m.RecordError("https://example.com/b", "timeout")
+
+	snapshot := m.GetSnapshot()
+	sv := reflect.ValueOf(&snapshot).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Name == "PagesPerSecond" {
+			continue
+		}
+		if got, want := sv.Field(i).Interface(), v.Field(i).Interface(); !reflect.DeepEqual(got, want) {
+			t.Errorf("snapshot.%s = %v, want %v", field.Name, got, want)
+		}
+	}
+
+	// Snapshots taken while a crawl updates the counters (run with -race)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m.IncrementDiscoveredAt(i % 5)
+			m.IncrementProcessed()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		m.GetSnapshot()
+	}
+	wg.Wait()
+}
+
 func TestMetricsJSONOutput(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "metrics_test")
 	if err != nil {
@@ -1101,7 +1657,11 @@ func TestExtractContent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			content, _, err := c.extractContent("https://example.com/article", tt.html)
+			page, err := NewPageDocument("https://example.com/article", []byte(tt.html))
+			if err != nil {
+				t.Fatalf("NewPageDocument() error = %v", err)
+			}
+			content, _, err := c.extractContent("https://example.com/article", page)
 			if tt.expectError && err == nil {
 				t.Error("expected error but got none")
 			}
@@ -1156,7 +1716,11 @@ func TestSaveContentWithExtraction(t *testing.T) {
 			t.Fatalf("failed to create output dir: %v", err)
 		}
 
-		err = c.saveContent("https://example.com/article", []byte(html))
+		page, err := NewPageDocument("https://example.com/article", []byte(html))
+		if err != nil {
+			t.Fatalf("NewPageDocument() error = %v", err)
+		}
+		err = c.saveContent("https://example.com/article", page)
 		if err != nil {
 			t.Fatalf("saveContent failed: %v", err)
 		}
@@ -1203,7 +1767,11 @@ func TestSaveContentWithExtraction(t *testing.T) {
 			t.Fatalf("failed to create output dir: %v", err)
 		}
 
-		err = c.saveContent("https://example.com/article", []byte(html))
+		page, err := NewPageDocument("https://example.com/article", []byte(html))
+		if err != nil {
+			t.Fatalf("NewPageDocument() error = %v", err)
+		}
+		err = c.saveContent("https://example.com/article", page)
 		if err != nil {
 			t.Fatalf("saveContent failed: %v", err)
 		}
@@ -1231,3 +1799,149 @@ func TestSaveContentWithExtraction(t *testing.T) {
 		}
 	})
 }
+
+func TestPageFiltersCrawl(t *testing.T) {
+	words := func(n int) string { return strings.Repeat("word ", n) }
+	pages := map[string]string{
+		// A tag page: mostly links, not saved but its links are followed
+		"/":        `<ul><li><a href="/article">Article</a></li><li><a href="/stub">Stub</a></li><li><a href="/long">Long</a></li><li><a href="/t/x">X</a></li></ul><p>Tags</p>`,
+		"/article": "<p>" + words(60) + "</p>",
+		"/stub":    "<p>" + words(5) + "</p>",
+		"/long":    "<p>" + words(400) + "</p>",
+		"/t/x":     "<p>" + words(20) + "</p>",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body>%s</body></html>", content)
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		MaxContentLength: 1500,
+		MinWords:         10,
+		MaxWords:         300,
+		MaxLinkDensity:   0.5,
+	}
+	c, err := NewCrawler(config, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	records, err := LoadURLInventory(outputDir)
+	if err != nil {
+		t.Fatalf("LoadURLInventory() error = %v", err)
+	}
+	got := make(map[string]string)
+	for _, r := range records {
+		got[strings.TrimPrefix(r.URL, server.URL)] = r.Status + " " + r.Reason
+	}
+	want := map[string]string{
+		"/":        URLStatusSkipped + " link density 0.80 above 0.50",
+		"/article": URLStatusSaved + " ",
+		"/stub":    URLStatusSkipped + " fewer than 10 words",
+		"/long":    URLStatusSkipped + " longer than 1500 characters",
+		"/t/x":     URLStatusSaved + " ",
+	}
+	for path, w := range want {
+		if got[path] != w {
+			t.Errorf("%s: got %q, want %q", path, got[path], w)
+		}
+	}
+	if filtered := c.GetMetrics().GetSnapshot().ContentFiltered; filtered != 3 {
+		t.Errorf("content filtered = %d, want 3", filtered)
+	}
+}
+
+func TestRobotsPrefetch(t *testing.T) {
+	text := strings.Repeat("Page text. ", 10)
+	const hostCount = 6
+	hits := make([]atomic.Int32, hostCount)
+	var robotsRequested atomic.Int32
+	var mu sync.Mutex
+	var requests []string // "robots" and "page" in the order the hosts got them
+	record := func(kind string) {
+		mu.Lock()
+		requests = append(requests, kind)
+		mu.Unlock()
+	}
+	var links strings.Builder
+	for i := range hostCount {
+		host := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				if hits[i].Add(1) == 1 {
+					robotsRequested.Add(1)
+				}
+				record("robots")
+				time.Sleep(50 * time.Millisecond)
+				fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+				return
+			}
+			// Prefetched robots.txt files all arrive while the single
+			// worker waits for its first page; fetched on first use, the
+			// others would only come after it
+			deadline := time.Now().Add(2 * time.Second)
+			for robotsRequested.Load() < hostCount && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			record("page")
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><body><p>%s</p></body></html>`, text)
+		}))
+		defer host.Close()
+		fmt.Fprintf(&links, `<a href="%s/page">Page</a><a href="%s/private">Private</a>`, host.URL, host.URL)
+	}
+	seed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p>%s</body></html>`, text, links.String())
+	}))
+	defer seed.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              seed.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		Concurrent:       true,
+		Workers:          1,
+	}
+	c, err := NewCrawler(config, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if i := slices.Index(requests, "page"); i < hostCount {
+		t.Errorf("requests = %v, want every robots.txt before the first page", requests)
+	}
+	for i := range hits {
+		if n := hits[i].Load(); n != 1 {
+			t.Errorf("robots.txt of host %d fetched %d times, want 1", i, n)
+		}
+	}
+	snapshot := c.GetMetrics().GetSnapshot()
+	if snapshot.RobotsBlocked != hostCount || snapshot.URLsSaved != hostCount+1 {
+		t.Errorf("blocked %d and saved %d pages, want %d and %d", snapshot.RobotsBlocked, snapshot.URLsSaved, hostCount, hostCount+1)
+	}
+}
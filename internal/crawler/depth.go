@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Depth modes, selecting what MaxDepth counts
+const (
+	DepthModeLinks = "links" // Link hops from the start URL
+	DepthModePath  = "path"  // URL path segments beyond the start URL or prefix filter
+)
+
+// ValidDepthMode reports whether mode is a known depth mode ("" is links)
+func ValidDepthMode(mode string) bool {
+	switch mode {
+	case "", DepthModeLinks, DepthModePath:
+		return true
+	}
+	return false
+}
+
+// linkDepth returns the depth of a link found on a page at currentDepth
+func (c *Crawler) linkDepth(rawURL string, currentDepth int) int {
+	if c.config.DepthMode != DepthModePath {
+		return currentDepth + 1
+	}
+	prefix := c.config.PrefixFilterURL
+	if prefix == "" || prefix == "none" {
+		prefix = c.config.URL
+	}
+	return PathDepth(rawURL, prefix)
+}
+
+// PathDepth returns the number of path segments of rawURL beyond those it
+// shares with prefix. A URL on another host counts all of its segments.
+func PathDepth(rawURL, prefix string) int {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0
+	}
+	segments := pathSegments(u.Path)
+	p, err := url.Parse(prefix)
+	if err != nil || !strings.EqualFold(p.Host, u.Host) {
+		return len(segments)
+	}
+	shared := 0
+	for _, s := range pathSegments(p.Path) {
+		if shared == len(segments) || segments[shared] != s {
+			break
+		}
+		shared++
+	}
+	return len(segments) - shared
+}
+
+// pathSegments splits a URL path into its non-empty segments
+func pathSegments(path string) []string {
+	var segments []string
+	for _, s := range strings.Split(path, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
+}
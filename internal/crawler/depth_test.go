@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathDepth(t *testing.T) {
+	tests := []struct {
+		url    string
+		prefix string
+		want   int
+	}{
+		{"https://example.com/docs/", "https://example.com/docs/", 0},
+		{"https://example.com/docs/guide", "https://example.com/docs/", 1},
+		{"https://example.com/docs/guide/install/", "https://example.com/docs", 2},
+		{"https://example.com/docs//guide", "https://example.com/docs/", 1},
+		{"https://example.com/blog/post", "https://example.com/docs/", 2},
+		{"https://example.com/", "https://example.com/docs/", 0},
+		{"https://example.com/a/b", "https://example.com/", 2},
+		{"https://other.com/docs/guide", "https://example.com/docs/", 2},
+		{"https://EXAMPLE.com/docs/guide", "https://example.com/docs/", 1},
+	}
+
+	for _, tt := range tests {
+		if got := PathDepth(tt.url, tt.prefix); got != tt.want {
+			t.Errorf("PathDepth(%q, %q) = %d, want %d", tt.url, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestDepthModePathCrawl(t *testing.T) {
+	// /docs/ links straight to every level, so link depth is 1 throughout
+	// and only path depth stops the crawl
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		links := ""
+		if r.URL.Path == "/docs/" {
+			links = `<a href="/docs/a">A</a><a href="/docs/a/b">B</a><a href="/docs/a/b/c">C</a>`
+		}
+		fmt.Fprintf(w, `<html><body><p>%s</p>%s</body></html>`, strings.Repeat("Documentation text. ", 10), links)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, tt := range []struct {
+		mode  string
+		saved []string
+		not   []string
+	}{
+		{DepthModeLinks, []string{"a.html", "a/b.html", "a/b/c.html"}, nil},
+		{DepthModePath, []string{"a.html", "a/b.html"}, []string{"a/b/c.html"}},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			outputDir := t.TempDir()
+			config := Config{
+				URL:              server.URL + "/docs/",
+				MaxDepth:         2,
+				DepthMode:        tt.mode,
+				OutputDir:        outputDir,
+				StateFile:        filepath.Join(outputDir, "state.json"),
+				MinContentLength: 10,
+			}
+			c, err := runSelfTestCrawl(context.Background(), config)
+			if err != nil {
+				t.Fatalf("crawl failed: %v", err)
+			}
+			for _, name := range tt.saved {
+				if _, err := os.Stat(filepath.Join(outputDir, "docs", name)); err != nil {
+					t.Errorf("%s not saved: %v", name, err)
+				}
+			}
+			for _, name := range tt.not {
+				if _, err := os.Stat(filepath.Join(outputDir, "docs", name)); err == nil {
+					t.Errorf("%s saved beyond the path depth", name)
+				}
+			}
+			if hits := c.GetMetrics().GetSnapshot().DepthLimitHits; hits != int64(len(tt.not)) {
+				t.Errorf("depth limit hits = %d, want %d", hits, len(tt.not))
+			}
+		})
+	}
+}
+
+func TestDepthHistogram(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		links := map[string]string{
+			"/":  `<a href="/a">A</a><a href="/b">B</a><a href="/missing">Missing</a>`,
+			"/a": `<a href="/a/1">A1</a><a href="/">Home</a>`,
+			"/b": "",
+		}
+		page, ok := links[r.URL.Path]
+		if !ok && r.URL.Path != "/a/1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p>%s</body></html>`, strings.Repeat("Page text. ", 10), page)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         3,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+	}
+	c, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	want := []DepthStats{
+		{Depth: 0, Discovered: 1, Saved: 1},
+		{Depth: 1, Discovered: 3, Saved: 2, Errored: 1},
+		{Depth: 2, Discovered: 1, Saved: 1},
+	}
+	got := c.GetMetrics().GetSnapshot().Depths
+	if len(got) != len(want) {
+		t.Fatalf("depths = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("depth %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	table := FormatDepthHistogram(got)
+	lines := strings.Split(strings.TrimRight(table, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("histogram has %d lines, want 4:\n%s", len(lines), table)
+	}
+	if !strings.HasSuffix(lines[2], " "+strings.Repeat("#", 30)) || !strings.HasSuffix(lines[1], " "+strings.Repeat("#", 10)) {
+		t.Errorf("bars should scale to the busiest depth:\n%s", table)
+	}
+}
@@ -0,0 +1,412 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"math/bits"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Duplicate content report files written to the output directory
+const (
+	DuplicatesReportHTML = "duplicates_report.html"
+	DuplicatesReportJSON = "duplicates_report.json"
+)
+
+// Near-duplicate detection limits
+const (
+	// DefaultDuplicateThreshold is the largest SimHash Hamming distance at
+	// which two pages count as near-duplicates
+	DefaultDuplicateThreshold = 3
+	// MaxDuplicateThreshold bounds the threshold; beyond it unrelated pages start to match
+	MaxDuplicateThreshold = 10
+	// MinDuplicateWords is the fewest words a page needs to be fingerprinted
+	MinDuplicateWords = 20
+)
+
+// DuplicateOptions configures near-duplicate clustering
+type DuplicateOptions struct {
+	Threshold int // Maximum Hamming distance between SimHashes (0 = DefaultDuplicateThreshold)
+}
+
+// DuplicateCluster is a group of pages with near-identical content
+type DuplicateCluster struct {
+	Size           int      `json:"size"`
+	Representative string   `json:"representative"` // Shortest URL of the cluster
+	Title          string   `json:"title"`          // Title of the representative page
+	URLs           []string `json:"urls"`           // Every member, representative first
+	SameTitle      bool     `json:"same_title"`     // All members share the representative's title
+	VaryingParams  []string `json:"varying_params"` // Query parameters that differ between members
+}
+
+// DuplicatesReport lists the near-duplicate clusters of a crawl
+type DuplicatesReport struct {
+	GeneratedAt    time.Time          `json:"generated_at"`
+	Pages          int                `json:"pages"`         // Pages fingerprinted
+	SkippedPages   int                `json:"skipped_pages"` // Pages with too little text to compare
+	Threshold      int                `json:"threshold"`
+	DuplicatePages int                `json:"duplicate_pages"` // Cluster members other than the representative
+	Clusters       []DuplicateCluster `json:"clusters"`        // Largest first
+	ParamCounts    map[string]int     `json:"param_counts"`    // Duplicate pages per varying query parameter
+	HTMLPath       string             `json:"html_path,omitempty"`
+	JSONPath       string             `json:"json_path,omitempty"`
+}
+
+// SimHash returns a 64-bit SimHash fingerprint of text built from
+// overlapping three-word shingles. Similar texts have fingerprints that
+// differ in few bits.
+func SimHash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(words) < 3 {
+		for _, w := range words {
+			add(w)
+		}
+	} else {
+		for i := 0; i+3 <= len(words); i++ {
+			add(words[i] + " " + words[i+1] + " " + words[i+2])
+		}
+	}
+
+	var hash uint64
+	for i, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// duplicatePage is the fingerprint of one saved page
+type duplicatePage struct {
+	url   string
+	title string
+	hash  uint64
+}
+
+// FindDuplicates clusters the saved pages of a crawl by near-duplicate
+// content. Pages whose SimHashes are within the threshold of each other,
+// directly or through other members, end up in the same cluster.
+func FindDuplicates(outputDir string, opts DuplicateOptions) (*DuplicatesReport, error) {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultDuplicateThreshold
+	}
+	if opts.Threshold < 0 || opts.Threshold > MaxDuplicateThreshold {
+		return nil, fmt.Errorf("threshold must be between 1 and %d", MaxDuplicateThreshold)
+	}
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+
+	report := &DuplicatesReport{
+		GeneratedAt: time.Now(),
+		Threshold:   opts.Threshold,
+		Clusters:    []DuplicateCluster{},
+		ParamCounts: make(map[string]int),
+	}
+	var pages []duplicatePage
+	err := forEachSavedPage(outputDir, func(pageURL string, doc *goquery.Document) {
+		text := pageText(doc)
+		if len(strings.Fields(text)) < MinDuplicateWords {
+			report.SkippedPages++
+			return
+		}
+		pages = append(pages, duplicatePage{
+			url:   pageURL,
+			title: collapseSpace(doc.Find("title").First().Text()),
+			hash:  SimHash(text),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 && report.SkippedPages == 0 {
+		return nil, fmt.Errorf("no saved pages found in %s", outputDir)
+	}
+	report.Pages = len(pages)
+
+	// Stable input order keeps cluster membership deterministic
+	sort.Slice(pages, func(i, j int) bool { return pages[i].url < pages[j].url })
+
+	for _, members := range clusterSimHashes(pages, opts.Threshold) {
+		cluster := newDuplicateCluster(pages, members)
+		report.Clusters = append(report.Clusters, cluster)
+		report.DuplicatePages += cluster.Size - 1
+		for _, p := range cluster.VaryingParams {
+			report.ParamCounts[p] += cluster.Size - 1
+		}
+	}
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		a, b := report.Clusters[i], report.Clusters[j]
+		if a.Size != b.Size {
+			return a.Size > b.Size
+		}
+		return a.Representative < b.Representative
+	})
+	return report, nil
+}
+
+// GenerateDuplicatesReport clusters a crawl's near-duplicate pages and writes
+// duplicates_report.html and duplicates_report.json to its output directory
+func GenerateDuplicatesReport(outputDir string, opts DuplicateOptions) (*DuplicatesReport, error) {
+	report, err := FindDuplicates(outputDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.HTMLPath = filepath.Join(outputDir, DuplicatesReportHTML)
+	report.JSONPath = filepath.Join(outputDir, DuplicatesReportJSON)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal duplicates report: %v", err)
+	}
+	if err := os.WriteFile(report.JSONPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write duplicates report: %v", err)
+	}
+
+	html, err := renderDuplicatesReport(report, filepath.Base(outputDir))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(report.HTMLPath, html, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write duplicates report: %v", err)
+	}
+	return report, nil
+}
+
+// pageText returns the visible text of a page without navigation and
+// other boilerplate shared by every page of a site
+func pageText(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template, nav, header, footer, aside, form").Remove()
+	return collapseSpace(body.Text())
+}
+
+// clusterSimHashes groups page indexes whose hashes are within threshold
+// bits. Hashes are split into threshold+1 bands: by the pigeonhole
+// principle two hashes within the threshold share at least one band
+// exactly, so only pages sharing a band are compared.
+func clusterSimHashes(pages []duplicatePage, threshold int) [][]int {
+	parent := make([]int, len(pages))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	bands := threshold + 1
+	width := 64 / bands
+	for b := 0; b < bands; b++ {
+		shift := uint(b * width)
+		n := width
+		if b == bands-1 {
+			n = 64 - b*width
+		}
+		mask := uint64(1)<<uint(n) - 1
+		buckets := make(map[uint64][]int)
+		for i, p := range pages {
+			key := (p.hash >> shift) & mask
+			buckets[key] = append(buckets[key], i)
+		}
+		for _, bucket := range buckets {
+			for x := 0; x < len(bucket); x++ {
+				for y := x + 1; y < len(bucket); y++ {
+					i, j := bucket[x], bucket[y]
+					if bits.OnesCount64(pages[i].hash^pages[j].hash) <= threshold {
+						parent[find(i)] = find(j)
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range pages {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+	var clusters [][]int
+	for _, members := range groups {
+		if len(members) > 1 {
+			clusters = append(clusters, members)
+		}
+	}
+	return clusters
+}
+
+// newDuplicateCluster describes a group of near-duplicate pages
+func newDuplicateCluster(pages []duplicatePage, members []int) DuplicateCluster {
+	sort.Slice(members, func(i, j int) bool {
+		a, b := pages[members[i]].url, pages[members[j]].url
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	rep := pages[members[0]]
+	cluster := DuplicateCluster{
+		Size:           len(members),
+		Representative: rep.url,
+		Title:          rep.title,
+		SameTitle:      true,
+		URLs:           make([]string, len(members)),
+	}
+
+	values := make(map[string]map[string]bool) // param -> distinct values ("" when absent)
+	for i, m := range members {
+		cluster.URLs[i] = pages[m].url
+		if pages[m].title != rep.title {
+			cluster.SameTitle = false
+		}
+		if u, err := url.Parse(pages[m].url); err == nil {
+			for name, v := range u.Query() {
+				if values[name] == nil {
+					values[name] = make(map[string]bool)
+				}
+				values[name][strings.Join(v, ",")] = true
+			}
+		}
+	}
+	for name := range values {
+		present := 0
+		for _, m := range members {
+			if u, err := url.Parse(pages[m].url); err == nil && u.Query().Has(name) {
+				present++
+			}
+		}
+		if len(values[name]) > 1 || present < len(members) {
+			cluster.VaryingParams = append(cluster.VaryingParams, name)
+		}
+	}
+	sort.Strings(cluster.VaryingParams)
+	if cluster.VaryingParams == nil {
+		cluster.VaryingParams = []string{}
+	}
+	return cluster
+}
+
+// renderDuplicatesReport renders the report as a standalone HTML page
+func renderDuplicatesReport(report *DuplicatesReport, title string) ([]byte, error) {
+	tmpl, err := template.New("duplicates").Parse(duplicatesReportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	type param struct {
+		Name  string
+		Pages int
+	}
+	var params []param
+	for name, n := range report.ParamCounts {
+		params = append(params, param{name, n})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].Pages != params[j].Pages {
+			return params[i].Pages > params[j].Pages
+		}
+		return params[i].Name < params[j].Name
+	})
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Title  string
+		Report *DuplicatesReport
+		Params []param
+	}{title, report, params})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render duplicates report: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const duplicatesReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Duplicate Content Report - {{.Title}}</title>
+    <style>
+        :root { --bg: #ffffff; --bg-alt: #f8f9fa; --text: #212529; --muted: #6c757d; --border: #dee2e6; --accent: #0d6efd; --bad: #dc3545; }
+        @media (prefers-color-scheme: dark) {
+            :root { --bg: #1a1a2e; --bg-alt: #16213e; --text: #f8f9fa; --muted: #9ca3af; --border: #374151; --accent: #60a5fa; --bad: #f87171; }
+        }
+        body { margin: 0 auto; max-width: 70rem; padding: 2rem; background: var(--bg); color: var(--text); font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; line-height: 1.5; }
+        .muted { color: var(--muted); font-size: 0.9rem; }
+        .cluster { border: 1px solid var(--border); border-radius: 6px; margin-bottom: 1rem; padding: 0.75rem 1rem; background: var(--bg-alt); }
+        .cluster h3 { margin: 0 0 0.25rem; font-size: 1rem; word-break: break-all; }
+        .cluster ul { margin: 0.5rem 0 0; padding-left: 1.25rem; font-size: 0.9rem; word-break: break-all; }
+        .tag { display: inline-block; padding: 0 0.4rem; margin-right: 0.25rem; border-radius: 4px; border: 1px solid var(--border); font-family: monospace; font-size: 0.85rem; }
+        .bad { color: var(--bad); }
+        table { border-collapse: collapse; margin-bottom: 2rem; font-size: 0.9rem; }
+        th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid var(--border); }
+        th { background: var(--bg-alt); }
+        a { color: var(--accent); }
+    </style>
+</head>
+<body>
+    <h1>Duplicate Content Report: {{.Title}}</h1>
+    <p class="muted">{{.Report.Pages}} pages compared{{if .Report.SkippedPages}} ({{.Report.SkippedPages}} with too little text skipped){{end}} &middot; {{len .Report.Clusters}} clusters &middot; {{.Report.DuplicatePages}} duplicate pages &middot; threshold {{.Report.Threshold}} bits &middot; generated {{.Report.GeneratedAt.Format "Jan 2, 2006 15:04"}}</p>
+
+    {{- if .Params}}
+    <h2>Query parameters behind duplicates</h2>
+    <p class="muted">Parameters that vary between pages with the same content, typically faceted navigation, sorting or tracking. Consider keeping links with them out of the next crawl.</p>
+    <table>
+        <tr><th>Parameter</th><th>Duplicate pages</th></tr>
+        {{- range .Params}}
+        <tr><td><span class="tag">{{.Name}}</span></td><td>{{.Pages}}</td></tr>
+        {{- end}}
+    </table>
+    {{- end}}
+
+    <h2>Clusters</h2>
+    {{- range .Report.Clusters}}
+    <div class="cluster">
+        <h3><a href="{{.Representative}}">{{.Representative}}</a></h3>
+        <div class="muted">{{.Size}} pages{{if .Title}} &middot; {{.Title}}{{end}}{{if not .SameTitle}} &middot; <span class="bad">titles differ</span>{{end}}
+            {{- if .VaryingParams}} &middot; varying: {{range .VaryingParams}}<span class="tag">{{.}}</span>{{end}}{{end}}</div>
+        <ul>
+            {{- range .URLs}}
+            <li><a href="{{.}}">{{.}}</a></li>
+            {{- end}}
+        </ul>
+    </div>
+    {{- else}}
+    <p>No near-duplicate pages found.</p>
+    {{- end}}
+</body>
+</html>
+`
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSimHash(t *testing.T) {
+	base := strings.Repeat("The quick brown fox jumps over the lazy dog near the river bank. ", 8)
+	tests := []struct {
+		name    string
+		a, b    string
+		maxDist int
+		minDist int
+	}{
+		{"identical", base, base, 0, 0},
+		{"case and punctuation", base, strings.ToUpper(strings.ReplaceAll(base, ".", "!")), 0, 0},
+		{"one word changed", base, base + " Sorted by price.", 6, 0},
+		{"unrelated", base, strings.Repeat("Lorem ipsum dolor sit amet consectetur adipiscing elit sed do. ", 8), 64, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := bits.OnesCount64(SimHash(tt.a) ^ SimHash(tt.b))
+			if d > tt.maxDist || d < tt.minDist {
+				t.Errorf("distance = %d, want between %d and %d", d, tt.minDist, tt.maxDist)
+			}
+		})
+	}
+}
+
+func TestClusterSimHashes(t *testing.T) {
+	pages := []duplicatePage{
+		{url: "a", hash: 0},
+		{url: "b", hash: 0b111},                 // 3 bits from a
+		{url: "c", hash: 0b111 | 0b111<<20},     // 3 bits from b, 6 from a
+		{url: "d", hash: 0xFFFF_FFFF_0000_0000}, // far from everything
+	}
+	clusters := clusterSimHashes(pages, 3)
+	if len(clusters) != 1 || len(clusters[0]) != 3 {
+		t.Fatalf("clusters = %v, want a, b and c chained together", clusters)
+	}
+	if clusters := clusterSimHashes(pages, 2); len(clusters) != 0 {
+		t.Errorf("threshold 2 clusters = %v, want none", clusters)
+	}
+}
+
+func TestGenerateDuplicatesReport(t *testing.T) {
+	dir := t.TempDir()
+	article := strings.Repeat("Our catalogue of hand made ceramic mugs ships worldwide within three days. ", 6)
+	page := func(title, text string) string {
+		return fmt.Sprintf(`<html><head><title>%s</title></head><body><nav>Home Shop About %d</nav><main>%s</main></body></html>`, title, len(text), text)
+	}
+	writeSEOFixture(t, dir, "mugs", "https://shop.example.com/mugs", page("Mugs", article))
+	writeSEOFixture(t, dir, "mugs-price", "https://shop.example.com/mugs?sort=price", page("Mugs", article))
+	writeSEOFixture(t, dir, "mugs-color", "https://shop.example.com/mugs?color=red&sort=name", page("Mugs - red", article))
+	writeSEOFixture(t, dir, "about", "https://shop.example.com/about",
+		page("About", strings.Repeat("We are a small family business founded in a quiet village by the sea in 1987. ", 6)))
+	writeSEOFixture(t, dir, "empty", "https://shop.example.com/empty", page("Empty", "Nothing here"))
+
+	report, err := GenerateDuplicatesReport(dir, DuplicateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateDuplicatesReport() error = %v", err)
+	}
+	if report.Pages != 4 || report.SkippedPages != 1 || report.Threshold != DefaultDuplicateThreshold {
+		t.Errorf("pages = %d, skipped = %d, threshold = %d", report.Pages, report.SkippedPages, report.Threshold)
+	}
+	if len(report.Clusters) != 1 {
+		t.Fatalf("clusters = %+v, want one", report.Clusters)
+	}
+	cluster := report.Clusters[0]
+	if cluster.Size != 3 || cluster.Representative != "https://shop.example.com/mugs" || cluster.SameTitle {
+		t.Errorf("cluster = %+v", cluster)
+	}
+	if strings.Join(cluster.VaryingParams, ",") != "color,sort" {
+		t.Errorf("varying params = %v, want color and sort", cluster.VaryingParams)
+	}
+	if report.DuplicatePages != 2 || report.ParamCounts["sort"] != 2 {
+		t.Errorf("duplicate pages = %d, param counts = %v", report.DuplicatePages, report.ParamCounts)
+	}
+
+	html, err := os.ReadFile(filepath.Join(dir, DuplicatesReportHTML))
+	if err != nil {
+		t.Fatalf("HTML report not written: %v", err)
+	}
+	if !strings.Contains(string(html), "https://shop.example.com/mugs?color=red&amp;sort=name") {
+		t.Error("HTML report missing cluster member")
+	}
+	if _, err := os.Stat(filepath.Join(dir, DuplicatesReportJSON)); err != nil {
+		t.Errorf("JSON report not written: %v", err)
+	}
+
+	if _, err := FindDuplicates(dir, DuplicateOptions{Threshold: MaxDuplicateThreshold + 1}); err == nil {
+		t.Error("FindDuplicates() with an out of range threshold should fail")
+	}
+	if _, err := FindDuplicates(t.TempDir(), DuplicateOptions{}); err == nil {
+		t.Error("FindDuplicates() on an empty directory should fail")
+	}
+}
@@ -0,0 +1,211 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultElasticsearchMappings are the mappings of the index an
+// elasticsearch sink creates when the sink sets none. Documents are the
+// PageSavedData of each saved page.
+var DefaultElasticsearchMappings = json.RawMessage(`{
+  "properties": {
+    "url": {"type": "keyword"},
+    "path": {"type": "keyword"},
+    "content_path": {"type": "keyword"},
+    "hash": {"type": "keyword"},
+    "size": {"type": "long"},
+    "title": {"type": "text"},
+    "timestamp": {"type": "date"},
+    "jobId": {"type": "keyword"},
+    "text": {"type": "text"}
+  }
+}`)
+
+// validateElasticsearchSink checks the settings only an elasticsearch sink
+// has: the index name, events and mappings
+func validateElasticsearchSink(s EventSink) error {
+	if s.Topic != "" {
+		if s.Topic != strings.ToLower(s.Topic) || strings.ContainsAny(s.Topic, ` \t\r\n\/*?"<>|,#:`) || strings.IndexAny(s.Topic[:1], "-_+.") == 0 {
+			return fmt.Errorf("index %q is not a valid index name (lowercase, without spaces or \\/*?\"<>|,#:, not starting with -, _, + or .)", s.Topic)
+		}
+	}
+	for _, t := range s.Events {
+		if t != EventPageSaved {
+			return fmt.Errorf("only takes page_saved events, got: %s", t)
+		}
+	}
+	if len(s.Mappings) > 0 {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(s.Mappings, &m); err != nil {
+			return fmt.Errorf("mappings must be a JSON object: %v", err)
+		}
+	}
+	return nil
+}
+
+// elasticsearchSink indexes saved pages through the bulk API of an
+// Elasticsearch or OpenSearch cluster. The index is created with the
+// sink's mappings on the first delivery when it doesn't exist yet. Pages
+// are indexed under the SHA-256 of their URL, so a re-crawl updates them.
+type elasticsearchSink struct {
+	base     string // Cluster URL, credentials included
+	index    string
+	mappings json.RawMessage
+	client   *http.Client
+	mu       sync.Mutex // Guards ready
+	ready    bool       // The index exists
+}
+
+func newElasticsearchSink(baseURL, index string, mappings json.RawMessage) *elasticsearchSink {
+	if len(mappings) == 0 {
+		mappings = DefaultElasticsearchMappings
+	}
+	return &elasticsearchSink{
+		base:     strings.TrimRight(baseURL, "/"),
+		index:    index,
+		mappings: mappings,
+		client:   &http.Client{Timeout: eventSinkTimeout},
+	}
+}
+
+func (s *elasticsearchSink) deliver(events []CrawlerEvent) error {
+	if err := s.createIndex(); err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	for _, e := range events {
+		page, ok := e.Data.(PageSavedData)
+		if !ok {
+			continue
+		}
+		id := sha256.Sum256([]byte(page.URL))
+		action, _ := json.Marshal(map[string]any{"index": map[string]string{"_index": s.index, "_id": hex.EncodeToString(id[:])}})
+		doc, err := json.Marshal(page)
+		if err != nil {
+			return err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+	if body.Len() == 0 {
+		return nil
+	}
+
+	resp, err := s.do(http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes())
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("invalid bulk response: %v", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	failed := 0
+	var first string
+	for _, item := range result.Items {
+		for _, r := range item {
+			if r.Status > 299 {
+				if failed == 0 {
+					first = r.Error.Type + ": " + r.Error.Reason
+				}
+				failed++
+			}
+		}
+	}
+	return fmt.Errorf("%d of %d pages not indexed: %s", failed, len(result.Items), first)
+}
+
+// createIndex creates the index with the sink's mappings unless it exists
+func (s *elasticsearchSink) createIndex() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	path := "/" + url.PathEscape(s.index)
+	if _, err := s.do(http.MethodHead, path, "", nil); err == nil {
+		s.ready = true
+		return nil
+	} else if !isHTTPStatus(err, http.StatusNotFound) {
+		return err
+	}
+	body, _ := json.Marshal(map[string]json.RawMessage{"mappings": s.mappings})
+	if _, err := s.do(http.MethodPut, path, "application/json", body); err != nil {
+		// Another crawl may have created it since
+		if _, headErr := s.do(http.MethodHead, path, "", nil); headErr != nil {
+			return fmt.Errorf("failed to create index %s: %v", s.index, err)
+		}
+	}
+	s.ready = true
+	return nil
+}
+
+// elasticsearchStatusError is an HTTP error status returned by the cluster
+type elasticsearchStatusError struct {
+	status int
+	body   string
+}
+
+func (e *elasticsearchStatusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("HTTP %d", e.status)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.status, e.body)
+}
+
+func isHTTPStatus(err error, status int) bool {
+	e, ok := err.(*elasticsearchStatusError)
+	return ok && e.status == status
+}
+
+// do sends a request to the cluster and returns the response body, or an
+// *elasticsearchStatusError for a status other than 2xx
+func (s *elasticsearchSink) do(method, path, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, s.base+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(data) > 200 {
+			data = data[:200]
+		}
+		return nil, &elasticsearchStatusError{status: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+	return data, nil
+}
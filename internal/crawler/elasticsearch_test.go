@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeElasticsearch answers index HEAD/PUT and _bulk requests, recording
+// the mappings and documents it receives
+type fakeElasticsearch struct {
+	mu       sync.Mutex
+	indexes  map[string]json.RawMessage
+	docs     map[string]map[string]any // By _id
+	user     string
+	failBulk bool
+}
+
+func (f *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.user, _, _ = r.BasicAuth()
+	switch {
+	case r.URL.Path == "/_bulk":
+		var items []map[string]any
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			var action struct {
+				Index struct {
+					Index string `json:"_index"`
+					ID    string `json:"_id"`
+				} `json:"index"`
+			}
+			json.Unmarshal(scanner.Bytes(), &action)
+			scanner.Scan()
+			var doc map[string]any
+			json.Unmarshal(scanner.Bytes(), &doc)
+			f.docs[action.Index.ID] = doc
+			status := 201
+			if f.failBulk {
+				status = 400
+			}
+			items = append(items, map[string]any{"index": map[string]any{"status": status, "error": map[string]string{"type": "mapper_parsing_exception", "reason": "failed to parse"}}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"errors": f.failBulk, "items": items})
+	case r.Method == http.MethodHead:
+		if _, ok := f.indexes[r.URL.Path[1:]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut:
+		var body struct {
+			Mappings json.RawMessage `json:"mappings"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.indexes[r.URL.Path[1:]] = body.Mappings
+		fmt.Fprint(w, `{"acknowledged": true}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestElasticsearchSink(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Search me</title></head><body><article><h1>Search me</h1><p>%s</p></article></body></html>`, strings.Repeat("Instantly searchable. ", 20))
+	}))
+	defer site.Close()
+
+	es := &fakeElasticsearch{indexes: make(map[string]json.RawMessage), docs: make(map[string]map[string]any)}
+	cluster := httptest.NewServer(es)
+	defer cluster.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		EventSinks: []EventSink{
+			{Type: EventSinkElasticsearch, URL: strings.Replace(cluster.URL, "http://", "http://elastic:secret@", 1), Topic: "pages"},
+		},
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if string(mustCompactJSON(t, es.indexes["pages"])) != string(mustCompactJSON(t, DefaultElasticsearchMappings)) {
+		t.Errorf("index created with mappings %s, want the default ones", es.indexes["pages"])
+	}
+	if es.user != "elastic" {
+		t.Errorf("requests authenticated as %q, want the URL's user", es.user)
+	}
+	if len(es.docs) != 1 {
+		t.Fatalf("indexed %d documents, want 1: %v", len(es.docs), es.docs)
+	}
+	for _, doc := range es.docs {
+		if doc["url"] != site.URL+"/" || doc["title"] != "Search me" || !strings.Contains(doc["text"].(string), "Instantly searchable.") {
+			t.Errorf("document = %v, want the page's metadata and text", doc)
+		}
+	}
+}
+
+func TestElasticsearchSinkErrors(t *testing.T) {
+	es := &fakeElasticsearch{indexes: map[string]json.RawMessage{"pages": nil}, docs: make(map[string]map[string]any), failBulk: true}
+	cluster := httptest.NewServer(es)
+	defer cluster.Close()
+
+	sink := newElasticsearchSink(cluster.URL, "pages", nil)
+	page := PageSavedData{ManifestEntry: ManifestEntry{URL: "https://example.com/"}}
+	err := sink.deliver([]CrawlerEvent{{Type: EventPageSaved, Data: page}})
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 pages not indexed: mapper_parsing_exception") {
+		t.Errorf("deliver() = %v, want the bulk item error", err)
+	}
+	if _, ok := es.indexes["pages"]; !ok || es.indexes["pages"] != nil {
+		t.Error("an existing index should not be created again")
+	}
+}
+
+func TestValidateElasticsearchSink(t *testing.T) {
+	tests := []struct {
+		sink EventSink
+		ok   bool
+	}{
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "pages"}, true},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "pages", Payload: PagePayloadPointer}, true},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "pages", Mappings: json.RawMessage(`{"properties": {"text": {"type": "text", "analyzer": "english"}}}`)}, true},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200"}, false},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "Pages"}, false},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "_pages"}, false},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "pages", Events: []EventType{EventCrawlCompleted}}, false},
+		{EventSink{Type: EventSinkElasticsearch, URL: "http://localhost:9200", Topic: "pages", Mappings: json.RawMessage(`[]`)}, false},
+		{EventSink{Type: EventSinkWebhook, URL: "http://localhost:9200", Mappings: json.RawMessage(`{}`)}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateEventSinks([]EventSink{tt.sink}); (err == nil) != tt.ok {
+			t.Errorf("ValidateEventSinks(%+v) = %v, want ok %v", tt.sink, err, tt.ok)
+		}
+	}
+}
+
+func mustCompactJSON(t *testing.T, data []byte) []byte {
+	var m any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	compact, _ := json.Marshal(m)
+	return compact
+}
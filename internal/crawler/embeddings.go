@@ -0,0 +1,303 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Where the vectors of an embeddings config are stored
+const (
+	EmbeddingsStoreFile      = "file"       // <page>.embedding.json next to the page's other files
+	EmbeddingsStoreResultsDB = "results-db" // The embeddings table of the results database
+)
+
+// EmbeddingsAPIKeyEnv holds the API key of the embeddings endpoint when the
+// config has none
+const EmbeddingsAPIKeyEnv = "SCRAPER_EMBEDDINGS_API_KEY"
+
+// EmbeddingFileExt replaces .html in the name of a saved page for the file
+// holding its embedding
+const EmbeddingFileExt = ".embedding.json"
+
+const (
+	embeddingsBatchSize = 16               // Pages per embeddings request
+	embeddingsMaxInput  = 24000            // Characters of text sent per page, about 6000 tokens
+	embeddingsQueue     = 256              // Pages waiting for a request before saving blocks
+	embeddingsTimeout   = 60 * time.Second // Timeout of an embeddings request
+	embeddingsRetries   = 3                // Attempts of a request failing with 429, 5xx or a network error
+)
+
+// EmbeddingsConfig sends the text of each saved page to an OpenAI-compatible
+// embeddings endpoint and stores the vectors, for semantic search
+type EmbeddingsConfig struct {
+	Endpoint   string `json:"endpoint,omitempty"`   // API base URL, e.g. https://api.openai.com/v1; /embeddings is appended ("" = off)
+	Model      string `json:"model,omitempty"`      // e.g. text-embedding-3-small
+	APIKey     string `json:"apiKey,omitempty"`     // Bearer token or secret://name (default: $SCRAPER_EMBEDDINGS_API_KEY)
+	Dimensions int    `json:"dimensions,omitempty"` // Vector size, for models that can shorten their vectors (0 = the model's)
+	Store      string `json:"store,omitempty"`      // "file" (default) or "results-db"
+}
+
+// Enabled reports whether embeddings are generated
+func (e EmbeddingsConfig) Enabled() bool {
+	return e.Endpoint != ""
+}
+
+// requestURL returns the URL embeddings are requested from
+func (e EmbeddingsConfig) requestURL() string {
+	base := strings.TrimRight(e.Endpoint, "/")
+	if strings.HasSuffix(base, "/embeddings") {
+		return base
+	}
+	return base + "/embeddings"
+}
+
+// validateEmbeddings checks the endpoint, model, dimensions and store. The
+// results-db store needs a results database.
+func validateEmbeddings(e EmbeddingsConfig, resultsDB string) error {
+	if !e.Enabled() {
+		return nil
+	}
+	u, err := url.Parse(e.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("embeddings endpoint must be an http or https URL, got: %s", e.Endpoint)
+	}
+	if strings.TrimSpace(e.Model) == "" {
+		return fmt.Errorf("embeddings need a model")
+	}
+	if e.Dimensions < 0 {
+		return fmt.Errorf("embedding dimensions must not be negative, got: %d", e.Dimensions)
+	}
+	switch e.Store {
+	case "", EmbeddingsStoreFile:
+	case EmbeddingsStoreResultsDB:
+		if resultsDB == "" {
+			return fmt.Errorf("embeddings store %s needs a results database", EmbeddingsStoreResultsDB)
+		}
+	default:
+		return fmt.Errorf("invalid embeddings store %q (want file or results-db)", e.Store)
+	}
+	return nil
+}
+
+// PageEmbedding is the content of a page's .embedding.json file
+type PageEmbedding struct {
+	URL       string    `json:"url"`
+	Model     string    `json:"model"`
+	Hash      string    `json:"hash"` // SHA-256 of the stored HTML the text was taken from
+	Embedding []float32 `json:"embedding"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// embeddingJob is a saved page waiting for its embedding
+type embeddingJob struct {
+	url  string
+	hash string
+	text string
+	file string // .embedding.json path, for the file store
+}
+
+// embedder requests the embeddings of saved pages in batches from its own
+// goroutine, so workers only wait when the queue is full. A failed request
+// is logged once per run of failures and its pages go without embeddings.
+type embedder struct {
+	config EmbeddingsConfig // API key resolved
+	client *http.Client
+	log    *Logger
+	jobs   chan embeddingJob
+	done   chan struct{}
+	store  func(job embeddingJob, vector []float32) error
+	failed int // Pages left without an embedding
+}
+
+// newEmbedder creates an embedder for config, whose secret reference, if
+// any, is already resolved
+func newEmbedder(config EmbeddingsConfig, log *Logger) *embedder {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv(EmbeddingsAPIKeyEnv)
+	}
+	return &embedder{
+		config: config,
+		client: &http.Client{Timeout: embeddingsTimeout},
+		log:    log,
+	}
+}
+
+// start begins taking pages, handing each vector to store
+func (e *embedder) start(store func(job embeddingJob, vector []float32) error) {
+	e.store = store
+	e.failed = 0
+	e.jobs = make(chan embeddingJob, embeddingsQueue)
+	e.done = make(chan struct{})
+	go e.run()
+}
+
+// add queues a saved page. Pages without text are skipped.
+func (e *embedder) add(job embeddingJob) {
+	if strings.TrimSpace(job.text) == "" {
+		return
+	}
+	if len(job.text) > embeddingsMaxInput {
+		job.text = strings.ToValidUTF8(job.text[:embeddingsMaxInput], "")
+	}
+	e.jobs <- job
+}
+
+// close requests the embeddings of the queued pages and stops the embedder
+func (e *embedder) close() {
+	close(e.jobs)
+	<-e.done
+	if e.failed > 0 {
+		e.log.Warn("%d pages were saved without an embedding", e.failed)
+	}
+}
+
+// run sends a request when a batch is full or no more pages are waiting
+func (e *embedder) run() {
+	defer close(e.done)
+	failing := false
+	var batch []embeddingJob
+	flush := func() {
+		vectors, err := e.request(batch, !failing)
+		if err != nil {
+			e.failed += len(batch)
+			if !failing {
+				e.log.Warn("Failed to get embeddings from %s: %v", e.config.requestURL(), err)
+			}
+			failing = true
+		} else {
+			if failing {
+				e.log.Info("Getting embeddings from %s again", e.config.requestURL())
+				failing = false
+			}
+			for i, job := range batch {
+				if err := e.store(job, vectors[i]); err != nil {
+					e.failed++
+					e.log.Warn("Failed to store the embedding of %s: %v", job.url, err)
+				}
+			}
+		}
+		batch = nil
+	}
+	for job := range e.jobs {
+		batch = append(batch, job)
+		if len(batch) >= embeddingsBatchSize || len(e.jobs) == 0 {
+			flush()
+		}
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+}
+
+// request returns the embeddings of the batch's texts, in order. With
+// retry, rate limits, server errors and network errors are retried with
+// growing pauses.
+func (e *embedder) request(batch []embeddingJob, retry bool) ([][]float32, error) {
+	input := make([]string, len(batch))
+	for i, job := range batch {
+		input[i] = job.text
+	}
+	body := map[string]any{"model": e.config.Model, "input": input}
+	if e.config.Dimensions > 0 {
+		body["dimensions"] = e.config.Dimensions
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := 1
+	if retry {
+		attempts = embeddingsRetries
+	}
+	for attempt := 1; ; attempt++ {
+		vectors, retryable, err := e.post(data, len(batch))
+		if err == nil || !retryable || attempt >= attempts {
+			return vectors, err
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+// post sends one embeddings request. retryable reports whether the error
+// may go away by itself.
+func (e *embedder) post(data []byte, n int) (vectors [][]float32, retryable bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, e.config.requestURL(), bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	if e.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 256*1024*1024))
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		msg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			msg += ": " + apiErr.Error.Message
+		}
+		return nil, resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, fmt.Errorf("%s", msg)
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, false, fmt.Errorf("invalid embeddings response: %v", err)
+	}
+	vectors = make([][]float32, n)
+	for _, d := range result.Data {
+		if d.Index >= 0 && d.Index < n {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, false, fmt.Errorf("embeddings response has no vector for input %d", i)
+		}
+	}
+	return vectors, false, nil
+}
+
+// storeEmbedding writes the vector of a page where the config wants it
+func (c *Crawler) storeEmbedding(job embeddingJob, vector []float32) error {
+	if c.config.Embeddings.Store == EmbeddingsStoreResultsDB {
+		c.results.addEmbedding(job.url, c.config.Embeddings.Model, job.hash, vector)
+		return nil
+	}
+	data, err := json.Marshal(PageEmbedding{
+		URL:       job.url,
+		Model:     c.config.Embeddings.Model,
+		Hash:      job.hash,
+		Embedding: vector,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return c.perms.writeFile(job.file, data)
+}
@@ -0,0 +1,196 @@
+package crawler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeEmbeddings answers OpenAI-style embeddings requests with vectors of
+// [len(input), index, 0.5]
+type fakeEmbeddings struct {
+	mu       sync.Mutex
+	requests int
+	inputs   []string
+	auth     string
+	model    string
+	failures int // Requests answered with 503 first
+}
+
+func (f *fakeEmbeddings) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if r.URL.Path != "/v1/embeddings" {
+		http.NotFound(w, r)
+		return
+	}
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"error": {"message": "overloaded"}}`)
+		return
+	}
+	var body struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.auth = r.Header.Get("Authorization")
+	f.model = body.Model
+	f.inputs = append(f.inputs, body.Input...)
+	type item struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	}
+	var data []item
+	// Answered in reverse to check that vectors are matched by index
+	for i := len(body.Input) - 1; i >= 0; i-- {
+		data = append(data, item{Index: i, Embedding: []float32{float32(len(body.Input[i])), float32(i), 0.5}})
+	}
+	json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data})
+}
+
+func embeddingsSite() *httptest.Server {
+	text := strings.Repeat("Semantic content. ", 10)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprintf(w, `<html><head><title>Home</title></head><body><p>%s</p><a href="/a">A</a><a href="/b">B</a></body></html>`, text)
+			return
+		}
+		fmt.Fprintf(w, `<html><head><title>Page</title></head><body><p>%s %s</p></body></html>`, r.URL.Path, text)
+	}))
+}
+
+func TestEmbeddingsFile(t *testing.T) {
+	site := embeddingsSite()
+	defer site.Close()
+	api := &fakeEmbeddings{failures: 1}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		Embeddings:       EmbeddingsConfig{Endpoint: server.URL + "/v1/", Model: "test-model", APIKey: "sk-test"},
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.auth != "Bearer sk-test" || api.model != "test-model" {
+		t.Errorf("request sent with %q and model %q", api.auth, api.model)
+	}
+	if len(api.inputs) != 3 {
+		t.Fatalf("embedded %d texts, want 3", len(api.inputs))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(outputDir, "*"+EmbeddingFileExt))
+	if len(files) != 3 {
+		t.Fatalf("found %d embedding files, want 3: %v", len(files), files)
+	}
+	lengths := make(map[string]float32) // Text length each page's vector encodes
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var e PageEmbedding
+		if err := json.Unmarshal(data, &e); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(e.URL, site.URL) || e.Model != "test-model" || len(e.Hash) != 64 || len(e.Embedding) != 3 || e.Embedding[2] != 0.5 {
+			t.Errorf("%s = %+v", file, e)
+		}
+		lengths[strings.TrimPrefix(e.URL, site.URL)] = e.Embedding[0]
+	}
+	if lengths["/a"] != lengths["/b"] || lengths["/a"] == lengths["/"] {
+		t.Errorf("pages have the vectors of other pages: %v", lengths)
+	}
+}
+
+func TestEmbeddingsResultsDB(t *testing.T) {
+	site := embeddingsSite()
+	defer site.Close()
+	api := &fakeEmbeddings{}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		ResultsDB:        ResultsDBFile,
+		Embeddings:       EmbeddingsConfig{Endpoint: server.URL + "/v1/embeddings", Model: "test-model", Store: EmbeddingsStoreResultsDB},
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(outputDir, ResultsDBFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM embeddings e JOIN pages p ON p.url = e.url AND p.hash = e.hash`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("embeddings has %d rows of saved pages, want 3", n)
+	}
+	var dims int
+	var blob []byte
+	if err := db.QueryRow(`SELECT dimensions, vector FROM embeddings WHERE url = ?`, site.URL+"/").Scan(&dims, &blob); err != nil {
+		t.Fatal(err)
+	}
+	if dims != 3 || len(blob) != 12 || math.Float32frombits(binary.LittleEndian.Uint32(blob[8:])) != 0.5 {
+		t.Errorf("vector = %d dimensions, %v", dims, blob)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(outputDir, "*"+EmbeddingFileExt)); len(matches) > 0 {
+		t.Errorf("the results-db store should not write embedding files: %v", matches)
+	}
+}
+
+func TestValidateEmbeddings(t *testing.T) {
+	tests := []struct {
+		embeddings EmbeddingsConfig
+		resultsDB  string
+		ok         bool
+	}{
+		{EmbeddingsConfig{}, "", true},
+		{EmbeddingsConfig{Endpoint: "https://api.openai.com/v1", Model: "text-embedding-3-small"}, "", true},
+		{EmbeddingsConfig{Endpoint: "http://localhost:11434/v1", Model: "nomic-embed-text", Dimensions: 256, Store: EmbeddingsStoreFile}, "", true},
+		{EmbeddingsConfig{Endpoint: "https://api.openai.com/v1", Model: "m", Store: EmbeddingsStoreResultsDB}, ResultsDBFile, true},
+		{EmbeddingsConfig{Endpoint: "https://api.openai.com/v1", Model: "m", Store: EmbeddingsStoreResultsDB}, "", false},
+		{EmbeddingsConfig{Endpoint: "https://api.openai.com/v1"}, "", false},
+		{EmbeddingsConfig{Endpoint: "ftp://example.com", Model: "m"}, "", false},
+		{EmbeddingsConfig{Endpoint: "https://api.openai.com/v1", Model: "m", Dimensions: -1}, "", false},
+		{EmbeddingsConfig{Endpoint: "https://api.openai.com/v1", Model: "m", Store: "s3"}, "", false},
+	}
+	for _, tt := range tests {
+		if err := validateEmbeddings(tt.embeddings, tt.resultsDB); (err == nil) != tt.ok {
+			t.Errorf("validateEmbeddings(%+v, %q) = %v, want ok %v", tt.embeddings, tt.resultsDB, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// APIEndpointsFile lists the XHR/fetch endpoints called by crawled pages
+const APIEndpointsFile = "api_endpoints.jsonl"
+
+// APIEndpoint is an XHR or fetch endpoint called by crawled pages, keyed by
+// method and URL without query string
+type APIEndpoint struct {
+	Method      string   `json:"method"`
+	URL         string   `json:"url"`                    // Scheme, host and path
+	ContentType string   `json:"content_type,omitempty"` // Response MIME type
+	Status      int      `json:"status,omitempty"`       // Most recent response status (0 if the request failed)
+	QueryParams []string `json:"query_params,omitempty"` // Query parameter names seen across calls
+	Example     string   `json:"example"`                // First full URL seen
+	Calls       int      `json:"calls"`
+	Pages       int      `json:"pages"`      // Distinct pages that called the endpoint
+	FirstPage   string   `json:"first_page"` // Page the endpoint was first seen on
+}
+
+// IsJSON reports whether the endpoint responds with JSON
+func (e APIEndpoint) IsJSON() bool {
+	return strings.Contains(e.ContentType, "json")
+}
+
+// apiEndpointLog deduplicates the XHR/fetch requests seen during a crawl
+type apiEndpointLog struct {
+	mu        sync.Mutex
+	endpoints map[string]*APIEndpoint
+	params    map[string]map[string]bool
+	pages     map[string]map[string]bool
+	order     []string
+}
+
+// newAPIEndpointLog creates an empty endpoint log
+func newAPIEndpointLog() *apiEndpointLog {
+	return &apiEndpointLog{
+		endpoints: make(map[string]*APIEndpoint),
+		params:    make(map[string]map[string]bool),
+		pages:     make(map[string]map[string]bool),
+	}
+}
+
+// Add records the XHR and fetch requests among a page's network entries
+func (l *apiEndpointLog) Add(pageURL string, entries []HAREntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range entries {
+		if e.ResourceType != string(network.ResourceTypeXHR) && e.ResourceType != string(network.ResourceTypeFetch) {
+			continue
+		}
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			continue
+		}
+		query := u.Query()
+		u.RawQuery, u.Fragment = "", ""
+		base := u.String()
+
+		key := e.Request.Method + " " + base
+		ep, ok := l.endpoints[key]
+		if !ok {
+			ep = &APIEndpoint{Method: e.Request.Method, URL: base, Example: e.Request.URL, FirstPage: pageURL}
+			l.endpoints[key] = ep
+			l.params[key] = make(map[string]bool)
+			l.pages[key] = make(map[string]bool)
+			l.order = append(l.order, key)
+		}
+		ep.Calls++
+		ep.Status = e.Response.Status
+		if e.Response.Content.MimeType != "" {
+			ep.ContentType = e.Response.Content.MimeType
+		}
+		if !l.pages[key][pageURL] {
+			l.pages[key][pageURL] = true
+			ep.Pages++
+		}
+		for name := range query {
+			if !l.params[key][name] {
+				l.params[key][name] = true
+				ep.QueryParams = append(ep.QueryParams, name)
+				sort.Strings(ep.QueryParams)
+			}
+		}
+	}
+}
+
+// Load adds the endpoints of a previous run, so a resumed crawl keeps them
+func (l *apiEndpointLog) Load(endpoints []APIEndpoint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, loaded := range endpoints {
+		key := loaded.Method + " " + loaded.URL
+		if _, ok := l.endpoints[key]; ok {
+			continue
+		}
+		ep := loaded
+		l.endpoints[key] = &ep
+		l.params[key] = make(map[string]bool)
+		for _, p := range ep.QueryParams {
+			l.params[key][p] = true
+		}
+		l.pages[key] = make(map[string]bool)
+		l.order = append(l.order, key)
+	}
+}
+
+// Endpoints returns a copy of the log in discovery order
+func (l *apiEndpointLog) Endpoints() []APIEndpoint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]APIEndpoint, 0, len(l.order))
+	for _, key := range l.order {
+		ep := *l.endpoints[key]
+		ep.QueryParams = append([]string(nil), ep.QueryParams...)
+		out = append(out, ep)
+	}
+	return out
+}
+
+// WriteAPIEndpointsJSONL writes one JSON object per endpoint
+func WriteAPIEndpointsJSONL(w io.Writer, endpoints []APIEndpoint) error {
+	enc := json.NewEncoder(w)
+	for _, ep := range endpoints {
+		if err := enc.Encode(ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadAPIEndpoints reads the api_endpoints.jsonl written by a previous crawl
+func LoadAPIEndpoints(outputDir string) ([]APIEndpoint, error) {
+	f, err := os.Open(filepath.Join(outputDir, APIEndpointsFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	endpoints := []APIEndpoint{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var ep APIEndpoint
+		if err := json.Unmarshal(scanner.Bytes(), &ep); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", APIEndpointsFile, err)
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, scanner.Err()
+}
+
+// FilterAPIEndpoints returns the endpoints responding with JSON when jsonOnly is set
+func FilterAPIEndpoints(endpoints []APIEndpoint, jsonOnly bool) []APIEndpoint {
+	if !jsonOnly {
+		return endpoints
+	}
+	out := []APIEndpoint{}
+	for _, ep := range endpoints {
+		if ep.IsJSON() {
+			out = append(out, ep)
+		}
+	}
+	return out
+}
+
+// APIEndpoints returns the XHR/fetch endpoints discovered so far in this crawl
+func (c *Crawler) APIEndpoints() []APIEndpoint {
+	if c.endpoints == nil {
+		return []APIEndpoint{}
+	}
+	return c.endpoints.Endpoints()
+}
+
+// recordAPIEndpoints logs the XHR/fetch requests a page made when API
+// discovery is enabled
+func (c *Crawler) recordAPIEndpoints(pageURL string, result *FetchResult) {
+	if !c.config.DiscoverAPIs || result == nil || result.HAR == nil {
+		return
+	}
+	c.endpoints.Add(pageURL, result.HAR.Entries)
+}
+
+// writeAPIEndpoints saves the discovered endpoints to the output directory
+func (c *Crawler) writeAPIEndpoints() error {
+	if !c.config.DiscoverAPIs {
+		return nil
+	}
+	f, err := c.perms.create(filepath.Join(c.config.OutputDir, APIEndpointsFile))
+	if err != nil {
+		return fmt.Errorf("failed to write API endpoints: %v", err)
+	}
+	if err := WriteAPIEndpointsJSONL(f, c.APIEndpoints()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write API endpoints: %v", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAPIEndpointLog(t *testing.T) {
+	entry := func(resourceType, method, rawURL, mimeType string, status int) HAREntry {
+		return HAREntry{
+			ResourceType: resourceType,
+			Request:      HARRequest{Method: method, URL: rawURL},
+			Response:     HARResponse{Status: status, Content: HARContent{MimeType: mimeType}},
+		}
+	}
+
+	l := newAPIEndpointLog()
+	l.Add("https://example.com/a", []HAREntry{
+		entry("Document", "GET", "https://example.com/a", "text/html", 200),
+		entry("Script", "GET", "https://example.com/app.js", "text/javascript", 200),
+		entry("XHR", "GET", "https://api.example.com/items?page=1", "application/json", 200),
+		entry("Fetch", "POST", "https://api.example.com/items", "application/json", 201),
+	})
+	l.Add("https://example.com/b", []HAREntry{
+		entry("Fetch", "GET", "https://api.example.com/items?page=2&sort=name#top", "application/json", 200),
+		entry("XHR", "GET", "https://example.com/fragment", "text/html", 404),
+	})
+	l.Add("https://example.com/b", []HAREntry{
+		entry("XHR", "GET", "https://api.example.com/items", "", 0),
+	})
+
+	got := l.Endpoints()
+	if len(got) != 3 {
+		t.Fatalf("Endpoints() returned %d endpoints, want 3: %+v", len(got), got)
+	}
+
+	items := got[0]
+	if items.Method != "GET" || items.URL != "https://api.example.com/items" {
+		t.Errorf("first endpoint = %s %s, want GET https://api.example.com/items", items.Method, items.URL)
+	}
+	if items.Calls != 3 || items.Pages != 2 {
+		t.Errorf("calls/pages = %d/%d, want 3/2", items.Calls, items.Pages)
+	}
+	if !reflect.DeepEqual(items.QueryParams, []string{"page", "sort"}) {
+		t.Errorf("QueryParams = %v, want [page sort]", items.QueryParams)
+	}
+	if items.Example != "https://api.example.com/items?page=1" || items.FirstPage != "https://example.com/a" {
+		t.Errorf("Example/FirstPage = %s/%s", items.Example, items.FirstPage)
+	}
+	if items.ContentType != "application/json" || items.Status != 0 {
+		t.Errorf("ContentType/Status = %s/%d, want application/json/0 (failed request keeps the type)", items.ContentType, items.Status)
+	}
+	if got[1].Method != "POST" || got[1].Status != 201 {
+		t.Errorf("second endpoint = %+v, want the POST", got[1])
+	}
+
+	if json := FilterAPIEndpoints(got, true); len(json) != 2 {
+		t.Errorf("FilterAPIEndpoints(jsonOnly) returned %d endpoints, want 2", len(json))
+	}
+	if all := FilterAPIEndpoints(got, false); len(all) != 3 {
+		t.Errorf("FilterAPIEndpoints(all) returned %d endpoints, want 3", len(all))
+	}
+}
+
+func TestAPIEndpointsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	endpoints := []APIEndpoint{
+		{Method: "GET", URL: "https://api.example.com/items", ContentType: "application/json", Status: 200,
+			QueryParams: []string{"page"}, Example: "https://api.example.com/items?page=1", Calls: 4, Pages: 2, FirstPage: "https://example.com/"},
+		{Method: "POST", URL: "https://api.example.com/search", Example: "https://api.example.com/search", Calls: 1, Pages: 1, FirstPage: "https://example.com/"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAPIEndpointsJSONL(&buf, endpoints); err != nil {
+		t.Fatalf("WriteAPIEndpointsJSONL() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, APIEndpointsFile), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadAPIEndpoints(dir)
+	if err != nil {
+		t.Fatalf("LoadAPIEndpoints() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded, endpoints) {
+		t.Errorf("LoadAPIEndpoints() = %+v, want %+v", loaded, endpoints)
+	}
+
+	// A resumed crawl keeps counting on top of the loaded endpoints
+	l := newAPIEndpointLog()
+	l.Load(loaded)
+	l.Add("https://example.com/next", []HAREntry{{
+		ResourceType: "XHR",
+		Request:      HARRequest{Method: "GET", URL: "https://api.example.com/items?q=x"},
+		Response:     HARResponse{Status: 200, Content: HARContent{MimeType: "application/json"}},
+	}})
+	got := l.Endpoints()
+	if len(got) != 2 || got[0].Calls != 5 || got[0].Pages != 3 || !reflect.DeepEqual(got[0].QueryParams, []string{"page", "q"}) {
+		t.Errorf("after resume = %+v", got)
+	}
+
+	if _, err := LoadAPIEndpoints(t.TempDir()); err == nil {
+		t.Error("LoadAPIEndpoints() on a directory without the file should fail")
+	}
+}
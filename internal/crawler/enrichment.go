@@ -0,0 +1,233 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnrichmentAPIKeyEnv holds the API key of the enrichment endpoint when the
+// config has none
+const EnrichmentAPIKeyEnv = "SCRAPER_ENRICHMENT_API_KEY"
+
+const (
+	DefaultEnrichmentConcurrency = 2                // Requests in flight at once
+	DefaultEnrichmentTimeout     = 60 * time.Second // Timeout of an enrichment request
+	enrichmentQueue              = 256              // Pages waiting for a request before saving blocks
+	enrichmentMaxInput           = 100000           // Characters of text and of HTML sent per page
+	enrichmentMaxResponse        = 1024 * 1024      // Bytes of response read
+	enrichmentMaxFailures        = 10               // Consecutive failures after which enrichment is turned off
+)
+
+// EnrichmentConfig posts the extracted content of each saved page to an
+// HTTP endpoint, typically in front of an LLM, and adds the JSON object it
+// answers with (summary, tags, classification, ...) to the page's metadata
+type EnrichmentConfig struct {
+	Endpoint    string        `json:"endpoint,omitempty"`    // URL pages are POSTed to ("" = off)
+	APIKey      string        `json:"apiKey,omitempty"`      // Bearer token or secret://name (default: $SCRAPER_ENRICHMENT_API_KEY)
+	Concurrency int           `json:"concurrency,omitempty"` // Requests in flight at once (default 2)
+	Timeout     time.Duration `json:"timeout,omitempty"`     // Per request (default 60s)
+}
+
+// Enabled reports whether saved pages are enriched
+func (e EnrichmentConfig) Enabled() bool {
+	return e.Endpoint != ""
+}
+
+func validateEnrichment(e EnrichmentConfig) error {
+	if !e.Enabled() {
+		return nil
+	}
+	u, err := url.Parse(e.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("enrichment endpoint must be an http or https URL, got: %s", e.Endpoint)
+	}
+	if e.Concurrency < 0 {
+		return fmt.Errorf("enrichment concurrency must not be negative, got: %d", e.Concurrency)
+	}
+	if e.Timeout < 0 {
+		return fmt.Errorf("enrichment timeout must not be negative, got: %s", e.Timeout)
+	}
+	return nil
+}
+
+// EnrichmentRequest is the body POSTed to the enrichment endpoint for each
+// saved page
+type EnrichmentRequest struct {
+	URL      string         `json:"url"`
+	JobID    string         `json:"jobId,omitempty"`
+	Metadata map[string]any `json:"metadata"`       // The page's .meta.json so far: title, author, date, language, ...
+	Text     string         `json:"text"`           // Plain text of the extracted content, or of the page without any
+	HTML     string         `json:"html,omitempty"` // Extracted content HTML, when content was extracted
+}
+
+// enrichmentJob is a saved page waiting for enrichment
+type enrichmentJob struct {
+	request  EnrichmentRequest
+	metaFile string
+}
+
+// enricher sends saved pages to the enrichment endpoint from a pool of
+// goroutines and rewrites their .meta.json with the answer. A page whose
+// request fails keeps its metadata; after enrichmentMaxFailures failures in
+// a row the endpoint is taken to be down and the remaining pages are not
+// sent.
+type enricher struct {
+	config EnrichmentConfig // API key resolved, defaults applied
+	client *http.Client
+	perms  outputPerms
+	log    *Logger
+	jobs   chan enrichmentJob
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex // Guards the counters below
+	enriched  int
+	failed    int
+	failures  int // Consecutive
+	disabled  bool
+	lastError error
+}
+
+// newEnricher creates an enricher for config, whose secret reference, if
+// any, is already resolved
+func newEnricher(config EnrichmentConfig, perms outputPerms, log *Logger) *enricher {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv(EnrichmentAPIKeyEnv)
+	}
+	if config.Concurrency == 0 {
+		config.Concurrency = DefaultEnrichmentConcurrency
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultEnrichmentTimeout
+	}
+	return &enricher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		perms:  perms,
+		log:    log,
+	}
+}
+
+// start begins taking pages
+func (e *enricher) start() {
+	e.enriched, e.failed, e.failures, e.disabled, e.lastError = 0, 0, 0, false, nil
+	e.jobs = make(chan enrichmentJob, enrichmentQueue)
+	for i := 0; i < e.config.Concurrency; i++ {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			for job := range e.jobs {
+				e.enrich(job)
+			}
+		}()
+	}
+}
+
+// add queues a saved page, unless enrichment was turned off. The metadata
+// must not be changed afterwards.
+func (e *enricher) add(request EnrichmentRequest, metaFile string) {
+	e.mu.Lock()
+	disabled := e.disabled
+	e.mu.Unlock()
+	if disabled {
+		return
+	}
+	if len(request.Text) > enrichmentMaxInput {
+		request.Text = strings.ToValidUTF8(request.Text[:enrichmentMaxInput], "")
+	}
+	if len(request.HTML) > enrichmentMaxInput {
+		request.HTML = strings.ToValidUTF8(request.HTML[:enrichmentMaxInput], "")
+	}
+	e.jobs <- enrichmentJob{request: request, metaFile: metaFile}
+}
+
+// close waits for the queued pages and stops the enricher
+func (e *enricher) close() {
+	close(e.jobs)
+	e.wg.Wait()
+	if e.failed > 0 {
+		e.log.Warn("Enriched %d pages; %d could not be enriched, the last error was: %v", e.enriched, e.failed, e.lastError)
+	} else if e.enriched > 0 {
+		e.log.Info("Enriched %d pages", e.enriched)
+	}
+}
+
+// enrich sends a page and merges the answer into its metadata
+func (e *enricher) enrich(job enrichmentJob) {
+	e.mu.Lock()
+	disabled := e.disabled
+	e.mu.Unlock()
+	if disabled {
+		return
+	}
+
+	fields, err := e.post(job.request)
+	if err == nil {
+		metadata := job.request.Metadata
+		metadata["enrichment"] = fields
+		data, _ := json.MarshalIndent(metadata, "", "  ")
+		err = e.perms.writeFile(job.metaFile, data)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err == nil {
+		e.enriched++
+		e.failures = 0
+		return
+	}
+	e.failed++
+	e.failures++
+	e.lastError = err
+	if e.failures == 1 {
+		e.log.Warn("Failed to enrich %s: %v", job.request.URL, err)
+	}
+	if e.failures >= enrichmentMaxFailures && !e.disabled {
+		e.disabled = true
+		e.log.Warn("Enrichment turned off after %d failures in a row; the remaining pages are saved without it", e.failures)
+	}
+}
+
+// post sends a page and returns the JSON object the endpoint answered with
+func (e *enricher) post(request EnrichmentRequest) (map[string]any, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	if e.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, enrichmentMaxResponse+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if len(data) > enrichmentMaxResponse {
+		return nil, fmt.Errorf("response larger than %d bytes", enrichmentMaxResponse)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("response is not a JSON object")
+	}
+	return fields, nil
+}
@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEnrichment(t *testing.T) {
+	site := embeddingsSite()
+	defer site.Close()
+
+	var mu sync.Mutex
+	var requests []EnrichmentRequest
+	var auth string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EnrichmentRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		auth = r.Header.Get("Authorization")
+		mu.Unlock()
+		if strings.HasSuffix(req.URL, "/b") {
+			fmt.Fprint(w, `["not", "an", "object"]`)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"summary": "About " + req.Metadata["title"].(string), "tags": []string{"docs"}})
+	}))
+	defer endpoint.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		JobID:            "job-1",
+		Enrichment:       EnrichmentConfig{Endpoint: endpoint.URL + "/enrich", APIKey: "token"},
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 3 || auth != "Bearer token" {
+		t.Fatalf("got %d requests with %q, want 3 with the API key", len(requests), auth)
+	}
+	for _, req := range requests {
+		if req.JobID != "job-1" || !strings.Contains(req.Text, "Semantic content.") || req.HTML == "" {
+			t.Errorf("request = %+v, want the job ID, text and extracted HTML", req)
+		}
+	}
+
+	readMeta := func(name string) map[string]any {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var meta map[string]any
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Fatal(err)
+		}
+		return meta
+	}
+	meta := readMeta("a.meta.json")
+	enrichment, ok := meta["enrichment"].(map[string]any)
+	if !ok || enrichment["summary"] != "About Page" || meta["url"] != site.URL+"/a" {
+		t.Errorf("a.meta.json = %v, want the page's metadata with the enrichment", meta)
+	}
+	if meta := readMeta("b.meta.json"); meta["enrichment"] != nil || meta["url"] != site.URL+"/b" {
+		t.Errorf("b.meta.json = %v, want it unchanged after a response that isn't an object", meta)
+	}
+}
+
+func TestEnrichmentTurnsOff(t *testing.T) {
+	var calls atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer endpoint.Close()
+
+	dir := t.TempDir()
+	e := newEnricher(EnrichmentConfig{Endpoint: endpoint.URL, Concurrency: 1}, outputPerms{}, &Logger{})
+	e.start()
+	for i := 0; i < enrichmentMaxFailures+5; i++ {
+		e.add(EnrichmentRequest{URL: fmt.Sprintf("https://example.com/%d", i), Metadata: map[string]any{}}, filepath.Join(dir, "page.meta.json"))
+	}
+	e.close()
+	if n := calls.Load(); n != enrichmentMaxFailures {
+		t.Errorf("endpoint called %d times, want enrichment turned off after %d failures", n, enrichmentMaxFailures)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "page.meta.json")); !os.IsNotExist(err) {
+		t.Error("failed enrichments should not write metadata")
+	}
+}
+
+func TestValidateEnrichment(t *testing.T) {
+	tests := []struct {
+		enrichment EnrichmentConfig
+		ok         bool
+	}{
+		{EnrichmentConfig{}, true},
+		{EnrichmentConfig{Endpoint: "http://localhost:8000/enrich", Concurrency: 4}, true},
+		{EnrichmentConfig{Endpoint: "localhost:8000"}, false},
+		{EnrichmentConfig{Endpoint: "http://localhost:8000", Concurrency: -1}, false},
+		{EnrichmentConfig{Endpoint: "http://localhost:8000", Timeout: -1}, false},
+	}
+	for _, tt := range tests {
+		if err := validateEnrichment(tt.enrichment); (err == nil) != tt.ok {
+			t.Errorf("validateEnrichment(%+v) = %v, want ok %v", tt.enrichment, err, tt.ok)
+		}
+	}
+}
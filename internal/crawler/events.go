@@ -17,6 +17,9 @@ const (
 	EventCrawlCompleted  EventType = "crawl_completed"
 	EventError           EventType = "error"
 	EventWaitingForLogin EventType = "waiting_for_login"
+	EventConfigChanged   EventType = "config_changed"
+	EventPageSaved       EventType = "page_saved" // Only sent to event sinks that list it
+	EventContentAlert    EventType = "content_alert"
 )
 
 // CrawlerEvent represents an event emitted by the crawler
@@ -28,15 +31,40 @@ type CrawlerEvent struct {
 
 // ProgressData contains real-time progress information
 type ProgressData struct {
-	ElapsedTime     string  `json:"elapsedTime"`
-	Percentage      float64 `json:"percentage"`
-	URLsProcessed   int64   `json:"urlsProcessed"`
-	URLsSaved       int64   `json:"urlsSaved"`
-	URLsErrored     int64   `json:"urlsErrored"`
-	QueueSize       int     `json:"queueSize"`
-	PagesPerSecond  float64 `json:"pagesPerSecond"`
-	BytesDownloaded int64   `json:"bytesDownloaded"`
-	CurrentURL      string  `json:"currentUrl"`
+	ElapsedTime     string        `json:"elapsedTime"`
+	Percentage      float64       `json:"percentage"`
+	URLsProcessed   int64         `json:"urlsProcessed"`
+	URLsSaved       int64         `json:"urlsSaved"`
+	URLsErrored     int64         `json:"urlsErrored"`
+	QueueSize       int           `json:"queueSize"`
+	PagesPerSecond  float64       `json:"pagesPerSecond"`
+	BytesDownloaded int64         `json:"bytesDownloaded"`
+	HeapAlloc       int64         `json:"heapAlloc"`
+	FollowOnly      int64         `json:"followOnly"`
+	Redirected      int64         `json:"redirected"`
+	RedirectErrors  int64         `json:"redirectErrors"`
+	TimedOut        int64         `json:"timedOut"`
+	BrowserRecycles int64         `json:"browserRecycles"`
+	BrowserCrashes  int64         `json:"browserCrashes"`
+	CurrentURL      string        `json:"currentUrl"`
+	Depths          []DepthStats  `json:"depths,omitempty"`       // Discovered, saved and errored URLs per depth
+	Content         ContentStats  `json:"content"`                // Status codes, content types, charsets, languages and largest pages
+	Active          []ActiveFetch `json:"active,omitempty"`       // URLs being processed by workers, oldest first
+	RecentErrors    []RecentError `json:"recentErrors,omitempty"` // Latest failed URLs, oldest first
+}
+
+// CompletedData tells why a crawl ended
+type CompletedData struct {
+	Reason string `json:"reason"` // One of the Stop* reasons
+}
+
+// PageSavedData describes a saved page to event sinks, for pipelines that
+// process pages as they are saved. Text is only set for sinks whose payload
+// is text.
+type PageSavedData struct {
+	ManifestEntry
+	JobID string `json:"jobId,omitempty"`
+	Text  string `json:"text,omitempty"` // Plain text of the extracted content, or of the page without it
 }
 
 // LogData contains log message information
@@ -77,7 +105,18 @@ func EmitProgress(emitter EventEmitter, metrics *CrawlerMetrics, currentURL stri
 			QueueSize:       snapshot.QueueSize,
 			PagesPerSecond:  snapshot.PagesPerSecond,
 			BytesDownloaded: snapshot.BytesDownloaded,
+			HeapAlloc:       snapshot.HeapAlloc,
+			FollowOnly:      snapshot.FollowOnly,
+			Redirected:      snapshot.Redirected,
+			RedirectErrors:  snapshot.RedirectErrors,
+			TimedOut:        snapshot.TimedOut,
+			BrowserRecycles: snapshot.BrowserRecycles,
+			BrowserCrashes:  snapshot.BrowserCrashes,
 			CurrentURL:      currentURL,
+			Depths:          snapshot.Depths,
+			Content:         snapshot.Content,
+			Active:          snapshot.Active,
+			RecentErrors:    snapshot.RecentErrors,
 		},
 	})
 }
@@ -110,6 +149,19 @@ func EmitStateChange(emitter EventEmitter, eventType EventType) {
 	})
 }
 
+// EmitCompleted sends the crawl completed event with the reason the crawl ended
+func EmitCompleted(emitter EventEmitter, reason string) {
+	if emitter == nil {
+		return
+	}
+
+	emitter.Emit(CrawlerEvent{
+		Type:      EventCrawlCompleted,
+		Timestamp: time.Now(),
+		Data:      CompletedData{Reason: reason},
+	})
+}
+
 // EmitError sends an error event
 func EmitError(emitter EventEmitter, message string) {
 	if emitter == nil {
@@ -140,3 +192,16 @@ func EmitWaitingForLogin(emitter EventEmitter, url string) {
 		},
 	})
 }
+
+// EmitConfigChanged sends a config changed event with the new runtime settings
+func EmitConfigChanged(emitter EventEmitter, config RuntimeConfig) {
+	if emitter == nil {
+		return
+	}
+
+	emitter.Emit(CrawlerEvent{
+		Type:      EventConfigChanged,
+		Timestamp: time.Now(),
+		Data:      config,
+	})
+}
@@ -0,0 +1,576 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event sink types
+const (
+	EventSinkFile    = "file"    // Appends events as JSON Lines to a file
+	EventSinkWebhook = "webhook" // POSTs batches of events as a JSON array
+	EventSinkNATS    = "nats"    // Publishes each event to a NATS subject
+	EventSinkKafka   = "kafka"   // Produces events to a Kafka topic through a Kafka REST Proxy
+
+	// Indexes each saved page in an Elasticsearch or OpenSearch index
+	EventSinkElasticsearch = "elasticsearch"
+)
+
+// Payloads of the page_saved events a sink receives
+const (
+	PagePayloadPointer = "pointer" // The page's URL, title, hash and stored paths (default)
+	PagePayloadText    = "text"    // The same plus the page's extracted text
+)
+
+// EventsFile is the default file of a file event sink, in the output directory
+const EventsFile = "events.ndjson"
+
+const (
+	EventSinkBatchSize     = 100              // Most events handed to a sink at once
+	EventSinkFlushInterval = time.Second      // Longest time an event waits for its batch
+	eventSinkBuffer        = 1000             // Events queued per sink before new ones are dropped
+	eventSinkTimeout       = 10 * time.Second // Timeout of a webhook, Kafka or NATS delivery
+)
+
+// EventSink sends the events of a crawl somewhere besides the GUI or the
+// API's event stream, for external monitoring
+type EventSink struct {
+	Type     string          `json:"type"`               // file, webhook, nats, kafka or elasticsearch
+	Path     string          `json:"path,omitempty"`     // file: JSON Lines file, relative to the output directory (default events.ndjson)
+	URL      string          `json:"url,omitempty"`      // webhook: endpoint; nats: server (nats://[user:pass@]host[:4222]); kafka: REST Proxy base URL; elasticsearch: cluster URL
+	Topic    string          `json:"topic,omitempty"`    // nats: subject; kafka: topic; elasticsearch: index
+	Events   []EventType     `json:"events,omitempty"`   // Event types sent (empty = all but page_saved, which must be listed; elasticsearch: page_saved only)
+	Payload  string          `json:"payload,omitempty"`  // page_saved events: pointer (default) or text (default for elasticsearch)
+	Mappings json.RawMessage `json:"mappings,omitempty"` // elasticsearch: mappings of an index the sink creates (default: DefaultElasticsearchMappings)
+}
+
+// String describes the sink for log messages
+func (s EventSink) String() string {
+	switch s.Type {
+	case EventSinkFile:
+		return "file " + s.Path
+	case EventSinkNATS, EventSinkKafka, EventSinkElasticsearch:
+		return s.Type + " " + redactURL(s.URL) + " " + s.Topic
+	}
+	return s.Type + " " + redactURL(s.URL)
+}
+
+// redactURL removes the password from rawURL
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// ValidateEventSinks checks the type and destination of each sink
+func ValidateEventSinks(sinks []EventSink) error {
+	for i, s := range sinks {
+		switch s.Payload {
+		case "", PagePayloadPointer, PagePayloadText:
+		default:
+			return fmt.Errorf("event sink %d has an unknown payload %q (want pointer or text)", i+1, s.Payload)
+		}
+		if s.Type == EventSinkElasticsearch {
+			if err := validateElasticsearchSink(s); err != nil {
+				return fmt.Errorf("event sink %d (elasticsearch) %v", i+1, err)
+			}
+		} else if len(s.Mappings) > 0 {
+			return fmt.Errorf("event sink %d (%s) mappings only apply to elasticsearch sinks", i+1, s.Type)
+		} else if s.Payload != "" && !slices.Contains(s.Events, EventPageSaved) {
+			return fmt.Errorf("event sink %d sets a payload but does not list the page_saved event", i+1)
+		}
+		var schemes []string
+		switch s.Type {
+		case EventSinkFile:
+			continue
+		case EventSinkWebhook, EventSinkKafka, EventSinkElasticsearch:
+			schemes = []string{"http", "https"}
+		case EventSinkNATS:
+			schemes = []string{"nats"}
+		default:
+			return fmt.Errorf("event sink %d has an unknown type %q (want file, webhook, nats, kafka or elasticsearch)", i+1, s.Type)
+		}
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("event sink %d (%s) needs a url", i+1, s.Type)
+		}
+		if !slices.Contains(schemes, u.Scheme) {
+			return fmt.Errorf("event sink %d (%s) url must use %s, got: %s", i+1, s.Type, strings.Join(schemes, " or "), u.Scheme)
+		}
+		if s.Type != EventSinkWebhook && strings.TrimSpace(s.Topic) == "" {
+			return fmt.Errorf("event sink %d (%s) needs a topic", i+1, s.Type)
+		}
+		if s.Type == EventSinkNATS && strings.ContainsAny(s.Topic, " \t\r\n") {
+			return fmt.Errorf("event sink %d (nats) subject must not contain whitespace", i+1)
+		}
+	}
+	return nil
+}
+
+// ParseEventSinks reads event sinks given inline as a JSON array or as the
+// path of a JSON file holding one
+func ParseEventSinks(value string) ([]EventSink, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	var sinks []EventSink
+	if err := json.Unmarshal(data, &sinks); err != nil {
+		return nil, fmt.Errorf("event sinks must be a JSON array of {\"type\", \"path\", \"url\", \"topic\", \"events\", \"payload\", \"mappings\"} objects: %v", err)
+	}
+	if err := ValidateEventSinks(sinks); err != nil {
+		return nil, err
+	}
+	return sinks, nil
+}
+
+// eventSinks passes every event to the emitter the crawler was created with
+// and to the sinks of Config.EventSinks while the crawl runs
+type eventSinks struct {
+	next     EventEmitter
+	mu       sync.RWMutex
+	batchers []*eventBatcher
+}
+
+// Emit implements EventEmitter
+func (s *eventSinks) Emit(event CrawlerEvent) {
+	if s.next != nil {
+		s.next.Emit(event)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, b := range s.batchers {
+		b.Emit(event)
+	}
+}
+
+// publishPage sends a page_saved event to the sinks that list it. text is
+// only called, once, when a sink's payload is text.
+func (s *eventSinks) publishPage(data PageSavedData, text func() string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var withText *PageSavedData
+	for _, b := range s.batchers {
+		if !b.types[EventPageSaved] {
+			continue
+		}
+		event := CrawlerEvent{Type: EventPageSaved, Timestamp: time.Now(), Data: data}
+		if b.sink.Payload == PagePayloadText {
+			if withText == nil {
+				d := data
+				d.Text = text()
+				withText = &d
+			}
+			event.Data = *withText
+		}
+		b.Emit(event)
+	}
+}
+
+// open starts a batcher for each sink. A file sink is appended to when a
+// crawl is resumed and created afresh otherwise.
+func (s *eventSinks) open(config Config, resume bool, perms outputPerms, log *Logger) error {
+	var batchers []*eventBatcher
+	for _, sink := range config.EventSinks {
+		var deliver func([]CrawlerEvent) error
+		var closeSink func() error
+		switch sink.Type {
+		case EventSinkFile:
+			path := sink.Path
+			if path == "" {
+				path = EventsFile
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(config.OutputDir, path)
+			}
+			sink.Path = path
+			f, err := openEventsFile(path, resume, perms)
+			if err != nil {
+				for _, b := range batchers {
+					b.close()
+				}
+				return err
+			}
+			deliver, closeSink = f.deliver, f.close
+		case EventSinkWebhook:
+			deliver = newWebhookSink(sink.URL).deliver
+		case EventSinkKafka:
+			deliver = newKafkaSink(sink.URL, sink.Topic).deliver
+		case EventSinkNATS:
+			n := &natsSink{url: sink.URL, subject: sink.Topic}
+			deliver, closeSink = n.deliver, n.close
+		case EventSinkElasticsearch:
+			// Only indexes pages, with their text unless asked not to
+			sink.Events = []EventType{EventPageSaved}
+			if sink.Payload == "" {
+				sink.Payload = PagePayloadText
+			}
+			deliver = newElasticsearchSink(sink.URL, sink.Topic, sink.Mappings).deliver
+		}
+		log.Info("Sending events to %s", sink)
+		batchers = append(batchers, newEventBatcher(sink, deliver, closeSink, log))
+	}
+
+	s.mu.Lock()
+	s.batchers = batchers
+	s.mu.Unlock()
+	return nil
+}
+
+// close delivers the queued events and closes the sinks. Events emitted
+// afterwards only reach the crawler's own emitter.
+func (s *eventSinks) close() {
+	s.mu.Lock()
+	batchers := s.batchers
+	s.batchers = nil
+	s.mu.Unlock()
+	for _, b := range batchers {
+		b.close()
+	}
+}
+
+// eventBatcher queues the events for one sink and delivers them in batches
+// from its own goroutine, so a slow or unreachable endpoint never holds up
+// the crawl. Events that don't fit in the queue are dropped.
+type eventBatcher struct {
+	sink      EventSink
+	types     map[EventType]bool // Event types sent, nil for all
+	deliver   func([]CrawlerEvent) error
+	closeSink func() error
+	log       *Logger
+	events    chan CrawlerEvent
+	done      chan struct{}
+	dropped   atomic.Int64 // Events the queue had no room for
+	failed    int64        // Events whose delivery failed
+	failing   bool         // Last delivery failed
+}
+
+func newEventBatcher(sink EventSink, deliver func([]CrawlerEvent) error, closeSink func() error, log *Logger) *eventBatcher {
+	b := &eventBatcher{
+		sink:      sink,
+		deliver:   deliver,
+		closeSink: closeSink,
+		log:       log,
+		events:    make(chan CrawlerEvent, eventSinkBuffer),
+		done:      make(chan struct{}),
+	}
+	if len(sink.Events) > 0 {
+		b.types = make(map[EventType]bool)
+		for _, t := range sink.Events {
+			b.types[t] = true
+		}
+	}
+	go b.run()
+	return b
+}
+
+// Emit queues event when the sink takes its type
+func (b *eventBatcher) Emit(event CrawlerEvent) {
+	if b.types != nil && !b.types[event.Type] {
+		return
+	}
+	select {
+	case b.events <- event:
+	default:
+		b.dropped.Add(1)
+	}
+}
+
+// run delivers a batch when it is full or EventSinkFlushInterval has passed
+func (b *eventBatcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(EventSinkFlushInterval)
+	defer ticker.Stop()
+
+	var batch []CrawlerEvent
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.deliver(batch); err != nil {
+			b.failed += int64(len(batch))
+			if !b.failing {
+				b.log.Warn("Failed to send events to %s: %v", b.sink, err)
+			}
+			b.failing = true
+		} else if b.failing {
+			b.log.Info("Sending events to %s again", b.sink)
+			b.failing = false
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case event, ok := <-b.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= EventSinkBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// close delivers the queued events, then closes the sink. No events may be
+// emitted to b afterwards.
+func (b *eventBatcher) close() {
+	close(b.events)
+	<-b.done
+	if lost := b.failed + b.dropped.Load(); lost > 0 {
+		b.log.Warn("%d events were not sent to %s", lost, b.sink)
+	}
+	if b.closeSink != nil {
+		if err := b.closeSink(); err != nil {
+			b.log.Warn("Failed to close event sink %s: %v", b.sink, err)
+		}
+	}
+}
+
+// marshalEventLines encodes events as JSON Lines
+func marshalEventLines(events []CrawlerEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// eventsFile appends events to a JSON Lines file
+type eventsFile struct {
+	file *os.File
+}
+
+func openEventsFile(path string, resume bool, perms outputPerms) (*eventsFile, error) {
+	var f *os.File
+	var err error
+	if resume {
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			err = perms.applyFile(path)
+		}
+	} else {
+		f, err = perms.create(path)
+	}
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, fmt.Errorf("failed to open events file: %v", err)
+	}
+	return &eventsFile{file: f}, nil
+}
+
+func (f *eventsFile) deliver(events []CrawlerEvent) error {
+	data, err := marshalEventLines(events)
+	if err != nil {
+		return err
+	}
+	_, err = f.file.Write(data)
+	return err
+}
+
+func (f *eventsFile) close() error {
+	return f.file.Close()
+}
+
+// httpEventSink POSTs each batch to an HTTP endpoint
+type httpEventSink struct {
+	url         string
+	contentType string
+	body        func([]CrawlerEvent) ([]byte, error)
+	client      *http.Client
+}
+
+// newWebhookSink POSTs batches to url as a JSON array of events
+func newWebhookSink(url string) *httpEventSink {
+	return &httpEventSink{
+		url:         url,
+		contentType: "application/json",
+		body: func(events []CrawlerEvent) ([]byte, error) {
+			return json.Marshal(events)
+		},
+		client: &http.Client{Timeout: eventSinkTimeout},
+	}
+}
+
+// newKafkaSink produces batches to topic through the Kafka REST Proxy at
+// proxyURL, one record per event
+func newKafkaSink(proxyURL, topic string) *httpEventSink {
+	type record struct {
+		Value CrawlerEvent `json:"value"`
+	}
+	return &httpEventSink{
+		url:         strings.TrimRight(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		contentType: "application/vnd.kafka.json.v2+json",
+		body: func(events []CrawlerEvent) ([]byte, error) {
+			records := make([]record, len(events))
+			for i, e := range events {
+				records[i].Value = e
+			}
+			return json.Marshal(map[string][]record{"records": records})
+		},
+		client: &http.Client{Timeout: eventSinkTimeout},
+	}
+}
+
+func (s *httpEventSink) deliver(events []CrawlerEvent) error {
+	body, err := s.body(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", s.contentType)
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// natsSink publishes each event to a subject over the NATS client protocol.
+// The connection is made on the first delivery and made again after it
+// fails.
+type natsSink struct {
+	url     string
+	subject string
+	mu      sync.Mutex // Guards conn, reader and writes to conn
+	conn    net.Conn
+	reader  *bufio.Reader // Reads conn, buffering what the server sent past the handshake
+}
+
+func (n *natsSink) deliver(events []CrawlerEvent) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		conn, reader, err := n.dial()
+		if err != nil {
+			return err
+		}
+		n.conn, n.reader = conn, reader
+		go n.answerPings(conn, reader)
+	}
+
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "PUB %s %d\r\n", n.subject, len(data))
+		buf.Write(data)
+		buf.WriteString("\r\n")
+	}
+	n.conn.SetWriteDeadline(time.Now().Add(eventSinkTimeout))
+	if _, err := n.conn.Write(buf.Bytes()); err != nil {
+		n.conn.Close()
+		n.conn, n.reader = nil, nil
+		return err
+	}
+	return nil
+}
+
+// dial connects to the server and completes the handshake: the server's
+// INFO, our CONNECT, and a PING answered by PONG, or -ERR when the server
+// refuses the connection. The returned reader must be kept for the rest of
+// the connection, as it may already hold data sent after the PONG.
+func (n *natsSink) dial() (net.Conn, *bufio.Reader, error) {
+	u, err := url.Parse(n.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := net.DialTimeout("tcp", host, eventSinkTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn.SetDeadline(time.Now().Add(eventSinkTimeout))
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, nil, fmt.Errorf("not a NATS server: %q %v", strings.TrimSpace(line), err)
+	}
+	options := map[string]any{"verbose": false, "pedantic": false, "name": "scraper", "lang": "go"}
+	if u.User != nil {
+		options["user"] = u.User.Username()
+		options["pass"], _ = u.User.Password()
+	}
+	data, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", data); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if line, err = r.ReadString('\n'); err != nil || !strings.HasPrefix(line, "PONG") {
+		conn.Close()
+		return nil, nil, fmt.Errorf("connection refused: %q %v", strings.TrimSpace(line), err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, r, nil
+}
+
+// answerPings replies to the server's keep-alive PINGs until conn closes,
+// reading through r, the reader dial left on the sink
+func (n *natsSink) answerPings(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if strings.HasPrefix(line, "PING") {
+			n.mu.Lock()
+			if n.conn == conn {
+				conn.Write([]byte("PONG\r\n"))
+			}
+			n.mu.Unlock()
+		}
+	}
+}
+
+func (n *natsSink) close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn, n.reader = nil, nil
+	return err
+}
@@ -0,0 +1,330 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventSinks(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p></body></html>`, strings.Repeat("Page text. ", 10))
+	}))
+	defer site.Close()
+
+	var mu sync.Mutex
+	var webhook []CrawlerEvent
+	var kafka []struct {
+		Value CrawlerEvent `json:"value"`
+	}
+	var kafkaPath, kafkaType string
+	endpoints := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/hook":
+			var batch []CrawlerEvent
+			if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+				t.Errorf("webhook body: %v", err)
+			}
+			webhook = append(webhook, batch...)
+		default:
+			kafkaPath, kafkaType = r.URL.Path, r.Header.Get("Content-Type")
+			var body struct {
+				Records []struct {
+					Value CrawlerEvent `json:"value"`
+				} `json:"records"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("kafka body: %v", err)
+			}
+			kafka = append(kafka, body.Records...)
+		}
+	}))
+	defer endpoints.Close()
+
+	nats := newFakeNATS(t)
+	defer nats.listener.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		EventSinks: []EventSink{
+			{Type: EventSinkFile},
+			{Type: EventSinkWebhook, URL: endpoints.URL + "/hook", Events: []EventType{EventCrawlStarted, EventCrawlCompleted}},
+			{Type: EventSinkKafka, URL: endpoints.URL + "/", Topic: "crawl-events"},
+			{Type: EventSinkNATS, URL: "nats://user:secret@" + nats.listener.Addr().String(), Topic: "scraper.events"},
+		},
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, EventsFile))
+	if err != nil {
+		t.Fatalf("events file not written: %v", err)
+	}
+	var types []EventType
+	seen := make(map[EventType]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e CrawlerEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line is not an event: %q", line)
+		}
+		types = append(types, e.Type)
+		seen[e.Type] = true
+	}
+	if !seen[EventCrawlStarted] || !seen[EventCrawlCompleted] || !seen[EventLogMessage] {
+		t.Errorf("events file has %v, want the start, completion and log messages", types)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(webhook) != 2 || webhook[0].Type != EventCrawlStarted || webhook[1].Type != EventCrawlCompleted {
+		t.Errorf("webhook got %+v, want only the started and completed events", webhook)
+	}
+	if kafkaPath != "/topics/crawl-events" || kafkaType != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("kafka request to %s as %s", kafkaPath, kafkaType)
+	}
+	if len(kafka) != len(types) {
+		t.Errorf("kafka got %d records, want %d", len(kafka), len(types))
+	}
+
+	published := nats.wait()
+	if nats.connect["user"] != "user" || nats.connect["pass"] != "secret" {
+		t.Errorf("CONNECT = %v, want the URL's credentials", nats.connect)
+	}
+	if len(published) != len(types) {
+		t.Errorf("nats got %d messages, want %d", len(published), len(types))
+	}
+}
+
+func TestEventSinkPages(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Pipeline</title></head><body><article><h1>Pipeline</h1><p>%s</p></article></body></html>`, strings.Repeat("Indexed downstream. ", 20))
+	}))
+	defer site.Close()
+
+	var mu sync.Mutex
+	received := make(map[string][]CrawlerEvent)
+	endpoints := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []CrawlerEvent
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("webhook body: %v", err)
+		}
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], batch...)
+		mu.Unlock()
+	}))
+	defer endpoints.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		JobID:            "job-1",
+		EventSinks: []EventSink{
+			{Type: EventSinkWebhook, URL: endpoints.URL + "/text", Events: []EventType{EventPageSaved}, Payload: PagePayloadText},
+			{Type: EventSinkWebhook, URL: endpoints.URL + "/pointer", Events: []EventType{EventPageSaved}},
+			{Type: EventSinkWebhook, URL: endpoints.URL + "/all"},
+		},
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	page := func(path string) PageSavedData {
+		events := received[path]
+		if len(events) != 1 || events[0].Type != EventPageSaved {
+			t.Fatalf("%s got %+v, want one page_saved event", path, events)
+		}
+		data, _ := json.Marshal(events[0].Data)
+		var p PageSavedData
+		json.Unmarshal(data, &p)
+		return p
+	}
+	text := page("/text")
+	if text.URL != site.URL+"/" || text.JobID != "job-1" || text.Hash == "" || text.Path != "index.html" {
+		t.Errorf("text payload = %+v, want the manifest entry and job", text)
+	}
+	if !strings.Contains(text.Text, "Indexed downstream.") || strings.Contains(text.Text, "<p>") {
+		t.Errorf("text payload text = %q, want the page's plain text", text.Text)
+	}
+	if pointer := page("/pointer"); pointer.Text != "" || pointer.Hash != text.Hash {
+		t.Errorf("pointer payload = %+v, want the entry without text", pointer)
+	}
+	for _, e := range received["/all"] {
+		if e.Type == EventPageSaved {
+			t.Error("a sink that does not list page_saved received it")
+		}
+	}
+}
+
+func TestValidateEventSinks(t *testing.T) {
+	tests := []struct {
+		sink EventSink
+		ok   bool
+	}{
+		{EventSink{Type: EventSinkFile}, true},
+		{EventSink{Type: EventSinkFile, Path: "/var/log/crawl.ndjson"}, true},
+		{EventSink{Type: EventSinkWebhook, URL: "https://hooks.example.com/crawl"}, true},
+		{EventSink{Type: EventSinkWebhook}, false},
+		{EventSink{Type: EventSinkWebhook, URL: "ftp://example.com"}, false},
+		{EventSink{Type: EventSinkNATS, URL: "nats://localhost:4222", Topic: "crawl"}, true},
+		{EventSink{Type: EventSinkNATS, URL: "nats://localhost:4222"}, false},
+		{EventSink{Type: EventSinkNATS, URL: "nats://localhost:4222", Topic: "a b"}, false},
+		{EventSink{Type: EventSinkNATS, URL: "http://localhost:4222", Topic: "crawl"}, false},
+		{EventSink{Type: EventSinkKafka, URL: "http://localhost:8082", Topic: "crawl"}, true},
+		{EventSink{Type: EventSinkKafka, URL: "http://localhost:8082"}, false},
+		{EventSink{Type: "syslog"}, false},
+		{EventSink{Type: EventSinkFile, Events: []EventType{EventPageSaved}, Payload: PagePayloadText}, true},
+		{EventSink{Type: EventSinkFile, Payload: PagePayloadText}, false},
+		{EventSink{Type: EventSinkFile, Events: []EventType{EventPageSaved}, Payload: "html"}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateEventSinks([]EventSink{tt.sink}); (err == nil) != tt.ok {
+			t.Errorf("ValidateEventSinks(%+v) = %v, want ok %v", tt.sink, err, tt.ok)
+		}
+	}
+}
+
+func TestParseEventSinks(t *testing.T) {
+	sinks, err := ParseEventSinks(`[{"type": "file", "path": "all.ndjson"}, {"type": "webhook", "url": "https://example.com/hook", "events": ["crawl_completed"]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sinks) != 2 || sinks[0].Path != "all.ndjson" || sinks[1].Events[0] != EventCrawlCompleted {
+		t.Errorf("sinks = %+v", sinks)
+	}
+	if _, err := ParseEventSinks(`[{"type": "kafka"}]`); err == nil {
+		t.Error("accepted a kafka sink without url and topic")
+	}
+}
+
+func TestNATSSinkAnswersPingSentWithPong(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	pong := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				pong <- err.Error()
+				return
+			}
+			if strings.HasPrefix(line, "PING") {
+				break
+			}
+		}
+		// The keep-alive PING arrives in the same read as the handshake's
+		// PONG, so it is buffered by the reader dial used
+		fmt.Fprint(conn, "PONG\r\nPING\r\n")
+		line, err := r.ReadString('\n')
+		if err != nil {
+			line = err.Error()
+		}
+		pong <- strings.TrimSpace(line)
+	}()
+
+	sink := &natsSink{url: "nats://" + l.Addr().String(), subject: "crawl"}
+	defer sink.close()
+	if err := sink.deliver(nil); err != nil {
+		t.Fatalf("deliver() error = %v", err)
+	}
+	if got := <-pong; got != "PONG" {
+		t.Errorf("server got %q after its PING, want PONG", got)
+	}
+}
+
+// fakeNATS accepts one client, answers its handshake and records what it
+// publishes until the connection closes
+type fakeNATS struct {
+	listener  net.Listener
+	connect   map[string]any
+	published []string
+	done      chan struct{}
+}
+
+func newFakeNATS(t *testing.T) *fakeNATS {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &fakeNATS{listener: l, done: make(chan struct{})}
+	go func() {
+		defer close(n.done)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch fields := strings.Fields(line); fields[0] {
+			case "CONNECT":
+				json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &n.connect)
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				n.published = append(n.published, string(payload[:size]))
+			}
+		}
+	}()
+	return n
+}
+
+// wait returns the published messages once the client has disconnected
+func (n *fakeNATS) wait() []string {
+	<-n.done
+	return n.published
+}
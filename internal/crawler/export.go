@@ -0,0 +1,654 @@
+package crawler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/google/uuid"
+)
+
+// ExportFormat selects the output format for book exports
+type ExportFormat string
+
+const (
+	// ExportFormatHTML produces a single HTML file with a table of contents
+	ExportFormatHTML ExportFormat = "html"
+	// ExportFormatEPUB produces an EPUB 3 book
+	ExportFormatEPUB ExportFormat = "epub"
+)
+
+// ExportOrder selects how chapters are ordered in a book export
+type ExportOrder string
+
+const (
+	// ExportOrderPath orders chapters by URL path hierarchy
+	ExportOrderPath ExportOrder = "path"
+	// ExportOrderCrawl orders chapters in the order they were crawled,
+	// which follows the site's navigation structure
+	ExportOrderCrawl ExportOrder = "crawl"
+)
+
+// MaxExportImageSize caps the size of a single embedded image
+const MaxExportImageSize = 10 * 1024 * 1024
+
+// ExportOptions configures a book export
+type ExportOptions struct {
+	Format      ExportFormat
+	Order       ExportOrder
+	Title       string // defaults to the output directory name
+	OutputFile  string // defaults to _book.html or _book.epub inside the output directory
+	EmbedImages bool
+
+	// fetchImage is used to download images; overridable for tests
+	fetchImage func(imageURL string) ([]byte, string, error)
+}
+
+// ExportResult describes a completed book export
+type ExportResult struct {
+	Path     string       `json:"path"`
+	Format   ExportFormat `json:"format"`
+	Chapters int          `json:"chapters"`
+	Images   int          `json:"images"`
+	Size     int64        `json:"size"`
+}
+
+// bookChapter is a single page in a book export
+type bookChapter struct {
+	ID        string
+	URL       string
+	Title     string
+	Language  string
+	Timestamp time.Time
+	Body      string // rendered content, links and images rewritten
+}
+
+// bookImage is an image embedded into a book export
+type bookImage struct {
+	ID        string
+	Href      string
+	MediaType string
+	Data      []byte
+}
+
+// ValidateExportOptions checks export options and applies defaults
+func ValidateExportOptions(opts *ExportOptions) error {
+	if opts.Format == "" {
+		opts.Format = ExportFormatHTML
+	}
+	if opts.Order == "" {
+		opts.Order = ExportOrderPath
+	}
+	switch opts.Format {
+	case ExportFormatHTML, ExportFormatEPUB:
+	default:
+		return fmt.Errorf("invalid export format %q: must be 'html' or 'epub'", opts.Format)
+	}
+	switch opts.Order {
+	case ExportOrderPath, ExportOrderCrawl:
+	default:
+		return fmt.Errorf("invalid export order %q: must be 'path' or 'crawl'", opts.Order)
+	}
+	return nil
+}
+
+// ExportBook stitches the extracted content of a crawl into a single book
+func ExportBook(outputDir string, opts ExportOptions) (*ExportResult, error) {
+	if err := ValidateExportOptions(&opts); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+	if opts.Title == "" {
+		opts.Title = filepath.Base(outputDir)
+	}
+	if opts.OutputFile == "" {
+		opts.OutputFile = filepath.Join(outputDir, "_book."+string(opts.Format))
+	}
+	if opts.fetchImage == nil {
+		opts.fetchImage = fetchExportImage
+	}
+
+	chapters, err := loadBookChapters(outputDir, opts.Order)
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no pages with extracted content found in %s", outputDir)
+	}
+
+	rewriter := chapterRewriter{
+		embedImages: opts.EmbedImages,
+		fetchImage:  opts.fetchImage,
+		linkTo: func(_, target *bookChapter) string {
+			if opts.Format == ExportFormatEPUB {
+				return target.ID + ".xhtml"
+			}
+			return "#" + target.ID
+		},
+		imageSrc: func(_ *bookChapter, img *bookImage) string {
+			if opts.Format == ExportFormatEPUB {
+				return img.Href
+			}
+			return "data:" + img.MediaType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+		},
+	}
+	images := rewriter.rewrite(chapters)
+
+	switch opts.Format {
+	case ExportFormatEPUB:
+		err = writeEPUB(opts.OutputFile, opts.Title, chapters, images)
+	default:
+		err = writeBookHTML(opts.OutputFile, opts.Title, chapters)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExportResult{
+		Path:     opts.OutputFile,
+		Format:   opts.Format,
+		Chapters: len(chapters),
+		Images:   len(images),
+	}
+	if info, err := os.Stat(opts.OutputFile); err == nil {
+		result.Size = info.Size()
+	}
+	return result, nil
+}
+
+// loadBookChapters reads every page with extracted content and orders them
+func loadBookChapters(outputDir string, order ExportOrder) ([]*bookChapter, error) {
+	metaFiles, err := scanMetaFiles(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan meta files: %v", err)
+	}
+
+	var chapters []*bookChapter
+	for _, metaPath := range metaFiles {
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+		var meta metaFileData
+		if err := json.Unmarshal(data, &meta); err != nil || meta.ContentFile == "" {
+			continue
+		}
+		content, err := ReadOutputFile(filepath.Join(outputDir, meta.ContentFile))
+		if err != nil || len(bytes.TrimSpace(content)) == 0 {
+			continue
+		}
+
+		title := meta.Title
+		if title == "" {
+			title = meta.URL
+		}
+		chapters = append(chapters, &bookChapter{
+			URL:       meta.URL,
+			Title:     title,
+			Language:  meta.Language,
+			Timestamp: time.Unix(meta.Timestamp, 0),
+			Body:      string(content),
+		})
+	}
+
+	sortBookChapters(chapters, order)
+	for i, ch := range chapters {
+		ch.ID = fmt.Sprintf("chapter-%d", i+1)
+	}
+	return chapters, nil
+}
+
+// sortBookChapters orders chapters by URL path hierarchy or crawl order
+func sortBookChapters(chapters []*bookChapter, order ExportOrder) {
+	if order == ExportOrderCrawl {
+		sort.SliceStable(chapters, func(i, j int) bool {
+			return chapters[i].Timestamp.Before(chapters[j].Timestamp)
+		})
+		return
+	}
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return comparePathSegments(urlPathSegments(chapters[i].URL), urlPathSegments(chapters[j].URL)) < 0
+	})
+}
+
+// urlPathSegments splits a URL into host followed by its non-empty path segments
+func urlPathSegments(rawURL string) []string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return []string{rawURL}
+	}
+	segments := []string{parsed.Host}
+	for _, seg := range strings.Split(parsed.Path, "/") {
+		if seg != "" && seg != "index.html" && seg != "index.htm" {
+			segments = append(segments, seg)
+		}
+	}
+	return segments
+}
+
+// comparePathSegments orders parents before their children, then alphabetically
+func comparePathSegments(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return strings.Compare(a[i], b[i])
+		}
+	}
+	return len(a) - len(b)
+}
+
+// chapterRewriter rewrites links and images inside chapter bodies
+type chapterRewriter struct {
+	embedImages bool
+	fetchImage  func(imageURL string) ([]byte, string, error)
+	// linkTo returns the href used in chapter "from" for a link to chapter "to"
+	linkTo func(from, to *bookChapter) string
+	// imageSrc returns the src used in chapter "from" for an embedded image
+	imageSrc func(from *bookChapter, img *bookImage) string
+}
+
+// rewrite points internal links at chapters and collects embedded images
+func (r chapterRewriter) rewrite(chapters []*bookChapter) []*bookImage {
+	chapterByURL := make(map[string]*bookChapter, len(chapters))
+	for _, ch := range chapters {
+		chapterByURL[strings.TrimSuffix(ch.URL, "/")] = ch
+	}
+
+	var images []*bookImage
+	imageByURL := make(map[string]*bookImage)
+
+	for _, ch := range chapters {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(ch.Body))
+		if err != nil {
+			continue
+		}
+		base, _ := url.Parse(ch.URL)
+
+		doc.Find("script, style, iframe, noscript").Remove()
+
+		doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+			href, _ := s.Attr("href")
+			resolved := resolveBookURL(base, href)
+			if resolved == "" {
+				return
+			}
+			target, ok := chapterByURL[strings.TrimSuffix(stripFragment(resolved), "/")]
+			if !ok {
+				s.SetAttr("href", resolved)
+				return
+			}
+			s.SetAttr("href", r.linkTo(ch, target))
+		})
+
+		doc.Find("img").Each(func(_ int, s *goquery.Selection) {
+			s.RemoveAttr("srcset")
+			src, _ := s.Attr("src")
+			resolved := resolveBookURL(base, src)
+			if resolved == "" {
+				return
+			}
+			s.SetAttr("src", resolved)
+			if !r.embedImages {
+				return
+			}
+
+			img, ok := imageByURL[resolved]
+			if !ok {
+				data, mediaType, err := r.fetchImage(resolved)
+				if err != nil || !strings.HasPrefix(mediaType, "image/") {
+					imageByURL[resolved] = nil
+					return
+				}
+				img = &bookImage{
+					ID:        fmt.Sprintf("image-%d", len(images)+1),
+					MediaType: mediaType,
+					Data:      data,
+				}
+				img.Href = "images/" + img.ID + imageExtension(mediaType)
+				images = append(images, img)
+				imageByURL[resolved] = img
+			}
+			if img == nil {
+				return
+			}
+			s.SetAttr("src", r.imageSrc(ch, img))
+		})
+
+		if body, err := doc.Find("body").Html(); err == nil {
+			ch.Body = body
+		}
+	}
+
+	return images
+}
+
+// resolveBookURL resolves a link or image reference against the page URL
+func resolveBookURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "data:") ||
+		strings.HasPrefix(ref, "javascript:") || strings.HasPrefix(ref, "mailto:") {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base == nil {
+		return parsed.String()
+	}
+	return base.ResolveReference(parsed).String()
+}
+
+// stripFragment removes the #fragment from a URL
+func stripFragment(rawURL string) string {
+	if idx := strings.Index(rawURL, "#"); idx != -1 {
+		return rawURL[:idx]
+	}
+	return rawURL
+}
+
+// imageExtension returns a file extension for an image media type
+func imageExtension(mediaType string) string {
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/svg+xml":
+		return ".svg"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".img"
+}
+
+// fetchExportImage downloads an image for embedding
+func fetchExportImage(imageURL string) ([]byte, string, error) {
+	client := &http.Client{Timeout: HTTPTimeout}
+	req, err := http.NewRequest("GET", imageURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxExportImageSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > MaxExportImageSize {
+		return nil, "", fmt.Errorf("image exceeds %d bytes", MaxExportImageSize)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = http.DetectContentType(data)
+	}
+	if mediaType == "" || !strings.HasPrefix(mediaType, "image/") {
+		if byExt := mime.TypeByExtension(path.Ext(imageURL)); strings.HasPrefix(byExt, "image/") {
+			mediaType = byExt
+		}
+	}
+	return data, mediaType, nil
+}
+
+// bookTemplateData holds the data for the single-file HTML book
+type bookTemplateData struct {
+	Title       string
+	Language    string
+	GeneratedAt time.Time
+	Chapters    []bookTemplateChapter
+}
+
+type bookTemplateChapter struct {
+	ID    string
+	URL   string
+	Title string
+	Body  template.HTML
+}
+
+// writeBookHTML writes the single-file HTML book
+func writeBookHTML(outputPath, title string, chapters []*bookChapter) error {
+	data := bookTemplateData{
+		Title:       title,
+		Language:    bookLanguage(chapters),
+		GeneratedAt: time.Now(),
+	}
+	for _, ch := range chapters {
+		data.Chapters = append(data.Chapters, bookTemplateChapter{
+			ID:    ch.ID,
+			URL:   ch.URL,
+			Title: ch.Title,
+			Body:  template.HTML(ch.Body),
+		})
+	}
+
+	tmpl, err := template.New("book").Parse(bookHTMLTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create book file: %v", err)
+	}
+	defer f.Close()
+
+	return tmpl.Execute(f, data)
+}
+
+// writeEPUB writes an EPUB 3 book
+func writeEPUB(outputPath, title string, chapters []*bookChapter, images []*bookImage) error {
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create book file: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	// The mimetype entry must come first and be stored uncompressed
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	language := bookLanguage(chapters)
+	files := []struct {
+		name string
+		tmpl string
+		data interface{}
+	}{
+		{"META-INF/container.xml", epubContainerTemplate, nil},
+		{"OEBPS/content.opf", epubPackageTemplate, map[string]interface{}{
+			"ID":       "urn:uuid:" + uuid.New().String(),
+			"Title":    title,
+			"Language": language,
+			"Modified": time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+			"Chapters": chapters,
+			"Images":   images,
+		}},
+		{"OEBPS/nav.xhtml", epubNavTemplate, map[string]interface{}{
+			"Title":    title,
+			"Language": language,
+			"Chapters": chapters,
+		}},
+	}
+	for _, ch := range chapters {
+		files = append(files, struct {
+			name string
+			tmpl string
+			data interface{}
+		}{"OEBPS/" + ch.ID + ".xhtml", epubChapterTemplate, map[string]interface{}{
+			"Language": language,
+			"Chapter":  ch,
+			"Body":     template.HTML(ch.Body),
+		}})
+	}
+
+	for _, file := range files {
+		tmpl, err := template.New(file.name).Parse(file.tmpl)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %v", err)
+		}
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, file.data); err != nil {
+			return fmt.Errorf("failed to write %s: %v", file.name, err)
+		}
+	}
+
+	for _, img := range images {
+		w, err := zw.Create("OEBPS/" + img.Href)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(img.Data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// bookLanguage picks the language of the first chapter that declares one
+func bookLanguage(chapters []*bookChapter) string {
+	for _, ch := range chapters {
+		if ch.Language != "" {
+			return ch.Language
+		}
+	}
+	return "en"
+}
+
+const bookHTMLTemplate = `<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{.Title}}</title>
+    <style>
+        body { max-width: 48rem; margin: 0 auto; padding: 2rem 1rem; font-family: Georgia, serif; line-height: 1.6; color: #212529; }
+        h1, h2, h3, h4 { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; line-height: 1.25; }
+        img { max-width: 100%; height: auto; }
+        pre { overflow-x: auto; background: #f8f9fa; padding: 1rem; border-radius: 4px; }
+        nav.toc ol { padding-left: 1.5rem; }
+        section.chapter { border-top: 1px solid #dee2e6; margin-top: 3rem; padding-top: 1rem; }
+        .source { font-size: 0.85rem; color: #6c757d; word-break: break-all; }
+        @media print { section.chapter { page-break-before: always; border-top: none; } }
+    </style>
+</head>
+<body>
+    <h1>{{.Title}}</h1>
+    <p class="source">Generated {{.GeneratedAt.Format "Jan 2, 2006 15:04"}} &middot; {{len .Chapters}} chapters</p>
+    <nav class="toc">
+        <h2>Contents</h2>
+        <ol>
+            {{- range .Chapters}}
+            <li><a href="#{{.ID}}">{{.Title}}</a></li>
+            {{- end}}
+        </ol>
+    </nav>
+    {{- range .Chapters}}
+    <section class="chapter" id="{{.ID}}">
+        <h2>{{.Title}}</h2>
+        <p class="source"><a href="{{.URL}}">{{.URL}}</a></p>
+        {{.Body}}
+    </section>
+    {{- end}}
+</body>
+</html>
+`
+
+const epubContainerTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const epubPackageTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.ID}}</dc:identifier>
+    <dc:title>{{.Title}}</dc:title>
+    <dc:language>{{.Language}}</dc:language>
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    {{- range .Chapters}}
+    <item id="{{.ID}}" href="{{.ID}}.xhtml" media-type="application/xhtml+xml"/>
+    {{- end}}
+    {{- range .Images}}
+    <item id="{{.ID}}" href="{{.Href}}" media-type="{{.MediaType}}"/>
+    {{- end}}
+  </manifest>
+  <spine>
+    {{- range .Chapters}}
+    <itemref idref="{{.ID}}"/>
+    {{- end}}
+  </spine>
+</package>
+`
+
+const epubNavTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{.Language}}" xml:lang="{{.Language}}">
+<head>
+  <title>{{.Title}}</title>
+</head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>{{.Title}}</h1>
+    <ol>
+      {{- range .Chapters}}
+      <li><a href="{{.ID}}.xhtml">{{.Title}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`
+
+const epubChapterTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" lang="{{.Language}}" xml:lang="{{.Language}}">
+<head>
+  <title>{{.Chapter.Title}}</title>
+</head>
+<body>
+  <h1>{{.Chapter.Title}}</h1>
+  {{.Body}}
+</body>
+</html>
+`
@@ -0,0 +1,201 @@
+package crawler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeExportFixture writes a page with extracted content the way saveContent does
+func writeExportFixture(t *testing.T, dir, name, pageURL, title, content string, timestamp int64) {
+	t.Helper()
+	meta := map[string]interface{}{
+		"url":               pageURL,
+		"timestamp":         timestamp,
+		"size":              len(content),
+		"content_file":      name + ".content.html",
+		"content_size":      len(content),
+		"content_extracted": true,
+		"title":             title,
+	}
+	data, _ := json.Marshal(meta)
+	if err := os.WriteFile(filepath.Join(dir, name+".meta.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".content.html"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSortBookChapters(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    ExportOrder
+		expected []string
+	}{
+		{
+			name:     "path order puts parents first",
+			order:    ExportOrderPath,
+			expected: []string{"https://example.com/docs/", "https://example.com/docs/guide", "https://example.com/docs/guide/install", "https://example.com/docs/reference"},
+		},
+		{
+			name:     "crawl order follows timestamps",
+			order:    ExportOrderCrawl,
+			expected: []string{"https://example.com/docs/", "https://example.com/docs/reference", "https://example.com/docs/guide", "https://example.com/docs/guide/install"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chapters := []*bookChapter{
+				{URL: "https://example.com/docs/guide/install", Timestamp: time.Unix(4, 0)},
+				{URL: "https://example.com/docs/reference", Timestamp: time.Unix(2, 0)},
+				{URL: "https://example.com/docs/", Timestamp: time.Unix(1, 0)},
+				{URL: "https://example.com/docs/guide", Timestamp: time.Unix(3, 0)},
+			}
+			sortBookChapters(chapters, tt.order)
+			for i, ch := range chapters {
+				if ch.URL != tt.expected[i] {
+					t.Errorf("position %d = %q, want %q", i, ch.URL, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateExportOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ExportOptions
+		wantErr bool
+	}{
+		{"defaults", ExportOptions{}, false},
+		{"epub crawl order", ExportOptions{Format: ExportFormatEPUB, Order: ExportOrderCrawl}, false},
+		{"invalid format", ExportOptions{Format: "pdf"}, true},
+		{"invalid order", ExportOptions{Order: "random"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExportOptions(&tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExportOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportBookHTML(t *testing.T) {
+	dir := t.TempDir()
+	writeExportFixture(t, dir, "guide", "https://example.com/docs/guide", "Guide",
+		`<div><p>See the <a href="/docs/">overview</a>.</p><img src="logo.png"><script>alert(1)</script></div>`, 200)
+	writeExportFixture(t, dir, "index", "https://example.com/docs/", "Overview",
+		`<div><p>Welcome to the docs.</p></div>`, 100)
+
+	result, err := ExportBook(dir, ExportOptions{
+		EmbedImages: true,
+		fetchImage: func(imageURL string) ([]byte, string, error) {
+			if imageURL != "https://example.com/docs/logo.png" {
+				return nil, "", fmt.Errorf("unexpected image %s", imageURL)
+			}
+			return []byte("PNGDATA"), "image/png", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExportBook() error = %v", err)
+	}
+	if result.Chapters != 2 || result.Images != 1 {
+		t.Errorf("result = %+v, want 2 chapters and 1 image", result)
+	}
+	if result.Path != filepath.Join(dir, "_book.html") {
+		t.Errorf("Path = %q", result.Path)
+	}
+
+	data, err := os.ReadFile(result.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	if strings.Index(html, `id="chapter-1"`) > strings.Index(html, `id="chapter-2"`) {
+		t.Error("chapters are out of order")
+	}
+	if !strings.Contains(html, `<a href="#chapter-1">Overview</a>`) {
+		t.Error("table of contents missing first chapter")
+	}
+	if !strings.Contains(html, `href="#chapter-1">overview</a>`) {
+		t.Error("internal link was not rewritten to chapter anchor")
+	}
+	if !strings.Contains(html, "data:image/png;base64,") {
+		t.Error("image was not embedded")
+	}
+	if strings.Contains(html, "alert(1)") {
+		t.Error("script was not stripped")
+	}
+}
+
+func TestExportBookEPUB(t *testing.T) {
+	dir := t.TempDir()
+	writeExportFixture(t, dir, "a", "https://example.com/a", "Page A", `<p>Alpha<br>text<img src="/img.jpg"></p>`, 100)
+	writeExportFixture(t, dir, "b", "https://example.com/b", "Page B", `<p>Beta &amp; more</p>`, 200)
+
+	result, err := ExportBook(dir, ExportOptions{
+		Format:      ExportFormatEPUB,
+		Title:       "Example Docs",
+		EmbedImages: true,
+		fetchImage: func(string) ([]byte, string, error) {
+			return []byte("JPEGDATA"), "image/jpeg", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ExportBook() error = %v", err)
+	}
+
+	zr, err := zip.OpenReader(result.Path)
+	if err != nil {
+		t.Fatalf("failed to open epub: %v", err)
+	}
+	defer zr.Close()
+
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Error("mimetype must be the first, uncompressed entry")
+	}
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/content.opf", "OEBPS/nav.xhtml", "OEBPS/chapter-1.xhtml", "OEBPS/chapter-2.xhtml", "OEBPS/images/image-1.jpg"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("epub missing %s", name)
+		}
+	}
+	if !strings.Contains(files["OEBPS/content.opf"], "<dc:title>Example Docs</dc:title>") {
+		t.Error("package document missing title")
+	}
+	if !strings.Contains(files["OEBPS/chapter-1.xhtml"], "<br/>") {
+		t.Error("chapter is not serialized as XHTML")
+	}
+	if !strings.Contains(files["OEBPS/chapter-1.xhtml"], `src="images/image-1.jpg"`) {
+		t.Error("image reference was not rewritten")
+	}
+}
+
+func TestExportBookNoContent(t *testing.T) {
+	if _, err := ExportBook(t.TempDir(), ExportOptions{}); err == nil {
+		t.Error("expected error for directory without pages")
+	}
+}
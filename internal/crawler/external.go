@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ExternalLinksFile lists the out-of-scope links found on crawled pages
+const ExternalLinksFile = "external_links.jsonl"
+
+// ExternalLink is a link to a URL outside the crawl's scope, recorded once
+// per target and page it was found on. The target is never fetched.
+type ExternalLink struct {
+	URL      string `json:"url"`
+	Text     string `json:"text,omitempty"`    // Anchor text, whitespace collapsed
+	Heading  string `json:"heading,omitempty"` // Nearest heading before the link
+	Rel      string `json:"rel,omitempty"`     // rel attribute, e.g. "nofollow sponsored"
+	Referrer string `json:"referrer"`          // Page the link was found on
+	Reason   string `json:"reason"`            // Filter that put it out of scope: host or prefix
+}
+
+// externalLinkLog deduplicates the external links seen during a crawl
+type externalLinkLog struct {
+	mu    sync.Mutex
+	seen  map[string]bool
+	links []ExternalLink
+}
+
+// newExternalLinkLog creates an empty external link log
+func newExternalLinkLog() *externalLinkLog {
+	return &externalLinkLog{seen: make(map[string]bool)}
+}
+
+// Add records link unless its target was already seen on the same page
+func (l *externalLinkLog) Add(link ExternalLink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key := link.Referrer + " " + link.URL
+	if l.seen[key] {
+		return
+	}
+	l.seen[key] = true
+	l.links = append(l.links, link)
+}
+
+// Load adds the links of a previous run, so a resumed crawl keeps them
+func (l *externalLinkLog) Load(links []ExternalLink) {
+	for _, link := range links {
+		l.Add(link)
+	}
+}
+
+// Links returns a copy of the log in discovery order
+func (l *externalLinkLog) Links() []ExternalLink {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ExternalLink{}, l.links...)
+}
+
+// WriteExternalLinksJSONL writes one JSON object per external link
+func WriteExternalLinksJSONL(w io.Writer, links []ExternalLink) error {
+	enc := json.NewEncoder(w)
+	for _, link := range links {
+		if err := enc.Encode(link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadExternalLinks reads the external_links.jsonl written by a previous crawl
+func LoadExternalLinks(outputDir string) ([]ExternalLink, error) {
+	f, err := os.Open(filepath.Join(outputDir, ExternalLinksFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	links := []ExternalLink{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var link ExternalLink
+		if err := json.Unmarshal(scanner.Bytes(), &link); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", ExternalLinksFile, err)
+		}
+		links = append(links, link)
+	}
+	return links, scanner.Err()
+}
+
+// FilterExternalLinks returns the links whose target host matches host, an
+// exact name or a *.domain pattern ("" keeps all)
+func FilterExternalLinks(links []ExternalLink, host string) []ExternalLink {
+	if host == "" {
+		return links
+	}
+	out := []ExternalLink{}
+	for _, link := range links {
+		if u, err := url.Parse(link.URL); err == nil && MatchHost(u.Hostname(), []string{host}) {
+			out = append(out, link)
+		}
+	}
+	return out
+}
+
+// ExternalLinks returns the external links found so far in this crawl
+func (c *Crawler) ExternalLinks() []ExternalLink {
+	if c.external == nil {
+		return []ExternalLink{}
+	}
+	return c.external.Links()
+}
+
+// recordExternalLink logs a link left out by the host or prefix filter when
+// external links are recorded
+func (c *Crawler) recordExternalLink(rawURL, referrer, reason string, link LinkContext) {
+	if !c.config.ExternalLinks || (reason != LinkFilterHost && reason != LinkFilterPrefix) {
+		return
+	}
+	c.external.Add(ExternalLink{
+		URL:      rawURL,
+		Text:     link.Text,
+		Heading:  link.Heading,
+		Rel:      link.Rel,
+		Referrer: referrer,
+		Reason:   reason,
+	})
+}
+
+// writeExternalLinks saves the external links to the output directory
+func (c *Crawler) writeExternalLinks() error {
+	if !c.config.ExternalLinks {
+		return nil
+	}
+	f, err := c.perms.create(filepath.Join(c.config.OutputDir, ExternalLinksFile))
+	if err != nil {
+		return fmt.Errorf("failed to write external links: %v", err)
+	}
+	if err := WriteExternalLinksJSONL(f, c.ExternalLinks()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write external links: %v", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExternalLinks(t *testing.T) {
+	text := strings.Repeat("Page text. ", 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/docs/home":
+			fmt.Fprintf(w, `<html><body><p>%s</p>
+				<a href="/docs/a">A</a><a href="/blog/post">Our
+				blog</a><a href="https://partner.example.org/">Partner</a>
+				<a href="https://partner.example.org/">Partner again</a><a href="mailto:team@example.com">Mail</a>
+				</body></html>`, text)
+		case "/docs/a":
+			fmt.Fprintf(w, `<html><body><p>%s</p><a href="https://partner.example.org/">Partner</a></body></html>`, text)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/docs/home",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		PrefixFilterURL:  server.URL + "/docs/",
+		MinContentLength: 10,
+		ExternalLinks:    true,
+	}
+	c, err := NewCrawler(config, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	links, err := LoadExternalLinks(outputDir)
+	if err != nil {
+		t.Fatalf("LoadExternalLinks() error = %v", err)
+	}
+	want := []ExternalLink{
+		{URL: server.URL + "/blog/post", Text: "Our blog", Referrer: server.URL + "/docs/home", Reason: LinkFilterPrefix},
+		{URL: "https://partner.example.org/", Text: "Partner", Referrer: server.URL + "/docs/home", Reason: LinkFilterPrefix},
+		{URL: "https://partner.example.org/", Text: "Partner", Referrer: server.URL + "/docs/a", Reason: LinkFilterPrefix},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("external links = %+v, want %+v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, links[i], want[i])
+		}
+	}
+
+	if got := FilterExternalLinks(links, "*.example.org"); len(got) != 2 {
+		t.Errorf("FilterExternalLinks(*.example.org) returned %d links, want 2", len(got))
+	}
+	if got := FilterExternalLinks(links, "example.org"); len(got) != 0 {
+		t.Errorf("FilterExternalLinks(example.org) returned %d links, want 0", len(got))
+	}
+}
@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInjectedReset is the error of a fetch failed by an injected connection reset
+var ErrInjectedReset = errors.New("connection reset by peer (injected fault)")
+
+// injectedStatuses are the 5xx responses an injected server error picks from
+var injectedStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// FaultConfig injects artificial failures into fetches, to exercise error,
+// timeout and metrics code paths. Rates are probabilities between 0 and 1 and
+// together must not exceed 1. Whether a fetch fails depends only on the seed,
+// the URL and how often that URL was fetched before, so the same seed fails
+// the same fetches in every run, sequential or concurrent.
+type FaultConfig struct {
+	Latency      time.Duration `json:"latency,omitempty"`      // Added to every fetch
+	ErrorRate    float64       `json:"errorRate,omitempty"`    // Fetches answered with a random 5xx without reaching the site
+	TruncateRate float64       `json:"truncateRate,omitempty"` // Fetches whose body is cut in half
+	ResetRate    float64       `json:"resetRate,omitempty"`    // Fetches failed with a connection reset
+	Seed         int64         `json:"seed,omitempty"`         // Selects which fetches fail
+}
+
+// Fault is the kind of failure injected into a fetch
+type Fault string
+
+const (
+	FaultNone     Fault = ""
+	FaultError    Fault = "error"
+	FaultTruncate Fault = "truncate"
+	FaultReset    Fault = "reset"
+)
+
+// Enabled reports whether any fault is injected
+func (f FaultConfig) Enabled() bool {
+	return f.Latency > 0 || f.ErrorRate > 0 || f.TruncateRate > 0 || f.ResetRate > 0
+}
+
+// Validate checks the rates and latency
+func (f FaultConfig) Validate() error {
+	if f.Latency < 0 {
+		return fmt.Errorf("fault latency cannot be negative, got: %v", f.Latency)
+	}
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{"fault-5xx-rate", f.ErrorRate},
+		{"fault-truncate-rate", f.TruncateRate},
+		{"fault-reset-rate", f.ResetRate},
+	}
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 || math.IsNaN(r.rate) {
+			return fmt.Errorf("%s must be between 0 and 1, got: %v", r.name, r.rate)
+		}
+	}
+	if sum := f.ErrorRate + f.TruncateRate + f.ResetRate; sum > 1 {
+		return fmt.Errorf("fault rates add up to %v, must not exceed 1", sum)
+	}
+	return nil
+}
+
+// Pick returns the fault injected into the attempt-th fetch (from 0) of rawURL,
+// and the status of an injected server error
+func (f FaultConfig) Pick(rawURL string, attempt int) (Fault, int) {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\x00%s\x00%d", f.Seed, rawURL, attempt)
+	sum := h.Sum64()
+	r := float64(sum>>11) / (1 << 53)
+
+	switch {
+	case r < f.ResetRate:
+		return FaultReset, 0
+	case r < f.ResetRate+f.ErrorRate:
+		return FaultError, injectedStatuses[sum%uint64(len(injectedStatuses))]
+	case r < f.ResetRate+f.ErrorRate+f.TruncateRate:
+		return FaultTruncate, 0
+	}
+	return FaultNone, 0
+}
+
+// faultAttempts counts the fetches of each URL so repeated fetches draw
+// different faults
+type faultAttempts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// next returns how often rawURL was fetched before and counts this fetch
+func (a *faultAttempts) next(rawURL string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.counts == nil {
+		a.counts = make(map[string]int)
+	}
+	n := a.counts[rawURL]
+	a.counts[rawURL]++
+	return n
+}
+
+// FaultFetcher wraps a Fetcher and injects the faults of a FaultConfig
+type FaultFetcher struct {
+	Fetcher
+	faults   FaultConfig
+	attempts faultAttempts
+}
+
+// NewFaultFetcher wraps fetcher with fault injection
+func NewFaultFetcher(fetcher Fetcher, faults FaultConfig) *FaultFetcher {
+	return &FaultFetcher{Fetcher: fetcher, faults: faults}
+}
+
+// Unwrap returns the wrapped fetcher
+func (f *FaultFetcher) Unwrap() Fetcher {
+	return f.Fetcher
+}
+
+// Fetch fetches url through the wrapped fetcher unless a fault replaces the fetch
+func (f *FaultFetcher) Fetch(url string, userAgent string) (*FetchResult, error) {
+	if f.faults.Latency > 0 {
+		time.Sleep(f.faults.Latency)
+	}
+
+	fault, status := f.faults.Pick(url, f.attempts.next(url))
+	switch fault {
+	case FaultReset:
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, ErrInjectedReset)
+	case FaultError:
+		return &FetchResult{
+			Body:        []byte(http.StatusText(status)),
+			StatusCode:  status,
+			ContentType: "text/plain",
+			FinalURL:    url,
+		}, nil
+	}
+
+	result, err := f.Fetcher.Fetch(url, userAgent)
+	if fault == FaultTruncate && err == nil && result != nil {
+		result.Body = result.Body[:len(result.Body)/2]
+	}
+	return result, err
+}
@@ -0,0 +1,160 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// stubFetcher returns the same page for every URL
+type stubFetcher struct {
+	fetches int
+}
+
+func (f *stubFetcher) Fetch(url string, userAgent string) (*FetchResult, error) {
+	f.fetches++
+	return &FetchResult{Body: []byte("0123456789"), StatusCode: 200, ContentType: "text/html", FinalURL: url}, nil
+}
+
+func (f *stubFetcher) Close() error { return nil }
+
+func TestFaultConfigPick(t *testing.T) {
+	faults := FaultConfig{ErrorRate: 0.2, TruncateRate: 0.1, ResetRate: 0.1, Seed: 42}
+
+	counts := make(map[Fault]int)
+	for i := 0; i < 10000; i++ {
+		url := fmt.Sprintf("https://example.com/%d", i)
+		fault, status := faults.Pick(url, 0)
+		if again, _ := faults.Pick(url, 0); again != fault {
+			t.Fatalf("Pick(%s) not deterministic: %q then %q", url, fault, again)
+		}
+		if fault == FaultError && (status < 500 || status > 599) {
+			t.Fatalf("injected error has status %d", status)
+		}
+		counts[fault]++
+	}
+
+	want := map[Fault]float64{FaultError: 0.2, FaultTruncate: 0.1, FaultReset: 0.1, FaultNone: 0.6}
+	for fault, rate := range want {
+		if got := float64(counts[fault]) / 10000; got < rate-0.02 || got > rate+0.02 {
+			t.Errorf("fault %q picked at rate %.3f, want about %.2f", fault, got, rate)
+		}
+	}
+
+	if fault, _ := (FaultConfig{}).Pick("https://example.com/", 0); fault != FaultNone {
+		t.Errorf("zero config picked %q", fault)
+	}
+}
+
+func TestFaultConfigValidate(t *testing.T) {
+	tests := []struct {
+		faults  FaultConfig
+		wantErr bool
+	}{
+		{FaultConfig{}, false},
+		{FaultConfig{ErrorRate: 0.5, ResetRate: 0.5}, false},
+		{FaultConfig{ErrorRate: 1.5}, true},
+		{FaultConfig{TruncateRate: -0.1}, true},
+		{FaultConfig{ErrorRate: 0.6, ResetRate: 0.6}, true},
+		{FaultConfig{Latency: -time.Second}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.faults.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.faults, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFaultFetcher(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		faults FaultConfig
+		check  func(t *testing.T, result *FetchResult, err error, inner *stubFetcher)
+	}{
+		{"reset", FaultConfig{ResetRate: 1}, func(t *testing.T, result *FetchResult, err error, inner *stubFetcher) {
+			if !errors.Is(err, ErrInjectedReset) || inner.fetches != 0 {
+				t.Errorf("expected injected reset without fetching, got %v after %d fetches", err, inner.fetches)
+			}
+		}},
+		{"error", FaultConfig{ErrorRate: 1}, func(t *testing.T, result *FetchResult, err error, inner *stubFetcher) {
+			if err != nil || result.StatusCode < 500 || inner.fetches != 0 {
+				t.Errorf("expected injected 5xx without fetching, got %v %+v after %d fetches", err, result, inner.fetches)
+			}
+		}},
+		{"truncate", FaultConfig{TruncateRate: 1}, func(t *testing.T, result *FetchResult, err error, inner *stubFetcher) {
+			if err != nil || string(result.Body) != "01234" {
+				t.Errorf("expected half the body, got %v %q", err, result.Body)
+			}
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &stubFetcher{}
+			f := NewFaultFetcher(inner, tt.faults)
+			result, err := f.Fetch("https://example.com/", "test")
+			tt.check(t, result, err, inner)
+		})
+	}
+
+	if _, ok := asBrowserFetcher(NewFaultFetcher(&stubFetcher{}, FaultConfig{})); ok {
+		t.Error("asBrowserFetcher found a browser fetcher behind a stub")
+	}
+}
+
+func TestFaultInjectionCrawl(t *testing.T) {
+	faults := FaultConfig{ErrorRate: 0.2, ResetRate: 0.1, TruncateRate: 0.1, Seed: 7}
+
+	// In a chain the first faulted page ends the crawl: it is either not
+	// fetched or truncated before its link to the next page
+	firstFault := func(site *TestSite, key func(n int) string) (int, Fault) {
+		for n := 0; n < site.Pages(); n++ {
+			if fault, _ := faults.Pick(key(n), 0); fault != FaultNone {
+				return n, fault
+			}
+		}
+		t.Fatal("no page faulted")
+		return 0, FaultNone
+	}
+
+	for _, tt := range []struct {
+		name      string
+		siteFault bool
+	}{
+		{"fetcher", false},
+		{"site", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := TestSiteOptions{Pages: 30, Structure: TestSiteChain}
+			if tt.siteFault {
+				opts.Faults = faults
+			}
+			site := NewTestSite(opts)
+			defer site.Close()
+
+			config := selfTestConfig(site, t.TempDir())
+			config.MaxDepth = site.Pages()
+			key := site.PagePath
+			if !tt.siteFault {
+				config.Faults = faults
+				key = site.PageURL
+			}
+			c, err := runSelfTestCrawl(context.Background(), config)
+			if err != nil {
+				t.Fatalf("crawl failed: %v", err)
+			}
+
+			n, fault := firstFault(site, key)
+			wantSaved, wantErrored := int64(n), int64(1)
+			if fault == FaultTruncate && !tt.siteFault {
+				// The fetcher hands on half the page, which is still saved
+				wantSaved, wantErrored = int64(n+1), 0
+			}
+			m := c.GetMetrics().GetSnapshot()
+			if m.URLsSaved != wantSaved || m.URLsErrored != wantErrored {
+				t.Errorf("first fault %q at page %d: saved %d, errored %d; want %d, %d",
+					fault, n, m.URLsSaved, m.URLsErrored, wantSaved, wantErrored)
+			}
+		})
+	}
+}
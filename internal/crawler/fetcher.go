@@ -5,7 +5,13 @@ type FetchResult struct {
 	Body        []byte
 	StatusCode  int
 	ContentType string
-	FinalURL    string // URL after any redirects
+	FinalURL    string      // URL after any redirects
+	Redirects   []Redirect  // Redirect hops followed to reach FinalURL, in order
+	HAR         *HARCapture // Network activity of the page load (browser mode with HAR capture only)
+	// Page scripts that threw, as "pattern: error" (browser mode only)
+	PageScriptErrors []string
+	// How a cookie banner was dismissed, empty if none was found (browser mode only)
+	CookieBanner string
 }
 
 // Fetcher is the interface for fetching web pages
@@ -15,3 +21,33 @@ type Fetcher interface {
 	// Close releases any resources held by the fetcher
 	Close() error
 }
+
+// asBrowserFetcher returns the BrowserFetcher behind f, looking through
+// fetchers that wrap another
+func asBrowserFetcher(f Fetcher) (*BrowserFetcher, bool) {
+	for {
+		switch v := f.(type) {
+		case *BrowserFetcher:
+			return v, true
+		case interface{ Unwrap() Fetcher }:
+			f = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+// asHTTPFetcher returns the HTTPFetcher behind f, looking through fetchers
+// that wrap another
+func asHTTPFetcher(f Fetcher) (*HTTPFetcher, bool) {
+	for {
+		switch v := f.(type) {
+		case *HTTPFetcher:
+			return v, true
+		case interface{ Unwrap() Fetcher }:
+			f = v.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/markusmobius/go-trafilatura"
+)
+
+// FetchedPage is a single page fetched and extracted by FetchPage
+type FetchedPage struct {
+	URL         string        `json:"url"`
+	FinalURL    string        `json:"final_url,omitempty"` // URL after redirects, when different
+	StatusCode  int           `json:"status_code"`
+	ContentType string        `json:"content_type,omitempty"`
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Author      string        `json:"author,omitempty"`
+	Date        string        `json:"date,omitempty"` // Publication date (RFC 3339) when found
+	Language    string        `json:"language,omitempty"`
+	Extracted   bool          `json:"extracted"` // Main content was found; otherwise Text and Markdown cover the whole page
+	Text        string        `json:"text"`
+	Markdown    string        `json:"markdown"`
+	Words       int           `json:"words"`
+	Links       []FetchedLink `json:"links"` // Distinct http(s) links on the page, in document order
+	Bytes       int64         `json:"bytes"` // Size of the fetched response
+}
+
+// FetchedLink is a link found on a page fetched by FetchPage
+type FetchedLink struct {
+	URL     string `json:"url"` // Absolute, without fragment
+	Text    string `json:"text,omitempty"`
+	Heading string `json:"heading,omitempty"` // Nearest heading before the link
+	Rel     string `json:"rel,omitempty"`
+	Reason  string `json:"reason,omitempty"` // Filter that would keep a crawl with the same settings from following it
+}
+
+// FetchPage fetches the page at config.URL with the fetch settings of config
+// (fetch mode, user agent, headers, cookies, robots.txt), extracts its main
+// content and returns it as text and Markdown. Unlike a crawl it follows no
+// links and writes nothing to the output directory.
+func FetchPage(ctx context.Context, config Config) (*FetchedPage, error) {
+	// A single fetch is not worth recording
+	if config.Cassette == CassetteRecord {
+		config.Cassette = ""
+	}
+	c, err := NewCrawler(config, ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.fetchPage(ctx, config.URL)
+}
+
+// fetchPage fetches and extracts one page, giving up when ctx is done
+func (c *Crawler) fetchPage(ctx context.Context, rawURL string) (*FetchedPage, error) {
+	if !c.isAllowedByRobots(rawURL) {
+		return nil, fmt.Errorf("blocked by robots.txt")
+	}
+	userAgent := c.userAgentFor(rawURL)
+	c.warmUp(rawURL, userAgent)
+	result, err := fetchContext(ctx, c.fetcher, rawURL, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := &FetchedPage{URL: rawURL, StatusCode: result.StatusCode, ContentType: result.ContentType, Bytes: int64(len(result.Body))}
+	pageURL := rawURL
+	if result.FinalURL != "" && result.FinalURL != rawURL {
+		fetched.FinalURL = result.FinalURL
+		pageURL = result.FinalURL
+	}
+	if result.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", result.StatusCode)
+	}
+	if c.exceedsMaxHTMLSize(result.Body) {
+		return nil, fmt.Errorf("page of %s exceeds the maximum HTML size", FormatBytes(int64(len(result.Body))))
+	}
+	page, err := NewPageDocument(pageURL, result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %v", err)
+	}
+	base, _ := url.Parse(pageURL)
+
+	// Unlike the saved .content.html, keep the links so the Markdown can
+	// point onwards
+	fetched.Title = collapseSpace(page.Doc.Find("title").First().Text())
+	doc, err := trafilatura.ExtractDocument(page.Root(), trafilatura.Options{
+		OriginalURL:    base,
+		EnableFallback: true,
+		IncludeLinks:   true,
+	})
+	if err != nil {
+		c.log.Debug("Failed to extract content for %s: %v", pageURL, err)
+	}
+	if doc != nil {
+		meta := doc.Metadata
+		if meta.Title != "" {
+			fetched.Title = meta.Title
+		}
+		fetched.Description = meta.Description
+		fetched.Author = meta.Author
+		fetched.Language = meta.Language
+		if !meta.Date.IsZero() {
+			fetched.Date = meta.Date.Format(time.RFC3339)
+		}
+	}
+
+	if doc != nil && doc.ContentNode != nil {
+		fetched.Extracted = true
+		fetched.Text = strings.TrimSpace(doc.ContentText)
+		fetched.Markdown = HTMLToMarkdown(doc.ContentNode, base)
+	} else {
+		fetched.Text = page.Text()
+		body := page.Doc.Find("body")
+		if body.Length() > 0 {
+			fetched.Markdown = HTMLToMarkdown(body.Get(0), base)
+		}
+	}
+	fetched.Words = len(strings.Fields(fetched.Text))
+	fetched.Links = c.fetchedLinks(page, base)
+	return fetched, nil
+}
+
+// fetchedLinks lists the distinct http(s) links of page with their context
+// and, for links a crawl would not follow, the filter that stops them
+func (c *Crawler) fetchedLinks(page *PageDocument, base *url.URL) []FetchedLink {
+	links := []FetchedLink{}
+	if base == nil {
+		return links
+	}
+	headings := linkHeadings(page.Root())
+	seen := make(map[string]bool)
+	page.Doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		u, err := base.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		rawURL := u.String()
+		if seen[rawURL] {
+			return
+		}
+		seen[rawURL] = true
+
+		link := linkContext(s, headings)
+		links = append(links, FetchedLink{
+			URL:     rawURL,
+			Text:    link.Text,
+			Heading: link.Heading,
+			Rel:     link.Rel,
+			Reason:  c.linkFilterReason(rawURL),
+		})
+	})
+	return links
+}
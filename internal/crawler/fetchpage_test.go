@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetchPage(t *testing.T) {
+	paragraph := strings.Repeat("Crawlers fetch pages and follow their links to discover more of a site. ", 6)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><head><title>How crawlers work</title></head><body>
+				<nav><a href="/">Home</a></nav>
+				<article><h1>How crawlers work</h1><p>%s</p><p>See the <a href="/more">next part</a> for %s</p>
+				<p><a href="/more#intro">Intro</a> <a href="mailto:a@example.com">Mail</a> <a href="https://other.example/" rel="nofollow">Elsewhere</a></p></article>
+				</body></html>`, paragraph, paragraph)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{URL: server.URL + "/article", PrefixFilterURL: server.URL + "/", OutputDir: outputDir, StateFile: filepath.Join(outputDir, "state.json")}
+	page, err := FetchPage(t.Context(), config)
+	if err != nil {
+		t.Fatalf("FetchPage() error = %v", err)
+	}
+	if page.StatusCode != http.StatusOK || page.Title != "How crawlers work" || !page.Extracted {
+		t.Errorf("FetchPage() = %+v", page)
+	}
+	if !strings.Contains(page.Text, "Crawlers fetch pages") || page.Words < 50 {
+		t.Errorf("Text = %q (%d words)", page.Text, page.Words)
+	}
+	if !strings.Contains(page.Markdown, "[next part]("+server.URL+"/more)") {
+		t.Errorf("Markdown should link the next part with an absolute URL:\n%s", page.Markdown)
+	}
+	wantLinks := []FetchedLink{
+		{URL: server.URL + "/", Text: "Home"},
+		{URL: server.URL + "/more", Text: "next part", Heading: "How crawlers work"},
+		{URL: "https://other.example/", Text: "Elsewhere", Heading: "How crawlers work", Rel: "nofollow", Reason: LinkFilterPrefix},
+	}
+	if len(page.Links) != len(wantLinks) {
+		t.Fatalf("Links = %+v, want %+v", page.Links, wantLinks)
+	}
+	for i, want := range wantLinks {
+		if page.Links[i] != want {
+			t.Errorf("Links[%d] = %+v, want %+v", i, page.Links[i], want)
+		}
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("FetchPage() wrote %d files to the output directory", len(entries))
+	}
+
+	config.URL = server.URL + "/missing"
+	if _, err := FetchPage(t.Context(), config); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("FetchPage() of a missing page error = %v, want HTTP 404", err)
+	}
+}
+
+func TestFetchPageContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	outputDir := t.TempDir()
+	config := Config{URL: server.URL + "/slow", IgnoreRobots: true, OutputDir: outputDir, StateFile: filepath.Join(outputDir, "state.json")}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := FetchPage(ctx, config); err == nil {
+		t.Error("FetchPage() of a page that never answers should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("FetchPage() returned %s after its context ended", elapsed)
+	}
+}
@@ -6,44 +6,68 @@ import (
 	"strings"
 )
 
-// isValidURL checks if a URL should be crawled based on scheme, extension, and prefix filter
+// Reasons a discovered link is not queued, as reported by linkFilterReason
+const (
+	LinkFilterInvalid   = "invalid"   // The URL cannot be parsed
+	LinkFilterExtension = "extension" // The path has an excluded extension
+	LinkFilterScheme    = "scheme"    // Not an http or https URL
+	LinkFilterHost      = "host"      // Denied, or not among the allowed hosts
+	LinkFilterPrefix    = "prefix"    // Outside the prefix filter
+	LinkFilterPlugin    = "plugin"    // Left out by the filterURL hook of a plugin
+)
+
+// isValidURL checks if a URL should be crawled based on scheme, extension, host lists and prefix filter
 func (c *Crawler) isValidURL(rawURL string) bool {
+	return c.linkFilterReason(rawURL) == ""
+}
+
+// linkFilterReason returns why a URL is not crawled, or "" when it passes the
+// scheme, extension, host, prefix and plugin filters
+func (c *Crawler) linkFilterReason(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
-		return false
+		return LinkFilterInvalid
 	}
 
 	// Check if URL extension should be excluded
 	if c.shouldExcludeByExtension(parsed.Path) {
-		return false
+		return LinkFilterExtension
 	}
 
 	// Must be HTTP/HTTPS
 	if parsed.Scheme != "http" && parsed.Scheme != "https" {
-		return false
+		return LinkFilterScheme
+	}
+
+	// Host lists apply with or without a prefix filter
+	if !c.hostAllowed(parsed.Hostname()) {
+		return LinkFilterHost
 	}
 
 	// If prefix filtering is disabled (empty or "none"), allow any HTTP/HTTPS URL discovered through the tree
 	if c.config.PrefixFilterURL == "" || c.config.PrefixFilterURL == "none" {
-		return true
+		return c.pluginFilterReason(rawURL)
 	}
 
 	// With prefix filtering enabled: check URL prefix constraint using the specified prefix filter URL
 	prefixURL, err := url.Parse(c.config.PrefixFilterURL)
 	if err != nil {
-		return false
+		return LinkFilterPrefix
 	}
 
 	// Check if URL has the prefix URL as prefix
 	if parsed.Host != prefixURL.Host {
-		return false
+		return LinkFilterPrefix
 	}
 
 	// Check if path starts with prefix path
 	prefixPath := strings.TrimSuffix(prefixURL.Path, "/")
 	urlPath := strings.TrimSuffix(parsed.Path, "/")
 
-	return strings.HasPrefix(urlPath, prefixPath)
+	if !strings.HasPrefix(urlPath, prefixPath) {
+		return LinkFilterPrefix
+	}
+	return c.pluginFilterReason(rawURL)
 }
 
 // shouldExcludeByExtension checks if a URL path has an excluded file extension
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// FollowOnlyRule marks navigation hubs such as pagination index pages and
+// category listings: matching pages are fetched and their links followed,
+// but they are never saved. A rule with both patterns needs both to match.
+type FollowOnlyRule struct {
+	Pattern string `json:"pattern,omitempty"` // Regular expression matched against the page URL
+	Title   string `json:"title,omitempty"`   // Regular expression matched against the page <title>
+}
+
+// compiledFollowOnlyRule is a FollowOnlyRule with its patterns compiled
+type compiledFollowOnlyRule struct {
+	FollowOnlyRule
+	re    *regexp.Regexp
+	title *regexp.Regexp
+}
+
+// compileFollowOnlyRules compiles the patterns of rules, keeping their order
+func compileFollowOnlyRules(rules []FollowOnlyRule) ([]compiledFollowOnlyRule, error) {
+	compiled := make([]compiledFollowOnlyRule, 0, len(rules))
+	for i, r := range rules {
+		if r.Pattern == "" && r.Title == "" {
+			return nil, fmt.Errorf("follow-only rule %d has neither pattern nor title", i+1)
+		}
+		cr := compiledFollowOnlyRule{FollowOnlyRule: r}
+		var err error
+		if r.Pattern != "" {
+			if cr.re, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("follow-only rule %d has an invalid pattern: %v", i+1, err)
+			}
+		}
+		if r.Title != "" {
+			if cr.title, err = regexp.Compile(r.Title); err != nil {
+				return nil, fmt.Errorf("follow-only rule %d has an invalid title: %v", i+1, err)
+			}
+		}
+		compiled = append(compiled, cr)
+	}
+	return compiled, nil
+}
+
+// ParseFollowOnlyRules reads follow-only rules given inline as a JSON array
+// or as the path of a JSON file holding one
+func ParseFollowOnlyRules(value string) ([]FollowOnlyRule, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	var rules []FollowOnlyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("follow-only rules must be a JSON array of {\"pattern\", \"title\"} objects: %v", err)
+	}
+	if _, err := compileFollowOnlyRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// followOnlyReason returns why page is only followed and not saved, or ""
+// when no follow-only rule matches it
+func (c *Crawler) followOnlyReason(page *PageDocument) string {
+	if len(c.followOnly) == 0 {
+		return ""
+	}
+	title := collapseSpace(page.Doc.Find("title").First().Text())
+	for _, r := range c.followOnly {
+		if r.re != nil && !r.re.MatchString(page.URL) {
+			continue
+		}
+		if r.title != nil && !r.title.MatchString(title) {
+			continue
+		}
+		if r.re != nil {
+			return fmt.Sprintf("follow only: URL matches %q", r.Pattern)
+		}
+		return fmt.Sprintf("follow only: title matches %q", r.Title)
+	}
+	return ""
+}
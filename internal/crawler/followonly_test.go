@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFollowOnlyRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "follow.json")
+	os.WriteFile(file, []byte(`[{"pattern": "/page/[0-9]+/?$"}]`), 0644)
+
+	tests := []struct {
+		name     string
+		value    string
+		want     int
+		errorMsg string
+	}{
+		{"inline", `[{"pattern": "/category/"}, {"title": "^Archive"}, {"pattern": "/tag/", "title": "Tag:"}]`, 3, ""},
+		{"file", file, 1, ""},
+		{"malformed JSON", `[{"pattern": ".*"`, 0, "must be a JSON array"},
+		{"invalid pattern", `[{"pattern": "("}]`, 0, "follow-only rule 1 has an invalid pattern"},
+		{"invalid title", `[{"pattern": "/x/"}, {"title": "[a"}]`, 0, "follow-only rule 2 has an invalid title"},
+		{"empty rule", `[{}]`, 0, "follow-only rule 1 has neither pattern nor title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseFollowOnlyRules(tt.value)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("ParseFollowOnlyRules() error = %v, want containing %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFollowOnlyRules() error = %v", err)
+			}
+			if len(rules) != tt.want {
+				t.Errorf("ParseFollowOnlyRules() returned %d rules, want %d", len(rules), tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowOnlyReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []FollowOnlyRule
+		url   string
+		want  string
+	}{
+		{"url", []FollowOnlyRule{{Pattern: `/page/\d+$`}}, "https://example.com/blog/page/2", `follow only: URL matches "/page/\\d+$"`},
+		{"title", []FollowOnlyRule{{Title: `^Category:`}}, "https://example.com/wiki/Cats", `follow only: title matches "^Category:"`},
+		{"both must match", []FollowOnlyRule{{Pattern: `/blog/`, Title: `^Tag:`}}, "https://example.com/wiki/Cats", ""},
+		{"no match", []FollowOnlyRule{{Pattern: `/page/\d+$`}, {Title: `^Archive`}}, "https://example.com/blog/post", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := compileFollowOnlyRules(tt.rules)
+			if err != nil {
+				t.Fatalf("compileFollowOnlyRules() error = %v", err)
+			}
+			c := &Crawler{log: &Logger{}, followOnly: rules}
+			page, _ := NewPageDocument(tt.url, []byte(`<html><head><title>
+  Category: Cats </title></head><body>Cats</body></html>`))
+			if got := c.followOnlyReason(page); got != tt.want {
+				t.Errorf("followOnlyReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowOnlyCrawl(t *testing.T) {
+	text := strings.Repeat("Readable article text. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Blog</title></head><body><p>%s</p><a href="/page/2">Older</a></body></html>`, text)
+	})
+	mux.HandleFunc("/page/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Blog, page 2</title></head><body><p>%s</p><a href="/post">Post</a><a href="/topics">Topics</a></body></html>`, text)
+	})
+	mux.HandleFunc("/topics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Category: Topics</title></head><body><p>%s</p></body></html>`, text)
+	})
+	mux.HandleFunc("/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Post</title></head><body><p>%s</p></body></html>`, text)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         3,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		FollowOnly:       []FollowOnlyRule{{Pattern: `/page/\d+$`}, {Title: `^Category:`}},
+	}
+	c, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	// The post is only linked from the follow-only page, so it is reached through it
+	for _, name := range []string{"index.html", "post.html"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err != nil {
+			t.Errorf("%s not saved: %v", name, err)
+		}
+	}
+	for _, name := range []string{filepath.Join("page", "2.html"), "topics.html"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err == nil {
+			t.Errorf("follow-only page %s was saved", name)
+		}
+	}
+
+	snapshot := c.GetMetrics().GetSnapshot()
+	if snapshot.URLsSaved != 2 || snapshot.FollowOnly != 2 || snapshot.ContentFiltered != 0 {
+		t.Errorf("saved %d, follow only %d, content filtered %d; want 2, 2, 0",
+			snapshot.URLsSaved, snapshot.FollowOnly, snapshot.ContentFiltered)
+	}
+
+	records, err := LoadURLInventory(outputDir)
+	if err != nil {
+		t.Fatalf("LoadURLInventory() error = %v", err)
+	}
+	for _, r := range records {
+		if strings.HasSuffix(r.URL, "/page/2") && (r.Status != URLStatusSkipped || !strings.HasPrefix(r.Reason, "follow only")) {
+			t.Errorf("/page/2 recorded as %s %q", r.Status, r.Reason)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Tuning of the frontier spilled to disk
+const (
+	DefaultFrontierRefill = 1000 // URLs read back at once when no FrontierLimit is set (resuming a spilled state)
+	maxFrontierSegments   = 16   // Segments kept before they are compacted into one
+)
+
+// SpillSegment is a file of queued URLs spilled to disk, as recorded in the
+// state file: one URLInfo per line sorted by depth then URL, of which the
+// part from Offset on is still queued
+type SpillSegment struct {
+	File      string `json:"file"`      // Name in the frontier directory
+	Offset    int64  `json:"offset"`    // Bytes already read back into the queue
+	Remaining int    `json:"remaining"` // URLs from Offset on
+}
+
+// FrontierDir is the directory a crawl with the given state file spills its
+// queue to
+func FrontierDir(stateFile string) string {
+	return stateFile + ".frontier"
+}
+
+// frontierSegment is an open SpillSegment with its next URL read ahead
+type frontierSegment struct {
+	SpillSegment
+	file    *os.File
+	reader  *bufio.Reader
+	head    *URLInfo
+	headLen int64
+}
+
+// advance reads the next URL of the segment into head, nil at its end
+func (s *frontierSegment) advance() error {
+	s.head = nil
+	line, err := s.reader.ReadBytes('\n')
+	if len(line) == 0 {
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	var info URLInfo
+	if err := json.Unmarshal(line, &info); err != nil {
+		return fmt.Errorf("corrupt frontier segment %s: %w", s.File, err)
+	}
+	s.head = &info
+	s.headLen = int64(len(line))
+	return nil
+}
+
+// pop consumes the head of the segment and reads the next one
+func (s *frontierSegment) pop() (URLInfo, error) {
+	info := *s.head
+	s.Offset += s.headLen
+	s.Remaining--
+	return info, s.advance()
+}
+
+// frontier keeps the part of the queue beyond FrontierLimit on disk, so the
+// memory of huge crawls doesn't grow with the URLs they discover but have
+// yet to fetch. The tail of the in-memory queue is spilled to a new segment
+// sorted by depth and URL; once the queue runs empty it is refilled from the
+// merged segments, shallowest URLs first. Spilled URLs are only remembered
+// by a 64-bit hash, for the duplicate checks of discovery. Segments are
+// compacted into one, without duplicates, once there are too many.
+// Segments a saved state may still refer to are removed after the next save.
+type frontier struct {
+	dir      string
+	limit    int
+	segments []*frontierSegment
+	spilled  map[uint64]struct{}
+	count    int
+	seq      int
+	obsolete []string
+}
+
+// hashURL is the key of a spilled URL
+func hashURL(u string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(u))
+	return h.Sum64()
+}
+
+// openFrontier opens the frontier in dir with the segments of a saved state,
+// calling fn with each URL still queued in them
+func openFrontier(dir string, limit int, saved []SpillSegment, fn func(URLInfo)) (*frontier, error) {
+	f := &frontier{dir: dir, limit: limit, spilled: make(map[uint64]struct{})}
+	for _, s := range saved {
+		seg, err := f.openSegment(s)
+		if err != nil {
+			f.close()
+			return nil, err
+		}
+		// A first pass rebuilds the hashes, a second one reads from the
+		// saved offset again
+		for seg.head != nil {
+			info, err := seg.pop()
+			if err != nil {
+				seg.file.Close()
+				f.close()
+				return nil, err
+			}
+			f.spilled[hashURL(info.URL)] = struct{}{}
+			if fn != nil {
+				fn(info)
+			}
+		}
+		seg.file.Close()
+		if seg, err = f.openSegment(s); err != nil {
+			f.close()
+			return nil, err
+		}
+		f.segments = append(f.segments, seg)
+		f.count += s.Remaining
+		var seq int
+		if _, err := fmt.Sscanf(s.File, "segment-%d.jsonl", &seq); err == nil && seq > f.seq {
+			f.seq = seq
+		}
+	}
+	return f, nil
+}
+
+// openSegment opens a segment at its saved offset
+func (f *frontier) openSegment(s SpillSegment) (*frontierSegment, error) {
+	file, err := os.Open(filepath.Join(f.dir, s.File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open frontier segment: %w", err)
+	}
+	if _, err := file.Seek(s.Offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open frontier segment: %w", err)
+	}
+	seg := &frontierSegment{SpillSegment: s, file: file, reader: bufio.NewReader(file)}
+	if err := seg.advance(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return seg, nil
+}
+
+// Len returns how many URLs are spilled
+func (f *frontier) Len() int {
+	return f.count
+}
+
+// Has reports whether u is (probably) spilled
+func (f *frontier) Has(u string) bool {
+	_, ok := f.spilled[hashURL(u)]
+	return ok
+}
+
+// Segments returns the spilled segments for the state file
+func (f *frontier) Segments() []SpillSegment {
+	segments := make([]SpillSegment, 0, len(f.segments))
+	for _, seg := range f.segments {
+		segments = append(segments, seg.SpillSegment)
+	}
+	return segments
+}
+
+// spill writes queue to a new segment
+func (f *frontier) spill(queue []URLInfo) error {
+	if len(queue) == 0 {
+		return nil
+	}
+	sorted := make([]URLInfo, len(queue))
+	copy(sorted, queue)
+	sort.Slice(sorted, func(i, j int) bool { return lessURLInfo(sorted[i], sorted[j]) })
+
+	i := 0
+	seg, err := f.writeSegment(func() (URLInfo, bool, error) {
+		if i == len(sorted) {
+			return URLInfo{}, false, nil
+		}
+		i++
+		return sorted[i-1], true, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, info := range queue {
+		f.spilled[hashURL(info.URL)] = struct{}{}
+	}
+	f.segments = append(f.segments, seg)
+	f.count += seg.Remaining
+	return nil
+}
+
+// compact merges the segments into one, keeping the first (shallowest) of
+// duplicate URLs. Reading them all through next leaves them obsolete.
+func (f *frontier) compact() error {
+	seen := make(map[uint64]struct{}, f.count)
+	seg, err := f.writeSegment(func() (URLInfo, bool, error) {
+		for {
+			info, ok, err := f.next()
+			if !ok || err != nil {
+				return URLInfo{}, false, err
+			}
+			h := hashURL(info.URL)
+			if _, dup := seen[h]; !dup {
+				seen[h] = struct{}{}
+				return info, true, nil
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	f.segments = []*frontierSegment{seg}
+	f.count = seg.Remaining
+	return nil
+}
+
+// writeSegment writes the URLs returned by next to a new segment and opens it
+func (f *frontier) writeSegment(next func() (URLInfo, bool, error)) (*frontierSegment, error) {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create frontier directory: %w", err)
+	}
+	f.seq++
+	name := fmt.Sprintf("segment-%06d.jsonl", f.seq)
+	file, err := os.Create(filepath.Join(f.dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to spill queue: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	count := 0
+	for {
+		info, ok, err := next()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if err := enc.Encode(info); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to spill queue: %w", err)
+		}
+		count++
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to spill queue: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to spill queue: %w", err)
+	}
+	return f.openSegment(SpillSegment{File: name, Remaining: count})
+}
+
+// next takes the shallowest spilled URL off the segments, dropping the
+// segments it empties. A segment that can't be read further is dropped with
+// the URLs left in it, returning the error along with the URL taken.
+func (f *frontier) next() (URLInfo, bool, error) {
+	var min *frontierSegment
+	for _, seg := range f.segments {
+		if seg.head != nil && (min == nil || lessURLInfo(*seg.head, *min.head)) {
+			min = seg
+		}
+	}
+	if min == nil {
+		return URLInfo{}, false, nil
+	}
+	info, err := min.pop()
+	f.count--
+	if err != nil {
+		f.count -= min.Remaining
+		min.head = nil
+	}
+	if min.head == nil {
+		min.file.Close()
+		f.obsolete = append(f.obsolete, min.File)
+		for i, seg := range f.segments {
+			if seg == min {
+				f.segments = append(f.segments[:i], f.segments[i+1:]...)
+				break
+			}
+		}
+	}
+	return info, true, err
+}
+
+// refill takes up to n spilled URLs for which keep returns true, shallowest
+// first
+func (f *frontier) refill(n int, keep func(URLInfo) bool) ([]URLInfo, error) {
+	var infos []URLInfo
+	for len(infos) < n {
+		info, ok, err := f.next()
+		if !ok {
+			break
+		}
+		delete(f.spilled, hashURL(info.URL))
+		if keep(info) {
+			infos = append(infos, info)
+		}
+		if err != nil {
+			return infos, err
+		}
+	}
+	return infos, nil
+}
+
+// removeObsolete deletes the segments no longer referred to, once a state
+// not referring to them has been saved
+func (f *frontier) removeObsolete() {
+	for _, name := range f.obsolete {
+		os.Remove(filepath.Join(f.dir, name))
+	}
+	f.obsolete = nil
+}
+
+// close closes the segments, removing the directory once nothing is spilled
+func (f *frontier) close() {
+	for _, seg := range f.segments {
+		seg.file.Close()
+	}
+	f.segments = nil
+	if f.count == 0 {
+		os.RemoveAll(f.dir)
+	}
+}
+
+// lessURLInfo orders spilled URLs by depth, then URL
+func lessURLInfo(a, b URLInfo) bool {
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	return a.URL < b.URL
+}
+
+// openFrontier sets up the frontier of a crawl with FrontierLimit or a state
+// that spilled its queue, counting the spilled URLs as discovered
+func (c *Crawler) openFrontier() error {
+	if c.config.FrontierLimit <= 0 && len(c.state.Spilled) == 0 {
+		return nil
+	}
+	f, err := openFrontier(FrontierDir(c.config.StateFile), c.config.FrontierLimit, c.state.Spilled, func(info URLInfo) {
+		c.inventory.Discover(info.URL, info.Depth, "")
+		c.metrics.IncrementDiscoveredAt(info.Depth)
+		c.targetQueued(info.URL)
+	})
+	if err != nil {
+		return err
+	}
+	c.frontier = f
+	if f.Len() > 0 {
+		c.log.Info("Resuming %d queued URLs spilled to %s", f.Len(), f.dir)
+	}
+	return nil
+}
+
+// closeFrontier closes the frontier at the end of a crawl
+func (c *Crawler) closeFrontier() {
+	if c.frontier != nil {
+		c.frontier.close()
+	}
+}
+
+// isQueued reports whether u is in the queue, in memory or spilled. Callers
+// hold c.mu.
+func (c *Crawler) isQueued(u string) bool {
+	return c.state.Queued[u] || (c.frontier != nil && c.frontier.Has(u))
+}
+
+// queueSize returns the length of the queue, spilled URLs included
+func (c *Crawler) queueSize() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.state.Queue)
+	if c.frontier != nil {
+		n += c.frontier.Len()
+	}
+	return n
+}
+
+// balanceFrontier spills the tail of the queue once it is longer than
+// FrontierLimit, keeping half of it, and refills an empty queue from the
+// spilled URLs. It reports whether there is a URL to process.
+func (c *Crawler) balanceFrontier() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.frontier
+	if f == nil {
+		return len(c.state.Queue) > 0
+	}
+
+	if f.limit > 0 && len(c.state.Queue) > f.limit {
+		keep := max(f.limit/2, 1)
+		tail := c.state.Queue[keep:]
+		if err := f.spill(tail); err != nil {
+			c.log.Warn("Failed to spill queue, keeping it in memory: %v", err)
+		} else {
+			for _, info := range tail {
+				delete(c.state.Queued, info.URL)
+				delete(c.state.URLDepths, info.URL)
+			}
+			c.state.Queue = append([]URLInfo(nil), c.state.Queue[:keep]...)
+			c.log.Debug("Spilled %d queued URLs to disk (%d spilled)", len(tail), f.Len())
+		}
+		if len(f.segments) > maxFrontierSegments {
+			if err := f.compact(); err != nil {
+				c.log.Warn("Failed to compact spilled queue: %v", err)
+			}
+		}
+	}
+
+	if len(c.state.Queue) == 0 && f.Len() > 0 {
+		n := f.limit / 2
+		if n <= 0 {
+			n = DefaultFrontierRefill
+		}
+		infos, err := f.refill(n, func(info URLInfo) bool {
+			return !c.state.Visited[info.URL] && !c.state.Queued[info.URL]
+		})
+		for _, info := range infos {
+			c.state.Queue = append(c.state.Queue, info)
+			c.state.Queued[info.URL] = true
+			c.state.URLDepths[info.URL] = info.Depth
+		}
+		if err != nil {
+			c.log.Error("Failed to read spilled queue, the rest of its segment is lost: %v", err)
+		}
+		c.log.Debug("Read %d spilled URLs back into the queue (%d spilled)", len(infos), f.Len())
+	}
+	return len(c.state.Queue) > 0
+}
+
+// nextQueued takes the URL at the head of the in-memory queue, which
+// balanceFrontier has just reported non-empty. Workers append to the queue
+// and the frontier spills and refills it, so it is only changed under c.mu.
+func (c *Crawler) nextQueued() URLInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info := c.state.Queue[0]
+	c.state.Queue = c.state.Queue[1:]
+	return info
+}
@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFrontierSpillAndRefill(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frontier")
+	f, err := openFrontier(dir, 4, nil, nil)
+	if err != nil {
+		t.Fatalf("openFrontier() error = %v", err)
+	}
+	defer f.close()
+
+	if err := f.spill([]URLInfo{{URL: "https://e.com/c", Depth: 2}, {URL: "https://e.com/b", Depth: 1}}); err != nil {
+		t.Fatalf("spill() error = %v", err)
+	}
+	if err := f.spill([]URLInfo{{URL: "https://e.com/a", Depth: 2}, {URL: "https://e.com/b", Depth: 3}}); err != nil {
+		t.Fatalf("spill() error = %v", err)
+	}
+	if f.Len() != 4 || !f.Has("https://e.com/a") || f.Has("https://e.com/d") {
+		t.Fatalf("Len() = %d, Has(a) = %v, Has(d) = %v", f.Len(), f.Has("https://e.com/a"), f.Has("https://e.com/d"))
+	}
+
+	// Saving now and reopening resumes the same URLs
+	resumed, err := openFrontier(dir, 4, f.Segments(), nil)
+	if err != nil {
+		t.Fatalf("openFrontier() error = %v", err)
+	}
+	if resumed.Len() != 4 || !resumed.Has("https://e.com/c") {
+		t.Errorf("resumed Len() = %d, Has(c) = %v", resumed.Len(), resumed.Has("https://e.com/c"))
+	}
+	resumed.close()
+
+	seen := make(map[string]bool)
+	keep := func(info URLInfo) bool {
+		if seen[info.URL] {
+			return false
+		}
+		seen[info.URL] = true
+		return true
+	}
+	got, err := f.refill(2, keep)
+	if err != nil {
+		t.Fatalf("refill() error = %v", err)
+	}
+	want := []URLInfo{{URL: "https://e.com/b", Depth: 1}, {URL: "https://e.com/a", Depth: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("refill() = %+v, want %+v", got, want)
+	}
+	got, err = f.refill(10, keep)
+	if err != nil {
+		t.Fatalf("refill() error = %v", err)
+	}
+	want = []URLInfo{{URL: "https://e.com/c", Depth: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("refill() = %+v, want %+v (the deeper duplicate dropped)", got, want)
+	}
+	if f.Len() != 0 || f.Has("https://e.com/c") {
+		t.Errorf("Len() = %d after draining, want 0", f.Len())
+	}
+
+	f.removeObsolete()
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("drained segments should be removed, got %d files", len(entries))
+	}
+}
+
+func TestFrontierCompact(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frontier")
+	f, err := openFrontier(dir, 4, nil, nil)
+	if err != nil {
+		t.Fatalf("openFrontier() error = %v", err)
+	}
+	defer f.close()
+
+	for i := 0; i < 5; i++ {
+		queue := []URLInfo{{URL: fmt.Sprintf("https://e.com/%d", i), Depth: 1}, {URL: "https://e.com/dup", Depth: 5 - i}}
+		if err := f.spill(queue); err != nil {
+			t.Fatalf("spill() error = %v", err)
+		}
+	}
+	if err := f.compact(); err != nil {
+		t.Fatalf("compact() error = %v", err)
+	}
+	if len(f.segments) != 1 || f.Len() != 6 {
+		t.Fatalf("compact() left %d segments with %d URLs, want 1 with 6", len(f.segments), f.Len())
+	}
+	got, err := f.refill(10, func(URLInfo) bool { return true })
+	if err != nil {
+		t.Fatalf("refill() error = %v", err)
+	}
+	if got[0] != (URLInfo{URL: "https://e.com/0", Depth: 1}) || got[5] != (URLInfo{URL: "https://e.com/dup", Depth: 1}) {
+		t.Errorf("refill() = %+v, want the shallowest duplicate kept", got)
+	}
+}
+
+func TestFrontierLimitCrawl(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		t.Run(fmt.Sprintf("concurrent=%v", concurrent), func(t *testing.T) {
+			site := NewTestSite(TestSiteOptions{Pages: 60, Fanout: 8})
+			defer site.Close()
+
+			outputDir := t.TempDir()
+			config := Config{
+				URL:              site.URL + "/",
+				MaxDepth:         10,
+				Concurrent:       concurrent,
+				Workers:          2,
+				OutputDir:        outputDir,
+				StateFile:        filepath.Join(outputDir, "state.json"),
+				FrontierLimit:    4,
+				MinContentLength: 10,
+			}
+			c, err := NewCrawler(config, context.Background())
+			if err != nil {
+				t.Fatalf("NewCrawler() error = %v", err)
+			}
+			if err := c.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			if got := len(c.state.Visited); got != site.Pages() {
+				t.Errorf("visited %d pages, want %d", got, site.Pages())
+			}
+			for n := 0; n < site.Pages(); n++ {
+				if hits := site.Hits(site.PagePath(n)); hits > 1 {
+					t.Errorf("%s fetched %d times", site.PagePath(n), hits)
+				}
+			}
+			if _, err := os.Stat(FrontierDir(config.StateFile)); !os.IsNotExist(err) {
+				t.Error("frontier directory should be removed once drained")
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// GeoRegionPlaceholder is replaced by the region code in GeoConfig.Proxy,
+// for proxy providers that select the exit country through the URL
+const GeoRegionPlaceholder = "{region}"
+
+// GeoConfig selects the language and location pages are requested from, so
+// region-specific variants of a site can be captured deliberately
+type GeoConfig struct {
+	Region         string `json:"region"`         // Preset from GeoRegions; fills the fields below that are empty
+	AcceptLanguage string `json:"acceptLanguage"` // Accept-Language header (e.g. "de-DE,de;q=0.9")
+	Locale         string `json:"locale"`         // Browser locale (e.g. "de-DE"), browser mode only
+	Timezone       string `json:"timezone"`       // IANA timezone (e.g. "Europe/Berlin"), browser mode only
+	Geolocation    string `json:"geolocation"`    // "latitude,longitude", browser mode only
+	Proxy          string `json:"proxy"`          // Proxy URL; {region} is replaced by the region code
+	ProxyDNS       bool   `json:"proxyDns"`       // Resolve host names only through the proxy; the browser makes no DNS lookups of its own
+}
+
+// GeoRegion holds the language and location defaults of a region preset
+type GeoRegion struct {
+	Name           string
+	AcceptLanguage string
+	Locale         string
+	Timezone       string
+	Geolocation    string // Capital or largest city
+}
+
+// GeoRegions are the region presets, keyed by ISO 3166-1 alpha-2 code
+var GeoRegions = map[string]GeoRegion{
+	"au": {"Australia", "en-AU,en;q=0.9", "en-AU", "Australia/Sydney", "-33.8688,151.2093"},
+	"br": {"Brazil", "pt-BR,pt;q=0.9,en;q=0.8", "pt-BR", "America/Sao_Paulo", "-23.5505,-46.6333"},
+	"ca": {"Canada", "en-CA,en;q=0.9,fr-CA;q=0.8", "en-CA", "America/Toronto", "43.6532,-79.3832"},
+	"de": {"Germany", "de-DE,de;q=0.9,en;q=0.8", "de-DE", "Europe/Berlin", "52.5200,13.4050"},
+	"es": {"Spain", "es-ES,es;q=0.9,en;q=0.8", "es-ES", "Europe/Madrid", "40.4168,-3.7038"},
+	"fr": {"France", "fr-FR,fr;q=0.9,en;q=0.8", "fr-FR", "Europe/Paris", "48.8566,2.3522"},
+	"gb": {"United Kingdom", "en-GB,en;q=0.9", "en-GB", "Europe/London", "51.5074,-0.1278"},
+	"in": {"India", "en-IN,en;q=0.9,hi;q=0.8", "en-IN", "Asia/Kolkata", "19.0760,72.8777"},
+	"it": {"Italy", "it-IT,it;q=0.9,en;q=0.8", "it-IT", "Europe/Rome", "41.9028,12.4964"},
+	"jp": {"Japan", "ja-JP,ja;q=0.9,en;q=0.8", "ja-JP", "Asia/Tokyo", "35.6762,139.6503"},
+	"nl": {"Netherlands", "nl-NL,nl;q=0.9,en;q=0.8", "nl-NL", "Europe/Amsterdam", "52.3676,4.9041"},
+	"us": {"United States", "en-US,en;q=0.9", "en-US", "America/New_York", "40.7128,-74.0060"},
+}
+
+// GeoRegionCodes returns the region preset codes in alphabetical order
+func GeoRegionCodes() []string {
+	codes := make([]string, 0, len(GeoRegions))
+	for code := range GeoRegions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Resolved returns the settings with the region preset applied to the
+// empty fields and the region placeholder in Proxy substituted
+func (g GeoConfig) Resolved() GeoConfig {
+	g.Region = strings.ToLower(strings.TrimSpace(g.Region))
+	if r, ok := GeoRegions[g.Region]; ok {
+		if g.AcceptLanguage == "" {
+			g.AcceptLanguage = r.AcceptLanguage
+		}
+		if g.Locale == "" {
+			g.Locale = r.Locale
+		}
+		if g.Timezone == "" {
+			g.Timezone = r.Timezone
+		}
+		if g.Geolocation == "" {
+			g.Geolocation = r.Geolocation
+		}
+	}
+	g.Proxy = strings.ReplaceAll(g.Proxy, GeoRegionPlaceholder, g.Region)
+	return g
+}
+
+// ProxyURL parses the proxy of resolved settings, returning nil when none is set
+func (g GeoConfig) ProxyURL() (*url.URL, error) {
+	if g.Proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(g.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy must use http, https, socks5 or socks5h scheme, got: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy URL must have a host")
+	}
+	return u, nil
+}
+
+// ParseGeolocation parses a "latitude,longitude" pair
+func ParseGeolocation(s string) (float64, float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("geolocation must be \"latitude,longitude\", got: %s", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("geolocation latitude must be between -90 and 90, got: %s", parts[0])
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("geolocation longitude must be between -180 and 180, got: %s", parts[1])
+	}
+	return lat, lon, nil
+}
+
+// validateGeo checks the region, geolocation and proxy settings. Locale,
+// timezone and geolocation can only be emulated by a browser; a region
+// preset in HTTP mode only contributes its Accept-Language header.
+func validateGeo(g GeoConfig, mode FetchMode) error {
+	region := strings.ToLower(strings.TrimSpace(g.Region))
+	if _, ok := GeoRegions[region]; region != "" && !ok {
+		return fmt.Errorf("region must be one of: %s, got: %s", strings.Join(GeoRegionCodes(), ", "), g.Region)
+	}
+	if mode != FetchModeBrowser && (g.Locale != "" || g.Timezone != "" || g.Geolocation != "") {
+		return fmt.Errorf("locale, timezone and geolocation emulation require browser fetch mode")
+	}
+	if g.Geolocation != "" {
+		if _, _, err := ParseGeolocation(g.Geolocation); err != nil {
+			return err
+		}
+	}
+	if strings.Contains(g.Proxy, GeoRegionPlaceholder) && region == "" {
+		return fmt.Errorf("proxy contains %s but no region is set", GeoRegionPlaceholder)
+	}
+	if g.ProxyDNS && g.Proxy == "" {
+		return fmt.Errorf("DNS through the proxy requires a proxy")
+	}
+	// A proxy from the secrets store is checked once resolved, when the
+	// crawler is created
+	if secretRefRegex.MatchString(g.Proxy) {
+		return nil
+	}
+	u, err := g.Resolved().ProxyURL()
+	if err != nil {
+		return err
+	}
+	// The browser answers the authentication challenges of HTTP proxies, but
+	// Chrome has no way to log in to a SOCKS proxy
+	if u != nil && u.User != nil && mode == FetchModeBrowser && isSOCKSProxy(u) {
+		return fmt.Errorf("SOCKS proxy credentials are not supported in browser fetch mode, use an http or https proxy")
+	}
+	return nil
+}
+
+// isSOCKSProxy reports whether a proxy URL is a SOCKS5 proxy
+func isSOCKSProxy(u *url.URL) bool {
+	return u.Scheme == "socks5" || u.Scheme == "socks5h"
+}
+
+// geoAllocatorOptions returns the Chrome flags for resolved settings. Chrome
+// takes the proxy without its credentials, which proxyAuthActions supplies
+// when the proxy asks for them. It resolves host names through SOCKS5
+// proxies itself, so socks5h is passed as socks5.
+func geoAllocatorOptions(g GeoConfig) []chromedp.ExecAllocatorOption {
+	var opts []chromedp.ExecAllocatorOption
+	if u, err := g.ProxyURL(); err == nil && u != nil {
+		scheme := u.Scheme
+		if scheme == "socks5h" {
+			scheme = "socks5"
+		}
+		opts = append(opts, chromedp.ProxyServer(scheme+"://"+u.Host))
+		if g.ProxyDNS {
+			// Every host name the browser would look up itself (DNS prefetch,
+			// preconnects, WebRTC) fails, except the proxy's own
+			opts = append(opts, chromedp.Flag("host-resolver-rules", "MAP * ~NOTFOUND , EXCLUDE "+u.Hostname()))
+		}
+	}
+	if g.Locale != "" {
+		opts = append(opts, chromedp.Flag("lang", g.Locale))
+	}
+	return opts
+}
+
+// proxyAuthActions answers the authentication challenges of the proxy of
+// resolved settings with its credentials in the tab of ctx. Challenges come
+// from the Fetch domain, which pauses every request of the tab until it is
+// continued. It returns no actions when the proxy has no credentials.
+func proxyAuthActions(ctx context.Context, g GeoConfig) []chromedp.Action {
+	u, err := g.ProxyURL()
+	if err != nil || u == nil || u.User == nil {
+		return nil
+	}
+	username := u.User.Username()
+	password, _ := u.User.Password()
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *fetch.EventRequestPaused:
+			go func() {
+				c := chromedp.FromContext(ctx)
+				fetch.ContinueRequest(e.RequestID).Do(cdp.WithExecutor(ctx, c.Target))
+			}()
+		case *fetch.EventAuthRequired:
+			response := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+			if e.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
+				response = &fetch.AuthChallengeResponse{
+					Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+					Username: username,
+					Password: password,
+				}
+			}
+			go func() {
+				c := chromedp.FromContext(ctx)
+				fetch.ContinueWithAuth(e.RequestID, response).Do(cdp.WithExecutor(ctx, c.Target))
+			}()
+		}
+	})
+	return []chromedp.Action{fetch.Enable().WithHandleAuthRequests(true)}
+}
+
+// geoActions emulates the locale, timezone, geolocation and Accept-Language
+// of resolved settings in a tab. Emulation is per tab, so it runs before
+// every navigation.
+func geoActions(g GeoConfig) []chromedp.Action {
+	var actions []chromedp.Action
+	if g.AcceptLanguage != "" {
+		actions = append(actions,
+			network.Enable(),
+			network.SetExtraHTTPHeaders(network.Headers{"Accept-Language": g.AcceptLanguage}),
+		)
+	}
+	if g.Locale != "" {
+		actions = append(actions, emulation.SetLocaleOverride().WithLocale(g.Locale))
+	}
+	if g.Timezone != "" {
+		actions = append(actions, emulation.SetTimezoneOverride(g.Timezone))
+	}
+	if lat, lon, err := ParseGeolocation(g.Geolocation); g.Geolocation != "" && err == nil {
+		actions = append(actions,
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				// Answer navigator.geolocation without a permission prompt
+				return browser.GrantPermissions([]browser.PermissionType{browser.PermissionTypeGeolocation}).Do(ctx)
+			}),
+			emulation.SetGeolocationOverride().WithLatitude(lat).WithLongitude(lon).WithAccuracy(100),
+		)
+	}
+	return actions
+}
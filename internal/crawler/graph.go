@@ -0,0 +1,141 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Crawl map sizes: the map holds the URLs discovered first, which form a
+// tree from the start URL down, and leaves out the rest of large crawls
+const (
+	DefaultGraphNodes = 2000
+	MaxGraphNodes     = 20000
+)
+
+// GraphNode is a URL on the crawl map, linked to the page it was first
+// found on
+type GraphNode struct {
+	URL        string `json:"url"`
+	Parent     string `json:"parent,omitempty"` // Referrer, "" for the start URL and URLs whose referrer is not on the map
+	Depth      int    `json:"depth"`
+	Status     string `json:"status"`               // Inventory outcome: queued, saved, skipped, error or blocked
+	HTTPStatus int    `json:"httpStatus,omitempty"` // 0 when no response was received
+}
+
+// CrawlGraph is the crawl map, or the nodes that changed since a version
+type CrawlGraph struct {
+	Version uint64      `json:"version"` // Pass as since to get the next changes
+	Nodes   []GraphNode `json:"nodes"`   // New and changed nodes in discovery order
+	Total   int         `json:"total"`   // URLs encountered
+	Omitted int         `json:"omitted"` // URLs left off the map past maxNodes
+}
+
+// ClampGraphNodes returns the number of map nodes to use for a requested
+// maxNodes (0 = DefaultGraphNodes, at most MaxGraphNodes)
+func ClampGraphNodes(maxNodes int) int {
+	if maxNodes <= 0 {
+		return DefaultGraphNodes
+	}
+	return min(maxNodes, MaxGraphNodes)
+}
+
+// Graph returns the map nodes changed after version since (0 = all), out
+// of the first maxNodes URLs discovered
+func (inv *urlInventory) Graph(since uint64, maxNodes int) CrawlGraph {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	shown := inv.order[:min(len(inv.order), ClampGraphNodes(maxNodes))]
+	g := CrawlGraph{
+		Version: inv.version,
+		Nodes:   []GraphNode{},
+		Total:   len(inv.order),
+		Omitted: len(inv.order) - len(shown),
+	}
+
+	var onMap map[string]bool
+	for _, u := range shown {
+		if inv.versions[u] <= since {
+			continue
+		}
+		if onMap == nil {
+			onMap = make(map[string]bool, len(shown))
+			for _, s := range shown {
+				onMap[s] = true
+			}
+		}
+		g.Nodes = append(g.Nodes, graphNode(inv.records[u], onMap))
+	}
+	return g
+}
+
+// CrawlGraph returns the crawl map nodes changed after version since, out
+// of the first maxNodes URLs discovered. Poll with the returned version to
+// follow the crawl.
+func (c *Crawler) CrawlGraph(since uint64, maxNodes int) CrawlGraph {
+	if c.inventory == nil {
+		return CrawlGraph{Nodes: []GraphNode{}}
+	}
+	return c.inventory.Graph(since, maxNodes)
+}
+
+// BuildCrawlGraph returns the crawl map of a URL inventory, such as the one
+// a finished crawl wrote, out of its first maxNodes URLs
+func BuildCrawlGraph(records []URLRecord, maxNodes int) CrawlGraph {
+	shown := records[:min(len(records), ClampGraphNodes(maxNodes))]
+	onMap := make(map[string]bool, len(shown))
+	for _, r := range shown {
+		onMap[r.URL] = true
+	}
+	g := CrawlGraph{
+		Nodes:   make([]GraphNode, 0, len(shown)),
+		Total:   len(records),
+		Omitted: len(records) - len(shown),
+	}
+	for i := range shown {
+		g.Nodes = append(g.Nodes, graphNode(&shown[i], onMap))
+	}
+	return g
+}
+
+// graphNode returns the map node of rec, dropping a referrer not on the map
+func graphNode(rec *URLRecord, onMap map[string]bool) GraphNode {
+	n := GraphNode{URL: rec.URL, Depth: rec.Depth, Status: rec.Status, HTTPStatus: rec.HTTPStatus}
+	if rec.Referrer != rec.URL && onMap[rec.Referrer] {
+		n.Parent = rec.Referrer
+	}
+	return n
+}
+
+// graphStatusColors are the node colors of the GUI's crawl map, used for
+// the DOT output as well
+var graphStatusColors = map[string]string{
+	URLStatusQueued:  "#9ca3af",
+	URLStatusSaved:   "#4ade80",
+	URLStatusSkipped: "#fbbf24",
+	URLStatusError:   "#f87171",
+	URLStatusBlocked: "#a78bfa",
+}
+
+// WriteCrawlGraphDOT writes the crawl map in Graphviz DOT, with nodes
+// colored by status and labeled with their URL
+func WriteCrawlGraphDOT(w io.Writer, g CrawlGraph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph crawl {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box, style=filled, fontsize=10];")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "  %s [fillcolor=%q, tooltip=%q];\n", strconv.Quote(n.URL), graphStatusColors[n.Status], n.Status+" at depth "+strconv.Itoa(n.Depth))
+	}
+	for _, n := range g.Nodes {
+		if n.Parent != "" {
+			fmt.Fprintf(bw, "  %s -> %s;\n", strconv.Quote(n.Parent), strconv.Quote(n.URL))
+		}
+	}
+	if g.Omitted > 0 {
+		fmt.Fprintf(bw, "  // %d more URLs left off the map\n", g.Omitted)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
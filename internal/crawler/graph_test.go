@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInventoryGraphChanges(t *testing.T) {
+	inv := newURLInventory()
+	inv.Discover("https://example.com/", 0, "")
+	inv.Discover("https://example.com/a", 1, "https://example.com/")
+	inv.Discover("https://example.com/b", 1, "https://example.com/")
+	inv.Record("https://example.com/", 0, URLStatusSaved, "", &FetchResult{StatusCode: 200})
+
+	g := inv.Graph(0, 0)
+	if len(g.Nodes) != 3 || g.Total != 3 || g.Omitted != 0 {
+		t.Fatalf("Graph(0) = %d nodes of %d (%d omitted), want all 3", len(g.Nodes), g.Total, g.Omitted)
+	}
+	if n := g.Nodes[0]; n.URL != "https://example.com/" || n.Status != URLStatusSaved || n.HTTPStatus != 200 || n.Parent != "" {
+		t.Errorf("start node = %+v", n)
+	}
+	if n := g.Nodes[2]; n.Parent != "https://example.com/" || n.Depth != 1 || n.Status != URLStatusQueued {
+		t.Errorf("discovered node = %+v", n)
+	}
+
+	// Finding a URL again on another page changes nothing
+	inv.Discover("https://example.com/b", 2, "https://example.com/a")
+	if changed := inv.Graph(g.Version, 0); len(changed.Nodes) != 0 {
+		t.Errorf("rediscovery changed %v", changed.Nodes)
+	}
+
+	inv.Record("https://example.com/b", 1, URLStatusError, "HTTP 500", nil)
+	changed := inv.Graph(g.Version, 0)
+	if len(changed.Nodes) != 1 || changed.Nodes[0].URL != "https://example.com/b" || changed.Nodes[0].Status != URLStatusError {
+		t.Errorf("changes since %d = %+v, want only the errored URL", g.Version, changed.Nodes)
+	}
+	if changed.Version <= g.Version {
+		t.Errorf("version did not advance: %d after %d", changed.Version, g.Version)
+	}
+}
+
+func TestInventoryGraphMaxNodes(t *testing.T) {
+	inv := newURLInventory()
+	inv.Discover("https://example.com/", 0, "")
+	inv.Discover("https://example.com/a", 1, "https://example.com/")
+	inv.Discover("https://example.com/a/1", 2, "https://example.com/a")
+
+	g := inv.Graph(0, 2)
+	if len(g.Nodes) != 2 || g.Total != 3 || g.Omitted != 1 {
+		t.Errorf("Graph(0, 2) = %d nodes of %d (%d omitted), want 2 of 3 (1 omitted)", len(g.Nodes), g.Total, g.Omitted)
+	}
+	inv.Record("https://example.com/a/1", 2, URLStatusSaved, "", nil)
+	if changed := inv.Graph(g.Version, 2); len(changed.Nodes) != 0 {
+		t.Errorf("a URL off the map was reported: %+v", changed.Nodes)
+	}
+}
+
+func TestBuildCrawlGraph(t *testing.T) {
+	records := []URLRecord{
+		{URL: "https://example.com/", Status: URLStatusSaved},
+		{URL: "https://example.com/a", Status: URLStatusSaved, Depth: 1, Referrer: "https://example.com/"},
+		{URL: "https://example.com/b", Status: URLStatusBlocked, Depth: 1, Referrer: "https://elsewhere.example/"},
+	}
+	g := BuildCrawlGraph(records, 0)
+	if len(g.Nodes) != 3 {
+		t.Fatalf("got %d nodes, want 3", len(g.Nodes))
+	}
+	if g.Nodes[1].Parent != "https://example.com/" {
+		t.Errorf("parent = %q, want the start URL", g.Nodes[1].Parent)
+	}
+	if g.Nodes[2].Parent != "" {
+		t.Errorf("a referrer off the map should be dropped, got %q", g.Nodes[2].Parent)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCrawlGraphDOT(&buf, g); err != nil {
+		t.Fatalf("WriteCrawlGraphDOT() error = %v", err)
+	}
+	dot := buf.String()
+	for _, want := range []string{"digraph crawl {", `"https://example.com/" -> "https://example.com/a";`, `fillcolor="#a78bfa"`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output lacks %s:\n%s", want, dot)
+		}
+	}
+}
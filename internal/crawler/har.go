@@ -0,0 +1,510 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+// HAR capture modes (browser mode only)
+const (
+	HARModeOff   = "off"   // No HAR capture (default)
+	HARModePage  = "page"  // One HAR file per fetched page under HARDir
+	HARModeCrawl = "crawl" // A single CrawlHARFile covering every page
+)
+
+// HAR output locations inside the output directory
+const (
+	HARDir       = "_har"
+	CrawlHARFile = "crawl.har"
+)
+
+// ValidHARMode reports whether mode is a supported HAR capture mode.
+// An empty mode is treated as HARModeOff.
+func ValidHARMode(mode string) bool {
+	switch mode {
+	case "", HARModeOff, HARModePage, HARModeCrawl:
+		return true
+	}
+	return false
+}
+
+// HAR is an HTTP Archive 1.2 document. Response bodies are not recorded.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR document
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Pages   []HARPage  `json:"pages"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that wrote the HAR
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HARPage is a page load that grouped a set of entries
+type HARPage struct {
+	StartedDateTime time.Time      `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     HARPageTimings `json:"pageTimings"`
+}
+
+// HARPageTimings holds page load milestones in milliseconds since the page started (-1 if unknown)
+type HARPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+// HAREntry is one network request made by a page
+type HAREntry struct {
+	PageRef         string      `json:"pageref,omitempty"`
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Total milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ResourceType    string      `json:"_resourceType,omitempty"` // Document, Script, XHR, Fetch, ...
+	Error           string      `json:"_error,omitempty"`        // Network error for failed requests
+}
+
+// HARNameValue is a header, cookie or query string pair
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARRequest describes a request
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse describes a response
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARContent describes a response body without its text
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// HARTimings breaks an entry's time down by phase in milliseconds (-1 if not applicable)
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HARCapture is the network activity recorded while loading one page
+type HARCapture struct {
+	Page    HARPage
+	Entries []HAREntry
+}
+
+// NewHAR assembles captures into a HAR document, numbering their pages in order
+func NewHAR(captures []*HARCapture) *HAR {
+	h := &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "scraper", Version: "1.0"},
+		Pages:   []HARPage{},
+		Entries: []HAREntry{},
+	}}
+	for i, c := range captures {
+		pg := c.Page
+		pg.ID = fmt.Sprintf("page_%d", i+1)
+		h.Log.Pages = append(h.Log.Pages, pg)
+		for _, e := range c.Entries {
+			e.PageRef = pg.ID
+			h.Log.Entries = append(h.Log.Entries, e)
+		}
+	}
+	return h
+}
+
+// WriteHAR saves a HAR document to path, creating its directory
+func WriteHAR(path string, h *HAR) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %v", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// harRecord tracks one in-flight request
+type harRecord struct {
+	entry   *HAREntry
+	started time.Time // Monotonic timestamp of requestWillBeSent
+	timing  *network.ResourceTiming
+}
+
+// harRecorder turns the DevTools network events of a tab into HAR entries.
+// Events arrive on the chromedp listener goroutine, so it is mutex guarded.
+type harRecorder struct {
+	mu       sync.Mutex
+	title    string
+	started  time.Time // Wall time of the first request
+	pageMono time.Time // Monotonic time of the first request
+	onDOM    float64
+	onLoad   float64
+	inflight map[network.RequestID]*harRecord
+	done     []*HAREntry
+}
+
+// newHARRecorder creates a recorder for a page load of rawURL
+func newHARRecorder(rawURL string) *harRecorder {
+	return &harRecorder{
+		title:    rawURL,
+		onDOM:    -1,
+		onLoad:   -1,
+		inflight: make(map[network.RequestID]*harRecord),
+	}
+}
+
+// handle records a chromedp target event; unrelated events are ignored
+func (r *harRecorder) handle(ev interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		// A redirect reuses the request ID: finish the previous hop first
+		if rec, ok := r.inflight[e.RequestID]; ok && e.RedirectResponse != nil {
+			r.setResponse(rec, e.RedirectResponse)
+			rec.entry.Response.RedirectURL = e.Request.URL
+			r.finish(e.RequestID, rec, monoTime(e.Timestamp), 0)
+		}
+		wall, mono := wallTime(e.WallTime), monoTime(e.Timestamp)
+		if r.started.IsZero() {
+			r.started, r.pageMono = wall, mono
+		}
+		r.inflight[e.RequestID] = &harRecord{
+			entry: &HAREntry{
+				StartedDateTime: wall,
+				Request:         harRequest(e.Request),
+				Response:        HARResponse{Cookies: []HARNameValue{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1},
+				Timings:         HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+				ResourceType:    string(e.Type),
+			},
+			started: mono,
+		}
+	case *network.EventResponseReceived:
+		if rec, ok := r.inflight[e.RequestID]; ok {
+			r.setResponse(rec, e.Response)
+		}
+	case *network.EventLoadingFinished:
+		if rec, ok := r.inflight[e.RequestID]; ok {
+			r.finish(e.RequestID, rec, monoTime(e.Timestamp), int64(e.EncodedDataLength))
+		}
+	case *network.EventLoadingFailed:
+		if rec, ok := r.inflight[e.RequestID]; ok {
+			rec.entry.Error = e.ErrorText
+			if e.BlockedReason != "" {
+				rec.entry.Error += " (" + string(e.BlockedReason) + ")"
+			}
+			r.finish(e.RequestID, rec, monoTime(e.Timestamp), 0)
+		}
+	case *page.EventDomContentEventFired:
+		if !r.pageMono.IsZero() {
+			r.onDOM = millis(monoTime(e.Timestamp).Sub(r.pageMono))
+		}
+	case *page.EventLoadEventFired:
+		if !r.pageMono.IsZero() {
+			r.onLoad = millis(monoTime(e.Timestamp).Sub(r.pageMono))
+		}
+	}
+}
+
+// setResponse copies a DevTools response into an entry
+func (r *harRecorder) setResponse(rec *harRecord, resp *network.Response) {
+	e := rec.entry
+	e.Response.Status = int(resp.Status)
+	e.Response.StatusText = resp.StatusText
+	e.Response.HTTPVersion = harHTTPVersion(resp.Protocol)
+	e.Response.Headers = harHeaders(resp.Headers)
+	e.Response.Content.MimeType = resp.MimeType
+	if e.Response.RedirectURL == "" {
+		e.Response.RedirectURL = headerValue(resp.Headers, "Location")
+	}
+	if len(resp.RequestHeaders) > 0 {
+		// The headers actually sent, including cookies added by the browser
+		e.Request.Headers = harHeaders(resp.RequestHeaders)
+	}
+	e.Request.HTTPVersion = e.Response.HTTPVersion
+	rec.timing = resp.Timing
+}
+
+// finish completes an entry at the monotonic time end
+func (r *harRecorder) finish(id network.RequestID, rec *harRecord, end time.Time, encoded int64) {
+	e := rec.entry
+	if encoded > 0 {
+		e.Response.BodySize = encoded
+		e.Response.Content.Size = encoded
+	}
+	if !end.IsZero() && !rec.started.IsZero() && end.After(rec.started) {
+		e.Time = millis(end.Sub(rec.started))
+	}
+	if t := rec.timing; t != nil {
+		e.Timings.DNS = harPhase(t.DNSStart, t.DNSEnd)
+		e.Timings.Connect = harPhase(t.ConnectStart, t.ConnectEnd)
+		e.Timings.SSL = harPhase(t.SslStart, t.SslEnd)
+		e.Timings.Send = harPhase(t.SendStart, t.SendEnd)
+		e.Timings.Wait = harPhase(t.SendEnd, t.ReceiveHeadersEnd)
+		if !end.IsZero() {
+			requestTime := cdp.MonotonicTimeEpoch.Add(time.Duration(t.RequestTime * float64(time.Second)))
+			elapsed := millis(end.Sub(requestTime))
+			if elapsed > t.ReceiveHeadersEnd {
+				e.Timings.Receive = elapsed - t.ReceiveHeadersEnd
+			}
+		}
+	}
+	if e.Timings.Send < 0 {
+		e.Timings.Send = 0
+	}
+	if e.Timings.Wait < 0 {
+		e.Timings.Wait = 0
+	}
+	r.done = append(r.done, e)
+	delete(r.inflight, id)
+}
+
+// capture returns the entries recorded since the previous call, including
+// requests still in flight, and starts a new page. A nil recorder returns nil.
+func (r *harRecorder) capture() *HARCapture {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]HAREntry, 0, len(r.done)+len(r.inflight))
+	for _, e := range r.done {
+		entries = append(entries, *e)
+	}
+	for _, rec := range r.inflight {
+		e := *rec.entry
+		if e.Error == "" {
+			e.Error = "pending"
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StartedDateTime.Before(entries[j].StartedDateTime) })
+
+	capture := &HARCapture{
+		Page: HARPage{
+			StartedDateTime: r.started,
+			Title:           r.title,
+			PageTimings:     HARPageTimings{OnContentLoad: r.onDOM, OnLoad: r.onLoad},
+		},
+		Entries: entries,
+	}
+	if capture.Page.StartedDateTime.IsZero() {
+		capture.Page.StartedDateTime = time.Now()
+	}
+
+	r.done = nil
+	r.inflight = make(map[network.RequestID]*harRecord)
+	r.started, r.pageMono = time.Time{}, time.Time{}
+	r.onDOM, r.onLoad = -1, -1
+	return capture
+}
+
+// SetTitle names the page of the next capture
+func (r *harRecorder) SetTitle(title string) {
+	r.mu.Lock()
+	r.title = title
+	r.mu.Unlock()
+}
+
+// harRequest converts a DevTools request
+func harRequest(req *network.Request) HARRequest {
+	out := HARRequest{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     harHeaders(req.Headers),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				out.QueryString = append(out.QueryString, HARNameValue{Name: name, Value: v})
+			}
+		}
+		sort.Slice(out.QueryString, func(i, j int) bool { return out.QueryString[i].Name < out.QueryString[j].Name })
+	}
+	for _, entry := range req.PostDataEntries {
+		out.BodySize += base64.StdEncoding.DecodedLen(len(entry.Bytes)) // Entries are base64 encoded
+	}
+	return out
+}
+
+// harHeaders converts DevTools headers to sorted name/value pairs. Chrome
+// joins repeated headers with newlines, so they are split back apart.
+func harHeaders(h network.Headers) []HARNameValue {
+	out := []HARNameValue{}
+	for name, v := range h {
+		for _, value := range strings.Split(fmt.Sprint(v), "\n") {
+			out = append(out, HARNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// headerValue looks up a header case-insensitively
+func headerValue(h network.Headers, name string) string {
+	for k, v := range h {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// harHTTPVersion maps a DevTools protocol name to a HAR HTTP version
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "", "http/1.1":
+		return "HTTP/1.1"
+	case "http/1.0":
+		return "HTTP/1.0"
+	case "h2":
+		return "HTTP/2"
+	case "h3", "h3-29":
+		return "HTTP/3"
+	}
+	return protocol
+}
+
+// harPhase returns the length of a timing phase, or -1 if it did not happen
+func harPhase(start, end float64) float64 {
+	if start < 0 || end < 0 {
+		return -1
+	}
+	return end - start
+}
+
+// millis converts a duration to fractional milliseconds
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// wallTime converts a DevTools wall clock time, defaulting to now
+func wallTime(t *cdp.TimeSinceEpoch) time.Time {
+	if t == nil {
+		return time.Now()
+	}
+	return t.Time()
+}
+
+// monoTime converts a DevTools monotonic time
+func monoTime(t *cdp.MonotonicTime) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time()
+}
+
+// harCollector accumulates page captures for HARModeCrawl
+type harCollector struct {
+	mu       sync.Mutex
+	captures []*HARCapture
+}
+
+// Add appends a page capture
+func (h *harCollector) Add(capture *HARCapture) {
+	h.mu.Lock()
+	h.captures = append(h.captures, capture)
+	h.mu.Unlock()
+}
+
+// HAR returns the collected pages as one document
+func (h *harCollector) HAR() *HAR {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return NewHAR(h.captures)
+}
+
+// saveHAR stores the network capture of a fetched page according to the
+// configured HAR mode. rawURL names the file in HARModePage.
+func (c *Crawler) saveHAR(rawURL string, result *FetchResult) {
+	if result == nil || result.HAR == nil {
+		return
+	}
+	switch c.config.HARMode {
+	case HARModeCrawl:
+		c.har.Add(result.HAR)
+	case HARModePage:
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			return
+		}
+		name := strings.TrimSuffix(c.generateFilename(parsedURL), ".html") + ".har"
+		if err := c.writeHAR(filepath.Join(c.config.OutputDir, HARDir, name), NewHAR([]*HARCapture{result.HAR})); err != nil {
+			c.log.Warn("Failed to write HAR for %s: %v", rawURL, err)
+		}
+	}
+}
+
+// writeCrawlHAR saves the crawl-wide HAR in HARModeCrawl
+func (c *Crawler) writeCrawlHAR() error {
+	if c.config.HARMode != HARModeCrawl {
+		return nil
+	}
+	return c.writeHAR(filepath.Join(c.config.OutputDir, CrawlHARFile), c.har.HAR())
+}
+
+// writeHAR saves a HAR document with the configured output permissions
+func (c *Crawler) writeHAR(path string, h *HAR) error {
+	if err := c.perms.mkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+	if err := WriteHAR(path, h); err != nil {
+		return err
+	}
+	return c.perms.applyFile(path)
+}
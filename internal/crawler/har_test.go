@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+)
+
+func TestHARRecorder(t *testing.T) {
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	wall := func(ms int) *cdp.TimeSinceEpoch {
+		t := cdp.TimeSinceEpoch(base.Add(time.Duration(ms) * time.Millisecond))
+		return &t
+	}
+	mono := func(ms int) *cdp.MonotonicTime {
+		t := cdp.MonotonicTime(cdp.MonotonicTimeEpoch.Add(time.Duration(ms) * time.Millisecond))
+		return &t
+	}
+
+	r := newHARRecorder("https://example.com/old")
+	events := []interface{}{
+		&network.EventRequestWillBeSent{
+			RequestID: "1", Type: network.ResourceTypeDocument, WallTime: wall(0), Timestamp: mono(0),
+			Request: &network.Request{Method: "GET", URL: "https://example.com/old", Headers: network.Headers{"Accept": "text/html"}},
+		},
+		// Redirects reuse the request ID
+		&network.EventRequestWillBeSent{
+			RequestID: "1", Type: network.ResourceTypeDocument, WallTime: wall(20), Timestamp: mono(20),
+			Request:          &network.Request{Method: "GET", URL: "https://example.com/new"},
+			RedirectResponse: &network.Response{Status: 301, StatusText: "Moved Permanently", Headers: network.Headers{"Location": "/new"}},
+		},
+		&network.EventResponseReceived{
+			RequestID: "1", Type: network.ResourceTypeDocument,
+			Response: &network.Response{Status: 200, StatusText: "OK", Protocol: "h2", MimeType: "text/html",
+				Headers: network.Headers{"Set-Cookie": "a=1\nb=2"}},
+		},
+		&network.EventLoadingFinished{RequestID: "1", Timestamp: mono(70), EncodedDataLength: 1234},
+		&network.EventRequestWillBeSent{
+			RequestID: "2", Type: network.ResourceTypeXHR, WallTime: wall(80), Timestamp: mono(80),
+			Request: &network.Request{Method: "GET", URL: "https://api.example.com/items?page=2&sort=name"},
+		},
+		&network.EventLoadingFailed{RequestID: "2", Timestamp: mono(90), ErrorText: "net::ERR_FAILED", BlockedReason: network.BlockedReasonMixedContent},
+		&network.EventRequestWillBeSent{
+			RequestID: "3", Type: network.ResourceTypeScript, WallTime: wall(95), Timestamp: mono(95),
+			Request: &network.Request{Method: "GET", URL: "https://cdn.example.com/app.js"},
+		},
+		&page.EventDomContentEventFired{Timestamp: mono(100)},
+		&page.EventLoadEventFired{Timestamp: mono(150)},
+	}
+	for _, ev := range events {
+		r.handle(ev)
+	}
+
+	capture := r.capture()
+	if capture.Page.Title != "https://example.com/old" || !capture.Page.StartedDateTime.Equal(base) {
+		t.Errorf("page = %+v", capture.Page)
+	}
+	if capture.Page.PageTimings.OnContentLoad != 100 || capture.Page.PageTimings.OnLoad != 150 {
+		t.Errorf("pageTimings = %+v, want 100/150", capture.Page.PageTimings)
+	}
+	if len(capture.Entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(capture.Entries))
+	}
+
+	redirect, doc, xhr, script := capture.Entries[0], capture.Entries[1], capture.Entries[2], capture.Entries[3]
+	if redirect.Response.Status != 301 || redirect.Response.RedirectURL != "https://example.com/new" || redirect.Time != 20 {
+		t.Errorf("redirect entry = %+v", redirect)
+	}
+	if doc.Request.URL != "https://example.com/new" || doc.Response.Status != 200 || doc.Response.HTTPVersion != "HTTP/2" {
+		t.Errorf("document entry = %+v", doc)
+	}
+	if doc.Response.BodySize != 1234 || doc.Time != 50 || len(doc.Response.Headers) != 2 {
+		t.Errorf("document size/time/headers = %d/%v/%v", doc.Response.BodySize, doc.Time, doc.Response.Headers)
+	}
+	if xhr.ResourceType != "XHR" || xhr.Error != "net::ERR_FAILED (mixed-content)" || len(xhr.Request.QueryString) != 2 {
+		t.Errorf("xhr entry = %+v", xhr)
+	}
+	if script.Error != "pending" {
+		t.Errorf("in-flight request error = %q, want pending", script.Error)
+	}
+
+	if next := r.capture(); len(next.Entries) != 0 || next.Page.PageTimings.OnLoad != -1 {
+		t.Errorf("second capture = %+v, want empty", next)
+	}
+	var nilRecorder *harRecorder
+	if nilRecorder.capture() != nil {
+		t.Error("nil recorder should capture nothing")
+	}
+}
+
+func TestNewHAR(t *testing.T) {
+	captures := []*HARCapture{
+		{Page: HARPage{Title: "a"}, Entries: []HAREntry{{Request: HARRequest{URL: "a"}}}},
+		{Page: HARPage{Title: "b"}, Entries: []HAREntry{{Request: HARRequest{URL: "b1"}}, {Request: HARRequest{URL: "b2"}}}},
+	}
+	h := NewHAR(captures)
+	if h.Log.Version != "1.2" || len(h.Log.Pages) != 2 || len(h.Log.Entries) != 3 {
+		t.Fatalf("unexpected HAR: %+v", h.Log)
+	}
+	if h.Log.Pages[1].ID != "page_2" || h.Log.Entries[2].PageRef != "page_2" || h.Log.Entries[0].PageRef != "page_1" {
+		t.Errorf("pages/entries not linked: %+v", h.Log)
+	}
+}
+
+func TestSaveHAR(t *testing.T) {
+	capture := &HARCapture{Page: HARPage{Title: "https://example.com/docs/a"}, Entries: []HAREntry{{Request: HARRequest{URL: "https://example.com/docs/a"}}}}
+
+	tests := []struct {
+		name string
+		mode string
+		file string
+	}{
+		{"page", HARModePage, filepath.Join(HARDir, "docs", "a.har")},
+		{"crawl", HARModeCrawl, CrawlHARFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := &Crawler{config: Config{OutputDir: dir, HARMode: tt.mode}, har: &harCollector{}, log: &Logger{}}
+			c.saveHAR("https://example.com/docs/a", &FetchResult{HAR: capture})
+			c.saveHAR("https://example.com/docs/b", &FetchResult{})
+			if err := c.writeCrawlHAR(); err != nil {
+				t.Fatalf("writeCrawlHAR() error = %v", err)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatalf("HAR not written: %v", err)
+			}
+			var h HAR
+			if err := json.Unmarshal(data, &h); err != nil {
+				t.Fatalf("invalid HAR: %v", err)
+			}
+			if len(h.Log.Pages) != 1 || len(h.Log.Entries) != 1 {
+				t.Errorf("unexpected HAR: %+v", h.Log)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package crawler
+
+import "net/url"
+
+// harvestDetail is the decision trace detail of URLs queued from a pagination
+const harvestDetail = "pagination click"
+
+// harvestPageURL queues the URL a pagination click of rawURL navigated to,
+// e.g. through history.pushState, as a regular page at the depth of rawURL,
+// when Pagination.HarvestURLs is set and the click changed the URL. It
+// reports whether it took the page over, in which case the page is crawled
+// through its own URL and not saved as a pagination page of rawURL.
+func (c *Crawler) harvestPageURL(rawURL string, currentDepth, pageNumber int, result *FetchResult) bool {
+	if !c.config.Pagination.HarvestURLs || pageNumber < 2 || result.FinalURL == "" {
+		return false
+	}
+	u, err := url.Parse(result.FinalURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	u.Fragment = ""
+	target := u.String()
+	normalizedURL := c.resolveAlias(c.normalizeURL(target))
+	if normalizedURL == rawURL {
+		return false // Same URL: a pagination page like any other
+	}
+
+	if reason := c.linkFilterReason(target); reason != "" {
+		c.log.Debug("Not harvesting page %d of %s: %s is filtered (%s)", pageNumber, rawURL, target, reason)
+		c.traceLink(target, rawURL, currentDepth, reason, harvestDetail)
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.state.Visited[normalizedURL]:
+		c.traceLink(normalizedURL, rawURL, currentDepth, DecisionDedup, "already visited")
+	case c.isQueued(normalizedURL):
+		c.traceLink(normalizedURL, rawURL, currentDepth, DecisionDedup, "already queued")
+	default:
+		c.log.Debug("Harvested page %d of %s: %s", pageNumber, rawURL, normalizedURL)
+		c.state.Queue = append(c.state.Queue, URLInfo{URL: normalizedURL, Depth: currentDepth})
+		c.state.URLDepths[normalizedURL] = currentDepth
+		c.state.Queued[normalizedURL] = true
+		c.state.Harvested[normalizedURL] = true
+		c.inventory.Discover(normalizedURL, currentDepth, rawURL)
+		c.metrics.IncrementDiscoveredAt(currentDepth)
+		c.targetQueued(normalizedURL)
+		c.traceLink(normalizedURL, rawURL, currentDepth, DecisionQueued, harvestDetail)
+		c.prefetchRobots(normalizedURL)
+	}
+	return true
+}
+
+// isHarvested reports whether rawURL was queued from a pagination, so it is
+// fetched as a regular page instead of being paginated itself
+func (c *Crawler) isHarvested(rawURL string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Harvested[rawURL]
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHarvestPageURL(t *testing.T) {
+	config := Config{
+		URL:             "https://example.com/list",
+		PrefixFilterURL: "https://example.com/",
+		MaxDepth:        3,
+		OutputDir:       t.TempDir(),
+		IgnoreRobots:    true,
+	}
+	c, err := NewCrawler(config, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	c.state = NewCrawlerState(config.URL)
+	c.config.Pagination.HarvestURLs = true
+
+	list := "https://example.com/list"
+	page := func(finalURL string) *FetchResult { return &FetchResult{FinalURL: finalURL} }
+
+	tests := []struct {
+		name       string
+		pageNumber int
+		finalURL   string
+		want       bool
+	}{
+		{"first page", 1, "https://example.com/list?page=1", false},
+		{"URL unchanged", 2, list, false},
+		{"fragment only", 2, list + "#page-2", false},
+		{"pushState URL", 2, "https://example.com/list?page=2", true},
+		{"already queued", 3, "https://example.com/list?page=2", true},
+		{"filtered", 4, "https://other.example/list?page=4", true},
+	}
+	for _, tt := range tests {
+		if got := c.harvestPageURL(list, 2, tt.pageNumber, page(tt.finalURL)); got != tt.want {
+			t.Errorf("%s: harvestPageURL() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if len(c.state.Queue) != 1 || c.state.Queue[0] != (URLInfo{URL: "https://example.com/list?page=2", Depth: 2}) {
+		t.Errorf("Queue = %+v, want page 2 at the depth of the list", c.state.Queue)
+	}
+	if !c.isHarvested("https://example.com/list?page=2") || c.isHarvested(list) {
+		t.Error("only page 2 should be marked harvested")
+	}
+	if ref := c.inventory.Referrer("https://example.com/list?page=2"); ref != list {
+		t.Errorf("referrer = %q, want the list", ref)
+	}
+
+	c.config.Pagination.HarvestURLs = false
+	if c.harvestPageURL(list, 2, 5, page("https://example.com/list?page=5")) {
+		t.Error("harvesting is off and should not take pages over")
+	}
+}
@@ -0,0 +1,202 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimiter spaces out requests to each host across every crawler sharing
+// it, so several crawls of one site don't add up their request rates, and
+// optionally caps the requests in flight to each host. The crawl's own delay
+// still applies on top.
+type HostLimiter struct {
+	mu          sync.Mutex
+	interval    time.Duration
+	next        map[string]time.Time // Earliest time of the next request to each host
+	concurrency int                  // Requests in flight to one host (0 = unlimited)
+	slots       map[string]chan struct{}
+}
+
+// NewHostLimiter creates a limiter allowing one request per interval to
+// each host
+func NewHostLimiter(interval time.Duration) *HostLimiter {
+	return &HostLimiter{interval: interval, next: make(map[string]time.Time)}
+}
+
+// Interval returns the minimum time between two requests to the same host
+func (l *HostLimiter) Interval() time.Duration {
+	return l.interval
+}
+
+// SetHostConcurrency caps the requests in flight to each host at n (0 =
+// unlimited). It must be called before the limiter is shared.
+func (l *HostLimiter) SetHostConcurrency(n int) {
+	l.concurrency = n
+	l.slots = make(map[string]chan struct{})
+}
+
+// HostConcurrency returns the cap on requests in flight to each host
+func (l *HostLimiter) HostConcurrency() int {
+	return l.concurrency
+}
+
+// slot returns the semaphore of host
+func (l *HostLimiter) slot(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Idle hosts hold no slot and have no waiters; forget them like the
+	// request times below
+	if len(l.slots) > 1000 {
+		for h, s := range l.slots {
+			if len(s) == 0 {
+				delete(l.slots, h)
+			}
+		}
+	}
+	s, ok := l.slots[host]
+	if !ok {
+		s = make(chan struct{}, l.concurrency)
+		l.slots[host] = s
+	}
+	return s
+}
+
+// reserve books the next free slot for host and returns how long the caller
+// has to wait for it
+func (l *HostLimiter) reserve(host string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Hosts whose slot has passed are free again; forget them so a long
+	// running server doesn't keep every host ever crawled
+	if len(l.next) > 1000 {
+		for h, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, h)
+			}
+		}
+	}
+	slot := now
+	if next, ok := l.next[host]; ok && next.After(now) {
+		slot = next
+	}
+	l.next[host] = slot.Add(l.interval)
+	return slot.Sub(now)
+}
+
+// limiterHost returns the host requests to rawURL are counted against
+func limiterHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return strings.ToLower(u.Hostname())
+	}
+	return rawURL
+}
+
+// Acquire takes one of the host's request slots, then waits like Wait. The
+// returned function gives the slot back once the request is done.
+func (l *HostLimiter) Acquire(ctx context.Context, rawURL string) (func(), error) {
+	release := func() {}
+	if l != nil && l.concurrency > 0 {
+		s := l.slot(limiterHost(rawURL))
+		select {
+		case s <- struct{}{}:
+			release = func() { <-s }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := l.Wait(ctx, rawURL); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// Wait blocks until a request to the host of rawURL is allowed, or ctx is done
+func (l *HostLimiter) Wait(ctx context.Context, rawURL string) error {
+	if l == nil || l.interval <= 0 {
+		return nil
+	}
+	wait := l.reserve(limiterHost(rawURL), time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HostLimitedFetcher wraps a Fetcher and waits for a HostLimiter before
+// every fetch, holding one of the host's request slots until it is done
+type HostLimitedFetcher struct {
+	Fetcher
+	ctx     context.Context
+	limiter *HostLimiter
+}
+
+// NewHostLimitedFetcher wraps fetcher with limiter; waits end when ctx is done
+func NewHostLimitedFetcher(ctx context.Context, fetcher Fetcher, limiter *HostLimiter) *HostLimitedFetcher {
+	return &HostLimitedFetcher{Fetcher: fetcher, ctx: ctx, limiter: limiter}
+}
+
+// Unwrap returns the wrapped fetcher
+func (f *HostLimitedFetcher) Unwrap() Fetcher {
+	return f.Fetcher
+}
+
+// Fetch waits for the limiter, then fetches url through the wrapped fetcher
+func (f *HostLimitedFetcher) Fetch(url string, userAgent string) (*FetchResult, error) {
+	release, err := f.limiter.Acquire(f.ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return f.Fetcher.Fetch(url, userAgent)
+}
+
+// FetchContext is Fetch giving up, while waiting or fetching, when ctx ends
+func (f *HostLimitedFetcher) FetchContext(ctx context.Context, url string, userAgent string) (*FetchResult, error) {
+	release, err := f.limiter.Acquire(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return fetchContext(ctx, f.Fetcher, url, userAgent)
+}
+
+// ScopesOverlap reports whether crawls with configs a and b could fetch the
+// same pages: they start on the same host and neither's prefix filter
+// excludes the other's. A crawl without a prefix filter covers its whole
+// start host.
+func ScopesOverlap(a, b Config) bool {
+	hostA, pathA := crawlScope(a)
+	hostB, pathB := crawlScope(b)
+	if hostA == "" || hostA != hostB {
+		return false
+	}
+	return strings.HasPrefix(pathA, pathB) || strings.HasPrefix(pathB, pathA)
+}
+
+// crawlScope returns the host and path prefix a crawl is confined to
+func crawlScope(config Config) (string, string) {
+	scope := config.URL
+	filtered := config.PrefixFilterURL != "" && config.PrefixFilterURL != "none"
+	if filtered {
+		scope = config.PrefixFilterURL
+	}
+	u, err := url.Parse(scope)
+	if err != nil {
+		return "", ""
+	}
+	if !filtered {
+		return strings.ToLower(u.Hostname()), ""
+	}
+	return strings.ToLower(u.Hostname()), strings.TrimSuffix(u.Path, "/")
+}
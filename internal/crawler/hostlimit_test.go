@@ -0,0 +1,107 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterReserve(t *testing.T) {
+	l := NewHostLimiter(time.Second)
+	now := time.Now()
+
+	if wait := l.reserve("example.com", now); wait != 0 {
+		t.Errorf("first request waits %v, want 0", wait)
+	}
+	if wait := l.reserve("example.com", now); wait != time.Second {
+		t.Errorf("second request waits %v, want 1s", wait)
+	}
+	if wait := l.reserve("example.com", now.Add(500*time.Millisecond)); wait != 1500*time.Millisecond {
+		t.Errorf("third request waits %v, want 1.5s", wait)
+	}
+	if wait := l.reserve("other.example", now); wait != 0 {
+		t.Errorf("another host waits %v, want 0", wait)
+	}
+	if wait := l.reserve("example.com", now.Add(time.Hour)); wait != 0 {
+		t.Errorf("a request after the booked slots waits %v, want 0", wait)
+	}
+}
+
+func TestHostLimiterWaitCancelled(t *testing.T) {
+	l := NewHostLimiter(time.Hour)
+	if err := l.Wait(context.Background(), "https://example.com/a"); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(ctx, "https://EXAMPLE.com/b"); err == nil {
+		t.Error("Wait() on the same host should wait for the next slot and return the context error")
+	}
+
+	var none *HostLimiter
+	if err := none.Wait(ctx, "https://example.com/"); err != nil {
+		t.Errorf("nil limiter Wait() error = %v", err)
+	}
+}
+
+func TestHostLimiterConcurrency(t *testing.T) {
+	l := NewHostLimiter(0)
+	l.SetHostConcurrency(2)
+	ctx := context.Background()
+
+	first, err := l.Acquire(ctx, "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	second, err := l.Acquire(ctx, "https://example.com/b")
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if release, err := l.Acquire(ctx, "https://other.example/"); err != nil {
+		t.Errorf("another host should have free slots, got %v", err)
+	} else {
+		release()
+	}
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(cancelled, "https://EXAMPLE.com/c"); err == nil {
+		t.Error("a third request to the host should wait for a slot and return the context error")
+	}
+
+	first()
+	if release, err := l.Acquire(ctx, "https://example.com/c"); err != nil {
+		t.Errorf("Acquire() after a release error = %v", err)
+	} else {
+		release()
+	}
+	second()
+
+	var none *HostLimiter
+	if release, err := none.Acquire(ctx, "https://example.com/"); err != nil {
+		t.Errorf("nil limiter Acquire() error = %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestScopesOverlap(t *testing.T) {
+	tests := []struct {
+		a, b Config
+		want bool
+	}{
+		{Config{URL: "https://example.com/"}, Config{URL: "https://example.com/blog/"}, true},
+		{Config{URL: "https://example.com/"}, Config{URL: "https://other.example/"}, false},
+		{Config{URL: "https://example.com/docs/", PrefixFilterURL: "https://example.com/docs/"}, Config{URL: "https://example.com/docs/api/", PrefixFilterURL: "https://example.com/docs/api/"}, true},
+		{Config{URL: "https://example.com/docs/", PrefixFilterURL: "https://example.com/docs/"}, Config{URL: "https://example.com/blog/", PrefixFilterURL: "https://example.com/blog/"}, false},
+		{Config{URL: "https://example.com/docs/", PrefixFilterURL: "https://example.com/docs/"}, Config{URL: "https://Example.com/", PrefixFilterURL: "none"}, true},
+	}
+	for _, tt := range tests {
+		if got := ScopesOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("ScopesOverlap(%s %s, %s %s) = %v, want %v", tt.a.URL, tt.a.PrefixFilterURL, tt.b.URL, tt.b.PrefixFilterURL, got, tt.want)
+		}
+		if got := ScopesOverlap(tt.b, tt.a); got != tt.want {
+			t.Errorf("ScopesOverlap is not symmetric for %s and %s", tt.a.URL, tt.b.URL)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidHostPattern reports whether pattern is a host name, optionally
+// starting with "*." to stand for the subdomains of a domain
+func ValidHostPattern(pattern string) bool {
+	name := strings.TrimPrefix(pattern, "*.")
+	if name == "" || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+		return false
+	}
+	return !strings.ContainsAny(name, "*/:@?# \t")
+}
+
+// MatchHost reports whether host matches one of patterns. A plain pattern
+// matches that host only, "*.cdn.com" any subdomain of cdn.com but not
+// cdn.com itself. Case is ignored; host must not include a port.
+func MatchHost(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if domain, ok := strings.CutPrefix(p, "*."); ok {
+			if strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		} else if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAllowed reports whether links to host may be queued under the
+// AllowedHosts and DeniedHosts lists. A denied host is never allowed.
+func (c *Crawler) hostAllowed(host string) bool {
+	if MatchHost(host, c.config.DeniedHosts) {
+		return false
+	}
+	return len(c.config.AllowedHosts) == 0 || MatchHost(host, c.config.AllowedHosts)
+}
+
+// validateHostLists checks the host patterns of config and that the start
+// URL's host passes them
+func validateHostLists(config *Config, startHost string) error {
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"allowed", config.AllowedHosts}, {"denied", config.DeniedHosts}} {
+		for _, p := range list.patterns {
+			if !ValidHostPattern(p) {
+				return fmt.Errorf("invalid %s host %q: expected a host name like example.com or *.example.com", list.name, p)
+			}
+		}
+	}
+	if MatchHost(startHost, config.DeniedHosts) {
+		return fmt.Errorf("the start URL's host %s is denied", startHost)
+	}
+	if len(config.AllowedHosts) > 0 && !MatchHost(startHost, config.AllowedHosts) {
+		return fmt.Errorf("the start URL's host %s is not among the allowed hosts", startHost)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package crawler
+
+import "testing"
+
+func TestMatchHost(t *testing.T) {
+	patterns := []string{"example.com", "*.CDN.com"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"EXAMPLE.com", true},
+		{"www.example.com", false},
+		{"img.cdn.com", true},
+		{"a.b.cdn.com", true},
+		{"cdn.com", false},
+		{"notcdn.com", false},
+	}
+	for _, tt := range tests {
+		if got := MatchHost(tt.host, patterns); got != tt.want {
+			t.Errorf("MatchHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestValidHostPattern(t *testing.T) {
+	for pattern, want := range map[string]bool{
+		"example.com":       true,
+		"*.example.com":     true,
+		"localhost":         true,
+		"":                  false,
+		"*.":                false,
+		"*example.com":      false,
+		"a.*.example.com":   false,
+		"example.com:8080":  false,
+		"https://a.com":     false,
+		"example.com/path":  false,
+		".example.com":      false,
+		"user@example.com":  false,
+		"exa mple.com":      false,
+		"*.*.example.com":   false,
+		"example.com.":      false,
+		"sub.example.co.uk": true,
+	} {
+		if got := ValidHostPattern(pattern); got != want {
+			t.Errorf("ValidHostPattern(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestLinkFilterReasonHosts(t *testing.T) {
+	c := &Crawler{config: Config{
+		AllowedHosts:    []string{"example.com", "*.example.com"},
+		DeniedHosts:     []string{"ads.example.com", "*.cdn.example.com"},
+		PrefixFilterURL: "none",
+	}}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/page", ""},
+		{"https://docs.example.com:8443/page", ""},
+		{"https://ads.example.com/banner", LinkFilterHost},
+		{"https://img.cdn.example.com/logo", LinkFilterHost},
+		{"https://tracker.net/pixel", LinkFilterHost},
+		{"mailto:team@example.com", LinkFilterScheme},
+	}
+	for _, tt := range tests {
+		if got := c.linkFilterReason(tt.url); got != tt.want {
+			t.Errorf("linkFilterReason(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	// Without an allowlist only the denied hosts are left out
+	c.config.AllowedHosts = nil
+	if got := c.linkFilterReason("https://tracker.net/pixel"); got != "" {
+		t.Errorf("linkFilterReason() without allowed hosts = %q, want \"\"", got)
+	}
+}
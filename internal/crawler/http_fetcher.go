@@ -1,19 +1,34 @@
 package crawler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
 	"time"
 )
 
+// ErrBodyTooLarge is returned when a response body exceeds the fetcher's size limit
+var ErrBodyTooLarge = errors.New("response body exceeds maximum HTML size")
+
 // HTTPFetcher implements Fetcher using standard HTTP client
 type HTTPFetcher struct {
-	client *http.Client
+	client         *http.Client
+	maxBodySize    int64                      // 0 = unlimited
+	acceptLanguage string                     // Sent as Accept-Language when set
+	referer        func(rawURL string) string // Referer of each request, nil = none
 }
 
 // NewHTTPFetcher creates a new HTTP-based fetcher
 func NewHTTPFetcher() *HTTPFetcher {
+	return NewHTTPFetcherWithMaxBodySize(0)
+}
+
+// NewHTTPFetcherWithMaxBodySize creates a new HTTP-based fetcher that stops
+// reading responses larger than maxBodySize bytes (0 = unlimited)
+func NewHTTPFetcherWithMaxBodySize(maxBodySize int64) *HTTPFetcher {
 	transport := &http.Transport{
 		MaxIdleConns:        100,
 		MaxIdleConnsPerHost: 10,
@@ -21,23 +36,51 @@ func NewHTTPFetcher() *HTTPFetcher {
 	}
 
 	return &HTTPFetcher{
+		maxBodySize: maxBodySize,
 		client: &http.Client{
 			Timeout:   HTTPTimeout,
 			Transport: transport,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= MaxRedirects {
-					return fmt.Errorf("stopped after %d redirects", MaxRedirects)
-				}
-				// User-Agent is preserved from original request
-				return nil
-			},
+			// User-Agent is preserved from original request
+			CheckRedirect: checkRedirect,
 		},
 	}
 }
 
+// SetGeo sends the Accept-Language of geo with every request and routes
+// requests through its proxy. Locale, timezone and geolocation need a browser.
+func (f *HTTPFetcher) SetGeo(geo GeoConfig) error {
+	geo = geo.Resolved()
+	proxy, err := geo.ProxyURL()
+	if err != nil {
+		return err
+	}
+	if proxy != nil {
+		f.client.Transport.(*http.Transport).Proxy = http.ProxyURL(proxy)
+	}
+	f.acceptLanguage = geo.AcceptLanguage
+	return nil
+}
+
+// SetReferer sends the Referer referer returns for each URL, when not empty
+func (f *HTTPFetcher) SetReferer(referer func(rawURL string) string) {
+	f.referer = referer
+}
+
+// KeepCookies stores the cookies of responses and sends them back, as a
+// browser does, so a session started by a warm-up visit carries over
+func (f *HTTPFetcher) KeepCookies() {
+	jar, _ := cookiejar.New(nil)
+	f.client.Jar = jar
+}
+
 // Fetch retrieves a URL using HTTP client
 func (f *HTTPFetcher) Fetch(rawURL string, userAgent string) (*FetchResult, error) {
-	req, err := http.NewRequest("GET", rawURL, nil)
+	return f.FetchContext(context.Background(), rawURL, userAgent)
+}
+
+// FetchContext is Fetch closing the request when ctx ends
+func (f *HTTPFetcher) FetchContext(ctx context.Context, rawURL string, userAgent string) (*FetchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -46,23 +89,61 @@ func (f *HTTPFetcher) Fetch(rawURL string, userAgent string) (*FetchResult, erro
 		userAgent = DefaultUserAgent
 	}
 	req.Header.Set("User-Agent", userAgent)
+	if f.acceptLanguage != "" {
+		req.Header.Set("Accept-Language", f.acceptLanguage)
+	}
+	if f.referer != nil {
+		if referer := f.referer(rawURL); referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+	}
 
-	resp, err := f.client.Do(req)
+	// Record every hop on a per-request copy of the client; a chain cut
+	// short by a loop or MaxRedirects is returned along with the error
+	var redirects []Redirect
+	client := *f.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		redirects = append(redirects, Redirect{
+			From:   via[len(via)-1].URL.String(),
+			To:     req.URL.String(),
+			Status: req.Response.StatusCode,
+		})
+		return checkRedirect(req, via)
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
+		if isRedirectError(err) {
+			return &FetchResult{Redirects: redirects}, err
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// Refuse oversized bodies up front when the server declares the length,
+	// otherwise stop reading one byte past the limit
+	reader := io.Reader(resp.Body)
+	if f.maxBodySize > 0 {
+		if resp.ContentLength > f.maxBodySize {
+			return nil, fmt.Errorf("%w (%s)", ErrBodyTooLarge, FormatBytes(resp.ContentLength))
+		}
+		reader = io.LimitReader(resp.Body, f.maxBodySize+1)
+	}
+
+	body, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if f.maxBodySize > 0 && int64(len(body)) > f.maxBodySize {
+		return nil, fmt.Errorf("%w (more than %s)", ErrBodyTooLarge, FormatBytes(f.maxBodySize))
+	}
 
 	return &FetchResult{
 		Body:        body,
 		StatusCode:  resp.StatusCode,
 		ContentType: resp.Header.Get("Content-Type"),
 		FinalURL:    resp.Request.URL.String(),
+		Redirects:   redirects,
 	}, nil
 }
 
@@ -0,0 +1,255 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Iframe capture modes for Config.Iframes
+const (
+	IframesOff    = "off"    // Save pages with their iframes as they are (default)
+	IframesLink   = "link"   // Queue the documents of iframes as pages of their own, saved as separate files
+	IframesInline = "inline" // Replace iframes with the body of their document in the saved page
+)
+
+// MaxIframesPerPage bounds the iframes captured from one page
+const MaxIframesPerPage = 20
+
+// iframeDetail is the decision trace detail of URLs queued from an iframe
+const iframeDetail = "iframe"
+
+// IframeModes returns the valid Config.Iframes values
+func IframeModes() []string {
+	return []string{IframesOff, IframesLink, IframesInline}
+}
+
+// validateIframes checks the iframe capture mode and cross-origin hosts
+func validateIframes(config *Config) error {
+	switch config.Iframes {
+	case "", IframesOff, IframesLink, IframesInline:
+	default:
+		return fmt.Errorf("iframes must be one of: %s, got: %s", strings.Join(IframeModes(), ", "), config.Iframes)
+	}
+	if len(config.IframeHosts) == 0 {
+		return nil
+	}
+	if config.Iframes == "" || config.Iframes == IframesOff {
+		return fmt.Errorf("iframe hosts require iframe capture to be enabled")
+	}
+	if config.FetchMode != FetchModeBrowser {
+		return fmt.Errorf("cross-origin iframe hosts require browser fetch mode")
+	}
+	for _, p := range config.IframeHosts {
+		if !ValidHostPattern(p) {
+			return fmt.Errorf("invalid iframe host %q: expected a host name like example.com or *.example.com", p)
+		}
+	}
+	return nil
+}
+
+// iframesEnabled reports whether iframes are captured
+func (c *Crawler) iframesEnabled() bool {
+	return c.config.Iframes == IframesLink || c.config.Iframes == IframesInline
+}
+
+// iframeSources returns the distinct URLs of the page's iframes whose
+// documents are captured, in document order: those on the page's origin
+// and those on IframeHosts, at most MaxIframesPerPage
+func (c *Crawler) iframeSources(page *PageDocument) []string {
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var sources []string
+	page.Doc.Find("iframe[src]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		src, _ := s.Attr("src")
+		u, err := base.Parse(strings.TrimSpace(src))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return true
+		}
+		sameOrigin := u.Scheme == base.Scheme && strings.EqualFold(u.Host, base.Host)
+		if !sameOrigin && !MatchHost(u.Hostname(), c.config.IframeHosts) {
+			return true
+		}
+		u.Fragment = ""
+		if frame := u.String(); !seen[frame] && !c.shouldExcludeByExtension(u.Path) {
+			seen[frame] = true
+			sources = append(sources, frame)
+		}
+		return len(sources) < MaxIframesPerPage
+	})
+	return sources
+}
+
+// captureIframes applies the iframe capture mode to a parsed page: it
+// queues the documents of its iframes, or returns the page with them
+// inlined. The page is returned unchanged when there is nothing to capture.
+func (c *Crawler) captureIframes(page *PageDocument, currentDepth int, userAgent string) *PageDocument {
+	if !c.iframesEnabled() {
+		return page
+	}
+	sources := c.iframeSources(page)
+	if len(sources) == 0 {
+		return page
+	}
+	if c.config.Iframes == IframesLink {
+		c.queueIframes(page.URL, sources, currentDepth)
+		return page
+	}
+	return c.inlineIframes(page, sources, userAgent)
+}
+
+// queueIframes queues the iframe documents of the page at rawURL at the
+// page's depth, as they are part of it rather than a link away. They are
+// saved as pages of their own, found through the page.
+func (c *Crawler) queueIframes(rawURL string, sources []string, currentDepth int) {
+	normalized := make([]string, len(sources))
+	for i, src := range sources {
+		normalized[i] = c.resolveAlias(c.normalizeURL(src))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, normalizedURL := range normalized {
+		switch {
+		case c.state.Visited[normalizedURL]:
+			c.traceLink(normalizedURL, rawURL, currentDepth, DecisionDedup, "already visited")
+		case c.isQueued(normalizedURL):
+			c.traceLink(normalizedURL, rawURL, currentDepth, DecisionDedup, "already queued")
+		default:
+			c.log.Debug("Queued iframe of %s: %s", rawURL, normalizedURL)
+			c.state.Queue = append(c.state.Queue, URLInfo{URL: normalizedURL, Depth: currentDepth})
+			c.state.URLDepths[normalizedURL] = currentDepth
+			c.state.Queued[normalizedURL] = true
+			c.inventory.Discover(normalizedURL, currentDepth, rawURL)
+			c.metrics.IncrementDiscoveredAt(currentDepth)
+			c.targetQueued(normalizedURL)
+			c.traceLink(normalizedURL, rawURL, currentDepth, DecisionQueued, iframeDetail)
+			c.prefetchRobots(normalizedURL)
+		}
+	}
+}
+
+// inlineIframes fetches the iframe documents and returns the page with the
+// first iframe of each replaced by a <div data-iframe-src> holding the body
+// of its document, links resolved against the iframe's URL. Iframes that
+// fail to load, are blocked by robots.txt or are not HTML are left in place.
+func (c *Crawler) inlineIframes(page *PageDocument, sources []string, userAgent string) *PageDocument {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.Body))
+	if err != nil {
+		return page
+	}
+	frames := make(map[string]*html.Node)
+	base, _ := url.Parse(page.URL)
+	doc.Find("iframe[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		if u, err := base.Parse(strings.TrimSpace(src)); err == nil {
+			u.Fragment = ""
+			if _, ok := frames[u.String()]; !ok {
+				frames[u.String()] = s.Nodes[0]
+			}
+		}
+	})
+
+	inlined := 0
+	for _, src := range sources {
+		if c.ctx.Err() != nil {
+			break
+		}
+		iframe := frames[src]
+		if iframe == nil || iframe.Parent == nil || !c.isAllowedByRobots(src) {
+			continue
+		}
+		time.Sleep(c.delay())
+		result, err := c.fetcher.Fetch(src, userAgent)
+		if err != nil || result == nil || result.StatusCode != http.StatusOK || c.shouldExcludeByContentType(result.ContentType) {
+			c.log.Debug("Not inlining iframe %s of %s: %v", src, page.URL, iframeFailure(result, err))
+			continue
+		}
+		frameURL := src
+		if result.FinalURL != "" {
+			frameURL = result.FinalURL
+		}
+		content, err := iframeContent(result.Body, frameURL)
+		if err != nil {
+			c.log.Debug("Not inlining iframe %s of %s: %v", src, page.URL, err)
+			continue
+		}
+		iframe.Parent.InsertBefore(content, iframe)
+		iframe.Parent.RemoveChild(iframe)
+		inlined++
+	}
+	if inlined == 0 {
+		return page
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc.Nodes[0]); err != nil {
+		c.log.Debug("Not inlining iframes of %s: %v", page.URL, err)
+		return page
+	}
+	c.log.Debug("Inlined %d iframe(s) into %s", inlined, page.URL)
+	return &PageDocument{URL: page.URL, Body: buf.Bytes(), Doc: doc, UserAgent: page.UserAgent}
+}
+
+// iframeFailure describes why a fetched iframe is not inlined
+func iframeFailure(result *FetchResult, err error) error {
+	switch {
+	case err != nil:
+		return err
+	case result == nil:
+		return fmt.Errorf("no response")
+	case result.StatusCode != http.StatusOK:
+		return fmt.Errorf("HTTP %d", result.StatusCode)
+	default:
+		return fmt.Errorf("excluded content type %s", result.ContentType)
+	}
+}
+
+// iframeContent parses an iframe document into a <div data-iframe-src>
+// holding the children of its body, with href and src attributes made
+// absolute against frameURL so they keep pointing where they did
+func iframeContent(body []byte, frameURL string) (*html.Node, error) {
+	frameDoc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(frameURL)
+	if err != nil {
+		return nil, err
+	}
+	frameBody := frameDoc.Find("body").First()
+	for _, attr := range []string{"href", "src"} {
+		frameBody.Find("[" + attr + "]").Each(func(_ int, s *goquery.Selection) {
+			v, _ := s.Attr(attr)
+			if u, err := base.Parse(strings.TrimSpace(v)); err == nil {
+				s.SetAttr(attr, u.String())
+			}
+		})
+	}
+
+	div := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+		Attr:     []html.Attribute{{Key: "data-iframe-src", Val: frameURL}},
+	}
+	for _, n := range frameBody.Nodes {
+		for child := n.FirstChild; child != nil; {
+			next := child.NextSibling
+			n.RemoveChild(child)
+			div.AppendChild(child)
+			child = next
+		}
+	}
+	return div, nil
+}
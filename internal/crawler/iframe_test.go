@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateIframes(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"unset", Config{}, ""},
+		{"link in http mode", Config{FetchMode: FetchModeHTTP, Iframes: IframesLink}, ""},
+		{"inline with hosts", Config{FetchMode: FetchModeBrowser, Iframes: IframesInline, IframeHosts: []string{"*.viewer.example"}}, ""},
+		{"unknown mode", Config{Iframes: "embed"}, "must be one of"},
+		{"hosts without capture", Config{FetchMode: FetchModeBrowser, IframeHosts: []string{"viewer.example"}}, "require iframe capture"},
+		{"hosts in http mode", Config{FetchMode: FetchModeHTTP, Iframes: IframesInline, IframeHosts: []string{"viewer.example"}}, "require browser"},
+		{"invalid host", Config{FetchMode: FetchModeBrowser, Iframes: IframesLink, IframeHosts: []string{"https://viewer.example"}}, "invalid iframe host"},
+	}
+	for _, tt := range tests {
+		err := validateIframes(&tt.config)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIframeSources(t *testing.T) {
+	c := &Crawler{config: Config{IframeHosts: []string{"viewer.example"}}}
+	page, err := NewPageDocument("https://docs.example/guide", []byte(`<html><body>
+		<iframe src="/embed/1"></iframe>
+		<iframe src="/embed/1#top"></iframe>
+		<iframe src="https://viewer.example/doc"></iframe>
+		<iframe src="https://ads.example/banner"></iframe>
+		<iframe src="/files/manual.pdf"></iframe>
+		<iframe src="javascript:void(0)"></iframe>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.config.ExcludeExtensions = []string{"pdf"}
+
+	got := c.iframeSources(page)
+	want := []string{"https://docs.example/embed/1", "https://viewer.example/doc"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("iframeSources() = %v, want %v", got, want)
+	}
+}
+
+func TestIframesCrawl(t *testing.T) {
+	text := strings.Repeat("Embedded reference text. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><h1>Guide</h1><p>How to read the reference.</p><iframe src="/embed/frame"></iframe></body></html>`)
+	})
+	mux.HandleFunc("/embed/frame", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p><a href="part2">Part 2</a></body></html>`, text)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, mode := range []string{IframesInline, IframesLink} {
+		t.Run(mode, func(t *testing.T) {
+			outputDir := t.TempDir()
+			config := Config{
+				URL:              server.URL + "/",
+				MaxDepth:         0,
+				OutputDir:        outputDir,
+				StateFile:        filepath.Join(outputDir, "state.json"),
+				MinContentLength: 10,
+				Iframes:          mode,
+			}
+			if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+				t.Fatalf("crawl failed: %v", err)
+			}
+			root, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+			if err != nil {
+				t.Fatalf("root page not saved: %v", err)
+			}
+			_, frameErr := os.Stat(filepath.Join(outputDir, "embed", "frame.html"))
+
+			if mode == IframesInline {
+				for _, s := range []string{"Embedded reference text", `data-iframe-src="` + server.URL + `/embed/frame"`, `href="` + server.URL + `/embed/part2"`} {
+					if !strings.Contains(string(root), s) {
+						t.Errorf("inlined page misses %q:\n%s", s, root)
+					}
+				}
+				if strings.Contains(string(root), "<iframe") || frameErr == nil {
+					t.Error("inlined iframe should replace the iframe and not be saved separately")
+				}
+				return
+			}
+			if strings.Contains(string(root), "Embedded reference text") || !strings.Contains(string(root), "<iframe") {
+				t.Errorf("linked iframe should stay an iframe:\n%s", root)
+			}
+			if frameErr != nil {
+				t.Errorf("iframe document not saved at the page's depth: %v", frameErr)
+			}
+		})
+	}
+}
@@ -9,19 +9,40 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 // PageEntry holds metadata for a single scraped page
 type PageEntry struct {
-	URL         string
-	Filename    string // relative path to raw HTML
-	ContentFile string // relative path to .content.html (empty if none)
-	Excerpt     string // plain text excerpt from content
-	Timestamp   time.Time
-	Size        int64
-	ContentSize int64
-	HasContent  bool
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`        // Title found by content extraction
+	Filename    string    `json:"path"`                   // relative path to raw HTML
+	ContentFile string    `json:"content_path,omitempty"` // relative path to .content.html (empty if none)
+	Excerpt     string    `json:"excerpt,omitempty"`      // plain text excerpt from content
+	Timestamp   time.Time `json:"saved_at"`
+	Size        int64     `json:"size"`
+	ContentSize int64     `json:"content_size,omitempty"`
+	HasContent  bool      `json:"has_content"`
+	Blob        string    `json:"blob,omitempty"`         // blob holding the raw HTML in content-addressable crawls
+	ContentBlob string    `json:"content_blob,omitempty"` // blob holding the content file in content-addressable crawls
+}
+
+// Href returns the link to the raw HTML, the blob itself in
+// content-addressable crawls so the index works when opened from disk
+func (e PageEntry) Href() string {
+	if e.Blob != "" {
+		return e.Blob
+	}
+	return e.Filename
+}
+
+// ContentHref returns the link to the content file, like Href
+func (e PageEntry) ContentHref() string {
+	if e.ContentBlob != "" {
+		return e.ContentBlob
+	}
+	return e.ContentFile
 }
 
 // IndexData holds all data needed to render the index template
@@ -43,6 +64,7 @@ type metaFileData struct {
 	ContentFile          string `json:"content_file"`
 	ContentSize          int    `json:"content_size"`
 	ContentExtracted     bool   `json:"content_extracted"`
+	Compression          string `json:"compression,omitempty"`           // gzip or zstd when the HTML files are compressed
 	ReadabilityExtracted *bool  `json:"readability_extracted,omitempty"` // Backward compat for old .meta.json files
 	// Trafilatura metadata
 	Title       string `json:"title,omitempty"`
@@ -51,31 +73,148 @@ type metaFileData struct {
 	Language    string `json:"language,omitempty"`
 	Description string `json:"description,omitempty"`
 	Sitename    string `json:"sitename,omitempty"`
+	// Content-addressable storage: blobs of the HTML and content files
+	Blob        string `json:"blob,omitempty"`
+	ContentBlob string `json:"content_blob,omitempty"`
 }
 
-// GenerateIndex creates an _index.html file in the output directory
-func GenerateIndex(outputDir string) error {
-	// Scan for all meta files
-	metaFiles, err := scanMetaFiles(outputDir)
+// IndexBuilder maintains the index in memory so _index.html can be rewritten
+// during a crawl without rescanning the output directory
+type IndexBuilder struct {
+	outputDir string
+	interval  int         // rewrite after this many added pages (0 = only on explicit Write)
+	perms     outputPerms // Mode and owner of the written index
+	pages     map[string]PageEntry
+	pending   int
+	mu        sync.Mutex
+}
+
+// NewIndexBuilder creates an index builder for the output directory
+func NewIndexBuilder(outputDir string, interval int) *IndexBuilder {
+	return &IndexBuilder{
+		outputDir: outputDir,
+		interval:  interval,
+		perms:     outputPerms{uid: -1, gid: -1},
+		pages:     make(map[string]PageEntry),
+	}
+}
+
+// Load seeds the index from .meta.json files already on disk (e.g. when resuming)
+func (b *IndexBuilder) Load() error {
+	metaFiles, err := scanMetaFiles(b.outputDir)
 	if err != nil {
 		return fmt.Errorf("failed to scan meta files: %v", err)
 	}
 
-	if len(metaFiles) == 0 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, metaPath := range metaFiles {
+		entry, err := loadPageEntry(b.outputDir, metaPath)
+		if err != nil {
+			continue // Skip files that can't be loaded
+		}
+		b.pages[entry.Filename] = entry
+	}
+	return nil
+}
+
+// Add records a saved page and rewrites the index once the interval is reached.
+// It reports whether the index file was written.
+func (b *IndexBuilder) Add(entry PageEntry) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pages[entry.Filename] = entry
+	b.pending++
+	if b.interval <= 0 || b.pending < b.interval {
+		return false, nil
+	}
+	return true, b.writeLocked()
+}
+
+// Write renders _index.html from the pages recorded so far
+func (b *IndexBuilder) Write() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writeLocked()
+}
+
+// Pages returns the indexed pages sorted by URL
+func (b *IndexBuilder) Pages() []PageEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pages := make([]PageEntry, 0, len(b.pages))
+	for _, entry := range b.pages {
+		pages = append(pages, entry)
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		if pages[i].URL != pages[j].URL {
+			return pages[i].URL < pages[j].URL
+		}
+		return pages[i].Filename < pages[j].Filename
+	})
+	return pages
+}
+
+// Len returns the number of indexed pages
+func (b *IndexBuilder) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pages)
+}
+
+func (b *IndexBuilder) writeLocked() error {
+	b.pending = 0
+	if len(b.pages) == 0 {
 		return nil // Nothing to index
 	}
 
-	// Load page entries from meta files
-	pages := make([]PageEntry, 0, len(metaFiles))
+	pages := make([]PageEntry, 0, len(b.pages))
+	for _, entry := range b.pages {
+		pages = append(pages, entry)
+	}
+	return writeIndex(b.outputDir, pages, b.perms)
+}
+
+// GenerateIndex creates an _index.html file in the output directory
+func GenerateIndex(outputDir string) error {
+	_, err := BuildIndex(outputDir)
+	return err
+}
+
+// BuildIndex creates _index.html in the output directory like GenerateIndex
+// and returns the indexed pages sorted by URL
+func BuildIndex(outputDir string) ([]PageEntry, error) {
+	builder := NewIndexBuilder(outputDir, 0)
+	if err := builder.Load(); err != nil {
+		return nil, err
+	}
+	if err := builder.Write(); err != nil {
+		return nil, err
+	}
+	return builder.Pages(), nil
+}
+
+// WriteIndex rewrites _index.html from the pages saved so far in this crawl
+// and returns them sorted by URL. Before the crawl starts it reads the
+// output directory instead.
+func (c *Crawler) WriteIndex() ([]PageEntry, error) {
+	if c.index == nil {
+		return BuildIndex(c.config.OutputDir)
+	}
+	if err := c.index.Write(); err != nil {
+		return nil, err
+	}
+	return c.index.Pages(), nil
+}
+
+// writeIndex renders the given pages to _index.html in the output directory
+func writeIndex(outputDir string, pages []PageEntry, perms outputPerms) error {
 	var totalSize int64
 	var earliest, latest time.Time
 
-	for _, metaPath := range metaFiles {
-		entry, err := loadPageEntry(outputDir, metaPath)
-		if err != nil {
-			continue // Skip files that can't be loaded
-		}
-		pages = append(pages, entry)
+	for _, entry := range pages {
 		totalSize += entry.Size
 
 		if earliest.IsZero() || entry.Timestamp.Before(earliest) {
@@ -102,9 +241,19 @@ func GenerateIndex(outputDir string) error {
 		LatestURL:   latest,
 	}
 
-	// Generate the index HTML
+	// Generate the index HTML, writing to a temp file first so readers
+	// never see a partially written index during a crawl
 	indexPath := filepath.Join(outputDir, "_index.html")
-	return writeIndexHTML(indexPath, data)
+	tmpPath := indexPath + ".tmp"
+	if err := writeIndexHTML(tmpPath, data); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := perms.applyFile(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, indexPath)
 }
 
 // scanMetaFiles recursively finds all .meta.json files in the directory
@@ -137,7 +286,7 @@ func loadPageEntry(outputDir, metaPath string) (PageEntry, error) {
 	}
 
 	// Calculate relative path for the HTML file
-	htmlPath := strings.TrimSuffix(metaPath, ".meta.json") + ".html"
+	htmlPath := strings.TrimSuffix(metaPath, ".meta.json") + ".html" + compressionExt(meta.Compression)
 	relPath, _ := filepath.Rel(outputDir, htmlPath)
 
 	// Calculate relative path for content file
@@ -153,6 +302,7 @@ func loadPageEntry(outputDir, metaPath string) (PageEntry, error) {
 
 	return PageEntry{
 		URL:         meta.URL,
+		Title:       meta.Title,
 		Filename:    relPath,
 		ContentFile: contentRelPath,
 		Excerpt:     excerpt,
@@ -160,12 +310,14 @@ func loadPageEntry(outputDir, metaPath string) (PageEntry, error) {
 		Size:        int64(meta.Size),
 		ContentSize: int64(meta.ContentSize),
 		HasContent:  (meta.ContentExtracted || (meta.ReadabilityExtracted != nil && *meta.ReadabilityExtracted)) && meta.ContentFile != "",
+		Blob:        filepath.FromSlash(meta.Blob),
+		ContentBlob: filepath.FromSlash(meta.ContentBlob),
 	}, nil
 }
 
 // extractExcerptFromFile reads a file and extracts a text excerpt
 func extractExcerptFromFile(path string, maxLen int) string {
-	data, err := os.ReadFile(path)
+	data, err := ReadOutputFile(path)
 	if err != nil {
 		return ""
 	}
@@ -537,15 +689,15 @@ const indexTemplate = `<!DOCTYPE html>
             <div class="page-card" data-url="{{.URL}}" data-excerpt="{{.Excerpt}}">
                 <div class="page-header">
                     <div class="page-title">
-                        <a href="{{.Filename}}">{{.Filename}}</a>
+                        <a href="{{.Href}}">{{.Filename}}</a>
                         <div class="page-url">
                             <a href="{{.URL}}" target="_blank" rel="noopener">{{.URL}}</a>
                         </div>
                     </div>
                     <div class="page-actions">
-                        <a href="{{.Filename}}" class="btn">Raw HTML</a>
+                        <a href="{{.Href}}" class="btn">Raw HTML</a>
                         {{if .HasContent}}
-                        <a href="{{.ContentFile}}" class="btn btn-primary">View Content</a>
+                        <a href="{{.ContentHref}}" class="btn btn-primary">View Content</a>
                         {{end}}
                     </div>
                 </div>
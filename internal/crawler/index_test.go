@@ -272,6 +272,45 @@ func TestGenerateIndexEmptyDirectory(t *testing.T) {
 	}
 }
 
+func TestBuildIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, p := range []struct{ filename, url, title string }{
+		{"b", "https://example.com/b", ""},
+		{"a", "https://example.com/a", "Page A"},
+	} {
+		basePath := filepath.Join(tmpDir, p.filename)
+		os.WriteFile(basePath+".html", []byte("<html></html>"), 0644)
+		os.WriteFile(basePath+".content.html", []byte("<p>Body of "+p.filename+"</p>"), 0644)
+		meta := metaFileData{
+			URL:              p.url,
+			Timestamp:        time.Now().Unix(),
+			Size:             13,
+			ContentFile:      p.filename + ".content.html",
+			ContentExtracted: true,
+			Title:            p.title,
+		}
+		metaJSON, _ := json.Marshal(meta)
+		os.WriteFile(basePath+".meta.json", metaJSON, 0644)
+	}
+
+	pages, err := BuildIndex(tmpDir)
+	if err != nil {
+		t.Fatalf("BuildIndex() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "_index.html")); err != nil {
+		t.Errorf("_index.html was not created: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("BuildIndex() returned %d pages, want 2", len(pages))
+	}
+	if pages[0].URL != "https://example.com/a" || pages[0].Title != "Page A" || pages[0].Filename != "a.html" || pages[0].Excerpt != "Body of a" {
+		t.Errorf("pages[0] = %+v", pages[0])
+	}
+	if pages[1].URL != "https://example.com/b" || pages[1].Title != "" || pages[1].ContentFile != "b.content.html" {
+		t.Errorf("pages[1] = %+v", pages[1])
+	}
+}
+
 func TestGenerateIndexWithMissingContentFile(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "index_test")
 	if err != nil {
@@ -304,3 +343,53 @@ func TestGenerateIndexWithMissingContentFile(t *testing.T) {
 		t.Error("_index.html should be created even with missing content files")
 	}
 }
+
+func TestIndexBuilderIncremental(t *testing.T) {
+	tmpDir := t.TempDir()
+	indexPath := filepath.Join(tmpDir, "_index.html")
+
+	// A page saved by a previous run should be picked up by Load
+	meta := metaFileData{URL: "https://example.com/old", Timestamp: time.Now().Unix(), Size: 10}
+	metaJSON, _ := json.Marshal(meta)
+	os.WriteFile(filepath.Join(tmpDir, "old.meta.json"), metaJSON, 0644)
+
+	builder := NewIndexBuilder(tmpDir, 2)
+	if err := builder.Load(); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if builder.Len() != 1 {
+		t.Errorf("Len() after Load = %d, want 1", builder.Len())
+	}
+
+	written, err := builder.Add(PageEntry{URL: "https://example.com/a", Filename: "a.html", Timestamp: time.Now()})
+	if err != nil || written {
+		t.Fatalf("first Add() = %v, %v; want no write", written, err)
+	}
+	if _, err := os.Stat(indexPath); !os.IsNotExist(err) {
+		t.Error("index should not be written before the interval is reached")
+	}
+
+	written, err = builder.Add(PageEntry{URL: "https://example.com/b", Filename: "b.html", Timestamp: time.Now()})
+	if err != nil || !written {
+		t.Fatalf("second Add() = %v, %v; want write", written, err)
+	}
+
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("index not written: %v", err)
+	}
+	for _, url := range []string{"https://example.com/old", "https://example.com/a", "https://example.com/b"} {
+		if !strings.Contains(string(content), url) {
+			t.Errorf("index missing %s", url)
+		}
+	}
+
+	// Re-adding the same file replaces rather than duplicates the entry
+	builder.Add(PageEntry{URL: "https://example.com/a", Filename: "a.html", Timestamp: time.Now()})
+	if builder.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", builder.Len())
+	}
+	if _, err := os.Stat(indexPath + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary index file should not be left behind")
+	}
+}
@@ -0,0 +1,344 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// URL inventory output files written to the output directory when a crawl ends
+const (
+	URLInventoryCSV   = "urls.csv"
+	URLInventoryJSONL = "urls.jsonl"
+)
+
+// Outcomes recorded for each URL in the inventory
+const (
+	URLStatusQueued  = "queued"  // Discovered but not fetched (crawl stopped or page limit reached)
+	URLStatusSaved   = "saved"   // Content saved to the output directory
+	URLStatusSkipped = "skipped" // Filtered out: depth limit, content type, size or no content
+	URLStatusError   = "error"   // Fetch, HTTP, parse or save failure
+	URLStatusBlocked = "blocked" // Disallowed by robots.txt
+)
+
+// URLRecord describes one URL encountered during a crawl
+type URLRecord struct {
+	URL         string `json:"url"`
+	Status      string `json:"status"`
+	Depth       int    `json:"depth"`
+	Referrer    string `json:"referrer,omitempty"` // Page the URL was first found on
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`                  // Response body size in bytes
+	HTTPStatus  int    `json:"http_status,omitempty"` // 0 when no response was received
+	Reason      string `json:"reason,omitempty"`      // Why the URL was skipped, blocked or errored
+	AnchorText  string `json:"anchor_text,omitempty"` // Text of the link on the referrer
+	Heading     string `json:"heading,omitempty"`     // Nearest heading before that link
+	Rel         string `json:"rel,omitempty"`         // rel attribute of that link
+}
+
+// urlInventory tracks every URL encountered in discovery order
+type urlInventory struct {
+	mu       sync.Mutex
+	records  map[string]*URLRecord
+	order    []string
+	version  uint64            // Incremented on every change
+	versions map[string]uint64 // Version of each URL's last change, for crawl map updates
+}
+
+// newURLInventory creates an empty inventory
+func newURLInventory() *urlInventory {
+	return &urlInventory{records: make(map[string]*URLRecord), versions: make(map[string]uint64)}
+}
+
+// get returns the record for rawURL, creating it if needed. Callers hold mu.
+func (inv *urlInventory) get(rawURL string, depth int) *URLRecord {
+	rec, ok := inv.records[rawURL]
+	if !ok {
+		rec = &URLRecord{URL: rawURL, Status: URLStatusQueued, Depth: depth}
+		inv.records[rawURL] = rec
+		inv.order = append(inv.order, rawURL)
+		inv.touch(rawURL)
+	}
+	return rec
+}
+
+// touch marks rawURL changed. Callers hold mu.
+func (inv *urlInventory) touch(rawURL string) {
+	inv.version++
+	inv.versions[rawURL] = inv.version
+}
+
+// Discover records a newly found URL. The first referrer wins.
+func (inv *urlInventory) Discover(rawURL string, depth int, referrer string) {
+	inv.DiscoverLink(rawURL, depth, referrer, LinkContext{})
+}
+
+// DiscoverLink records a URL found through a link on referrer, keeping the
+// context of the link alongside the first referrer
+func (inv *urlInventory) DiscoverLink(rawURL string, depth int, referrer string, link LinkContext) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	rec := inv.get(rawURL, depth)
+	if rec.Referrer == "" {
+		rec.Referrer = referrer
+		rec.AnchorText = link.Text
+		rec.Heading = link.Heading
+		rec.Rel = link.Rel
+		if referrer != "" {
+			inv.touch(rawURL)
+		}
+	}
+}
+
+// Record sets the outcome of a URL. result may be nil when nothing was fetched.
+func (inv *urlInventory) Record(rawURL string, depth int, status, reason string, result *FetchResult) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	rec := inv.get(rawURL, depth)
+	rec.Status = status
+	rec.Depth = depth
+	rec.Reason = reason
+	inv.touch(rawURL)
+	if result != nil {
+		rec.ContentType = result.ContentType
+		rec.Size = int64(len(result.Body))
+		rec.HTTPStatus = result.StatusCode
+	}
+}
+
+// Settle sets the outcome of a URL that is still queued, leaving URLs with
+// a recorded outcome untouched
+func (inv *urlInventory) Settle(rawURL, status, reason string) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if rec, ok := inv.records[rawURL]; ok && rec.Status == URLStatusQueued {
+		rec.Status = status
+		rec.Reason = reason
+		inv.touch(rawURL)
+	}
+}
+
+// Requeue sets an errored URL back to queued for a retry
+func (inv *urlInventory) Requeue(rawURL string) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if rec, ok := inv.records[rawURL]; ok && rec.Status == URLStatusError {
+		rec.Status = URLStatusQueued
+		rec.Reason = ""
+		rec.HTTPStatus = 0
+		inv.touch(rawURL)
+	}
+}
+
+// Depth returns the depth rawURL was recorded at
+func (inv *urlInventory) Depth(rawURL string) (int, bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if rec, ok := inv.records[rawURL]; ok {
+		return rec.Depth, true
+	}
+	return 0, false
+}
+
+// Referrer returns the page rawURL was first found on ("" for the start
+// URL and URLs not found through a link)
+func (inv *urlInventory) Referrer(rawURL string) string {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if rec, ok := inv.records[rawURL]; ok {
+		return rec.Referrer
+	}
+	return ""
+}
+
+// Load adds records from a previous run, so a resumed crawl's inventory
+// still lists the URLs handled before it was interrupted
+func (inv *urlInventory) Load(records []URLRecord) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	for _, r := range records {
+		rec := inv.get(r.URL, r.Depth)
+		*rec = r
+		inv.touch(r.URL)
+	}
+}
+
+// Records returns a copy of the inventory in discovery order
+func (inv *urlInventory) Records() []URLRecord {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	records := make([]URLRecord, 0, len(inv.order))
+	for _, u := range inv.order {
+		records = append(records, *inv.records[u])
+	}
+	return records
+}
+
+// URLInventory returns every URL encountered so far in this crawl
+func (c *Crawler) URLInventory() []URLRecord {
+	if c.inventory == nil {
+		return []URLRecord{}
+	}
+	return c.inventory.Records()
+}
+
+// recordURL sets the inventory outcome of a processed URL
+func (c *Crawler) recordURL(rawURL string, depth int, status, reason string, result *FetchResult) {
+	c.inventory.Record(rawURL, depth, status, reason, result)
+	c.metrics.RecordDepthOutcome(depth, status)
+	c.metrics.RecordResponse(rawURL, result)
+	if status == URLStatusError {
+		c.metrics.RecordError(rawURL, reason)
+		c.results.addError(rawURL, depth, reason, result)
+	}
+	c.traceOutcome(rawURL, depth, status, reason)
+	c.tracer.outcome(rawURL, status, reason)
+	c.trackErrors(status, result)
+}
+
+// recordPage sets the inventory outcome of one page of a paginated URL.
+// Later pages are listed under their virtual URL with the paginated URL as
+// referrer.
+func (c *Crawler) recordPage(rawURL, virtualURL string, depth int, status, reason string, result *FetchResult) {
+	if virtualURL != rawURL {
+		c.inventory.Discover(virtualURL, depth, rawURL)
+		c.metrics.IncrementDiscoveredAt(depth)
+	}
+	c.inventory.Record(virtualURL, depth, status, reason, result)
+	c.metrics.RecordDepthOutcome(depth, status)
+	c.metrics.RecordResponse(virtualURL, result)
+	if status == URLStatusError {
+		c.metrics.RecordError(virtualURL, reason)
+		c.results.addError(virtualURL, depth, reason, result)
+	}
+	c.traceOutcome(virtualURL, depth, status, reason)
+	c.trackErrors(status, result)
+}
+
+// writeURLInventory saves the inventory as CSV and JSON Lines in the output directory
+func (c *Crawler) writeURLInventory() error {
+	records := c.URLInventory()
+
+	f, err := c.perms.create(filepath.Join(c.config.OutputDir, URLInventoryJSONL))
+	if err != nil {
+		return fmt.Errorf("failed to write URL inventory: %v", err)
+	}
+	if err := WriteURLInventoryJSONL(f, records); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write URL inventory: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write URL inventory: %v", err)
+	}
+
+	f, err = c.perms.create(filepath.Join(c.config.OutputDir, URLInventoryCSV))
+	if err != nil {
+		return fmt.Errorf("failed to write URL inventory: %v", err)
+	}
+	defer f.Close()
+	return WriteURLInventoryCSV(f, records)
+}
+
+// WriteURLInventoryCSV writes URL records as CSV with a header row
+func WriteURLInventoryCSV(w io.Writer, records []URLRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"url", "status", "depth", "referrer", "content_type", "size", "http_status", "reason", "anchor_text", "heading", "rel"})
+	for _, r := range records {
+		httpStatus := ""
+		if r.HTTPStatus != 0 {
+			httpStatus = strconv.Itoa(r.HTTPStatus)
+		}
+		cw.Write([]string{
+			r.URL,
+			r.Status,
+			strconv.Itoa(r.Depth),
+			r.Referrer,
+			r.ContentType,
+			strconv.FormatInt(r.Size, 10),
+			httpStatus,
+			r.Reason,
+			r.AnchorText,
+			r.Heading,
+			r.Rel,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteURLInventoryJSONL writes URL records as one JSON object per line
+func WriteURLInventoryJSONL(w io.Writer, records []URLRecord) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadURLInventory reads the inventory written by a finished crawl
+func LoadURLInventory(outputDir string) ([]URLRecord, error) {
+	f, err := os.Open(filepath.Join(outputDir, URLInventoryJSONL))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []URLRecord{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r URLRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("failed to parse URL inventory: %v", err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read URL inventory: %v", err)
+	}
+	return records, nil
+}
+
+// FilterURLRecords returns the records with the given status ("" keeps all)
+func FilterURLRecords(records []URLRecord, status string) []URLRecord {
+	if status == "" {
+		return records
+	}
+	filtered := []URLRecord{}
+	for _, r := range records {
+		if r.Status == status {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// CountURLStatuses returns the number of records per status
+func CountURLStatuses(records []URLRecord) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range records {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// ValidURLStatus reports whether s is a known inventory status
+func ValidURLStatus(s string) bool {
+	switch s {
+	case URLStatusQueued, URLStatusSaved, URLStatusSkipped, URLStatusError, URLStatusBlocked:
+		return true
+	}
+	return false
+}
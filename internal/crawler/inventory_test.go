@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestURLInventory(t *testing.T) {
+	inv := newURLInventory()
+	inv.Discover("https://example.com/", 0, "")
+	inv.Discover("https://example.com/a", 1, "https://example.com/")
+	inv.Discover("https://example.com/a", 1, "https://example.com/b") // first referrer wins
+	inv.Record("https://example.com/", 0, URLStatusSaved, "", &FetchResult{StatusCode: 200, ContentType: "text/html", Body: []byte("hello")})
+	inv.Record("https://example.com/a", 1, URLStatusError, "HTTP 404", &FetchResult{StatusCode: 404})
+
+	records := inv.Records()
+	if len(records) != 2 {
+		t.Fatalf("Records() = %d, want 2", len(records))
+	}
+	want := []URLRecord{
+		{URL: "https://example.com/", Status: URLStatusSaved, Depth: 0, ContentType: "text/html", Size: 5, HTTPStatus: 200},
+		{URL: "https://example.com/a", Status: URLStatusError, Depth: 1, Referrer: "https://example.com/", HTTPStatus: 404, Reason: "HTTP 404"},
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+
+	counts := CountURLStatuses(records)
+	if counts[URLStatusSaved] != 1 || counts[URLStatusError] != 1 {
+		t.Errorf("CountURLStatuses() = %v", counts)
+	}
+	if got := FilterURLRecords(records, URLStatusError); len(got) != 1 || got[0].URL != "https://example.com/a" {
+		t.Errorf("FilterURLRecords(error) = %+v", got)
+	}
+}
+
+func TestURLInventoryFiles(t *testing.T) {
+	outputDir := t.TempDir()
+	c := &Crawler{config: Config{OutputDir: outputDir}, inventory: newURLInventory()}
+	c.inventory.Discover("https://example.com/", 0, "")
+	c.inventory.Discover("https://example.com/x,y", 1, "https://example.com/")
+	c.recordURL("https://example.com/", 0, URLStatusSaved, "", &FetchResult{StatusCode: 200, ContentType: "text/html", Body: []byte("abc")})
+	c.recordURL("https://example.com/x,y", 1, URLStatusBlocked, "robots.txt", nil)
+
+	if err := c.writeURLInventory(); err != nil {
+		t.Fatalf("writeURLInventory() error = %v", err)
+	}
+
+	records, err := LoadURLInventory(outputDir)
+	if err != nil {
+		t.Fatalf("LoadURLInventory() error = %v", err)
+	}
+	if len(records) != 2 || records[1].Status != URLStatusBlocked || records[1].Referrer != "https://example.com/" {
+		t.Errorf("loaded records = %+v", records)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteURLInventoryCSV(&buf, records); err != nil {
+		t.Fatalf("WriteURLInventoryCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("CSV has %d lines, want 3", len(lines))
+	}
+	if lines[0] != "url,status,depth,referrer,content_type,size,http_status,reason,anchor_text,heading,rel" {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	if lines[2] != `"https://example.com/x,y",blocked,1,https://example.com/,,0,,robots.txt,,,` {
+		t.Errorf("unexpected row: %s", lines[2])
+	}
+
+	if _, err := LoadURLInventory(filepath.Join(outputDir, "missing")); err == nil {
+		t.Error("LoadURLInventory() on missing dir should fail")
+	}
+}
+
+func TestCrawlWritesURLInventory(t *testing.T) {
+	body := strings.Repeat("Some meaningful content for the page. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p><a href="/missing">m</a><a href="/private">p</a></body></html>`, body)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		NormalizeURLs:    true,
+	}
+	c, err := NewCrawler(config, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	records, err := LoadURLInventory(outputDir)
+	if err != nil {
+		t.Fatalf("LoadURLInventory() error = %v", err)
+	}
+	byURL := make(map[string]URLRecord)
+	for _, r := range records {
+		byURL[r.URL] = r
+	}
+
+	root := byURL[server.URL+"/"]
+	if root.Status != URLStatusSaved || root.HTTPStatus != 200 || root.Size == 0 || !strings.HasPrefix(root.ContentType, "text/html") {
+		t.Errorf("root record = %+v", root)
+	}
+	if r := byURL[server.URL+"/missing"]; r.Status != URLStatusError || r.HTTPStatus != 404 || r.Referrer != server.URL+"/" {
+		t.Errorf("missing record = %+v", r)
+	}
+	if r := byURL[server.URL+"/private"]; r.Status != URLStatusBlocked {
+		t.Errorf("private record = %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, URLInventoryCSV)); err != nil {
+		t.Errorf("%s not written", URLInventoryCSV)
+	}
+}
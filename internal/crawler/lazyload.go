@@ -0,0 +1,115 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultScrollDelay is the pause after each lazy-load scroll step
+const DefaultScrollDelay = 250 * time.Millisecond
+
+// MaxScrollSteps bounds the lazy-load scroll of one page, so pages that keep
+// growing as they are scrolled (infinite scroll) are captured after a while
+const MaxScrollSteps = 50
+
+// lazyImageWait bounds the wait for images still loading once the page has
+// been scrolled through
+const lazyImageWait = 3 * time.Second
+
+// scrollStepScript scrolls one step down, the given pixels or one viewport
+// for 0, and reports whether the bottom of the page was reached
+const scrollStepScript = `(() => {
+  window.scrollBy(0, %d || window.innerHeight);
+  const page = document.scrollingElement || document.documentElement;
+  return window.scrollY + window.innerHeight >= page.scrollHeight - 2;
+})()`
+
+// awaitImagesScript waits until the images of the page have loaded or
+// failed, at most the given milliseconds, and returns how many are pending
+const awaitImagesScript = `new Promise(resolve => {
+  let pending = Array.from(document.images).filter(img => !img.complete).length;
+  if (pending === 0) { resolve(0); return; }
+  const done = () => { if (--pending === 0) resolve(0); };
+  for (const img of document.images) {
+    if (!img.complete) {
+      img.addEventListener('load', done, { once: true });
+      img.addEventListener('error', done, { once: true });
+    }
+  }
+  setTimeout(() => resolve(pending), %d);
+})`
+
+// validateLazyLoad checks the lazy-load scroll settings
+func validateLazyLoad(config *Config) error {
+	if config.ScrollStep < 0 {
+		return fmt.Errorf("scroll step must not be negative, got: %d", config.ScrollStep)
+	}
+	if config.ScrollDelay < 0 {
+		return fmt.Errorf("scroll delay must not be negative, got: %v", config.ScrollDelay)
+	}
+	if !config.LazyLoadScroll {
+		if config.ScrollStep > 0 || config.ScrollDelay > 0 {
+			return fmt.Errorf("scroll step and delay require lazy-load scrolling to be enabled")
+		}
+		return nil
+	}
+	if config.FetchMode != FetchModeBrowser {
+		return fmt.Errorf("lazy-load scrolling requires browser fetch mode")
+	}
+	return nil
+}
+
+// lazyLoadScroll is how the browser fetcher scrolls through pages
+type lazyLoadScroll struct {
+	step  int           // Pixels per step, 0 = one viewport
+	delay time.Duration // Pause after each step
+}
+
+// SetLazyLoadScroll makes the fetcher scroll through every page before
+// capture, step pixels at a time (0 = one viewport) with a pause of delay
+// after each step (0 = DefaultScrollDelay), so lazy-loaded images and
+// content are in the saved HTML. It must be called before the first fetch.
+func (f *BrowserFetcher) SetLazyLoadScroll(step int, delay time.Duration) {
+	if delay <= 0 {
+		delay = DefaultScrollDelay
+	}
+	f.lazyLoad = &lazyLoadScroll{step: step, delay: delay}
+}
+
+// lazyLoadActions scroll down to the bottom of the page, at most
+// MaxScrollSteps steps, back to the top and wait for the images still
+// loading. A failing scroll does not fail the fetch.
+func (f *BrowserFetcher) lazyLoadActions() []chromedp.Action {
+	if f.lazyLoad == nil {
+		return nil
+	}
+	s := *f.lazyLoad
+	return []chromedp.Action{chromedp.ActionFunc(func(ctx context.Context) error {
+		for i := 0; i < MaxScrollSteps; i++ {
+			var bottom bool
+			if err := chromedp.Evaluate(fmt.Sprintf(scrollStepScript, s.step), &bottom).Do(ctx); err != nil {
+				return nil
+			}
+			if err := chromedp.Sleep(s.delay).Do(ctx); err != nil {
+				return err
+			}
+			if bottom {
+				break
+			}
+		}
+		if err := chromedp.Evaluate(`window.scrollTo(0, 0)`, nil).Do(ctx); err != nil {
+			return nil
+		}
+		var pending int
+		chromedp.Evaluate(fmt.Sprintf(awaitImagesScript, lazyImageWait.Milliseconds()), &pending,
+			func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+				return p.WithAwaitPromise(true)
+			},
+		).Do(ctx)
+		return nil
+	})}
+}
@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateLazyLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"off", Config{}, ""},
+		{"browser defaults", Config{FetchMode: FetchModeBrowser, LazyLoadScroll: true}, ""},
+		{"browser custom", Config{FetchMode: FetchModeBrowser, LazyLoadScroll: true, ScrollStep: 400, ScrollDelay: time.Second}, ""},
+		{"http mode", Config{FetchMode: FetchModeHTTP, LazyLoadScroll: true}, "requires browser"},
+		{"negative step", Config{FetchMode: FetchModeBrowser, LazyLoadScroll: true, ScrollStep: -1}, "must not be negative"},
+		{"negative delay", Config{FetchMode: FetchModeBrowser, LazyLoadScroll: true, ScrollDelay: -time.Second}, "must not be negative"},
+		{"step without scrolling", Config{FetchMode: FetchModeBrowser, ScrollStep: 400}, "require lazy-load"},
+	}
+	for _, tt := range tests {
+		err := validateLazyLoad(&tt.config)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetLazyLoadScroll(t *testing.T) {
+	f := &BrowserFetcher{}
+	if actions := f.lazyLoadActions(); actions != nil {
+		t.Errorf("lazy-load actions without scrolling = %v, want none", actions)
+	}
+
+	f.SetLazyLoadScroll(0, 0)
+	if f.lazyLoad.step != 0 || f.lazyLoad.delay != DefaultScrollDelay {
+		t.Errorf("defaults = %+v, want one viewport and %v", *f.lazyLoad, DefaultScrollDelay)
+	}
+	f.SetLazyLoadScroll(600, time.Second)
+	if f.lazyLoad.step != 600 || f.lazyLoad.delay != time.Second {
+		t.Errorf("settings = %+v, want 600px and 1s", *f.lazyLoad)
+	}
+	if actions := f.lazyLoadActions(); len(actions) != 1 {
+		t.Errorf("lazy-load actions = %d, want 1", len(actions))
+	}
+}
@@ -0,0 +1,53 @@
+package crawler
+
+import "sync"
+
+// workerLimiter bounds the number of in-flight requests in concurrent mode.
+// Unlike a buffered channel, its limit can be changed while the crawl runs.
+type workerLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+// newWorkerLimiter creates a limiter allowing up to limit concurrent holders
+func newWorkerLimiter(limit int) *workerLimiter {
+	l := &workerLimiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Acquire blocks until a worker slot is free
+func (l *workerLimiter) Acquire() {
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+// Release frees a worker slot
+func (l *workerLimiter) Release() {
+	l.mu.Lock()
+	l.active--
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// SetLimit changes the number of allowed workers. Lowering the limit does not
+// interrupt in-flight requests; new ones wait until enough of them finish.
+func (l *workerLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// Limit returns the current worker limit
+func (l *workerLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
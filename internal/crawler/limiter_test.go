@@ -0,0 +1,89 @@
+package crawler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerLimiter(t *testing.T) {
+	l := newWorkerLimiter(2)
+	l.Acquire()
+	l.Acquire()
+
+	var acquired atomic.Bool
+	go func() {
+		l.Acquire()
+		acquired.Store(true)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if acquired.Load() {
+		t.Fatal("Acquire() should block while the limit is reached")
+	}
+
+	// Raising the limit lets the waiting worker through
+	l.SetLimit(3)
+	deadline := time.Now().Add(time.Second)
+	for !acquired.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !acquired.Load() {
+		t.Fatal("Acquire() should proceed after SetLimit raises the limit")
+	}
+
+	// Lowering the limit holds new workers until enough have released
+	l.SetLimit(1)
+	acquired.Store(false)
+	go func() {
+		l.Acquire()
+		acquired.Store(true)
+	}()
+	l.Release()
+	l.Release()
+	time.Sleep(20 * time.Millisecond)
+	if acquired.Load() {
+		t.Fatal("Acquire() should block until active workers drop below the new limit")
+	}
+	l.Release()
+	deadline = time.Now().Add(time.Second)
+	for !acquired.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !acquired.Load() {
+		t.Fatal("Acquire() should proceed once a slot frees up")
+	}
+	if l.Limit() != 1 {
+		t.Errorf("Limit() = %d, want 1", l.Limit())
+	}
+}
+
+func TestSetWorkers(t *testing.T) {
+	c, err := NewCrawler(Config{URL: "https://example.com", MaxDepth: 1, Concurrent: true}, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+
+	if c.Workers() != DefaultWorkers {
+		t.Errorf("Workers() = %d, want %d", c.Workers(), DefaultWorkers)
+	}
+	if err := c.SetWorkers(3); err != nil || c.Workers() != 3 {
+		t.Errorf("SetWorkers(3) = %v, Workers() = %d", err, c.Workers())
+	}
+	if err := c.SetWorkers(0); err == nil {
+		t.Error("SetWorkers(0) should fail")
+	}
+	if err := c.SetWorkers(MaxWorkers + 1); err == nil {
+		t.Error("SetWorkers above MaxWorkers should fail")
+	}
+
+	sequential, err := NewCrawler(Config{URL: "https://example.com", MaxDepth: 1}, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer sequential.Close()
+	if err := sequential.SetWorkers(2); err == nil {
+		t.Error("SetWorkers should fail for sequential crawls")
+	}
+}
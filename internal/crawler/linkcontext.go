@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// LinkContext describes the link a URL was found through, so analysis can
+// use what pages say about a URL and not just the bare address
+type LinkContext struct {
+	Text    string // Anchor text, or the aria-label, title or image alt of a link without text
+	Heading string // Text of the nearest heading before the link
+	Rel     string // rel attribute, e.g. "nofollow" or "next"
+}
+
+// linkHeadings maps every element with an href attribute to the text of
+// the last h1-h6 that starts before it in document order. A link inside a
+// heading gets that heading.
+func linkHeadings(root *html.Node) map[*html.Node]string {
+	headings := make(map[*html.Node]string)
+	current := ""
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				current = collapseSpace(goquery.NewDocumentFromNode(n).Text())
+			case "script", "style":
+				return
+			}
+			for _, attr := range n.Attr {
+				if attr.Key == "href" {
+					headings[n] = current
+					break
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+	return headings
+}
+
+// linkContext returns the context of the link element s, taking its heading
+// from the map built by linkHeadings
+func linkContext(s *goquery.Selection, headings map[*html.Node]string) LinkContext {
+	link := LinkContext{Text: collapseSpace(s.Text())}
+	if link.Text == "" {
+		for _, alt := range []string{s.AttrOr("aria-label", ""), s.AttrOr("title", ""), s.Find("img[alt]").First().AttrOr("alt", "")} {
+			if alt = collapseSpace(alt); alt != "" {
+				link.Text = alt
+				break
+			}
+		}
+	}
+	if len(s.Nodes) > 0 {
+		link.Heading = headings[s.Nodes[0]]
+	}
+	link.Rel = strings.ToLower(collapseSpace(s.AttrOr("rel", "")))
+	return link
+}
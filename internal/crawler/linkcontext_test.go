@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestLinkContext(t *testing.T) {
+	page, err := NewPageDocument("https://example.com/", []byte(`<html><body>
+		<a href="/top">Top   of
+			page</a>
+		<h1>Guide</h1>
+		<section>
+			<h2>Install <small>v2</small></h2>
+			<p>See <a href="/download" rel="NoFollow  sponsored">the download page</a>.</p>
+			<script>var h = "<h3>not a heading</h3>";</script>
+			<a href="/icon"><img src="i.png" alt="Settings icon"></a>
+		</section>
+		<h3><a href="/faq" title="ignored">FAQ</a></h3>
+		<a href="/next" aria-label="Next page" rel="next"></a>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("NewPageDocument() error = %v", err)
+	}
+	headings := linkHeadings(page.Root())
+
+	want := map[string]LinkContext{
+		"/top":      {Text: "Top of page"},
+		"/download": {Text: "the download page", Heading: "Install v2", Rel: "nofollow sponsored"},
+		"/icon":     {Text: "Settings icon", Heading: "Install v2"},
+		"/faq":      {Text: "FAQ", Heading: "FAQ"},
+		"/next":     {Text: "Next page", Heading: "FAQ", Rel: "next"},
+	}
+	page.Doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		if got := linkContext(s, headings); got != want[href] {
+			t.Errorf("linkContext(%s) = %+v, want %+v", href, got, want[href])
+		}
+	})
+}
+
+func TestURLInventoryDiscoverLink(t *testing.T) {
+	inv := newURLInventory()
+	inv.DiscoverLink("https://example.com/a", 1, "https://example.com/", LinkContext{Text: "A", Heading: "Intro", Rel: "nofollow"})
+	inv.DiscoverLink("https://example.com/a", 2, "https://example.com/b", LinkContext{Text: "Other"})
+
+	records := inv.Records()
+	if len(records) != 1 {
+		t.Fatalf("Records() returned %d records, want 1", len(records))
+	}
+	r := records[0]
+	if r.Referrer != "https://example.com/" || r.AnchorText != "A" || r.Heading != "Intro" || r.Rel != "nofollow" {
+		t.Errorf("first link should win, got %+v", r)
+	}
+}
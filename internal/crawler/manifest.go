@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ManifestFile lists the saved pages in the order they were saved, one JSON
+// object per line, appended to as the crawl goes
+const ManifestFile = "pages.ndjson"
+
+// ManifestEntry is one line of the page manifest: a saved page
+type ManifestEntry struct {
+	URL         string    `json:"url"`
+	Path        string    `json:"path"`                   // Stored HTML file, relative to the output directory, slash-separated
+	ContentPath string    `json:"content_path,omitempty"` // Stored .content.html file, if any
+	Hash        string    `json:"hash"`                   // SHA-256 of the stored HTML, uncompressed
+	Size        int64     `json:"size"`
+	Title       string    `json:"title,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// ManifestChunk is the part of a manifest read from a byte offset
+type ManifestChunk struct {
+	Pages      []ManifestEntry `json:"pages"`
+	NextOffset int64           `json:"nextOffset"` // Offset to read the pages saved after these from
+}
+
+// pageManifest appends saved pages to ManifestFile. Each line is written
+// with a single write, so readers tailing the file only ever miss the end
+// of the line being written.
+type pageManifest struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openPageManifest creates the manifest, or appends to it when a crawl is
+// resumed or re-crawls URLs into its output directory
+func openPageManifest(outputDir string, appendTo bool, perms outputPerms) (*pageManifest, error) {
+	path := filepath.Join(outputDir, ManifestFile)
+	var f *os.File
+	var err error
+	if appendTo {
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			err = perms.applyFile(path)
+		}
+	} else {
+		f, err = perms.create(path)
+	}
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, fmt.Errorf("failed to open page manifest: %v", err)
+	}
+	return &pageManifest{file: f}, nil
+}
+
+// write appends one saved page
+func (m *pageManifest) write(entry ManifestEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the manifest file
+func (m *pageManifest) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.file.Close()
+}
+
+// newManifestEntry describes a saved page whose stored HTML is stored
+func newManifestEntry(entry PageEntry, stored []byte) ManifestEntry {
+	sum := sha256.Sum256(stored)
+	return ManifestEntry{
+		URL:         entry.URL,
+		Path:        filepath.ToSlash(entry.Filename),
+		ContentPath: filepath.ToSlash(entry.ContentFile),
+		Hash:        hex.EncodeToString(sum[:]),
+		Size:        entry.Size,
+		Title:       entry.Title,
+		Timestamp:   entry.Timestamp,
+	}
+}
+
+// recordManifest appends a saved page to the manifest
+func (c *Crawler) recordManifest(entry ManifestEntry) {
+	if c.manifest == nil {
+		return
+	}
+	if err := c.manifest.write(entry); err != nil {
+		c.log.Warn("Failed to write page manifest: %v", err)
+	}
+}
+
+// ReadManifestLines returns the complete lines of the manifest of
+// outputDir from byte offset on, and the offset after them. A line still
+// being written is left for the next read. A missing manifest reads as
+// empty, as it is only created when the crawl starts. An offset past the
+// end is an error: the manifest was started afresh by a new crawl.
+func ReadManifestLines(outputDir string, offset int64) ([]byte, int64, error) {
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("offset must not be negative")
+	}
+	f, err := os.Open(filepath.Join(outputDir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, offset, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		return nil, 0, err
+	} else if offset > info.Size() {
+		return nil, 0, fmt.Errorf("offset %d is past the end of the manifest (%d bytes); a new crawl may have started it afresh", offset, info.Size())
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	end := bytes.LastIndexByte(data, '\n') + 1
+	return data[:end], offset + int64(end), nil
+}
+
+// ReadManifest returns the pages of the manifest of outputDir saved from
+// byte offset on
+func ReadManifest(outputDir string, offset int64) (*ManifestChunk, error) {
+	data, next, err := ReadManifestLines(outputDir, offset)
+	if err != nil {
+		return nil, err
+	}
+	pages, err := ParseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	return &ManifestChunk{Pages: pages, NextOffset: next}, nil
+}
+
+// ParseManifest parses manifest lines, skipping blank ones
+func ParseManifest(data []byte) ([]ManifestEntry, error) {
+	pages := []ManifestEntry{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry ManifestEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("invalid manifest line: %v", err)
+		}
+		pages = append(pages, entry)
+	}
+	return pages, nil
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifestCrawl(t *testing.T) {
+	text := strings.Repeat("Manifest test content. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			fmt.Fprintf(w, `<html><head><title>Home</title></head><body><p>%s</p><a href="/docs">Docs</a></body></html>`, text)
+			return
+		}
+		fmt.Fprintf(w, `<html><head><title>Docs</title></head><body><p>%s</p></body></html>`, text)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		CompressOutput:   CompressionGzip,
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	chunk, err := ReadManifest(outputDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunk.Pages) != 2 {
+		t.Fatalf("manifest has %d pages, want 2: %+v", len(chunk.Pages), chunk.Pages)
+	}
+	info, _ := os.Stat(filepath.Join(outputDir, ManifestFile))
+	if chunk.NextOffset != info.Size() {
+		t.Errorf("NextOffset = %d, want the manifest size %d", chunk.NextOffset, info.Size())
+	}
+	for _, p := range chunk.Pages {
+		stored, err := ReadOutputFile(filepath.Join(outputDir, filepath.FromSlash(p.Path)))
+		if err != nil {
+			t.Errorf("%s: path %s not readable: %v", p.URL, p.Path, err)
+			continue
+		}
+		sum := sha256.Sum256(stored)
+		if p.Hash != hex.EncodeToString(sum[:]) || p.Size != int64(len(stored)) || p.Title == "" || p.Timestamp.IsZero() {
+			t.Errorf("%s: wrong manifest entry %+v", p.URL, p)
+		}
+		if !strings.HasSuffix(p.Path, ".html.gz") || !strings.HasSuffix(p.ContentPath, ".content.html.gz") {
+			t.Errorf("%s: paths should name the stored files: %q, %q", p.URL, p.Path, p.ContentPath)
+		}
+	}
+
+	rest, err := ReadManifest(outputDir, chunk.NextOffset)
+	if err != nil || len(rest.Pages) != 0 || rest.NextOffset != chunk.NextOffset {
+		t.Errorf("reading from the end = %+v, %v; want no pages at the same offset", rest, err)
+	}
+}
+
+func TestReadManifestLines(t *testing.T) {
+	dir := t.TempDir()
+	if data, next, err := ReadManifestLines(dir, 0); err != nil || len(data) != 0 || next != 0 {
+		t.Errorf("missing manifest = %q, %d, %v; want empty", data, next, err)
+	}
+
+	// The second line is still being written
+	content := `{"url":"https://example.com/a"}` + "\n" + `{"url":"https://exa`
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, next, err := ReadManifestLines(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"url":"https://example.com/a"}`+"\n" || next != int64(len(data)) {
+		t.Errorf("ReadManifestLines() = %q, %d; want the complete line only", data, next)
+	}
+	if data, again, _ := ReadManifestLines(dir, next); len(data) != 0 || again != next {
+		t.Errorf("partial line returned early: %q, %d", data, again)
+	}
+	if _, _, err := ReadManifestLines(dir, -1); err == nil {
+		t.Error("negative offset should be rejected")
+	}
+	if _, _, err := ReadManifestLines(dir, int64(len(content)+1)); err == nil {
+		t.Error("offset past the end should be rejected")
+	}
+}
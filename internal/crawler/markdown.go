@@ -0,0 +1,312 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// markdownBlocks are the elements rendered as blocks of their own; anything
+// else is inline
+var markdownBlocks = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"dd": true, "details": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "html": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "summary": true, "table": true,
+	"tbody": true, "td": true, "tfoot": true, "th": true, "thead": true, "tr": true,
+	"ul": true,
+}
+
+// markdownSkipped are the elements left out of Markdown entirely
+var markdownSkipped = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true,
+	"iframe": true, "svg": true, "button": true, "select": true, "textarea": true,
+}
+
+var (
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+	nestedListGap   = regexp.MustCompile(`\n\n(- |\d+\. )`)
+	whitespaceRun   = regexp.MustCompile(`\s+`)
+)
+
+// HTMLToMarkdown renders the HTML under n as Markdown: headings, paragraphs,
+// lists, block quotes, code, tables, links, images and emphasis. Relative
+// links and image sources are resolved against base when it is not nil.
+func HTMLToMarkdown(n *html.Node, base *url.URL) string {
+	w := &markdownWriter{base: base}
+	md := w.blocks(n)
+	md = blankLinesRegex.ReplaceAllString(md, "\n\n")
+	return strings.TrimSpace(md) + "\n"
+}
+
+// markdownWriter converts a node tree to Markdown
+type markdownWriter struct {
+	base *url.URL
+}
+
+// blocks renders the children of n as a sequence of blocks, each followed
+// by a blank line. Runs of inline content become paragraphs.
+func (w *markdownWriter) blocks(n *html.Node) string {
+	var out, inline strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(inline.String()); text != "" {
+			out.WriteString(text)
+			out.WriteString("\n\n")
+		}
+		inline.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && markdownBlocks[child.Data] {
+			flush()
+			out.WriteString(w.block(child))
+			continue
+		}
+		inline.WriteString(w.inline(child))
+	}
+	flush()
+	return out.String()
+}
+
+// block renders one block element
+func (w *markdownWriter) block(n *html.Node) string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		text := strings.TrimSpace(w.inlineChildren(n))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", level) + " " + text + "\n\n"
+	case "hr":
+		return "---\n\n"
+	case "pre":
+		code := strings.Trim(nodeText(n), "\n")
+		return "```\n" + code + "\n```\n\n"
+	case "blockquote":
+		inner := strings.TrimSpace(w.blocks(n))
+		if inner == "" {
+			return ""
+		}
+		return prefixLines(inner, "> ", "> ") + "\n\n"
+	case "ul", "ol":
+		return w.list(n) + "\n"
+	case "table":
+		return w.table(n)
+	default:
+		return w.blocks(n)
+	}
+}
+
+// list renders the items of a ul or ol, indenting their continuation lines
+// and nested lists under the marker
+func (w *markdownWriter) list(n *html.Node) string {
+	var out strings.Builder
+	number := 1
+	if start, err := strconv.Atoi(attrValue(n, "start")); err == nil {
+		number = start
+	}
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		content := strings.TrimSpace(blankLinesRegex.ReplaceAllString(w.blocks(item), "\n\n"))
+		// Keep tight lists tight: a nested list follows its item directly
+		content = nestedListGap.ReplaceAllString(content, "\n$1")
+		out.WriteString(prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// table renders a table as a Markdown pipe table, the first row being the
+// header
+func (w *markdownWriter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := collapseSpace(w.inlineChildren(cell))
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			case "thead", "tbody", "tfoot":
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	var out strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			out.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return out.String() + "\n"
+}
+
+// inline renders an inline node
+func (w *markdownWriter) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespaceRun.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if markdownSkipped[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "  \n"
+	case "img":
+		src := w.resolve(attrValue(n, "src"))
+		if src == "" {
+			return ""
+		}
+		return "![" + collapseSpace(attrValue(n, "alt")) + "](" + src + ")"
+	case "code", "kbd", "samp":
+		code := nodeText(n)
+		if code == "" {
+			return ""
+		}
+		return "`" + code + "`"
+	}
+
+	text := w.inlineChildren(n)
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+	switch n.Data {
+	case "a":
+		href := w.resolve(attrValue(n, "href"))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return text
+		}
+		return wrapInline(text, "[", "]("+href+")")
+	case "strong", "b":
+		return wrapInline(text, "**", "**")
+	case "em", "i":
+		return wrapInline(text, "*", "*")
+	case "del", "s", "strike":
+		return wrapInline(text, "~~", "~~")
+	}
+	return text
+}
+
+// inlineChildren renders the children of n as inline content, block
+// children included
+func (w *markdownWriter) inlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && markdownBlocks[child.Data] {
+			sb.WriteString(" " + w.inlineChildren(child) + " ")
+			continue
+		}
+		sb.WriteString(w.inline(child))
+	}
+	return sb.String()
+}
+
+// resolve makes a link target absolute against the base URL
+func (w *markdownWriter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || w.base == nil {
+		return ref
+	}
+	u, err := w.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// wrapInline surrounds text with open and close, keeping its leading and
+// trailing spaces outside so the markup stays valid
+func wrapInline(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + open + trimmed + close + trail
+}
+
+// prefixLines prefixes the first line of text with first and the others
+// with rest, leaving blank lines unindented
+func prefixLines(text, first, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" && i > 0 {
+			lines[i] = strings.TrimRight(prefix, " ")
+			continue
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// nodeText returns the raw text under n, whitespace preserved
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		if n.Type == html.ElementNode && n.Data == "br" {
+			sb.WriteString("\n")
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// attrValue returns the value of the attribute key of n, or ""
+func attrValue(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTMLToMarkdown(t *testing.T) {
+	page, err := NewPageDocument("https://example.com/docs/", []byte(`<html><head><title>T</title></head><body>
+		<h1>Getting  started</h1>
+		<p>Install the <strong>CLI</strong> with <code>go install</code>, then read
+		the <a href="guide">guide</a>.<script>ignored()</script></p>
+		<ul>
+			<li>First</li>
+			<li>Second
+				<ol start="3"><li>Nested</li></ol>
+			</li>
+		</ul>
+		<blockquote><p>Quoted <em>text</em></p><p>More</p></blockquote>
+		<pre><code>line 1
+  line 2</code></pre>
+		<table><tr><th>Flag</th><th>Use</th></tr><tr><td>-v</td><td>a|b</td></tr></table>
+		<p><img src="/logo.png" alt="Logo"><br>After break</p>
+		<hr>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("NewPageDocument() error = %v", err)
+	}
+	base, _ := url.Parse(page.URL)
+
+	want := "# Getting started\n\n" +
+		"Install the **CLI** with `go install`, then read the [guide](https://example.com/docs/guide).\n\n" +
+		"- First\n" +
+		"- Second\n" +
+		"  3. Nested\n\n" +
+		"> Quoted *text*\n>\n> More\n\n" +
+		"```\nline 1\n  line 2\n```\n\n" +
+		"| Flag | Use |\n| --- | --- |\n| -v | a\\|b |\n\n" +
+		"![Logo](https://example.com/logo.png)  \nAfter break\n\n" +
+		"---\n"
+	if got := HTMLToMarkdown(page.Doc.Find("body").Get(0), base); got != want {
+		t.Errorf("HTMLToMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
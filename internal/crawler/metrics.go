@@ -4,30 +4,55 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
 
 // CrawlerMetrics tracks statistics during crawling
 type CrawlerMetrics struct {
-	StartTime        time.Time `json:"start_time"`
-	EndTime          time.Time `json:"end_time,omitempty"`
-	Duration         float64   `json:"duration_seconds,omitempty"`
-	URLsProcessed    int64     `json:"urls_processed"`
-	URLsSaved        int64     `json:"urls_saved"`
-	URLsSkipped      int64     `json:"urls_skipped"`
-	URLsErrored      int64     `json:"urls_errored"`
-	BytesDownloaded  int64     `json:"bytes_downloaded"`
-	RobotsBlocked    int64     `json:"robots_blocked"`
-	DepthLimitHits   int64     `json:"depth_limit_hits"`
-	ContentFiltered  int64     `json:"content_filtered"`
-	PagesPerSecond   float64   `json:"pages_per_second,omitempty"`
-	QueueSize        int       `json:"queue_size"`
+	StartTime        time.Time     `json:"start_time"`
+	EndTime          time.Time     `json:"end_time,omitempty"`
+	Duration         float64       `json:"duration_seconds,omitempty"`
+	URLsProcessed    int64         `json:"urls_processed"`
+	URLsSaved        int64         `json:"urls_saved"`
+	URLsSkipped      int64         `json:"urls_skipped"`
+	URLsErrored      int64         `json:"urls_errored"`
+	BytesDownloaded  int64         `json:"bytes_downloaded"`
+	RobotsBlocked    int64         `json:"robots_blocked"`
+	DepthLimitHits   int64         `json:"depth_limit_hits"`
+	ContentFiltered  int64         `json:"content_filtered"`
+	FollowOnly       int64         `json:"follow_only"`      // Pages whose links were followed without saving them
+	Redirected       int64         `json:"redirected"`       // Fetches that followed at least one redirect
+	RedirectErrors   int64         `json:"redirect_errors"`  // Redirect loops and chains longer than MaxRedirects
+	TimedOut         int64         `json:"timed_out"`        // URLs given up at URLTimeout, or whose worker hung past it
+	BrowserRecycles  int64         `json:"browser_recycles"` // Browser relaunches after BrowserRecycle pages
+	BrowserCrashes   int64         `json:"browser_crashes"`  // Browser relaunches after a tab or the browser crashed
+	PagesPerSecond   float64       `json:"pages_per_second,omitempty"`
+	QueueSize        int           `json:"queue_size"`
+	HeapAlloc        int64         `json:"heap_alloc_bytes"`      // Live heap at the last sample
+	PeakHeapAlloc    int64         `json:"peak_heap_alloc_bytes"` // Largest heap observed during the crawl
+	SysMemory        int64         `json:"sys_memory_bytes"`      // Memory obtained from the OS
+	NumGC            int64         `json:"num_gc"`
+	StopReason       string        `json:"stop_reason,omitempty"`   // Why the crawl ended, set when it does
+	Depths           []DepthStats  `json:"depths,omitempty"`        // URLs per depth level, indexed by depth
+	Content          ContentStats  `json:"content"`                 // Status codes, content types, charsets, languages and largest pages
+	Active           []ActiveFetch `json:"active,omitempty"`        // URLs being processed by workers, oldest first
+	RecentErrors     []RecentError `json:"recent_errors,omitempty"` // Latest failed URLs, oldest first
 	mu               sync.Mutex
 	lastDisplayTime  time.Time
 	lastDisplayCount int64
 }
 
+// DepthStats counts the URLs of one depth level
+type DepthStats struct {
+	Depth      int   `json:"depth"`
+	Discovered int64 `json:"discovered"` // Queued at this depth
+	Saved      int64 `json:"saved"`
+	Errored    int64 `json:"errored"`
+}
+
 // MetricsDisplayInterval controls how often progress is displayed
 const MetricsDisplayInterval = 2 * time.Second
 
@@ -54,6 +79,13 @@ func (m *CrawlerMetrics) IncrementSaved(bytes int64) {
 	m.BytesDownloaded += bytes
 }
 
+// Saved returns the number of pages saved so far
+func (m *CrawlerMetrics) Saved() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.URLsSaved
+}
+
 // IncrementSkipped increments the skipped URL count
 func (m *CrawlerMetrics) IncrementSkipped() {
 	m.mu.Lock()
@@ -68,6 +100,18 @@ func (m *CrawlerMetrics) IncrementErrored() {
 	m.URLsErrored++
 }
 
+// RetryErrored takes back the counts of an errored URL at depth that is
+// queued again, so it counts once however often it is retried
+func (m *CrawlerMetrics) RetryErrored(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.URLsErrored = max(0, m.URLsErrored-1)
+	m.URLsProcessed = max(0, m.URLsProcessed-1)
+	if depth >= 0 && depth < len(m.Depths) && m.Depths[depth].Errored > 0 {
+		m.Depths[depth].Errored--
+	}
+}
+
 // IncrementRobotsBlocked increments the robots.txt blocked count
 func (m *CrawlerMetrics) IncrementRobotsBlocked() {
 	m.mu.Lock()
@@ -89,6 +133,90 @@ func (m *CrawlerMetrics) IncrementContentFiltered() {
 	m.ContentFiltered++
 }
 
+// IncrementFollowOnly increments the count of pages followed but not saved
+func (m *CrawlerMetrics) IncrementFollowOnly() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.FollowOnly++
+}
+
+// IncrementRedirected increments the count of fetches that followed redirects
+func (m *CrawlerMetrics) IncrementRedirected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Redirected++
+}
+
+// IncrementBrowserRecycles increments the count of browser relaunches to reclaim memory
+func (m *CrawlerMetrics) IncrementBrowserRecycles() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BrowserRecycles++
+}
+
+// IncrementBrowserCrashes increments the count of browser relaunches after a crash
+func (m *CrawlerMetrics) IncrementBrowserCrashes() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BrowserCrashes++
+}
+
+// IncrementTimedOut increments the count of URLs that ran into URLTimeout
+func (m *CrawlerMetrics) IncrementTimedOut() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.TimedOut++
+}
+
+// IncrementRedirectErrors increments the redirect loop and chain length error count
+func (m *CrawlerMetrics) IncrementRedirectErrors() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.RedirectErrors++
+}
+
+// depthStats returns the counters of depth, growing the histogram as
+// needed. Caller must hold m.mu.
+func (m *CrawlerMetrics) depthStats(depth int) *DepthStats {
+	for len(m.Depths) <= depth {
+		m.Depths = append(m.Depths, DepthStats{Depth: len(m.Depths)})
+	}
+	return &m.Depths[depth]
+}
+
+// IncrementDiscoveredAt counts a URL queued at depth
+func (m *CrawlerMetrics) IncrementDiscoveredAt(depth int) {
+	if m == nil || depth < 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depthStats(depth).Discovered++
+}
+
+// RecordDepthOutcome counts a URL at depth that was saved or failed; other
+// outcomes are not part of the histogram
+func (m *CrawlerMetrics) RecordDepthOutcome(depth int, status string) {
+	if m == nil || depth < 0 || (status != URLStatusSaved && status != URLStatusError) {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.depthStats(depth)
+	if status == URLStatusSaved {
+		stats.Saved++
+	} else {
+		stats.Errored++
+	}
+}
+
+// SetStopReason records why the crawl ended
+func (m *CrawlerMetrics) SetStopReason(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.StopReason = reason
+}
+
 // SetQueueSize updates the current queue size
 func (m *CrawlerMetrics) SetQueueSize(size int) {
 	m.mu.Lock()
@@ -107,16 +235,59 @@ func (m *CrawlerMetrics) Finalize() {
 	}
 }
 
-// GetSnapshot returns a copy of current metrics
+// sampleMemory records process memory statistics. Caller must hold m.mu.
+func (m *CrawlerMetrics) sampleMemory() {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	m.HeapAlloc = int64(ms.HeapAlloc)
+	m.SysMemory = int64(ms.Sys)
+	m.NumGC = int64(ms.NumGC)
+	if m.HeapAlloc > m.PeakHeapAlloc {
+		m.PeakHeapAlloc = m.HeapAlloc
+	}
+}
+
+// GetSnapshot returns a copy of current metrics. It is built field by field
+// under the lock, as copying the struct would also copy the mutex other
+// goroutines are locking.
 func (m *CrawlerMetrics) GetSnapshot() CrawlerMetrics {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	snapshot := *m
-	elapsed := time.Since(m.StartTime).Seconds()
-	if elapsed > 0 {
-		snapshot.PagesPerSecond = float64(m.URLsProcessed) / elapsed
+	m.sampleMemory()
+	pagesPerSecond := m.PagesPerSecond
+	if elapsed := time.Since(m.StartTime).Seconds(); elapsed > 0 {
+		pagesPerSecond = float64(m.URLsProcessed) / elapsed
+	}
+	return CrawlerMetrics{
+		StartTime:       m.StartTime,
+		EndTime:         m.EndTime,
+		Duration:        m.Duration,
+		URLsProcessed:   m.URLsProcessed,
+		URLsSaved:       m.URLsSaved,
+		URLsSkipped:     m.URLsSkipped,
+		URLsErrored:     m.URLsErrored,
+		BytesDownloaded: m.BytesDownloaded,
+		RobotsBlocked:   m.RobotsBlocked,
+		DepthLimitHits:  m.DepthLimitHits,
+		ContentFiltered: m.ContentFiltered,
+		FollowOnly:      m.FollowOnly,
+		Redirected:      m.Redirected,
+		RedirectErrors:  m.RedirectErrors,
+		TimedOut:        m.TimedOut,
+		BrowserRecycles: m.BrowserRecycles,
+		BrowserCrashes:  m.BrowserCrashes,
+		PagesPerSecond:  pagesPerSecond,
+		QueueSize:       m.QueueSize,
+		HeapAlloc:       m.HeapAlloc,
+		PeakHeapAlloc:   m.PeakHeapAlloc,
+		SysMemory:       m.SysMemory,
+		NumGC:           m.NumGC,
+		StopReason:      m.StopReason,
+		Depths:          append([]DepthStats(nil), m.Depths...),
+		Content:         m.Content.copy(),
+		Active:          append([]ActiveFetch(nil), m.Active...),
+		RecentErrors:    append([]RecentError(nil), m.RecentErrors...),
 	}
-	return snapshot
 }
 
 // ShouldDisplay checks if enough time has passed to display progress
@@ -154,7 +325,7 @@ func (m *CrawlerMetrics) DisplayProgress(verbose bool) {
 	}
 
 	if verbose {
-		fmt.Printf("\r[%s] Progress: %s | Processed: %d | Saved: %d | Errors: %d | Queue: %d | %.2f p/s | %s   ",
+		fmt.Printf("\r[%s] Progress: %s | Processed: %d | Saved: %d | Errors: %d | Queue: %d | %.2f p/s | %s | heap %s   ",
 			FormatDuration(elapsed),
 			progressStr,
 			snapshot.URLsProcessed,
@@ -163,6 +334,7 @@ func (m *CrawlerMetrics) DisplayProgress(verbose bool) {
 			snapshot.QueueSize,
 			snapshot.PagesPerSecond,
 			bytesStr,
+			FormatBytes(snapshot.HeapAlloc),
 		)
 	} else {
 		fmt.Printf("\r[%s] %s | %d processed | %d saved | %d in queue | %.2f p/s   ",
@@ -187,6 +359,9 @@ func (m *CrawlerMetrics) DisplayFinalSummary() {
 	fmt.Println()
 	fmt.Println("=== Crawl Complete ===")
 	fmt.Printf("Duration:         %s\n", FormatDuration(time.Duration(snapshot.Duration*float64(time.Second))))
+	if snapshot.StopReason != "" {
+		fmt.Printf("Stop Reason:      %s\n", snapshot.StopReason)
+	}
 	fmt.Printf("URLs Processed:   %d\n", snapshot.URLsProcessed)
 	fmt.Printf("URLs Saved:       %d\n", snapshot.URLsSaved)
 	fmt.Printf("URLs Skipped:     %d\n", snapshot.URLsSkipped)
@@ -194,8 +369,49 @@ func (m *CrawlerMetrics) DisplayFinalSummary() {
 	fmt.Printf("Robots Blocked:   %d\n", snapshot.RobotsBlocked)
 	fmt.Printf("Depth Limit Hits: %d\n", snapshot.DepthLimitHits)
 	fmt.Printf("Content Filtered: %d\n", snapshot.ContentFiltered)
+	fmt.Printf("Follow Only:      %d\n", snapshot.FollowOnly)
+	fmt.Printf("Redirected:       %d (%d loops/too long)\n", snapshot.Redirected, snapshot.RedirectErrors)
+	if snapshot.TimedOut > 0 {
+		fmt.Printf("Timed Out:        %d\n", snapshot.TimedOut)
+	}
+	if snapshot.BrowserRecycles > 0 || snapshot.BrowserCrashes > 0 {
+		fmt.Printf("Browser Restarts: %d recycled, %d after crashes\n", snapshot.BrowserRecycles, snapshot.BrowserCrashes)
+	}
 	fmt.Printf("Data Downloaded:  %s\n", FormatBytes(snapshot.BytesDownloaded))
 	fmt.Printf("Average Speed:    %.2f pages/second\n", snapshot.PagesPerSecond)
+	fmt.Printf("Peak Heap:        %s\n", FormatBytes(snapshot.PeakHeapAlloc))
+	if len(snapshot.Depths) > 0 {
+		fmt.Println()
+		fmt.Println("URLs per Depth:")
+		fmt.Print(FormatDepthHistogram(snapshot.Depths))
+	}
+	if stats := FormatContentStats(snapshot.Content); stats != "" {
+		fmt.Println()
+		fmt.Print(stats)
+	}
+}
+
+// FormatDepthHistogram renders per-depth counts as a table with a bar of
+// discovered URLs per depth, scaled to the busiest level
+func FormatDepthHistogram(depths []DepthStats) string {
+	const barWidth = 30
+	var most int64
+	for _, d := range depths {
+		if d.Discovered > most {
+			most = d.Discovered
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("  Depth  Discovered     Saved    Errors\n")
+	for _, d := range depths {
+		bar := 0
+		if most > 0 {
+			bar = int((d.Discovered*barWidth + most - 1) / most)
+		}
+		fmt.Fprintf(&sb, "  %5d  %10d  %8d  %8d  %s\n", d.Depth, d.Discovered, d.Saved, d.Errored, strings.Repeat("#", bar))
+	}
+	return sb.String()
 }
 
 // WriteJSON writes metrics to a JSON file
@@ -203,7 +419,7 @@ func (m *CrawlerMetrics) WriteJSON(filepath string) error {
 	m.Finalize()
 	snapshot := m.GetSnapshot()
 
-	data, err := json.MarshalIndent(snapshot, "", "  ")
+	data, err := json.MarshalIndent(&snapshot, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal metrics: %v", err)
 	}
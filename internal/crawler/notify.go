@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// How the end of a crawl is announced
+const (
+	NotifyOff     = "off"     // Not at all (default)
+	NotifyDesktop = "desktop" // A desktop notification, or the terminal bell where no notifier is found
+	NotifyBell    = "bell"    // The terminal bell
+)
+
+// notifyTimeout bounds how long a desktop notifier may take
+const notifyTimeout = 10 * time.Second
+
+// windowsToastScript shows the title and message passed in the environment
+// as a toast, under PowerShell's app ID as unregistered apps can't show one
+const windowsToastScript = `$m = [Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime]
+$t = $m::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$x = $t.GetElementsByTagName('text')
+$x.Item(0).AppendChild($t.CreateTextNode($env:SCRAPER_NOTIFY_TITLE)) > $null
+$x.Item(1).AppendChild($t.CreateTextNode($env:SCRAPER_NOTIFY_MESSAGE)) > $null
+$m::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show([Windows.UI.Notifications.ToastNotification]::new($t))`
+
+// notifyCommand returns the command showing a desktop notification on this
+// platform, or nil when no notifier is installed. Replaced in tests.
+var notifyCommand = desktopNotifyCommand
+
+// bellOutput is where the terminal bell is rung. Stderr keeps it out of
+// NDJSON progress on stdout.
+var bellOutput io.Writer = os.Stderr
+
+// ValidNotify reports whether mode is a notification mode. An empty mode is
+// treated as NotifyOff.
+func ValidNotify(mode string) bool {
+	switch mode {
+	case "", NotifyOff, NotifyDesktop, NotifyBell:
+		return true
+	}
+	return false
+}
+
+// Notify announces title and message as mode says: a desktop notification
+// through notify-send on Linux, osascript on macOS or a PowerShell toast on
+// Windows, falling back to the terminal bell, or only the bell
+func Notify(mode, title, message string) error {
+	switch mode {
+	case NotifyDesktop:
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if cmd := notifyCommand(ctx, title, message); cmd != nil {
+			if out, err := cmd.CombinedOutput(); err != nil {
+				ringBell()
+				return fmt.Errorf("desktop notification failed: %v %s", err, out)
+			}
+			return nil
+		}
+		ringBell()
+	case NotifyBell:
+		ringBell()
+	}
+	return nil
+}
+
+func ringBell() {
+	fmt.Fprint(bellOutput, "\a")
+}
+
+// desktopNotifyCommand builds the notifier command for the platform. The
+// title and message are passed as arguments or environment, never as code.
+func desktopNotifyCommand(ctx context.Context, title, message string) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("osascript"); err == nil {
+			cmd = exec.CommandContext(ctx, "osascript",
+				"-e", "on run argv",
+				"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+				"-e", "end run",
+				title, message)
+		}
+	case "windows":
+		if _, err := exec.LookPath("powershell"); err == nil {
+			cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript)
+			cmd.Env = append(os.Environ(), "SCRAPER_NOTIFY_TITLE="+title, "SCRAPER_NOTIFY_MESSAGE="+message)
+		}
+	default:
+		if _, err := exec.LookPath("notify-send"); err == nil {
+			cmd = exec.CommandContext(ctx, "notify-send", "--app-name=scraper", title, message)
+		}
+	}
+	return cmd
+}
+
+// notifyFinished announces the end of the crawl, or its failure with err,
+// when Notify is set
+func (c *Crawler) notifyFinished(err error) {
+	if c.config.Notify == "" || c.config.Notify == NotifyOff {
+		return
+	}
+	site := c.config.URL
+	if u, parseErr := url.Parse(site); parseErr == nil && u.Host != "" {
+		site = u.Host
+	}
+
+	title := "Crawl finished"
+	var message string
+	if err != nil {
+		title = "Crawl failed"
+		message = fmt.Sprintf("%s: %v", site, err)
+	} else {
+		m := c.metrics.GetSnapshot()
+		message = fmt.Sprintf("%s: %d pages saved, %d errors in %s", site, m.URLsSaved, m.URLsErrored, time.Since(m.StartTime).Round(time.Second))
+		if m.StopReason != "" {
+			message += " (" + m.StopReason + ")"
+		}
+	}
+	if notifyErr := Notify(c.config.Notify, title, message); notifyErr != nil {
+		c.log.Warn("Failed to send notification: %v", notifyErr)
+	}
+}
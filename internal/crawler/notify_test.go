@@ -0,0 +1,93 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordNotifications replaces the desktop notifier with one recording the
+// notifications, or reporting no notifier when available is false, and the
+// bell with a buffer
+func recordNotifications(t *testing.T, available bool) (*[]string, *bytes.Buffer) {
+	var sent []string
+	bell := &bytes.Buffer{}
+	origCommand, origBell := notifyCommand, bellOutput
+	t.Cleanup(func() { notifyCommand, bellOutput = origCommand, origBell })
+	notifyCommand = func(ctx context.Context, title, message string) *exec.Cmd {
+		if !available {
+			return nil
+		}
+		sent = append(sent, title+": "+message)
+		// The test binary running no tests exits successfully
+		return exec.CommandContext(ctx, os.Args[0], "-test.run=^$")
+	}
+	bellOutput = bell
+	return &sent, bell
+}
+
+func TestNotifyFinishedCrawl(t *testing.T) {
+	sent, bell := recordNotifications(t, true)
+	site := NewTestSite(TestSiteOptions{Pages: 3})
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         5,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		Notify:           NotifyDesktop,
+	}
+	c, err := NewCrawler(config, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1: %v", len(*sent), *sent)
+	}
+	if got := (*sent)[0]; !strings.HasPrefix(got, "Crawl finished: ") || !strings.Contains(got, "pages saved") {
+		t.Errorf("notification = %q, want the finished crawl's summary", got)
+	}
+	if bell.Len() != 0 {
+		t.Errorf("rang the bell although the desktop notification was sent")
+	}
+}
+
+func TestNotifyModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		available bool
+		wantSent  int
+		wantBell  bool
+	}{
+		{"off", NotifyOff, true, 0, false},
+		{"desktop", NotifyDesktop, true, 1, false},
+		{"desktop without notifier", NotifyDesktop, false, 0, true},
+		{"bell", NotifyBell, true, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, bell := recordNotifications(t, tt.available)
+			if err := Notify(tt.mode, "Crawl failed", "example.com: failed to load state"); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			if len(*sent) != tt.wantSent {
+				t.Errorf("sent %d desktop notifications, want %d", len(*sent), tt.wantSent)
+			}
+			if rang := bell.String() == "\a"; rang != tt.wantBell {
+				t.Errorf("bell rang = %v, want %v", rang, tt.wantBell)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// PageDocument is a fetched page parsed once and shared by every processing
+// stage: the content check, content extraction, saving and link discovery.
+// The parsed tree must be treated as read-only.
+type PageDocument struct {
+	URL  string            // URL the page was fetched from, used to resolve links
+	Body []byte            // Raw HTML as fetched
+	Doc  *goquery.Document // Parsed DOM
+	// User-Agent the page was requested with, recorded in its metadata
+	UserAgent string
+
+	text      string
+	textDone  bool
+	words     int
+	wordsDone bool
+}
+
+// NewPageDocument parses a page body into a shared document
+func NewPageDocument(rawURL string, body []byte) (*PageDocument, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return &PageDocument{URL: rawURL, Body: body, Doc: doc}, nil
+}
+
+// Root returns the document node of the parsed tree
+func (p *PageDocument) Root() *html.Node {
+	return p.Doc.Nodes[0]
+}
+
+// Text returns the visible text of the page, skipping script and style
+// elements. The result is computed once and cached.
+func (p *PageDocument) Text() string {
+	if !p.textDone {
+		var sb strings.Builder
+		collectVisibleText(p.Root(), &sb)
+		p.text = strings.TrimSpace(sb.String())
+		p.textDone = true
+	}
+	return p.text
+}
+
+// Words returns the number of words in the visible text, counting adjacent
+// elements such as list items as separate words. The result is cached.
+func (p *PageDocument) Words() int {
+	if !p.wordsDone {
+		p.words = countVisibleWords(p.Root())
+		p.wordsDone = true
+	}
+	return p.words
+}
+
+// LinkDensity returns the share of the visible words that are link text,
+// from 0 to 1. Link farms, tag pages and archives score high.
+func (p *PageDocument) LinkDensity() float64 {
+	words := p.Words()
+	if words == 0 {
+		return 0
+	}
+	linkWords := 0
+	for _, n := range p.Doc.Find("a").Not("a a").Nodes {
+		linkWords += countVisibleWords(n)
+	}
+	return float64(linkWords) / float64(words)
+}
+
+// Size returns the raw body size in bytes
+func (p *PageDocument) Size() int {
+	return len(p.Body)
+}
+
+// countVisibleWords counts the words of the text nodes of n and its
+// descendants, skipping script and style
+func countVisibleWords(n *html.Node) int {
+	if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+		return 0
+	}
+	if n.Type == html.TextNode {
+		return len(strings.Fields(n.Data))
+	}
+	words := 0
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		words += countVisibleWords(child)
+	}
+	return words
+}
+
+// collectVisibleText appends the text of n and its descendants, skipping script and style
+func collectVisibleText(n *html.Node, sb *strings.Builder) {
+	if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+		return
+	}
+	if n.Type == html.TextNode {
+		sb.WriteString(n.Data)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		collectVisibleText(child, sb)
+	}
+}
@@ -0,0 +1,57 @@
+package crawler
+
+import "testing"
+
+func TestPageDocument(t *testing.T) {
+	body := []byte(`<html><head><style>p{color:red}</style><script>var x = 1;</script></head><body><p>Hello <b>world</b></p><a href="/next">next</a></body></html>`)
+
+	page, err := NewPageDocument("https://example.com/page", body)
+	if err != nil {
+		t.Fatalf("NewPageDocument() error = %v", err)
+	}
+
+	if page.URL != "https://example.com/page" {
+		t.Errorf("URL = %q", page.URL)
+	}
+	if page.Size() != len(body) {
+		t.Errorf("Size() = %d, want %d", page.Size(), len(body))
+	}
+	if got := page.Text(); got != "Hello worldnext" {
+		t.Errorf("Text() = %q, want %q", got, "Hello worldnext")
+	}
+	// Text must not strip script or style from the shared tree
+	if page.Doc.Find("script, style").Length() != 2 {
+		t.Error("Text() modified the document")
+	}
+	if href, _ := page.Doc.Find("a").Attr("href"); href != "/next" {
+		t.Errorf("link href = %q, want /next", href)
+	}
+}
+
+func TestPageWordsAndLinkDensity(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantWords   int
+		wantDensity float64
+	}{
+		{"article", `<body><p>One two three four five six seven</p><p>See <a href="/x">this page</a></p></body>`, 10, 0.2},
+		{"tag page", `<body><ul><li><a href="/t/a">Go</a></li><li><a href="/t/b">Rust</a></li><li><a href="/t/c">Zig</a></li></ul><p>Tags</p></body>`, 4, 0.75},
+		{"script and style", `<head><style>p{}</style></head><body><script>var a = 1;</script><a href="/">home</a></body>`, 1, 1},
+		{"empty", `<body></body>`, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := NewPageDocument("https://example.com/", []byte(tt.body))
+			if err != nil {
+				t.Fatalf("NewPageDocument() error = %v", err)
+			}
+			if got := page.Words(); got != tt.wantWords {
+				t.Errorf("Words() = %d, want %d", got, tt.wantWords)
+			}
+			if got := page.LinkDensity(); got != tt.wantDensity {
+				t.Errorf("LinkDensity() = %v, want %v", got, tt.wantDensity)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// PageScript is a JavaScript snippet run after load on pages whose URL
+// matches Pattern, for site-specific interaction such as accepting cookies,
+// expanding comments or switching to a list view
+type PageScript struct {
+	Pattern string `json:"pattern"` // Regular expression matched against the requested URL
+	Script  string `json:"script"`  // Run as the body of an async function, so it may await
+}
+
+// compiledPageScript is a PageScript with its pattern compiled
+type compiledPageScript struct {
+	PageScript
+	re *regexp.Regexp
+}
+
+// compilePageScripts compiles the patterns of scripts, keeping their order
+func compilePageScripts(scripts []PageScript) ([]compiledPageScript, error) {
+	compiled := make([]compiledPageScript, 0, len(scripts))
+	for i, s := range scripts {
+		if strings.TrimSpace(s.Script) == "" {
+			return nil, fmt.Errorf("page script %d has no script", i+1)
+		}
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("page script %d has an invalid pattern: %v", i+1, err)
+		}
+		compiled = append(compiled, compiledPageScript{PageScript: s, re: re})
+	}
+	return compiled, nil
+}
+
+// ParsePageScripts reads page scripts given inline as a JSON array or as
+// the path of a JSON file holding one
+func ParsePageScripts(value string) ([]PageScript, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	var scripts []PageScript
+	if err := json.Unmarshal(data, &scripts); err != nil {
+		return nil, fmt.Errorf("page scripts must be a JSON array of {\"pattern\", \"script\"} objects: %v", err)
+	}
+	if _, err := compilePageScripts(scripts); err != nil {
+		return nil, err
+	}
+	return scripts, nil
+}
+
+// SetPageScripts sets the snippets run after loading matching pages, in
+// order. It must be called before the first fetch.
+func (f *BrowserFetcher) SetPageScripts(scripts []PageScript) error {
+	compiled, err := compilePageScripts(scripts)
+	if err != nil {
+		return err
+	}
+	f.pageScripts = compiled
+	return nil
+}
+
+// pageScriptActions runs the snippets matching rawURL, then waits the page
+// load wait again so their effects render. A snippet that throws does not
+// fail the fetch; its error is appended to errs as "pattern: error".
+func (f *BrowserFetcher) pageScriptActions(rawURL string, errs *[]string) []chromedp.Action {
+	var actions []chromedp.Action
+	for _, s := range f.pageScripts {
+		if !s.re.MatchString(rawURL) {
+			continue
+		}
+		s := s
+		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := runPageScript(ctx, s.Script); err != nil {
+				*errs = append(*errs, fmt.Sprintf("%s: %v", s.Pattern, err))
+			}
+			return nil
+		}))
+	}
+	if len(actions) > 0 {
+		actions = append(actions, chromedp.Sleep(f.pageLoadWait))
+	}
+	return actions
+}
+
+// runPageScript evaluates a snippet as the body of an async function and
+// waits for it to finish
+func runPageScript(ctx context.Context, script string) error {
+	return chromedp.Evaluate("(async () => {\n"+script+"\n})()", nil,
+		func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		},
+	).Do(ctx)
+}
+
+// logPageScriptErrors reports the page scripts that threw on a page
+func (c *Crawler) logPageScriptErrors(rawURL string, result *FetchResult) {
+	if result == nil {
+		return
+	}
+	for _, e := range result.PageScriptErrors {
+		c.log.Warn("Page script failed on %s: %s", rawURL, e)
+	}
+}
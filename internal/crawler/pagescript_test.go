@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePageScripts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scripts.json")
+	os.WriteFile(file, []byte(`[{"pattern": "/forum/", "script": "document.querySelector('.expand').click()"}]`), 0644)
+
+	tests := []struct {
+		name     string
+		value    string
+		want     int
+		errorMsg string
+	}{
+		{"inline", `[{"pattern": "example\\.com", "script": "window.scrollTo(0, 0)"}, {"pattern": ".*", "script": "1"}]`, 2, ""},
+		{"file", file, 1, ""},
+		{"missing file", filepath.Join(t.TempDir(), "missing.json"), 0, "no such file"},
+		{"object instead of array", `{"pattern": ".*", "script": "1"}`, 0, "no such file"},
+		{"malformed JSON", `[{"pattern": ".*"`, 0, "must be a JSON array"},
+		{"invalid pattern", `[{"pattern": "(", "script": "1"}]`, 0, "page script 1 has an invalid pattern"},
+		{"empty script", `[{"pattern": ".*", "script": "1"}, {"pattern": ".*", "script": " "}]`, 0, "page script 2 has no script"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts, err := ParsePageScripts(tt.value)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("ParsePageScripts() error = %v, want containing %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePageScripts() error = %v", err)
+			}
+			if len(scripts) != tt.want {
+				t.Errorf("ParsePageScripts() returned %d scripts, want %d", len(scripts), tt.want)
+			}
+		})
+	}
+}
+
+func TestPageScriptActions(t *testing.T) {
+	f := &BrowserFetcher{}
+	err := f.SetPageScripts([]PageScript{
+		{Pattern: `example\.com/forum/`, Script: "document.querySelectorAll('.more').forEach(b => b.click())"},
+		{Pattern: `\?view=grid`, Script: "document.querySelector('#list-view').click()"},
+		{Pattern: `.*`, Script: "document.querySelector('#accept-cookies')?.click()"},
+	})
+	if err != nil {
+		t.Fatalf("SetPageScripts() error = %v", err)
+	}
+
+	tests := []struct {
+		url  string
+		want int // Matching scripts plus the settle wait
+	}{
+		{"https://example.com/forum/thread-1", 3},
+		{"https://example.com/shop?view=grid", 3},
+		{"https://example.com/about", 2},
+	}
+	for _, tt := range tests {
+		var errs []string
+		if got := len(f.pageScriptActions(tt.url, &errs)); got != tt.want {
+			t.Errorf("pageScriptActions(%q) returned %d actions, want %d", tt.url, got, tt.want)
+		}
+	}
+
+	none := &BrowserFetcher{}
+	var errs []string
+	if got := len(none.pageScriptActions("https://example.com/", &errs)); got != 0 {
+		t.Errorf("pageScriptActions() without scripts returned %d actions, want 0", got)
+	}
+
+	if err := f.SetPageScripts([]PageScript{{Pattern: "[", Script: "1"}}); err == nil {
+		t.Error("SetPageScripts() with an invalid pattern should fail")
+	}
+}
@@ -24,6 +24,7 @@ type PaginationState struct {
 	ClickCount  int               // Number of clicks performed
 	ContentHash string            // Hash of current page content
 	SeenHashes  map[string]bool   // Set of previously seen content hashes
+	Replay      []string          // Content hashes of the pages captured before an interruption, in page order
 	Config      PaginationConfig  // Pagination configuration
 	Behavior    *HumanBehavior    // Human behavior helper for natural clicks
 }
@@ -57,6 +58,23 @@ func (ps *PaginationState) RecordClick(contentHash string) bool {
 	return true
 }
 
+// Replaying reports whether the next click leads to a page captured before
+// an interruption, which is clicked through without human-like delays
+func (ps *PaginationState) Replaying() bool {
+	return ps.ClickCount+1 < len(ps.Replay)
+}
+
+// Captured reports whether page (1-indexed), whose content hashes to hash,
+// was captured before an interruption. The first page that differs ends the
+// replay: the site changed, so that page and every later one are new.
+func (ps *PaginationState) Captured(page int, hash string) bool {
+	if page < 1 || page > len(ps.Replay) || ps.Replay[page-1] != hash {
+		ps.Replay = nil
+		return false
+	}
+	return true
+}
+
 // ClickPagination attempts to click the pagination element and waits for page update
 // Returns a PaginationResult indicating success/failure and reason
 func ClickPagination(ctx context.Context, selector string, config PaginationConfig, behavior *HumanBehavior) (*PaginationResult, error) {
@@ -102,6 +102,36 @@ func TestPaginationStateRecordClick(t *testing.T) {
 	}
 }
 
+func TestPaginationStateReplay(t *testing.T) {
+	state := NewPaginationState(PaginationConfig{MaxClicks: 10}, AntiBotConfig{})
+	state.Replay = []string{"h1", "h2", "h3"}
+
+	if !state.Captured(1, "h1") {
+		t.Error("page 1 should have been captured")
+	}
+	if !state.Replaying() {
+		t.Error("the click to page 2 should be replayed")
+	}
+	state.RecordClick("h2")
+	if !state.Captured(2, "h2") {
+		t.Error("page 2 should have been captured")
+	}
+	state.RecordClick("h3")
+	if state.Replaying() {
+		t.Error("the click past the last captured page should not be replayed")
+	}
+
+	// A page whose content changed ends the replay
+	state = NewPaginationState(PaginationConfig{MaxClicks: 10}, AntiBotConfig{})
+	state.Replay = []string{"h1", "h2", "h3"}
+	if !state.Captured(1, "h1") || state.Captured(2, "changed") {
+		t.Fatal("page 2 changed and should be new")
+	}
+	if state.Captured(3, "h3") || state.Replaying() {
+		t.Error("pages after a changed page should be new")
+	}
+}
+
 func TestEscapeSelector(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -228,6 +258,16 @@ func TestPaginationConfigDefaults(t *testing.T) {
 	}
 }
 
+func TestPaginationHarvestRequiresPagination(t *testing.T) {
+	config := Config{
+		URL:        "https://example.com",
+		Pagination: PaginationConfig{HarvestURLs: true},
+	}
+	if err := ValidateConfig(&config); err == nil {
+		t.Error("expected an error for URL harvesting without pagination")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsAt(s, substr, 0))
 }
@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// OutputPermissions sets the modes and owner of the files and directories a
+// crawl writes, so output on volumes shared with other users or containers
+// is usable. Empty fields keep the defaults (0644 files, 0755 directories
+// less the umask, owned by the crawling user).
+type OutputPermissions struct {
+	FileMode string `json:"fileMode"` // Octal file mode (e.g. "0664"), applied regardless of the umask
+	DirMode  string `json:"dirMode"`  // Octal directory mode (e.g. "2775"), applied regardless of the umask
+	Owner    string `json:"owner"`    // "uid[:gid]" to chown output to; Unix only, requires root
+}
+
+// outputPerms is the parsed form of OutputPermissions
+type outputPerms struct {
+	fileMode os.FileMode // 0 = leave the mode set at creation
+	dirMode  os.FileMode
+	uid, gid int // -1 = unchanged
+}
+
+// parse checks and parses the permission settings
+func (p OutputPermissions) parse() (outputPerms, error) {
+	perms := outputPerms{uid: -1, gid: -1}
+	var err error
+	if perms.fileMode, err = ParseFileMode(p.FileMode, false); err != nil {
+		return perms, fmt.Errorf("invalid file mode: %v", err)
+	}
+	if perms.dirMode, err = ParseFileMode(p.DirMode, true); err != nil {
+		return perms, fmt.Errorf("invalid directory mode: %v", err)
+	}
+	if p.Owner == "" {
+		return perms, nil
+	}
+	if runtime.GOOS == "windows" {
+		return perms, fmt.Errorf("output owner is only supported on Unix")
+	}
+	uid, gid, _ := strings.Cut(p.Owner, ":")
+	if perms.uid, err = strconv.Atoi(uid); err != nil || perms.uid < 0 {
+		return perms, fmt.Errorf("owner must be \"uid[:gid]\" with numeric ids, got: %s", p.Owner)
+	}
+	if gid != "" {
+		if perms.gid, err = strconv.Atoi(gid); err != nil || perms.gid < 0 {
+			return perms, fmt.Errorf("owner must be \"uid[:gid]\" with numeric ids, got: %s", p.Owner)
+		}
+	}
+	if os.Geteuid() != 0 {
+		return perms, fmt.Errorf("output owner requires running as root")
+	}
+	return perms, nil
+}
+
+// Validate checks the permission settings, e.g. an owner set for a whole
+// server before any job uses it
+func (p OutputPermissions) Validate() error {
+	_, err := p.parse()
+	return err
+}
+
+// ParseFileMode parses an octal permission mode such as "0664" or "2775".
+// Only the permission bits are kept, plus setgid for directories, so that
+// files in a shared group directory inherit its group: output never becomes
+// setuid, setgid or sticky otherwise. An empty string yields 0.
+func ParseFileMode(s string, dir bool) (os.FileMode, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+	if err != nil || v > 07777 {
+		return 0, fmt.Errorf("mode must be octal between 0000 and 7777, got: %s", s)
+	}
+	mode := os.FileMode(v & 0777)
+	if dir && v&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	return mode, nil
+}
+
+// apply sets the configured mode and owner of a written path
+func (p outputPerms) apply(path string, mode os.FileMode) error {
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+	if p.uid >= 0 {
+		return os.Lchown(path, p.uid, p.gid)
+	}
+	return nil
+}
+
+// applyFile sets the mode and owner of a written file
+func (p outputPerms) applyFile(path string) error {
+	return p.apply(path, p.fileMode)
+}
+
+// writeFile writes data to path like os.WriteFile, then applies the
+// configured mode and owner
+func (p outputPerms) writeFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	return p.applyFile(path)
+}
+
+// create creates or truncates path like os.Create, then applies the
+// configured mode and owner
+func (p outputPerms) create(path string) (*os.File, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.applyFile(path); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// mkdirAll creates dir and its missing parents like os.MkdirAll, applying
+// the configured mode and owner to the directories it creates
+func (p outputPerms) mkdirAll(dir string) error {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || d == filepath.Dir(d) {
+			break
+		}
+		missing = append(missing, d)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := p.apply(missing[i], p.dirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
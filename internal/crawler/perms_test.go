@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		dir     bool
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"", false, 0, false},
+		{"0644", false, 0644, false},
+		{"664", false, 0664, false},
+		{"0o600", false, 0600, false},
+		{"2775", true, 0775 | os.ModeSetgid, false},
+		{"2775", false, 0775, false},
+		{"1777", true, 0777, false},
+		{"4755", false, 0755, false},
+		{"4755", true, 0755, false},
+		{"7777", true, 0777 | os.ModeSetgid, false},
+		{"0999", false, 0, true},
+		{"17777", false, 0, true},
+		{"rw-r--r--", false, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s dir=%v", tt.input, tt.dir), func(t *testing.T) {
+			got, err := ParseFileMode(tt.input, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFileMode(%q, %v) error = %v, wantErr %v", tt.input, tt.dir, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFileMode(%q, %v) = %v, want %v", tt.input, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputPermissionsParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		perms    OutputPermissions
+		errorMsg string
+	}{
+		{"empty", OutputPermissions{}, ""},
+		{"modes", OutputPermissions{FileMode: "0664", DirMode: "2775"}, ""},
+		{"invalid file mode", OutputPermissions{FileMode: "abc"}, "invalid file mode"},
+		{"invalid directory mode", OutputPermissions{DirMode: "8000"}, "invalid directory mode"},
+		{"owner by name", OutputPermissions{Owner: "www-data"}, "numeric ids"},
+		{"negative gid", OutputPermissions{Owner: "1000:-1"}, "numeric ids"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.perms.parse()
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("parse() unexpected error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("parse() error = %v, want containing %q", err, tt.errorMsg)
+			}
+		})
+	}
+
+	if runtime.GOOS != "windows" && os.Geteuid() != 0 {
+		if _, err := (OutputPermissions{Owner: "1000:1000"}).parse(); err == nil || !strings.Contains(err.Error(), "requires running as root") {
+			t.Errorf("parse() with owner as non-root error = %v", err)
+		}
+	}
+}
+
+func TestCrawlOutputPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on Windows")
+	}
+	body := strings.Repeat("Some meaningful content for the page. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s %s</p><a href="/docs/guide">g</a></body></html>`, r.URL.Path, body)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Group write is masked by the usual 022 umask, so these modes can only
+	// be the result of an explicit chmod
+	outputDir := filepath.Join(t.TempDir(), "shared", "out")
+	c, err := NewCrawler(Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		Permissions:      OutputPermissions{FileMode: "0660", DirMode: "0770"},
+	}, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	var files, dirs int
+	err = filepath.Walk(filepath.Dir(outputDir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		want := os.FileMode(0660)
+		if info.IsDir() {
+			want = 0770
+			dirs++
+		} else {
+			files++
+		}
+		if info.Mode().Perm() != want {
+			t.Errorf("%s has mode %v, want %v", path, info.Mode().Perm(), want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	if files == 0 || dirs < 3 {
+		t.Errorf("walked %d files and %d directories, want output in nested directories", files, dirs)
+	}
+}
@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// DefaultPlanPages is the number of pages a plan fetches when MaxPages is not set
+const DefaultPlanPages = 20
+
+// Further reasons a link found by a plan is not queued, besides those of
+// linkFilterReason
+const (
+	LinkFilterSelector = "selector" // Not matched by the link selectors
+	LinkFilterRobots   = "robots"   // Disallowed by robots.txt
+	LinkFilterDepth    = "depth"    // Beyond the maximum depth
+)
+
+// PlannedPage is a page fetched by a plan
+type PlannedPage struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+	Error string `json:"error,omitempty"` // Why the page's links could not be read
+	Links int    `json:"links"`           // Distinct links first found on this page
+}
+
+// PlannedLink is a link found by a plan and whether the crawl would queue it
+type PlannedLink struct {
+	URL    string `json:"url"`
+	Source string `json:"source"` // Page the link was found on
+	Depth  int    `json:"depth"`
+	Queued bool   `json:"queued"`
+	Reason string `json:"reason,omitempty"` // Why the link is not queued
+}
+
+// CrawlPlan is the preview of a crawl produced by Plan
+type CrawlPlan struct {
+	Pages    []PlannedPage  `json:"pages"`
+	Links    []PlannedLink  `json:"links"`
+	Queued   int            `json:"queued"`
+	Filtered map[string]int `json:"filtered"` // Links not queued, by reason
+	Bytes    int64          `json:"bytes"`    // Size of the fetched responses
+}
+
+// Plan previews the links a crawl with config would follow, to check the
+// filter settings before a long crawl. It fetches the start URL and, breadth
+// first, the queued pages above MaxDepth, at most MaxPages of them
+// (DefaultPlanPages when unset), and reports for every distinct link found
+// whether it would be queued or why it is filtered out. Nothing is written to
+// the output directory.
+func Plan(ctx context.Context, config Config) (*CrawlPlan, error) {
+	// A preview is not worth recording
+	if config.Cassette == CassetteRecord {
+		config.Cassette = ""
+	}
+	c, err := NewCrawler(config, ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.plan(), nil
+}
+
+// plan fetches the pages of a plan and sorts their links
+func (c *Crawler) plan() *CrawlPlan {
+	plan := &CrawlPlan{Pages: []PlannedPage{}, Links: []PlannedLink{}, Filtered: make(map[string]int)}
+	maxPages := c.config.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultPlanPages
+	}
+
+	seed := c.normalizeURL(c.config.URL)
+	seen := map[string]bool{seed: true}
+	queue := []URLInfo{{URL: seed, Depth: 0}}
+	for len(queue) > 0 && len(plan.Pages) < maxPages {
+		if len(plan.Pages) > 0 {
+			select {
+			case <-c.ctx.Done():
+			case <-time.After(c.delay()):
+			}
+		}
+		if c.isShuttingDown() {
+			break
+		}
+		info := queue[0]
+		queue = queue[1:]
+
+		planned := PlannedPage{URL: info.URL, Depth: info.Depth}
+		page, size, err := c.planFetch(info.URL)
+		plan.Bytes += size
+		if err != nil {
+			planned.Error = err.Error()
+			plan.Pages = append(plan.Pages, planned)
+			continue
+		}
+		links := c.planLinks(page, info.Depth, seen)
+		planned.Links = len(links)
+		plan.Pages = append(plan.Pages, planned)
+
+		for _, link := range links {
+			plan.Links = append(plan.Links, link)
+			if !link.Queued {
+				plan.Filtered[link.Reason]++
+				continue
+			}
+			plan.Queued++
+			if link.Depth < c.config.MaxDepth {
+				queue = append(queue, URLInfo{URL: link.URL, Depth: link.Depth})
+			}
+		}
+	}
+	return plan
+}
+
+// planFetch fetches and parses one page of a plan. It also returns the size
+// of the response, fetched even when the page is refused.
+func (c *Crawler) planFetch(rawURL string) (*PageDocument, int64, error) {
+	if !c.isAllowedByRobots(rawURL) {
+		return nil, 0, fmt.Errorf("blocked by robots.txt")
+	}
+	userAgent := c.userAgentFor(rawURL)
+	c.warmUp(rawURL, userAgent)
+	result, err := c.fetcher.Fetch(rawURL, userAgent)
+	if err != nil {
+		return nil, 0, err
+	}
+	size := int64(len(result.Body))
+	if result.StatusCode != http.StatusOK {
+		return nil, size, fmt.Errorf("HTTP %d", result.StatusCode)
+	}
+	page, err := NewPageDocument(rawURL, result.Body)
+	if err != nil {
+		return nil, size, fmt.Errorf("parse error: %v", err)
+	}
+	return page, size, nil
+}
+
+// planLinks sorts the links of page that were not seen before, in the order
+// the crawl would meet them: those matched by the link selectors, then the
+// other links the selectors leave out
+func (c *Crawler) planLinks(page *PageDocument, depth int, seen map[string]bool) []PlannedLink {
+	base, err := url.Parse(page.URL)
+	if err != nil {
+		return nil
+	}
+
+	var links []PlannedLink
+	add := func(href, reason string) {
+		rawURL := href
+		if absolute, err := base.Parse(href); err != nil {
+			reason = LinkFilterInvalid
+		} else {
+			rawURL = absolute.String()
+		}
+		if reason == "" {
+			reason = c.linkFilterReason(rawURL)
+		}
+		if reason == "" {
+			rawURL = c.normalizeURL(rawURL)
+		}
+		if seen[rawURL] {
+			return
+		}
+		seen[rawURL] = true
+
+		link := PlannedLink{URL: rawURL, Source: page.URL, Depth: c.linkDepth(rawURL, depth)}
+		switch {
+		case reason != "":
+		case link.Depth > c.config.MaxDepth:
+			reason = LinkFilterDepth
+		case !c.isAllowedByRobots(rawURL):
+			reason = LinkFilterRobots
+		}
+		link.Queued = reason == ""
+		link.Reason = reason
+		links = append(links, link)
+	}
+
+	selectors := c.config.LinkSelectors
+	if len(selectors) == 0 {
+		selectors = []string{"a[href]"}
+	}
+	matched := make(map[*html.Node]bool)
+	for _, selector := range selectors {
+		page.Doc.Find(selector).Each(func(i int, s *goquery.Selection) {
+			matched[s.Get(0)] = true
+			if href, ok := s.Attr("href"); ok {
+				add(href, "")
+			}
+		})
+	}
+	page.Doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		if !matched[s.Get(0)] {
+			add(s.AttrOr("href", ""), LinkFilterSelector)
+		}
+	})
+	return links
+}
@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func planTestServer() *httptest.Server {
+	pages := map[string]string{
+		"/": `<nav><a href="/docs/a">A</a></nav>
+			<a href="/docs/b">B</a><a href="/docs/a">A again</a><a href="/file.pdf">PDF</a>
+			<a href="mailto:team@example.com">Mail</a><a href="https://other.example/">Other</a>
+			<a href="/private/x">Private</a>`,
+		"/docs/a": `<a href="/docs/a/deeper">Deeper</a><a href="/docs/b">B</a>`,
+		"/docs/b": `<a href="/docs/b/deeper">Deeper</a>`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+	}))
+}
+
+func TestPlan(t *testing.T) {
+	server := planTestServer()
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		pages     int
+		links     map[string]string // path or URL -> "queue" or filter reason
+	}{
+		{
+			name: "start page",
+			configure: func(c *Config) {
+				c.ExcludeExtensions = []string{"pdf"}
+				c.PrefixFilterURL = server.URL + "/"
+			},
+			pages: 1,
+			links: map[string]string{
+				"/docs/a":                 "queue",
+				"/docs/b":                 "queue",
+				"/file.pdf":               LinkFilterExtension,
+				"mailto:team@example.com": LinkFilterScheme,
+				"https://other.example/":  LinkFilterPrefix,
+				"/private/x":              LinkFilterRobots,
+			},
+		},
+		{
+			name:      "link selectors",
+			configure: func(c *Config) { c.LinkSelectors = []string{"nav a"} },
+			pages:     1,
+			links: map[string]string{
+				"/docs/a": "queue",
+				"/docs/b": LinkFilterSelector,
+			},
+		},
+		{
+			name: "second level",
+			configure: func(c *Config) {
+				c.MaxDepth = 2
+				c.PrefixFilterURL = server.URL + "/docs/"
+			},
+			pages: 3,
+			links: map[string]string{
+				"/docs/a/deeper": "queue",
+				"/docs/b/deeper": "queue",
+			},
+		},
+		{
+			name: "path depth",
+			configure: func(c *Config) {
+				c.DepthMode = DepthModePath
+				c.PrefixFilterURL = server.URL + "/"
+			},
+			pages: 1,
+			links: map[string]string{
+				"/docs/a":   LinkFilterDepth,
+				"/file.pdf": "queue",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputDir := t.TempDir() + "/out"
+			config := Config{URL: server.URL + "/", MaxDepth: 1, OutputDir: outputDir}
+			tt.configure(&config)
+
+			plan, err := Plan(t.Context(), config)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if len(plan.Pages) != tt.pages {
+				t.Errorf("fetched %d pages, want %d: %+v", len(plan.Pages), tt.pages, plan.Pages)
+			}
+
+			got := make(map[string]string)
+			queued := 0
+			for _, l := range plan.Links {
+				decision := l.Reason
+				if l.Queued {
+					decision = "queue"
+					queued++
+				}
+				got[l.URL] = decision
+			}
+			for link, want := range tt.links {
+				url := link
+				if link[0] == '/' {
+					url = server.URL + link
+				}
+				if got[url] != want {
+					t.Errorf("%s: got %q, want %q", link, got[url], want)
+				}
+			}
+			if queued != plan.Queued {
+				t.Errorf("Queued = %d, counted %d", plan.Queued, queued)
+			}
+			if _, err := os.Stat(outputDir); err == nil {
+				t.Error("plan created the output directory")
+			}
+		})
+	}
+}
+
+func TestPlanUnreachableStart(t *testing.T) {
+	server := planTestServer()
+	defer server.Close()
+
+	plan, err := Plan(t.Context(), Config{URL: server.URL + "/missing", MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Pages) != 1 || plan.Pages[0].Error != "HTTP 404" || len(plan.Links) != 0 {
+		t.Errorf("plan = %+v", plan)
+	}
+}
@@ -0,0 +1,359 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// PluginTimeout bounds each call of a plugin hook; a hook running longer is
+// interrupted and counted as failed
+const PluginTimeout = 5 * time.Second
+
+// PluginExt is the extension of the plugin files loaded from a plugins directory
+const PluginExt = ".js"
+
+// Hooks a plugin may define as top-level functions
+const (
+	PluginHookFilterURL = "filterURL" // filterURL(url) returns false to leave a discovered link out
+	PluginHookTransform = "transform" // transform(page) returns the HTML saved instead, or nothing to keep the page as it is
+	PluginHookExtract   = "extract"   // extract(page) returns fields added to the page's .meta.json
+)
+
+// pluginHooks lists the hooks in the order they run on a page
+var pluginHooks = []string{PluginHookFilterURL, PluginHookTransform, PluginHookExtract}
+
+// PluginInfo describes a plugin loaded from a plugins directory
+type PluginInfo struct {
+	Name  string   `json:"name"`  // File name without the .js extension
+	File  string   `json:"file"`  // Path of the plugin file
+	Hooks []string `json:"hooks"` // Hooks the plugin defines
+}
+
+// plugin is a JavaScript plugin with its own runtime. goja runtimes are not
+// safe for concurrent use, so calls into a plugin take turns.
+type plugin struct {
+	PluginInfo
+	mu    sync.Mutex
+	vm    *goja.Runtime
+	hooks map[string]goja.Callable
+
+	errors int64 // Hook calls that threw, timed out or returned unusable HTML, guarded by mu
+}
+
+// pluginPage is the page handed to the transform and extract hooks
+type pluginPage struct {
+	URL   string `json:"url"`
+	HTML  string `json:"html"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// pluginSet runs the hooks of the plugins of a crawl, in file name order
+type pluginSet struct {
+	plugins []*plugin
+	log     *Logger
+}
+
+// ListPlugins loads the plugins of dir and describes them, failing on the
+// first one that does not compile
+func ListPlugins(dir string) ([]PluginInfo, error) {
+	plugins, err := loadPlugins(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]PluginInfo, 0, len(plugins))
+	for _, p := range plugins {
+		infos = append(infos, p.PluginInfo)
+	}
+	return infos, nil
+}
+
+// loadPlugins compiles and runs each .js file of dir in a runtime of its
+// own, keeping those that define at least one hook
+func loadPlugins(dir string, log *Logger) ([]*plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins directory: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), PluginExt) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var plugins []*plugin
+	for _, name := range names {
+		p, err := loadPlugin(filepath.Join(dir, name), log)
+		if err != nil {
+			return nil, err
+		}
+		if len(p.hooks) == 0 {
+			if log != nil {
+				log.Warn("Plugin %s defines none of %s, ignoring it", p.Name, strings.Join(pluginHooks, ", "))
+			}
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// loadPlugin runs the plugin file at path and looks up its hooks
+func loadPlugin(path string, log *Logger) (*plugin, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin: %v", err)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	p := &plugin{
+		PluginInfo: PluginInfo{Name: name, File: path, Hooks: []string{}},
+		vm:         goja.New(),
+		hooks:      make(map[string]goja.Callable),
+	}
+	p.vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	p.vm.Set("log", func(args ...any) {
+		if log != nil {
+			log.Info("[plugin %s] %s", name, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+		}
+	})
+
+	_, err = p.interruptible(func() (goja.Value, error) {
+		return p.vm.RunScript(path, string(src))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", name, err)
+	}
+
+	for _, hook := range pluginHooks {
+		if fn, ok := goja.AssertFunction(p.vm.Get(hook)); ok {
+			p.hooks[hook] = fn
+			p.Hooks = append(p.Hooks, hook)
+		}
+	}
+	return p, nil
+}
+
+// interruptible runs fn on the plugin's runtime, interrupting it after
+// PluginTimeout. The interrupt is cleared once the timer can no longer
+// fire, so a late one can't stop the next call.
+func (p *plugin) interruptible(fn func() (goja.Value, error)) (goja.Value, error) {
+	fired := make(chan struct{})
+	timer := time.AfterFunc(PluginTimeout, func() {
+		p.vm.Interrupt("timed out")
+		close(fired)
+	})
+	result, err := fn()
+	if !timer.Stop() {
+		<-fired
+	}
+	p.vm.ClearInterrupt()
+	return result, err
+}
+
+// call runs a hook of the plugin with args, interrupting it after
+// PluginTimeout, and returns its result as a Go value (nil for undefined
+// and null). It reports false when the plugin does not define the hook.
+// On error, failures is the plugin's count of failed calls, this one
+// included.
+func (p *plugin) call(hook string, args ...any) (result any, ok bool, failures int64, err error) {
+	fn, ok := p.hooks[hook]
+	if !ok {
+		return nil, false, 0, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	values := make([]goja.Value, len(args))
+	for i, a := range args {
+		values[i] = p.vm.ToValue(a)
+	}
+	value, err := p.interruptible(func() (goja.Value, error) {
+		return fn(goja.Undefined(), values...)
+	})
+	if err != nil {
+		p.errors++
+		return nil, true, p.errors, err
+	}
+	return value.Export(), true, 0, nil
+}
+
+// fail counts a failure of the plugin outside its hook calls, and returns
+// its count of failures
+func (p *plugin) fail() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errors++
+	return p.errors
+}
+
+// newPluginSet loads the plugins of dir for a crawl
+func newPluginSet(dir string, log *Logger) (*pluginSet, error) {
+	plugins, err := loadPlugins(dir, log)
+	if err != nil {
+		return nil, err
+	}
+	return &pluginSet{plugins: plugins, log: log}, nil
+}
+
+// names returns the names of the loaded plugins
+func (s *pluginSet) names() []string {
+	names := make([]string, 0, len(s.plugins))
+	for _, p := range s.plugins {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// failed logs a hook error, the plugin's failures-th, as a warning the
+// first time a plugin fails so a broken plugin does not flood the log
+func (s *pluginSet) failed(p *plugin, hook, rawURL string, failures int64, err error) {
+	if failures == 1 {
+		s.log.Warn("Plugin %s: %s failed on %s: %v", p.Name, hook, rawURL, err)
+		return
+	}
+	s.log.Debug("Plugin %s: %s failed on %s: %v", p.Name, hook, rawURL, err)
+}
+
+// filterURL returns the name of the first plugin whose hook returns false
+// for rawURL, or "" when every plugin lets it through. A failing hook lets
+// the URL through.
+func (s *pluginSet) filterURL(rawURL string) string {
+	for _, p := range s.plugins {
+		result, ok, failures, err := p.call(PluginHookFilterURL, rawURL)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			s.failed(p, PluginHookFilterURL, rawURL, failures, err)
+			continue
+		}
+		if allowed, ok := result.(bool); ok && !allowed {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// newPluginPage returns what the transform and extract hooks see of page
+func newPluginPage(page *PageDocument) pluginPage {
+	return pluginPage{
+		URL:   page.URL,
+		HTML:  string(page.Body),
+		Title: collapseSpace(page.Doc.Find("title").First().Text()),
+		Text:  page.Text(),
+	}
+}
+
+// transform passes page through the transform hooks in turn, each seeing
+// the HTML returned by the one before. It returns page itself when no hook
+// changed it, and otherwise a new document so the shared one stays intact
+// for link discovery.
+func (s *pluginSet) transform(page *PageDocument) *PageDocument {
+	var pp *pluginPage
+	changed := false
+	for _, p := range s.plugins {
+		if _, ok := p.hooks[PluginHookTransform]; !ok {
+			continue
+		}
+		if pp == nil {
+			v := newPluginPage(page)
+			pp = &v
+		}
+		result, _, failures, err := p.call(PluginHookTransform, *pp)
+		if err != nil {
+			s.failed(p, PluginHookTransform, page.URL, failures, err)
+			continue
+		}
+		html, ok := result.(string)
+		if !ok || html == pp.HTML {
+			continue
+		}
+		doc, err := NewPageDocument(page.URL, []byte(html))
+		if err != nil {
+			s.failed(p, PluginHookTransform, page.URL, p.fail(), err)
+			continue
+		}
+		pp.HTML = html
+		pp.Title = collapseSpace(doc.Doc.Find("title").First().Text())
+		pp.Text = doc.Text()
+		changed = true
+	}
+	if !changed {
+		return page
+	}
+	transformed, _ := NewPageDocument(page.URL, []byte(pp.HTML))
+	transformed.UserAgent = page.UserAgent
+	return transformed
+}
+
+// extract runs the extract hooks on page and returns their fields by
+// plugin name, or nil when none returned any
+func (s *pluginSet) extract(page *PageDocument) map[string]any {
+	var pp *pluginPage
+	var fields map[string]any
+	for _, p := range s.plugins {
+		if _, ok := p.hooks[PluginHookExtract]; !ok {
+			continue
+		}
+		if pp == nil {
+			v := newPluginPage(page)
+			pp = &v
+		}
+		result, _, failures, err := p.call(PluginHookExtract, *pp)
+		if err != nil {
+			s.failed(p, PluginHookExtract, page.URL, failures, err)
+			continue
+		}
+		if result == nil {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]any)
+		}
+		fields[p.Name] = result
+	}
+	return fields
+}
+
+// pluginFilterReason returns LinkFilterPlugin when a plugin leaves rawURL
+// out, or ""
+func (c *Crawler) pluginFilterReason(rawURL string) string {
+	if c.plugins == nil {
+		return ""
+	}
+	if name := c.plugins.filterURL(rawURL); name != "" {
+		c.log.Debug("Plugin %s left out %s", name, rawURL)
+		return LinkFilterPlugin
+	}
+	return ""
+}
+
+// transformContent returns page as changed by the transform hooks of the
+// plugins, or page itself without any
+func (c *Crawler) transformContent(page *PageDocument) *PageDocument {
+	if c.plugins == nil {
+		return page
+	}
+	return c.plugins.transform(page)
+}
+
+// summary logs the plugins whose hooks failed during the crawl
+func (s *pluginSet) summary() {
+	for _, p := range s.plugins {
+		p.mu.Lock()
+		errors := p.errors
+		p.mu.Unlock()
+		if errors > 0 {
+			s.log.Warn("Plugin %s: %d hook calls failed", p.Name, errors)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writePlugins writes each plugin source to <name>.js in a new directory
+func writePlugins(t *testing.T, plugins map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range plugins {
+		if err := os.WriteFile(filepath.Join(dir, name+PluginExt), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestListPlugins(t *testing.T) {
+	dir := writePlugins(t, map[string]string{
+		"b-extract": `function extract(page) { return {len: page.text.length}; }`,
+		"a-filter":  `function filterURL(url) { return true; } function transform(page) {}`,
+		"helpers":   `var shared = 1;`,
+	})
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a plugin"), 0644)
+
+	plugins, err := ListPlugins(dir)
+	if err != nil {
+		t.Fatalf("ListPlugins() error = %v", err)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("ListPlugins() returned %d plugins, want 2 (no hooks and non-.js files left out): %+v", len(plugins), plugins)
+	}
+	if plugins[0].Name != "a-filter" || !slices.Equal(plugins[0].Hooks, []string{PluginHookFilterURL, PluginHookTransform}) {
+		t.Errorf("first plugin = %+v", plugins[0])
+	}
+	if plugins[1].Name != "b-extract" || !slices.Equal(plugins[1].Hooks, []string{PluginHookExtract}) {
+		t.Errorf("second plugin = %+v", plugins[1])
+	}
+
+	broken := writePlugins(t, map[string]string{"broken": `function filterURL(url) {`})
+	if _, err := ListPlugins(broken); err == nil || !strings.Contains(err.Error(), "plugin broken") {
+		t.Errorf("ListPlugins() error = %v, want a compile error naming the plugin", err)
+	}
+	if _, err := ListPlugins(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestPluginHooks(t *testing.T) {
+	dir := writePlugins(t, map[string]string{
+		"filter":  `function filterURL(url) { return url.indexOf("/private/") < 0; }`,
+		"throws":  `function filterURL(url) { throw new Error("boom"); } function extract(page) { null.x; }`,
+		"upper":   `function transform(page) { return page.html.replace("hello", "HELLO"); }`,
+		"extract": `function extract(page) { return {title: page.title, shouting: page.text.indexOf("HELLO") >= 0}; }`,
+		"loop":    `function transform(page) { if (page.url.indexOf("loop") >= 0) { while (true) {} } }`,
+	})
+	set, err := newPluginSet(dir, &Logger{})
+	if err != nil {
+		t.Fatalf("newPluginSet() error = %v", err)
+	}
+
+	if got := set.filterURL("https://example.com/private/x"); got != "filter" {
+		t.Errorf("filterURL(private) = %q, want filter", got)
+	}
+	if got := set.filterURL("https://example.com/public"); got != "" {
+		t.Errorf("filterURL(public) = %q, want it let through despite the throwing plugin", got)
+	}
+
+	page, _ := NewPageDocument("https://example.com/a", []byte(`<html><head><title>Greeting</title></head><body><p>hello world</p></body></html>`))
+	transformed := set.transform(page)
+	if transformed == page || !strings.Contains(string(transformed.Body), "HELLO") {
+		t.Fatalf("transform() = %s, want the upper plugin's HTML", transformed.Body)
+	}
+	if !strings.Contains(string(page.Body), "hello world") {
+		t.Error("transform() changed the shared page")
+	}
+
+	fields := set.extract(transformed)
+	extracted, ok := fields["extract"].(map[string]any)
+	if !ok || extracted["title"] != "Greeting" || extracted["shouting"] != true {
+		t.Errorf("extract() = %v, want the extract plugin's fields of the transformed page", fields)
+	}
+	if _, ok := fields["throws"]; ok {
+		t.Error("extract() kept the result of a throwing hook")
+	}
+
+	unchanged, _ := NewPageDocument("https://example.com/b", []byte(`<html><body>nothing to change</body></html>`))
+	if set.transform(unchanged) != unchanged {
+		t.Error("transform() made a new document without any change")
+	}
+
+	if testing.Short() {
+		return
+	}
+	looping, _ := NewPageDocument("https://example.com/loop", []byte(`<html><body>hello</body></html>`))
+	if got := set.transform(looping); !strings.Contains(string(got.Body), "HELLO") {
+		t.Errorf("transform() after a timed out hook = %s", got.Body)
+	}
+}
+
+func TestPluginFailureCount(t *testing.T) {
+	dir := writePlugins(t, map[string]string{
+		"throws": `function filterURL(url) { throw new Error("boom"); }`,
+	})
+	set, err := newPluginSet(dir, &Logger{})
+	if err != nil {
+		t.Fatalf("newPluginSet() error = %v", err)
+	}
+	p := set.plugins[0]
+
+	// Each failing call gets its own count, so exactly one sees the first
+	const calls = 20
+	counts := make(chan int64, calls)
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, failures, _ := p.call(PluginHookFilterURL, "https://example.com/")
+			counts <- failures
+		}()
+	}
+	wg.Wait()
+	close(counts)
+	seen := make(map[int64]bool)
+	for n := range counts {
+		if n < 1 || n > calls || seen[n] {
+			t.Errorf("failure count %d repeated or out of range", n)
+		}
+		seen[n] = true
+	}
+}
+
+func TestPluginsCrawl(t *testing.T) {
+	text := strings.Repeat("Readable article text. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Home</title></head><body><p>%s</p><div class="ad">Buy now</div><a href="/post">Post</a><a href="/login">Log in</a></body></html>`, text)
+	})
+	mux.HandleFunc("/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Post</title></head><body><p>%s</p><span class="price">12.50</span></body></html>`, text)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("/login was fetched despite the filterURL hook")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dir := writePlugins(t, map[string]string{
+		"site": `
+function filterURL(url) { return !/\/login$/.test(url); }
+function transform(page) { return page.html.replace('<div class="ad">Buy now</div>', ''); }
+function extract(page) {
+  var m = /class="price">([0-9.]+)</.exec(page.html);
+  return m ? {price: parseFloat(m[1])} : null;
+}`,
+	})
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		PluginsDir:       dir,
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	c, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if saved := c.GetMetrics().GetSnapshot().URLsSaved; saved != 2 {
+		t.Errorf("saved %d pages, want 2", saved)
+	}
+
+	index, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(index), "Buy now") {
+		t.Error("index.html still has the ad removed by the transform hook")
+	}
+
+	var meta map[string]any
+	data, err := os.ReadFile(filepath.Join(outputDir, "post.meta.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(data, &meta)
+	plugins, _ := meta["plugins"].(map[string]any)
+	site, _ := plugins["site"].(map[string]any)
+	if site["price"] != 12.5 {
+		t.Errorf("post.meta.json plugins = %v, want the extracted price", meta["plugins"])
+	}
+	data, _ = os.ReadFile(filepath.Join(outputDir, "index.meta.json"))
+	if strings.Contains(string(data), `"plugins"`) {
+		t.Error("index.meta.json has plugin fields although extract returned null")
+	}
+}
+
+func TestValidateConfigPlugins(t *testing.T) {
+	dir := writePlugins(t, map[string]string{"broken": `function transform(page) { return`})
+	config := Config{URL: "https://example.com/", MaxDepth: 1, OutputDir: t.TempDir(), PluginsDir: dir}
+	if err := ValidateConfig(&config); err == nil || !strings.Contains(err.Error(), "plugin broken") {
+		t.Errorf("ValidateConfig() error = %v, want the plugin compile error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Defaults of polite mode
+const (
+	DefaultPoliteMinDelay        = time.Second // Shortest delay between requests
+	DefaultPoliteHostConcurrency = 2           // Requests in flight to one host
+)
+
+// contactPattern finds a URL or an email address in a user agent
+var contactPattern = regexp.MustCompile(`(?i)https?://[^\s;)]+|[^\s@;()<>]+@[^\s@;()<>]+\.[a-z]{2,}`)
+
+// PolitePolicy is a set of guardrails a deployment enforces on every crawl
+// it runs, so operators can offer crawling to others without their server
+// being used against the sites it visits: robots.txt is always obeyed, the
+// delay between requests has a floor, each host gets a limited number of
+// requests at once, and the crawler identifies itself with a user agent
+// site owners can reach someone through.
+type PolitePolicy struct {
+	MinDelay        time.Duration `json:"minDelay"`            // Delays below this are raised to it
+	HostConcurrency int           `json:"hostConcurrency"`     // Requests in flight to one host at once (0 = unlimited)
+	UserAgent       string        `json:"userAgent,omitempty"` // Sent by crawls that set none (default DefaultUserAgent)
+}
+
+// DefaultPolitePolicy returns the policy of polite mode with its defaults
+func DefaultPolitePolicy() PolitePolicy {
+	return PolitePolicy{MinDelay: DefaultPoliteMinDelay, HostConcurrency: DefaultPoliteHostConcurrency}
+}
+
+// Validate checks the policy itself
+func (p PolitePolicy) Validate() error {
+	if p.MinDelay < 0 {
+		return fmt.Errorf("polite minimum delay must not be negative, got: %s", p.MinDelay)
+	}
+	if p.HostConcurrency < 0 {
+		return fmt.Errorf("polite host concurrency must not be negative, got: %d", p.HostConcurrency)
+	}
+	if p.UserAgent != "" && !HasContact(p.UserAgent) {
+		return fmt.Errorf("polite user agent must contain a URL or email address to reach its operator, got: %s", p.UserAgent)
+	}
+	return nil
+}
+
+// HasContact reports whether a user agent tells site owners how to reach
+// the crawler's operator, with a URL or an email address
+func HasContact(userAgent string) bool {
+	return contactPattern.MatchString(userAgent)
+}
+
+// Enforce applies the policy to config. Settings that defeat it (ignoring
+// robots.txt, rotating or anonymous user agents) are refused with an error;
+// settings it only bounds are adjusted, and each adjustment is described in
+// the returned notes. A config without a host limiter gets one of its own
+// for the concurrency cap; callers that share one across crawls set it
+// first.
+func (p PolitePolicy) Enforce(config *Config) ([]string, error) {
+	if config.IgnoreRobots {
+		return nil, fmt.Errorf("polite mode: robots.txt can't be ignored")
+	}
+	if config.AntiBot.RotateUserAgent {
+		return nil, fmt.Errorf("polite mode: user agents can't be rotated, the crawler must identify itself")
+	}
+	if len(config.UserAgents) > 0 {
+		return nil, fmt.Errorf("polite mode: user agent lists can't be used, the crawler must identify itself")
+	}
+	if config.UserAgent != "" && !HasContact(config.UserAgent) {
+		return nil, fmt.Errorf("polite mode: user agent %q has no URL or email address to reach its operator", config.UserAgent)
+	}
+
+	var notes []string
+	if config.UserAgent == "" && p.UserAgent != "" {
+		config.UserAgent = p.UserAgent
+	}
+	if config.Delay < p.MinDelay {
+		notes = append(notes, fmt.Sprintf("polite mode: delay raised from %v to %v", config.Delay, p.MinDelay))
+		config.Delay = p.MinDelay
+	}
+	if p.HostConcurrency > 0 && config.HostLimiter == nil {
+		config.HostLimiter = NewHostLimiter(0)
+		config.HostLimiter.SetHostConcurrency(p.HostConcurrency)
+	}
+	return notes, nil
+}
+
+// String describes the policy for logs
+func (p PolitePolicy) String() string {
+	parts := []string{"robots.txt obeyed", fmt.Sprintf("delay at least %v", p.MinDelay)}
+	if p.HostConcurrency > 0 {
+		parts = append(parts, fmt.Sprintf("at most %d requests at once per host", p.HostConcurrency))
+	}
+	parts = append(parts, "contactable user agent required")
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHasContact(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      bool
+	}{
+		{DefaultUserAgent, true},
+		{"ResearchBot/2.0 (+http://lab.example.edu/bot)", true},
+		{"ResearchBot/2.0 (crawler@lab.example.edu)", true},
+		{"ResearchBot/2.0", false},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36", false},
+		{"Bot/1.0 (@handle)", false},
+	}
+	for _, tt := range tests {
+		if got := HasContact(tt.userAgent); got != tt.want {
+			t.Errorf("HasContact(%q) = %v, want %v", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func TestPolitePolicyEnforce(t *testing.T) {
+	policy := DefaultPolitePolicy()
+	policy.UserAgent = "OrgBot/1.0 (+https://org.example/bot)"
+
+	config := Config{URL: "https://example.com/", Delay: 100 * time.Millisecond}
+	notes, err := policy.Enforce(&config)
+	if err != nil {
+		t.Fatalf("Enforce() error = %v", err)
+	}
+	if config.Delay != time.Second || len(notes) != 1 || !strings.Contains(notes[0], "delay raised") {
+		t.Errorf("delay = %v with notes %v, want it raised to 1s", config.Delay, notes)
+	}
+	if config.UserAgent != policy.UserAgent {
+		t.Errorf("user agent = %q, want the policy's", config.UserAgent)
+	}
+	if config.HostLimiter == nil || config.HostLimiter.HostConcurrency() != DefaultPoliteHostConcurrency {
+		t.Errorf("host limiter = %+v, want a cap of %d requests per host", config.HostLimiter, DefaultPoliteHostConcurrency)
+	}
+
+	shared := NewHostLimiter(time.Second)
+	config = Config{URL: "https://example.com/", Delay: 2 * time.Second, UserAgent: "Bot/1.0 (ops@example.com)", HostLimiter: shared}
+	if notes, err := policy.Enforce(&config); err != nil || len(notes) != 0 || config.HostLimiter != shared || config.UserAgent != "Bot/1.0 (ops@example.com)" {
+		t.Errorf("Enforce() = %v, %v; want the config left as it was", notes, err)
+	}
+
+	refused := []Config{
+		{IgnoreRobots: true},
+		{AntiBot: AntiBotConfig{RotateUserAgent: true}},
+		{UserAgents: []string{"Bot/1.0 (+https://example.com)"}},
+		{UserAgent: "Mozilla/5.0 (X11; Linux x86_64)"},
+	}
+	for _, config := range refused {
+		if _, err := policy.Enforce(&config); err == nil {
+			t.Errorf("Enforce(%+v) should be refused", config)
+		}
+	}
+}
+
+func TestValidatePolitePolicy(t *testing.T) {
+	tests := []struct {
+		policy PolitePolicy
+		ok     bool
+	}{
+		{DefaultPolitePolicy(), true},
+		{PolitePolicy{UserAgent: "OrgBot/1.0 (+https://org.example/bot)"}, true},
+		{PolitePolicy{MinDelay: -time.Second}, false},
+		{PolitePolicy{HostConcurrency: -1}, false},
+		{PolitePolicy{UserAgent: "OrgBot/1.0"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok %v", tt.policy, err, tt.ok)
+		}
+	}
+}
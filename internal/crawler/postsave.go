@@ -0,0 +1,264 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPostSaveConcurrency = 2                // Commands running at once
+	DefaultPostSaveTimeout     = 60 * time.Second // Time a command may run
+	postSaveQueue              = 256              // Pages waiting for a command before saving blocks
+	postSaveMaxOutput          = 2048             // Bytes of a failed command's output kept for the log
+)
+
+// What happens after a post-save command fails
+const (
+	PostSaveFailureWarn    = "warn"    // Log it and run the command for the next pages
+	PostSaveFailureDisable = "disable" // Run the command for no more pages
+	PostSaveFailureStop    = "stop"    // Stop the crawl
+)
+
+// ValidPostSaveOnFailure reports whether policy is a post-save failure
+// policy. An empty policy is treated as PostSaveFailureWarn.
+func ValidPostSaveOnFailure(policy string) bool {
+	switch policy {
+	case "", PostSaveFailureWarn, PostSaveFailureDisable, PostSaveFailureStop:
+		return true
+	}
+	return false
+}
+
+func validatePostSave(config *Config) error {
+	if config.PostSaveConcurrency < 0 {
+		return fmt.Errorf("post-save concurrency must not be negative, got: %d", config.PostSaveConcurrency)
+	}
+	if config.PostSaveTimeout < 0 {
+		return fmt.Errorf("post-save timeout must not be negative, got: %s", config.PostSaveTimeout)
+	}
+	if !ValidPostSaveOnFailure(config.PostSaveOnFailure) {
+		return fmt.Errorf("post-save on-failure must be one of: %s, %s, %s, got: %s", PostSaveFailureWarn, PostSaveFailureDisable, PostSaveFailureStop, config.PostSaveOnFailure)
+	}
+	return nil
+}
+
+// postSaveJob is a saved page waiting for the post-save command
+type postSaveJob struct {
+	url      string
+	file     string // The saved HTML, compressed when CompressOutput is set
+	metaFile string
+	metadata []byte // Contents of metaFile, written to the command's stdin
+}
+
+// postSaver runs the post-save command on saved pages from a pool of
+// goroutines. What happens after a command fails, exits non-zero or times
+// out is up to the failure policy: warn and go on, stop running it, or stop
+// the crawl.
+type postSaver struct {
+	command     string
+	concurrency int
+	timeout     time.Duration
+	onFailure   string
+	jobID       string
+	outputDir   string
+	log         *Logger
+	jobs        chan postSaveJob
+	wg          sync.WaitGroup
+
+	mu        sync.Mutex // Guards the counters below
+	ran       int
+	failed    int
+	disabled  bool
+	stop      bool // A command failed under PostSaveFailureStop
+	lastError error
+}
+
+// newPostSaver creates a post-saver for the command of config, applying
+// the defaults
+func newPostSaver(config *Config, log *Logger) *postSaver {
+	p := &postSaver{
+		command:     config.PostSaveCommand,
+		concurrency: config.PostSaveConcurrency,
+		timeout:     config.PostSaveTimeout,
+		onFailure:   config.PostSaveOnFailure,
+		jobID:       config.JobID,
+		outputDir:   config.OutputDir,
+		log:         log,
+	}
+	if p.concurrency == 0 {
+		p.concurrency = DefaultPostSaveConcurrency
+	}
+	if p.timeout == 0 {
+		p.timeout = DefaultPostSaveTimeout
+	}
+	if p.onFailure == "" {
+		p.onFailure = PostSaveFailureWarn
+	}
+	if abs, err := filepath.Abs(p.outputDir); err == nil {
+		p.outputDir = abs
+	}
+	return p
+}
+
+// start begins taking pages
+func (p *postSaver) start() {
+	p.ran, p.failed, p.disabled, p.stop, p.lastError = 0, 0, false, false, nil
+	p.jobs = make(chan postSaveJob, postSaveQueue)
+	for i := 0; i < p.concurrency; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				p.process(job)
+			}
+		}()
+	}
+}
+
+// add queues a saved page, unless the command was turned off
+func (p *postSaver) add(job postSaveJob) {
+	if p.off() {
+		return
+	}
+	p.jobs <- job
+}
+
+// off reports whether the command is no longer run, after a failure under
+// the disable or stop policy
+func (p *postSaver) off() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.disabled || p.stop
+}
+
+// stopRequested reports whether a command failed under PostSaveFailureStop
+func (p *postSaver) stopRequested() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stop
+}
+
+// close waits for the queued pages and stops the post-saver
+func (p *postSaver) close() {
+	close(p.jobs)
+	p.wg.Wait()
+	if p.failed > 0 {
+		p.log.Warn("Post-save command ran on %d pages; %d failed, the last error was: %v", p.ran, p.failed, p.lastError)
+	} else if p.ran > 0 {
+		p.log.Info("Post-save command ran on %d pages", p.ran)
+	}
+}
+
+// process runs the command on a page and applies the failure policy
+func (p *postSaver) process(job postSaveJob) {
+	if p.off() {
+		return
+	}
+	err := p.run(job)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ran++
+	if err == nil {
+		return
+	}
+	p.failed++
+	p.lastError = err
+	switch p.onFailure {
+	case PostSaveFailureDisable:
+		if !p.disabled {
+			p.disabled = true
+			p.log.Warn("Post-save command failed on %s: %v; it is not run for the remaining pages", job.url, err)
+		}
+	case PostSaveFailureStop:
+		if !p.stop {
+			p.stop = true
+			p.log.Warn("Post-save command failed on %s: %v; stopping crawl", job.url, err)
+		}
+	default:
+		if p.failed == 1 {
+			p.log.Warn("Post-save command failed on %s: %v", job.url, err)
+		} else {
+			p.log.Debug("Post-save command failed on %s: %v", job.url, err)
+		}
+	}
+}
+
+// run runs the command through the shell with the page's metadata JSON on
+// stdin, killing it after the timeout
+func (p *postSaver) run(job postSaveJob) error {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	file := job.file
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		// cmd has no positional parameters, and expands %...% even inside
+		// quotes, so the file is only passed as %SCRAPER_FILE%
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.command)
+	} else {
+		// The file is $1 of the command, so it needs no quoting
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.command, "sh", file)
+	}
+	metaFile := job.metaFile
+	if abs, err := filepath.Abs(metaFile); err == nil {
+		metaFile = abs
+	}
+	cmd.Env = append(os.Environ(),
+		"SCRAPER_FILE="+file,
+		"SCRAPER_META_FILE="+metaFile,
+		"SCRAPER_URL="+job.url,
+		"SCRAPER_JOB_ID="+p.jobID,
+		"SCRAPER_OUTPUT_DIR="+p.outputDir,
+	)
+	cmd.Stdin = bytes.NewReader(job.metadata)
+	output := &cappedOutput{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// Children left holding the output open do not keep the worker waiting
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", p.timeout)
+	}
+	if err != nil {
+		if out := strings.TrimSpace(strings.ToValidUTF8(output.buf.String(), "")); out != "" {
+			if output.truncated {
+				out += "..."
+			}
+			return fmt.Errorf("%v: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// cappedOutput keeps the first postSaveMaxOutput bytes of a command's
+// output and discards the rest. Set as both stdout and stderr, it is
+// written by one goroutine at a time.
+type cappedOutput struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (o *cappedOutput) Write(b []byte) (int, error) {
+	if room := postSaveMaxOutput - o.buf.Len(); room < len(b) {
+		o.buf.Write(b[:max(room, 0)])
+		o.truncated = true
+	} else {
+		o.buf.Write(b)
+	}
+	return len(b), nil
+}
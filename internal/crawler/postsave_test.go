@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skipWithoutShell(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("post-save command tests use sh")
+	}
+}
+
+func TestPostSaveCommand(t *testing.T) {
+	skipWithoutShell(t)
+	site := embeddingsSite()
+	defer site.Close()
+
+	// Each run copies stdin and the environment to files named after the page
+	received := t.TempDir()
+	t.Setenv("POSTSAVE_OUT", received)
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         1,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		JobID:            "job-1",
+		PostSaveCommand:  `name=$(basename "$1"); cat > "$POSTSAVE_OUT/$name.json"; printf '%s\n%s\n%s\n' "$SCRAPER_FILE" "$SCRAPER_URL" "$SCRAPER_JOB_ID" > "$POSTSAVE_OUT/$name.env"`,
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	for _, name := range []string{"index.html", "a.html", "b.html"} {
+		data, err := os.ReadFile(filepath.Join(received, name+".json"))
+		if err != nil {
+			t.Fatalf("command not run for %s: %v", name, err)
+		}
+		var meta map[string]any
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Fatalf("stdin of %s is not the metadata JSON: %s", name, data)
+		}
+		saved, _ := os.ReadFile(filepath.Join(outputDir, strings.TrimSuffix(name, ".html")+".meta.json"))
+		if string(data) != string(saved) {
+			t.Errorf("stdin of %s = %s, want its .meta.json %s", name, data, saved)
+		}
+
+		env, _ := os.ReadFile(filepath.Join(received, name+".env"))
+		lines := strings.Split(strings.TrimSpace(string(env)), "\n")
+		if len(lines) != 3 || !filepath.IsAbs(lines[0]) || filepath.Base(lines[0]) != name || lines[1] != meta["url"] || lines[2] != "job-1" {
+			t.Errorf("environment of %s = %q, want the absolute file, the URL and the job ID", name, lines)
+		}
+	}
+}
+
+func TestPostSaveFailurePolicies(t *testing.T) {
+	skipWithoutShell(t)
+	tests := []struct {
+		policy string
+		ran    int
+		off    bool
+		stop   bool
+	}{
+		{PostSaveFailureWarn, 3, false, false},
+		{PostSaveFailureDisable, 1, true, false},
+		{PostSaveFailureStop, 1, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			p := newPostSaver(&Config{
+				PostSaveCommand:   `echo "cannot convert $1" >&2; exit 3`,
+				PostSaveOnFailure: tt.policy,
+			}, &Logger{})
+			// One page after the other, so the policy applies before the next
+			for _, file := range []string{"a.html", "b.html", "c.html"} {
+				p.process(postSaveJob{url: "https://example.com/" + file, file: file})
+			}
+
+			if p.ran != tt.ran || p.failed != tt.ran || p.off() != tt.off || p.stopRequested() != tt.stop {
+				t.Errorf("ran %d, failed %d, off %v, stop %v; want %d, %d, %v, %v", p.ran, p.failed, p.off(), p.stopRequested(), tt.ran, tt.ran, tt.off, tt.stop)
+			}
+			if p.lastError == nil || !strings.Contains(p.lastError.Error(), "exit status 3: cannot convert") {
+				t.Errorf("last error = %v, want the exit status and output", p.lastError)
+			}
+		})
+	}
+}
+
+func TestPostSaveTimeout(t *testing.T) {
+	skipWithoutShell(t)
+	p := newPostSaver(&Config{PostSaveCommand: "sleep 5", PostSaveTimeout: 100 * time.Millisecond}, &Logger{})
+	start := time.Now()
+	err := p.run(postSaveJob{url: "https://example.com/", file: "index.html"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("run() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("run() took %s, want the command killed at the timeout", elapsed)
+	}
+}
+
+func TestPostSaveStopsCrawl(t *testing.T) {
+	skipWithoutShell(t)
+	site := NewTestSite(TestSiteOptions{Pages: 40, Latency: 20 * time.Millisecond})
+	defer site.Close()
+
+	config := selfTestConfig(site, t.TempDir())
+	config.PostSaveCommand = "exit 1"
+	config.PostSaveOnFailure = PostSaveFailureStop
+	c, err := runSelfTestCrawl(t.Context(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if reason := c.StopReason(); reason != StopPostSaveFailed {
+		t.Errorf("StopReason() = %q, want %q", reason, StopPostSaveFailed)
+	}
+	if saved := c.GetMetrics().GetSnapshot().URLsSaved; saved >= int64(site.Pages()) {
+		t.Errorf("saved all %d pages despite the failed command", saved)
+	}
+}
+
+func TestValidateConfigPostSave(t *testing.T) {
+	config := Config{URL: "https://example.com/", MaxDepth: 1, OutputDir: t.TempDir(), PostSaveCommand: "true", PostSaveOnFailure: "retry"}
+	if err := ValidateConfig(&config); err == nil || !strings.Contains(err.Error(), "on-failure") {
+		t.Errorf("ValidateConfig() error = %v, want the failure policy rejected", err)
+	}
+	config.PostSaveOnFailure = PostSaveFailureStop
+	config.PostSaveTimeout = -time.Second
+	if err := ValidateConfig(&config); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("ValidateConfig() error = %v, want the negative timeout rejected", err)
+	}
+}
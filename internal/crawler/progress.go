@@ -0,0 +1,302 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress output formats
+const (
+	ProgressFormatText   = "text"   // Progress line or panel and a final summary for people
+	ProgressFormatNDJSON = "ndjson" // One JSON record per line for scripts
+)
+
+// Types of the records in the NDJSON progress stream
+const (
+	ProgressRecordProgress = "progress" // Written every MetricsDisplayInterval while crawling
+	ProgressRecordSummary  = "summary"  // Written once, as the last record
+)
+
+// ValidProgressFormat reports whether format is a progress output format.
+// An empty format is treated as ProgressFormatText.
+func ValidProgressFormat(format string) bool {
+	switch format {
+	case "", ProgressFormatText, ProgressFormatNDJSON:
+		return true
+	}
+	return false
+}
+
+// ProgressRecord is one line of the NDJSON progress stream. Metrics holds
+// the same fields as the metrics JSON file.
+type ProgressRecord struct {
+	Type       string          `json:"type"`
+	Time       time.Time       `json:"time"`
+	CurrentURL string          `json:"current_url,omitempty"`
+	Metrics    *CrawlerMetrics `json:"metrics"`
+}
+
+// WriteProgressRecord writes the current metrics to w as one JSON line of
+// recordType. The summary record finalizes the metrics first.
+func (m *CrawlerMetrics) WriteProgressRecord(w io.Writer, recordType, currentURL string) error {
+	if recordType == ProgressRecordSummary {
+		m.Finalize()
+	}
+	snapshot := m.GetSnapshot()
+	data, err := json.Marshal(ProgressRecord{
+		Type:       recordType,
+		Time:       time.Now(),
+		CurrentURL: currentURL,
+		Metrics:    &snapshot,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %v", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// RecentErrorsKept is how many of the latest errors the metrics keep
+const RecentErrorsKept = 5
+
+// progressPanelURLWidth bounds the URLs shown in the progress panel, so
+// every entry stays on one terminal line
+const progressPanelURLWidth = 90
+
+// ActiveFetch is a URL a worker is processing
+type ActiveFetch struct {
+	URL       string    `json:"url"`
+	Started   time.Time `json:"started"`
+	Clicks    int       `json:"clicks,omitempty"`    // Pagination clicks done, for URLs paginated in browser mode
+	MaxClicks int       `json:"maxClicks,omitempty"` // Pagination clicks allowed
+}
+
+// RecentError is one of the latest URLs that failed
+type RecentError struct {
+	URL    string    `json:"url"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// StartFetch marks rawURL as being processed by a worker
+func (m *CrawlerMetrics) StartFetch(rawURL string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Active = append(m.Active, ActiveFetch{URL: rawURL, Started: time.Now()})
+}
+
+// EndFetch marks rawURL as no longer being processed
+func (m *CrawlerMetrics) EndFetch(rawURL string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, a := range m.Active {
+		if a.URL == rawURL {
+			m.Active = append(m.Active[:i], m.Active[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetPaginationClicks records how many of its maxClicks pagination clicks
+// the worker processing rawURL has done
+func (m *CrawlerMetrics) SetPaginationClicks(rawURL string, clicks, maxClicks int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.Active {
+		if m.Active[i].URL == rawURL {
+			m.Active[i].Clicks = clicks
+			m.Active[i].MaxClicks = maxClicks
+			return
+		}
+	}
+}
+
+// rateSinceDisplay returns the pages processed per second since progress
+// was last displayed
+func (m *CrawlerMetrics) rateSinceDisplay() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	since := time.Since(m.lastDisplayTime).Seconds()
+	if since <= 0 {
+		return 0
+	}
+	return float64(m.URLsProcessed-m.lastDisplayCount) / since
+}
+
+// RecordError keeps rawURL among the latest errors
+func (m *CrawlerMetrics) RecordError(rawURL, reason string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.RecentErrors = append(m.RecentErrors, RecentError{URL: rawURL, Reason: reason, Time: time.Now()})
+	if len(m.RecentErrors) > RecentErrorsKept {
+		m.RecentErrors = append([]RecentError(nil), m.RecentErrors[len(m.RecentErrors)-RecentErrorsKept:]...)
+	}
+}
+
+// progressPanel draws the multi-line progress panel on a terminal. It is
+// also the log output while the crawl runs, so log lines are printed above
+// the panel instead of being overwritten by its next redraw.
+type progressPanel struct {
+	mu    sync.Mutex
+	out   io.Writer // The terminal the panel is drawn on
+	logs  io.Writer // Where log lines go
+	shown string    // Panel currently on screen
+}
+
+// newProgressPanel returns a panel drawn on stdout that takes over the log
+// output until it is closed
+func newProgressPanel() *progressPanel {
+	p := &progressPanel{out: os.Stdout, logs: log.Writer()}
+	log.SetOutput(p)
+	return p
+}
+
+// erase clears the panel from the screen. Caller must hold p.mu.
+func (p *progressPanel) erase() {
+	if lines := strings.Count(p.shown, "\n"); lines > 0 {
+		// Move to the first line of the panel and clear to the end of screen
+		fmt.Fprintf(p.out, "\033[%dA\r\033[J", lines)
+	}
+}
+
+// draw replaces the panel on screen with panel
+func (p *progressPanel) draw(panel string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.erase()
+	fmt.Fprint(p.out, panel)
+	p.shown = panel
+}
+
+// Write prints a log line above the panel
+func (p *progressPanel) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.erase()
+	n, err := p.logs.Write(b)
+	fmt.Fprint(p.out, p.shown)
+	return n, err
+}
+
+// close restores the log output, leaving the last panel on screen
+func (p *progressPanel) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	log.SetOutput(p.logs)
+	p.shown = ""
+}
+
+// FormatProgressPanel renders the progress panel for snapshot. recentRate
+// is the throughput since the previous redraw.
+func FormatProgressPanel(snapshot *CrawlerMetrics, recentRate float64, now time.Time) string {
+	var sb strings.Builder
+
+	total := snapshot.URLsProcessed + int64(snapshot.QueueSize)
+	progress := "..."
+	if total > 0 {
+		progress = fmt.Sprintf("%.1f%%", float64(snapshot.URLsProcessed)/float64(total)*100)
+	}
+	elapsed := now.Sub(snapshot.StartTime)
+	var bytesPerSecond float64
+	if elapsed > 0 {
+		bytesPerSecond = float64(snapshot.BytesDownloaded) / elapsed.Seconds()
+	}
+	var errorShare float64
+	if snapshot.URLsProcessed > 0 {
+		errorShare = float64(snapshot.URLsErrored) / float64(snapshot.URLsProcessed) * 100
+	}
+
+	fmt.Fprintf(&sb, "[%s] %s | %d processed | %d saved | %d skipped | %d errors | %d in queue\n",
+		FormatDuration(elapsed), progress, snapshot.URLsProcessed, snapshot.URLsSaved, snapshot.URLsSkipped, snapshot.URLsErrored, snapshot.QueueSize)
+	fmt.Fprintf(&sb, "Rate: %.2f p/s now, %.2f p/s overall | %s/s | %.1f%% errors | heap %s\n",
+		recentRate, snapshot.PagesPerSecond, FormatBytes(int64(bytesPerSecond)), errorShare, FormatBytes(snapshot.HeapAlloc))
+
+	fmt.Fprintf(&sb, "Workers (%d active):\n", len(snapshot.Active))
+	for _, a := range snapshot.Active {
+		var clicks string
+		if a.MaxClicks > 0 {
+			clicks = fmt.Sprintf("  [%d/%d clicks]", a.Clicks, a.MaxClicks)
+		}
+		fmt.Fprintf(&sb, "  %6s  %s%s\n", now.Sub(a.Started).Round(100*time.Millisecond), truncateMiddle(a.URL, progressPanelURLWidth), clicks)
+	}
+
+	if len(snapshot.RecentErrors) > 0 {
+		sb.WriteString("Recent errors:\n")
+		for i := len(snapshot.RecentErrors) - 1; i >= 0; i-- {
+			e := snapshot.RecentErrors[i]
+			fmt.Fprintf(&sb, "  %s  %s: %s\n", e.Time.Format("15:04:05"), truncateMiddle(e.URL, progressPanelURLWidth), e.Reason)
+		}
+	}
+	return sb.String()
+}
+
+// truncateMiddle shortens s to at most width characters by replacing its
+// middle with an ellipsis, keeping the host and the end of a URL readable
+func truncateMiddle(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width || width < 5 {
+		return s
+	}
+	head := (width - 1) / 2
+	tail := width - 1 - head
+	return string(r[:head]) + "…" + string(r[len(r)-tail:])
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal that the progress
+// panel can redraw in place
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb"
+}
+
+// displayProgress writes a progress record in the NDJSON format, redraws
+// the progress panel when there is one, or prints the progress line
+func (c *Crawler) displayProgress(currentURL string) {
+	if c.config.ProgressFormat == ProgressFormatNDJSON {
+		if err := c.metrics.WriteProgressRecord(os.Stdout, ProgressRecordProgress, currentURL); err != nil {
+			c.log.Warn("Failed to write progress: %v", err)
+		}
+		c.metrics.MarkDisplayed()
+		return
+	}
+	if c.panel == nil {
+		c.metrics.DisplayProgress(c.log.IsVerbose())
+		return
+	}
+	snapshot := c.metrics.GetSnapshot()
+	c.panel.draw(FormatProgressPanel(&snapshot, c.metrics.rateSinceDisplay(), time.Now()))
+	c.metrics.MarkDisplayed()
+}
+
+// displayFinalSummary writes the summary record in the NDJSON format, or
+// prints the final summary
+func (c *Crawler) displayFinalSummary() {
+	if c.config.ProgressFormat != ProgressFormatNDJSON {
+		c.metrics.DisplayFinalSummary()
+		return
+	}
+	if err := c.metrics.WriteProgressRecord(os.Stdout, ProgressRecordSummary, ""); err != nil {
+		c.log.Warn("Failed to write progress: %v", err)
+	}
+}
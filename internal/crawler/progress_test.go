@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActiveFetchesAndRecentErrors(t *testing.T) {
+	m := NewCrawlerMetrics()
+	m.StartFetch("https://example.com/a")
+	m.StartFetch("https://example.com/b")
+	m.StartFetch("https://example.com/c")
+	m.EndFetch("https://example.com/b")
+	m.SetPaginationClicks("https://example.com/c", 3, 50)
+	for i := 1; i <= RecentErrorsKept+2; i++ {
+		m.RecordError(fmt.Sprintf("https://example.com/err%d", i), "HTTP 500")
+	}
+
+	snapshot := m.GetSnapshot()
+	if len(snapshot.Active) != 2 || snapshot.Active[0].URL != "https://example.com/a" || snapshot.Active[1].URL != "https://example.com/c" {
+		t.Errorf("active = %+v, want a and c", snapshot.Active)
+	}
+	if c := snapshot.Active[1]; c.Clicks != 3 || c.MaxClicks != 50 {
+		t.Errorf("pagination of c = %d/%d clicks, want 3/50", c.Clicks, c.MaxClicks)
+	}
+	if len(snapshot.RecentErrors) != RecentErrorsKept {
+		t.Fatalf("kept %d errors, want %d", len(snapshot.RecentErrors), RecentErrorsKept)
+	}
+	if first := snapshot.RecentErrors[0].URL; first != "https://example.com/err3" {
+		t.Errorf("oldest kept error = %s, want err3", first)
+	}
+
+	// Snapshots must not change when workers finish afterwards
+	m.EndFetch("https://example.com/a")
+	if snapshot.Active[0].URL != "https://example.com/a" {
+		t.Errorf("snapshot changed after EndFetch: %+v", snapshot.Active)
+	}
+}
+
+func TestFormatProgressPanel(t *testing.T) {
+	now := time.Now()
+	m := &CrawlerMetrics{
+		StartTime:     now.Add(-time.Minute),
+		URLsProcessed: 40,
+		URLsSaved:     30,
+		URLsErrored:   4,
+		QueueSize:     60,
+		Active: []ActiveFetch{
+			{URL: "https://example.com/slow", Started: now.Add(-3 * time.Second), Clicks: 4, MaxClicks: 100},
+			{URL: "https://example.com/" + strings.Repeat("x", 200), Started: now},
+		},
+		RecentErrors: []RecentError{
+			{URL: "https://example.com/old", Reason: "HTTP 500", Time: now.Add(-time.Second)},
+			{URL: "https://example.com/new", Reason: "HTTP 404", Time: now},
+		},
+	}
+
+	panel := FormatProgressPanel(m, 1.5, now)
+	for _, want := range []string{"40.0%", "4 errors", "1.50 p/s now", "10.0% errors", "Workers (2 active):", "3s  https://example.com/slow  [4/100 clicks]", "Recent errors:"} {
+		if !strings.Contains(panel, want) {
+			t.Errorf("panel missing %q:\n%s", want, panel)
+		}
+	}
+	if strings.Index(panel, "/new: HTTP 404") > strings.Index(panel, "/old: HTTP 500") {
+		t.Errorf("newest error should come first:\n%s", panel)
+	}
+	for _, line := range strings.Split(panel, "\n") {
+		if len([]rune(line)) > 120 {
+			t.Errorf("line not truncated: %q", line)
+		}
+	}
+}
+
+func TestProgressPanelKeepsLogsAbove(t *testing.T) {
+	var out, logs bytes.Buffer
+	p := &progressPanel{out: &out, logs: &logs}
+
+	p.draw("line 1\nline 2\n")
+	p.Write([]byte("log message\n"))
+	if logs.String() != "log message\n" {
+		t.Errorf("logs = %q", logs.String())
+	}
+	// The panel is erased before the log line and drawn again after it
+	want := "line 1\nline 2\n\033[2A\r\033[Jline 1\nline 2\n"
+	if out.String() != want {
+		t.Errorf("out = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	p.draw("line 3\n")
+	if want := "\033[2A\r\033[Jline 3\n"; out.String() != want {
+		t.Errorf("redraw = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteProgressRecord(t *testing.T) {
+	m := NewCrawlerMetrics()
+	m.IncrementProcessed()
+	m.IncrementSaved(2048)
+	m.SetStopReason(StopQueueEmpty)
+
+	var buf bytes.Buffer
+	if err := m.WriteProgressRecord(&buf, ProgressRecordProgress, "https://example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteProgressRecord(&buf, ProgressRecordSummary, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	var progress, summary struct {
+		Type       string         `json:"type"`
+		CurrentURL string         `json:"current_url"`
+		Metrics    map[string]any `json:"metrics"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &progress); err != nil {
+		t.Fatalf("progress line is not JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &summary); err != nil {
+		t.Fatalf("summary line is not JSON: %v", err)
+	}
+	if progress.Type != ProgressRecordProgress || progress.CurrentURL != "https://example.com/a" || progress.Metrics["urls_saved"] != float64(1) {
+		t.Errorf("progress record = %s", lines[0])
+	}
+	if summary.Type != ProgressRecordSummary || summary.Metrics["stop_reason"] != StopQueueEmpty || summary.Metrics["end_time"] == "0001-01-01T00:00:00Z" {
+		t.Errorf("summary record = %s", lines[1])
+	}
+}
@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// Version is the crawler version recorded in provenance blocks, set at
+// build time with -ldflags "-X scraper/internal/crawler.Version=..."
+var Version = "1.0.0"
+
+// Provenance modes for Config.Provenance
+const (
+	ProvenanceOff     = "off"     // Save pages as fetched (default)
+	ProvenanceComment = "comment" // Prepend an HTML comment recording where and when the page came from
+	ProvenanceBanner  = "banner"  // The comment, plus a visible banner at the top of the body
+)
+
+// Markers delimiting the provenance blocks, so they can be told apart from
+// the page and removed again
+const (
+	provenanceCommentStart = "<!-- scraper-provenance\n"
+	provenanceBannerStart  = "<!--scraper-provenance-banner-->"
+	provenanceBannerEnd    = "<!--/scraper-provenance-banner-->"
+)
+
+// ProvenanceModes returns the valid Config.Provenance values
+func ProvenanceModes() []string {
+	return []string{ProvenanceOff, ProvenanceComment, ProvenanceBanner}
+}
+
+// validateProvenance checks the provenance mode
+func validateProvenance(config *Config) error {
+	switch config.Provenance {
+	case "", ProvenanceOff, ProvenanceComment, ProvenanceBanner:
+		return nil
+	}
+	return fmt.Errorf("provenance must be one of: %s, got: %s", strings.Join(ProvenanceModes(), ", "), config.Provenance)
+}
+
+// pageProvenance is where and when a saved page came from
+type pageProvenance struct {
+	URL       string
+	FetchedAt time.Time
+	Version   string
+	JobID     string
+}
+
+// withProvenance returns body with the provenance block of the configured
+// mode, or body itself when provenance is off
+func (c *Crawler) withProvenance(rawURL string, body []byte, fetchedAt time.Time) []byte {
+	mode := c.config.Provenance
+	if mode != ProvenanceComment && mode != ProvenanceBanner {
+		return body
+	}
+	return injectProvenance(body, mode, pageProvenance{URL: rawURL, FetchedAt: fetchedAt, Version: Version, JobID: c.config.JobID})
+}
+
+// injectProvenance prepends the provenance comment to body and, in banner
+// mode, inserts a visible banner right after the opening <body> tag (at the
+// start of the page when it has none)
+func injectProvenance(body []byte, mode string, p pageProvenance) []byte {
+	var comment strings.Builder
+	comment.WriteString(provenanceCommentStart)
+	fmt.Fprintf(&comment, "source: %s\n", commentSafe(p.URL))
+	fmt.Fprintf(&comment, "fetched: %s\n", p.FetchedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&comment, "crawler: scraper %s\n", commentSafe(p.Version))
+	if p.JobID != "" {
+		fmt.Fprintf(&comment, "job: %s\n", commentSafe(p.JobID))
+	}
+	comment.WriteString("-->\n")
+
+	out := make([]byte, 0, comment.Len()+len(body)+512)
+	out = append(out, comment.String()...)
+	if mode != ProvenanceBanner {
+		return append(out, body...)
+	}
+
+	banner := provenanceBanner(p)
+	at := bodyContentStart(body)
+	out = append(out, body[:at]...)
+	out = append(out, banner...)
+	return append(out, body[at:]...)
+}
+
+// provenanceBanner renders the visible provenance banner
+func provenanceBanner(p pageProvenance) string {
+	job := ""
+	if p.JobID != "" {
+		job = ", job " + html.EscapeString(p.JobID)
+	}
+	return fmt.Sprintf(`%s<div id="scraper-provenance" style="font:13px/1.4 sans-serif;background:#fff8dc;color:#333;border-bottom:1px solid #e0d8b0;padding:6px 10px">`+
+		`Archived copy of <a href="%s">%s</a>, fetched %s by scraper %s%s</div>%s`,
+		provenanceBannerStart, html.EscapeString(p.URL), html.EscapeString(p.URL),
+		p.FetchedAt.UTC().Format("2006-01-02 15:04:05 UTC"), html.EscapeString(p.Version), job,
+		provenanceBannerEnd)
+}
+
+// bodyContentStart returns the offset right after the opening <body> tag
+// of body, or 0 when there is none
+func bodyContentStart(body []byte) int {
+	lower := bytes.ToLower(body)
+	for from := 0; ; {
+		i := bytes.Index(lower[from:], []byte("<body"))
+		if i < 0 {
+			return 0
+		}
+		i += from
+		// <body> or <body attr...>, not <bodyguard>
+		if next := i + len("<body"); next < len(lower) && (lower[next] == '>' || isHTMLSpace(lower[next])) {
+			if end := bytes.IndexByte(lower[next:], '>'); end >= 0 {
+				return next + end + 1
+			}
+			return 0
+		}
+		from = i + 1
+	}
+}
+
+// isHTMLSpace reports whether b is whitespace between HTML attributes
+func isHTMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '/'
+}
+
+// commentSafe keeps a value from closing the provenance comment early
+func commentSafe(s string) string {
+	return strings.NewReplacer("--", "%2D%2D", "\n", " ", "\r", " ").Replace(s)
+}
+
+// stripProvenance returns body without the provenance blocks
+// injectProvenance added, so a saved page compares equal to its fetch
+func stripProvenance(body []byte) []byte {
+	if bytes.HasPrefix(body, []byte(provenanceCommentStart)) {
+		if end := bytes.Index(body, []byte("-->\n")); end >= 0 {
+			body = body[end+len("-->\n"):]
+		}
+	}
+	start := bytes.Index(body, []byte(provenanceBannerStart))
+	if start < 0 {
+		return body
+	}
+	end := bytes.Index(body[start:], []byte(provenanceBannerEnd))
+	if end < 0 {
+		return body
+	}
+	stripped := make([]byte, 0, len(body))
+	stripped = append(stripped, body[:start]...)
+	return append(stripped, body[start+end+len(provenanceBannerEnd):]...)
+}
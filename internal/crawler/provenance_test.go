@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInjectProvenance(t *testing.T) {
+	p := pageProvenance{
+		URL:       "https://example.com/a--b?x=1&y=2",
+		FetchedAt: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC),
+		Version:   "1.2.3",
+		JobID:     "job-42",
+	}
+	body := []byte(`<html><head><title>T</title></head><body class="main"><p>Text</p></body></html>`)
+
+	comment := string(injectProvenance(body, ProvenanceComment, p))
+	for _, want := range []string{
+		"<!-- scraper-provenance\n",
+		"source: https://example.com/a%2D%2Db?x=1&y=2\n",
+		"fetched: 2026-03-04T05:06:07Z\n",
+		"crawler: scraper 1.2.3\n",
+		"job: job-42\n",
+	} {
+		if !strings.Contains(comment, want) {
+			t.Errorf("comment misses %q:\n%s", want, comment)
+		}
+	}
+	if !strings.HasSuffix(comment, string(body)) || strings.Contains(comment, "scraper-provenance-banner") {
+		t.Errorf("comment mode should only prepend the comment:\n%s", comment)
+	}
+
+	banner := string(injectProvenance(body, ProvenanceBanner, p))
+	if !strings.Contains(banner, `<body class="main"><!--scraper-provenance-banner--><div id="scraper-provenance"`) {
+		t.Errorf("banner not right after <body>:\n%s", banner)
+	}
+	if !strings.Contains(banner, `href="https://example.com/a--b?x=1&amp;y=2"`) || !strings.Contains(banner, "job job-42") {
+		t.Errorf("banner misses the escaped URL or job:\n%s", banner)
+	}
+
+	for name, saved := range map[string]string{"comment": comment, "banner": banner} {
+		if got := string(stripProvenance([]byte(saved))); got != string(body) {
+			t.Errorf("%s: stripProvenance() = %q, want the fetched body", name, got)
+		}
+	}
+	if got := string(stripProvenance(body)); got != string(body) {
+		t.Errorf("stripProvenance() changed a page without provenance: %q", got)
+	}
+}
+
+func TestBodyContentStart(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`<html><BODY>x</BODY></html>`, len(`<html><BODY>`)},
+		{`<bodyguard>x</bodyguard><body id="b">`, len(`<bodyguard>x</bodyguard><body id="b">`)},
+		{`<p>fragment</p>`, 0},
+	}
+	for _, tt := range tests {
+		if got := bodyContentStart([]byte(tt.body)); got != tt.want {
+			t.Errorf("bodyContentStart(%q) = %d, want %d", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestValidateProvenance(t *testing.T) {
+	for _, mode := range append(ProvenanceModes(), "") {
+		if err := validateProvenance(&Config{Provenance: mode}); err != nil {
+			t.Errorf("validateProvenance(%q) error = %v", mode, err)
+		}
+	}
+	if err := validateProvenance(&Config{Provenance: "footer"}); err == nil {
+		t.Error("unknown provenance mode should be rejected")
+	}
+}
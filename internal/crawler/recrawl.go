@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+)
+
+// Re-crawl scopes, selecting which URLs of a previous crawl to fetch again
+const (
+	RecrawlFailed  = "failed"  // URLs whose fetch, HTTP status, parse or save failed
+	RecrawlChanged = "changed" // Saved pages, rewritten only when their HTML changed
+	RecrawlPattern = "pattern" // URLs matching a regular expression
+)
+
+// ValidRecrawlScope reports whether scope is a known re-crawl scope
+func ValidRecrawlScope(scope string) bool {
+	switch scope {
+	case RecrawlFailed, RecrawlChanged, RecrawlPattern:
+		return true
+	}
+	return false
+}
+
+// SelectRecrawlURLs picks the URLs of a previous crawl's inventory to fetch
+// again, in inventory order. pattern is required for RecrawlPattern and
+// ignored otherwise.
+func SelectRecrawlURLs(records []URLRecord, scope, pattern string) ([]string, error) {
+	var re *regexp.Regexp
+	switch scope {
+	case RecrawlFailed, RecrawlChanged:
+	case RecrawlPattern:
+		if pattern == "" {
+			return nil, fmt.Errorf("re-crawl pattern is required for scope %q", RecrawlPattern)
+		}
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid re-crawl pattern: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("re-crawl scope must be failed, changed or pattern, got: %s", scope)
+	}
+
+	var urls []string
+	for _, r := range records {
+		switch {
+		case scope == RecrawlFailed && r.Status == URLStatusError,
+			scope == RecrawlChanged && r.Status == URLStatusSaved,
+			re != nil && re.MatchString(r.URL):
+			urls = append(urls, r.URL)
+		}
+	}
+	return urls, nil
+}
+
+// RecrawlStateFile is the state file of re-crawls into outputDir, kept apart
+// from the state of the crawl that wrote it
+func RecrawlStateFile(outputDir string) string {
+	return filepath.Join(outputDir, filepath.Base(outputDir)+"_recrawl_state.json")
+}
+
+// PrepareRecrawl turns config into a re-crawl of the URLs its output
+// directory's inventory selects for scope, returning how many were selected
+func PrepareRecrawl(config *Config, scope, pattern string) (int, error) {
+	records, err := LoadURLInventory(config.OutputDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load URL inventory of %s: %v", config.OutputDir, err)
+	}
+	urls, err := SelectRecrawlURLs(records, scope, pattern)
+	if err != nil {
+		return 0, err
+	}
+	if len(urls) == 0 {
+		return 0, fmt.Errorf("no URLs of the previous crawl match re-crawl scope %q", scope)
+	}
+	config.RecrawlURLs = urls
+	config.RecrawlScope = scope
+	config.StateFile = RecrawlStateFile(config.OutputDir)
+	return len(urls), nil
+}
+
+// queueRecrawl queues the re-crawl URLs at the depth they were found at
+func (c *Crawler) queueRecrawl() {
+	for _, rawURL := range c.config.RecrawlURLs {
+		u := c.resolveAlias(c.normalizeURL(rawURL))
+		if c.state.Queued[u] {
+			continue
+		}
+		depth, _ := c.inventory.Depth(u)
+		c.state.Queue = append(c.state.Queue, URLInfo{URL: u, Depth: depth})
+		c.state.URLDepths[u] = depth
+		c.state.Queued[u] = true
+		c.traceDecision(Decision{Stage: DecisionStageDiscover, URL: u, Depth: depth, Accepted: true, Rule: DecisionStart, Detail: "re-crawl"})
+	}
+}
+
+// unchangedOnDisk reports whether the page stored for rawURL by an earlier
+// crawl has exactly the fetched body
+func (c *Crawler) unchangedOnDisk(rawURL string, body []byte) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	stored, err := ReadOutputFile(filepath.Join(c.config.OutputDir, c.generateFilename(parsedURL)))
+	return err == nil && bytes.Equal(stripProvenance(stored), body)
+}
@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSelectRecrawlURLs(t *testing.T) {
+	records := []URLRecord{
+		{URL: "https://example.com/", Status: URLStatusSaved},
+		{URL: "https://example.com/docs/a", Status: URLStatusSaved},
+		{URL: "https://example.com/docs/b", Status: URLStatusError},
+		{URL: "https://example.com/private", Status: URLStatusBlocked},
+		{URL: "https://example.com/c", Status: URLStatusError},
+	}
+
+	tests := []struct {
+		scope    string
+		pattern  string
+		want     string
+		errorMsg string
+	}{
+		{RecrawlFailed, "", "https://example.com/docs/b,https://example.com/c", ""},
+		{RecrawlChanged, "", "https://example.com/,https://example.com/docs/a", ""},
+		{RecrawlPattern, "/docs/", "https://example.com/docs/a,https://example.com/docs/b", ""},
+		{RecrawlPattern, "", "", "pattern is required"},
+		{RecrawlPattern, "(", "", "invalid re-crawl pattern"},
+		{"all", "", "", "scope must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope+" "+tt.pattern, func(t *testing.T) {
+			urls, err := SelectRecrawlURLs(records, tt.scope, tt.pattern)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("SelectRecrawlURLs() error = %v, want containing %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectRecrawlURLs() error = %v", err)
+			}
+			if got := strings.Join(urls, ","); got != tt.want {
+				t.Errorf("SelectRecrawlURLs() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecrawl(t *testing.T) {
+	body := strings.Repeat("Some meaningful content for the page. ", 10)
+	var failing, version atomic.Int32
+	failing.Store(1)
+	var mu sync.Mutex
+	var fetched []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/flaky" && failing.Load() == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		content := body
+		if r.URL.Path == "/news" {
+			content = fmt.Sprintf("%s version %d", body, version.Load())
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s %s</p><a href="/flaky">f</a><a href="/news">n</a><a href="/about">a</a></body></html>`, r.URL.Path, content)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+	}
+	crawl := func(config Config) []URLRecord {
+		t.Helper()
+		mu.Lock()
+		fetched = nil
+		mu.Unlock()
+		c, err := NewCrawler(config, context.Background())
+		if err != nil {
+			t.Fatalf("NewCrawler() error = %v", err)
+		}
+		defer c.Close()
+		if err := c.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		records, err := LoadURLInventory(outputDir)
+		if err != nil {
+			t.Fatalf("LoadURLInventory() error = %v", err)
+		}
+		return records
+	}
+	status := func(records []URLRecord, path string) (string, string) {
+		for _, r := range records {
+			if r.URL == server.URL+path {
+				return r.Status, r.Reason
+			}
+		}
+		return "", ""
+	}
+
+	records := crawl(config)
+	if s, _ := status(records, "/flaky"); s != URLStatusError {
+		t.Fatalf("/flaky status = %q, want error", s)
+	}
+
+	// Failed URLs only, keeping the rest of the inventory
+	failing.Store(0)
+	recrawl := config
+	if n, err := PrepareRecrawl(&recrawl, RecrawlFailed, ""); err != nil || n != 1 {
+		t.Fatalf("PrepareRecrawl() = %d, %v", n, err)
+	}
+	records = crawl(recrawl)
+	if got := strings.Join(fetched, ","); got != "/flaky" {
+		t.Errorf("failed re-crawl fetched %s, want only /flaky", got)
+	}
+	if s, _ := status(records, "/flaky"); s != URLStatusSaved {
+		t.Errorf("/flaky status = %q after re-crawl, want saved", s)
+	}
+	if len(records) != 4 {
+		t.Errorf("inventory has %d records after re-crawl, want 4", len(records))
+	}
+
+	// Changed pages are rewritten, unchanged ones kept
+	version.Store(1)
+	recrawl = config
+	if n, err := PrepareRecrawl(&recrawl, RecrawlChanged, ""); err != nil || n != 4 {
+		t.Fatalf("PrepareRecrawl() = %d, %v", n, err)
+	}
+	records = crawl(recrawl)
+	if s, reason := status(records, "/news"); s != URLStatusSaved || reason != "" {
+		t.Errorf("/news = %q %q, want saved", s, reason)
+	}
+	if s, reason := status(records, "/about"); s != URLStatusSaved || reason != "unchanged" {
+		t.Errorf("/about = %q %q, want saved unchanged", s, reason)
+	}
+	stored, err := ReadOutputFile(filepath.Join(outputDir, "news.html"))
+	if err != nil || !strings.Contains(string(stored), "version 1") {
+		t.Errorf("news.html was not rewritten: %v", err)
+	}
+
+	// Nothing matching is an error
+	recrawl = config
+	if _, err := PrepareRecrawl(&recrawl, RecrawlPattern, "/missing"); err == nil {
+		t.Error("PrepareRecrawl() with no matching URLs should fail")
+	}
+}
@@ -0,0 +1,251 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// RedirectsFile is the redirect mapping written to the output directory when a crawl ends
+const RedirectsFile = "redirects.json"
+
+var (
+	// ErrRedirectLoop is returned when a redirect leads back to a URL already in its chain
+	ErrRedirectLoop = errors.New("redirect loop")
+	// ErrTooManyRedirects is returned when a redirect chain exceeds MaxRedirects
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// Redirect is one hop of a redirect chain
+type Redirect struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Status int    `json:"status"`
+}
+
+// Permanent reports whether the hop is a permanent redirect (301 or 308)
+func (r Redirect) Permanent() bool {
+	return r.Status == http.StatusMovedPermanently || r.Status == http.StatusPermanentRedirect
+}
+
+// RedirectFailure records a URL whose redirect chain was abandoned
+type RedirectFailure struct {
+	URL    string     `json:"url"`
+	Reason string     `json:"reason"` // "redirect loop" or "too many redirects"
+	Chain  []Redirect `json:"chain"`
+}
+
+// RedirectMap is the content of redirects.json
+type RedirectMap struct {
+	Redirects []Redirect        `json:"redirects"`
+	Failures  []RedirectFailure `json:"failures"`
+	Aliases   map[string]string `json:"aliases"` // URL -> final URL of a chain of permanent redirects
+}
+
+// checkRedirect is the http.Client CheckRedirect policy: it stops chains
+// that revisit a URL or grow longer than MaxRedirects
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	target := req.URL.String()
+	for _, prev := range via {
+		if prev.URL.String() == target {
+			return fmt.Errorf("%w: %s", ErrRedirectLoop, target)
+		}
+	}
+	if len(via) >= MaxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, MaxRedirects)
+	}
+	return nil
+}
+
+// isRedirectError reports whether err ended a fetch because of its redirect chain
+func isRedirectError(err error) bool {
+	return errors.Is(err, ErrRedirectLoop) || errors.Is(err, ErrTooManyRedirects)
+}
+
+// redirectLog collects redirect hops and failed chains seen during a crawl
+type redirectLog struct {
+	mu        sync.Mutex
+	redirects map[string]Redirect // Keyed by From; the latest hop wins
+	failures  map[string]RedirectFailure
+}
+
+// newRedirectLog creates an empty redirect log
+func newRedirectLog() *redirectLog {
+	return &redirectLog{
+		redirects: make(map[string]Redirect),
+		failures:  make(map[string]RedirectFailure),
+	}
+}
+
+// Add records the hops of a followed chain
+func (l *redirectLog) Add(hops []Redirect) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, hop := range hops {
+		l.redirects[hop.From] = hop
+	}
+}
+
+// Fail records a chain that was abandoned because of err
+func (l *redirectLog) Fail(rawURL string, err error, hops []Redirect) {
+	reason := ErrTooManyRedirects.Error()
+	if errors.Is(err, ErrRedirectLoop) {
+		reason = ErrRedirectLoop.Error()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures[rawURL] = RedirectFailure{URL: rawURL, Reason: reason, Chain: hops}
+}
+
+// Load adds the redirects of a previous run
+func (l *redirectLog) Load(m *RedirectMap) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, hop := range m.Redirects {
+		l.redirects[hop.From] = hop
+	}
+}
+
+// Map returns the log as a RedirectMap sorted by URL
+func (l *redirectLog) Map(aliases map[string]string) *RedirectMap {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := &RedirectMap{
+		Redirects: make([]Redirect, 0, len(l.redirects)),
+		Failures:  make([]RedirectFailure, 0, len(l.failures)),
+		Aliases:   make(map[string]string, len(aliases)),
+	}
+	for _, hop := range l.redirects {
+		m.Redirects = append(m.Redirects, hop)
+	}
+	for _, f := range l.failures {
+		m.Failures = append(m.Failures, f)
+	}
+	for from, to := range aliases {
+		m.Aliases[from] = to
+	}
+	sort.Slice(m.Redirects, func(i, j int) bool { return m.Redirects[i].From < m.Redirects[j].From })
+	sort.Slice(m.Failures, func(i, j int) bool { return m.Failures[i].URL < m.Failures[j].URL })
+	return m
+}
+
+// LoadRedirectMap reads the redirect mapping written by a previous crawl
+func LoadRedirectMap(outputDir string) (*RedirectMap, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, RedirectsFile))
+	if err != nil {
+		return nil, err
+	}
+	var m RedirectMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", RedirectsFile, err)
+	}
+	return &m, nil
+}
+
+// Redirects returns the redirect mapping collected so far in this crawl
+func (c *Crawler) Redirects() *RedirectMap {
+	if c.redirects == nil {
+		return &RedirectMap{Redirects: []Redirect{}, Failures: []RedirectFailure{}, Aliases: map[string]string{}}
+	}
+	c.mu.Lock()
+	var aliases map[string]string
+	if c.state != nil {
+		aliases = c.state.Aliases
+	}
+	m := c.redirects.Map(aliases)
+	c.mu.Unlock()
+	return m
+}
+
+// trackRedirects records the redirect chain of a fetch. Every permanent
+// tail of the chain becomes an alias of the final URL so later links and
+// future crawls request it directly. The final URL is marked visited; the
+// returned value is true when it already was, i.e. the page is a duplicate.
+func (c *Crawler) trackRedirects(rawURL string, result *FetchResult, fetchErr error) (string, bool) {
+	var hops []Redirect
+	if result != nil {
+		hops = result.Redirects
+	}
+	if isRedirectError(fetchErr) {
+		c.redirects.Fail(rawURL, fetchErr, hops)
+		c.metrics.IncrementRedirectErrors()
+		return "", false
+	}
+	if fetchErr != nil || len(hops) == 0 {
+		return "", false
+	}
+
+	c.redirects.Add(hops)
+	c.results.addRedirects(hops)
+	c.metrics.IncrementRedirected()
+
+	finalURL := c.normalizeURL(hops[len(hops)-1].To)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	permanent := true
+	for i := len(hops) - 1; i >= 0 && permanent; i-- {
+		permanent = hops[i].Permanent()
+		if from := c.normalizeURL(hops[i].From); permanent && from != finalURL {
+			c.state.Aliases[from] = finalURL
+		}
+	}
+	if finalURL == rawURL {
+		return finalURL, false
+	}
+	if c.state.Visited[finalURL] {
+		return finalURL, true
+	}
+	c.state.Visited[finalURL] = true
+	return finalURL, false
+}
+
+// resolveAlias returns the final URL rawURL permanently redirects to, or
+// rawURL itself when it has no alias or the target is out of scope
+func (c *Crawler) resolveAlias(rawURL string) string {
+	c.mu.Lock()
+	target := rawURL
+	for i := 0; i < MaxRedirects; i++ {
+		next, ok := c.state.Aliases[target]
+		if !ok || next == rawURL {
+			break
+		}
+		target = next
+	}
+	c.mu.Unlock()
+	if target != rawURL && !c.isValidURL(target) {
+		return rawURL
+	}
+	return target
+}
+
+// loadRedirects seeds the log and aliases with the mapping of a previous
+// crawl into the same output directory
+func (c *Crawler) loadRedirects() {
+	m, err := LoadRedirectMap(c.config.OutputDir)
+	if err != nil {
+		return
+	}
+	c.redirects.Load(m)
+	for from, to := range m.Aliases {
+		if _, ok := c.state.Aliases[from]; !ok {
+			c.state.Aliases[from] = to
+		}
+	}
+}
+
+// writeRedirects saves the redirect mapping to the output directory
+func (c *Crawler) writeRedirects() error {
+	data, err := json.MarshalIndent(c.Redirects(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal redirects: %v", err)
+	}
+	if err := c.perms.writeFile(filepath.Join(c.config.OutputDir, RedirectsFile), data); err != nil {
+		return fmt.Errorf("failed to write redirects: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	chain := func(urls ...string) []*http.Request {
+		var via []*http.Request
+		for _, u := range urls {
+			req, _ := http.NewRequest("GET", u, nil)
+			via = append(via, req)
+		}
+		return via
+	}
+	long := make([]string, MaxRedirects)
+	for i := range long {
+		long[i] = fmt.Sprintf("https://example.com/%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		via     []*http.Request
+		wantErr error
+	}{
+		{"first hop", "https://example.com/b", chain("https://example.com/a"), nil},
+		{"loop", "https://example.com/a", chain("https://example.com/a", "https://example.com/b"), ErrRedirectLoop},
+		{"too long", "https://example.com/next", chain(long...), ErrTooManyRedirects},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.target, nil)
+			err := checkRedirect(req, tt.via)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("checkRedirect() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBrowserRedirectError(t *testing.T) {
+	loop := []Redirect{{From: "a", To: "b", Status: 302}, {From: "b", To: "a", Status: 302}}
+	if err := browserRedirectError(loop); !errors.Is(err, ErrRedirectLoop) {
+		t.Errorf("browserRedirectError(loop) = %v", err)
+	}
+	long := []Redirect{{From: "a", To: "b", Status: 302}, {From: "b", To: "c", Status: 302}}
+	if err := browserRedirectError(long); !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("browserRedirectError(long) = %v", err)
+	}
+}
+
+func TestCrawlRedirects(t *testing.T) {
+	body := strings.Repeat("Some meaningful content for the page. ", 10)
+	var oldHits int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&oldHits, 1)
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/temp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusFound)
+	})
+	mux.HandleFunc("/loop-a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-b", http.StatusFound)
+	})
+	mux.HandleFunc("/loop-b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-a", http.StatusFound)
+	})
+	mux.HandleFunc("/chain/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/chain/"), "%d", &n)
+		http.Redirect(w, r, fmt.Sprintf("/chain/%d", n+1), http.StatusFound)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		links := ""
+		if r.URL.Path == "/" {
+			links = `<a href="/old">o</a><a href="/new">n</a><a href="/temp">t</a><a href="/loop-a">l</a><a href="/chain/0">c</a>`
+		}
+		fmt.Fprintf(w, `<html><body><p>%s %s</p>%s</body></html>`, r.URL.Path, body, links)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	crawl := func(startURL, stateFile string) {
+		t.Helper()
+		c, err := NewCrawler(Config{
+			URL:              startURL,
+			MaxDepth:         1,
+			OutputDir:        outputDir,
+			StateFile:        filepath.Join(outputDir, stateFile),
+			MinContentLength: 10,
+			NormalizeURLs:    true,
+		}, context.Background())
+		if err != nil {
+			t.Fatalf("NewCrawler() error = %v", err)
+		}
+		defer c.Close()
+		if err := c.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+	}
+	crawl(server.URL+"/", "state.json")
+
+	m, err := LoadRedirectMap(outputDir)
+	if err != nil {
+		t.Fatalf("LoadRedirectMap() error = %v", err)
+	}
+	hops := make(map[string]Redirect)
+	for _, r := range m.Redirects {
+		hops[r.From] = r
+	}
+	if r := hops[server.URL+"/old"]; r.To != server.URL+"/new" || r.Status != http.StatusMovedPermanently {
+		t.Errorf("/old hop = %+v", r)
+	}
+	if r := hops[server.URL+"/temp"]; r.Status != http.StatusFound {
+		t.Errorf("/temp hop = %+v", r)
+	}
+	if m.Aliases[server.URL+"/old"] != server.URL+"/new" || m.Aliases[server.URL+"/temp"] != "" {
+		t.Errorf("aliases = %v, want only /old", m.Aliases)
+	}
+
+	failures := make(map[string]string)
+	for _, f := range m.Failures {
+		failures[f.URL] = f.Reason
+	}
+	if failures[server.URL+"/loop-a"] != "redirect loop" || failures[server.URL+"/chain/0"] != "too many redirects" {
+		t.Errorf("failures = %v", failures)
+	}
+
+	records, err := LoadURLInventory(outputDir)
+	if err != nil {
+		t.Fatalf("LoadURLInventory() error = %v", err)
+	}
+	byURL := make(map[string]URLRecord)
+	for _, r := range records {
+		byURL[r.URL] = r
+	}
+	if r := byURL[server.URL+"/old"]; r.Status != URLStatusSaved {
+		t.Errorf("/old record = %+v", r)
+	}
+	if r := byURL[server.URL+"/new"]; r.Status != URLStatusSkipped || r.Reason != "already visited" {
+		t.Errorf("/new should not be fetched again after /old redirected to it, got %+v", r)
+	}
+	if r := byURL[server.URL+"/loop-a"]; r.Status != URLStatusError || !strings.Contains(r.Reason, "redirect loop") {
+		t.Errorf("/loop-a record = %+v", r)
+	}
+
+	// A later crawl starting from the permanently redirected URL requests
+	// the final URL directly
+	hits := atomic.LoadInt32(&oldHits)
+	crawl(server.URL+"/old", "state2.json")
+	if got := atomic.LoadInt32(&oldHits); got != hits {
+		t.Errorf("/old requested %d more times, want 0", got-hits)
+	}
+}
@@ -0,0 +1,275 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CrawlReportFile is the summary report written to the output directory
+// with Config.Report
+const CrawlReportFile = "report.html"
+
+// MaxReportErrors bounds the rows of the error table in the crawl report
+const MaxReportErrors = 500
+
+// reportError is a row of the error table, with the saved copy of the
+// referring page when there is one
+type reportError struct {
+	URLRecord
+	ReferrerFile string
+}
+
+// reportCount is one row of a distribution table
+type reportCount struct {
+	Value string
+	Count int64
+}
+
+// crawlReportData is what the report template renders
+type crawlReportData struct {
+	Title       string
+	StartURL    string
+	GeneratedAt time.Time
+	Duration    string
+	Metrics     *CrawlerMetrics
+	Samples     []MetricsSample
+	StatusCodes map[string]int64
+	Types       []reportCount
+	Languages   []reportCount
+	Errors      []reportError
+	TotalErrors int
+	HasIndex    bool
+}
+
+// writeCrawlReport writes report.html to the output directory when
+// Config.Report is set
+func (c *Crawler) writeCrawlReport() error {
+	if !c.config.Report {
+		return nil
+	}
+	c.metrics.Finalize()
+	snapshot := c.metrics.GetSnapshot()
+
+	saved := make(map[string]string)
+	for _, page := range c.index.Pages() {
+		saved[page.URL] = page.Filename
+	}
+	data := crawlReportData{
+		Title:       filepath.Base(c.config.OutputDir),
+		StartURL:    c.config.URL,
+		GeneratedAt: time.Now(),
+		Duration:    FormatDuration(time.Duration(snapshot.Duration * float64(time.Second))),
+		Metrics:     &snapshot,
+		Samples:     c.MetricsTimeSeries().Samples,
+		StatusCodes: snapshot.Content.StatusCodes,
+		Types:       sortedCounts(snapshot.Content.ContentTypes),
+		Languages:   sortedCounts(snapshot.Content.Languages),
+		HasIndex:    len(saved) > 0,
+	}
+	for _, r := range c.URLInventory() {
+		if r.Status != URLStatusError {
+			continue
+		}
+		data.TotalErrors++
+		if len(data.Errors) < MaxReportErrors {
+			data.Errors = append(data.Errors, reportError{URLRecord: r, ReferrerFile: saved[r.Referrer]})
+		}
+	}
+
+	html, err := renderCrawlReport(&data)
+	if err != nil {
+		return err
+	}
+	if err := c.perms.writeFile(filepath.Join(c.config.OutputDir, CrawlReportFile), html); err != nil {
+		return fmt.Errorf("failed to write crawl report: %v", err)
+	}
+	return nil
+}
+
+// sortedCounts returns the entries of counts, most frequent first
+func sortedCounts(counts map[string]int64) []reportCount {
+	out := make([]reportCount, 0, len(counts))
+	for v, n := range counts {
+		out = append(out, reportCount{v, n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
+
+// renderCrawlReport renders the report as a standalone HTML page
+func renderCrawlReport(data *crawlReportData) ([]byte, error) {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{"bytes": FormatBytes}).Parse(crawlReportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render crawl report: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const crawlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>Crawl Report - {{.Title}}</title>
+    <style>
+        :root { --bg: #ffffff; --bg-alt: #f8f9fa; --text: #212529; --muted: #6c757d; --border: #dee2e6; --accent: #0d6efd; --bad: #dc3545; --good: #198754; }
+        @media (prefers-color-scheme: dark) {
+            :root { --bg: #1a1a2e; --bg-alt: #16213e; --text: #f8f9fa; --muted: #9ca3af; --border: #374151; --accent: #60a5fa; --bad: #f87171; --good: #34d399; }
+        }
+        body { margin: 0 auto; max-width: 70rem; padding: 2rem; background: var(--bg); color: var(--text); font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; line-height: 1.5; }
+        .muted { color: var(--muted); font-size: 0.9rem; }
+        .summary { display: grid; grid-template-columns: repeat(auto-fill, minmax(10rem, 1fr)); gap: 0.75rem; margin: 1.5rem 0; }
+        .summary div { padding: 0.75rem; border: 1px solid var(--border); border-radius: 6px; background: var(--bg-alt); }
+        .summary strong { display: block; font-size: 1.5rem; }
+        .summary .bad strong { color: var(--bad); }
+        .summary .good strong { color: var(--good); }
+        .charts { display: grid; grid-template-columns: repeat(auto-fit, minmax(20rem, 1fr)); gap: 1.5rem; }
+        .chart { border: 1px solid var(--border); border-radius: 6px; padding: 0.75rem; background: var(--bg-alt); }
+        .chart svg { width: 100%; height: 12rem; display: block; }
+        .chart polyline { fill: none; stroke: var(--accent); stroke-width: 2; vector-effect: non-scaling-stroke; }
+        .chart rect { fill: var(--accent); }
+        .chart rect.bad { fill: var(--bad); }
+        table { width: 100%; border-collapse: collapse; margin-bottom: 2rem; font-size: 0.9rem; }
+        th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid var(--border); vertical-align: top; word-break: break-all; }
+        th { background: var(--bg-alt); }
+        td.num { text-align: right; word-break: normal; }
+        a { color: var(--accent); }
+    </style>
+</head>
+<body>
+    <h1>Crawl Report: {{.Title}}</h1>
+    <p class="muted"><a href="{{.StartURL}}">{{.StartURL}}</a> &middot; generated {{.GeneratedAt.Format "Jan 2, 2006 15:04"}}{{if .Metrics.StopReason}} &middot; stopped: {{.Metrics.StopReason}}{{end}}</p>
+    <p>{{if .HasIndex}}<a href="_index.html">Browse the saved pages</a> &middot; {{end}}<a href="urls.csv">URL inventory (CSV)</a> &middot; <a href="metrics-timeseries.csv">Metrics time series (CSV)</a></p>
+
+    <div class="summary">
+        <div><strong>{{.Metrics.URLsProcessed}}</strong>URLs processed</div>
+        <div class="good"><strong>{{.Metrics.URLsSaved}}</strong>Pages saved</div>
+        <div><strong>{{.Metrics.URLsSkipped}}</strong>Skipped</div>
+        <div class="{{if .Metrics.URLsErrored}}bad{{else}}good{{end}}"><strong>{{.Metrics.URLsErrored}}</strong>Errors</div>
+        <div><strong>{{bytes .Metrics.BytesDownloaded}}</strong>Downloaded</div>
+        <div><strong>{{.Duration}}</strong>Duration</div>
+        <div><strong>{{printf "%.2f" .Metrics.PagesPerSecond}}</strong>Pages per second</div>
+        <div><strong>{{bytes .Metrics.PeakHeapAlloc}}</strong>Peak memory</div>
+    </div>
+
+    <div class="charts">
+        <div class="chart">
+            <h2>Throughput</h2>
+            <svg id="throughput" viewBox="0 0 100 50" preserveAspectRatio="none"></svg>
+            <p class="muted" id="throughput-caption"></p>
+        </div>
+        <div class="chart">
+            <h2>Status codes</h2>
+            <svg id="statuses" viewBox="0 0 100 50" preserveAspectRatio="none"></svg>
+            <p class="muted" id="statuses-caption"></p>
+        </div>
+    </div>
+
+    {{- if .Types}}
+    <h2>Content types</h2>
+    <table>
+        <tr><th>Type</th><th>Responses</th></tr>
+        {{- range .Types}}
+        <tr><td>{{.Value}}</td><td class="num">{{.Count}}</td></tr>
+        {{- end}}
+    </table>
+    {{- end}}
+
+    {{- if .Languages}}
+    <h2>Languages</h2>
+    <table>
+        <tr><th>Language</th><th>Pages</th></tr>
+        {{- range .Languages}}
+        <tr><td>{{.Value}}</td><td class="num">{{.Count}}</td></tr>
+        {{- end}}
+    </table>
+    {{- end}}
+
+    {{- if .Metrics.Content.LargestPages}}
+    <h2>Largest pages</h2>
+    <table>
+        <tr><th>Page</th><th>Size</th></tr>
+        {{- range .Metrics.Content.LargestPages}}
+        <tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="num">{{bytes .Bytes}}</td></tr>
+        {{- end}}
+    </table>
+    {{- end}}
+
+    <h2 id="errors">Errors ({{.TotalErrors}})</h2>
+    {{- if .Errors}}
+    {{- if gt .TotalErrors (len .Errors)}}
+    <p class="muted">The first {{len .Errors}} are listed; urls.csv has all of them.</p>
+    {{- end}}
+    <table>
+        <tr><th>URL</th><th>Reason</th><th>Found on</th></tr>
+        {{- range .Errors}}
+        <tr>
+            <td><a href="{{.URL}}">{{.URL}}</a></td>
+            <td>{{.Reason}}</td>
+            <td>{{if .ReferrerFile}}<a href="{{.ReferrerFile}}">{{.Referrer}}</a>{{else}}{{.Referrer}}{{end}}</td>
+        </tr>
+        {{- end}}
+    </table>
+    {{- else}}
+    <p class="muted">No errors.</p>
+    {{- end}}
+
+    <script>
+        const samples = {{.Samples}} || [];
+        const statuses = {{.StatusCodes}} || {};
+        const ns = 'http://www.w3.org/2000/svg';
+
+        function add(svg, name, attrs) {
+            const el = document.createElementNS(ns, name);
+            for (const [k, v] of Object.entries(attrs)) el.setAttribute(k, v);
+            svg.appendChild(el);
+            return el;
+        }
+
+        // Pages per second between samples, scaled to the fastest interval
+        const throughput = document.getElementById('throughput');
+        const rates = samples.map(s => s.pages_per_second);
+        const peak = Math.max(...rates, 0);
+        if (rates.length > 1 && peak > 0) {
+            const points = rates.map((r, i) => (i / (rates.length - 1) * 100) + ',' + (50 - r / peak * 48)).join(' ');
+            add(throughput, 'polyline', { points });
+            const last = samples[samples.length - 1];
+            document.getElementById('throughput-caption').textContent =
+                'Peak ' + peak.toFixed(2) + ' pages/s over ' + Math.round(last.elapsed_seconds) + 's, sampled every ' + Math.round(last.elapsed_seconds / (samples.length - 1)) + 's';
+        } else {
+            document.getElementById('throughput-caption').textContent = 'Too few samples for a chart.';
+        }
+
+        // One bar per status code, errors in red
+        const svg = document.getElementById('statuses');
+        const codes = Object.keys(statuses).sort();
+        const most = Math.max(...codes.map(c => statuses[c]), 0);
+        const width = 100 / Math.max(codes.length, 1);
+        codes.forEach((code, i) => {
+            const h = statuses[code] / most * 40;
+            const bar = add(svg, 'rect', { x: i * width + width * 0.15, y: 44 - h, width: width * 0.7, height: h });
+            if (Number(code) >= 400) bar.setAttribute('class', 'bad');
+            const title = document.createElementNS(ns, 'title');
+            title.textContent = code + ': ' + statuses[code];
+            bar.appendChild(title);
+        });
+        document.getElementById('statuses-caption').textContent = codes.length
+            ? codes.map(c => c + ': ' + statuses[c]).join(' · ')
+            : 'No responses.';
+    </script>
+</body>
+</html>
+`
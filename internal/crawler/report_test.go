@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCrawlReport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html lang="en"><body><p>%s</p><a href="/missing">Missing</a></body></html>`, strings.Repeat("Page text. ", 10))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		Report:           true,
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, CrawlReportFile))
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	report := string(data)
+	for _, want := range []string{
+		"Crawl Report:",
+		`<a href="_index.html">`,
+		"Errors (1)",
+		server.URL + "/missing",
+		"HTTP 404",
+		`href="index.html">` + server.URL + "/</a>", // The referrer links to its saved copy
+		`"404":1`,
+		"text/html",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+}
+
+func TestCrawlReportOff(t *testing.T) {
+	outputDir := t.TempDir()
+	c := &Crawler{config: Config{OutputDir: outputDir}}
+	if err := c.writeCrawlReport(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, CrawlReportFile)); !os.IsNotExist(err) {
+		t.Errorf("report written without Config.Report: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReprocessOptions are the extraction settings applied to saved pages
+type ReprocessOptions struct {
+	DisableContentExtraction bool            // Remove extracted content instead of extracting it again
+	ContentFilters           []ContentFilter // Applied to each page before extraction
+	PluginsDir               string          // Directory of plugins whose extract hooks fill the "plugins" metadata
+}
+
+// ReprocessResult summarizes a reprocessing run
+type ReprocessResult struct {
+	Pages     int    `json:"pages"`     // Saved pages found
+	Extracted int    `json:"extracted"` // Pages with extracted content afterwards
+	Changed   int    `json:"changed"`   // Pages whose content file or metadata was rewritten
+	Failed    int    `json:"failed"`    // Pages that could not be read or written
+	IndexFile string `json:"index_file"`
+}
+
+// Reprocess runs content extraction again over the raw HTML saved in
+// outputDir with the given settings, rewriting the .content.html and
+// .meta.json files that change, and then regenerates _index.html. Nothing is
+// fetched, so changing extraction settings needs no new crawl.
+func Reprocess(outputDir string, opts ReprocessOptions) (*ReprocessResult, error) {
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+	filters, err := compileContentFilters(opts.ContentFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	// A crawler that only saves, never started
+	c := &Crawler{
+		config: Config{
+			OutputDir:                outputDir,
+			DisableContentExtraction: opts.DisableContentExtraction,
+		},
+		log:     &Logger{},
+		filters: filters,
+		perms:   outputPerms{uid: -1, gid: -1},
+	}
+	if opts.PluginsDir != "" {
+		if c.plugins, err = newPluginSet(opts.PluginsDir, c.log); err != nil {
+			return nil, err
+		}
+		defer c.plugins.summary()
+	}
+
+	metaFiles, err := scanMetaFiles(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(metaFiles) == 0 {
+		return nil, fmt.Errorf("no saved pages found in %s", outputDir)
+	}
+
+	result := &ReprocessResult{Pages: len(metaFiles)}
+	for _, metaFile := range metaFiles {
+		changed, extracted, err := c.reprocessPage(metaFile)
+		if err != nil {
+			c.log.Warn("Failed to reprocess %s: %v", metaFile, err)
+			result.Failed++
+			continue
+		}
+		if changed {
+			result.Changed++
+		}
+		if extracted {
+			result.Extracted++
+		}
+	}
+
+	if _, err := BuildIndex(outputDir); err != nil {
+		return result, fmt.Errorf("failed to generate index: %v", err)
+	}
+	result.IndexFile = filepath.Join(outputDir, BrowseIndexFile)
+	return result, nil
+}
+
+// reprocessPage extracts the content of the page saved with metaFile again.
+// It reports whether the content file or metadata changed, and whether the
+// page has extracted content now.
+func (c *Crawler) reprocessPage(metaFile string) (changed, extracted bool, err error) {
+	data, err := os.ReadFile(metaFile)
+	if err != nil {
+		return false, false, err
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return false, false, err
+	}
+	rawURL, _ := metadata["url"].(string)
+	if rawURL == "" {
+		return false, false, fmt.Errorf("no URL in metadata")
+	}
+	compression, _ := metadata["compression"].(string)
+	blob, _ := metadata["blob"].(string)
+
+	base := strings.TrimSuffix(metaFile, ".meta.json")
+	body, err := ReadOutputFile(base + ".html")
+	if err != nil {
+		return false, false, err
+	}
+	page, err := NewPageDocument(rawURL, body)
+	if err != nil {
+		return false, false, err
+	}
+
+	// Compared afterwards, so unchanged pages are left alone
+	before, _ := json.MarshalIndent(metadata, "", "  ")
+	oldContentFile, _ := metadata["content_file"].(string)
+	var oldContent []byte
+	if oldContentFile != "" {
+		oldContent, _ = ReadOutputFile(filepath.Join(c.config.OutputDir, filepath.FromSlash(oldContentFile)))
+	}
+
+	delete(metadata, "content_file")
+	delete(metadata, "content_size")
+	delete(metadata, "content_blob")
+	for _, field := range extractedMetadataFields {
+		delete(metadata, field)
+	}
+	var content string
+	if !c.config.DisableContentExtraction {
+		extractedHTML, doc, err := c.extractContent(rawURL, c.filterContent(page))
+		if err != nil {
+			c.log.Debug("Failed to extract content for %s: %v", rawURL, err)
+		} else if extractedHTML != "" {
+			content = extractedHTML
+			if doc != nil {
+				setExtractedMetadata(metadata, doc)
+			}
+		}
+	}
+
+	contentFile := base + ".content.html"
+	newContentFile := ""
+	if content != "" {
+		rel, err := filepath.Rel(c.config.OutputDir, contentFile)
+		if err != nil {
+			return false, false, err
+		}
+		newContentFile = filepath.ToSlash(rel) + compressionExt(compression)
+		metadata["content_file"] = newContentFile
+		metadata["content_size"] = len(content)
+		if blob != "" {
+			metadata["content_blob"] = blobPath([]byte(content), compression)
+		}
+	}
+	metadata["content_extracted"] = content != ""
+	if c.plugins != nil {
+		delete(metadata, "plugins")
+		if fields := c.plugins.extract(page); fields != nil {
+			metadata["plugins"] = fields
+		}
+	}
+
+	after, _ := json.MarshalIndent(metadata, "", "  ")
+	if bytes.Equal(before, after) && string(oldContent) == content {
+		return false, content != "", nil
+	}
+
+	// A content file no longer extracted goes; blobs may be shared, so they stay
+	if oldContentFile != "" && oldContentFile != newContentFile {
+		old := filepath.Join(c.config.OutputDir, filepath.FromSlash(oldContentFile))
+		for _, path := range []string{old, old + BlobPointerExt} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return false, false, err
+			}
+		}
+	}
+	if content != "" {
+		// Stored the way the page itself was
+		c.config.CompressOutput = compression
+		c.config.ContentAddressable = blob != ""
+		written, _, err := c.storeOutputFile(contentFile, []byte(content))
+		if err != nil {
+			return false, false, err
+		}
+		if err := c.perms.applyFile(written); err != nil {
+			return false, false, err
+		}
+	}
+	if err := c.perms.writeFile(metaFile, after); err != nil {
+		return false, false, err
+	}
+	return true, content != "", nil
+}
@@ -0,0 +1,112 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// reprocessSite serves a home page linking two pages, each with enough text
+// for content extraction
+func reprocessSite() *httptest.Server {
+	text := strings.Repeat("A sentence long enough for content extraction to keep it. ", 10)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		links := ""
+		if r.URL.Path == "/" {
+			links = `<a href="/a">A</a><a href="/b">B</a>`
+		}
+		fmt.Fprintf(w, `<html><head><title>Page %s</title></head><body><article><p>%s</p></article>%s</body></html>`, r.URL.Path, text, links)
+	}))
+}
+
+// reprocessedMeta reads the metadata of the saved page at name
+func reprocessedMeta(t *testing.T, outputDir, name string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(outputDir, name+".meta.json"))
+	if err != nil {
+		t.Fatalf("reading metadata: %v", err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatalf("parsing metadata: %v", err)
+	}
+	return meta
+}
+
+func TestReprocess(t *testing.T) {
+	site := reprocessSite()
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:                      site.URL + "/",
+		MaxDepth:                 1,
+		OutputDir:                outputDir,
+		StateFile:                filepath.Join(outputDir, "state.json"),
+		MinContentLength:         10,
+		CompressOutput:           CompressionGzip,
+		DisableContentExtraction: true,
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	if _, err := runSelfTestCrawl(t.Context(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if meta := reprocessedMeta(t, outputDir, "a"); meta["content_extracted"] != false {
+		t.Fatalf("content_extracted = %v after a crawl without extraction", meta["content_extracted"])
+	}
+
+	result, err := Reprocess(outputDir, ReprocessOptions{})
+	if err != nil {
+		t.Fatalf("Reprocess() error = %v", err)
+	}
+	if result.Pages != 3 || result.Extracted != 3 || result.Changed != 3 || result.Failed != 0 {
+		t.Errorf("result = %+v, want 3 pages extracted and changed", result)
+	}
+	meta := reprocessedMeta(t, outputDir, "a")
+	if meta["content_extracted"] != true || meta["content_file"] != "a.content.html.gz" || meta["compression"] != CompressionGzip {
+		t.Errorf("metadata = %v, want the gzipped content file recorded", meta)
+	}
+	content, err := ReadOutputFile(filepath.Join(outputDir, "a.content.html"))
+	if err != nil || !strings.Contains(string(content), "long enough for content extraction") {
+		t.Errorf("content file = %q (%v), want the extracted text", content, err)
+	}
+	index, err := os.ReadFile(result.IndexFile)
+	if err != nil || !strings.Contains(string(index), "a.content.html.gz") {
+		t.Errorf("index does not link the extracted content (%v)", err)
+	}
+
+	// Same settings, nothing to rewrite
+	if result, err = Reprocess(outputDir, ReprocessOptions{}); err != nil || result.Changed != 0 {
+		t.Errorf("second run changed %d pages (%v), want none", result.Changed, err)
+	}
+
+	if result, err = Reprocess(outputDir, ReprocessOptions{DisableContentExtraction: true}); err != nil {
+		t.Fatalf("Reprocess() without extraction error = %v", err)
+	}
+	if result.Extracted != 0 || result.Changed != 3 {
+		t.Errorf("result = %+v, want the extracted content of 3 pages removed", result)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "a.content.html.gz")); !os.IsNotExist(err) {
+		t.Errorf("content file still exists (%v), want it removed", err)
+	}
+	if meta := reprocessedMeta(t, outputDir, "a"); meta["content_file"] != nil || meta["title"] != nil {
+		t.Errorf("metadata = %v, want the content fields removed", meta)
+	}
+}
+
+func TestReprocessNoPages(t *testing.T) {
+	if _, err := Reprocess(t.TempDir(), ReprocessOptions{}); err == nil {
+		t.Error("Reprocess() of an empty directory succeeded, want an error")
+	}
+	if _, err := Reprocess(filepath.Join(t.TempDir(), "missing"), ReprocessOptions{}); err == nil {
+		t.Error("Reprocess() of a missing directory succeeded, want an error")
+	}
+}
@@ -0,0 +1,197 @@
+package crawler
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go SQLite driver, registered as "sqlite"
+)
+
+// ResultsDBFile is the usual name of the results database, in the output
+// directory
+const ResultsDBFile = "results.sqlite"
+
+// resultsSchema is the schema of the results database. Tables are only
+// created when missing, so a resumed crawl adds to the database of its
+// first run. Times are RFC 3339 strings, which SQLite's date functions read.
+const resultsSchema = `
+CREATE TABLE IF NOT EXISTS pages (
+	url          TEXT PRIMARY KEY,
+	path         TEXT NOT NULL,  -- Stored HTML file, relative to the output directory
+	content_path TEXT,           -- Stored .content.html file, if any
+	title        TEXT,
+	depth        INTEGER,
+	size         INTEGER,        -- Bytes of HTML saved
+	hash         TEXT,           -- SHA-256 of the stored HTML
+	saved_at     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS links (
+	source TEXT NOT NULL,        -- Page the link was found on
+	target TEXT NOT NULL,
+	depth  INTEGER,              -- Depth the target would be crawled at
+	queued INTEGER NOT NULL,     -- 1 when the link was followed
+	rule   TEXT NOT NULL,        -- Why it was followed or not, as in the decision trace
+	detail TEXT
+);
+CREATE INDEX IF NOT EXISTS links_source ON links (source);
+CREATE INDEX IF NOT EXISTS links_target ON links (target);
+CREATE TABLE IF NOT EXISTS errors (
+	url         TEXT NOT NULL,
+	depth       INTEGER,
+	http_status INTEGER,         -- NULL when no response was received
+	reason      TEXT,
+	time        TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS redirects (
+	source TEXT PRIMARY KEY,     -- The latest hop from a URL wins
+	target TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	time   TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS metrics (
+	timestamp        TEXT NOT NULL,
+	elapsed_seconds  REAL,
+	urls_processed   INTEGER,
+	urls_saved       INTEGER,
+	urls_skipped     INTEGER,
+	urls_errored     INTEGER,
+	queue_size       INTEGER,
+	bytes_downloaded INTEGER,
+	pages_per_second REAL,
+	heap_alloc_bytes INTEGER
+);
+CREATE TABLE IF NOT EXISTS embeddings (
+	url        TEXT PRIMARY KEY,
+	model      TEXT NOT NULL,
+	dimensions INTEGER NOT NULL,
+	hash       TEXT,             -- SHA-256 of the stored HTML the text was taken from
+	vector     BLOB NOT NULL,    -- Little-endian float32s, as sqlite-vec reads them
+	created_at TEXT NOT NULL
+);
+`
+
+// resultsDB writes what a crawl finds to Config.ResultsDB as it goes, so
+// the crawl can be queried with SQL. A single connection serializes the
+// writes of the workers. A failed write is logged once and the crawl goes
+// on.
+type resultsDB struct {
+	db     *sql.DB
+	log    *Logger
+	failed atomic.Bool
+}
+
+// openResultsDB creates the results database, or adds to it when a crawl
+// is resumed
+func openResultsDB(path string, resume bool, perms outputPerms, log *Logger) (*resultsDB, error) {
+	if !resume {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove old results database: %v", err)
+			}
+		}
+	}
+	if err := perms.mkdirAll(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %v", filepath.Dir(path), err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	// WAL lets readers query the database while the crawl writes to it
+	for _, stmt := range []string{"PRAGMA journal_mode = WAL", "PRAGMA synchronous = NORMAL", "PRAGMA busy_timeout = 5000", resultsSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create results database: %v", err)
+		}
+	}
+	if err := perms.applyFile(path); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &resultsDB{db: db, log: log}, nil
+}
+
+// exec runs a write, logging the first one that fails. r may be nil.
+func (r *resultsDB) exec(query string, args ...any) {
+	if r == nil {
+		return
+	}
+	if _, err := r.db.Exec(query, args...); err != nil && !r.failed.Swap(true) {
+		r.log.Warn("Failed to write results database: %v", err)
+	}
+}
+
+func (r *resultsDB) addPage(entry ManifestEntry, depth int) {
+	r.exec(`INSERT OR REPLACE INTO pages (url, path, content_path, title, depth, size, hash, saved_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.URL, entry.Path, nullString(entry.ContentPath), entry.Title, depth, entry.Size, entry.Hash, entry.Timestamp.Format(time.RFC3339))
+}
+
+func (r *resultsDB) addLink(source, target string, depth int, rule, detail string) {
+	queued := 0
+	if rule == DecisionQueued {
+		queued = 1
+	}
+	r.exec(`INSERT INTO links (source, target, depth, queued, rule, detail) VALUES (?, ?, ?, ?, ?, ?)`,
+		source, target, depth, queued, rule, nullString(detail))
+}
+
+func (r *resultsDB) addError(rawURL string, depth int, reason string, result *FetchResult) {
+	var status any
+	if result != nil && result.StatusCode != 0 {
+		status = result.StatusCode
+	}
+	r.exec(`INSERT INTO errors (url, depth, http_status, reason, time) VALUES (?, ?, ?, ?, ?)`,
+		rawURL, depth, status, reason, time.Now().Format(time.RFC3339))
+}
+
+func (r *resultsDB) addRedirects(hops []Redirect) {
+	now := time.Now().Format(time.RFC3339)
+	for _, hop := range hops {
+		r.exec(`INSERT OR REPLACE INTO redirects (source, target, status, time) VALUES (?, ?, ?, ?)`,
+			hop.From, hop.To, hop.Status, now)
+	}
+}
+
+func (r *resultsDB) addSample(s MetricsSample) {
+	r.exec(`INSERT INTO metrics (timestamp, elapsed_seconds, urls_processed, urls_saved, urls_skipped, urls_errored, queue_size, bytes_downloaded, pages_per_second, heap_alloc_bytes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.Timestamp.Format(time.RFC3339), s.Elapsed, s.URLsProcessed, s.URLsSaved, s.URLsSkipped, s.URLsErrored, s.QueueSize, s.BytesDownloaded, s.PagesPerSecond, s.HeapAlloc)
+}
+
+func (r *resultsDB) addEmbedding(rawURL, model, hash string, vector []float32) {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	r.exec(`INSERT OR REPLACE INTO embeddings (url, model, dimensions, hash, vector, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		rawURL, model, len(vector), hash, blob, time.Now().Format(time.RFC3339))
+}
+
+// Close checkpoints the write-ahead log into the database file and closes it
+func (r *resultsDB) Close() error {
+	r.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return r.db.Close()
+}
+
+// nullString stores "" as NULL
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// resultsDBPath returns where the results database of config goes:
+// Config.ResultsDB, relative to the output directory unless absolute
+func resultsDBPath(config Config) string {
+	if filepath.IsAbs(config.ResultsDB) {
+		return config.ResultsDB
+	}
+	return filepath.Join(config.OutputDir, config.ResultsDB)
+}
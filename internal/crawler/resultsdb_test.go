@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResultsDB(t *testing.T) {
+	text := strings.Repeat("Queryable content. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprintf(w, `<html><head><title>Home</title></head><body><p>%s</p><a href="/docs">Docs</a><a href="/old">Old</a><a href="/missing">Missing</a><a href="/file.pdf">PDF</a></body></html>`, text)
+		case "/docs", "/new":
+			fmt.Fprintf(w, `<html><head><title>Page</title></head><body><p>%s</p><a href="/">Home</a></body></html>`, text)
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:               server.URL + "/",
+		MaxDepth:          1,
+		OutputDir:         outputDir,
+		StateFile:         filepath.Join(outputDir, "state.json"),
+		MinContentLength:  10,
+		ExcludeExtensions: []string{"pdf"},
+		ResultsDB:         ResultsDBFile,
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	path := filepath.Join(outputDir, ResultsDBFile)
+	if info, err := os.Stat(path + "-wal"); err == nil && info.Size() > 0 {
+		t.Error("the write-ahead log should be checkpointed when the crawl ends")
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return n
+	}
+	if n := count(`SELECT COUNT(*) FROM pages`); n != 3 {
+		t.Errorf("pages has %d rows, want home, docs and the redirect target", n)
+	}
+	var title, hash string
+	var depth int
+	if err := db.QueryRow(`SELECT title, hash, depth FROM pages WHERE url = ?`, server.URL+"/docs").Scan(&title, &hash, &depth); err != nil {
+		t.Fatal(err)
+	}
+	if title != "Page" || len(hash) != 64 || depth != 1 {
+		t.Errorf("docs page = %q, %q, depth %d", title, hash, depth)
+	}
+	if n := count(`SELECT COUNT(*) FROM links WHERE source = ? AND queued = 1`, server.URL+"/"); n != 3 {
+		t.Errorf("home has %d followed links, want 3", n)
+	}
+	if n := count(`SELECT COUNT(*) FROM links WHERE target = ? AND rule = ?`, server.URL+"/file.pdf", LinkFilterExtension); n != 1 {
+		t.Errorf("the excluded PDF link should be recorded with its rule")
+	}
+	if n := count(`SELECT COUNT(*) FROM errors WHERE url = ? AND http_status = 404`, server.URL+"/missing"); n != 1 {
+		t.Errorf("the missing page should be in errors")
+	}
+	if n := count(`SELECT COUNT(*) FROM redirects WHERE source = ? AND target = ? AND status = 301`, server.URL+"/old", server.URL+"/new"); n != 1 {
+		t.Errorf("the redirect should be in redirects")
+	}
+	if n := count(`SELECT COUNT(*) FROM metrics WHERE urls_processed > 0`); n == 0 {
+		t.Error("metrics has no samples of the finished crawl")
+	}
+}
@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Classes of errored URLs, derived from their HTTP status and reason
+const (
+	ErrorClassAuth        = "auth"         // 401, 403 or 407: a login or permission is missing
+	ErrorClassNotFound    = "not-found"    // 404 or 410
+	ErrorClassRateLimited = "rate-limited" // 429
+	ErrorClassClient      = "client"       // Other 4xx responses
+	ErrorClassServer      = "server"       // 5xx responses
+	ErrorClassTimeout     = "timeout"      // URLTimeout or a fetch timeout
+	ErrorClassNetwork     = "network"      // DNS, connection and TLS failures
+	ErrorClassRedirect    = "redirect"     // Redirect loops and overly long chains
+	ErrorClassBrowser     = "browser"      // Browser crashes and browser-only features without one
+	ErrorClassParse       = "parse"        // Response could not be parsed
+	ErrorClassSave        = "save"         // Page could not be written
+	ErrorClassOther       = "other"
+)
+
+// ErrorClasses lists the error classes in the order they are shown
+var ErrorClasses = []string{
+	ErrorClassAuth, ErrorClassNotFound, ErrorClassRateLimited, ErrorClassClient, ErrorClassServer,
+	ErrorClassTimeout, ErrorClassNetwork, ErrorClassRedirect, ErrorClassBrowser, ErrorClassParse,
+	ErrorClassSave, ErrorClassOther,
+}
+
+// ErrNotRetryable is returned when no errored URL could be queued again
+var ErrNotRetryable = errors.New("no errored URLs to retry")
+
+// ValidErrorClass reports whether class is an error class
+func ValidErrorClass(class string) bool {
+	for _, c := range ErrorClasses {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyURLError returns the error class of an errored inventory record
+func ClassifyURLError(rec URLRecord) string {
+	reason := strings.ToLower(rec.Reason)
+	switch {
+	case strings.HasPrefix(reason, "timed out") || strings.Contains(reason, "deadline exceeded") || strings.Contains(reason, "timeout"):
+		return ErrorClassTimeout
+	case strings.Contains(reason, ErrRedirectLoop.Error()) || strings.Contains(reason, ErrTooManyRedirects.Error()):
+		return ErrorClassRedirect
+	case strings.HasPrefix(reason, "parse error"):
+		return ErrorClassParse
+	case strings.HasPrefix(reason, "save error"):
+		return ErrorClassSave
+	}
+
+	switch s := rec.HTTPStatus; {
+	case s == http.StatusUnauthorized || s == http.StatusForbidden || s == http.StatusProxyAuthRequired:
+		return ErrorClassAuth
+	case s == http.StatusNotFound || s == http.StatusGone:
+		return ErrorClassNotFound
+	case s == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case s >= 400 && s < 500:
+		return ErrorClassClient
+	case s >= 500:
+		return ErrorClassServer
+	}
+
+	switch {
+	case strings.Contains(reason, "browser"):
+		return ErrorClassBrowser
+	case strings.Contains(reason, "no such host") || strings.Contains(reason, "connection refused") ||
+		strings.Contains(reason, "connection reset") || strings.Contains(reason, "eof") ||
+		strings.Contains(reason, "tls") || strings.Contains(reason, "certificate") ||
+		strings.Contains(reason, "network is unreachable") || strings.Contains(reason, "dial "):
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
+
+// URLError is an errored URL with its error class, for drilling into the
+// failures of a crawl and retrying them
+type URLError struct {
+	URL        string `json:"url"`
+	Class      string `json:"class"`
+	Reason     string `json:"reason"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	Depth      int    `json:"depth"`
+	Referrer   string `json:"referrer,omitempty"` // Page the URL was first found on
+}
+
+// URLErrors returns the errored URLs of an inventory, only those of class
+// when it is not ""
+func URLErrors(records []URLRecord, class string) []URLError {
+	errs := []URLError{}
+	for _, r := range records {
+		if r.Status != URLStatusError {
+			continue
+		}
+		e := URLError{URL: r.URL, Class: ClassifyURLError(r), Reason: r.Reason, HTTPStatus: r.HTTPStatus, Depth: r.Depth, Referrer: r.Referrer}
+		if class == "" || e.Class == class {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+// CrawlErrors returns the errored URLs of the crawl so far, only those of
+// class when it is not ""
+func (c *Crawler) CrawlErrors(class string) ([]URLError, error) {
+	if class != "" && !ValidErrorClass(class) {
+		return nil, fmt.Errorf("unknown error class %q, expected one of: %s", class, strings.Join(ErrorClasses, ", "))
+	}
+	return URLErrors(c.URLInventory(), class), nil
+}
+
+// CountErrorClasses returns the number of errored URLs per class
+func CountErrorClasses(errs []URLError) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range errs {
+		counts[e.Class]++
+	}
+	return counts
+}
+
+// RetryErrors queues the errored URLs of class ("" for all) again on the
+// running crawl. See RetryURLs.
+func (c *Crawler) RetryErrors(class string) (int, error) {
+	if class != "" && !ValidErrorClass(class) {
+		return 0, fmt.Errorf("unknown error class %q, expected one of: %s", class, strings.Join(ErrorClasses, ", "))
+	}
+	var urls []string
+	for _, e := range URLErrors(c.URLInventory(), class) {
+		urls = append(urls, e.URL)
+	}
+	return c.RetryURLs(urls)
+}
+
+// RetryURLs queues errored URLs of the running crawl again, at the front of
+// the queue, e.g. after confirming a login or fixing the site. They no longer
+// count as errors until they fail again. URLs that did not error, or were
+// not fetched as themselves such as later pages of a paginated URL, are
+// left alone. Returns the number of URLs queued.
+func (c *Crawler) RetryURLs(urls []string) (int, error) {
+	c.pauseMu.Lock()
+	done := c.loopDone
+	c.pauseMu.Unlock()
+	if done || c.state == nil {
+		return 0, fmt.Errorf("the crawl is not running; re-crawl its failed URLs instead")
+	}
+
+	errored := make(map[string]URLRecord)
+	for _, r := range c.URLInventory() {
+		if r.Status == URLStatusError {
+			errored[r.URL] = r
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var retry []URLInfo
+	for _, u := range urls {
+		rec, ok := errored[u]
+		if !ok || !c.state.Visited[u] {
+			continue
+		}
+		delete(errored, u) // Listed twice
+		delete(c.state.Visited, u)
+		c.state.URLDepths[u] = rec.Depth
+		c.state.Queued[u] = true
+		c.targetQueued(u)
+		c.inventory.Requeue(u)
+		c.metrics.RetryErrored(rec.Depth)
+		retry = append(retry, URLInfo{URL: u, Depth: rec.Depth})
+	}
+	if len(retry) == 0 {
+		return 0, ErrNotRetryable
+	}
+	c.state.Queue = append(retry, c.state.Queue...)
+	c.metrics.SetQueueSize(len(c.state.Queue))
+	c.log.Info("Queued %d errored URLs again", len(retry))
+	return len(retry), nil
+}
@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClassifyURLError(t *testing.T) {
+	tests := []struct {
+		status int
+		reason string
+		want   string
+	}{
+		{401, "HTTP 401", ErrorClassAuth},
+		{403, "HTTP 403", ErrorClassAuth},
+		{404, "HTTP 404", ErrorClassNotFound},
+		{410, "HTTP 410", ErrorClassNotFound},
+		{429, "HTTP 429", ErrorClassRateLimited},
+		{418, "HTTP 418", ErrorClassClient},
+		{503, "HTTP 503", ErrorClassServer},
+		{0, "timed out during fetch", ErrorClassTimeout},
+		{0, `Get "https://example.com/": context deadline exceeded`, ErrorClassTimeout},
+		{0, `Get "https://example.com/": dial tcp: lookup example.com: no such host`, ErrorClassNetwork},
+		{0, "tls: failed to verify certificate", ErrorClassNetwork},
+		{0, `Get "https://example.com/a": redirect loop`, ErrorClassRedirect},
+		{0, "too many redirects", ErrorClassRedirect},
+		{0, "browser crashed: target closed", ErrorClassBrowser},
+		{200, "parse error: unexpected EOF", ErrorClassParse},
+		{200, "save error: disk full", ErrorClassSave},
+		{0, "something odd", ErrorClassOther},
+	}
+	for _, tt := range tests {
+		rec := URLRecord{Status: URLStatusError, HTTPStatus: tt.status, Reason: tt.reason}
+		if got := ClassifyURLError(rec); got != tt.want {
+			t.Errorf("ClassifyURLError(%d, %q) = %s, want %s", tt.status, tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestURLErrors(t *testing.T) {
+	records := []URLRecord{
+		{URL: "https://example.com/", Status: URLStatusSaved},
+		{URL: "https://example.com/a", Status: URLStatusError, HTTPStatus: 403, Reason: "HTTP 403", Depth: 1, Referrer: "https://example.com/"},
+		{URL: "https://example.com/b", Status: URLStatusError, HTTPStatus: 500, Reason: "HTTP 500", Depth: 1},
+	}
+	errs := URLErrors(records, "")
+	if len(errs) != 2 || errs[0].Class != ErrorClassAuth || errs[0].Referrer != "https://example.com/" {
+		t.Fatalf("URLErrors() = %+v", errs)
+	}
+	if got := URLErrors(records, ErrorClassServer); len(got) != 1 || got[0].URL != "https://example.com/b" {
+		t.Errorf("URLErrors(server) = %+v", got)
+	}
+	if counts := CountErrorClasses(errs); counts[ErrorClassAuth] != 1 || counts[ErrorClassServer] != 1 {
+		t.Errorf("CountErrorClasses() = %v", counts)
+	}
+}
+
+func TestRetryURLsOnRunningCrawl(t *testing.T) {
+	var loggedIn atomic.Bool
+	var failed atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><h1>Home</h1><p>Welcome to the members area.</p><a href="/members">Members</a> <a href="/slow">Slow</a></body></html>`)
+	})
+	mux.HandleFunc("/members", func(w http.ResponseWriter, r *http.Request) {
+		if !loggedIn.Load() {
+			failed.Store(true)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>Members</h1><p>Only for logged-in members.</p></body></html>`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+		fmt.Fprint(w, `<html><body><h1>Slow</h1><p>This page takes its time.</p></body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	c, err := NewCrawler(Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+	}, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Start() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !failed.Load() || len(URLErrors(c.URLInventory(), "")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the members page never errored")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	errs, err := c.CrawlErrors(ErrorClassAuth)
+	if err != nil || len(errs) != 1 || errs[0].Referrer != server.URL+"/" {
+		t.Fatalf("CrawlErrors(auth) = %+v, %v", errs, err)
+	}
+
+	loggedIn.Store(true)
+	if n, err := c.RetryURLs([]string{errs[0].URL, server.URL + "/"}); err != nil || n != 1 {
+		t.Fatalf("RetryURLs() = %d, %v; want 1 queued", n, err)
+	}
+	if _, err := c.RetryURLs([]string{errs[0].URL}); err != ErrNotRetryable {
+		t.Errorf("retrying a queued URL: error = %v, want ErrNotRetryable", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if errs := URLErrors(c.URLInventory(), ""); len(errs) != 0 {
+		t.Errorf("errors after retry = %+v", errs)
+	}
+	if m := c.metrics.GetSnapshot(); m.URLsErrored != 0 || m.URLsSaved != 3 {
+		t.Errorf("metrics: %d errored, %d saved; want 0 and 3", m.URLsErrored, m.URLsSaved)
+	}
+	if _, err := c.RetryErrors(""); err == nil {
+		t.Error("RetryErrors() after the crawl ended should fail")
+	}
+}
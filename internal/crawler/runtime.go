@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfigUpdate holds the settings that can be changed while a crawl runs.
+// Nil fields are left unchanged.
+type ConfigUpdate struct {
+	Delay    *time.Duration
+	Workers  *int
+	MaxPages *int
+	Verbose  *bool
+}
+
+// RuntimeConfig reports the current values of the runtime-tunable settings
+type RuntimeConfig struct {
+	Delay    string `json:"delay"`
+	Workers  int    `json:"workers,omitempty"` // 0 in sequential mode
+	MaxPages int    `json:"maxPages"`
+	Verbose  bool   `json:"verbose"`
+}
+
+// RuntimeConfig returns the current runtime-tunable settings
+func (c *Crawler) RuntimeConfig() RuntimeConfig {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	return RuntimeConfig{
+		Delay:    c.config.Delay.String(),
+		Workers:  c.Workers(),
+		MaxPages: c.config.MaxPages,
+		Verbose:  c.log.IsVerbose(),
+	}
+}
+
+// UpdateConfig applies a runtime configuration change without restarting the
+// crawl. The update is validated as a whole before anything is applied.
+func (c *Crawler) UpdateConfig(update ConfigUpdate) (RuntimeConfig, error) {
+	if update.Delay != nil && *update.Delay < 0 {
+		return RuntimeConfig{}, fmt.Errorf("delay cannot be negative, got: %v", *update.Delay)
+	}
+	if update.Delay != nil && c.config.Polite != nil && *update.Delay < c.config.Polite.MinDelay {
+		return RuntimeConfig{}, fmt.Errorf("polite mode: delay must be at least %v, got: %v", c.config.Polite.MinDelay, *update.Delay)
+	}
+	if update.MaxPages != nil && *update.MaxPages < 0 {
+		return RuntimeConfig{}, fmt.Errorf("max-pages cannot be negative, got: %d", *update.MaxPages)
+	}
+	if update.Workers != nil {
+		if c.workers == nil {
+			return RuntimeConfig{}, fmt.Errorf("workers can only be changed in concurrent mode")
+		}
+		if *update.Workers < 1 || *update.Workers > MaxWorkers {
+			return RuntimeConfig{}, fmt.Errorf("workers must be between 1 and %d, got: %d", MaxWorkers, *update.Workers)
+		}
+	}
+
+	c.configMu.Lock()
+	if update.Delay != nil {
+		c.config.Delay = *update.Delay
+	}
+	if update.MaxPages != nil {
+		c.config.MaxPages = *update.MaxPages
+	}
+	if update.Verbose != nil {
+		c.config.Verbose = *update.Verbose
+		c.log.SetVerbose(*update.Verbose)
+	}
+	c.configMu.Unlock()
+
+	if update.Workers != nil {
+		c.workers.SetLimit(*update.Workers)
+	}
+
+	current := c.RuntimeConfig()
+	c.log.Info("Configuration updated: delay=%s workers=%d max-pages=%d verbose=%v",
+		current.Delay, current.Workers, current.MaxPages, current.Verbose)
+	EmitConfigChanged(c.emitter, current)
+
+	return current, nil
+}
+
+// delay returns the current delay between fetches
+func (c *Crawler) delay() time.Duration {
+	c.configMu.RLock()
+	defer c.configMu.RUnlock()
+	// Replayed responses do not reach any server
+	if c.config.Cassette == CassetteReplay {
+		return 0
+	}
+	return c.config.Delay
+}
+
+// pageLimitReached reports whether the configured page limit has been hit
+func (c *Crawler) pageLimitReached() bool {
+	c.configMu.RLock()
+	maxPages := c.config.MaxPages
+	c.configMu.RUnlock()
+
+	if maxPages > 0 && c.metrics.Saved() >= int64(maxPages) {
+		c.log.Info("Page limit of %d reached, stopping crawl", maxPages)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events []CrawlerEvent
+}
+
+func (r *recordingEmitter) Emit(event CrawlerEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func TestUpdateConfig(t *testing.T) {
+	emitter := &recordingEmitter{}
+	c, err := NewCrawlerWithEmitter(Config{
+		URL:        "https://example.com",
+		MaxDepth:   1,
+		Concurrent: true,
+		Delay:      time.Second,
+	}, t.Context(), emitter)
+	if err != nil {
+		t.Fatalf("NewCrawlerWithEmitter() error = %v", err)
+	}
+	defer c.Close()
+
+	delay := 250 * time.Millisecond
+	workers := 4
+	maxPages := 20
+	verbose := true
+	current, err := c.UpdateConfig(ConfigUpdate{Delay: &delay, Workers: &workers, MaxPages: &maxPages, Verbose: &verbose})
+	if err != nil {
+		t.Fatalf("UpdateConfig() error = %v", err)
+	}
+
+	expected := RuntimeConfig{Delay: "250ms", Workers: 4, MaxPages: 20, Verbose: true}
+	if current != expected {
+		t.Errorf("UpdateConfig() = %+v, want %+v", current, expected)
+	}
+	if c.delay() != delay || !c.log.IsVerbose() {
+		t.Error("settings were not applied to the crawler")
+	}
+
+	var changed *CrawlerEvent
+	for i := range emitter.events {
+		if emitter.events[i].Type == EventConfigChanged {
+			changed = &emitter.events[i]
+		}
+	}
+	if changed == nil {
+		t.Fatal("expected a config_changed event")
+	}
+	if changed.Data.(RuntimeConfig) != expected {
+		t.Errorf("event data = %+v, want %+v", changed.Data, expected)
+	}
+}
+
+func TestUpdateConfigValidation(t *testing.T) {
+	c, err := NewCrawler(Config{URL: "https://example.com", MaxDepth: 1, Delay: time.Second}, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+
+	negative := -1
+	delay := 2 * time.Second
+	workers := 4
+
+	tests := []struct {
+		name   string
+		update ConfigUpdate
+	}{
+		{"negative max pages", ConfigUpdate{Delay: &delay, MaxPages: &negative}},
+		{"workers in sequential mode", ConfigUpdate{Delay: &delay, Workers: &workers}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.UpdateConfig(tt.update); err == nil {
+				t.Error("expected error")
+			}
+			// A rejected update must not partially apply
+			if c.delay() != time.Second {
+				t.Errorf("delay = %v, want unchanged 1s", c.delay())
+			}
+		})
+	}
+}
+
+func TestPageLimitReached(t *testing.T) {
+	c, err := NewCrawler(Config{URL: "https://example.com", MaxDepth: 1, MaxPages: 2}, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+
+	c.metrics.IncrementSaved(10)
+	if c.pageLimitReached() {
+		t.Error("limit should not be reached after 1 of 2 pages")
+	}
+	c.metrics.IncrementSaved(10)
+	if !c.pageLimitReached() {
+		t.Error("limit should be reached after 2 of 2 pages")
+	}
+
+	unlimited := 0
+	c.UpdateConfig(ConfigUpdate{MaxPages: &unlimited})
+	if c.pageLimitReached() {
+		t.Error("limit of 0 should be unlimited")
+	}
+}
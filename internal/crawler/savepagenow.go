@@ -0,0 +1,226 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WaybackSaveKeyEnv holds the archive.org API key of Save Page Now
+// submissions when the config has none
+const WaybackSaveKeyEnv = "SCRAPER_WAYBACK_KEY"
+
+const (
+	DefaultWaybackSaveInterval = 10 * time.Second // Time between submissions
+	waybackSaveQueue           = 10000            // URLs waiting for submission; more are skipped
+	waybackSaveMaxFailures     = 10               // Consecutive failures after which submission is turned off
+	waybackSaveMaxAttempts     = 3                // Attempts of a URL answered with HTTP 429
+	waybackSaveBackoff         = time.Minute      // Wait after HTTP 429 without Retry-After
+	waybackSaveMaxResponse     = 64 * 1024        // Bytes of response read
+)
+
+// waybackSaveURL is the Save Page Now endpoint. Replaced in tests.
+var waybackSaveURL = "https://web.archive.org/save"
+
+func validateWaybackSave(config *Config) error {
+	if config.WaybackSaveInterval < 0 {
+		return fmt.Errorf("wayback save interval must not be negative, got: %s", config.WaybackSaveInterval)
+	}
+	key := config.WaybackSaveKey
+	if key != "" && !strings.HasPrefix(key, SecretRefPrefix) && !strings.Contains(key, ":") {
+		return fmt.Errorf("wayback save key must be an archive.org accesskey:secret pair or a secret:// reference")
+	}
+	return nil
+}
+
+// waybackSaver submits the URLs of saved pages to the Wayback Machine's
+// Save Page Now from one goroutine, a submission per interval so archive.org
+// does not turn it away. URLs answered with HTTP 429 are tried again after
+// the wait the answer asks for; after waybackSaveMaxFailures failures in a
+// row submission is turned off for the rest of the crawl.
+type waybackSaver struct {
+	client *waybackClient
+	key    string // archive.org accesskey:secret, "" for anonymous captures
+	log    *Logger
+	jobs   chan string
+	done   chan struct{}
+
+	mu        sync.Mutex // Guards the counters below
+	submitted int
+	failed    int
+	skipped   int // Queue full, submission turned off or the crawl stopped
+	failures  int // Consecutive
+	disabled  bool
+	lastError error
+}
+
+// newWaybackSaver creates a saver with key, whose secret reference, if
+// any, is already resolved
+func newWaybackSaver(key string, interval time.Duration, log *Logger) *waybackSaver {
+	if key == "" {
+		key = os.Getenv(WaybackSaveKeyEnv)
+	}
+	if interval == 0 {
+		interval = DefaultWaybackSaveInterval
+	}
+	return &waybackSaver{client: newWaybackClient(0, interval), key: key, log: log}
+}
+
+// start begins taking URLs, submitting them until ctx is done
+func (s *waybackSaver) start(ctx context.Context) {
+	s.submitted, s.failed, s.skipped, s.failures, s.disabled, s.lastError = 0, 0, 0, 0, false, nil
+	s.jobs = make(chan string, waybackSaveQueue)
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		for rawURL := range s.jobs {
+			if ctx.Err() != nil || s.off() {
+				s.mu.Lock()
+				s.skipped++
+				s.mu.Unlock()
+				continue
+			}
+			s.submit(ctx, rawURL)
+		}
+	}()
+}
+
+// add queues the URL of a saved page. It never blocks: with the queue full
+// or submission turned off the URL is skipped.
+func (s *waybackSaver) add(rawURL string) {
+	if !s.off() {
+		select {
+		case s.jobs <- rawURL:
+			return
+		default:
+		}
+	}
+	s.mu.Lock()
+	s.skipped++
+	s.mu.Unlock()
+}
+
+// off reports whether submission was turned off after repeated failures
+func (s *waybackSaver) off() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disabled
+}
+
+// close waits for the queued URLs, which stopping the crawl skips, and
+// stops the saver
+func (s *waybackSaver) close() {
+	if queued := len(s.jobs); queued > 0 && !s.off() {
+		s.log.Info("Submitting the last %d pages to the Wayback Machine, about %s", queued, time.Duration(queued)*s.client.gap)
+	}
+	close(s.jobs)
+	<-s.done
+	if s.failed > 0 || s.skipped > 0 {
+		s.log.Warn("Submitted %d pages to the Wayback Machine; %d failed and %d were skipped, the last error was: %v", s.submitted, s.failed, s.skipped, s.lastError)
+	} else if s.submitted > 0 {
+		s.log.Info("Submitted %d pages to the Wayback Machine", s.submitted)
+	}
+}
+
+// submit sends a URL to Save Page Now, waiting and trying again while it
+// is rate limited
+func (s *waybackSaver) submit(ctx context.Context, rawURL string) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = s.post(ctx, rawURL)
+		if retryAfter == 0 || attempt == waybackSaveMaxAttempts {
+			break
+		}
+		s.log.Debug("Wayback Machine rate limit hit submitting %s, waiting %s", rawURL, retryAfter)
+		s.client.backOff(retryAfter)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		s.skipped++
+		return
+	}
+	if err == nil {
+		s.submitted++
+		s.failures = 0
+		return
+	}
+	s.failed++
+	s.failures++
+	s.lastError = err
+	if s.failures == 1 {
+		s.log.Warn("Failed to submit %s to the Wayback Machine: %v", rawURL, err)
+	}
+	if s.failures >= waybackSaveMaxFailures && !s.disabled {
+		s.disabled = true
+		s.log.Warn("Wayback Machine submission turned off after %d failures in a row", s.failures)
+	}
+}
+
+// post submits a URL once the request gap allows. A submission answered
+// with HTTP 429 returns the time to wait before trying again.
+func (s *waybackSaver) post(ctx context.Context, rawURL string) (time.Duration, error) {
+	if err := s.client.wait(ctx); err != nil {
+		return 0, err
+	}
+	form := url.Values{"url": {rawURL}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, waybackSaveURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	if s.key != "" {
+		req.Header.Set("Authorization", "LOW "+s.key)
+	}
+	resp, err := s.client.fetcher.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, waybackSaveMaxResponse))
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait := waybackSaveBackoff
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		return wait, fmt.Errorf("rate limited (HTTP 429)")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	// A capture that started answers with its job; a refused one with why
+	var answer struct {
+		JobID     string `json:"job_id"`
+		StatusExt string `json:"status_ext"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return 0, fmt.Errorf("response is not JSON")
+	}
+	if answer.JobID == "" {
+		switch {
+		case answer.Message != "":
+			return 0, errors.New(answer.Message)
+		case answer.StatusExt != "":
+			return 0, errors.New(answer.StatusExt)
+		}
+		return 0, fmt.Errorf("no capture started")
+	}
+	return 0, nil
+}
@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSavePageNow records the URLs submitted to it and answers with
+// answer, pointing waybackSaveURL at itself for the test
+func fakeSavePageNow(t *testing.T, answer func(w http.ResponseWriter, r *http.Request)) (submitted func() []string, auth func() string) {
+	t.Helper()
+	var mu sync.Mutex
+	var urls []string
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Accept") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		urls = append(urls, r.FormValue("url"))
+		authorization = r.Header.Get("Authorization")
+		mu.Unlock()
+		answer(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	saveURL := waybackSaveURL
+	waybackSaveURL = server.URL + "/save"
+	t.Cleanup(func() { waybackSaveURL = saveURL })
+	submitted = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(urls)
+	}
+	auth = func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return authorization
+	}
+	return submitted, auth
+}
+
+func TestWaybackSave(t *testing.T) {
+	submitted, auth := fakeSavePageNow(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"url": "`+r.FormValue("url")+`", "job_id": "spn2-1"}`)
+	})
+	site := embeddingsSite()
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:                 site.URL + "/",
+		MaxDepth:            1,
+		OutputDir:           outputDir,
+		StateFile:           filepath.Join(outputDir, "state.json"),
+		MinContentLength:    10,
+		WaybackSave:         true,
+		WaybackSaveKey:      "access:secret",
+		WaybackSaveInterval: time.Millisecond,
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatalf("ValidateConfig() error = %v", err)
+	}
+	if _, err := runSelfTestCrawl(t.Context(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	got := submitted()
+	slices.Sort(got)
+	want := []string{site.URL + "/", site.URL + "/a", site.URL + "/b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("submitted %q, want the saved pages %q", got, want)
+	}
+	if auth() != "LOW access:secret" {
+		t.Errorf("Authorization = %q, want the S3-style key", auth())
+	}
+}
+
+func TestWaybackSaveRateLimited(t *testing.T) {
+	calls := 0
+	submitted, auth := fakeSavePageNow(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"job_id": "spn2-2"}`)
+	})
+
+	s := newWaybackSaver("", time.Millisecond, &Logger{})
+	start := time.Now()
+	s.submit(t.Context(), "https://example.com/")
+	if s.submitted != 1 || s.failed != 0 {
+		t.Errorf("submitted %d, failed %d (%v); want the URL submitted after the wait", s.submitted, s.failed, s.lastError)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the Retry-After of 1s", elapsed)
+	}
+	if got := submitted(); len(got) != 2 {
+		t.Errorf("submitted %q, want the URL twice", got)
+	}
+	if auth() != "" {
+		t.Errorf("Authorization = %q without a key, want none", auth())
+	}
+}
+
+func TestWaybackSaveTurnedOff(t *testing.T) {
+	submitted, _ := fakeSavePageNow(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": "error", "status_ext": "error:too-many-daily-captures", "message": "This URL has been captured 10 times today."}`)
+	})
+
+	s := newWaybackSaver("access:secret", time.Millisecond, &Logger{})
+	s.start(t.Context())
+	for i := range waybackSaveMaxFailures + 5 {
+		s.add(fmt.Sprintf("https://example.com/%d", i))
+	}
+	s.close()
+	if s.failed != waybackSaveMaxFailures || s.skipped != 5 || !s.disabled {
+		t.Errorf("failed %d, skipped %d, disabled %v; want %d failed and the rest skipped", s.failed, s.skipped, s.disabled, waybackSaveMaxFailures)
+	}
+	if s.lastError == nil || !strings.Contains(s.lastError.Error(), "captured 10 times") {
+		t.Errorf("last error = %v, want the message of the answer", s.lastError)
+	}
+	if n := len(submitted()); n != waybackSaveMaxFailures {
+		t.Errorf("sent %d submissions, want none after turning off", n)
+	}
+}
+
+func TestValidateConfigWaybackSave(t *testing.T) {
+	for _, tt := range []struct {
+		key   string
+		valid bool
+	}{
+		{"", true},
+		{"access:secret", true},
+		{"secret://archive-org", true},
+		{"access-only", false},
+	} {
+		config := Config{URL: "https://example.com/", MaxDepth: 1, OutputDir: t.TempDir(), WaybackSave: true, WaybackSaveKey: tt.key}
+		if err := ValidateConfig(&config); (err == nil) != tt.valid {
+			t.Errorf("ValidateConfig() with key %q error = %v, want valid %v", tt.key, err, tt.valid)
+		}
+	}
+}
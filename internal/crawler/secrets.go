@@ -0,0 +1,324 @@
+package crawler
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Environment variables configuring the secrets store
+const (
+	SecretsKeyEnv  = "SCRAPER_SECRETS_KEY"  // Master key the store is encrypted with
+	SecretsFileEnv = "SCRAPER_SECRETS_FILE" // Store path (default: secrets.enc in the user config dir)
+)
+
+// SecretRefPrefix starts a reference to a stored secret, e.g.
+// "secret://corp-proxy"
+const SecretRefPrefix = "secret://"
+
+// secretsKDFIterations is the PBKDF2 work factor deriving the encryption
+// key from the master key
+const secretsKDFIterations = 600000
+
+// ErrSecretsKeyMissing is returned when the store is used without a master key
+var ErrSecretsKeyMissing = fmt.Errorf("secrets store requires a master key in %s", SecretsKeyEnv)
+
+var (
+	secretNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,99}$`)
+	secretRefRegex  = regexp.MustCompile(`secret://([A-Za-z0-9][A-Za-z0-9_.-]{0,99})`)
+)
+
+// secretsFile is the on-disk form of a SecretStore: the secrets as a JSON
+// object, sealed with AES-256-GCM under a key derived from the master key
+type secretsFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// SecretStore holds named secrets (passwords, proxy URLs with credentials,
+// ...) encrypted at rest, so configs, presets and API payloads can refer to
+// them as secret://name instead of carrying the plaintext
+type SecretStore struct {
+	path       string
+	key        []byte
+	salt       []byte
+	iterations int
+	secrets    map[string]string
+}
+
+// DefaultSecretsPath returns the store path from SecretsFileEnv, or
+// secrets.enc in the user config directory
+func DefaultSecretsPath() (string, error) {
+	if path := os.Getenv(SecretsFileEnv); path != "" {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, "scraper", "secrets.enc"), nil
+}
+
+// OpenDefaultSecretStore opens the store at DefaultSecretsPath with the
+// master key from SecretsKeyEnv
+func OpenDefaultSecretStore() (*SecretStore, error) {
+	masterKey := os.Getenv(SecretsKeyEnv)
+	if masterKey == "" {
+		return nil, ErrSecretsKeyMissing
+	}
+	path, err := DefaultSecretsPath()
+	if err != nil {
+		return nil, err
+	}
+	return OpenSecretStore(path, masterKey)
+}
+
+// OpenSecretStore decrypts the store at path with masterKey. A missing file
+// is an empty store, created on the first Save.
+func OpenSecretStore(path, masterKey string) (*SecretStore, error) {
+	if masterKey == "" {
+		return nil, ErrSecretsKeyMissing
+	}
+	s := &SecretStore{path: path, iterations: secretsKDFIterations, secrets: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.salt = make([]byte, 16)
+		if _, err := rand.Read(s.salt); err != nil {
+			return nil, err
+		}
+		s.key = deriveSecretsKey(masterKey, s.salt, s.iterations)
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets store: %v", err)
+	}
+
+	var file secretsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets store %s: %v", path, err)
+	}
+	if file.Version != 1 || file.Iterations <= 0 {
+		return nil, fmt.Errorf("unsupported secrets store version %d", file.Version)
+	}
+	s.salt = file.Salt
+	s.iterations = file.Iterations
+	s.key = deriveSecretsKey(masterKey, s.salt, s.iterations)
+	gcm, err := secretsCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets store %s: wrong master key or corrupted file", path)
+	}
+	if err := json.Unmarshal(plain, &s.secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets store %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// derivedKeys caches PBKDF2 results, which are slow on purpose, by master
+// key and salt
+var derivedKeys sync.Map
+
+// deriveSecretsKey derives the AES-256 key of a store
+func deriveSecretsKey(masterKey string, salt []byte, iterations int) []byte {
+	id := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%x\x00%d", masterKey, salt, iterations)))
+	if key, ok := derivedKeys.Load(id); ok {
+		return key.([]byte)
+	}
+	key, _ := pbkdf2.Key(sha256.New, masterKey, salt, iterations, 32)
+	derivedKeys.Store(id, key)
+	return key
+}
+
+// secretsCipher returns the AES-GCM cipher of key
+func secretsCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Path returns the file the store is kept in
+func (s *SecretStore) Path() string {
+	return s.path
+}
+
+// Names returns the names of the stored secrets, sorted
+func (s *SecretStore) Names() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the value of the secret name
+func (s *SecretStore) Get(name string) (string, bool) {
+	value, ok := s.secrets[name]
+	return value, ok
+}
+
+// Set stores value as the secret name; call Save to write the store
+func (s *SecretStore) Set(name, value string) error {
+	if !secretNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '.', '_' and '-' (at most 100)", name)
+	}
+	if value == "" {
+		return fmt.Errorf("secret %q cannot be empty", name)
+	}
+	s.secrets[name] = value
+	return nil
+}
+
+// Delete removes the secret name and reports whether it existed; call Save
+// to write the store
+func (s *SecretStore) Delete(name string) bool {
+	_, ok := s.secrets[name]
+	delete(s.secrets, name)
+	return ok
+}
+
+// Save encrypts the store with a fresh nonce and writes it, readable by the
+// owner only
+func (s *SecretStore) Save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := secretsCipher(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(secretsFile{
+		Version:    1,
+		Iterations: s.iterations,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Data:       gcm.Seal(nil, nonce, plain, nil),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %v", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets store: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write secrets store: %v", err)
+	}
+	return nil
+}
+
+// IsSecretRef reports whether s is a reference to a stored secret and
+// nothing else, e.g. "secret://corp-proxy"
+func IsSecretRef(s string) bool {
+	m := secretRefRegex.FindStringIndex(s)
+	return m != nil && m[0] == 0 && m[1] == len(s)
+}
+
+// SecretRefs returns the names of the secrets config refers to, in the
+// proxy URL, the page scripts and the embeddings, enrichment and Wayback
+// Machine API keys
+func SecretRefs(config Config) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(s string) {
+		for _, m := range secretRefRegex.FindAllStringSubmatch(s, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	add(config.Geo.Proxy)
+	add(config.Embeddings.APIKey)
+	add(config.Enrichment.APIKey)
+	add(config.WaybackSaveKey)
+	for _, ps := range config.PageScripts {
+		add(ps.Script)
+	}
+	return names
+}
+
+// ResolveSecrets returns a copy of config with its secret references
+// replaced by the values in the default store. The proxy gets the value
+// as is; in page scripts it is escaped for use inside a JavaScript string
+// literal. A config without references is returned unchanged and needs no
+// store.
+func ResolveSecrets(config Config) (Config, error) {
+	names := SecretRefs(config)
+	if len(names) == 0 {
+		return config, nil
+	}
+	store, err := OpenDefaultSecretStore()
+	if err != nil {
+		return config, err
+	}
+	return store.resolve(config, names)
+}
+
+// resolve replaces the references to names in config with their values
+func (s *SecretStore) resolve(config Config, names []string) (Config, error) {
+	for _, name := range names {
+		if _, ok := s.secrets[name]; !ok {
+			return config, fmt.Errorf("unknown secret %q in %s", name, s.path)
+		}
+	}
+	replace := func(text string, escape func(string) string) string {
+		return secretRefRegex.ReplaceAllStringFunc(text, func(ref string) string {
+			return escape(s.secrets[strings.TrimPrefix(ref, SecretRefPrefix)])
+		})
+	}
+
+	config.Geo.Proxy = replace(config.Geo.Proxy, func(v string) string { return v })
+	config.Embeddings.APIKey = replace(config.Embeddings.APIKey, func(v string) string { return v })
+	config.Enrichment.APIKey = replace(config.Enrichment.APIKey, func(v string) string { return v })
+	config.WaybackSaveKey = replace(config.WaybackSaveKey, func(v string) string { return v })
+	if len(config.PageScripts) > 0 {
+		scripts := make([]PageScript, len(config.PageScripts))
+		for i, ps := range config.PageScripts {
+			ps.Script = replace(ps.Script, jsStringEscape)
+			scripts[i] = ps
+		}
+		config.PageScripts = scripts
+	}
+	return config, nil
+}
+
+// jsStringEscape escapes v for any JavaScript string literal: double,
+// single or backtick quoted
+func jsStringEscape(v string) string {
+	quoted, _ := json.Marshal(v)
+	escaped := string(quoted[1 : len(quoted)-1])
+	escaped = strings.ReplaceAll(escaped, "'", `\u0027`)
+	escaped = strings.ReplaceAll(escaped, "`", `\u0060`)
+	return strings.ReplaceAll(escaped, "${", `\u0024{`)
+}
@@ -0,0 +1,342 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SelfTestResult is the outcome of one self-test check
+type SelfTestResult struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Passed      bool    `json:"passed"`
+	Seconds     float64 `json:"seconds"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// selfTestCheck crawls a synthetic site into dir and verifies the outcome
+type selfTestCheck struct {
+	name        string
+	description string
+	run         func(ctx context.Context, dir string) error
+}
+
+var selfTestChecks = []selfTestCheck{
+	{"crawl", "sequential crawl saves every page exactly once", checkSelfTestCrawl},
+	{"depth-limit", "pages beyond the depth limit are never fetched", checkSelfTestDepthLimit},
+	{"robots", "robots.txt disallowed pages are never fetched", checkSelfTestRobots},
+	{"redirects", "redirects are followed and recorded", checkSelfTestRedirects},
+	{"concurrency", "concurrent workers fetch in parallel without duplicates", checkSelfTestConcurrency},
+	{"resume", "a crawl killed mid-way resumes from its state file", checkSelfTestResume},
+}
+
+// SelfTestNames returns the names of the self-test checks in run order
+func SelfTestNames() []string {
+	names := make([]string, len(selfTestChecks))
+	for i, c := range selfTestChecks {
+		names[i] = c.name
+	}
+	return names
+}
+
+// RunSelfTest crawls synthetic sites served on a local port to verify the
+// crawler works in this installation. names selects checks (all when empty);
+// each check writes its output to a subdirectory of dir. onResult, when set,
+// is called as each check finishes.
+func RunSelfTest(ctx context.Context, dir string, names []string, onResult func(SelfTestResult)) ([]SelfTestResult, error) {
+	checks := selfTestChecks
+	if len(names) > 0 {
+		checks = nil
+		for _, name := range names {
+			found := false
+			for _, c := range selfTestChecks {
+				if c.name == name {
+					checks = append(checks, c)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown self-test check %q (available: %s)", name, strings.Join(SelfTestNames(), ", "))
+			}
+		}
+	}
+
+	var results []SelfTestResult
+	for _, c := range checks {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		checkDir := filepath.Join(dir, c.name)
+		start := time.Now()
+		err := os.MkdirAll(checkDir, 0755)
+		if err == nil {
+			err = c.run(ctx, checkDir)
+		}
+
+		result := SelfTestResult{
+			Name:        c.name,
+			Description: c.description,
+			Passed:      err == nil,
+			Seconds:     time.Since(start).Seconds(),
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+	return results, nil
+}
+
+// selfTestConfig returns a fast crawl configuration for a synthetic site
+func selfTestConfig(site *TestSite, dir string) Config {
+	return Config{
+		URL:              site.PageURL(0),
+		MaxDepth:         10,
+		OutputDir:        dir,
+		StateFile:        filepath.Join(dir, "state.json"),
+		MinContentLength: 10,
+	}
+}
+
+// runSelfTestCrawl runs a crawl to completion
+func runSelfTestCrawl(ctx context.Context, config Config) (*Crawler, error) {
+	c, err := NewCrawler(config, ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// expectSaved checks that the inventory of dir records every page of pages
+// as saved, under the URL it was linked with
+func expectSaved(site *TestSite, dir string, pages []int) error {
+	records, err := LoadURLInventory(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read URL inventory: %w", err)
+	}
+	status := make(map[string]string, len(records))
+	for _, r := range records {
+		status[r.URL] = r.Status
+	}
+	for _, n := range pages {
+		if s := status[site.LinkURL(n)]; s != URLStatusSaved {
+			return fmt.Errorf("%s was not saved (status %q)", site.LinkURL(n), s)
+		}
+	}
+	return nil
+}
+
+// expectFetchedOnce checks that every page of pages was requested exactly once
+func expectFetchedOnce(site *TestSite, pages []int) error {
+	for _, n := range pages {
+		if hits := site.Hits(site.PagePath(n)); hits != 1 {
+			return fmt.Errorf("%s fetched %d times, want 1", site.PagePath(n), hits)
+		}
+	}
+	return nil
+}
+
+func checkSelfTestCrawl(ctx context.Context, dir string) error {
+	site := NewTestSite(TestSiteOptions{Pages: 30, Structure: TestSiteTree})
+	defer site.Close()
+
+	c, err := runSelfTestCrawl(ctx, selfTestConfig(site, dir))
+	if err != nil {
+		return err
+	}
+	all := site.PagesWithin(site.Pages())
+	if err := expectFetchedOnce(site, all); err != nil {
+		return err
+	}
+	if err := expectSaved(site, dir, all); err != nil {
+		return err
+	}
+	if saved := c.GetMetrics().GetSnapshot().URLsSaved; saved != int64(len(all)) {
+		return fmt.Errorf("saved %d pages, want %d", saved, len(all))
+	}
+	return nil
+}
+
+func checkSelfTestDepthLimit(ctx context.Context, dir string) error {
+	const maxDepth = 2
+	site := NewTestSite(TestSiteOptions{Pages: 40, Structure: TestSiteTree})
+	defer site.Close()
+
+	config := selfTestConfig(site, dir)
+	config.MaxDepth = maxDepth
+	if _, err := runSelfTestCrawl(ctx, config); err != nil {
+		return err
+	}
+	for n := 0; n < site.Pages(); n++ {
+		if site.Depth(n) > maxDepth && site.Hits(site.PagePath(n)) > 0 {
+			return fmt.Errorf("%s at depth %d fetched despite depth limit %d", site.PagePath(n), site.Depth(n), maxDepth)
+		}
+	}
+	return expectSaved(site, dir, site.PagesWithin(maxDepth))
+}
+
+func checkSelfTestRobots(ctx context.Context, dir string) error {
+	const disallowed = 3
+	site := NewTestSite(TestSiteOptions{Pages: 10, Disallowed: disallowed})
+	defer site.Close()
+
+	c, err := runSelfTestCrawl(ctx, selfTestConfig(site, dir))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < disallowed; i++ {
+		if site.Hits(site.PrivatePath(i)) > 0 {
+			return fmt.Errorf("%s fetched although robots.txt disallows it", site.PrivatePath(i))
+		}
+	}
+	if blocked := c.GetMetrics().GetSnapshot().RobotsBlocked; blocked != disallowed {
+		return fmt.Errorf("%d URLs blocked by robots.txt, want %d", blocked, disallowed)
+	}
+	return expectSaved(site, dir, site.PagesWithin(site.Pages()))
+}
+
+func checkSelfTestRedirects(ctx context.Context, dir string) error {
+	const redirects = 4
+	site := NewTestSite(TestSiteOptions{Pages: 12, Redirects: redirects})
+	defer site.Close()
+
+	if _, err := runSelfTestCrawl(ctx, selfTestConfig(site, dir)); err != nil {
+		return err
+	}
+	if err := expectSaved(site, dir, site.PagesWithin(site.Pages())); err != nil {
+		return err
+	}
+
+	m, err := LoadRedirectMap(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read redirect map: %w", err)
+	}
+	recorded := make(map[string]string)
+	for _, r := range m.Redirects {
+		recorded[r.From] = r.To
+	}
+	for n := 1; n <= redirects; n++ {
+		from := site.URL + site.RedirectPath(n)
+		if to := recorded[from]; to != site.PageURL(n) {
+			return fmt.Errorf("redirect %s -> %s not recorded (got %q)", from, site.PageURL(n), to)
+		}
+	}
+	return nil
+}
+
+func checkSelfTestConcurrency(ctx context.Context, dir string) error {
+	const workers = 4
+	site := NewTestSite(TestSiteOptions{Pages: 40, Structure: TestSiteMesh, Fanout: 4, Latency: 20 * time.Millisecond})
+	defer site.Close()
+
+	config := selfTestConfig(site, dir)
+	config.MaxDepth = site.Pages()
+	config.Concurrent = true
+	config.Workers = workers
+	if _, err := runSelfTestCrawl(ctx, config); err != nil {
+		return err
+	}
+
+	all := site.PagesWithin(site.Pages())
+	if err := expectFetchedOnce(site, all); err != nil {
+		return err
+	}
+	if inFlight := site.MaxInFlight(); inFlight < 2 || inFlight > workers {
+		return fmt.Errorf("%d requests in flight at once, want between 2 and %d", inFlight, workers)
+	}
+	return expectSaved(site, dir, all)
+}
+
+// checkSelfTestResume kills a crawl right after a periodic state save and
+// discards everything written since, as a crash would, then resumes it
+func checkSelfTestResume(ctx context.Context, dir string) error {
+	site := NewTestSite(TestSiteOptions{Pages: 60, Structure: TestSiteTree, Latency: 5 * time.Millisecond})
+	defer site.Close()
+
+	config := selfTestConfig(site, dir)
+	crawlCtx, kill := context.WithCancel(ctx)
+	defer kill()
+	c, err := NewCrawler(config, crawlCtx)
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Start() }()
+
+	// Wait for the first periodic save
+	var snapshot []byte
+	var saved CrawlerState
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		if data, err := os.ReadFile(config.StateFile); err == nil && json.Unmarshal(data, &saved) == nil && saved.Processed >= StateSaveInterval {
+			snapshot = data
+			break
+		}
+		if time.Now().After(deadline) {
+			kill()
+			<-done
+			c.Close()
+			return errors.New("no state saved before the deadline")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	kill()
+	<-done
+	c.Close()
+
+	// Roll back to the crash point
+	if err := os.WriteFile(config.StateFile, snapshot, 0644); err != nil {
+		return err
+	}
+	os.Remove(filepath.Join(dir, URLInventoryJSONL))
+	os.Remove(filepath.Join(dir, RedirectsFile))
+	if len(saved.Queue) == 0 {
+		return errors.New("crawl finished before it could be killed")
+	}
+
+	if _, err := runSelfTestCrawl(ctx, config); err != nil {
+		return fmt.Errorf("resume failed: %w", err)
+	}
+
+	final, err := LoadState(config.StateFile, config.URL)
+	if err != nil {
+		return err
+	}
+	refetched := 0
+	for _, n := range site.PagesWithin(site.Pages()) {
+		if !final.Visited[site.PageURL(n)] {
+			return fmt.Errorf("%s lost after resume", site.PagePath(n))
+		}
+		switch hits := site.Hits(site.PagePath(n)); {
+		case hits == 0:
+			return fmt.Errorf("%s never fetched", site.PagePath(n))
+		case hits > 2:
+			return fmt.Errorf("%s fetched %d times", site.PagePath(n), hits)
+		case hits == 2:
+			refetched++
+			if saved.Visited[site.PageURL(n)] {
+				return fmt.Errorf("%s fetched again although the state had it visited", site.PagePath(n))
+			}
+		}
+	}
+	if refetched > StateSaveInterval {
+		return fmt.Errorf("%d pages fetched again after resume, want at most %d", refetched, StateSaveInterval)
+	}
+	return nil
+}
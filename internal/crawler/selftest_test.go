@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestTestSite(t *testing.T) {
+	site := NewTestSite(TestSiteOptions{Pages: 10, Structure: TestSiteChain, Redirects: 2, Disallowed: 1})
+	defer site.Close()
+
+	if got := site.Depth(9); got != 9 {
+		t.Errorf("Depth(9) = %d, want 9", got)
+	}
+	if got := len(site.PagesWithin(3)); got != 4 {
+		t.Errorf("PagesWithin(3) has %d pages, want 4", got)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Get(site.LinkURL(1))
+	if err != nil {
+		t.Fatalf("GET %s: %v", site.LinkURL(1), err)
+	}
+	resp.Body.Close()
+	if resp.Request.URL.Path != site.PagePath(1) {
+		t.Errorf("redirect led to %s, want %s", resp.Request.URL.Path, site.PagePath(1))
+	}
+	if site.Hits(site.RedirectPath(1)) != 1 || site.Hits(site.PagePath(1)) != 1 {
+		t.Errorf("expected one hit on the redirect and its target")
+	}
+
+	resp, err = client.Get(site.URL + "/robots.txt")
+	if err != nil {
+		t.Fatalf("GET robots.txt: %v", err)
+	}
+	robots, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(robots) != "User-agent: *\nDisallow: /private/\n" {
+		t.Errorf("unexpected robots.txt %q", robots)
+	}
+
+	resp, err = client.Get(site.URL + "/page/10")
+	if err != nil {
+		t.Fatalf("GET /page/10: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("page beyond the site: status %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	for _, name := range SelfTestNames() {
+		t.Run(name, func(t *testing.T) {
+			results, err := RunSelfTest(context.Background(), t.TempDir(), []string{name}, nil)
+			if err != nil {
+				t.Fatalf("RunSelfTest() error = %v", err)
+			}
+			if len(results) != 1 || results[0].Name != name {
+				t.Fatalf("expected one result for %s, got %+v", name, results)
+			}
+			if !results[0].Passed {
+				t.Errorf("%s failed: %s", name, results[0].Error)
+			}
+		})
+	}
+
+	if _, err := RunSelfTest(context.Background(), t.TempDir(), []string{"nope"}, nil); err == nil {
+		t.Error("expected an error for an unknown check")
+	}
+}
@@ -0,0 +1,487 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SEO report files written to the output directory
+const (
+	SEOReportHTML = "seo_report.html"
+	SEOReportJSON = "seo_report.json"
+)
+
+// SEO issue types
+const (
+	SEOIssueMissingTitle         = "missing_title"
+	SEOIssueDuplicateTitle       = "duplicate_title"
+	SEOIssueMissingDescription   = "missing_description"
+	SEOIssueDuplicateDescription = "duplicate_description"
+	SEOIssueMissingCanonical     = "missing_canonical"
+	SEOIssueMissingH1            = "missing_h1"
+	SEOIssueMultipleH1           = "multiple_h1"
+	SEOIssueSkippedHeading       = "skipped_heading_level"
+	SEOIssueBrokenLink           = "broken_internal_link"
+)
+
+// seoIssueLabels are the headings used for each issue type in the HTML report
+var seoIssueLabels = map[string]string{
+	SEOIssueMissingTitle:         "Missing title",
+	SEOIssueDuplicateTitle:       "Duplicate title",
+	SEOIssueMissingDescription:   "Missing meta description",
+	SEOIssueDuplicateDescription: "Duplicate meta description",
+	SEOIssueMissingCanonical:     "Missing canonical tag",
+	SEOIssueMissingH1:            "Missing h1",
+	SEOIssueMultipleH1:           "Multiple h1",
+	SEOIssueSkippedHeading:       "Skipped heading level",
+	SEOIssueBrokenLink:           "Broken internal link",
+}
+
+// SEOIssueTypes lists the issue types from most to least severe
+var SEOIssueTypes = []string{
+	SEOIssueBrokenLink,
+	SEOIssueMissingTitle,
+	SEOIssueDuplicateTitle,
+	SEOIssueMissingDescription,
+	SEOIssueDuplicateDescription,
+	SEOIssueMissingCanonical,
+	SEOIssueMissingH1,
+	SEOIssueMultipleH1,
+	SEOIssueSkippedHeading,
+}
+
+// SEOIssue is a single problem found on a saved page
+type SEOIssue struct {
+	Type    string   `json:"type"`
+	URL     string   `json:"url"`               // Page with the issue
+	Detail  string   `json:"detail,omitempty"`  // Offending value, e.g. the duplicated title
+	Target  string   `json:"target,omitempty"`  // Link target for broken links
+	Related []string `json:"related,omitempty"` // Other pages sharing a duplicated value
+}
+
+// SEOPage holds the SEO-relevant data extracted from a saved page
+type SEOPage struct {
+	URL           string `json:"url"`
+	Title         string `json:"title,omitempty"`
+	Description   string `json:"description,omitempty"`
+	Canonical     string `json:"canonical,omitempty"`
+	H1Count       int    `json:"h1_count"`
+	InternalLinks int    `json:"internal_links"`
+	Issues        int    `json:"issues"`
+}
+
+// SEOReport is the result of an SEO audit of a crawl
+type SEOReport struct {
+	GeneratedAt     time.Time      `json:"generated_at"`
+	Pages           int            `json:"pages"`
+	IssueCounts     map[string]int `json:"issue_counts"`
+	Issues          []SEOIssue     `json:"issues"`
+	PageDetails     []SEOPage      `json:"page_details"`
+	BrokenLinkCheck bool           `json:"broken_link_check"` // False when no URL inventory was available
+	HTMLPath        string         `json:"html_path,omitempty"`
+	JSONPath        string         `json:"json_path,omitempty"`
+}
+
+// seoPageData is a parsed page awaiting cross-page checks
+type seoPageData struct {
+	SEOPage
+	links []string // Absolute internal link targets without fragments
+}
+
+// AuditSEO inspects the saved pages of a crawl for missing and duplicate
+// titles and descriptions, missing canonical tags, heading structure issues
+// and, when the crawl's URL inventory is available, broken internal links
+func AuditSEO(outputDir string) (*SEOReport, error) {
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+
+	var pages []*seoPageData
+	var issues []SEOIssue
+	err := forEachSavedPage(outputDir, func(pageURL string, doc *goquery.Document) {
+		page, pageIssues, err := auditSEOPage(pageURL, doc)
+		if err != nil {
+			return
+		}
+		pages = append(pages, page)
+		issues = append(issues, pageIssues...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("no saved pages found in %s", outputDir)
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+
+	issues = append(issues, seoDuplicates(pages, SEOIssueDuplicateTitle, func(p *seoPageData) string { return p.Title })...)
+	issues = append(issues, seoDuplicates(pages, SEOIssueDuplicateDescription, func(p *seoPageData) string { return p.Description })...)
+
+	report := &SEOReport{
+		GeneratedAt: time.Now(),
+		Pages:       len(pages),
+		IssueCounts: make(map[string]int),
+	}
+
+	if records, err := LoadURLInventory(outputDir); err == nil {
+		report.BrokenLinkCheck = true
+		issues = append(issues, seoBrokenLinks(pages, records)...)
+	}
+
+	sortSEOIssues(issues)
+	perPage := make(map[string]int)
+	for _, issue := range issues {
+		report.IssueCounts[issue.Type]++
+		perPage[issue.URL]++
+	}
+	report.Issues = issues
+	if report.Issues == nil {
+		report.Issues = []SEOIssue{}
+	}
+
+	report.PageDetails = make([]SEOPage, len(pages))
+	for i, p := range pages {
+		p.Issues = perPage[p.URL]
+		report.PageDetails[i] = p.SEOPage
+	}
+	return report, nil
+}
+
+// GenerateSEOReport audits a crawl and writes seo_report.html and
+// seo_report.json to its output directory
+func GenerateSEOReport(outputDir string) (*SEOReport, error) {
+	report, err := AuditSEO(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	report.HTMLPath = filepath.Join(outputDir, SEOReportHTML)
+	report.JSONPath = filepath.Join(outputDir, SEOReportJSON)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SEO report: %v", err)
+	}
+	if err := os.WriteFile(report.JSONPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write SEO report: %v", err)
+	}
+
+	html, err := renderSEOReport(report, filepath.Base(outputDir))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(report.HTMLPath, html, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write SEO report: %v", err)
+	}
+	return report, nil
+}
+
+// auditSEOPage extracts the SEO data of one page and reports the issues
+// that can be found without looking at other pages
+func auditSEOPage(pageURL string, doc *goquery.Document) (*seoPageData, []SEOIssue, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page := &seoPageData{SEOPage: SEOPage{URL: pageURL}}
+	page.Title = collapseSpace(doc.Find("head title").First().Text())
+	if page.Title == "" {
+		page.Title = collapseSpace(doc.Find("title").First().Text())
+	}
+	doc.Find("meta[name]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if strings.EqualFold(s.AttrOr("name", ""), "description") {
+			page.Description = collapseSpace(s.AttrOr("content", ""))
+			return false
+		}
+		return true
+	})
+	doc.Find("link[rel]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		for _, rel := range strings.Fields(s.AttrOr("rel", "")) {
+			if strings.EqualFold(rel, "canonical") {
+				page.Canonical = strings.TrimSpace(s.AttrOr("href", ""))
+				return false
+			}
+		}
+		return true
+	})
+
+	var issues []SEOIssue
+	add := func(issueType, detail string) {
+		issues = append(issues, SEOIssue{Type: issueType, URL: pageURL, Detail: detail})
+	}
+	if page.Title == "" {
+		add(SEOIssueMissingTitle, "")
+	}
+	if page.Description == "" {
+		add(SEOIssueMissingDescription, "")
+	}
+	if page.Canonical == "" {
+		add(SEOIssueMissingCanonical, "")
+	}
+
+	var skips []string
+	page.H1Count, skips = headingStructure(doc)
+	switch {
+	case page.H1Count == 0:
+		add(SEOIssueMissingH1, "")
+	case page.H1Count > 1:
+		add(SEOIssueMultipleH1, strconv.Itoa(page.H1Count)+" h1 elements")
+	}
+	if len(skips) > 0 {
+		add(SEOIssueSkippedHeading, skips[0])
+	}
+
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		target, err := base.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host != base.Host {
+			return
+		}
+		target.Fragment = ""
+		link := target.String()
+		if !seen[link] {
+			seen[link] = true
+			page.links = append(page.links, link)
+		}
+	})
+	page.InternalLinks = len(page.links)
+
+	return page, issues, nil
+}
+
+// forEachSavedPage parses the raw HTML of every page saved in outputDir
+// and calls fn with its URL. Unreadable pages are skipped.
+func forEachSavedPage(outputDir string, fn func(pageURL string, doc *goquery.Document)) error {
+	metaFiles, err := scanMetaFiles(outputDir)
+	if err != nil {
+		return fmt.Errorf("failed to scan meta files: %v", err)
+	}
+
+	for _, metaPath := range metaFiles {
+		data, err := os.ReadFile(metaPath)
+		if err != nil {
+			continue
+		}
+		var meta metaFileData
+		if err := json.Unmarshal(data, &meta); err != nil || meta.URL == "" {
+			continue
+		}
+		htmlPath := strings.TrimSuffix(metaPath, ".meta.json") + ".html" + compressionExt(meta.Compression)
+		body, err := ReadOutputFile(htmlPath)
+		if err != nil {
+			continue
+		}
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		fn(meta.URL, doc)
+	}
+	return nil
+}
+
+// headingStructure counts a page's h1 elements and describes every place
+// where the heading level goes up by more than one (e.g. "h2 followed by
+// h4"). Levels may go down by any amount.
+func headingStructure(doc *goquery.Document) (int, []string) {
+	h1Count := 0
+	prev := 0
+	var skips []string
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+		level := int(goquery.NodeName(s)[1] - '0')
+		if level == 1 {
+			h1Count++
+		}
+		if prev > 0 && level > prev+1 {
+			skips = append(skips, fmt.Sprintf("h%d followed by h%d", prev, level))
+		}
+		prev = level
+	})
+	return h1Count, skips
+}
+
+// seoDuplicates reports every page whose value (title or description) is
+// shared with at least one other page
+func seoDuplicates(pages []*seoPageData, issueType string, value func(*seoPageData) string) []SEOIssue {
+	groups := make(map[string][]string)
+	for _, p := range pages {
+		if v := value(p); v != "" {
+			groups[v] = append(groups[v], p.URL)
+		}
+	}
+
+	var issues []SEOIssue
+	for v, urls := range groups {
+		if len(urls) < 2 {
+			continue
+		}
+		for _, u := range urls {
+			related := make([]string, 0, len(urls)-1)
+			for _, other := range urls {
+				if other != u {
+					related = append(related, other)
+				}
+			}
+			issues = append(issues, SEOIssue{Type: issueType, URL: u, Detail: v, Related: related})
+		}
+	}
+	return issues
+}
+
+// seoBrokenLinks reports internal links whose target failed with an HTTP
+// error or could not be fetched at all, according to the URL inventory
+func seoBrokenLinks(pages []*seoPageData, records []URLRecord) []SEOIssue {
+	broken := make(map[string]URLRecord)
+	for _, r := range records {
+		if r.Status == URLStatusError && (r.HTTPStatus == 0 || r.HTTPStatus >= 400) {
+			broken[r.URL] = r
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+
+	var issues []SEOIssue
+	for _, p := range pages {
+		for _, link := range p.links {
+			r, ok := broken[link]
+			if !ok {
+				r, ok = broken[NormalizeURL(link)]
+			}
+			if !ok {
+				continue
+			}
+			detail := r.Reason
+			if r.HTTPStatus != 0 {
+				detail = "HTTP " + strconv.Itoa(r.HTTPStatus)
+			}
+			issues = append(issues, SEOIssue{Type: SEOIssueBrokenLink, URL: p.URL, Target: link, Detail: detail})
+		}
+	}
+	return issues
+}
+
+// sortSEOIssues orders issues by severity, then page and target
+func sortSEOIssues(issues []SEOIssue) {
+	rank := make(map[string]int, len(SEOIssueTypes))
+	for i, t := range SEOIssueTypes {
+		rank[t] = i
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Type != b.Type {
+			return rank[a.Type] < rank[b.Type]
+		}
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.Target < b.Target
+	})
+}
+
+// collapseSpace trims s and collapses internal whitespace runs
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// seoReportSection groups the issues of one type for the HTML report
+type seoReportSection struct {
+	Type   string
+	Label  string
+	Issues []SEOIssue
+}
+
+// renderSEOReport renders the report as a standalone HTML page
+func renderSEOReport(report *SEOReport, title string) ([]byte, error) {
+	tmpl, err := template.New("seo").Parse(seoReportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	byType := make(map[string][]SEOIssue)
+	for _, issue := range report.Issues {
+		byType[issue.Type] = append(byType[issue.Type], issue)
+	}
+	var sections []seoReportSection
+	for _, t := range SEOIssueTypes {
+		sections = append(sections, seoReportSection{Type: t, Label: seoIssueLabels[t], Issues: byType[t]})
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Title    string
+		Report   *SEOReport
+		Sections []seoReportSection
+	}{title, report, sections})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render SEO report: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+const seoReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>SEO Report - {{.Title}}</title>
+    <style>
+        :root { --bg: #ffffff; --bg-alt: #f8f9fa; --text: #212529; --muted: #6c757d; --border: #dee2e6; --accent: #0d6efd; --bad: #dc3545; --good: #198754; }
+        @media (prefers-color-scheme: dark) {
+            :root { --bg: #1a1a2e; --bg-alt: #16213e; --text: #f8f9fa; --muted: #9ca3af; --border: #374151; --accent: #60a5fa; --bad: #f87171; --good: #34d399; }
+        }
+        body { margin: 0 auto; max-width: 70rem; padding: 2rem; background: var(--bg); color: var(--text); font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; line-height: 1.5; }
+        .muted { color: var(--muted); font-size: 0.9rem; }
+        .summary { display: grid; grid-template-columns: repeat(auto-fill, minmax(12rem, 1fr)); gap: 0.75rem; margin: 1.5rem 0; }
+        .summary a { display: block; padding: 0.75rem; border: 1px solid var(--border); border-radius: 6px; background: var(--bg-alt); color: var(--text); text-decoration: none; }
+        .summary strong { display: block; font-size: 1.5rem; }
+        .summary .bad strong { color: var(--bad); }
+        .summary .good strong { color: var(--good); }
+        table { width: 100%; border-collapse: collapse; margin-bottom: 2rem; font-size: 0.9rem; }
+        th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid var(--border); vertical-align: top; word-break: break-all; }
+        th { background: var(--bg-alt); }
+        a { color: var(--accent); }
+    </style>
+</head>
+<body>
+    <h1>SEO Report: {{.Title}}</h1>
+    <p class="muted">{{.Report.Pages}} pages audited &middot; {{len .Report.Issues}} issues &middot; generated {{.Report.GeneratedAt.Format "Jan 2, 2006 15:04"}}{{if not .Report.BrokenLinkCheck}} &middot; broken links not checked (no URL inventory){{end}}</p>
+
+    <div class="summary">
+        {{- range .Sections}}
+        <a href="#{{.Type}}" class="{{if .Issues}}bad{{else}}good{{end}}"><strong>{{len .Issues}}</strong>{{.Label}}</a>
+        {{- end}}
+    </div>
+
+    {{- range .Sections}}
+    {{- if .Issues}}
+    <h2 id="{{.Type}}">{{.Label}} ({{len .Issues}})</h2>
+    <table>
+        <tr><th>Page</th><th>Details</th></tr>
+        {{- range .Issues}}
+        <tr>
+            <td><a href="{{.URL}}">{{.URL}}</a></td>
+            <td>
+                {{- if .Target}}<a href="{{.Target}}">{{.Target}}</a> {{end}}{{.Detail}}
+                {{- if .Related}}<br><span class="muted">Also on: {{range $i, $u := .Related}}{{if $i}}, {{end}}{{$u}}{{end}}</span>{{end}}
+            </td>
+        </tr>
+        {{- end}}
+    </table>
+    {{- end}}
+    {{- end}}
+</body>
+</html>
+`
@@ -0,0 +1,140 @@
+package crawler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// writeSEOFixture writes a saved page (raw HTML plus meta file) to dir
+func writeSEOFixture(t *testing.T, dir, name, pageURL, html string) {
+	t.Helper()
+	meta := map[string]interface{}{"url": pageURL, "timestamp": 1, "size": len(html)}
+	data, _ := json.Marshal(meta)
+	if err := os.WriteFile(filepath.Join(dir, name+".meta.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".html"), []byte(html), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuditSEOPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		wantIssues []string
+		wantLinks  int
+	}{
+		{
+			name: "clean page",
+			html: `<html><head><title> Home </title><meta name="Description" content="About us">
+				<link rel="canonical" href="https://example.com/"></head>
+				<body><h1>Home</h1><h2>A</h2><h3>B</h3><h2>C</h2>
+				<a href="/a#top">a</a><a href="/a">again</a><a href="https://other.com/">x</a><a href="mailto:x@example.com">m</a></body></html>`,
+			wantLinks: 1,
+		},
+		{
+			name:       "missing everything",
+			html:       `<html><body><h2>Sub</h2><h4>Deep</h4></body></html>`,
+			wantIssues: []string{SEOIssueMissingTitle, SEOIssueMissingDescription, SEOIssueMissingCanonical, SEOIssueMissingH1, SEOIssueSkippedHeading},
+		},
+		{
+			name: "multiple h1",
+			html: `<html><head><title>T</title><meta name="description" content="D">
+				<link rel="alternate canonical" href="/"></head><body><h1>A</h1><h1>B</h1></body></html>`,
+			wantIssues: []string{SEOIssueMultipleH1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, issues, err := auditSEOPage("https://example.com/", doc)
+			if err != nil {
+				t.Fatalf("auditSEOPage() error = %v", err)
+			}
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.Type)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantIssues, ",") {
+				t.Errorf("issues = %v, want %v", got, tt.wantIssues)
+			}
+			if page.InternalLinks != tt.wantLinks {
+				t.Errorf("internal links = %d, want %d", page.InternalLinks, tt.wantLinks)
+			}
+		})
+	}
+}
+
+func TestGenerateSEOReport(t *testing.T) {
+	dir := t.TempDir()
+	head := `<head><title>Same</title><meta name="description" content="Docs"><link rel="canonical" href="/"></head>`
+	writeSEOFixture(t, dir, "index", "https://example.com/",
+		`<html>`+head+`<body><h1>Home</h1><a href="/gone">gone</a><a href="/guide">guide</a></body></html>`)
+	writeSEOFixture(t, dir, "guide", "https://example.com/guide",
+		`<html>`+head+`<body><h1>Guide</h1></body></html>`)
+
+	f, err := os.Create(filepath.Join(dir, URLInventoryJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	WriteURLInventoryJSONL(f, []URLRecord{
+		{URL: "https://example.com/", Status: URLStatusSaved, HTTPStatus: 200},
+		{URL: "https://example.com/guide", Status: URLStatusSaved, HTTPStatus: 200},
+		{URL: "https://example.com/gone", Status: URLStatusError, HTTPStatus: 404, Reason: "HTTP 404"},
+	})
+	f.Close()
+
+	report, err := GenerateSEOReport(dir)
+	if err != nil {
+		t.Fatalf("GenerateSEOReport() error = %v", err)
+	}
+
+	if report.Pages != 2 || !report.BrokenLinkCheck {
+		t.Errorf("pages = %d, broken link check = %v", report.Pages, report.BrokenLinkCheck)
+	}
+	want := map[string]int{SEOIssueDuplicateTitle: 2, SEOIssueDuplicateDescription: 2, SEOIssueBrokenLink: 1}
+	for issueType, n := range want {
+		if report.IssueCounts[issueType] != n {
+			t.Errorf("%s count = %d, want %d", issueType, report.IssueCounts[issueType], n)
+		}
+	}
+	if first := report.Issues[0]; first.Type != SEOIssueBrokenLink || first.Target != "https://example.com/gone" || first.Detail != "HTTP 404" {
+		t.Errorf("first issue = %+v, want the broken link", first)
+	}
+
+	html, err := os.ReadFile(filepath.Join(dir, SEOReportHTML))
+	if err != nil {
+		t.Fatalf("HTML report not written: %v", err)
+	}
+	if !strings.Contains(string(html), "Broken internal link (1)") {
+		t.Error("HTML report missing broken link section")
+	}
+
+	var loaded SEOReport
+	data, err := os.ReadFile(filepath.Join(dir, SEOReportJSON))
+	if err != nil {
+		t.Fatalf("JSON report not written: %v", err)
+	}
+	if err := json.Unmarshal(data, &loaded); err != nil || len(loaded.Issues) != len(report.Issues) {
+		t.Errorf("JSON report does not match: %v", err)
+	}
+}
+
+func TestAuditSEONoPages(t *testing.T) {
+	if _, err := AuditSEO(t.TempDir()); err == nil {
+		t.Error("AuditSEO() on an empty directory should fail")
+	}
+	if _, err := AuditSEO(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("AuditSEO() on a missing directory should fail")
+	}
+}
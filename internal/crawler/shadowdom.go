@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Shadow DOM capture modes for Config.ShadowDOM
+const (
+	ShadowDOMOff         = "off"         // Save the light DOM only, as browsers serialize it (default)
+	ShadowDOMDeclarative = "declarative" // Save open shadow roots as <template shadowrootmode> inside their hosts
+	ShadowDOMFlatten     = "flatten"     // Inline open shadow roots into their hosts, slots replaced by what is assigned to them
+)
+
+// ShadowDOMModes returns the valid Config.ShadowDOM values
+func ShadowDOMModes() []string {
+	return []string{ShadowDOMOff, ShadowDOMDeclarative, ShadowDOMFlatten}
+}
+
+// validateShadowDOM checks the shadow DOM capture mode
+func validateShadowDOM(config *Config) error {
+	switch config.ShadowDOM {
+	case "", ShadowDOMOff:
+		return nil
+	case ShadowDOMDeclarative, ShadowDOMFlatten:
+	default:
+		return fmt.Errorf("shadow DOM mode must be one of: %s, got: %s", strings.Join(ShadowDOMModes(), ", "), config.ShadowDOM)
+	}
+	if config.FetchMode != FetchModeBrowser {
+		return fmt.Errorf("shadow DOM capture requires browser fetch mode")
+	}
+	return nil
+}
+
+// shadowDOMScript serializes the document with its open shadow roots, in
+// the mode given as a string. It works on copies, so the page stays usable
+// for pagination clicks, and returns null when the mode cannot be applied.
+const shadowDOMScript = `((mode) => {
+  const roots = [];
+  const collect = (node) => {
+    for (const el of node.querySelectorAll('*')) {
+      if (el.shadowRoot) {
+        roots.push(el.shadowRoot);
+        collect(el.shadowRoot);
+      }
+    }
+  };
+  collect(document);
+  if (roots.length === 0) return null;
+
+  const html = document.documentElement;
+  const open = () => {
+    const attrs = Array.from(html.attributes, a =>
+      ' ' + a.name + '="' + a.value.replace(/&/g, '&amp;').replace(/"/g, '&quot;') + '"');
+    return '<html' + attrs.join('') + '>';
+  };
+
+  if (mode === 'declarative') {
+    if (typeof html.getHTML !== 'function') return null;
+    return open() + html.getHTML({ shadowRoots: roots }) + '</html>';
+  }
+
+  // Flatten: copy the tree, putting each host's shadow content in place of
+  // its children and each slot's assigned nodes in place of the slot.
+  // Shadow styles are dropped, they would apply to the whole page.
+  const copy = (node) => {
+    if (node.nodeType !== Node.ELEMENT_NODE) return node.cloneNode(false);
+    if (node.localName === 'template') return node.cloneNode(true);
+    const out = node.cloneNode(false);
+    let children = node.childNodes;
+    if (node.shadowRoot) {
+      children = Array.from(node.shadowRoot.childNodes)
+        .filter(c => c.localName !== 'style' && !(c.localName === 'link' && c.rel === 'stylesheet'));
+    }
+    for (const child of children) {
+      if (child.localName === 'slot' && child.getRootNode() instanceof ShadowRoot) {
+        const assigned = child.assignedNodes({ flatten: true });
+        for (const n of (assigned.length ? assigned : child.childNodes)) out.appendChild(copy(n));
+        continue;
+      }
+      out.appendChild(copy(child));
+    }
+    return out;
+  };
+  return copy(html).outerHTML;
+})(%q)`
+
+// SetShadowDOM sets how the fetcher saves the shadow roots of web
+// components: ShadowDOMOff ("" too) saves the light DOM only, as before,
+// ShadowDOMDeclarative and ShadowDOMFlatten serialize open shadow roots
+// into the captured HTML. It must be called before the first fetch.
+func (f *BrowserFetcher) SetShadowDOM(mode string) {
+	if mode == ShadowDOMOff {
+		mode = ""
+	}
+	f.shadowDOM = mode
+}
+
+// captureHTMLAction reads the HTML of the page into html, with its shadow
+// roots serialized per the shadow DOM mode. Pages without open shadow roots,
+// and browsers that cannot serialize them, are read as they are.
+func (f *BrowserFetcher) captureHTMLAction(html *string) chromedp.Action {
+	outer := chromedp.OuterHTML("html", html, chromedp.ByQuery)
+	if f.shadowDOM == "" {
+		return outer
+	}
+	mode := f.shadowDOM
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		var serialized *string
+		if err := chromedp.Evaluate(fmt.Sprintf(shadowDOMScript, mode), &serialized).Do(ctx); err == nil && serialized != nil {
+			*html = *serialized
+			return nil
+		}
+		return outer.Do(ctx)
+	})
+}
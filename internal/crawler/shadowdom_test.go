@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestValidateShadowDOM(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"unset", Config{}, ""},
+		{"off in http mode", Config{FetchMode: FetchModeHTTP, ShadowDOM: ShadowDOMOff}, ""},
+		{"declarative", Config{FetchMode: FetchModeBrowser, ShadowDOM: ShadowDOMDeclarative}, ""},
+		{"flatten", Config{FetchMode: FetchModeBrowser, ShadowDOM: ShadowDOMFlatten}, ""},
+		{"http mode", Config{FetchMode: FetchModeHTTP, ShadowDOM: ShadowDOMFlatten}, "requires browser"},
+		{"unknown mode", Config{FetchMode: FetchModeBrowser, ShadowDOM: "inline"}, "must be one of"},
+	}
+	for _, tt := range tests {
+		err := validateShadowDOM(&tt.config)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetShadowDOM(t *testing.T) {
+	f := &BrowserFetcher{}
+	f.SetShadowDOM(ShadowDOMOff)
+	if f.shadowDOM != "" {
+		t.Errorf("off should capture the light DOM only, got %q", f.shadowDOM)
+	}
+	f.SetShadowDOM(ShadowDOMFlatten)
+	if f.shadowDOM != ShadowDOMFlatten {
+		t.Errorf("shadowDOM = %q, want %q", f.shadowDOM, ShadowDOMFlatten)
+	}
+
+	script := fmt.Sprintf(shadowDOMScript, ShadowDOMDeclarative)
+	if !strings.HasSuffix(script, `})("declarative")`) {
+		t.Errorf("script should be called with the mode, ends with %q", script[len(script)-30:])
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package crawler
+
+import "context"
+
+// HandleControlSignals does nothing on platforms without SIGUSR1/SIGUSR2;
+// use a control socket instead
+func HandleControlSignals(ctx context.Context, c *Crawler) {}
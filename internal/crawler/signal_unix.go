@@ -0,0 +1,42 @@
+//go:build unix
+
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleControlSignals lets a running crawl be inspected and paused from
+// outside until ctx is done: SIGUSR1 prints a status summary to stderr and
+// writes it as JSON to status.json in the output directory, SIGUSR2 toggles
+// pause/resume. The signals are handled from when it returns.
+func HandleControlSignals(ctx context.Context, c *Crawler) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case sig := <-sigChan:
+				switch sig {
+				case syscall.SIGUSR1:
+					c.WriteStatus(os.Stderr)
+					if path, err := c.WriteStatusFile(); err != nil {
+						fmt.Fprintf(os.Stderr, "Status not written: %v\n", err)
+					} else {
+						fmt.Fprintf(os.Stderr, "Status written to %s\n", path)
+					}
+				case syscall.SIGUSR2:
+					c.TogglePause()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleControlSignals(t *testing.T) {
+	c := newControlTestCrawler(t)
+	HandleControlSignals(t.Context(), c)
+
+	statusFile := filepath.Join(c.config.OutputDir, StatusFile)
+	deadline := time.Now().Add(2 * time.Second)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	for {
+		if _, err := os.Stat(statusFile); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("SIGUSR1 did not write the status file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	for !c.IsPaused() {
+		if time.Now().After(deadline) {
+			t.Fatal("SIGUSR2 did not pause the crawl")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
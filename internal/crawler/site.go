@@ -0,0 +1,399 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SiteOptions configures static site generation
+type SiteOptions struct {
+	Title       string // defaults to the output directory name
+	SiteDir     string // defaults to _site inside the output directory
+	EmbedImages bool   // download images into the site instead of hot-linking
+
+	// fetchImage is used to download images; overridable for tests
+	fetchImage func(imageURL string) ([]byte, string, error)
+}
+
+// SiteResult describes a generated static site
+type SiteResult struct {
+	Path   string `json:"path"`
+	Pages  int    `json:"pages"`
+	Images int    `json:"images"`
+}
+
+// siteNavNode is a node in the URL-path navigation tree
+type siteNavNode struct {
+	Name     string
+	Title    string
+	Path     string // site-relative page path, empty for intermediate directories
+	Children []*siteNavNode
+}
+
+// siteSearchEntry is a single record in the client-side search index
+type siteSearchEntry struct {
+	Title   string `json:"t"`
+	URL     string `json:"u"`
+	Path    string `json:"p"`
+	Excerpt string `json:"x"`
+}
+
+var unsafeSiteSegment = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// GenerateSite turns a crawl's extracted content into a browsable static mirror
+func GenerateSite(outputDir string, opts SiteOptions) (*SiteResult, error) {
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("output directory does not exist: %s", outputDir)
+	}
+	if opts.Title == "" {
+		opts.Title = filepath.Base(outputDir)
+	}
+	if opts.SiteDir == "" {
+		opts.SiteDir = filepath.Join(outputDir, "_site")
+	}
+	if opts.fetchImage == nil {
+		opts.fetchImage = fetchExportImage
+	}
+
+	chapters, err := loadBookChapters(outputDir, ExportOrderPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, fmt.Errorf("no pages with extracted content found in %s", outputDir)
+	}
+
+	paths := assignSitePaths(chapters)
+
+	rewriter := chapterRewriter{
+		embedImages: opts.EmbedImages,
+		fetchImage:  opts.fetchImage,
+		linkTo: func(from, target *bookChapter) string {
+			return relativeSitePath(paths[from], paths[target])
+		},
+		imageSrc: func(from *bookChapter, img *bookImage) string {
+			return relativeSitePath(paths[from], "_assets/"+img.Href)
+		},
+	}
+	images := rewriter.rewrite(chapters)
+
+	if err := os.MkdirAll(opts.SiteDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create site directory: %v", err)
+	}
+
+	nav := buildSiteNav(chapters, paths)
+	search := make([]siteSearchEntry, 0, len(chapters))
+	for _, ch := range chapters {
+		search = append(search, siteSearchEntry{
+			Title:   ch.Title,
+			URL:     ch.URL,
+			Path:    paths[ch],
+			Excerpt: extractTextExcerpt(ch.Body, 300),
+		})
+	}
+	if err := writeSiteSearchIndex(filepath.Join(opts.SiteDir, "search-index.js"), search); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("site").Parse(sitePageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %v", err)
+	}
+
+	for _, ch := range chapters {
+		if err := writeSitePage(tmpl, opts.SiteDir, paths[ch], sitePageData{
+			SiteTitle: opts.Title,
+			Title:     ch.Title,
+			SourceURL: ch.URL,
+			Language:  ch.Language,
+			Body:      template.HTML(ch.Body),
+		}, nav); err != nil {
+			return nil, err
+		}
+	}
+
+	home := sitePageData{
+		SiteTitle:   opts.Title,
+		Title:       opts.Title,
+		Language:    bookLanguage(chapters),
+		IsHome:      true,
+		PageCount:   len(chapters),
+		GeneratedAt: time.Now(),
+	}
+	if err := writeSitePage(tmpl, opts.SiteDir, "index.html", home, nav); err != nil {
+		return nil, err
+	}
+
+	for _, img := range images {
+		imgPath := filepath.Join(opts.SiteDir, "_assets", filepath.FromSlash(img.Href))
+		if err := os.MkdirAll(filepath.Dir(imgPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create asset directory: %v", err)
+		}
+		if err := os.WriteFile(imgPath, img.Data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write image: %v", err)
+		}
+	}
+
+	return &SiteResult{
+		Path:   opts.SiteDir,
+		Pages:  len(chapters),
+		Images: len(images),
+	}, nil
+}
+
+// assignSitePaths maps each chapter to a unique directory-style page path
+func assignSitePaths(chapters []*bookChapter) map[*bookChapter]string {
+	paths := make(map[*bookChapter]string, len(chapters))
+	used := make(map[string]bool, len(chapters))
+
+	for _, ch := range chapters {
+		segments := urlPathSegments(ch.URL)
+		for i, seg := range segments {
+			seg = unsafeSiteSegment.ReplaceAllString(seg, "_")
+			if seg == "" || seg == "." || seg == ".." || strings.HasPrefix(seg, "_") {
+				seg = "p" + seg
+			}
+			segments[i] = seg
+		}
+
+		base := path.Join(segments...)
+		p := base + "/index.html"
+		for n := 2; used[p]; n++ {
+			p = fmt.Sprintf("%s-%d/index.html", base, n)
+		}
+		used[p] = true
+		paths[ch] = p
+	}
+	return paths
+}
+
+// relativeSitePath returns the link from one site page to another
+func relativeSitePath(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// buildSiteNav derives a navigation tree from the chapters' URL paths
+func buildSiteNav(chapters []*bookChapter, paths map[*bookChapter]string) *siteNavNode {
+	root := &siteNavNode{}
+	for _, ch := range chapters {
+		node := root
+		for _, seg := range urlPathSegments(ch.URL) {
+			var child *siteNavNode
+			for _, c := range node.Children {
+				if c.Name == seg {
+					child = c
+					break
+				}
+			}
+			if child == nil {
+				child = &siteNavNode{Name: seg, Title: seg}
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+		if node.Path == "" {
+			node.Path = paths[ch]
+			node.Title = ch.Title
+		}
+	}
+
+	var sortNode func(n *siteNavNode)
+	sortNode = func(n *siteNavNode) {
+		sort.SliceStable(n.Children, func(i, j int) bool {
+			return n.Children[i].Name < n.Children[j].Name
+		})
+		for _, c := range n.Children {
+			sortNode(c)
+		}
+	}
+	sortNode(root)
+
+	// Skip the host level when the whole crawl is a single site
+	if len(root.Children) == 1 {
+		return root.Children[0]
+	}
+	return root
+}
+
+// renderSiteNav renders the navigation tree with links relative to the current page
+func renderSiteNav(node *siteNavNode, current string) template.HTML {
+	var b strings.Builder
+	var walk func(n *siteNavNode) bool
+	walk = func(n *siteNavNode) bool {
+		b.WriteString("<ul>")
+		containsCurrent := false
+		for _, c := range n.Children {
+			b.WriteString("<li>")
+			label := html.EscapeString(c.Title)
+			if c.Path != "" {
+				class := ""
+				if c.Path == current {
+					class = ` class="current"`
+					containsCurrent = true
+				}
+				fmt.Fprintf(&b, `<a href="%s"%s>%s</a>`, html.EscapeString(relativeSitePath(current, c.Path)), class, label)
+			} else {
+				fmt.Fprintf(&b, `<span>%s</span>`, label)
+			}
+			if len(c.Children) > 0 {
+				if walk(c) {
+					containsCurrent = true
+				}
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+		return containsCurrent
+	}
+	if node.Path != "" {
+		fmt.Fprintf(&b, `<a class="nav-root" href="%s">%s</a>`, html.EscapeString(relativeSitePath(current, node.Path)), html.EscapeString(node.Title))
+	}
+	walk(node)
+	return template.HTML(b.String())
+}
+
+// sitePageData holds the data for a single rendered site page
+type sitePageData struct {
+	SiteTitle   string
+	Title       string
+	SourceURL   string
+	Language    string
+	Body        template.HTML
+	IsHome      bool
+	PageCount   int
+	GeneratedAt time.Time
+
+	Root string // relative path back to the site root
+	Nav  template.HTML
+}
+
+// writeSitePage renders one page of the site
+func writeSitePage(tmpl *template.Template, siteDir, pagePath string, data sitePageData, nav *siteNavNode) error {
+	if data.Language == "" {
+		data.Language = "en"
+	}
+	data.Root = strings.Repeat("../", strings.Count(pagePath, "/"))
+	data.Nav = renderSiteNav(nav, pagePath)
+
+	fullPath := filepath.Join(siteDir, filepath.FromSlash(pagePath))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create page directory: %v", err)
+	}
+
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to create page: %v", err)
+	}
+	defer f.Close()
+
+	return tmpl.Execute(f, data)
+}
+
+// writeSiteSearchIndex writes the search index as a script so it also works from file:// URLs
+func writeSiteSearchIndex(path string, entries []siteSearchEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search index: %v", err)
+	}
+	content := "window.SITE_SEARCH = " + string(data) + ";\n"
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+const sitePageTemplate = `<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{if .IsHome}}{{.SiteTitle}}{{else}}{{.Title}} - {{.SiteTitle}}{{end}}</title>
+    <style>
+        :root { --bg: #ffffff; --bg-side: #f8f9fa; --text: #212529; --muted: #6c757d; --border: #dee2e6; --accent: #0d6efd; }
+        @media (prefers-color-scheme: dark) {
+            :root { --bg: #1a1a2e; --bg-side: #16213e; --text: #f8f9fa; --muted: #9ca3af; --border: #374151; --accent: #60a5fa; }
+        }
+        * { box-sizing: border-box; }
+        body { margin: 0; display: flex; min-height: 100vh; background: var(--bg); color: var(--text); font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; }
+        aside { width: 18rem; flex-shrink: 0; padding: 1rem; background: var(--bg-side); border-right: 1px solid var(--border); overflow-y: auto; max-height: 100vh; position: sticky; top: 0; font-size: 0.9rem; }
+        aside .site-title { display: block; font-weight: 700; font-size: 1.1rem; margin-bottom: 0.75rem; color: var(--text); text-decoration: none; }
+        aside ul { list-style: none; margin: 0; padding-left: 0.9rem; }
+        aside > ul { padding-left: 0; }
+        aside li { margin: 0.2rem 0; }
+        aside span { color: var(--muted); }
+        aside a { color: var(--accent); text-decoration: none; }
+        aside a.current { font-weight: 700; color: var(--text); }
+        #search { width: 100%; padding: 0.4rem 0.6rem; margin-bottom: 0.75rem; border: 1px solid var(--border); border-radius: 4px; background: var(--bg); color: var(--text); }
+        #results { display: none; }
+        #results a { display: block; margin-bottom: 0.5rem; }
+        #results small { display: block; color: var(--muted); }
+        main { flex: 1; max-width: 50rem; padding: 2rem; line-height: 1.6; }
+        main img { max-width: 100%; height: auto; }
+        main pre { overflow-x: auto; background: var(--bg-side); padding: 1rem; border-radius: 4px; }
+        .source { font-size: 0.85rem; color: var(--muted); word-break: break-all; }
+        @media (max-width: 48rem) { body { flex-direction: column; } aside { width: auto; max-height: none; position: static; } }
+    </style>
+</head>
+<body>
+    <aside>
+        <a class="site-title" href="{{.Root}}index.html">{{.SiteTitle}}</a>
+        <input type="text" id="search" placeholder="Search pages..." autocomplete="off">
+        <div id="results"></div>
+        <nav id="nav">{{.Nav}}</nav>
+    </aside>
+    <main>
+        <h1>{{.Title}}</h1>
+        {{- if .IsHome}}
+        <p class="source">{{.PageCount}} pages &middot; generated {{.GeneratedAt.Format "Jan 2, 2006 15:04"}}</p>
+        <p>Use the navigation or search to browse the mirrored pages.</p>
+        {{- else}}
+        <p class="source">Source: <a href="{{.SourceURL}}">{{.SourceURL}}</a></p>
+        {{.Body}}
+        {{- end}}
+    </main>
+    <script src="{{.Root}}search-index.js"></script>
+    <script>
+        (function() {
+            var root = {{.Root}};
+            var input = document.getElementById('search');
+            var results = document.getElementById('results');
+            var nav = document.getElementById('nav');
+            input.addEventListener('input', function() {
+                var q = input.value.trim().toLowerCase();
+                results.innerHTML = '';
+                if (!q || !window.SITE_SEARCH) {
+                    results.style.display = 'none';
+                    nav.style.display = '';
+                    return;
+                }
+                var hits = window.SITE_SEARCH.filter(function(e) {
+                    return (e.t + ' ' + e.u + ' ' + e.x).toLowerCase().indexOf(q) !== -1;
+                }).slice(0, 50);
+                hits.forEach(function(e) {
+                    var a = document.createElement('a');
+                    a.href = root + e.p;
+                    a.textContent = e.t;
+                    var s = document.createElement('small');
+                    s.textContent = e.x.slice(0, 120);
+                    a.appendChild(s);
+                    results.appendChild(a);
+                });
+                if (!hits.length) results.textContent = 'No matches.';
+                results.style.display = 'block';
+                nav.style.display = 'none';
+            });
+        })();
+    </script>
+</body>
+</html>
+`
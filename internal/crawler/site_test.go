@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAssignSitePaths(t *testing.T) {
+	chapters := []*bookChapter{
+		{URL: "https://example.com/"},
+		{URL: "https://example.com/docs/getting started"},
+		{URL: "https://example.com/docs/guide?page=1"},
+		{URL: "https://example.com/docs/guide?page=2"},
+		{URL: "https://example.com/_private"},
+	}
+	paths := assignSitePaths(chapters)
+
+	expected := []string{
+		"example.com/index.html",
+		"example.com/docs/getting_started/index.html",
+		"example.com/docs/guide/index.html",
+		"example.com/docs/guide-2/index.html",
+		"example.com/p_private/index.html",
+	}
+	for i, ch := range chapters {
+		if paths[ch] != expected[i] {
+			t.Errorf("path for %s = %q, want %q", ch.URL, paths[ch], expected[i])
+		}
+	}
+}
+
+func TestRelativeSitePath(t *testing.T) {
+	tests := []struct {
+		from, to, expected string
+	}{
+		{"example.com/a/index.html", "example.com/b/index.html", "../b/index.html"},
+		{"example.com/index.html", "example.com/a/b/index.html", "a/b/index.html"},
+		{"index.html", "example.com/index.html", "example.com/index.html"},
+		{"example.com/a/b/index.html", "_assets/images/image-1.png", "../../../_assets/images/image-1.png"},
+	}
+
+	for _, tt := range tests {
+		if got := relativeSitePath(tt.from, tt.to); got != tt.expected {
+			t.Errorf("relativeSitePath(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.expected)
+		}
+	}
+}
+
+func TestGenerateSite(t *testing.T) {
+	dir := t.TempDir()
+	writeExportFixture(t, dir, "index", "https://example.com/", "Home",
+		`<div><p>Start at the <a href="/docs/guide">guide</a>.</p></div>`, 100)
+	writeExportFixture(t, dir, "guide", "https://example.com/docs/guide", "Guide",
+		`<div><p>Back <a href="https://example.com/">home</a>.</p><img src="/logo.png"></div>`, 200)
+
+	result, err := GenerateSite(dir, SiteOptions{
+		Title:       "Example",
+		EmbedImages: true,
+		fetchImage: func(string) ([]byte, string, error) {
+			return []byte("PNGDATA"), "image/png", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateSite() error = %v", err)
+	}
+	if result.Pages != 2 || result.Images != 1 {
+		t.Errorf("result = %+v, want 2 pages and 1 image", result)
+	}
+
+	siteDir := filepath.Join(dir, "_site")
+	for _, name := range []string{"index.html", "search-index.js", "example.com/index.html", "example.com/docs/guide/index.html", "_assets/images/image-1.png"} {
+		if _, err := os.Stat(filepath.Join(siteDir, name)); err != nil {
+			t.Errorf("site missing %s", name)
+		}
+	}
+
+	guide, _ := os.ReadFile(filepath.Join(siteDir, "example.com", "docs", "guide", "index.html"))
+	page := string(guide)
+	if !strings.Contains(page, `href="../../index.html">home</a>`) {
+		t.Error("internal link was not rewritten to a relative site path")
+	}
+	if !strings.Contains(page, `src="../../../_assets/images/image-1.png"`) {
+		t.Error("image was not rewritten to the local asset")
+	}
+	if !strings.Contains(page, `class="current"`) {
+		t.Error("navigation does not highlight the current page")
+	}
+	if !strings.Contains(page, `src="../../../search-index.js"`) {
+		t.Error("page does not load the search index")
+	}
+
+	search, _ := os.ReadFile(filepath.Join(siteDir, "search-index.js"))
+	if !strings.Contains(string(search), `"p":"example.com/docs/guide/index.html"`) {
+		t.Error("search index missing guide page")
+	}
+}
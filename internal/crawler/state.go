@@ -2,9 +2,25 @@ package crawler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
 )
 
+// StateSchemaVersion is the current state file format. Version 1 is the
+// format saved before state files were versioned, without schema_version,
+// which may also lack the queued set. Version 2 adds schema_version and
+// always has the queued set. Version 3 adds the segments of a queue spilled
+// to disk, which older versions would silently drop.
+const StateSchemaVersion = 3
+
+// ErrStateTooNew is returned for state files written by a newer version of
+// the scraper, whose format this one can't read without losing data
+var ErrStateTooNew = errors.New("state file was written by a newer version of the scraper")
+
 // URLInfo represents a URL with its discovery depth
 type URLInfo struct {
 	URL   string `json:"url"`
@@ -13,26 +29,44 @@ type URLInfo struct {
 
 // CrawlerState tracks the current state of the crawler for persistence and resumption
 type CrawlerState struct {
-	Visited   map[string]bool `json:"visited"`
-	Queue     []URLInfo       `json:"queue"`
-	BaseURL   string          `json:"base_url"`
-	Processed int             `json:"processed"`
-	URLDepths map[string]int  `json:"url_depths"`
-	Queued    map[string]bool `json:"queued"`
+	SchemaVersion int               `json:"schema_version"`
+	Visited       map[string]bool   `json:"visited"`
+	Queue         []URLInfo         `json:"queue"`
+	BaseURL       string            `json:"base_url"`
+	Processed     int               `json:"processed"`
+	URLDepths     map[string]int    `json:"url_depths"`
+	Queued        map[string]bool   `json:"queued"`
+	Aliases       map[string]string `json:"aliases,omitempty"` // Permanently redirected URL -> final URL
+	// Paginated URL -> content hashes of the pages captured so far, in page
+	// order, while its click-based pagination is unfinished
+	Pagination map[string][]string `json:"pagination,omitempty"`
+	// URLs pagination clicks navigated to, crawled as regular pages
+	Harvested map[string]bool `json:"harvested,omitempty"`
+	// Part of the queue spilled to disk beyond Config.FrontierLimit, in
+	// FrontierDir of the state file
+	Spilled []SpillSegment `json:"spilled,omitempty"`
 }
 
 // NewCrawlerState creates a new empty crawler state
 func NewCrawlerState(baseURL string) *CrawlerState {
 	return &CrawlerState{
-		Visited:   make(map[string]bool),
-		Queue:     []URLInfo{},
-		BaseURL:   baseURL,
-		URLDepths: make(map[string]int),
-		Queued:    make(map[string]bool),
+		SchemaVersion: StateSchemaVersion,
+		Visited:       make(map[string]bool),
+		Queue:         []URLInfo{},
+		BaseURL:       baseURL,
+		URLDepths:     make(map[string]int),
+		Queued:        make(map[string]bool),
+		Aliases:       make(map[string]string),
+		Pagination:    make(map[string][]string),
+		Harvested:     make(map[string]bool),
 	}
 }
 
-// LoadState loads crawler state from a file or returns a fresh state
+// LoadState loads crawler state from a file or returns a fresh state.
+// State files of older formats are migrated to the current one, keeping a
+// copy of the original next to it (see StateBackupPath) since the next save
+// overwrites it. State files of a newer format are refused with
+// ErrStateTooNew, after copying them the same way.
 func LoadState(stateFile string, baseURL string) (*CrawlerState, error) {
 	state := NewCrawlerState(baseURL)
 
@@ -45,22 +79,316 @@ func LoadState(stateFile string, baseURL string) (*CrawlerState, error) {
 		return nil, err
 	}
 
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	version := 1
+	if v, ok := raw["schema_version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, fmt.Errorf("invalid schema_version: %w", err)
+		}
+	}
+	if version > StateSchemaVersion {
+		backup, err := backupState(stateFile, data, version)
+		if err != nil {
+			return nil, fmt.Errorf("%w (schema %d, this version reads up to %d): %s is left as it is; %v", ErrStateTooNew, version, StateSchemaVersion, stateFile, err)
+		}
+		return nil, fmt.Errorf("%w (schema %d, this version reads up to %d): upgrade the scraper to resume it, or use another state file to start afresh; a copy is kept at %s", ErrStateTooNew, version, StateSchemaVersion, backup)
+	}
+	if version < StateSchemaVersion {
+		if _, err := backupState(stateFile, data, version); err != nil {
+			return nil, err
+		}
+		for v := version; v < StateSchemaVersion; v++ {
+			if err := stateMigrations[v](raw); err != nil {
+				return nil, fmt.Errorf("failed to migrate state file from schema %d: %w", v, err)
+			}
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := json.Unmarshal(data, state); err != nil {
 		return nil, err
 	}
+	state.SchemaVersion = StateSchemaVersion
+	if state.Queued == nil {
+		state.Queued = make(map[string]bool)
+	}
+	if state.Aliases == nil {
+		state.Aliases = make(map[string]string)
+	}
+	if state.Pagination == nil {
+		state.Pagination = make(map[string][]string)
+	}
+	if state.Harvested == nil {
+		state.Harvested = make(map[string]bool)
+	}
+	state.requeuePagination()
+
+	return state, nil
+}
+
+// How the start URL a state file was saved for relates to the start URL of
+// the crawl resuming it
+const (
+	StartURLSame    = "same"    // Equal, or equal but for a trailing slash or letter case of the host
+	StartURLVariant = "variant" // The same site under another scheme or with/without "www."
+	StartURLOther   = "other"   // Another site or section
+)
 
-	// Initialize Queued map from Queue if empty (backward compatibility with old state files)
-	if len(state.Queued) == 0 && len(state.Queue) > 0 {
-		for _, urlInfo := range state.Queue {
-			state.Queued[urlInfo.URL] = true
+// CompareStartURLs tells how the start URL a state was saved for relates to
+// the one a crawl resumes it with. States without a start URL are the same.
+func CompareStartURLs(saved, current string) string {
+	if saved == "" || saved == current {
+		return StartURLSame
+	}
+	s, err1 := url.Parse(saved)
+	c, err2 := url.Parse(current)
+	if err1 != nil || err2 != nil {
+		return StartURLOther
+	}
+	if strings.TrimSuffix(s.EscapedPath(), "/") != strings.TrimSuffix(c.EscapedPath(), "/") || s.RawQuery != c.RawQuery {
+		return StartURLOther
+	}
+	if s.Scheme == c.Scheme && startURLHost(s, false) == startURLHost(c, false) {
+		return StartURLSame
+	}
+	if startURLHost(s, true) == startURLHost(c, true) {
+		return StartURLVariant
+	}
+	return StartURLOther
+}
+
+// startURLHost returns the lowercased host of u without the default port of
+// its scheme, and without "www." when bare is set
+func startURLHost(u *url.URL, bare bool) string {
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	if bare {
+		host = strings.TrimPrefix(host, "www.")
+	}
+	return host
+}
+
+// MergeStartURL adopts current as the start URL of the state. URLs under
+// the scheme and host of the saved start URL are rewritten to those of
+// current, merging with any already recorded under the new ones: a URL
+// visited under either form stays visited, it keeps the smaller of its
+// depths, and queued URLs that turn out visited are dropped. Returns the
+// number of visited URLs rewritten.
+func (s *CrawlerState) MergeStartURL(current string) int {
+	saved, err1 := url.Parse(s.BaseURL)
+	target, err2 := url.Parse(current)
+	s.BaseURL = current
+	if err1 != nil || err2 != nil || saved.Host == "" {
+		return 0
+	}
+	from := saved.Scheme + "://" + saved.Host
+	to := target.Scheme + "://" + target.Host
+	if from == to {
+		return 0
+	}
+
+	rewrite := func(u string) string {
+		if u == from || strings.HasPrefix(u, from+"/") || strings.HasPrefix(u, from+"?") {
+			return to + strings.TrimPrefix(u, from)
 		}
+		return u
 	}
 
-	return state, nil
+	rewritten := 0
+	visited := make(map[string]bool, len(s.Visited))
+	for u, v := range s.Visited {
+		if merged := rewrite(u); merged != u {
+			rewritten++
+			u = merged
+		}
+		visited[u] = visited[u] || v
+	}
+	s.Visited = visited
+
+	depths := make(map[string]int, len(s.URLDepths))
+	for u, d := range s.URLDepths {
+		u = rewrite(u)
+		if old, ok := depths[u]; !ok || d < old {
+			depths[u] = d
+		}
+	}
+	s.URLDepths = depths
+
+	queue := make([]URLInfo, 0, len(s.Queue))
+	queued := make(map[string]bool, len(s.Queued))
+	for _, info := range s.Queue {
+		info.URL = rewrite(info.URL)
+		if s.Visited[info.URL] || queued[info.URL] {
+			continue
+		}
+		if d, ok := s.URLDepths[info.URL]; ok && d < info.Depth {
+			info.Depth = d
+		}
+		queue = append(queue, info)
+		queued[info.URL] = true
+	}
+	s.Queue = queue
+	for u := range s.Queued {
+		if u = rewrite(u); !s.Visited[u] {
+			queued[u] = true
+		}
+	}
+	s.Queued = queued
+
+	aliases := make(map[string]string, len(s.Aliases))
+	for from, final := range s.Aliases {
+		aliases[rewrite(from)] = rewrite(final)
+	}
+	s.Aliases = aliases
+
+	pagination := make(map[string][]string, len(s.Pagination))
+	for u, hashes := range s.Pagination {
+		pagination[rewrite(u)] = hashes
+	}
+	s.Pagination = pagination
+
+	harvested := make(map[string]bool, len(s.Harvested))
+	for u := range s.Harvested {
+		harvested[rewrite(u)] = true
+	}
+	s.Harvested = harvested
+
+	return rewritten
+}
+
+// reconcileStartURL deals with a state file saved for another start URL
+// than the crawl's. With StateMerge the state is merged into the new start
+// URL, which is queued unless it was visited; otherwise the state is used
+// as it is, with a warning of what that means.
+func (c *Crawler) reconcileStartURL() {
+	saved := c.state.BaseURL
+	match := CompareStartURLs(saved, c.config.URL)
+	if match == StartURLSame {
+		return
+	}
+
+	if !c.config.StateMerge {
+		switch {
+		case match == StartURLVariant:
+			c.log.Warn("State file %s was saved for %s, a variant of %s: pages visited under the old form are fetched again under the new one unless the state is merged", c.config.StateFile, saved, c.config.URL)
+		case len(c.state.Queue) > 0:
+			c.log.Warn("State file %s was saved for a crawl of %s, not %s: its %d queued URLs are resumed and %s is not queued unless the state is merged", c.config.StateFile, saved, c.config.URL, len(c.state.Queue), c.config.URL)
+		default:
+			c.log.Warn("State file %s was saved for a crawl of %s, not %s: its %d visited URLs are skipped", c.config.StateFile, saved, c.config.URL, len(c.state.Visited))
+		}
+		return
+	}
+
+	rewritten := c.state.MergeStartURL(c.config.URL)
+	if match == StartURLVariant {
+		c.log.Info("Merged state saved for %s into %s (%d visited URLs rewritten)", saved, c.config.URL, rewritten)
+	} else {
+		c.log.Info("Merged state saved for %s into the crawl of %s (%d visited URLs kept)", saved, c.config.URL, len(c.state.Visited))
+	}
+
+	// An empty queue gets the start URL when the crawl starts
+	start := c.resolveAlias(c.normalizeURL(c.config.URL))
+	if len(c.state.Queue) > 0 && !c.state.Visited[start] && !c.isQueued(start) {
+		c.state.Queue = append([]URLInfo{{URL: start, Depth: 0}}, c.state.Queue...)
+		c.state.URLDepths[start] = 0
+		c.state.Queued[start] = true
+	}
+}
+
+// stateMigrations upgrade the raw fields of a state file from the version
+// of their key to the next one
+var stateMigrations = map[int]func(map[string]json.RawMessage) error{
+	1: migrateStateV1,
+	2: migrateStateV2,
+}
+
+// migrateStateV1 builds the queued set of version 1 state files saved
+// before it existed from their queue
+func migrateStateV1(raw map[string]json.RawMessage) error {
+	var queued map[string]bool
+	if v, ok := raw["queued"]; ok {
+		if err := json.Unmarshal(v, &queued); err != nil {
+			return err
+		}
+	}
+	if len(queued) == 0 {
+		var queue []URLInfo
+		if v, ok := raw["queue"]; ok {
+			if err := json.Unmarshal(v, &queue); err != nil {
+				return err
+			}
+		}
+		queued = make(map[string]bool, len(queue))
+		for _, info := range queue {
+			queued[info.URL] = true
+		}
+	}
+	data, err := json.Marshal(queued)
+	if err != nil {
+		return err
+	}
+	raw["queued"] = data
+	raw["schema_version"] = json.RawMessage("2")
+	return nil
+}
+
+// migrateStateV2 only bumps the version, since version 2 state files have
+// no spilled queue
+func migrateStateV2(raw map[string]json.RawMessage) error {
+	raw["schema_version"] = json.RawMessage("3")
+	return nil
+}
+
+// StateBackupPath is where the state file of the given schema version is
+// copied before it is migrated or refused
+func StateBackupPath(stateFile string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", stateFile, version)
+}
+
+// backupState copies the state file data of the given schema version to
+// StateBackupPath, unless an earlier load already did
+func backupState(stateFile string, data []byte, version int) (string, error) {
+	backup := StateBackupPath(stateFile, version)
+	if _, err := os.Stat(backup); err == nil {
+		return backup, nil
+	}
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up state file: %w", err)
+	}
+	return backup, nil
 }
 
-// SaveState persists the current crawler state to a file
+// requeuePagination puts the URLs whose pagination was interrupted back at
+// the front of the queue, so it resumes after the pages already captured
+func (s *CrawlerState) requeuePagination() {
+	urls := make([]string, 0, len(s.Pagination))
+	for u := range s.Pagination {
+		if !s.Queued[u] {
+			urls = append(urls, u)
+		}
+	}
+	sort.Strings(urls)
+
+	requeued := make([]URLInfo, 0, len(urls))
+	for _, u := range urls {
+		delete(s.Visited, u)
+		s.Queued[u] = true
+		requeued = append(requeued, URLInfo{URL: u, Depth: s.URLDepths[u]})
+	}
+	s.Queue = append(requeued, s.Queue...)
+}
+
+// SaveState persists the current crawler state to a file in the current
+// schema version
 func SaveState(state *CrawlerState, stateFile string) error {
+	state.SchemaVersion = StateSchemaVersion
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return err
@@ -68,3 +396,21 @@ func SaveState(state *CrawlerState, stateFile string) error {
 
 	return os.WriteFile(stateFile, data, 0644)
 }
+
+// saveState persists the crawler state with the configured output permissions
+func (c *Crawler) saveState() error {
+	if c.frontier != nil {
+		c.mu.Lock()
+		c.state.Spilled = c.frontier.Segments()
+		c.mu.Unlock()
+	}
+	if err := SaveState(c.state, c.config.StateFile); err != nil {
+		return err
+	}
+	if c.frontier != nil {
+		c.mu.Lock()
+		c.frontier.removeObsolete()
+		c.mu.Unlock()
+	}
+	return c.perms.applyFile(c.config.StateFile)
+}
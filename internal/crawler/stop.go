@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"regexp"
+	"time"
+)
+
+// Stop reasons, telling why a crawl ended. They are reported in the metrics,
+// the crawl completed event and the job status.
+const (
+	StopQueueEmpty        = "queue-empty"        // Every discovered URL was processed
+	StopMaxPages          = "max-pages"          // MaxPages pages were saved
+	StopMaxRuntime        = "max-runtime"        // The crawl ran for MaxRuntime
+	StopConsecutiveErrors = "consecutive-errors" // MaxConsecutiveErrors fetches failed in a row
+	StopTargetsFetched    = "targets-fetched"    // Every discovered URL matching StopPattern was fetched
+	StopPostSaveFailed    = "post-save-failed"   // The post-save command failed with PostSaveOnFailure "stop"
+	StopStopped           = "stopped"            // The crawl was stopped or cancelled
+)
+
+// compileStopPattern compiles the StopPattern of config, nil when it is empty
+func compileStopPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// stopCondition returns the reason to end the crawl before the queue is
+// exhausted, or "" to go on
+func (c *Crawler) stopCondition() string {
+	if c.pageLimitReached() {
+		return StopMaxPages
+	}
+	if !c.deadline.IsZero() && time.Now().After(c.deadline) {
+		c.log.Info("Run time limit of %s reached, stopping crawl", c.config.MaxRuntime)
+		return StopMaxRuntime
+	}
+	if n := c.config.MaxConsecutiveErrors; n > 0 && c.consecutiveErrors.Load() >= int64(n) {
+		c.log.Info("%d fetches failed in a row, stopping crawl", n)
+		return StopConsecutiveErrors
+	}
+	if c.postSave != nil && c.postSave.stopRequested() {
+		return StopPostSaveFailed
+	}
+	if c.stopPattern != nil && c.fetchedTargets.Load() > 0 && c.pendingTargets.Load() == 0 {
+		c.log.Info("Every URL matching %q found so far was fetched, stopping crawl", c.config.StopPattern)
+		return StopTargetsFetched
+	}
+	return ""
+}
+
+// isStopTarget reports whether rawURL matches StopPattern
+func (c *Crawler) isStopTarget(rawURL string) bool {
+	return c.stopPattern != nil && c.stopPattern.MatchString(rawURL)
+}
+
+// targetQueued counts rawURL as waiting to be fetched when it matches StopPattern
+func (c *Crawler) targetQueued(rawURL string) {
+	if c.isStopTarget(rawURL) {
+		c.pendingTargets.Add(1)
+	}
+}
+
+// targetDone takes rawURL off the URLs matching StopPattern still to be
+// fetched, counting it as fetched unless it was skipped
+func (c *Crawler) targetDone(rawURL string, fetched bool) {
+	if !c.isStopTarget(rawURL) {
+		return
+	}
+	c.pendingTargets.Add(-1)
+	if fetched {
+		c.fetchedTargets.Add(1)
+	}
+}
+
+// trackErrors counts the fetches failed in a row for MaxConsecutiveErrors.
+// Outcomes decided without a fetch leave the count as it is.
+func (c *Crawler) trackErrors(status string, result *FetchResult) {
+	switch {
+	case status == URLStatusError:
+		c.consecutiveErrors.Add(1)
+	case result != nil:
+		c.consecutiveErrors.Store(0)
+	}
+}
+
+// StopReason returns why the crawl ended, or "" while it runs
+func (c *Crawler) StopReason() string {
+	c.metrics.mu.Lock()
+	defer c.metrics.mu.Unlock()
+	return c.metrics.StopReason
+}
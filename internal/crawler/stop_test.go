@@ -0,0 +1,123 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stopTestServer serves a root page linking to /api/a, /api/b and
+// /other/1, which links on to /other/2. Pages under /bad/ fail with a 500
+// and the root also links to three of them when withErrors is set.
+func stopTestServer(withErrors bool) *httptest.Server {
+	text := strings.Repeat("Page text. ", 10)
+	links := map[string]string{
+		"/":        `<a href="/api/a">A</a><a href="/api/b">B</a><a href="/other/1">1</a>`,
+		"/other/1": `<a href="/other/2">2</a>`,
+	}
+	if withErrors {
+		links["/"] = `<a href="/bad/1">1</a><a href="/bad/2">2</a><a href="/bad/3">3</a>` + links["/"]
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/bad/") {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p>%s</body></html>`, text, links[r.URL.Path])
+	}))
+}
+
+func TestStopConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		withErrors bool
+		configure  func(*Config)
+		reason     string
+		saved      int64
+		errored    int64
+	}{
+		{"queue empty", false, func(*Config) {}, StopQueueEmpty, 5, 0},
+		{"max pages", false, func(c *Config) { c.MaxPages = 2 }, StopMaxPages, 2, 0},
+		{"consecutive errors", true, func(c *Config) { c.MaxConsecutiveErrors = 3 }, StopConsecutiveErrors, 1, 3},
+		{"errors below the limit", true, func(c *Config) { c.MaxConsecutiveErrors = 4 }, StopQueueEmpty, 5, 3},
+		{"targets fetched", false, func(c *Config) { c.StopPattern = `/api/` }, StopTargetsFetched, 3, 0},
+		{"targets fetched concurrently", false, func(c *Config) {
+			c.StopPattern = `/other/`
+			c.Concurrent = true
+			c.Workers = 1
+		}, StopTargetsFetched, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := stopTestServer(tt.withErrors)
+			defer server.Close()
+
+			outputDir := t.TempDir()
+			config := Config{
+				URL:              server.URL + "/",
+				MaxDepth:         5,
+				OutputDir:        outputDir,
+				StateFile:        filepath.Join(outputDir, "state.json"),
+				MinContentLength: 10,
+			}
+			tt.configure(&config)
+			emitter := &recordingEmitter{}
+			c, err := NewCrawlerWithEmitter(config, t.Context(), emitter)
+			if err != nil {
+				t.Fatalf("NewCrawlerWithEmitter() error = %v", err)
+			}
+			defer c.Close()
+			if err := c.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			if got := c.StopReason(); got != tt.reason {
+				t.Errorf("StopReason() = %q, want %q", got, tt.reason)
+			}
+			snapshot := c.GetMetrics().GetSnapshot()
+			if snapshot.StopReason != tt.reason || snapshot.URLsSaved != tt.saved || snapshot.URLsErrored != tt.errored {
+				t.Errorf("metrics: reason %q, saved %d, errored %d; want %q, %d, %d",
+					snapshot.StopReason, snapshot.URLsSaved, snapshot.URLsErrored, tt.reason, tt.saved, tt.errored)
+			}
+			var completed []string
+			for _, e := range emitter.events {
+				if e.Type == EventCrawlCompleted {
+					data, _ := e.Data.(CompletedData)
+					completed = append(completed, data.Reason)
+				}
+			}
+			if len(completed) != 1 || completed[0] != tt.reason {
+				t.Errorf("crawl completed events with reasons %q, want one with %q", completed, tt.reason)
+			}
+		})
+	}
+}
+
+func TestStopMaxRuntime(t *testing.T) {
+	site := NewTestSite(TestSiteOptions{Pages: 40, Latency: 20 * time.Millisecond})
+	defer site.Close()
+
+	config := selfTestConfig(site, t.TempDir())
+	config.MaxRuntime = 100 * time.Millisecond
+	start := time.Now()
+	c, err := runSelfTestCrawl(t.Context(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	if reason := c.StopReason(); reason != StopMaxRuntime {
+		t.Errorf("StopReason() = %q, want %q", reason, StopMaxRuntime)
+	}
+	if saved := c.GetMetrics().GetSnapshot().URLsSaved; saved == 0 || saved >= int64(site.Pages()) {
+		t.Errorf("saved %d of %d pages, want some but not all", saved, site.Pages())
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("crawl ran for %s after a 100ms limit", elapsed)
+	}
+}
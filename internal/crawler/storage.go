@@ -5,18 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/markusmobius/go-trafilatura"
 	"golang.org/x/net/html"
 )
 
-// extractContent uses trafilatura to extract the main article content and metadata from HTML
-func (c *Crawler) extractContent(rawURL string, htmlContent string) (string, *trafilatura.ExtractResult, error) {
+// extractContent uses trafilatura to extract the main article content and metadata
+// from an already parsed page. Trafilatura works on its own copy of the tree, so
+// the shared document is left untouched.
+func (c *Crawler) extractContent(rawURL string, page *PageDocument) (string, *trafilatura.ExtractResult, error) {
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to parse URL: %v", err)
@@ -27,7 +28,7 @@ func (c *Crawler) extractContent(rawURL string, htmlContent string) (string, *tr
 		EnableFallback: true,
 	}
 
-	result, err := trafilatura.Extract(strings.NewReader(htmlContent), opts)
+	result, err := trafilatura.ExtractDocument(page.Root(), opts)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to extract content: %v", err)
 	}
@@ -45,24 +46,21 @@ func (c *Crawler) extractContent(rawURL string, htmlContent string) (string, *tr
 	return buf.String(), result, nil
 }
 
+// exceedsMaxHTMLSize reports whether a page body is too large to parse
+func (c *Crawler) exceedsMaxHTMLSize(body []byte) bool {
+	return c.config.MaxHTMLSize > 0 && int64(len(body)) > c.config.MaxHTMLSize
+}
+
 // hasContent checks if an HTML page has meaningful text content
-func (c *Crawler) hasContent(html string) bool {
+func (c *Crawler) hasContent(page *PageDocument) bool {
 	defer func() {
 		if r := recover(); r != nil {
 			c.log.Error("Panic in hasContent: %v", r)
 		}
 	}()
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
-	if err != nil {
-		return false
-	}
-
-	// Remove script and style elements
-	doc.Find("script, style").Remove()
-
-	// Get text content
-	text := strings.TrimSpace(doc.Text())
+	// Visible text excludes script and style elements
+	text := page.Text()
 
 	// Use configured minimum content length, fall back to constant if not set
 	minLength := c.config.MinContentLength
@@ -74,8 +72,33 @@ func (c *Crawler) hasContent(html string) bool {
 	return len(text) > minLength
 }
 
-// saveContent saves HTML content and metadata to the output directory
-func (c *Crawler) saveContent(rawURL string, content []byte) error {
+// pageFilterReason returns why the length, word count or link density
+// filters keep a page out of the saved corpus, or "" when none applies
+func (c *Crawler) pageFilterReason(page *PageDocument) string {
+	if max := c.config.MaxContentLength; max > 0 && len(page.Text()) > max {
+		return fmt.Sprintf("longer than %d characters", max)
+	}
+	if max := c.config.MaxLinkDensity; max > 0 {
+		if density := page.LinkDensity(); density > max {
+			return fmt.Sprintf("link density %.2f above %.2f", density, max)
+		}
+	}
+	if min := c.config.MinWords; min > 0 && page.Words() < min {
+		return fmt.Sprintf("fewer than %d words", min)
+	}
+	if max := c.config.MaxWords; max > 0 && page.Words() > max {
+		return fmt.Sprintf("more than %d words", max)
+	}
+	return ""
+}
+
+// saveContent saves HTML content and metadata to the output directory.
+// rawURL determines the filename and may differ from page.URL for paginated pages.
+func (c *Crawler) saveContent(rawURL string, page *PageDocument) error {
+	span := c.tracer.stage(page.URL, SpanSave)
+	defer span.End()
+	content := page.Body
+
 	// Create filename based on URL structure
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
@@ -88,69 +111,199 @@ func (c *Crawler) saveContent(rawURL string, content []byte) error {
 	// Create subdirectories if needed
 	fullPath := filepath.Join(c.config.OutputDir, filename)
 	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := c.perms.mkdirAll(dir); err != nil {
 		return fmt.Errorf("failed to create directory %s: %v", dir, err)
 	}
 
 	// Create metadata file
+	savedAt := time.Now()
 	metadata := map[string]interface{}{
 		"url":       rawURL,
-		"timestamp": time.Now().Unix(),
+		"timestamp": savedAt.Unix(),
 		"size":      len(content),
 	}
+	compression := c.config.CompressOutput
+	ext := compressionExt(compression)
+	if ext != "" {
+		metadata["compression"] = compression
+	}
+	if page.UserAgent != "" {
+		metadata["user_agent"] = page.UserAgent
+	}
+	entry := PageEntry{
+		URL:       rawURL,
+		Filename:  filepath.FromSlash(filename + ext),
+		Timestamp: time.Unix(savedAt.Unix(), 0),
+		Size:      int64(len(content)),
+	}
 
 	// Save original HTML file
-	if err := os.WriteFile(fullPath, content, 0644); err != nil {
+	stored := c.withProvenance(page.URL, content, savedAt)
+	written, blob, err := c.storeOutputFile(fullPath, stored)
+	if err != nil {
+		return err
+	}
+	if err := c.perms.applyFile(written); err != nil {
 		return err
 	}
+	savedFile := written
+	if blob != "" {
+		metadata["blob"] = blob
+		entry.Blob = filepath.FromSlash(blob)
+		// The pointer file only names the blob
+		savedFile = filepath.Join(c.config.OutputDir, entry.Blob)
+	}
 
 	// Extract and save content if enabled
 	contentExtracted := false
+	extracted := ""
 	if !c.config.DisableContentExtraction {
-		extractedHTML, doc, err := c.extractContent(rawURL, string(content))
+		extractSpan := c.tracer.child(span, SpanExtract)
+		extractedHTML, doc, err := c.extractContent(rawURL, page)
+		extractSpan.End()
 		if err != nil {
 			c.log.Debug("Failed to extract content for %s: %v", rawURL, err)
 		} else if extractedHTML != "" {
 			// Save extracted content to .content.html file
 			contentFile := strings.TrimSuffix(fullPath, ".html") + ".content.html"
-			if err := os.WriteFile(contentFile, []byte(extractedHTML), 0644); err != nil {
+			if written, blob, err := c.storeOutputFile(contentFile, []byte(extractedHTML)); err != nil {
 				c.log.Debug("Failed to save extracted content for %s: %v", rawURL, err)
 			} else {
+				if err := c.perms.applyFile(written); err != nil {
+					c.log.Warn("Failed to set permissions of %s: %v", written, err)
+				}
 				contentExtracted = true
-				metadata["content_file"] = strings.TrimSuffix(filename, ".html") + ".content.html"
+				extracted = extractedHTML
+				metadata["content_file"] = strings.TrimSuffix(filename, ".html") + ".content.html" + ext
 				metadata["content_size"] = len(extractedHTML)
+				if blob != "" {
+					metadata["content_blob"] = blob
+					entry.ContentBlob = filepath.FromSlash(blob)
+				}
+				entry.ContentFile = metadata["content_file"].(string)
+				entry.ContentSize = int64(len(extractedHTML))
+				entry.Excerpt = extractTextExcerpt(extractedHTML, 300)
+				entry.HasContent = true
 			}
 
 			// Add trafilatura metadata when available
 			if doc != nil {
-				meta := doc.Metadata
-				if meta.Title != "" {
-					metadata["title"] = meta.Title
-				}
-				if meta.Author != "" {
-					metadata["author"] = meta.Author
-				}
-				if !meta.Date.IsZero() {
-					metadata["date"] = meta.Date.Format(time.RFC3339)
-				}
-				if meta.Language != "" {
-					metadata["language"] = meta.Language
-				}
-				if meta.Description != "" {
-					metadata["description"] = meta.Description
-				}
-				if meta.Sitename != "" {
-					metadata["sitename"] = meta.Sitename
-				}
+				setExtractedMetadata(metadata, doc)
+				entry.Title = doc.Metadata.Title
 			}
 		}
 	}
 	metadata["content_extracted"] = contentExtracted
+	if source, ok := c.archived.Load(rawURL); ok {
+		metadata["archived_source"] = source
+	}
+	if c.plugins != nil {
+		if fields := c.plugins.extract(page); fields != nil {
+			metadata["plugins"] = fields
+		}
+	}
 
 	metaData, _ := json.MarshalIndent(metadata, "", "  ")
 	metaFile := strings.TrimSuffix(fullPath, ".html") + ".meta.json"
 
-	return os.WriteFile(metaFile, metaData, 0644)
+	if err := c.perms.writeFile(metaFile, metaData); err != nil {
+		return err
+	}
+
+	c.addToIndex(entry)
+	saved := newManifestEntry(entry, stored)
+	c.recordManifest(saved)
+	if c.results != nil {
+		depth, _ := c.inventory.Depth(rawURL)
+		c.results.addPage(saved, depth)
+	}
+	// Computed once, and only when an event sink, embeddings or enrichment wants it
+	text := sync.OnceValue(func() string { return savedText(rawURL, page, extracted) })
+	if c.sinks != nil {
+		c.sinks.publishPage(PageSavedData{ManifestEntry: saved, JobID: c.config.JobID}, text)
+	}
+	if c.embeddings != nil {
+		c.embeddings.add(embeddingJob{
+			url:  rawURL,
+			hash: saved.Hash,
+			text: text(),
+			file: strings.TrimSuffix(fullPath, ".html") + EmbeddingFileExt,
+		})
+	}
+	if len(c.alertRules) > 0 {
+		c.checkAlerts(rawURL, text, content)
+	}
+	if c.enrichment != nil {
+		c.enrichment.add(EnrichmentRequest{
+			URL:      rawURL,
+			JobID:    c.config.JobID,
+			Metadata: metadata,
+			Text:     text(),
+			HTML:     extracted,
+		}, metaFile)
+	}
+	if c.postSave != nil {
+		c.postSave.add(postSaveJob{url: rawURL, file: savedFile, metaFile: metaFile, metadata: metaData})
+	}
+	// Snapshots of dead pages are already archived
+	if c.waybackSave != nil {
+		if _, archived := c.archived.Load(rawURL); !archived {
+			c.waybackSave.add(rawURL)
+		}
+	}
+	return nil
+}
+
+// extractedMetadataFields are the .meta.json fields filled from the
+// metadata trafilatura finds
+var extractedMetadataFields = []string{"title", "author", "date", "language", "description", "sitename"}
+
+// setExtractedMetadata adds the metadata trafilatura found on a page to its
+// .meta.json fields
+func setExtractedMetadata(metadata map[string]interface{}, doc *trafilatura.ExtractResult) {
+	meta := doc.Metadata
+	if meta.Title != "" {
+		metadata["title"] = meta.Title
+	}
+	if meta.Author != "" {
+		metadata["author"] = meta.Author
+	}
+	if !meta.Date.IsZero() {
+		metadata["date"] = meta.Date.Format(time.RFC3339)
+	}
+	if meta.Language != "" {
+		metadata["language"] = meta.Language
+	}
+	if meta.Description != "" {
+		metadata["description"] = meta.Description
+	}
+	if meta.Sitename != "" {
+		metadata["sitename"] = meta.Sitename
+	}
+}
+
+// savedText returns the plain text of a saved page: that of its extracted
+// content, or of the whole page when none was extracted
+func savedText(rawURL string, page *PageDocument, extracted string) string {
+	if extracted != "" {
+		if doc, err := NewPageDocument(rawURL, []byte(extracted)); err == nil {
+			return doc.Text()
+		}
+	}
+	return page.Text()
+}
+
+// addToIndex records a saved page and periodically rewrites _index.html
+func (c *Crawler) addToIndex(entry PageEntry) {
+	if c.index == nil {
+		return
+	}
+	written, err := c.index.Add(entry)
+	if err != nil {
+		c.log.Warn("Failed to update index: %v", err)
+	} else if written {
+		c.log.Debug("Updated index page (%d pages)", c.index.Len())
+	}
 }
 
 // generateFilename creates a filesystem-safe filename from a URL
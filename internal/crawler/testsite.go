@@ -0,0 +1,346 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestSiteStructure is how the pages of a TestSite link to each other
+type TestSiteStructure string
+
+const (
+	TestSiteChain TestSiteStructure = "chain" // Each page links to the next one
+	TestSiteTree  TestSiteStructure = "tree"  // Each page links to Fanout child pages
+	TestSiteMesh  TestSiteStructure = "mesh"  // Each page links to the next one and Fanout-1 pages across the site
+)
+
+// Default TestSite options
+const (
+	DefaultTestSitePages     = 20
+	DefaultTestSiteFanout    = 3
+	DefaultTestSiteSlowDelay = 200 * time.Millisecond
+)
+
+// TestSiteOptions shape a synthetic site
+type TestSiteOptions struct {
+	Pages      int               // Content pages including the root (default 20)
+	Structure  TestSiteStructure // Link structure (default tree)
+	Fanout     int               // Links per page in tree and mesh sites (default 3)
+	Redirects  int               // The first pages after the root are linked through a 301 from /go/<n>
+	SlowPages  int               // The last pages are served only after SlowDelay
+	SlowDelay  time.Duration     // Delay of slow pages (default 200ms)
+	Latency    time.Duration     // Added to every page response
+	Disallowed int               // Pages under /private/, linked from the root and disallowed by robots.txt
+	Faults     FaultConfig       // Failures the server injects into page responses, as FaultFetcher does on the client side
+}
+
+// TestSite is a synthetic website served by a local httptest server, used by
+// integration tests and `scraper selftest`. Page 0 is the root "/", page n is
+// "/page/<n>". Every request is counted.
+type TestSite struct {
+	URL string // Base URL without trailing slash
+
+	opts        TestSiteOptions
+	server      *httptest.Server
+	links       [][]int // Pages each page links to
+	depths      []int   // Link hops from the root to each page
+	hits        map[string]int
+	inFlight    int
+	maxInFlight int
+	mu          sync.Mutex
+}
+
+// NewTestSite starts serving a synthetic site
+func NewTestSite(opts TestSiteOptions) *TestSite {
+	if opts.Pages <= 0 {
+		opts.Pages = DefaultTestSitePages
+	}
+	if opts.Structure == "" {
+		opts.Structure = TestSiteTree
+	}
+	if opts.Fanout <= 0 {
+		opts.Fanout = DefaultTestSiteFanout
+	}
+	if opts.SlowDelay <= 0 {
+		opts.SlowDelay = DefaultTestSiteSlowDelay
+	}
+
+	s := &TestSite{
+		opts: opts,
+		hits: make(map[string]int),
+	}
+	s.links = make([][]int, opts.Pages)
+	for n := range s.links {
+		s.links[n] = testSiteLinks(opts, n)
+	}
+	s.depths = s.computeDepths()
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.server.URL
+	return s
+}
+
+// testSiteLinks returns the pages page n links to
+func testSiteLinks(opts TestSiteOptions, n int) []int {
+	var links []int
+	switch opts.Structure {
+	case TestSiteChain:
+		if n+1 < opts.Pages {
+			links = append(links, n+1)
+		}
+	case TestSiteMesh:
+		links = append(links, (n+1)%opts.Pages)
+		for j := 1; j < opts.Fanout; j++ {
+			links = append(links, (n*7+j*13)%opts.Pages)
+		}
+	default:
+		for j := 1; j <= opts.Fanout; j++ {
+			if child := n*opts.Fanout + j; child < opts.Pages {
+				links = append(links, child)
+			}
+		}
+	}
+	return links
+}
+
+// computeDepths finds the shortest link distance of every page from the root
+func (s *TestSite) computeDepths() []int {
+	depths := make([]int, len(s.links))
+	for i := range depths {
+		depths[i] = -1
+	}
+	depths[0] = 0
+	queue := []int{0}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range s.links[n] {
+			if depths[m] < 0 {
+				depths[m] = depths[n] + 1
+				queue = append(queue, m)
+			}
+		}
+	}
+	return depths
+}
+
+// Close stops the server
+func (s *TestSite) Close() {
+	s.server.Close()
+}
+
+// Pages returns the number of content pages
+func (s *TestSite) Pages() int {
+	return s.opts.Pages
+}
+
+// PagePath returns the path of page n
+func (s *TestSite) PagePath(n int) string {
+	if n == 0 {
+		return "/"
+	}
+	return fmt.Sprintf("/page/%d", n)
+}
+
+// PageURL returns the URL of page n
+func (s *TestSite) PageURL(n int) string {
+	return s.URL + s.PagePath(n)
+}
+
+// Depth returns the link depth of page n
+func (s *TestSite) Depth(n int) int {
+	return s.depths[n]
+}
+
+// PagesWithin returns the pages reachable from the root in at most maxDepth link hops
+func (s *TestSite) PagesWithin(maxDepth int) []int {
+	var pages []int
+	for n, d := range s.depths {
+		if d >= 0 && d <= maxDepth {
+			pages = append(pages, n)
+		}
+	}
+	return pages
+}
+
+// PrivatePath returns the path of the n-th page disallowed by robots.txt
+func (s *TestSite) PrivatePath(n int) string {
+	return fmt.Sprintf("/private/%d", n)
+}
+
+// RedirectPath returns the path that redirects to page n, or "" when page n is linked directly
+func (s *TestSite) RedirectPath(n int) string {
+	if n < 1 || n > s.opts.Redirects {
+		return ""
+	}
+	return fmt.Sprintf("/go/%d", n)
+}
+
+// LinkURL returns the URL other pages link to page n with: its redirect
+// path when it has one, otherwise its own URL
+func (s *TestSite) LinkURL(n int) string {
+	if p := s.RedirectPath(n); p != "" {
+		return s.URL + p
+	}
+	return s.PageURL(n)
+}
+
+// Hits returns how often path was requested
+func (s *TestSite) Hits(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+// Requests returns the number of page requests, robots.txt excluded
+func (s *TestSite) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for path, n := range s.hits {
+		if path != "/robots.txt" {
+			total += n
+		}
+	}
+	return total
+}
+
+// MaxInFlight returns the most page requests that were served at the same time
+func (s *TestSite) MaxInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxInFlight
+}
+
+func (s *TestSite) serve(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+
+	s.mu.Lock()
+	s.hits[path]++
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	if path == "/robots.txt" {
+		w.Header().Set("Content-Type", "text/plain")
+		if s.opts.Disallowed > 0 {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+		} else {
+			fmt.Fprint(w, "User-agent: *\nDisallow:\n")
+		}
+		return
+	}
+
+	if s.opts.Latency > 0 {
+		time.Sleep(s.opts.Latency)
+	}
+	if s.opts.Faults.Latency > 0 {
+		time.Sleep(s.opts.Faults.Latency)
+	}
+	fault, status := s.opts.Faults.Pick(path, s.Hits(path)-1)
+	switch fault {
+	case FaultReset:
+		resetConnection(w)
+		return
+	case FaultError:
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if rest, ok := strings.CutPrefix(path, "/go/"); ok {
+		if n, err := strconv.Atoi(rest); err == nil && s.RedirectPath(n) != "" {
+			http.Redirect(w, r, s.PagePath(n), http.StatusMovedPermanently)
+			return
+		}
+	}
+	if rest, ok := strings.CutPrefix(path, "/private/"); ok {
+		if n, err := strconv.Atoi(rest); err == nil && n >= 0 && n < s.opts.Disallowed {
+			writeTestPage(w, "Private page "+rest, nil, fault == FaultTruncate)
+			return
+		}
+	}
+
+	n := -1
+	if path == "/" {
+		n = 0
+	} else if rest, ok := strings.CutPrefix(path, "/page/"); ok {
+		if i, err := strconv.Atoi(rest); err == nil && i > 0 && i < s.opts.Pages {
+			n = i
+		}
+	}
+	if n < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if n >= s.opts.Pages-s.opts.SlowPages {
+		time.Sleep(s.opts.SlowDelay)
+	}
+
+	var hrefs []string
+	for _, m := range s.links[n] {
+		hrefs = append(hrefs, strings.TrimPrefix(s.LinkURL(m), s.URL))
+	}
+	if n == 0 {
+		for i := 0; i < s.opts.Disallowed; i++ {
+			hrefs = append(hrefs, s.PrivatePath(i))
+		}
+	}
+	writeTestPage(w, fmt.Sprintf("Test page %d", n), hrefs, fault == FaultTruncate)
+}
+
+// resetConnection drops the connection of w without a response, as a reset
+// by the server or a proxy would
+func resetConnection(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// writeTestPage writes an HTML page with enough text to pass content filters.
+// A truncated page announces its full length but ends half way, then the
+// connection is dropped.
+func writeTestPage(w http.ResponseWriter, title string, hrefs []string, truncate bool) {
+	var page bytes.Buffer
+	fmt.Fprintf(&page, "<!DOCTYPE html>\n<html><head><title>%s</title></head><body>\n<article><h1>%s</h1>\n", title, title)
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&page, "<p>This is paragraph %d of %s, a synthetic page generated for crawler testing. It carries enough text to be treated as real content by the extractor.</p>\n", i+1, strings.ToLower(title))
+	}
+	fmt.Fprint(&page, "</article>\n<nav>\n")
+	for _, href := range hrefs {
+		fmt.Fprintf(&page, "<a href=\"%s\">%s</a>\n", href, href)
+	}
+	fmt.Fprint(&page, "</nav>\n</body></html>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if !truncate {
+		w.Write(page.Bytes())
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(page.Len()))
+	w.Write(page.Bytes()[:page.Len()/2])
+	panic(http.ErrAbortHandler)
+}
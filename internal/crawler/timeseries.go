@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMetricsInterval is the time between metrics time series samples
+const DefaultMetricsInterval = 5 * time.Second
+
+// Time series output files written to the output directory when a crawl ends
+const (
+	MetricsTimeSeriesJSON = "metrics-timeseries.json"
+	MetricsTimeSeriesCSV  = "metrics-timeseries.csv"
+)
+
+// MetricsSample is a point-in-time reading of the crawl metrics
+type MetricsSample struct {
+	Timestamp       time.Time `json:"timestamp"`
+	Elapsed         float64   `json:"elapsed_seconds"`
+	URLsProcessed   int64     `json:"urls_processed"`
+	URLsSaved       int64     `json:"urls_saved"`
+	URLsSkipped     int64     `json:"urls_skipped"`
+	URLsErrored     int64     `json:"urls_errored"`
+	QueueSize       int       `json:"queue_size"`
+	BytesDownloaded int64     `json:"bytes_downloaded"`
+	PagesPerSecond  float64   `json:"pages_per_second"` // Throughput since the previous sample
+	HeapAlloc       int64     `json:"heap_alloc_bytes"`
+}
+
+// MetricsTimeSeries is the sequence of samples recorded over a crawl
+type MetricsTimeSeries struct {
+	Interval float64         `json:"interval_seconds"`
+	Samples  []MetricsSample `json:"samples"`
+}
+
+// metricsRecorder collects metrics samples at a fixed interval
+type metricsRecorder struct {
+	mu       sync.Mutex
+	interval time.Duration
+	samples  []MetricsSample
+	onSample func(MetricsSample) // Also given each sample when set, e.g. the results database
+}
+
+// newMetricsRecorder creates a recorder sampling every interval
+func newMetricsRecorder(interval time.Duration) *metricsRecorder {
+	return &metricsRecorder{interval: interval}
+}
+
+// Record appends a sample taken from the current metrics
+func (r *metricsRecorder) Record(metrics *CrawlerMetrics) MetricsSample {
+	snapshot := metrics.GetSnapshot()
+	now := time.Now()
+	sample := MetricsSample{
+		Timestamp:       now,
+		Elapsed:         now.Sub(snapshot.StartTime).Seconds(),
+		URLsProcessed:   snapshot.URLsProcessed,
+		URLsSaved:       snapshot.URLsSaved,
+		URLsSkipped:     snapshot.URLsSkipped,
+		URLsErrored:     snapshot.URLsErrored,
+		QueueSize:       snapshot.QueueSize,
+		BytesDownloaded: snapshot.BytesDownloaded,
+		HeapAlloc:       snapshot.HeapAlloc,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Rate over the last interval rather than the crawl average, so spikes
+	// and stalls are visible
+	prevElapsed, prevProcessed := 0.0, int64(0)
+	if n := len(r.samples); n > 0 {
+		prevElapsed = r.samples[n-1].Elapsed
+		prevProcessed = r.samples[n-1].URLsProcessed
+	}
+	if dt := sample.Elapsed - prevElapsed; dt > 0 {
+		sample.PagesPerSecond = float64(sample.URLsProcessed-prevProcessed) / dt
+	}
+
+	r.samples = append(r.samples, sample)
+	if r.onSample != nil {
+		r.onSample(sample)
+	}
+	return sample
+}
+
+// Series returns a copy of the recorded samples
+func (r *metricsRecorder) Series() MetricsTimeSeries {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	samples := make([]MetricsSample, len(r.samples))
+	copy(samples, r.samples)
+	return MetricsTimeSeries{
+		Interval: r.interval.Seconds(),
+		Samples:  samples,
+	}
+}
+
+// Run samples metrics every interval until stop is closed
+func (r *metricsRecorder) Run(metrics *CrawlerMetrics, stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Record(metrics)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// MetricsTimeSeries returns the samples recorded so far in this crawl
+func (c *Crawler) MetricsTimeSeries() MetricsTimeSeries {
+	if c.recorder == nil {
+		return MetricsTimeSeries{Samples: []MetricsSample{}}
+	}
+	return c.recorder.Series()
+}
+
+// startMetricsRecorder begins periodic sampling. The returned function stops
+// sampling and records a final sample.
+func (c *Crawler) startMetricsRecorder() func() {
+	c.recorder.Record(c.metrics)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.recorder.Run(c.metrics, stop)
+	}()
+
+	return func() {
+		close(stop)
+		<-done
+		c.recorder.Record(c.metrics)
+	}
+}
+
+// writeMetricsTimeSeries saves the recorded samples as JSON and CSV in the output directory
+func (c *Crawler) writeMetricsTimeSeries() error {
+	series := c.MetricsTimeSeries()
+
+	data, err := json.MarshalIndent(series, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metrics time series: %v", err)
+	}
+	if err := c.perms.writeFile(filepath.Join(c.config.OutputDir, MetricsTimeSeriesJSON), data); err != nil {
+		return fmt.Errorf("failed to write metrics time series: %v", err)
+	}
+
+	f, err := c.perms.create(filepath.Join(c.config.OutputDir, MetricsTimeSeriesCSV))
+	if err != nil {
+		return fmt.Errorf("failed to write metrics time series: %v", err)
+	}
+	defer f.Close()
+	return WriteMetricsCSV(f, series)
+}
+
+// WriteMetricsCSV writes a time series as CSV with a header row
+func WriteMetricsCSV(w io.Writer, series MetricsTimeSeries) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"timestamp", "elapsed_seconds", "urls_processed", "urls_saved", "urls_skipped",
+		"urls_errored", "queue_size", "bytes_downloaded", "pages_per_second", "heap_alloc_bytes",
+	})
+	for _, s := range series.Samples {
+		cw.Write([]string{
+			s.Timestamp.Format(time.RFC3339),
+			strconv.FormatFloat(s.Elapsed, 'f', 1, 64),
+			strconv.FormatInt(s.URLsProcessed, 10),
+			strconv.FormatInt(s.URLsSaved, 10),
+			strconv.FormatInt(s.URLsSkipped, 10),
+			strconv.FormatInt(s.URLsErrored, 10),
+			strconv.Itoa(s.QueueSize),
+			strconv.FormatInt(s.BytesDownloaded, 10),
+			strconv.FormatFloat(s.PagesPerSecond, 'f', 2, 64),
+			strconv.FormatInt(s.HeapAlloc, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// LoadMetricsTimeSeries reads the time series written by a finished crawl
+func LoadMetricsTimeSeries(outputDir string) (*MetricsTimeSeries, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, MetricsTimeSeriesJSON))
+	if err != nil {
+		return nil, err
+	}
+	var series MetricsTimeSeries
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics time series: %v", err)
+	}
+	return &series, nil
+}
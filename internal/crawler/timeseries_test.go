@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsRecorder(t *testing.T) {
+	metrics := NewCrawlerMetrics()
+	metrics.StartTime = time.Now().Add(-10 * time.Second)
+	r := newMetricsRecorder(time.Second)
+
+	first := r.Record(metrics)
+	if first.URLsProcessed != 0 {
+		t.Errorf("first sample processed = %d, want 0", first.URLsProcessed)
+	}
+
+	for i := 0; i < 5; i++ {
+		metrics.IncrementProcessed()
+	}
+	metrics.IncrementErrored()
+	metrics.SetQueueSize(7)
+	time.Sleep(20 * time.Millisecond)
+
+	second := r.Record(metrics)
+	if second.URLsProcessed != 5 || second.URLsErrored != 1 || second.QueueSize != 7 {
+		t.Errorf("unexpected second sample: %+v", second)
+	}
+	// Rate is computed over the interval since the previous sample, not the whole crawl
+	if second.PagesPerSecond <= 5/second.Elapsed {
+		t.Errorf("interval rate %.2f should exceed crawl average %.2f", second.PagesPerSecond, 5/second.Elapsed)
+	}
+
+	series := r.Series()
+	if series.Interval != 1 || len(series.Samples) != 2 {
+		t.Errorf("Series() = interval %v, %d samples", series.Interval, len(series.Samples))
+	}
+}
+
+func TestMetricsRecorderRun(t *testing.T) {
+	metrics := NewCrawlerMetrics()
+	r := newMetricsRecorder(time.Millisecond)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(metrics, stop)
+	}()
+
+	// Counters change while the sampler reads them, as in a crawl (run with -race)
+	for i := 0; i < 200; i++ {
+		metrics.IncrementProcessed()
+		metrics.IncrementDiscoveredAt(i % 3)
+		metrics.SetQueueSize(i)
+		if i%20 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	close(stop)
+	<-done
+
+	samples := r.Series().Samples
+	if len(samples) == 0 {
+		t.Fatal("no samples recorded")
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i].URLsProcessed < samples[i-1].URLsProcessed || samples[i].PagesPerSecond < 0 {
+			t.Errorf("sample %d = %+v does not follow %+v", i, samples[i], samples[i-1])
+		}
+	}
+}
+
+func TestMetricsTimeSeriesFiles(t *testing.T) {
+	outputDir := t.TempDir()
+	c := &Crawler{
+		config:   Config{OutputDir: outputDir},
+		metrics:  NewCrawlerMetrics(),
+		recorder: newMetricsRecorder(time.Hour),
+	}
+
+	stop := c.startMetricsRecorder()
+	c.metrics.IncrementSaved(100)
+	stop()
+
+	if err := c.writeMetricsTimeSeries(); err != nil {
+		t.Fatalf("writeMetricsTimeSeries() error = %v", err)
+	}
+
+	series, err := LoadMetricsTimeSeries(outputDir)
+	if err != nil {
+		t.Fatalf("LoadMetricsTimeSeries() error = %v", err)
+	}
+	if len(series.Samples) != 2 {
+		t.Fatalf("expected start and final samples, got %d", len(series.Samples))
+	}
+	if series.Samples[1].URLsSaved != 1 {
+		t.Errorf("final sample saved = %d, want 1", series.Samples[1].URLsSaved)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetricsCSV(&buf, *series); err != nil {
+		t.Fatalf("WriteMetricsCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "timestamp,elapsed_seconds,urls_processed") {
+		t.Errorf("unexpected CSV header: %s", lines[0])
+	}
+}
@@ -0,0 +1,165 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stages of a crawl at which a URL is accepted or rejected
+const (
+	DecisionStageDiscover = "discover" // A link found on a fetched page is queued or not
+	DecisionStageProcess  = "process"  // A URL taken off the queue is saved or not
+)
+
+// Rules recorded in the decision trace besides the LinkFilter reasons
+// (prefix, host, extension, scheme, invalid) of discovered links
+const (
+	DecisionStart       = "start"        // The start URL or a re-crawl URL
+	DecisionQueued      = "queued"       // A discovered link passed every filter
+	DecisionDedup       = "dedup"        // Already visited or queued
+	DecisionSaved       = "saved"        // Content saved (or unchanged on a re-crawl)
+	DecisionRobots      = "robots"       // Disallowed by robots.txt
+	DecisionDepth       = "depth"        // Beyond the maximum depth
+	DecisionContentType = "content-type" // Excluded content type
+	DecisionSize        = "size"         // Body above the fetch or parse limit
+	DecisionFollowOnly  = "follow-only"  // Matched a follow-only rule: links followed, page not saved
+	DecisionContent     = "content"      // No meaningful content, or outside the page length, word or link density limits
+	DecisionError       = "error"        // Fetch, HTTP, parse or save failure
+)
+
+// Decision is one line of the decision trace: a URL considered by the crawl
+// and the rule that accepted or rejected it
+type Decision struct {
+	Time     time.Time `json:"time"`
+	Stage    string    `json:"stage"`
+	URL      string    `json:"url"`
+	Source   string    `json:"source,omitempty"` // Page a discovered link was found on
+	Depth    int       `json:"depth"`
+	Accepted bool      `json:"accepted"`
+	Rule     string    `json:"rule"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+// decisionTrace appends decisions as JSON Lines to Config.TraceDecisions
+type decisionTrace struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// openDecisionTrace creates the trace file, or appends to it when a crawl
+// is resumed
+func openDecisionTrace(path string, resume bool, perms outputPerms) (*decisionTrace, error) {
+	var f *os.File
+	var err error
+	if resume {
+		if f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+			err = perms.applyFile(path)
+		}
+	} else {
+		f, err = perms.create(path)
+	}
+	if err != nil {
+		if f != nil {
+			f.Close()
+		}
+		return nil, fmt.Errorf("failed to open decision trace: %v", err)
+	}
+	return &decisionTrace{file: f, w: bufio.NewWriter(f)}, nil
+}
+
+// write appends one decision
+func (t *decisionTrace) write(d Decision) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write(append(data, '\n'))
+}
+
+// Close flushes and closes the trace file
+func (t *decisionTrace) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.w.Flush()
+	if closeErr := t.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// traceDecision records d when decision tracing is enabled
+func (c *Crawler) traceDecision(d Decision) {
+	if c.trace == nil {
+		return
+	}
+	d.Time = time.Now()
+	c.trace.write(d)
+}
+
+// traceLink records whether a link found on source, a page at sourceDepth,
+// is queued (rule DecisionQueued) or why it is not, in the decision trace
+// and the results database
+func (c *Crawler) traceLink(rawURL, source string, sourceDepth int, rule, detail string) {
+	if c.trace == nil && c.results == nil {
+		return
+	}
+	depth := c.linkDepth(rawURL, sourceDepth)
+	c.results.addLink(source, rawURL, depth, rule, detail)
+	c.traceDecision(Decision{
+		Stage:    DecisionStageDiscover,
+		URL:      rawURL,
+		Source:   source,
+		Depth:    depth,
+		Accepted: rule == DecisionQueued,
+		Rule:     rule,
+		Detail:   detail,
+	})
+}
+
+// traceOutcome records the inventory outcome of a processed URL
+func (c *Crawler) traceOutcome(rawURL string, depth int, status, reason string) {
+	if c.trace == nil {
+		return
+	}
+	c.traceDecision(Decision{
+		Stage:    DecisionStageProcess,
+		URL:      rawURL,
+		Depth:    depth,
+		Accepted: status == URLStatusSaved,
+		Rule:     decisionRule(status, reason),
+		Detail:   reason,
+	})
+}
+
+// decisionRule maps an inventory status and reason to the rule behind it
+func decisionRule(status, reason string) string {
+	switch status {
+	case URLStatusSaved:
+		return DecisionSaved
+	case URLStatusBlocked:
+		return DecisionRobots
+	case URLStatusError:
+		return DecisionError
+	}
+	switch {
+	case reason == "depth limit":
+		return DecisionDepth
+	case reason == "already visited" || strings.HasPrefix(reason, "redirects to already visited"):
+		return DecisionDedup
+	case reason == "excluded content type":
+		return DecisionContentType
+	case reason == "body too large" || reason == "larger than parse limit":
+		return DecisionSize
+	case strings.HasPrefix(reason, "follow only"):
+		return DecisionFollowOnly
+	}
+	return DecisionContent
+}
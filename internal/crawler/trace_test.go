@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTraceDecisions(t *testing.T) {
+	text := strings.Repeat("Page text. ", 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /docs/private\n")
+			return
+		case "/docs/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("not really a png"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/docs/home":
+			fmt.Fprintf(w, `<html><body><p>%s</p>
+				<a href="/docs/a">A</a><a href="/docs/a#top">A again</a><a href="/docs/b.pdf">PDF</a>
+				<a href="/blog/">Blog</a><a href="mailto:team@example.com">Mail</a>
+				<a href="/docs/private">Private</a><a href="/docs/empty">Empty</a><a href="/docs/image">Image</a>
+				</body></html>`, text)
+		case "/docs/a":
+			fmt.Fprintf(w, `<html><body><p>%s</p><a href="/docs/home">Home</a></body></html>`, text)
+		default:
+			fmt.Fprint(w, `<html><body></body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	tracePath := filepath.Join(t.TempDir(), "trace.jsonl")
+	config := Config{
+		URL:               server.URL + "/docs/home",
+		MaxDepth:          2,
+		OutputDir:         outputDir,
+		StateFile:         filepath.Join(outputDir, "state.json"),
+		PrefixFilterURL:   server.URL + "/docs/",
+		ExcludeExtensions: []string{"pdf", "png"},
+		MinContentLength:  10,
+		NormalizeURLs:     true,
+		TraceDecisions:    tracePath,
+	}
+	c, err := NewCrawler(config, t.Context())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	f, err := os.Open(tracePath)
+	if err != nil {
+		t.Fatalf("trace not written: %v", err)
+	}
+	defer f.Close()
+	got := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var d Decision
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatalf("invalid trace line %q: %v", scanner.Text(), err)
+		}
+		if d.Time.IsZero() {
+			t.Errorf("decision without time: %s", scanner.Text())
+		}
+		path := strings.TrimPrefix(d.URL, server.URL)
+		got[fmt.Sprintf("%s %s %s %v", d.Stage, path, d.Rule, d.Accepted)] = true
+	}
+
+	want := []string{
+		"discover /docs/home start true",
+		"discover /docs/a queued true",
+		"discover /docs/a dedup false",
+		"discover /docs/b.pdf extension false",
+		"discover /blog/ prefix false",
+		"discover mailto:team@example.com scheme false",
+		"discover /docs/private queued true",
+		"process /docs/home saved true",
+		"process /docs/a saved true",
+		"discover /docs/home dedup false",
+		"process /docs/private robots false",
+		"process /docs/empty content false",
+		"process /docs/image content-type false",
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("trace is missing %q", w)
+		}
+	}
+	if t.Failed() {
+		t.Logf("trace: %v", got)
+	}
+}
+
+func TestDecisionRule(t *testing.T) {
+	tests := []struct {
+		status, reason, want string
+	}{
+		{URLStatusSaved, "unchanged", DecisionSaved},
+		{URLStatusBlocked, "robots.txt", DecisionRobots},
+		{URLStatusError, "HTTP 404", DecisionError},
+		{URLStatusSkipped, "depth limit", DecisionDepth},
+		{URLStatusSkipped, "redirects to already visited https://example.com/", DecisionDedup},
+		{URLStatusSkipped, "larger than parse limit", DecisionSize},
+		{URLStatusSkipped, `follow only: URL matches "/page/"`, DecisionFollowOnly},
+		{URLStatusSkipped, "fewer than 50 words", DecisionContent},
+	}
+	for _, tt := range tests {
+		if got := decisionRule(tt.status, tt.reason); got != tt.want {
+			t.Errorf("decisionRule(%q, %q) = %q, want %q", tt.status, tt.reason, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of the spans of a traced URL. SpanProcess is the root of the
+// URL's trace and the others are its children.
+const (
+	SpanProcess = "process" // Everything done with one URL
+	SpanRobots  = "robots"  // robots.txt check, including its fetch when not cached
+	SpanFetch   = "fetch"   // HTTP or browser fetch
+	SpanParse   = "parse"   // HTML parsing
+	SpanExtract = "extract" // Content extraction
+	SpanSave    = "save"    // Writing the page, its metadata and extracted content
+)
+
+// tracingShutdownTimeout bounds the export of the spans still queued when
+// the crawl ends
+const tracingShutdownTimeout = 5 * time.Second
+
+// TracingConfig exports OpenTelemetry spans of the stages each URL goes
+// through, to analyze slow crawls in Jaeger, Tempo or any OTLP collector
+type TracingConfig struct {
+	Endpoint   string  `json:"endpoint,omitempty"`   // OTLP/HTTP collector, e.g. http://localhost:4318 ("" = tracing off)
+	SampleRate float64 `json:"sampleRate,omitempty"` // Share of URLs traced, from 0 to 1 (0 = every URL)
+	JobID      string  `json:"-"`                    // API or MCP job of the crawl, added to every URL span
+}
+
+// Enabled reports whether spans are exported
+func (t TracingConfig) Enabled() bool {
+	return t.Endpoint != ""
+}
+
+// validateTracing checks the collector URL and the sample rate
+func validateTracing(t TracingConfig) error {
+	if t.SampleRate < 0 || t.SampleRate > 1 || math.IsNaN(t.SampleRate) {
+		return fmt.Errorf("trace sample rate must be between 0 and 1, got: %v", t.SampleRate)
+	}
+	if t.Endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(t.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("OTLP endpoint must be an http or https URL, got: %s", t.Endpoint)
+	}
+	return nil
+}
+
+// crawlTracer starts the spans of each URL and exports them over OTLP/HTTP.
+// The root span of a URL is kept by URL while it is processed, so the
+// stages can add their spans without a context being passed through every
+// call. A nil crawlTracer hands out spans that record nothing.
+type crawlTracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	attrs    []attribute.KeyValue // Added to every URL span
+	active   sync.Map             // URL -> context.Context of its process span
+}
+
+// newCrawlTracer sets up the export to config.Tracing.Endpoint. Spans go
+// to its /v1/traces unless the endpoint has a path of its own.
+func newCrawlTracer(config Config) (*crawlTracer, error) {
+	u, err := url.Parse(config.Tracing.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint: %v", err)
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Path != "" && u.Path != "/" {
+		options = append(options, otlptracehttp.WithURLPath(u.Path))
+	}
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %v", err)
+	}
+
+	rate := config.Tracing.SampleRate
+	if rate == 0 {
+		rate = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(rate))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("scraper"))),
+	)
+
+	attrs := []attribute.KeyValue{attribute.String("crawl.start_url", config.URL)}
+	if config.Tracing.JobID != "" {
+		attrs = append(attrs, attribute.String("crawl.job_id", config.Tracing.JobID))
+	}
+	return &crawlTracer{
+		provider: provider,
+		tracer:   provider.Tracer("scraper/crawler"),
+		attrs:    attrs,
+	}, nil
+}
+
+// startURL starts the root span of rawURL
+func (t *crawlTracer) startURL(rawURL string, depth int) {
+	if t == nil {
+		return
+	}
+	ctx, _ := t.tracer.Start(context.Background(), SpanProcess,
+		trace.WithAttributes(t.attrs...),
+		trace.WithAttributes(semconv.URLFull(rawURL), attribute.Int("crawl.depth", depth)))
+	t.active.Store(rawURL, ctx)
+}
+
+// endURL ends the root span of rawURL
+func (t *crawlTracer) endURL(rawURL string) {
+	if t == nil {
+		return
+	}
+	if ctx, ok := t.active.LoadAndDelete(rawURL); ok {
+		trace.SpanFromContext(ctx.(context.Context)).End()
+	}
+}
+
+// stage starts the span of one stage of rawURL. The caller ends it.
+func (t *crawlTracer) stage(rawURL, name string) trace.Span {
+	if t == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	ctx, ok := t.active.Load(rawURL)
+	if !ok {
+		return trace.SpanFromContext(context.Background())
+	}
+	_, span := t.tracer.Start(ctx.(context.Context), name)
+	return span
+}
+
+// child starts a span inside parent. The caller ends it.
+func (t *crawlTracer) child(parent trace.Span, name string) trace.Span {
+	if t == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	_, span := t.tracer.Start(trace.ContextWithSpan(context.Background(), parent), name)
+	return span
+}
+
+// outcome adds the inventory status and reason of rawURL to its root span,
+// marking it failed on errors
+func (t *crawlTracer) outcome(rawURL, status, reason string) {
+	if t == nil {
+		return
+	}
+	ctx, ok := t.active.Load(rawURL)
+	if !ok {
+		return
+	}
+	span := trace.SpanFromContext(ctx.(context.Context))
+	span.SetAttributes(attribute.String("crawl.status", status))
+	if reason != "" {
+		span.SetAttributes(attribute.String("crawl.reason", reason))
+	}
+	if status == URLStatusError {
+		span.SetStatus(codes.Error, reason)
+	}
+}
+
+// shutdown exports the spans still queued
+func (t *crawlTracer) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	defer cancel()
+	return t.provider.Shutdown(ctx)
+}
+
+// endFetchSpan adds the response of a fetch to its span and ends it
+func endFetchSpan(span trace.Span, result *FetchResult, err error) {
+	if result != nil {
+		span.SetAttributes(
+			semconv.HTTPResponseStatusCode(result.StatusCode),
+			attribute.String("http.response.content_type", result.ContentType),
+			semconv.HTTPResponseBodySize(len(result.Body)))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTracing(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, `<html><body><p>%s</p><a href="/missing">Missing</a></body></html>`, strings.Repeat("Page text. ", 10))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer site.Close()
+
+	// A collector receiving OTLP/HTTP protobuf exports
+	var mu sync.Mutex
+	var spans []*tracepb.Span
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("export is not OTLP: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		Tracing:          TracingConfig{Endpoint: collector.URL, JobID: "job-1"},
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) == 0 || paths[0] != "/v1/traces" {
+		t.Fatalf("exports went to %v, want /v1/traces", paths)
+	}
+	names := make(map[string]int)
+	roots := make(map[string]*tracepb.Span)
+	for _, s := range spans {
+		names[s.Name]++
+		if s.Name == SpanProcess {
+			roots[attributeValue(s, "url.full")] = s
+		}
+	}
+	for name, want := range map[string]int{SpanProcess: 2, SpanRobots: 2, SpanFetch: 2, SpanParse: 1, SpanSave: 1, SpanExtract: 1} {
+		if names[name] != want {
+			t.Errorf("%d %q spans, want %d (all: %v)", names[name], name, want, names)
+		}
+	}
+
+	root := roots[site.URL+"/"]
+	if root == nil {
+		t.Fatalf("no process span for the start URL")
+	}
+	if attributeValue(root, "crawl.job_id") != "job-1" || attributeValue(root, "crawl.status") != URLStatusSaved {
+		t.Errorf("start URL span attributes = %v", root.Attributes)
+	}
+	missing := roots[site.URL+"/missing"]
+	if missing == nil || missing.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR || attributeValue(missing, "crawl.reason") != "HTTP 404" {
+		t.Errorf("span of the missing page = %v", missing)
+	}
+	for _, s := range spans {
+		if s.Name != SpanProcess && string(s.TraceId) != string(roots[site.URL+"/"].TraceId) && string(s.TraceId) != string(missing.TraceId) {
+			t.Errorf("%s span is not in a URL's trace", s.Name)
+		}
+	}
+}
+
+func TestTracingSampleRate(t *testing.T) {
+	config := Config{URL: "https://example.com", Tracing: TracingConfig{Endpoint: "http://localhost:4318", SampleRate: 0.000001}}
+	tracer, err := newCrawlTracer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracer.shutdown()
+
+	sampled := 0
+	for i := 0; i < 100; i++ {
+		u := fmt.Sprintf("https://example.com/%d", i)
+		tracer.startURL(u, 0)
+		if tracer.stage(u, SpanFetch).IsRecording() {
+			sampled++
+		}
+		tracer.endURL(u)
+	}
+	if sampled > 1 {
+		t.Errorf("%d of 100 URLs traced at a one in a million sample rate", sampled)
+	}
+}
+
+// attributeValue returns the string value of the span attribute key
+func attributeValue(s *tracepb.Span, key string) string {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			return a.Value.GetStringValue()
+		}
+	}
+	return ""
+}
@@ -1,16 +1,45 @@
 package crawler
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
 	"math/rand"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
 )
 
+// Built-in user agent sets for Config.UserAgentPool
+const (
+	UserAgentPoolDesktop = "desktop" // Chrome and Edge on Windows, macOS and Linux (default)
+	UserAgentPoolMobile  = "mobile"  // Chrome on Android, Safari and Chrome on iPhone and iPad
+	UserAgentPoolAll     = "all"     // Desktop and mobile
+)
+
+// MaxUserAgentLength bounds each entry of Config.UserAgents
+const MaxUserAgentLength = 512
+
 // ChromeUserAgents contains realistic Chrome user agent strings for rotation
 var chromeUserAgents = []string{
-	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
-	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
-	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36 Edg/131.0.0.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36 Edg/131.0.0.0",
+}
+
+// mobileUserAgents are phones and tablets, for sites serving a mobile variant
+var mobileUserAgents = []string{
+	"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Mobile Safari/537.36",
+	"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Mobile Safari/537.36",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_7 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.7 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 18_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/131.0.6778.73 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPad; CPU OS 18_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.1 Mobile/15E148 Safari/604.1",
 }
 
 // GetChromeUserAgents returns the list of Chrome user agents for rotation
@@ -18,6 +47,138 @@ func GetChromeUserAgents() []string {
 	return chromeUserAgents
 }
 
+// UserAgentPools returns the names of the built-in user agent sets
+func UserAgentPools() []string {
+	return []string{UserAgentPoolDesktop, UserAgentPoolMobile, UserAgentPoolAll}
+}
+
+// BuiltinUserAgents returns the user agents of a built-in set ("" is the
+// desktop set), or nil for an unknown name
+func BuiltinUserAgents(pool string) []string {
+	switch pool {
+	case "", UserAgentPoolDesktop:
+		return chromeUserAgents
+	case UserAgentPoolMobile:
+		return mobileUserAgents
+	case UserAgentPoolAll:
+		return append(append([]string{}, chromeUserAgents...), mobileUserAgents...)
+	}
+	return nil
+}
+
+// ParseUserAgents reads the value of -user-agents: a file with one user
+// agent per line, where blank lines and lines starting with # are skipped,
+// or an inline JSON array
+func ParseUserAgents(value string) ([]string, error) {
+	var agents []string
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if err := json.Unmarshal([]byte(value), &agents); err != nil {
+			return nil, fmt.Errorf("user agents must be a file or a JSON array of strings: %v", err)
+		}
+	} else {
+		f, err := os.Open(value)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				agents = append(agents, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("no user agents in %s", value)
+	}
+	return agents, validateUserAgentList(agents)
+}
+
+// validateUserAgents checks the user agent rotation settings
+func validateUserAgents(config *Config) error {
+	if BuiltinUserAgents(config.UserAgentPool) == nil {
+		return fmt.Errorf("user agent pool must be one of: %s, got: %s", strings.Join(UserAgentPools(), ", "), config.UserAgentPool)
+	}
+	if !config.AntiBot.Effective().RotateUserAgent && (config.UserAgentPool != "" || len(config.UserAgents) > 0 || config.StickyUserAgent) {
+		return fmt.Errorf("user agent pool, list and sticky assignment require user agent rotation")
+	}
+	return validateUserAgentList(config.UserAgents)
+}
+
+// validateUserAgentList rejects entries that can't be sent as a header
+func validateUserAgentList(agents []string) error {
+	for _, ua := range agents {
+		if strings.TrimSpace(ua) == "" || len(ua) > MaxUserAgentLength || strings.ContainsAny(ua, "\r\n") {
+			return fmt.Errorf("invalid user agent %q: must be a single non-empty line of at most %d characters", ua, MaxUserAgentLength)
+		}
+	}
+	return nil
+}
+
+// userAgentPool hands out the user agent of each request when
+// AntiBot.RotateUserAgent is set, in turn or, with StickyUserAgent, the
+// same one for every page of a host. A nil pool hands out none.
+type userAgentPool struct {
+	agents []string
+	sticky bool
+	mu     sync.Mutex
+	next   int
+	hosts  map[string]string // Host -> its user agent when sticky
+}
+
+// newUserAgentPool returns the pool of config, nil without rotation
+func newUserAgentPool(config Config) *userAgentPool {
+	if !config.AntiBot.Effective().RotateUserAgent {
+		return nil
+	}
+	agents := config.UserAgents
+	if len(agents) == 0 {
+		agents = BuiltinUserAgents(config.UserAgentPool)
+	}
+	return &userAgentPool{agents: agents, sticky: config.StickyUserAgent, hosts: make(map[string]string)}
+}
+
+// pick returns the user agent to request rawURL with, "" without a pool
+func (p *userAgentPool) pick(rawURL string) string {
+	if p == nil || len(p.agents) == 0 {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var host string
+	if p.sticky {
+		if u, err := url.Parse(rawURL); err == nil {
+			host = strings.ToLower(u.Hostname())
+		}
+		if ua, ok := p.hosts[host]; ok {
+			return ua
+		}
+	}
+	ua := p.agents[p.next]
+	p.next = (p.next + 1) % len(p.agents)
+	if p.sticky {
+		p.hosts[host] = ua
+	}
+	return ua
+}
+
+// userAgentFor returns the User-Agent to request rawURL with: the next one
+// of the rotation pool, or the configured one
+func (c *Crawler) userAgentFor(rawURL string) string {
+	if ua := c.userAgents.pick(rawURL); ua != "" {
+		return ua
+	}
+	if c.config.UserAgent != "" {
+		return c.config.UserAgent
+	}
+	return DefaultUserAgent
+}
+
 // Viewport represents a screen resolution
 type Viewport struct {
 	Width  int
@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUserAgentPool(t *testing.T) {
+	if p := newUserAgentPool(Config{UserAgentPool: UserAgentPoolMobile}); p != nil {
+		t.Error("pool created without rotation")
+	}
+
+	p := newUserAgentPool(Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgents: []string{"a", "b"}})
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, p.pick("https://example.com/"))
+	}
+	if strings.Join(got, ",") != "a,b,a" {
+		t.Errorf("rotation = %v, want a,b,a", got)
+	}
+
+	p = newUserAgentPool(Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgents: []string{"a", "b"}, StickyUserAgent: true})
+	first := p.pick("https://one.example.com/x")
+	other := p.pick("https://two.example.com/")
+	if again := p.pick("https://ONE.example.com/y"); again != first || other == first {
+		t.Errorf("sticky picks = %s, %s, %s; want the same agent for each host", first, other, again)
+	}
+
+	if n := len(BuiltinUserAgents(UserAgentPoolAll)); n != len(chromeUserAgents)+len(mobileUserAgents) {
+		t.Errorf("all pool has %d agents", n)
+	}
+}
+
+func TestValidateUserAgents(t *testing.T) {
+	tests := []struct {
+		config Config
+		ok     bool
+	}{
+		{Config{}, true},
+		{Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgentPool: UserAgentPoolMobile, StickyUserAgent: true}, true},
+		{Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgentPool: "tablet"}, false},
+		{Config{UserAgentPool: UserAgentPoolMobile}, false},
+		{Config{StickyUserAgent: true}, false},
+		{Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgents: []string{"Bot/1.0"}}, true},
+		{Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgents: []string{"Bot/1.0\r\nX-Injected: 1"}}, false},
+		{Config{AntiBot: AntiBotConfig{RotateUserAgent: true}, UserAgents: []string{" "}}, false},
+	}
+	for _, tt := range tests {
+		if err := validateUserAgents(&tt.config); (err == nil) != tt.ok {
+			t.Errorf("validateUserAgents(%+v) = %v, want ok %v", tt.config, err, tt.ok)
+		}
+	}
+}
+
+func TestParseUserAgents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agents.txt")
+	os.WriteFile(path, []byte("# Desktop\nAgent/1.0\n\n  Agent/2.0  \n"), 0o644)
+	agents, err := ParseUserAgents(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(agents, "|") != "Agent/1.0|Agent/2.0" {
+		t.Errorf("agents = %q", agents)
+	}
+
+	if agents, err := ParseUserAgents(`["Agent/3.0"]`); err != nil || len(agents) != 1 {
+		t.Errorf("inline = %q, %v", agents, err)
+	}
+	if _, err := ParseUserAgents("[]"); err == nil {
+		t.Error("accepted an empty list")
+	}
+}
+
+func TestUserAgentRecordedInMetadata(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p><a href="/second">Second</a></body></html>`, strings.Repeat("Page text. ", 10))
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              server.URL + "/",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		IgnoreRobots:     true,
+		AntiBot:          AntiBotConfig{RotateUserAgent: true},
+		UserAgents:       []string{"Agent/1.0", "Agent/2.0"},
+		StickyUserAgent:  true,
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runSelfTestCrawl(context.Background(), config); err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if seen["/"] != "Agent/1.0" || seen["/second"] != "Agent/1.0" {
+		t.Errorf("requests sent with %v, want Agent/1.0 for the whole host", seen)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "second.meta.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta map[string]interface{}
+	json.Unmarshal(data, &meta)
+	if meta["user_agent"] != "Agent/1.0" {
+		t.Errorf("metadata user_agent = %v", meta["user_agent"])
+	}
+}
@@ -0,0 +1,260 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Referer policies for Config.RefererPolicy
+const (
+	RefererPolicyNone   = "none"   // Send no Referer (default without warm-up)
+	RefererPolicyOrigin = "origin" // Send the origin of the linking page only
+	RefererPolicyStrict = "strict" // As browsers do: the linking page on the same origin, its origin across origins, nothing from HTTPS to HTTP (default with warm-up)
+	RefererPolicyFull   = "full"   // Send the full URL of the linking page
+)
+
+// MaxWarmUpPages bounds Config.WarmUpPages
+const MaxWarmUpPages = 5
+
+// RefererPolicies returns the valid Config.RefererPolicy values
+func RefererPolicies() []string {
+	return []string{RefererPolicyNone, RefererPolicyOrigin, RefererPolicyStrict, RefererPolicyFull}
+}
+
+// validateWarmUp checks the warm-up page count and the referer policy
+func validateWarmUp(config *Config) error {
+	switch config.RefererPolicy {
+	case "", RefererPolicyNone, RefererPolicyOrigin, RefererPolicyStrict, RefererPolicyFull:
+	default:
+		return fmt.Errorf("referer policy must be one of: %s, got: %s", strings.Join(RefererPolicies(), ", "), config.RefererPolicy)
+	}
+	if config.WarmUpPages < 0 || config.WarmUpPages > MaxWarmUpPages {
+		return fmt.Errorf("warm-up pages must be between 0 and %d, got: %d", MaxWarmUpPages, config.WarmUpPages)
+	}
+	if config.WarmUpPages > 0 && !config.WarmUp {
+		return fmt.Errorf("warm-up pages require warm-up to be enabled")
+	}
+	return nil
+}
+
+// EffectiveRefererPolicy returns the referer policy in effect: the one set,
+// or strict with warm-up and none without
+func (c Config) EffectiveRefererPolicy() string {
+	if c.RefererPolicy != "" {
+		return c.RefererPolicy
+	}
+	if c.WarmUp {
+		return RefererPolicyStrict
+	}
+	return RefererPolicyNone
+}
+
+// refererFor returns the Referer policy sends when the page from links to
+// the page to ("" = none)
+func refererFor(policy, from, to string) string {
+	if from == "" || policy == RefererPolicyNone {
+		return ""
+	}
+	f, err := url.Parse(from)
+	if err != nil || (f.Scheme != "http" && f.Scheme != "https") {
+		return ""
+	}
+	// Never send credentials or fragments, whatever the policy
+	f.User = nil
+	f.Fragment = ""
+	origin := f.Scheme + "://" + f.Host + "/"
+	switch policy {
+	case RefererPolicyFull:
+		return f.String()
+	case RefererPolicyOrigin:
+		return origin
+	}
+
+	t, err := url.Parse(to)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case f.Scheme == "https" && t.Scheme == "http":
+		return ""
+	case f.Scheme == t.Scheme && strings.EqualFold(f.Host, t.Host):
+		return f.String()
+	default:
+		return origin
+	}
+}
+
+// warmUpHosts records the hosts whose session has been warmed up
+type warmUpHosts struct {
+	mu    sync.Mutex
+	hosts map[string]*hostWarmUp
+}
+
+// hostWarmUp is the warm-up of one host, run once
+type hostWarmUp struct {
+	once sync.Once
+	last string // Last page visited, the Referer of the host's URLs not found through a link
+}
+
+// host returns the warm-up of host, creating it if needed
+func (w *warmUpHosts) host(host string) *hostWarmUp {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h, ok := w.hosts[host]
+	if !ok {
+		h = &hostWarmUp{}
+		w.hosts[host] = h
+	}
+	return h
+}
+
+// lastPage returns the last page the warm-up of host visited
+func (w *warmUpHosts) lastPage(host string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if h, ok := w.hosts[host]; ok {
+		return h.last
+	}
+	return ""
+}
+
+// setLastPage records the last page the warm-up of host visited
+func (w *warmUpHosts) setLastPage(host, page string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hosts[host].last = page
+}
+
+// refererOf returns the Referer to request rawURL with: the page it was
+// first found on, or the last warm-up page of its host for URLs not found
+// through a link, according to the referer policy
+func (c *Crawler) refererOf(rawURL string) string {
+	from := c.inventory.Referrer(rawURL)
+	if from == "" && c.warmUps != nil {
+		if u, err := url.Parse(rawURL); err == nil {
+			from = c.warmUps.lastPage(strings.ToLower(u.Host))
+		}
+	}
+	return refererFor(c.config.EffectiveRefererPolicy(), from, rawURL)
+}
+
+// warmUp visits the homepage of rawURL's host, and WarmUpPages pages it
+// links to on the same host, before the first URL of that host is fetched,
+// so sites that block deep links without a session or Referer see a visitor
+// who came in through the front door. Other workers wait for it to finish.
+// Warm-up pages are not saved.
+func (c *Crawler) warmUp(rawURL, userAgent string) {
+	if c.warmUps == nil {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return
+	}
+	host := strings.ToLower(u.Host)
+	c.warmUps.host(host).once.Do(func() {
+		home := u.Scheme + "://" + u.Host + "/"
+		if strings.TrimSuffix(rawURL, "/") == strings.TrimSuffix(home, "/") {
+			return
+		}
+		if !c.isAllowedByRobots(home) {
+			c.log.Debug("Skipping warm-up of %s: homepage blocked by robots.txt", host)
+			return
+		}
+
+		c.log.Info("Warming up session on %s", home)
+		result, err := c.fetcher.Fetch(home, userAgent)
+		if err != nil || result == nil {
+			c.log.Warn("Warm-up of %s failed: %v", home, err)
+			return
+		}
+		c.warmUps.setLastPage(host, home)
+
+		for _, page := range warmUpLinks(result, home, rawURL, c.config.WarmUpPages) {
+			if c.ctx.Err() != nil {
+				return
+			}
+			if !c.isAllowedByRobots(page) || c.shouldExcludeByExtension(page) {
+				continue
+			}
+			time.Sleep(c.delay())
+			c.log.Debug("Warm-up visit: %s", page)
+			result, err := c.fetcher.Fetch(page, userAgent)
+			if err != nil || result == nil {
+				c.log.Debug("Warm-up visit of %s failed: %v", page, err)
+				continue
+			}
+			c.warmUps.setLastPage(host, page)
+		}
+		time.Sleep(c.delay())
+	})
+}
+
+// warmUpLinks returns up to n distinct links of the fetched homepage to
+// other pages of its host, in document order, leaving out target
+func warmUpLinks(result *FetchResult, home, target string, n int) []string {
+	if n <= 0 || len(result.Body) == 0 {
+		return nil
+	}
+	base, err := url.Parse(home)
+	if err != nil {
+		return nil
+	}
+	if result.FinalURL != "" {
+		if final, err := url.Parse(result.FinalURL); err == nil {
+			base = final
+		}
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(result.Body))
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{home: true, base.String(): true, target: true}
+	var links []string
+	doc.Find("a[href]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		href, _ := a.Attr("href")
+		u, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !strings.EqualFold(u.Host, base.Host) {
+			return true
+		}
+		u.Fragment = ""
+		link := u.String()
+		if seen[link] {
+			return true
+		}
+		seen[link] = true
+		links = append(links, link)
+		return len(links) < n
+	})
+	return links
+}
+
+// setUpSession makes the fetcher keep cookies during warm-up and send the
+// Referer of the referer policy
+func (c *Crawler) setUpSession() {
+	policy := c.config.EffectiveRefererPolicy()
+	if !c.config.WarmUp && policy == RefererPolicyNone {
+		return
+	}
+	if bf, ok := asBrowserFetcher(c.fetcher); ok {
+		if policy != RefererPolicyNone {
+			bf.SetReferer(c.refererOf)
+		}
+		return
+	}
+	if hf, ok := asHTTPFetcher(c.fetcher); ok {
+		if c.config.WarmUp {
+			hf.KeepCookies()
+		}
+		if policy != RefererPolicyNone {
+			hf.SetReferer(c.refererOf)
+		}
+	}
+}
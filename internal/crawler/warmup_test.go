@@ -0,0 +1,123 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	var mu sync.Mutex
+	var visits []string
+	referers := make(map[string]string)
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		visits = append(visits, r.URL.Path)
+		referers[r.URL.Path] = r.Header.Get("Referer")
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "ok", Path: "/"})
+			fmt.Fprint(w, `<html><body><a href="/about">About</a><a href="/docs/deep">Deep</a></body></html>`)
+			return
+		case "/robots.txt":
+			http.NotFound(w, r)
+			return
+		}
+		// Deep pages are only served within a session
+		if c, err := r.Cookie("session"); err != nil || c.Value != "ok" {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `<html><body><p>%s</p><a href="/docs/next">Next</a></body></html>`, strings.Repeat("Page text. ", 10))
+	}))
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/docs/deep",
+		MaxDepth:         2,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		PrefixFilterURL:  site.URL + "/docs/",
+		WarmUp:           true,
+		WarmUpPages:      1,
+	}
+	if err := ValidateConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+	c, err := runSelfTestCrawl(context.Background(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if saved := c.metrics.Saved(); saved != 2 {
+		t.Errorf("saved %d pages, want the deep page and the one it links to", saved)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var pages []string
+	for _, v := range visits {
+		if v != "/robots.txt" {
+			pages = append(pages, v)
+		}
+	}
+	if want := "/ /about /docs/deep /docs/next"; strings.Join(pages, " ") != want {
+		t.Errorf("visits = %v, want %s", pages, want)
+	}
+	for path, want := range map[string]string{
+		"/":          "",
+		"/about":     site.URL + "/",
+		"/docs/deep": site.URL + "/about",
+		"/docs/next": site.URL + "/docs/deep",
+	} {
+		if referers[path] != want {
+			t.Errorf("Referer of %s = %q, want %q", path, referers[path], want)
+		}
+	}
+}
+
+func TestRefererFor(t *testing.T) {
+	tests := []struct {
+		policy, from, to, want string
+	}{
+		{RefererPolicyNone, "https://a.com/x", "https://a.com/y", ""},
+		{RefererPolicyStrict, "https://a.com/x?q=1", "https://a.com/y", "https://a.com/x?q=1"},
+		{RefererPolicyStrict, "https://a.com/x", "https://b.com/y", "https://a.com/"},
+		{RefererPolicyStrict, "https://a.com/x", "http://a.com/y", ""},
+		{RefererPolicyOrigin, "https://a.com/x", "https://a.com/y", "https://a.com/"},
+		{RefererPolicyFull, "https://user:pw@a.com/x#top", "http://b.com/", "https://a.com/x"},
+		{RefererPolicyFull, "", "https://a.com/", ""},
+	}
+	for _, tt := range tests {
+		if got := refererFor(tt.policy, tt.from, tt.to); got != tt.want {
+			t.Errorf("refererFor(%s, %s, %s) = %q, want %q", tt.policy, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWarmUp(t *testing.T) {
+	tests := []struct {
+		config Config
+		ok     bool
+	}{
+		{Config{}, true},
+		{Config{WarmUp: true, WarmUpPages: MaxWarmUpPages, RefererPolicy: RefererPolicyOrigin}, true},
+		{Config{WarmUp: true, WarmUpPages: MaxWarmUpPages + 1}, false},
+		{Config{WarmUpPages: 1}, false},
+		{Config{RefererPolicy: "unsafe-url"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateWarmUp(&tt.config); (err == nil) != tt.ok {
+			t.Errorf("validateWarmUp(%+v) = %v, want ok %v", tt.config, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Bounds of the interval the watchdog checks in-flight URLs at
+const (
+	minWatchdogInterval = 100 * time.Millisecond
+	maxWatchdogInterval = 5 * time.Second
+)
+
+// ErrURLTimeout is the cause of the context of a URL that ran past URLTimeout
+var ErrURLTimeout = errors.New("URL processing timed out")
+
+// ContextFetcher is implemented by fetchers that can abandon a fetch when
+// its context ends, closing the connection or browser tab
+type ContextFetcher interface {
+	FetchContext(ctx context.Context, url string, userAgent string) (*FetchResult, error)
+}
+
+// fetchContext fetches url with f, giving up when ctx ends. Fetchers that
+// don't take a context keep running in the background until they return.
+func fetchContext(ctx context.Context, f Fetcher, url, userAgent string) (*FetchResult, error) {
+	if ctx.Done() == nil {
+		return f.Fetch(url, userAgent)
+	}
+	if cf, ok := f.(ContextFetcher); ok {
+		return cf.FetchContext(ctx, url, userAgent)
+	}
+
+	type outcome struct {
+		result *FetchResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := f.Fetch(url, userAgent)
+		done <- outcome{result, err}
+	}()
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+// inflightURL is a URL a worker is processing under URLTimeout
+type inflightURL struct {
+	started time.Time
+	hung    bool // Reported by the watchdog
+}
+
+// watchdog tracks the URLs being processed under URLTimeout, so that
+// workers still busy well past the deadline, in a stage that doesn't
+// observe its context, are reported
+type watchdog struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	inflight map[string]*inflightURL
+}
+
+func newWatchdog(timeout time.Duration) *watchdog {
+	return &watchdog{timeout: timeout, inflight: make(map[string]*inflightURL)}
+}
+
+// urlContext returns the context processing rawURL runs under, ended with
+// ErrURLTimeout after URLTimeout, and a function to call when done. Without
+// a timeout it is the crawl's context.
+func (c *Crawler) urlContext(rawURL string) (context.Context, func()) {
+	if c.watchdog == nil {
+		return c.ctx, func() {}
+	}
+	ctx, cancel := context.WithTimeoutCause(c.ctx, c.config.URLTimeout, ErrURLTimeout)
+
+	w := c.watchdog
+	w.mu.Lock()
+	w.inflight[rawURL] = &inflightURL{started: time.Now()}
+	w.mu.Unlock()
+	return ctx, func() {
+		w.mu.Lock()
+		delete(w.inflight, rawURL)
+		w.mu.Unlock()
+		cancel()
+	}
+}
+
+// urlTimedOut reports whether processing ran into URLTimeout, recording the
+// URL as failed if so
+func (c *Crawler) urlTimedOut(ctx context.Context, rawURL string, depth int, stage string, result *FetchResult) bool {
+	if !errors.Is(context.Cause(ctx), ErrURLTimeout) {
+		return false
+	}
+	c.log.Warn("Timed out processing %s after %v during %s", rawURL, c.config.URLTimeout, stage)
+	if !c.watchdog.reported(rawURL) {
+		c.metrics.IncrementTimedOut()
+	}
+	c.metrics.IncrementErrored()
+	c.recordURL(rawURL, depth, URLStatusError, "timed out during "+stage, result)
+	return true
+}
+
+// startWatchdog checks the in-flight URLs until the returned function is
+// called. A URL still processing at twice URLTimeout is stuck in a stage
+// that ignored its deadline, such as a browser tab that stopped
+// responding: it is logged and counted as timed out, once.
+func (c *Crawler) startWatchdog() func() {
+	if c.watchdog == nil {
+		return func() {}
+	}
+	w := c.watchdog
+	interval := min(max(w.timeout/4, minWatchdogInterval), maxWatchdogInterval)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				for _, hung := range w.check(now) {
+					c.log.Error("Worker hung on %s: still running at twice its %v deadline", hung, w.timeout)
+					c.metrics.IncrementTimedOut()
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// reported reports whether the watchdog already counted rawURL as hung
+func (w *watchdog) reported(rawURL string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, ok := w.inflight[rawURL]
+	return ok && f.hung
+}
+
+// check returns the URLs processed for twice the timeout that were not
+// reported before
+func (w *watchdog) check(now time.Time) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var hung []string
+	for u, f := range w.inflight {
+		if f.hung || now.Sub(f.started) < 2*w.timeout {
+			continue
+		}
+		f.hung = true
+		hung = append(hung, u)
+	}
+	return hung
+}
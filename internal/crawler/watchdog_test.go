@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// blockingFetcher never answers until released
+type blockingFetcher struct {
+	release chan struct{}
+}
+
+func (f *blockingFetcher) Fetch(url string, userAgent string) (*FetchResult, error) {
+	<-f.release
+	return &FetchResult{StatusCode: 200}, nil
+}
+
+func (f *blockingFetcher) Close() error { return nil }
+
+func TestURLTimeoutCrawl(t *testing.T) {
+	site := NewTestSite(TestSiteOptions{Pages: 4, SlowPages: 1, SlowDelay: 2 * time.Second})
+	defer site.Close()
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         5,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		URLTimeout:       200 * time.Millisecond,
+		MinContentLength: 10,
+	}
+	c, err := NewCrawler(config, context.Background())
+	if err != nil {
+		t.Fatalf("NewCrawler() error = %v", err)
+	}
+	start := time.Now()
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("crawl took %v, the slow page should have been given up", elapsed)
+	}
+
+	slow := site.PageURL(site.Pages() - 1)
+	var found bool
+	for _, rec := range c.URLInventory() {
+		if rec.URL != slow {
+			continue
+		}
+		found = true
+		if rec.Status != URLStatusError || rec.Reason != "timed out during fetch" {
+			t.Errorf("slow page recorded as %s (%q), want error (timed out during fetch)", rec.Status, rec.Reason)
+		}
+	}
+	if !found {
+		t.Fatalf("slow page %s missing from the inventory", slow)
+	}
+	if got := c.GetMetrics().GetSnapshot().TimedOut; got != 1 {
+		t.Errorf("TimedOut = %d, want 1", got)
+	}
+}
+
+func TestFetchContextAbandonsFetcher(t *testing.T) {
+	f := &blockingFetcher{release: make(chan struct{})}
+	defer close(f.release)
+
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, ErrURLTimeout)
+	defer cancel()
+	if _, err := fetchContext(ctx, f, "https://e.com/", "test"); !errors.Is(err, ErrURLTimeout) {
+		t.Errorf("fetchContext() error = %v, want ErrURLTimeout", err)
+	}
+}
+
+func TestWatchdogCheck(t *testing.T) {
+	w := newWatchdog(time.Second)
+	start := time.Now()
+	w.inflight["https://e.com/a"] = &inflightURL{started: start}
+	w.inflight["https://e.com/b"] = &inflightURL{started: start.Add(time.Second)}
+
+	if hung := w.check(start.Add(1500 * time.Millisecond)); len(hung) != 0 {
+		t.Errorf("check() before twice the timeout = %v, want none", hung)
+	}
+	hung := w.check(start.Add(2500 * time.Millisecond))
+	if len(hung) != 1 || hung[0] != "https://e.com/a" {
+		t.Errorf("check() = %v, want [https://e.com/a]", hung)
+	}
+	if !w.reported("https://e.com/a") || w.reported("https://e.com/b") {
+		t.Error("only a should be reported")
+	}
+	if hung := w.check(start.Add(5 * time.Second)); len(hung) != 1 || hung[0] != "https://e.com/b" {
+		t.Errorf("check() = %v, want only the newly hung b", hung)
+	}
+}
@@ -0,0 +1,195 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Wayback Machine endpoints and pacing. Replaced in tests.
+var (
+	waybackAvailableURL = "https://archive.org/wayback/available" // Availability API, answering with the closest snapshot of a URL
+	waybackWebURL       = "https://web.archive.org/web/"          // Snapshots, as <timestamp>id_/<url> for the page as captured
+	waybackRequestGap   = time.Second                             // Requests to the Wayback Machine are at least this far apart
+)
+
+// waybackTimeLayout is the layout of snapshot timestamps
+const waybackTimeLayout = "20060102150405"
+
+// waybackSnapshot is a capture of a URL by the Wayback Machine
+type waybackSnapshot struct {
+	URL       string    // Playback URL of the snapshot
+	Timestamp time.Time // When the page was captured
+}
+
+// rawURL returns the URL of the snapshot's page as captured, without the
+// Wayback Machine's toolbar or rewritten links
+func (s waybackSnapshot) rawURL(original string) string {
+	return waybackWebURL + s.Timestamp.Format(waybackTimeLayout) + "id_/" + original
+}
+
+// waybackClient talks to the Wayback Machine for the crawls of one
+// crawler, spacing out its requests
+type waybackClient struct {
+	fetcher *HTTPFetcher
+	gap     time.Duration // Time between requests
+
+	mu   sync.Mutex // Guards next
+	next time.Time  // Earliest time of the next request
+}
+
+// newWaybackClient creates a client reading snapshots of at most
+// maxBodySize bytes (0 = unlimited) and sending requests gap apart
+func newWaybackClient(maxBodySize int64, gap time.Duration) *waybackClient {
+	return &waybackClient{fetcher: NewHTTPFetcherWithMaxBodySize(maxBodySize), gap: gap}
+}
+
+// wait returns once the client may send its next request, or with the
+// error of ctx
+func (w *waybackClient) wait(ctx context.Context) error {
+	w.mu.Lock()
+	now := time.Now()
+	at := w.next
+	if at.Before(now) {
+		at = now
+	}
+	w.next = at.Add(w.gap)
+	w.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backOff holds off the next request for at least d, after the Wayback
+// Machine asked to slow down
+func (w *waybackClient) backOff(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if at := time.Now().Add(d); at.After(w.next) {
+		w.next = at
+	}
+}
+
+// get fetches rawURL once the request gap allows
+func (w *waybackClient) get(ctx context.Context, rawURL string) (*FetchResult, error) {
+	if err := w.wait(ctx); err != nil {
+		return nil, err
+	}
+	return w.fetcher.FetchContext(ctx, rawURL, DefaultUserAgent)
+}
+
+// latestSnapshot returns the most recent snapshot of rawURL captured with
+// a 200 response, or nil when the Wayback Machine has none
+func (w *waybackClient) latestSnapshot(ctx context.Context, rawURL string) (*waybackSnapshot, error) {
+	result, err := w.get(ctx, waybackAvailableURL+"?url="+url.QueryEscape(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	if result.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("availability API answered HTTP %d", result.StatusCode)
+	}
+	var answer struct {
+		ArchivedSnapshots struct {
+			Closest *struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+				Timestamp string `json:"timestamp"`
+				Status    string `json:"status"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.Unmarshal(result.Body, &answer); err != nil {
+		return nil, fmt.Errorf("availability API answer is not JSON: %v", err)
+	}
+	closest := answer.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available || closest.Status != "200" {
+		return nil, nil
+	}
+	captured, err := time.Parse(waybackTimeLayout, closest.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot timestamp %q", closest.Timestamp)
+	}
+	return &waybackSnapshot{URL: closest.URL, Timestamp: captured}, nil
+}
+
+// isFetchTimeout reports whether err is a fetch that timed out
+func isFetchTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded) || strings.Contains(strings.ToLower(err.Error()), "timeout")
+}
+
+// waybackFallback fetches the latest Wayback Machine snapshot of rawURL
+// when WaybackFallback is set and the page answered 404 or 410 or its
+// fetch timed out. It returns the snapshot as the fetch result, recording
+// it for saveContent, or nil to keep the outcome of the fetch.
+func (c *Crawler) waybackFallback(ctx context.Context, rawURL string, result *FetchResult, err error) *FetchResult {
+	if c.wayback == nil {
+		return nil
+	}
+	var reason string
+	switch {
+	case err == nil && result != nil && (result.StatusCode == http.StatusNotFound || result.StatusCode == http.StatusGone):
+		reason = fmt.Sprintf("HTTP %d", result.StatusCode)
+	case isFetchTimeout(err):
+		reason = "timeout"
+	default:
+		return nil
+	}
+
+	snapshot, lookupErr := c.wayback.latestSnapshot(ctx, rawURL)
+	if lookupErr != nil {
+		c.log.Warn("Failed to look up a Wayback Machine snapshot of %s: %v", rawURL, lookupErr)
+		return nil
+	}
+	if snapshot == nil {
+		c.log.Debug("No Wayback Machine snapshot of %s (%s)", rawURL, reason)
+		return nil
+	}
+	archived, fetchErr := c.wayback.get(ctx, snapshot.rawURL(rawURL))
+	if fetchErr != nil || archived.StatusCode != http.StatusOK {
+		if fetchErr == nil {
+			fetchErr = fmt.Errorf("HTTP %d", archived.StatusCode)
+		}
+		c.log.Warn("Failed to fetch the Wayback Machine snapshot of %s: %v", rawURL, fetchErr)
+		return nil
+	}
+
+	c.log.Info("%s for %s, using its Wayback Machine snapshot of %s", reason, rawURL, snapshot.Timestamp.Format("2006-01-02"))
+	c.archived.Store(rawURL, archivedSource{
+		Snapshot:  snapshot.URL,
+		Timestamp: snapshot.Timestamp,
+		Reason:    reason,
+	})
+	// The page is saved under rawURL; the snapshot's own redirects are not
+	// those of the site
+	archived.FinalURL = ""
+	archived.Redirects = nil
+	return archived
+}
+
+// archivedSource is where a page saved from the Wayback Machine came from,
+// recorded under archived_source in its .meta.json
+type archivedSource struct {
+	Snapshot  string    `json:"snapshot"`  // Playback URL of the snapshot
+	Timestamp time.Time `json:"timestamp"` // When the snapshot was captured
+	Reason    string    `json:"reason"`    // Why the live page was not used: "HTTP 404", "HTTP 410" or "timeout"
+}
@@ -0,0 +1,152 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeWayback serves the availability API and snapshots of the URLs in
+// pages, and points the Wayback Machine endpoints at itself for the test
+func fakeWayback(t *testing.T, pages map[string]string) *httptest.Server {
+	t.Helper()
+	// Not a ServeMux, which would clean the "//" out of snapshot paths
+	available := func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("url")
+		if _, ok := pages[target]; !ok {
+			fmt.Fprint(w, `{"url": "`+target+`", "archived_snapshots": {}}`)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"url": target,
+			"archived_snapshots": map[string]any{
+				"closest": map[string]any{"status": "200", "available": true, "timestamp": "20200102030405", "url": "http://web.archive.org/web/20200102030405/" + target},
+			},
+		})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/wayback/available" {
+			available(w, r)
+			return
+		}
+		html, ok := pages[strings.TrimPrefix(r.URL.RequestURI(), "/web/20200102030405id_/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, html)
+	}))
+	t.Cleanup(server.Close)
+
+	availableURL, web, gap := waybackAvailableURL, waybackWebURL, waybackRequestGap
+	waybackAvailableURL, waybackWebURL, waybackRequestGap = server.URL+"/wayback/available", server.URL+"/web/", time.Millisecond
+	t.Cleanup(func() { waybackAvailableURL, waybackWebURL, waybackRequestGap = availableURL, web, gap })
+	return server
+}
+
+func TestWaybackFallback(t *testing.T) {
+	text := strings.Repeat("Readable article text. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p><a href="/dead">Dead</a><a href="/lost">Lost</a></body></html>`, text)
+	})
+	mux.HandleFunc("/revived", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><p>%s</p></body></html>`, text)
+	})
+	site := httptest.NewServer(mux)
+	defer site.Close()
+	fakeWayback(t, map[string]string{
+		site.URL + "/dead": fmt.Sprintf(`<html><head><title>Dead</title></head><body><p>%s</p><a href="/revived">Revived</a></body></html>`, text),
+	})
+
+	outputDir := t.TempDir()
+	config := Config{
+		URL:              site.URL + "/",
+		MaxDepth:         3,
+		OutputDir:        outputDir,
+		StateFile:        filepath.Join(outputDir, "state.json"),
+		MinContentLength: 10,
+		WaybackFallback:  true,
+	}
+	c, err := runSelfTestCrawl(t.Context(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	snapshot := c.GetMetrics().GetSnapshot()
+	if snapshot.URLsSaved != 3 || snapshot.URLsErrored != 1 {
+		t.Errorf("saved %d and errored %d, want 3 saved (with the snapshot and the page it links to) and /lost errored", snapshot.URLsSaved, snapshot.URLsErrored)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "dead.meta.json"))
+	if err != nil {
+		t.Fatalf("snapshot of /dead not saved: %v", err)
+	}
+	var meta struct {
+		URL    string         `json:"url"`
+		Source archivedSource `json:"archived_source"`
+	}
+	json.Unmarshal(data, &meta)
+	if meta.URL != site.URL+"/dead" || meta.Source.Reason != "HTTP 404" ||
+		meta.Source.Snapshot != "http://web.archive.org/web/20200102030405/"+site.URL+"/dead" ||
+		!meta.Source.Timestamp.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("dead.meta.json = %s, want the page's URL and its archived source", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outputDir, "revived.meta.json")); strings.Contains(string(data), "archived_source") {
+		t.Error("revived.meta.json is marked archived although the live page was saved")
+	}
+}
+
+func TestWaybackFallbackOff(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	site := httptest.NewServer(mux)
+	defer site.Close()
+	fakeWayback(t, map[string]string{site.URL + "/": `<html><body><p>` + strings.Repeat("Archived. ", 10) + `</p></body></html>`})
+
+	outputDir := t.TempDir()
+	config := Config{URL: site.URL + "/", MaxDepth: 1, OutputDir: outputDir, StateFile: filepath.Join(outputDir, "state.json"), MinContentLength: 10}
+	c, err := runSelfTestCrawl(t.Context(), config)
+	if err != nil {
+		t.Fatalf("crawl failed: %v", err)
+	}
+	if saved := c.GetMetrics().GetSnapshot().URLsSaved; saved != 0 {
+		t.Errorf("saved %d pages without WaybackFallback, want none", saved)
+	}
+}
+
+func TestIsFetchTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	client := &http.Client{Timeout: 20 * time.Millisecond}
+	_, err := client.Get(slow.URL)
+	if !isFetchTimeout(err) {
+		t.Errorf("isFetchTimeout(%v) = false, want true", err)
+	}
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, err = http.Get(closed.URL)
+	if err == nil || isFetchTimeout(err) {
+		t.Errorf("isFetchTimeout(%v) = true, want false for a refused connection", err)
+	}
+	if isFetchTimeout(nil) {
+		t.Error("isFetchTimeout(nil) = true")
+	}
+}
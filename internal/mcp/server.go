@@ -1,15 +1,29 @@
 package mcp
 
 import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"scraper/internal/api"
+	"scraper/internal/crawler"
 )
 
 // Server wraps the MCP server with scraper functionality
 type Server struct {
 	mcpServer  *server.MCPServer
 	jobManager *api.JobManager
+	presetsDir string // Directory of scraper_presets (default: the desktop app's presets)
+
+	schedulesFile string // File of scraper_schedules (default: the desktop app's schedules)
+	runSchedules  bool   // Run the enabled schedules when due
+	scheduler     *api.Scheduler
+	schedulerMu   sync.Mutex
 }
 
 // NewServer creates a new MCP server for the scraper
@@ -17,19 +31,19 @@ func NewServer(maxJobs int) *Server {
 	// Create job manager
 	jobManager := api.NewJobManager(maxJobs)
 
+	s := &Server{
+		jobManager: jobManager,
+	}
+
 	// Create MCP server
-	mcpServer := server.NewMCPServer(
+	s.mcpServer = server.NewMCPServer(
 		"Web Scraper",
 		"1.0.0",
 		server.WithToolCapabilities(true),
 		server.WithRecovery(),
+		server.WithToolHandlerMiddleware(s.auditTool),
 	)
 
-	s := &Server{
-		mcpServer:  mcpServer,
-		jobManager: jobManager,
-	}
-
 	// Register all tools
 	s.registerTools()
 
@@ -46,12 +60,40 @@ func (s *Server) registerTools() {
 				mcp.Required(),
 				mcp.Description("Target URL to start crawling from"),
 			),
+			mcp.WithString("recipe",
+				mcp.Description("Site recipe (see scraper_recipes, e.g. discourse-forum or mediawiki) whose link selectors, follow-only rules, content filters and browser settings apply where this call leaves them unset"),
+			),
 			mcp.WithNumber("maxDepth",
 				mcp.Description("Maximum link depth to crawl (default: 10)"),
 			),
+			mcp.WithString("depthMode",
+				mcp.Description("What maxDepth counts: links (link hops from the start URL, default) or path (URL path segments beyond the start URL, or prefixFilter when set). Path depth is more predictable on documentation trees: with maxDepth 2 and start URL https://example.com/docs/, /docs/a/b is crawled and /docs/a/b/c is not"),
+				mcp.Enum("links", "path"),
+			),
+			mcp.WithNumber("maxPages",
+				mcp.Description("Stop after this many pages have been saved (default: unlimited)"),
+			),
+			mcp.WithString("maxRuntime",
+				mcp.Description("Stop after the crawl has run this long, e.g. '30m' or '2h' (default: unlimited). The crawl ends with stopReason max-runtime"),
+			),
+			mcp.WithNumber("maxConsecutiveErrors",
+				mcp.Description("Stop after this many fetches failed in a row, e.g. when a site starts blocking (default: never). The crawl ends with stopReason consecutive-errors"),
+			),
+			mcp.WithString("urlTimeout",
+				mcp.Description("Hard deadline for fetching, parsing and saving one URL, e.g. '45s'; a URL past it is given up as an error and counted in the timedOut metric, and a worker hung at twice the deadline is logged (default: none). Paginated browser URLs are exempt"),
+			),
+			mcp.WithString("stopPattern",
+				mcp.Description("Stop once every URL matching this regex found so far has been fetched, e.g. '/docs/api/' to end a crawl as soon as the API reference is complete. The crawl ends with stopReason targets-fetched"),
+			),
+			mcp.WithNumber("maxHtmlSize",
+				mcp.Description("Skip pages whose HTML is larger than this many bytes (default: 10485760)"),
+			),
 			mcp.WithBoolean("concurrent",
 				mcp.Description("Enable concurrent crawling for faster processing"),
 			),
+			mcp.WithNumber("workers",
+				mcp.Description("Number of simultaneous requests in concurrent mode (default: 10, max: 100)"),
+			),
 			mcp.WithString("delay",
 				mcp.Description("Delay between requests (e.g. '500ms' or '1s')"),
 			),
@@ -74,14 +116,60 @@ func (s *Server) registerTools() {
 			mcp.WithString("userAgent",
 				mcp.Description("Custom User-Agent string"),
 			),
+			mcp.WithString("userAgentPool",
+				mcp.Description("Built-in user agents antiBot.rotateUserAgent cycles through: desktop (Chrome and Edge, default), mobile (Android and iOS) or all"),
+			),
+			mcp.WithArray("userAgents",
+				mcp.Description("Own user agents antiBot.rotateUserAgent cycles through instead of userAgentPool"),
+			),
+			mcp.WithBoolean("stickyUserAgent",
+				mcp.Description("Keep the user agent first picked for a host for all of its pages, with antiBot.rotateUserAgent (default: false). The user agent of each page is recorded as user_agent in its .meta.json"),
+			),
+			mcp.WithBoolean("warmUp",
+				mcp.Description("Visit each host's homepage before its first deeper URL, keeping its cookies, for sites that block deep links without a session or Referer (default: false)"),
+			),
+			mcp.WithNumber("warmUpPages",
+				mcp.Description("Pages linked from the homepage also visited during warm-up, 0-5 (default: 0)"),
+			),
+			mcp.WithString("refererPolicy",
+				mcp.Description("Referer sent with each request, from the page the URL was found on (or the last warm-up page for the start URL): none, origin (its origin only), strict (as browsers: full on the same origin, origin across origins, none from HTTPS to HTTP) or full. Default: strict with warmUp, none without"),
+				mcp.Enum("none", "origin", "strict", "full"),
+			),
 			mcp.WithBoolean("ignoreRobots",
 				mcp.Description("Ignore robots.txt restrictions"),
 			),
 			mcp.WithNumber("minContent",
 				mcp.Description("Minimum content length to save a page (default: 100)"),
 			),
+			mcp.WithNumber("maxContent",
+				mcp.Description("Skip pages with more visible text than this many characters (default: 0, no limit)"),
+			),
+			mcp.WithNumber("minWords",
+				mcp.Description("Skip pages with fewer words (default: 0, no limit)"),
+			),
+			mcp.WithNumber("maxWords",
+				mcp.Description("Skip pages with more words (default: 0, no limit)"),
+			),
+			mcp.WithNumber("maxLinkDensity",
+				mcp.Description("Skip pages whose share of words inside links is higher, 0-1 (e.g. 0.5 keeps tag, archive and index pages out of the saved pages; their links are still followed). Default: 0, no limit"),
+			),
+			mcp.WithNumber("indexInterval",
+				mcp.Description("Rewrite _index.html every N saved pages during the crawl; 0 = only at completion (default: 50)"),
+			),
+			mcp.WithString("metricsInterval",
+				mcp.Description("Time between metrics time series samples (e.g. '5s', '1m'; default: 5s)"),
+			),
 			mcp.WithObject("antiBot",
-				mcp.Description("Anti-bot detection evasion settings (browser mode only)"),
+				mcp.Description("Anti-bot detection evasion settings (browser mode only, except rotateUserAgent which works in both modes). profile (off, low, standard or aggressive) sets a coherent combination of the options; options set individually (hideWebdriver, spoofPlugins, spoofLanguages, spoofWebGL, addCanvasNoise, naturalMouseMovement, randomTypingDelays, naturalScrolling, randomActionDelays, randomClickOffset, rotateUserAgent, randomViewport, matchTimezone, timezone) are added to it"),
+			),
+			mcp.WithObject("geo",
+				mcp.Description("Capture region-specific content: region (preset au, br, ca, de, es, fr, gb, in, it, jp, nl, us filling the other fields), acceptLanguage (header, both modes), locale, timezone and geolocation ('lat,lon'; browser mode only), proxy (http, https, socks5 or socks5h URL, '{region}' is replaced by the region code; user:pass@ credentials work in both modes except for SOCKS proxies in browser mode), proxyDns (true: resolve host names only through the proxy, the browser makes no DNS lookups of its own)"),
+			),
+			mcp.WithObject("permissions",
+				mcp.Description("Modes of the files and directories the crawl writes, for output on shared volumes: fileMode and dirMode (octal, e.g. '0664' and '2775', applied regardless of the umask; only permission bits are kept, plus setgid for directories). The owner is set for the whole server with -chown"),
+			),
+			mcp.WithObject("faults",
+				mcp.Description("Development only: inject failures into fetches to exercise error and retry handling. latency (duration added to every fetch), errorRate, truncateRate and resetRate (shares of fetches answered with a random 5xx, cut in half, or failed with a connection reset; 0-1, together at most 1), seed (the same seed fails the same fetches)"),
 			),
 			mcp.WithBoolean("normalizeUrls",
 				mcp.Description("Enable URL normalization for better duplicate detection (default: true)"),
@@ -92,21 +180,169 @@ func (s *Server) registerTools() {
 			mcp.WithString("pageLoadWait",
 				mcp.Description("Time to wait after page load for dynamic content (browser mode, e.g. '500ms', '2s')"),
 			),
+			mcp.WithString("harMode",
+				mcp.Description("Record every network request pages make as HAR (browser mode only): 'off' (default), 'page' (one _har/<page>.har per page) or 'crawl' (a single crawl.har). Useful for finding the API endpoints behind JS-rendered content; read the files with scraper_read_file"),
+				mcp.Enum("off", "page", "crawl"),
+			),
+			mcp.WithString("cassette",
+				mcp.Description("Fetch cassette: 'off' (default), 'record' (save every response to cassette.jsonl in the output directory) or 'replay' (answer every fetch from a recorded cassette without network access, to iterate on extraction settings; give cassetteFile and a new outputDir to keep the recorded crawl)"),
+				mcp.Enum("off", "record", "replay"),
+			),
+			mcp.WithString("cassetteFile",
+				mcp.Description("Cassette path (default: cassette.jsonl in the output directory)"),
+			),
+			mcp.WithString("notify",
+				mcp.Description("Announce the end or failure of the crawl on the desktop of the machine running the server, for long crawls: 'off' (default), 'desktop' (notify-send on Linux, Notification Center on macOS, a toast on Windows; the terminal bell where no notifier is found) or 'bell' (terminal bell only)"),
+				mcp.Enum("off", "desktop", "bell"),
+			),
+			mcp.WithString("traceDecisions",
+				mcp.Description("Path of a JSON Lines file recording every URL the crawl considers and the rule that accepted or rejected it (start, queued, dedup, prefix, host, extension, scheme, invalid, robots, depth, content-type, size, follow-only, content, error, saved). Use it to find out why expected pages were not captured; read it with scraper_read_file when it is in the output directory"),
+			),
+			mcp.WithString("resultsDb",
+				mcp.Description("Write a SQLite database of the crawl as it runs, e.g. 'results.sqlite' (relative to the output directory unless absolute), with tables pages (url, path, content_path, title, depth, size, hash, saved_at), links (source, target, depth, queued, rule, detail), errors (url, depth, http_status, reason, time), redirects (source, target, status, time) and metrics (samples of the progress counters), so the crawl can be analyzed with SQL instead of reading thousands of files"),
+			),
+			mcp.WithBoolean("discoverApis",
+				mcp.Description("Log the XHR/fetch requests pages make (method, URL, content type), deduplicated across pages, to api_endpoints.jsonl (browser mode only). Read them with scraper_api_endpoints"),
+			),
+			mcp.WithArray("eventSinks",
+				mcp.Description("Also send the crawl events (progress, log, crawl_started, crawl_completed, error, ...) to external monitoring, e.g. [{\"type\": \"file\"}, {\"type\": \"webhook\", \"url\": \"https://hooks.example.com/crawl\", \"events\": [\"crawl_completed\", \"error\"]}]. Types: file (JSON Lines, path relative to the output directory, default events.ndjson), webhook (POSTs batches as a JSON array), nats (url nats://host:4222 and topic as the subject) and kafka (url of a Kafka REST Proxy and topic). events limits the event types sent (default all but page_saved). List page_saved to publish each saved page (URL, title, hash, stored paths) as it is saved, e.g. to feed search indexing or embeddings; set payload to \"text\" to include its extracted text (default \"pointer\"). Type elasticsearch (url of an Elasticsearch or OpenSearch cluster, credentials in the URL, topic as the index) indexes each saved page with its text through the bulk API, creating the index with the sink's mappings object (default: keyword url/hash/paths, text title/text, date timestamp) when it doesn't exist"),
+			),
+			mcp.WithObject("tracing",
+				mcp.Description("Export OpenTelemetry spans of each URL (process, with robots, fetch, parse, extract and save children, carrying the job ID, URL, depth, status code and outcome) to analyze slow crawls in Jaeger or Tempo. endpoint: OTLP/HTTP collector URL, e.g. 'http://localhost:4318' (spans go to /v1/traces unless the URL has a path); sampleRate: share of URLs traced, 0-1 (default every URL)"),
+			),
+			mcp.WithObject("embeddings",
+				mcp.Description("Embed the extracted text of each saved page (cut to 24000 characters) through an OpenAI-compatible embeddings API, for semantic search over the crawl. endpoint: API base URL, e.g. 'https://api.openai.com/v1' or Ollama's 'http://localhost:11434/v1' (/embeddings is appended); model: e.g. 'text-embedding-3-small' (required); apiKey: Bearer token or 'secret://name' (default $SCRAPER_EMBEDDINGS_API_KEY); dimensions: vector size for models that can shorten their vectors; store: 'file' (default, <page>.embedding.json with url, model, hash and embedding next to each page) or 'results-db' (embeddings table of resultsDb, vectors as little-endian float32 blobs)"),
+			),
+			mcp.WithObject("enrichment",
+				mcp.Description("POST the extracted content of each saved page as {url, jobId, metadata, text, html} to an HTTP endpoint, e.g. a service prompting an LLM, and add the JSON object it answers with (summary, tags, classification, ...) to the page's .meta.json under 'enrichment'. endpoint: the URL; apiKey: Bearer token or 'secret://name' (default $SCRAPER_ENRICHMENT_API_KEY); concurrency: requests in flight (default 2); timeout: per request (default '60s'). Failed requests leave the page's metadata as it was; after 10 failures in a row enrichment is turned off for the rest of the crawl"),
+			),
+			mcp.WithString("postSaveCommand",
+				mcp.Description("Shell command run for each saved page as it arrives, e.g. to pipe pages into a converter: the saved file (compressed with compressOutput) is $1 and $SCRAPER_FILE, its .meta.json is on stdin, and $SCRAPER_META_FILE, $SCRAPER_URL, $SCRAPER_JOB_ID and $SCRAPER_OUTPUT_DIR are set. Example: 'pandoc -f html -t gfm \"$1\" -o \"${1%.html}.md\"'"),
+			),
+			mcp.WithNumber("postSaveConcurrency",
+				mcp.Description("Post-save commands running at once (default: 2)"),
+			),
+			mcp.WithString("postSaveTimeout",
+				mcp.Description("Kill a post-save command running longer than this, counting it as failed (default: '60s')"),
+			),
+			mcp.WithString("postSaveOnFailure",
+				mcp.Description("After a post-save command fails, exits non-zero or times out: 'warn' (default: log it and run the command for the next pages), 'disable' (run it for no more pages) or 'stop' (end the crawl with stopReason post-save-failed)"),
+			),
+			mcp.WithBoolean("waybackFallback",
+				mcp.Description("When a page answers 404 or 410 or its fetch times out, save the latest Wayback Machine snapshot of it captured with a 200 instead, and follow its links as usual (default: false). The page's .meta.json gets archived_source: {snapshot, timestamp, reason}. Requests to archive.org are spaced 1s apart"),
+			),
+			mcp.WithBoolean("waybackSave",
+				mcp.Description("Submit the URL of each saved page to the Internet Archive's Save Page Now, so the crawl also archives the site publicly (default: false). Submissions are spaced waybackSaveInterval apart and wait as long as archive.org asks when rate limited; pages saved from waybackFallback snapshots are not submitted. After 10 failures in a row submission is turned off for the rest of the crawl"),
+			),
+			mcp.WithString("waybackSaveKey",
+				mcp.Description("archive.org API key (S3-style, from archive.org/account/s3.php) as 'accesskey:secret', or 'secret://name' (default $SCRAPER_WAYBACK_KEY). Without one captures are anonymous, with lower limits"),
+			),
+			mcp.WithString("waybackSaveInterval",
+				mcp.Description("Time between Save Page Now submissions (default: '10s')"),
+			),
+			mcp.WithBoolean("keepCookieBanners",
+				mcp.Description("Don't dismiss cookie/GDPR consent banners before capture (browser mode only). By default known consent managers and accept buttons inside consent dialogs are clicked and the banner elements removed, so they don't obscure content or pollute extracted text"),
+			),
+			mcp.WithBoolean("lazyLoadScroll",
+				mcp.Description("Scroll through each page before capture, then back to the top, and wait for the images still loading, so lazy-loaded images and content triggered by scrolling are in the saved HTML (browser mode only, default: false). Infinite-scroll pages are scrolled at most 50 steps"),
+			),
+			mcp.WithNumber("scrollStep",
+				mcp.Description("Pixels per lazyLoadScroll step (default: 0 = one viewport)"),
+			),
+			mcp.WithString("scrollDelay",
+				mcp.Description("Pause after each lazyLoadScroll step for content to load, e.g. '500ms' (default: '250ms')"),
+			),
+			mcp.WithString("iframes",
+				mcp.Description("Capture the documents of same-origin iframes, where docs sites and embedded viewers often put their content: off (default), link (queued at the page's depth and saved as pages of their own) or inline (fetched and put in place of the iframe, in a <div data-iframe-src>, so the page's saved HTML and extracted text include them). At most 20 iframes per page"),
+				mcp.Enum("off", "link", "inline"),
+			),
+			mcp.WithArray("iframeHosts",
+				mcp.Description("Cross-origin hosts whose iframes are captured too, *.example.com matching subdomains (browser mode only), e.g. ['viewer.example.com']"),
+			),
+			mcp.WithString("provenance",
+				mcp.Description("Record where and when each saved page came from in its HTML file, for anyone opening the archived file later: off (default), comment (an HTML comment at the top with the source URL, fetch time, crawler version and job ID) or banner (the comment plus a visible banner at the top of the page). Extracted content is not affected"),
+				mcp.Enum("off", "comment", "banner"),
+			),
+			mcp.WithBoolean("contentAddressable",
+				mcp.Description("Content-addressable storage for mirror-style crawls with many identical pages: each distinct HTML or content file is stored once in _blobs/ under its SHA-256, and every URL gets a small <name>.blob pointer file. The index, exports, file reads and the output browser resolve pointers transparently (default: false)"),
+			),
+			mcp.WithString("shadowDom",
+				mcp.Description("Save the content web components render inside open shadow roots, which is otherwise missing from the saved HTML (browser mode only): off (default), declarative (each shadow root as a <template shadowrootmode> inside its host, which browsers render when the page is opened) or flatten (shadow content inlined into its host with slots filled in, best for content extraction)"),
+				mcp.Enum("off", "declarative", "flatten"),
+			),
+			mcp.WithNumber("browserRecycle",
+				mcp.Description("Relaunch the browser after this many pages to reclaim the memory a long-running Chrome leaks, carrying its cookies over (browser mode only, default: never). Crashed tabs or browsers are relaunched regardless and their URL queued once more; relaunches are counted as browserRecycles and browserCrashes in the metrics"),
+			),
+			mcp.WithString("browserPath",
+				mcp.Description("Chrome binary to run, e.g. a downloaded Chrome for Testing (browser mode only, default: a system-installed Chrome found on PATH)"),
+			),
+			mcp.WithNumber("browserMaxMemory",
+				mcp.Description("Relaunch the browser, keeping cookies, when its processes use more than this many MB of resident memory (browser mode on Linux only, default: unlimited). Counted in browserRecycles"),
+			),
+			mcp.WithNumber("browserMaxTabs",
+				mcp.Description("Tabs open at once; fetches wait for a free one (browser mode only, default: unlimited)"),
+			),
+			mcp.WithNumber("browserMaxProcesses",
+				mcp.Description("Chrome processes, renderers included: caps the renderers at this minus 3 and relaunches the browser when it runs more (browser mode only, relaunch on Linux only, at least 4, default: unlimited)"),
+			),
+			mcp.WithArray("pageScripts",
+				mcp.Description("JavaScript snippets run after load on pages whose URL matches a regex, in order (browser mode only), e.g. [{\"pattern\": \"example\\.com/forum\", \"script\": \"document.querySelectorAll('.more').forEach(b => b.click())\"}]. Each script runs as the body of an async function, so it may await; a script that throws is logged and the page is still saved"),
+			),
+			mcp.WithArray("contentFilters",
+				mcp.Description("Clean saved pages by URL pattern, e.g. [{\"pattern\": \"/blog/\", \"keep\": \"main article\", \"remove\": \".ads, .related-posts, .share\"}]. On pages whose URL matches the regex (empty matches all), elements matching the remove CSS selector are stripped, then the body is reduced to the elements matching keep, before the content check, saving and extraction. Links are still followed from the whole page"),
+			),
+			mcp.WithArray("followOnly",
+				mcp.Description("Navigation hubs to traverse without saving, e.g. [{\"pattern\": \"/page/[0-9]+/?$\"}, {\"title\": \"^Category:\"}]. Pages whose URL matches pattern and whose <title> matches title (a rule may give either or both) are fetched and their links followed, but they are never written to disk. They are counted as followOnly in the metrics and recorded as skipped in the URL inventory"),
+			),
+			mcp.WithArray("alerts",
+				mcp.Description("Watch saved pages for patterns or keywords, e.g. [{\"name\": \"aws-key\", \"pattern\": \"AKIA[0-9A-Z]{16}\", \"target\": \"html\"}, {\"name\": \"brand\", \"keywords\": [\"acme\", \"acme corp\"]}]. pattern is a regular expression, keywords match literally ignoring case, and a rule with both fires on either. target is text (default: the extracted text) or html (the stored HTML, including scripts and attributes). Each match is logged as a warning, recorded in alerts.jsonl with its context and sent as a content_alert event, which webhook sinks can list. Read them with scraper_alerts"),
+			),
+			mcp.WithString("pluginsDir",
+				mcp.Description("Directory on the server of JavaScript plugins (*.js, run in file name order) extending the crawl without rebuilding it. A plugin defines any of filterURL(url) (return false to leave a discovered link out, recorded with reason plugin), transform(page) (return the HTML to save instead, after contentFilters) and extract(page) (return an object added to the page's .meta.json under plugins.<name>); page has url, html, title and text, and log(...) writes to the crawl log. Hooks are interrupted after 5s and a failing hook leaves the page as it is. Check a directory with scraper_plugins"),
+			),
 			mcp.WithBoolean("disableContentExtraction",
 				mcp.Description("Disable content extraction (trafilatura) and save raw HTML only"),
 			),
 			mcp.WithBoolean("disableReadability",
 				mcp.Description("Deprecated: use disableContentExtraction instead"),
 			),
+			mcp.WithString("compressOutput",
+				mcp.Description("Compress stored HTML files: 'none' (default), 'gzip' (.gz) or 'zstd' (.zst)"),
+				mcp.Enum("none", "gzip", "zstd"),
+			),
 			mcp.WithObject("pagination",
-				mcp.Description("Click-based pagination settings (browser mode only). Properties: enable (bool), selector (CSS selector), maxClicks (int), waitAfterClick (duration), waitSelector (CSS), stopOnDuplicate (bool)"),
+				mcp.Description("Click-based pagination settings (browser mode only). Properties: enable (bool), selector (CSS selector), maxClicks (int), waitAfterClick (duration), waitSelector (CSS), stopOnDuplicate (bool), harvestUrls (bool: queue the URLs clicks navigate to, e.g. via history.pushState, as regular pages)"),
 			),
 			mcp.WithArray("excludeExtensions",
 				mcp.Description("File extensions to exclude from crawling (e.g. ['.pdf', '.zip', '.png'])"),
 			),
+			mcp.WithArray("allowedHosts",
+				mcp.Description("Only follow links to these hosts; '*.example.com' matches any subdomain of example.com (default: any host, subject to prefixFilter)"),
+			),
+			mcp.WithArray("deniedHosts",
+				mcp.Description("Never follow links to these hosts, matched like allowedHosts and checked first (e.g. ['*.cdn.com', 'tracker.net', 'twitter.com'])"),
+			),
+			mcp.WithBoolean("stateMerge",
+				mcp.Description("When the state file of outputDir was saved for another start URL, merge it: URLs visited under the http/https or www variant of the start URL are rewritten to this one instead of being fetched again, and the new start URL is queued unless visited (default: resume it as is, with a warning)"),
+			),
+			mcp.WithNumber("frontierLimit",
+				mcp.Description("Queued URLs kept in memory; beyond it the rest of the queue spills to sorted files next to the state file and is read back shallowest first, so crawls discovering millions of URLs don't exhaust memory (default: 0 = unlimited)"),
+			),
+			mcp.WithBoolean("externalLinks",
+				mcp.Description("Record the links left out by prefixFilter or the host lists (target, anchor text, referring page) to external_links.jsonl without fetching them, for outbound link audits or as seeds for follow-up crawls. Read them with scraper_external_links (default: false)"),
+			),
+			mcp.WithBoolean("report",
+				mcp.Description("Write report.html to the output directory when the crawl ends: headline stats, throughput and status code charts, content types, largest pages and the errors with the pages linking to them, a shareable summary. Read it with scraper_read_file (default: false)"),
+			),
 			mcp.WithArray("linkSelectors",
 				mcp.Description("CSS selectors to find links (defaults to standard link tags, e.g. ['a.nav-link', '.content a'])"),
 			),
+			mcp.WithArray("tags",
+				mcp.Description("Free-form labels for organizing and filtering jobs (e.g. ['docs', 'team-a'])"),
+			),
+			mcp.WithBoolean("keep",
+				mcp.Description("Exempt this job from automatic retention cleanup"),
+			),
 		),
 		s.handleStart,
 	)
@@ -114,7 +350,31 @@ func (s *Server) registerTools() {
 	// scraper_list - List all jobs
 	s.mcpServer.AddTool(
 		mcp.NewTool("scraper_list",
-			mcp.WithDescription("List all crawl jobs with their current status"),
+			mcp.WithDescription("List crawl jobs with their current status, optionally filtered, sorted and paginated"),
+			mcp.WithString("status",
+				mcp.Description("Only list jobs in this state"),
+				mcp.Enum("pending", "running", "paused", "completed", "stopped", "error", "waiting_for_login"),
+			),
+			mcp.WithString("tag",
+				mcp.Description("Only list jobs carrying this tag (case-insensitive)"),
+			),
+			mcp.WithString("urlContains",
+				mcp.Description("Only list jobs whose URL contains this text (case-insensitive)"),
+			),
+			mcp.WithString("sort",
+				mcp.Description("Sort field: 'created' (default), 'url' or 'status'"),
+				mcp.Enum("created", "url", "status"),
+			),
+			mcp.WithString("order",
+				mcp.Description("Sort order: 'desc' (default for created) or 'asc' (default otherwise)"),
+				mcp.Enum("asc", "desc"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of jobs to return (default: all)"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("Number of matching jobs to skip (default: 0)"),
+			),
 		),
 		s.handleList,
 	)
@@ -167,6 +427,61 @@ func (s *Server) registerTools() {
 		s.handleResume,
 	)
 
+	// scraper_set_workers - Change concurrency of a running job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_set_workers",
+			mcp.WithDescription("Change the number of simultaneous requests of an active concurrent crawl, e.g. to throttle a crawl that is overloading a site"),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to adjust"),
+			),
+			mcp.WithNumber("workers",
+				mcp.Required(),
+				mcp.Description("New number of simultaneous requests (1-100)"),
+			),
+		),
+		s.handleSetWorkers,
+	)
+
+	// scraper_keep - Exempt a job from retention cleanup
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_keep",
+			mcp.WithDescription("Mark a job as kept so automatic retention cleanup never removes it or its files, or clear the mark"),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to mark"),
+			),
+			mcp.WithBoolean("keep",
+				mcp.Description("true to exempt the job from retention (default), false to make it eligible again"),
+			),
+		),
+		s.handleKeep,
+	)
+
+	// scraper_update_config - Adjust runtime settings of a running job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_update_config",
+			mcp.WithDescription("Adjust runtime settings of an active crawl without restarting it. Only the provided settings change."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to adjust"),
+			),
+			mcp.WithString("delay",
+				mcp.Description("New delay between requests (e.g. '2s')"),
+			),
+			mcp.WithNumber("workers",
+				mcp.Description("New number of simultaneous requests (concurrent mode only, 1-100)"),
+			),
+			mcp.WithNumber("maxPages",
+				mcp.Description("New page limit (0 = unlimited)"),
+			),
+			mcp.WithBoolean("verbose",
+				mcp.Description("Enable or disable debug logging"),
+			),
+		),
+		s.handleUpdateConfig,
+	)
+
 	// scraper_metrics - Get real-time metrics
 	s.mcpServer.AddTool(
 		mcp.NewTool("scraper_metrics",
@@ -175,10 +490,194 @@ func (s *Server) registerTools() {
 				mcp.Required(),
 				mcp.Description("Job ID to get metrics for"),
 			),
+			mcp.WithBoolean("includeTimeseries",
+				mcp.Description("Also return the periodic metrics samples recorded over the crawl, for plotting throughput, queue growth and error spikes (default: false)"),
+			),
 		),
 		s.handleMetrics,
 	)
 
+	// scraper_events - Replay recent events of a job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_events",
+			mcp.WithDescription("Get recent events of a crawl job (logs, state changes, progress) from its replay buffer. Pass the returned lastEventId as since on the next call to fetch only new events."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get events for"),
+			),
+			mcp.WithNumber("since",
+				mcp.Description("Only return events with an ID greater than this (default: 0, all buffered events)"),
+			),
+		),
+		s.handleEvents,
+	)
+
+	// scraper_urls - URL inventory of a job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_urls",
+			mcp.WithDescription("Get the URL inventory of a crawl job: every encountered URL with its outcome (saved, skipped, error, blocked, queued), depth, referrer, content type, size and HTTP status. Useful for audits and finding broken links. The same data is written to urls.csv and urls.jsonl in the output directory when the crawl ends."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get the URL inventory for"),
+			),
+			mcp.WithString("status",
+				mcp.Description("Only return URLs with this outcome"),
+				mcp.Enum("saved", "skipped", "error", "blocked", "queued"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of URLs to return (default: 100, 0 for all)"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("Number of URLs to skip (default: 0)"),
+			),
+		),
+		s.handleURLs,
+	)
+
+	// scraper_url_errors - Errored URLs of a job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_url_errors",
+			mcp.WithDescription("List the URLs a crawl job failed on, each with its error class (auth, not-found, rate-limited, client, server, timeout, network, redirect, browser, parse, save or other), reason, HTTP status, depth and the page it was found on (referrer), along with the count of each class. Use it to see why pages failed and which ones are worth retrying with scraper_retry, such as auth errors after confirming a login."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to list the errors of"),
+			),
+			mcp.WithString("class",
+				mcp.Description("Only errors of this class"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum URLs to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleURLErrors,
+	)
+
+	// scraper_retry - Retry errored URLs of a running job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_retry",
+			mcp.WithDescription("Queue errored URLs of a running or paused crawl job again, ahead of the rest of the queue, without restarting it: for example after confirming a login, once a rate limit has passed, or after fixing the site. Retried URLs no longer count as errors unless they fail again. Pass urls, or all (optionally with class). For a finished job use scraper_recrawl with scope failed instead."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID of the running crawl"),
+			),
+			mcp.WithArray("urls",
+				mcp.Description("Errored URLs to retry, as listed by scraper_url_errors"),
+			),
+			mcp.WithBoolean("all",
+				mcp.Description("Retry every errored URL instead of urls (default: false)"),
+			),
+			mcp.WithString("class",
+				mcp.Description("With all: only retry errors of this class"),
+			),
+		),
+		s.handleRetry,
+	)
+
+	// scraper_crawl_graph - Crawl map of a job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_crawl_graph",
+			mcp.WithDescription("Get the crawl map of a job: the URLs discovered first, each with the page it was found on (parent), its depth, outcome (saved, skipped, error, blocked, queued) and HTTP status, forming a tree from the start URL. Use it to see which sections of a site the crawl went into, and where errors or skipped pages cluster. While the crawl runs, pass the returned version as since to get only the nodes added or changed since; omitted counts the URLs past maxNodes. The GUI draws the same map."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get the crawl map for"),
+			),
+			mcp.WithNumber("since",
+				mcp.Description("Version of a previous response; only nodes changed after it are returned (default: 0, the whole map; ignored once the crawl has finished)"),
+			),
+			mcp.WithNumber("maxNodes",
+				mcp.Description("Number of URLs on the map, in discovery order (default: 200, at most 20000)"),
+			),
+		),
+		s.handleCrawlGraph,
+	)
+
+	// scraper_redirects - Redirect mapping of a job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_redirects",
+			mcp.WithDescription("Get the redirects followed by a crawl job (from, to, HTTP status), the URLs abandoned because of a redirect loop or an overly long chain, and the aliases created for permanent (301/308) redirects so later links and crawls request the final URL directly. The same data is written to redirects.json in the output directory when the crawl ends."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get redirects for"),
+			),
+			mcp.WithBoolean("failuresOnly",
+				mcp.Description("Only return redirect loops and overly long chains (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of redirects to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleRedirects,
+	)
+
+	// scraper_api_endpoints - XHR/fetch endpoints discovered in browser mode
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_api_endpoints",
+			mcp.WithDescription("Get the XHR/fetch endpoints called by the pages of a browser mode crawl started with discoverApis, deduplicated by method and URL without query string. Each endpoint lists its response content type and status, the query parameters seen, an example URL, the number of calls and pages, and the first page that called it. Use it to find the JSON APIs behind single-page apps. The same data is written to api_endpoints.jsonl when the crawl ends."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get API endpoints for"),
+			),
+			mcp.WithBoolean("jsonOnly",
+				mcp.Description("Only return endpoints that responded with JSON (default: false)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of endpoints to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleAPIEndpoints,
+	)
+
+	// scraper_external_links - Out-of-scope links recorded without fetching
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_external_links",
+			mcp.WithDescription("Get the external links of a crawl started with externalLinks: links found on crawled pages that prefixFilter or the host lists kept out of the crawl, which are never fetched. Each link lists its target URL, anchor text, the page it was found on and the filter that excluded it (host or prefix), once per target and page. Use it for outbound link audits or to build the seed list of a follow-up crawl. The same data is written to external_links.jsonl when the crawl ends."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get external links for"),
+			),
+			mcp.WithString("host",
+				mcp.Description("Only return links to this host; '*.example.com' matches any subdomain of example.com"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of links to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleExternalLinks,
+	)
+
+	// scraper_alerts - Alert rule matches of saved pages
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_alerts",
+			mcp.WithDescription("Get the alerts of a crawl started with alerts rules: each saved page that matched a rule, with the rule name, the number of matches and the first matches with the text around them. Alerts are written to alerts.jsonl as pages are saved, so they can be read while the crawl runs."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get alerts for"),
+			),
+			mcp.WithString("rule",
+				mcp.Description("Only return alerts of the rule with this name"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of alerts to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleAlerts,
+	)
+
+	// scraper_manifest - Saved pages, readable while the crawl runs
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_manifest",
+			mcp.WithDescription("Get the pages a crawl has saved so far from its pages.ndjson manifest, which is appended to as each page is saved, so downstream processing can start before the crawl ends. Each page lists its URL, stored file path, content file path, SHA-256 hash of the stored HTML, size, title and save time. Pass the returned nextOffset as offset to get only the pages saved since the previous call; done is true once the job has finished and no more pages will follow."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to get saved pages for"),
+			),
+			mcp.WithNumber("offset",
+				mcp.Description("Byte offset in the manifest to read from, the nextOffset of the previous call (default: 0, from the start)"),
+			),
+		),
+		s.handleManifest,
+	)
+
 	// scraper_confirm_login - Confirm browser login
 	s.mcpServer.AddTool(
 		mcp.NewTool("scraper_confirm_login",
@@ -194,7 +693,7 @@ func (s *Server) registerTools() {
 	// scraper_wait - Wait for job completion
 	s.mcpServer.AddTool(
 		mcp.NewTool("scraper_wait",
-			mcp.WithDescription("Wait for a crawl job to complete, returning final metrics. Polls every 2 seconds by default."),
+			mcp.WithDescription("Wait for a crawl job to complete, returning final metrics. Polls every 2 seconds by default. Can return early when progress stalls or a percentage is reached, with the latest metrics, an ETA and snapshots taken while waiting; 'reason' says why it returned."),
 			mcp.WithString("jobId",
 				mcp.Required(),
 				mcp.Description("Job ID to wait for"),
@@ -205,9 +704,495 @@ func (s *Server) registerTools() {
 			mcp.WithNumber("pollIntervalMs",
 				mcp.Description("Polling interval in milliseconds (default: 2000)"),
 			),
+			mcp.WithNumber("stallSeconds",
+				mcp.Description("Return early with reason 'stalled' when a running job processes no URL for this many seconds (default: off)"),
+			),
+			mcp.WithNumber("untilPercentage",
+				mcp.Description("Return early with reason 'percentage' once the job is this far along, 0-100, processed / (processed + queued) (default: off)"),
+			),
+			mcp.WithNumber("snapshotSeconds",
+				mcp.Description("Record a progress snapshot at most every this many seconds and return them in 'snapshots', the last 60 kept (default: off)"),
+			),
 		),
 		s.handleWait,
 	)
+
+	// scraper_export - Export crawled pages as a book
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_export",
+			mcp.WithDescription("Stitch the extracted content of a crawl job into a single HTML file with a table of contents or an EPUB book, with images embedded for offline reading"),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to export"),
+			),
+			mcp.WithString("format",
+				mcp.Description("Export format: 'html' for a single file with a table of contents or 'epub' for an e-book (default: html)"),
+				mcp.Enum("html", "epub"),
+			),
+			mcp.WithString("order",
+				mcp.Description("Chapter order: 'path' by URL hierarchy or 'crawl' by navigation/discovery order (default: path)"),
+				mcp.Enum("path", "crawl"),
+			),
+			mcp.WithString("title",
+				mcp.Description("Book title (defaults to the output directory name)"),
+			),
+			mcp.WithBoolean("embedImages",
+				mcp.Description("Download and embed images so the book works offline (default: true)"),
+			),
+		),
+		s.handleExport,
+	)
+
+	// scraper_site - Generate a static site from a job's output
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_site",
+			mcp.WithDescription("Generate a browsable static site from a crawl job: cleaned per-page HTML, navigation derived from URL paths, and client-side search"),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to generate a site for"),
+			),
+			mcp.WithString("title",
+				mcp.Description("Site title (defaults to the output directory name)"),
+			),
+			mcp.WithBoolean("embedImages",
+				mcp.Description("Download images into the site instead of linking to the original host (default: false)"),
+			),
+		),
+		s.handleSite,
+	)
+
+	// scraper_seo_audit - Audit a job's saved pages for SEO issues
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_seo_audit",
+			mcp.WithDescription("Audit a crawl job's saved pages for SEO issues: missing or duplicate titles and meta descriptions, missing canonical tags, missing/multiple h1 and skipped heading levels, and broken internal links. Writes seo_report.html and seo_report.json to the output directory."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to audit"),
+			),
+			mcp.WithString("issueType",
+				mcp.Description("Only return issues of this type"),
+				mcp.Enum("broken_internal_link", "missing_title", "duplicate_title", "missing_description", "duplicate_description", "missing_canonical", "missing_h1", "multiple_h1", "skipped_heading_level"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of issues to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleSEOAudit,
+	)
+
+	// scraper_accessibility_audit - Audit a job's saved pages for accessibility issues
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_accessibility_audit",
+			mcp.WithDescription("Audit a crawl job's saved pages for basic accessibility issues: images without alt attributes, empty links and buttons, a missing lang attribute and skipped heading levels. Returns per-page counts, pages with the most issues first, and writes accessibility_report.html and accessibility_report.json to the output directory."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to audit"),
+			),
+			mcp.WithString("issueType",
+				mcp.Description("Only return pages with issues of this type"),
+				mcp.Enum("missing_alt", "empty_link", "empty_button", "missing_lang", "heading_order"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of pages to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleAccessibilityAudit,
+	)
+
+	// scraper_duplicates - Cluster a job's saved pages by near-duplicate content
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_duplicates",
+			mcp.WithDescription("Cluster a crawl job's saved pages by near-duplicate content (SimHash of the page text without navigation, header and footer). Each cluster lists its representative URL, its members, whether they share a title, and the query parameters that vary between them - usually faceted navigation or sorting worth excluding from the next crawl. Writes duplicates_report.html and duplicates_report.json to the output directory."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to analyze"),
+			),
+			mcp.WithNumber("threshold",
+				mcp.Description("Maximum number of differing SimHash bits for two pages to be near-duplicates (default: 3, max: 10)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of clusters to return (default: 50, 0 for all)"),
+			),
+		),
+		s.handleDuplicates,
+	)
+
+	// scraper_index - Regenerate a job's index and list the captured pages
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_index",
+			mcp.WithDescription("Regenerate _index.html for a crawl job and list the pages it captured, sorted by URL: source URL, title, a text excerpt and the local path of the raw HTML and extracted content. Paths are relative to the output directory and can be passed to scraper_read_file. Works during a crawl too, listing the pages saved so far."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID to index"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of pages to return (default: 100, 0 for all)"),
+			),
+		),
+		s.handleIndex,
+	)
+
+	// scraper_reprocess - Extract a job's saved pages again
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_reprocess",
+			mcp.WithDescription("Run content extraction again over the raw HTML a finished crawl job saved, with changed settings, and regenerate _index.html - no page is fetched again. Rewrites the .content.html files and the title, author, date and other extracted fields of .meta.json of the pages that change, leaving the raw HTML untouched. Settings not given are taken from the job's config."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID whose output to reprocess"),
+			),
+			mcp.WithBoolean("disableContentExtraction",
+				mcp.Description("Remove the extracted content instead of extracting it again"),
+			),
+			mcp.WithArray("contentFilters",
+				mcp.Description("Content filters applied to each page before extraction, as in scraper_start, e.g. [{\"pattern\": \"/blog/\", \"keep\": \"main article\", \"remove\": \".ads\"}]; an empty array applies none"),
+			),
+			mcp.WithString("pluginsDir",
+				mcp.Description("Directory on the server of JavaScript plugins whose extract(page) hooks fill the plugins field of .meta.json again; an empty string runs none"),
+			),
+		),
+		s.handleReprocess,
+	)
+
+	// scraper_read_file - Read a stored file from a job's output
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_read_file",
+			mcp.WithDescription("Read a file from a crawl job's output directory. Compressed .gz and .zst files are decompressed transparently."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID whose output to read"),
+			),
+			mcp.WithString("path",
+				mcp.Required(),
+				mcp.Description("Path relative to the output directory (e.g. 'docs/intro.content.html'); the uncompressed name works for compressed files"),
+			),
+		),
+		s.handleReadFile,
+	)
+
+	// scraper_list_files - List a directory of a job's output
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_list_files",
+			mcp.WithDescription("List the files and subdirectories of a crawl job's output directory, to find what to read with scraper_read_file. Directories are listed first."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID whose output to list"),
+			),
+			mcp.WithString("path",
+				mcp.Description("Directory relative to the output directory (default: the output directory itself)"),
+			),
+		),
+		s.handleListFiles,
+	)
+
+	// scraper_recrawl - Re-crawl part of a finished job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_recrawl",
+			mcp.WithDescription("Start a child job that fetches again selected URLs of a finished job into the same output directory, without following links. Scope 'failed' retries URLs that errored, 'changed' refetches saved pages and rewrites only those whose HTML changed, 'pattern' refetches URLs matching a regex. The URL inventory keeps the parent's other URLs."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Finished job whose URLs to re-crawl"),
+			),
+			mcp.WithString("scope",
+				mcp.Required(),
+				mcp.Description("Which URLs to re-crawl: 'failed', 'changed' or 'pattern'"),
+				mcp.Enum("failed", "changed", "pattern"),
+			),
+			mcp.WithString("pattern",
+				mcp.Description("URL regex, required for the 'pattern' scope (e.g. '/docs/')"),
+			),
+		),
+		s.handleRecrawl,
+	)
+
+	// scraper_usage - Pages and bytes fetched per API key
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_usage",
+			mcp.WithDescription("Show the pages and bytes fetched by the jobs of each API key today, this month and in total (UTC), with the key's quota and active jobs. Jobs started through MCP or without an API key are charged to 'anonymous'."),
+			mcp.WithString("key",
+				mcp.Description("Only show this API key name (default: all keys)"),
+			),
+		),
+		s.handleUsage,
+	)
+
+	// scraper_audit - Recorded tool calls that changed server state
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_audit",
+			mcp.WithDescription("List the recorded tool calls that changed server state (starting, controlling and deleting jobs, config updates, presets, schedules, secrets), most recent first, with the job and a summary of the arguments (names only, apart from the url). Requires the server to be started with -audit-log."),
+			mcp.WithString("jobId",
+				mcp.Description("Only list calls for this job"),
+			),
+			mcp.WithString("since",
+				mcp.Description("Only list calls from this RFC 3339 time on"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Most recent calls to list (default: 100, 0 = all)"),
+			),
+		),
+		s.handleAudit,
+	)
+
+	// scraper_runtime - Goroutines and heap of the server, per job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_runtime",
+			mcp.WithDescription("Diagnose leaks and contention in a long-running MCP server: goroutine count, heap statistics and the goroutines each job started. A finished job that still has goroutines points to a leak. Start the server with -pprof localhost:6060 for full profiles."),
+		),
+		s.handleRuntime,
+	)
+
+	// scraper_secrets - Manage the encrypted secrets store
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_secrets",
+			mcp.WithDescription("Manage the encrypted secrets store (master key in SCRAPER_SECRETS_KEY). A crawl's proxy and page scripts can refer to a stored value as secret://name, e.g. proxy 'secret://corp-proxy' or a login script typing 'secret://site-password'; it is resolved when the crawl starts and never written to state files or definitions. Values are never returned."),
+			mcp.WithString("action",
+				mcp.Required(),
+				mcp.Description("list the secret names, set one or delete one"),
+				mcp.Enum("list", "set", "delete"),
+			),
+			mcp.WithString("name",
+				mcp.Description("Secret name for set and delete: letters, digits, '.', '_' and '-'"),
+			),
+			mcp.WithString("value",
+				mcp.Description("Value for set"),
+			),
+		),
+		s.handleSecrets,
+	)
+
+	// scraper_selftest - Crawl synthetic sites to validate the installation
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_selftest",
+			mcp.WithDescription("Validate the installation by crawling synthetic sites served on a local port: a full crawl, the depth limit, robots.txt rules, redirects, concurrent workers and resuming a killed crawl from its state file. Takes a few seconds; reports each check with its error if it failed."),
+			mcp.WithArray("checks",
+				mcp.Description("Checks to run (default all): crawl, depth-limit, robots, redirects, concurrency, resume"),
+			),
+		),
+		s.handleSelfTest,
+	)
+
+	// scraper_antibot_test - Report the fingerprint signals a browser crawl leaks
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_antibot_test",
+			mcp.WithDescription("Tune anti-bot settings before a real crawl: open a page in browser mode with the given profile and options and report which fingerprint signals give the browser away (webdriver flag, user agent, plugins, languages, WebGL vendor and renderer, window.chrome, window size), each with the setting that hides it. Needs Chrome; takes a few seconds."),
+			mcp.WithString("url",
+				mcp.Description("Fingerprinting test page to probe (default: a bundled page served on a local port)"),
+			),
+			mcp.WithObject("antiBot",
+				mcp.Description("Anti-bot settings to test, as for scraper_start: profile (off, low, standard or aggressive) plus individual options such as hideWebdriver, spoofPlugins, spoofLanguages, spoofWebGL, rotateUserAgent and randomViewport"),
+			),
+			mcp.WithBoolean("headless",
+				mcp.Description("Run the browser in headless mode (default: true)"),
+			),
+			mcp.WithString("userAgent",
+				mcp.Description("Custom User-Agent when not rotating"),
+			),
+		),
+		s.handleAntiBotTest,
+	)
+
+	// scraper_plan - Preview the links a crawl would queue or filter out
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_plan",
+			mcp.WithDescription("Preview a crawl before starting it: fetch the start page (and the pages above maxDepth) and list every link found as queued or filtered out, with the reason (prefix, host, extension, scheme, robots, selector, depth, invalid). Use it to debug prefixFilter, allowedHosts, deniedHosts, excludeExtensions and linkSelectors; nothing is saved and no job is created."),
+			mcp.WithString("url",
+				mcp.Required(),
+				mcp.Description("Start URL of the crawl to preview"),
+			),
+			mcp.WithString("recipe",
+				mcp.Description("Site recipe whose settings apply where this call leaves them unset (see scraper_recipes)"),
+			),
+			mcp.WithNumber("maxDepth",
+				mcp.Description("Depth of the links to preview (default: 1, the links of the start page); pages above it are fetched too"),
+			),
+			mcp.WithString("depthMode",
+				mcp.Description("What maxDepth counts: links (default) or path"),
+				mcp.Enum("links", "path"),
+			),
+			mcp.WithNumber("maxPages",
+				mcp.Description("Fetch at most this many pages (default: 20)"),
+			),
+			mcp.WithString("delay",
+				mcp.Description("Delay between requests (e.g. '500ms' or '1s')"),
+			),
+			mcp.WithString("prefixFilter",
+				mcp.Description("Only crawl URLs starting with this prefix"),
+			),
+			mcp.WithArray("excludeExtensions",
+				mcp.Description("File extensions to exclude from crawling (e.g. ['pdf', 'zip', 'png'])"),
+			),
+			mcp.WithArray("allowedHosts",
+				mcp.Description("Only follow links to these hosts; '*.example.com' matches any subdomain of example.com (default: any host, subject to prefixFilter)"),
+			),
+			mcp.WithArray("deniedHosts",
+				mcp.Description("Never follow links to these hosts, matched like allowedHosts and checked first (e.g. ['*.cdn.com', 'tracker.net', 'twitter.com'])"),
+			),
+			mcp.WithArray("linkSelectors",
+				mcp.Description("CSS selectors to find links (defaults to all links)"),
+			),
+			mcp.WithString("fetchMode",
+				mcp.Description("Fetch mode: 'http' for fast requests or 'browser' for JavaScript-rendered pages"),
+				mcp.Enum("http", "browser"),
+			),
+			mcp.WithString("userAgent",
+				mcp.Description("Custom User-Agent string"),
+			),
+			mcp.WithBoolean("ignoreRobots",
+				mcp.Description("Ignore robots.txt restrictions"),
+			),
+			mcp.WithBoolean("normalizeUrls",
+				mcp.Description("Normalize URLs for duplicate detection (default: true)"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("Maximum number of links to list (default: 200, 0 = all); the counts cover every link"),
+			),
+		),
+		s.handlePlan,
+	)
+
+	// scraper_fetch_page - Fetch and extract a single page without a job
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_fetch_page",
+			mcp.WithDescription("Fetch one URL, extract its main content and return the title, metadata and content as Markdown or plain text inline. The links on the page are listed but not followed; no job is created and nothing is saved. Use it when you just need one page quickly. Falls back to the whole page when no main content is found (extracted: false)."),
+			mcp.WithString("url",
+				mcp.Required(),
+				mcp.Description("URL of the page to fetch"),
+			),
+			mcp.WithString("recipe",
+				mcp.Description("Site recipe whose settings (fetch mode, content filters, ...) apply where this call leaves them unset (see scraper_recipes)"),
+			),
+			mcp.WithString("fetchMode",
+				mcp.Description("Fetch mode: 'http' for fast requests or 'browser' for JavaScript-rendered pages"),
+				mcp.Enum("http", "browser"),
+			),
+			mcp.WithString("pageLoadWait",
+				mcp.Description("Time to wait after page load for dynamic content (browser mode, e.g. '500ms', '2s')"),
+			),
+			mcp.WithString("userAgent",
+				mcp.Description("Custom User-Agent string"),
+			),
+			mcp.WithBoolean("ignoreRobots",
+				mcp.Description("Ignore robots.txt restrictions"),
+			),
+			mcp.WithString("format",
+				mcp.Description("Content to return: 'markdown' (default), 'text' or 'both'"),
+				mcp.Enum("markdown", "text", "both"),
+			),
+			mcp.WithNumber("maxChars",
+				mcp.Description("Truncate the returned content to this many characters (default: 20000, 0 = no limit)"),
+			),
+			mcp.WithNumber("maxLinks",
+				mcp.Description("Maximum number of links on the page to list (default: 100, 0 = all); totalLinks counts every link"),
+			),
+		),
+		s.handleFetchPage,
+	)
+
+	// scraper_export_definition - Export a job's config for reuse elsewhere
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_export_definition",
+			mcp.WithDescription("Export a job's full crawl configuration as a portable JSON definition that scraper_import_definition (or POST /api/v1/crawl/import) can start again in any environment. Output directory, state file and keep flag are omitted."),
+			mcp.WithString("jobId",
+				mcp.Required(),
+				mcp.Description("Job ID whose configuration to export"),
+			),
+		),
+		s.handleExportDefinition,
+	)
+
+	// scraper_import_definition - Start a job from a definition
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_import_definition",
+			mcp.WithDescription("Create and start a new crawl job from a definition produced by scraper_export_definition. A bare scraper_start-style config object is accepted too."),
+			mcp.WithObject("definition",
+				mcp.Required(),
+				mcp.Description("Job definition object ({version, request: {...}}) or a bare crawl request"),
+			),
+		),
+		s.handleImportDefinition,
+	)
+
+	// scraper_recipes - Crawl settings for well-known kinds of sites
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_recipes",
+			mcp.WithDescription("List or show site recipes: named crawl settings for well-known site software (Discourse forums, MediaWiki, WordPress, Docusaurus, Medium, ...) with link selectors, follow-only rules, content filters, pagination and anti-bot options. Pass a recipe name as recipe to scraper_start, scraper_plan or scraper_fetch_page; settings given in the call override the recipe's. User recipes are loaded from the directories in SCRAPER_RECIPES_DIR (default: recipes in the user config dir) and replace built-in ones of the same name."),
+			mcp.WithString("action",
+				mcp.Description("list (default) or get"),
+				mcp.Enum("list", "get"),
+			),
+			mcp.WithString("name",
+				mcp.Description("Recipe to show with get"),
+			),
+		),
+		s.handleRecipes,
+	)
+
+	// scraper_plugins - JavaScript plugins of a directory
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_plugins",
+			mcp.WithDescription("Load the JavaScript plugins of a directory, as pluginsDir of scraper_start does, and list each with the hooks it defines (filterURL, transform, extract). Fails with the error of the first plugin that does not compile, so use it to check plugins before a crawl."),
+			mcp.WithString("dir",
+				mcp.Required(),
+				mcp.Description("Plugins directory on the server"),
+			),
+		),
+		s.handlePlugins,
+	)
+
+	// scraper_presets - Named crawl settings shared with the GUI, CLI and API
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_presets",
+			mcp.WithDescription("Manage presets: named crawl settings, e.g. tuned for one site, shared with the desktop app, the CLI (-preset) and the API. list, get (a preset's crawl request, usable with scraper_start), save (the config of a job under a name), delete, export (presets bundled into one versioned JSON document for sharing) and import (a bundle or preset; presets saved by older versions are upgraded rather than rejected)."),
+			mcp.WithString("action",
+				mcp.Required(),
+				mcp.Description("What to do"),
+				mcp.Enum("list", "get", "save", "delete", "export", "import"),
+			),
+			mcp.WithString("name",
+				mcp.Description("Preset name for get, save and delete: letters, digits, '-' and '_', at most 50"),
+			),
+			mcp.WithString("jobId",
+				mcp.Description("For save: job whose crawl configuration to save"),
+			),
+			mcp.WithArray("names",
+				mcp.Description("For export: presets to bundle (default all)"),
+			),
+			mcp.WithObject("bundle",
+				mcp.Description("For import: a bundle from export ({version, presets: [...]}) or a single preset file"),
+			),
+			mcp.WithBoolean("overwrite",
+				mcp.Description("For import: replace presets with the same name (default: skip them)"),
+			),
+		),
+		s.handlePresets,
+	)
+
+	// scraper_schedules - Presets run at set times, shared with the GUI, CLI and API
+	s.mcpServer.AddTool(
+		mcp.NewTool("scraper_schedules",
+			mcp.WithDescription("Manage schedules that run a preset at set times, e.g. nightly, shared with the desktop app, the CLI (schedule) and the API. list, add (a preset with a spec: \"hourly\", \"nightly\" (daily 02:00), \"daily HH:MM\", \"weekly <day> HH:MM\" or \"every <duration>\", in local time), remove, enable, disable, run (start a run now, also when disabled; it shows up in scraper_list as a job tagged \"scheduled\") and history (past runs with their job, pages saved and errors, newest first). Schedules run when due only while a process running them is up: the desktop app, scraper schedule daemon, or this server started with -run-schedules."),
+			mcp.WithString("action",
+				mcp.Required(),
+				mcp.Description("What to do"),
+				mcp.Enum("list", "add", "remove", "enable", "disable", "run", "history"),
+			),
+			mcp.WithString("id",
+				mcp.Description("Schedule ID for remove, enable, disable and run; for history, only this schedule's runs"),
+			),
+			mcp.WithString("preset",
+				mcp.Description("For add: preset to run (see scraper_presets)"),
+			),
+			mcp.WithString("spec",
+				mcp.Description("For add: when to run, e.g. nightly, daily 03:30, weekly mon 06:00 or every 6h"),
+			),
+			mcp.WithString("url",
+				mcp.Description("For add: start URL, overriding the preset's"),
+			),
+			mcp.WithNumber("limit",
+				mcp.Description("For history: maximum runs to return (default 50)"),
+			),
+		),
+		s.handleSchedules,
+	)
 }
 
 // Serve starts the MCP server with stdio transport
@@ -215,9 +1200,162 @@ func (s *Server) Serve() error {
 	return server.ServeStdio(s.mcpServer)
 }
 
-// Shutdown gracefully shuts down the server
+// SetRetention applies a retention policy for finished jobs
+func (s *Server) SetRetention(policy api.RetentionPolicy) {
+	s.jobManager.SetRetention(policy)
+}
+
+// SetPresetsDir sets the directory scraper_presets keeps presets in
+func (s *Server) SetPresetsDir(dir string) {
+	s.presetsDir = dir
+}
+
+// SetSchedulesFile sets the file scraper_schedules keeps schedules in
+func (s *Server) SetSchedulesFile(path string) {
+	s.schedulesFile = path
+}
+
+// RunSchedules runs the enabled schedules when due while the server is up
+func (s *Server) RunSchedules() error {
+	scheduler, err := s.schedules()
+	if err != nil {
+		return err
+	}
+	scheduler.Start()
+	return nil
+}
+
+// schedules returns the scheduler of scraper_schedules, creating it on
+// first use
+func (s *Server) schedules() (*api.Scheduler, error) {
+	s.schedulerMu.Lock()
+	defer s.schedulerMu.Unlock()
+	if s.scheduler != nil {
+		return s.scheduler, nil
+	}
+
+	presetsDir := s.presetsDir
+	if presetsDir == "" {
+		dir, err := api.DefaultPresetsDir()
+		if err != nil {
+			return nil, err
+		}
+		presetsDir = dir
+	}
+	path := s.schedulesFile
+	if path == "" {
+		p, err := api.DefaultSchedulesFile()
+		if err != nil {
+			return nil, err
+		}
+		path = p
+	}
+	s.scheduler = api.NewScheduler(api.NewScheduleStore(path), api.NewPresetStore(presetsDir), s.jobManager.ScheduleRunner(api.AnonymousKey))
+	return s.scheduler, nil
+}
+
+// SetHostDelay spaces out requests to each host across all jobs
+func (s *Server) SetHostDelay(delay time.Duration) {
+	s.jobManager.SetHostDelay(delay)
+}
+
+// SetOutputOwner chowns the output of every job to owner ("uid[:gid]",
+// "" = leave it to the server user)
+func (s *Server) SetOutputOwner(owner string) {
+	s.jobManager.SetOutputOwner(owner)
+}
+
+// SetPolitePolicy enforces polite mode on every job (nil turns it off)
+func (s *Server) SetPolitePolicy(policy *crawler.PolitePolicy) {
+	s.jobManager.SetPolitePolicy(policy)
+}
+
+// SetAuditLog records tool calls that change server state in audit (nil
+// turns the audit log off)
+func (s *Server) SetAuditLog(audit *api.AuditLog) {
+	s.jobManager.SetAuditLog(audit)
+}
+
+// auditedTools are the tools that change server state, with the actions
+// that do for tools that also read
+var auditedTools = map[string][]string{
+	"scraper_start":               nil,
+	"scraper_stop":                nil,
+	"scraper_pause":               nil,
+	"scraper_resume":              nil,
+	"scraper_set_workers":         nil,
+	"scraper_keep":                nil,
+	"scraper_update_config":       nil,
+	"scraper_confirm_login":       nil,
+	"scraper_export":              nil,
+	"scraper_site":                nil,
+	"scraper_seo_audit":           nil,
+	"scraper_accessibility_audit": nil,
+	"scraper_duplicates":          nil,
+	"scraper_index":               nil,
+	"scraper_reprocess":           nil,
+	"scraper_recrawl":             nil,
+	"scraper_retry":               nil,
+	"scraper_import_definition":   nil,
+	"scraper_secrets":             {"set", "delete"},
+	"scraper_presets":             {"save", "delete", "import"},
+	"scraper_schedules":           {"add", "remove", "enable", "disable", "run"},
+}
+
+// auditTool records the calls of auditedTools in the audit log, if any, as
+// made by the key "mcp"
+func (s *Server) auditTool(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		audit := s.jobManager.AuditLog()
+		actions, audited := auditedTools[req.Params.Name]
+		args := req.GetArguments()
+		if action, _ := args["action"].(string); audit == nil || !audited || (actions != nil && !slices.Contains(actions, action)) {
+			return next(ctx, req)
+		}
+
+		result, err := next(ctx, req)
+
+		entry := api.AuditEntry{Key: "mcp", Action: req.Params.Name, Summary: api.SummarizeArguments(args)}
+		entry.JobID, _ = args["jobId"].(string)
+		text := ""
+		if result != nil && len(result.Content) > 0 {
+			if content, ok := result.Content[0].(mcp.TextContent); ok {
+				text = content.Text
+			}
+		}
+		switch {
+		case err != nil:
+			entry.Error = err.Error()
+		case result != nil && result.IsError:
+			entry.Error = text
+		case entry.JobID == "":
+			var created struct {
+				JobID string `json:"jobId"`
+			}
+			if json.Unmarshal([]byte(text), &created) == nil {
+				entry.JobID = created.JobID
+			}
+		}
+		if err := audit.Record(entry); err != nil {
+			log.Printf("Audit: %v", err)
+		}
+		return result, err
+	}
+}
+
+// Shutdown checkpoints running crawls so they can be resumed, then stops all jobs
 func (s *Server) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), api.ShutdownCheckpointTimeout)
+	s.jobManager.CheckpointJobs(ctx)
+	cancel()
 	s.jobManager.Shutdown()
+
+	s.schedulerMu.Lock()
+	scheduler := s.scheduler
+	s.schedulerMu.Unlock()
+	if scheduler != nil {
+		scheduler.Stop()
+	}
 }
 
 // GetJobManager returns the job manager for testing
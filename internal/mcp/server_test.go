@@ -3,10 +3,19 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"scraper/internal/api"
+	"scraper/internal/crawler"
 )
 
 func TestNewServer(t *testing.T) {
@@ -50,6 +59,58 @@ func TestHandleList_Empty(t *testing.T) {
 	}
 }
 
+func TestHandleList_Filters(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	for _, req := range []*api.CrawlRequest{
+		{URL: "https://docs.example.com", Tags: []string{"docs"}},
+		{URL: "https://blog.example.com", Tags: []string{"blog"}},
+	} {
+		if _, err := server.jobManager.CreateJob(req); err != nil {
+			t.Fatalf("CreateJob() error = %v", err)
+		}
+	}
+
+	result, err := server.handleList(context.Background(), createCallToolRequest(map[string]interface{}{
+		"tag": "DOCS",
+	}))
+	if err != nil {
+		t.Fatalf("handleList returned error: %v", err)
+	}
+	var output JobListOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Total != 1 || len(output.Jobs) != 1 || output.Jobs[0].URL != "https://docs.example.com" {
+		t.Fatalf("unexpected filtered list: %+v", output)
+	}
+	if len(output.Jobs[0].Tags) != 1 || output.Jobs[0].Tags[0] != "docs" {
+		t.Errorf("expected tags in summary, got %v", output.Jobs[0].Tags)
+	}
+
+	result, err = server.handleList(context.Background(), createCallToolRequest(map[string]interface{}{
+		"limit":  float64(1),
+		"offset": float64(1),
+	}))
+	if err != nil {
+		t.Fatalf("handleList returned error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Total != 2 || len(output.Jobs) != 1 || output.Offset != 1 {
+		t.Errorf("unexpected page: %+v", output)
+	}
+
+	result, _ = server.handleList(context.Background(), createCallToolRequest(map[string]interface{}{
+		"status": "bogus",
+	}))
+	if !result.IsError {
+		t.Error("expected error result for invalid status")
+	}
+}
+
 func TestHandleStart_MissingURL(t *testing.T) {
 	server := NewServer(5)
 	defer server.Shutdown()
@@ -203,7 +264,43 @@ func TestHandleWait_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandleExport_NotFound(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	req := createCallToolRequest(map[string]interface{}{
+		"jobId":  "nonexistent",
+		"format": "epub",
+	})
+
+	result, err := server.handleExport(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleExport returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for nonexistent job")
+	}
+}
+
+func TestHandleSite_NotFound(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	req := createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	})
+
+	result, err := server.handleSite(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleSite returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for nonexistent job")
+	}
+}
+
 func TestHandleWait_Timeout(t *testing.T) {
+	t.Chdir(t.TempDir())
 	server := NewServer(5)
 	defer server.Shutdown()
 
@@ -262,8 +359,52 @@ func TestHandleWait_Timeout(t *testing.T) {
 	server.handleStop(context.Background(), stopReq)
 }
 
+func TestWaitProgress(t *testing.T) {
+	start := time.Now()
+	w := &waitProgress{
+		stallAfter:      10 * time.Second,
+		untilPercentage: 90,
+		snapshotEvery:   5 * time.Second,
+		start:           start,
+		lastChange:      start,
+	}
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+
+	running := &api.MetricsSnapshot{URLsProcessed: 5, QueueSize: 20, PagesPerSecond: 2, Percentage: 20}
+	if reason := w.observe(api.JobStatusRunning, running, at(0)); reason != "" {
+		t.Errorf("observe() = %q while progressing, want \"\"", reason)
+	}
+	// Paused time does not count towards a stall
+	if reason := w.observe(api.JobStatusPaused, running, at(30)); reason != "" {
+		t.Errorf("observe() = %q while paused, want \"\"", reason)
+	}
+	if reason := w.observe(api.JobStatusRunning, running, at(35)); reason != "" {
+		t.Errorf("observe() = %q 5s after resuming, want \"\"", reason)
+	}
+	if reason := w.observe(api.JobStatusRunning, running, at(41)); reason != waitReasonStalled {
+		t.Errorf("observe() = %q after 11s without progress, want %q", reason, waitReasonStalled)
+	}
+	if reason := w.observe(api.JobStatusRunning, &api.MetricsSnapshot{URLsProcessed: 95, QueueSize: 5, Percentage: 95}, at(42)); reason != waitReasonPercentage {
+		t.Errorf("observe() = %q at 95%%, want %q", reason, waitReasonPercentage)
+	}
+
+	// Snapshots at 0, 30, 35 and 41; 42 is within 5s of the last one
+	if len(w.snapshots) != 4 {
+		t.Fatalf("recorded %d snapshots, want 4: %+v", len(w.snapshots), w.snapshots)
+	}
+	if s := w.snapshots[1]; s.ElapsedSeconds != 30 || s.Status != "paused" || s.URLsProcessed != 5 {
+		t.Errorf("snapshot 1 = %+v", s)
+	}
+
+	output := w.output("job-1", api.JobStatusRunning, waitReasonStalled, running, "")
+	if output.EtaSeconds != 10 || output.Metrics == nil || output.Reason != waitReasonStalled {
+		t.Errorf("output() = %+v", output)
+	}
+}
+
 func TestParseAntiBotConfig(t *testing.T) {
 	raw := map[string]interface{}{
+		"profile":              "standard",
 		"hideWebdriver":        true,
 		"spoofPlugins":         true,
 		"naturalMouseMovement": true,
@@ -288,6 +429,142 @@ func TestParseAntiBotConfig(t *testing.T) {
 	if config.Timezone != "America/New_York" {
 		t.Errorf("Expected Timezone to be 'America/New_York', got '%s'", config.Timezone)
 	}
+	if config.Profile != "standard" {
+		t.Errorf("Expected Profile to be 'standard', got '%s'", config.Profile)
+	}
+}
+
+func TestParseGeoConfig(t *testing.T) {
+	raw := map[string]interface{}{
+		"region":      "de",
+		"geolocation": "48.1351,11.5820",
+		"proxy":       "http://{region}.proxy.example.com:8080",
+		"unknown":     true,
+	}
+
+	config := parseGeoConfig(raw)
+
+	if config.Region != "de" {
+		t.Errorf("Expected Region to be 'de', got '%s'", config.Region)
+	}
+	if config.Geolocation != "48.1351,11.5820" {
+		t.Errorf("Expected Geolocation to be '48.1351,11.5820', got '%s'", config.Geolocation)
+	}
+	if config.Proxy != "http://{region}.proxy.example.com:8080" {
+		t.Errorf("Expected Proxy to be kept unresolved, got '%s'", config.Proxy)
+	}
+	if config.AcceptLanguage != "" || config.Locale != "" || config.Timezone != "" {
+		t.Errorf("Expected unset fields to stay empty, got %+v", config)
+	}
+}
+
+func TestParseOutputPermissions(t *testing.T) {
+	raw := map[string]interface{}{
+		"fileMode": "0664",
+		"owner":    "1000:1000",
+		"dirMode":  2775,
+	}
+
+	config := parseOutputPermissions(raw)
+
+	if config.FileMode != "0664" {
+		t.Errorf("Expected FileMode to be '0664', got '%s'", config.FileMode)
+	}
+	if config.Owner != "1000:1000" {
+		t.Errorf("Expected Owner to be '1000:1000', got '%s'", config.Owner)
+	}
+	if config.DirMode != "" {
+		t.Errorf("Expected a non-string DirMode to be ignored, got '%s'", config.DirMode)
+	}
+}
+
+func TestParsePageScripts(t *testing.T) {
+	raw := []interface{}{
+		map[string]interface{}{"pattern": `example\.com/forum`, "script": "document.querySelector('.more').click()"},
+		"not an object",
+		map[string]interface{}{"pattern": ".*"},
+	}
+
+	scripts := parsePageScripts(raw)
+
+	if len(scripts) != 2 {
+		t.Fatalf("Expected 2 page scripts, got %d", len(scripts))
+	}
+	if scripts[0].Pattern != `example\.com/forum` || scripts[0].Script != "document.querySelector('.more').click()" {
+		t.Errorf("Unexpected first page script: %+v", scripts[0])
+	}
+	// Entries without a script are kept so config validation can report them
+	if scripts[1].Pattern != ".*" || scripts[1].Script != "" {
+		t.Errorf("Unexpected second page script: %+v", scripts[1])
+	}
+}
+
+func TestParseContentFilters(t *testing.T) {
+	raw := []interface{}{
+		map[string]interface{}{"pattern": "/blog/", "keep": "main article", "remove": ".ads, .share"},
+		42,
+		map[string]interface{}{"remove": ".related-posts"},
+	}
+
+	filters := parseContentFilters(raw)
+
+	if len(filters) != 2 {
+		t.Fatalf("Expected 2 content filters, got %d", len(filters))
+	}
+	if filters[0].Pattern != "/blog/" || filters[0].Keep != "main article" || filters[0].Remove != ".ads, .share" {
+		t.Errorf("Unexpected first content filter: %+v", filters[0])
+	}
+	if filters[1].Pattern != "" || filters[1].Keep != "" || filters[1].Remove != ".related-posts" {
+		t.Errorf("Unexpected second content filter: %+v", filters[1])
+	}
+}
+
+func TestParseEventSinks(t *testing.T) {
+	raw := []interface{}{
+		map[string]interface{}{"type": "file"},
+		"webhook",
+		map[string]interface{}{"type": "nats", "url": "nats://localhost:4222", "topic": "crawl", "events": []interface{}{"crawl_completed", "error"}},
+		map[string]interface{}{"type": "kafka", "url": "http://localhost:8082", "topic": "pages", "events": []interface{}{"page_saved"}, "payload": "text"},
+		map[string]interface{}{"type": "elasticsearch", "url": "http://localhost:9200", "topic": "pages", "mappings": map[string]interface{}{"properties": map[string]interface{}{}}},
+	}
+
+	sinks := parseEventSinks(raw)
+
+	if len(sinks) != 4 {
+		t.Fatalf("Expected 4 event sinks, got %d", len(sinks))
+	}
+	if sinks[0].Type != crawler.EventSinkFile || sinks[0].URL != "" {
+		t.Errorf("Unexpected first event sink: %+v", sinks[0])
+	}
+	if sinks[1].URL != "nats://localhost:4222" || sinks[1].Topic != "crawl" || len(sinks[1].Events) != 2 || sinks[1].Events[1] != crawler.EventError {
+		t.Errorf("Unexpected second event sink: %+v", sinks[1])
+	}
+	if sinks[2].Payload != crawler.PagePayloadText || sinks[2].Events[0] != crawler.EventPageSaved {
+		t.Errorf("Unexpected third event sink: %+v", sinks[2])
+	}
+	if string(sinks[3].Mappings) != `{"properties":{}}` {
+		t.Errorf("Unexpected fourth event sink mappings: %s", sinks[3].Mappings)
+	}
+}
+
+func TestParseFollowOnlyRules(t *testing.T) {
+	raw := []interface{}{
+		map[string]interface{}{"pattern": "/page/[0-9]+$"},
+		"/category/",
+		map[string]interface{}{"pattern": "/tag/", "title": "^Tag:"},
+	}
+
+	rules := parseFollowOnlyRules(raw)
+
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 follow-only rules, got %d", len(rules))
+	}
+	if rules[0].Pattern != "/page/[0-9]+$" || rules[0].Title != "" {
+		t.Errorf("Unexpected first follow-only rule: %+v", rules[0])
+	}
+	if rules[1].Pattern != "/tag/" || rules[1].Title != "^Tag:" {
+		t.Errorf("Unexpected second follow-only rule: %+v", rules[1])
+	}
 }
 
 func TestConvertMetrics(t *testing.T) {
@@ -342,3 +619,1344 @@ func getResultText(t *testing.T, result *mcp.CallToolResult) string {
 	}
 	return textContent.Text
 }
+
+func TestHandleSetWorkers_NotFound(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	req := createCallToolRequest(map[string]interface{}{
+		"jobId":   "nonexistent",
+		"workers": float64(2),
+	})
+
+	result, err := server.handleSetWorkers(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleSetWorkers returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for nonexistent job")
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.GetJobManager().CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.Emitter.Emit(crawler.CrawlerEvent{Type: crawler.EventCrawlStarted, Timestamp: time.Now()})
+	job.Emitter.Emit(crawler.CrawlerEvent{Type: crawler.EventLogMessage, Timestamp: time.Now(), Data: "hello"})
+
+	result, err := server.handleEvents(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": job.ID,
+		"since": float64(1),
+	}))
+	if err != nil {
+		t.Fatalf("handleEvents returned error: %v", err)
+	}
+
+	var output EventsOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if len(output.Events) != 1 || output.Events[0].Type != string(crawler.EventLogMessage) {
+		t.Errorf("expected only the log event, got %+v", output.Events)
+	}
+	if output.LastEventID != 2 {
+		t.Errorf("LastEventID = %d, want 2", output.LastEventID)
+	}
+
+	result, _ = server.handleEvents(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if !result.IsError {
+		t.Error("Expected error result for nonexistent job")
+	}
+}
+
+func TestHandleReadFile_NotFound(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	req := createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+		"path":  "index.html",
+	})
+
+	result, err := server.handleReadFile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleReadFile returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for nonexistent job")
+	}
+}
+
+func TestHandleListFiles(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	os.MkdirAll(filepath.Join(job.OutputDir, "docs"), 0755)
+	os.WriteFile(filepath.Join(job.OutputDir, "index.html"), []byte("<html></html>"), 0644)
+	os.WriteFile(filepath.Join(job.OutputDir, "docs", "intro.html"), []byte("<p>intro</p>"), 0644)
+
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		wantError bool
+		want      []FileEntry
+	}{
+		{"nonexistent job", map[string]interface{}{"jobId": "nonexistent"}, true, nil},
+		{"root", map[string]interface{}{"jobId": job.ID}, false, []FileEntry{{Name: "docs", Dir: true}, {Name: "index.html", Size: 13}}},
+		{"subdirectory", map[string]interface{}{"jobId": job.ID, "path": "docs"}, false, []FileEntry{{Name: "intro.html", Size: 12}}},
+		{"missing directory", map[string]interface{}{"jobId": job.ID, "path": "missing"}, true, nil},
+		{"escaping path", map[string]interface{}{"jobId": job.ID, "path": "../"}, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleListFiles(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleListFiles returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v: %s", result.IsError, tt.wantError, getResultText(t, result))
+			}
+			if tt.wantError {
+				return
+			}
+			var output ListFilesOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if !reflect.DeepEqual(output.Entries, tt.want) {
+				t.Errorf("entries = %+v, want %+v", output.Entries, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleRecrawl(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	job.Status = api.JobStatusStopped
+	f, err := os.Create(filepath.Join(job.OutputDir, crawler.URLInventoryJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler.WriteURLInventoryJSONL(f, []crawler.URLRecord{
+		{URL: "https://example.com/", Status: crawler.URLStatusSaved},
+	})
+	f.Close()
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"nonexistent job", map[string]interface{}{"jobId": "nonexistent", "scope": "failed"}},
+		{"missing scope", map[string]interface{}{"jobId": job.ID}},
+		{"invalid scope", map[string]interface{}{"jobId": job.ID, "scope": "all"}},
+		{"nothing failed", map[string]interface{}{"jobId": job.ID, "scope": "failed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleRecrawl(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleRecrawl returned error: %v", err)
+			}
+			if !result.IsError {
+				t.Errorf("Expected error result, got %s", getResultText(t, result))
+			}
+		})
+	}
+}
+
+func TestHandleUpdateConfig_NotFound(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	req := createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+		"delay": "2s",
+	})
+
+	result, err := server.handleUpdateConfig(context.Background(), req)
+	if err != nil {
+		t.Fatalf("handleUpdateConfig returned error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("Expected error result for nonexistent job")
+	}
+}
+
+func TestHandleKeep(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	result, err := server.handleKeep(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": job.ID,
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleKeep failed: %v %v", err, result)
+	}
+	var output KeepOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if !output.Keep || !job.ToSummary().Keep {
+		t.Error("keep should default to true")
+	}
+
+	result, _ = server.handleKeep(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if !result.IsError {
+		t.Error("expected error result for unknown job")
+	}
+}
+
+func TestHandleAudit(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	result, _ := server.handleAudit(context.Background(), createCallToolRequest(nil))
+	if !result.IsError {
+		t.Error("expected an error without an audit log")
+	}
+
+	audit, err := api.OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"), api.DefaultAuditMaxSize, api.DefaultAuditMaxFiles)
+	if err != nil {
+		t.Fatalf("OpenAuditLog() error = %v", err)
+	}
+	server.SetAuditLog(audit)
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	call := func(name string, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]interface{}) {
+		req := createCallToolRequest(args)
+		req.Params.Name = name
+		server.auditTool(handler)(context.Background(), req)
+	}
+	call("scraper_keep", server.handleKeep, map[string]interface{}{"jobId": job.ID})
+	call("scraper_keep", server.handleKeep, map[string]interface{}{"jobId": "nonexistent"})
+	call("scraper_get", server.handleGet, map[string]interface{}{"jobId": job.ID})
+	call("scraper_secrets", server.handleSecrets, map[string]interface{}{"action": "list"})
+
+	result, err = server.handleAudit(context.Background(), createCallToolRequest(nil))
+	if err != nil || result.IsError {
+		t.Fatalf("handleAudit failed: %v %v", err, result)
+	}
+	var output AuditOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Total != 2 {
+		t.Fatalf("expected only the two keep calls, got %+v", output.Entries)
+	}
+	failed, kept := output.Entries[0], output.Entries[1]
+	if kept.Action != "scraper_keep" || kept.Key != "mcp" || kept.JobID != job.ID || kept.Error != "" {
+		t.Errorf("unexpected entry: %+v", kept)
+	}
+	if failed.Error == "" {
+		t.Errorf("expected the failed call's error, got %+v", failed)
+	}
+}
+
+func TestHandleExportDefinition(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com", MaxDepth: 2, OutputDir: "/tmp/x"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	result, err := server.handleExportDefinition(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": job.ID,
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleExportDefinition failed: %v %v", err, result)
+	}
+	var def api.JobDefinition
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &def); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if def.Request.URL != "https://example.com" || def.Request.MaxDepth != 2 || def.Request.OutputDir != "" {
+		t.Errorf("unexpected definition: %+v", def.Request)
+	}
+
+	result, _ = server.handleExportDefinition(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if !result.IsError {
+		t.Error("expected error result for unknown job")
+	}
+}
+
+func TestHandleImportDefinition_Invalid(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"missing definition", map[string]interface{}{}},
+		{"missing url", map[string]interface{}{"definition": map[string]interface{}{"version": float64(1), "request": map[string]interface{}{}}}},
+		{"newer version", map[string]interface{}{"definition": `{"version": 9, "request": {"url": "https://example.com"}}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleImportDefinition(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleImportDefinition returned error: %v", err)
+			}
+			if !result.IsError {
+				t.Error("expected error result")
+			}
+		})
+	}
+	if len(server.jobManager.ListJobs()) != 0 {
+		t.Error("no job should be created from an invalid definition")
+	}
+}
+
+func TestHandleURLs(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	f, err := os.Create(filepath.Join(job.OutputDir, crawler.URLInventoryJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler.WriteURLInventoryJSONL(f, []crawler.URLRecord{
+		{URL: "https://example.com/", Status: crawler.URLStatusSaved},
+		{URL: "https://example.com/a", Status: crawler.URLStatusError, HTTPStatus: 500},
+		{URL: "https://example.com/b", Status: crawler.URLStatusBlocked, Reason: "robots.txt"},
+	})
+	f.Close()
+
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		wantError bool
+		wantTotal int
+		wantURLs  int
+	}{
+		{"unknown job", map[string]interface{}{"jobId": "nonexistent"}, true, 0, 0},
+		{"invalid status", map[string]interface{}{"jobId": job.ID, "status": "bogus"}, true, 0, 0},
+		{"all", map[string]interface{}{"jobId": job.ID}, false, 3, 3},
+		{"status filter", map[string]interface{}{"jobId": job.ID, "status": "error"}, false, 1, 1},
+		{"limit", map[string]interface{}{"jobId": job.ID, "limit": float64(2), "offset": float64(2)}, false, 3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleURLs(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleURLs returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v: %s", result.IsError, tt.wantError, getResultText(t, result))
+			}
+			if tt.wantError {
+				return
+			}
+			var output URLsOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if output.Total != tt.wantTotal || len(output.URLs) != tt.wantURLs {
+				t.Errorf("total = %d, urls = %d; want %d, %d", output.Total, len(output.URLs), tt.wantTotal, tt.wantURLs)
+			}
+			if output.Counts["blocked"] != 1 {
+				t.Errorf("counts = %v", output.Counts)
+			}
+		})
+	}
+}
+
+func TestHandleCrawlGraph(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	f, err := os.Create(filepath.Join(job.OutputDir, crawler.URLInventoryJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler.WriteURLInventoryJSONL(f, []crawler.URLRecord{
+		{URL: "https://example.com/", Status: crawler.URLStatusSaved},
+		{URL: "https://example.com/a", Status: crawler.URLStatusError, Depth: 1, Referrer: "https://example.com/", HTTPStatus: 500},
+		{URL: "https://example.com/a/b", Status: crawler.URLStatusQueued, Depth: 2, Referrer: "https://example.com/a"},
+	})
+	f.Close()
+
+	tests := []struct {
+		name        string
+		args        map[string]interface{}
+		wantError   bool
+		wantNodes   int
+		wantOmitted int
+	}{
+		{"unknown job", map[string]interface{}{"jobId": "nonexistent"}, true, 0, 0},
+		{"negative maxNodes", map[string]interface{}{"jobId": job.ID, "maxNodes": float64(-1)}, true, 0, 0},
+		{"all", map[string]interface{}{"jobId": job.ID}, false, 3, 0},
+		{"sampled", map[string]interface{}{"jobId": job.ID, "maxNodes": float64(2)}, false, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleCrawlGraph(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleCrawlGraph returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v: %s", result.IsError, tt.wantError, getResultText(t, result))
+			}
+			if tt.wantError {
+				return
+			}
+			var output CrawlGraphOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if len(output.Nodes) != tt.wantNodes || output.Omitted != tt.wantOmitted || output.Total != 3 {
+				t.Errorf("nodes = %d, omitted = %d, total = %d; want %d, %d, 3", len(output.Nodes), output.Omitted, output.Total, tt.wantNodes, tt.wantOmitted)
+			}
+			if n := output.Nodes[1]; n.Parent != "https://example.com/" || n.Status != "error" || n.HTTPStatus != 500 {
+				t.Errorf("node = %+v", n)
+			}
+		})
+	}
+}
+
+func TestHandleURLErrorsAndRetry(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	f, err := os.Create(filepath.Join(job.OutputDir, crawler.URLInventoryJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler.WriteURLInventoryJSONL(f, []crawler.URLRecord{
+		{URL: "https://example.com/", Status: crawler.URLStatusSaved},
+		{URL: "https://example.com/members", Status: crawler.URLStatusError, Depth: 1, Referrer: "https://example.com/", HTTPStatus: 401, Reason: "HTTP 401"},
+		{URL: "https://example.com/down", Status: crawler.URLStatusError, Depth: 1, Referrer: "https://example.com/", HTTPStatus: 502, Reason: "HTTP 502"},
+	})
+	f.Close()
+
+	result, err := server.handleURLErrors(context.Background(), createCallToolRequest(map[string]interface{}{"jobId": job.ID, "class": "auth"}))
+	if err != nil {
+		t.Fatalf("handleURLErrors returned error: %v", err)
+	}
+	var output URLErrorsOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Total != 1 || output.Errors[0].Class != "auth" || output.Errors[0].Referrer != "https://example.com/" || output.Counts["server"] != 1 {
+		t.Errorf("output = %+v", output)
+	}
+
+	result, _ = server.handleURLErrors(context.Background(), createCallToolRequest(map[string]interface{}{"jobId": job.ID, "class": "bogus"}))
+	if !result.IsError {
+		t.Error("expected an error for an unknown class")
+	}
+
+	// The job is not running, so there is nothing to queue the URLs on
+	result, _ = server.handleRetry(context.Background(), createCallToolRequest(map[string]interface{}{"jobId": job.ID, "urls": []interface{}{"https://example.com/members"}}))
+	if !result.IsError || !strings.Contains(getResultText(t, result), "not active") {
+		t.Errorf("retry on a finished job = %s", getResultText(t, result))
+	}
+	result, _ = server.handleRetry(context.Background(), createCallToolRequest(map[string]interface{}{"jobId": job.ID}))
+	if !result.IsError {
+		t.Error("expected an error without urls or all")
+	}
+}
+
+func TestHandleSEOAudit(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	for name, pageURL := range map[string]string{"a": "https://example.com/a", "b": "https://example.com/b"} {
+		html := `<html><head><title>Same</title></head><body><h1>` + name + `</h1></body></html>`
+		os.WriteFile(filepath.Join(job.OutputDir, name+".html"), []byte(html), 0644)
+		os.WriteFile(filepath.Join(job.OutputDir, name+".meta.json"), []byte(`{"url": "`+pageURL+`"}`), 0644)
+	}
+
+	result, err := server.handleSEOAudit(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected error result for nonexistent job, got %v", err)
+	}
+
+	result, err = server.handleSEOAudit(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId":     job.ID,
+		"issueType": "duplicate_title",
+		"limit":     float64(1),
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleSEOAudit failed: %v %v", err, result)
+	}
+	var output SEOAuditOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Pages != 2 || output.IssueCounts["duplicate_title"] != 2 {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if len(output.Issues) != 1 || output.Issues[0].Type != "duplicate_title" {
+		t.Errorf("issues = %+v, want one duplicate_title", output.Issues)
+	}
+	if output.BrokenLinkCheck {
+		t.Error("broken link check should be off without a URL inventory")
+	}
+}
+
+func TestHandleAccessibilityAudit(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	pages := map[string]string{
+		"a": `<html lang="en"><body><img src="x.png"><a href="/"></a></body></html>`,
+		"b": `<html lang="en"><body><a href="/">Home</a></body></html>`,
+		"c": `<html lang="en"><body><button></button></body></html>`,
+	}
+	for name, html := range pages {
+		os.WriteFile(filepath.Join(job.OutputDir, name+".html"), []byte(html), 0644)
+		os.WriteFile(filepath.Join(job.OutputDir, name+".meta.json"), []byte(`{"url": "https://example.com/`+name+`"}`), 0644)
+	}
+
+	result, err := server.handleAccessibilityAudit(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected error result for nonexistent job, got %v", err)
+	}
+
+	result, err = server.handleAccessibilityAudit(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId":     job.ID,
+		"issueType": "missing_alt",
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleAccessibilityAudit failed: %v %v", err, result)
+	}
+	var output AccessibilityAuditOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Pages != 3 || output.PagesAffected != 2 || output.TotalIssues != 3 {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if len(output.PageDetails) != 1 || output.PageDetails[0].URL != "https://example.com/a" {
+		t.Errorf("pageDetails = %+v, want only page a", output.PageDetails)
+	}
+}
+
+func TestHandleRedirects(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	data := `{"redirects": [{"from": "https://example.com/a", "to": "https://example.com/b", "status": 308},
+			{"from": "https://example.com/c", "to": "https://example.com/d", "status": 302}],
+		"failures": [{"url": "https://example.com/loop", "reason": "redirect loop",
+			"chain": [{"from": "https://example.com/loop", "to": "https://example.com/loop2", "status": 302}]}],
+		"aliases": {"https://example.com/a": "https://example.com/b"}}`
+	os.WriteFile(filepath.Join(job.OutputDir, crawler.RedirectsFile), []byte(data), 0644)
+
+	tests := []struct {
+		name          string
+		args          map[string]interface{}
+		wantError     bool
+		wantRedirects int
+	}{
+		{"unknown job", map[string]interface{}{"jobId": "nonexistent"}, true, 0},
+		{"all", map[string]interface{}{"jobId": job.ID}, false, 2},
+		{"limit", map[string]interface{}{"jobId": job.ID, "limit": float64(1)}, false, 1},
+		{"failures only", map[string]interface{}{"jobId": job.ID, "failuresOnly": true}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleRedirects(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleRedirects returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v", result.IsError, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			var output RedirectsOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if output.TotalRedirects != 2 || len(output.Redirects) != tt.wantRedirects {
+				t.Errorf("got %d of %d redirects, want %d", len(output.Redirects), output.TotalRedirects, tt.wantRedirects)
+			}
+			if len(output.Failures) != 1 || output.Failures[0].Chain[0].Permanent {
+				t.Errorf("failures = %+v", output.Failures)
+			}
+			if len(output.Redirects) > 0 && !output.Redirects[0].Permanent {
+				t.Error("308 redirect should be permanent")
+			}
+		})
+	}
+}
+
+func TestHandleAPIEndpoints(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	data := `{"method": "GET", "url": "https://api.example.com/items", "content_type": "application/json", "status": 200, "query_params": ["page"], "example": "https://api.example.com/items?page=1", "calls": 3, "pages": 2, "first_page": "https://example.com/"}
+{"method": "GET", "url": "https://example.com/fragment", "content_type": "text/html", "status": 200, "example": "https://example.com/fragment", "calls": 1, "pages": 1, "first_page": "https://example.com/"}
+`
+	os.WriteFile(filepath.Join(job.OutputDir, crawler.APIEndpointsFile), []byte(data), 0644)
+
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		wantError bool
+		wantTotal int
+		wantCount int
+	}{
+		{"missing jobId", map[string]interface{}{}, true, 0, 0},
+		{"unknown job", map[string]interface{}{"jobId": "nonexistent"}, true, 0, 0},
+		{"all", map[string]interface{}{"jobId": job.ID}, false, 2, 2},
+		{"json only", map[string]interface{}{"jobId": job.ID, "jsonOnly": true}, false, 1, 1},
+		{"limit", map[string]interface{}{"jobId": job.ID, "limit": float64(1)}, false, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleAPIEndpoints(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleAPIEndpoints returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v", result.IsError, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			var output APIEndpointsOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if output.Total != tt.wantTotal || len(output.Endpoints) != tt.wantCount {
+				t.Errorf("got %d of %d endpoints, want %d of %d", len(output.Endpoints), output.Total, tt.wantCount, tt.wantTotal)
+			}
+			if ep := output.Endpoints[0]; ep.ContentType != "application/json" || ep.Calls != 3 || len(ep.QueryParams) != 1 {
+				t.Errorf("unexpected first endpoint: %+v", ep)
+			}
+		})
+	}
+}
+
+func TestHandleExternalLinks(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	data := `{"url": "https://docs.partner.com/api", "text": "Partner API", "referrer": "https://example.com/", "reason": "host"}
+{"url": "https://github.com/example", "text": "GitHub", "referrer": "https://example.com/", "reason": "prefix"}
+`
+	os.WriteFile(filepath.Join(job.OutputDir, crawler.ExternalLinksFile), []byte(data), 0644)
+
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		wantError bool
+		wantTotal int
+		wantCount int
+	}{
+		{"missing jobId", map[string]interface{}{}, true, 0, 0},
+		{"unknown job", map[string]interface{}{"jobId": "nonexistent"}, true, 0, 0},
+		{"all", map[string]interface{}{"jobId": job.ID}, false, 2, 2},
+		{"host", map[string]interface{}{"jobId": job.ID, "host": "docs.partner.com"}, false, 1, 1},
+		{"limit", map[string]interface{}{"jobId": job.ID, "limit": float64(1)}, false, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleExternalLinks(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleExternalLinks returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v", result.IsError, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			var output ExternalLinksOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if output.Total != tt.wantTotal || len(output.Links) != tt.wantCount {
+				t.Errorf("got %d of %d links, want %d of %d", len(output.Links), output.Total, tt.wantCount, tt.wantTotal)
+			}
+			if link := output.Links[0]; link.Text != "Partner API" || link.Reason != "host" {
+				t.Errorf("unexpected first link: %+v", link)
+			}
+		})
+	}
+}
+
+func TestHandleManifest(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	first := `{"url":"https://example.com/","path":"index.html","content_path":"index.content.html","hash":"ab","size":10,"title":"Home","timestamp":"2026-01-02T03:04:05Z"}` + "\n"
+	second := `{"url":"https://example.com/docs","path":"docs.html","hash":"cd","size":20,"timestamp":"2026-01-02T03:04:06Z"}` + "\n"
+	os.WriteFile(filepath.Join(job.OutputDir, crawler.ManifestFile), []byte(first+second), 0644)
+
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		wantError bool
+		wantPages int
+	}{
+		{"missing jobId", map[string]interface{}{}, true, 0},
+		{"unknown job", map[string]interface{}{"jobId": "nonexistent"}, true, 0},
+		{"negative offset", map[string]interface{}{"jobId": job.ID, "offset": float64(-1)}, true, 0},
+		{"all", map[string]interface{}{"jobId": job.ID}, false, 2},
+		{"since offset", map[string]interface{}{"jobId": job.ID, "offset": float64(len(first))}, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := server.handleManifest(context.Background(), createCallToolRequest(tt.args))
+			if err != nil {
+				t.Fatalf("handleManifest returned error: %v", err)
+			}
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v, want %v", result.IsError, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			var output ManifestOutput
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if len(output.Pages) != tt.wantPages || output.NextOffset != int64(len(first+second)) || output.Done {
+				t.Errorf("got %d pages, next offset %d, done %v", len(output.Pages), output.NextOffset, output.Done)
+			}
+			if last := output.Pages[len(output.Pages)-1]; last.URL != "https://example.com/docs" || last.Hash != "cd" {
+				t.Errorf("unexpected last page: %+v", last)
+			}
+		})
+	}
+}
+func TestHandleIndex(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	os.MkdirAll(filepath.Join(job.OutputDir, "docs"), 0755)
+	os.WriteFile(filepath.Join(job.OutputDir, "docs", "intro.html"), []byte("<html></html>"), 0644)
+	os.WriteFile(filepath.Join(job.OutputDir, "docs", "intro.content.html"), []byte("<p>Getting started</p>"), 0644)
+	os.WriteFile(filepath.Join(job.OutputDir, "docs", "intro.meta.json"), []byte(`{"url": "https://example.com/docs/intro", "title": "Intro", "content_file": "docs/intro.content.html", "content_extracted": true}`), 0644)
+
+	result, err := server.handleIndex(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected error result for nonexistent job, got %v", err)
+	}
+
+	result, err = server.handleIndex(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": job.ID,
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleIndex failed: %v %v", err, result)
+	}
+	var output IndexOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	want := IndexPage{URL: "https://example.com/docs/intro", Title: "Intro", Excerpt: "Getting started", Path: "docs/intro.html", ContentPath: "docs/intro.content.html"}
+	if output.Total != 1 || len(output.Pages) != 1 || output.Pages[0] != want {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if _, err := os.Stat(output.IndexFile); err != nil {
+		t.Errorf("index not written: %v", err)
+	}
+}
+
+func TestHandleReprocess(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com", DisableContentExtraction: true})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	job.Status = api.JobStatusCompleted
+	text := strings.Repeat("A sentence long enough for content extraction to keep it. ", 10)
+	os.WriteFile(filepath.Join(job.OutputDir, "a.html"), []byte(`<html><head><title>A</title></head><body><article><p>`+text+`</p></article></body></html>`), 0644)
+	os.WriteFile(filepath.Join(job.OutputDir, "a.meta.json"), []byte(`{"url": "https://example.com/a", "content_extracted": false}`), 0644)
+
+	result, err := server.handleReprocess(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected error result for nonexistent job, got %v", err)
+	}
+
+	result, err = server.handleReprocess(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId":                    job.ID,
+		"disableContentExtraction": false,
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleReprocess failed: %v %v", err, result)
+	}
+	var output ReprocessOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Pages != 1 || output.Extracted != 1 || output.Changed != 1 {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if _, err := os.Stat(filepath.Join(job.OutputDir, "a.content.html")); err != nil {
+		t.Errorf("content file not written: %v", err)
+	}
+}
+
+func TestHandleDuplicates(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	job.OutputDir = t.TempDir()
+	shared := "<p>Our catalogue lists every product we sell with prices, sizes and colours so you can compare them side by side before ordering anything.</p>"
+	pages := map[string]string{
+		"a":     shared,
+		"a-red": shared,
+		"b":     "<p>Shipping is free on all orders above fifty euros and takes between two and four working days to most addresses across the continent.</p>",
+	}
+	urls := map[string]string{
+		"a":     "https://example.com/shop",
+		"a-red": "https://example.com/shop?colour=red",
+		"b":     "https://example.com/shipping",
+	}
+	for name, body := range pages {
+		os.WriteFile(filepath.Join(job.OutputDir, name+".html"), []byte("<html><body>"+body+"</body></html>"), 0644)
+		os.WriteFile(filepath.Join(job.OutputDir, name+".meta.json"), []byte(`{"url": "`+urls[name]+`"}`), 0644)
+	}
+
+	result, err := server.handleDuplicates(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": "nonexistent",
+	}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected error result for nonexistent job, got %v", err)
+	}
+
+	result, err = server.handleDuplicates(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId":     job.ID,
+		"threshold": float64(11),
+	}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected error result for out of range threshold, got %v", err)
+	}
+
+	result, err = server.handleDuplicates(context.Background(), createCallToolRequest(map[string]interface{}{
+		"jobId": job.ID,
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleDuplicates failed: %v %v", err, result)
+	}
+	var output DuplicatesOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if output.Pages != 3 || output.DuplicatePages != 1 || output.TotalClusters != 1 {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if len(output.Clusters) != 1 || output.Clusters[0].Representative != "https://example.com/shop" {
+		t.Fatalf("clusters = %+v, want the shop cluster", output.Clusters)
+	}
+	if got := output.Clusters[0].VaryingParams; len(got) != 1 || got[0] != "colour" {
+		t.Errorf("varyingParams = %v, want [colour]", got)
+	}
+}
+
+func TestHandleUsage(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	if _, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com"}); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	result, err := server.handleUsage(context.Background(), createCallToolRequest(map[string]interface{}{"key": api.AnonymousKey}))
+	if err != nil {
+		t.Fatalf("handleUsage() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleUsage() returned error: %s", getResultText(t, result))
+	}
+
+	var output UsageOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if len(output.Keys) != 1 || output.Keys[0].Key != api.AnonymousKey || output.Keys[0].ActiveJobs != 1 {
+		t.Errorf("unexpected usage: %+v", output)
+	}
+}
+
+func TestHandleRuntime(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	result, err := server.handleRuntime(context.Background(), createCallToolRequest(map[string]interface{}{}))
+	if err != nil {
+		t.Fatalf("handleRuntime() error = %v", err)
+	}
+
+	var output api.RuntimeResponse
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if output.Goroutines == 0 || output.Heap.Sys == 0 || output.Jobs == nil {
+		t.Errorf("unexpected runtime: %+v", output)
+	}
+}
+
+func TestHandleSelfTest(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	result, err := server.handleSelfTest(context.Background(), createCallToolRequest(map[string]interface{}{
+		"checks": []interface{}{"crawl", "redirects"},
+	}))
+	if err != nil {
+		t.Fatalf("handleSelfTest() error = %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleSelfTest() returned error: %s", getResultText(t, result))
+	}
+
+	var output SelfTestOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if !output.Passed || len(output.Results) != 2 {
+		t.Errorf("unexpected self-test output: %+v", output)
+	}
+
+	result, _ = server.handleSelfTest(context.Background(), createCallToolRequest(map[string]interface{}{
+		"checks": []interface{}{"nope"},
+	}))
+	if !result.IsError {
+		t.Error("expected an error for an unknown check")
+	}
+}
+
+func TestHandleAntiBotTestValidation(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	for _, args := range []map[string]interface{}{
+		{"antiBot": map[string]interface{}{"profile": "paranoid"}},
+		{"url": "ftp://example.com"},
+	} {
+		result, err := server.handleAntiBotTest(context.Background(), createCallToolRequest(args))
+		if err != nil {
+			t.Fatalf("handleAntiBotTest() error = %v", err)
+		}
+		if !result.IsError {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestHandlePlan(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a class="nav" href="/a">A</a><a href="/b">B</a><a href="/c.pdf">C</a></body></html>`)
+	}))
+	defer site.Close()
+	t.Chdir(t.TempDir())
+
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	result, err := server.handlePlan(context.Background(), createCallToolRequest(map[string]interface{}{
+		"url":               site.URL + "/",
+		"linkSelectors":     []interface{}{"a.nav", "a[href$='.pdf']"},
+		"excludeExtensions": []interface{}{"pdf"},
+		"ignoreRobots":      true,
+		"limit":             float64(2),
+	}))
+	if err != nil {
+		t.Fatalf("handlePlan returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handlePlan failed: %s", getResultText(t, result))
+	}
+	var output PlanOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	want := map[string]int{crawler.LinkFilterExtension: 1, crawler.LinkFilterSelector: 1}
+	if output.Queued != 1 || output.TotalLinks != 3 || len(output.Links) != 2 || !reflect.DeepEqual(output.Filtered, want) {
+		t.Errorf("unexpected output: %+v", output)
+	}
+
+	result, err = server.handlePlan(context.Background(), createCallToolRequest(map[string]interface{}{}))
+	if err != nil || !result.IsError {
+		t.Errorf("expected an error result without url, got %v", err)
+	}
+}
+
+func TestHandleFetchPage(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>Changelog</title></head><body><article><h1>Changelog</h1><p>%s</p><a href="/v1">v1</a> <a href="/v2">v2</a></article></body></html>`,
+			strings.Repeat("Version two adds incremental crawls and faster exports. ", 8))
+	}))
+	defer site.Close()
+	t.Chdir(t.TempDir())
+
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	result, err := server.handleFetchPage(context.Background(), createCallToolRequest(map[string]interface{}{
+		"url":          site.URL + "/changelog",
+		"ignoreRobots": true,
+		"format":       "both",
+		"maxChars":     float64(40),
+		"maxLinks":     float64(1),
+	}))
+	if err != nil {
+		t.Fatalf("handleFetchPage returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("handleFetchPage failed: %s", getResultText(t, result))
+	}
+	var output FetchPageOutput
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+	if output.Title != "Changelog" || !output.Truncated || len([]rune(output.Markdown)) != 40 || len([]rune(output.Text)) != 40 || output.Words == 0 {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if output.TotalLinks != 2 || len(output.Links) != 1 || output.Links[0].URL != site.URL+"/v1" {
+		t.Errorf("expected the first of 2 links, got %d: %+v", output.TotalLinks, output.Links)
+	}
+
+	result, err = server.handleFetchPage(context.Background(), createCallToolRequest(map[string]interface{}{
+		"url":          site.URL + "/changelog",
+		"ignoreRobots": true,
+	}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleFetchPage failed: %v", err)
+	}
+	output = FetchPageOutput{}
+	json.Unmarshal([]byte(getResultText(t, result)), &output)
+	if output.Text != "" || !strings.HasPrefix(output.Markdown, "# Changelog") || output.Truncated {
+		t.Errorf("default format should return the whole Markdown only: %+v", output)
+	}
+
+	for _, args := range []map[string]interface{}{{}, {"url": site.URL, "format": "html"}} {
+		result, err = server.handleFetchPage(context.Background(), createCallToolRequest(args))
+		if err != nil || !result.IsError {
+			t.Errorf("expected an error result for %v, got %v", args, err)
+		}
+	}
+}
+
+func TestHandleSecrets(t *testing.T) {
+	t.Setenv(crawler.SecretsFileEnv, filepath.Join(t.TempDir(), "secrets.enc"))
+	t.Setenv(crawler.SecretsKeyEnv, "master")
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	call := func(args map[string]interface{}) (SecretsOutput, bool) {
+		result, err := server.handleSecrets(context.Background(), createCallToolRequest(args))
+		if err != nil {
+			t.Fatalf("handleSecrets() error = %v", err)
+		}
+		var output SecretsOutput
+		if !result.IsError {
+			if err := json.Unmarshal([]byte(getResultText(t, result)), &output); err != nil {
+				t.Fatalf("failed to parse output: %v", err)
+			}
+		}
+		return output, result.IsError
+	}
+
+	if output, isErr := call(map[string]interface{}{"action": "set", "name": "site-login", "value": "hunter2"}); isErr || len(output.Names) != 1 {
+		t.Fatalf("unexpected set output: %+v", output)
+	}
+	if output, isErr := call(map[string]interface{}{"action": "list"}); isErr || len(output.Names) != 1 || output.Names[0] != "site-login" {
+		t.Errorf("unexpected list output: %+v", output)
+	}
+	if _, isErr := call(map[string]interface{}{"action": "set", "value": "x"}); !isErr {
+		t.Error("expected an error for set without a name")
+	}
+	if output, isErr := call(map[string]interface{}{"action": "delete", "name": "site-login"}); isErr || len(output.Names) != 0 {
+		t.Errorf("unexpected delete output: %+v", output)
+	}
+	if _, isErr := call(map[string]interface{}{"action": "delete", "name": "site-login"}); !isErr {
+		t.Error("expected an error for deleting an unknown secret")
+	}
+}
+
+func TestHandlePresets(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+	server.SetPresetsDir(t.TempDir())
+
+	call := func(args map[string]interface{}) (string, bool) {
+		result, err := server.handlePresets(context.Background(), createCallToolRequest(args))
+		if err != nil {
+			t.Fatalf("handlePresets() error = %v", err)
+		}
+		return getResultText(t, result), result.IsError
+	}
+
+	job, err := server.jobManager.CreateJob(&api.CrawlRequest{URL: "https://example.com", MaxDepth: 2, OutputDir: "/tmp/x"})
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	text, isErr := call(map[string]interface{}{"action": "save", "name": "example", "jobId": job.ID})
+	if isErr {
+		t.Fatalf("save failed: %s", text)
+	}
+	var p api.Preset
+	json.Unmarshal([]byte(text), &p)
+	if p.Request.URL != "https://example.com" || p.Request.MaxDepth != 2 || p.Request.OutputDir != "" {
+		t.Errorf("unexpected preset: %+v", p)
+	}
+	if _, isErr := call(map[string]interface{}{"action": "save", "jobId": job.ID}); !isErr {
+		t.Error("expected an error for save without a name")
+	}
+
+	text, isErr = call(map[string]interface{}{"action": "export"})
+	var bundle api.PresetBundle
+	if err := json.Unmarshal([]byte(text), &bundle); isErr || err != nil || len(bundle.Presets) != 1 {
+		t.Fatalf("unexpected export: %s", text)
+	}
+
+	if _, isErr := call(map[string]interface{}{"action": "delete", "name": "example"}); isErr {
+		t.Fatal("delete failed")
+	}
+	// Bundles are accepted as objects or as JSON text
+	text, isErr = call(map[string]interface{}{"action": "import", "bundle": text})
+	var result api.PresetImportResult
+	if err := json.Unmarshal([]byte(text), &result); isErr || err != nil || len(result.Imported) != 1 {
+		t.Fatalf("unexpected import: %s", text)
+	}
+	text, isErr = call(map[string]interface{}{"action": "import", "bundle": map[string]interface{}{"name": "example", "url": "https://v1.example.com"}})
+	json.Unmarshal([]byte(text), &result)
+	if isErr || len(result.Skipped) != 1 || result.Upgraded != 1 {
+		t.Errorf("expected the existing preset to be skipped: %s", text)
+	}
+
+	text, isErr = call(map[string]interface{}{"action": "list"})
+	var list api.PresetsResponse
+	if err := json.Unmarshal([]byte(text), &list); isErr || err != nil || len(list.Presets) != 1 || list.Presets[0].Name != "example" {
+		t.Errorf("unexpected list: %s", text)
+	}
+	if _, isErr := call(map[string]interface{}{"action": "get", "name": "missing"}); !isErr {
+		t.Error("expected an error for a missing preset")
+	}
+}
+
+func TestHandleSchedules(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+	presetsDir := t.TempDir()
+	server.SetPresetsDir(presetsDir)
+	server.SetSchedulesFile(filepath.Join(t.TempDir(), "schedules.json"))
+	if err := api.NewPresetStore(presetsDir).Save(api.NewPreset("docs", api.CrawlRequest{URL: "https://docs.example.com"})); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	call := func(args map[string]interface{}) (string, bool) {
+		result, err := server.handleSchedules(context.Background(), createCallToolRequest(args))
+		if err != nil {
+			t.Fatalf("handleSchedules() error = %v", err)
+		}
+		return getResultText(t, result), result.IsError
+	}
+
+	if _, isErr := call(map[string]interface{}{"action": "add", "preset": "missing", "spec": "nightly"}); !isErr {
+		t.Error("expected an error for a missing preset")
+	}
+	if _, isErr := call(map[string]interface{}{"action": "add", "preset": "docs", "spec": "now and then"}); !isErr {
+		t.Error("expected an error for an invalid spec")
+	}
+	text, isErr := call(map[string]interface{}{"action": "add", "preset": "docs", "spec": "weekly mon 06:00"})
+	if isErr {
+		t.Fatalf("add failed: %s", text)
+	}
+	var sched api.Schedule
+	json.Unmarshal([]byte(text), &sched)
+	if sched.ID == "" || !sched.Enabled || sched.NextRun.Weekday() != time.Monday {
+		t.Errorf("unexpected schedule: %+v", sched)
+	}
+
+	text, _ = call(map[string]interface{}{"action": "disable", "id": sched.ID})
+	json.Unmarshal([]byte(text), &sched)
+	if sched.Enabled {
+		t.Errorf("expected the schedule to be disabled: %s", text)
+	}
+	if _, isErr := call(map[string]interface{}{"action": "run"}); !isErr {
+		t.Error("expected an error for run without an id")
+	}
+
+	text, _ = call(map[string]interface{}{"action": "list"})
+	var list api.SchedulesResponse
+	json.Unmarshal([]byte(text), &list)
+	if list.Running || len(list.Schedules) != 1 {
+		t.Errorf("unexpected list: %s", text)
+	}
+	text, isErr = call(map[string]interface{}{"action": "history", "id": sched.ID})
+	if isErr || !strings.Contains(text, `"runs": []`) {
+		t.Errorf("expected an empty history, got %s", text)
+	}
+
+	text, _ = call(map[string]interface{}{"action": "remove", "id": sched.ID})
+	json.Unmarshal([]byte(text), &list)
+	if len(list.Schedules) != 0 {
+		t.Errorf("expected the schedule to be removed: %s", text)
+	}
+}
+
+func TestHandlePlugins(t *testing.T) {
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "no-login.js"), []byte(`function filterURL(url) { return url.indexOf("/login") < 0; }`), 0644)
+	result, err := server.handlePlugins(context.Background(), createCallToolRequest(map[string]interface{}{"dir": dir}))
+	if err != nil || result.IsError {
+		t.Fatalf("handlePlugins() failed: %v %v", err, result)
+	}
+	var list api.PluginsResponse
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &list); err != nil || len(list.Plugins) != 1 || list.Plugins[0].Hooks[0] != crawler.PluginHookFilterURL {
+		t.Fatalf("unexpected list: %s", getResultText(t, result))
+	}
+
+	os.WriteFile(filepath.Join(dir, "broken.js"), []byte(`function extract(page) {`), 0644)
+	result, _ = server.handlePlugins(context.Background(), createCallToolRequest(map[string]interface{}{"dir": dir}))
+	if !result.IsError || !strings.Contains(getResultText(t, result), "plugin broken") {
+		t.Errorf("expected the compile error of the broken plugin, got %s", getResultText(t, result))
+	}
+
+	result, _ = server.handleStart(context.Background(), createCallToolRequest(map[string]interface{}{"url": "https://example.com", "pluginsDir": dir}))
+	if !result.IsError {
+		t.Error("expected scraper_start to reject a broken plugin")
+	}
+}
+
+func TestHandleRecipes(t *testing.T) {
+	t.Setenv(api.RecipesDirEnv, t.TempDir())
+	server := NewServer(5)
+	defer server.Shutdown()
+
+	result, err := server.handleRecipes(context.Background(), createCallToolRequest(map[string]interface{}{}))
+	if err != nil || result.IsError {
+		t.Fatalf("handleRecipes() failed: %v %v", err, result)
+	}
+	var list api.RecipesResponse
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &list); err != nil || len(list.Recipes) == 0 {
+		t.Fatalf("unexpected list: %s", getResultText(t, result))
+	}
+
+	result, _ = server.handleRecipes(context.Background(), createCallToolRequest(map[string]interface{}{"action": "get", "name": "wordpress-blog"}))
+	var recipe api.Recipe
+	if err := json.Unmarshal([]byte(getResultText(t, result)), &recipe); result.IsError || err != nil || len(recipe.Request.FollowOnly) == 0 {
+		t.Errorf("unexpected recipe: %s", getResultText(t, result))
+	}
+
+	result, _ = server.handleRecipes(context.Background(), createCallToolRequest(map[string]interface{}{"action": "get", "name": "missing"}))
+	if !result.IsError {
+		t.Error("expected an error for an unknown recipe")
+	}
+
+	result, _ = server.handleStart(context.Background(), createCallToolRequest(map[string]interface{}{"url": "https://example.com", "recipe": "missing"}))
+	if !result.IsError {
+		t.Error("expected scraper_start to reject an unknown recipe")
+	}
+}
@@ -3,11 +3,16 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"scraper/internal/api"
+	"scraper/internal/crawler"
 )
 
 // handleStart handles the scraper_start tool
@@ -24,14 +29,41 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 	crawlReq := &api.CrawlRequest{
 		URL: url,
 	}
+	if recipe, ok := args["recipe"].(string); ok {
+		crawlReq.Recipe = recipe
+	}
 
 	// Optional parameters
 	if maxDepth, ok := args["maxDepth"].(float64); ok {
 		crawlReq.MaxDepth = int(maxDepth)
 	}
+	if depthMode, ok := args["depthMode"].(string); ok {
+		crawlReq.DepthMode = depthMode
+	}
+	if maxPages, ok := args["maxPages"].(float64); ok {
+		crawlReq.MaxPages = int(maxPages)
+	}
+	if maxRuntime, ok := args["maxRuntime"].(string); ok {
+		crawlReq.MaxRuntime = maxRuntime
+	}
+	if maxErrors, ok := args["maxConsecutiveErrors"].(float64); ok {
+		crawlReq.MaxConsecutiveErrors = int(maxErrors)
+	}
+	if urlTimeout, ok := args["urlTimeout"].(string); ok {
+		crawlReq.URLTimeout = urlTimeout
+	}
+	if stopPattern, ok := args["stopPattern"].(string); ok {
+		crawlReq.StopPattern = stopPattern
+	}
+	if maxHTMLSize, ok := args["maxHtmlSize"].(float64); ok {
+		crawlReq.MaxHTMLSize = int64(maxHTMLSize)
+	}
 	if concurrent, ok := args["concurrent"].(bool); ok {
 		crawlReq.Concurrent = concurrent
 	}
+	if workers, ok := args["workers"].(float64); ok {
+		crawlReq.Workers = int(workers)
+	}
 	if delay, ok := args["delay"].(string); ok {
 		crawlReq.Delay = delay
 	}
@@ -41,6 +73,12 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 	if stateFile, ok := args["stateFile"].(string); ok {
 		crawlReq.StateFile = stateFile
 	}
+	if stateMerge, ok := args["stateMerge"].(bool); ok {
+		crawlReq.StateMerge = stateMerge
+	}
+	if frontierLimit, ok := args["frontierLimit"].(float64); ok {
+		crawlReq.FrontierLimit = int(frontierLimit)
+	}
 	if verbose, ok := args["verbose"].(bool); ok {
 		crawlReq.Verbose = verbose
 	}
@@ -59,18 +97,69 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 	if userAgent, ok := args["userAgent"].(string); ok {
 		crawlReq.UserAgent = userAgent
 	}
+	if pool, ok := args["userAgentPool"].(string); ok {
+		crawlReq.UserAgentPool = pool
+	}
+	if userAgentsRaw, ok := args["userAgents"].([]interface{}); ok {
+		crawlReq.UserAgents = toStringSlice(userAgentsRaw)
+	}
+	if sticky, ok := args["stickyUserAgent"].(bool); ok {
+		crawlReq.StickyUserAgent = sticky
+	}
+	if warmUp, ok := args["warmUp"].(bool); ok {
+		crawlReq.WarmUp = warmUp
+	}
+	if warmUpPages, ok := args["warmUpPages"].(float64); ok {
+		crawlReq.WarmUpPages = int(warmUpPages)
+	}
+	if policy, ok := args["refererPolicy"].(string); ok {
+		crawlReq.RefererPolicy = policy
+	}
 	if ignoreRobots, ok := args["ignoreRobots"].(bool); ok {
 		crawlReq.IgnoreRobots = ignoreRobots
 	}
 	if minContent, ok := args["minContent"].(float64); ok {
 		crawlReq.MinContentLength = int(minContent)
 	}
+	if maxContent, ok := args["maxContent"].(float64); ok {
+		crawlReq.MaxContentLength = int(maxContent)
+	}
+	if minWords, ok := args["minWords"].(float64); ok {
+		crawlReq.MinWords = int(minWords)
+	}
+	if maxWords, ok := args["maxWords"].(float64); ok {
+		crawlReq.MaxWords = int(maxWords)
+	}
+	if maxLinkDensity, ok := args["maxLinkDensity"].(float64); ok {
+		crawlReq.MaxLinkDensity = maxLinkDensity
+	}
+	if indexInterval, ok := args["indexInterval"].(float64); ok {
+		interval := int(indexInterval)
+		crawlReq.IndexInterval = &interval
+	}
+	if metricsInterval, ok := args["metricsInterval"].(string); ok {
+		crawlReq.MetricsInterval = metricsInterval
+	}
 
 	// Handle antiBot settings
 	if antiBotRaw, ok := args["antiBot"].(map[string]interface{}); ok {
 		crawlReq.AntiBot = parseAntiBotConfig(antiBotRaw)
 	}
 
+	// Handle geo settings
+	if geoRaw, ok := args["geo"].(map[string]interface{}); ok {
+		crawlReq.Geo = parseGeoConfig(geoRaw)
+	}
+
+	// Handle output permissions
+	if permissionsRaw, ok := args["permissions"].(map[string]interface{}); ok {
+		crawlReq.Permissions = parseOutputPermissions(permissionsRaw)
+	}
+
+	if faultsRaw, ok := args["faults"].(map[string]interface{}); ok {
+		crawlReq.Faults = parseFaultConfig(faultsRaw)
+	}
+
 	// Handle URL normalization settings
 	if normalizeURLs, ok := args["normalizeUrls"].(bool); ok {
 		crawlReq.NormalizeURLs = &normalizeURLs
@@ -83,12 +172,129 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 	if pageLoadWait, ok := args["pageLoadWait"].(string); ok {
 		crawlReq.PageLoadWait = pageLoadWait
 	}
+	if harMode, ok := args["harMode"].(string); ok {
+		crawlReq.HARMode = harMode
+	}
+	if cassette, ok := args["cassette"].(string); ok {
+		crawlReq.Cassette = cassette
+	}
+	if cassetteFile, ok := args["cassetteFile"].(string); ok {
+		crawlReq.CassetteFile = cassetteFile
+	}
+	if notify, ok := args["notify"].(string); ok {
+		crawlReq.Notify = notify
+	}
+	if traceDecisions, ok := args["traceDecisions"].(string); ok {
+		crawlReq.TraceDecisions = traceDecisions
+	}
+	if resultsDB, ok := args["resultsDb"].(string); ok {
+		crawlReq.ResultsDB = resultsDB
+	}
+	if discoverAPIs, ok := args["discoverApis"].(bool); ok {
+		crawlReq.DiscoverAPIs = discoverAPIs
+	}
+	if keepCookieBanners, ok := args["keepCookieBanners"].(bool); ok {
+		crawlReq.KeepCookieBanners = keepCookieBanners
+	}
+	if lazyLoadScroll, ok := args["lazyLoadScroll"].(bool); ok {
+		crawlReq.LazyLoadScroll = lazyLoadScroll
+	}
+	if scrollStep, ok := args["scrollStep"].(float64); ok {
+		crawlReq.ScrollStep = int(scrollStep)
+	}
+	if scrollDelay, ok := args["scrollDelay"].(string); ok {
+		crawlReq.ScrollDelay = scrollDelay
+	}
+	if shadowDOM, ok := args["shadowDom"].(string); ok {
+		crawlReq.ShadowDOM = shadowDOM
+	}
+	if browserRecycle, ok := args["browserRecycle"].(float64); ok {
+		crawlReq.BrowserRecycle = int(browserRecycle)
+	}
+	if browserPath, ok := args["browserPath"].(string); ok {
+		crawlReq.BrowserPath = browserPath
+	}
+	if maxMemory, ok := args["browserMaxMemory"].(float64); ok {
+		crawlReq.BrowserMaxMemory = int(maxMemory)
+	}
+	if maxTabs, ok := args["browserMaxTabs"].(float64); ok {
+		crawlReq.BrowserMaxTabs = int(maxTabs)
+	}
+	if maxProcesses, ok := args["browserMaxProcesses"].(float64); ok {
+		crawlReq.BrowserMaxProcesses = int(maxProcesses)
+	}
+	if provenance, ok := args["provenance"].(string); ok {
+		crawlReq.Provenance = provenance
+	}
+	if contentAddressable, ok := args["contentAddressable"].(bool); ok {
+		crawlReq.ContentAddressable = contentAddressable
+	}
+	if iframes, ok := args["iframes"].(string); ok {
+		crawlReq.Iframes = iframes
+	}
+	if iframeHostsRaw, ok := args["iframeHosts"].([]interface{}); ok {
+		crawlReq.IframeHosts = toStringSlice(iframeHostsRaw)
+	}
+	if pageScriptsRaw, ok := args["pageScripts"].([]interface{}); ok {
+		crawlReq.PageScripts = parsePageScripts(pageScriptsRaw)
+	}
+	if filtersRaw, ok := args["contentFilters"].([]interface{}); ok {
+		crawlReq.ContentFilters = parseContentFilters(filtersRaw)
+	}
+	if followOnlyRaw, ok := args["followOnly"].([]interface{}); ok {
+		crawlReq.FollowOnly = parseFollowOnlyRules(followOnlyRaw)
+	}
+	if alertsRaw, ok := args["alerts"].([]interface{}); ok {
+		crawlReq.Alerts = parseAlertRules(alertsRaw)
+	}
+	if pluginsDir, ok := args["pluginsDir"].(string); ok {
+		crawlReq.PluginsDir = pluginsDir
+	}
+	if sinksRaw, ok := args["eventSinks"].([]interface{}); ok {
+		crawlReq.EventSinks = parseEventSinks(sinksRaw)
+	}
+	if tracingRaw, ok := args["tracing"].(map[string]interface{}); ok {
+		crawlReq.Tracing = parseTracingConfig(tracingRaw)
+	}
+	if embeddingsRaw, ok := args["embeddings"].(map[string]interface{}); ok {
+		crawlReq.Embeddings = parseEmbeddingsConfig(embeddingsRaw)
+	}
+	if enrichmentRaw, ok := args["enrichment"].(map[string]interface{}); ok {
+		crawlReq.Enrichment = parseEnrichmentConfig(enrichmentRaw)
+	}
+	if postSaveCommand, ok := args["postSaveCommand"].(string); ok {
+		crawlReq.PostSaveCommand = postSaveCommand
+	}
+	if postSaveConcurrency, ok := args["postSaveConcurrency"].(float64); ok {
+		crawlReq.PostSaveConcurrency = int(postSaveConcurrency)
+	}
+	if postSaveTimeout, ok := args["postSaveTimeout"].(string); ok {
+		crawlReq.PostSaveTimeout = postSaveTimeout
+	}
+	if postSaveOnFailure, ok := args["postSaveOnFailure"].(string); ok {
+		crawlReq.PostSaveOnFailure = postSaveOnFailure
+	}
+	if waybackFallback, ok := args["waybackFallback"].(bool); ok {
+		crawlReq.WaybackFallback = waybackFallback
+	}
+	if waybackSave, ok := args["waybackSave"].(bool); ok {
+		crawlReq.WaybackSave = waybackSave
+	}
+	if waybackSaveKey, ok := args["waybackSaveKey"].(string); ok {
+		crawlReq.WaybackSaveKey = waybackSaveKey
+	}
+	if waybackSaveInterval, ok := args["waybackSaveInterval"].(string); ok {
+		crawlReq.WaybackSaveInterval = waybackSaveInterval
+	}
 	if disableContentExtraction, ok := args["disableContentExtraction"].(bool); ok {
 		crawlReq.DisableContentExtraction = disableContentExtraction
 	}
 	if disableReadability, ok := args["disableReadability"].(bool); ok {
 		crawlReq.DisableReadability = disableReadability
 	}
+	if compressOutput, ok := args["compressOutput"].(string); ok {
+		crawlReq.CompressOutput = compressOutput
+	}
 
 	// Handle pagination settings
 	if paginationRaw, ok := args["pagination"].(map[string]interface{}); ok {
@@ -99,9 +305,31 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 	if excludeExtRaw, ok := args["excludeExtensions"].([]interface{}); ok {
 		crawlReq.ExcludeExtensions = toStringSlice(excludeExtRaw)
 	}
+	if allowedHostsRaw, ok := args["allowedHosts"].([]interface{}); ok {
+		crawlReq.AllowedHosts = toStringSlice(allowedHostsRaw)
+	}
+	if deniedHostsRaw, ok := args["deniedHosts"].([]interface{}); ok {
+		crawlReq.DeniedHosts = toStringSlice(deniedHostsRaw)
+	}
+	if externalLinks, ok := args["externalLinks"].(bool); ok {
+		crawlReq.ExternalLinks = externalLinks
+	}
+	if report, ok := args["report"].(bool); ok {
+		crawlReq.Report = report
+	}
 	if linkSelectorsRaw, ok := args["linkSelectors"].([]interface{}); ok {
 		crawlReq.LinkSelectors = toStringSlice(linkSelectorsRaw)
 	}
+	if tagsRaw, ok := args["tags"].([]interface{}); ok {
+		crawlReq.Tags = toStringSlice(tagsRaw)
+	}
+	if keep, ok := args["keep"].(bool); ok {
+		crawlReq.Keep = keep
+	}
+
+	if err := api.ValidateCrawlRequest(crawlReq); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	// Create job
 	job, err := s.jobManager.CreateJob(crawlReq)
@@ -125,6 +353,7 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 		Status:    string(job.GetStatus()),
 		Message:   fmt.Sprintf("Crawl job started for %s", url),
 		OutputDir: outputDir,
+		Warnings:  job.GetWarnings(),
 	}
 
 	return resultJSON(output)
@@ -132,7 +361,34 @@ func (s *Server) handleStart(ctx context.Context, req mcp.CallToolRequest) (*mcp
 
 // handleList handles the scraper_list tool
 func (s *Server) handleList(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	jobs := s.jobManager.ListJobs()
+	args := req.GetArguments()
+
+	var opts api.JobListOptions
+	if status, ok := args["status"].(string); ok {
+		opts.Status = api.JobStatus(status)
+	}
+	if tag, ok := args["tag"].(string); ok {
+		opts.Tag = tag
+	}
+	if urlContains, ok := args["urlContains"].(string); ok {
+		opts.URLContains = urlContains
+	}
+	if sortField, ok := args["sort"].(string); ok {
+		opts.Sort = sortField
+	}
+	if order, ok := args["order"].(string); ok {
+		opts.Order = order
+	}
+	if limit, ok := args["limit"].(float64); ok {
+		opts.Limit = int(limit)
+	}
+	if offset, ok := args["offset"].(float64); ok {
+		opts.Offset = int(offset)
+	}
+	jobs, total, err := s.jobManager.QueryJobs(opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
 	summaries := make([]JobSummary, 0, len(jobs))
 	for _, job := range jobs {
@@ -142,12 +398,15 @@ func (s *Server) handleList(ctx context.Context, req mcp.CallToolRequest) (*mcp.
 			URL:       summary.URL,
 			Status:    string(summary.Status),
 			CreatedAt: summary.CreatedAt,
+			Tags:      summary.Tags,
+			Keep:      summary.Keep,
 		})
 	}
 
 	output := JobListOutput{
-		Jobs:  summaries,
-		Total: len(summaries),
+		Jobs:   summaries,
+		Total:  total,
+		Offset: opts.Offset,
 	}
 
 	return resultJSON(output)
@@ -173,11 +432,20 @@ func (s *Server) handleGet(ctx context.Context, req mcp.CallToolRequest) (*mcp.C
 		CreatedAt:       details.CreatedAt,
 		StartedAt:       details.StartedAt,
 		CompletedAt:     details.CompletedAt,
+		StopReason:      details.StopReason,
 		WaitingForLogin: details.WaitingForLogin,
+		Workers:         details.Workers,
+		SSEClients:      details.SSEClients,
+		Warnings:        details.Warnings,
 	}
 
 	if details.Config != nil {
 		output.OutputDir = details.Config.OutputDir
+		output.Tags = details.Config.Tags
+		output.Keep = details.Config.Keep
+	}
+	if outputDir := job.GetOutputDir(); outputDir != "" {
+		output.OutputDir = outputDir
 	}
 
 	if details.Metrics != nil {
@@ -251,184 +519,1728 @@ func (s *Server) handleResume(ctx context.Context, req mcp.CallToolRequest) (*mc
 	return resultJSON(output)
 }
 
-// handleMetrics handles the scraper_metrics tool
-func (s *Server) handleMetrics(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// handleSetWorkers handles the scraper_set_workers tool
+func (s *Server) handleSetWorkers(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	jobID, err := req.RequireString("jobId")
 	if err != nil {
 		return mcp.NewToolResultError("jobId is required"), nil
 	}
 
-	job, err := s.jobManager.GetJob(jobID)
+	workers, err := req.RequireFloat("workers")
 	if err != nil {
-		return mcp.NewToolResultError(err.Error()), nil
+		return mcp.NewToolResultError("workers is required"), nil
 	}
 
-	output := MetricsOutput{
-		JobID:  jobID,
-		Status: string(job.GetStatus()),
+	if err := s.jobManager.SetJobWorkers(jobID, int(workers)); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	metrics := job.GetMetrics()
-	if metrics != nil {
-		output.Metrics = convertMetrics(metrics)
+	output := WorkersOutput{
+		JobID:   jobID,
+		Workers: int(workers),
+		Message: "Worker count updated",
 	}
 
 	return resultJSON(output)
 }
 
-// handleConfirmLogin handles the scraper_confirm_login tool
-func (s *Server) handleConfirmLogin(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// handleKeep handles the scraper_keep tool
+func (s *Server) handleKeep(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	jobID, err := req.RequireString("jobId")
 	if err != nil {
 		return mcp.NewToolResultError("jobId is required"), nil
 	}
 
-	if err := s.jobManager.ConfirmLogin(jobID); err != nil {
+	keep := true
+	if v, ok := req.GetArguments()["keep"].(bool); ok {
+		keep = v
+	}
+
+	if err := s.jobManager.SetJobKeep(jobID, keep); err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	output := StatusOutput{
+	message := "Job exempt from retention cleanup"
+	if !keep {
+		message = "Job subject to retention cleanup"
+	}
+	output := KeepOutput{
 		JobID:   jobID,
-		Status:  "running",
-		Message: "Login confirmed, crawl continuing",
+		Keep:    keep,
+		Message: message,
 	}
 
 	return resultJSON(output)
 }
 
-// handleWait handles the scraper_wait tool
-func (s *Server) handleWait(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// handleUpdateConfig handles the scraper_update_config tool
+func (s *Server) handleUpdateConfig(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	jobID, err := req.RequireString("jobId")
 	if err != nil {
 		return mcp.NewToolResultError("jobId is required"), nil
 	}
 
-	// Parse optional parameters
-	timeoutSeconds := 300 // default 5 minutes
-	if timeout, ok := req.GetArguments()["timeoutSeconds"].(float64); ok {
-		timeoutSeconds = int(timeout)
+	args := req.GetArguments()
+	patch := &api.ConfigPatchRequest{}
+	if delay, ok := args["delay"].(string); ok {
+		patch.Delay = &delay
 	}
-
-	pollIntervalMs := 2000 // default 2 seconds
-	if interval, ok := req.GetArguments()["pollIntervalMs"].(float64); ok {
-		pollIntervalMs = int(interval)
+	if workers, ok := args["workers"].(float64); ok {
+		n := int(workers)
+		patch.Workers = &n
+	}
+	if maxPages, ok := args["maxPages"].(float64); ok {
+		n := int(maxPages)
+		patch.MaxPages = &n
+	}
+	if verbose, ok := args["verbose"].(bool); ok {
+		patch.Verbose = &verbose
 	}
 
-	pollInterval := time.Duration(pollIntervalMs) * time.Millisecond
-	timeout := time.Duration(timeoutSeconds) * time.Second
-	deadline := time.Now().Add(timeout)
-	startTime := time.Now()
-
-	// Poll until completion or timeout
-	for {
-		job, err := s.jobManager.GetJob(jobID)
-		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
-		}
-
-		status := job.GetStatus()
+	current, err := s.jobManager.UpdateJobConfig(jobID, patch)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-		// Check for terminal states
-		if isTerminalStatus(status) {
-			details := job.ToDetails()
-			output := WaitOutput{
-				JobID:         jobID,
-				Status:        string(status),
-				WaitedSeconds: int(time.Since(startTime).Seconds()),
-			}
+	output := UpdateConfigOutput{
+		JobID:    jobID,
+		Delay:    current.Delay,
+		Workers:  current.Workers,
+		MaxPages: current.MaxPages,
+		Verbose:  current.Verbose,
+		Message:  "Configuration updated",
+	}
 
-			if details.Config != nil {
-				output.OutputDir = details.Config.OutputDir
-			}
+	return resultJSON(output)
+}
 
-			if details.Metrics != nil {
-				output.FinalMetrics = convertMetrics(details.Metrics)
-			}
+// handleMetrics handles the scraper_metrics tool
+func (s *Server) handleMetrics(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
 
-			if job.Error != nil {
-				output.Error = job.Error.Error()
-			}
+	job, err := s.jobManager.GetJob(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-			return resultJSON(output)
-		}
+	output := MetricsOutput{
+		JobID:  jobID,
+		Status: string(job.GetStatus()),
+	}
 
-		// Check timeout
-		if time.Now().After(deadline) {
-			output := WaitOutput{
-				JobID:         jobID,
-				Status:        string(status),
-				Error:         "timeout waiting for job completion",
-				WaitedSeconds: int(time.Since(startTime).Seconds()),
-			}
-			return resultJSON(output)
-		}
+	metrics := job.GetMetrics()
+	if metrics != nil {
+		output.Metrics = convertMetrics(metrics)
+	}
 
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			output := WaitOutput{
-				JobID:         jobID,
-				Status:        string(status),
-				Error:         "wait cancelled",
-				WaitedSeconds: int(time.Since(startTime).Seconds()),
-			}
-			return resultJSON(output)
-		case <-time.After(pollInterval):
-			// Continue polling
+	if includeTimeseries, ok := req.GetArguments()["includeTimeseries"].(bool); ok && includeTimeseries {
+		if series := job.GetMetricsTimeSeries(); series != nil {
+			output.IntervalSeconds = series.IntervalSeconds
+			output.Timeseries = convertSamples(series.Samples)
 		}
 	}
-}
-
-// Helper functions
 
-// isTerminalStatus checks if a job status is terminal (completed, stopped, or error)
-func isTerminalStatus(status api.JobStatus) bool {
-	return status == api.JobStatusCompleted ||
-		status == api.JobStatusStopped ||
-		status == api.JobStatusError
+	return resultJSON(output)
 }
 
-// parseAntiBotConfig parses anti-bot settings from a map
-func parseAntiBotConfig(raw map[string]interface{}) *api.AntiBotConfig {
-	config := &api.AntiBotConfig{}
-
-	// Browser Fingerprint Modifications
-	if v, ok := raw["hideWebdriver"].(bool); ok {
-		config.HideWebdriver = v
+// handleURLs handles the scraper_urls tool
+func (s *Server) handleURLs(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
 	}
-	if v, ok := raw["spoofPlugins"].(bool); ok {
-		config.SpoofPlugins = v
+
+	args := req.GetArguments()
+	query := api.URLInventoryQuery{Limit: 100}
+	if v, ok := args["status"].(string); ok {
+		query.Status = v
 	}
-	if v, ok := raw["spoofLanguages"].(bool); ok {
-		config.SpoofLanguages = v
+	if v, ok := args["limit"].(float64); ok {
+		query.Limit = int(v)
 	}
-	if v, ok := raw["spoofWebGL"].(bool); ok {
-		config.SpoofWebGL = v
+	if v, ok := args["offset"].(float64); ok {
+		query.Offset = int(v)
 	}
-	if v, ok := raw["addCanvasNoise"].(bool); ok {
-		config.AddCanvasNoise = v
+
+	resp, _, err := s.jobManager.QueryURLInventory(jobID, query)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	// Human Behavior Simulation
-	if v, ok := raw["naturalMouseMovement"].(bool); ok {
-		config.NaturalMouseMovement = v
+	output := URLsOutput{
+		JobID:  resp.JobID,
+		Total:  resp.Total,
+		Offset: resp.Offset,
+		Counts: resp.Counts,
+		URLs:   make([]URLRecord, len(resp.URLs)),
 	}
-	if v, ok := raw["randomTypingDelays"].(bool); ok {
-		config.RandomTypingDelays = v
+	for i, r := range resp.URLs {
+		output.URLs[i] = URLRecord(r)
 	}
-	if v, ok := raw["naturalScrolling"].(bool); ok {
-		config.NaturalScrolling = v
+	return resultJSON(output)
+}
+
+// handleCrawlGraph handles the scraper_crawl_graph tool
+func (s *Server) handleCrawlGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
 	}
-	if v, ok := raw["randomActionDelays"].(bool); ok {
-		config.RandomActionDelays = v
+
+	args := req.GetArguments()
+	var since uint64
+	if v, ok := args["since"].(float64); ok && v > 0 {
+		since = uint64(v)
 	}
-	if v, ok := raw["randomClickOffset"].(bool); ok {
-		config.RandomClickOffset = v
+	maxNodes := 200
+	if v, ok := args["maxNodes"].(float64); ok {
+		maxNodes = int(v)
 	}
 
-	// Browser Properties
-	if v, ok := raw["rotateUserAgent"].(bool); ok {
-		config.RotateUserAgent = v
+	resp, err := s.jobManager.GetJobGraph(jobID, since, maxNodes)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := CrawlGraphOutput{
+		JobID:   resp.JobID,
+		Version: resp.Version,
+		Total:   resp.Total,
+		Omitted: resp.Omitted,
+		Nodes:   make([]GraphNode, len(resp.Nodes)),
+	}
+	for i, n := range resp.Nodes {
+		output.Nodes[i] = GraphNode(n)
+	}
+	return resultJSON(output)
+}
+
+// handleURLErrors handles the scraper_url_errors tool
+func (s *Server) handleURLErrors(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	class, _ := args["class"].(string)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	resp, err := s.jobManager.GetJobErrors(jobID, class, limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := URLErrorsOutput{
+		JobID:  resp.JobID,
+		Total:  resp.Total,
+		Counts: resp.Counts,
+		Errors: make([]URLError, len(resp.Errors)),
+	}
+	for i, e := range resp.Errors {
+		output.Errors[i] = URLError(e)
+	}
+	return resultJSON(output)
+}
+
+// handleRetry handles the scraper_retry tool
+func (s *Server) handleRetry(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	retryReq := api.RetryRequest{}
+	if urlsRaw, ok := args["urls"].([]interface{}); ok {
+		retryReq.URLs = toStringSlice(urlsRaw)
+	}
+	retryReq.All, _ = args["all"].(bool)
+	retryReq.Class, _ = args["class"].(string)
+
+	resp, err := s.jobManager.RetryJobErrors(jobID, &retryReq)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	job, _ := s.jobManager.GetJob(jobID)
+	output := StatusOutput{
+		JobID:   resp.JobID,
+		Status:  string(job.GetStatus()),
+		Message: fmt.Sprintf("Queued %d errored URLs again", resp.Queued),
+	}
+	return resultJSON(output)
+}
+
+// handleRedirects handles the scraper_redirects tool
+func (s *Server) handleRedirects(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	failuresOnly, _ := args["failuresOnly"].(bool)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	resp, err := s.jobManager.GetJobRedirects(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := RedirectsOutput{
+		JobID:          resp.JobID,
+		TotalRedirects: len(resp.Redirects),
+		Redirects:      []Redirect{},
+		Failures:       []RedirectFailure{},
+	}
+	if !failuresOnly {
+		output.Aliases = resp.Aliases
+		for _, r := range resp.Redirects {
+			if limit > 0 && len(output.Redirects) >= limit {
+				break
+			}
+			output.Redirects = append(output.Redirects, Redirect{From: r.From, To: r.To, Status: r.Status, Permanent: r.Permanent()})
+		}
+	}
+	for _, f := range resp.Failures {
+		failure := RedirectFailure{URL: f.URL, Reason: f.Reason, Chain: make([]Redirect, len(f.Chain))}
+		for i, r := range f.Chain {
+			failure.Chain[i] = Redirect{From: r.From, To: r.To, Status: r.Status, Permanent: r.Permanent()}
+		}
+		output.Failures = append(output.Failures, failure)
+	}
+	return resultJSON(output)
+}
+
+// handleAPIEndpoints handles the scraper_api_endpoints tool
+func (s *Server) handleAPIEndpoints(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	jsonOnly, _ := args["jsonOnly"].(bool)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	resp, err := s.jobManager.GetJobAPIEndpoints(jobID, jsonOnly, limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := APIEndpointsOutput{
+		JobID:     resp.JobID,
+		Total:     resp.Total,
+		Endpoints: make([]APIEndpoint, len(resp.Endpoints)),
+	}
+	for i, ep := range resp.Endpoints {
+		output.Endpoints[i] = APIEndpoint(ep)
+	}
+	return resultJSON(output)
+}
+
+// handleExternalLinks handles the scraper_external_links tool
+func (s *Server) handleExternalLinks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	host, _ := args["host"].(string)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	resp, err := s.jobManager.GetJobExternalLinks(jobID, host, limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := ExternalLinksOutput{
+		JobID: resp.JobID,
+		Total: resp.Total,
+		Links: make([]ExternalLink, len(resp.Links)),
+	}
+	for i, link := range resp.Links {
+		output.Links[i] = ExternalLink(link)
+	}
+	return resultJSON(output)
+}
+
+// handleAlerts handles the scraper_alerts tool
+func (s *Server) handleAlerts(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	rule, _ := args["rule"].(string)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	resp, err := s.jobManager.GetJobAlerts(jobID, rule, limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	output := AlertsOutput{
+		JobID:  resp.JobID,
+		Total:  resp.Total,
+		Alerts: make([]Alert, len(resp.Alerts)),
+	}
+	for i, a := range resp.Alerts {
+		output.Alerts[i] = Alert{Time: a.Time, URL: a.URL, Rule: a.Rule, Count: a.Count, Matches: make([]AlertMatch, len(a.Matches))}
+		for j, m := range a.Matches {
+			output.Alerts[i].Matches[j] = AlertMatch(m)
+		}
+	}
+	return resultJSON(output)
+}
+
+// handleManifest handles the scraper_manifest tool
+func (s *Server) handleManifest(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	var offset int64
+	if v, ok := req.GetArguments()["offset"].(float64); ok {
+		offset = int64(v)
+	}
+
+	data, next, done, err := s.jobManager.ReadJobManifest(jobID, offset)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	pages, err := crawler.ParseManifest(data)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := ManifestOutput{
+		JobID:      jobID,
+		Pages:      make([]ManifestPage, len(pages)),
+		NextOffset: next,
+		Done:       done,
+	}
+	for i, p := range pages {
+		output.Pages[i] = ManifestPage(p)
+	}
+	return resultJSON(output)
+}
+
+// handleEvents handles the scraper_events tool
+func (s *Server) handleEvents(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	job, err := s.jobManager.GetJob(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var since uint64
+	if v, ok := req.GetArguments()["since"].(float64); ok {
+		if v < 0 {
+			return mcp.NewToolResultError("since cannot be negative"), nil
+		}
+		since = uint64(v)
+	}
+
+	events := job.Emitter.EventsSince(since)
+	output := EventsOutput{
+		JobID:       jobID,
+		Status:      string(job.GetStatus()),
+		Events:      make([]EventOutput, 0, len(events)),
+		LastEventID: since,
+	}
+	for _, ev := range events {
+		output.Events = append(output.Events, EventOutput{
+			ID:        ev.ID,
+			Type:      ev.Type,
+			Timestamp: ev.Timestamp,
+			Data:      ev.Data,
+		})
+		output.LastEventID = ev.ID
+	}
+
+	return resultJSON(output)
+}
+
+// handleConfirmLogin handles the scraper_confirm_login tool
+func (s *Server) handleConfirmLogin(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	if err := s.jobManager.ConfirmLogin(jobID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := StatusOutput{
+		JobID:   jobID,
+		Status:  "running",
+		Message: "Login confirmed, crawl continuing",
+	}
+
+	return resultJSON(output)
+}
+
+// handleWait handles the scraper_wait tool
+func (s *Server) handleWait(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	// Parse optional parameters
+	timeoutSeconds := 300 // default 5 minutes
+	if timeout, ok := req.GetArguments()["timeoutSeconds"].(float64); ok {
+		timeoutSeconds = int(timeout)
+	}
+
+	pollIntervalMs := 2000 // default 2 seconds
+	if interval, ok := req.GetArguments()["pollIntervalMs"].(float64); ok {
+		pollIntervalMs = int(interval)
+	}
+
+	startTime := time.Now()
+	progress := &waitProgress{start: startTime, lastChange: startTime}
+	if stall, ok := req.GetArguments()["stallSeconds"].(float64); ok && stall > 0 {
+		progress.stallAfter = time.Duration(stall * float64(time.Second))
+	}
+	if percentage, ok := req.GetArguments()["untilPercentage"].(float64); ok && percentage > 0 {
+		progress.untilPercentage = percentage
+	}
+	if every, ok := req.GetArguments()["snapshotSeconds"].(float64); ok && every > 0 {
+		progress.snapshotEvery = time.Duration(every * float64(time.Second))
+	}
+
+	pollInterval := time.Duration(pollIntervalMs) * time.Millisecond
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	deadline := startTime.Add(timeout)
+
+	// Poll until completion, an early return condition or timeout
+	for {
+		job, err := s.jobManager.GetJob(jobID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		status := job.GetStatus()
+
+		// Check for terminal states
+		if isTerminalStatus(status) {
+			details := job.ToDetails()
+			output := WaitOutput{
+				JobID:         jobID,
+				Status:        string(status),
+				Reason:        waitReasonCompleted,
+				Snapshots:     progress.snapshots,
+				WaitedSeconds: int(time.Since(startTime).Seconds()),
+			}
+
+			if details.Config != nil {
+				output.OutputDir = details.Config.OutputDir
+			}
+
+			if details.Metrics != nil {
+				output.FinalMetrics = convertMetrics(details.Metrics)
+			}
+
+			if job.Error != nil {
+				output.Error = job.Error.Error()
+			}
+
+			return resultJSON(output)
+		}
+
+		metrics := job.GetMetrics()
+		if reason := progress.observe(status, metrics, time.Now()); reason != "" {
+			return resultJSON(progress.output(jobID, status, reason, metrics, ""))
+		}
+
+		// Check timeout
+		if time.Now().After(deadline) {
+			return resultJSON(progress.output(jobID, status, waitReasonTimeout, metrics, "timeout waiting for job completion"))
+		}
+
+		// Check context cancellation
+		select {
+		case <-ctx.Done():
+			return resultJSON(progress.output(jobID, status, waitReasonCancelled, metrics, "wait cancelled"))
+		case <-time.After(pollInterval):
+			// Continue polling
+		}
+	}
+}
+
+// Reasons scraper_wait returns for
+const (
+	waitReasonCompleted  = "completed"  // The job reached a terminal status
+	waitReasonStalled    = "stalled"    // No URL was processed for stallSeconds
+	waitReasonPercentage = "percentage" // The job reached untilPercentage
+	waitReasonTimeout    = "timeout"
+	waitReasonCancelled  = "cancelled"
+)
+
+// maxWaitSnapshots bounds the snapshots returned by one scraper_wait call;
+// the oldest are dropped first
+const maxWaitSnapshots = 60
+
+// waitProgress follows a job across scraper_wait polls to decide when to
+// return before it finishes, and keeps the snapshots seen along the way
+type waitProgress struct {
+	stallAfter      time.Duration // Return when the processed count is unchanged this long (0 = never)
+	untilPercentage float64       // Return once the job is this far along (0 = never)
+	snapshotEvery   time.Duration // Interval between recorded snapshots (0 = none)
+
+	start        time.Time
+	processed    int64
+	lastChange   time.Time
+	lastSnapshot time.Time
+	snapshots    []WaitSnapshot
+}
+
+// observe records the status and metrics of a poll at now, returning the
+// reason to stop waiting or "" to keep polling. Only a running job can
+// stall; time spent pending, paused or waiting for login does not count.
+func (w *waitProgress) observe(status api.JobStatus, m *api.MetricsSnapshot, now time.Time) string {
+	if m == nil {
+		w.lastChange = now
+		return ""
+	}
+	if w.snapshotEvery > 0 && (len(w.snapshots) == 0 || now.Sub(w.lastSnapshot) >= w.snapshotEvery) {
+		w.snapshots = append(w.snapshots, WaitSnapshot{
+			ElapsedSeconds: now.Sub(w.start).Seconds(),
+			Status:         string(status),
+			URLsProcessed:  m.URLsProcessed,
+			URLsSaved:      m.URLsSaved,
+			URLsErrored:    m.URLsErrored,
+			QueueSize:      m.QueueSize,
+			PagesPerSecond: m.PagesPerSecond,
+			Percentage:     m.Percentage,
+		})
+		if len(w.snapshots) > maxWaitSnapshots {
+			w.snapshots = w.snapshots[len(w.snapshots)-maxWaitSnapshots:]
+		}
+		w.lastSnapshot = now
+	}
+	if status != api.JobStatusRunning || m.URLsProcessed != w.processed {
+		w.processed = m.URLsProcessed
+		w.lastChange = now
+	}
+	if status != api.JobStatusRunning {
+		return ""
+	}
+	if w.untilPercentage > 0 && m.URLsProcessed > 0 && m.Percentage >= w.untilPercentage {
+		return waitReasonPercentage
+	}
+	if w.stallAfter > 0 && now.Sub(w.lastChange) >= w.stallAfter {
+		return waitReasonStalled
+	}
+	return ""
+}
+
+// output builds the scraper_wait result for a job that has not finished
+func (w *waitProgress) output(jobID string, status api.JobStatus, reason string, m *api.MetricsSnapshot, errMsg string) WaitOutput {
+	output := WaitOutput{
+		JobID:         jobID,
+		Status:        string(status),
+		Reason:        reason,
+		Metrics:       convertMetrics(m),
+		EtaSeconds:    etaSeconds(m),
+		Snapshots:     w.snapshots,
+		Error:         errMsg,
+		WaitedSeconds: int(time.Since(w.start).Seconds()),
+	}
+	if reason == waitReasonStalled {
+		output.StalledSeconds = int(time.Since(w.lastChange).Seconds())
+	}
+	return output
+}
+
+// etaSeconds estimates the seconds left to drain the queue at the current
+// rate, or 0 when there is no rate to go by. New links keep the queue
+// growing, so it is a lower bound.
+func etaSeconds(m *api.MetricsSnapshot) float64 {
+	if m == nil || m.PagesPerSecond <= 0 || m.QueueSize == 0 {
+		return 0
+	}
+	return float64(m.QueueSize) / m.PagesPerSecond
+}
+
+// Helper functions
+
+// handleExport handles the scraper_export tool
+func (s *Server) handleExport(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	opts := crawler.ExportOptions{EmbedImages: true}
+	if format, ok := args["format"].(string); ok {
+		opts.Format = crawler.ExportFormat(format)
+	}
+	if order, ok := args["order"].(string); ok {
+		opts.Order = crawler.ExportOrder(order)
+	}
+	if title, ok := args["title"].(string); ok {
+		opts.Title = title
+	}
+	if embedImages, ok := args["embedImages"].(bool); ok {
+		opts.EmbedImages = embedImages
+	}
+
+	result, err := s.jobManager.ExportJob(jobID, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := ExportOutput{
+		JobID:    jobID,
+		Path:     result.Path,
+		Format:   string(result.Format),
+		Chapters: result.Chapters,
+		Images:   result.Images,
+		Size:     result.Size,
+	}
+
+	return resultJSON(output)
+}
+
+// handleSite handles the scraper_site tool
+func (s *Server) handleSite(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	var opts crawler.SiteOptions
+	if title, ok := args["title"].(string); ok {
+		opts.Title = title
+	}
+	if embedImages, ok := args["embedImages"].(bool); ok {
+		opts.EmbedImages = embedImages
+	}
+
+	result, err := s.jobManager.GenerateJobSite(jobID, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := SiteOutput{
+		JobID:  jobID,
+		Path:   result.Path,
+		Pages:  result.Pages,
+		Images: result.Images,
+	}
+
+	return resultJSON(output)
+}
+
+// handleSEOAudit handles the scraper_seo_audit tool
+func (s *Server) handleSEOAudit(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	issueType, _ := args["issueType"].(string)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	report, err := s.jobManager.AuditJobSEO(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := SEOAuditOutput{
+		JobID:           jobID,
+		HTMLPath:        report.HTMLPath,
+		JSONPath:        report.JSONPath,
+		Pages:           report.Pages,
+		TotalIssues:     len(report.Issues),
+		IssueCounts:     report.IssueCounts,
+		BrokenLinkCheck: report.BrokenLinkCheck,
+		Issues:          []SEOIssue{},
+	}
+	for _, issue := range report.Issues {
+		if issueType != "" && issue.Type != issueType {
+			continue
+		}
+		if limit > 0 && len(output.Issues) >= limit {
+			break
+		}
+		output.Issues = append(output.Issues, SEOIssue(issue))
+	}
+
+	return resultJSON(output)
+}
+
+// handleAccessibilityAudit handles the scraper_accessibility_audit tool
+func (s *Server) handleAccessibilityAudit(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	issueType, _ := args["issueType"].(string)
+	limit := 100
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	report, err := s.jobManager.AuditJobAccessibility(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := AccessibilityAuditOutput{
+		JobID:         jobID,
+		HTMLPath:      report.HTMLPath,
+		JSONPath:      report.JSONPath,
+		Pages:         report.Pages,
+		PagesAffected: report.PagesAffected,
+		TotalIssues:   report.TotalIssues,
+		IssueCounts:   report.IssueCounts,
+		PageDetails:   []AccessibilityPage{},
+	}
+	for _, page := range report.PageDetails {
+		if page.Issues == 0 || (issueType != "" && page.Counts[issueType] == 0) {
+			continue
+		}
+		if limit > 0 && len(output.PageDetails) >= limit {
+			break
+		}
+		output.PageDetails = append(output.PageDetails, AccessibilityPage(page))
+	}
+
+	return resultJSON(output)
+}
+
+// handleDuplicates handles the scraper_duplicates tool
+func (s *Server) handleDuplicates(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	var opts crawler.DuplicateOptions
+	if v, ok := args["threshold"].(float64); ok {
+		opts.Threshold = int(v)
+	}
+	limit := 50
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	report, err := s.jobManager.FindJobDuplicates(jobID, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := DuplicatesOutput{
+		JobID:          jobID,
+		HTMLPath:       report.HTMLPath,
+		JSONPath:       report.JSONPath,
+		Pages:          report.Pages,
+		SkippedPages:   report.SkippedPages,
+		Threshold:      report.Threshold,
+		DuplicatePages: report.DuplicatePages,
+		TotalClusters:  len(report.Clusters),
+		ParamCounts:    report.ParamCounts,
+		Clusters:       []DuplicateCluster{},
+	}
+	for _, c := range report.Clusters {
+		if limit > 0 && len(output.Clusters) >= limit {
+			break
+		}
+		output.Clusters = append(output.Clusters, DuplicateCluster(c))
+	}
+
+	return resultJSON(output)
+}
+
+// handleIndex handles the scraper_index tool
+func (s *Server) handleIndex(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	limit := 100
+	if v, ok := req.GetArguments()["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	resp, err := s.jobManager.GenerateJobIndex(jobID, limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := IndexOutput{
+		JobID:     resp.JobID,
+		IndexFile: resp.IndexFile,
+		Total:     resp.Total,
+		Pages:     make([]IndexPage, len(resp.Pages)),
+	}
+	for i, p := range resp.Pages {
+		output.Pages[i] = IndexPage{
+			URL:         p.URL,
+			Title:       p.Title,
+			Excerpt:     p.Excerpt,
+			Path:        filepath.ToSlash(p.Filename),
+			ContentPath: filepath.ToSlash(p.ContentFile),
+		}
+	}
+	return resultJSON(output)
+}
+
+// handleReprocess handles the scraper_reprocess tool
+func (s *Server) handleReprocess(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	args := req.GetArguments()
+	var reprocess api.ReprocessRequest
+	if v, ok := args["disableContentExtraction"].(bool); ok {
+		reprocess.DisableContentExtraction = &v
+	}
+	if filtersRaw, ok := args["contentFilters"].([]interface{}); ok {
+		reprocess.ContentFilters = parseContentFilters(filtersRaw)
+	}
+	if v, ok := args["pluginsDir"].(string); ok {
+		reprocess.PluginsDir = &v
+	}
+
+	result, err := s.jobManager.ReprocessJob(jobID, reprocess)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return resultJSON(ReprocessOutput{
+		JobID:     jobID,
+		Pages:     result.Pages,
+		Extracted: result.Extracted,
+		Changed:   result.Changed,
+		Failed:    result.Failed,
+		IndexFile: result.IndexFile,
+	})
+}
+
+// handleReadFile handles the scraper_read_file tool
+func (s *Server) handleReadFile(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+	path, err := req.RequireString("path")
+	if err != nil {
+		return mcp.NewToolResultError("path is required"), nil
+	}
+
+	rc, _, err := s.jobManager.OpenJobFile(jobID, path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// handleListFiles handles the scraper_list_files tool
+func (s *Server) handleListFiles(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+	path, _ := req.GetArguments()["path"].(string)
+
+	outputDir, err := s.jobManager.JobOutputDir(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	entries, err := crawler.ListOutputDir(outputDir, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return mcp.NewToolResultError("directory not found"), nil
+		}
+		if errors.Is(err, os.ErrInvalid) {
+			return mcp.NewToolResultError("invalid directory path"), nil
+		}
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := ListFilesOutput{
+		JobID:   jobID,
+		Path:    path,
+		Entries: make([]FileEntry, len(entries)),
+	}
+	for i, e := range entries {
+		output.Entries[i] = FileEntry{Name: e.Name, Dir: e.Dir, Size: e.Size}
+	}
+	return resultJSON(output)
+}
+
+// handleRecrawl handles the scraper_recrawl tool
+func (s *Server) handleRecrawl(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+	scope, err := req.RequireString("scope")
+	if err != nil {
+		return mcp.NewToolResultError("scope is required"), nil
+	}
+	pattern, _ := req.GetArguments()["pattern"].(string)
+
+	crawlReq, err := s.jobManager.RecrawlJobRequest(jobID, api.RecrawlRequest{Scope: scope, Pattern: pattern})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	job, err := s.jobManager.CreateJob(crawlReq)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := s.jobManager.StartJob(job.ID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := StartCrawlOutput{
+		JobID:     job.ID,
+		Status:    string(job.GetStatus()),
+		Message:   fmt.Sprintf("Re-crawl of %d %s URLs of job %s started", len(crawlReq.RecrawlURLs), scope, jobID),
+		OutputDir: crawlReq.OutputDir,
+		Warnings:  job.GetWarnings(),
+	}
+
+	return resultJSON(output)
+}
+
+// handleSecrets handles the scraper_secrets tool
+func (s *Server) handleSecrets(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	action, err := req.RequireString("action")
+	if err != nil {
+		return mcp.NewToolResultError("action is required"), nil
+	}
+	name, _ := req.GetArguments()["name"].(string)
+	value, _ := req.GetArguments()["value"].(string)
+	if action != "list" && name == "" {
+		return mcp.NewToolResultError("name is required for " + action), nil
+	}
+
+	store, err := crawler.OpenDefaultSecretStore()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var message string
+	switch action {
+	case "list":
+	case "set":
+		if err := store.Set(name, value); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		message = fmt.Sprintf("Stored %s; refer to it as %s%s", name, crawler.SecretRefPrefix, name)
+	case "delete":
+		if !store.Delete(name) {
+			return mcp.NewToolResultError(fmt.Sprintf("unknown secret %q", name)), nil
+		}
+		message = fmt.Sprintf("Deleted %s", name)
+	default:
+		return mcp.NewToolResultError("action must be list, set or delete"), nil
+	}
+	if action != "list" {
+		if err := store.Save(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	return resultJSON(SecretsOutput{Names: store.Names(), Message: message})
+}
+
+// handleSelfTest handles the scraper_selftest tool
+func (s *Server) handleSelfTest(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var checks []string
+	if checksRaw, ok := req.GetArguments()["checks"].([]interface{}); ok {
+		checks = toStringSlice(checksRaw)
+	}
+
+	dir, err := os.MkdirTemp("", "scraper-selftest-")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create self-test directory: %v", err)), nil
+	}
+	defer os.RemoveAll(dir)
+
+	results, err := crawler.RunSelfTest(ctx, dir, checks, nil)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := SelfTestOutput{Passed: true, Results: make([]SelfTestCheck, 0, len(results))}
+	for _, r := range results {
+		output.Passed = output.Passed && r.Passed
+		output.Results = append(output.Results, SelfTestCheck{
+			Name:        r.Name,
+			Description: r.Description,
+			Passed:      r.Passed,
+			Seconds:     r.Seconds,
+			Error:       r.Error,
+		})
+	}
+	return resultJSON(output)
+}
+
+// handleAntiBotTest handles the scraper_antibot_test tool
+func (s *Server) handleAntiBotTest(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+
+	opts := crawler.AntiBotTestOptions{Headless: true}
+	if url, ok := args["url"].(string); ok {
+		opts.URL = url
+	}
+	if raw, ok := args["antiBot"].(map[string]interface{}); ok {
+		opts.AntiBot = crawler.AntiBotConfig(*parseAntiBotConfig(raw))
+	}
+	if headless, ok := args["headless"].(bool); ok {
+		opts.Headless = headless
+	}
+	if userAgent, ok := args["userAgent"].(string); ok {
+		opts.UserAgent = userAgent
+	}
+	if err := opts.Validate(); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result, err := crawler.RunAntiBotTest(ctx, opts)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := AntiBotTestOutput{
+		URL:      result.URL,
+		Profile:  result.Profile,
+		Headless: result.Headless,
+		Leaks:    result.Leaks,
+		Signals:  make([]AntiBotTestSignal, 0, len(result.Signals)),
+	}
+	for _, s := range result.Signals {
+		output.Signals = append(output.Signals, AntiBotTestSignal{Name: s.Name, Value: s.Value, Leaks: s.Leaks, Fix: s.Fix})
+	}
+	return resultJSON(output)
+}
+
+// handlePlan handles the scraper_plan tool
+func (s *Server) handlePlan(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+
+	url, ok := args["url"].(string)
+	if !ok || url == "" {
+		return mcp.NewToolResultError("url is required"), nil
+	}
+	planReq := &api.CrawlRequest{URL: url}
+	if recipe, ok := args["recipe"].(string); ok {
+		planReq.Recipe = recipe
+	}
+	if maxDepth, ok := args["maxDepth"].(float64); ok {
+		planReq.MaxDepth = int(maxDepth)
+	}
+	if depthMode, ok := args["depthMode"].(string); ok {
+		planReq.DepthMode = depthMode
+	}
+	if maxPages, ok := args["maxPages"].(float64); ok {
+		planReq.MaxPages = int(maxPages)
+	}
+	if delay, ok := args["delay"].(string); ok {
+		planReq.Delay = delay
+	}
+	if prefixFilter, ok := args["prefixFilter"].(string); ok {
+		planReq.PrefixFilterURL = prefixFilter
+	}
+	if excludeExtRaw, ok := args["excludeExtensions"].([]interface{}); ok {
+		planReq.ExcludeExtensions = toStringSlice(excludeExtRaw)
+	}
+	if allowedHostsRaw, ok := args["allowedHosts"].([]interface{}); ok {
+		planReq.AllowedHosts = toStringSlice(allowedHostsRaw)
+	}
+	if deniedHostsRaw, ok := args["deniedHosts"].([]interface{}); ok {
+		planReq.DeniedHosts = toStringSlice(deniedHostsRaw)
+	}
+	if linkSelectorsRaw, ok := args["linkSelectors"].([]interface{}); ok {
+		planReq.LinkSelectors = toStringSlice(linkSelectorsRaw)
+	}
+	if fetchMode, ok := args["fetchMode"].(string); ok {
+		planReq.FetchMode = fetchMode
+	}
+	if userAgent, ok := args["userAgent"].(string); ok {
+		planReq.UserAgent = userAgent
+	}
+	if ignoreRobots, ok := args["ignoreRobots"].(bool); ok {
+		planReq.IgnoreRobots = ignoreRobots
+	}
+	if normalizeURLs, ok := args["normalizeUrls"].(bool); ok {
+		planReq.NormalizeURLs = &normalizeURLs
+	}
+	limit := 200
+	if v, ok := args["limit"].(float64); ok {
+		limit = int(v)
+	}
+
+	if err := api.ValidateCrawlRequest(planReq); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	plan, err := s.jobManager.PlanCrawl(ctx, planReq)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := PlanOutput{
+		Pages:      make([]PlannedPage, 0, len(plan.Pages)),
+		Links:      []PlannedLink{},
+		TotalLinks: len(plan.Links),
+		Queued:     plan.Queued,
+		Filtered:   plan.Filtered,
+	}
+	for _, p := range plan.Pages {
+		output.Pages = append(output.Pages, PlannedPage{URL: p.URL, Depth: p.Depth, Error: p.Error, Links: p.Links})
+	}
+	for _, l := range plan.Links {
+		if limit > 0 && len(output.Links) >= limit {
+			break
+		}
+		output.Links = append(output.Links, PlannedLink{URL: l.URL, Source: l.Source, Depth: l.Depth, Queued: l.Queued, Reason: l.Reason})
+	}
+	return resultJSON(output)
+}
+
+// handleUsage handles the scraper_usage tool
+func (s *Server) handleAudit(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	audit := s.jobManager.AuditLog()
+	if audit == nil {
+		return mcp.NewToolResultError("audit log not enabled; start the server with -audit-log"), nil
+	}
+
+	args := req.GetArguments()
+	filter := api.AuditFilter{Limit: 100}
+	filter.JobID, _ = args["jobId"].(string)
+	if v, ok := args["since"].(string); ok && v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid since: %v", err)), nil
+		}
+		filter.Since = since
+	}
+	if v, ok := args["limit"].(float64); ok {
+		if v < 0 {
+			return mcp.NewToolResultError("limit must not be negative"), nil
+		}
+		filter.Limit = int(v)
+	}
+
+	entries, total, err := audit.Entries(filter)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	output := AuditOutput{Total: total, Entries: make([]AuditEntry, 0, len(entries))}
+	for _, e := range entries {
+		output.Entries = append(output.Entries, AuditEntry{
+			Time:     e.Time,
+			Key:      e.Key,
+			ClientIP: e.ClientIP,
+			Action:   e.Action,
+			Path:     e.Path,
+			JobID:    e.JobID,
+			Status:   e.Status,
+			Error:    e.Error,
+			Summary:  e.Summary,
+		})
+	}
+
+	return resultJSON(output)
+}
+
+func (s *Server) handleUsage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, _ := req.GetArguments()["key"].(string)
+
+	usage := s.jobManager.Usage(key, time.Now())
+	output := UsageOutput{Keys: make([]KeyUsage, 0, len(usage))}
+	for _, u := range usage {
+		k := KeyUsage{
+			Key:        u.Key,
+			Day:        u.Day,
+			DayBytes:   u.DayBytes,
+			DayPages:   u.DayPages,
+			Month:      u.Month,
+			MonthBytes: u.MonthBytes,
+			MonthPages: u.MonthPages,
+			TotalBytes: u.TotalBytes,
+			TotalPages: u.TotalPages,
+			Exceeded:   u.Exceeded,
+			ActiveJobs: u.ActiveJobs,
+		}
+		if u.Quota != nil {
+			k.Quota = &QuotaOutput{
+				DailyBytes:   u.Quota.DailyBytes,
+				DailyPages:   u.Quota.DailyPages,
+				MonthlyBytes: u.Quota.MonthlyBytes,
+				MonthlyPages: u.Quota.MonthlyPages,
+			}
+		}
+		output.Keys = append(output.Keys, k)
+	}
+
+	return resultJSON(output)
+}
+
+// handleRuntime handles the scraper_runtime tool
+func (s *Server) handleRuntime(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return resultJSON(s.jobManager.Runtime())
+}
+
+// handleExportDefinition handles the scraper_export_definition tool
+func (s *Server) handleExportDefinition(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID, err := req.RequireString("jobId")
+	if err != nil {
+		return mcp.NewToolResultError("jobId is required"), nil
+	}
+
+	def, err := s.jobManager.ExportJobDefinition(jobID)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return resultJSON(def)
+}
+
+// handleRecipes handles the scraper_recipes tool
+func (s *Server) handleRecipes(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "list":
+		recipes, err := api.Recipes()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(api.RecipesResponse{Recipes: recipes})
+	case "get":
+		name, _ := args["name"].(string)
+		if name == "" {
+			return mcp.NewToolResultError("name is required for get"), nil
+		}
+		recipe, err := api.GetRecipe(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(recipe)
+	default:
+		return mcp.NewToolResultError("action must be list or get"), nil
+	}
+}
+
+// handlePlugins handles the scraper_plugins tool
+func (s *Server) handlePlugins(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dir, err := req.RequireString("dir")
+	if err != nil {
+		return mcp.NewToolResultError("dir is required"), nil
+	}
+	plugins, err := crawler.ListPlugins(dir)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return resultJSON(api.PluginsResponse{Dir: dir, Plugins: plugins})
+}
+
+// handlePresets handles the scraper_presets tool
+func (s *Server) handlePresets(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	action, err := req.RequireString("action")
+	if err != nil {
+		return mcp.NewToolResultError("action is required"), nil
+	}
+	args := req.GetArguments()
+	name, _ := args["name"].(string)
+	if name == "" && (action == "get" || action == "save" || action == "delete") {
+		return mcp.NewToolResultError("name is required for " + action), nil
+	}
+
+	dir := s.presetsDir
+	if dir == "" {
+		if dir, err = api.DefaultPresetsDir(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	store := api.NewPresetStore(dir)
+
+	switch action {
+	case "list":
+		presets, err := store.List()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(api.PresetsResponse{Presets: presets})
+	case "get":
+		p, err := store.Get(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(p)
+	case "save":
+		jobID, _ := args["jobId"].(string)
+		if jobID == "" {
+			return mcp.NewToolResultError("jobId is required for save"), nil
+		}
+		def, err := s.jobManager.ExportJobDefinition(jobID)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if err := store.Save(api.NewPreset(name, def.Request)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		p, err := store.Get(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(p)
+	case "delete":
+		if err := store.Delete(name); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		presets, err := store.List()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(api.PresetsResponse{Presets: presets})
+	case "export":
+		var names []string
+		if namesRaw, ok := args["names"].([]interface{}); ok {
+			names = toStringSlice(namesRaw)
+		}
+		bundle, err := store.Export(names)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(bundle)
+	case "import":
+		var data []byte
+		switch bundle := args["bundle"].(type) {
+		case map[string]interface{}:
+			data, _ = json.Marshal(bundle)
+		case string:
+			// Some clients pass the exported JSON as text
+			data = []byte(bundle)
+		default:
+			return mcp.NewToolResultError("bundle is required for import"), nil
+		}
+		overwrite, _ := args["overwrite"].(bool)
+		result, err := store.Import(data, overwrite)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(result)
+	default:
+		return mcp.NewToolResultError("action must be list, get, save, delete, export or import"), nil
+	}
+}
+
+// handleSchedules handles the scraper_schedules tool
+func (s *Server) handleSchedules(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	action, err := req.RequireString("action")
+	if err != nil {
+		return mcp.NewToolResultError("action is required"), nil
+	}
+	args := req.GetArguments()
+	id, _ := args["id"].(string)
+	if id == "" && (action == "remove" || action == "enable" || action == "disable" || action == "run") {
+		return mcp.NewToolResultError("id is required for " + action), nil
+	}
+
+	scheduler, err := s.schedules()
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	store := scheduler.Store()
+
+	switch action {
+	case "list":
+		schedules, err := store.List()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(api.SchedulesResponse{Running: scheduler.Running(), Schedules: schedules})
+	case "add":
+		preset, _ := args["preset"].(string)
+		spec, _ := args["spec"].(string)
+		url, _ := args["url"].(string)
+		if preset == "" || spec == "" {
+			return mcp.NewToolResultError("preset and spec are required for add"), nil
+		}
+		sched, err := scheduler.Add(preset, url, spec)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(sched)
+	case "remove":
+		if err := store.Remove(id); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		schedules, err := store.List()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(api.SchedulesResponse{Running: scheduler.Running(), Schedules: schedules})
+	case "enable", "disable":
+		sched, err := store.SetEnabled(id, action == "enable")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(sched)
+	case "run":
+		run, err := scheduler.RunNow(id)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(run)
+	case "history":
+		limit := 50
+		if v, ok := args["limit"].(float64); ok && v >= 0 {
+			limit = int(v)
+		}
+		runs, err := store.History(id, limit)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return resultJSON(api.ScheduleHistoryResponse{Runs: runs})
+	default:
+		return mcp.NewToolResultError("action must be list, add, remove, enable, disable, run or history"), nil
+	}
+}
+
+// handleImportDefinition handles the scraper_import_definition tool
+func (s *Server) handleImportDefinition(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var data []byte
+	switch def := req.GetArguments()["definition"].(type) {
+	case map[string]interface{}:
+		data, _ = json.Marshal(def)
+	case string:
+		// Some clients pass the exported JSON as text
+		data = []byte(def)
+	default:
+		return mcp.NewToolResultError("definition is required"), nil
+	}
+
+	crawlReq, err := api.ParseJobDefinition(data)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	job, err := s.jobManager.CreateJob(crawlReq)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := s.jobManager.StartJob(job.ID); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	outputDir := crawlReq.OutputDir
+	if outputDir == "" {
+		outputDir = "(auto-generated based on URL)"
+	}
+
+	output := StartCrawlOutput{
+		JobID:     job.ID,
+		Status:    string(job.GetStatus()),
+		Message:   fmt.Sprintf("Crawl job started for %s from imported definition", crawlReq.URL),
+		OutputDir: outputDir,
+		Warnings:  job.GetWarnings(),
+	}
+
+	return resultJSON(output)
+}
+
+// handleFetchPage handles the scraper_fetch_page tool
+func (s *Server) handleFetchPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := req.GetArguments()
+
+	url, ok := args["url"].(string)
+	if !ok || url == "" {
+		return mcp.NewToolResultError("url is required"), nil
+	}
+	fetchReq := &api.CrawlRequest{URL: url}
+	if recipe, ok := args["recipe"].(string); ok {
+		fetchReq.Recipe = recipe
+	}
+	if fetchMode, ok := args["fetchMode"].(string); ok {
+		fetchReq.FetchMode = fetchMode
+	}
+	if pageLoadWait, ok := args["pageLoadWait"].(string); ok {
+		fetchReq.PageLoadWait = pageLoadWait
+	}
+	if userAgent, ok := args["userAgent"].(string); ok {
+		fetchReq.UserAgent = userAgent
+	}
+	if ignoreRobots, ok := args["ignoreRobots"].(bool); ok {
+		fetchReq.IgnoreRobots = ignoreRobots
+	}
+	format := "markdown"
+	if v, ok := args["format"].(string); ok && v != "" {
+		format = v
+	}
+	if format != "markdown" && format != "text" && format != "both" {
+		return mcp.NewToolResultError("format must be markdown, text or both"), nil
+	}
+	maxChars := 20000
+	if v, ok := args["maxChars"].(float64); ok {
+		maxChars = int(v)
+	}
+	maxLinks := 100
+	if v, ok := args["maxLinks"].(float64); ok {
+		maxLinks = int(v)
+	}
+
+	if err := api.ValidateCrawlRequest(fetchReq); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	page, err := s.jobManager.FetchPage(ctx, fetchReq)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := FetchPageOutput{
+		URL:         page.URL,
+		FinalURL:    page.FinalURL,
+		StatusCode:  page.StatusCode,
+		ContentType: page.ContentType,
+		Title:       page.Title,
+		Description: page.Description,
+		Author:      page.Author,
+		Date:        page.Date,
+		Language:    page.Language,
+		Extracted:   page.Extracted,
+		Words:       page.Words,
+		Links:       []FetchPageLink{},
+		TotalLinks:  len(page.Links),
+	}
+	for _, l := range page.Links {
+		if maxLinks > 0 && len(output.Links) >= maxLinks {
+			break
+		}
+		output.Links = append(output.Links, FetchPageLink{URL: l.URL, Text: l.Text, Heading: l.Heading, Rel: l.Rel, Reason: l.Reason})
+	}
+	var truncated bool
+	if format != "text" {
+		output.Markdown, truncated = truncateChars(page.Markdown, maxChars)
+		output.Truncated = output.Truncated || truncated
+	}
+	if format != "markdown" {
+		output.Text, truncated = truncateChars(page.Text, maxChars)
+		output.Truncated = output.Truncated || truncated
+	}
+	return resultJSON(output)
+}
+
+// truncateChars cuts s to at most max characters (0 = no limit) and reports
+// whether it did
+func truncateChars(s string, max int) (string, bool) {
+	if max <= 0 {
+		return s, false
+	}
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s, false
+	}
+	return string(runes[:max]), true
+}
+
+// isTerminalStatus checks if a job status is terminal (completed, stopped, or error)
+func isTerminalStatus(status api.JobStatus) bool {
+	return status == api.JobStatusCompleted ||
+		status == api.JobStatusStopped ||
+		status == api.JobStatusError
+}
+
+// parseAntiBotConfig parses anti-bot settings from a map
+func parseAntiBotConfig(raw map[string]interface{}) *api.AntiBotConfig {
+	config := &api.AntiBotConfig{}
+	if v, ok := raw["profile"].(string); ok {
+		config.Profile = v
+	}
+
+	// Browser Fingerprint Modifications
+	if v, ok := raw["hideWebdriver"].(bool); ok {
+		config.HideWebdriver = v
+	}
+	if v, ok := raw["spoofPlugins"].(bool); ok {
+		config.SpoofPlugins = v
+	}
+	if v, ok := raw["spoofLanguages"].(bool); ok {
+		config.SpoofLanguages = v
+	}
+	if v, ok := raw["spoofWebGL"].(bool); ok {
+		config.SpoofWebGL = v
+	}
+	if v, ok := raw["addCanvasNoise"].(bool); ok {
+		config.AddCanvasNoise = v
+	}
+
+	// Human Behavior Simulation
+	if v, ok := raw["naturalMouseMovement"].(bool); ok {
+		config.NaturalMouseMovement = v
+	}
+	if v, ok := raw["randomTypingDelays"].(bool); ok {
+		config.RandomTypingDelays = v
+	}
+	if v, ok := raw["naturalScrolling"].(bool); ok {
+		config.NaturalScrolling = v
+	}
+	if v, ok := raw["randomActionDelays"].(bool); ok {
+		config.RandomActionDelays = v
+	}
+	if v, ok := raw["randomClickOffset"].(bool); ok {
+		config.RandomClickOffset = v
+	}
+
+	// Browser Properties
+	if v, ok := raw["rotateUserAgent"].(bool); ok {
+		config.RotateUserAgent = v
 	}
 	if v, ok := raw["randomViewport"].(bool); ok {
 		config.RandomViewport = v
@@ -443,6 +2255,49 @@ func parseAntiBotConfig(raw map[string]interface{}) *api.AntiBotConfig {
 	return config
 }
 
+// parseGeoConfig parses region emulation settings from a map
+func parseGeoConfig(raw map[string]interface{}) *api.GeoConfig {
+	config := &api.GeoConfig{}
+	if v, ok := raw["region"].(string); ok {
+		config.Region = v
+	}
+	if v, ok := raw["acceptLanguage"].(string); ok {
+		config.AcceptLanguage = v
+	}
+	if v, ok := raw["locale"].(string); ok {
+		config.Locale = v
+	}
+	if v, ok := raw["timezone"].(string); ok {
+		config.Timezone = v
+	}
+	if v, ok := raw["geolocation"].(string); ok {
+		config.Geolocation = v
+	}
+	if v, ok := raw["proxy"].(string); ok {
+		config.Proxy = v
+	}
+	if v, ok := raw["proxyDns"].(bool); ok {
+		config.ProxyDNS = v
+	}
+	return config
+}
+
+// parseOutputPermissions parses output file modes from a map. An owner is
+// passed on for the job manager to refuse, as only the server sets it.
+func parseOutputPermissions(raw map[string]interface{}) *api.OutputPermissions {
+	config := &api.OutputPermissions{}
+	if v, ok := raw["fileMode"].(string); ok {
+		config.FileMode = v
+	}
+	if v, ok := raw["dirMode"].(string); ok {
+		config.DirMode = v
+	}
+	if v, ok := raw["owner"].(string); ok {
+		config.Owner = v
+	}
+	return config
+}
+
 // convertMetrics converts api.MetricsSnapshot to mcp.MetricsSnapshot
 func convertMetrics(m *api.MetricsSnapshot) *MetricsSnapshot {
 	if m == nil {
@@ -457,12 +2312,91 @@ func convertMetrics(m *api.MetricsSnapshot) *MetricsSnapshot {
 		RobotsBlocked:   m.RobotsBlocked,
 		DepthLimitHits:  m.DepthLimitHits,
 		ContentFiltered: m.ContentFiltered,
+		FollowOnly:      m.FollowOnly,
+		StopReason:      m.StopReason,
+		Redirected:      m.Redirected,
+		RedirectErrors:  m.RedirectErrors,
+		TimedOut:        m.TimedOut,
+		BrowserRecycles: m.BrowserRecycles,
+		BrowserCrashes:  m.BrowserCrashes,
 		PagesPerSecond:  m.PagesPerSecond,
 		QueueSize:       m.QueueSize,
+		HeapAlloc:       m.HeapAlloc,
+		PeakHeapAlloc:   m.PeakHeapAlloc,
+		SysMemory:       m.SysMemory,
+		NumGC:           m.NumGC,
 		ElapsedTime:     m.ElapsedTime,
 		Percentage:      m.Percentage,
 		CurrentURL:      m.CurrentURL,
+		Depths:          convertDepths(m.Depths),
+		Content:         convertContentStats(m.Content),
+		Active:          convertActive(m.Active),
+		RecentErrors:    convertRecentErrors(m.RecentErrors),
+	}
+}
+
+// convertActive converts the URLs being processed to MCP active fetches
+func convertActive(active []crawler.ActiveFetch) []ActiveFetch {
+	var out []ActiveFetch
+	for _, a := range active {
+		out = append(out, ActiveFetch(a))
+	}
+	return out
+}
+
+// convertRecentErrors converts the latest errors to MCP recent errors
+func convertRecentErrors(errs []crawler.RecentError) []RecentError {
+	var out []RecentError
+	for _, e := range errs {
+		out = append(out, RecentError(e))
+	}
+	return out
+}
+
+// convertDepths converts the per-depth histogram to MCP depth stats
+func convertDepths(depths []crawler.DepthStats) []DepthStats {
+	if len(depths) == 0 {
+		return nil
+	}
+	out := make([]DepthStats, len(depths))
+	for i, d := range depths {
+		out[i] = DepthStats(d)
+	}
+	return out
+}
+
+// convertContentStats converts the content distributions to MCP content stats
+func convertContentStats(stats crawler.ContentStats) ContentStats {
+	out := ContentStats{
+		StatusCodes:  stats.StatusCodes,
+		ContentTypes: stats.ContentTypes,
+		Charsets:     stats.Charsets,
+		Languages:    stats.Languages,
+	}
+	for _, p := range stats.LargestPages {
+		out.LargestPages = append(out.LargestPages, PageSize(p))
+	}
+	return out
+}
+
+// convertSamples converts API metrics samples to MCP samples
+func convertSamples(samples []api.MetricsSample) []MetricsSample {
+	out := make([]MetricsSample, len(samples))
+	for i, s := range samples {
+		out[i] = MetricsSample{
+			Timestamp:       s.Timestamp,
+			ElapsedSeconds:  s.ElapsedSeconds,
+			URLsProcessed:   s.URLsProcessed,
+			URLsSaved:       s.URLsSaved,
+			URLsSkipped:     s.URLsSkipped,
+			URLsErrored:     s.URLsErrored,
+			QueueSize:       s.QueueSize,
+			BytesDownloaded: s.BytesDownloaded,
+			PagesPerSecond:  s.PagesPerSecond,
+			HeapAlloc:       s.HeapAlloc,
+		}
 	}
+	return out
 }
 
 // resultJSON creates a JSON tool result
@@ -496,11 +2430,180 @@ func parsePaginationConfig(raw map[string]interface{}) *api.PaginationConfig {
 	if v, ok := raw["stopOnDuplicate"].(bool); ok {
 		config.StopOnDuplicate = v
 	}
+	if v, ok := raw["harvestUrls"].(bool); ok {
+		config.HarvestURLs = v
+	}
+
+	return config
+}
+
+// parseFaultConfig parses fault injection settings
+func parseFaultConfig(raw map[string]interface{}) *api.FaultConfig {
+	config := &api.FaultConfig{}
+	if v, ok := raw["latency"].(string); ok {
+		config.Latency = v
+	}
+	if v, ok := raw["errorRate"].(float64); ok {
+		config.ErrorRate = v
+	}
+	if v, ok := raw["truncateRate"].(float64); ok {
+		config.TruncateRate = v
+	}
+	if v, ok := raw["resetRate"].(float64); ok {
+		config.ResetRate = v
+	}
+	if v, ok := raw["seed"].(float64); ok {
+		config.Seed = int64(v)
+	}
+	return config
+}
+
+// parseEmbeddingsConfig parses the embeddings endpoint settings
+func parseEmbeddingsConfig(raw map[string]interface{}) *api.EmbeddingsConfig {
+	config := &api.EmbeddingsConfig{}
+	if v, ok := raw["endpoint"].(string); ok {
+		config.Endpoint = v
+	}
+	if v, ok := raw["model"].(string); ok {
+		config.Model = v
+	}
+	if v, ok := raw["apiKey"].(string); ok {
+		config.APIKey = v
+	}
+	if v, ok := raw["dimensions"].(float64); ok {
+		config.Dimensions = int(v)
+	}
+	if v, ok := raw["store"].(string); ok {
+		config.Store = v
+	}
+	return config
+}
+
+// parseEnrichmentConfig parses the enrichment endpoint settings
+func parseEnrichmentConfig(raw map[string]interface{}) *api.EnrichmentConfig {
+	config := &api.EnrichmentConfig{}
+	if v, ok := raw["endpoint"].(string); ok {
+		config.Endpoint = v
+	}
+	if v, ok := raw["apiKey"].(string); ok {
+		config.APIKey = v
+	}
+	if v, ok := raw["concurrency"].(float64); ok {
+		config.Concurrency = int(v)
+	}
+	if v, ok := raw["timeout"].(string); ok {
+		config.Timeout = v
+	}
+	return config
+}
 
+// parseTracingConfig parses OpenTelemetry export settings
+func parseTracingConfig(raw map[string]interface{}) *api.TracingConfig {
+	config := &api.TracingConfig{}
+	if v, ok := raw["endpoint"].(string); ok {
+		config.Endpoint = v
+	}
+	if v, ok := raw["sampleRate"].(float64); ok {
+		config.SampleRate = v
+	}
 	return config
 }
 
 // toStringSlice converts an interface slice to a string slice
+// parsePageScripts parses {pattern, script} objects, skipping malformed entries
+func parsePageScripts(raw []interface{}) []crawler.PageScript {
+	scripts := make([]crawler.PageScript, 0, len(raw))
+	for _, v := range raw {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pattern, _ := m["pattern"].(string)
+		script, _ := m["script"].(string)
+		scripts = append(scripts, crawler.PageScript{Pattern: pattern, Script: script})
+	}
+	return scripts
+}
+
+// parseContentFilters parses {pattern, keep, remove} objects, skipping malformed entries
+func parseContentFilters(raw []interface{}) []crawler.ContentFilter {
+	filters := make([]crawler.ContentFilter, 0, len(raw))
+	for _, v := range raw {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pattern, _ := m["pattern"].(string)
+		keep, _ := m["keep"].(string)
+		remove, _ := m["remove"].(string)
+		filters = append(filters, crawler.ContentFilter{Pattern: pattern, Keep: keep, Remove: remove})
+	}
+	return filters
+}
+
+// parseEventSinks parses {type, path, url, topic, events, payload, mappings} objects, skipping malformed entries
+func parseEventSinks(raw []interface{}) []crawler.EventSink {
+	sinks := make([]crawler.EventSink, 0, len(raw))
+	for _, v := range raw {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sink := crawler.EventSink{}
+		sink.Type, _ = m["type"].(string)
+		sink.Path, _ = m["path"].(string)
+		sink.URL, _ = m["url"].(string)
+		sink.Topic, _ = m["topic"].(string)
+		sink.Payload, _ = m["payload"].(string)
+		if mappings, ok := m["mappings"].(map[string]interface{}); ok {
+			sink.Mappings, _ = json.Marshal(mappings)
+		}
+		if events, ok := m["events"].([]interface{}); ok {
+			for _, e := range toStringSlice(events) {
+				sink.Events = append(sink.Events, crawler.EventType(e))
+			}
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+// parseFollowOnlyRules parses {pattern, title} objects, skipping malformed entries
+func parseFollowOnlyRules(raw []interface{}) []crawler.FollowOnlyRule {
+	rules := make([]crawler.FollowOnlyRule, 0, len(raw))
+	for _, v := range raw {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pattern, _ := m["pattern"].(string)
+		title, _ := m["title"].(string)
+		rules = append(rules, crawler.FollowOnlyRule{Pattern: pattern, Title: title})
+	}
+	return rules
+}
+
+// parseAlertRules parses {name, pattern, keywords, target} objects, skipping
+// malformed entries
+func parseAlertRules(raw []interface{}) []crawler.AlertRule {
+	rules := make([]crawler.AlertRule, 0, len(raw))
+	for _, v := range raw {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var rule crawler.AlertRule
+		rule.Name, _ = m["name"].(string)
+		rule.Pattern, _ = m["pattern"].(string)
+		rule.Target, _ = m["target"].(string)
+		if keywords, ok := m["keywords"].([]interface{}); ok {
+			rule.Keywords = toStringSlice(keywords)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
 func toStringSlice(raw []interface{}) []string {
 	result := make([]string, 0, len(raw))
 	for _, v := range raw {
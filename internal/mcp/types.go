@@ -8,25 +8,94 @@ import "time"
 type StartCrawlInput struct {
 	URL               string           `json:"url" jsonschema:"required,description=Target URL to start crawling from"`
 	MaxDepth          int              `json:"maxDepth,omitempty" jsonschema:"description=Maximum link depth to crawl (default: 10)"`
+	DepthMode         string           `json:"depthMode,omitempty" jsonschema:"enum=links,enum=path,description=What maxDepth counts: links (link hops, default) or path (URL path segments beyond the start URL or prefix filter)"`
+	MaxPages          int              `json:"maxPages,omitempty" jsonschema:"description=Stop after this many pages have been saved (default: unlimited)"`
+	MaxRuntime        string           `json:"maxRuntime,omitempty" jsonschema:"description=Stop after the crawl has run this long, e.g. '30m' (default: unlimited)"`
+	MaxConsecutiveErrors int           `json:"maxConsecutiveErrors,omitempty" jsonschema:"description=Stop after this many fetches failed in a row (default: never)"`
+	URLTimeout        string           `json:"urlTimeout,omitempty" jsonschema:"description=Give up on a URL not fetched, parsed and saved within this long, e.g. '45s' (default: none)"`
+	StopPattern       string           `json:"stopPattern,omitempty" jsonschema:"description=Stop once every URL matching this regex found so far has been fetched"`
+	MaxHTMLSize       int64            `json:"maxHtmlSize,omitempty" jsonschema:"description=Skip pages whose HTML is larger than this many bytes (default: 10 MiB)"`
 	Concurrent        bool             `json:"concurrent,omitempty" jsonschema:"description=Enable concurrent crawling for faster processing"`
+	Workers           int              `json:"workers,omitempty" jsonschema:"description=Number of simultaneous requests in concurrent mode (default: 10)"`
 	Delay             string           `json:"delay,omitempty" jsonschema:"description=Delay between requests (e.g. '500ms' or '1s')"`
 	OutputDir         string           `json:"outputDir,omitempty" jsonschema:"description=Directory to save crawled content"`
 	StateFile         string           `json:"stateFile,omitempty" jsonschema:"description=Path to state file for resume functionality"`
+	StateMerge        bool             `json:"stateMerge,omitempty" jsonschema:"description=Merge a state file saved for another start URL into this one"`
+	FrontierLimit     int              `json:"frontierLimit,omitempty" jsonschema:"description=Queued URLs kept in memory before the rest spills to disk (default: unlimited)"`
 	Verbose           bool             `json:"verbose,omitempty" jsonschema:"description=Enable verbose debug output"`
 	PrefixFilter      string           `json:"prefixFilter,omitempty" jsonschema:"description=Only crawl URLs starting with this prefix"`
 	FetchMode         string           `json:"fetchMode,omitempty" jsonschema:"enum=http,enum=browser,description=Fetch mode: 'http' for fast requests or 'browser' for JavaScript-rendered pages"`
 	Headless          *bool            `json:"headless,omitempty" jsonschema:"description=Run browser in headless mode (default: true)"`
 	WaitForLogin      bool             `json:"waitForLogin,omitempty" jsonschema:"description=Wait for manual login before starting crawl (browser mode only)"`
 	UserAgent         string           `json:"userAgent,omitempty" jsonschema:"description=Custom User-Agent string"`
+	UserAgentPool     string           `json:"userAgentPool,omitempty" jsonschema:"description=Built-in user agents antiBot.rotateUserAgent cycles through: desktop (default), mobile or all"`
+	UserAgents        []string         `json:"userAgents,omitempty" jsonschema:"description=Own user agents antiBot.rotateUserAgent cycles through instead of userAgentPool"`
+	StickyUserAgent   bool             `json:"stickyUserAgent,omitempty" jsonschema:"description=Keep one user agent per host"`
+	WarmUp            bool             `json:"warmUp,omitempty" jsonschema:"description=Visit each host's homepage before its deeper URLs, keeping cookies"`
+	WarmUpPages       int              `json:"warmUpPages,omitempty" jsonschema:"description=Pages linked from the homepage also visited during warm-up (0-5)"`
+	RefererPolicy     string           `json:"refererPolicy,omitempty" jsonschema:"description=Referer sent with requests: none, origin, strict or full (default: strict with warmUp, none without)"`
 	IgnoreRobots      bool             `json:"ignoreRobots,omitempty" jsonschema:"description=Ignore robots.txt restrictions"`
 	MinContentLength  int              `json:"minContent,omitempty" jsonschema:"description=Minimum content length to save a page (default: 100)"`
+	MaxContentLength  int              `json:"maxContent,omitempty" jsonschema:"description=Skip pages with more text characters (default: 0, no limit)"`
+	MinWords          int              `json:"minWords,omitempty" jsonschema:"description=Skip pages with fewer words (default: 0, no limit)"`
+	MaxWords          int              `json:"maxWords,omitempty" jsonschema:"description=Skip pages with more words (default: 0, no limit)"`
+	MaxLinkDensity    float64          `json:"maxLinkDensity,omitempty" jsonschema:"description=Skip pages whose share of words inside links is higher, 0-1 (default: 0, no limit)"`
+	IndexInterval     *int             `json:"indexInterval,omitempty" jsonschema:"description=Rewrite _index.html every N saved pages; 0 = only at completion (default: 50)"`
+	MetricsInterval   string           `json:"metricsInterval,omitempty" jsonschema:"description=Time between metrics time series samples (default: 5s)"`
 	ExcludeExtensions []string         `json:"excludeExtensions,omitempty" jsonschema:"description=File extensions to exclude (e.g. ['.pdf', '.zip'])"`
+	AllowedHosts      []string         `json:"allowedHosts,omitempty" jsonschema:"description=Only follow links to these hosts; *.example.com matches subdomains"`
+	DeniedHosts       []string         `json:"deniedHosts,omitempty" jsonschema:"description=Never follow links to these hosts, checked before allowedHosts"`
+	ExternalLinks     bool             `json:"externalLinks,omitempty" jsonschema:"description=Record links left out by prefixFilter or the host lists to external_links.jsonl without fetching them"`
+	Report            bool             `json:"report,omitempty" jsonschema:"description=Write report.html with headline stats, charts and errors to the output directory when the crawl ends"`
 	LinkSelectors     []string         `json:"linkSelectors,omitempty" jsonschema:"description=CSS selectors to find links (defaults to standard link tags)"`
+	Tags              []string         `json:"tags,omitempty" jsonschema:"description=Free-form labels for organizing and filtering jobs"`
+	Keep              bool             `json:"keep,omitempty" jsonschema:"description=Exempt this job from automatic retention cleanup"`
 	Pagination         *PaginationInput `json:"pagination,omitempty" jsonschema:"description=Click-based pagination settings (browser mode only)"`
 	AntiBot            *AntiBotInput    `json:"antiBot,omitempty" jsonschema:"description=Anti-bot detection evasion settings (browser mode only)"`
+	Geo                *GeoInput        `json:"geo,omitempty" jsonschema:"description=Region, language and proxy settings for capturing region-specific content"`
+	Permissions        *PermissionsInput `json:"permissions,omitempty" jsonschema:"description=Modes of written files and directories"`
 	PageLoadWait       string           `json:"pageLoadWait,omitempty" jsonschema:"description=Time to wait after page load for dynamic content (browser mode, e.g. '500ms' or '2s')"`
+	HARMode            string           `json:"harMode,omitempty" jsonschema:"description=Record network activity as HAR: off (default), page (one _har/<page>.har per page) or crawl (a single crawl.har); browser mode only"`
+	Cassette           string           `json:"cassette,omitempty" jsonschema:"description=Fetch cassette: off (default), record (save every response) or replay (answer every fetch from the cassette without network access)"`
+	CassetteFile       string           `json:"cassetteFile,omitempty" jsonschema:"description=Cassette path (default cassette.jsonl in the output directory)"`
+	Notify             string           `json:"notify,omitempty" jsonschema:"description=Announce the end or failure of the crawl on the server's desktop: off (default), desktop (terminal bell where no notifier is found) or bell"`
+	TraceDecisions     string           `json:"traceDecisions,omitempty" jsonschema:"description=JSON Lines file recording every URL considered and the rule that accepted or rejected it"`
+	ResultsDB          string           `json:"resultsDb,omitempty" jsonschema:"description=SQLite database of pages, links, errors, redirects and metrics samples, relative to the output directory"`
+	DiscoverAPIs       bool             `json:"discoverApis,omitempty" jsonschema:"description=Log XHR/fetch endpoints called by pages to api_endpoints.jsonl (browser mode only)"`
+	PageScripts        []PageScriptInput `json:"pageScripts,omitempty" jsonschema:"description=JavaScript snippets run after load on pages matching a URL regex (browser mode only)"`
+	ContentFilters     []ContentFilterInput `json:"contentFilters,omitempty" jsonschema:"description=Elements stripped (remove) or kept (keep) on pages whose URL matches a regex, before saving and extraction"`
+	FollowOnly         []FollowOnlyInput `json:"followOnly,omitempty" jsonschema:"description=Navigation hubs matched by URL or title regex whose links are followed but which are never saved"`
+	Alerts             []AlertRuleInput `json:"alerts,omitempty" jsonschema:"description=Patterns or keywords watched for in saved pages; matches are recorded in alerts.jsonl and sent as content_alert events"`
+	PluginsDir         string           `json:"pluginsDir,omitempty" jsonschema:"description=Directory of JavaScript plugins defining filterURL, transform and extract hooks"`
+	EventSinks         []EventSinkInput `json:"eventSinks,omitempty" jsonschema:"description=Files, webhooks, NATS subjects, Kafka topics and Elasticsearch indexes the crawl events are also sent to"`
+	Tracing            *TracingInput    `json:"tracing,omitempty" jsonschema:"description=OpenTelemetry spans of each URL's robots check, fetch, parse, extraction and save, exported over OTLP/HTTP"`
+	Embeddings         *EmbeddingsInput `json:"embeddings,omitempty" jsonschema:"description=Vectors of each saved page's text from an OpenAI-compatible embeddings API, for semantic search"`
+	Enrichment         *EnrichmentInput `json:"enrichment,omitempty" jsonschema:"description=HTTP endpoint each saved page's extracted content is POSTed to; its JSON answer is added to the page's .meta.json"`
+	PostSaveCommand     string `json:"postSaveCommand,omitempty" jsonschema:"description=Shell command run for each saved page with the file as $1 and its metadata JSON on stdin"`
+	PostSaveConcurrency int    `json:"postSaveConcurrency,omitempty" jsonschema:"description=Post-save commands running at once (default 2)"`
+	PostSaveTimeout     string `json:"postSaveTimeout,omitempty" jsonschema:"description=Kill a post-save command after this long (default 60s)"`
+	PostSaveOnFailure   string `json:"postSaveOnFailure,omitempty" jsonschema:"description=After a failed post-save command: warn, disable or stop"`
+	WaybackFallback     bool   `json:"waybackFallback,omitempty" jsonschema:"description=Save the latest Wayback Machine snapshot of pages answering 404 or 410 or timing out"`
+	WaybackSave         bool   `json:"waybackSave,omitempty" jsonschema:"description=Submit the URL of each saved page to the Wayback Machine's Save Page Now"`
+	WaybackSaveKey      string `json:"waybackSaveKey,omitempty" jsonschema:"description=archive.org API key as accesskey:secret or secret://name (default $SCRAPER_WAYBACK_KEY)"`
+	WaybackSaveInterval string `json:"waybackSaveInterval,omitempty" jsonschema:"description=Time between Save Page Now submissions (default 10s)"`
+	KeepCookieBanners  bool             `json:"keepCookieBanners,omitempty" jsonschema:"description=Don't dismiss cookie consent banners before capture (browser mode only)"`
+	LazyLoadScroll     bool             `json:"lazyLoadScroll,omitempty" jsonschema:"description=Scroll through each page before capture so lazy-loaded images are saved (browser mode only)"`
+	ScrollStep         int              `json:"scrollStep,omitempty" jsonschema:"description=Pixels per lazy-load scroll step (0 = one viewport)"`
+	ScrollDelay        string           `json:"scrollDelay,omitempty" jsonschema:"description=Pause after each lazy-load scroll step (e.g. '500ms', default '250ms')"`
+	ShadowDOM          string           `json:"shadowDom,omitempty" jsonschema:"description=Save shadow root content: off (default), declarative or flatten (browser mode only)"`
+	BrowserRecycle     int              `json:"browserRecycle,omitempty" jsonschema:"description=Relaunch the browser every N pages, keeping cookies (browser mode only, default: never)"`
+	BrowserPath        string           `json:"browserPath,omitempty" jsonschema:"description=Chrome binary to run (default: a system-installed Chrome found on PATH)"`
+	BrowserMaxMemory   int              `json:"browserMaxMemory,omitempty" jsonschema:"description=Relaunch the browser when it uses more than this many MB (Linux only, default: unlimited)"`
+	BrowserMaxTabs     int              `json:"browserMaxTabs,omitempty" jsonschema:"description=Tabs open at once (default: unlimited)"`
+	BrowserMaxProcesses int             `json:"browserMaxProcesses,omitempty" jsonschema:"description=Chrome processes, renderers included (at least 4, default: unlimited)"`
+	Iframes            string           `json:"iframes,omitempty" jsonschema:"description=Capture same-origin iframe documents: off (default), link or inline"`
+	IframeHosts        []string         `json:"iframeHosts,omitempty" jsonschema:"description=Cross-origin hosts whose iframes are captured too (browser mode only)"`
+	Provenance         string           `json:"provenance,omitempty" jsonschema:"description=Record source URL, fetch time, crawler version and job ID in saved pages: off (default), comment or banner"`
+	ContentAddressable bool             `json:"contentAddressable,omitempty" jsonschema:"description=Store each distinct file once in _blobs/ under its hash with per-URL pointer files"`
 	DisableContentExtraction bool       `json:"disableContentExtraction,omitempty" jsonschema:"description=Disable content extraction (trafilatura) and save raw HTML only"`
 	DisableReadability       bool       `json:"disableReadability,omitempty" jsonschema:"description=Deprecated: use disableContentExtraction instead"`
+	CompressOutput     string           `json:"compressOutput,omitempty" jsonschema:"description=Compress stored HTML files: none (default), gzip or zstd"`
 	NormalizeURLs      *bool            `json:"normalizeUrls,omitempty" jsonschema:"description=Enable URL normalization for better duplicate detection (default: true)"`
 	LowercasePaths     bool             `json:"lowercasePaths,omitempty" jsonschema:"description=Lowercase URL paths during normalization (default: false, use with caution)"`
 }
@@ -39,10 +108,13 @@ type PaginationInput struct {
 	WaitAfterClick  string `json:"waitAfterClick,omitempty" jsonschema:"description=Time to wait after clicking (e.g. '2s')"`
 	WaitSelector    string `json:"waitSelector,omitempty" jsonschema:"description=CSS selector to wait for after click (optional)"`
 	StopOnDuplicate bool   `json:"stopOnDuplicate,omitempty" jsonschema:"description=Stop if duplicate content detected (default: true)"`
+	HarvestURLs     bool   `json:"harvestUrls,omitempty" jsonschema:"description=Queue the URLs clicks navigate to (e.g. via history.pushState) as regular pages"`
 }
 
 // AntiBotInput configures anti-bot detection measures
 type AntiBotInput struct {
+	Profile string `json:"profile,omitempty" jsonschema:"description=Named set of the options below: off, low, standard or aggressive; options set individually are added to it"`
+
 	// Browser Fingerprint Modifications
 	HideWebdriver  bool `json:"hideWebdriver,omitempty" jsonschema:"description=Hide webdriver property to avoid detection"`
 	SpoofPlugins   bool `json:"spoofPlugins,omitempty" jsonschema:"description=Spoof browser plugins"`
@@ -64,6 +136,85 @@ type AntiBotInput struct {
 	Timezone        string `json:"timezone,omitempty" jsonschema:"description=Specific timezone to use (e.g. 'America/New_York')"`
 }
 
+// PageScriptInput is a JavaScript snippet run on pages matching a URL pattern
+type PageScriptInput struct {
+	Pattern string `json:"pattern" jsonschema:"required,description=Regular expression matched against the requested URL"`
+	Script  string `json:"script" jsonschema:"required,description=JavaScript run after load as the body of an async function"`
+}
+
+// ContentFilterInput cleans pages matching a URL pattern before they are saved
+type ContentFilterInput struct {
+	Pattern string `json:"pattern,omitempty" jsonschema:"description=Regular expression matched against the page URL (empty matches every page)"`
+	Keep    string `json:"keep,omitempty" jsonschema:"description=CSS selector of the containers to keep, e.g. main article"`
+	Remove  string `json:"remove,omitempty" jsonschema:"description=CSS selector of the elements to strip, e.g. .ads, .related-posts"`
+}
+
+// FollowOnlyInput marks pages to traverse for links without saving them
+type FollowOnlyInput struct {
+	Pattern string `json:"pattern,omitempty" jsonschema:"description=Regular expression matched against the page URL"`
+	Title   string `json:"title,omitempty" jsonschema:"description=Regular expression matched against the page title"`
+}
+
+// AlertRuleInput watches saved pages for a regular expression or keywords
+type AlertRuleInput struct {
+	Name     string   `json:"name,omitempty" jsonschema:"description=Shown in alerts (default rule N)"`
+	Pattern  string   `json:"pattern,omitempty" jsonschema:"description=Regular expression"`
+	Keywords []string `json:"keywords,omitempty" jsonschema:"description=Words or phrases matched literally, ignoring case"`
+	Target   string   `json:"target,omitempty" jsonschema:"description=text (default) or html"`
+}
+
+// EventSinkInput sends the crawl events somewhere for external monitoring
+type EventSinkInput struct {
+	Type     string         `json:"type" jsonschema:"description=file, webhook, nats, kafka or elasticsearch"`
+	Path     string         `json:"path,omitempty" jsonschema:"description=file: JSON Lines file relative to the output directory (default events.ndjson)"`
+	URL      string         `json:"url,omitempty" jsonschema:"description=webhook: endpoint; nats: server (nats://host:4222); kafka: REST Proxy base URL; elasticsearch: cluster URL"`
+	Topic    string         `json:"topic,omitempty" jsonschema:"description=nats: subject; kafka: topic; elasticsearch: index"`
+	Events   []string       `json:"events,omitempty" jsonschema:"description=Event types sent, e.g. crawl_started, crawl_completed, error (default all but page_saved, which must be listed)"`
+	Payload  string         `json:"payload,omitempty" jsonschema:"description=page_saved events: pointer (URL, title, hash and stored paths, default) or text (also the extracted text, default for elasticsearch)"`
+	Mappings map[string]any `json:"mappings,omitempty" jsonschema:"description=elasticsearch: mappings of the index when the sink creates it"`
+}
+
+// TracingInput configures the export of OpenTelemetry spans
+type TracingInput struct {
+	Endpoint   string  `json:"endpoint,omitempty" jsonschema:"description=OTLP/HTTP collector URL (e.g. http://localhost:4318)"`
+	SampleRate float64 `json:"sampleRate,omitempty" jsonschema:"description=Share of URLs traced from 0 to 1 (default every URL)"`
+}
+
+// EmbeddingsInput configures the embeddings of saved pages
+type EmbeddingsInput struct {
+	Endpoint   string `json:"endpoint,omitempty" jsonschema:"description=API base URL (e.g. https://api.openai.com/v1)"`
+	Model      string `json:"model,omitempty" jsonschema:"description=Embedding model (e.g. text-embedding-3-small)"`
+	APIKey     string `json:"apiKey,omitempty" jsonschema:"description=Bearer token or secret://name (default $SCRAPER_EMBEDDINGS_API_KEY)"`
+	Dimensions int    `json:"dimensions,omitempty" jsonschema:"description=Vector size for models that can shorten their vectors"`
+	Store      string `json:"store,omitempty" jsonschema:"description=file (default, <page>.embedding.json) or results-db (embeddings table of resultsDb)"`
+}
+
+// EnrichmentInput configures the enrichment of saved pages
+type EnrichmentInput struct {
+	Endpoint    string `json:"endpoint,omitempty" jsonschema:"description=URL pages are POSTed to as {url, jobId, metadata, text, html}"`
+	APIKey      string `json:"apiKey,omitempty" jsonschema:"description=Bearer token or secret://name (default $SCRAPER_ENRICHMENT_API_KEY)"`
+	Concurrency int    `json:"concurrency,omitempty" jsonschema:"description=Requests in flight at once (default 2)"`
+	Timeout     string `json:"timeout,omitempty" jsonschema:"description=Per request (e.g. '2m', default '60s')"`
+}
+
+// GeoInput configures region and language emulation
+type GeoInput struct {
+	Region         string `json:"region,omitempty" jsonschema:"description=Region preset (au, br, ca, de, es, fr, gb, in, it, jp, nl, us) filling the fields left empty"`
+	AcceptLanguage string `json:"acceptLanguage,omitempty" jsonschema:"description=Accept-Language header (e.g. 'de-DE,de;q=0.9')"`
+	Locale         string `json:"locale,omitempty" jsonschema:"description=Browser locale (e.g. 'de-DE'; browser mode only)"`
+	Timezone       string `json:"timezone,omitempty" jsonschema:"description=IANA timezone (e.g. 'Europe/Berlin'; browser mode only)"`
+	Geolocation    string `json:"geolocation,omitempty" jsonschema:"description=Latitude and longitude (e.g. '52.52,13.405'; browser mode only)"`
+	Proxy          string `json:"proxy,omitempty" jsonschema:"description=Proxy URL (http, https, socks5 or socks5h; credentials except for SOCKS in browser mode); {region} is replaced by the region code"`
+	ProxyDNS       bool   `json:"proxyDns,omitempty" jsonschema:"description=Resolve host names only through the proxy; the browser makes no DNS lookups of its own"`
+}
+
+// PermissionsInput configures the modes of crawl output; its owner is set
+// for the whole server with -chown
+type PermissionsInput struct {
+	FileMode string `json:"fileMode,omitempty" jsonschema:"description=Octal file mode (e.g. '0664'), applied regardless of the umask"`
+	DirMode  string `json:"dirMode,omitempty" jsonschema:"description=Octal directory mode (e.g. '2775'), applied regardless of the umask"`
+}
+
 // JobIDInput is input for tools that operate on a specific job
 type JobIDInput struct {
 	JobID string `json:"jobId" jsonschema:"required,description=Job ID returned from scraper_start"`
@@ -76,18 +227,57 @@ type WaitInput struct {
 	PollInterval   int    `json:"pollIntervalMs,omitempty" jsonschema:"description=Polling interval in milliseconds (default: 2000)"`
 }
 
+// WorkersInput is input for scraper_set_workers tool
+type WorkersInput struct {
+	JobID   string `json:"jobId" jsonschema:"required,description=Job ID to adjust"`
+	Workers int    `json:"workers" jsonschema:"required,description=New number of simultaneous requests"`
+}
+
+// UpdateConfigInput is input for scraper_update_config tool
+type UpdateConfigInput struct {
+	JobID    string  `json:"jobId" jsonschema:"required,description=Job ID to adjust"`
+	Delay    *string `json:"delay,omitempty" jsonschema:"description=New delay between requests (e.g. '2s')"`
+	Workers  *int    `json:"workers,omitempty" jsonschema:"description=New number of simultaneous requests (concurrent mode only)"`
+	MaxPages *int    `json:"maxPages,omitempty" jsonschema:"description=New page limit (0 = unlimited)"`
+	Verbose  *bool   `json:"verbose,omitempty" jsonschema:"description=Enable or disable debug logging"`
+}
+
+// ExportInput is input for scraper_export tool
+type ExportInput struct {
+	JobID       string `json:"jobId" jsonschema:"required,description=Job ID to export"`
+	Format      string `json:"format,omitempty" jsonschema:"enum=html,enum=epub,description=Export format (default: html)"`
+	Order       string `json:"order,omitempty" jsonschema:"enum=path,enum=crawl,description=Chapter order (default: path)"`
+	Title       string `json:"title,omitempty" jsonschema:"description=Book title (defaults to the output directory name)"`
+	EmbedImages *bool  `json:"embedImages,omitempty" jsonschema:"description=Download and embed images (default: true)"`
+}
+
+// SiteInput is input for scraper_site tool
+type SiteInput struct {
+	JobID       string `json:"jobId" jsonschema:"required,description=Job ID to generate a site for"`
+	Title       string `json:"title,omitempty" jsonschema:"description=Site title (defaults to the output directory name)"`
+	EmbedImages bool   `json:"embedImages,omitempty" jsonschema:"description=Download images into the site (default: false)"`
+}
+
+// ReadFileInput is input for scraper_read_file tool
+type ReadFileInput struct {
+	JobID string `json:"jobId" jsonschema:"required,description=Job ID whose output to read"`
+	Path  string `json:"path" jsonschema:"required,description=Path relative to the output directory"`
+}
+
 // StartCrawlOutput is the response from scraper_start
 type StartCrawlOutput struct {
 	JobID     string `json:"jobId"`
 	Status    string `json:"status"`
 	Message   string `json:"message"`
 	OutputDir string `json:"outputDir,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"` // e.g. scope overlaps with other active jobs
 }
 
 // JobListOutput is the response from scraper_list
 type JobListOutput struct {
-	Jobs  []JobSummary `json:"jobs"`
-	Total int          `json:"total"`
+	Jobs   []JobSummary `json:"jobs"`
+	Total  int          `json:"total"`  // Matching jobs before pagination
+	Offset int          `json:"offset"` // Matching jobs skipped before this page
 }
 
 // JobSummary provides a brief overview of a job
@@ -96,6 +286,8 @@ type JobSummary struct {
 	URL       string    `json:"url"`
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"createdAt"`
+	Tags      []string  `json:"tags,omitempty"`
+	Keep      bool      `json:"keep,omitempty"`
 }
 
 // JobDetailsOutput is the response from scraper_get
@@ -106,8 +298,14 @@ type JobDetailsOutput struct {
 	CreatedAt       time.Time        `json:"createdAt"`
 	StartedAt       *time.Time       `json:"startedAt,omitempty"`
 	CompletedAt     *time.Time       `json:"completedAt,omitempty"`
+	StopReason      string           `json:"stopReason,omitempty"` // Why the crawl ended
+	Tags            []string         `json:"tags,omitempty"`
+	Keep            bool             `json:"keep,omitempty"` // Exempt from retention cleanup
 	Metrics         *MetricsSnapshot `json:"metrics,omitempty"`
 	WaitingForLogin bool             `json:"waitingForLogin,omitempty"`
+	Workers         int              `json:"workers,omitempty"`
+	SSEClients      int              `json:"sseClients"` // HTTP clients streaming this job's events
+	Warnings        []string         `json:"warnings,omitempty"` // Raised when the job started, e.g. scope overlaps with other active jobs
 	OutputDir       string           `json:"outputDir,omitempty"`
 	Error           string           `json:"error,omitempty"`
 }
@@ -122,18 +320,265 @@ type MetricsSnapshot struct {
 	RobotsBlocked   int64   `json:"robotsBlocked"`
 	DepthLimitHits  int64   `json:"depthLimitHits"`
 	ContentFiltered int64   `json:"contentFiltered"`
+	FollowOnly      int64   `json:"followOnly"`
+	StopReason      string  `json:"stopReason,omitempty"`
+	Redirected      int64   `json:"redirected"`
+	RedirectErrors  int64   `json:"redirectErrors"`
+	TimedOut        int64   `json:"timedOut"`
+	BrowserRecycles int64   `json:"browserRecycles"`
+	BrowserCrashes  int64   `json:"browserCrashes"`
 	PagesPerSecond  float64 `json:"pagesPerSecond"`
 	QueueSize       int     `json:"queueSize"`
+	HeapAlloc       int64   `json:"heapAlloc"`
+	PeakHeapAlloc   int64   `json:"peakHeapAlloc"`
+	SysMemory       int64   `json:"sysMemory"`
+	NumGC           int64   `json:"numGC"`
 	ElapsedTime     string  `json:"elapsedTime,omitempty"`
 	Percentage      float64 `json:"percentage,omitempty"`
 	CurrentURL      string  `json:"currentUrl,omitempty"`
+	Depths          []DepthStats `json:"depths,omitempty"` // Discovered, saved and errored URLs per depth
+	Content         ContentStats `json:"content"`          // Status codes, content types, charsets, languages and largest pages
+	Active          []ActiveFetch `json:"active,omitempty"`       // URLs being processed by workers, oldest first
+	RecentErrors    []RecentError `json:"recentErrors,omitempty"` // Latest failed URLs, oldest first
+}
+
+// DepthStats counts the URLs of one depth level
+type DepthStats struct {
+	Depth      int   `json:"depth"`
+	Discovered int64 `json:"discovered"`
+	Saved      int64 `json:"saved"`
+	Errored    int64 `json:"errored"`
+}
+
+// ContentStats describes what a crawl fetched
+type ContentStats struct {
+	StatusCodes  map[string]int64 `json:"statuses,omitempty"`  // Responses per HTTP status code
+	ContentTypes map[string]int64 `json:"types,omitempty"`     // Responses per media type
+	Charsets     map[string]int64 `json:"charsets,omitempty"`  // HTML pages per declared charset
+	Languages    map[string]int64 `json:"languages,omitempty"` // HTML pages per lang attribute
+	LargestPages []PageSize       `json:"largest,omitempty"`   // Largest bodies, largest first
+}
+
+// PageSize is the body size of one fetched URL
+type PageSize struct {
+	URL   string `json:"url"`
+	Bytes int64  `json:"bytes"`
+}
+
+// ActiveFetch is a URL a worker is processing
+type ActiveFetch struct {
+	URL       string    `json:"url"`
+	Started   time.Time `json:"started"`
+	Clicks    int       `json:"clicks,omitempty"`    // Pagination clicks done, for URLs paginated in browser mode
+	MaxClicks int       `json:"maxClicks,omitempty"` // Pagination clicks allowed
+}
+
+// RecentError is one of the latest URLs that failed
+type RecentError struct {
+	URL    string    `json:"url"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
 }
 
 // MetricsOutput is the response from scraper_metrics
 type MetricsOutput struct {
-	JobID   string           `json:"jobId"`
-	Status  string           `json:"status"`
-	Metrics *MetricsSnapshot `json:"metrics,omitempty"`
+	JobID           string           `json:"jobId"`
+	Status          string           `json:"status"`
+	Metrics         *MetricsSnapshot `json:"metrics,omitempty"`
+	IntervalSeconds float64          `json:"intervalSeconds,omitempty"`
+	Timeseries      []MetricsSample  `json:"timeseries,omitempty"`
+}
+
+// MetricsSample is one point of a job's metrics time series
+type MetricsSample struct {
+	Timestamp       time.Time `json:"timestamp"`
+	ElapsedSeconds  float64   `json:"elapsedSeconds"`
+	URLsProcessed   int64     `json:"urlsProcessed"`
+	URLsSaved       int64     `json:"urlsSaved"`
+	URLsSkipped     int64     `json:"urlsSkipped"`
+	URLsErrored     int64     `json:"urlsErrored"`
+	QueueSize       int       `json:"queueSize"`
+	BytesDownloaded int64     `json:"bytesDownloaded"`
+	PagesPerSecond  float64   `json:"pagesPerSecond"` // Throughput since the previous sample
+	HeapAlloc       int64     `json:"heapAlloc"`
+}
+
+// URLsOutput is the response from scraper_urls
+type URLsOutput struct {
+	JobID  string         `json:"jobId"`
+	Total  int            `json:"total"` // Matching URLs before limit and offset
+	Offset int            `json:"offset"`
+	Counts map[string]int `json:"counts"` // URLs per status across the whole inventory
+	URLs   []URLRecord    `json:"urls"`
+}
+
+// URLErrorsOutput is the response from scraper_url_errors
+type URLErrorsOutput struct {
+	JobID  string         `json:"jobId"`
+	Total  int            `json:"total"`  // Matching errored URLs before limit
+	Counts map[string]int `json:"counts"` // Errored URLs per error class
+	Errors []URLError     `json:"errors"`
+}
+
+// URLError is an errored URL of a job
+type URLError struct {
+	URL        string `json:"url"`
+	Class      string `json:"class"` // auth, not-found, rate-limited, client, server, timeout, network, redirect, browser, parse, save or other
+	Reason     string `json:"reason"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	Depth      int    `json:"depth"`
+	Referrer   string `json:"referrer,omitempty"` // Page the URL was first found on
+}
+
+// CrawlGraphOutput is the response from scraper_crawl_graph
+type CrawlGraphOutput struct {
+	JobID   string      `json:"jobId"`
+	Version uint64      `json:"version"` // Pass as since to get the next changes
+	Total   int         `json:"total"`   // URLs encountered
+	Omitted int         `json:"omitted"` // URLs left off the map past maxNodes
+	Nodes   []GraphNode `json:"nodes"`   // In discovery order
+}
+
+// GraphNode is a URL on the crawl map
+type GraphNode struct {
+	URL        string `json:"url"`
+	Parent     string `json:"parent,omitempty"` // Page the URL was first found on, when it is on the map
+	Depth      int    `json:"depth"`
+	Status     string `json:"status"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+}
+
+// RedirectsOutput is the response from scraper_redirects
+type RedirectsOutput struct {
+	JobID          string            `json:"jobId"`
+	TotalRedirects int               `json:"totalRedirects"`
+	Redirects      []Redirect        `json:"redirects"` // Up to limit, sorted by source URL
+	Failures       []RedirectFailure `json:"failures"`  // Redirect loops and overly long chains
+	Aliases        map[string]string `json:"aliases,omitempty"`
+}
+
+// Redirect is one followed redirect hop
+type Redirect struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Status    int    `json:"status"`
+	Permanent bool   `json:"permanent"`
+}
+
+// RedirectFailure is a URL abandoned because of its redirect chain
+type RedirectFailure struct {
+	URL    string     `json:"url"`
+	Reason string     `json:"reason"` // "redirect loop" or "too many redirects"
+	Chain  []Redirect `json:"chain"`
+}
+
+// APIEndpointsOutput is the response from scraper_api_endpoints
+type APIEndpointsOutput struct {
+	JobID     string        `json:"jobId"`
+	Total     int           `json:"total"`     // Matching endpoints before limit
+	Endpoints []APIEndpoint `json:"endpoints"` // In discovery order
+}
+
+// APIEndpoint is an XHR or fetch endpoint called by crawled pages
+type APIEndpoint struct {
+	Method      string   `json:"method"`
+	URL         string   `json:"url"` // Without query string
+	ContentType string   `json:"contentType,omitempty"`
+	Status      int      `json:"status,omitempty"`
+	QueryParams []string `json:"queryParams,omitempty"`
+	Example     string   `json:"example"`
+	Calls       int      `json:"calls"`
+	Pages       int      `json:"pages"`
+	FirstPage   string   `json:"firstPage"`
+}
+
+// AlertsOutput is the response from scraper_alerts
+type AlertsOutput struct {
+	JobID  string  `json:"jobId"`
+	Total  int     `json:"total"`  // Matching alerts before limit
+	Alerts []Alert `json:"alerts"` // In the order pages were saved
+}
+
+// Alert is a saved page matching an alert rule
+type Alert struct {
+	Time    time.Time    `json:"time"`
+	URL     string       `json:"url"`
+	Rule    string       `json:"rule"`
+	Count   int          `json:"count"`   // Matches in the page
+	Matches []AlertMatch `json:"matches"` // The first ones
+}
+
+// AlertMatch is a matching piece of a page with the text around it
+type AlertMatch struct {
+	Text    string `json:"text"`
+	Context string `json:"context"`
+}
+
+// ExternalLinksOutput is the response from scraper_external_links
+type ExternalLinksOutput struct {
+	JobID string         `json:"jobId"`
+	Total int            `json:"total"` // Matching links before limit
+	Links []ExternalLink `json:"links"` // In discovery order
+}
+
+// ExternalLink is a link found on a crawled page to a URL outside the crawl's scope
+type ExternalLink struct {
+	URL      string `json:"url"`
+	Text     string `json:"text,omitempty"`    // Anchor text
+	Heading  string `json:"heading,omitempty"` // Nearest heading before the link
+	Rel      string `json:"rel,omitempty"`     // rel attribute
+	Referrer string `json:"referrer"`          // Page the link was found on
+	Reason   string `json:"reason"`            // host or prefix
+}
+
+// ManifestOutput is the response from scraper_manifest
+type ManifestOutput struct {
+	JobID      string         `json:"jobId"`
+	Pages      []ManifestPage `json:"pages"`      // In the order they were saved
+	NextOffset int64          `json:"nextOffset"` // Offset to read the pages saved after these from
+	Done       bool           `json:"done"`       // The job has finished, no pages follow
+}
+
+// ManifestPage is a saved page of the manifest
+type ManifestPage struct {
+	URL         string    `json:"url"`
+	Path        string    `json:"path"`                  // Stored HTML file, relative to the output directory
+	ContentPath string    `json:"contentPath,omitempty"` // Stored .content.html file
+	Hash        string    `json:"hash"`                  // SHA-256 of the stored HTML
+	Size        int64     `json:"size"`
+	Title       string    `json:"title,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// URLRecord is one URL of a job's inventory
+type URLRecord struct {
+	URL         string `json:"url"`
+	Status      string `json:"status"`
+	Depth       int    `json:"depth"`
+	Referrer    string `json:"referrer,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+	HTTPStatus  int    `json:"httpStatus,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	AnchorText  string `json:"anchorText,omitempty"` // Text of the link on the referrer
+	Heading     string `json:"heading,omitempty"`    // Nearest heading before that link
+	Rel         string `json:"rel,omitempty"`        // rel attribute of that link
+}
+
+// EventsOutput is the response from scraper_events
+type EventsOutput struct {
+	JobID       string        `json:"jobId"`
+	Status      string        `json:"status"`
+	Events      []EventOutput `json:"events"`
+	LastEventID uint64        `json:"lastEventId"` // Pass as since to fetch only newer events
+}
+
+// EventOutput is a single buffered job event
+type EventOutput struct {
+	ID        uint64      `json:"id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
 }
 
 // StatusOutput is a generic status response
@@ -145,12 +590,177 @@ type StatusOutput struct {
 
 // WaitOutput is the response from scraper_wait
 type WaitOutput struct {
-	JobID         string           `json:"jobId"`
-	Status        string           `json:"status"`
-	FinalMetrics  *MetricsSnapshot `json:"finalMetrics,omitempty"`
-	OutputDir     string           `json:"outputDir,omitempty"`
-	Error         string           `json:"error,omitempty"`
-	WaitedSeconds int              `json:"waitedSeconds"`
+	JobID          string           `json:"jobId"`
+	Status         string           `json:"status"`
+	Reason         string           `json:"reason"` // completed, stalled, percentage, timeout or cancelled
+	FinalMetrics   *MetricsSnapshot `json:"finalMetrics,omitempty"`
+	Metrics        *MetricsSnapshot `json:"metrics,omitempty"`        // Latest metrics when returning before the job finished
+	EtaSeconds     float64          `json:"etaSeconds,omitempty"`     // Estimated seconds to drain the queue at the current rate
+	StalledSeconds int              `json:"stalledSeconds,omitempty"` // Seconds without a processed URL when stalled
+	Snapshots      []WaitSnapshot   `json:"snapshots,omitempty"`
+	OutputDir      string           `json:"outputDir,omitempty"`
+	Error          string           `json:"error,omitempty"`
+	WaitedSeconds  int              `json:"waitedSeconds"`
+}
+
+// WaitSnapshot is the progress of a job at one point of a scraper_wait call
+type WaitSnapshot struct {
+	ElapsedSeconds float64 `json:"elapsedSeconds"` // Seconds since the wait began
+	Status         string  `json:"status"`
+	URLsProcessed  int64   `json:"urlsProcessed"`
+	URLsSaved      int64   `json:"urlsSaved"`
+	URLsErrored    int64   `json:"urlsErrored"`
+	QueueSize      int     `json:"queueSize"`
+	PagesPerSecond float64 `json:"pagesPerSecond"`
+	Percentage     float64 `json:"percentage"`
+}
+
+// KeepOutput is the response from scraper_keep
+type KeepOutput struct {
+	JobID   string `json:"jobId"`
+	Keep    bool   `json:"keep"`
+	Message string `json:"message"`
+}
+
+// WorkersOutput is the response from scraper_set_workers
+type WorkersOutput struct {
+	JobID   string `json:"jobId"`
+	Workers int    `json:"workers"`
+	Message string `json:"message"`
+}
+
+// UpdateConfigOutput is the response from scraper_update_config
+type UpdateConfigOutput struct {
+	JobID    string `json:"jobId"`
+	Delay    string `json:"delay"`
+	Workers  int    `json:"workers"`
+	MaxPages int    `json:"maxPages"`
+	Verbose  bool   `json:"verbose"`
+	Message  string `json:"message"`
+}
+
+// ExportOutput is the response from scraper_export
+type ExportOutput struct {
+	JobID    string `json:"jobId"`
+	Path     string `json:"path"`
+	Format   string `json:"format"`
+	Chapters int    `json:"chapters"`
+	Images   int    `json:"images"`
+	Size     int64  `json:"size"`
+}
+
+// SiteOutput is the response from scraper_site
+type SiteOutput struct {
+	JobID  string `json:"jobId"`
+	Path   string `json:"path"`
+	Pages  int    `json:"pages"`
+	Images int    `json:"images"`
+}
+
+// SEOAuditOutput is the response from scraper_seo_audit
+type SEOAuditOutput struct {
+	JobID           string         `json:"jobId"`
+	HTMLPath        string         `json:"htmlPath"`
+	JSONPath        string         `json:"jsonPath"`
+	Pages           int            `json:"pages"`
+	TotalIssues     int            `json:"totalIssues"`
+	IssueCounts     map[string]int `json:"issueCounts"`
+	BrokenLinkCheck bool           `json:"brokenLinkCheck"` // False when the crawl has no URL inventory
+	Issues          []SEOIssue     `json:"issues"`          // Most severe first, up to limit
+}
+
+// SEOIssue is a single problem found by the SEO audit
+type SEOIssue struct {
+	Type    string   `json:"type"`
+	URL     string   `json:"url"`
+	Detail  string   `json:"detail,omitempty"`
+	Target  string   `json:"target,omitempty"`
+	Related []string `json:"related,omitempty"`
+}
+
+// AccessibilityAuditOutput is the response from scraper_accessibility_audit
+type AccessibilityAuditOutput struct {
+	JobID         string              `json:"jobId"`
+	HTMLPath      string              `json:"htmlPath"`
+	JSONPath      string              `json:"jsonPath"`
+	Pages         int                 `json:"pages"`
+	PagesAffected int                 `json:"pagesAffected"`
+	TotalIssues   int                 `json:"totalIssues"`
+	IssueCounts   map[string]int      `json:"issueCounts"`
+	PageDetails   []AccessibilityPage `json:"pageDetails"` // Pages with issues, most first, up to limit
+}
+
+// AccessibilityPage holds the accessibility issue counts of one page
+type AccessibilityPage struct {
+	URL      string         `json:"url"`
+	Issues   int            `json:"issues"`
+	Counts   map[string]int `json:"counts"`
+	Examples []string       `json:"examples,omitempty"`
+}
+
+// DuplicatesOutput is the response from scraper_duplicates
+type DuplicatesOutput struct {
+	JobID          string             `json:"jobId"`
+	HTMLPath       string             `json:"htmlPath"`
+	JSONPath       string             `json:"jsonPath"`
+	Pages          int                `json:"pages"`
+	SkippedPages   int                `json:"skippedPages"` // Too little text to compare
+	Threshold      int                `json:"threshold"`
+	DuplicatePages int                `json:"duplicatePages"`
+	TotalClusters  int                `json:"totalClusters"`
+	ParamCounts    map[string]int     `json:"paramCounts"` // Duplicate pages per varying query parameter
+	Clusters       []DuplicateCluster `json:"clusters"`    // Largest first, up to limit
+}
+
+// DuplicateCluster is a group of pages with near-identical content
+type DuplicateCluster struct {
+	Size           int      `json:"size"`
+	Representative string   `json:"representative"`
+	Title          string   `json:"title"`
+	URLs           []string `json:"urls"`
+	SameTitle      bool     `json:"sameTitle"`
+	VaryingParams  []string `json:"varyingParams"`
+}
+
+// IndexOutput is the response from scraper_index
+type IndexOutput struct {
+	JobID     string      `json:"jobId"`
+	IndexFile string      `json:"indexFile"` // Path of the regenerated _index.html
+	Total     int         `json:"total"`     // Indexed pages before limit
+	Pages     []IndexPage `json:"pages"`     // Sorted by URL
+}
+
+// IndexPage is one captured page of a job
+type IndexPage struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Excerpt     string `json:"excerpt,omitempty"`     // Start of the extracted text
+	Path        string `json:"path"`                  // Raw HTML, relative to the output directory
+	ContentPath string `json:"contentPath,omitempty"` // Extracted content, relative to the output directory
+}
+
+// ReprocessOutput is the response from scraper_reprocess
+type ReprocessOutput struct {
+	JobID     string `json:"jobId"`
+	Pages     int    `json:"pages"`     // Saved pages found
+	Extracted int    `json:"extracted"` // Pages with extracted content afterwards
+	Changed   int    `json:"changed"`   // Pages whose content file or metadata was rewritten
+	Failed    int    `json:"failed"`    // Pages that could not be read or written
+	IndexFile string `json:"indexFile"` // Path of the regenerated _index.html
+}
+
+// ListFilesOutput is the response from scraper_list_files
+type ListFilesOutput struct {
+	JobID   string      `json:"jobId"`
+	Path    string      `json:"path"`
+	Entries []FileEntry `json:"entries"` // Directories first, each group sorted by name
+}
+
+// FileEntry is a file or directory in a job's output
+type FileEntry struct {
+	Name string `json:"name"`
+	Dir  bool   `json:"dir"`
+	Size int64  `json:"size"` // 0 for directories
 }
 
 // ErrorOutput represents an error response
@@ -159,3 +769,145 @@ type ErrorOutput struct {
 	Code    int    `json:"code,omitempty"`
 	Details string `json:"details,omitempty"`
 }
+
+// SecretsOutput is the response from scraper_secrets
+type SecretsOutput struct {
+	Names   []string `json:"names"` // Stored secret names, sorted
+	Message string   `json:"message,omitempty"`
+}
+
+// SelfTestOutput is the response from scraper_selftest
+type SelfTestOutput struct {
+	Passed  bool            `json:"passed"` // Whether every check passed
+	Results []SelfTestCheck `json:"results"`
+}
+
+// SelfTestCheck is the outcome of one self-test check
+type SelfTestCheck struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Passed      bool    `json:"passed"`
+	Seconds     float64 `json:"seconds"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// AntiBotTestOutput is the response from scraper_antibot_test
+type AntiBotTestOutput struct {
+	URL      string              `json:"url,omitempty"` // Empty for the bundled page
+	Profile  string              `json:"profile"`
+	Headless bool                `json:"headless"`
+	Leaks    int                 `json:"leaks"` // Signals that give the browser away
+	Signals  []AntiBotTestSignal `json:"signals"`
+}
+
+// AntiBotTestSignal is one fingerprint signal as the probed page saw it
+type AntiBotTestSignal struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Leaks bool   `json:"leaks"`
+	Fix   string `json:"fix,omitempty"` // Setting that hides it, when it leaks
+}
+
+// FetchPageOutput is the response from scraper_fetch_page
+type FetchPageOutput struct {
+	URL         string `json:"url"`
+	FinalURL    string `json:"finalUrl,omitempty"` // URL after redirects, when different
+	StatusCode  int    `json:"statusCode"`
+	ContentType string `json:"contentType,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Date        string `json:"date,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Extracted   bool   `json:"extracted"` // Main content found; otherwise the whole page is returned
+	Words       int    `json:"words"`
+	Markdown    string `json:"markdown,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"` // Content cut at maxChars
+	Links       []FetchPageLink `json:"links"`      // Links on the page, up to maxLinks
+	TotalLinks  int             `json:"totalLinks"` // Links on the page in total
+}
+
+// FetchPageLink is a link found by scraper_fetch_page
+type FetchPageLink struct {
+	URL     string `json:"url"`
+	Text    string `json:"text,omitempty"`
+	Heading string `json:"heading,omitempty"` // Nearest heading before the link
+	Rel     string `json:"rel,omitempty"`
+	Reason  string `json:"reason,omitempty"` // Why a crawl with the same settings would not follow it: prefix, host, extension
+}
+
+// PlanOutput is the response from scraper_plan
+type PlanOutput struct {
+	Pages      []PlannedPage  `json:"pages"`      // Pages fetched for the preview
+	Links      []PlannedLink  `json:"links"`      // Links found, up to the limit
+	TotalLinks int            `json:"totalLinks"` // Links found in total
+	Queued     int            `json:"queued"`     // Links the crawl would queue
+	Filtered   map[string]int `json:"filtered"`   // Links filtered out, by reason
+}
+
+// PlannedPage is a page fetched by scraper_plan
+type PlannedPage struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+	Error string `json:"error,omitempty"`
+	Links int    `json:"links"` // Links first found on this page
+}
+
+// PlannedLink is a link found by scraper_plan
+type PlannedLink struct {
+	URL    string `json:"url"`
+	Source string `json:"source"` // Page the link was found on
+	Depth  int    `json:"depth"`
+	Queued bool   `json:"queued"`
+	Reason string `json:"reason,omitempty"` // Why the link is not queued: prefix, host, extension, scheme, robots, selector, depth or invalid
+}
+
+// AuditOutput is the response from scraper_audit
+type AuditOutput struct {
+	Total   int          `json:"total"`
+	Entries []AuditEntry `json:"entries"` // Most recent first
+}
+
+// AuditEntry is one recorded operation: an MCP tool call (key "mcp") or,
+// when the API server shares the audit log, an API request
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Key      string    `json:"key"`
+	ClientIP string    `json:"clientIp,omitempty"`
+	Action   string    `json:"action"` // Tool name, or method and route of API requests
+	Path     string    `json:"path,omitempty"`
+	JobID    string    `json:"jobId,omitempty"`
+	Status   int       `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Summary  string    `json:"summary,omitempty"`
+}
+
+// UsageOutput is the response from scraper_usage
+type UsageOutput struct {
+	Keys []KeyUsage `json:"keys"` // Sorted by key name
+}
+
+// KeyUsage is what the jobs of one API key fetched (days and months in UTC)
+type KeyUsage struct {
+	Key        string       `json:"key"`
+	Day        string       `json:"day"`
+	DayBytes   int64        `json:"dayBytes"`
+	DayPages   int64        `json:"dayPages"`
+	Month      string       `json:"month"`
+	MonthBytes int64        `json:"monthBytes"`
+	MonthPages int64        `json:"monthPages"`
+	TotalBytes int64        `json:"totalBytes"`
+	TotalPages int64        `json:"totalPages"`
+	Quota      *QuotaOutput `json:"quota,omitempty"`
+	Exceeded   string       `json:"exceeded,omitempty"` // Limit reached, if any
+	ActiveJobs int          `json:"activeJobs"`
+}
+
+// QuotaOutput is the quota of an API key (0 = unlimited)
+type QuotaOutput struct {
+	DailyBytes   int64 `json:"dailyBytes,omitempty"`
+	DailyPages   int64 `json:"dailyPages,omitempty"`
+	MonthlyBytes int64 `json:"monthlyBytes,omitempty"`
+	MonthlyPages int64 `json:"monthlyPages,omitempty"`
+}
@@ -27,6 +27,16 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        application.Startup,
+		OnShutdown:       application.Shutdown,
+		// In background mode closing the window hides it; starting the
+		// app again shows it
+		OnBeforeClose: application.BeforeClose,
+		SingleInstanceLock: &options.SingleInstanceLock{
+			UniqueId: "scraper-desktop-3f0b9c2e",
+			OnSecondInstanceLaunch: func(options.SecondInstanceData) {
+				application.ShowWindow()
+			},
+		},
 		Bind: []interface{}{
 			application,
 		},
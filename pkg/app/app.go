@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
-	"sort"
 	"sync"
 	"time"
 
+	"scraper/internal/api"
 	"scraper/internal/crawler"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -23,6 +24,16 @@ type App struct {
 	cancel  context.CancelFunc
 	mu      sync.Mutex
 	running bool
+
+	lastOutputDir string // output directory of the most recent crawl
+
+	browseURL string // address of the output browsing server, once started
+	browseDir string // output directory it serves
+
+	scheduler  *api.Scheduler // runs the shared schedules while the app is open
+	background bool           // closing the window hides it instead of quitting
+	hidden     bool           // the window is hidden in background mode
+	quitting   bool           // Quit was called, so closing is not prevented
 }
 
 // NewApp creates a new App instance
@@ -33,6 +44,7 @@ func NewApp() *App {
 // Startup is called when the app starts
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+	a.startScheduler()
 }
 
 // Emit implements EventEmitter interface
@@ -44,22 +56,72 @@ func (a *App) Emit(event crawler.CrawlerEvent) {
 type CrawlConfig struct {
 	URL                string `json:"url"`
 	Concurrent         bool   `json:"concurrent"`
+	Workers            int    `json:"workers"`
 	Delay              string `json:"delay"`
 	MaxDepth           int    `json:"maxDepth"`
+	DepthMode          string `json:"depthMode"`
+	MaxPages           int    `json:"maxPages"`
+	MaxRuntime         string `json:"maxRuntime"`           // Stop after crawling this long, e.g. "30m" (unlimited when empty)
+	MaxConsecutiveErrors int  `json:"maxConsecutiveErrors"` // Stop after N fetches failed in a row (0 = never)
+	URLTimeout         string `json:"urlTimeout"`           // Give up on a URL not processed within this long, e.g. "45s" (none when empty)
+	StopPattern        string `json:"stopPattern"`          // Stop once every discovered URL matching this regex was fetched
+	MaxHTMLSize        int64  `json:"maxHtmlSize"`
 	OutputDir          string `json:"outputDir"`
 	StateFile          string `json:"stateFile"`
+	StateMerge         bool   `json:"stateMerge"` // Merge a state file saved for another start URL
+	FrontierLimit      int    `json:"frontierLimit"` // Queued URLs kept in memory, the rest spilled to disk (0 = unlimited)
 	PrefixFilterURL    string `json:"prefixFilter"`
+	AllowedHosts       string `json:"allowedHosts"` // Comma-separated; *.example.com matches subdomains
+	DeniedHosts        string `json:"deniedHosts"`
+	ExternalLinks      bool   `json:"externalLinks"` // Record out-of-scope links to external_links.jsonl
+	Report             bool   `json:"report"`        // Write report.html when the crawl ends
 	ExcludeExtensions  string `json:"excludeExtensions"`
 	LinkSelectors      string `json:"linkSelectors"`
 	Verbose            bool   `json:"verbose"`
 	UserAgent          string `json:"userAgent"`
+	UserAgentPool      string `json:"userAgentPool"`   // Built-in set cycled through with rotateUserAgent
+	UserAgents         string `json:"userAgents"`      // Own user agents to cycle through, one per line
+	StickyUserAgent    bool   `json:"stickyUserAgent"` // Keep one user agent per host
+	WarmUp             bool   `json:"warmUp"`          // Visit each host's homepage before its deeper URLs
+	WarmUpPages        int    `json:"warmUpPages"`     // Pages linked from the homepage also visited during warm-up
+	RefererPolicy      string `json:"refererPolicy"`   // none, origin, strict or full ("" = strict with warm-up)
 	IgnoreRobots       bool   `json:"ignoreRobots"`
+	Polite             bool   `json:"polite"` // Enforce polite mode: robots.txt, delay floor, per-host concurrency, contactable user agent
 	MinContentLength   int    `json:"minContent"`
+	MaxContentLength   int     `json:"maxContent"`
+	MinWords           int     `json:"minWords"`
+	MaxWords           int     `json:"maxWords"`
+	MaxLinkDensity     float64 `json:"maxLinkDensity"`
 	DisableContentExtraction bool `json:"disableContentExtraction"`
+	CompressOutput     string `json:"compressOutput"`
 	FetchMode          string `json:"fetchMode"`
 	Headless           bool   `json:"headless"`
 	WaitForLogin       bool   `json:"waitForLogin"`
 	PageLoadWait       string `json:"pageLoadWait"`
+	HARMode            string `json:"harMode"`
+	DiscoverAPIs       bool   `json:"discoverApis"`
+	PageScripts        []crawler.PageScript `json:"pageScripts"` // JavaScript run after load on matching pages
+	ContentFilters     []crawler.ContentFilter `json:"contentFilters"` // Elements stripped or kept on matching pages before saving
+	FollowOnly         []crawler.FollowOnlyRule `json:"followOnly"` // Pages traversed for links but never saved
+	Alerts             []crawler.AlertRule `json:"alerts"` // Patterns or keywords watched for in saved pages
+	PluginsDir         string `json:"pluginsDir"` // Directory of JavaScript plugins filtering URLs, transforming and extracting from pages
+	EventSinks         []crawler.EventSink `json:"eventSinks"` // Files, webhooks, NATS subjects, Kafka topics and Elasticsearch indexes the crawl events are also sent to
+	KeepCookieBanners  bool   `json:"keepCookieBanners"`
+	LazyLoadScroll     bool   `json:"lazyLoadScroll"`
+	ScrollStep         int    `json:"scrollStep"`  // Pixels per lazy-load scroll step, 0 = one viewport
+	ScrollDelay        string `json:"scrollDelay"` // Pause after each lazy-load scroll step
+	ShadowDOM          string `json:"shadowDom"`   // "off", "declarative" or "flatten"
+	BrowserRecycle     int    `json:"browserRecycle"` // Relaunch the browser every N pages, 0 = never
+	BrowserPath        string `json:"browserPath"`    // Chrome binary, "" = system-installed
+	BrowserMaxMemory   int    `json:"browserMaxMemory"`    // MB before the browser is relaunched, 0 = unlimited
+	BrowserMaxTabs     int    `json:"browserMaxTabs"`      // Tabs open at once, 0 = unlimited
+	BrowserMaxProcesses int   `json:"browserMaxProcesses"` // Chrome processes, 0 = unlimited
+	Iframes            string `json:"iframes"`     // "off", "link" or "inline"
+	IframeHosts        string `json:"iframeHosts"` // Comma-separated cross-origin hosts whose iframes are captured
+	Provenance         string `json:"provenance"`  // "off", "comment" or "banner"
+	ContentAddressable bool   `json:"contentAddressable"`
+	IndexInterval      int    `json:"indexInterval"`
+	MetricsInterval    string `json:"metricsInterval"`
 	// Pagination settings
 	EnablePagination          bool   `json:"enablePagination"`
 	PaginationSelector        string `json:"paginationSelector"`
@@ -67,7 +129,9 @@ type CrawlConfig struct {
 	PaginationWait            string `json:"paginationWait"`
 	PaginationWaitSelector    string `json:"paginationWaitSelector"`
 	PaginationStopOnDuplicate bool   `json:"paginationStopOnDuplicate"`
+	PaginationHarvestURLs     bool   `json:"paginationHarvestUrls"`
 	// Anti-bot settings
+	AntiBotProfile       string `json:"antiBotProfile"` // off, low, standard or aggressive; the options below add to it
 	HideWebdriver        bool   `json:"hideWebdriver"`
 	SpoofPlugins         bool   `json:"spoofPlugins"`
 	SpoofLanguages       bool   `json:"spoofLanguages"`
@@ -82,20 +146,90 @@ type CrawlConfig struct {
 	RandomViewport       bool   `json:"randomViewport"`
 	MatchTimezone        bool   `json:"matchTimezone"`
 	Timezone             string `json:"timezone"`
+	// Region emulation settings
+	Region         string `json:"region"`
+	AcceptLanguage string `json:"acceptLanguage"`
+	Locale         string `json:"locale"`
+	GeoTimezone    string `json:"geoTimezone"`
+	Geolocation    string `json:"geolocation"`
+	Proxy          string `json:"proxy"`
+	ProxyDNS       bool   `json:"proxyDns"`
+	// Output permission settings
+	FileMode string `json:"fileMode"`
+	DirMode  string `json:"dirMode"`
+	Owner    string `json:"owner"`
 	// URL normalization settings
 	NormalizeURLs  bool `json:"normalizeUrls"`
 	LowercasePaths bool `json:"lowercasePaths"`
+	// Re-crawl of the previous crawl in the output directory: "failed", "changed" or "pattern"
+	RecrawlScope   string `json:"recrawlScope"`
+	RecrawlPattern string `json:"recrawlPattern"`
+	// Record-and-replay: "off", "record" or "replay", and the cassette path (default in the output directory)
+	Cassette     string `json:"cassette"`
+	CassetteFile string `json:"cassetteFile"`
+	// Announce the end or failure of the crawl: "off", "desktop" or "bell"
+	Notify string `json:"notify"`
+	// JSON Lines file recording every URL considered and the rule that accepted or rejected it
+	TraceDecisions string `json:"traceDecisions"`
+	// SQLite database of pages, links, errors, redirects and metrics samples, relative to the output folder
+	ResultsDB string `json:"resultsDb"`
+	// OpenTelemetry collector the spans of each URL are exported to, and the share of URLs traced
+	OTLPEndpoint    string  `json:"otlpEndpoint"`
+	TraceSampleRate float64 `json:"traceSampleRate"`
+	// OpenAI-compatible endpoint the text of each saved page is embedded with; the key may be secret://name
+	EmbeddingsEndpoint   string `json:"embeddingsEndpoint"`
+	EmbeddingsModel      string `json:"embeddingsModel"`
+	EmbeddingsAPIKey     string `json:"embeddingsApiKey"`
+	EmbeddingsDimensions int    `json:"embeddingsDimensions"`
+	EmbeddingsStore      string `json:"embeddingsStore"`
+	// HTTP endpoint the extracted content of each saved page is POSTed to; its JSON answer goes into the .meta.json
+	EnrichmentEndpoint    string `json:"enrichmentEndpoint"`
+	EnrichmentAPIKey      string `json:"enrichmentApiKey"`
+	EnrichmentConcurrency int    `json:"enrichmentConcurrency"`
+	EnrichmentTimeout     string `json:"enrichmentTimeout"`
+	// Shell command run for each saved page with the file as $1 and its metadata JSON on stdin
+	PostSaveCommand     string `json:"postSaveCommand"`
+	PostSaveConcurrency int    `json:"postSaveConcurrency"`
+	PostSaveTimeout     string `json:"postSaveTimeout"`   // e.g. "2m" (default 60s)
+	PostSaveOnFailure   string `json:"postSaveOnFailure"` // warn (default), disable or stop
+	// Save the latest Wayback Machine snapshot of pages answering 404 or 410 or timing out
+	WaybackFallback bool `json:"waybackFallback"`
+	// Submit the URL of each saved page to the Wayback Machine's Save Page Now
+	WaybackSave         bool   `json:"waybackSave"`
+	WaybackSaveKey      string `json:"waybackSaveKey"`      // accesskey:secret or secret://name
+	WaybackSaveInterval string `json:"waybackSaveInterval"` // e.g. "30s" (default 10s)
+	// Fault injection, for development
+	FaultLatency      string  `json:"faultLatency"`
+	FaultErrorRate    float64 `json:"faultErrorRate"`
+	FaultTruncateRate float64 `json:"faultTruncateRate"`
+	FaultResetRate    float64 `json:"faultResetRate"`
+	FaultSeed         int64   `json:"faultSeed"`
 }
 
-// StartCrawl starts the crawler with the given configuration
-func (a *App) StartCrawl(cfg CrawlConfig) error {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	if a.running {
-		return fmt.Errorf("crawler is already running")
+// antiBotConfigOf returns the anti-bot settings of the form
+func antiBotConfigOf(cfg CrawlConfig) crawler.AntiBotConfig {
+	return crawler.AntiBotConfig{
+		Profile:              cfg.AntiBotProfile,
+		HideWebdriver:        cfg.HideWebdriver,
+		SpoofPlugins:         cfg.SpoofPlugins,
+		SpoofLanguages:       cfg.SpoofLanguages,
+		SpoofWebGL:           cfg.SpoofWebGL,
+		AddCanvasNoise:       cfg.AddCanvasNoise,
+		NaturalMouseMovement: cfg.NaturalMouseMovement,
+		RandomTypingDelays:   cfg.RandomTypingDelays,
+		NaturalScrolling:     cfg.NaturalScrolling,
+		RandomActionDelays:   cfg.RandomActionDelays,
+		RandomClickOffset:    cfg.RandomClickOffset,
+		RotateUserAgent:      cfg.RotateUserAgent,
+		RandomViewport:       cfg.RandomViewport,
+		MatchTimezone:        cfg.MatchTimezone,
+		Timezone:             cfg.Timezone,
 	}
+}
 
+// StartCrawl starts the crawler with the given configuration
+// buildConfig converts the settings of the form to a validated crawler config
+func buildConfig(cfg CrawlConfig) (crawler.Config, error) {
 	// Parse delay duration
 	delay, err := time.ParseDuration(cfg.Delay)
 	if err != nil {
@@ -118,22 +252,87 @@ func (a *App) StartCrawl(cfg CrawlConfig) error {
 		pageLoadWait = waitDuration
 	}
 
-	// Build anti-bot config
-	antiBotConfig := crawler.AntiBotConfig{
-		HideWebdriver:        cfg.HideWebdriver,
-		SpoofPlugins:         cfg.SpoofPlugins,
-		SpoofLanguages:       cfg.SpoofLanguages,
-		SpoofWebGL:           cfg.SpoofWebGL,
-		AddCanvasNoise:       cfg.AddCanvasNoise,
-		NaturalMouseMovement: cfg.NaturalMouseMovement,
-		RandomTypingDelays:   cfg.RandomTypingDelays,
-		NaturalScrolling:     cfg.NaturalScrolling,
-		RandomActionDelays:   cfg.RandomActionDelays,
-		RandomClickOffset:    cfg.RandomClickOffset,
-		RotateUserAgent:      cfg.RotateUserAgent,
-		RandomViewport:       cfg.RandomViewport,
-		MatchTimezone:        cfg.MatchTimezone,
-		Timezone:             cfg.Timezone,
+	// Parse metrics sampling interval
+	var metricsInterval time.Duration
+	if cfg.MetricsInterval != "" {
+		d, err := time.ParseDuration(cfg.MetricsInterval)
+		if err != nil {
+			d = crawler.DefaultMetricsInterval
+		}
+		metricsInterval = d
+	}
+
+	antiBotConfig := antiBotConfigOf(cfg)
+
+	geoConfig := crawler.GeoConfig{
+		Region:         cfg.Region,
+		AcceptLanguage: cfg.AcceptLanguage,
+		Locale:         cfg.Locale,
+		Timezone:       cfg.GeoTimezone,
+		Geolocation:    cfg.Geolocation,
+		Proxy:          cfg.Proxy,
+		ProxyDNS:       cfg.ProxyDNS,
+	}
+
+	permissions := crawler.OutputPermissions{
+		FileMode: cfg.FileMode,
+		DirMode:  cfg.DirMode,
+		Owner:    cfg.Owner,
+	}
+
+	faults := crawler.FaultConfig{
+		ErrorRate:    cfg.FaultErrorRate,
+		TruncateRate: cfg.FaultTruncateRate,
+		ResetRate:    cfg.FaultResetRate,
+		Seed:         cfg.FaultSeed,
+	}
+	if cfg.FaultLatency != "" {
+		faults.Latency, err = time.ParseDuration(cfg.FaultLatency)
+		if err != nil {
+			return crawler.Config{}, fmt.Errorf("invalid fault latency: %w", err)
+		}
+	}
+
+	enrichment := crawler.EnrichmentConfig{
+		Endpoint:    cfg.EnrichmentEndpoint,
+		APIKey:      cfg.EnrichmentAPIKey,
+		Concurrency: cfg.EnrichmentConcurrency,
+	}
+	if cfg.EnrichmentTimeout != "" {
+		enrichment.Timeout, err = time.ParseDuration(cfg.EnrichmentTimeout)
+		if err != nil {
+			return crawler.Config{}, fmt.Errorf("invalid enrichment timeout: %w", err)
+		}
+	}
+	var postSaveTimeout time.Duration
+	if cfg.PostSaveTimeout != "" {
+		postSaveTimeout, err = time.ParseDuration(cfg.PostSaveTimeout)
+		if err != nil {
+			return crawler.Config{}, fmt.Errorf("invalid post-save timeout: %w", err)
+		}
+	}
+	var waybackSaveInterval time.Duration
+	if cfg.WaybackSaveInterval != "" {
+		waybackSaveInterval, err = time.ParseDuration(cfg.WaybackSaveInterval)
+		if err != nil {
+			return crawler.Config{}, fmt.Errorf("invalid wayback save interval: %w", err)
+		}
+	}
+
+	var maxRuntime time.Duration
+	if cfg.MaxRuntime != "" {
+		maxRuntime, err = time.ParseDuration(cfg.MaxRuntime)
+		if err != nil {
+			return crawler.Config{}, fmt.Errorf("invalid max runtime: %w", err)
+		}
+	}
+
+	var urlTimeout time.Duration
+	if cfg.URLTimeout != "" {
+		urlTimeout, err = time.ParseDuration(cfg.URLTimeout)
+		if err != nil {
+			return crawler.Config{}, fmt.Errorf("invalid URL timeout: %w", err)
+		}
 	}
 
 	// Build pagination config if enabled
@@ -150,6 +349,7 @@ func (a *App) StartCrawl(cfg CrawlConfig) error {
 			WaitAfterClick:  paginationWait,
 			WaitSelector:    cfg.PaginationWaitSelector,
 			StopOnDuplicate: cfg.PaginationStopOnDuplicate,
+			HarvestURLs:     cfg.PaginationHarvestURLs,
 		}
 		// Set defaults if not specified
 		if paginationConfig.MaxClicks <= 0 {
@@ -161,22 +361,86 @@ func (a *App) StartCrawl(cfg CrawlConfig) error {
 	config := crawler.Config{
 		URL:                cfg.URL,
 		Concurrent:         cfg.Concurrent,
+		Workers:            cfg.Workers,
 		Delay:              delay,
 		MaxDepth:           cfg.MaxDepth,
+		DepthMode:          cfg.DepthMode,
+		MaxPages:           cfg.MaxPages,
+		MaxRuntime:         maxRuntime,
+		MaxConsecutiveErrors: cfg.MaxConsecutiveErrors,
+		URLTimeout:         urlTimeout,
+		StopPattern:        cfg.StopPattern,
+		MaxHTMLSize:        cfg.MaxHTMLSize,
 		OutputDir:          cfg.OutputDir,
 		StateFile:          cfg.StateFile,
+		StateMerge:         cfg.StateMerge,
+		FrontierLimit:      cfg.FrontierLimit,
 		PrefixFilterURL:    cfg.PrefixFilterURL,
 		Verbose:            cfg.Verbose,
 		UserAgent:          cfg.UserAgent,
+		WarmUp:             cfg.WarmUp,
+		RefererPolicy:      cfg.RefererPolicy,
 		IgnoreRobots:       cfg.IgnoreRobots,
 		MinContentLength:   cfg.MinContentLength,
+		MaxContentLength:   cfg.MaxContentLength,
+		MinWords:           cfg.MinWords,
+		MaxWords:           cfg.MaxWords,
+		MaxLinkDensity:     cfg.MaxLinkDensity,
 		ShowProgress:       false, // GUI handles progress display
 		DisableContentExtraction: cfg.DisableContentExtraction,
+		CompressOutput:     cfg.CompressOutput,
 		FetchMode:          fetchMode,
 		Headless:           cfg.Headless,
 		WaitForLogin:       cfg.WaitForLogin,
 		PageLoadWait:       pageLoadWait,
+		HARMode:            cfg.HARMode,
+		DiscoverAPIs:       cfg.DiscoverAPIs,
+		ExternalLinks:      cfg.ExternalLinks,
+		Report:             cfg.Report,
+		PageScripts:        cfg.PageScripts,
+		ContentFilters:     cfg.ContentFilters,
+		FollowOnly:         cfg.FollowOnly,
+		Alerts:             cfg.Alerts,
+		PluginsDir:         cfg.PluginsDir,
+		EventSinks:         cfg.EventSinks,
+		KeepCookieBanners:  cfg.KeepCookieBanners,
+		ShadowDOM:          cfg.ShadowDOM,
+		BrowserRecycle:     cfg.BrowserRecycle,
+		BrowserPath:        cfg.BrowserPath,
+		BrowserMaxMemory:   cfg.BrowserMaxMemory,
+		BrowserMaxTabs:     cfg.BrowserMaxTabs,
+		BrowserMaxProcesses: cfg.BrowserMaxProcesses,
+		Iframes:            cfg.Iframes,
+		Provenance:         cfg.Provenance,
+		ContentAddressable: cfg.ContentAddressable,
+		IndexInterval:      cfg.IndexInterval,
+		MetricsInterval:    metricsInterval,
 		AntiBot:            antiBotConfig,
+		Geo:                geoConfig,
+		Permissions:        permissions,
+		Faults:             faults,
+		Cassette:           cfg.Cassette,
+		CassetteFile:       cfg.CassetteFile,
+		Notify:             cfg.Notify,
+		TraceDecisions:     cfg.TraceDecisions,
+		ResultsDB:          cfg.ResultsDB,
+		Tracing:            crawler.TracingConfig{Endpoint: cfg.OTLPEndpoint, SampleRate: cfg.TraceSampleRate},
+		Embeddings: crawler.EmbeddingsConfig{
+			Endpoint:   cfg.EmbeddingsEndpoint,
+			Model:      cfg.EmbeddingsModel,
+			APIKey:     cfg.EmbeddingsAPIKey,
+			Dimensions: cfg.EmbeddingsDimensions,
+			Store:      cfg.EmbeddingsStore,
+		},
+		Enrichment:         enrichment,
+		PostSaveCommand:     cfg.PostSaveCommand,
+		PostSaveConcurrency: cfg.PostSaveConcurrency,
+		PostSaveTimeout:     postSaveTimeout,
+		PostSaveOnFailure:   cfg.PostSaveOnFailure,
+		WaybackFallback:     cfg.WaybackFallback,
+		WaybackSave:         cfg.WaybackSave,
+		WaybackSaveKey:      cfg.WaybackSaveKey,
+		WaybackSaveInterval: waybackSaveInterval,
 		Pagination:         paginationConfig,
 		NormalizeURLs:      cfg.NormalizeURLs,
 		LowercasePaths:     cfg.LowercasePaths,
@@ -188,6 +452,44 @@ func (a *App) StartCrawl(cfg CrawlConfig) error {
 		config.ExcludeExtensions = exts
 	}
 
+	// User agent rotation; the pool settings stay in the form while it is off
+	if antiBotConfig.Effective().RotateUserAgent {
+		config.UserAgentPool = cfg.UserAgentPool
+		config.UserAgents = splitAndTrim(cfg.UserAgents, "\n")
+		config.StickyUserAgent = cfg.StickyUserAgent
+	}
+
+	// The warm-up page count stays in the form while warm-up is off
+	if cfg.WarmUp {
+		config.WarmUpPages = cfg.WarmUpPages
+	}
+
+	// Cross-origin iframe hosts stay in the form in HTTP mode and while
+	// iframes are not captured
+	if cfg.FetchMode == "browser" && cfg.Iframes != "" && cfg.Iframes != crawler.IframesOff && cfg.IframeHosts != "" {
+		config.IframeHosts = splitAndTrim(cfg.IframeHosts, ",")
+	}
+
+	// So do the scroll step and delay while lazy-load scrolling is off
+	if cfg.LazyLoadScroll {
+		config.LazyLoadScroll = true
+		config.ScrollStep = cfg.ScrollStep
+		if cfg.ScrollDelay != "" {
+			config.ScrollDelay, err = time.ParseDuration(cfg.ScrollDelay)
+			if err != nil {
+				return crawler.Config{}, fmt.Errorf("invalid scroll delay: %w", err)
+			}
+		}
+	}
+
+	// Parse host lists
+	if cfg.AllowedHosts != "" {
+		config.AllowedHosts = splitAndTrim(cfg.AllowedHosts, ",")
+	}
+	if cfg.DeniedHosts != "" {
+		config.DeniedHosts = splitAndTrim(cfg.DeniedHosts, ",")
+	}
+
 	// Parse link selectors
 	if cfg.LinkSelectors != "" {
 		selectors := splitAndTrim(cfg.LinkSelectors, ",")
@@ -199,8 +501,28 @@ func (a *App) StartCrawl(cfg CrawlConfig) error {
 		config.MinContentLength = 100
 	}
 
+	if cfg.Polite {
+		policy := crawler.DefaultPolitePolicy()
+		config.Polite = &policy
+	}
+
 	// Validate config
 	if err := crawler.ValidateConfig(&config); err != nil {
+		return crawler.Config{}, err
+	}
+	return config, nil
+}
+
+func (a *App) StartCrawl(cfg CrawlConfig) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.running {
+		return fmt.Errorf("crawler is already running")
+	}
+
+	config, err := buildConfig(cfg)
+	if err != nil {
 		return err
 	}
 
@@ -211,6 +533,14 @@ func (a *App) StartCrawl(cfg CrawlConfig) error {
 
 	// Set default state file
 	crawler.SetDefaultStateFile(&config)
+	a.lastOutputDir = config.OutputDir
+
+	// Select the URLs to re-crawl from the previous crawl's URL inventory
+	if cfg.RecrawlScope != "" {
+		if _, err := crawler.PrepareRecrawl(&config, cfg.RecrawlScope, cfg.RecrawlPattern); err != nil {
+			return err
+		}
+	}
 
 	// Create context for this crawl
 	ctx, cancel := context.WithCancel(context.Background())
@@ -282,6 +612,44 @@ func (a *App) ResumeCrawl() error {
 	return nil
 }
 
+// RuntimeConfigUpdate holds settings the frontend can change during a crawl.
+// Omitted fields are left unchanged.
+type RuntimeConfigUpdate struct {
+	Delay    *string `json:"delay,omitempty"`
+	Workers  *int    `json:"workers,omitempty"`
+	MaxPages *int    `json:"maxPages,omitempty"`
+	Verbose  *bool   `json:"verbose,omitempty"`
+}
+
+// UpdateConfig applies runtime settings to the running crawl without restarting it
+func (a *App) UpdateConfig(cfg RuntimeConfigUpdate) (*crawler.RuntimeConfig, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.running || a.crawler == nil {
+		return nil, fmt.Errorf("no crawler running")
+	}
+
+	update := crawler.ConfigUpdate{
+		Workers:  cfg.Workers,
+		MaxPages: cfg.MaxPages,
+		Verbose:  cfg.Verbose,
+	}
+	if cfg.Delay != nil {
+		d, err := time.ParseDuration(*cfg.Delay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delay: %v", err)
+		}
+		update.Delay = &d
+	}
+
+	current, err := a.crawler.UpdateConfig(update)
+	if err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
 // GetStatus returns the current crawler status
 type CrawlerStatus struct {
 	Running         bool   `json:"running"`
@@ -341,8 +709,23 @@ type MetricsSnapshot struct {
 	RobotsBlocked   int64   `json:"robotsBlocked"`
 	DepthLimitHits  int64   `json:"depthLimitHits"`
 	ContentFiltered int64   `json:"contentFiltered"`
+	FollowOnly      int64   `json:"followOnly"`
+	StopReason      string  `json:"stopReason,omitempty"`
+	Redirected      int64   `json:"redirected"`
+	RedirectErrors  int64   `json:"redirectErrors"`
+	TimedOut        int64   `json:"timedOut"`
+	BrowserRecycles int64   `json:"browserRecycles"`
+	BrowserCrashes  int64   `json:"browserCrashes"`
 	PagesPerSecond  float64 `json:"pagesPerSecond"`
 	QueueSize       int     `json:"queueSize"`
+	HeapAlloc       int64   `json:"heapAlloc"`
+	PeakHeapAlloc   int64   `json:"peakHeapAlloc"`
+	SysMemory       int64   `json:"sysMemory"`
+	NumGC           int64   `json:"numGC"`
+	Depths          []crawler.DepthStats `json:"depths"`  // Discovered, saved and errored URLs per depth
+	Content         crawler.ContentStats `json:"content"` // Status codes, content types, charsets, languages and largest pages
+	Active          []crawler.ActiveFetch `json:"active"`       // URLs being processed by workers, oldest first
+	RecentErrors    []crawler.RecentError `json:"recentErrors"` // Latest failed URLs, oldest first
 }
 
 // GetMetrics returns current crawler metrics
@@ -366,11 +749,548 @@ func (a *App) GetMetrics() (*MetricsSnapshot, error) {
 		RobotsBlocked:   snapshot.RobotsBlocked,
 		DepthLimitHits:  snapshot.DepthLimitHits,
 		ContentFiltered: snapshot.ContentFiltered,
+		FollowOnly:      snapshot.FollowOnly,
+		StopReason:      snapshot.StopReason,
+		Redirected:      snapshot.Redirected,
+		RedirectErrors:  snapshot.RedirectErrors,
+		TimedOut:        snapshot.TimedOut,
+		BrowserRecycles: snapshot.BrowserRecycles,
+		BrowserCrashes:  snapshot.BrowserCrashes,
 		PagesPerSecond:  snapshot.PagesPerSecond,
 		QueueSize:       snapshot.QueueSize,
+		HeapAlloc:       snapshot.HeapAlloc,
+		PeakHeapAlloc:   snapshot.PeakHeapAlloc,
+		SysMemory:       snapshot.SysMemory,
+		NumGC:           snapshot.NumGC,
+		Depths:          snapshot.Depths,
+		Content:         snapshot.Content,
+		Active:          snapshot.Active,
+		RecentErrors:    snapshot.RecentErrors,
 	}, nil
 }
 
+// GetMetricsTimeSeries returns the metrics samples of the running crawl, or
+// of the most recent finished crawl when none is running
+func (a *App) GetMetricsTimeSeries() (*crawler.MetricsTimeSeries, error) {
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		series := c.MetricsTimeSeries()
+		return &series, nil
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read metrics from")
+	}
+	return crawler.LoadMetricsTimeSeries(outputDir)
+}
+
+// GetURLInventory returns every URL encountered by the running crawl, or by
+// the most recent finished crawl when none is running. status filters by
+// outcome (saved, skipped, error, blocked, queued); "" returns all.
+func (a *App) GetURLInventory(status string) ([]crawler.URLRecord, error) {
+	if status != "" && !crawler.ValidURLStatus(status) {
+		return nil, fmt.Errorf("invalid status %q", status)
+	}
+
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		return crawler.FilterURLRecords(c.URLInventory(), status), nil
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read URLs from")
+	}
+	records, err := crawler.LoadURLInventory(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	return crawler.FilterURLRecords(records, status), nil
+}
+
+// GetURLErrors returns the URLs the running crawl failed on, or those of the
+// most recent finished crawl when none is running, with their error class
+// and the page each was found on. class filters by error class; "" returns
+// all.
+func (a *App) GetURLErrors(class string) ([]crawler.URLError, error) {
+	if class != "" && !crawler.ValidErrorClass(class) {
+		return nil, fmt.Errorf("invalid error class %q", class)
+	}
+	records, err := a.GetURLInventory(crawler.URLStatusError)
+	if err != nil {
+		return nil, err
+	}
+	return crawler.URLErrors(records, class), nil
+}
+
+// RetryURL queues one errored URL of the running crawl again, e.g. after
+// confirming a login, without restarting the crawl
+func (a *App) RetryURL(url string) error {
+	_, err := a.RetryURLs([]string{url})
+	return err
+}
+
+// RetryURLs queues the given errored URLs of the running crawl again and
+// returns how many were queued
+func (a *App) RetryURLs(urls []string) (int, error) {
+	a.mu.Lock()
+	c := a.crawler
+	a.mu.Unlock()
+
+	if c == nil {
+		return 0, fmt.Errorf("no crawler running")
+	}
+	return c.RetryURLs(urls)
+}
+
+// RetryAllErrors queues every errored URL of the running crawl again, or
+// only those of class when it is not "", and returns how many were queued
+func (a *App) RetryAllErrors(class string) (int, error) {
+	a.mu.Lock()
+	c := a.crawler
+	a.mu.Unlock()
+
+	if c == nil {
+		return 0, fmt.Errorf("no crawler running")
+	}
+	return c.RetryErrors(class)
+}
+
+// GetCrawlGraph returns the crawl map of the running crawl: the nodes added
+// or changed after version since, out of the first maxNodes URLs discovered
+// (0 = crawler.DefaultGraphNodes). Passing the returned Version back gets the
+// next changes. When no crawl is running, the whole map of the most recent
+// finished crawl is returned.
+func (a *App) GetCrawlGraph(since uint64, maxNodes int) (*crawler.CrawlGraph, error) {
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		g := c.CrawlGraph(since, maxNodes)
+		return &g, nil
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to draw the map of")
+	}
+	records, err := crawler.LoadURLInventory(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	g := crawler.BuildCrawlGraph(records, maxNodes)
+	return &g, nil
+}
+
+// GetRedirects returns the redirect mapping of the running crawl, or of
+// the most recent finished crawl when none is running
+func (a *App) GetRedirects() (*crawler.RedirectMap, error) {
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		return c.Redirects(), nil
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read redirects from")
+	}
+	return crawler.LoadRedirectMap(outputDir)
+}
+
+// GetAPIEndpoints returns the XHR/fetch endpoints discovered by the running
+// crawl, or by the most recent finished crawl when none is running
+func (a *App) GetAPIEndpoints(jsonOnly bool) ([]crawler.APIEndpoint, error) {
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		return crawler.FilterAPIEndpoints(c.APIEndpoints(), jsonOnly), nil
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read API endpoints from")
+	}
+	endpoints, err := crawler.LoadAPIEndpoints(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	return crawler.FilterAPIEndpoints(endpoints, jsonOnly), nil
+}
+
+// GetExternalLinks returns the out-of-scope links recorded by the running
+// crawl, or by the most recent finished crawl when none is running. host
+// keeps only links to that host or *.domain ("" keeps all).
+func (a *App) GetExternalLinks(host string) ([]crawler.ExternalLink, error) {
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		return crawler.FilterExternalLinks(c.ExternalLinks(), host), nil
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read external links from")
+	}
+	links, err := crawler.LoadExternalLinks(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	return crawler.FilterExternalLinks(links, host), nil
+}
+
+// GetAlerts returns the alert rule matches of the running crawl, or of the
+// most recent finished crawl when none is running. rule keeps only the
+// alerts of the named rule ("" keeps all).
+func (a *App) GetAlerts(rule string) ([]crawler.Alert, error) {
+	a.mu.Lock()
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read alerts from")
+	}
+	alerts, err := crawler.LoadAlerts(outputDir)
+	if os.IsNotExist(err) {
+		return []crawler.Alert{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return crawler.FilterAlerts(alerts, rule), nil
+}
+
+// GetManifest returns the pages the running crawl, or the most recent
+// finished crawl when none is running, saved from byte offset on in its
+// pages.ndjson. Passing the returned NextOffset back gets the pages saved
+// since the previous call.
+func (a *App) GetManifest(offset int64) (*crawler.ManifestChunk, error) {
+	a.mu.Lock()
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to read the page manifest of")
+	}
+	return crawler.ReadManifest(outputDir, offset)
+}
+
+// GenerateIndex rewrites _index.html for the running crawl, or the most
+// recent finished crawl when none is running, and returns the indexed pages
+// sorted by URL
+func (a *App) GenerateIndex() ([]crawler.PageEntry, error) {
+	a.mu.Lock()
+	c := a.crawler
+	outputDir := a.lastOutputDir
+	a.mu.Unlock()
+
+	if c != nil {
+		return c.WriteIndex()
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no crawl to index")
+	}
+	return crawler.BuildIndex(outputDir)
+}
+
+// ExportConfig is the book export configuration passed from the frontend
+type ExportConfig struct {
+	OutputDir   string `json:"outputDir"` // defaults to the most recent crawl
+	Format      string `json:"format"`
+	Order       string `json:"order"`
+	Title       string `json:"title"`
+	EmbedImages bool   `json:"embedImages"`
+}
+
+// ExportBook stitches a crawl's extracted content into a single HTML file or EPUB
+func (a *App) ExportBook(cfg ExportConfig) (*crawler.ExportResult, error) {
+	a.mu.Lock()
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no output directory to export")
+	}
+
+	return crawler.ExportBook(outputDir, crawler.ExportOptions{
+		Format:      crawler.ExportFormat(cfg.Format),
+		Order:       crawler.ExportOrder(cfg.Order),
+		Title:       cfg.Title,
+		EmbedImages: cfg.EmbedImages,
+	})
+}
+
+// SiteConfig is the static site configuration passed from the frontend
+type SiteConfig struct {
+	OutputDir   string `json:"outputDir"` // defaults to the most recent crawl
+	Title       string `json:"title"`
+	EmbedImages bool   `json:"embedImages"`
+}
+
+// GenerateSite builds a browsable static site from a crawl's output
+func (a *App) GenerateSite(cfg SiteConfig) (*crawler.SiteResult, error) {
+	a.mu.Lock()
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no output directory to build a site from")
+	}
+
+	return crawler.GenerateSite(outputDir, crawler.SiteOptions{
+		Title:       cfg.Title,
+		EmbedImages: cfg.EmbedImages,
+	})
+}
+
+// GenerateSEOReport audits a crawl's saved pages and writes seo_report.html
+// and seo_report.json. outputDir defaults to the most recent crawl.
+func (a *App) GenerateSEOReport(outputDir string) (*crawler.SEOReport, error) {
+	a.mu.Lock()
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no output directory to audit")
+	}
+
+	return crawler.GenerateSEOReport(outputDir)
+}
+
+// GenerateAccessibilityReport checks a crawl's saved pages for accessibility
+// issues and writes accessibility_report.html and accessibility_report.json.
+// outputDir defaults to the most recent crawl.
+func (a *App) GenerateAccessibilityReport(outputDir string) (*crawler.AccessibilityReport, error) {
+	a.mu.Lock()
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no output directory to audit")
+	}
+
+	return crawler.GenerateAccessibilityReport(outputDir)
+}
+
+// GenerateDuplicatesReport clusters a crawl's saved pages by near-duplicate
+// content and writes duplicates_report.html and duplicates_report.json.
+// outputDir defaults to the most recent crawl; threshold 0 uses the default.
+func (a *App) GenerateDuplicatesReport(outputDir string, threshold int) (*crawler.DuplicatesReport, error) {
+	a.mu.Lock()
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	a.mu.Unlock()
+
+	if outputDir == "" {
+		return nil, fmt.Errorf("no output directory to analyze")
+	}
+
+	return crawler.GenerateDuplicatesReport(outputDir, crawler.DuplicateOptions{Threshold: threshold})
+}
+
+// ReprocessOutput extracts the content of the pages a crawl saved again with
+// the extraction settings of cfg and regenerates its _index.html, without
+// fetching anything. The output directory defaults to the most recent crawl.
+func (a *App) ReprocessOutput(cfg CrawlConfig) (*crawler.ReprocessResult, error) {
+	a.mu.Lock()
+	running := a.running
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	a.mu.Unlock()
+
+	if running {
+		return nil, fmt.Errorf("cannot reprocess while a crawl is running")
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("no output directory to reprocess")
+	}
+
+	return crawler.Reprocess(outputDir, crawler.ReprocessOptions{
+		DisableContentExtraction: cfg.DisableContentExtraction,
+		ContentFilters:           cfg.ContentFilters,
+		PluginsDir:               cfg.PluginsDir,
+	})
+}
+
+// RunSelfTest crawls synthetic sites served on a local port to validate the
+// installation. checks selects checks by name; all run when it is empty.
+func (a *App) RunSelfTest(checks []string) ([]crawler.SelfTestResult, error) {
+	dir, err := os.MkdirTemp("", "scraper-selftest-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return crawler.RunSelfTest(ctx, dir, checks, nil)
+}
+
+// ListSecrets returns the names of the secrets in the encrypted store
+func (a *App) ListSecrets() ([]string, error) {
+	store, err := crawler.OpenDefaultSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Names(), nil
+}
+
+// SetSecret stores value as the secret name, which the proxy and page
+// scripts can refer to as secret://name
+func (a *App) SetSecret(name, value string) ([]string, error) {
+	store, err := crawler.OpenDefaultSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Set(name, value); err != nil {
+		return nil, err
+	}
+	if err := store.Save(); err != nil {
+		return nil, err
+	}
+	return store.Names(), nil
+}
+
+// DeleteSecret removes the secret name from the store
+func (a *App) DeleteSecret(name string) ([]string, error) {
+	store, err := crawler.OpenDefaultSecretStore()
+	if err != nil {
+		return nil, err
+	}
+	if !store.Delete(name) {
+		return nil, fmt.Errorf("unknown secret %q", name)
+	}
+	if err := store.Save(); err != nil {
+		return nil, err
+	}
+	return store.Names(), nil
+}
+
+// RunAntiBotTest opens testURL, or a bundled local page when it is empty, in
+// browser mode with the form's anti-bot settings and reports which
+// fingerprint signals leak
+func (a *App) RunAntiBotTest(cfg CrawlConfig, testURL string) (*crawler.AntiBotTestResult, error) {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return crawler.RunAntiBotTest(ctx, crawler.AntiBotTestOptions{
+		URL:       testURL,
+		AntiBot:   antiBotConfigOf(cfg),
+		Headless:  cfg.Headless,
+		UserAgent: cfg.UserAgent,
+	})
+}
+
+// PlanCrawl previews the links a crawl with cfg would queue or filter out,
+// reading only the start page so the form's filters can be checked quickly
+func (a *App) PlanCrawl(cfg CrawlConfig) (*crawler.CrawlPlan, error) {
+	config, err := buildConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxPages = 1
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return crawler.Plan(ctx, config)
+}
+
+// FetchPage fetches the form's start URL with its fetch settings and returns
+// the extracted content as text and Markdown, without crawling or saving
+func (a *App) FetchPage(cfg CrawlConfig) (*crawler.FetchedPage, error) {
+	config, err := buildConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return crawler.FetchPage(ctx, config)
+}
+
+// ReadOutputFile returns the contents of a stored crawl file, decompressing
+// .gz and .zst files. Relative paths resolve against the most recent crawl.
+func (a *App) ReadOutputFile(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		a.mu.Lock()
+		outputDir := a.lastOutputDir
+		a.mu.Unlock()
+		if outputDir == "" {
+			return "", fmt.Errorf("no output directory to read from")
+		}
+		path = filepath.Join(outputDir, path)
+	}
+
+	data, err := crawler.ReadOutputFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(data), nil
+}
+
+// BrowseOutput serves a crawl's output directory on a local port and opens
+// it in the system browser, returning the URL. outputDir defaults to the
+// most recent crawl. The server is started once and reused, serving the
+// directory of the latest call.
+func (a *App) BrowseOutput(outputDir string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if outputDir == "" {
+		outputDir = a.lastOutputDir
+	}
+	if outputDir == "" {
+		return "", fmt.Errorf("no output directory to browse")
+	}
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("output directory not found: %s", outputDir)
+	}
+	a.browseDir = outputDir
+
+	if a.browseURL == "" {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return "", fmt.Errorf("failed to start browse server: %w", err)
+		}
+		go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			a.mu.Lock()
+			dir := a.browseDir
+			a.mu.Unlock()
+			crawler.NewOutputBrowser(dir).ServeHTTP(w, r)
+		}))
+		a.browseURL = "http://" + listener.Addr().String() + "/"
+	}
+
+	runtime.BrowserOpenURL(a.ctx, a.browseURL)
+	return a.browseURL, nil
+}
+
 // BrowseDirectory opens a directory picker dialog
 func (a *App) BrowseDirectory() (string, error) {
 	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
@@ -437,22 +1357,93 @@ type PresetConfig struct {
 	// Core settings
 	URL             string `json:"url"`
 	Concurrent      bool   `json:"concurrent"`
+	Workers         int    `json:"workers"`
 	Delay           string `json:"delay"`
 	MaxDepth        int    `json:"maxDepth"`
+	DepthMode       string `json:"depthMode"`
+	MaxPages        int    `json:"maxPages"`
+	MaxRuntime      string `json:"maxRuntime"`
+	MaxConsecutiveErrors int `json:"maxConsecutiveErrors"`
+	StopPattern     string `json:"stopPattern"`
+	MaxHTMLSize     int64  `json:"maxHtmlSize"`
 	PrefixFilterURL string `json:"prefixFilter"`
+	AllowedHosts    string `json:"allowedHosts"`
+	DeniedHosts     string `json:"deniedHosts"`
+	ExternalLinks   bool   `json:"externalLinks"`
+	Report          bool   `json:"report"`
 	// Content settings
 	ExcludeExtensions  string `json:"excludeExtensions"`
 	LinkSelectors      string `json:"linkSelectors"`
 	Verbose            bool   `json:"verbose"`
 	UserAgent          string `json:"userAgent"`
+	UserAgentPool      string `json:"userAgentPool"`
+	UserAgents         string `json:"userAgents"`
+	StickyUserAgent    bool   `json:"stickyUserAgent"`
+	WarmUp             bool   `json:"warmUp"`
+	WarmUpPages        int    `json:"warmUpPages"`
+	RefererPolicy      string `json:"refererPolicy"`
 	IgnoreRobots       bool   `json:"ignoreRobots"`
+	Polite             bool   `json:"polite"`
 	MinContentLength   int    `json:"minContent"`
+	MaxContentLength   int     `json:"maxContent"`
+	MinWords           int     `json:"minWords"`
+	MaxWords           int     `json:"maxWords"`
+	MaxLinkDensity     float64 `json:"maxLinkDensity"`
 	DisableContentExtraction bool `json:"disableContentExtraction"`
+	CompressOutput     string `json:"compressOutput"`
+	IndexInterval      int    `json:"indexInterval"`
+	MetricsInterval    string `json:"metricsInterval"`
 	// Browser settings
 	FetchMode    string `json:"fetchMode"`
 	Headless     bool   `json:"headless"`
 	WaitForLogin bool   `json:"waitForLogin"`
 	PageLoadWait string `json:"pageLoadWait"`
+	HARMode      string `json:"harMode"`
+	DiscoverAPIs bool   `json:"discoverApis"`
+	PageScripts  []crawler.PageScript `json:"pageScripts"`
+	KeepCookieBanners bool `json:"keepCookieBanners"`
+	LazyLoadScroll    bool   `json:"lazyLoadScroll"`
+	ScrollStep        int    `json:"scrollStep"`
+	ScrollDelay       string `json:"scrollDelay"`
+	ShadowDOM         string `json:"shadowDom"`
+	Iframes           string `json:"iframes"`
+	IframeHosts       string `json:"iframeHosts"`
+	Provenance        string `json:"provenance"`
+	// Content-addressable storage
+	ContentAddressable bool `json:"contentAddressable"`
+	// SQLite results database, relative to the output folder
+	ResultsDB string `json:"resultsDb"`
+	// Embeddings of saved pages; the key may be secret://name
+	EmbeddingsEndpoint   string `json:"embeddingsEndpoint"`
+	EmbeddingsModel      string `json:"embeddingsModel"`
+	EmbeddingsAPIKey     string `json:"embeddingsApiKey"`
+	EmbeddingsDimensions int    `json:"embeddingsDimensions"`
+	EmbeddingsStore      string `json:"embeddingsStore"`
+	// Enrichment endpoint; the key may be secret://name
+	EnrichmentEndpoint    string `json:"enrichmentEndpoint"`
+	EnrichmentAPIKey      string `json:"enrichmentApiKey"`
+	EnrichmentConcurrency int    `json:"enrichmentConcurrency"`
+	EnrichmentTimeout     string `json:"enrichmentTimeout"`
+	// Post-save command
+	PostSaveCommand     string `json:"postSaveCommand"`
+	PostSaveConcurrency int    `json:"postSaveConcurrency"`
+	PostSaveTimeout     string `json:"postSaveTimeout"`
+	PostSaveOnFailure   string `json:"postSaveOnFailure"`
+	// Wayback Machine fallback for dead pages and Save Page Now submission
+	WaybackFallback     bool   `json:"waybackFallback"`
+	WaybackSave         bool   `json:"waybackSave"`
+	WaybackSaveKey      string `json:"waybackSaveKey"`
+	WaybackSaveInterval string `json:"waybackSaveInterval"`
+	// Content filters
+	ContentFilters []crawler.ContentFilter `json:"contentFilters"`
+	// Navigation hubs followed but not saved
+	FollowOnly []crawler.FollowOnlyRule `json:"followOnly"`
+	// Patterns or keywords watched for in saved pages
+	Alerts []crawler.AlertRule `json:"alerts"`
+	// JavaScript plugins directory
+	PluginsDir string `json:"pluginsDir"`
+	// Where crawl events are also sent
+	EventSinks []crawler.EventSink `json:"eventSinks"`
 	// Pagination settings
 	EnablePagination          bool   `json:"enablePagination"`
 	PaginationSelector        string `json:"paginationSelector"`
@@ -460,7 +1451,9 @@ type PresetConfig struct {
 	PaginationWait            string `json:"paginationWait"`
 	PaginationWaitSelector    string `json:"paginationWaitSelector"`
 	PaginationStopOnDuplicate bool   `json:"paginationStopOnDuplicate"`
+	PaginationHarvestURLs     bool   `json:"paginationHarvestUrls"`
 	// Anti-bot settings
+	AntiBotProfile       string `json:"antiBotProfile"`
 	HideWebdriver        bool   `json:"hideWebdriver"`
 	SpoofPlugins         bool   `json:"spoofPlugins"`
 	SpoofLanguages       bool   `json:"spoofLanguages"`
@@ -475,6 +1468,18 @@ type PresetConfig struct {
 	RandomViewport       bool   `json:"randomViewport"`
 	MatchTimezone        bool   `json:"matchTimezone"`
 	Timezone             string `json:"timezone"`
+	// Region emulation settings
+	Region         string `json:"region"`
+	AcceptLanguage string `json:"acceptLanguage"`
+	Locale         string `json:"locale"`
+	GeoTimezone    string `json:"geoTimezone"`
+	Geolocation    string `json:"geolocation"`
+	Proxy          string `json:"proxy"`
+	ProxyDNS       bool   `json:"proxyDns"`
+	// Output permission settings
+	FileMode string `json:"fileMode"`
+	DirMode  string `json:"dirMode"`
+	Owner    string `json:"owner"`
 	// URL normalization settings
 	NormalizeURLs  bool `json:"normalizeUrls"`
 	LowercasePaths bool `json:"lowercasePaths"`
@@ -487,16 +1492,15 @@ type PresetInfo struct {
 }
 
 // validPresetName validates preset names (alphanumeric, dashes, underscores only)
-var validPresetName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+var validPresetName = api.PresetNamePattern
 
 // GetPresetsDir returns the presets directory path, creating it if needed
 func (a *App) GetPresetsDir() (string, error) {
-	configDir, err := os.UserConfigDir()
+	presetsDir, err := api.DefaultPresetsDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get user config dir: %w", err)
+		return "", err
 	}
 
-	presetsDir := filepath.Join(configDir, "scraper", "presets")
 	if err := os.MkdirAll(presetsDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create presets dir: %w", err)
 	}
@@ -504,108 +1508,99 @@ func (a *App) GetPresetsDir() (string, error) {
 	return presetsDir, nil
 }
 
-// ListPresets returns a list of all available presets
-func (a *App) ListPresets() ([]PresetInfo, error) {
+// presetStore returns the store of the presets directory
+func (a *App) presetStore() (*api.PresetStore, error) {
 	presetsDir, err := a.GetPresetsDir()
 	if err != nil {
 		return nil, err
 	}
+	return api.NewPresetStore(presetsDir), nil
+}
 
-	entries, err := os.ReadDir(presetsDir)
+// ListPresets returns a list of all available presets
+func (a *App) ListPresets() ([]PresetInfo, error) {
+	store, err := a.presetStore()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read presets dir: %w", err)
+		return nil, err
 	}
 
-	presets := make([]PresetInfo, 0)
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
-
-		name := entry.Name()[:len(entry.Name())-5] // Remove .json extension
-
-		// Read the preset to get CreatedAt
-		preset, err := a.LoadPreset(name)
-		if err != nil {
-			continue // Skip invalid presets
-		}
+	summaries, err := store.List()
+	if err != nil {
+		return nil, err
+	}
 
+	// Already sorted by creation time (newest first)
+	presets := make([]PresetInfo, 0, len(summaries))
+	for _, p := range summaries {
 		presets = append(presets, PresetInfo{
-			Name:      name,
-			CreatedAt: preset.CreatedAt,
+			Name:      p.Name,
+			CreatedAt: p.CreatedAt,
 		})
 	}
 
-	// Sort by creation time (newest first)
-	sort.Slice(presets, func(i, j int) bool {
-		return presets[i].CreatedAt.After(presets[j].CreatedAt)
-	})
-
 	return presets, nil
 }
 
-// SavePreset saves a configuration preset with the given name
+// SavePreset saves a configuration preset with the given name. Presets are
+// stored in the format shared with the CLI, the API and MCP.
 func (a *App) SavePreset(name string, config PresetConfig) error {
 	// Validate preset name
 	if name == "" {
 		return fmt.Errorf("preset name cannot be empty")
 	}
-	if len(name) > 50 {
-		return fmt.Errorf("preset name too long (max 50 characters)")
+	if len(name) > api.MaxPresetNameLength {
+		return fmt.Errorf("preset name too long (max %d characters)", api.MaxPresetNameLength)
 	}
 	if !validPresetName.MatchString(name) {
 		return fmt.Errorf("preset name can only contain letters, numbers, dashes, and underscores")
 	}
 
-	presetsDir, err := a.GetPresetsDir()
+	store, err := a.presetStore()
 	if err != nil {
 		return err
 	}
 
-	// Set metadata
-	config.Name = name
-	config.CreatedAt = time.Now()
-
-	// Marshal to JSON with indentation for readability
-	data, err := json.MarshalIndent(config, "", "  ")
+	// PresetConfig shares its JSON field names with CrawlConfig
+	data, err := json.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal preset: %w", err)
 	}
-
-	// Write to file
-	filePath := filepath.Join(presetsDir, name+".json")
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write preset file: %w", err)
+	var cfg CrawlConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to convert preset: %w", err)
 	}
 
-	return nil
+	return store.Save(api.NewPreset(name, requestFromCrawlConfig(cfg)))
 }
 
-// LoadPreset loads a configuration preset by name
+// LoadPreset loads a configuration preset by name. Presets saved by older
+// versions are upgraded on the fly.
 func (a *App) LoadPreset(name string) (*PresetConfig, error) {
 	// Validate name to prevent path traversal
 	if !validPresetName.MatchString(name) {
 		return nil, fmt.Errorf("invalid preset name")
 	}
 
-	presetsDir, err := a.GetPresetsDir()
+	store, err := a.presetStore()
 	if err != nil {
 		return nil, err
 	}
 
-	filePath := filepath.Join(presetsDir, name+".json")
-	data, err := os.ReadFile(filePath)
+	preset, err := store.Get(name)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("preset '%s' not found", name)
-		}
-		return nil, fmt.Errorf("failed to read preset file: %w", err)
+		return nil, err
 	}
 
+	data, err := json.Marshal(crawlConfigFromRequest(&preset.Request))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal preset: %w", err)
+	}
 	var config PresetConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse preset file: %w", err)
+		return nil, fmt.Errorf("failed to parse preset: %w", err)
 	}
+	config.Name = preset.Name
+	config.CreatedAt = preset.CreatedAt
 
 	return &config, nil
 }
@@ -617,18 +1612,75 @@ func (a *App) DeletePreset(name string) error {
 		return fmt.Errorf("invalid preset name")
 	}
 
-	presetsDir, err := a.GetPresetsDir()
+	store, err := a.presetStore()
 	if err != nil {
 		return err
 	}
 
-	filePath := filepath.Join(presetsDir, name+".json")
-	if err := os.Remove(filePath); err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("preset '%s' not found", name)
-		}
-		return fmt.Errorf("failed to delete preset: %w", err)
+	return store.Delete(name)
+}
+
+// ExportPresets saves all presets as one bundle to a file chosen in a save
+// dialog, for sharing with other machines, the CLI (presets import) or the
+// API. Returns the written path, or "" if the dialog was cancelled.
+func (a *App) ExportPresets() (string, error) {
+	store, err := a.presetStore()
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	bundle, err := store.Export(nil)
+	if err != nil {
+		return "", err
+	}
+	if len(bundle.Presets) == 0 {
+		return "", fmt.Errorf("no presets to export")
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Presets",
+		DefaultFilename: "presets.json",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "JSON Files", Pattern: "*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal presets: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write presets: %w", err)
+	}
+	return path, nil
+}
+
+// ImportPresets imports a preset bundle or single preset file chosen in an
+// open dialog. Presets with an existing name are skipped unless overwrite is
+// set. Returns nil if the dialog was cancelled.
+func (a *App) ImportPresets(overwrite bool) (*api.PresetImportResult, error) {
+	store, err := a.presetStore()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Presets",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "JSON Files", Pattern: "*.json"},
+			{DisplayName: "All Files", Pattern: "*.*"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+	return store.Import(data, overwrite)
 }
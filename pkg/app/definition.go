@@ -0,0 +1,476 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"scraper/internal/api"
+	"scraper/internal/crawler"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ExportDefinition saves the given settings as a job definition file chosen
+// in a save dialog. The file can be imported by the GUI, the CLI
+// (-definition) or the API (POST /api/v1/crawl/import). Returns the written
+// path, or "" if the dialog was cancelled.
+func (a *App) ExportDefinition(cfg CrawlConfig) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Job Definition",
+		DefaultFilename: "job-definition.json",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "JSON Files", Pattern: "*.json"},
+		},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	return path, WriteDefinitionFile(path, cfg)
+}
+
+// ImportDefinition loads settings from a job definition file chosen in an
+// open dialog. Returns nil if the dialog was cancelled.
+func (a *App) ImportDefinition() (*CrawlConfig, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Job Definition",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "JSON Files", Pattern: "*.json"},
+			{DisplayName: "All Files", Pattern: "*.*"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	return ReadDefinitionFile(path)
+}
+
+// WriteDefinitionFile writes cfg to path as a job definition
+func WriteDefinitionFile(path string, cfg CrawlConfig) error {
+	def := api.NewJobDefinition(requestFromCrawlConfig(cfg), "")
+	data, err := json.MarshalIndent(def, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal definition: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write definition: %w", err)
+	}
+	return nil
+}
+
+// ReadDefinitionFile reads a job definition and converts it to GUI settings
+func ReadDefinitionFile(path string) (*CrawlConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read definition: %w", err)
+	}
+	req, err := api.ParseJobDefinition(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := crawlConfigFromRequest(req)
+	return &cfg, nil
+}
+
+// requestFromCrawlConfig converts GUI settings to a crawl request
+func requestFromCrawlConfig(cfg CrawlConfig) api.CrawlRequest {
+	headless := cfg.Headless
+	indexInterval := cfg.IndexInterval
+	normalizeURLs := cfg.NormalizeURLs
+
+	req := api.CrawlRequest{
+		URL:                      cfg.URL,
+		MaxDepth:                 cfg.MaxDepth,
+		DepthMode:                cfg.DepthMode,
+		MaxPages:                 cfg.MaxPages,
+		MaxRuntime:               cfg.MaxRuntime,
+		MaxConsecutiveErrors:     cfg.MaxConsecutiveErrors,
+		URLTimeout:               cfg.URLTimeout,
+		StopPattern:              cfg.StopPattern,
+		Concurrent:               cfg.Concurrent,
+		Workers:                  cfg.Workers,
+		Delay:                    cfg.Delay,
+		OutputDir:                cfg.OutputDir,
+		StateFile:                cfg.StateFile,
+		StateMerge:               cfg.StateMerge,
+		FrontierLimit:            cfg.FrontierLimit,
+		PrefixFilterURL:          cfg.PrefixFilterURL,
+		AllowedHosts:             splitAndTrim(cfg.AllowedHosts, ","),
+		DeniedHosts:              splitAndTrim(cfg.DeniedHosts, ","),
+		ExternalLinks:            cfg.ExternalLinks,
+		Report:                   cfg.Report,
+		ExcludeExtensions:        splitAndTrim(cfg.ExcludeExtensions, ","),
+		LinkSelectors:            splitAndTrim(cfg.LinkSelectors, ","),
+		Verbose:                  cfg.Verbose,
+		UserAgent:                cfg.UserAgent,
+		UserAgentPool:            cfg.UserAgentPool,
+		UserAgents:               splitAndTrim(cfg.UserAgents, "\n"),
+		StickyUserAgent:          cfg.StickyUserAgent,
+		WarmUp:                   cfg.WarmUp,
+		WarmUpPages:              cfg.WarmUpPages,
+		RefererPolicy:            cfg.RefererPolicy,
+		IgnoreRobots:             cfg.IgnoreRobots,
+		MinContentLength:         cfg.MinContentLength,
+		MaxContentLength:         cfg.MaxContentLength,
+		MinWords:                 cfg.MinWords,
+		MaxWords:                 cfg.MaxWords,
+		MaxLinkDensity:           cfg.MaxLinkDensity,
+		MaxHTMLSize:              cfg.MaxHTMLSize,
+		DisableContentExtraction: cfg.DisableContentExtraction,
+		CompressOutput:           cfg.CompressOutput,
+		MetricsInterval:          cfg.MetricsInterval,
+		FetchMode:                cfg.FetchMode,
+		Headless:                 &headless,
+		WaitForLogin:             cfg.WaitForLogin,
+		PageLoadWait:             cfg.PageLoadWait,
+		HARMode:                  cfg.HARMode,
+		Cassette:                 cfg.Cassette,
+		CassetteFile:             cfg.CassetteFile,
+		Notify:                   cfg.Notify,
+		TraceDecisions:           cfg.TraceDecisions,
+		ResultsDB:                cfg.ResultsDB,
+		DiscoverAPIs:             cfg.DiscoverAPIs,
+		PageScripts:              cfg.PageScripts,
+		ContentFilters:           cfg.ContentFilters,
+		FollowOnly:               cfg.FollowOnly,
+		Alerts:                   cfg.Alerts,
+		PluginsDir:               cfg.PluginsDir,
+		EventSinks:               cfg.EventSinks,
+		KeepCookieBanners:        cfg.KeepCookieBanners,
+		LazyLoadScroll:           cfg.LazyLoadScroll,
+		ShadowDOM:                cfg.ShadowDOM,
+		BrowserRecycle:           cfg.BrowserRecycle,
+		BrowserPath:              cfg.BrowserPath,
+		BrowserMaxMemory:         cfg.BrowserMaxMemory,
+		BrowserMaxTabs:           cfg.BrowserMaxTabs,
+		BrowserMaxProcesses:      cfg.BrowserMaxProcesses,
+		Iframes:                  cfg.Iframes,
+		IframeHosts:              splitAndTrim(cfg.IframeHosts, ","),
+		Provenance:               cfg.Provenance,
+		ContentAddressable:       cfg.ContentAddressable,
+		IndexInterval:            &indexInterval,
+		NormalizeURLs:            &normalizeURLs,
+		LowercasePaths:           cfg.LowercasePaths,
+	}
+	if cfg.LazyLoadScroll {
+		req.ScrollStep = cfg.ScrollStep
+		req.ScrollDelay = cfg.ScrollDelay
+	}
+	if cfg.EnablePagination {
+		req.Pagination = &api.PaginationConfig{
+			Enable:          true,
+			Selector:        cfg.PaginationSelector,
+			MaxClicks:       cfg.MaxPaginationClicks,
+			WaitAfterClick:  cfg.PaginationWait,
+			WaitSelector:    cfg.PaginationWaitSelector,
+			StopOnDuplicate: cfg.PaginationStopOnDuplicate,
+			HarvestURLs:     cfg.PaginationHarvestURLs,
+		}
+	}
+	antiBot := api.AntiBotConfig{
+		Profile:              cfg.AntiBotProfile,
+		HideWebdriver:        cfg.HideWebdriver,
+		SpoofPlugins:         cfg.SpoofPlugins,
+		SpoofLanguages:       cfg.SpoofLanguages,
+		SpoofWebGL:           cfg.SpoofWebGL,
+		AddCanvasNoise:       cfg.AddCanvasNoise,
+		NaturalMouseMovement: cfg.NaturalMouseMovement,
+		RandomTypingDelays:   cfg.RandomTypingDelays,
+		NaturalScrolling:     cfg.NaturalScrolling,
+		RandomActionDelays:   cfg.RandomActionDelays,
+		RandomClickOffset:    cfg.RandomClickOffset,
+		RotateUserAgent:      cfg.RotateUserAgent,
+		RandomViewport:       cfg.RandomViewport,
+		MatchTimezone:        cfg.MatchTimezone,
+		Timezone:             cfg.Timezone,
+	}
+	if antiBot != (api.AntiBotConfig{}) {
+		req.AntiBot = &antiBot
+	}
+	geo := api.GeoConfig{
+		Region:         cfg.Region,
+		AcceptLanguage: cfg.AcceptLanguage,
+		Locale:         cfg.Locale,
+		Timezone:       cfg.GeoTimezone,
+		Geolocation:    cfg.Geolocation,
+		Proxy:          cfg.Proxy,
+		ProxyDNS:       cfg.ProxyDNS,
+	}
+	if geo != (api.GeoConfig{}) {
+		req.Geo = &geo
+	}
+	permissions := api.OutputPermissions{
+		FileMode: cfg.FileMode,
+		DirMode:  cfg.DirMode,
+		Owner:    cfg.Owner,
+	}
+	if permissions != (api.OutputPermissions{}) {
+		req.Permissions = &permissions
+	}
+	if cfg.OTLPEndpoint != "" {
+		req.Tracing = &api.TracingConfig{Endpoint: cfg.OTLPEndpoint, SampleRate: cfg.TraceSampleRate}
+	}
+	if cfg.EmbeddingsEndpoint != "" {
+		req.Embeddings = &api.EmbeddingsConfig{
+			Endpoint:   cfg.EmbeddingsEndpoint,
+			Model:      cfg.EmbeddingsModel,
+			APIKey:     cfg.EmbeddingsAPIKey,
+			Dimensions: cfg.EmbeddingsDimensions,
+			Store:      cfg.EmbeddingsStore,
+		}
+	}
+	if cfg.EnrichmentEndpoint != "" {
+		req.Enrichment = &api.EnrichmentConfig{
+			Endpoint:    cfg.EnrichmentEndpoint,
+			APIKey:      cfg.EnrichmentAPIKey,
+			Concurrency: cfg.EnrichmentConcurrency,
+			Timeout:     cfg.EnrichmentTimeout,
+		}
+	}
+	req.PostSaveCommand = cfg.PostSaveCommand
+	req.PostSaveConcurrency = cfg.PostSaveConcurrency
+	req.PostSaveTimeout = cfg.PostSaveTimeout
+	req.PostSaveOnFailure = cfg.PostSaveOnFailure
+	req.WaybackFallback = cfg.WaybackFallback
+	req.WaybackSave = cfg.WaybackSave
+	req.WaybackSaveKey = cfg.WaybackSaveKey
+	req.WaybackSaveInterval = cfg.WaybackSaveInterval
+	faults := api.FaultConfig{
+		Latency:      cfg.FaultLatency,
+		ErrorRate:    cfg.FaultErrorRate,
+		TruncateRate: cfg.FaultTruncateRate,
+		ResetRate:    cfg.FaultResetRate,
+		Seed:         cfg.FaultSeed,
+	}
+	if faults != (api.FaultConfig{}) {
+		req.Faults = &faults
+	}
+	return req
+}
+
+// crawlConfigFromRequest converts a crawl request to GUI settings, filling
+// settings the request leaves unset with the same defaults the API uses
+func crawlConfigFromRequest(req *api.CrawlRequest) CrawlConfig {
+	cfg := CrawlConfig{
+		URL:                       req.URL,
+		Concurrent:                req.Concurrent,
+		Workers:                   req.Workers,
+		Delay:                     req.Delay,
+		MaxDepth:                  req.MaxDepth,
+		DepthMode:                 req.DepthMode,
+		MaxPages:                  req.MaxPages,
+		MaxRuntime:                req.MaxRuntime,
+		MaxConsecutiveErrors:      req.MaxConsecutiveErrors,
+		URLTimeout:                req.URLTimeout,
+		StopPattern:               req.StopPattern,
+		MaxHTMLSize:               req.MaxHTMLSize,
+		OutputDir:                 req.OutputDir,
+		StateFile:                 req.StateFile,
+		StateMerge:                req.StateMerge,
+		FrontierLimit:             req.FrontierLimit,
+		PrefixFilterURL:           req.PrefixFilterURL,
+		AllowedHosts:              strings.Join(req.AllowedHosts, ","),
+		DeniedHosts:               strings.Join(req.DeniedHosts, ","),
+		ExternalLinks:             req.ExternalLinks,
+		Report:                    req.Report,
+		ExcludeExtensions:         strings.Join(req.ExcludeExtensions, ","),
+		LinkSelectors:             strings.Join(req.LinkSelectors, ","),
+		Verbose:                   req.Verbose,
+		UserAgent:                 req.UserAgent,
+		UserAgentPool:             req.UserAgentPool,
+		UserAgents:                strings.Join(req.UserAgents, "\n"),
+		StickyUserAgent:           req.StickyUserAgent,
+		WarmUp:                    req.WarmUp,
+		WarmUpPages:               req.WarmUpPages,
+		RefererPolicy:             req.RefererPolicy,
+		IgnoreRobots:              req.IgnoreRobots,
+		MinContentLength:          req.MinContentLength,
+		MaxContentLength:          req.MaxContentLength,
+		MinWords:                  req.MinWords,
+		MaxWords:                  req.MaxWords,
+		MaxLinkDensity:            req.MaxLinkDensity,
+		DisableContentExtraction:  req.DisableContentExtraction || req.DisableReadability,
+		CompressOutput:            req.CompressOutput,
+		FetchMode:                 req.FetchMode,
+		Headless:                  true,
+		WaitForLogin:              req.WaitForLogin,
+		PageLoadWait:              req.PageLoadWait,
+		HARMode:                   req.HARMode,
+		Cassette:                  req.Cassette,
+		CassetteFile:              req.CassetteFile,
+		Notify:                    req.Notify,
+		TraceDecisions:            req.TraceDecisions,
+		ResultsDB:                 req.ResultsDB,
+		DiscoverAPIs:              req.DiscoverAPIs,
+		PageScripts:               req.PageScripts,
+		ContentFilters:            req.ContentFilters,
+		FollowOnly:                req.FollowOnly,
+		Alerts:                    req.Alerts,
+		PluginsDir:                req.PluginsDir,
+		EventSinks:                req.EventSinks,
+		KeepCookieBanners:         req.KeepCookieBanners,
+		LazyLoadScroll:            req.LazyLoadScroll,
+		ScrollStep:                req.ScrollStep,
+		ScrollDelay:               req.ScrollDelay,
+		ShadowDOM:                 req.ShadowDOM,
+		BrowserRecycle:            req.BrowserRecycle,
+		BrowserPath:               req.BrowserPath,
+		BrowserMaxMemory:          req.BrowserMaxMemory,
+		BrowserMaxTabs:            req.BrowserMaxTabs,
+		BrowserMaxProcesses:       req.BrowserMaxProcesses,
+		Iframes:                   req.Iframes,
+		IframeHosts:               strings.Join(req.IframeHosts, ","),
+		Provenance:                req.Provenance,
+		ContentAddressable:        req.ContentAddressable,
+		IndexInterval:             crawler.DefaultIndexInterval,
+		MetricsInterval:           req.MetricsInterval,
+		MaxPaginationClicks:       100,
+		PaginationWait:            "2s",
+		PaginationStopOnDuplicate: true,
+		NormalizeURLs:             true,
+		LowercasePaths:            req.LowercasePaths,
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = crawler.DefaultWorkers
+	}
+	if cfg.Delay == "" {
+		cfg.Delay = "1s"
+	}
+	if cfg.MaxDepth == 0 {
+		cfg.MaxDepth = 10
+	}
+	if cfg.DepthMode == "" {
+		cfg.DepthMode = crawler.DepthModeLinks
+	}
+	if cfg.MaxHTMLSize == 0 {
+		cfg.MaxHTMLSize = crawler.DefaultMaxHTMLSize
+	}
+	if cfg.MinContentLength == 0 {
+		cfg.MinContentLength = 100
+	}
+	if cfg.CompressOutput == "" {
+		cfg.CompressOutput = crawler.CompressionNone
+	}
+	if cfg.FetchMode == "" {
+		cfg.FetchMode = string(crawler.FetchModeHTTP)
+	}
+	if cfg.PageLoadWait == "" {
+		cfg.PageLoadWait = "500ms"
+	}
+	if cfg.HARMode == "" {
+		cfg.HARMode = crawler.HARModeOff
+	}
+	if cfg.ShadowDOM == "" {
+		cfg.ShadowDOM = crawler.ShadowDOMOff
+	}
+	if cfg.Iframes == "" {
+		cfg.Iframes = crawler.IframesOff
+	}
+	if cfg.Provenance == "" {
+		cfg.Provenance = crawler.ProvenanceOff
+	}
+	if cfg.Cassette == "" {
+		cfg.Cassette = crawler.CassetteOff
+	}
+	if cfg.Notify == "" {
+		cfg.Notify = crawler.NotifyOff
+	}
+	if cfg.MetricsInterval == "" {
+		cfg.MetricsInterval = crawler.DefaultMetricsInterval.String()
+	}
+	if req.Headless != nil {
+		cfg.Headless = *req.Headless
+	}
+	if req.IndexInterval != nil {
+		cfg.IndexInterval = *req.IndexInterval
+	}
+	if req.NormalizeURLs != nil {
+		cfg.NormalizeURLs = *req.NormalizeURLs
+	}
+
+	if p := req.Pagination; p != nil {
+		cfg.EnablePagination = p.Enable
+		cfg.PaginationSelector = p.Selector
+		if p.MaxClicks > 0 {
+			cfg.MaxPaginationClicks = p.MaxClicks
+		}
+		if p.WaitAfterClick != "" {
+			cfg.PaginationWait = p.WaitAfterClick
+		}
+		cfg.PaginationWaitSelector = p.WaitSelector
+		cfg.PaginationStopOnDuplicate = p.StopOnDuplicate
+		cfg.PaginationHarvestURLs = p.HarvestURLs
+	}
+
+	if ab := req.AntiBot; ab != nil {
+		cfg.AntiBotProfile = ab.Profile
+		cfg.HideWebdriver = ab.HideWebdriver
+		cfg.SpoofPlugins = ab.SpoofPlugins
+		cfg.SpoofLanguages = ab.SpoofLanguages
+		cfg.SpoofWebGL = ab.SpoofWebGL
+		cfg.AddCanvasNoise = ab.AddCanvasNoise
+		cfg.NaturalMouseMovement = ab.NaturalMouseMovement
+		cfg.RandomTypingDelays = ab.RandomTypingDelays
+		cfg.NaturalScrolling = ab.NaturalScrolling
+		cfg.RandomActionDelays = ab.RandomActionDelays
+		cfg.RandomClickOffset = ab.RandomClickOffset
+		cfg.RotateUserAgent = ab.RotateUserAgent
+		cfg.RandomViewport = ab.RandomViewport
+		cfg.MatchTimezone = ab.MatchTimezone
+		cfg.Timezone = ab.Timezone
+	}
+
+	if g := req.Geo; g != nil {
+		cfg.Region = g.Region
+		cfg.AcceptLanguage = g.AcceptLanguage
+		cfg.Locale = g.Locale
+		cfg.GeoTimezone = g.Timezone
+		cfg.Geolocation = g.Geolocation
+		cfg.Proxy = g.Proxy
+		cfg.ProxyDNS = g.ProxyDNS
+	}
+
+	if p := req.Permissions; p != nil {
+		cfg.FileMode = p.FileMode
+		cfg.DirMode = p.DirMode
+		cfg.Owner = p.Owner
+	}
+
+	if t := req.Tracing; t != nil {
+		cfg.OTLPEndpoint = t.Endpoint
+		cfg.TraceSampleRate = t.SampleRate
+	}
+
+	if e := req.Embeddings; e != nil {
+		cfg.EmbeddingsEndpoint = e.Endpoint
+		cfg.EmbeddingsModel = e.Model
+		cfg.EmbeddingsAPIKey = e.APIKey
+		cfg.EmbeddingsDimensions = e.Dimensions
+		cfg.EmbeddingsStore = e.Store
+	}
+
+	if e := req.Enrichment; e != nil {
+		cfg.EnrichmentEndpoint = e.Endpoint
+		cfg.EnrichmentAPIKey = e.APIKey
+		cfg.EnrichmentConcurrency = e.Concurrency
+		cfg.EnrichmentTimeout = e.Timeout
+	}
+	cfg.PostSaveCommand = req.PostSaveCommand
+	cfg.PostSaveConcurrency = req.PostSaveConcurrency
+	cfg.PostSaveTimeout = req.PostSaveTimeout
+	cfg.PostSaveOnFailure = req.PostSaveOnFailure
+	cfg.WaybackFallback = req.WaybackFallback
+	cfg.WaybackSave = req.WaybackSave
+	cfg.WaybackSaveKey = req.WaybackSaveKey
+	cfg.WaybackSaveInterval = req.WaybackSaveInterval
+
+	if f := req.Faults; f != nil {
+		cfg.FaultLatency = f.Latency
+		cfg.FaultErrorRate = f.ErrorRate
+		cfg.FaultTruncateRate = f.TruncateRate
+		cfg.FaultResetRate = f.ResetRate
+		cfg.FaultSeed = f.Seed
+	}
+	return cfg
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"scraper/internal/crawler"
+)
+
+func TestDefinitionFileRoundTrip(t *testing.T) {
+	cfg := CrawlConfig{
+		URL:                       "https://example.com/docs",
+		Concurrent:                true,
+		Workers:                   4,
+		Delay:                     "500ms",
+		MaxDepth:                  3,
+		DepthMode:                 "path",
+		MaxRuntime:                "30m0s",
+		MaxConsecutiveErrors:      5,
+		StopPattern:               `/api/`,
+		MaxHTMLSize:               1 << 20,
+		OutputDir:                 "/tmp/out",
+		StateFile:                 "/tmp/out/state.json",
+		StateMerge:                true,
+		FrontierLimit:             5000,
+		URLTimeout:                "45s",
+		AllowedHosts:              "example.com,*.example.com",
+		DeniedHosts:               "*.cdn.example.com",
+		ExternalLinks:             true,
+		Report:                    true,
+		ExcludeExtensions:         "pdf,zip",
+		LinkSelectors:             "a.nav",
+		MinContentLength:          200,
+		MaxContentLength:          50000,
+		MinWords:                  40,
+		MaxWords:                  8000,
+		MaxLinkDensity:            0.6,
+		CompressOutput:            "gzip",
+		FetchMode:                 "browser",
+		Headless:                  false,
+		PageLoadWait:              "1s",
+		HARMode:                   "crawl",
+		Cassette:                  "record",
+		CassetteFile:              "/tmp/out/cassette.jsonl",
+		Notify:                    "desktop",
+		TraceDecisions:            "/tmp/out/decisions.jsonl",
+		ResultsDB:                 "results.sqlite",
+		DiscoverAPIs:              true,
+		PageScripts:               []crawler.PageScript{{Pattern: `/forum/`, Script: "document.querySelector('.expand').click()"}},
+		KeepCookieBanners:         true,
+		LazyLoadScroll:            true,
+		ScrollStep:                600,
+		ScrollDelay:               "500ms",
+		ShadowDOM:                 "flatten",
+		BrowserRecycle:            200,
+		BrowserPath:               "/opt/chrome/chrome",
+		BrowserMaxMemory:          1024,
+		BrowserMaxTabs:            4,
+		BrowserMaxProcesses:       12,
+		Iframes:                   "inline",
+		IframeHosts:               "viewer.example.com,*.embed.example.com",
+		Provenance:                "banner",
+		ContentAddressable:        true,
+		ContentFilters:            []crawler.ContentFilter{{Pattern: `/blog/`, Keep: "main article", Remove: ".share"}},
+		FollowOnly:                []crawler.FollowOnlyRule{{Pattern: `/page/\d+$`}, {Title: `^Category:`}},
+		Alerts:                    []crawler.AlertRule{{Name: "aws-key", Pattern: `AKIA[0-9A-Z]{16}`, Target: crawler.AlertTargetHTML}, {Keywords: []string{"acme"}}},
+		PluginsDir:                "/opt/scraper/plugins",
+		EventSinks:                []crawler.EventSink{{Type: crawler.EventSinkWebhook, URL: "https://hooks.example.com/crawl", Events: []crawler.EventType{crawler.EventCrawlCompleted}}},
+		IndexInterval:             0,
+		MetricsInterval:           "10s",
+		EnablePagination:          true,
+		PaginationSelector:        "a.next",
+		MaxPaginationClicks:       5,
+		PaginationWait:            "3s",
+		PaginationStopOnDuplicate: false,
+		PaginationHarvestURLs:     true,
+		AntiBotProfile:            "standard",
+		HideWebdriver:             true,
+		RotateUserAgent:           true,
+		UserAgentPool:             "mobile",
+		UserAgents:                "Agent/1.0\nAgent/2.0",
+		StickyUserAgent:           true,
+		WarmUp:                    true,
+		WarmUpPages:               2,
+		RefererPolicy:             "origin",
+		Timezone:                  "Europe/Berlin",
+		Region:                    "fr",
+		GeoTimezone:               "Europe/Paris",
+		Proxy:                     "http://{region}.proxy.example.com:8080",
+		ProxyDNS:                  true,
+		FileMode:                  "0664",
+		DirMode:                   "2775",
+		Owner:                     "1000:1000",
+		NormalizeURLs:             false,
+		OTLPEndpoint:              "http://localhost:4318",
+		TraceSampleRate:           0.1,
+		EmbeddingsEndpoint:        "https://api.openai.com/v1",
+		EmbeddingsModel:           "text-embedding-3-small",
+		EmbeddingsAPIKey:          "secret://openai",
+		EmbeddingsDimensions:      512,
+		EmbeddingsStore:           "results-db",
+		EnrichmentEndpoint:        "http://localhost:8000/enrich",
+		EnrichmentAPIKey:          "secret://llm",
+		EnrichmentConcurrency:     4,
+		EnrichmentTimeout:         "2m0s",
+		PostSaveCommand:           `pandoc -f html -t gfm "$1" -o "${1%.html}.md"`,
+		PostSaveConcurrency:       3,
+		PostSaveTimeout:           "30s",
+		PostSaveOnFailure:         "disable",
+		WaybackFallback:           true,
+		WaybackSave:               true,
+		WaybackSaveKey:            "secret://archive-org",
+		WaybackSaveInterval:       "30s",
+		FaultLatency:              "50ms",
+		FaultErrorRate:            0.25,
+		FaultSeed:                 9,
+	}
+
+	path := filepath.Join(t.TempDir(), "def.json")
+	if err := WriteDefinitionFile(path, cfg); err != nil {
+		t.Fatalf("WriteDefinitionFile() error = %v", err)
+	}
+	got, err := ReadDefinitionFile(path)
+	if err != nil {
+		t.Fatalf("ReadDefinitionFile() error = %v", err)
+	}
+
+	// Environment-specific paths are not part of a definition
+	want := cfg
+	want.OutputDir = ""
+	want.StateFile = ""
+	want.StateMerge = false
+	want.CassetteFile = ""
+	want.TraceDecisions = ""
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", *got, want)
+	}
+}
+
+func TestReadDefinitionFile_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "def.json")
+	if err := os.WriteFile(path, []byte(`{"url": "https://example.com"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadDefinitionFile(path)
+	if err != nil {
+		t.Fatalf("ReadDefinitionFile() error = %v", err)
+	}
+	if got.Workers != 10 || got.Delay != "1s" || got.MaxDepth != 10 || got.DepthMode != crawler.DepthModeLinks || !got.Headless || !got.NormalizeURLs || got.IndexInterval != 50 {
+		t.Errorf("unset settings should get defaults, got %+v", *got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 99, "request": {"url": "https://example.com"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadDefinitionFile(path); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"scraper/internal/crawler"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ListPlugins loads the JavaScript plugins of dir, as a crawl with it as
+// its plugins directory does, and returns the hooks each defines. It fails
+// with the error of the first plugin that does not compile.
+func (a *App) ListPlugins(dir string) ([]crawler.PluginInfo, error) {
+	return crawler.ListPlugins(dir)
+}
+
+// BrowsePluginsDirectory opens a directory picker dialog for the plugins
+// directory
+func (a *App) BrowsePluginsDirectory() (string, error) {
+	return runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Select Plugins Directory",
+	})
+}
@@ -124,6 +124,8 @@ func TestPresetConfigFields(t *testing.T) {
 		Delay:                     "1s",
 		MaxDepth:                  10,
 		PrefixFilterURL:           "https://example.com/docs",
+		DeniedHosts:               "*.cdn.com",
+		ExternalLinks:             true,
 		ExcludeExtensions:         "js,css,png",
 		LinkSelectors:             "a[href]",
 		Verbose:                   true,
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"scraper/internal/api"
+)
+
+// ListRecipes returns the built-in site recipes and those in the user
+// recipe directories (SCRAPER_RECIPES_DIR, default: recipes in the user
+// config dir)
+func (a *App) ListRecipes() ([]api.Recipe, error) {
+	return api.Recipes()
+}
+
+// ApplyRecipe returns cfg with the settings of the recipe name replacing
+// the form's. Settings the recipe leaves out, the URL and the output paths
+// are kept, so the result can be adjusted before starting the crawl.
+func (a *App) ApplyRecipe(name string, cfg CrawlConfig) (*CrawlConfig, error) {
+	recipe, err := api.GetRecipe(name)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := api.MergeRequest(requestFromCrawlConfig(cfg), recipe.Request)
+	if err != nil {
+		return nil, err
+	}
+	out := crawlConfigFromRequest(&merged)
+	// The request conversion leaves out the re-crawl settings
+	out.RecrawlScope = cfg.RecrawlScope
+	out.RecrawlPattern = cfg.RecrawlPattern
+	return &out, nil
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"testing"
+
+	"scraper/internal/api"
+)
+
+func TestApplyRecipe(t *testing.T) {
+	t.Setenv(api.RecipesDirEnv, t.TempDir())
+	a := &App{}
+
+	cfg := CrawlConfig{URL: "https://forum.example.com", OutputDir: "/data/forum", Delay: "5s", FetchMode: "http"}
+	out, err := a.ApplyRecipe("medium-blog", cfg)
+	if err != nil {
+		t.Fatalf("ApplyRecipe() error = %v", err)
+	}
+	if out.URL != cfg.URL || out.OutputDir != cfg.OutputDir {
+		t.Errorf("expected the URL and output directory to be kept, got %q %q", out.URL, out.OutputDir)
+	}
+	if out.FetchMode != "browser" || out.Delay != "3s" || !out.HideWebdriver || len(out.ContentFilters) != 1 {
+		t.Errorf("expected the recipe settings to replace the form's: %+v", out)
+	}
+
+	if _, err := a.ApplyRecipe("missing", cfg); err == nil {
+		t.Error("expected an unknown recipe to fail")
+	}
+}
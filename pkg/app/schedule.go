@@ -0,0 +1,295 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"scraper/internal/api"
+	"scraper/internal/crawler"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// scheduleRunPoll is how often a scheduled run checks whether its crawl ended
+const scheduleRunPoll = time.Second
+
+// EventScheduleRun is emitted to the frontend when a scheduled run starts
+// or ends, with the run
+const EventScheduleRun = "schedule_run"
+
+// desktopSettings are the settings of the desktop app kept between sessions
+type desktopSettings struct {
+	Background bool `json:"background"` // Keep running with the window hidden when it is closed
+}
+
+// desktopSettingsFile returns the file the desktop settings are kept in
+func desktopSettingsFile() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config dir: %w", err)
+	}
+	return filepath.Join(configDir, "scraper", "desktop.json"), nil
+}
+
+// loadDesktopSettings reads the desktop settings, returning the defaults
+// when they were never saved
+func loadDesktopSettings() desktopSettings {
+	var settings desktopSettings
+	path, err := desktopSettingsFile()
+	if err != nil {
+		return settings
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &settings)
+	}
+	return settings
+}
+
+// saveDesktopSettings writes the desktop settings
+func saveDesktopSettings(settings desktopSettings) error {
+	path, err := desktopSettingsFile()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// startScheduler runs the schedules shared with the CLI, the API and the
+// MCP server while the app is open
+func (a *App) startScheduler() {
+	store, err := a.presetStore()
+	if err != nil {
+		log.Printf("Schedules disabled: %v", err)
+		return
+	}
+	path, err := api.DefaultSchedulesFile()
+	if err != nil {
+		log.Printf("Schedules disabled: %v", err)
+		return
+	}
+	scheduler := api.NewScheduler(api.NewScheduleStore(path), store, a.runScheduled)
+	scheduler.OnRunEnd(a.scheduledRunEnded)
+
+	a.mu.Lock()
+	a.scheduler = scheduler
+	a.background = loadDesktopSettings().Background
+	a.mu.Unlock()
+	scheduler.Start()
+}
+
+// Shutdown is called when the app quits. It stops the scheduled runs.
+func (a *App) Shutdown(ctx context.Context) {
+	a.mu.Lock()
+	scheduler := a.scheduler
+	a.mu.Unlock()
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+}
+
+// runScheduled runs the crawl of a scheduled run like one started from the
+// form, so it shows in the window, and waits for it to end. A run due while
+// another crawl is running fails: the app runs one crawl at a time.
+func (a *App) runScheduled(ctx context.Context, req *api.CrawlRequest, run *api.ScheduleRun) error {
+	// Schedules of API keys are confined to the key on the API server, not
+	// run with the rights of the desktop user
+	if run.Owner != "" {
+		return fmt.Errorf("schedule of API key %q only runs on the API server", run.Owner)
+	}
+	if err := a.StartCrawl(crawlConfigFromRequest(req)); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	c := a.crawler
+	run.OutputDir = a.lastOutputDir
+	a.mu.Unlock()
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, EventScheduleRun, *run)
+	}
+
+	ticker := time.NewTicker(scheduleRunPoll)
+	defer ticker.Stop()
+	for {
+		a.mu.Lock()
+		done := a.crawler != c
+		a.mu.Unlock()
+		if done {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			a.StopCrawl()
+			ctx = context.Background()
+		}
+	}
+
+	if m := c.GetMetrics(); m != nil {
+		snapshot := m.GetSnapshot()
+		run.PagesSaved = snapshot.URLsSaved
+		run.Errors = snapshot.URLsErrored
+	}
+	run.StopReason = c.StopReason()
+	return nil
+}
+
+// scheduledRunEnded tells the frontend a scheduled run ended and, while
+// the window is hidden in background mode, the user
+func (a *App) scheduledRunEnded(run api.ScheduleRun) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, EventScheduleRun, run)
+
+	a.mu.Lock()
+	hidden := a.hidden
+	a.mu.Unlock()
+	if hidden && run.Status != api.ScheduleRunSkipped {
+		message := fmt.Sprintf("%s: %d pages saved, %d errors", run.Preset, run.PagesSaved, run.Errors)
+		if run.Error != "" {
+			message = fmt.Sprintf("%s: %s", run.Preset, run.Error)
+		}
+		if err := crawler.Notify(crawler.NotifyDesktop, "Scheduled crawl "+run.Status, message); err != nil {
+			log.Printf("Schedules: %v", err)
+		}
+	}
+}
+
+// schedules returns the app's scheduler
+func (a *App) schedules() (*api.Scheduler, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.scheduler == nil {
+		return nil, fmt.Errorf("schedules are not available")
+	}
+	return a.scheduler, nil
+}
+
+// ListSchedules returns the schedules shared with the CLI, the API and the
+// MCP server
+func (a *App) ListSchedules() ([]api.Schedule, error) {
+	scheduler, err := a.schedules()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.Store().List()
+}
+
+// AddSchedule schedules a preset, e.g. with spec "nightly"; url overrides
+// the preset's start URL when not empty
+func (a *App) AddSchedule(preset, url, spec string) (*api.Schedule, error) {
+	scheduler, err := a.schedules()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.Add(preset, url, spec)
+}
+
+// RemoveSchedule deletes a schedule; its runs stay in the history
+func (a *App) RemoveSchedule(id string) error {
+	scheduler, err := a.schedules()
+	if err != nil {
+		return err
+	}
+	return scheduler.Store().Remove(id)
+}
+
+// SetScheduleEnabled enables or disables a schedule
+func (a *App) SetScheduleEnabled(id string, enabled bool) (*api.Schedule, error) {
+	scheduler, err := a.schedules()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.Store().SetEnabled(id, enabled)
+}
+
+// RunScheduleNow starts a run of a schedule, also when it is disabled
+func (a *App) RunScheduleNow(id string) (*api.ScheduleRun, error) {
+	scheduler, err := a.schedules()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.RunNow(id)
+}
+
+// GetScheduleHistory returns the runs of a schedule, or of all schedules
+// when id is empty, newest first
+func (a *App) GetScheduleHistory(id string, limit int) ([]api.ScheduleRun, error) {
+	scheduler, err := a.schedules()
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.Store().History(id, limit)
+}
+
+// GetScheduleSpecHelp describes the accepted schedule specs
+func (a *App) GetScheduleSpecHelp() string {
+	return api.ScheduleSpecHelp
+}
+
+// GetBackgroundMode reports whether closing the window hides it, keeping
+// schedules and the running crawl going
+func (a *App) GetBackgroundMode() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.background
+}
+
+// SetBackgroundMode sets whether closing the window hides it instead of
+// quitting, and keeps the setting for the next sessions
+func (a *App) SetBackgroundMode(enabled bool) error {
+	a.mu.Lock()
+	a.background = enabled
+	a.mu.Unlock()
+	return saveDesktopSettings(desktopSettings{Background: enabled})
+}
+
+// HideWindow hides the window, keeping the app running in the background
+// until it is opened again (by starting the app again) or quit
+func (a *App) HideWindow() {
+	a.mu.Lock()
+	a.hidden = true
+	a.mu.Unlock()
+	runtime.WindowHide(a.ctx)
+}
+
+// ShowWindow brings the hidden or minimised window back
+func (a *App) ShowWindow() {
+	a.mu.Lock()
+	a.hidden = false
+	a.mu.Unlock()
+	runtime.WindowShow(a.ctx)
+	runtime.WindowUnminimise(a.ctx)
+}
+
+// Quit quits the app, also in background mode
+func (a *App) Quit() {
+	a.mu.Lock()
+	a.quitting = true
+	a.mu.Unlock()
+	runtime.Quit(a.ctx)
+}
+
+// BeforeClose is called when the window is closed. In background mode it
+// hides the window instead, so schedules keep running.
+func (a *App) BeforeClose(ctx context.Context) (prevent bool) {
+	a.mu.Lock()
+	hide := a.background && !a.quitting
+	a.mu.Unlock()
+	if hide {
+		a.HideWindow()
+	}
+	return hide
+}
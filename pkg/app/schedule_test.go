@@ -0,0 +1,34 @@
+package app
+
+import (
+	"testing"
+)
+
+// TestDesktopSettingsRoundTrip tests that background mode is kept between sessions
+func TestDesktopSettingsRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	if loadDesktopSettings().Background {
+		t.Fatal("expected background mode to be off by default")
+	}
+
+	a := NewApp()
+	if err := a.SetBackgroundMode(true); err != nil {
+		t.Fatalf("SetBackgroundMode() error = %v", err)
+	}
+	if !a.GetBackgroundMode() || !loadDesktopSettings().Background {
+		t.Error("expected background mode to be on and saved")
+	}
+}
+
+// TestSchedulesWithoutScheduler tests that schedule calls fail cleanly before startup
+func TestSchedulesWithoutScheduler(t *testing.T) {
+	a := NewApp()
+	if _, err := a.ListSchedules(); err == nil {
+		t.Error("expected ListSchedules to fail without a scheduler")
+	}
+	if _, err := a.RunScheduleNow("x"); err == nil {
+		t.Error("expected RunScheduleNow to fail without a scheduler")
+	}
+}